@@ -0,0 +1,529 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3metrics/aggregation"
+	"github.com/m3db/m3metrics/matcher"
+	"github.com/m3db/m3metrics/metadata"
+	"github.com/m3db/m3metrics/policy"
+)
+
+const (
+	defaultBackfillChunkSize = time.Hour
+)
+
+var (
+	errBackfillNoSource     = errors.New("backfill source not set")
+	errBackfillInvalidRange = errors.New("backfill end must be after start")
+)
+
+// BackfillOptions is a set of backfill options.
+type BackfillOptions struct {
+	// Source is the storage raw datapoints are read from, it must only
+	// return raw unaggregated datapoints.
+	Source storage.Storage
+
+	// Matchers select the series to backfill.
+	Matchers models.Matchers
+
+	// Start and End are the range of raw datapoints to backfill.
+	Start time.Time
+	End   time.Time
+
+	// ChunkSize is the range of raw datapoints read and aggregated at a
+	// time, chunks are aligned to the chunk size which should be a multiple
+	// of every rule resolution so that no aggregation window spans chunks.
+	ChunkSize time.Duration
+
+	// CheckpointPath if set is the file that the end of the last completed
+	// chunk is recorded to, a backfill resumes from the checkpoint.
+	CheckpointPath string
+
+	// MaxDatapointsPerSecond if set limits the rate of aggregated writes.
+	MaxDatapointsPerSecond int
+}
+
+// BackfillResult is the result of a backfill.
+type BackfillResult struct {
+	Chunks     int
+	Series     int
+	Datapoints int
+}
+
+// Backfiller aggregates historical raw datapoints according to the current
+// downsampling rules and writes the aggregated datapoints to storage, so
+// that newly added rules also cover existing data.
+type Backfiller interface {
+	// Run runs the backfill until the end of the range or the context is
+	// cancelled, progress is checkpointed after each chunk.
+	Run(ctx context.Context) (BackfillResult, error)
+
+	// Close closes the backfiller.
+	Close() error
+}
+
+type backfiller struct {
+	opts         DownsamplerOptions
+	backfillOpts BackfillOptions
+	matcher      matcher.Matcher
+	pools        aggPools
+	aggTypeOpts  aggregation.TypesOptions
+	tagEncoder   serialize.TagEncoder
+	tags         *tags
+	nowFn        func() time.Time
+	sleepFn      func(time.Duration)
+
+	rateStart time.Time
+	written   int
+}
+
+// NewBackfiller returns a new backfiller that writes aggregated datapoints
+// to the downsampler storage using the downsampler rules.
+func NewBackfiller(
+	opts DownsamplerOptions,
+	backfillOpts BackfillOptions,
+) (Backfiller, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if backfillOpts.Source == nil {
+		return nil, errBackfillNoSource
+	}
+	if !backfillOpts.End.After(backfillOpts.Start) {
+		return nil, errBackfillInvalidRange
+	}
+	if backfillOpts.ChunkSize <= 0 {
+		backfillOpts.ChunkSize = defaultBackfillChunkSize
+	}
+
+	pools := opts.newAggregatorPools()
+	ruleSetOpts := opts.newAggregatorRulesOptions(pools)
+
+	var defaultAggregationTypes aggregation.TypesConfiguration
+	aggTypeOpts, err := defaultAggregationTypes.NewOptions(opts.InstrumentOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := opts.newAggregatorMatcher(opts.ClockOptions,
+		opts.InstrumentOptions, ruleSetOpts, opts.RulesKVStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backfiller{
+		opts:         opts,
+		backfillOpts: backfillOpts,
+		matcher:      matcher,
+		pools:        pools,
+		aggTypeOpts:  aggTypeOpts,
+		tagEncoder:   pools.tagEncoderPool.Get(),
+		tags:         newTags(),
+		nowFn:        opts.ClockOptions.NowFn(),
+		sleepFn:      time.Sleep,
+	}, nil
+}
+
+func (b *backfiller) Run(ctx context.Context) (BackfillResult, error) {
+	var (
+		result    BackfillResult
+		logger    = b.opts.InstrumentOptions.Logger()
+		chunkSize = b.backfillOpts.ChunkSize
+		start     = b.backfillOpts.Start.Truncate(chunkSize)
+		end       = b.backfillOpts.End
+	)
+	if path := b.backfillOpts.CheckpointPath; path != "" {
+		checkpoint, ok, err := readBackfillCheckpoint(path)
+		if err != nil {
+			return result, err
+		}
+		if ok && checkpoint.After(start) {
+			logger.Infof("resuming backfill from checkpoint: %v", checkpoint)
+			start = checkpoint
+		}
+	}
+
+	b.rateStart = b.nowFn()
+	b.written = 0
+	for chunkStart := start; chunkStart.Before(end); chunkStart = chunkStart.Add(chunkSize) {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		chunkEnd := chunkStart.Add(chunkSize)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+
+		series, datapoints, err := b.backfillChunk(ctx, chunkStart, chunkEnd)
+		if err != nil {
+			return result, fmt.Errorf("could not backfill chunk %v to %v: %v",
+				chunkStart, chunkEnd, err)
+		}
+
+		result.Chunks++
+		result.Series += series
+		result.Datapoints += datapoints
+		logger.Infof("backfilled chunk %v to %v: series=%d, datapoints=%d",
+			chunkStart, chunkEnd, series, datapoints)
+
+		if path := b.backfillOpts.CheckpointPath; path != "" {
+			if err := writeBackfillCheckpoint(path, chunkEnd); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+type backfillKey struct {
+	id            string
+	storagePolicy policy.StoragePolicy
+	windowEnd     int64
+}
+
+type backfillTarget struct {
+	id              []byte
+	stagedMetadatas metadata.StagedMetadatas
+}
+
+// backfillChunk aggregates and writes a single chunk, returning the number
+// of series read and aggregated datapoints written.
+func (b *backfiller) backfillChunk(
+	ctx context.Context,
+	start, end time.Time,
+) (int, int, error) {
+	result, err := b.backfillOpts.Source.Fetch(ctx, &storage.FetchQuery{
+		TagMatchers: b.backfillOpts.Matchers,
+		Start:       start,
+		End:         end,
+	}, &storage.FetchOptions{})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		aggregations = make(map[backfillKey]*gaugeAggregation)
+		types        = make(map[backfillKey]aggregation.Types)
+		keys         []backfillKey
+	)
+	for _, series := range result.SeriesList {
+		targets, err := b.match(series.Tags)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		values := series.Values()
+		for _, target := range targets {
+			if len(target.stagedMetadatas) == 0 {
+				continue
+			}
+			// Only the rules currently in effect are backfilled
+			active := target.stagedMetadatas[len(target.stagedMetadatas)-1]
+			if active.Tombstoned {
+				continue
+			}
+			for _, pipeline := range active.Pipelines {
+				if !pipeline.Pipeline.IsEmpty() {
+					// Transformations are applied by the aggregator tier and are
+					// not supported by the backfill.
+					continue
+				}
+				aggTypes, err := b.aggregationTypes(pipeline.AggregationID)
+				if err != nil {
+					return 0, 0, err
+				}
+				for _, sp := range pipeline.StoragePolicies {
+					window := sp.Resolution().Window
+					for i := 0; i < values.Len(); i++ {
+						dp := values.DatapointAt(i)
+						if dp.Timestamp.Before(start) || !dp.Timestamp.Before(end) {
+							continue
+						}
+						key := backfillKey{
+							id:            string(target.id),
+							storagePolicy: sp,
+							// Aggregated values are timestamped at the end of their
+							// window as when flushed by the aggregator.
+							windowEnd: dp.Timestamp.Truncate(window).Add(window).UnixNano(),
+						}
+						agg, ok := aggregations[key]
+						if !ok {
+							agg = newGaugeAggregation()
+							aggregations[key] = agg
+							types[key] = aggTypes
+							keys = append(keys, key)
+						}
+						agg.add(dp)
+					}
+				}
+			}
+		}
+	}
+
+	written := 0
+	for _, key := range keys {
+		n, err := b.write(ctx, key, types[key], aggregations[key])
+		if err != nil {
+			return 0, 0, err
+		}
+		written += n
+	}
+	return len(result.SeriesList), written, nil
+}
+
+// match returns the encoded IDs and metadatas that the series is aggregated
+// to by the current rules.
+func (b *backfiller) match(seriesTags models.Tags) ([]backfillTarget, error) {
+	b.tags.names = b.tags.names[:0]
+	b.tags.values = b.tags.values[:0]
+	for name, value := range seriesTags {
+		b.tags.append(name, value)
+	}
+	sort.Sort(b.tags)
+
+	b.tagEncoder.Reset()
+	if err := b.tagEncoder.Encode(b.tags); err != nil {
+		return nil, err
+	}
+	data, ok := b.tagEncoder.Data()
+	if !ok {
+		return nil, fmt.Errorf("unable to encode tags: names=%v, values=%v",
+			b.tags.names, b.tags.values)
+	}
+	encodedID := append([]byte(nil), data.Bytes()...)
+
+	id := b.pools.encodedTagsIteratorPool.Get()
+	id.Reset(encodedID)
+	nowNanos := b.nowFn().UnixNano()
+	matchResult := b.matcher.ForwardMatch(id, nowNanos, nowNanos+1)
+	id.Close()
+
+	var targets []backfillTarget
+	if existing := matchResult.ForExistingIDAt(nowNanos); !existing.IsDefault() {
+		targets = append(targets, backfillTarget{
+			id:              encodedID,
+			stagedMetadatas: existing,
+		})
+	}
+	for i := 0; i < matchResult.NumNewRollupIDs(); i++ {
+		rollup := matchResult.ForNewRollupIDsAt(i, nowNanos)
+		targets = append(targets, backfillTarget{
+			id:              rollup.ID,
+			stagedMetadatas: rollup.Metadatas,
+		})
+	}
+	return targets, nil
+}
+
+func (b *backfiller) aggregationTypes(id aggregation.ID) (aggregation.Types, error) {
+	if id.IsDefault() {
+		// Raw datapoints are gauges
+		return b.aggTypeOpts.DefaultGaugeAggregationTypes(), nil
+	}
+	return id.Types()
+}
+
+// write writes the aggregated values for a key, returning the number of
+// datapoints written.
+func (b *backfiller) write(
+	ctx context.Context,
+	key backfillKey,
+	aggTypes aggregation.Types,
+	agg *gaugeAggregation,
+) (int, error) {
+	iter := b.pools.encodedTagsIteratorPool.Get()
+	iter.Reset([]byte(key.id))
+	tags := make(models.Tags, iter.NumTags()+1)
+	for iter.Next() {
+		name, value := iter.Current()
+		tags[string(name)] = string(value)
+	}
+	err := iter.Err()
+	iter.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for _, aggType := range aggTypes {
+		value, ok := agg.valueOf(aggType)
+		if !ok {
+			continue
+		}
+
+		writeTags := tags
+		if len(aggTypes) > 1 {
+			// Multiple aggregations of the same ID are distinguished by a suffix
+			writeTags = make(models.Tags, len(tags)+1)
+			for name, value := range tags {
+				writeTags[name] = value
+			}
+			writeTags[aggregationSuffixTag] = string(b.aggTypeOpts.TypeStringForGauge(aggType))
+		}
+
+		b.throttle()
+		err := b.opts.Storage.Write(ctx, &storage.WriteQuery{
+			Tags: writeTags,
+			Datapoints: ts.Datapoints{ts.Datapoint{
+				Timestamp: time.Unix(0, key.windowEnd),
+				Value:     value,
+			}},
+			Unit: key.storagePolicy.Resolution().Precision,
+			Attributes: storage.Attributes{
+				MetricsType: storage.AggregatedMetricsType,
+				Retention:   key.storagePolicy.Retention().Duration(),
+				Resolution:  key.storagePolicy.Resolution().Window,
+			},
+		})
+		if err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// throttle sleeps as required to keep the write rate under the limit.
+func (b *backfiller) throttle() {
+	limit := b.backfillOpts.MaxDatapointsPerSecond
+	if limit <= 0 {
+		return
+	}
+	b.written++
+	target := time.Duration(float64(time.Second) * float64(b.written) / float64(limit))
+	if elapsed := b.nowFn().Sub(b.rateStart); elapsed < target {
+		b.sleepFn(target - elapsed)
+	}
+}
+
+func (b *backfiller) Close() error {
+	b.tagEncoder.Finalize()
+	return b.matcher.Close()
+}
+
+// gaugeAggregation aggregates gauge values within a single window.
+type gaugeAggregation struct {
+	lastAt time.Time
+	last   float64
+	min    float64
+	max    float64
+	sum    float64
+	sumSq  float64
+	count  int64
+}
+
+func newGaugeAggregation() *gaugeAggregation {
+	return &gaugeAggregation{
+		min: math.Inf(1),
+		max: math.Inf(-1),
+	}
+}
+
+func (a *gaugeAggregation) add(dp ts.Datapoint) {
+	if math.IsNaN(dp.Value) {
+		return
+	}
+	if a.count == 0 || !dp.Timestamp.Before(a.lastAt) {
+		a.lastAt = dp.Timestamp
+		a.last = dp.Value
+	}
+	a.min = math.Min(a.min, dp.Value)
+	a.max = math.Max(a.max, dp.Value)
+	a.sum += dp.Value
+	a.sumSq += dp.Value * dp.Value
+	a.count++
+}
+
+// valueOf returns the aggregated value for an aggregation type, returning
+// false if there are no values or the type is not supported for gauges.
+func (a *gaugeAggregation) valueOf(aggType aggregation.Type) (float64, bool) {
+	if a.count == 0 {
+		return 0, false
+	}
+	switch aggType {
+	case aggregation.Last:
+		return a.last, true
+	case aggregation.Min:
+		return a.min, true
+	case aggregation.Max:
+		return a.max, true
+	case aggregation.Mean:
+		return a.sum / float64(a.count), true
+	case aggregation.Count:
+		return float64(a.count), true
+	case aggregation.Sum:
+		return a.sum, true
+	case aggregation.SumSq:
+		return a.sumSq, true
+	case aggregation.Stdev:
+		if a.count < 2 {
+			return 0, true
+		}
+		n := float64(a.count)
+		variance := (a.sumSq - a.sum*a.sum/n) / (n - 1)
+		return math.Sqrt(math.Max(variance, 0)), true
+	}
+	return 0, false
+}
+
+func readBackfillCheckpoint(path string) (time.Time, bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	checkpoint, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid backfill checkpoint: %v", err)
+	}
+	return checkpoint, true, nil
+}
+
+func writeBackfillCheckpoint(path string, checkpoint time.Time) error {
+	// Write then rename so a crash never leaves a partial checkpoint
+	tmpPath := path + ".tmp"
+	data := []byte(checkpoint.UTC().Format(time.RFC3339Nano) + "\n")
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3metrics/aggregation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGaugeAggregation(t *testing.T) {
+	now := time.Now()
+	agg := newGaugeAggregation()
+	for i, v := range []float64{4, 2, 6} {
+		agg.add(ts.Datapoint{Timestamp: now.Add(time.Duration(i) * time.Second), Value: v})
+	}
+
+	for _, test := range []struct {
+		aggType  aggregation.Type
+		expected float64
+	}{
+		{aggregation.Last, 6},
+		{aggregation.Min, 2},
+		{aggregation.Max, 6},
+		{aggregation.Mean, 4},
+		{aggregation.Count, 3},
+		{aggregation.Sum, 12},
+		{aggregation.SumSq, 56},
+		{aggregation.Stdev, 2},
+	} {
+		value, ok := agg.valueOf(test.aggType)
+		require.True(t, ok)
+		assert.InDelta(t, test.expected, value, 1e-9, test.aggType.String())
+	}
+
+	_, ok := agg.valueOf(aggregation.P99)
+	assert.False(t, ok)
+	_, ok = newGaugeAggregation().valueOf(aggregation.Last)
+	assert.False(t, ok)
+}
+
+func TestBackfillCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backfill-checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	checkpointPath := path.Join(dir, "checkpoint")
+	_, ok, err := readBackfillCheckpoint(checkpointPath)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	checkpoint := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, writeBackfillCheckpoint(checkpointPath, checkpoint))
+
+	read, ok, err := readBackfillCheckpoint(checkpointPath)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, checkpoint.Equal(read))
+}
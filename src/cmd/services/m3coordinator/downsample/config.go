@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"errors"
+	"os"
+
+	"github.com/m3db/m3aggregator/client"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/services"
+	"github.com/m3db/m3x/clock"
+	"github.com/m3db/m3x/instrument"
+)
+
+const (
+	defaultLeaderElectionServiceName = "m3coordinator_downsampler"
+	defaultLeaderElectionTTLSeconds  = 10
+)
+
+var (
+	errNoLeaderElectionClusterClient = errors.New(
+		"leader election requires a cluster management client")
+)
+
+// Configuration configures a downsampler.
+type Configuration struct {
+	// RemoteAggregator specifies that downsampling should be done remotely
+	// by sending values to a remote m3aggregator cluster which then
+	// can forward the aggregated values to the storage namespaces.
+	RemoteAggregator *RemoteAggregatorConfiguration `yaml:"remoteAggregator"`
+
+	// LeaderElection if set will elect a single coordinator replica to flush
+	// downsampled values at a time, preventing duplicate rollup writes when
+	// running multiple coordinators.
+	LeaderElection *LeaderElectionConfiguration `yaml:"leaderElection"`
+}
+
+// LeaderElectionConfiguration configures leader election amongst the
+// coordinator replicas that perform downsampling.
+type LeaderElectionConfiguration struct {
+	// Env is the environment of the election service.
+	Env string `yaml:"env"`
+
+	// Zone is the zone of the election service.
+	Zone string `yaml:"zone"`
+
+	// Service is the name of the election service, all replicas that should
+	// campaign together must use the same service.
+	Service string `yaml:"service"`
+
+	// LeaderValue identifies this replica, defaults to the hostname.
+	LeaderValue string `yaml:"leaderValue"`
+
+	// TTLSeconds is the TTL of the leader lease, lower values result in faster
+	// failover at the cost of more frequent lease renewals.
+	TTLSeconds int `yaml:"ttlSeconds"`
+}
+
+// NewLeaderService creates a new leader service and returns it along with
+// the leader value to campaign with.
+func (c LeaderElectionConfiguration) NewLeaderService(
+	clusterClient clusterclient.Client,
+) (services.LeaderService, string, error) {
+	if clusterClient == nil {
+		return nil, "", errNoLeaderElectionClusterClient
+	}
+
+	leaderValue := c.LeaderValue
+	if leaderValue == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, "", err
+		}
+		leaderValue = hostname
+	}
+
+	serviceName := defaultLeaderElectionServiceName
+	if c.Service != "" {
+		serviceName = c.Service
+	}
+
+	ttlSeconds := defaultLeaderElectionTTLSeconds
+	if c.TTLSeconds > 0 {
+		ttlSeconds = c.TTLSeconds
+	}
+
+	svcs, err := clusterClient.Services(services.NewOverrideOptions())
+	if err != nil {
+		return nil, "", err
+	}
+
+	serviceID := services.NewServiceID().
+		SetName(serviceName).
+		SetEnvironment(c.Env).
+		SetZone(c.Zone)
+
+	electionOpts := services.NewElectionOptions().
+		SetTTLSecs(ttlSeconds)
+
+	leaderService, err := svcs.LeaderService(serviceID, electionOpts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return leaderService, leaderValue, nil
+}
+
+// RemoteAggregatorConfiguration specifies a remote aggregator
+// to use for downsampling.
+type RemoteAggregatorConfiguration struct {
+	// Client is the remote aggregator client.
+	Client client.Configuration `yaml:"client"`
+}
+
+// NewClient creates a new remote aggregator client.
+func (c RemoteAggregatorConfiguration) NewClient(
+	kvClient clusterclient.Client,
+	clockOpts clock.Options,
+	instrumentOpts instrument.Options,
+) (client.Client, error) {
+	return c.Client.NewClient(kvClient, clockOpts, instrumentOpts)
+}
@@ -42,8 +42,10 @@ type SamplesAppender interface {
 }
 
 type downsampler struct {
-	opts DownsamplerOptions
-	agg  agg
+	opts              DownsamplerOptions
+	agg               agg
+	nameTag           string
+	seriesTypeMetrics seriesTypeMetrics
 }
 
 // NewDownsampler returns a new downsampler.
@@ -55,19 +57,30 @@ func NewDownsampler(
 		return nil, err
 	}
 
+	nameTag := string(defaultMetricNameTagName)
+	if opts.NameTag != "" {
+		nameTag = opts.NameTag
+	}
+
+	scope := opts.InstrumentOptions.MetricsScope().SubScope("downsampler")
 	return &downsampler{
-		opts: opts,
-		agg:  agg,
+		opts:              opts,
+		agg:               agg,
+		nameTag:           nameTag,
+		seriesTypeMetrics: newSeriesTypeMetrics(scope),
 	}, nil
 }
 
 func (d *downsampler) NewMetricsAppender() MetricsAppender {
 	return newMetricsAppender(metricsAppenderOptions{
 		agg:                     d.agg.aggregator,
+		clientRemote:            d.agg.clientRemote,
 		clockOpts:               d.agg.clockOpts,
 		tagEncoder:              d.agg.pools.tagEncoderPool.Get(),
 		matcher:                 d.agg.matcher,
 		encodedTagsIteratorPool: d.agg.pools.encodedTagsIteratorPool,
+		nameTag:                 d.nameTag,
+		seriesTypeMetrics:       d.seriesTypeMetrics,
 	})
 }
 
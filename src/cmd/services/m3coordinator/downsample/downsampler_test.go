@@ -27,6 +27,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3aggregator/client"
 	"github.com/m3db/m3cluster/kv/mem"
 	"github.com/m3db/m3ctl/service/r2/store"
 	r2kv "github.com/m3db/m3ctl/service/r2/store/kv"
@@ -42,6 +43,7 @@ import (
 	xlog "github.com/m3db/m3x/log"
 	"github.com/m3db/m3x/pool"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -169,6 +171,83 @@ func TestDownsamplerAggregation(t *testing.T) {
 	}
 }
 
+func TestDownsamplerAggregationWithRemoteAggregatorClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	remoteClient := client.NewMockClient(ctrl)
+	remoteClient.EXPECT().Init().Return(nil)
+
+	testDownsampler := newTestDownsampler(t, testDownsamplerOptions{
+		remoteClient: remoteClient,
+	})
+	downsampler := testDownsampler.downsampler
+	rulesStore := testDownsampler.rulesStore
+
+	// Create rules
+	_, err := rulesStore.CreateNamespace("default", store.NewUpdateOptions())
+	require.NoError(t, err)
+
+	rule := view.MappingRule{
+		ID:              "mappingrule",
+		Name:            "mappingrule",
+		Filter:          "app:test*",
+		AggregationID:   aggregation.MustCompressTypes(aggregation.Sum),
+		StoragePolicies: []policy.StoragePolicy{policy.MustParseStoragePolicy("2s:1d")},
+	}
+	_, err = rulesStore.CreateMappingRule("default", rule,
+		store.NewUpdateOptions())
+	require.NoError(t, err)
+
+	// Wait for mapping rule to appear
+	matcher := testDownsampler.matcher
+	testMatchID := newTestID(t, map[string]string{
+		"__name__": "foo",
+		"app":      "test123",
+	})
+	for {
+		now := time.Now().UnixNano()
+		res := matcher.ForwardMatch(testMatchID, now, now+1)
+		results := res.ForExistingIDAt(now)
+		if !results.IsDefault() {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	remoteClient.EXPECT().
+		WriteUntimedCounter(gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(3)
+	remoteClient.EXPECT().
+		WriteUntimedGauge(gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(2)
+
+	appender := downsampler.NewMetricsAppender()
+	defer appender.Finalize()
+
+	appender.AddTag("__name__", "counter0")
+	appender.AddTag("app", "testapp")
+	samplesAppender, err := appender.SamplesAppender()
+	require.NoError(t, err)
+	for _, sample := range []int64{1, 2, 3} {
+		require.NoError(t, samplesAppender.AppendCounterSample(sample))
+	}
+
+	appender.Reset()
+	appender.AddTag("__name__", "gauge0")
+	appender.AddTag("app", "testapp")
+	samplesAppender, err = appender.SamplesAppender()
+	require.NoError(t, err)
+	for _, sample := range []float64{4, 5} {
+		require.NoError(t, samplesAppender.AppendGaugeSample(sample))
+	}
+
+	// Nothing should have been aggregated and written locally
+	assert.Equal(t, 0, len(testDownsampler.storage.Writes()))
+}
+
 type testDownsampler struct {
 	opts           DownsamplerOptions
 	downsampler    Downsampler
@@ -181,6 +260,7 @@ type testDownsampler struct {
 type testDownsamplerOptions struct {
 	clockOpts      clock.Options
 	instrumentOpts instrument.Options
+	remoteClient   client.Client
 }
 
 func newTestDownsampler(t *testing.T, opts testDownsamplerOptions) testDownsampler {
@@ -224,14 +304,15 @@ func newTestDownsampler(t *testing.T, opts testDownsamplerOptions) testDownsampl
 				SubScope("tag-decoder-pool")))
 
 	instance, err := NewDownsampler(DownsamplerOptions{
-		Storage:               storage,
-		RulesKVStore:          rulesKVStore,
-		ClockOptions:          clockOpts,
-		InstrumentOptions:     instrumentOpts,
-		TagEncoderOptions:     tagEncoderOptions,
-		TagDecoderOptions:     tagDecoderOptions,
-		TagEncoderPoolOptions: tagEncoderPoolOptions,
-		TagDecoderPoolOptions: tagDecoderPoolOptions,
+		Storage:                storage,
+		RulesKVStore:           rulesKVStore,
+		ClockOptions:           clockOpts,
+		InstrumentOptions:      instrumentOpts,
+		TagEncoderOptions:      tagEncoderOptions,
+		TagDecoderOptions:      tagDecoderOptions,
+		TagEncoderPoolOptions:  tagEncoderPoolOptions,
+		TagDecoderPoolOptions:  tagDecoderPoolOptions,
+		RemoteAggregatorClient: opts.remoteClient,
 	})
 	require.NoError(t, err)
 
@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/x/logsample"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/ts"
@@ -39,6 +40,9 @@ import (
 
 const (
 	aggregationSuffixTag = "agg"
+
+	flushPrepareErrorLogClass = "downsampler-flush-prepare"
+	flushWriteErrorLogClass   = "downsampler-flush-write"
 )
 
 type downsamplerFlushHandler struct {
@@ -47,6 +51,7 @@ type downsamplerFlushHandler struct {
 	encodedTagsIteratorPool *encodedTagsIteratorPool
 	workerPool              xsync.WorkerPool
 	instrumentOpts          instrument.Options
+	logger                  *logsample.Logger
 	metrics                 downsamplerFlushHandlerMetrics
 }
 
@@ -68,6 +73,7 @@ func newDownsamplerFlushHandler(
 	storage storage.Storage,
 	encodedTagsIteratorPool *encodedTagsIteratorPool,
 	workerPool xsync.WorkerPool,
+	logSampler *logsample.Sampler,
 	instrumentOpts instrument.Options,
 ) handler.Handler {
 	scope := instrumentOpts.MetricsScope().SubScope("downsampler-flush-handler")
@@ -76,6 +82,7 @@ func newDownsamplerFlushHandler(
 		encodedTagsIteratorPool: encodedTagsIteratorPool,
 		workerPool:              workerPool,
 		instrumentOpts:          instrumentOpts,
+		logger:                  logsample.NewLogger(instrumentOpts.Logger(), logSampler),
 		metrics:                 newDownsamplerFlushHandlerMetrics(scope),
 	}
 }
@@ -105,7 +112,7 @@ func (w *downsamplerFlushHandlerWriter) Write(
 	w.handler.workerPool.Go(func() {
 		defer w.wg.Done()
 
-		logger := w.handler.instrumentOpts.Logger()
+		logger := w.handler.logger
 
 		iter := w.handler.encodedTagsIteratorPool.Get()
 		iter.Reset(mp.ChunkedID.Data)
@@ -128,7 +135,8 @@ func (w *downsamplerFlushHandlerWriter) Write(
 		err := iter.Err()
 		iter.Close()
 		if err != nil {
-			logger.Errorf("downsampler flush error preparing write: %v", err)
+			logger.Errorf(flushPrepareErrorLogClass,
+				"downsampler flush error preparing write: %v", err)
 			w.handler.metrics.flushErrors.Inc(1)
 			return
 		}
@@ -147,7 +155,8 @@ func (w *downsamplerFlushHandlerWriter) Write(
 			},
 		})
 		if err != nil {
-			logger.Errorf("downsampler flush error failed write: %v", err)
+			logger.Errorf(flushWriteErrorLogClass,
+				"downsampler flush error failed write: %v", err)
 			w.handler.metrics.flushErrors.Inc(1)
 			return
 		}
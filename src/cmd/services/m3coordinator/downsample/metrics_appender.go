@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3aggregator/aggregator"
+	"github.com/m3db/m3aggregator/client"
 	"github.com/m3db/m3metrics/matcher"
 	"github.com/m3db/m3x/clock"
 )
@@ -40,10 +41,13 @@ type metricsAppender struct {
 
 type metricsAppenderOptions struct {
 	agg                     aggregator.Aggregator
+	clientRemote            client.Client
 	clockOpts               clock.Options
 	tagEncoder              serialize.TagEncoder
 	matcher                 matcher.Matcher
 	encodedTagsIteratorPool *encodedTagsIteratorPool
+	nameTag                 string
+	seriesTypeMetrics       seriesTypeMetrics
 }
 
 func (a *metricsAppender) AddTag(name, value string) {
@@ -67,6 +71,7 @@ func (a *metricsAppender) SamplesAppender() (SamplesAppender, error) {
 
 	a.multiSamplesAppender.reset()
 	unownedID := data.Bytes()
+	seriesType := inferSeriesType(a.tags, a.nameTag)
 
 	// Match policies and rollups and build samples appender
 	id := a.encodedTagsIteratorPool.Get()
@@ -83,6 +88,7 @@ func (a *metricsAppender) SamplesAppender() (SamplesAppender, error) {
 		// Only sample if going to actually aggregate
 		a.multiSamplesAppender.addSamplesAppender(samplesAppender{
 			agg:             a.agg,
+			clientRemote:    a.clientRemote,
 			unownedID:       unownedID,
 			stagedMetadatas: stagedMetadatas,
 		})
@@ -91,8 +97,12 @@ func (a *metricsAppender) SamplesAppender() (SamplesAppender, error) {
 	numRollups := matchResult.NumNewRollupIDs()
 	for i := 0; i < numRollups; i++ {
 		rollup := matchResult.ForNewRollupIDsAt(i, nowNanos)
+		if !a.canRollup(seriesType, rollup.ID) {
+			continue
+		}
 		a.multiSamplesAppender.addSamplesAppender(samplesAppender{
 			agg:             a.agg,
+			clientRemote:    a.clientRemote,
 			unownedID:       rollup.ID,
 			stagedMetadatas: rollup.Metadatas,
 		})
@@ -101,6 +111,29 @@ func (a *metricsAppender) SamplesAppender() (SamplesAppender, error) {
 	return a.multiSamplesAppender, nil
 }
 
+// canRollup returns whether a series of the type can be aggregated into
+// the rollup, rollups of histogram buckets must keep the bucket tag so that
+// only buckets of the same upper bound are summed and rollups of summary
+// quantiles are dropped since quantiles can not be summed.
+func (a *metricsAppender) canRollup(seriesType seriesType, rollupID []byte) bool {
+	switch seriesType {
+	case histogramBucketSeriesType:
+		iter := a.encodedTagsIteratorPool.Get()
+		iter.Reset(rollupID)
+		_, ok := iter.TagValue([]byte(histogramBucketTag))
+		iter.Close()
+		if !ok {
+			a.seriesTypeMetrics.droppedBucketRollups.Inc(1)
+		}
+		return ok
+	case summaryQuantileSeriesType:
+		a.seriesTypeMetrics.droppedQuantileRollups.Inc(1)
+		return false
+	default:
+		return true
+	}
+}
+
 func (a *metricsAppender) Reset() {
 	a.tags.names = a.tags.names[:0]
 	a.tags.values = a.tags.values[:0]
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/x/logsample"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3aggregator/aggregator"
 	"github.com/m3db/m3aggregator/aggregator/handler"
@@ -84,6 +85,29 @@ type DownsamplerOptions struct {
 	TagEncoderPoolOptions   pool.ObjectPoolOptions
 	TagDecoderPoolOptions   pool.ObjectPoolOptions
 	OpenTimeout             time.Duration
+
+	// LeaderService if set will be used to elect a single leader amongst
+	// all downsampler replicas that flushes the aggregated values, all other
+	// replicas aggregate as followers and can take over on failover.
+	LeaderService services.LeaderService
+
+	// LeaderValue is the value used to identify this replica when
+	// campaigning with the leader service.
+	LeaderValue string
+
+	// FlushTimesKVStore is the store used to share the last flush times
+	// between replicas, it should be set when using a leader service so
+	// that a new leader knows which values have already been flushed.
+	FlushTimesKVStore kv.Store
+
+	// RemoteAggregatorClient if set will forward all samples to a remote
+	// m3aggregator tier rather than aggregating in-process, the remote tier
+	// is then responsible for flushing the aggregated values to storage.
+	RemoteAggregatorClient client.Client
+
+	// LogSampler if set samples the errors logged when flushing aggregated
+	// values to storage, otherwise every error is logged.
+	LogSampler *logsample.Sampler
 }
 
 // Validate validates the dynamic downsampling options.
@@ -116,10 +140,11 @@ func (o DownsamplerOptions) validate() error {
 }
 
 type agg struct {
-	aggregator aggregator.Aggregator
-	clockOpts  clock.Options
-	matcher    matcher.Matcher
-	pools      aggPools
+	aggregator   aggregator.Aggregator
+	clientRemote client.Client
+	clockOpts    clock.Options
+	matcher      matcher.Matcher
+	pools        aggPools
 }
 
 func (o DownsamplerOptions) newAggregator() (agg, error) {
@@ -158,6 +183,21 @@ func (o DownsamplerOptions) newAggregator() (agg, error) {
 		return agg{}, err
 	}
 
+	if remoteClient := o.RemoteAggregatorClient; remoteClient != nil {
+		// Downsampling is done by a remote aggregator tier, we only need to
+		// match the rules locally and forward the samples with their metadatas.
+		if err := remoteClient.Init(); err != nil {
+			return agg{}, err
+		}
+
+		return agg{
+			clientRemote: remoteClient,
+			clockOpts:    clockOpts,
+			matcher:      matcher,
+			pools:        pools,
+		}, nil
+	}
+
 	aggClient := client.NewClient(client.NewOptions())
 	adminAggClient, ok := aggClient.(client.AdminClient)
 	if !ok {
@@ -172,6 +212,11 @@ func (o DownsamplerOptions) newAggregator() (agg, error) {
 
 	localKVStore := mem.NewStore()
 
+	flushTimesStore := o.FlushTimesKVStore
+	if flushTimesStore == nil {
+		flushTimesStore = localKVStore
+	}
+
 	placementManager, err := o.newAggregatorPlacementManager(serviceID,
 		localKVStore)
 	if err != nil {
@@ -180,7 +225,7 @@ func (o DownsamplerOptions) newAggregator() (agg, error) {
 
 	flushTimesManager := aggregator.NewFlushTimesManager(
 		aggregator.NewFlushTimesManagerOptions().
-			SetFlushTimesStore(localKVStore))
+			SetFlushTimesStore(flushTimesStore))
 
 	electionManager, err := o.newAggregatorElectionManager(serviceID,
 		placementManager, flushTimesManager)
@@ -213,9 +258,11 @@ func (o DownsamplerOptions) newAggregator() (agg, error) {
 		return agg{}, err
 	}
 
-	// Wait until the aggregator becomes leader so we don't miss datapoints
+	// Wait until the aggregator becomes leader so we don't miss datapoints,
+	// when campaigning with other replicas there is no guarantee this instance
+	// will ever become leader so only wait when electing locally.
 	deadline := time.Now().Add(openTimeout)
-	for {
+	for o.LeaderService == nil {
 		if !time.Now().Before(deadline) {
 			return agg{}, fmt.Errorf("aggregator not promoted to leader after: %s",
 				openTimeout.String())
@@ -228,6 +275,7 @@ func (o DownsamplerOptions) newAggregator() (agg, error) {
 
 	return agg{
 		aggregator: aggregatorInstance,
+		clockOpts:  clockOpts,
 		matcher:    matcher,
 		pools:      pools,
 	}, nil
@@ -372,6 +420,10 @@ func (o DownsamplerOptions) newAggregatorElectionManager(
 	flushTimesManager aggregator.FlushTimesManager,
 ) (aggregator.ElectionManager, error) {
 	leaderValue := instanceID
+	if o.LeaderValue != "" {
+		leaderValue = o.LeaderValue
+	}
+
 	campaignOpts, err := services.NewCampaignOptions()
 	if err != nil {
 		return nil, err
@@ -379,7 +431,10 @@ func (o DownsamplerOptions) newAggregatorElectionManager(
 
 	campaignOpts = campaignOpts.SetLeaderValue(leaderValue)
 
-	leaderService := newLocalLeaderService(serviceID)
+	leaderService := o.LeaderService
+	if leaderService == nil {
+		leaderService = newLocalLeaderService(serviceID)
+	}
 
 	electionManagerOpts := aggregator.NewElectionManagerOptions().
 		SetCampaignOptions(campaignOpts).
@@ -409,7 +464,7 @@ func (o DownsamplerOptions) newAggregatorFlushManagerAndHandler(
 	flushWorkers := xsync.NewWorkerPool(storageFlushConcurrency)
 	flushWorkers.Init()
 	handler := newDownsamplerFlushHandler(o.Storage, pools.encodedTagsIteratorPool,
-		flushWorkers, instrumentOpts)
+		flushWorkers, o.LogSampler, instrumentOpts)
 
 	return flushManager, handler
 }
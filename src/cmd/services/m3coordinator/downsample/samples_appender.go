@@ -22,6 +22,7 @@ package downsample
 
 import (
 	"github.com/m3db/m3aggregator/aggregator"
+	"github.com/m3db/m3aggregator/client"
 	"github.com/m3db/m3metrics/metadata"
 	"github.com/m3db/m3metrics/metric"
 	"github.com/m3db/m3metrics/metric/unaggregated"
@@ -30,11 +31,21 @@ import (
 
 type samplesAppender struct {
 	agg             aggregator.Aggregator
+	clientRemote    client.Client
 	unownedID       []byte
 	stagedMetadatas metadata.StagedMetadatas
 }
 
 func (a samplesAppender) AppendCounterSample(value int64) error {
+	if a.clientRemote != nil {
+		// Remote client write instead of local aggregation.
+		sample := unaggregated.Counter{
+			ID:    a.unownedID,
+			Value: value,
+		}
+		return a.clientRemote.WriteUntimedCounter(sample, a.stagedMetadatas)
+	}
+
 	sample := unaggregated.MetricUnion{
 		Type:       metric.CounterType,
 		ID:         a.unownedID,
@@ -44,6 +55,15 @@ func (a samplesAppender) AppendCounterSample(value int64) error {
 }
 
 func (a samplesAppender) AppendGaugeSample(value float64) error {
+	if a.clientRemote != nil {
+		// Remote client write instead of local aggregation.
+		sample := unaggregated.Gauge{
+			ID:    a.unownedID,
+			Value: value,
+		}
+		return a.clientRemote.WriteUntimedGauge(sample, a.stagedMetadatas)
+	}
+
 	sample := unaggregated.MetricUnion{
 		Type:     metric.GaugeType,
 		ID:       a.unownedID,
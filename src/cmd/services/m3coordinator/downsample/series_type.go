@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"strings"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	histogramBucketSuffix = "_bucket"
+	histogramBucketTag    = "le"
+	summaryQuantileTag    = "quantile"
+)
+
+// seriesType is the type of a series inferred from its name and tags by the
+// Prometheus conventions, since writes do not carry the type of series.
+type seriesType int
+
+const (
+	// independentSeriesType is a series, such as a gauge or counter, that
+	// can be aggregated with any other series.
+	independentSeriesType seriesType = iota
+	// histogramBucketSeriesType is a cumulative histogram bucket, buckets
+	// can only be aggregated with buckets of the same upper bound.
+	histogramBucketSeriesType
+	// summaryQuantileSeriesType is a quantile of a summary, quantiles
+	// computed by different clients can not be aggregated.
+	summaryQuantileSeriesType
+)
+
+// inferSeriesType returns the type of a series given its tags. The sum and
+// count series of histograms and summaries are independent series since
+// they can be summed like any other counter.
+func inferSeriesType(t *tags, nameTag string) seriesType {
+	var (
+		name                      string
+		hasBucketTag, hasQuantile bool
+	)
+	for i, tagName := range t.names {
+		switch tagName {
+		case nameTag:
+			name = t.values[i]
+		case histogramBucketTag:
+			hasBucketTag = true
+		case summaryQuantileTag:
+			hasQuantile = true
+		}
+	}
+
+	switch {
+	case hasBucketTag && strings.HasSuffix(name, histogramBucketSuffix):
+		return histogramBucketSeriesType
+	case hasQuantile:
+		return summaryQuantileSeriesType
+	default:
+		return independentSeriesType
+	}
+}
+
+type seriesTypeMetrics struct {
+	droppedBucketRollups   tally.Counter
+	droppedQuantileRollups tally.Counter
+}
+
+func newSeriesTypeMetrics(scope tally.Scope) seriesTypeMetrics {
+	return seriesTypeMetrics{
+		droppedBucketRollups: scope.Tagged(map[string]string{
+			"type": "histogram-bucket",
+		}).Counter("dropped-rollups"),
+		droppedQuantileRollups: scope.Tagged(map[string]string{
+			"type": "summary-quantile",
+		}).Counter("dropped-rollups"),
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferSeriesType(t *testing.T) {
+	tests := []struct {
+		tags     map[string]string
+		expected seriesType
+	}{
+		{
+			tags:     map[string]string{"__name__": "requests", "app": "foo"},
+			expected: independentSeriesType,
+		},
+		{
+			tags:     map[string]string{"__name__": "latency_bucket", "le": "0.5"},
+			expected: histogramBucketSeriesType,
+		},
+		{
+			tags:     map[string]string{"__name__": "latency_count"},
+			expected: independentSeriesType,
+		},
+		{
+			tags:     map[string]string{"__name__": "latency_sum"},
+			expected: independentSeriesType,
+		},
+		{
+			// A le tag without the bucket suffix is not a histogram bucket.
+			tags:     map[string]string{"__name__": "latency", "le": "0.5"},
+			expected: independentSeriesType,
+		},
+		{
+			tags:     map[string]string{"__name__": "latency", "quantile": "0.99"},
+			expected: summaryQuantileSeriesType,
+		},
+	}
+
+	for _, tt := range tests {
+		tags := newTags()
+		for name, value := range tt.tags {
+			tags.append(name, value)
+		}
+		assert.Equal(t, tt.expected, inferSeriesType(tags, "__name__"), "%v", tt.tags)
+	}
+}
@@ -23,15 +23,18 @@ package config
 import (
 	"bytes"
 	"errors"
-	"fmt"
+	"net"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	coordinatorcfg "github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/environment"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3x/config/hostid"
 	"github.com/m3db/m3x/instrument"
 	xlog "github.com/m3db/m3x/log"
@@ -42,10 +45,16 @@ import (
 )
 
 const (
-	defaultEtcdDirSuffix  = "etcd"
-	defaultEtcdListenHost = "http://0.0.0.0"
-	defaultEtcdClientPort = 2379
-	defaultEtcdServerPort = 2380
+	defaultEtcdDirSuffix      = "etcd"
+	defaultEtcdScheme         = "http"
+	defaultEtcdListenHost     = "0.0.0.0"
+	defaultEtcdListenHostIPv6 = "::"
+	defaultEtcdClientPort     = 2379
+	defaultEtcdServerPort     = 2380
+)
+
+var (
+	errInvalidInitialClusterFormat = errors.New("invalid initialCluster format")
 )
 
 // Configuration is the top level configuration that includes both a DB
@@ -69,6 +78,10 @@ type DBConfiguration struct {
 	// Metrics configuration.
 	Metrics instrument.MetricsConfiguration `yaml:"metrics"`
 
+	// LatencyBuckets configures the buckets of the per-RPC latency
+	// histograms, omit this to use the default buckets.
+	LatencyBuckets *xmetrics.LatencyBucketsConfiguration `yaml:"latencyBuckets"`
+
 	// The host and port on which to listen for the node service.
 	ListenAddress string `yaml:"listenAddress" validate:"nonzero"`
 
@@ -96,6 +109,18 @@ type DBConfiguration struct {
 	// Write new series limit per second to limit overwhelming during new ID bursts.
 	WriteNewSeriesLimitPerSecond int `yaml:"writeNewSeriesLimitPerSecond"`
 
+	// Write new series burst allowance, accrued from limit unused in previous
+	// seconds, to absorb short spikes of new series above the limit.
+	WriteNewSeriesBurst int `yaml:"writeNewSeriesBurst"`
+
+	// Write new series overflow per shard, the number of new series above the
+	// limit to defer to the next second rather than reject.
+	WriteNewSeriesOverflowPerShard int `yaml:"writeNewSeriesOverflowPerShard"`
+
+	// Write new series limits per second by namespace, the lower of the
+	// namespace limit and the global limit applies to a namespace.
+	WriteNewSeriesNamespaceLimitsPerSecond map[string]int `yaml:"writeNewSeriesNamespaceLimitsPerSecond"`
+
 	// Write new series backoff between batches of new series insertions.
 	WriteNewSeriesBackoffDuration time.Duration `yaml:"writeNewSeriesBackoffDuration"`
 
@@ -131,6 +156,26 @@ type DBConfiguration struct {
 
 	// Write new series asynchronously for fast ingestion of new ID bursts.
 	WriteNewSeriesAsync bool `yaml:"writeNewSeriesAsync"`
+
+	// The graceful shutdown policy, omit this to close immediately on exit.
+	GracefulShutdown *GracefulShutdownPolicy `yaml:"gracefulShutdown"`
+
+	// The cleanup policy for expired filesets, snapshots and commit logs.
+	Cleanup *CleanupPolicy `yaml:"cleanup"`
+
+	// The block size recommendation policy, omit this to only report
+	// recommendations without applying them.
+	BlockSizeRecommendation *BlockSizeRecommendationPolicy `yaml:"blockSizeRecommendation"`
+
+	// The change data capture configuration for emitting series created,
+	// block flushed, block expired and namespace changed events to a sink,
+	// omit this to emit no events.
+	ChangeDataCapture *cdc.Configuration `yaml:"changeDataCapture"`
+
+	// The initializing shards policy, omit this to write to the series of
+	// initializing shards directly rather than buffering the writes until
+	// the shards are bootstrapped.
+	InitializingShards *InitializingShardsPolicy `yaml:"initializingShards"`
 }
 
 // IndexConfiguration contains index-specific configuration.
@@ -140,6 +185,26 @@ type IndexConfiguration struct {
 	// important to prevent index queries from overloading the database entirely
 	// as they are very CPU-intensive (regex and FST matching.)
 	MaxQueryIDsConcurrency int `yaml:"maxQueryIDsConcurrency" validate:"min=0"`
+
+	// MemoryBudget bounds the memory held by in-memory index segments, the
+	// least recently queried segments are flushed to disk backed segments
+	// when the budget is exceeded. Omit to not bound index memory.
+	MemoryBudget *IndexMemoryBudgetConfiguration `yaml:"memoryBudget"`
+}
+
+// IndexMemoryBudgetConfiguration is the index memory budget configuration.
+type IndexMemoryBudgetConfiguration struct {
+	// LimitBytes is the estimated number of bytes in-memory index segments
+	// may hold before they are flushed to disk.
+	LimitBytes int64 `yaml:"limitBytes" validate:"min=1"`
+
+	// TargetRatio is the fraction of the limit that in-memory index segments
+	// are flushed down to once the limit is exceeded, defaults to 0.8.
+	TargetRatio float64 `yaml:"targetRatio" validate:"min=0,max=1"`
+
+	// Directory is the directory disk backed index segments are written to,
+	// defaults to the system temporary directory.
+	Directory string `yaml:"directory"`
 }
 
 // TickConfiguration is the tick configuration for background processing of
@@ -165,6 +230,57 @@ type BlockRetrievePolicy struct {
 	// FetchConcurrency is the concurrency to fetch blocks from disk. For
 	// spinning disks it is highly recommended to set this value to 1.
 	FetchConcurrency int `yaml:"fetchConcurrency" validate:"min=0"`
+
+	// PrefetchBlocks is the number of subsequent block starts to fetch ahead
+	// of the earliest block start when a fetch spans multiple blocks, zero
+	// disables prefetching.
+	PrefetchBlocks *int `yaml:"prefetchBlocks" validate:"min=0"`
+}
+
+// GracefulShutdownPolicy is the graceful shutdown policy, on exit the node
+// reports itself as unhealthy for the drain period so clients route away from
+// it, optionally snapshots unflushed series and then waits for in flight
+// RPCs to complete before closing.
+type GracefulShutdownPolicy struct {
+	// DrainPeriod is how long the node reports itself as unhealthy before it
+	// stops accepting RPCs.
+	DrainPeriod time.Duration `yaml:"drainPeriod" validate:"min=0"`
+
+	// DrainTimeout is how long to wait for in flight RPCs to complete after
+	// the node stops accepting RPCs.
+	DrainTimeout time.Duration `yaml:"drainTimeout" validate:"min=0"`
+
+	// SnapshotOnExit flushes and snapshots unflushed series on exit so they
+	// do not need to be replayed from the commit log on restart.
+	SnapshotOnExit bool `yaml:"snapshotOnExit"`
+}
+
+// CleanupPolicy is the policy for cleaning up expired data filesets, index
+// filesets, snapshots and commit logs.
+type CleanupPolicy struct {
+	// DryRun only reports the files and bytes each cleanup would reclaim
+	// instead of deleting them.
+	DryRun bool `yaml:"dryRun"`
+}
+
+// InitializingShardsPolicy is the policy for accepting writes for shards
+// that are initializing while they bootstrap from their peers.
+type InitializingShardsPolicy struct {
+	// WriteBufferSize is the maximum number of writes buffered per shard
+	// until the shard is bootstrapped, further writes are rejected.
+	WriteBufferSize int `yaml:"writeBufferSize" validate:"min=1"`
+}
+
+// BlockSizeRecommendationPolicy is the policy for applying the block size,
+// buffer past and buffer future recommended from the sampled write patterns
+// of each namespace to the namespace registry.
+type BlockSizeRecommendationPolicy struct {
+	// AutoApply applies the recommended buffer past and buffer future when
+	// they grow the buffers of a namespace, block sizes are only reported.
+	AutoApply bool `yaml:"autoApply"`
+
+	// Interval is how often recommendations are applied.
+	Interval time.Duration `yaml:"interval" validate:"nonzero"`
 }
 
 // CommitLogPolicy is the commit log policy.
@@ -223,6 +339,15 @@ type RepairPolicy struct {
 
 	// The repair check interval.
 	CheckInterval time.Duration `yaml:"checkInterval" validate:"nonzero"`
+
+	// The interval at which sampled blocks are compared across replicas to
+	// publish the divergence percentage of each namespace, zero disables the
+	// consistency checks.
+	ConsistencyCheckInterval time.Duration `yaml:"consistencyCheckInterval"`
+
+	// The number of shard blocks sampled per namespace by each consistency
+	// check, uses the default if zero.
+	ConsistencyCheckSampleSize int `yaml:"consistencyCheckSampleSize" validate:"min=0"`
 }
 
 // HashingConfiguration is the configuration for hashing.
@@ -248,30 +373,37 @@ func NewEtcdEmbedConfig(cfg DBConfiguration) (*embed.Config, error) {
 	}
 	newKVCfg.Dir = dir
 
-	LPUrls, err := convertToURLsWithDefault(kvCfg.ListenPeerUrls, newURL(defaultEtcdListenHost, defaultEtcdServerPort))
+	scheme, host, err := getHostFromHostID(kvCfg.InitialCluster, hostID)
 	if err != nil {
 		return nil, err
 	}
-	newKVCfg.LPUrls = LPUrls
 
-	LCUrls, err := convertToURLsWithDefault(kvCfg.ListenClientUrls, newURL(defaultEtcdListenHost, defaultEtcdClientPort))
+	// Listen on all interfaces of the same address family as the advertised
+	// host, which for IPv6 also accepts IPv4 connections on dual-stack hosts.
+	listenHost := defaultEtcdListenHost
+	if isIPv6(host) {
+		listenHost = defaultEtcdListenHostIPv6
+	}
+
+	LPUrls, err := convertToURLsWithDefault(kvCfg.ListenPeerUrls, newURL(defaultEtcdScheme, listenHost, defaultEtcdServerPort))
 	if err != nil {
 		return nil, err
 	}
-	newKVCfg.LCUrls = LCUrls
+	newKVCfg.LPUrls = LPUrls
 
-	host, err := getHostFromHostID(kvCfg.InitialCluster, hostID)
+	LCUrls, err := convertToURLsWithDefault(kvCfg.ListenClientUrls, newURL(defaultEtcdScheme, listenHost, defaultEtcdClientPort))
 	if err != nil {
 		return nil, err
 	}
+	newKVCfg.LCUrls = LCUrls
 
-	APUrls, err := convertToURLsWithDefault(kvCfg.InitialAdvertisePeerUrls, newURL(host, defaultEtcdServerPort))
+	APUrls, err := convertToURLsWithDefault(kvCfg.InitialAdvertisePeerUrls, newURL(scheme, host, defaultEtcdServerPort))
 	if err != nil {
 		return nil, err
 	}
 	newKVCfg.APUrls = APUrls
 
-	ACUrls, err := convertToURLsWithDefault(kvCfg.AdvertiseClientUrls, newURL(host, defaultEtcdClientPort))
+	ACUrls, err := convertToURLsWithDefault(kvCfg.AdvertiseClientUrls, newURL(scheme, host, defaultEtcdClientPort))
 	if err != nil {
 		return nil, err
 	}
@@ -294,8 +426,36 @@ func NewEtcdEmbedConfig(cfg DBConfiguration) (*embed.Config, error) {
 	return newKVCfg, nil
 }
 
-func newURL(host string, port int) string {
-	return fmt.Sprintf("%s:%d", host, port)
+// newURL returns a URL for the host and port, IPv6 hosts are bracketed and
+// the scheme is omitted if empty.
+func newURL(scheme, host string, port int) string {
+	hostPort := net.JoinHostPort(host, strconv.Itoa(port))
+	if scheme == "" {
+		return hostPort
+	}
+	return scheme + "://" + hostPort
+}
+
+// splitEndpoint splits an endpoint of the form [scheme://]host:port into its
+// scheme and host, IPv6 hosts must be bracketed, e.g. http://[::1]:2380.
+func splitEndpoint(endpoint string) (string, string, error) {
+	var (
+		scheme string
+		rest   = endpoint
+	)
+	if idx := strings.Index(endpoint, "://"); idx != -1 {
+		scheme, rest = endpoint[:idx], endpoint[idx+len("://"):]
+	}
+	host, _, err := net.SplitHostPort(rest)
+	if err != nil || host == "" {
+		return "", "", errInvalidInitialClusterFormat
+	}
+	return scheme, host, nil
+}
+
+func isIPv6(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
 }
 
 func convertToURLsWithDefault(urlStrs []string, def ...string) ([]url.URL, error) {
@@ -327,25 +487,18 @@ func initialClusterString(initialCluster []environment.SeedNode) string {
 	return buffer.String()
 }
 
-func getHostFromHostID(initialCluster []environment.SeedNode, hostID string) (string, error) {
+func getHostFromHostID(initialCluster []environment.SeedNode, hostID string) (string, string, error) {
 	if len(initialCluster) == 0 {
-		return "", errors.New("zero seed nodes in initialCluster")
+		return "", "", errors.New("zero seed nodes in initialCluster")
 	}
 
 	for _, seedNode := range initialCluster {
 		if hostID == seedNode.HostID {
-			endpoint := seedNode.Endpoint
-
-			colonIdx := strings.LastIndex(endpoint, ":")
-			if colonIdx == -1 {
-				return "", errors.New("invalid initialCluster format")
-			}
-
-			return endpoint[:colonIdx], nil
+			return splitEndpoint(seedNode.Endpoint)
 		}
 	}
 
-	return "", errors.New("host not in initialCluster list")
+	return "", "", errors.New("host not in initialCluster list")
 }
 
 // InitialClusterEndpoints returns the endpoints of the initial cluster
@@ -353,14 +506,12 @@ func InitialClusterEndpoints(initialCluster []environment.SeedNode) ([]string, e
 	endpoints := make([]string, 0, len(initialCluster))
 
 	for _, seedNode := range initialCluster {
-		endpoint := seedNode.Endpoint
-
-		colonIdx := strings.LastIndex(endpoint, ":")
-		if colonIdx == -1 {
-			return nil, errors.New("invalid initialCluster format")
+		scheme, host, err := splitEndpoint(seedNode.Endpoint)
+		if err != nil {
+			return nil, err
 		}
 
-		endpoints = append(endpoints, newURL(endpoint[:colonIdx], defaultEtcdClientPort))
+		endpoints = append(endpoints, newURL(scheme, host, defaultEtcdClientPort))
 	}
 
 	return endpoints, nil
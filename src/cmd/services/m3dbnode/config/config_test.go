@@ -302,6 +302,7 @@ db:
 	expected := `db:
   index:
     maxQueryIDsConcurrency: 0
+    memoryBudget: null
   logging:
     file: /var/log/m3dbnode.log
     level: info
@@ -319,6 +320,7 @@ db:
     samplingRate: 1
     extended: 3
     sanitization: 2
+  latencyBuckets: null
   listenAddress: 0.0.0.0:9000
   clusterListenAddress: 0.0.0.0:9001
   httpNodeListenAddress: 0.0.0.0:9002
@@ -361,6 +363,9 @@ db:
       seed: 42
   gcPercentage: 100
   writeNewSeriesLimitPerSecond: 1048576
+  writeNewSeriesBurst: 0
+  writeNewSeriesOverflowPerShard: 0
+  writeNewSeriesNamespaceLimitsPerSecond: {}
   writeNewSeriesBackoffDuration: 2ms
   tick: null
   bootstrap:
@@ -377,6 +382,7 @@ db:
     series: null
   fs:
     filePathPrefix: /var/lib/m3db
+    dataVolumes: []
     writeBufferSize: 65536
     dataReadBufferSize: 65536
     infoReadBufferSize: 128
@@ -386,6 +392,8 @@ db:
     newFileMode: null
     newDirectoryMode: null
     mmap: null
+    encryption: null
+    indexPostings: null
   commitlog:
     flushMaxBytes: 524288
     flushEvery: 1s
@@ -401,6 +409,8 @@ db:
     jitter: 1h0m0s
     throttle: 2m0s
     checkInterval: 1m0s
+    consistencyCheckInterval: 0s
+    consistencyCheckSampleSize: 0
   pooling:
     blockAllocSize: 16
     type: simple
@@ -584,6 +594,11 @@ db:
   hashing:
     seed: 42
   writeNewSeriesAsync: true
+  gracefulShutdown: null
+  cleanup: null
+  blockSizeRecommendation: null
+  changeDataCapture: null
+  initializingShards: null
 coordinator: null
 `
 
@@ -644,6 +659,49 @@ func TestInitialClusterEndpoints(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestInitialClusterEndpointsIPv6(t *testing.T) {
+	seedNodes := []environment.SeedNode{
+		environment.SeedNode{
+			HostID:   "host1",
+			Endpoint: "http://[2001:db8::1]:2380",
+		},
+		environment.SeedNode{
+			HostID:   "host2",
+			Endpoint: "http://1.1.1.2:2380",
+		},
+	}
+	endpoints, err := InitialClusterEndpoints(seedNodes)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(endpoints))
+	assert.Equal(t, "http://[2001:db8::1]:2379", endpoints[0])
+	assert.Equal(t, "http://1.1.1.2:2379", endpoints[1])
+
+	seedNodes = []environment.SeedNode{
+		environment.SeedNode{
+			HostID:   "host1",
+			Endpoint: "http://2001:db8::1:2380",
+		},
+	}
+	_, err = InitialClusterEndpoints(seedNodes)
+	require.Error(t, err)
+}
+
+func TestGetHostFromHostIDIPv6(t *testing.T) {
+	seedNodes := []environment.SeedNode{
+		environment.SeedNode{
+			HostID:   "host1",
+			Endpoint: "http://[2001:db8::1]:2380",
+		},
+	}
+	scheme, host, err := getHostFromHostID(seedNodes, "host1")
+	require.NoError(t, err)
+	assert.Equal(t, "http", scheme)
+	assert.Equal(t, "2001:db8::1", host)
+	assert.True(t, isIPv6(host))
+	assert.Equal(t, "http://[2001:db8::1]:2380", newURL(scheme, host, defaultEtcdServerPort))
+	assert.Equal(t, "http://[::]:2379", newURL(scheme, defaultEtcdListenHostIPv6, defaultEtcdClientPort))
+}
+
 func TestIsSeedNode(t *testing.T) {
 	seedNodes := []environment.SeedNode{
 		environment.SeedNode{
@@ -23,6 +23,9 @@ package config
 import (
 	"fmt"
 	"os"
+
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
+	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fs"
 )
 
 const (
@@ -47,6 +50,11 @@ type FilesystemConfiguration struct {
 	// File path prefix for reading/writing TSDB files
 	FilePathPrefix string `yaml:"filePathPrefix" validate:"nonzero"`
 
+	// Data volumes to stripe shard data and snapshot files across, shard
+	// directories under the file path prefix link to the volume each shard
+	// is striped to and are moved when volumes are added or removed
+	DataVolumes []string `yaml:"dataVolumes"`
+
 	// Write buffer size
 	WriteBufferSize int `yaml:"writeBufferSize" validate:"min=1"`
 
@@ -75,6 +83,34 @@ type FilesystemConfiguration struct {
 
 	// Mmap is the mmap options which features are primarily platform dependent
 	Mmap *MmapConfiguration `yaml:"mmap"`
+
+	// Encryption is the encryption at rest configuration, when set the commit
+	// logs and the filesets of namespaces with encryption enabled are encrypted
+	Encryption *EncryptionConfiguration `yaml:"encryption"`
+
+	// IndexPostings is the encoding of the postings lists of index segments,
+	// when not set postings lists are encoded as roaring bitmaps
+	IndexPostings *IndexPostingsConfiguration `yaml:"indexPostings"`
+}
+
+// IndexPostingsConfiguration is the index postings lists encoding configuration.
+type IndexPostingsConfiguration struct {
+	// Format is the encoding of the postings lists of all fields without a
+	// format of their own, one of roaring, delta-varint, bitset or auto which
+	// selects the most compact encoding of each postings list
+	Format m3ninxfs.PostingsFormat `yaml:"format"`
+
+	// FieldFormats is the encoding of the postings lists of specific fields
+	FieldFormats map[string]m3ninxfs.PostingsFormat `yaml:"fieldFormats"`
+}
+
+// EncryptionConfiguration is the encryption at rest configuration.
+type EncryptionConfiguration struct {
+	// KeyFile is the path to a file with one key per line in the form
+	// "<id>:<hex material>", the first key is used to encrypt new data and
+	// the remaining keys are retained to decrypt data written before a key
+	// was rotated
+	KeyFile string `yaml:"keyFile" validate:"nonzero"`
 }
 
 // MmapConfiguration is the mmap configuration.
@@ -148,3 +184,23 @@ func (p FilesystemConfiguration) MmapConfiguration() MmapConfiguration {
 	}
 	return *p.Mmap
 }
+
+// IndexSegmentWriterOpts returns the options of the writer of index segments.
+func (p FilesystemConfiguration) IndexSegmentWriterOpts() m3ninxfs.NewWriterOpts {
+	if p.IndexPostings == nil {
+		return m3ninxfs.NewWriterOpts{}
+	}
+	return m3ninxfs.NewWriterOpts{
+		PostingsFormat:       p.IndexPostings.Format,
+		FieldPostingsFormats: p.IndexPostings.FieldFormats,
+	}
+}
+
+// EncryptionKeyProvider returns the encryption key provider, or nil if
+// encryption is not configured.
+func (p FilesystemConfiguration) EncryptionKeyProvider() (encryption.KeyProvider, error) {
+	if p.Encryption == nil {
+		return nil, nil
+	}
+	return encryption.NewStaticKeyFileProvider(p.Encryption.KeyFile)
+}
@@ -21,9 +21,14 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fs"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -47,3 +52,45 @@ func TestFilesystemConfigurationParseNewDirectoryMode(t *testing.T) {
 
 	assert.Equal(t, os.FileMode(0775)|os.ModeDir, v)
 }
+
+func TestFilesystemConfigurationEncryptionKeyProvider(t *testing.T) {
+	provider, err := FilesystemConfiguration{}.EncryptionKeyProvider()
+	require.NoError(t, err)
+	assert.Nil(t, provider)
+
+	dir, err := ioutil.TempDir("", "fs-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "keys")
+	key := "current:" + strings.Repeat("ab", 32) + "\n"
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(key), 0600))
+
+	cfg := FilesystemConfiguration{
+		Encryption: &EncryptionConfiguration{KeyFile: keyFile},
+	}
+	provider, err = cfg.EncryptionKeyProvider()
+	require.NoError(t, err)
+
+	current, err := provider.CurrentKey()
+	require.NoError(t, err)
+	assert.Equal(t, "current", current.ID)
+}
+
+func TestFilesystemConfigurationIndexSegmentWriterOpts(t *testing.T) {
+	assert.Equal(t, m3ninxfs.NewWriterOpts{},
+		FilesystemConfiguration{}.IndexSegmentWriterOpts())
+
+	cfg := FilesystemConfiguration{
+		IndexPostings: &IndexPostingsConfiguration{
+			Format: m3ninxfs.AutoPostingsFormat,
+			FieldFormats: map[string]m3ninxfs.PostingsFormat{
+				"city": m3ninxfs.BitsetPostingsFormat,
+			},
+		},
+	}
+	opts := cfg.IndexSegmentWriterOpts()
+	require.NoError(t, opts.Validate())
+	assert.Equal(t, m3ninxfs.AutoPostingsFormat, opts.PostingsFormat)
+	assert.Equal(t, m3ninxfs.BitsetPostingsFormat, opts.FieldPostingsFormats["city"])
+}
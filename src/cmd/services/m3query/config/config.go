@@ -23,9 +23,32 @@ package config
 import (
 	"time"
 
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/dbnode/environment/backend"
+	"github.com/m3db/m3/src/dbnode/x/logsample"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
+	"github.com/m3db/m3/src/query/api/v1/handler/openmetrics"
+	"github.com/m3db/m3/src/query/api/v1/handler/otlp"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/frontend"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/ruler"
+	"github.com/m3db/m3/src/query/storage/discovery"
+	"github.com/m3db/m3/src/query/storage/enrich"
 	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3/src/query/storage/partition"
+	"github.com/m3db/m3/src/query/storage/readonly"
+	"github.com/m3db/m3/src/query/storage/routing"
+	"github.com/m3db/m3/src/query/storage/salt"
+	"github.com/m3db/m3/src/query/storage/temporality"
+	"github.com/m3db/m3/src/query/storage/validation"
+	"github.com/m3db/m3/src/query/tsdb/thanos"
+	"github.com/m3db/m3/src/query/util/journal"
 	etcdclient "github.com/m3db/m3cluster/client/etcd"
 	"github.com/m3db/m3x/instrument"
+	"github.com/m3db/m3x/retry"
 )
 
 // Configuration is the configuration for the query service.
@@ -33,6 +56,10 @@ type Configuration struct {
 	// Metrics configuration.
 	Metrics instrument.MetricsConfiguration `yaml:"metrics"`
 
+	// LatencyBuckets configures the buckets of the per-route HTTP latency
+	// histograms, omit this to use the default buckets.
+	LatencyBuckets *xmetrics.LatencyBucketsConfiguration `yaml:"latencyBuckets"`
+
 	// Clusters is the DB cluster configurations for read, write and
 	// query endpoints.
 	Clusters local.ClustersStaticConfiguration `yaml:"clusters"`
@@ -57,6 +84,147 @@ type Configuration struct {
 	// DecompressWorkerPoolSize is the size of the worker pool given to each
 	// fetch request.
 	DecompressWorkerPoolSize int `yaml:"workerPoolSize"`
+
+	// QueryPolicy is the query policy configuration used to block or
+	// rewrite queries (optional).
+	QueryPolicy *rules.Configuration `yaml:"queryPolicy"`
+
+	// Downsample configurates how the metrics should be downsampled.
+	Downsample downsample.Configuration `yaml:"downsample"`
+
+	// QueryJournal is the configuration for recording incoming queries to
+	// a journal file that can be replayed against a test cluster (optional).
+	QueryJournal *journal.Configuration `yaml:"queryJournal"`
+
+	// Routing is the configuration for routing series by tags to clusters
+	// other than the default clusters (optional).
+	Routing *routing.Configuration `yaml:"routing"`
+
+	// Partitioning is the configuration for writing unaggregated metrics to
+	// time partitioned namespaces and reading across them (optional).
+	Partitioning *partition.Configuration `yaml:"partitioning"`
+
+	// HotSeriesSalt is the configuration for spreading the writes of hot
+	// series across shards by salting them with a tag (optional).
+	HotSeriesSalt *salt.Configuration `yaml:"hotSeriesSalt"`
+
+	// EvaluationCache is the configuration for caching the results of
+	// expensive transforms across queries (optional).
+	EvaluationCache *transform.EvaluationCacheConfiguration `yaml:"evaluationCache"`
+
+	// ReadOnly is the configuration for rejecting writes while continuing
+	// to serve queries (optional).
+	ReadOnly *readonly.Configuration `yaml:"readOnly"`
+
+	// WriteValidation is the configuration for validating the datapoints
+	// of writes against rules before they are written (optional).
+	WriteValidation *validation.Configuration `yaml:"writeValidation"`
+
+	// DeltaConversion is the configuration for converting the writes of
+	// delta temporality counters into cumulative series (optional).
+	DeltaConversion *temporality.Configuration `yaml:"deltaConversion"`
+
+	// ResampleInterpolation is the interpolation used to resample blocks of
+	// different resolutions combined by binary operations, defaults to
+	// previous.
+	ResampleInterpolation block.Interpolation `yaml:"resampleInterpolation"`
+
+	// Decimation is how fetched series with a resolution far finer than the
+	// step of a query are reduced to a single value per step for queries
+	// which do not specify one, defaults to none.
+	Decimation models.Decimation `yaml:"decimation"`
+
+	// Enrichment is the configuration for adding the tags of lookup tables
+	// joined on the tags of fetched series at query time (optional).
+	Enrichment *enrich.Configuration `yaml:"enrichment"`
+
+	// IngestEnrichment is the configuration for adding tags resolved from
+	// service discovery metadata to series as they are written (optional).
+	IngestEnrichment *discovery.Configuration `yaml:"ingestEnrichment"`
+
+	// Spill is the configuration for spilling blocks held by memory heavy
+	// transforms to disk once a memory budget is exceeded (optional).
+	Spill *transform.SpillConfiguration `yaml:"spill"`
+
+	// OTLP is the configuration for receiving OpenTelemetry metrics exports
+	// over OTLP/HTTP and, if a gRPC listen address is set, OTLP/gRPC
+	// (optional).
+	OTLP *otlp.Configuration `yaml:"otlp"`
+
+	// ThanosStore is the configuration for serving the Thanos StoreAPI so
+	// that Thanos Query can federate the coordinator (optional).
+	ThanosStore *thanos.Configuration `yaml:"thanosStore"`
+
+	// LogSampling configures how often repeated errors of the write paths
+	// are logged, by default each class of error is logged at most once
+	// every ten seconds.
+	LogSampling logsample.Configuration `yaml:"logSampling"`
+
+	// QuerySplitting is the configuration for splitting long range queries
+	// into sub-range queries that are executed concurrently (optional).
+	QuerySplitting *QuerySplittingConfiguration `yaml:"querySplitting"`
+
+	// QueryFrontend is the configuration for queueing queries per tenant
+	// and priority so that queries are executed fairly (optional).
+	QueryFrontend *frontend.Configuration `yaml:"queryFrontend"`
+
+	// QueryRetry is the configuration for retrying fragments of queries
+	// that fail transiently, i.e. the fetches of single storages and the
+	// sub-range queries of split queries, without executing the fragments
+	// that succeeded again (optional).
+	QueryRetry *retry.Configuration `yaml:"queryRetry"`
+
+	// MetricsScrape is the configuration for the scrape endpoint that
+	// exposes the internal metrics of allowed subsystems on the HTTP
+	// listen address (optional).
+	MetricsScrape *openmetrics.Configuration `yaml:"metricsScrape"`
+
+	// PlacementConstraints is the configuration for the failure domain
+	// constraints placements changed through the placement API must meet
+	// (optional).
+	PlacementConstraints *PlacementConstraintsConfiguration `yaml:"placementConstraints"`
+
+	// Ruler is the configuration for evaluating recording rules, optionally
+	// sharded by rule group between the coordinator replicas (optional).
+	Ruler *ruler.Configuration `yaml:"ruler"`
+
+	// ConfigReload is the configuration for reloading the query policy,
+	// write validation and routing sections at runtime (optional).
+	ConfigReload *ConfigReloadConfiguration `yaml:"configReload"`
+}
+
+// PlacementConstraintsConfiguration is the configuration for the failure
+// domain constraints of placements.
+type PlacementConstraintsConfiguration struct {
+	// FailureDomains are the failure domains, of zone, rack and host, that
+	// no two replicas of a shard may share.
+	FailureDomains []string `yaml:"failureDomains" validate:"nonzero"`
+}
+
+// ConfigReloadConfiguration is the configuration for reloading sections of
+// the configuration at runtime. Reloads are triggered by SIGHUP, which
+// reloads the configuration file, by changes to the KV key and by the
+// config reload endpoint.
+type ConfigReloadConfiguration struct {
+	// KVKey is the KV key to watch for configuration overrides, the value
+	// is expected to be a string proto containing YAML that is overlaid
+	// on the configuration file before it is reloaded.
+	KVKey string `yaml:"kvKey"`
+}
+
+// QuerySplittingConfiguration is the configuration for splitting long range
+// queries into sub-range queries, e.g. one per day, that are executed
+// concurrently and stitched back together.
+type QuerySplittingConfiguration struct {
+	// Interval is the length of the sub-ranges, sub-ranges are aligned to
+	// multiples of the interval since the Unix epoch. To split queries on
+	// the boundaries of downsampled datapoints the interval should be a
+	// multiple of the resolutions of the aggregated namespaces.
+	Interval time.Duration `yaml:"interval" validate:"nonzero"`
+
+	// MaxParallelism is the max number of sub-range queries of a query that
+	// are executed at once, by default all of them are executed at once.
+	MaxParallelism int `yaml:"maxParallelism" validate:"min=0"`
 }
 
 // LocalConfiguration is the local embedded configuration if running
@@ -74,6 +242,10 @@ type LocalConfiguration struct {
 type ClusterManagementConfiguration struct {
 	// Etcd is the client configuration for etcd.
 	Etcd etcdclient.Configuration `yaml:"etcd"`
+
+	// Backend is the client configuration for Consul or ZooKeeper, used
+	// instead of Etcd when set.
+	Backend *backend.Configuration `yaml:"backend"`
 }
 
 // RPCConfiguration is the RPC configuration for the coordinator for
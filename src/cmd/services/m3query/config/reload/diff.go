@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reload
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// sectionFields are the indexes of the fields of the configuration keyed
+// by their YAML key.
+var sectionFields = newSectionFields()
+
+func newSectionFields() map[string]int {
+	t := reflect.TypeOf(config.Configuration{})
+	result := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		result[name] = i
+	}
+	return result
+}
+
+// SectionDiff is the diff of a changed section of the configuration.
+type SectionDiff struct {
+	Section string `json:"section"`
+	// Reloadable is whether the section is applied by a reload.
+	Reloadable bool `json:"reloadable"`
+	// Lines are the lines of the YAML encoded section prefixed with "-"
+	// if removed, "+" if added or " " if unchanged.
+	Lines []string `json:"lines"`
+}
+
+// Diff returns the diff of every section that differs between the
+// configurations, in the order the sections are declared.
+func Diff(old, new config.Configuration) []SectionDiff {
+	var (
+		oldValue = reflect.ValueOf(old)
+		newValue = reflect.ValueOf(new)
+		t        = oldValue.Type()
+		result   []SectionDiff
+	)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		oldLines := sectionLines(oldValue.Field(i).Interface())
+		newLines := sectionLines(newValue.Field(i).Interface())
+		if equalLines(oldLines, newLines) {
+			continue
+		}
+		result = append(result, SectionDiff{
+			Section: name,
+			Lines:   diffLines(oldLines, newLines),
+		})
+	}
+	return result
+}
+
+// sectionLines returns the lines of the YAML encoded section, sections are
+// compared by their encoding so that unexported state is ignored.
+func sectionLines(section interface{}) []string {
+	data, err := yaml.Marshal(section)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffLines returns the line diff of the longest common subsequence of
+// the old and new lines.
+func diffLines(old, new []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// old[i:] and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	result := make([]string, 0, len(old)+len(new))
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			result = append(result, " "+old[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, "-"+old[i])
+			i++
+		default:
+			result = append(result, "+"+new[j])
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		result = append(result, "-"+old[i])
+	}
+	for ; j < len(new); j++ {
+		result = append(result, "+"+new[j])
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reload
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+	xconfig "github.com/m3db/m3x/config"
+
+	"go.uber.org/zap"
+	validator "gopkg.in/validator.v2"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	errNoKVStore = errors.New("configuration reload KV key set without a KV store")
+)
+
+// NewLoadFn returns a function that loads the configuration file and
+// overlays the YAML set in the KV key, if any, on top of it.
+func NewLoadFn(file string, store kv.Store, key string) LoadFn {
+	return func() (config.Configuration, error) {
+		var cfg config.Configuration
+		if file != "" {
+			if err := xconfig.LoadFile(&cfg, file, xconfig.Options{}); err != nil {
+				return cfg, err
+			}
+		}
+
+		if store == nil || key == "" {
+			return cfg, nil
+		}
+
+		value, err := store.Get(key)
+		if err == kv.ErrNotFound {
+			return cfg, nil
+		}
+		if err != nil {
+			return cfg, err
+		}
+
+		protoValue := &commonpb.StringProto{}
+		if err := value.Unmarshal(protoValue); err != nil {
+			return cfg, fmt.Errorf("unable to unmarshal %s: %v", key, err)
+		}
+		if err := yaml.UnmarshalStrict([]byte(protoValue.Value), &cfg); err != nil {
+			return cfg, fmt.Errorf("unable to parse %s: %v", key, err)
+		}
+		if err := validator.Validate(cfg); err != nil {
+			return cfg, err
+		}
+		return cfg, nil
+	}
+}
+
+// WatchKV reloads the configuration every time the value of the KV key
+// changes, the reloads are logged and recorded by the reloader.
+func WatchKV(
+	reloader *Reloader,
+	store kv.Store,
+	key string,
+	logger *zap.Logger,
+) error {
+	if store == nil {
+		return errNoKVStore
+	}
+
+	watch, err := store.Watch(key)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		// The first notification is the value at the time of the watch,
+		// reloading it applies the overrides set before startup.
+		for range watch.C() {
+			logger.Info("configuration KV key changed, reloading",
+				zap.String("key", key))
+			reloader.Reload(KVTrigger)
+		}
+	}()
+
+	return nil
+}
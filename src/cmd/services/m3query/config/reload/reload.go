@@ -0,0 +1,294 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package reload reloads sections of the coordinator configuration at
+// runtime, validating every changed section before any is applied and
+// rolling back the applied sections if a section fails to apply.
+package reload
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+var (
+	errNoLoadFn = errors.New("no configuration load function set")
+)
+
+// Trigger is what triggered a reload.
+type Trigger string
+
+const (
+	// SignalTrigger is a reload triggered by SIGHUP.
+	SignalTrigger Trigger = "signal"
+	// KVTrigger is a reload triggered by a change to the KV key.
+	KVTrigger Trigger = "kv"
+	// APITrigger is a reload triggered by the config reload endpoint.
+	APITrigger Trigger = "api"
+)
+
+// Section is a section of the configuration that can be reloaded.
+type Section interface {
+	// Name returns the YAML key of the section in the configuration.
+	Name() string
+
+	// Validate returns an error if the section of the new configuration
+	// can not be applied in place of the section of the old configuration.
+	Validate(old, new config.Configuration) error
+
+	// Apply applies the section of the configuration.
+	Apply(cfg config.Configuration) error
+}
+
+// LoadFn loads the configuration to reload.
+type LoadFn func() (config.Configuration, error)
+
+// ValidationError is returned when the configuration to reload is invalid,
+// in which case no section has been applied.
+type ValidationError struct {
+	Section string
+	Err     error
+}
+
+func (e ValidationError) Error() string {
+	if e.Section == "" {
+		return fmt.Sprintf("invalid configuration: %v", e.Err)
+	}
+	return fmt.Sprintf("invalid %s configuration: %v", e.Section, e.Err)
+}
+
+// IsValidationError returns true if the error is a validation error.
+func IsValidationError(err error) bool {
+	_, ok := err.(ValidationError)
+	return ok
+}
+
+// Result is the result of a reload.
+type Result struct {
+	Time    time.Time `json:"time"`
+	Trigger Trigger   `json:"trigger"`
+	// Applied are the sections that were applied.
+	Applied []string `json:"applied"`
+	// RolledBack are the sections that were rolled back after another
+	// section failed to apply.
+	RolledBack []string `json:"rolledBack,omitempty"`
+	// RestartRequired are the changed sections that can not be reloaded
+	// and only take effect once the coordinator is restarted.
+	RestartRequired []string `json:"restartRequired,omitempty"`
+	// Diff is the diff of every changed section.
+	Diff  []SectionDiff `json:"diff"`
+	Error string        `json:"error,omitempty"`
+}
+
+type reloaderMetrics struct {
+	success         tally.Counter
+	validationError tally.Counter
+	applyError      tally.Counter
+	rollbackError   tally.Counter
+}
+
+func newReloaderMetrics(scope tally.Scope) reloaderMetrics {
+	return reloaderMetrics{
+		success:         scope.Counter("success"),
+		validationError: scope.Counter("validation-error"),
+		applyError:      scope.Counter("apply-error"),
+		rollbackError:   scope.Counter("rollback-error"),
+	}
+}
+
+// Reloader reloads the sections of the configuration added to it.
+type Reloader struct {
+	sync.Mutex
+
+	loadFn   LoadFn
+	current  config.Configuration
+	sections []Section
+	last     *Result
+	nowFn    func() time.Time
+	logger   *zap.Logger
+	metrics  reloaderMetrics
+}
+
+// NewReloader returns a new reloader for the current configuration.
+func NewReloader(
+	current config.Configuration,
+	loadFn LoadFn,
+	scope tally.Scope,
+	logger *zap.Logger,
+) (*Reloader, error) {
+	if loadFn == nil {
+		return nil, errNoLoadFn
+	}
+	return &Reloader{
+		loadFn:  loadFn,
+		current: current,
+		nowFn:   time.Now,
+		logger:  logger,
+		metrics: newReloaderMetrics(scope),
+	}, nil
+}
+
+// AddSection adds a reloadable section, sections are applied in the order
+// they are added.
+func (r *Reloader) AddSection(section Section) error {
+	if _, ok := sectionFields[section.Name()]; !ok {
+		return fmt.Errorf("unknown configuration section: %s", section.Name())
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.sections = append(r.sections, section)
+	return nil
+}
+
+// LastResult returns the result of the last reload, if any.
+func (r *Reloader) LastResult() (Result, bool) {
+	r.Lock()
+	defer r.Unlock()
+	if r.last == nil {
+		return Result{}, false
+	}
+	return *r.last, true
+}
+
+// Reload loads the configuration and applies every changed section that
+// can be reloaded.
+func (r *Reloader) Reload(trigger Trigger) (Result, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	result, err := r.reloadWithLock(trigger)
+	if err != nil {
+		result.Error = err.Error()
+		if IsValidationError(err) {
+			r.metrics.validationError.Inc(1)
+		} else {
+			r.metrics.applyError.Inc(1)
+		}
+		r.logger.Error("unable to reload configuration",
+			zap.String("trigger", string(trigger)), zap.Any("error", err))
+	} else {
+		r.metrics.success.Inc(1)
+		r.logger.Info("reloaded configuration",
+			zap.String("trigger", string(trigger)),
+			zap.Strings("applied", result.Applied),
+			zap.Strings("restartRequired", result.RestartRequired))
+	}
+	r.last = &result
+	return result, err
+}
+
+func (r *Reloader) reloadWithLock(trigger Trigger) (Result, error) {
+	result := Result{
+		Time:    r.nowFn(),
+		Trigger: trigger,
+		Applied: []string{},
+	}
+
+	cfg, err := r.loadFn()
+	if err != nil {
+		return result, ValidationError{Err: err}
+	}
+
+	var changed []Section
+	for _, diff := range Diff(r.current, cfg) {
+		diff.Reloadable = r.section(diff.Section) != nil
+		if !diff.Reloadable {
+			result.RestartRequired = append(result.RestartRequired, diff.Section)
+		}
+		result.Diff = append(result.Diff, diff)
+	}
+	for _, section := range r.sections {
+		if sectionChanged(result.Diff, section.Name()) {
+			changed = append(changed, section)
+		}
+	}
+
+	// Validate every section up front so that invalid configuration is
+	// rejected before any section has been applied.
+	for _, section := range changed {
+		if err := section.Validate(r.current, cfg); err != nil {
+			return result, ValidationError{Section: section.Name(), Err: err}
+		}
+	}
+
+	for i, section := range changed {
+		if err := section.Apply(cfg); err != nil {
+			r.rollback(&result, changed[:i])
+			return result, fmt.Errorf("unable to apply %s configuration: %v",
+				section.Name(), err)
+		}
+		result.Applied = append(result.Applied, section.Name())
+	}
+
+	for _, section := range changed {
+		setSection(&r.current, cfg, section.Name())
+	}
+	return result, nil
+}
+
+// rollback applies the current configuration of the applied sections in
+// reverse order.
+func (r *Reloader) rollback(result *Result, applied []Section) {
+	result.Applied = []string{}
+	for i := len(applied) - 1; i >= 0; i-- {
+		section := applied[i]
+		if err := section.Apply(r.current); err != nil {
+			r.metrics.rollbackError.Inc(1)
+			r.logger.Error("unable to roll back configuration section",
+				zap.String("section", section.Name()), zap.Any("error", err))
+			continue
+		}
+		result.RolledBack = append(result.RolledBack, section.Name())
+	}
+}
+
+func (r *Reloader) section(name string) Section {
+	for _, section := range r.sections {
+		if section.Name() == name {
+			return section
+		}
+	}
+	return nil
+}
+
+func sectionChanged(diffs []SectionDiff, name string) bool {
+	for _, diff := range diffs {
+		if diff.Section == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setSection sets the named section of the configuration to the value of
+// the section in the source configuration.
+func setSection(cfg *config.Configuration, src config.Configuration, name string) {
+	idx := sectionFields[name]
+	reflect.ValueOf(cfg).Elem().Field(idx).Set(reflect.ValueOf(src).Field(idx))
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reload
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/storage/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+type testSection struct {
+	name        string
+	validateErr error
+	applyErr    error
+	applied     []config.Configuration
+}
+
+func (s *testSection) Name() string {
+	return s.name
+}
+
+func (s *testSection) Validate(old, new config.Configuration) error {
+	return s.validateErr
+}
+
+func (s *testSection) Apply(cfg config.Configuration) error {
+	s.applied = append(s.applied, cfg)
+	if s.applyErr != nil && len(s.applied) == 1 {
+		return s.applyErr
+	}
+	return nil
+}
+
+func newTestConfig(listenAddress string, numPolicyRules, numValidationRules int) config.Configuration {
+	cfg := config.Configuration{
+		ListenAddress:   listenAddress,
+		QueryPolicy:     &rules.Configuration{},
+		WriteValidation: &validation.Configuration{},
+	}
+	for i := 0; i < numPolicyRules; i++ {
+		cfg.QueryPolicy.Rules = append(cfg.QueryPolicy.Rules,
+			rules.Rule{Name: "block", Action: rules.BlockAction, MetricNames: []string{"foo"}})
+	}
+	for i := 0; i < numValidationRules; i++ {
+		cfg.WriteValidation.Rules = append(cfg.WriteValidation.Rules,
+			validation.Rule{Name: "reject", Action: validation.RejectAction})
+	}
+	return cfg
+}
+
+func newTestReloader(
+	t *testing.T,
+	current config.Configuration,
+	next *config.Configuration,
+	sections ...Section,
+) *Reloader {
+	r, err := NewReloader(current, func() (config.Configuration, error) {
+		return *next, nil
+	}, tally.NoopScope, zap.NewNop())
+	require.NoError(t, err)
+	for _, section := range sections {
+		require.NoError(t, r.AddSection(section))
+	}
+	return r
+}
+
+func TestAddSectionRejectsUnknownSection(t *testing.T) {
+	next := newTestConfig("0.0.0.0:7201", 0, 0)
+	r := newTestReloader(t, next, &next)
+	require.Error(t, r.AddSection(&testSection{name: "unknown"}))
+}
+
+func TestReloadAppliesChangedSections(t *testing.T) {
+	var (
+		current         = newTestConfig("0.0.0.0:7201", 0, 0)
+		next            = newTestConfig("0.0.0.0:7202", 1, 0)
+		policy          = &testSection{name: "queryPolicy"}
+		writeValidation = &testSection{name: "writeValidation"}
+		r               = newTestReloader(t, current, &next, policy, writeValidation)
+	)
+
+	result, err := r.Reload(APITrigger)
+	require.NoError(t, err)
+	assert.Equal(t, APITrigger, result.Trigger)
+	assert.Equal(t, []string{"queryPolicy"}, result.Applied)
+	assert.Equal(t, []string{"listenAddress"}, result.RestartRequired)
+	require.Len(t, result.Diff, 2)
+	assert.Equal(t, "listenAddress", result.Diff[0].Section)
+	assert.False(t, result.Diff[0].Reloadable)
+	assert.Equal(t, []string{"-0.0.0.0:7201", "+0.0.0.0:7202"}, result.Diff[0].Lines)
+	assert.Equal(t, "queryPolicy", result.Diff[1].Section)
+	assert.True(t, result.Diff[1].Reloadable)
+
+	require.Len(t, policy.applied, 1)
+	assert.Len(t, policy.applied[0].QueryPolicy.Rules, 1)
+	assert.Empty(t, writeValidation.applied)
+
+	last, ok := r.LastResult()
+	require.True(t, ok)
+	assert.Equal(t, result, last)
+
+	// Reloading the same configuration again applies nothing since the
+	// applied sections are now current.
+	result, err = r.Reload(SignalTrigger)
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Len(t, policy.applied, 1)
+}
+
+func TestReloadValidatesBeforeApplying(t *testing.T) {
+	var (
+		current         = newTestConfig("0.0.0.0:7201", 0, 0)
+		next            = newTestConfig("0.0.0.0:7201", 1, 1)
+		policy          = &testSection{name: "queryPolicy"}
+		writeValidation = &testSection{name: "writeValidation", validateErr: errors.New("bad rule")}
+		r               = newTestReloader(t, current, &next, policy, writeValidation)
+	)
+
+	result, err := r.Reload(APITrigger)
+	require.Error(t, err)
+	assert.True(t, IsValidationError(err))
+	assert.Equal(t, "invalid writeValidation configuration: bad rule", result.Error)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, policy.applied)
+	assert.Empty(t, writeValidation.applied)
+}
+
+func TestReloadRollsBackOnApplyError(t *testing.T) {
+	var (
+		current         = newTestConfig("0.0.0.0:7201", 0, 0)
+		next            = newTestConfig("0.0.0.0:7201", 1, 1)
+		policy          = &testSection{name: "queryPolicy"}
+		writeValidation = &testSection{name: "writeValidation", applyErr: errors.New("apply failed")}
+		r               = newTestReloader(t, current, &next, policy, writeValidation)
+	)
+
+	result, err := r.Reload(KVTrigger)
+	require.Error(t, err)
+	assert.False(t, IsValidationError(err))
+	assert.Empty(t, result.Applied)
+	assert.Equal(t, []string{"queryPolicy"}, result.RolledBack)
+
+	// The policy section was applied then rolled back to the current rules.
+	require.Len(t, policy.applied, 2)
+	assert.Len(t, policy.applied[0].QueryPolicy.Rules, 1)
+	assert.Len(t, policy.applied[1].QueryPolicy.Rules, 0)
+
+	// Neither section is current so both are applied by the next reload.
+	result, err = r.Reload(KVTrigger)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"queryPolicy", "writeValidation"}, result.Applied)
+}
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t, []string{" a", "-b", "+c", " d", "+e"},
+		diffLines([]string{"a", "b", "d"}, []string{"a", "c", "d", "e"}))
+}
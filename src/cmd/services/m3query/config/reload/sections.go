@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reload
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/routing"
+	"github.com/m3db/m3/src/query/storage/validation"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errSectionRemoved         = errors.New("section can not be removed without a restart")
+	errSectionAdded           = errors.New("section can not be added without a restart")
+	errKVKeyChanged           = errors.New("kvKey can not be changed without a restart")
+	errMaxTrackedChanged      = errors.New("maxTrackedSeries can not be changed without a restart")
+	errRoutingClustersChanged = errors.New("routing clusters can not be changed without a restart")
+)
+
+type queryPolicySection struct {
+	engine rules.Engine
+}
+
+// NewQueryPolicySection returns a section that sets the static rules of the
+// query policy engine.
+func NewQueryPolicySection(engine rules.Engine) Section {
+	return &queryPolicySection{engine: engine}
+}
+
+func (s *queryPolicySection) Name() string {
+	return "queryPolicy"
+}
+
+func (s *queryPolicySection) Validate(old, new config.Configuration) error {
+	if err := validatePresence(old.QueryPolicy != nil, new.QueryPolicy != nil); err != nil {
+		return err
+	}
+	if new.QueryPolicy == nil {
+		return nil
+	}
+	if old.QueryPolicy.KVKey != new.QueryPolicy.KVKey {
+		return errKVKeyChanged
+	}
+	// Compile the rules with a detached engine so they are validated
+	// without being applied.
+	_, err := rules.NewEngine(new.QueryPolicy.Rules, tally.NoopScope)
+	return err
+}
+
+func (s *queryPolicySection) Apply(cfg config.Configuration) error {
+	return s.engine.SetRules(cfg.QueryPolicy.Rules)
+}
+
+type writeValidationSection struct {
+	engine validation.Engine
+}
+
+// NewWriteValidationSection returns a section that sets the static rules of
+// the write validation engine.
+func NewWriteValidationSection(engine validation.Engine) Section {
+	return &writeValidationSection{engine: engine}
+}
+
+func (s *writeValidationSection) Name() string {
+	return "writeValidation"
+}
+
+func (s *writeValidationSection) Validate(old, new config.Configuration) error {
+	if err := validatePresence(old.WriteValidation != nil, new.WriteValidation != nil); err != nil {
+		return err
+	}
+	if new.WriteValidation == nil {
+		return nil
+	}
+	if old.WriteValidation.KVKey != new.WriteValidation.KVKey {
+		return errKVKeyChanged
+	}
+	if old.WriteValidation.MaxTrackedSeries != new.WriteValidation.MaxTrackedSeries {
+		return errMaxTrackedChanged
+	}
+	_, err := validation.NewEngine(new.WriteValidation.Rules,
+		new.WriteValidation.MaxTrackedSeries, tally.NoopScope)
+	return err
+}
+
+func (s *writeValidationSection) Apply(cfg config.Configuration) error {
+	return s.engine.SetRules(cfg.WriteValidation.Rules)
+}
+
+type routingSection struct {
+	storage routing.Storage
+	stores  map[string]storage.Storage
+}
+
+// NewRoutingSection returns a section that sets the routes of the routing
+// storage, routes may only route to the clusters connected at startup.
+func NewRoutingSection(
+	storage routing.Storage,
+	stores map[string]storage.Storage,
+) Section {
+	return &routingSection{storage: storage, stores: stores}
+}
+
+func (s *routingSection) Name() string {
+	return "routing"
+}
+
+func (s *routingSection) Validate(old, new config.Configuration) error {
+	if err := validatePresence(old.Routing != nil, new.Routing != nil); err != nil {
+		return err
+	}
+	if new.Routing == nil {
+		return nil
+	}
+	if !reflect.DeepEqual(old.Routing.Clusters, new.Routing.Clusters) {
+		return errRoutingClustersChanged
+	}
+	routes, err := new.Routing.NewRoutes(s.stores)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("invalid route %s: %v", route.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *routingSection) Apply(cfg config.Configuration) error {
+	routes, err := cfg.Routing.NewRoutes(s.stores)
+	if err != nil {
+		return err
+	}
+	return s.storage.SetRoutes(routes)
+}
+
+// validatePresence returns an error if an optional section was added or
+// removed, since the components of optional sections are only created at
+// startup.
+func validatePresence(old, new bool) error {
+	switch {
+	case old && !new:
+		return errSectionRemoved
+	case !old && new:
+		return errSectionAdded
+	}
+	return nil
+}
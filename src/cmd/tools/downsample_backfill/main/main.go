@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/local"
+	etcdclient "github.com/m3db/m3cluster/client/etcd"
+	"github.com/m3db/m3x/clock"
+	xconfig "github.com/m3db/m3x/config"
+	"github.com/m3db/m3x/instrument"
+	xlog "github.com/m3db/m3x/log"
+	"github.com/m3db/m3x/pool"
+	xsync "github.com/m3db/m3x/sync"
+)
+
+func main() {
+	var (
+		configArg     = flag.String("f", "", "Coordinator configuration file")
+		startArg      = flag.String("start", "", "Start of the range to backfill, RFC3339")
+		endArg        = flag.String("end", "", "End of the range to backfill, RFC3339, defaults to now")
+		metricArg     = flag.String("metric", ".+", "Regular expression selecting metric names to backfill")
+		chunkArg      = flag.Duration("chunk", time.Hour, "Range of raw data aggregated at a time, must be a multiple of every rule resolution")
+		checkpointArg = flag.String("checkpoint", "", "File to checkpoint progress to and resume from")
+		rateArg       = flag.Int("rate", 10000, "Maximum aggregated datapoints written per second, 0 is unlimited")
+	)
+	flag.Parse()
+
+	if *configArg == "" || *startArg == "" || *chunkArg <= 0 || *rateArg < 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log := xlog.NewLogger(os.Stderr)
+
+	start, err := time.Parse(time.RFC3339, *startArg)
+	if err != nil {
+		log.Fatalf("invalid start: %v", err)
+	}
+	end := time.Now()
+	if *endArg != "" {
+		end, err = time.Parse(time.RFC3339, *endArg)
+		if err != nil {
+			log.Fatalf("invalid end: %v", err)
+		}
+	}
+
+	var cfg config.Configuration
+	if err := xconfig.LoadFile(&cfg, *configArg, xconfig.Options{}); err != nil {
+		log.Fatalf("unable to load %s: %v", *configArg, err)
+	}
+
+	var etcdCfg *etcdclient.Configuration
+	switch {
+	case cfg.ClusterManagement != nil:
+		etcdCfg = &cfg.ClusterManagement.Etcd
+	case len(cfg.Clusters) == 1 &&
+		cfg.Clusters[0].Client.EnvironmentConfig.Service != nil:
+		etcdCfg = cfg.Clusters[0].Client.EnvironmentConfig.Service
+	default:
+		log.Fatalf("no cluster management config to read downsampling rules from")
+	}
+
+	clusterManagementClient, err := etcdclient.NewConfigServiceClient(etcdCfg.NewOptions())
+	if err != nil {
+		log.Fatalf("unable to create cluster management etcd client: %v", err)
+	}
+	kvStore, err := clusterManagementClient.KV()
+	if err != nil {
+		log.Fatalf("unable to create KV store: %v", err)
+	}
+
+	clusters, err := cfg.Clusters.NewClusters(local.ClustersStaticConfigurationOptions{})
+	if err != nil {
+		log.Fatalf("unable to connect to clusters: %v", err)
+	}
+	defer clusters.Close()
+
+	// Read only from the unaggregated namespace so raw data is never mixed
+	// with data from the aggregated namespaces being backfilled.
+	unaggregated := clusters.UnaggregatedClusterNamespace()
+	sourceClusters, err := local.NewClusters(local.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: unaggregated.NamespaceID(),
+		Session:     unaggregated.Session(),
+		Retention:   unaggregated.Attributes().Retention,
+	})
+	if err != nil {
+		log.Fatalf("unable to create source clusters: %v", err)
+	}
+
+	instrumentOpts := instrument.NewOptions().SetLogger(log)
+	workerPool := pool.NewObjectPool(pool.NewObjectPoolOptions().SetSize(1))
+	workerPool.Init(func() interface{} {
+		workerPool := xsync.NewWorkerPool(16)
+		workerPool.Init()
+		return workerPool
+	})
+
+	matcher, err := models.NewMatcher(models.MatchRegexp, "__name__", *metricArg)
+	if err != nil {
+		log.Fatalf("invalid metric regular expression: %v", err)
+	}
+
+	backfiller, err := downsample.NewBackfiller(downsample.DownsamplerOptions{
+		Storage:               local.NewStorage(clusters, workerPool),
+		RulesKVStore:          kvStore,
+		ClockOptions:          clock.NewOptions(),
+		InstrumentOptions:     instrumentOpts,
+		TagEncoderOptions:     serialize.NewTagEncoderOptions(),
+		TagDecoderOptions:     serialize.NewTagDecoderOptions(),
+		TagEncoderPoolOptions: pool.NewObjectPoolOptions(),
+		TagDecoderPoolOptions: pool.NewObjectPoolOptions(),
+	}, downsample.BackfillOptions{
+		Source:                 local.NewStorage(sourceClusters, workerPool),
+		Matchers:               models.Matchers{matcher},
+		Start:                  start,
+		End:                    end,
+		ChunkSize:              *chunkArg,
+		CheckpointPath:         *checkpointArg,
+		MaxDatapointsPerSecond: *rateArg,
+	})
+	if err != nil {
+		log.Fatalf("unable to create backfiller: %v", err)
+	}
+	defer backfiller.Close()
+
+	log.Infof("backfilling %v to %v", start, end)
+	result, err := backfiller.Run(context.Background())
+	if err != nil {
+		log.Fatalf("backfill failed after %d chunks: %v", result.Chunks, err)
+	}
+	log.Infof("backfilled %d chunks: series=%d, datapoints=%d",
+		result.Chunks, result.Series, result.Datapoints)
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"strconv"
 	"time"
 
 	m3emnode "github.com/m3db/m3/src/dbnode/x/m3em/node"
@@ -163,7 +165,7 @@ func (c *Configuration) Nodes(opts node.Options, numNodes int) ([]m3emnode.Node,
 }
 
 func (pi *PlacementInstance) operatorClientFn(agentPort int, tlsConfig *TLSConfiguration) (node.OperatorClientFn, error) {
-	agentEndpoint := fmt.Sprintf("%s:%d", pi.Hostname, agentPort)
+	agentEndpoint := net.JoinHostPort(pi.Hostname, strconv.Itoa(agentPort))
 
 	dialOpt := grpc.WithInsecure()
 	if tlsConfig != nil {
@@ -184,7 +186,7 @@ func (pi *PlacementInstance) operatorClientFn(agentPort int, tlsConfig *TLSConfi
 }
 
 func (pi *PlacementInstance) newServicesPlacementInstance(nodePort int) placement.Instance {
-	endpoint := fmt.Sprintf("%s:%d", pi.Hostname, nodePort)
+	endpoint := net.JoinHostPort(pi.Hostname, strconv.Itoa(nodePort))
 	return placement.NewInstance().
 		SetID(pi.ID).
 		SetIsolationGroup(pi.Rack).
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	_ "net/http/pprof" // _ is used for pprof
 	"os"
@@ -463,7 +464,7 @@ func (dt *DTestHarness) newHeartbeatRouter() node.HeartbeatRouter {
 		dt.logger.Fatalf("could not retrieve hostname: %v", err)
 	}
 
-	externalAddress := fmt.Sprintf("%s:%d", hostname, hbPort)
+	externalAddress := net.JoinHostPort(hostname, strconv.Itoa(hbPort))
 	hbRouter := node.NewHeartbeatRouter(externalAddress)
 	hbServer := xgrpc.NewServer(nil)
 	hb.RegisterHeartbeaterServer(hbServer, hbRouter)
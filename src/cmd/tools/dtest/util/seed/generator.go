@@ -162,3 +162,7 @@ func (f *fakeShardSet) Max() uint32 {
 func (f *fakeShardSet) HashFn() sharding.HashFn {
 	return nil
 }
+
+func (f *fakeShardSet) NumShards() int {
+	return 0
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/journal"
+	xlog "github.com/m3db/m3x/log"
+)
+
+func main() {
+	var (
+		journalArg     = flag.String("journal", "", "Query journal file to replay")
+		targetArg      = flag.String("target", "http://127.0.0.1:7201", "Coordinator base URL to replay queries against")
+		speedArg       = flag.Float64("speed", 1, "Factor to accelerate the original query pace by, 0 replays as fast as possible")
+		concurrencyArg = flag.Int("concurrency", 16, "Maximum number of queries in flight")
+	)
+	flag.Parse()
+
+	if *journalArg == "" ||
+		*targetArg == "" ||
+		*speedArg < 0 ||
+		*concurrencyArg <= 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log := xlog.NewLogger(os.Stderr)
+
+	fd, err := os.Open(*journalArg)
+	if err != nil {
+		log.Fatalf("could not open query journal: %v", err)
+	}
+	defer fd.Close()
+
+	log.Infof("replaying %s against %s at speed %v", *journalArg, *targetArg, *speedArg)
+	result, err := journal.Replay(journal.NewReader(fd), journal.ReplayOptions{
+		TargetURL:   *targetArg,
+		Speed:       *speedArg,
+		Concurrency: *concurrencyArg,
+	})
+	if err != nil {
+		log.Fatalf("could not replay query journal: %v", err)
+	}
+
+	log.Infof("replayed %d queries in %s, skipped %d without recorded body",
+		result.Queries, result.Elapsed.String(), result.Skipped)
+	log.Infof("errors: %d, status mismatches: %d",
+		result.Errors, result.StatusMismatches)
+	if n := result.Queries - result.Errors; n > 0 {
+		log.Infof("mean duration: original %s, replay %s",
+			(result.OriginalDuration / time.Duration(result.Queries)).String(),
+			(result.ReplayDuration / time.Duration(n)).String())
+	}
+	log.Infof("max duration: original %s, replay %s",
+		result.MaxOriginalDuration.String(), result.MaxReplayDuration.String())
+}
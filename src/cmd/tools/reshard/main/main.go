@@ -0,0 +1,252 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3cluster/shard"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/ident/testutil"
+	xlog "github.com/m3db/m3x/log"
+	xtime "github.com/m3db/m3x/time"
+)
+
+var (
+	optSrcPathPrefix  = flag.String("src-path-prefix", "/var/lib/m3db", "Source Path prefix")
+	optNamespace      = flag.String("namespace", "metrics", "Namespace")
+	optBlockstart     = flag.Int64("block-start", 0, "Block Start Time [in nsec]")
+	optBlockSize      = flag.Duration("block-size", 0, "Block Size")
+	optDestPathPrefix = flag.String("dest-path-prefix", "/tmp/m3db-reshard", "Destination Path prefix")
+	optDestNumShards  = flag.Int("dest-num-shards", 0, "Destination number of shards")
+	optHashStrategy   = flag.String("hash-strategy", "murmur3", "Destination hash strategy [murmur3, jump, tag_subset]")
+	optTagNames       = flag.String("tag-names", "", "Comma separated tag names hashed by the tag_subset strategy")
+	optDryRun         = flag.Bool("dry-run", false, "Report the destination shard of each series without writing data")
+)
+
+func main() {
+	flag.Parse()
+	if *optSrcPathPrefix == "" ||
+		*optDestPathPrefix == "" ||
+		*optNamespace == "" ||
+		*optBlockstart <= 0 ||
+		*optBlockSize <= 0 ||
+		*optDestNumShards <= 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log := xlog.NewLogger(os.Stderr)
+
+	strategy, err := sharding.ParseHashStrategy(*optHashStrategy)
+	if err != nil {
+		log.Fatalf("invalid hash strategy: %v", err)
+	}
+	var tagNames []string
+	if *optTagNames != "" {
+		tagNames = strings.Split(*optTagNames, ",")
+	}
+
+	shardFn, err := newDestShardFn(*optDestNumShards, strategy, tagNames)
+	if err != nil {
+		log.Fatalf("unable to create destination hash function: %v", err)
+	}
+
+	namespace := ident.StringID(*optNamespace)
+	blockStart := xtime.FromNanoseconds(*optBlockstart)
+	srcShards, err := sourceShards(*optSrcPathPrefix, namespace)
+	if err != nil {
+		log.Fatalf("unable to list source shards: %v", err)
+	}
+
+	fsOpts := fs.NewOptions()
+	writers := make(map[uint32]fs.DataFileSetWriter)
+	counts := make(map[uint32]int)
+	moved := 0
+
+	for _, srcShard := range srcShards {
+		exists, err := fs.DataFileSetExistsAt(*optSrcPathPrefix, namespace, srcShard, blockStart)
+		if err != nil {
+			log.Fatalf("unable to check fileset for shard %d: %v", srcShard, err)
+		}
+		if !exists {
+			continue
+		}
+
+		reader, err := fs.NewReader(nil, fsOpts.SetFilePathPrefix(*optSrcPathPrefix))
+		if err != nil {
+			log.Fatalf("unable to create fileset reader: %v", err)
+		}
+		openOpts := fs.DataReaderOpenOptions{
+			Identifier: fs.FileSetFileIdentifier{
+				Namespace:  namespace,
+				Shard:      srcShard,
+				BlockStart: blockStart,
+			},
+			FileSetType: persist.FileSetFlushType,
+		}
+		if err := reader.Open(openOpts); err != nil {
+			log.Fatalf("unable to read source fileset for shard %d: %v", srcShard, err)
+		}
+
+		for {
+			id, tagsIter, data, checksum, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Fatalf("unexpected error while reading shard %d: %v", srcShard, err)
+			}
+
+			destShard := shardFn(id)
+			counts[destShard]++
+			if destShard != srcShard {
+				moved++
+			}
+
+			if *optDryRun {
+				tagsIter.Close()
+				data.IncRef()
+				data.DecRef()
+				data.Finalize()
+				continue
+			}
+
+			tags, err := testutil.NewTagsFromTagIterator(tagsIter)
+			if err != nil {
+				log.Fatalf("unable to read tags: %v", err)
+			}
+
+			writer, ok := writers[destShard]
+			if !ok {
+				writer, err = newDestWriter(fsOpts, namespace, destShard, blockStart)
+				if err != nil {
+					log.Fatalf("unable to open writer for shard %d: %v", destShard, err)
+				}
+				writers[destShard] = writer
+			}
+
+			data.IncRef()
+			if err := writer.Write(id, tags, data, checksum); err != nil {
+				log.Fatalf("unexpected error while writing data: %v", err)
+			}
+			data.DecRef()
+			data.Finalize()
+		}
+
+		if err := reader.Close(); err != nil {
+			log.Fatalf("unable to finalize reader: %v", err)
+		}
+	}
+
+	for shard, writer := range writers {
+		if err := writer.Close(); err != nil {
+			log.Fatalf("unable to finalize writer for shard %d: %v", shard, err)
+		}
+	}
+
+	destShards := make([]int, 0, len(counts))
+	for shard := range counts {
+		destShards = append(destShards, int(shard))
+	}
+	sort.Ints(destShards)
+	for _, shard := range destShards {
+		fmt.Printf("shard %d: %d series\n", shard, counts[uint32(shard)])
+	}
+	log.Infof("%d series change shard under the %s strategy", moved, strategy.String())
+	if !*optDryRun {
+		log.Infof("successfully resharded data to %s", *optDestPathPrefix)
+	}
+}
+
+func newDestShardFn(
+	numShards int,
+	strategy sharding.HashStrategy,
+	tagNames []string,
+) (sharding.HashFn, error) {
+	ids := make([]uint32, 0, numShards)
+	for i := 0; i < numShards; i++ {
+		ids = append(ids, uint32(i))
+	}
+	shardSet, err := sharding.NewShardSetWithNumShards(
+		sharding.NewShards(ids, shard.Available),
+		sharding.DefaultHashFn(numShards),
+		numShards,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sharding.NewStrategyHashFn(shardSet, strategy, tagNames)
+}
+
+func newDestWriter(
+	fsOpts fs.Options,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (fs.DataFileSetWriter, error) {
+	writer, err := fs.NewWriter(fsOpts.SetFilePathPrefix(*optDestPathPrefix))
+	if err != nil {
+		return nil, err
+	}
+	writerOpts := fs.DataWriterOpenOptions{
+		BlockSize: *optBlockSize,
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  namespace,
+			Shard:      shard,
+			BlockStart: blockStart,
+		},
+	}
+	if err := writer.Open(writerOpts); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func sourceShards(pathPrefix string, namespace ident.ID) ([]uint32, error) {
+	entries, err := ioutil.ReadDir(fs.NamespaceDataDirPath(pathPrefix, namespace))
+	if err != nil {
+		return nil, err
+	}
+	var shards []uint32
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		shard, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		shards = append(shards, uint32(shard))
+	}
+	return shards, nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/backup"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/gogo/protobuf/jsonpb"
+)
+
+var (
+	optManifestDir = flag.String("manifest-dir", "", "Directory of backup manifest JSON files")
+	optBackupDir   = flag.String("backup-dir", "", "Directory of backed up files, one subdirectory per host ID")
+	optPlacement   = flag.String("placement", "", "Placement JSON file of the cluster being restored to")
+	optHostID      = flag.String("host-id", "", "Host ID to restore")
+	optPathPrefix  = flag.String("path-prefix", "", "Path prefix to restore to [e.g. /var/lib/m3db]")
+	optPointInTime = flag.String("point-in-time", "", "Point in time to restore to [RFC3339, defaults to latest backup]")
+	optNamespaces  = flag.String("namespaces", "", "Comma separated namespaces to restore [defaults to all]")
+)
+
+func main() {
+	flag.Parse()
+	if *optManifestDir == "" ||
+		*optBackupDir == "" ||
+		*optPlacement == "" ||
+		*optHostID == "" ||
+		*optPathPrefix == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log := xlog.NewLogger(os.Stderr)
+
+	pointInTime := time.Now()
+	if *optPointInTime != "" {
+		t, err := time.Parse(time.RFC3339, *optPointInTime)
+		if err != nil {
+			log.Fatalf("invalid point in time: %v", err)
+		}
+		pointInTime = t
+	}
+
+	var namespaces []string
+	if *optNamespaces != "" {
+		namespaces = strings.Split(*optNamespaces, ",")
+	}
+
+	manifests, err := readManifests(*optManifestDir)
+	if err != nil {
+		log.Fatalf("unable to read manifests: %v", err)
+	}
+	manifest, err := backup.SelectManifest(manifests, pointInTime)
+	if err != nil {
+		log.Fatalf("unable to select manifest: %v", err)
+	}
+	log.Infof("restoring backup %s with cut time %v", manifest.ID, manifest.CutTime)
+
+	placement, err := readPlacement(*optPlacement)
+	if err != nil {
+		log.Fatalf("unable to read placement: %v", err)
+	}
+	if _, ok := placement[*optHostID]; !ok {
+		log.Fatalf("host %s is not in the placement", *optHostID)
+	}
+
+	opts := backup.RestoreOptions{
+		Namespaces: namespaces,
+		Placement:  placement,
+	}
+	if *optPointInTime != "" {
+		opts.PointInTime = pointInTime
+	}
+	plan, err := backup.PlanRestore(manifest, opts)
+	if err != nil {
+		log.Fatalf("unable to plan restore: %v", err)
+	}
+
+	var (
+		store  = backup.NewDirectoryObjectStore(*optBackupDir)
+		fsOpts = fs.NewOptions().SetFilePathPrefix(*optPathPrefix)
+	)
+	if err := backup.RestoreHost(plan, *optHostID, store, fsOpts); err != nil {
+		log.Fatalf("unable to restore: %v", err)
+	}
+
+	log.Infof("successfully restored %d filesets", len(plan[*optHostID]))
+}
+
+func readManifests(dir string) ([]backup.Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	manifests := make([]backup.Manifest, 0, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var manifest backup.Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// readPlacement reads the placement as returned by the coordinator placement
+// get API and returns the shards owned by each host.
+func readPlacement(path string) (map[string][]uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var resp admin.PlacementGetResponse
+	if err := jsonpb.Unmarshal(f, &resp); err != nil {
+		return nil, err
+	}
+
+	placement := make(map[string][]uint32)
+	if resp.Placement == nil {
+		return placement, nil
+	}
+	for id, instance := range resp.Placement.Instances {
+		shards := make([]uint32, 0, len(instance.Shards))
+		for _, shard := range instance.Shards {
+			shards = append(shards, shard.Id)
+		}
+		placement[id] = shards
+	}
+	return placement, nil
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package backup coordinates consistent snapshots across all the nodes of a
+// cluster through a KV barrier.
+//
+// A backup is started by writing a Request to RequestKey, the request names
+// the hosts taking part and a cut time shortly in the future. Each node
+// watches RequestKey, waits until the cut time, snapshots all of its owned
+// namespaces and reports the fileset files that make up its part of the
+// backup under NodeResultKey. Once every host has reported, the results are
+// collected into a Manifest describing the point in time cut, which is
+// persisted under ManifestKey so that it can be used for a restore.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+)
+
+const (
+	// RequestKey is the KV key holding the most recent backup request.
+	RequestKey = "m3db.backup.request"
+
+	keyPrefix = "m3db.backup."
+)
+
+var (
+	// ErrBackupInProgress is returned when starting a backup while another
+	// backup has neither completed nor expired.
+	ErrBackupInProgress = errors.New("another backup is in progress")
+
+	// ErrBackupNotFound is returned when a backup does not exist.
+	ErrBackupNotFound = errors.New("backup not found")
+)
+
+// NodeResultKey returns the KV key a host reports its backup result under.
+func NodeResultKey(id, hostID string) string {
+	return keyPrefix + id + ".node." + hostID
+}
+
+// ManifestKey returns the KV key the manifest of a finished backup is
+// persisted under.
+func ManifestKey(id string) string {
+	return keyPrefix + id + ".manifest"
+}
+
+// Request is a cluster wide backup request.
+type Request struct {
+	// ID uniquely identifies the backup.
+	ID string `json:"id"`
+
+	// CutTime is the time at which all hosts snapshot, every write
+	// acknowledged before the cut time is contained in the backup.
+	CutTime time.Time `json:"cutTime"`
+
+	// Expires is the time after which hosts that have not yet snapshotted
+	// no longer take part in the backup.
+	Expires time.Time `json:"expires"`
+
+	// HostIDs are the hosts taking part in the backup.
+	HostIDs []string `json:"hostIDs"`
+
+	// Namespaces restricts the backup to the given namespaces, all owned
+	// namespaces are backed up if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// IncludesHost returns whether the host takes part in the backup.
+func (r Request) IncludesHost(hostID string) bool {
+	for _, id := range r.HostIDs {
+		if id == hostID {
+			return true
+		}
+	}
+	return false
+}
+
+// IncludesNamespace returns whether the namespace is part of the backup.
+func (r Request) IncludesNamespace(namespace string) bool {
+	if len(r.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range r.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// FileSetType is the type of a fileset in a backup.
+type FileSetType string
+
+const (
+	// DataFileSetType is a flushed data fileset.
+	DataFileSetType FileSetType = "data"
+
+	// SnapshotFileSetType is a data snapshot fileset of a block that has not
+	// been flushed yet.
+	SnapshotFileSetType FileSetType = "snapshot"
+
+	// IndexFileSetType is a flushed index fileset.
+	IndexFileSetType FileSetType = "index"
+)
+
+// FileSet is a fileset that is part of a backup.
+type FileSet struct {
+	Type        FileSetType `json:"type"`
+	Namespace   string      `json:"namespace"`
+	Shard       uint32      `json:"shard"`
+	BlockStart  time.Time   `json:"blockStart"`
+	VolumeIndex int         `json:"volumeIndex"`
+
+	// Files are the paths of the fileset files relative to the file path
+	// prefix of the host.
+	Files []string `json:"files"`
+}
+
+// NodeResult is the result of a backup on a single host.
+type NodeResult struct {
+	ID           string    `json:"id"`
+	HostID       string    `json:"hostID"`
+	SnapshotTime time.Time `json:"snapshotTime"`
+	FileSets     []FileSet `json:"fileSets,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Status is the status of a backup.
+type Status string
+
+const (
+	// PendingStatus is the status of a backup some hosts have not yet
+	// reported for.
+	PendingStatus Status = "pending"
+
+	// CompleteStatus is the status of a backup all hosts have successfully
+	// reported for.
+	CompleteStatus Status = "complete"
+
+	// FailedStatus is the status of a backup a host failed to snapshot for.
+	FailedStatus Status = "failed"
+
+	// ExpiredStatus is the status of a backup that expired before all hosts
+	// reported for it.
+	ExpiredStatus Status = "expired"
+)
+
+// Manifest describes the point in time cut of a backup across all hosts.
+type Manifest struct {
+	ID         string       `json:"id"`
+	CutTime    time.Time    `json:"cutTime"`
+	Namespaces []string     `json:"namespaces,omitempty"`
+	Status     Status       `json:"status"`
+	Pending    []string     `json:"pending,omitempty"`
+	Nodes      []NodeResult `json:"nodes"`
+}
+
+// Values are stored in KV as JSON wrapped in a string proto so that they
+// remain readable with the existing KV tooling.
+func marshalValue(v interface{}) (*commonpb.StringProto, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &commonpb.StringProto{Value: string(data)}, nil
+}
+
+func setValue(store kv.Store, key string, v interface{}) error {
+	proto, err := marshalValue(v)
+	if err != nil {
+		return err
+	}
+	_, err = store.Set(key, proto)
+	return err
+}
+
+func unmarshalValue(value kv.Value, v interface{}) error {
+	var proto commonpb.StringProto
+	if err := value.Unmarshal(&proto); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(proto.Value), v); err != nil {
+		return fmt.Errorf("unable to parse backup value: %v", err)
+	}
+	return nil
+}
+
+// getValue reads the value of a key, returning false if it is not set.
+func getValue(store kv.Store, key string, v interface{}) (bool, error) {
+	value, err := store.Get(key)
+	if err == kv.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := unmarshalValue(value, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3cluster/kv"
+)
+
+// Coordinator starts cluster wide backups and collects their manifests.
+type Coordinator struct {
+	store kv.Store
+	nowFn clock.NowFn
+}
+
+// NewCoordinator returns a new backup coordinator.
+func NewCoordinator(store kv.Store, nowFn clock.NowFn) *Coordinator {
+	return &Coordinator{store: store, nowFn: nowFn}
+}
+
+// Start starts a backup of the given hosts, the hosts snapshot once the cut
+// delay has passed and stop taking part in the backup once the timeout has
+// passed after the cut time. Start fails with ErrBackupInProgress if the
+// previous backup is still pending.
+func (c *Coordinator) Start(
+	hostIDs []string,
+	namespaces []string,
+	cutDelay time.Duration,
+	timeout time.Duration,
+) (Request, error) {
+	value, err := c.store.Get(RequestKey)
+	if err != nil && err != kv.ErrNotFound {
+		return Request{}, err
+	}
+
+	version := 0
+	if err == nil {
+		var current Request
+		if err := unmarshalValue(value, &current); err != nil {
+			return Request{}, err
+		}
+		manifest, err := c.Manifest(current.ID)
+		if err != nil {
+			return Request{}, err
+		}
+		if manifest.Status == PendingStatus {
+			return Request{}, ErrBackupInProgress
+		}
+		version = value.Version()
+	}
+
+	var (
+		now     = c.nowFn()
+		cutTime = now.Add(cutDelay)
+		req     = Request{
+			ID:         strconv.FormatInt(now.UnixNano(), 10),
+			CutTime:    cutTime,
+			Expires:    cutTime.Add(timeout),
+			HostIDs:    hostIDs,
+			Namespaces: namespaces,
+		}
+	)
+	proto, err := marshalValue(req)
+	if err != nil {
+		return Request{}, err
+	}
+
+	// The request is written with a check and set against the version read
+	// above so that concurrent starts cannot both succeed.
+	if version == 0 {
+		_, err = c.store.SetIfNotExists(RequestKey, proto)
+	} else {
+		_, err = c.store.CheckAndSet(RequestKey, version, proto)
+	}
+	if err == kv.ErrAlreadyExists || err == kv.ErrVersionMismatch {
+		return Request{}, ErrBackupInProgress
+	}
+	if err != nil {
+		return Request{}, err
+	}
+	return req, nil
+}
+
+// Manifest returns the manifest of a backup. The manifest of a backup that
+// is no longer pending is persisted so it remains available after further
+// backups are started.
+func (c *Coordinator) Manifest(id string) (Manifest, error) {
+	var manifest Manifest
+	found, err := getValue(c.store, ManifestKey(id), &manifest)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if found {
+		return manifest, nil
+	}
+
+	var req Request
+	found, err = getValue(c.store, RequestKey, &req)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if !found || req.ID != id {
+		return Manifest{}, ErrBackupNotFound
+	}
+
+	manifest = Manifest{
+		ID:         req.ID,
+		CutTime:    req.CutTime,
+		Namespaces: req.Namespaces,
+		Status:     PendingStatus,
+		Nodes:      make([]NodeResult, 0, len(req.HostIDs)),
+	}
+	failed := false
+	for _, hostID := range req.HostIDs {
+		var result NodeResult
+		found, err := getValue(c.store, NodeResultKey(id, hostID), &result)
+		if err != nil {
+			return Manifest{}, err
+		}
+		if !found {
+			manifest.Pending = append(manifest.Pending, hostID)
+			continue
+		}
+		if result.Error != "" {
+			failed = true
+		}
+		manifest.Nodes = append(manifest.Nodes, result)
+	}
+
+	switch {
+	case failed:
+		manifest.Status = FailedStatus
+	case len(manifest.Pending) == 0:
+		manifest.Status = CompleteStatus
+	case c.nowFn().After(req.Expires):
+		manifest.Status = ExpiredStatus
+	default:
+		return manifest, nil
+	}
+
+	if err := setValue(c.store, ManifestKey(id), manifest); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
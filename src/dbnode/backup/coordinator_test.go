@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3cluster/kv/mem"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinatorStartAndManifest(t *testing.T) {
+	var (
+		store       = mem.NewStore()
+		now         = time.Unix(1000, 0)
+		coordinator = NewCoordinator(store, func() time.Time { return now })
+	)
+
+	req, err := coordinator.Start([]string{"a", "b"}, []string{"metrics"},
+		time.Second, time.Minute)
+	require.NoError(t, err)
+	require.True(t, now.Add(time.Second).Equal(req.CutTime))
+	require.True(t, now.Add(time.Second+time.Minute).Equal(req.Expires))
+
+	_, err = coordinator.Start([]string{"a", "b"}, nil, time.Second, time.Minute)
+	require.Equal(t, ErrBackupInProgress, err)
+
+	manifest, err := coordinator.Manifest(req.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingStatus, manifest.Status)
+	require.Equal(t, []string{"a", "b"}, manifest.Pending)
+
+	for _, hostID := range req.HostIDs {
+		require.NoError(t, setValue(store, NodeResultKey(req.ID, hostID), NodeResult{
+			ID:     req.ID,
+			HostID: hostID,
+		}))
+	}
+
+	manifest, err = coordinator.Manifest(req.ID)
+	require.NoError(t, err)
+	require.Equal(t, CompleteStatus, manifest.Status)
+	require.Empty(t, manifest.Pending)
+	require.Len(t, manifest.Nodes, 2)
+	require.Equal(t, []string{"metrics"}, manifest.Namespaces)
+
+	// The completed manifest remains available once the next backup starts.
+	now = now.Add(time.Hour)
+	next, err := coordinator.Start([]string{"a"}, nil, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotEqual(t, req.ID, next.ID)
+
+	manifest, err = coordinator.Manifest(req.ID)
+	require.NoError(t, err)
+	require.Equal(t, CompleteStatus, manifest.Status)
+
+	_, err = coordinator.Manifest("unknown")
+	require.Equal(t, ErrBackupNotFound, err)
+}
+
+func TestCoordinatorManifestFailedAndExpired(t *testing.T) {
+	var (
+		store       = mem.NewStore()
+		now         = time.Unix(1000, 0)
+		coordinator = NewCoordinator(store, func() time.Time { return now })
+	)
+
+	req, err := coordinator.Start([]string{"a", "b"}, nil, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, setValue(store, NodeResultKey(req.ID, "a"), NodeResult{
+		ID:     req.ID,
+		HostID: "a",
+		Error:  "snapshot failed",
+	}))
+
+	manifest, err := coordinator.Manifest(req.ID)
+	require.NoError(t, err)
+	require.Equal(t, FailedStatus, manifest.Status)
+	require.Equal(t, []string{"b"}, manifest.Pending)
+
+	now = now.Add(time.Hour)
+	req, err = coordinator.Start([]string{"a", "b"}, nil, time.Second, time.Minute)
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	manifest, err = coordinator.Manifest(req.ID)
+	require.NoError(t, err)
+	require.Equal(t, ExpiredStatus, manifest.Status)
+}
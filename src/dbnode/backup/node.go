@@ -0,0 +1,244 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3cluster/kv"
+	xlog "github.com/m3db/m3x/log"
+)
+
+// Node takes part in cluster wide backups on a single host, it snapshots the
+// database at the cut time of each backup request that includes the host
+// and reports the fileset files that make up its part of the backup.
+type Node struct {
+	sync.Mutex
+
+	store          kv.Store
+	db             storage.Database
+	hostID         string
+	filePathPrefix string
+	log            xlog.Logger
+	nowFn          clock.NowFn
+	sleepFn        func(time.Duration)
+	lastID         string
+}
+
+// NewNode returns a new backup node.
+func NewNode(
+	store kv.Store,
+	db storage.Database,
+	hostID string,
+	filePathPrefix string,
+	log xlog.Logger,
+) *Node {
+	return &Node{
+		store:          store,
+		db:             db,
+		hostID:         hostID,
+		filePathPrefix: filePathPrefix,
+		log:            log,
+		nowFn:          time.Now,
+		sleepFn:        time.Sleep,
+	}
+}
+
+// Start watches for backup requests.
+func (n *Node) Start() error {
+	watch, err := n.store.Watch(RequestKey)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for range watch.C() {
+			value := watch.Get()
+			if value == nil {
+				continue
+			}
+			var req Request
+			if err := unmarshalValue(value, &req); err != nil {
+				n.log.Warnf("could not process backup request: %v", err)
+				continue
+			}
+			if err := n.Backup(req); err != nil {
+				n.log.Errorf("could not report backup %s: %v", req.ID, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Backup snapshots the database at the cut time of the request and reports
+// the result. Requests that do not include the host, have expired or have
+// already been reported are ignored.
+func (n *Node) Backup(req Request) error {
+	n.Lock()
+	defer n.Unlock()
+
+	if req.ID == n.lastID || !req.IncludesHost(n.hostID) {
+		return nil
+	}
+	n.lastID = req.ID
+
+	if n.nowFn().After(req.Expires) {
+		n.log.Warnf("ignoring expired backup %s", req.ID)
+		return nil
+	}
+
+	// Another process on this host may have already reported the backup
+	// before a restart.
+	var existing NodeResult
+	found, err := getValue(n.store, NodeResultKey(req.ID, n.hostID), &existing)
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+
+	if wait := req.CutTime.Sub(n.nowFn()); wait > 0 {
+		n.sleepFn(wait)
+	}
+
+	result := NodeResult{
+		ID:           req.ID,
+		HostID:       n.hostID,
+		SnapshotTime: n.nowFn(),
+	}
+	fileSets, err := n.snapshot(req)
+	if err != nil {
+		result.Error = err.Error()
+		n.log.Errorf("backup %s failed: %v", req.ID, err)
+	} else {
+		result.FileSets = fileSets
+		n.log.Infof("backup %s snapshotted %d filesets", req.ID, len(fileSets))
+	}
+
+	return setValue(n.store, NodeResultKey(req.ID, n.hostID), result)
+}
+
+func (n *Node) snapshot(req Request) ([]FileSet, error) {
+	if err := n.db.Snapshot(); err != nil {
+		return nil, fmt.Errorf("unable to snapshot: %v", err)
+	}
+
+	var result []FileSet
+	for _, ns := range n.db.Namespaces() {
+		nsID := ns.ID()
+		if !req.IncludesNamespace(nsID.String()) {
+			continue
+		}
+
+		for _, shard := range ns.Shards() {
+			dataFiles, err := fs.DataFiles(n.filePathPrefix, nsID, shard.ID())
+			if err != nil {
+				return nil, err
+			}
+
+			flushed := make(map[int64]struct{}, len(dataFiles))
+			for _, f := range dataFiles {
+				if !f.HasCheckpointFile() {
+					continue
+				}
+				flushed[f.ID.BlockStart.UnixNano()] = struct{}{}
+				fileSet, err := n.fileSet(DataFileSetType, f)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, fileSet)
+			}
+
+			// Unflushed blocks are covered by the latest complete snapshot.
+			snapshotFiles, err := fs.SnapshotFiles(n.filePathPrefix, nsID, shard.ID())
+			if err != nil {
+				return nil, err
+			}
+			seen := make(map[int64]struct{}, len(snapshotFiles))
+			for _, f := range snapshotFiles {
+				blockStart := f.ID.BlockStart
+				if _, ok := flushed[blockStart.UnixNano()]; ok {
+					continue
+				}
+				if _, ok := seen[blockStart.UnixNano()]; ok {
+					continue
+				}
+				seen[blockStart.UnixNano()] = struct{}{}
+
+				latest, ok := snapshotFiles.LatestVolumeForBlock(blockStart)
+				if !ok {
+					continue
+				}
+				fileSet, err := n.fileSet(SnapshotFileSetType, latest)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, fileSet)
+			}
+		}
+
+		if !ns.Options().IndexOptions().Enabled() {
+			continue
+		}
+		indexFiles, err := fs.IndexFiles(n.filePathPrefix, nsID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range indexFiles {
+			if !f.HasCheckpointFile() {
+				continue
+			}
+			fileSet, err := n.fileSet(IndexFileSetType, f)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fileSet)
+		}
+	}
+
+	return result, nil
+}
+
+func (n *Node) fileSet(fileSetType FileSetType, f fs.FileSetFile) (FileSet, error) {
+	files := make([]string, 0, len(f.AbsoluteFilepaths))
+	for _, path := range f.AbsoluteFilepaths {
+		rel, err := filepath.Rel(n.filePathPrefix, path)
+		if err != nil {
+			return FileSet{}, err
+		}
+		files = append(files, rel)
+	}
+	return FileSet{
+		Type:        fileSetType,
+		Namespace:   f.ID.Namespace.String(),
+		Shard:       f.ID.Shard,
+		BlockStart:  f.ID.BlockStart,
+		VolumeIndex: f.ID.VolumeIndex,
+		Files:       files,
+	}, nil
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3cluster/kv/mem"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func createFileSetFiles(t *testing.T, dir string, name string, suffixes ...string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for _, suffix := range suffixes {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.db", name, suffix))
+		require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	}
+}
+
+func TestNodeBackup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		nsID         = ident.StringID("metrics")
+		flushedStart = time.Unix(0, 0)
+		pendingStart = time.Unix(7200, 0)
+		dataDir      = fs.ShardDataDirPath(dir, nsID, 0)
+		snapshotDir  = fs.ShardSnapshotsDirPath(dir, nsID, 0)
+	)
+	createFileSetFiles(t, dataDir,
+		fmt.Sprintf("fileset-%d", flushedStart.UnixNano()), "info", "checkpoint")
+	// Snapshots of flushed blocks and incomplete snapshots are not backed up.
+	createFileSetFiles(t, snapshotDir,
+		fmt.Sprintf("fileset-%d-0", flushedStart.UnixNano()), "info", "checkpoint")
+	createFileSetFiles(t, snapshotDir,
+		fmt.Sprintf("fileset-%d-0", pendingStart.UnixNano()), "info", "checkpoint")
+	createFileSetFiles(t, snapshotDir,
+		fmt.Sprintf("fileset-%d-1", pendingStart.UnixNano()), "info")
+
+	shard := storage.NewMockShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	ns := storage.NewMockNamespace(ctrl)
+	ns.EXPECT().ID().Return(nsID).AnyTimes()
+	ns.EXPECT().Shards().Return([]storage.Shard{shard}).AnyTimes()
+	ns.EXPECT().Options().Return(namespace.NewOptions()).AnyTimes()
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Snapshot().Return(nil)
+	db.EXPECT().Namespaces().Return([]storage.Namespace{ns})
+
+	var (
+		store = mem.NewStore()
+		now   = time.Unix(1000, 0)
+		slept time.Duration
+		node  = NewNode(store, db, "a", dir, xlog.NullLogger)
+	)
+	node.nowFn = func() time.Time { return now }
+	node.sleepFn = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+
+	req := Request{
+		ID:      "1",
+		CutTime: now.Add(time.Second),
+		Expires: now.Add(time.Minute),
+		HostIDs: []string{"a", "b"},
+	}
+	require.NoError(t, node.Backup(req))
+	require.Equal(t, time.Second, slept)
+
+	// Handling the same request again is a no-op.
+	require.NoError(t, node.Backup(req))
+
+	var result NodeResult
+	found, err := getValue(store, NodeResultKey("1", "a"), &result)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Empty(t, result.Error)
+	require.True(t, req.CutTime.Equal(result.SnapshotTime))
+	require.Equal(t, 2, len(result.FileSets))
+
+	require.Equal(t, DataFileSetType, result.FileSets[0].Type)
+	require.Equal(t, "metrics", result.FileSets[0].Namespace)
+	require.True(t, flushedStart.Equal(result.FileSets[0].BlockStart))
+	for _, f := range result.FileSets[0].Files {
+		require.False(t, filepath.IsAbs(f))
+		require.Equal(t, filepath.Join("data", "metrics", "0"), filepath.Dir(f))
+	}
+
+	require.Equal(t, SnapshotFileSetType, result.FileSets[1].Type)
+	require.True(t, pendingStart.Equal(result.FileSets[1].BlockStart))
+	require.Equal(t, 0, result.FileSets[1].VolumeIndex)
+}
+
+func TestNodeBackupIgnoresOtherHostsAndExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		store = mem.NewStore()
+		db    = storage.NewMockDatabase(ctrl)
+		now   = time.Unix(1000, 0)
+		node  = NewNode(store, db, "a", "/var/lib/m3db", xlog.NullLogger)
+	)
+	node.nowFn = func() time.Time { return now }
+
+	require.NoError(t, node.Backup(Request{
+		ID:      "1",
+		CutTime: now,
+		Expires: now.Add(time.Minute),
+		HostIDs: []string{"b"},
+	}))
+	require.NoError(t, node.Backup(Request{
+		ID:      "2",
+		CutTime: now.Add(-time.Hour),
+		Expires: now.Add(-time.Minute),
+		HostIDs: []string{"a"},
+	}))
+
+	for _, id := range []string{"1", "2"} {
+		var result NodeResult
+		found, err := getValue(store, NodeResultKey(id, "a"), &result)
+		require.NoError(t, err)
+		require.False(t, found)
+	}
+}
+
+func TestNodeBackupReportsSnapshotError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		store = mem.NewStore()
+		db    = storage.NewMockDatabase(ctrl)
+		now   = time.Unix(1000, 0)
+		node  = NewNode(store, db, "a", "/var/lib/m3db", xlog.NullLogger)
+	)
+	node.nowFn = func() time.Time { return now }
+	db.EXPECT().Snapshot().Return(fmt.Errorf("not bootstrapped"))
+
+	require.NoError(t, node.Backup(Request{
+		ID:      "1",
+		CutTime: now,
+		Expires: now.Add(time.Minute),
+		HostIDs: []string{"a"},
+	}))
+
+	var result NodeResult
+	found, err := getValue(store, NodeResultKey("1", "a"), &result)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Contains(t, result.Error, "not bootstrapped")
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+)
+
+const checkpointFileSuffix = "-checkpoint.db"
+
+var (
+	errNoManifest = errors.New(
+		"no complete backup manifest with a cut time at or before the point in time")
+)
+
+// ObjectStore is a read only store of backed up fileset files.
+type ObjectStore interface {
+	// Open opens a backed up file of a host for reading, the path is
+	// relative to the file path prefix of the host as listed in the manifest.
+	Open(hostID, path string) (io.ReadCloser, error)
+}
+
+type directoryObjectStore struct {
+	root string
+}
+
+// NewDirectoryObjectStore returns an object store for backups that have been
+// copied to a directory, with the files of each host in a subdirectory named
+// after the host ID.
+func NewDirectoryObjectStore(root string) ObjectStore {
+	return directoryObjectStore{root: root}
+}
+
+func (s directoryObjectStore) Open(hostID, path string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.root, hostID, path))
+}
+
+// SelectManifest returns the latest complete manifest with a cut time at or
+// before the point in time.
+func SelectManifest(manifests []Manifest, pointInTime time.Time) (Manifest, error) {
+	var (
+		selected Manifest
+		found    bool
+	)
+	for _, m := range manifests {
+		if m.Status != CompleteStatus || m.CutTime.After(pointInTime) {
+			continue
+		}
+		if !found || m.CutTime.After(selected.CutTime) {
+			selected = m
+			found = true
+		}
+	}
+	if !found {
+		return Manifest{}, errNoManifest
+	}
+	return selected, nil
+}
+
+// RestoreOptions are the options for planning a restore.
+type RestoreOptions struct {
+	// PointInTime excludes the filesets of blocks starting after it, the cut
+	// time of the manifest is used if zero.
+	PointInTime time.Time
+
+	// Namespaces restricts the restore to the given namespaces, all backed
+	// up namespaces are restored if empty.
+	Namespaces []string
+
+	// Placement is the shards owned by each host of the cluster being
+	// restored to, keyed by host ID.
+	Placement map[string][]uint32
+}
+
+// RestoreFileSet is a backed up fileset and the host it was backed up from.
+type RestoreFileSet struct {
+	SourceHostID string
+	FileSet
+}
+
+// RestorePlan is the filesets each host restores, keyed by host ID.
+type RestorePlan map[string][]RestoreFileSet
+
+type shardBlockKey struct {
+	namespace  string
+	shard      uint32
+	blockStart int64
+}
+
+// PlanRestore plans restoring a backup to a cluster. One replica of each
+// backed up block is restored to every host that owns its shard in the target
+// placement, so the target placement may differ from the one backed up as
+// long as it has the same number of shards. Flushed filesets are preferred
+// over snapshots of the same block.
+//
+// Index filesets cover all the shards of the host that flushed them, they are
+// only restored to hosts that own exactly the same shards as a backed up
+// host and are otherwise rebuilt from the data filesets on bootstrap.
+func PlanRestore(manifest Manifest, opts RestoreOptions) (RestorePlan, error) {
+	if manifest.Status != CompleteStatus {
+		return nil, fmt.Errorf("backup %s is %s, only complete backups can be restored",
+			manifest.ID, manifest.Status)
+	}
+
+	pointInTime := opts.PointInTime
+	if pointInTime.IsZero() {
+		pointInTime = manifest.CutTime
+	}
+	restoreReq := Request{Namespaces: opts.Namespaces}
+	include := func(f FileSet) bool {
+		return restoreReq.IncludesNamespace(f.Namespace) && !f.BlockStart.After(pointInTime)
+	}
+
+	owners := make(map[uint32][]string)
+	for hostID, shards := range opts.Placement {
+		for _, shard := range shards {
+			owners[shard] = append(owners[shard], hostID)
+		}
+	}
+
+	nodes := append([]NodeResult(nil), manifest.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].HostID < nodes[j].HostID
+	})
+
+	var (
+		selected     = make(map[shardBlockKey]RestoreFileSet)
+		sourceShards = make(map[string]map[uint32]struct{}, len(nodes))
+		sourceIndex  = make(map[string][]RestoreFileSet, len(nodes))
+	)
+	for _, node := range nodes {
+		shards := make(map[uint32]struct{})
+		sourceShards[node.HostID] = shards
+		for _, f := range node.FileSets {
+			if f.Type != IndexFileSetType {
+				shards[f.Shard] = struct{}{}
+			}
+			if !include(f) {
+				continue
+			}
+			candidate := RestoreFileSet{SourceHostID: node.HostID, FileSet: f}
+			if f.Type == IndexFileSetType {
+				sourceIndex[node.HostID] = append(sourceIndex[node.HostID], candidate)
+				continue
+			}
+
+			key := shardBlockKey{
+				namespace:  f.Namespace,
+				shard:      f.Shard,
+				blockStart: f.BlockStart.UnixNano(),
+			}
+			if existing, ok := selected[key]; ok && !preferFileSet(f, existing.FileSet) {
+				continue
+			}
+			selected[key] = candidate
+		}
+	}
+
+	plan := make(RestorePlan, len(opts.Placement))
+	for key, f := range selected {
+		hostIDs, ok := owners[key.shard]
+		if !ok {
+			return nil, fmt.Errorf(
+				"shard %d of namespace %s is not owned by any host in the target placement",
+				key.shard, key.namespace)
+		}
+		for _, hostID := range hostIDs {
+			plan[hostID] = append(plan[hostID], f)
+		}
+	}
+
+	for hostID, shards := range opts.Placement {
+		for _, node := range nodes {
+			if sameShards(shards, sourceShards[node.HostID]) {
+				plan[hostID] = append(plan[hostID], sourceIndex[node.HostID]...)
+				break
+			}
+		}
+	}
+
+	for _, fileSets := range plan {
+		sortRestoreFileSets(fileSets)
+	}
+	return plan, nil
+}
+
+// preferFileSet returns whether a fileset should be restored instead of
+// another fileset of the same block.
+func preferFileSet(f, other FileSet) bool {
+	if f.Type != other.Type {
+		return f.Type == DataFileSetType
+	}
+	return f.VolumeIndex > other.VolumeIndex
+}
+
+func sameShards(shards []uint32, other map[uint32]struct{}) bool {
+	if len(other) == 0 {
+		return false
+	}
+	unique := make(map[uint32]struct{}, len(shards))
+	for _, shard := range shards {
+		if _, ok := other[shard]; !ok {
+			return false
+		}
+		unique[shard] = struct{}{}
+	}
+	return len(unique) == len(other)
+}
+
+func sortRestoreFileSets(fileSets []RestoreFileSet) {
+	sort.Slice(fileSets, func(i, j int) bool {
+		a, b := fileSets[i], fileSets[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Shard != b.Shard {
+			return a.Shard < b.Shard
+		}
+		if !a.BlockStart.Equal(b.BlockStart) {
+			return a.BlockStart.Before(b.BlockStart)
+		}
+		return a.VolumeIndex < b.VolumeIndex
+	})
+}
+
+// RestoreHost copies the filesets planned for a host from the object store
+// to the file path prefix of the filesystem options. The checkpoint file of
+// each fileset is copied last so that a restore that is interrupted never
+// leaves a fileset that appears complete, and existing files are never
+// overwritten.
+func RestoreHost(
+	plan RestorePlan,
+	hostID string,
+	store ObjectStore,
+	fsOpts fs.Options,
+) error {
+	for _, f := range plan[hostID] {
+		files := append([]string(nil), f.Files...)
+		sort.SliceStable(files, func(i, j int) bool {
+			return !isCheckpointFile(files[i]) && isCheckpointFile(files[j])
+		})
+		for _, file := range files {
+			if err := restoreFile(store, f.SourceHostID, file, fsOpts); err != nil {
+				return fmt.Errorf("unable to restore %s from host %s: %v",
+					file, f.SourceHostID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isCheckpointFile(path string) bool {
+	return strings.HasSuffix(path, checkpointFileSuffix)
+}
+
+func restoreFile(store ObjectStore, sourceHostID, path string, fsOpts fs.Options) error {
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(clean) || clean == ".." ||
+		strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path is not relative to the file path prefix")
+	}
+
+	dst := filepath.Join(fsOpts.FilePathPrefix(), clean)
+	if err := os.MkdirAll(filepath.Dir(dst), fsOpts.NewDirectoryMode()); err != nil {
+		return err
+	}
+
+	src, err := store.Open(sourceHostID, path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fsOpts.NewFileMode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRestoreManifest() Manifest {
+	var (
+		block0 = time.Unix(0, 0)
+		block1 = time.Unix(7200, 0)
+		block2 = time.Unix(14400, 0)
+	)
+	return Manifest{
+		ID:      "1",
+		CutTime: block1.Add(time.Minute),
+		Status:  CompleteStatus,
+		Nodes: []NodeResult{
+			{
+				HostID: "b",
+				FileSets: []FileSet{
+					{Type: DataFileSetType, Namespace: "metrics", Shard: 0, BlockStart: block0,
+						Files: []string{"data/metrics/0/fileset-0-checkpoint.db"}},
+					{Type: DataFileSetType, Namespace: "metrics", Shard: 0, BlockStart: block1,
+						Files: []string{"data/metrics/0/fileset-7200000000000-checkpoint.db"}},
+					{Type: IndexFileSetType, Namespace: "metrics", BlockStart: block0,
+						Files: []string{"index/data/metrics/fileset-0-0-checkpoint.db"}},
+				},
+			},
+			{
+				HostID: "a",
+				FileSets: []FileSet{
+					{Type: DataFileSetType, Namespace: "metrics", Shard: 0, BlockStart: block0,
+						Files: []string{"data/metrics/0/fileset-0-checkpoint.db"}},
+					{Type: SnapshotFileSetType, Namespace: "metrics", Shard: 0, BlockStart: block1,
+						VolumeIndex: 2, Files: []string{"snapshots/metrics/0/fileset-7200000000000-2-checkpoint.db"}},
+					{Type: SnapshotFileSetType, Namespace: "metrics", Shard: 1, BlockStart: block2,
+						Files: []string{"snapshots/metrics/1/fileset-14400000000000-0-checkpoint.db"}},
+					{Type: DataFileSetType, Namespace: "other", Shard: 1, BlockStart: block0,
+						Files: []string{"data/other/1/fileset-0-checkpoint.db"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSelectManifest(t *testing.T) {
+	manifests := []Manifest{
+		{ID: "1", CutTime: time.Unix(100, 0), Status: CompleteStatus},
+		{ID: "2", CutTime: time.Unix(200, 0), Status: CompleteStatus},
+		{ID: "3", CutTime: time.Unix(250, 0), Status: FailedStatus},
+		{ID: "4", CutTime: time.Unix(300, 0), Status: CompleteStatus},
+	}
+
+	m, err := SelectManifest(manifests, time.Unix(299, 0))
+	require.NoError(t, err)
+	require.Equal(t, "2", m.ID)
+
+	m, err = SelectManifest(manifests, time.Unix(300, 0))
+	require.NoError(t, err)
+	require.Equal(t, "4", m.ID)
+
+	_, err = SelectManifest(manifests, time.Unix(99, 0))
+	require.Equal(t, errNoManifest, err)
+}
+
+func TestPlanRestoreRemapsShards(t *testing.T) {
+	plan, err := PlanRestore(testRestoreManifest(), RestoreOptions{
+		Namespaces: []string{"metrics"},
+		Placement: map[string][]uint32{
+			"x": []uint32{0},
+			"y": []uint32{0, 1},
+		},
+	})
+	require.NoError(t, err)
+
+	// Host x owns exactly the shards of host b so also restores its index.
+	x := plan["x"]
+	require.Equal(t, 3, len(x))
+	require.Equal(t, DataFileSetType, x[0].Type)
+	require.Equal(t, "a", x[0].SourceHostID)
+	require.True(t, time.Unix(0, 0).Equal(x[0].BlockStart))
+	require.Equal(t, DataFileSetType, x[1].Type)
+	require.Equal(t, "b", x[1].SourceHostID)
+	require.True(t, time.Unix(7200, 0).Equal(x[1].BlockStart))
+	require.Equal(t, IndexFileSetType, x[2].Type)
+	require.Equal(t, "b", x[2].SourceHostID)
+
+	// Host y owns the same shards as host a which has no index filesets, the
+	// block of shard 1 starting after the cut time is excluded.
+	y := plan["y"]
+	require.Equal(t, 2, len(y))
+	for _, f := range y {
+		require.Equal(t, DataFileSetType, f.Type)
+		require.Equal(t, uint32(0), f.Shard)
+	}
+}
+
+func TestPlanRestoreErrors(t *testing.T) {
+	manifest := testRestoreManifest()
+	_, err := PlanRestore(manifest, RestoreOptions{
+		PointInTime: time.Unix(20000, 0),
+		Placement:   map[string][]uint32{"x": []uint32{0}},
+	})
+	require.Error(t, err)
+
+	manifest.Status = PendingStatus
+	_, err = PlanRestore(manifest, RestoreOptions{
+		Placement: map[string][]uint32{"x": []uint32{0, 1}},
+	})
+	require.Error(t, err)
+}
+
+func TestRestoreHost(t *testing.T) {
+	backupDir, err := ioutil.TempDir("", "backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(backupDir)
+	restoreDir, err := ioutil.TempDir("", "restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(restoreDir)
+
+	files := []string{
+		"data/metrics/0/fileset-0-checkpoint.db",
+		"data/metrics/0/fileset-0-data.db",
+	}
+	for _, f := range files {
+		path := filepath.Join(backupDir, "a", f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(f), 0644))
+	}
+
+	var (
+		store  = NewDirectoryObjectStore(backupDir)
+		fsOpts = fs.NewOptions().SetFilePathPrefix(restoreDir)
+		plan   = RestorePlan{
+			"x": []RestoreFileSet{{
+				SourceHostID: "a",
+				FileSet:      FileSet{Type: DataFileSetType, Files: files},
+			}},
+		}
+	)
+	require.NoError(t, RestoreHost(plan, "x", store, fsOpts))
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(restoreDir, f))
+		require.NoError(t, err)
+		require.Equal(t, f, string(data))
+	}
+
+	// Existing files are never overwritten.
+	require.Error(t, RestoreHost(plan, "x", store, fsOpts))
+
+	plan["x"][0].Files = []string{"../escape.db"}
+	require.Error(t, RestoreHost(plan, "x", store, fsOpts))
+}
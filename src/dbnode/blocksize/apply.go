@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package blocksize
+
+import (
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3cluster/kv"
+	xlog "github.com/m3db/m3x/log"
+)
+
+// Applier applies the recommendations that are safe to apply to the
+// namespace registry. Namespaces do not pick up changes to their options
+// until the nodes are restarted, so applied recommendations take effect on
+// the next restart of each node.
+type Applier struct {
+	store kv.Store
+	db    storage.Database
+	opts  Options
+	log   xlog.Logger
+}
+
+// NewApplier returns a new applier.
+func NewApplier(
+	store kv.Store,
+	db storage.Database,
+	opts Options,
+	log xlog.Logger,
+) *Applier {
+	return &Applier{
+		store: store,
+		db:    db,
+		opts:  opts,
+		log:   log,
+	}
+}
+
+// Start applies the recommendations for the database every interval.
+func (a *Applier) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			applied, err := a.Apply(RecommendDatabase(a.db, a.opts))
+			if err != nil {
+				a.log.Errorf("could not apply block size recommendations: %v", err)
+				continue
+			}
+			for _, ns := range applied {
+				a.log.Infof("applied buffer recommendations to namespace %s, "+
+					"they take effect once nodes are restarted", ns)
+			}
+		}
+	}()
+}
+
+// Apply applies the recommendations that are safe to apply to the namespace
+// registry and returns the namespaces that were updated.
+func (a *Applier) Apply(recs []Recommendation) ([]string, error) {
+	value, err := a.store.Get(kvconfig.NamespacesKey)
+	if err == kv.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var registry nsproto.Registry
+	if err := value.Unmarshal(&registry); err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, rec := range recs {
+		retention, ok := SafeToApply(rec)
+		if !ok {
+			continue
+		}
+		nsOpts, ok := registry.Namespaces[rec.Namespace]
+		if !ok || nsOpts.RetentionOptions == nil {
+			continue
+		}
+		// The registry may have been updated since the namespace options the
+		// recommendation was made from, only ever grow the buffers it holds.
+		ropts := nsOpts.RetentionOptions
+		if ropts.BlockSizeNanos != int64(retention.BlockSize) {
+			continue
+		}
+		changed := false
+		if v := int64(retention.BufferPast); v > ropts.BufferPastNanos {
+			ropts.BufferPastNanos = v
+			changed = true
+		}
+		if v := int64(retention.BufferFuture); v > ropts.BufferFutureNanos {
+			ropts.BufferFutureNanos = v
+			changed = true
+		}
+		if changed {
+			applied = append(applied, rec.Namespace)
+		}
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+
+	// Every node applies its own recommendations, the check and set fails
+	// if another node updated the registry first and the recommendations
+	// are applied again on the next interval.
+	if _, err := a.store.CheckAndSet(kvconfig.NamespacesKey, value.Version(), &registry); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
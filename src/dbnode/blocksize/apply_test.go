@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package blocksize
+
+import (
+	"testing"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3cluster/kv/mem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplierApply(t *testing.T) {
+	store := mem.NewStore()
+	_, err := store.Set(kvconfig.NamespacesKey, &nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"metrics": &nsproto.NamespaceOptions{
+				RetentionOptions: &nsproto.RetentionOptions{
+					RetentionPeriodNanos: int64(48 * time.Hour),
+					BlockSizeNanos:       int64(2 * time.Hour),
+					BufferPastNanos:      int64(10 * time.Minute),
+					BufferFutureNanos:    int64(2 * time.Minute),
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	current := Retention{
+		BlockSize:    2 * time.Hour,
+		BufferPast:   10 * time.Minute,
+		BufferFuture: 2 * time.Minute,
+	}
+	recs := []Recommendation{
+		{
+			Namespace: "metrics",
+			Confident: true,
+			Current:   current,
+			Recommended: Retention{
+				BlockSize:    12 * time.Hour,
+				BufferPast:   22 * time.Minute,
+				BufferFuture: time.Minute,
+			},
+		},
+		{
+			Namespace:   "unknown",
+			Confident:   true,
+			Current:     current,
+			Recommended: Retention{BlockSize: 2 * time.Hour, BufferPast: time.Hour},
+		},
+	}
+
+	applier := NewApplier(store, nil, NewOptions(), nil)
+	applied, err := applier.Apply(recs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metrics"}, applied)
+
+	value, err := store.Get(kvconfig.NamespacesKey)
+	require.NoError(t, err)
+	var registry nsproto.Registry
+	require.NoError(t, value.Unmarshal(&registry))
+	ropts := registry.Namespaces["metrics"].RetentionOptions
+	assert.Equal(t, int64(2*time.Hour), ropts.BlockSizeNanos)
+	assert.Equal(t, int64(22*time.Minute), ropts.BufferPastNanos)
+	assert.Equal(t, int64(2*time.Minute), ropts.BufferFutureNanos)
+
+	// Applying again leaves the registry as is.
+	applied, err = applier.Apply(recs)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package blocksize
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// defaultTargetDatapointsPerBlock balances compression, which improves
+	// with every datapoint of a block, with the cost of decoding a block.
+	defaultTargetDatapointsPerBlock = 720
+
+	defaultMinDatapointsPerBlock = 120
+	defaultMaxBufferedDatapoints = 1 << 30
+	defaultMinBlockSize          = 10 * time.Minute
+	defaultMaxBlockSize          = 24 * time.Hour
+	defaultMaxBufferPast         = 2 * time.Hour
+	defaultMaxBufferFuture       = 30 * time.Minute
+	defaultMinSampledWrites      = 1000
+)
+
+var (
+	errTargetDatapointsPerBlockPositive = errors.New("target datapoints per block must be positive")
+	errMinDatapointsPerBlockInvalid     = errors.New("min datapoints per block must be positive and at most the target")
+	errMaxBufferedDatapointsNegative    = errors.New("max buffered datapoints must not be negative")
+	errBlockSizeBoundsInvalid           = errors.New("min block size must be positive and at most the max block size")
+	errMaxBufferNegative                = errors.New("max buffer past and future must not be negative")
+)
+
+type options struct {
+	targetDatapointsPerBlock int
+	minDatapointsPerBlock    int
+	maxBufferedDatapoints    int64
+	minBlockSize             time.Duration
+	maxBlockSize             time.Duration
+	maxBufferPast            time.Duration
+	maxBufferFuture          time.Duration
+	minSampledWrites         int64
+}
+
+// NewOptions creates new block size recommendation options.
+func NewOptions() Options {
+	return &options{
+		targetDatapointsPerBlock: defaultTargetDatapointsPerBlock,
+		minDatapointsPerBlock:    defaultMinDatapointsPerBlock,
+		maxBufferedDatapoints:    defaultMaxBufferedDatapoints,
+		minBlockSize:             defaultMinBlockSize,
+		maxBlockSize:             defaultMaxBlockSize,
+		maxBufferPast:            defaultMaxBufferPast,
+		maxBufferFuture:          defaultMaxBufferFuture,
+		minSampledWrites:         defaultMinSampledWrites,
+	}
+}
+
+func (o *options) Validate() error {
+	if o.targetDatapointsPerBlock <= 0 {
+		return errTargetDatapointsPerBlockPositive
+	}
+	if o.minDatapointsPerBlock <= 0 || o.minDatapointsPerBlock > o.targetDatapointsPerBlock {
+		return errMinDatapointsPerBlockInvalid
+	}
+	if o.maxBufferedDatapoints < 0 {
+		return errMaxBufferedDatapointsNegative
+	}
+	if o.minBlockSize <= 0 || o.minBlockSize > o.maxBlockSize {
+		return errBlockSizeBoundsInvalid
+	}
+	if o.maxBufferPast < 0 || o.maxBufferFuture < 0 {
+		return errMaxBufferNegative
+	}
+	return nil
+}
+
+func (o *options) SetTargetDatapointsPerBlock(value int) Options {
+	opts := *o
+	opts.targetDatapointsPerBlock = value
+	return &opts
+}
+
+func (o *options) TargetDatapointsPerBlock() int {
+	return o.targetDatapointsPerBlock
+}
+
+func (o *options) SetMinDatapointsPerBlock(value int) Options {
+	opts := *o
+	opts.minDatapointsPerBlock = value
+	return &opts
+}
+
+func (o *options) MinDatapointsPerBlock() int {
+	return o.minDatapointsPerBlock
+}
+
+func (o *options) SetMaxBufferedDatapoints(value int64) Options {
+	opts := *o
+	opts.maxBufferedDatapoints = value
+	return &opts
+}
+
+func (o *options) MaxBufferedDatapoints() int64 {
+	return o.maxBufferedDatapoints
+}
+
+func (o *options) SetMinBlockSize(value time.Duration) Options {
+	opts := *o
+	opts.minBlockSize = value
+	return &opts
+}
+
+func (o *options) MinBlockSize() time.Duration {
+	return o.minBlockSize
+}
+
+func (o *options) SetMaxBlockSize(value time.Duration) Options {
+	opts := *o
+	opts.maxBlockSize = value
+	return &opts
+}
+
+func (o *options) MaxBlockSize() time.Duration {
+	return o.maxBlockSize
+}
+
+func (o *options) SetMaxBufferPast(value time.Duration) Options {
+	opts := *o
+	opts.maxBufferPast = value
+	return &opts
+}
+
+func (o *options) MaxBufferPast() time.Duration {
+	return o.maxBufferPast
+}
+
+func (o *options) SetMaxBufferFuture(value time.Duration) Options {
+	opts := *o
+	opts.maxBufferFuture = value
+	return &opts
+}
+
+func (o *options) MaxBufferFuture() time.Duration {
+	return o.maxBufferFuture
+}
+
+func (o *options) SetMinSampledWrites(value int64) Options {
+	opts := *o
+	opts.minSampledWrites = value
+	return &opts
+}
+
+func (o *options) MinSampledWrites() int64 {
+	return o.minSampledWrites
+}
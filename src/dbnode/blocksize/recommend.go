@@ -0,0 +1,238 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package blocksize
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+)
+
+// blockSizes are the block sizes that are recommended.
+var blockSizes = []time.Duration{
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	4 * time.Hour,
+	6 * time.Hour,
+	8 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// RecommendDatabase returns the recommendations for the namespaces of the
+// database sorted by namespace.
+func RecommendDatabase(db storage.Database, opts Options) []Recommendation {
+	namespaces := db.Namespaces()
+	sort.Sort(storage.NamespacesByID(namespaces))
+
+	recs := make([]Recommendation, 0, len(namespaces))
+	for _, ns := range namespaces {
+		recs = append(recs, Recommend(ns.ID().String(), ns.Options(),
+			ns.WritePatterns(), opts))
+	}
+	return recs
+}
+
+// Recommend returns the recommended retention of a namespace given its write
+// patterns. The block size is chosen to hold the target datapoints per series,
+// made smaller when queries span less than a block or when the series would
+// buffer too many datapoints in memory, and the buffers are chosen to accept
+// almost all of the datapoints written late or early.
+func Recommend(
+	id string,
+	nsOpts namespace.Options,
+	patterns storage.WritePatterns,
+	opts Options,
+) Recommendation {
+	ropts := nsOpts.RetentionOptions()
+	current := Retention{
+		BlockSize:    ropts.BlockSize(),
+		BufferPast:   ropts.BufferPast(),
+		BufferFuture: ropts.BufferFuture(),
+	}
+	rec := Recommendation{
+		Namespace:   id,
+		Current:     current,
+		Recommended: current,
+		Patterns:    patterns,
+	}
+	if patterns.SampledWrites < opts.MinSampledWrites() ||
+		patterns.WriteInterval.Samples == 0 {
+		rec.reason("only %d writes were sampled, at least %d are required",
+			patterns.SampledWrites, opts.MinSampledWrites())
+		return rec
+	}
+
+	rec.Confident = true
+	if candidates := blockSizeCandidates(nsOpts, opts); len(candidates) > 0 {
+		rec.Recommended.BlockSize = rec.recommendBlockSize(candidates, opts)
+	} else {
+		rec.reason("no block size between %s and %s is valid for the namespace",
+			opts.MinBlockSize().String(), opts.MaxBlockSize().String())
+	}
+
+	blockSize := rec.Recommended.BlockSize
+	rec.Recommended.BufferPast = recommendBuffer(patterns.Lateness,
+		opts.MaxBufferPast(), blockSize)
+	rec.reason("99%% of datapoints are written at most %s late",
+		patterns.Lateness.P99.String())
+	rec.Recommended.BufferFuture = recommendBuffer(patterns.Earliness,
+		opts.MaxBufferFuture(), blockSize)
+	rec.reason("99%% of datapoints are written at most %s early",
+		patterns.Earliness.P99.String())
+	return rec
+}
+
+func (r *Recommendation) reason(format string, args ...interface{}) {
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, args...))
+}
+
+func (r *Recommendation) recommendBlockSize(
+	candidates []time.Duration,
+	opts Options,
+) time.Duration {
+	var (
+		interval = r.Patterns.WriteInterval.P50
+		target   = opts.TargetDatapointsPerBlock()
+		ideal    = interval * time.Duration(target)
+	)
+	r.reason("datapoints are written every %s, %d datapoints take %s",
+		interval.String(), target, ideal.String())
+
+	if q := r.Patterns.QueryRange; q.Samples > 0 && q.P90 < ideal {
+		narrowed := interval * time.Duration(opts.MinDatapointsPerBlock())
+		if q.P90 > narrowed {
+			narrowed = q.P90
+		}
+		if narrowed < ideal {
+			ideal = narrowed
+			r.reason("90%% of queries span at most %s, blocks of %s limit the datapoints decoded",
+				q.P90.String(), ideal.String())
+		}
+	}
+
+	idx := sort.Search(len(candidates), func(i int) bool {
+		return candidates[i] >= ideal
+	})
+	if idx == len(candidates) {
+		idx--
+	}
+
+	if max := opts.MaxBufferedDatapoints(); max > 0 {
+		stepped := false
+		for idx > 0 && bufferedDatapoints(r.Patterns.NumSeries, interval, candidates[idx]) > max {
+			idx--
+			stepped = true
+		}
+		if stepped {
+			r.reason("%d series would buffer more than %d datapoints with larger blocks",
+				r.Patterns.NumSeries, max)
+		}
+	}
+	return candidates[idx]
+}
+
+// blockSizeCandidates returns the block sizes within the bounds that are
+// valid for the namespace, in increasing order.
+func blockSizeCandidates(nsOpts namespace.Options, opts Options) []time.Duration {
+	var (
+		retentionPeriod = nsOpts.RetentionOptions().RetentionPeriod()
+		indexOpts       = nsOpts.IndexOptions()
+		candidates      []time.Duration
+	)
+	for _, blockSize := range blockSizes {
+		if blockSize < opts.MinBlockSize() || blockSize > opts.MaxBlockSize() {
+			continue
+		}
+		if blockSize > retentionPeriod {
+			continue
+		}
+		if indexOpts.Enabled() && indexOpts.BlockSize()%blockSize != 0 {
+			continue
+		}
+		candidates = append(candidates, blockSize)
+	}
+	return candidates
+}
+
+// bufferedDatapoints estimates the datapoints the series hold in memory, each
+// series buffers the block being written and the previous block while it can
+// still receive late writes.
+func bufferedDatapoints(
+	numSeries int64,
+	interval time.Duration,
+	blockSize time.Duration,
+) int64 {
+	if interval <= 0 {
+		return 0
+	}
+	return 2 * numSeries * int64(blockSize/interval)
+}
+
+// recommendBuffer returns a buffer accepting the 99th percentile of the
+// sampled durations with some headroom, rounded up to a minute and kept
+// at most half of the block size.
+func recommendBuffer(
+	q storage.DurationQuantiles,
+	max time.Duration,
+	blockSize time.Duration,
+) time.Duration {
+	value := q.P99 + q.P99/10
+	if rem := value % time.Minute; rem != 0 {
+		value += time.Minute - rem
+	}
+	if value < time.Minute {
+		value = time.Minute
+	}
+	if value > max {
+		value = max
+	}
+	if limit := blockSize / 2; value > limit {
+		value = limit
+	}
+	return value
+}
+
+// SafeToApply returns the retention that is safe to apply for a
+// recommendation and whether it differs from the current retention. Only
+// increases of the buffers are safe to apply, the block size is never applied
+// since the filesets already flushed for a namespace are aligned to its
+// current block size, and decreasing a buffer would reject writes that are
+// accepted today.
+func SafeToApply(rec Recommendation) (Retention, bool) {
+	apply := rec.Current
+	if !rec.Confident {
+		return apply, false
+	}
+	if v := rec.Recommended.BufferPast; v > apply.BufferPast && v < apply.BlockSize {
+		apply.BufferPast = v
+	}
+	if v := rec.Recommended.BufferFuture; v > apply.BufferFuture && v < apply.BlockSize {
+		apply.BufferFuture = v
+	}
+	return apply, apply != rec.Current
+}
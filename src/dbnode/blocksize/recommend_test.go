@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package blocksize
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWritePatterns(interval time.Duration) storage.WritePatterns {
+	return storage.WritePatterns{
+		NumSeries:     1000,
+		SampledWrites: 10000,
+		WriteInterval: storage.DurationQuantiles{Samples: 1024, P50: interval},
+		Lateness:      storage.DurationQuantiles{Samples: 1024, P99: 20 * time.Minute},
+		Earliness:     storage.DurationQuantiles{Samples: 1024},
+	}
+}
+
+func TestRecommendNotEnoughWrites(t *testing.T) {
+	patterns := testWritePatterns(10 * time.Second)
+	patterns.SampledWrites = 10
+
+	rec := Recommend("metrics", namespace.NewOptions(), patterns, NewOptions())
+	assert.False(t, rec.Confident)
+	assert.False(t, rec.Changed())
+	assert.Equal(t, 2*time.Hour, rec.Current.BlockSize)
+}
+
+func TestRecommend(t *testing.T) {
+	rec := Recommend("metrics", namespace.NewOptions(),
+		testWritePatterns(time.Minute), NewOptions())
+	require.True(t, rec.Confident)
+	assert.Equal(t, Retention{
+		BlockSize:    12 * time.Hour,
+		BufferPast:   22 * time.Minute,
+		BufferFuture: time.Minute,
+	}, rec.Recommended)
+	assert.True(t, rec.Changed())
+	assert.NotEmpty(t, rec.Reasons)
+}
+
+func TestRecommendQueryRanges(t *testing.T) {
+	patterns := testWritePatterns(10 * time.Second)
+	patterns.QueryRange = storage.DurationQuantiles{Samples: 100, P90: 25 * time.Minute}
+
+	rec := Recommend("metrics", namespace.NewOptions(), patterns, NewOptions())
+	assert.Equal(t, 30*time.Minute, rec.Recommended.BlockSize)
+
+	// Blocks never hold fewer than the min datapoints per block.
+	patterns.QueryRange.P90 = time.Minute
+	rec = Recommend("metrics", namespace.NewOptions(), patterns, NewOptions())
+	assert.Equal(t, 30*time.Minute, rec.Recommended.BlockSize)
+}
+
+func TestRecommendMaxBufferedDatapoints(t *testing.T) {
+	patterns := testWritePatterns(10 * time.Second)
+	patterns.NumSeries = 1000000
+
+	opts := NewOptions().SetMaxBufferedDatapoints(1000000000)
+	rec := Recommend("metrics", namespace.NewOptions(), patterns, opts)
+	assert.Equal(t, time.Hour, rec.Recommended.BlockSize)
+
+	rec = Recommend("metrics", namespace.NewOptions(), patterns,
+		opts.SetMaxBufferedDatapoints(0))
+	assert.Equal(t, 2*time.Hour, rec.Recommended.BlockSize)
+}
+
+func TestRecommendIndexBlockSize(t *testing.T) {
+	nsOpts := namespace.NewOptions().SetIndexOptions(
+		namespace.NewIndexOptions().SetEnabled(true).SetBlockSize(2 * time.Hour))
+
+	rec := Recommend("metrics", nsOpts, testWritePatterns(time.Minute), NewOptions())
+	assert.Equal(t, 2*time.Hour, rec.Recommended.BlockSize)
+	assert.Equal(t, 22*time.Minute, rec.Recommended.BufferPast)
+}
+
+func TestSafeToApply(t *testing.T) {
+	rec := Recommendation{
+		Confident: true,
+		Current: Retention{
+			BlockSize:    2 * time.Hour,
+			BufferPast:   10 * time.Minute,
+			BufferFuture: 2 * time.Minute,
+		},
+		Recommended: Retention{
+			BlockSize:    12 * time.Hour,
+			BufferPast:   22 * time.Minute,
+			BufferFuture: time.Minute,
+		},
+	}
+
+	retention, ok := SafeToApply(rec)
+	require.True(t, ok)
+	assert.Equal(t, Retention{
+		BlockSize:    2 * time.Hour,
+		BufferPast:   22 * time.Minute,
+		BufferFuture: 2 * time.Minute,
+	}, retention)
+
+	rec.Confident = false
+	_, ok = SafeToApply(rec)
+	assert.False(t, ok)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package blocksize recommends the block size, buffer past and buffer future
+// of namespaces from the write patterns sampled by the database, and applies
+// the recommendations that are safe to apply to the namespace registry.
+package blocksize
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+)
+
+// Options controls the bounds of the recommendations.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetTargetDatapointsPerBlock sets the number of datapoints per series a
+	// block should hold, blocks with more datapoints compress better but cost
+	// more to decode when queried.
+	SetTargetDatapointsPerBlock(value int) Options
+
+	// TargetDatapointsPerBlock returns the number of datapoints per series a
+	// block should hold.
+	TargetDatapointsPerBlock() int
+
+	// SetMinDatapointsPerBlock sets the fewest datapoints per series a block
+	// may hold when it is made smaller to fit the ranges queried.
+	SetMinDatapointsPerBlock(value int) Options
+
+	// MinDatapointsPerBlock returns the fewest datapoints per series a block
+	// may hold when it is made smaller to fit the ranges queried.
+	MinDatapointsPerBlock() int
+
+	// SetMaxBufferedDatapoints sets the most datapoints the series of a
+	// namespace may hold in memory across their buffered blocks, zero for
+	// no limit.
+	SetMaxBufferedDatapoints(value int64) Options
+
+	// MaxBufferedDatapoints returns the most datapoints the series of a
+	// namespace may hold in memory across their buffered blocks.
+	MaxBufferedDatapoints() int64
+
+	// SetMinBlockSize sets the smallest block size recommended.
+	SetMinBlockSize(value time.Duration) Options
+
+	// MinBlockSize returns the smallest block size recommended.
+	MinBlockSize() time.Duration
+
+	// SetMaxBlockSize sets the largest block size recommended.
+	SetMaxBlockSize(value time.Duration) Options
+
+	// MaxBlockSize returns the largest block size recommended.
+	MaxBlockSize() time.Duration
+
+	// SetMaxBufferPast sets the largest buffer past recommended.
+	SetMaxBufferPast(value time.Duration) Options
+
+	// MaxBufferPast returns the largest buffer past recommended.
+	MaxBufferPast() time.Duration
+
+	// SetMaxBufferFuture sets the largest buffer future recommended.
+	SetMaxBufferFuture(value time.Duration) Options
+
+	// MaxBufferFuture returns the largest buffer future recommended.
+	MaxBufferFuture() time.Duration
+
+	// SetMinSampledWrites sets the fewest writes that must be sampled before
+	// a recommendation is made.
+	SetMinSampledWrites(value int64) Options
+
+	// MinSampledWrites returns the fewest writes that must be sampled before
+	// a recommendation is made.
+	MinSampledWrites() int64
+}
+
+// Retention is the part of the retention options of a namespace that is
+// recommended.
+type Retention struct {
+	BlockSize    time.Duration
+	BufferPast   time.Duration
+	BufferFuture time.Duration
+}
+
+// Recommendation is the recommended retention of a namespace.
+type Recommendation struct {
+	Namespace   string
+	Current     Retention
+	Recommended Retention
+	Patterns    storage.WritePatterns
+
+	// Confident is whether enough writes were sampled for the recommendation
+	// to be made, the current retention is recommended otherwise.
+	Confident bool
+
+	// Reasons explains how the recommendation was made.
+	Reasons []string
+}
+
+// Changed returns whether the recommended retention differs from the current.
+func (r Recommendation) Changed() bool {
+	return r.Recommended != r.Current
+}
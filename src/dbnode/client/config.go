@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	"github.com/m3db/m3/src/dbnode/environment"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/tchannel"
 	"github.com/m3db/m3x/instrument"
@@ -69,6 +70,23 @@ type Configuration struct {
 	// FetchRetry is the fetch retry config.
 	FetchRetry retry.Configuration `yaml:"fetchRetry"`
 
+	// WriteBatchElementRetries is the number of times elements of a write
+	// batch that failed with a retryable error are resent to a host.
+	WriteBatchElementRetries *int `yaml:"writeBatchElementRetries"`
+
+	// WriteResourceExhaustedMaxBackoff is the max time to wait before resending
+	// elements of a write batch to a host that is applying backpressure.
+	WriteResourceExhaustedMaxBackoff *time.Duration `yaml:"writeResourceExhaustedMaxBackoff"`
+
+	// BackgroundConnectMaxBackoff is the max time to back off between
+	// background connect attempts to a host that keeps failing to connect.
+	BackgroundConnectMaxBackoff *time.Duration `yaml:"backgroundConnectMaxBackoff"`
+
+	// TopologyChangeWarmUpTimeout is the max time to wait for connections to
+	// hosts added by a topology change to be established before switching to
+	// the new topology, zero disables waiting.
+	TopologyChangeWarmUpTimeout *time.Duration `yaml:"topologyChangeWarmUpTimeout"`
+
 	// BackgroundHealthCheckFailLimit is the amount of times a background check
 	// must fail before a connection is taken out of consideration.
 	BackgroundHealthCheckFailLimit int `yaml:"backgroundHealthCheckFailLimit" validate:"min=1,max=10"`
@@ -99,6 +117,10 @@ type ConfigurationParameters struct {
 	// constructing a client from configuration.
 	TopologyInitializer topology.Initializer
 
+	// NamespaceInitializer is an optional argument when
+	// constructing a client from configuration.
+	NamespaceInitializer namespace.Initializer
+
 	// EncodingOptions is an optional argument when
 	// constructing a client from configuration.
 	EncodingOptions encoding.Options
@@ -148,7 +170,8 @@ func (c Configuration) NewAdminClient(
 	fetchRequestScope := iopts.MetricsScope().SubScope("fetch-req")
 
 	envCfg := environment.ConfigureResults{
-		TopologyInitializer: params.TopologyInitializer,
+		TopologyInitializer:  params.TopologyInitializer,
+		NamespaceInitializer: params.NamespaceInitializer,
 	}
 
 	var err error
@@ -177,6 +200,7 @@ func (c Configuration) NewAdminClient(
 
 	v := NewAdminOptions().
 		SetTopologyInitializer(envCfg.TopologyInitializer).
+		SetNamespaceInitializer(envCfg.NamespaceInitializer).
 		SetWriteConsistencyLevel(c.WriteConsistencyLevel).
 		SetReadConsistencyLevel(c.ReadConsistencyLevel).
 		SetClusterConnectConsistencyLevel(c.ConnectConsistencyLevel).
@@ -190,6 +214,19 @@ func (c Configuration) NewAdminClient(
 		SetChannelOptions(xtchannel.NewDefaultChannelOptions()).
 		SetInstrumentOptions(iopts)
 
+	if c.WriteBatchElementRetries != nil {
+		v = v.SetWriteBatchElementRetries(*c.WriteBatchElementRetries)
+	}
+	if c.WriteResourceExhaustedMaxBackoff != nil {
+		v = v.SetWriteResourceExhaustedMaxBackoff(*c.WriteResourceExhaustedMaxBackoff)
+	}
+	if c.BackgroundConnectMaxBackoff != nil {
+		v = v.SetBackgroundConnectMaxBackoff(*c.BackgroundConnectMaxBackoff)
+	}
+	if c.TopologyChangeWarmUpTimeout != nil {
+		v = v.SetTopologyChangeWarmUpTimeout(*c.TopologyChangeWarmUpTimeout)
+	}
+
 	encodingOpts := params.EncodingOptions
 	if encodingOpts == nil {
 		encodingOpts = encoding.NewOptions()
@@ -52,6 +52,10 @@ fetchRetry:
     backoffFactor: 2
     maxRetries: 3
     jitter: true
+writeBatchElementRetries: 2
+writeResourceExhaustedMaxBackoff: 2s
+backgroundConnectMaxBackoff: 20s
+topologyChangeWarmUpTimeout: 5s
 backgroundHealthCheckFailLimit: 4
 backgroundHealthCheckFailThrottleFactor: 0.5
 hashing:
@@ -73,6 +77,10 @@ hashing:
 	require.NoError(t, err)
 
 	boolTrue := true
+	writeBatchElementRetries := 2
+	writeResourceExhaustedMaxBackoff := 2 * time.Second
+	backgroundConnectMaxBackoff := 20 * time.Second
+	topologyChangeWarmUpTimeout := 5 * time.Second
 	expected := Configuration{
 		WriteConsistencyLevel:   topology.ConsistencyLevelMajority,
 		ReadConsistencyLevel:    topology.ReadConsistencyLevelUnstrictMajority,
@@ -92,6 +100,10 @@ hashing:
 			MaxRetries:     3,
 			Jitter:         &boolTrue,
 		},
+		WriteBatchElementRetries:                &writeBatchElementRetries,
+		WriteResourceExhaustedMaxBackoff:        &writeResourceExhaustedMaxBackoff,
+		BackgroundConnectMaxBackoff:             &backgroundConnectMaxBackoff,
+		TopologyChangeWarmUpTimeout:             &topologyChangeWarmUpTimeout,
 		BackgroundHealthCheckFailLimit:          4,
 		BackgroundHealthCheckFailThrottleFactor: 0.5,
 		HashingConfiguration: HashingConfiguration{
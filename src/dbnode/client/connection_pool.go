@@ -35,6 +35,7 @@ import (
 	xclose "github.com/m3db/m3x/close"
 
 	"github.com/spaolacci/murmur3"
+	"github.com/uber-go/tally"
 	"github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/thrift"
 )
@@ -65,6 +66,32 @@ type connPool struct {
 	sleepHealth        sleepFn
 	sleepHealthRetry   sleepFn
 	status             status
+	metrics            connPoolMetrics
+}
+
+type connPoolMetrics struct {
+	connections         tally.Gauge
+	connectSuccess      tally.Counter
+	connectErrors       tally.Counter
+	connectHealthErrors tally.Counter
+	healthCheckSuccess  tally.Counter
+	healthCheckErrors   tally.Counter
+	healthCheckEvicted  tally.Counter
+}
+
+func newConnPoolMetrics(host topology.Host, scope tally.Scope) connPoolMetrics {
+	scope = scope.SubScope("connection-pool").Tagged(map[string]string{
+		"host": host.ID(),
+	})
+	return connPoolMetrics{
+		connections:         scope.Gauge("connections"),
+		connectSuccess:      scope.Counter("connect.success"),
+		connectErrors:       scope.Counter("connect.errors"),
+		connectHealthErrors: scope.Counter("connect.health-check-errors"),
+		healthCheckSuccess:  scope.Counter("health-check.success"),
+		healthCheckErrors:   scope.Counter("health-check.errors"),
+		healthCheckEvicted:  scope.Counter("health-check.evicted"),
+	}
 }
 
 type conn struct {
@@ -97,6 +124,7 @@ func newConnectionPool(host topology.Host, opts Options) connectionPool {
 		sleepConnect:       time.Sleep,
 		sleepHealth:        time.Sleep,
 		sleepHealthRetry:   time.Sleep,
+		metrics:            newConnPoolMetrics(host, opts.InstrumentOptions().MetricsScope()),
 	}
 
 	return p
@@ -159,8 +187,8 @@ func (p *connPool) Close() {
 }
 
 func (p *connPool) connectEvery(interval time.Duration, stutter time.Duration) {
-	log := p.opts.InstrumentOptions().Logger()
 	target := p.opts.MaxConnectionCount()
+	failedRounds := 0
 
 	for {
 		p.RLock()
@@ -171,41 +199,94 @@ func (p *connPool) connectEvery(interval time.Duration, stutter time.Duration) {
 			return
 		}
 
-		address := p.host.Address()
+		if failed := p.connect(target - poolLen); failed > 0 {
+			failedRounds++
+		} else {
+			failedRounds = 0
+		}
 
-		var wg sync.WaitGroup
-		for i := 0; i < target-poolLen; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
+		p.sleepConnect(p.connectBackoff(interval, failedRounds) +
+			randStutter(p.connectRand, stutter))
+	}
+}
 
-				// Create connection
-				channel, client, err := p.newConn(channelName, address, p.opts)
-				if err != nil {
-					log.Debugf("could not connect to %s: %v", address, err)
-					return
-				}
+// connect concurrently establishes and health checks the given number of
+// connections, adding the healthy ones to the pool so they are warm by the
+// time they are first used. It returns the number of connections that could
+// not be established.
+func (p *connPool) connect(count int) int {
+	var (
+		log     = p.opts.InstrumentOptions().Logger()
+		address = p.host.Address()
+		wg      sync.WaitGroup
+		failed  int64
+	)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Create connection
+			channel, client, err := p.newConn(channelName, address, p.opts)
+			if err != nil {
+				log.Debugf("could not connect to %s: %v", address, err)
+				p.metrics.connectErrors.Inc(1)
+				atomic.AddInt64(&failed, 1)
+				return
+			}
+
+			// Health check the connection
+			if err := p.healthCheckNewConn(client, p.opts); err != nil {
+				log.Debugf("could not connect to %s: failed health check: %v", address, err)
+				p.metrics.connectHealthErrors.Inc(1)
+				atomic.AddInt64(&failed, 1)
+				channel.Close()
+				return
+			}
+
+			p.metrics.connectSuccess.Inc(1)
+			p.Lock()
+			if p.status == statusOpen {
+				p.pool = append(p.pool, conn{channel, client})
+				p.poolLen = int64(len(p.pool))
+			}
+			p.Unlock()
+		}()
+	}
 
-				// Health check the connection
-				if err := p.healthCheckNewConn(client, p.opts); err != nil {
-					log.Debugf("could not connect to %s: failed health check: %v", address, err)
-					channel.Close()
-					return
-				}
+	wg.Wait()
 
-				p.Lock()
-				if p.status == statusOpen {
-					p.pool = append(p.pool, conn{channel, client})
-					p.poolLen = int64(len(p.pool))
-				}
-				p.Unlock()
-			}()
-		}
+	p.RLock()
+	p.metrics.connections.Update(float64(p.poolLen))
+	p.RUnlock()
 
-		wg.Wait()
+	return int(failed)
+}
+
+// connectBackoff returns how long to wait before the next connect round. The
+// interval is doubled for every consecutive round that failed to fill the pool
+// up to the max backoff, with half of the backoff jittered so that clients do
+// not reconnect in lockstep to a host that has just come back.
+func (p *connPool) connectBackoff(interval time.Duration, failedRounds int) time.Duration {
+	if failedRounds == 0 {
+		return interval
+	}
 
-		p.sleepConnect(interval + randStutter(p.connectRand, stutter))
+	var (
+		maxBackoff = p.opts.BackgroundConnectMaxBackoff()
+		backoff    = interval
+	)
+	for i := 0; i < failedRounds && backoff < maxBackoff; i++ {
+		backoff *= 2
 	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	if backoff < interval {
+		backoff = interval
+	}
+
+	return backoff/2 + randStutter(p.connectRand, backoff/2)
 }
 
 func (p *connPool) healthCheckEvery(interval time.Duration, stutter time.Duration) {
@@ -239,6 +320,7 @@ func (p *connPool) healthCheckEvery(interval time.Duration, stutter time.Duratio
 				)
 				for j := 0; j < attempts; j++ {
 					if err := p.healthCheck(client, p.opts); err != nil {
+						p.metrics.healthCheckErrors.Inc(1)
 						checkErr = err
 						failed++
 						throttleDuration := time.Duration(math.Max(
@@ -249,6 +331,7 @@ func (p *connPool) healthCheckEvery(interval time.Duration, stutter time.Duratio
 						continue
 					}
 					// Healthy
+					p.metrics.healthCheckSuccess.Inc(1)
 					break
 				}
 
@@ -273,7 +356,9 @@ func (p *connPool) healthCheckEvery(interval time.Duration, stutter time.Duratio
 							break
 						}
 					}
+					p.metrics.connections.Update(float64(p.poolLen))
 					p.Unlock()
+					p.metrics.healthCheckEvicted.Inc(1)
 
 					// Close the client's channel
 					c.channel.Close()
@@ -33,6 +33,8 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 const (
@@ -150,6 +152,90 @@ func TestConnectionPoolConnectsAndRetriesConnects(t *testing.T) {
 	assert.Equal(t, errConnectionPoolClosed, err)
 }
 
+func TestConnectionPoolBacksOffConnectsAndReportsMetrics(t *testing.T) {
+	// Scenario:
+	// 1. Fail to connect for 3 rounds
+	// > Back off exponentially with jitter up to the max backoff
+	// 2. Connect successfully
+	// > Return to the regular connect interval
+
+	var (
+		attempts int32
+		sleeps   []time.Duration
+		sleepsMu sync.Mutex
+		doneWg   sync.WaitGroup
+		closeWg  sync.WaitGroup
+	)
+	doneWg.Add(1)
+	closeWg.Add(1)
+
+	scope := tally.NewTestScope("", nil)
+	opts := newConnectionPoolTestOptions().
+		SetMaxConnectionCount(1).
+		SetBackgroundConnectInterval(10 * time.Millisecond).
+		SetBackgroundConnectStutter(0).
+		SetBackgroundConnectMaxBackoff(40 * time.Millisecond)
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(scope))
+
+	conns := newConnectionPool(h, opts).(*connPool)
+	conns.newConn = func(ch string, addr string, opts Options) (xclose.SimpleCloser, rpc.TChanNode, error) {
+		if atomic.AddInt32(&attempts, 1) <= 3 {
+			return nil, nil, fmt.Errorf("a connect error")
+		}
+		return channelNone, nil, nil
+	}
+	conns.healthCheckNewConn = func(client rpc.TChanNode, opts Options) error {
+		return nil
+	}
+	conns.healthCheck = func(client rpc.TChanNode, opts Options) error {
+		return nil
+	}
+	conns.sleepConnect = func(d time.Duration) {
+		sleepsMu.Lock()
+		sleeps = append(sleeps, d)
+		n := len(sleeps)
+		sleepsMu.Unlock()
+		if n == 4 {
+			doneWg.Done()
+			closeWg.Wait()
+		}
+	}
+
+	conns.Open()
+	doneWg.Wait()
+
+	sleepsMu.Lock()
+	require.Len(t, sleeps, 4)
+	for i, maxBackoff := range []time.Duration{
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		40 * time.Millisecond,
+	} {
+		assert.True(t, sleeps[i] >= maxBackoff/2 && sleeps[i] <= maxBackoff,
+			"sleep %d of %v not within [%v, %v]", i, sleeps[i], maxBackoff/2, maxBackoff)
+	}
+	assert.Equal(t, 10*time.Millisecond, sleeps[3])
+	sleepsMu.Unlock()
+
+	assert.Equal(t, 1, conns.ConnectionCount())
+
+	snapshot := scope.Snapshot()
+	tags := map[string]string{"host": testHostStr}
+	counters := snapshot.Counters()
+	connectErrors, ok := counters[tally.KeyForPrefixedStringMap("connection-pool.connect.errors", tags)]
+	require.True(t, ok)
+	assert.Equal(t, int64(3), connectErrors.Value())
+	connectSuccess, ok := counters[tally.KeyForPrefixedStringMap("connection-pool.connect.success", tags)]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), connectSuccess.Value())
+	connections, ok := snapshot.Gauges()[tally.KeyForPrefixedStringMap("connection-pool.connections", tags)]
+	require.True(t, ok)
+	assert.Equal(t, float64(1), connections.Value())
+
+	conns.Close()
+	closeWg.Done()
+}
+
 func TestConnectionPoolHealthChecks(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
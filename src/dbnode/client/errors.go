@@ -23,9 +23,11 @@ package client
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	xerrors "github.com/m3db/m3x/errors"
 )
 
@@ -54,6 +56,49 @@ func IsBadRequestError(err error) bool {
 	return false
 }
 
+// IsResourceExhaustedError determines if the error is a resource exhausted
+// error returned by a host that is applying backpressure
+func IsResourceExhaustedError(err error) bool {
+	_, ok := ResourceExhaustedRetryAfter(err)
+	return ok
+}
+
+// ResourceExhaustedRetryAfter returns the hint of how long to wait before
+// retrying given by a host returning a resource exhausted error and whether
+// the error is a resource exhausted error
+func ResourceExhaustedRetryAfter(err error) (time.Duration, bool) {
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsResourceExhaustedError(e) {
+			return time.Duration(e.GetRetryAfterMillis()) * time.Millisecond, true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return 0, false
+}
+
+// ErrorCode returns the code classifying the error, a consistency error is
+// classified as a bad request if any host rejected the request as such
+func ErrorCode(err error) m3dberrors.Code {
+	for e := err; e != nil; e = xerrors.InnerError(e) {
+		if rpcErr, ok := e.(*rpc.Error); ok && rpcErr != nil {
+			return tterrors.CodeFromErrorType(rpcErr.Type)
+		}
+		if _, ok := e.(consistencyResultError); ok {
+			if IsBadRequestError(e) {
+				return m3dberrors.CodeBadRequest
+			}
+			return m3dberrors.CodeConsistencyNotAchieved
+		}
+	}
+	return m3dberrors.GetCode(err)
+}
+
+// IsRetryableError determines if the error may succeed if the operation
+// is retried, bad requests and corrupt data are not retryable
+func IsRetryableError(err error) bool {
+	return ErrorCode(err).Retryable()
+}
+
 // NumResponded returns how many nodes responded for a given error
 func NumResponded(err error) int {
 	for err != nil {
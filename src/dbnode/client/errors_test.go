@@ -21,11 +21,15 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xerrors "github.com/m3db/m3x/errors"
 
@@ -54,3 +58,94 @@ func TestConsistencyResultError(t *testing.T) {
 	assert.Equal(t, 1, NumSuccess(err))
 	assert.Equal(t, 2, NumError(err))
 }
+
+func TestResourceExhaustedError(t *testing.T) {
+	rpcErr := tterrors.NewResourceExhaustedError(errors.New("saturated"),
+		250*time.Millisecond)
+
+	err := consistencyResultErr{
+		level:       topology.ConsistencyLevelMajority,
+		enqueued:    3,
+		responded:   3,
+		topLevelErr: rpcErr,
+		errs:        []error{rpcErr, rpcErr, rpcErr},
+	}
+
+	assert.True(t, IsResourceExhaustedError(err))
+	assert.False(t, IsInternalServerError(err))
+	assert.False(t, IsBadRequestError(err))
+
+	retryAfter, ok := ResourceExhaustedRetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 250*time.Millisecond, retryAfter)
+
+	_, ok = ResourceExhaustedRetryAfter(tterrors.NewInternalError(errors.New("internal")))
+	assert.False(t, ok)
+}
+
+func TestErrorCode(t *testing.T) {
+	newRPCError := func(errType rpc.ErrorType) error {
+		return &rpc.Error{Type: errType, Message: "an error"}
+	}
+	newConsistencyError := func(errs ...error) error {
+		return newConsistencyResultError(topology.ConsistencyLevelMajority,
+			3, 3, errs)
+	}
+
+	tests := []struct {
+		err       error
+		code      m3dberrors.Code
+		retryable bool
+	}{
+		{
+			err:       newRPCError(rpc.ErrorType_INTERNAL_ERROR),
+			code:      m3dberrors.CodeInternal,
+			retryable: true,
+		},
+		{
+			err:       newRPCError(rpc.ErrorType_BAD_REQUEST),
+			code:      m3dberrors.CodeBadRequest,
+			retryable: false,
+		},
+		{
+			err:       newRPCError(rpc.ErrorType_UNAVAILABLE),
+			code:      m3dberrors.CodeUnavailable,
+			retryable: true,
+		},
+		{
+			err:       newRPCError(rpc.ErrorType_DATA_CORRUPTION),
+			code:      m3dberrors.CodeDataCorruption,
+			retryable: false,
+		},
+		{
+			err:       xerrors.NewRetryableError(newRPCError(rpc.ErrorType_RESOURCE_EXHAUSTED)),
+			code:      m3dberrors.CodeResourceExhausted,
+			retryable: true,
+		},
+		{
+			err:       newConsistencyError(newRPCError(rpc.ErrorType_UNAVAILABLE)),
+			code:      m3dberrors.CodeConsistencyNotAchieved,
+			retryable: true,
+		},
+		{
+			err: newConsistencyError(newRPCError(rpc.ErrorType_INTERNAL_ERROR),
+				newRPCError(rpc.ErrorType_BAD_REQUEST)),
+			code:      m3dberrors.CodeBadRequest,
+			retryable: false,
+		},
+		{
+			err:       xerrors.NewInvalidParamsError(errors.New("invalid")),
+			code:      m3dberrors.CodeBadRequest,
+			retryable: false,
+		},
+		{
+			err:       errors.New("unclassified"),
+			code:      m3dberrors.CodeInternal,
+			retryable: true,
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.code, ErrorCode(test.err), test.err.Error())
+		assert.Equal(t, test.retryable, IsRetryableError(test.err), test.err.Error())
+	}
+}
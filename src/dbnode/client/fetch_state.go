@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3x/ident"
@@ -89,12 +90,23 @@ func (f *fetchState) Reset(
 	startTime time.Time,
 	endTime time.Time,
 	op *fetchTaggedOp, topoMap topology.Map,
+	shardFn sharding.HashFn,
+	hostIdxs []int,
 	majority int,
 	consistencyLevel topology.ReadConsistencyLevel,
 ) {
 	op.incRef() // take a reference to the provided op
 	f.op = op
 	f.tagResultAccumulator.Reset(startTime, endTime, topoMap, majority, consistencyLevel)
+	if shardFn != nil {
+		// Series of namespaces with a hash strategy are not assigned to
+		// shards by the topology shard set hash function
+		f.tagResultAccumulator.shardFn = shardFn
+	}
+	if hostIdxs != nil {
+		// Only the hosts at the given indexes are enqueued to
+		f.tagResultAccumulator.restrictHosts(hostIdxs)
+	}
 }
 
 func (f *fetchState) completionFn(
@@ -22,6 +22,7 @@ package client
 
 import (
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	"github.com/m3db/m3x/pool"
 )
 
@@ -32,6 +33,7 @@ var (
 type fetchTaggedOp struct {
 	refCounter
 	request      rpc.FetchTaggedRequest
+	trace        tracing.SpanContext
 	completionFn completionFn
 
 	pool fetchTaggedOpPool
@@ -55,6 +57,7 @@ func (f *fetchTaggedOp) requestLimit(defaultValue int) int {
 func (f *fetchTaggedOp) close() {
 	f.completionFn = nil
 	f.request = fetchTaggedOpRequestZeroed
+	f.trace = tracing.SpanContext{}
 	// return to pool
 	if f.pool == nil {
 		return
@@ -28,9 +28,11 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3cluster/shard"
 	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
 )
 
 type fetchTaggedResultAccumulatorOpts struct {
@@ -61,6 +63,7 @@ type fetchTaggedResultAccumulator struct {
 	majority         int
 	consistencyLevel topology.ReadConsistencyLevel
 	topoMap          topology.Map
+	shardFn          sharding.HashFn
 }
 
 type fetchTaggedShardConsistencyResult struct {
@@ -180,6 +183,7 @@ func (accum *fetchTaggedResultAccumulator) Clear() {
 	accum.majority, accum.numHostsPending, accum.numShardsPending = 0, 0, 0
 	accum.startTime, accum.endTime = time.Time{}, time.Time{}
 	accum.topoMap = nil
+	accum.shardFn = nil
 	accum.exhaustive = true
 }
 
@@ -194,6 +198,7 @@ func (accum *fetchTaggedResultAccumulator) Reset(
 	accum.startTime = startTime
 	accum.endTime = endTime
 	accum.topoMap = topoMap
+	accum.shardFn = topoMap.ShardSet().HashFn()
 	accum.majority = majority
 	accum.consistencyLevel = consistencyLevel
 	accum.numHostsPending = int32(topoMap.HostsLen())
@@ -212,6 +217,21 @@ func (accum *fetchTaggedResultAccumulator) Reset(
 	}
 }
 
+// restrictHosts limits the responses expected to those from the hosts at the
+// given indexes of the topology, used when not fanning out to every host.
+func (accum *fetchTaggedResultAccumulator) restrictHosts(hostIdxs []int) {
+	accum.numHostsPending = int32(len(hostIdxs))
+	for i := range accum.shardConsistencyResults {
+		accum.shardConsistencyResults[i].enqueued = 0
+	}
+	hostShardSets := accum.topoMap.HostShardSets()
+	for _, idx := range hostIdxs {
+		for _, hShard := range hostShardSets[idx].ShardSet().All() {
+			accum.shardConsistencyResults[hShard.ID()].enqueued++
+		}
+	}
+}
+
 func (accum *fetchTaggedResultAccumulator) sliceResponsesAsSeriesIter(
 	pools fetchTaggedPools,
 	elems fetchTaggedIDResults,
@@ -238,12 +258,27 @@ func (accum *fetchTaggedResultAccumulator) sliceResponsesAsSeriesIter(
 	tsID := pools.CheckedBytesWrapper().Get(elem.ID)
 	nsID := pools.CheckedBytesWrapper().Get(elem.NameSpace)
 	seriesIter := pools.SeriesIterator().Get()
-	seriesIter.Reset(pools.ID().BinaryID(tsID), pools.ID().BinaryID(nsID),
+	seriesID := pools.ID().BinaryID(tsID)
+	seriesIter.Reset(seriesID, pools.ID().BinaryID(nsID),
 		decoder, accum.startTime, accum.endTime, iters)
+	seriesIter.SetCompleteness(accum.seriesCompleteness(seriesID))
 
 	return seriesIter
 }
 
+// seriesCompleteness returns the completeness of a series based on the
+// responses received for the shard it belongs to.
+func (accum *fetchTaggedResultAccumulator) seriesCompleteness(id ident.ID) float64 {
+	shardID := int(accum.shardFn(id))
+	if shardID >= len(accum.shardConsistencyResults) {
+		return 1
+	}
+
+	result := accum.shardConsistencyResults[shardID]
+	return topology.ReadConsistencyCompleteness(accum.consistencyLevel,
+		accum.majority, int(result.enqueued), int(result.success))
+}
+
 func (accum *fetchTaggedResultAccumulator) AsEncodingSeriesIterators(
 	limit int, pools fetchTaggedPools,
 ) (encoding.SeriesIterators, bool, error) {
@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/pool"
 
@@ -54,6 +55,8 @@ type queue struct {
 	opsArrayPool                               *opArrayPool
 	drainIn                                    chan []op
 	status                                     status
+	sleepFn                                    sleepFn
+	placementGenerationFn                      placementGenerationFn
 }
 
 func newHostQueue(
@@ -94,6 +97,8 @@ func newHostQueue(
 		ops:          opArrayPool.Get(),
 		opsArrayPool: opArrayPool,
 		drainIn:      make(chan []op, opsArraysLen),
+		sleepFn:      time.Sleep,
+		placementGenerationFn: hostQueueOpts.placementGenerationFn,
 	}
 }
 
@@ -207,7 +212,7 @@ func (q *queue) drain() {
 				if currWriteOpsByNamespace.lenAt(idx) == writeBatchSize {
 					// Reached write batch limit, write async and reset
 					q.asyncWrite(namespace, currWriteOpsByNamespace[idx].ops,
-						currWriteOpsByNamespace[idx].elems)
+						currWriteOpsByNamespace[idx].elems, 0)
 					currWriteOpsByNamespace.resetAt(idx)
 				}
 			case *writeTaggedOperation:
@@ -228,7 +233,7 @@ func (q *queue) drain() {
 				if currTaggedWriteOpsByNamespace.lenAt(idx) == writeBatchSize {
 					// Reached write batch limit, write async and reset
 					q.asyncTaggedWrite(namespace, currTaggedWriteOpsByNamespace[idx].ops,
-						currTaggedWriteOpsByNamespace[idx].elems)
+						currTaggedWriteOpsByNamespace[idx].elems, 0)
 					currTaggedWriteOpsByNamespace.resetAt(idx)
 				}
 			case *fetchBatchOp:
@@ -247,7 +252,7 @@ func (q *queue) drain() {
 		for i, writeOps := range currWriteOpsByNamespace {
 			if len(writeOps.ops) > 0 {
 				q.asyncWrite(writeOps.namespace, writeOps.ops,
-					writeOps.elems)
+					writeOps.elems, 0)
 			}
 			// Zero the element
 			currWriteOpsByNamespace[i] = namespaceWriteBatchOps{}
@@ -259,7 +264,7 @@ func (q *queue) drain() {
 		for i, writeOps := range currTaggedWriteOpsByNamespace {
 			if len(writeOps.ops) > 0 {
 				q.asyncTaggedWrite(writeOps.namespace, writeOps.ops,
-					writeOps.elems)
+					writeOps.elems, 0)
 			}
 			// Zero the element
 			currTaggedWriteOpsByNamespace[i] = namespaceWriteTaggedBatchOps{}
@@ -281,6 +286,7 @@ func (q *queue) asyncTaggedWrite(
 	namespace ident.ID,
 	ops []op,
 	elems []*rpc.WriteTaggedBatchRawRequestElement,
+	attempt int,
 ) {
 	q.Add(1)
 	// TODO(r): Use a worker pool to avoid creating new go routines for async writes
@@ -308,7 +314,7 @@ func (q *queue) asyncTaggedWrite(
 			return
 		}
 
-		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
+		ctx := q.newContext(q.opts.WriteRequestTimeout(), tracing.SpanContext{})
 		err = client.WriteTaggedBatchRaw(ctx, req)
 		if err == nil {
 			// All succeeded
@@ -318,19 +324,22 @@ func (q *queue) asyncTaggedWrite(
 		}
 
 		if batchErrs, ok := err.(*rpc.WriteBatchRawErrors); ok {
-			// Callback all writes with errors
-			hasErr := make(map[int]struct{})
-			for _, batchErr := range batchErrs.Errors {
-				op := ops[batchErr.Index]
-				op.CompletionFn()(q.host, batchErr.Err)
-				hasErr[int(batchErr.Index)] = struct{}{}
-			}
-			// Callback all writes with no errors
-			for i := range ops {
-				if _, ok := hasErr[i]; !ok {
-					// No error
-					ops[i].CompletionFn()(q.host, nil)
+			// Callback all writes that succeeded or failed terminally and
+			// resend only the elements that failed with a retryable error
+			retry := attempt < q.opts.WriteBatchElementRetries()
+			retryIdxs, backoff := q.completeWriteBatchErrs(ops, batchErrs, retry)
+			if len(retryIdxs) > 0 {
+				retryOps := q.opsArrayPool.Get()
+				retryElems := q.writeTaggedBatchRawRequestElementArrayPool.Get()
+				for _, idx := range retryIdxs {
+					retryOps = append(retryOps, ops[idx])
+					retryElems = append(retryElems, elems[idx])
+				}
+				if backoff > 0 {
+					// Host is applying backpressure, wait before resending
+					q.sleepFn(backoff)
 				}
+				q.asyncTaggedWrite(namespace, retryOps, retryElems, attempt+1)
 			}
 			cleanup()
 			return
@@ -346,6 +355,7 @@ func (q *queue) asyncWrite(
 	namespace ident.ID,
 	ops []op,
 	elems []*rpc.WriteBatchRawRequestElement,
+	attempt int,
 ) {
 	q.Add(1)
 	// TODO(r): Use a worker pool to avoid creating new go routines for async writes
@@ -373,7 +383,7 @@ func (q *queue) asyncWrite(
 			return
 		}
 
-		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
+		ctx := q.newContext(q.opts.WriteRequestTimeout(), tracing.SpanContext{})
 		err = client.WriteBatchRaw(ctx, req)
 		if err == nil {
 			// All succeeded
@@ -383,19 +393,22 @@ func (q *queue) asyncWrite(
 		}
 
 		if batchErrs, ok := err.(*rpc.WriteBatchRawErrors); ok {
-			// Callback all writes with errors
-			hasErr := make(map[int]struct{})
-			for _, batchErr := range batchErrs.Errors {
-				op := ops[batchErr.Index]
-				op.CompletionFn()(q.host, batchErr.Err)
-				hasErr[int(batchErr.Index)] = struct{}{}
-			}
-			// Callback all writes with no errors
-			for i := range ops {
-				if _, ok := hasErr[i]; !ok {
-					// No error
-					ops[i].CompletionFn()(q.host, nil)
+			// Callback all writes that succeeded or failed terminally and
+			// resend only the elements that failed with a retryable error
+			retry := attempt < q.opts.WriteBatchElementRetries()
+			retryIdxs, backoff := q.completeWriteBatchErrs(ops, batchErrs, retry)
+			if len(retryIdxs) > 0 {
+				retryOps := q.opsArrayPool.Get()
+				retryElems := q.writeBatchRawRequestElementArrayPool.Get()
+				for _, idx := range retryIdxs {
+					retryOps = append(retryOps, ops[idx])
+					retryElems = append(retryElems, elems[idx])
+				}
+				if backoff > 0 {
+					// Host is applying backpressure, wait before resending
+					q.sleepFn(backoff)
 				}
+				q.asyncWrite(namespace, retryOps, retryElems, attempt+1)
 			}
 			cleanup()
 			return
@@ -407,6 +420,56 @@ func (q *queue) asyncWrite(
 	}()
 }
 
+// completeWriteBatchErrs calls back the ops of a batch that returned per
+// element errors, if retry is set then the ops that failed with a retryable
+// error are not called back and instead their indexes are returned so that
+// just those elements can be resent. If the host signaled that it is
+// exhausted of resources then the backoff to wait before resending is
+// also returned.
+func (q *queue) completeWriteBatchErrs(
+	ops []op,
+	batchErrs *rpc.WriteBatchRawErrors,
+	retry bool,
+) ([]int, time.Duration) {
+	var (
+		retryIdxs  []int
+		backoff    time.Duration
+		maxBackoff = q.opts.WriteResourceExhaustedMaxBackoff()
+		hasErr     = make(map[int]struct{}, len(batchErrs.Errors))
+	)
+	// Callback all writes with errors
+	for _, batchErr := range batchErrs.Errors {
+		idx := int(batchErr.Index)
+		if idx < 0 || idx >= len(ops) {
+			continue
+		}
+		hasErr[idx] = struct{}{}
+		if retryAfter, ok := ResourceExhaustedRetryAfter(batchErr.Err); ok && retry {
+			if retryAfter > maxBackoff {
+				retryAfter = maxBackoff
+			}
+			if retryAfter > backoff {
+				backoff = retryAfter
+			}
+			retryIdxs = append(retryIdxs, idx)
+			continue
+		}
+		if retry && IsRetryableError(batchErr.Err) {
+			retryIdxs = append(retryIdxs, idx)
+			continue
+		}
+		ops[idx].CompletionFn()(q.host, batchErr.Err)
+	}
+	// Callback all writes with no errors
+	for i := range ops {
+		if _, ok := hasErr[i]; !ok {
+			// No error
+			ops[i].CompletionFn()(q.host, nil)
+		}
+	}
+	return retryIdxs, backoff
+}
+
 func (q *queue) asyncFetch(op *fetchBatchOp) {
 	q.Add(1)
 	// TODO(r): Use a worker pool to avoid creating new go routines for async fetches
@@ -426,7 +489,7 @@ func (q *queue) asyncFetch(op *fetchBatchOp) {
 			return
 		}
 
-		ctx, _ := thrift.NewContext(q.opts.FetchRequestTimeout())
+		ctx := q.newContext(q.opts.FetchRequestTimeout(), tracing.SpanContext{})
 		result, err := client.FetchBatchRaw(ctx, &op.request)
 		if err != nil {
 			op.completeAll(nil, err)
@@ -470,7 +533,7 @@ func (q *queue) asyncFetchTagged(op *fetchTaggedOp) {
 			return
 		}
 
-		ctx, _ := thrift.NewContext(q.opts.FetchRequestTimeout())
+		ctx := q.newContext(q.opts.FetchRequestTimeout(), op.trace)
 		result, err := client.FetchTagged(ctx, &op.request)
 		if err != nil {
 			op.CompletionFn()(fetchTaggedResultAccumulatorOpts{host: q.host}, err)
@@ -486,6 +549,31 @@ func (q *queue) asyncFetchTagged(op *fetchTaggedOp) {
 	}()
 }
 
+// newContext returns an RPC context carrying the placement generation the
+// request is routed with and the trace context of the request, if any.
+func (q *queue) newContext(
+	timeout time.Duration,
+	trace tracing.SpanContext,
+) thrift.Context {
+	ctx, _ := thrift.NewContext(timeout)
+
+	var generation int
+	if q.placementGenerationFn != nil {
+		generation = q.placementGenerationFn()
+	}
+	headers := topology.PlacementGenerationHeaders(generation)
+	for k, v := range tracing.InjectMap(trace) {
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[k] = v
+	}
+	if headers == nil {
+		return ctx
+	}
+	return thrift.WithHeaders(ctx, headers)
+}
+
 func (q *queue) asyncTruncate(op *truncateOp) {
 	q.Add(1)
 
@@ -500,7 +588,7 @@ func (q *queue) asyncTruncate(op *truncateOp) {
 			return
 		}
 
-		ctx, _ := thrift.NewContext(q.opts.TruncateRequestTimeout())
+		ctx := q.newContext(q.opts.TruncateRequestTimeout(), tracing.SpanContext{})
 		if res, err := client.Truncate(ctx, &op.request); err != nil {
 			op.completionFn(nil, err)
 		} else {
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -97,6 +98,49 @@ func TestHostQueueDrainOnCloseFetchTagged(t *testing.T) {
 	}
 }
 
+func TestHostQueueFetchTaggedPropagatesTrace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConnPool := NewMockconnectionPool(ctrl)
+
+	opts := newHostQueueTestOptions()
+	queue := newTestHostQueue(opts)
+	queue.connPool = mockConnPool
+
+	mockConnPool.EXPECT().Open()
+	queue.Open()
+
+	var wg sync.WaitGroup
+	callback := func(r interface{}, err error) {
+		assert.NoError(t, err)
+		wg.Done()
+	}
+
+	trace := tracing.SpanContext{
+		TraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:   "00f067aa0ba902b7",
+		Sampling: tracing.SamplingAccept,
+	}
+	fetch := testFetchTaggedOp("testNs", callback)
+	fetch.trace = trace
+
+	mockClient := rpc.NewMockTChanNode(ctrl)
+	fetchTagged := func(ctx thrift.Context, req *rpc.FetchTaggedRequest) {
+		sc, ok := tracing.Extract(tracing.MapCarrier(ctx.Headers()))
+		require.True(t, ok)
+		assert.Equal(t, trace, sc)
+	}
+	mockClient.EXPECT().FetchTagged(gomock.Any(), gomock.Any()).Do(fetchTagged).Return(nil, nil)
+	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
+	mockConnPool.EXPECT().Close().AnyTimes()
+
+	wg.Add(1)
+	require.NoError(t, queue.Enqueue(fetch))
+	queue.Close()
+	wg.Wait()
+}
+
 func TestHostQueueFetchTagged(t *testing.T) {
 	namespace := "testNs"
 	res := &rpc.FetchTaggedResult_{
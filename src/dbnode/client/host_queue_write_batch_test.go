@@ -31,6 +31,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/tchannel-go/thrift"
 )
 
@@ -254,7 +255,8 @@ func TestHostQueueWriteBatchesPartialBatchErrs(t *testing.T) {
 	mockConnPool := NewMockconnectionPool(ctrl)
 
 	opts := newHostQueueTestOptions()
-	opts = opts.SetHostQueueOpsFlushSize(2)
+	opts = opts.SetHostQueueOpsFlushSize(2).
+		SetWriteBatchElementRetries(0)
 	queue := newTestHostQueue(opts)
 	queue.connPool = mockConnPool
 
@@ -317,6 +319,161 @@ func TestHostQueueWriteBatchesPartialBatchErrs(t *testing.T) {
 	closeWg.Wait()
 }
 
+func TestHostQueueWriteBatchesPartialBatchErrsRetriesFailedElements(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConnPool := NewMockconnectionPool(ctrl)
+
+	opts := newHostQueueTestOptions()
+	opts = opts.SetHostQueueOpsFlushSize(3).
+		SetWriteBatchElementRetries(1)
+	queue := newTestHostQueue(opts)
+	queue.connPool = mockConnPool
+
+	// Open
+	mockConnPool.EXPECT().Open()
+	queue.Open()
+	assert.Equal(t, statusOpen, queue.status)
+
+	// Prepare writes
+	var wg sync.WaitGroup
+	badRequestErr := "a bad request error"
+	writes := []*writeOperation{
+		testWriteOp("testNs", "foo", 1.0, 1000, rpc.TimeType_UNIX_SECONDS, func(r interface{}, err error) {
+			// Retried and succeeded on the second attempt
+			assert.NoError(t, err)
+			wg.Done()
+		}),
+		testWriteOp("testNs", "bar", 2.0, 2000, rpc.TimeType_UNIX_SECONDS, func(r interface{}, err error) {
+			// Bad request errors are not retried
+			assert.Error(t, err)
+			assert.True(t, IsBadRequestError(err))
+			wg.Done()
+		}),
+		testWriteOp("testNs", "baz", 3.0, 3000, rpc.TimeType_UNIX_SECONDS, func(r interface{}, err error) {
+			assert.NoError(t, err)
+			wg.Done()
+		}),
+	}
+	wg.Add(len(writes))
+
+	// Prepare mocks for flush
+	mockClient := rpc.NewMockTChanNode(ctrl)
+	firstBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawRequest) {
+		require.Equal(t, len(writes), len(req.Elements))
+		for i, write := range writes {
+			assert.Equal(t, req.Elements[i].ID, write.request.ID)
+		}
+	}
+	firstBatchErrs := &rpc.WriteBatchRawErrors{Errors: []*rpc.WriteBatchRawError{
+		&rpc.WriteBatchRawError{Index: 0, Err: &rpc.Error{
+			Type:    rpc.ErrorType_INTERNAL_ERROR,
+			Message: "a write error",
+		}},
+		&rpc.WriteBatchRawError{Index: 1, Err: &rpc.Error{
+			Type:    rpc.ErrorType_BAD_REQUEST,
+			Message: badRequestErr,
+		}},
+	}}
+	retryBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawRequest) {
+		// Only the element that failed with a retryable error is resent
+		require.Equal(t, 1, len(req.Elements))
+		assert.Equal(t, writes[0].request.ID, req.Elements[0].ID)
+		assert.Equal(t, writes[0].request.Datapoint, req.Elements[0].Datapoint)
+	}
+	gomock.InOrder(
+		mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).
+			Do(firstBatch).Return(firstBatchErrs),
+		mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).
+			Do(retryBatch).Return(nil),
+	)
+	mockConnPool.EXPECT().NextClient().Return(mockClient, nil).Times(2)
+
+	// Perform writes
+	for _, write := range writes {
+		assert.NoError(t, queue.Enqueue(write))
+	}
+
+	// Wait for flush
+	wg.Wait()
+
+	// Close
+	var closeWg sync.WaitGroup
+	closeWg.Add(1)
+	mockConnPool.EXPECT().Close().Do(func() {
+		closeWg.Done()
+	})
+	queue.Close()
+	closeWg.Wait()
+}
+
+func TestHostQueueWriteBatchesResourceExhaustedBacksOff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConnPool := NewMockconnectionPool(ctrl)
+
+	opts := newHostQueueTestOptions()
+	opts = opts.SetHostQueueOpsFlushSize(1).
+		SetWriteBatchElementRetries(1).
+		SetWriteResourceExhaustedMaxBackoff(time.Second)
+	queue := newTestHostQueue(opts)
+	queue.connPool = mockConnPool
+
+	var slept []time.Duration
+	queue.sleepFn = func(d time.Duration) {
+		slept = append(slept, d)
+	}
+
+	// Open
+	mockConnPool.EXPECT().Open()
+	queue.Open()
+	assert.Equal(t, statusOpen, queue.status)
+
+	// Prepare writes
+	var wg sync.WaitGroup
+	write := testWriteOp("testNs", "foo", 1.0, 1000, rpc.TimeType_UNIX_SECONDS, func(r interface{}, err error) {
+		assert.NoError(t, err)
+		wg.Done()
+	})
+	wg.Add(1)
+
+	// Prepare mocks for flush
+	retryAfterMillis := int64(5000)
+	batchErrs := &rpc.WriteBatchRawErrors{Errors: []*rpc.WriteBatchRawError{
+		&rpc.WriteBatchRawError{Index: 0, Err: &rpc.Error{
+			Type:             rpc.ErrorType_RESOURCE_EXHAUSTED,
+			Message:          "index insert queue is saturated",
+			RetryAfterMillis: &retryAfterMillis,
+		}},
+	}}
+	mockClient := rpc.NewMockTChanNode(ctrl)
+	gomock.InOrder(
+		mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Return(batchErrs),
+		mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Return(nil),
+	)
+	mockConnPool.EXPECT().NextClient().Return(mockClient, nil).Times(2)
+
+	// Perform write
+	assert.NoError(t, queue.Enqueue(write))
+
+	// Wait for flush
+	wg.Wait()
+
+	// Backoff hint from the host is capped at the max backoff
+	assert.Equal(t, []time.Duration{time.Second}, slept)
+
+	// Close
+	var closeWg sync.WaitGroup
+	closeWg.Add(1)
+	mockConnPool.EXPECT().Close().Do(func() {
+		closeWg.Done()
+	})
+	queue.Close()
+	closeWg.Wait()
+}
+
 func TestHostQueueWriteBatchesEntireBatchErr(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
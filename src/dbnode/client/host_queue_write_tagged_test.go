@@ -281,7 +281,8 @@ func TestHostQueueWriteTaggedBatchesPartialBatchErrs(t *testing.T) {
 	mockConnPool := NewMockconnectionPool(ctrl)
 
 	opts := newHostQueueTestOptions()
-	opts = opts.SetHostQueueOpsFlushSize(2)
+	opts = opts.SetHostQueueOpsFlushSize(2).
+		SetWriteBatchElementRetries(0)
 	queue := newTestHostQueue(opts)
 	queue.connPool = mockConnPool
 
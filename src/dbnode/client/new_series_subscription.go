@@ -0,0 +1,282 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const (
+	defaultNewSeriesSubscriptionBufferSize  = 1024
+	defaultNewSeriesSubscriptionPollTimeout = 10 * time.Second
+	defaultNewSeriesSubscriptionPollLimit   = 1024
+
+	// newSeriesSubscriptionRequestTimeoutPadding is added to the poll timeout
+	// for the request timeout so polls that wait the full poll timeout do not
+	// time out in flight.
+	newSeriesSubscriptionRequestTimeoutPadding = 5 * time.Second
+	newSeriesSubscriptionRetryInterval         = time.Second
+	newSeriesSubscriptionCloseTimeout          = 5 * time.Second
+)
+
+// SubscribeNewSeries subscribes to series created in the namespace whose
+// tags match the query. Each shard is subscribed to on a single replica that
+// has the shard available, the subscription is made against the placement at
+// the time of subscribing and callers should resubscribe after placement
+// changes to follow shards to their new replicas.
+func (s *session) SubscribeNewSeries(
+	namespace ident.ID,
+	q index.Query,
+	opts NewSeriesSubscriptionOptions,
+) (NewSeriesSubscription, error) {
+	query, err := idx.Marshal(q.Query)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultNewSeriesSubscriptionBufferSize
+	}
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = defaultNewSeriesSubscriptionPollTimeout
+	}
+	if opts.PollLimit <= 0 {
+		opts.PollLimit = defaultNewSeriesSubscriptionPollLimit
+	}
+
+	s.state.RLock()
+	if s.state.status != statusOpen {
+		s.state.RUnlock()
+		return nil, errSessionStatusNotOpen
+	}
+	var (
+		topoMap      = s.state.topoMap
+		queues       = s.state.queues
+		seed         = atomic.AddUint32(&s.followerReadSeed, 1)
+		shardsByHost = make(map[int][]int32)
+	)
+	for _, shard := range topoMap.ShardSet().AllIDs() {
+		hostIdx, ok := followerReadHostIdx(topoMap, shard, seed)
+		if !ok {
+			s.state.RUnlock()
+			return nil, fmt.Errorf(
+				"unable to subscribe to new series: shard %d not available on any host", shard)
+		}
+		shardsByHost[hostIdx] = append(shardsByHost[hostIdx], int32(shard))
+	}
+	s.state.RUnlock()
+
+	var (
+		timeoutMillis = int64(opts.PollTimeout / time.Millisecond)
+		limit         = int64(opts.PollLimit)
+		pollers       = make([]*newSeriesHostPoller, 0, len(shardsByHost))
+	)
+	for hostIdx, shards := range shardsByHost {
+		pollers = append(pollers, &newSeriesHostPoller{
+			queue:          queues[hostIdx],
+			requestTimeout: opts.PollTimeout + newSeriesSubscriptionRequestTimeoutPadding,
+			request: rpc.SubscribeNewSeriesRequest{
+				NameSpace:     append([]byte(nil), namespace.Bytes()...),
+				Query:         query,
+				Shards:        shards,
+				TimeoutMillis: &timeoutMillis,
+				Limit:         &limit,
+			},
+		})
+	}
+
+	sub := newNewSeriesSubscription(opts.BufferSize, s.pools.tagDecoder, s.log)
+	sub.start(pollers)
+	return sub, nil
+}
+
+type newSeriesSubscription struct {
+	ch         chan NewSeries
+	dropped    int64
+	closed     chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+	tagDecoder serialize.TagDecoderPool
+	log        xlog.Logger
+}
+
+func newNewSeriesSubscription(
+	bufferSize int,
+	tagDecoder serialize.TagDecoderPool,
+	log xlog.Logger,
+) *newSeriesSubscription {
+	return &newSeriesSubscription{
+		ch:         make(chan NewSeries, bufferSize),
+		closed:     make(chan struct{}),
+		tagDecoder: tagDecoder,
+		log:        log,
+	}
+}
+
+// start starts the pollers and closes the channel new series are delivered
+// on once all pollers have stopped.
+func (s *newSeriesSubscription) start(pollers []*newSeriesHostPoller) {
+	s.wg.Add(len(pollers))
+	for _, poller := range pollers {
+		poller.sub = s
+		go poller.run()
+	}
+	go func() {
+		s.wg.Wait()
+		close(s.ch)
+	}()
+}
+
+func (s *newSeriesSubscription) C() <-chan NewSeries {
+	return s.ch
+}
+
+func (s *newSeriesSubscription) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+func (s *newSeriesSubscription) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+}
+
+func (s *newSeriesSubscription) newSeries(elem *rpc.NewSeriesElement) (NewSeries, error) {
+	dec := s.tagDecoder.Get()
+	defer dec.Close()
+
+	dec.Reset(checked.NewBytes(elem.EncodedTags, nil))
+	tags := ident.NewTags()
+	for dec.Next() {
+		tag := dec.Current()
+		tags.Append(ident.StringTag(tag.Name.String(), tag.Value.String()))
+	}
+	if err := dec.Err(); err != nil {
+		return NewSeries{}, err
+	}
+
+	return NewSeries{
+		Shard: uint32(elem.Shard),
+		ID:    ident.BinaryID(checked.NewBytes(elem.ID, nil)),
+		Tags:  tags,
+	}, nil
+}
+
+// newSeriesHostPoller long polls the subscription made to a single host for
+// the shards assigned to it, subscribing again if the host loses the
+// subscription, for instance after it restarts.
+type newSeriesHostPoller struct {
+	sub            *newSeriesSubscription
+	queue          hostQueue
+	request        rpc.SubscribeNewSeriesRequest
+	requestTimeout time.Duration
+	lastDropped    int64
+}
+
+func (p *newSeriesHostPoller) run() {
+	defer p.sub.wg.Done()
+
+	for {
+		select {
+		case <-p.sub.closed:
+			p.close()
+			return
+		default:
+		}
+
+		result, err := p.poll()
+		if err != nil {
+			p.sub.log.Warnf("new series subscription poll to %s failed: %v",
+				p.queue.Host().ID(), err)
+			p.request.SubscriptionID = nil
+			p.lastDropped = 0
+			select {
+			case <-time.After(newSeriesSubscriptionRetryInterval):
+			case <-p.sub.closed:
+				return
+			}
+			continue
+		}
+
+		subscriptionID := result.SubscriptionID
+		p.request.SubscriptionID = &subscriptionID
+		if result.Dropped > p.lastDropped {
+			atomic.AddInt64(&p.sub.dropped, result.Dropped-p.lastDropped)
+			p.lastDropped = result.Dropped
+		}
+
+		for _, elem := range result.Elements {
+			series, err := p.sub.newSeries(elem)
+			if err != nil {
+				p.sub.log.Errorf("unable to decode new series tags: %v", err)
+				continue
+			}
+			select {
+			case p.sub.ch <- series:
+			case <-p.sub.closed:
+				p.close()
+				return
+			}
+		}
+	}
+}
+
+func (p *newSeriesHostPoller) poll() (*rpc.SubscribeNewSeriesResult_, error) {
+	var (
+		result *rpc.SubscribeNewSeriesResult_
+		err    error
+	)
+	borrowErr := p.queue.BorrowConnection(func(client rpc.TChanNode) {
+		tctx, _ := thrift.NewContext(p.requestTimeout)
+		result, err = client.SubscribeNewSeries(tctx, &p.request)
+	})
+	if borrowErr != nil {
+		return nil, borrowErr
+	}
+	return result, err
+}
+
+// close closes the subscription on the host on a best effort basis, the host
+// expires the subscription if it is not polled anyway.
+func (p *newSeriesHostPoller) close() {
+	if p.request.SubscriptionID == nil {
+		return
+	}
+	closeSub := true
+	req := p.request
+	req.Close = &closeSub
+	p.queue.BorrowConnection(func(client rpc.TChanNode) {
+		tctx, _ := thrift.NewContext(newSeriesSubscriptionCloseTimeout)
+		client.SubscribeNewSeries(tctx, &req)
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+func TestNewSeriesSubscriptionPollsHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	encoderPool := serialize.NewTagEncoderPool(serialize.NewTagEncoderOptions(), nil)
+	encoderPool.Init()
+	decoderPool := serialize.NewTagDecoderPool(serialize.NewTagDecoderOptions(), nil)
+	decoderPool.Init()
+
+	enc := encoderPool.Get()
+	require.NoError(t, enc.Encode(ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("foo", "bar"),
+	))))
+	encodedTags, ok := enc.Data()
+	require.True(t, ok)
+
+	client := rpc.NewMockTChanNode(ctrl)
+	queue := NewMockhostQueue(ctrl)
+	queue.EXPECT().BorrowConnection(gomock.Any()).Do(func(fn withConnectionFn) {
+		fn(client)
+	}).Return(nil).AnyTimes()
+
+	sub := newNewSeriesSubscription(1, decoderPool, xlog.NullLogger)
+	subscriptionID := int64(7)
+	gomock.InOrder(
+		client.EXPECT().SubscribeNewSeries(gomock.Any(), gomock.Any()).
+			Do(func(_ thrift.Context, req *rpc.SubscribeNewSeriesRequest) {
+				assert.Nil(t, req.SubscriptionID)
+				assert.Equal(t, []int32{1, 3}, req.Shards)
+			}).
+			Return(&rpc.SubscribeNewSeriesResult_{
+				SubscriptionID: subscriptionID,
+				Elements: []*rpc.NewSeriesElement{
+					{ID: []byte("foo"), EncodedTags: encodedTags.Bytes(), Shard: 3},
+				},
+				Dropped: 2,
+			}, nil),
+		client.EXPECT().SubscribeNewSeries(gomock.Any(), gomock.Any()).
+			Do(func(_ thrift.Context, req *rpc.SubscribeNewSeriesRequest) {
+				require.NotNil(t, req.SubscriptionID)
+				assert.Equal(t, subscriptionID, *req.SubscriptionID)
+				sub.Close()
+			}).
+			Return(&rpc.SubscribeNewSeriesResult_{
+				SubscriptionID: subscriptionID,
+				Dropped:        5,
+			}, nil),
+		client.EXPECT().SubscribeNewSeries(gomock.Any(), gomock.Any()).
+			Do(func(_ thrift.Context, req *rpc.SubscribeNewSeriesRequest) {
+				require.NotNil(t, req.Close)
+				assert.True(t, *req.Close)
+			}).
+			Return(&rpc.SubscribeNewSeriesResult_{SubscriptionID: subscriptionID}, nil),
+	)
+
+	sub.start([]*newSeriesHostPoller{{
+		queue:   queue,
+		request: rpc.SubscribeNewSeriesRequest{Shards: []int32{1, 3}},
+	}})
+
+	var received []NewSeries
+	for series := range sub.C() {
+		received = append(received, series)
+	}
+	require.Equal(t, 1, len(received))
+	assert.Equal(t, uint32(3), received[0].Shard)
+	assert.Equal(t, "foo", received[0].ID.String())
+	assert.True(t, received[0].Tags.Equal(ident.NewTags(ident.StringTag("foo", "bar"))))
+	assert.Equal(t, int64(5), sub.Dropped())
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
@@ -67,6 +68,10 @@ const (
 	// defaultClusterConnectTimeout is the default cluster connect timeout
 	defaultClusterConnectTimeout = 30 * time.Second
 
+	// defaultTopologyChangeWarmUpTimeout is the default max time to wait for
+	// the connection pools of new hosts to fill on a topology change
+	defaultTopologyChangeWarmUpTimeout = 10 * time.Second
+
 	// defaultClusterConnectConsistencyLevel is the default cluster connect consistency level
 	defaultClusterConnectConsistencyLevel = topology.ConnectConsistencyLevelAny
 
@@ -100,6 +105,14 @@ const (
 	// defaultHostQueueOpsFlushSize is the default host queue ops flush size
 	defaultHostQueueOpsFlushSize = 128
 
+	// defaultWriteBatchElementRetries is the default number of times elements
+	// of a write batch that failed with a retryable error are retried
+	defaultWriteBatchElementRetries = 1
+
+	// defaultWriteResourceExhaustedMaxBackoff is the default max backoff before
+	// resending elements of a write batch to a host that is applying backpressure
+	defaultWriteResourceExhaustedMaxBackoff = time.Second
+
 	// defaultHostQueueOpsFlushInterval is the default host queue flush interval
 	defaultHostQueueOpsFlushInterval = 5 * time.Millisecond
 
@@ -112,6 +125,10 @@ const (
 	// defaultBackgroundConnectStutter is the default background connect stutter
 	defaultBackgroundConnectStutter = 2 * time.Second
 
+	// defaultBackgroundConnectMaxBackoff is the default max backoff between
+	// background connect attempts to a host that keeps failing to connect
+	defaultBackgroundConnectMaxBackoff = 30 * time.Second
+
 	// defaultBackgroundHealthCheckInterval is the default background health check interval
 	defaultBackgroundHealthCheckInterval = 4 * time.Second
 
@@ -187,6 +204,7 @@ type options struct {
 	clockOpts                               clock.Options
 	instrumentOpts                          instrument.Options
 	topologyInitializer                     topology.Initializer
+	namespaceInitializer                    namespace.Initializer
 	readConsistencyLevel                    topology.ReadConsistencyLevel
 	writeConsistencyLevel                   topology.ConsistencyLevel
 	bootstrapConsistencyLevel               topology.ReadConsistencyLevel
@@ -195,12 +213,14 @@ type options struct {
 	minConnectionCount                      int
 	hostConnectTimeout                      time.Duration
 	clusterConnectTimeout                   time.Duration
+	topologyChangeWarmUpTimeout             time.Duration
 	clusterConnectConsistencyLevel          topology.ConnectConsistencyLevel
 	writeRequestTimeout                     time.Duration
 	fetchRequestTimeout                     time.Duration
 	truncateRequestTimeout                  time.Duration
 	backgroundConnectInterval               time.Duration
 	backgroundConnectStutter                time.Duration
+	backgroundConnectMaxBackoff             time.Duration
 	backgroundHealthCheckInterval           time.Duration
 	backgroundHealthCheckStutter            time.Duration
 	backgroundHealthCheckFailLimit          int
@@ -220,6 +240,8 @@ type options struct {
 	fetchBatchSize                          int
 	identifierPool                          ident.Pool
 	hostQueueOpsFlushSize                   int
+	writeBatchElementRetries                int
+	writeResourceExhaustedMaxBackoff        time.Duration
 	hostQueueOpsFlushInterval               time.Duration
 	hostQueueOpsArrayPoolSize               int
 	seriesIteratorPoolSize                  int
@@ -275,12 +297,14 @@ func newOptions() *options {
 		minConnectionCount:                      defaultMinConnectionCount,
 		hostConnectTimeout:                      defaultHostConnectTimeout,
 		clusterConnectTimeout:                   defaultClusterConnectTimeout,
+		topologyChangeWarmUpTimeout:             defaultTopologyChangeWarmUpTimeout,
 		clusterConnectConsistencyLevel:          defaultClusterConnectConsistencyLevel,
 		writeRequestTimeout:                     defaultWriteRequestTimeout,
 		fetchRequestTimeout:                     defaultFetchRequestTimeout,
 		truncateRequestTimeout:                  defaultTruncateRequestTimeout,
 		backgroundConnectInterval:               defaultBackgroundConnectInterval,
 		backgroundConnectStutter:                defaultBackgroundConnectStutter,
+		backgroundConnectMaxBackoff:             defaultBackgroundConnectMaxBackoff,
 		backgroundHealthCheckInterval:           defaultBackgroundHealthCheckInterval,
 		backgroundHealthCheckStutter:            defaultBackgroundHealthCheckStutter,
 		backgroundHealthCheckFailLimit:          defaultBackgroundHealthCheckFailLimit,
@@ -299,6 +323,8 @@ func newOptions() *options {
 		fetchBatchSize:                          defaultFetchBatchSize,
 		identifierPool:                          idPool,
 		hostQueueOpsFlushSize:                   defaultHostQueueOpsFlushSize,
+		writeBatchElementRetries:                defaultWriteBatchElementRetries,
+		writeResourceExhaustedMaxBackoff:        defaultWriteResourceExhaustedMaxBackoff,
 		hostQueueOpsFlushInterval:               defaultHostQueueOpsFlushInterval,
 		hostQueueOpsArrayPoolSize:               defaultHostQueueOpsArrayPoolSize,
 		seriesIteratorPoolSize:                  defaultSeriesIteratorPoolSize,
@@ -389,6 +415,16 @@ func (o *options) TopologyInitializer() topology.Initializer {
 	return o.topologyInitializer
 }
 
+func (o *options) SetNamespaceInitializer(value namespace.Initializer) Options {
+	opts := *o
+	opts.namespaceInitializer = value
+	return &opts
+}
+
+func (o *options) NamespaceInitializer() namespace.Initializer {
+	return o.namespaceInitializer
+}
+
 func (o *options) SetReadConsistencyLevel(value topology.ReadConsistencyLevel) Options {
 	opts := *o
 	opts.readConsistencyLevel = value
@@ -469,6 +505,16 @@ func (o *options) ClusterConnectTimeout() time.Duration {
 	return o.clusterConnectTimeout
 }
 
+func (o *options) SetTopologyChangeWarmUpTimeout(value time.Duration) Options {
+	opts := *o
+	opts.topologyChangeWarmUpTimeout = value
+	return &opts
+}
+
+func (o *options) TopologyChangeWarmUpTimeout() time.Duration {
+	return o.topologyChangeWarmUpTimeout
+}
+
 func (o *options) SetClusterConnectConsistencyLevel(value topology.ConnectConsistencyLevel) Options {
 	opts := *o
 	opts.clusterConnectConsistencyLevel = value
@@ -516,7 +562,7 @@ func (o *options) SetBackgroundConnectInterval(value time.Duration) Options {
 }
 
 func (o *options) BackgroundConnectInterval() time.Duration {
-	return o.writeRequestTimeout
+	return o.backgroundConnectInterval
 }
 
 func (o *options) SetBackgroundConnectStutter(value time.Duration) Options {
@@ -529,6 +575,16 @@ func (o *options) BackgroundConnectStutter() time.Duration {
 	return o.backgroundConnectStutter
 }
 
+func (o *options) SetBackgroundConnectMaxBackoff(value time.Duration) Options {
+	opts := *o
+	opts.backgroundConnectMaxBackoff = value
+	return &opts
+}
+
+func (o *options) BackgroundConnectMaxBackoff() time.Duration {
+	return o.backgroundConnectMaxBackoff
+}
+
 func (o *options) SetBackgroundHealthCheckInterval(value time.Duration) Options {
 	opts := *o
 	opts.backgroundHealthCheckInterval = value
@@ -729,6 +785,26 @@ func (o *options) HostQueueOpsFlushSize() int {
 	return o.hostQueueOpsFlushSize
 }
 
+func (o *options) SetWriteBatchElementRetries(value int) Options {
+	opts := *o
+	opts.writeBatchElementRetries = value
+	return &opts
+}
+
+func (o *options) WriteBatchElementRetries() int {
+	return o.writeBatchElementRetries
+}
+
+func (o *options) SetWriteResourceExhaustedMaxBackoff(value time.Duration) Options {
+	opts := *o
+	opts.writeResourceExhaustedMaxBackoff = value
+	return &opts
+}
+
+func (o *options) WriteResourceExhaustedMaxBackoff() time.Duration {
+	return o.writeResourceExhaustedMaxBackoff
+}
+
 func (o *options) SetHostQueueOpsFlushInterval(value time.Duration) Options {
 	opts := *o
 	opts.hostQueueOpsFlushInterval = value
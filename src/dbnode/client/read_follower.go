@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3cluster/shard"
+)
+
+// followerReadHostIdx returns the index of the single host to read a shard
+// from when reading with topology.ReadConsistencyLevelFollower, rotating
+// between the replicas with the shard available using the seed to spread
+// reads across them. Returns false if no replica has the shard available.
+func followerReadHostIdx(
+	topoMap topology.Map,
+	shardID uint32,
+	seed uint32,
+) (int, bool) {
+	available := 0
+	if err := topoMap.RouteShardForEach(shardID, func(_ int, host topology.Host) {
+		if hostShardAvailable(topoMap, host, shardID) {
+			available++
+		}
+	}); err != nil || available == 0 {
+		return 0, false
+	}
+
+	var (
+		target = int(seed % uint32(available))
+		curr   = 0
+		result = -1
+	)
+	topoMap.RouteShardForEach(shardID, func(idx int, host topology.Host) {
+		if !hostShardAvailable(topoMap, host, shardID) {
+			return
+		}
+		if curr == target {
+			result = idx
+		}
+		curr++
+	})
+	return result, result >= 0
+}
+
+// followerReadHostIdxs returns the indexes of a subset of hosts that together
+// have every shard available once, used to fan out index queries when reading
+// with topology.ReadConsistencyLevelFollower. The seed rotates the hosts
+// considered first to spread reads across replicas. Returns false if some
+// shard is not available on any host.
+func followerReadHostIdxs(
+	topoMap topology.Map,
+	seed uint32,
+) ([]int, bool) {
+	var (
+		hostShardSets = topoMap.HostShardSets()
+		numHosts      = len(hostShardSets)
+		coverage      = make([]int, 1+int(topoMap.ShardSet().Max()))
+		remaining     = len(topoMap.ShardSet().All())
+		result        []int
+	)
+	if numHosts == 0 {
+		return nil, false
+	}
+
+	// Greedily select hosts that have a shard available not yet covered.
+	for i := 0; i < numHosts && remaining > 0; i++ {
+		idx := (int(seed) + i) % numHosts
+		selected := false
+		for _, s := range hostShardSets[idx].ShardSet().All() {
+			if s.State() != shard.Available {
+				continue
+			}
+			if coverage[s.ID()] == 0 {
+				remaining--
+				selected = true
+			}
+		}
+		if !selected {
+			continue
+		}
+		for _, s := range hostShardSets[idx].ShardSet().All() {
+			if s.State() == shard.Available {
+				coverage[s.ID()]++
+			}
+		}
+		result = append(result, idx)
+	}
+	if remaining > 0 {
+		return nil, false
+	}
+
+	// Drop selected hosts whose available shards are all covered by others.
+	selected := result[:0]
+	for _, idx := range result {
+		redundant := true
+		for _, s := range hostShardSets[idx].ShardSet().All() {
+			if s.State() == shard.Available && coverage[s.ID()] < 2 {
+				redundant = false
+				break
+			}
+		}
+		if !redundant {
+			selected = append(selected, idx)
+			continue
+		}
+		for _, s := range hostShardSets[idx].ShardSet().All() {
+			if s.State() == shard.Available {
+				coverage[s.ID()]--
+			}
+		}
+	}
+	return selected, true
+}
+
+func hostShardAvailable(
+	topoMap topology.Map,
+	host topology.Host,
+	shardID uint32,
+) bool {
+	hostShardSet, ok := topoMap.LookupHostShardSet(host.ID())
+	if !ok {
+		return false
+	}
+	state, err := hostShardSet.ShardSet().LookupStateByID(shardID)
+	return err == nil && state == shard.Available
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3cluster/shard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFollowerReadTestTopoMap returns a topology of three replicas each
+// split across two hosts, with shard 0 initializing on the first host.
+func newFollowerReadTestTopoMap(t *testing.T) topology.Map {
+	allShards := sharding.NewShards([]uint32{0, 1, 2, 3}, shard.Available)
+	shardSet, err := sharding.NewShardSet(allShards, sharding.DefaultHashFn(4))
+	require.NoError(t, err)
+
+	var hostShardSets []topology.HostShardSet
+	for i := 0; i < 6; i++ {
+		ids := []uint32{0, 1}
+		if i%2 == 1 {
+			ids = []uint32{2, 3}
+		}
+		shards := sharding.NewShards(ids, shard.Available)
+		if i == 0 {
+			shards = append(sharding.NewShards([]uint32{0}, shard.Initializing),
+				sharding.NewShards([]uint32{1}, shard.Available)...)
+		}
+		hostShardSet, err := sharding.NewShardSet(shards, sharding.DefaultHashFn(4))
+		require.NoError(t, err)
+		host := topology.NewHost(fmt.Sprintf("testhost%d", i),
+			fmt.Sprintf("testhost%d:9000", i))
+		hostShardSets = append(hostShardSets,
+			topology.NewHostShardSet(host, hostShardSet))
+	}
+
+	return topology.NewStaticMap(topology.NewStaticOptions().
+		SetReplicas(3).
+		SetShardSet(shardSet).
+		SetHostShardSets(hostShardSets))
+}
+
+func TestFollowerReadHostIdxSkipsUnavailable(t *testing.T) {
+	topoMap := newFollowerReadTestTopoMap(t)
+
+	selected := make(map[int]struct{})
+	for seed := uint32(0); seed < 6; seed++ {
+		idx, ok := followerReadHostIdx(topoMap, 0, seed)
+		require.True(t, ok)
+		selected[idx] = struct{}{}
+	}
+
+	// Rotates between the replicas with shard 0 available
+	assert.Equal(t, map[int]struct{}{2: {}, 4: {}}, selected)
+}
+
+func TestFollowerReadHostIdxsCoverEachShardOnce(t *testing.T) {
+	topoMap := newFollowerReadTestTopoMap(t)
+	hostShardSets := topoMap.HostShardSets()
+
+	for seed := uint32(0); seed < 6; seed++ {
+		idxs, ok := followerReadHostIdxs(topoMap, seed)
+		require.True(t, ok)
+		assert.True(t, len(idxs) < len(hostShardSets))
+
+		covered := make(map[uint32]int)
+		for _, idx := range idxs {
+			for _, s := range hostShardSets[idx].ShardSet().All() {
+				if s.State() == shard.Available {
+					covered[s.ID()]++
+				}
+			}
+		}
+		for _, id := range topoMap.ShardSet().AllIDs() {
+			assert.True(t, covered[id] > 0, "shard %d not covered", id)
+		}
+	}
+}
+
+func TestFollowerReadHostIdxsUnavailableShard(t *testing.T) {
+	allShards := sharding.NewShards([]uint32{0, 1}, shard.Available)
+	shardSet, err := sharding.NewShardSet(allShards, sharding.DefaultHashFn(2))
+	require.NoError(t, err)
+
+	hostShards, err := sharding.NewShardSet(append(
+		sharding.NewShards([]uint32{0}, shard.Available),
+		sharding.NewShards([]uint32{1}, shard.Initializing)...),
+		sharding.DefaultHashFn(2))
+	require.NoError(t, err)
+
+	topoMap := topology.NewStaticMap(topology.NewStaticOptions().
+		SetReplicas(1).
+		SetShardSet(shardSet).
+		SetHostShardSets([]topology.HostShardSet{
+			topology.NewHostShardSet(topology.NewHost("testhost0", "testhost0:9000"), hostShards),
+		}))
+
+	_, ok := followerReadHostIdxs(topoMap, 0)
+	assert.False(t, ok)
+
+	_, ok = followerReadHostIdx(topoMap, 1, 0)
+	assert.False(t, ok)
+}
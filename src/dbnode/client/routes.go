@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"github.com/m3db/m3/src/dbnode/topology"
+)
+
+// SeriesRoute is the shard a series belongs to and the hosts owning it.
+type SeriesRoute struct {
+	// Shard is the shard the series belongs to.
+	Shard uint32
+
+	// Hosts are the hosts that own the shard, in topology order.
+	Hosts []topology.Host
+
+	// PlacementGeneration is the generation of the placement the route was
+	// computed with, routes computed with different generations may differ.
+	PlacementGeneration int
+}
+
+// shardRoute is the hosts of a shard along with their indexes in the
+// topology hosts, which are also the indexes of their host queues.
+type shardRoute struct {
+	routed   bool
+	hostIdxs []int
+	hosts    []topology.Host
+}
+
+// routingTable caches the hosts each shard routes to for a topology map so
+// that the routes are only computed again when the placement changes.
+type routingTable struct {
+	generation int
+	hostIDs    []string
+	shards     []shardRoute
+}
+
+func newRoutingTable(topoMap topology.Map) *routingTable {
+	var (
+		hosts = topoMap.Hosts()
+		table = &routingTable{
+			generation: topoMap.Generation(),
+			hostIDs:    make([]string, 0, len(hosts)),
+		}
+	)
+	for _, host := range hosts {
+		table.hostIDs = append(table.hostIDs, host.ID())
+	}
+
+	for _, shard := range topoMap.ShardSet().AllIDs() {
+		route := shardRoute{routed: true}
+		if err := topoMap.RouteShardForEach(shard, func(idx int, host topology.Host) {
+			route.hostIdxs = append(route.hostIdxs, idx)
+			route.hosts = append(route.hosts, host)
+		}); err != nil {
+			continue
+		}
+		for int(shard) >= len(table.shards) {
+			table.shards = append(table.shards, shardRoute{})
+		}
+		table.shards[shard] = route
+	}
+	return table
+}
+
+// validFor returns whether the routes can be used with a topology map, the
+// routes are valid as long as the placement generation and the order of the
+// hosts, which the host queues are indexed by, are unchanged.
+func (t *routingTable) validFor(topoMap topology.Map) bool {
+	if t == nil || t.generation != topoMap.Generation() {
+		return false
+	}
+	hosts := topoMap.Hosts()
+	if len(hosts) != len(t.hostIDs) {
+		return false
+	}
+	for i, host := range hosts {
+		if host.ID() != t.hostIDs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// route returns the route of a shard, returns false if the shard is not
+// owned by the topology.
+func (t *routingTable) route(shard uint32) (shardRoute, bool) {
+	if t == nil || int(shard) >= len(t.shards) || !t.shards[shard].routed {
+		return shardRoute{}, false
+	}
+	return t.shards[shard], true
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3cluster/shard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRoutingTestTopoMap returns a topology of two replicas each split
+// across two hosts.
+func newRoutingTestTopoMap(t *testing.T, generation int, hostIDs ...string) topology.Map {
+	allShards := sharding.NewShards([]uint32{0, 1, 2, 3}, shard.Available)
+	shardSet, err := sharding.NewShardSet(allShards, sharding.DefaultHashFn(4))
+	require.NoError(t, err)
+
+	var hostShardSets []topology.HostShardSet
+	for i, id := range hostIDs {
+		ids := []uint32{0, 1}
+		if i%2 == 1 {
+			ids = []uint32{2, 3}
+		}
+		hostShards, err := sharding.NewShardSet(sharding.NewShards(ids, shard.Available),
+			sharding.DefaultHashFn(4))
+		require.NoError(t, err)
+		host := topology.NewHost(id, fmt.Sprintf("%s:9000", id))
+		hostShardSets = append(hostShardSets, topology.NewHostShardSet(host, hostShards))
+	}
+
+	return topology.NewStaticMap(topology.NewStaticOptions().
+		SetReplicas(2).
+		SetShardSet(shardSet).
+		SetHostShardSets(hostShardSets).
+		SetGeneration(generation))
+}
+
+func TestRoutingTableMatchesTopology(t *testing.T) {
+	topoMap := newRoutingTestTopoMap(t, 1, "a", "b", "c", "d")
+	table := newRoutingTable(topoMap)
+
+	for _, shardID := range topoMap.ShardSet().AllIDs() {
+		var (
+			expectedIdxs  []int
+			expectedHosts []string
+		)
+		require.NoError(t, topoMap.RouteShardForEach(shardID, func(idx int, host topology.Host) {
+			expectedIdxs = append(expectedIdxs, idx)
+			expectedHosts = append(expectedHosts, host.ID())
+		}))
+
+		route, ok := table.route(shardID)
+		require.True(t, ok)
+		assert.Equal(t, expectedIdxs, route.hostIdxs)
+		var hosts []string
+		for _, host := range route.hosts {
+			hosts = append(hosts, host.ID())
+		}
+		assert.Equal(t, expectedHosts, hosts)
+	}
+
+	_, ok := table.route(4)
+	assert.False(t, ok)
+}
+
+func TestRoutingTableValidFor(t *testing.T) {
+	table := newRoutingTable(newRoutingTestTopoMap(t, 1, "a", "b", "c", "d"))
+
+	assert.True(t, table.validFor(newRoutingTestTopoMap(t, 1, "a", "b", "c", "d")))
+	assert.False(t, table.validFor(newRoutingTestTopoMap(t, 2, "a", "b", "c", "d")))
+	assert.False(t, table.validFor(newRoutingTestTopoMap(t, 1, "a", "b", "c", "e")))
+	assert.False(t, table.validFor(newRoutingTestTopoMap(t, 1, "a", "b")))
+
+	var nilTable *routingTable
+	assert.False(t, nilTable.validFor(newRoutingTestTopoMap(t, 1, "a", "b")))
+}
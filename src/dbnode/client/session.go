@@ -39,6 +39,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
@@ -46,6 +47,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/ts"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3cluster/shard"
@@ -149,6 +151,11 @@ type sessionState struct {
 	topo           topology.Topology
 	topoMap        topology.Map
 	topoWatch      topology.MapWatch
+	nsRegistry     namespace.Registry
+	nsWatch        namespace.Watch
+	nsMap          namespace.Map
+	nsShardFns     map[string]sharding.HashFn
+	routes         *routingTable
 	replicas       int
 	majority       int
 }
@@ -176,6 +183,8 @@ type session struct {
 	streamBlocksMetadataBatchTimeout time.Duration
 	streamBlocksBatchTimeout         time.Duration
 	metrics                          sessionMetrics
+	followerReadSeed                 uint32
+	placementGeneration              int64
 }
 
 type shardMetricsKey struct {
@@ -193,6 +202,9 @@ type sessionMetrics struct {
 	fetchNodesRespondingErrors []tally.Counter
 	topologyUpdatedSuccess     tally.Counter
 	topologyUpdatedError       tally.Counter
+	topologyRoutesRebuilt      tally.Counter
+	topologyWarmUpSuccess      tally.Counter
+	topologyWarmUpTimeout      tally.Counter
 	streamFromPeersMetrics     map[shardMetricsKey]streamFromPeersMetrics
 }
 
@@ -204,6 +216,9 @@ func newSessionMetrics(scope tally.Scope) sessionMetrics {
 		fetchErrors:            scope.Counter("fetch.errors"),
 		topologyUpdatedSuccess: scope.Counter("topology.updated-success"),
 		topologyUpdatedError:   scope.Counter("topology.updated-error"),
+		topologyRoutesRebuilt:  scope.Counter("topology.routes-rebuilt"),
+		topologyWarmUpSuccess:  scope.Counter("topology.warm-up-success"),
+		topologyWarmUpTimeout:  scope.Counter("topology.warm-up-timeout"),
 		streamFromPeersMetrics: make(map[shardMetricsKey]streamFromPeersMetrics),
 	}
 }
@@ -232,9 +247,14 @@ type hostQueueOpts struct {
 	writeBatchRawRequestElementArrayPool       writeBatchRawRequestElementArrayPool
 	writeTaggedBatchRawRequestPool             writeTaggedBatchRawRequestPool
 	writeTaggedBatchRawRequestElementArrayPool writeTaggedBatchRawRequestElementArrayPool
+	placementGenerationFn                      placementGenerationFn
 	opts                                       Options
 }
 
+// placementGenerationFn returns the generation of the placement requests are
+// routed with, sent to nodes so that nodes with a stale placement are fenced.
+type placementGenerationFn func() int
+
 type newHostQueueFn func(
 	host topology.Host,
 	hostQueueOpts hostQueueOpts,
@@ -357,6 +377,45 @@ func (s *session) ShardID(id ident.ID) (uint32, error) {
 	return value, nil
 }
 
+func (s *session) RouteSeries(namespace, id ident.ID) (SeriesRoute, error) {
+	s.state.RLock()
+	defer s.state.RUnlock()
+	if s.state.status != statusOpen {
+		return SeriesRoute{}, errSessionStatusNotOpen
+	}
+
+	shardID := s.shardFnWithRLock(namespace)(id)
+	route, ok := s.state.routes.route(shardID)
+	if !ok {
+		return SeriesRoute{}, fmt.Errorf("shard %d is not owned by any host", shardID)
+	}
+	// Copy the hosts as the cached routes are shared.
+	hosts := make([]topology.Host, len(route.hosts))
+	copy(hosts, route.hosts)
+	return SeriesRoute{
+		Shard:               shardID,
+		Hosts:               hosts,
+		PlacementGeneration: s.state.routes.generation,
+	}, nil
+}
+
+// routeShardForEachWithRLock executes the function for each host a shard
+// routes to using the cached routes of the topology.
+func (s *session) routeShardForEachWithRLock(
+	shardID uint32,
+	forEachFn topology.RouteForEachFn,
+) error {
+	route, ok := s.state.routes.route(shardID)
+	if !ok {
+		// Let the topology determine why the shard can not be routed.
+		return s.state.topoMap.RouteShardForEach(shardID, forEachFn)
+	}
+	for i, idx := range route.hostIdxs {
+		forEachFn(idx, route.hosts[i])
+	}
+	return nil
+}
+
 // newPeerMetadataStreamingProgressMetrics returns a struct with an embedded
 // list of fields that can be used to emit metrics about the current state of
 // the peer metadata streaming process
@@ -381,10 +440,10 @@ func (s *session) newPeerMetadataStreamingProgressMetrics(
 		s.metrics.Unlock()
 		return &m
 	}
-	scope = scope.SubScope("stream-from-peers").Tagged(map[string]string{
-		"shard":      fmt.Sprintf("%d", shard),
-		"resultType": string(resultType),
-	})
+	scope = xmetrics.ShardScope(scope.SubScope("stream-from-peers"), shard).
+		Tagged(map[string]string{
+			"resultType": string(resultType),
+		})
 	m = streamFromPeersMetrics{
 		fetchBlocksFromPeers:       scope.Gauge("fetch-blocks-inprogress"),
 		metadataFetches:            scope.Gauge("fetch-metadata-peers-inprogress"),
@@ -474,6 +533,13 @@ func (s *session) Open() error {
 	s.setTopologyWithLock(topoMap, queues, replicas, majority)
 	s.state.topoWatch = watch
 
+	if nsInit := s.opts.NamespaceInitializer(); nsInit != nil {
+		if err := s.initNamespacesWithLock(nsInit); err != nil {
+			s.state.Unlock()
+			return err
+		}
+	}
+
 	// NB(r): Alloc pools that can take some time in Open, expectation
 	// is already that Open will take some time
 	writeOperationPoolOpts := pool.NewObjectPoolOptions().
@@ -538,8 +604,13 @@ func (s *session) Open() error {
 	s.pools.seriesIterators = encoding.NewMutableSeriesIteratorsPool(s.opts.SeriesIteratorArrayPoolBuckets())
 	s.pools.seriesIterators.Init()
 	s.state.status = statusOpen
+	nsWatch := s.state.nsWatch
 	s.state.Unlock()
 
+	if nsWatch != nil {
+		go s.watchNamespaces(nsWatch)
+	}
+
 	go func() {
 		for range watch.C() {
 			s.log.Info("received update for topology")
@@ -555,6 +626,11 @@ func (s *session) Open() error {
 				s.metrics.topologyUpdatedError.Inc(1)
 				continue
 			}
+
+			// Keep serving with the existing topology while connections to
+			// any new hosts are established and health checked
+			s.warmUpNewHostQueues(existingQueues, queues)
+
 			s.state.Lock()
 			s.setTopologyWithLock(topoMap, queues, replicas, majority)
 			s.state.Unlock()
@@ -702,6 +778,56 @@ func (s *session) hostQueues(
 	return queues, replicas, majority, nil
 }
 
+// warmUpNewHostQueues waits for the connection pools of the host queues that
+// are not part of the existing topology to fill, so that requests are not
+// routed to new hosts while only a few connections to them are established.
+// It gives up waiting once the topology change warm up timeout elapses.
+func (s *session) warmUpNewHostQueues(existing, queues []hostQueue) {
+	timeout := s.opts.TopologyChangeWarmUpTimeout()
+	if timeout <= 0 {
+		return
+	}
+
+	existingByHostID := make(map[string]hostQueue, len(existing))
+	for _, queue := range existing {
+		existingByHostID[queue.Host().ID()] = queue
+	}
+
+	var newQueues []hostQueue
+	for _, queue := range queues {
+		if existingByHostID[queue.Host().ID()] != queue {
+			newQueues = append(newQueues, queue)
+		}
+	}
+	if len(newQueues) == 0 {
+		return
+	}
+
+	var (
+		start  = s.nowFn()
+		target = s.opts.MaxConnectionCount()
+	)
+	for {
+		warm := 0
+		for _, queue := range newQueues {
+			if queue.ConnectionCount() >= target {
+				warm++
+			}
+		}
+		if warm == len(newQueues) {
+			s.metrics.topologyWarmUpSuccess.Inc(1)
+			return
+		}
+		if s.nowFn().Sub(start) >= timeout {
+			s.log.Warnf("timed out warming up connections to new hosts, %d of %d fully connected",
+				warm, len(newQueues))
+			s.metrics.topologyWarmUpTimeout.Inc(1)
+			return
+		}
+		time.Sleep(clusterConnectWaitInterval)
+	}
+}
+
 func (s *session) setTopologyWithLock(topoMap topology.Map, queues []hostQueue, replicas, majority int) {
 	prevQueues := s.state.queues
 
@@ -714,6 +840,12 @@ func (s *session) setTopologyWithLock(topoMap topology.Map, queues []hostQueue,
 	s.state.queuesByHostID = newQueuesByHostID
 
 	s.state.topoMap = topoMap
+	s.state.nsShardFns = s.namespaceShardFns(s.state.nsMap, topoMap)
+	if !s.state.routes.validFor(topoMap) {
+		s.state.routes = newRoutingTable(topoMap)
+		s.metrics.topologyRoutesRebuilt.Inc(1)
+	}
+	atomic.StoreInt64(&s.placementGeneration, int64(topoMap.Generation()))
 
 	s.state.replicas = replicas
 	s.state.majority = majority
@@ -793,6 +925,82 @@ func (s *session) setTopologyWithLock(topoMap topology.Map, queues []hostQueue,
 	s.log.Infof("successfully updated topology to %d hosts", topoMap.HostsLen())
 }
 
+// initNamespacesWithLock waits for the first namespaces from the namespace
+// registry so that writes and reads are routed by the hash strategy of each
+// namespace from the start.
+func (s *session) initNamespacesWithLock(nsInit namespace.Initializer) error {
+	registry, err := nsInit.Init()
+	if err != nil {
+		return err
+	}
+	watch, err := registry.Watch()
+	if err != nil {
+		registry.Close()
+		return err
+	}
+
+	// Wait for the namespaces to be available
+	<-watch.C()
+
+	s.state.nsRegistry = registry
+	s.state.nsWatch = watch
+	s.setNamespacesWithLock(watch.Get())
+	return nil
+}
+
+func (s *session) watchNamespaces(watch namespace.Watch) {
+	for range watch.C() {
+		s.log.Info("received update for namespaces")
+		nsMap := watch.Get()
+
+		s.state.Lock()
+		s.setNamespacesWithLock(nsMap)
+		s.state.Unlock()
+	}
+}
+
+func (s *session) setNamespacesWithLock(nsMap namespace.Map) {
+	s.state.nsMap = nsMap
+	s.state.nsShardFns = s.namespaceShardFns(nsMap, s.state.topoMap)
+}
+
+// namespaceShardFns returns the shard hash functions of the namespaces that
+// can use their hash strategy with the topology.
+func (s *session) namespaceShardFns(
+	nsMap namespace.Map,
+	topoMap topology.Map,
+) map[string]sharding.HashFn {
+	if nsMap == nil || topoMap == nil {
+		return nil
+	}
+	var (
+		metadatas = nsMap.Metadatas()
+		shardFns  = make(map[string]sharding.HashFn, len(metadatas))
+	)
+	for _, md := range metadatas {
+		sopts := md.Options().ShardingOptions()
+		fn, err := sharding.NewStrategyHashFn(topoMap.ShardSet(),
+			sopts.HashStrategy(), sopts.TagNames())
+		if err != nil {
+			s.log.Errorf("could not use hash strategy %s for namespace %s: %v",
+				sopts.HashStrategy().String(), md.ID().String(), err)
+			continue
+		}
+		shardFns[md.ID().String()] = fn
+	}
+	return shardFns
+}
+
+// shardFnWithRLock returns the function assigning the series of a namespace
+// to shards, namespaces that are unknown use the topology shard set hash
+// function.
+func (s *session) shardFnWithRLock(namespace ident.ID) sharding.HashFn {
+	if fn, ok := s.state.nsShardFns[string(namespace.Bytes())]; ok {
+		return fn
+	}
+	return s.state.topoMap.ShardSet().HashFn()
+}
+
 func (s *session) newHostQueue(host topology.Host, topoMap topology.Map) hostQueue {
 	// NB(r): Due to hosts being replicas we have:
 	// = replica * numWrites
@@ -850,12 +1058,17 @@ func (s *session) newHostQueue(host topology.Host, topoMap topology.Map) hostQue
 		writeBatchRawRequestElementArrayPool:       writeBatchRawRequestElementArrayPool,
 		writeTaggedBatchRawRequestPool:             writeTaggedBatchRequestPool,
 		writeTaggedBatchRawRequestElementArrayPool: writeTaggedBatchRawRequestElementArrayPool,
-		opts: s.opts,
+		placementGenerationFn:                      s.currentPlacementGeneration,
+		opts:                                       s.opts,
 	})
 	hostQueue.Open()
 	return hostQueue
 }
 
+func (s *session) currentPlacementGeneration() int {
+	return int(atomic.LoadInt64(&s.placementGeneration))
+}
+
 func (s *session) Write(
 	namespace, id ident.ID,
 	t time.Time,
@@ -874,6 +1087,26 @@ func (s *session) Write(
 	return err
 }
 
+func (s *session) WriteWithSequence(
+	namespace, id ident.ID,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+	sequence int64,
+) error {
+	w := s.pools.writeAttempt.Get()
+	w.args.attemptType = untaggedWriteAttemptType
+	w.args.namespace, w.args.id = namespace, id
+	w.args.tags = ident.EmptyTagIterator
+	w.args.t, w.args.value, w.args.unit, w.args.annotation =
+		t, value, unit, annotation
+	w.args.sequence, w.args.hasSequence = sequence, true
+	err := s.writeRetrier.Attempt(w.attemptFn)
+	s.pools.writeAttempt.Put(w)
+	return err
+}
+
 func (s *session) WriteTagged(
 	namespace, id ident.ID,
 	tags ident.TagIterator,
@@ -892,6 +1125,26 @@ func (s *session) WriteTagged(
 	return err
 }
 
+func (s *session) WriteTaggedWithSequence(
+	namespace, id ident.ID,
+	tags ident.TagIterator,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+	sequence int64,
+) error {
+	w := s.pools.writeAttempt.Get()
+	w.args.attemptType = taggedWriteAttemptType
+	w.args.namespace, w.args.id, w.args.tags = namespace, id, tags
+	w.args.t, w.args.value, w.args.unit, w.args.annotation =
+		t, value, unit, annotation
+	w.args.sequence, w.args.hasSequence = sequence, true
+	err := s.writeRetrier.Attempt(w.attemptFn)
+	s.pools.writeAttempt.Put(w)
+	return err
+}
+
 func (s *session) writeAttempt(
 	wType writeAttemptType,
 	namespace, id ident.ID,
@@ -900,6 +1153,7 @@ func (s *session) writeAttempt(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
+	sequence *int64,
 ) error {
 	timeType, timeTypeErr := convert.ToTimeType(unit)
 	if timeTypeErr != nil {
@@ -917,8 +1171,13 @@ func (s *session) writeAttempt(
 		return errSessionStatusNotOpen
 	}
 
+	if err := s.validateTimestampPrecisionWithRLock(namespace, t); err != nil {
+		s.state.RUnlock()
+		return err
+	}
+
 	state, majority, enqueued, err := s.writeAttemptWithRLock(
-		wType, namespace, id, inputTags, timestamp, value, timeType, annotation)
+		wType, namespace, id, inputTags, timestamp, value, timeType, annotation, sequence)
 	s.state.RUnlock()
 
 	if err != nil {
@@ -942,6 +1201,29 @@ func (s *session) writeAttempt(
 	return err
 }
 
+// validateTimestampPrecisionWithRLock returns a non-retryable error if the
+// timestamp of a write is finer than the timestamp precision of its namespace,
+// writes to namespaces unknown to the session are not validated.
+func (s *session) validateTimestampPrecisionWithRLock(
+	namespace ident.ID,
+	t time.Time,
+) error {
+	if s.state.nsMap == nil {
+		return nil
+	}
+	md, err := s.state.nsMap.Get(namespace)
+	if err != nil {
+		return nil
+	}
+	precision := md.Options().TimestampPrecision()
+	if precision <= time.Nanosecond || t.Truncate(precision).Equal(t) {
+		return nil
+	}
+	return xerrors.NewNonRetryableError(xerrors.NewInvalidParamsError(fmt.Errorf(
+		"timestamp %d is finer than the %s timestamp precision of namespace %s",
+		t.UnixNano(), precision.String(), namespace.String())))
+}
+
 // NB(prateek): the returned writeState, if valid, still holds the lock. Its ownership
 // is transferred to the calling function, and is expected to manage the lifecycle of
 // of the object (including releasing the lock/decRef'ing it).
@@ -953,6 +1235,7 @@ func (s *session) writeAttemptWithRLock(
 	value float64,
 	timeType rpc.TimeType,
 	annotation []byte,
+	sequence *int64,
 ) (*writeState, int32, int32, error) {
 	var (
 		majority = int32(s.state.majority)
@@ -975,22 +1258,25 @@ func (s *session) writeAttemptWithRLock(
 		}
 	}
 
+	shardID := s.shardFnWithRLock(nsID)(tsID)
+
 	var op writeOp
 	switch wType {
 	case untaggedWriteAttemptType:
 		wop := s.pools.writeOperation.Get()
 		wop.namespace = nsID
-		wop.shardID = s.state.topoMap.ShardSet().Lookup(tsID)
+		wop.shardID = shardID
 		wop.request.ID = tsID.Bytes()
 		wop.request.Datapoint.Value = value
 		wop.request.Datapoint.Timestamp = timestamp
 		wop.request.Datapoint.TimestampTimeType = timeType
 		wop.request.Datapoint.Annotation = annotation
+		wop.setSequence(sequence)
 		op = wop
 	case taggedWriteAttemptType:
 		wop := s.pools.writeTaggedOperation.Get()
 		wop.namespace = nsID
-		wop.shardID = s.state.topoMap.ShardSet().Lookup(tsID)
+		wop.shardID = shardID
 		wop.request.ID = tsID.Bytes()
 		encodedTagBytes, ok := tagEncoder.Data()
 		if !ok {
@@ -1001,6 +1287,7 @@ func (s *session) writeAttemptWithRLock(
 		wop.request.Datapoint.Timestamp = timestamp
 		wop.request.Datapoint.TimestampTimeType = timeType
 		wop.request.Datapoint.Annotation = annotation
+		wop.setSequence(sequence)
 		op = wop
 	default:
 		// should never happen
@@ -1017,7 +1304,7 @@ func (s *session) writeAttemptWithRLock(
 	state.nsID, state.tsID, state.tagEncoder = nsID, tsID, tagEncoder
 	op.SetCompletionFn(state.completionFn)
 
-	if err := s.state.topoMap.RouteForEach(tsID, func(idx int, host topology.Host) {
+	if err := s.routeShardForEachWithRLock(shardID, func(idx int, host topology.Host) {
 		// Count pending write requests before we enqueue the completion fns,
 		// which rely on the count when executing
 		state.pending++
@@ -1205,10 +1492,28 @@ func (s *session) fetchTaggedAttemptWithRLock(
 	fetchState.incRef()       // indicate current go-routine has a reference to the fetchState
 	op.incRef()               // indicate current go-routine has a reference to the op
 	op.update(req, fetchState.completionFn)
+	op.trace = opts.Trace
+
+	// Follower reads only fan out to enough hosts to read each shard once.
+	var (
+		queues   = s.state.queues
+		hostIdxs []int
+	)
+	if s.state.readLevel == topology.ReadConsistencyLevelFollower {
+		seed := atomic.AddUint32(&s.followerReadSeed, 1)
+		if idxs, ok := followerReadHostIdxs(topoMap, seed); ok {
+			hostIdxs = idxs
+			queues = make([]hostQueue, 0, len(idxs))
+			for _, idx := range idxs {
+				queues = append(queues, s.state.queues[idx])
+			}
+		}
+	}
 
-	fetchState.Reset(opts.StartInclusive, opts.EndExclusive, op, topoMap, s.state.majority, s.state.readLevel)
+	fetchState.Reset(opts.StartInclusive, opts.EndExclusive, op, topoMap,
+		s.shardFnWithRLock(ns), hostIdxs, s.state.majority, s.state.readLevel)
 	fetchState.Lock()
-	for _, hq := range s.state.queues {
+	for _, hq := range queues {
 		// inc to indicate the hostQueue has a reference to `op` which has a ref to the fetchState
 		fetchState.incRef()
 		if err := hq.Enqueue(op); err != nil {
@@ -1296,6 +1601,9 @@ func (s *session) fetchIDsAttempt(
 
 	consistencyLevel = s.state.readLevel
 	majority = int32(s.state.majority)
+	followerRead := consistencyLevel == topology.ReadConsistencyLevelFollower
+	shardFn := s.shardFnWithRLock(namespace)
+	followerReadSeed := atomic.AddUint32(&s.followerReadSeed, 1)
 
 	// NB(prateek): namespaceAccessors tracks the number of pending accessors for nsID.
 	// It is set to incremented by `replica` for each requested ID during fetch enqueuing,
@@ -1324,8 +1632,20 @@ func (s *session) fetchIDsAttempt(
 			success          int32
 			errors           []error
 			errs             int32
+
+			followerHostIdx = -1
 		)
 
+		shardID := shardFn(tsID)
+		if followerRead {
+			// Read from a single replica with the shard available, falling
+			// back to all replicas if none has it available.
+			if hostIdx, ok := followerReadHostIdx(s.state.topoMap, shardID,
+				followerReadSeed+uint32(idx)); ok {
+				followerHostIdx = hostIdx
+			}
+		}
+
 		// increment namespaceAccesors by 1 to indicate it still needs to be handled by the
 		// allCompletionFn for tsID.
 		atomic.AddInt32(&namespaceAccessors, 1)
@@ -1354,6 +1674,8 @@ func (s *session) fetchIDsAttempt(
 				resultsLock.RLock()
 				successIters := results[:success]
 				resultsLock.RUnlock()
+				completeness := topology.ReadConsistencyCompleteness(consistencyLevel,
+					int(majority), int(enqueued), len(successIters))
 				iter := s.pools.seriesIterator.Get()
 				// NB(prateek): we need to allocate a copy of ident.ID to allow the seriesIterator
 				// to have control over the lifecycle of ID. We cannot allow seriesIterator
@@ -1362,6 +1684,7 @@ func (s *session) fetchIDsAttempt(
 				seriesID := s.pools.id.Clone(tsID)
 				namespaceID := s.pools.id.Clone(namespace)
 				iter.Reset(seriesID, namespaceID, nil, startInclusive, endExclusive, successIters)
+				iter.SetCompleteness(completeness)
 				iters.SetAt(idx, iter)
 			}
 			if atomic.AddInt32(&resultsAccessors, -1) == 0 {
@@ -1420,7 +1743,11 @@ func (s *session) fetchIDsAttempt(
 			}
 		}
 
-		if err := s.state.topoMap.RouteForEach(tsID, func(hostIdx int, host topology.Host) {
+		if err := s.routeShardForEachWithRLock(shardID, func(hostIdx int, host topology.Host) {
+			if followerHostIdx >= 0 && hostIdx != followerHostIdx {
+				return
+			}
+
 			// Inc safely as this for each is sequential
 			enqueued++
 			pending++
@@ -1545,6 +1872,8 @@ func (s *session) Close() error {
 	queues := s.state.queues
 	topoWatch := s.state.topoWatch
 	topo := s.state.topo
+	nsWatch := s.state.nsWatch
+	nsRegistry := s.state.nsRegistry
 	s.state.Unlock()
 
 	for _, q := range queues {
@@ -1554,6 +1883,13 @@ func (s *session) Close() error {
 	topoWatch.Close()
 	topo.Close()
 
+	if nsWatch != nil {
+		nsWatch.Close()
+	}
+	if nsRegistry != nil {
+		nsRegistry.Close()
+	}
+
 	if closer := s.runtimeOptsListenerCloser; closer != nil {
 		closer.Close()
 	}
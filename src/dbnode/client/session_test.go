@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3cluster/shard"
@@ -103,6 +104,7 @@ func applySessionTestOptions(opts Options) Options {
 		SetWriteOpPoolSize(0).
 		SetWriteTaggedOpPoolSize(0).
 		SetFetchBatchOpPoolSize(0).
+		SetTopologyChangeWarmUpTimeout(0).
 		SetTopologyInitializer(topology.NewStaticInitializer(
 			topology.NewStaticOptions().
 				SetReplicas(sessionTestReplicas).
@@ -152,6 +154,90 @@ func TestSessionShardID(t *testing.T) {
 	assert.NoError(t, s.Close())
 }
 
+func TestSessionRouteSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSessionTestOptions()
+	s, err := newSession(opts)
+	assert.NoError(t, err)
+
+	_, err = s.RouteSeries(ident.StringID("ns"), ident.StringID("foo"))
+	assert.Equal(t, errSessionStatusNotOpen, err)
+
+	mockHostQueues(ctrl, s.(*session), sessionTestReplicas, nil)
+
+	require.NoError(t, s.Open())
+
+	// Every host owns every shard of the test topology
+	route, err := s.RouteSeries(ident.StringID("ns"), ident.StringID("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), route.Shard)
+	require.Equal(t, sessionTestReplicas, len(route.Hosts))
+	for i, host := range route.Hosts {
+		assert.Equal(t, testHostName(i), host.ID())
+	}
+
+	assert.NoError(t, s.Close())
+}
+
+func TestSessionNamespaceShardFn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var ids []uint32
+	for i := uint32(0); i < uint32(sessionTestShards); i++ {
+		ids = append(ids, i)
+	}
+	hashFn := sharding.DefaultHashFn(sessionTestShards)
+	shardSet, err := sharding.NewShardSetWithNumShards(
+		sharding.NewShards(ids, shard.Available), hashFn, sessionTestShards)
+	require.NoError(t, err)
+
+	tagSubset, err := namespace.NewMetadata(ident.StringID("tagSubset"),
+		namespace.NewOptions().SetShardingOptions(namespace.NewShardingOptions().
+			SetHashStrategy(sharding.TagSubsetHashStrategy).
+			SetTagNames([]string{"service"})))
+	require.NoError(t, err)
+	jump, err := namespace.NewMetadata(ident.StringID("jump"),
+		namespace.NewOptions().SetShardingOptions(namespace.NewShardingOptions().
+			SetHashStrategy(sharding.JumpHashStrategy)))
+	require.NoError(t, err)
+
+	opts := newSessionTestOptions().
+		SetTopologyInitializer(topology.NewStaticInitializer(
+			topology.NewStaticOptions().
+				SetReplicas(sessionTestReplicas).
+				SetShardSet(shardSet).
+				SetHostShardSets(sessionTestHostAndShards(shardSet)))).
+		SetNamespaceInitializer(namespace.NewStaticInitializer(
+			[]namespace.Metadata{tagSubset, jump}))
+	s, err := newSession(opts)
+	require.NoError(t, err)
+	session := s.(*session)
+
+	mockHostQueues(ctrl, session, sessionTestReplicas, nil)
+
+	require.NoError(t, session.Open())
+
+	var (
+		first  = ident.StringID("__name__=cpu,host=a,service=foo,")
+		second = ident.StringID("__name__=mem,host=b,service=foo,")
+	)
+	session.state.RLock()
+	tagSubsetFn := session.shardFnWithRLock(ident.StringID("tagSubset"))
+	jumpFn := session.shardFnWithRLock(ident.StringID("jump"))
+	unknownFn := session.shardFnWithRLock(ident.StringID("unknown"))
+	session.state.RUnlock()
+
+	assert.Equal(t, tagSubsetFn(first), tagSubsetFn(second))
+	assert.Equal(t, hashFn(ident.StringID("service=foo,")), tagSubsetFn(first))
+	assert.Equal(t, sharding.NewJumpHashFn(sessionTestShards)(first), jumpFn(first))
+	assert.Equal(t, hashFn(first), unknownFn(first))
+
+	assert.NoError(t, session.Close())
+}
+
 func TestSessionClusterConnectConsistencyLevelAll(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -167,3 +167,64 @@ func TestSessionTopologyChangeCreatesNewClosesOldHostQueues(t *testing.T) {
 	require.Equal(t, 1, len(createdQueues.get("testhost2")))
 	require.Equal(t, 1, len(closedQueues.get("testhost0")))
 }
+
+func TestSessionWarmUpNewHostQueues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := newSessionTestOptions().
+		SetMaxConnectionCount(4).
+		SetTopologyChangeWarmUpTimeout(time.Minute)
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().
+		SetMetricsScope(scope))
+
+	s, err := newSession(opts)
+	require.NoError(t, err)
+	session := s.(*session)
+
+	existing := NewMockhostQueue(ctrl)
+	existing.EXPECT().Host().Return(topology.NewHost("existing", "existing:9000")).AnyTimes()
+
+	// The new host queue fills its connection pool after a few checks
+	added := NewMockhostQueue(ctrl)
+	added.EXPECT().Host().Return(topology.NewHost("added", "added:9000")).AnyTimes()
+	added.EXPECT().ConnectionCount().Return(1).Times(2)
+	added.EXPECT().ConnectionCount().Return(4)
+
+	session.warmUpNewHostQueues([]hostQueue{existing}, []hostQueue{existing, added})
+
+	counters := scope.Snapshot().Counters()
+	warmUpSuccess, ok := counters["topology.warm-up-success+"]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), warmUpSuccess.Value())
+	_, ok = counters["topology.warm-up-timeout+"]
+	assert.False(t, ok)
+}
+
+func TestSessionWarmUpNewHostQueuesTimesOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := newSessionTestOptions().
+		SetMaxConnectionCount(4).
+		SetTopologyChangeWarmUpTimeout(5 * clusterConnectWaitInterval)
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().
+		SetMetricsScope(scope))
+
+	s, err := newSession(opts)
+	require.NoError(t, err)
+	session := s.(*session)
+
+	added := NewMockhostQueue(ctrl)
+	added.EXPECT().Host().Return(topology.NewHost("added", "added:9000")).AnyTimes()
+	added.EXPECT().ConnectionCount().Return(1).MinTimes(1)
+
+	session.warmUpNewHostQueues(nil, []hostQueue{added})
+
+	counters := scope.Snapshot().Counters()
+	warmUpTimeout, ok := counters["topology.warm-up-timeout+"]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), warmUpTimeout.Value())
+}
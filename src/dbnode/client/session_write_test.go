@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	xerrors "github.com/m3db/m3x/errors"
@@ -180,6 +181,35 @@ func TestSessionWriteBadRequestErrorIsNonRetryable(t *testing.T) {
 	assert.NoError(t, session.Close())
 }
 
+func TestSessionWriteTimestampPrecisionErrorIsNonRetryable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	md, err := namespace.NewMetadata(ident.StringID("testNs"),
+		namespace.NewOptions().SetTimestampPrecision(time.Millisecond))
+	require.NoError(t, err)
+
+	opts := newSessionTestOptions().
+		SetNamespaceInitializer(namespace.NewStaticInitializer(
+			[]namespace.Metadata{md})).
+		SetWriteRetrier(
+			xretry.NewRetrier(xretry.NewOptions().SetMaxRetries(1)))
+	session := newTestSession(t, opts).(*session)
+
+	mockHostQueues(ctrl, session, sessionTestReplicas, nil)
+
+	assert.NoError(t, session.Open())
+
+	w := newWriteStub()
+	ts := time.Unix(1500000000, 123456789)
+	err = session.Write(w.ns, w.id, ts, w.value, xtime.Nanosecond, w.annotation)
+	assert.Error(t, err)
+	assert.True(t, xerrors.IsNonRetryableError(err))
+	assert.True(t, xerrors.IsInvalidParams(xerrors.GetInnerNonRetryableError(err)))
+
+	assert.NoError(t, session.Close())
+}
+
 func TestSessionWriteRetry(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -66,6 +66,15 @@ type Session interface {
 	// WriteTagged value to the database for an ID and given tags.
 	WriteTagged(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) error
 
+	// WriteWithSequence value to the database for an ID with a write sequence,
+	// sequences must be unique for each series and writes retried with an
+	// already accepted sequence are acknowledged without being written again.
+	WriteWithSequence(namespace, id ident.ID, t time.Time, value float64, unit xtime.Unit, annotation []byte, sequence int64) error
+
+	// WriteTaggedWithSequence value to the database for an ID and given tags
+	// with a write sequence, see WriteWithSequence.
+	WriteTaggedWithSequence(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte, sequence int64) error
+
 	// Fetch values from the database for an ID
 	Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error)
 
@@ -78,11 +87,20 @@ type Session interface {
 	// FetchTaggedIDs resolves the provided query to known IDs.
 	FetchTaggedIDs(namespace ident.ID, q index.Query, opts index.QueryOptions) (iter TaggedIDsIterator, exhaustive bool, err error)
 
+	// SubscribeNewSeries subscribes to series created in the namespace
+	// whose tags match the query.
+	SubscribeNewSeries(namespace ident.ID, q index.Query, opts NewSeriesSubscriptionOptions) (NewSeriesSubscription, error)
+
 	// ShardID returns the given shard for an ID for callers
 	// to easily discern what shard is failing when operations
 	// for given IDs begin failing
 	ShardID(id ident.ID) (uint32, error)
 
+	// RouteSeries returns the shard of an ID in a namespace and the hosts
+	// owning the shard so that related processing can be co-located with
+	// the hosts, routes are cached until the placement changes.
+	RouteSeries(namespace, id ident.ID) (SeriesRoute, error)
+
 	// IteratorPools exposes the internal iterator pools used by the session to clients
 	IteratorPools() (encoding.IteratorPools, error)
 
@@ -106,6 +124,44 @@ type TaggedIDsIterator interface {
 	Finalize()
 }
 
+// NewSeriesSubscriptionOptions are options for subscribing to new series.
+type NewSeriesSubscriptionOptions struct {
+	// BufferSize is the number of new series buffered for the subscriber,
+	// nodes buffer and then drop new series while the buffer is full. A
+	// default is used if zero.
+	BufferSize int
+
+	// PollTimeout is how long nodes wait for new series before responding
+	// to a poll, a default is used if zero.
+	PollTimeout time.Duration
+
+	// PollLimit is the maximum number of new series returned by a node for
+	// a single poll, a default is used if zero.
+	PollLimit int
+}
+
+// NewSeries is a series created after a new series subscription was made.
+type NewSeries struct {
+	Shard uint32
+	ID    ident.ID
+	Tags  ident.Tags
+}
+
+// NewSeriesSubscription delivers series created in a namespace whose tags
+// match the query of the subscription.
+type NewSeriesSubscription interface {
+	// C returns the channel new series are delivered on, the channel is
+	// closed once the subscription has stopped after being closed.
+	C() <-chan NewSeries
+
+	// Dropped returns the number of new series nodes dropped because the
+	// subscriber did not keep up.
+	Dropped() int64
+
+	// Close closes the subscription.
+	Close()
+}
+
 // AdminClient can create administration sessions
 type AdminClient interface {
 	Client
@@ -234,6 +290,14 @@ type Options interface {
 	// TopologyInitializer returns the TopologyInitializer
 	TopologyInitializer() topology.Initializer
 
+	// SetNamespaceInitializer sets the NamespaceInitializer used to learn the
+	// shard hashing strategy of each namespace, if not set all namespaces are
+	// assumed to use the topology shard set hash function
+	SetNamespaceInitializer(value namespace.Initializer) Options
+
+	// NamespaceInitializer returns the NamespaceInitializer
+	NamespaceInitializer() namespace.Initializer
+
 	// SetReadConsistencyLevel sets the read consistency level
 	SetReadConsistencyLevel(value topology.ReadConsistencyLevel) Options
 
@@ -276,6 +340,16 @@ type Options interface {
 	// ClusterConnectTimeout returns the clusterConnectTimeout
 	ClusterConnectTimeout() time.Duration
 
+	// SetTopologyChangeWarmUpTimeout sets the max time to wait for the
+	// connection pools of hosts added by a topology change to fill before
+	// switching to the new topology, zero disables waiting
+	SetTopologyChangeWarmUpTimeout(value time.Duration) Options
+
+	// TopologyChangeWarmUpTimeout returns the max time to wait for the
+	// connection pools of hosts added by a topology change to fill before
+	// switching to the new topology, zero disables waiting
+	TopologyChangeWarmUpTimeout() time.Duration
+
 	// SetClusterConnectConsistencyLevel sets the clusterConnectConsistencyLevel
 	SetClusterConnectConsistencyLevel(value topology.ConnectConsistencyLevel) Options
 
@@ -312,6 +386,14 @@ type Options interface {
 	// BackgroundConnectStutter returns the backgroundConnectStutter
 	BackgroundConnectStutter() time.Duration
 
+	// SetBackgroundConnectMaxBackoff sets the max time to back off between
+	// background connect attempts to a host that keeps failing to connect
+	SetBackgroundConnectMaxBackoff(value time.Duration) Options
+
+	// BackgroundConnectMaxBackoff returns the max time to back off between
+	// background connect attempts to a host that keeps failing to connect
+	BackgroundConnectMaxBackoff() time.Duration
+
 	// SetBackgroundHealthCheckInterval sets the background health check interval
 	SetBackgroundHealthCheckInterval(value time.Duration) Options
 
@@ -432,6 +514,25 @@ type Options interface {
 	// HostQueueOpsFlushSize returns the hostQueueOpsFlushSize
 	HostQueueOpsFlushSize() int
 
+	// SetWriteBatchElementRetries sets the number of times individual elements
+	// of a write batch that failed with a retryable error are resent to a host,
+	// the elements that succeeded are not resent
+	SetWriteBatchElementRetries(value int) Options
+
+	// WriteBatchElementRetries returns the number of times individual elements
+	// of a write batch that failed with a retryable error are resent to a host
+	WriteBatchElementRetries() int
+
+	// SetWriteResourceExhaustedMaxBackoff sets the max time to wait before
+	// resending elements of a write batch to a host that returned a resource
+	// exhausted error, the host's retry after hint is used up to this max
+	SetWriteResourceExhaustedMaxBackoff(value time.Duration) Options
+
+	// WriteResourceExhaustedMaxBackoff returns the max time to wait before
+	// resending elements of a write batch to a host that returned a resource
+	// exhausted error
+	WriteResourceExhaustedMaxBackoff() time.Duration
+
 	// SetHostQueueOpsFlushInterval sets the hostQueueOpsFlushInterval
 	SetHostQueueOpsFlushInterval(value time.Duration) Options
 
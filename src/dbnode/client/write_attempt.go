@@ -58,6 +58,8 @@ type writeAttemptArgs struct {
 	annotation  []byte
 	unit        xtime.Unit
 	attemptType writeAttemptType
+	sequence    int64
+	hasSequence bool
 }
 
 func (w *writeAttempt) reset() {
@@ -65,9 +67,13 @@ func (w *writeAttempt) reset() {
 }
 
 func (w *writeAttempt) perform() error {
+	var sequence *int64
+	if w.args.hasSequence {
+		sequence = &w.args.sequence
+	}
 	err := w.session.writeAttempt(w.args.attemptType,
 		w.args.namespace, w.args.id, w.args.tags, w.args.t,
-		w.args.value, w.args.unit, w.args.annotation)
+		w.args.value, w.args.unit, w.args.annotation, sequence)
 
 	if IsBadRequestError(err) {
 		// Do not retry bad request errors
@@ -38,6 +38,7 @@ type writeOperation struct {
 	shardID      uint32
 	request      rpc.WriteBatchRawRequestElement
 	datapoint    rpc.Datapoint
+	sequence     int64
 	completionFn completionFn
 	pool         *writeOperationPool
 }
@@ -47,6 +48,14 @@ func (w *writeOperation) reset() {
 	w.request.Datapoint = &w.datapoint
 }
 
+func (w *writeOperation) setSequence(sequence *int64) {
+	if sequence == nil {
+		return
+	}
+	w.sequence = *sequence
+	w.request.Datapoint.WriteSequence = &w.sequence
+}
+
 func (w *writeOperation) Close() {
 	p := w.pool
 	w.reset()
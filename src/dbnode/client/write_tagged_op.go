@@ -38,6 +38,7 @@ type writeTaggedOperation struct {
 	shardID      uint32
 	request      rpc.WriteTaggedBatchRawRequestElement
 	datapoint    rpc.Datapoint
+	sequence     int64
 	completionFn completionFn
 	pool         *writeTaggedOperationPool
 }
@@ -47,6 +48,14 @@ func (w *writeTaggedOperation) reset() {
 	w.request.Datapoint = &w.datapoint
 }
 
+func (w *writeTaggedOperation) setSequence(sequence *int64) {
+	if sequence == nil {
+		return
+	}
+	w.sequence = *sequence
+	w.request.Datapoint.WriteSequence = &w.sequence
+}
+
 func (w *writeTaggedOperation) Close() {
 	p := w.pool
 	w.reset()
@@ -39,6 +39,7 @@ type seriesIterator struct {
 	err              error
 	firstNext        bool
 	closed           bool
+	completeness     float64
 	pool             SeriesIteratorPool
 }
 
@@ -121,6 +122,14 @@ func (it *seriesIterator) Replicas() []MultiReaderIterator {
 	return it.multiReaderIters
 }
 
+func (it *seriesIterator) Completeness() float64 {
+	return it.completeness
+}
+
+func (it *seriesIterator) SetCompleteness(value float64) {
+	it.completeness = value
+}
+
 func (it *seriesIterator) Reset(id ident.ID, nsID ident.ID, tags ident.TagIterator, startInclusive, endExclusive time.Time, replicas []MultiReaderIterator) {
 	it.id = id
 	it.nsID = nsID
@@ -133,6 +142,7 @@ func (it *seriesIterator) Reset(id ident.ID, nsID ident.ID, tags ident.TagIterat
 	it.err = nil
 	it.firstNext = true
 	it.closed = false
+	it.completeness = 1
 	for _, replica := range replicas {
 		if !replica.Next() || !it.iters.push(replica) {
 			replica.Close()
@@ -153,6 +153,23 @@ func TestSeriesIteratorErrorOnOutOfOrder(t *testing.T) {
 	assertTestSeriesIterator(t, test)
 }
 
+func TestSeriesIteratorCompleteness(t *testing.T) {
+	start := time.Now().Truncate(time.Minute)
+	end := start.Add(time.Minute)
+
+	iter := NewSeriesIterator(ident.StringID("foo"), ident.StringID("bar"),
+		ident.EmptyTagIterator, start, end, nil, nil)
+	assert.Equal(t, 1.0, iter.Completeness())
+
+	iter.SetCompleteness(0.5)
+	assert.Equal(t, 0.5, iter.Completeness())
+
+	iter.Reset(ident.StringID("foo"), ident.StringID("bar"),
+		ident.EmptyTagIterator, start, end, nil)
+	assert.Equal(t, 1.0, iter.Completeness())
+	iter.Close()
+}
+
 func assertTestSeriesIterator(
 	t *testing.T,
 	series testSeries,
@@ -175,6 +175,14 @@ type SeriesIterator interface {
 
 	// Replicas exposes the underlying MultiReaderIterator slice for this SeriesIterator
 	Replicas() []MultiReaderIterator
+
+	// Completeness returns the fraction of the replica responses required by
+	// the read consistency level that returned the series, capped at one.
+	Completeness() float64
+
+	// SetCompleteness sets the completeness of the series, it is reset to one
+	// when the iterator is reset.
+	SetCompleteness(value float64)
 }
 
 // SeriesIterators is a collection of SeriesIterator that can close all iterators
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backend
+
+import (
+	"errors"
+	"sync"
+
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3cluster/services"
+)
+
+const (
+	defaultNamespace = "_kv"
+)
+
+var (
+	errTxnNotSupported       = errors.New("transactions are not supported by this backend")
+	errHeartbeatNotSupported = errors.New("heartbeats are not supported by this backend")
+	errLeaderNotSupported    = errors.New("leader election is not supported by this backend")
+)
+
+type client struct {
+	sync.Mutex
+
+	backend Backend
+	opts    Options
+	stores  map[string]kv.Store
+}
+
+// NewClient returns a cluster client that stores namespaces, placements
+// and other cluster metadata in a backend. The client does not support
+// transactions, heartbeats or leader election, so services must be
+// queried with unhealthy instances included.
+func NewClient(backend Backend, opts Options) (clusterclient.Client, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &client{
+		backend: backend,
+		opts:    opts,
+		stores:  make(map[string]kv.Store),
+	}, nil
+}
+
+func (c *client) Services(opts services.OverrideOptions) (services.Services, error) {
+	if opts == nil {
+		opts = services.NewOverrideOptions()
+	}
+
+	servicesOpts := c.opts.ServicesOptions().
+		SetNamespaceOptions(opts.NamespaceOptions()).
+		SetInstrumentsOptions(c.opts.InstrumentOptions()).
+		SetKVGen(func(zone string) (kv.Store, error) {
+			return c.Store(kv.NewOverrideOptions().SetZone(zone))
+		}).
+		SetHeartbeatGen(func(sid services.ServiceID) (services.HeartbeatService, error) {
+			return nil, errHeartbeatNotSupported
+		}).
+		SetLeaderGen(func(sid services.ServiceID, opts services.ElectionOptions) (services.LeaderService, error) {
+			return nil, errLeaderNotSupported
+		})
+	return services.NewServices(servicesOpts)
+}
+
+func (c *client) KV() (kv.Store, error) {
+	return c.Store(kv.NewOverrideOptions())
+}
+
+func (c *client) Txn() (kv.TxnStore, error) {
+	return nil, errTxnNotSupported
+}
+
+func (c *client) Store(opts kv.OverrideOptions) (kv.Store, error) {
+	if opts == nil {
+		opts = kv.NewOverrideOptions()
+	}
+	if opts.Namespace() == "" {
+		opts = opts.SetNamespace(defaultNamespace)
+	}
+	if opts.Environment() == "" {
+		opts = opts.SetEnvironment(c.opts.Env())
+	}
+	if opts.Zone() == "" {
+		opts = opts.SetZone(c.opts.Zone())
+	}
+
+	// Stores share watches so reuse them for the same prefix.
+	storeKey := opts.Namespace() + keySeparator + opts.Environment()
+
+	c.Lock()
+	defer c.Unlock()
+
+	store, ok := c.stores[storeKey]
+	if !ok {
+		logger := c.opts.InstrumentOptions().Logger()
+		store = NewStore(c.backend, opts, logger)
+		c.stores[storeKey] = store
+	}
+	return store, nil
+}
+
+func (c *client) TxnStore(opts kv.OverrideOptions) (kv.TxnStore, error) {
+	return nil, errTxnNotSupported
+}
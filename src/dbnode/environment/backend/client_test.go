@@ -0,0 +1,76 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientStoreDefaults(t *testing.T) {
+	backend := newTestBackend()
+	client, err := NewClient(backend, NewOptions().SetEnv("test"))
+	require.NoError(t, err)
+
+	store, err := client.KV()
+	require.NoError(t, err)
+
+	_, err = store.Set("key", testRegistry("a"))
+	require.NoError(t, err)
+
+	_, ok := backend.entries["_kv/test/key"]
+	assert.True(t, ok)
+
+	// Stores for the same namespace and environment are shared.
+	other, err := client.Store(kv.NewOverrideOptions())
+	require.NoError(t, err)
+	assert.True(t, store == other)
+
+	store, err = client.Store(kv.NewOverrideOptions().
+		SetNamespace("ns").SetEnvironment("env"))
+	require.NoError(t, err)
+
+	_, err = store.Set("key", testRegistry("a"))
+	require.NoError(t, err)
+
+	_, ok = backend.entries["ns/env/key"]
+	assert.True(t, ok)
+}
+
+func TestClientTxnNotSupported(t *testing.T) {
+	client, err := NewClient(newTestBackend(), NewOptions().SetEnv("test"))
+	require.NoError(t, err)
+
+	_, err = client.Txn()
+	assert.Equal(t, errTxnNotSupported, err)
+
+	_, err = client.TxnStore(kv.NewOverrideOptions())
+	assert.Equal(t, errTxnNotSupported, err)
+}
+
+func TestNewClientRequiresEnv(t *testing.T) {
+	_, err := NewClient(newTestBackend(), NewOptions())
+	assert.Equal(t, errNoEnv, err)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backend
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/dbnode/environment/backend/consul"
+	"github.com/m3db/m3/src/dbnode/environment/backend/zookeeper"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/services"
+	"github.com/m3db/m3x/instrument"
+)
+
+var (
+	errNoBackend        = errors.New("must supply either consul or zookeeper config")
+	errMultipleBackends = errors.New("must supply only one of consul or zookeeper config")
+)
+
+// Configuration is the configuration for a cluster client that stores
+// cluster metadata in Consul or ZooKeeper instead of etcd.
+type Configuration struct {
+	Zone      string                   `yaml:"zone"`
+	Env       string                   `yaml:"env" validate:"nonzero"`
+	Service   string                   `yaml:"service" validate:"nonzero"`
+	Consul    *consul.Configuration    `yaml:"consul"`
+	ZooKeeper *zookeeper.Configuration `yaml:"zookeeper"`
+	SDConfig  services.Configuration   `yaml:"m3sd"`
+}
+
+// ServiceID returns the ID of the configured service.
+func (c Configuration) ServiceID() services.ServiceID {
+	return services.NewServiceID().
+		SetName(c.Service).
+		SetEnvironment(c.Env).
+		SetZone(c.Zone)
+}
+
+// NewBackend creates the configured backend.
+func (c Configuration) NewBackend(instrumentOpts instrument.Options) (Backend, error) {
+	switch {
+	case c.Consul != nil && c.ZooKeeper != nil:
+		return nil, errMultipleBackends
+	case c.Consul != nil:
+		return c.Consul.NewBackend(instrumentOpts)
+	case c.ZooKeeper != nil:
+		return c.ZooKeeper.NewBackend(instrumentOpts)
+	}
+	return nil, errNoBackend
+}
+
+// NewOptions returns the cluster client options for the configuration.
+func (c Configuration) NewOptions() Options {
+	return NewOptions().
+		SetEnv(c.Env).
+		SetZone(c.Zone).
+		SetServicesOptions(c.SDConfig.NewOptions())
+}
+
+// NewClient creates a cluster client over the configured backend.
+func (c Configuration) NewClient(instrumentOpts instrument.Options) (clusterclient.Client, error) {
+	backend, err := c.NewBackend(instrumentOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := c.NewOptions().SetInstrumentOptions(instrumentOpts)
+	client, err := NewClient(backend, opts)
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+	return client, nil
+}
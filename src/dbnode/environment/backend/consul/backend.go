@@ -0,0 +1,231 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3x/instrument"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	watchRetryInterval = time.Second
+)
+
+// Backend is a cluster metadata backend that stores keys in the Consul KV
+// store. Consul's modify index is used for compare and set while the key
+// version is kept in the flags of each key.
+type Backend struct {
+	kv       *api.KV
+	prefix   string
+	waitTime time.Duration
+	logger   xlog.Logger
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewBackend returns a new Consul backend.
+func NewBackend(
+	kv *api.KV,
+	prefix string,
+	waitTime time.Duration,
+	instrumentOpts instrument.Options,
+) *Backend {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Backend{
+		kv:       kv,
+		prefix:   strings.Trim(prefix, "/"),
+		waitTime: waitTime,
+		logger:   instrumentOpts.Logger(),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Get returns the data and version stored for a key.
+func (b *Backend) Get(key string) ([]byte, int, error) {
+	pair, _, err := b.kv.Get(b.path(key), b.queryOptions())
+	if err != nil {
+		return nil, 0, err
+	}
+	if pair == nil {
+		return nil, 0, kv.ErrNotFound
+	}
+	return pair.Value, int(pair.Flags), nil
+}
+
+// Create stores data for a key that does not exist yet.
+func (b *Backend) Create(key string, data []byte) error {
+	// A modify index of zero only succeeds if the key does not exist.
+	ok, _, err := b.kv.CAS(&api.KVPair{
+		Key:         b.path(key),
+		Value:       data,
+		Flags:       1,
+		ModifyIndex: 0,
+	}, b.writeOptions())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return kv.ErrAlreadyExists
+	}
+	return nil
+}
+
+// Update stores data for a key if its current version matches.
+func (b *Backend) Update(key string, version int, data []byte) error {
+	pair, err := b.pairWithVersion(key, version)
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := b.kv.CAS(&api.KVPair{
+		Key:         pair.Key,
+		Value:       data,
+		Flags:       uint64(version + 1),
+		ModifyIndex: pair.ModifyIndex,
+	}, b.writeOptions())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return kv.ErrVersionMismatch
+	}
+	return nil
+}
+
+// Delete removes a key if its current version matches.
+func (b *Backend) Delete(key string, version int) error {
+	pair, err := b.pairWithVersion(key, version)
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := b.kv.DeleteCAS(pair, b.writeOptions())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return kv.ErrVersionMismatch
+	}
+	return nil
+}
+
+func (b *Backend) pairWithVersion(key string, version int) (*api.KVPair, error) {
+	pair, _, err := b.kv.Get(b.path(key), b.queryOptions())
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, kv.ErrNotFound
+	}
+	if int(pair.Flags) != version {
+		return nil, kv.ErrVersionMismatch
+	}
+	return pair, nil
+}
+
+// Watch returns a channel that is notified whenever the key may have changed.
+func (b *Backend) Watch(key string) (<-chan struct{}, error) {
+	notifyCh := make(chan struct{}, 1)
+	go b.watch(b.path(key), notifyCh)
+	return notifyCh, nil
+}
+
+func (b *Backend) watch(path string, notifyCh chan struct{}) {
+	defer close(notifyCh)
+
+	var index uint64
+	for {
+		opts := &api.QueryOptions{
+			WaitIndex:         index,
+			WaitTime:          b.waitTime,
+			RequireConsistent: true,
+		}
+		_, meta, err := b.kv.Get(path, opts.WithContext(b.ctx))
+		if b.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.logger.Errorf("consul watch on key %s failed: %v", path, err)
+			select {
+			case <-time.After(watchRetryInterval):
+				// Changes may have been missed while failing.
+				index = 0
+				continue
+			case <-b.ctx.Done():
+				return
+			}
+		}
+
+		if meta.LastIndex == index {
+			// Wait time elapsed without any change.
+			continue
+		}
+		// The index can go backwards, e.g. when the Consul servers
+		// lose their state, in which case the watch starts over.
+		if meta.LastIndex < index {
+			index = 0
+		} else {
+			index = meta.LastIndex
+		}
+
+		notify(notifyCh)
+	}
+}
+
+// Close closes the backend and all its watches.
+func (b *Backend) Close() error {
+	b.cancel()
+	return nil
+}
+
+func (b *Backend) path(key string) string {
+	// Consul keys must not start with a slash.
+	key = strings.TrimLeft(key, "/")
+	if b.prefix == "" {
+		return key
+	}
+	return b.prefix + "/" + key
+}
+
+func (b *Backend) queryOptions() *api.QueryOptions {
+	opts := &api.QueryOptions{RequireConsistent: true}
+	return opts.WithContext(b.ctx)
+}
+
+func (b *Backend) writeOptions() *api.WriteOptions {
+	opts := &api.WriteOptions{}
+	return opts.WithContext(b.ctx)
+}
+
+func notify(notifyCh chan struct{}) {
+	select {
+	case notifyCh <- struct{}{}:
+	default:
+	}
+}
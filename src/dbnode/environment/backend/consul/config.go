@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	defaultWatchWaitTime = 5 * time.Minute
+)
+
+// Configuration is the configuration for a Consul backend.
+type Configuration struct {
+	// Address is the address of the Consul agent.
+	Address string `yaml:"address" validate:"nonzero"`
+
+	// Scheme is the URI scheme of the Consul agent, defaults to http.
+	Scheme string `yaml:"scheme"`
+
+	// Datacenter is the datacenter to use, defaults to the agent's.
+	Datacenter string `yaml:"datacenter"`
+
+	// Token is the ACL token used for requests.
+	Token string `yaml:"token"`
+
+	// Prefix is prepended to all keys stored in Consul.
+	Prefix string `yaml:"prefix"`
+
+	// WatchWaitTime is the maximum time a blocking watch query waits for.
+	WatchWaitTime time.Duration `yaml:"watchWaitTime"`
+}
+
+// NewBackend creates a new Consul backend.
+func (c Configuration) NewBackend(instrumentOpts instrument.Options) (*Backend, error) {
+	apiCfg := api.DefaultConfig()
+	apiCfg.Address = c.Address
+	if c.Scheme != "" {
+		apiCfg.Scheme = c.Scheme
+	}
+	apiCfg.Datacenter = c.Datacenter
+	apiCfg.Token = c.Token
+
+	apiClient, err := api.NewClient(apiCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	waitTime := c.WatchWaitTime
+	if waitTime <= 0 {
+		waitTime = defaultWatchWaitTime
+	}
+
+	return NewBackend(apiClient.KV(), c.Prefix, waitTime, instrumentOpts), nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backend
+
+import (
+	"errors"
+
+	"github.com/m3db/m3cluster/services"
+	"github.com/m3db/m3x/instrument"
+)
+
+var (
+	errNoEnv             = errors.New("no env set")
+	errNoServicesOptions = errors.New("no services options set")
+)
+
+type options struct {
+	env               string
+	zone              string
+	servicesOpts      services.Options
+	instrumentOptions instrument.Options
+}
+
+// NewOptions creates a new set of cluster client options.
+func NewOptions() Options {
+	return &options{
+		servicesOpts:      services.NewOptions(),
+		instrumentOptions: instrument.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.env == "" {
+		return errNoEnv
+	}
+	if o.servicesOpts == nil {
+		return errNoServicesOptions
+	}
+	return nil
+}
+
+func (o *options) SetEnv(value string) Options {
+	opts := *o
+	opts.env = value
+	return &opts
+}
+
+func (o *options) Env() string {
+	return o.env
+}
+
+func (o *options) SetZone(value string) Options {
+	opts := *o
+	opts.zone = value
+	return &opts
+}
+
+func (o *options) Zone() string {
+	return o.zone
+}
+
+func (o *options) SetServicesOptions(value services.Options) Options {
+	opts := *o
+	opts.servicesOpts = value
+	return &opts
+}
+
+func (o *options) ServicesOptions() services.Options {
+	return o.servicesOpts
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOptions = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOptions
+}
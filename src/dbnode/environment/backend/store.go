@@ -0,0 +1,239 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backend
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/m3db/m3cluster/kv"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	keySeparator = "/"
+)
+
+var (
+	errHistoryNotSupported = errors.New("history is not supported by this backend")
+)
+
+type store struct {
+	sync.Mutex
+
+	backend    Backend
+	prefix     string
+	logger     xlog.Logger
+	watchables map[string]kv.ValueWatchable
+}
+
+// NewStore returns a new kv.Store that stores values in a backend, keys
+// are prefixed with the namespace and environment of the override options.
+func NewStore(backend Backend, opts kv.OverrideOptions, logger xlog.Logger) kv.Store {
+	var parts []string
+	if namespace := opts.Namespace(); namespace != "" {
+		parts = append(parts, namespace)
+	}
+	if env := opts.Environment(); env != "" {
+		parts = append(parts, env)
+	}
+
+	var prefix string
+	if len(parts) > 0 {
+		prefix = strings.Join(parts, keySeparator) + keySeparator
+	}
+
+	return &store{
+		backend:    backend,
+		prefix:     prefix,
+		logger:     logger,
+		watchables: make(map[string]kv.ValueWatchable),
+	}
+}
+
+func (s *store) Get(key string) (kv.Value, error) {
+	return s.get(s.prefix + key)
+}
+
+func (s *store) get(key string) (kv.Value, error) {
+	data, version, err := s.backend.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return newValue(data, version), nil
+}
+
+func (s *store) Watch(key string) (kv.ValueWatch, error) {
+	key = s.prefix + key
+
+	s.Lock()
+	watchable, ok := s.watchables[key]
+	if !ok {
+		notifyCh, err := s.backend.Watch(key)
+		if err != nil {
+			s.Unlock()
+			return nil, err
+		}
+
+		watchable = kv.NewValueWatchable()
+		s.watchables[key] = watchable
+		s.refresh(key, watchable)
+
+		go func() {
+			for range notifyCh {
+				s.refresh(key, watchable)
+			}
+		}()
+	}
+	s.Unlock()
+
+	_, w, err := watchable.Watch()
+	return w, err
+}
+
+func (s *store) refresh(key string, watchable kv.ValueWatchable) {
+	v, err := s.get(key)
+	if err == kv.ErrNotFound {
+		return
+	}
+	if err != nil {
+		s.logger.Errorf("could not refresh watched key %s: %v", key, err)
+		return
+	}
+
+	if curr := watchable.Get(); curr != nil && !v.IsNewer(curr) {
+		return
+	}
+	if err := watchable.Update(v); err != nil {
+		s.logger.Errorf("could not update watch for key %s: %v", key, err)
+	}
+}
+
+func (s *store) Set(key string, v proto.Message) (int, error) {
+	data, err := proto.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+
+	key = s.prefix + key
+	for {
+		_, version, err := s.backend.Get(key)
+		switch err {
+		case nil:
+			err = s.backend.Update(key, version, data)
+			if err == nil {
+				return version + 1, nil
+			}
+		case kv.ErrNotFound:
+			err = s.backend.Create(key, data)
+			if err == nil {
+				return 1, nil
+			}
+		}
+		if err != kv.ErrVersionMismatch && err != kv.ErrAlreadyExists &&
+			err != kv.ErrNotFound {
+			return 0, err
+		}
+		// Lost a race with a concurrent writer, try again.
+	}
+}
+
+func (s *store) SetIfNotExists(key string, v proto.Message) (int, error) {
+	data, err := proto.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.backend.Create(s.prefix+key, data); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func (s *store) CheckAndSet(key string, version int, v proto.Message) (int, error) {
+	if version == 0 {
+		// A version of zero means the key is expected to not exist yet.
+		n, err := s.SetIfNotExists(key, v)
+		if err == kv.ErrAlreadyExists {
+			return 0, kv.ErrVersionMismatch
+		}
+		return n, err
+	}
+
+	data, err := proto.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	err = s.backend.Update(s.prefix+key, version, data)
+	if err == kv.ErrNotFound {
+		return 0, kv.ErrVersionMismatch
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+func (s *store) Delete(key string) (kv.Value, error) {
+	key = s.prefix + key
+	for {
+		v, err := s.get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.backend.Delete(key, v.Version())
+		if err == nil {
+			return v, nil
+		}
+		if err != kv.ErrVersionMismatch {
+			return nil, err
+		}
+		// Key was updated concurrently, try again.
+	}
+}
+
+func (s *store) History(key string, from, to int) ([]kv.Value, error) {
+	return nil, errHistoryNotSupported
+}
+
+type value struct {
+	data    []byte
+	version int
+}
+
+func newValue(data []byte, version int) kv.Value {
+	return &value{data: data, version: version}
+}
+
+func (v *value) Unmarshal(msg proto.Message) error {
+	return proto.Unmarshal(v.data, msg)
+}
+
+func (v *value) Version() int {
+	return v.version
+}
+
+func (v *value) IsNewer(other kv.Value) bool {
+	return v.version > other.Version()
+}
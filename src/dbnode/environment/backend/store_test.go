@@ -0,0 +1,278 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backend
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEntry struct {
+	data    []byte
+	version int
+}
+
+type testBackend struct {
+	sync.Mutex
+
+	entries  map[string]testEntry
+	watches  map[string][]chan struct{}
+	failures int
+}
+
+func newTestBackend() *testBackend {
+	return &testBackend{
+		entries: make(map[string]testEntry),
+		watches: make(map[string][]chan struct{}),
+	}
+}
+
+func (b *testBackend) Get(key string) ([]byte, int, error) {
+	b.Lock()
+	defer b.Unlock()
+	e, ok := b.entries[key]
+	if !ok {
+		return nil, 0, kv.ErrNotFound
+	}
+	return e.data, e.version, nil
+}
+
+func (b *testBackend) Create(key string, data []byte) error {
+	b.Lock()
+	defer b.Unlock()
+	if _, ok := b.entries[key]; ok {
+		return kv.ErrAlreadyExists
+	}
+	b.entries[key] = testEntry{data: data, version: 1}
+	b.notifyWithLock(key)
+	return nil
+}
+
+func (b *testBackend) Update(key string, version int, data []byte) error {
+	b.Lock()
+	defer b.Unlock()
+	e, ok := b.entries[key]
+	if !ok {
+		return kv.ErrNotFound
+	}
+	if b.failures > 0 {
+		// Simulate a concurrent writer winning the race.
+		b.failures--
+		return kv.ErrVersionMismatch
+	}
+	if e.version != version {
+		return kv.ErrVersionMismatch
+	}
+	b.entries[key] = testEntry{data: data, version: version + 1}
+	b.notifyWithLock(key)
+	return nil
+}
+
+func (b *testBackend) Delete(key string, version int) error {
+	b.Lock()
+	defer b.Unlock()
+	e, ok := b.entries[key]
+	if !ok {
+		return kv.ErrNotFound
+	}
+	if e.version != version {
+		return kv.ErrVersionMismatch
+	}
+	delete(b.entries, key)
+	b.notifyWithLock(key)
+	return nil
+}
+
+func (b *testBackend) Watch(key string) (<-chan struct{}, error) {
+	b.Lock()
+	defer b.Unlock()
+	ch := make(chan struct{}, 1)
+	b.watches[key] = append(b.watches[key], ch)
+	return ch, nil
+}
+
+func (b *testBackend) Close() error {
+	b.Lock()
+	defer b.Unlock()
+	for _, chs := range b.watches {
+		for _, ch := range chs {
+			close(ch)
+		}
+	}
+	b.watches = nil
+	return nil
+}
+
+func (b *testBackend) notifyWithLock(key string) {
+	for _, ch := range b.watches[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func newTestStore(backend Backend) kv.Store {
+	opts := kv.NewOverrideOptions().SetNamespace("_kv").SetEnvironment("test")
+	return NewStore(backend, opts, instrument.NewOptions().Logger())
+}
+
+func testRegistry(ids ...string) *nsproto.Registry {
+	registry := &nsproto.Registry{
+		Namespaces: make(map[string]*nsproto.NamespaceOptions),
+	}
+	for _, id := range ids {
+		registry.Namespaces[id] = &nsproto.NamespaceOptions{}
+	}
+	return registry
+}
+
+func TestStoreSetAndGet(t *testing.T) {
+	backend := newTestBackend()
+	store := newTestStore(backend)
+
+	_, err := store.Get("key")
+	require.Equal(t, kv.ErrNotFound, err)
+
+	version, err := store.Set("key", testRegistry("a"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	version, err = store.Set("key", testRegistry("a", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	value, err := store.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, 2, value.Version())
+
+	var registry nsproto.Registry
+	require.NoError(t, value.Unmarshal(&registry))
+	assert.Len(t, registry.Namespaces, 2)
+
+	// Keys are prefixed with the namespace and environment.
+	_, ok := backend.entries["_kv/test/key"]
+	assert.True(t, ok)
+}
+
+func TestStoreSetRetriesOnVersionMismatch(t *testing.T) {
+	backend := newTestBackend()
+	store := newTestStore(backend)
+
+	_, err := store.Set("key", testRegistry("a"))
+	require.NoError(t, err)
+
+	backend.failures = 2
+	version, err := store.Set("key", testRegistry("b"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.Equal(t, 0, backend.failures)
+}
+
+func TestStoreSetIfNotExistsAndCheckAndSet(t *testing.T) {
+	store := newTestStore(newTestBackend())
+
+	version, err := store.SetIfNotExists("key", testRegistry("a"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	_, err = store.SetIfNotExists("key", testRegistry("b"))
+	assert.Equal(t, kv.ErrAlreadyExists, err)
+
+	_, err = store.CheckAndSet("key", 0, testRegistry("b"))
+	assert.Equal(t, kv.ErrVersionMismatch, err)
+
+	_, err = store.CheckAndSet("key", 2, testRegistry("b"))
+	assert.Equal(t, kv.ErrVersionMismatch, err)
+
+	version, err = store.CheckAndSet("key", 1, testRegistry("b"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	version, err = store.CheckAndSet("other", 0, testRegistry("c"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	_, err = store.CheckAndSet("missing", 1, testRegistry("c"))
+	assert.Equal(t, kv.ErrVersionMismatch, err)
+}
+
+func TestStoreDelete(t *testing.T) {
+	store := newTestStore(newTestBackend())
+
+	_, err := store.Delete("key")
+	assert.Equal(t, kv.ErrNotFound, err)
+
+	_, err = store.Set("key", testRegistry("a"))
+	require.NoError(t, err)
+
+	value, err := store.Delete("key")
+	require.NoError(t, err)
+	assert.Equal(t, 1, value.Version())
+
+	_, err = store.Get("key")
+	assert.Equal(t, kv.ErrNotFound, err)
+}
+
+func TestStoreWatch(t *testing.T) {
+	backend := newTestBackend()
+	store := newTestStore(backend)
+
+	_, err := store.Set("key", testRegistry("a"))
+	require.NoError(t, err)
+
+	w, err := store.Watch("key")
+	require.NoError(t, err)
+	defer w.Close()
+
+	<-w.C()
+	assert.Equal(t, 1, w.Get().Version())
+
+	_, err = store.Set("key", testRegistry("a", "b"))
+	require.NoError(t, err)
+
+	select {
+	case <-w.C():
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for watch update")
+	}
+	assert.Equal(t, 2, w.Get().Version())
+
+	var registry nsproto.Registry
+	require.NoError(t, w.Get().Unmarshal(&registry))
+	assert.Len(t, registry.Namespaces, 2)
+
+	require.NoError(t, backend.Close())
+}
+
+func TestStoreHistoryNotSupported(t *testing.T) {
+	store := newTestStore(newTestBackend())
+	_, err := store.History("key", 1, 2)
+	assert.Equal(t, errHistoryNotSupported, err)
+}
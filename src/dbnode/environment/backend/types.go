@@ -0,0 +1,84 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backend
+
+import (
+	"github.com/m3db/m3cluster/services"
+	"github.com/m3db/m3x/instrument"
+)
+
+// Backend is a versioned key value store that namespaces, placements and
+// other cluster metadata can be stored in. Versions of a key start at 1
+// when the key is created and are incremented by 1 on every update.
+type Backend interface {
+	// Get returns the data and version stored for a key, or
+	// kv.ErrNotFound if the key does not exist.
+	Get(key string) ([]byte, int, error)
+
+	// Create stores data for a key that does not exist yet with
+	// version 1, or returns kv.ErrAlreadyExists.
+	Create(key string, data []byte) error
+
+	// Update stores data for a key if its current version matches
+	// version, or returns kv.ErrVersionMismatch.
+	Update(key string, version int, data []byte) error
+
+	// Delete removes a key if its current version matches version,
+	// or returns kv.ErrVersionMismatch.
+	Delete(key string, version int) error
+
+	// Watch returns a channel that is notified whenever the key may have
+	// changed, the channel is closed when the backend is closed.
+	Watch(key string) (<-chan struct{}, error)
+
+	// Close closes the backend and all its watches.
+	Close() error
+}
+
+// Options is a set of options for a cluster client over a backend.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetEnv sets the default environment of key value stores.
+	SetEnv(value string) Options
+
+	// Env returns the default environment of key value stores.
+	Env() string
+
+	// SetZone sets the zone of the backend.
+	SetZone(value string) Options
+
+	// Zone returns the zone of the backend.
+	Zone() string
+
+	// SetServicesOptions sets the options used to create services.
+	SetServicesOptions(value services.Options) Options
+
+	// ServicesOptions returns the options used to create services.
+	ServicesOptions() services.Options
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+}
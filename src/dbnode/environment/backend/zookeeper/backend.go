@@ -0,0 +1,179 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zookeeper
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3x/instrument"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const (
+	watchRetryInterval = time.Second
+)
+
+// Backend is a cluster metadata backend that stores each key as a
+// ZooKeeper node, the version of a key is the node's data version plus
+// one since ZooKeeper starts counting versions from zero.
+type Backend struct {
+	conn    *zk.Conn
+	root    string
+	acl     []zk.ACL
+	logger  xlog.Logger
+	closeCh chan struct{}
+}
+
+// NewBackend returns a new ZooKeeper backend over a connection.
+func NewBackend(
+	conn *zk.Conn,
+	root string,
+	instrumentOpts instrument.Options,
+) *Backend {
+	return &Backend{
+		conn:    conn,
+		root:    path.Join("/", root),
+		acl:     zk.WorldACL(zk.PermAll),
+		logger:  instrumentOpts.Logger(),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Get returns the data and version stored for a key.
+func (b *Backend) Get(key string) ([]byte, int, error) {
+	data, stat, err := b.conn.Get(b.path(key))
+	if err != nil {
+		return nil, 0, convertError(err)
+	}
+	return data, int(stat.Version) + 1, nil
+}
+
+// Create stores data for a key that does not exist yet.
+func (b *Backend) Create(key string, data []byte) error {
+	nodePath := b.path(key)
+	if err := b.createParents(nodePath); err != nil {
+		return err
+	}
+	_, err := b.conn.Create(nodePath, data, 0, b.acl)
+	return convertError(err)
+}
+
+// Update stores data for a key if its current version matches.
+func (b *Backend) Update(key string, version int, data []byte) error {
+	_, err := b.conn.Set(b.path(key), data, int32(version-1))
+	return convertError(err)
+}
+
+// Delete removes a key if its current version matches.
+func (b *Backend) Delete(key string, version int) error {
+	return convertError(b.conn.Delete(b.path(key), int32(version-1)))
+}
+
+// Watch returns a channel that is notified whenever the key may have changed.
+func (b *Backend) Watch(key string) (<-chan struct{}, error) {
+	notifyCh := make(chan struct{}, 1)
+	go b.watch(b.path(key), notifyCh)
+	return notifyCh, nil
+}
+
+func (b *Backend) watch(nodePath string, notifyCh chan struct{}) {
+	defer close(notifyCh)
+
+	failed := false
+	for {
+		// ZooKeeper watches fire once, so a new one is set after every event.
+		_, _, eventCh, err := b.conn.ExistsW(nodePath)
+		if err != nil {
+			b.logger.Errorf("zookeeper watch on node %s failed: %v", nodePath, err)
+			failed = true
+			select {
+			case <-time.After(watchRetryInterval):
+				continue
+			case <-b.closeCh:
+				return
+			}
+		}
+
+		if failed {
+			// Changes may have been missed while failing.
+			failed = false
+			notify(notifyCh)
+		}
+
+		select {
+		case <-eventCh:
+			notify(notifyCh)
+		case <-b.closeCh:
+			return
+		}
+	}
+}
+
+// Close closes the backend and all its watches.
+func (b *Backend) Close() error {
+	close(b.closeCh)
+	b.conn.Close()
+	return nil
+}
+
+func (b *Backend) path(key string) string {
+	return path.Join(b.root, key)
+}
+
+func (b *Backend) createParents(nodePath string) error {
+	parts := strings.Split(strings.TrimPrefix(path.Dir(nodePath), "/"), "/")
+	parent := ""
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		parent += "/" + part
+		_, err := b.conn.Create(parent, nil, 0, b.acl)
+		if err != nil && err != zk.ErrNodeExists {
+			return err
+		}
+	}
+	return nil
+}
+
+func notify(notifyCh chan struct{}) {
+	select {
+	case notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+func convertError(err error) error {
+	switch err {
+	case zk.ErrNoNode:
+		return kv.ErrNotFound
+	case zk.ErrNodeExists:
+		return kv.ErrAlreadyExists
+	case zk.ErrBadVersion:
+		return kv.ErrVersionMismatch
+	}
+	return err
+}
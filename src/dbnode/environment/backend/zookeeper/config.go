@@ -0,0 +1,66 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zookeeper
+
+import (
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const (
+	defaultSessionTimeout = 10 * time.Second
+	defaultRoot           = "/m3"
+)
+
+// Configuration is the configuration for a ZooKeeper backend.
+type Configuration struct {
+	// Servers are the addresses of the ZooKeeper ensemble.
+	Servers []string `yaml:"servers" validate:"nonzero"`
+
+	// SessionTimeout is the ZooKeeper session timeout.
+	SessionTimeout time.Duration `yaml:"sessionTimeout"`
+
+	// Root is the node all keys are stored under, defaults to /m3.
+	Root string `yaml:"root"`
+}
+
+// NewBackend connects to ZooKeeper and creates a new ZooKeeper backend.
+func (c Configuration) NewBackend(instrumentOpts instrument.Options) (*Backend, error) {
+	sessionTimeout := c.SessionTimeout
+	if sessionTimeout <= 0 {
+		sessionTimeout = defaultSessionTimeout
+	}
+
+	root := c.Root
+	if root == "" {
+		root = defaultRoot
+	}
+
+	conn, _, err := zk.Connect(c.Servers, sessionTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBackend(conn, root, instrumentOpts), nil
+}
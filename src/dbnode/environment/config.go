@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/environment/backend"
 	"github.com/m3db/m3/src/dbnode/kvconfig"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
@@ -43,7 +44,7 @@ const (
 )
 
 var (
-	errInvalidConfig = errors.New("must supply either service or static config")
+	errInvalidConfig = errors.New("must supply either service, backend or static config")
 )
 
 // Configuration is a configuration that can be used to create namespaces, a topology, and kv store
@@ -51,6 +52,9 @@ type Configuration struct {
 	// Service is used when a topology initializer is not supplied.
 	Service *etcdclient.Configuration `yaml:"service"`
 
+	// Backend is used instead of Service to store cluster metadata in Consul or ZooKeeper.
+	Backend *backend.Configuration `yaml:"backend"`
+
 	// StaticConfiguration is used for running M3DB with a static config
 	Static *StaticConfiguration `yaml:"static"`
 
@@ -120,11 +124,17 @@ type ConfigurationParameters struct {
 func (c Configuration) Configure(cfgParams ConfigurationParameters) (ConfigureResults, error) {
 	var emptyConfig ConfigureResults
 
-	if c.Service != nil && c.Static != nil {
+	numConfigs := 0
+	for _, set := range []bool{c.Service != nil, c.Backend != nil, c.Static != nil} {
+		if set {
+			numConfigs++
+		}
+	}
+	if numConfigs > 1 {
 		return emptyConfig, errInvalidConfig
 	}
 
-	if c.Service != nil {
+	if c.Service != nil || c.Backend != nil {
 		return c.configureDynamic(cfgParams)
 	}
 
@@ -136,15 +146,7 @@ func (c Configuration) Configure(cfgParams ConfigurationParameters) (ConfigureRe
 }
 
 func (c Configuration) configureDynamic(cfgParams ConfigurationParameters) (ConfigureResults, error) {
-	sdTimeout := defaultSDTimeout
-	if initTimeout := c.Service.SDConfig.InitTimeout; initTimeout != nil && *initTimeout != 0 {
-		sdTimeout = *initTimeout
-	}
-
-	configSvcClientOpts := c.Service.NewOptions().
-		SetInstrumentOptions(cfgParams.InstrumentOpts).
-		SetServicesOptions(c.Service.SDConfig.NewOptions().SetInitTimeout(sdTimeout))
-	configSvcClient, err := etcdclient.NewConfigServiceClient(configSvcClientOpts)
+	configSvcClient, serviceID, err := c.newConfigServiceClient(cfgParams)
 	if err != nil {
 		err = fmt.Errorf("could not create m3cluster client: %v", err)
 		return ConfigureResults{}, err
@@ -157,11 +159,6 @@ func (c Configuration) configureDynamic(cfgParams ConfigurationParameters) (Conf
 		SetInitTimeout(cfgParams.NamespaceResolutionTimeout)
 	nsInit := namespace.NewDynamicInitializer(dynamicOpts)
 
-	serviceID := services.NewServiceID().
-		SetName(c.Service.Service).
-		SetEnvironment(c.Service.Env).
-		SetZone(c.Service.Zone)
-
 	topoOpts := topology.NewDynamicOptions().
 		SetConfigServiceClient(configSvcClient).
 		SetServiceID(serviceID).
@@ -185,6 +182,50 @@ func (c Configuration) configureDynamic(cfgParams ConfigurationParameters) (Conf
 	}, nil
 }
 
+func (c Configuration) newConfigServiceClient(
+	cfgParams ConfigurationParameters,
+) (clusterclient.Client, services.ServiceID, error) {
+	if c.Backend != nil {
+		kvBackend, err := c.Backend.NewBackend(cfgParams.InstrumentOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clientOpts := c.Backend.NewOptions().
+			SetInstrumentOptions(cfgParams.InstrumentOpts).
+			SetServicesOptions(c.Backend.SDConfig.NewOptions().
+				SetInitTimeout(sdInitTimeout(c.Backend.SDConfig)))
+		configSvcClient, err := backend.NewClient(kvBackend, clientOpts)
+		if err != nil {
+			kvBackend.Close()
+			return nil, nil, err
+		}
+		return configSvcClient, c.Backend.ServiceID(), nil
+	}
+
+	configSvcClientOpts := c.Service.NewOptions().
+		SetInstrumentOptions(cfgParams.InstrumentOpts).
+		SetServicesOptions(c.Service.SDConfig.NewOptions().
+			SetInitTimeout(sdInitTimeout(c.Service.SDConfig)))
+	configSvcClient, err := etcdclient.NewConfigServiceClient(configSvcClientOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceID := services.NewServiceID().
+		SetName(c.Service.Service).
+		SetEnvironment(c.Service.Env).
+		SetZone(c.Service.Zone)
+	return configSvcClient, serviceID, nil
+}
+
+func sdInitTimeout(cfg services.Configuration) time.Duration {
+	if initTimeout := cfg.InitTimeout; initTimeout != nil && *initTimeout != 0 {
+		return *initTimeout
+	}
+	return defaultSDTimeout
+}
+
 func (c Configuration) configureStatic(cfgParams ConfigurationParameters) (ConfigureResults, error) {
 	var emptyConfig ConfigureResults
 
@@ -249,7 +290,8 @@ func newStaticShardSet(numShards int, hosts []topology.HostShardConfig) (shardin
 	}
 
 	shards := sharding.NewShards(shardIDs, shard.Available)
-	shardSet, err = sharding.NewShardSet(shards, sharding.DefaultHashFn(len(shards)))
+	shardSet, err = sharding.NewShardSetWithNumShards(shards,
+		sharding.DefaultHashFn(len(shards)), len(shards))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -24,6 +24,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/environment/backend"
+	"github.com/m3db/m3/src/dbnode/environment/backend/zookeeper"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
@@ -100,3 +102,23 @@ func TestConfigureDynamic(t *testing.T) {
 	assert.NotNil(t, configRes)
 	assert.NoError(t, err)
 }
+
+func TestConfigureMultipleDynamicConfigs(t *testing.T) {
+	config := Configuration{
+		Service: &etcdclient.Configuration{
+			Zone:    "local",
+			Env:     "test",
+			Service: "m3dbnode_test",
+		},
+		Backend: &backend.Configuration{
+			Env:     "test",
+			Service: "m3dbnode_test",
+			ZooKeeper: &zookeeper.Configuration{
+				Servers: []string{"localhost:2181"},
+			},
+		},
+	}
+
+	_, err := config.Configure(ConfigurationParameters{})
+	assert.Equal(t, errInvalidConfig, err)
+}
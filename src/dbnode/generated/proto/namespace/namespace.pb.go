@@ -22,16 +22,23 @@
 // THE SOFTWARE.
 
 /*
-	Package namespace is a generated protocol buffer package.
+Package namespace is a generated protocol buffer package.
 
-	It is generated from these files:
-		github.com/m3db/m3/src/dbnode/generated/proto/namespace/namespace.proto
+It is generated from these files:
 
-	It has these top-level messages:
-		RetentionOptions
-		IndexOptions
-		NamespaceOptions
-		Registry
+	github.com/m3db/m3/src/dbnode/generated/proto/namespace/namespace.proto
+
+It has these top-level messages:
+
+	RetentionOptions
+	IndexOptions
+	QuotaOptions
+	CompressionDictionaryOptions
+	ShardingOptions
+	NamespaceOptions
+	Registry
+	ContinuousQuery
+	RetentionTransition
 */
 package namespace
 
@@ -52,6 +59,89 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
+type QuotaExceededAction int32
+
+const (
+	QuotaExceededAction_WARN              QuotaExceededAction = 0
+	QuotaExceededAction_REJECT_NEW_SERIES QuotaExceededAction = 1
+	QuotaExceededAction_REJECT_WRITES     QuotaExceededAction = 2
+)
+
+var QuotaExceededAction_name = map[int32]string{
+	0: "WARN",
+	1: "REJECT_NEW_SERIES",
+	2: "REJECT_WRITES",
+}
+var QuotaExceededAction_value = map[string]int32{
+	"WARN":              0,
+	"REJECT_NEW_SERIES": 1,
+	"REJECT_WRITES":     2,
+}
+
+func (x QuotaExceededAction) String() string {
+	return proto.EnumName(QuotaExceededAction_name, int32(x))
+}
+func (QuotaExceededAction) EnumDescriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{0} }
+
+type HashStrategy int32
+
+const (
+	HashStrategy_MURMUR3    HashStrategy = 0
+	HashStrategy_JUMP       HashStrategy = 1
+	HashStrategy_TAG_SUBSET HashStrategy = 2
+)
+
+var HashStrategy_name = map[int32]string{
+	0: "MURMUR3",
+	1: "JUMP",
+	2: "TAG_SUBSET",
+}
+var HashStrategy_value = map[string]int32{
+	"MURMUR3":    0,
+	"JUMP":       1,
+	"TAG_SUBSET": 2,
+}
+
+func (x HashStrategy) String() string {
+	return proto.EnumName(HashStrategy_name, int32(x))
+}
+func (HashStrategy) EnumDescriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{1} }
+
+type ContinuousQueryAggregation int32
+
+const (
+	ContinuousQueryAggregation_MAX   ContinuousQueryAggregation = 0
+	ContinuousQueryAggregation_MIN   ContinuousQueryAggregation = 1
+	ContinuousQueryAggregation_SUM   ContinuousQueryAggregation = 2
+	ContinuousQueryAggregation_COUNT ContinuousQueryAggregation = 3
+	ContinuousQueryAggregation_MEAN  ContinuousQueryAggregation = 4
+	ContinuousQueryAggregation_LAST  ContinuousQueryAggregation = 5
+)
+
+var ContinuousQueryAggregation_name = map[int32]string{
+	0: "MAX",
+	1: "MIN",
+	2: "SUM",
+	3: "COUNT",
+	4: "MEAN",
+	5: "LAST",
+}
+var ContinuousQueryAggregation_value = map[string]int32{
+	"MAX":   0,
+	"MIN":   1,
+	"SUM":   2,
+	"COUNT": 3,
+	"MEAN":  4,
+	"LAST":  5,
+}
+
+func (x ContinuousQueryAggregation) String() string {
+	return proto.EnumName(ContinuousQueryAggregation_name, int32(x))
+}
+func (ContinuousQueryAggregation) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptorNamespace, []int{2}
+}
+
 type RetentionOptions struct {
 	RetentionPeriodNanos                     int64 `protobuf:"varint,1,opt,name=retentionPeriodNanos,proto3" json:"retentionPeriodNanos,omitempty"`
 	BlockSizeNanos                           int64 `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
@@ -109,8 +199,9 @@ func (m *RetentionOptions) GetBlockDataExpiryAfterNotAccessPeriodNanos() int64 {
 }
 
 type IndexOptions struct {
-	Enabled        bool  `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
-	BlockSizeNanos int64 `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
+	Enabled        bool     `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	BlockSizeNanos int64    `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
+	NumericTags    []string `protobuf:"bytes,3,rep,name=numericTags" json:"numericTags,omitempty"`
 }
 
 func (m *IndexOptions) Reset()                    { *m = IndexOptions{} }
@@ -132,21 +223,119 @@ func (m *IndexOptions) GetBlockSizeNanos() int64 {
 	return 0
 }
 
+func (m *IndexOptions) GetNumericTags() []string {
+	if m != nil {
+		return m.NumericTags
+	}
+	return nil
+}
+
+type QuotaOptions struct {
+	MaxBytes       int64               `protobuf:"varint,1,opt,name=maxBytes,proto3" json:"maxBytes,omitempty"`
+	ExceededAction QuotaExceededAction `protobuf:"varint,2,opt,name=exceededAction,proto3,enum=namespace.QuotaExceededAction" json:"exceededAction,omitempty"`
+}
+
+func (m *QuotaOptions) Reset()                    { *m = QuotaOptions{} }
+func (m *QuotaOptions) String() string            { return proto.CompactTextString(m) }
+func (*QuotaOptions) ProtoMessage()               {}
+func (*QuotaOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{2} }
+
+func (m *QuotaOptions) GetMaxBytes() int64 {
+	if m != nil {
+		return m.MaxBytes
+	}
+	return 0
+}
+
+func (m *QuotaOptions) GetExceededAction() QuotaExceededAction {
+	if m != nil {
+		return m.ExceededAction
+	}
+	return QuotaExceededAction_WARN
+}
+
+type CompressionDictionaryOptions struct {
+	Enabled    bool  `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	SampleSize int64 `protobuf:"varint,2,opt,name=sampleSize,proto3" json:"sampleSize,omitempty"`
+	MaxBytes   int64 `protobuf:"varint,3,opt,name=maxBytes,proto3" json:"maxBytes,omitempty"`
+}
+
+func (m *CompressionDictionaryOptions) Reset()         { *m = CompressionDictionaryOptions{} }
+func (m *CompressionDictionaryOptions) String() string { return proto.CompactTextString(m) }
+func (*CompressionDictionaryOptions) ProtoMessage()    {}
+func (*CompressionDictionaryOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptorNamespace, []int{3}
+}
+
+func (m *CompressionDictionaryOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+func (m *CompressionDictionaryOptions) GetSampleSize() int64 {
+	if m != nil {
+		return m.SampleSize
+	}
+	return 0
+}
+
+func (m *CompressionDictionaryOptions) GetMaxBytes() int64 {
+	if m != nil {
+		return m.MaxBytes
+	}
+	return 0
+}
+
+type ShardingOptions struct {
+	HashStrategy HashStrategy `protobuf:"varint,1,opt,name=hashStrategy,proto3,enum=namespace.HashStrategy" json:"hashStrategy,omitempty"`
+	TagNames     []string     `protobuf:"bytes,2,rep,name=tagNames" json:"tagNames,omitempty"`
+}
+
+func (m *ShardingOptions) Reset()                    { *m = ShardingOptions{} }
+func (m *ShardingOptions) String() string            { return proto.CompactTextString(m) }
+func (*ShardingOptions) ProtoMessage()               {}
+func (*ShardingOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{4} }
+
+func (m *ShardingOptions) GetHashStrategy() HashStrategy {
+	if m != nil {
+		return m.HashStrategy
+	}
+	return HashStrategy_MURMUR3
+}
+
+func (m *ShardingOptions) GetTagNames() []string {
+	if m != nil {
+		return m.TagNames
+	}
+	return nil
+}
+
 type NamespaceOptions struct {
-	BootstrapEnabled  bool              `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
-	FlushEnabled      bool              `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
-	WritesToCommitLog bool              `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
-	CleanupEnabled    bool              `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
-	RepairEnabled     bool              `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
-	RetentionOptions  *RetentionOptions `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
-	SnapshotEnabled   bool              `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
-	IndexOptions      *IndexOptions     `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
+	BootstrapEnabled             bool                          `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
+	FlushEnabled                 bool                          `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
+	WritesToCommitLog            bool                          `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
+	CleanupEnabled               bool                          `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
+	RepairEnabled                bool                          `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
+	RetentionOptions             *RetentionOptions             `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
+	SnapshotEnabled              bool                          `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
+	IndexOptions                 *IndexOptions                 `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
+	QuotaOptions                 *QuotaOptions                 `protobuf:"bytes,9,opt,name=quotaOptions" json:"quotaOptions,omitempty"`
+	CompressionDictionaryOptions *CompressionDictionaryOptions `protobuf:"bytes,10,opt,name=compressionDictionaryOptions" json:"compressionDictionaryOptions,omitempty"`
+	InMemoryOnly                 bool                          `protobuf:"varint,11,opt,name=inMemoryOnly,proto3" json:"inMemoryOnly,omitempty"`
+	EncryptionEnabled            bool                          `protobuf:"varint,12,opt,name=encryptionEnabled,proto3" json:"encryptionEnabled,omitempty"`
+	CloneSource                  string                        `protobuf:"bytes,13,opt,name=cloneSource,proto3" json:"cloneSource,omitempty"`
+	ShardingOptions              *ShardingOptions              `protobuf:"bytes,14,opt,name=shardingOptions" json:"shardingOptions,omitempty"`
+	TimestampPrecisionNanos      int64                         `protobuf:"varint,15,opt,name=timestampPrecisionNanos,proto3" json:"timestampPrecisionNanos,omitempty"`
+	ContinuousQueries            []*ContinuousQuery            `protobuf:"bytes,16,rep,name=continuousQueries" json:"continuousQueries,omitempty"`
+	RetentionTransition          *RetentionTransition          `protobuf:"bytes,17,opt,name=retentionTransition" json:"retentionTransition,omitempty"`
 }
 
 func (m *NamespaceOptions) Reset()                    { *m = NamespaceOptions{} }
 func (m *NamespaceOptions) String() string            { return proto.CompactTextString(m) }
 func (*NamespaceOptions) ProtoMessage()               {}
-func (*NamespaceOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{2} }
+func (*NamespaceOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{5} }
 
 func (m *NamespaceOptions) GetBootstrapEnabled() bool {
 	if m != nil {
@@ -204,6 +393,69 @@ func (m *NamespaceOptions) GetIndexOptions() *IndexOptions {
 	return nil
 }
 
+func (m *NamespaceOptions) GetQuotaOptions() *QuotaOptions {
+	if m != nil {
+		return m.QuotaOptions
+	}
+	return nil
+}
+
+func (m *NamespaceOptions) GetCompressionDictionaryOptions() *CompressionDictionaryOptions {
+	if m != nil {
+		return m.CompressionDictionaryOptions
+	}
+	return nil
+}
+
+func (m *NamespaceOptions) GetInMemoryOnly() bool {
+	if m != nil {
+		return m.InMemoryOnly
+	}
+	return false
+}
+
+func (m *NamespaceOptions) GetEncryptionEnabled() bool {
+	if m != nil {
+		return m.EncryptionEnabled
+	}
+	return false
+}
+
+func (m *NamespaceOptions) GetCloneSource() string {
+	if m != nil {
+		return m.CloneSource
+	}
+	return ""
+}
+
+func (m *NamespaceOptions) GetShardingOptions() *ShardingOptions {
+	if m != nil {
+		return m.ShardingOptions
+	}
+	return nil
+}
+
+func (m *NamespaceOptions) GetTimestampPrecisionNanos() int64 {
+	if m != nil {
+		return m.TimestampPrecisionNanos
+	}
+	return 0
+}
+
+func (m *NamespaceOptions) GetContinuousQueries() []*ContinuousQuery {
+	if m != nil {
+		return m.ContinuousQueries
+	}
+	return nil
+}
+
+func (m *NamespaceOptions) GetRetentionTransition() *RetentionTransition {
+	if m != nil {
+		return m.RetentionTransition
+	}
+	return nil
+}
+
 type Registry struct {
 	Namespaces map[string]*NamespaceOptions `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
 }
@@ -211,7 +463,7 @@ type Registry struct {
 func (m *Registry) Reset()                    { *m = Registry{} }
 func (m *Registry) String() string            { return proto.CompactTextString(m) }
 func (*Registry) ProtoMessage()               {}
-func (*Registry) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{3} }
+func (*Registry) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{6} }
 
 func (m *Registry) GetNamespaces() map[string]*NamespaceOptions {
 	if m != nil {
@@ -220,11 +472,83 @@ func (m *Registry) GetNamespaces() map[string]*NamespaceOptions {
 	return nil
 }
 
+type ContinuousQuery struct {
+	TargetNamespace string                     `protobuf:"bytes,1,opt,name=targetNamespace,proto3" json:"targetNamespace,omitempty"`
+	ResolutionNanos int64                      `protobuf:"varint,2,opt,name=resolutionNanos,proto3" json:"resolutionNanos,omitempty"`
+	Aggregation     ContinuousQueryAggregation `protobuf:"varint,3,opt,name=aggregation,proto3,enum=namespace.ContinuousQueryAggregation" json:"aggregation,omitempty"`
+}
+
+func (m *ContinuousQuery) Reset()                    { *m = ContinuousQuery{} }
+func (m *ContinuousQuery) String() string            { return proto.CompactTextString(m) }
+func (*ContinuousQuery) ProtoMessage()               {}
+func (*ContinuousQuery) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{7} }
+
+func (m *ContinuousQuery) GetTargetNamespace() string {
+	if m != nil {
+		return m.TargetNamespace
+	}
+	return ""
+}
+
+func (m *ContinuousQuery) GetResolutionNanos() int64 {
+	if m != nil {
+		return m.ResolutionNanos
+	}
+	return 0
+}
+
+func (m *ContinuousQuery) GetAggregation() ContinuousQueryAggregation {
+	if m != nil {
+		return m.Aggregation
+	}
+	return ContinuousQueryAggregation_MAX
+}
+
+type RetentionTransition struct {
+	TargetNamespace string                     `protobuf:"bytes,1,opt,name=targetNamespace,proto3" json:"targetNamespace,omitempty"`
+	ResolutionNanos int64                      `protobuf:"varint,2,opt,name=resolutionNanos,proto3" json:"resolutionNanos,omitempty"`
+	Aggregation     ContinuousQueryAggregation `protobuf:"varint,3,opt,name=aggregation,proto3,enum=namespace.ContinuousQueryAggregation" json:"aggregation,omitempty"`
+}
+
+func (m *RetentionTransition) Reset()                    { *m = RetentionTransition{} }
+func (m *RetentionTransition) String() string            { return proto.CompactTextString(m) }
+func (*RetentionTransition) ProtoMessage()               {}
+func (*RetentionTransition) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{8} }
+
+func (m *RetentionTransition) GetTargetNamespace() string {
+	if m != nil {
+		return m.TargetNamespace
+	}
+	return ""
+}
+
+func (m *RetentionTransition) GetResolutionNanos() int64 {
+	if m != nil {
+		return m.ResolutionNanos
+	}
+	return 0
+}
+
+func (m *RetentionTransition) GetAggregation() ContinuousQueryAggregation {
+	if m != nil {
+		return m.Aggregation
+	}
+	return ContinuousQueryAggregation_MAX
+}
+
 func init() {
 	proto.RegisterType((*RetentionOptions)(nil), "namespace.RetentionOptions")
 	proto.RegisterType((*IndexOptions)(nil), "namespace.IndexOptions")
+	proto.RegisterType((*QuotaOptions)(nil), "namespace.QuotaOptions")
+	proto.RegisterType((*CompressionDictionaryOptions)(nil), "namespace.CompressionDictionaryOptions")
+	proto.RegisterType((*ShardingOptions)(nil), "namespace.ShardingOptions")
 	proto.RegisterType((*NamespaceOptions)(nil), "namespace.NamespaceOptions")
 	proto.RegisterType((*Registry)(nil), "namespace.Registry")
+	proto.RegisterType((*ContinuousQuery)(nil), "namespace.ContinuousQuery")
+	proto.RegisterType((*RetentionTransition)(nil), "namespace.RetentionTransition")
+	proto.RegisterEnum("namespace.QuotaExceededAction", QuotaExceededAction_name, QuotaExceededAction_value)
+	proto.RegisterEnum("namespace.HashStrategy", HashStrategy_name, HashStrategy_value)
+	proto.RegisterEnum("namespace.ContinuousQueryAggregation", ContinuousQueryAggregation_name, ContinuousQueryAggregation_value)
 }
 func (m *RetentionOptions) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
@@ -309,6 +633,125 @@ func (m *IndexOptions) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockSizeNanos))
 	}
+	if len(m.NumericTags) > 0 {
+		for _, s := range m.NumericTags {
+			dAtA[i] = 0x1a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	return i, nil
+}
+
+func (m *QuotaOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuotaOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.MaxBytes != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.MaxBytes))
+	}
+	if m.ExceededAction != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ExceededAction))
+	}
+	return i, nil
+}
+
+func (m *CompressionDictionaryOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CompressionDictionaryOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.SampleSize != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.SampleSize))
+	}
+	if m.MaxBytes != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.MaxBytes))
+	}
+	return i, nil
+}
+
+func (m *ShardingOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardingOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.HashStrategy != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.HashStrategy))
+	}
+	if len(m.TagNames) > 0 {
+		for _, s := range m.TagNames {
+			dAtA[i] = 0x12
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
 	return i, nil
 }
 
@@ -407,22 +850,109 @@ func (m *NamespaceOptions) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n2
 	}
-	return i, nil
-}
-
-func (m *Registry) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+	if m.QuotaOptions != nil {
+		dAtA[i] = 0x4a
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.QuotaOptions.Size()))
+		n3, err := m.QuotaOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n3
 	}
-	return dAtA[:n], nil
-}
-
-func (m *Registry) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
+	if m.CompressionDictionaryOptions != nil {
+		dAtA[i] = 0x52
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.CompressionDictionaryOptions.Size()))
+		n4, err := m.CompressionDictionaryOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
+	}
+	if m.InMemoryOnly {
+		dAtA[i] = 0x58
+		i++
+		if m.InMemoryOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.EncryptionEnabled {
+		dAtA[i] = 0x60
+		i++
+		if m.EncryptionEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.CloneSource) > 0 {
+		dAtA[i] = 0x6a
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.CloneSource)))
+		i += copy(dAtA[i:], m.CloneSource)
+	}
+	if m.ShardingOptions != nil {
+		dAtA[i] = 0x72
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ShardingOptions.Size()))
+		n5, err := m.ShardingOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n5
+	}
+	if m.TimestampPrecisionNanos != 0 {
+		dAtA[i] = 0x78
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.TimestampPrecisionNanos))
+	}
+	if len(m.ContinuousQueries) > 0 {
+		for _, msg := range m.ContinuousQueries {
+			dAtA[i] = 0x82
+			i++
+			dAtA[i] = 0x1
+			i++
+			i = encodeVarintNamespace(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.RetentionTransition != nil {
+		dAtA[i] = 0x8a
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.RetentionTransition.Size()))
+		n, err := m.RetentionTransition.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n
+	}
+	return i, nil
+}
+
+func (m *Registry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Registry) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
 	if len(m.Namespaces) > 0 {
@@ -456,6 +986,74 @@ func (m *Registry) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *ContinuousQuery) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ContinuousQuery) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.TargetNamespace) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.TargetNamespace)))
+		i += copy(dAtA[i:], m.TargetNamespace)
+	}
+	if m.ResolutionNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ResolutionNanos))
+	}
+	if m.Aggregation != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.Aggregation))
+	}
+	return i, nil
+}
+
+func (m *RetentionTransition) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RetentionTransition) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.TargetNamespace) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.TargetNamespace)))
+		i += copy(dAtA[i:], m.TargetNamespace)
+	}
+	if m.ResolutionNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ResolutionNanos))
+	}
+	if m.Aggregation != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.Aggregation))
+	}
+	return i, nil
+}
+
 func encodeVarintNamespace(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -498,6 +1096,54 @@ func (m *IndexOptions) Size() (n int) {
 	if m.BlockSizeNanos != 0 {
 		n += 1 + sovNamespace(uint64(m.BlockSizeNanos))
 	}
+	if len(m.NumericTags) > 0 {
+		for _, s := range m.NumericTags {
+			l = len(s)
+			n += 1 + l + sovNamespace(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *QuotaOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.MaxBytes != 0 {
+		n += 1 + sovNamespace(uint64(m.MaxBytes))
+	}
+	if m.ExceededAction != 0 {
+		n += 1 + sovNamespace(uint64(m.ExceededAction))
+	}
+	return n
+}
+
+func (m *CompressionDictionaryOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	if m.SampleSize != 0 {
+		n += 1 + sovNamespace(uint64(m.SampleSize))
+	}
+	if m.MaxBytes != 0 {
+		n += 1 + sovNamespace(uint64(m.MaxBytes))
+	}
+	return n
+}
+
+func (m *ShardingOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.HashStrategy != 0 {
+		n += 1 + sovNamespace(uint64(m.HashStrategy))
+	}
+	if len(m.TagNames) > 0 {
+		for _, s := range m.TagNames {
+			l = len(s)
+			n += 1 + l + sovNamespace(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -530,6 +1176,41 @@ func (m *NamespaceOptions) Size() (n int) {
 		l = m.IndexOptions.Size()
 		n += 1 + l + sovNamespace(uint64(l))
 	}
+	if m.QuotaOptions != nil {
+		l = m.QuotaOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.CompressionDictionaryOptions != nil {
+		l = m.CompressionDictionaryOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.InMemoryOnly {
+		n += 2
+	}
+	if m.EncryptionEnabled {
+		n += 2
+	}
+	l = len(m.CloneSource)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.ShardingOptions != nil {
+		l = m.ShardingOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.TimestampPrecisionNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.TimestampPrecisionNanos))
+	}
+	if len(m.ContinuousQueries) > 0 {
+		for _, e := range m.ContinuousQueries {
+			l = e.Size()
+			n += 2 + l + sovNamespace(uint64(l))
+		}
+	}
+	if m.RetentionTransition != nil {
+		l = m.RetentionTransition.Size()
+		n += 2 + l + sovNamespace(uint64(l))
+	}
 	return n
 }
 
@@ -552,6 +1233,38 @@ func (m *Registry) Size() (n int) {
 	return n
 }
 
+func (m *ContinuousQuery) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.TargetNamespace)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.ResolutionNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.ResolutionNanos))
+	}
+	if m.Aggregation != 0 {
+		n += 1 + sovNamespace(uint64(m.Aggregation))
+	}
+	return n
+}
+
+func (m *RetentionTransition) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.TargetNamespace)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.ResolutionNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.ResolutionNanos))
+	}
+	if m.Aggregation != 0 {
+		n += 1 + sovNamespace(uint64(m.Aggregation))
+	}
+	return n
+}
+
 func sovNamespace(x uint64) (n int) {
 	for {
 		n++
@@ -798,6 +1511,35 @@ func (m *IndexOptions) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumericTags", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.NumericTags = append(m.NumericTags, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNamespace(dAtA[iNdEx:])
@@ -819,7 +1561,7 @@ func (m *IndexOptions) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
+func (m *QuotaOptions) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -842,17 +1584,17 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: NamespaceOptions: wiretype end group for non-group")
+			return fmt.Errorf("proto: QuotaOptions: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: NamespaceOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: QuotaOptions: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BootstrapEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxBytes", wireType)
 			}
-			var v int
+			m.MaxBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -862,17 +1604,16 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.MaxBytes |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.BootstrapEnabled = bool(v != 0)
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FlushEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ExceededAction", wireType)
 			}
-			var v int
+			m.ExceededAction = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -882,15 +1623,64 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.ExceededAction |= (QuotaExceededAction(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.FlushEnabled = bool(v != 0)
-		case 3:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CompressionDictionaryOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CompressionDictionaryOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CompressionDictionaryOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WritesToCommitLog", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -907,12 +1697,12 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.WritesToCommitLog = bool(v != 0)
-		case 4:
+			m.Enabled = bool(v != 0)
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CleanupEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SampleSize", wireType)
 			}
-			var v int
+			m.SampleSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -922,7 +1712,253 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.SampleSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxBytes", wireType)
+			}
+			m.MaxBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxBytes |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardingOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardingOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardingOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HashStrategy", wireType)
+			}
+			m.HashStrategy = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HashStrategy |= (HashStrategy(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TagNames", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TagNames = append(m.TagNames, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NamespaceOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NamespaceOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BootstrapEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.BootstrapEnabled = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlushEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.FlushEnabled = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WritesToCommitLog", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.WritesToCommitLog = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CleanupEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -932,7 +1968,219 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field RepairEnabled", wireType)
 			}
-			var v int
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.RepairEnabled = bool(v != 0)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetentionOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RetentionOptions == nil {
+				m.RetentionOptions = &RetentionOptions{}
+			}
+			if err := m.RetentionOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SnapshotEnabled = bool(v != 0)
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IndexOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.IndexOptions == nil {
+				m.IndexOptions = &IndexOptions{}
+			}
+			if err := m.IndexOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QuotaOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.QuotaOptions == nil {
+				m.QuotaOptions = &QuotaOptions{}
+			}
+			if err := m.QuotaOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompressionDictionaryOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.CompressionDictionaryOptions == nil {
+				m.CompressionDictionaryOptions = &CompressionDictionaryOptions{}
+			}
+			if err := m.CompressionDictionaryOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InMemoryOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.InMemoryOnly = bool(v != 0)
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EncryptionEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.EncryptionEnabled = bool(v != 0)
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CloneSource", wireType)
+			}
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -942,15 +2190,24 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.RepairEnabled = bool(v != 0)
-		case 6:
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CloneSource = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 14:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RetentionOptions", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardingOptions", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -974,18 +2231,18 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.RetentionOptions == nil {
-				m.RetentionOptions = &RetentionOptions{}
+			if m.ShardingOptions == nil {
+				m.ShardingOptions = &ShardingOptions{}
 			}
-			if err := m.RetentionOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.ShardingOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 7:
+		case 15:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TimestampPrecisionNanos", wireType)
 			}
-			var v int
+			m.TimestampPrecisionNanos = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -995,15 +2252,14 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.TimestampPrecisionNanos |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.SnapshotEnabled = bool(v != 0)
-		case 8:
+		case 16:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IndexOptions", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ContinuousQueries", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1027,10 +2283,41 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.IndexOptions == nil {
-				m.IndexOptions = &IndexOptions{}
+			m.ContinuousQueries = append(m.ContinuousQueries, &ContinuousQuery{})
+			if err := m.ContinuousQueries[len(m.ContinuousQueries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			if err := m.IndexOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetentionTransition", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RetentionTransition == nil {
+				m.RetentionTransition = &RetentionTransition{}
+			}
+			if err := m.RetentionTransition.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -1228,6 +2515,240 @@ func (m *Registry) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ContinuousQuery) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ContinuousQuery: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ContinuousQuery: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TargetNamespace", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TargetNamespace = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResolutionNanos", wireType)
+			}
+			m.ResolutionNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResolutionNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Aggregation", wireType)
+			}
+			m.Aggregation = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Aggregation |= (ContinuousQueryAggregation(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RetentionTransition) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RetentionTransition: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RetentionTransition: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TargetNamespace", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TargetNamespace = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResolutionNanos", wireType)
+			}
+			m.ResolutionNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResolutionNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Aggregation", wireType)
+			}
+			m.Aggregation = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Aggregation |= (ContinuousQueryAggregation(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipNamespace(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -1338,38 +2859,70 @@ func init() {
 }
 
 var fileDescriptorNamespace = []byte{
-	// 513 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x94, 0xdf, 0x6e, 0xd3, 0x30,
-	0x14, 0xc6, 0x49, 0xbb, 0x3f, 0xdd, 0x59, 0x61, 0xc1, 0x42, 0xa2, 0x02, 0xa9, 0x9a, 0x0a, 0x42,
-	0x15, 0x42, 0x8d, 0x68, 0x6f, 0x10, 0x5c, 0x8d, 0x51, 0x26, 0x24, 0x54, 0x2a, 0xc3, 0xd5, 0xee,
-	0x9c, 0xe4, 0xb4, 0xb5, 0xd6, 0xd8, 0x91, 0xed, 0xc0, 0xca, 0x43, 0x20, 0xde, 0x83, 0x17, 0xe1,
-	0x82, 0x0b, 0x1e, 0x01, 0x95, 0x17, 0x41, 0x71, 0x48, 0x97, 0xb8, 0xbb, 0xd8, 0x4d, 0xe5, 0x7e,
-	0xe7, 0x67, 0x1f, 0xe7, 0x7c, 0x5f, 0x02, 0x67, 0x73, 0x6e, 0x16, 0x59, 0x38, 0x88, 0x64, 0x12,
-	0x24, 0xa3, 0x38, 0x0c, 0x92, 0x51, 0xa0, 0x55, 0x14, 0xc4, 0xa1, 0x90, 0x31, 0x06, 0x73, 0x14,
-	0xa8, 0x98, 0xc1, 0x38, 0x48, 0x95, 0x34, 0x32, 0x10, 0x2c, 0x41, 0x9d, 0xb2, 0x08, 0xaf, 0x56,
-	0x03, 0x5b, 0x21, 0x07, 0x1b, 0xa1, 0xf7, 0xab, 0x01, 0x3e, 0x45, 0x83, 0xc2, 0x70, 0x29, 0x3e,
-	0xa4, 0xf9, 0xaf, 0x26, 0x43, 0xb8, 0xa7, 0x4a, 0x6d, 0x8a, 0x8a, 0xcb, 0x78, 0xc2, 0x84, 0xd4,
-	0x1d, 0xef, 0xd8, 0xeb, 0x37, 0xe9, 0xb5, 0x35, 0xf2, 0x04, 0xee, 0x84, 0x4b, 0x19, 0x5d, 0x7c,
-	0xe4, 0x5f, 0xb1, 0xa0, 0x1b, 0x96, 0x76, 0x54, 0xf2, 0x0c, 0xee, 0x86, 0xd9, 0x6c, 0x86, 0xea,
-	0x6d, 0x66, 0x32, 0xf5, 0x1f, 0x6d, 0x5a, 0x74, 0xbb, 0x40, 0xfa, 0x70, 0x54, 0x88, 0x53, 0xa6,
-	0x4d, 0xc1, 0xee, 0x58, 0xd6, 0x95, 0x2d, 0x99, 0x77, 0x7a, 0xc3, 0x0c, 0x1b, 0x5f, 0xa6, 0x5c,
-	0xad, 0x3a, 0xbb, 0xc7, 0x5e, 0xbf, 0x45, 0x5d, 0x99, 0x9c, 0x43, 0xdf, 0x91, 0x4e, 0x66, 0x06,
-	0xd5, 0x44, 0x9a, 0x93, 0x28, 0x42, 0xad, 0xab, 0x4f, 0xbc, 0x67, 0x9b, 0xdd, 0x98, 0xef, 0x4d,
-	0xa1, 0xfd, 0x4e, 0xc4, 0x78, 0x59, 0x4e, 0xb2, 0x03, 0xfb, 0x28, 0x58, 0xb8, 0xc4, 0xd8, 0x0e,
-	0xaf, 0x45, 0xcb, 0xbf, 0x37, 0x9d, 0x57, 0xef, 0x5b, 0x13, 0xfc, 0x49, 0x69, 0x57, 0x79, 0xec,
-	0x53, 0xf0, 0x43, 0x29, 0x8d, 0x36, 0x8a, 0xa5, 0xe3, 0xda, 0xf9, 0x5b, 0x3a, 0xe9, 0x41, 0x7b,
-	0xb6, 0xcc, 0xf4, 0xa2, 0xe4, 0x1a, 0x96, 0xab, 0x69, 0xb9, 0x29, 0x5f, 0x14, 0x37, 0xa8, 0x3f,
-	0xc9, 0x53, 0x99, 0x24, 0xdc, 0xbc, 0x97, 0x73, 0x6b, 0x4a, 0x8b, 0x6e, 0x17, 0xf2, 0xab, 0x47,
-	0x4b, 0x64, 0x22, 0xdb, 0xf4, 0xde, 0xb1, 0xa8, 0xa3, 0x92, 0xc7, 0x70, 0x5b, 0x61, 0xca, 0xb8,
-	0x2a, 0xb1, 0xc2, 0x90, 0xba, 0x48, 0xce, 0xc0, 0x57, 0x4e, 0x00, 0xed, 0xd8, 0x0f, 0x87, 0x0f,
-	0x07, 0x57, 0xc1, 0x75, 0x33, 0x4a, 0xb7, 0x36, 0xe5, 0x09, 0xd0, 0x82, 0xa5, 0x7a, 0x21, 0x4d,
-	0xd9, 0x70, 0xbf, 0x48, 0x80, 0x23, 0x93, 0x57, 0xd0, 0xe6, 0x15, 0x97, 0x3a, 0x2d, 0xdb, 0xee,
-	0x7e, 0xa5, 0x5d, 0xd5, 0x44, 0x5a, 0x83, 0x7b, 0x3f, 0x3c, 0x68, 0x51, 0x9c, 0x73, 0x6d, 0xd4,
-	0x8a, 0x9c, 0x02, 0x6c, 0x36, 0xe5, 0xef, 0x47, 0xb3, 0x7f, 0x38, 0x7c, 0x54, 0xbb, 0x76, 0x01,
-	0x0e, 0x36, 0x16, 0xea, 0xb1, 0x30, 0x6a, 0x45, 0x2b, 0xdb, 0x1e, 0x9c, 0xc3, 0x91, 0x53, 0x26,
-	0x3e, 0x34, 0x2f, 0x70, 0x65, 0x3d, 0x3d, 0xa0, 0xf9, 0x92, 0x3c, 0x87, 0xdd, 0xcf, 0x6c, 0x99,
-	0xa1, 0xf5, 0xaf, 0x3e, 0x1b, 0x37, 0x1e, 0xb4, 0x20, 0x5f, 0x36, 0x5e, 0x78, 0xaf, 0xfd, 0x9f,
-	0xeb, 0xae, 0xf7, 0x7b, 0xdd, 0xf5, 0xfe, 0xac, 0xbb, 0xde, 0xf7, 0xbf, 0xdd, 0x5b, 0xe1, 0x9e,
-	0xfd, 0x06, 0x8c, 0xfe, 0x05, 0x00, 0x00, 0xff, 0xff, 0x30, 0x42, 0xc6, 0x09, 0x4e, 0x04, 0x00,
-	0x00,
+	// 1033 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x56, 0xdd, 0x4e, 0xe3, 0x46,
+	0x14, 0xc6, 0x09, 0x2c, 0xe1, 0x00, 0x89, 0x19, 0x5a, 0xad, 0x45, 0x57, 0x11, 0x4a, 0xff, 0x10,
+	0xaa, 0x88, 0x0a, 0xaa, 0xb4, 0x6a, 0xaf, 0x42, 0xf0, 0xb2, 0xac, 0x36, 0x81, 0x1d, 0x27, 0xa5,
+	0xda, 0x1b, 0x34, 0x71, 0x06, 0xc7, 0x25, 0x9e, 0x49, 0x67, 0xc6, 0x2d, 0xe9, 0x53, 0xf4, 0x3d,
+	0x2a, 0xf5, 0xa2, 0x4f, 0xb1, 0x17, 0xbd, 0xe8, 0x23, 0x54, 0xf4, 0x45, 0xaa, 0x19, 0xe3, 0x60,
+	0x3b, 0x40, 0xf7, 0x72, 0x6f, 0xa2, 0x99, 0xef, 0x7c, 0xe7, 0x9c, 0x39, 0xbf, 0x0e, 0x1c, 0x07,
+	0xa1, 0x1a, 0xc5, 0x83, 0x3d, 0x9f, 0x47, 0xcd, 0xe8, 0x60, 0x38, 0x68, 0x46, 0x07, 0x4d, 0x29,
+	0xfc, 0xe6, 0x70, 0xc0, 0xf8, 0x90, 0x36, 0x03, 0xca, 0xa8, 0x20, 0x8a, 0x0e, 0x9b, 0x13, 0xc1,
+	0x15, 0x6f, 0x32, 0x12, 0x51, 0x39, 0x21, 0x3e, 0xbd, 0x3b, 0xed, 0x19, 0x09, 0x5a, 0x99, 0x01,
+	0x8d, 0xbf, 0x4a, 0x60, 0x63, 0xaa, 0x28, 0x53, 0x21, 0x67, 0xa7, 0x13, 0xfd, 0x2b, 0xd1, 0x3e,
+	0x7c, 0x24, 0x52, 0xec, 0x8c, 0x8a, 0x90, 0x0f, 0xbb, 0x84, 0x71, 0xe9, 0x58, 0xdb, 0xd6, 0x4e,
+	0x19, 0xdf, 0x2b, 0x43, 0x5f, 0x40, 0x75, 0x30, 0xe6, 0xfe, 0x95, 0x17, 0xfe, 0x4a, 0x13, 0x76,
+	0xc9, 0xb0, 0x0b, 0x28, 0xfa, 0x0a, 0x36, 0x06, 0xf1, 0xe5, 0x25, 0x15, 0x2f, 0x62, 0x15, 0x8b,
+	0x5b, 0x6a, 0xd9, 0x50, 0xe7, 0x05, 0x68, 0x07, 0x6a, 0x09, 0x78, 0x46, 0xa4, 0x4a, 0xb8, 0x8b,
+	0x86, 0x5b, 0x84, 0x0d, 0x53, 0x7b, 0x3a, 0x22, 0x8a, 0xb8, 0xd7, 0x93, 0x50, 0x4c, 0x9d, 0xa5,
+	0x6d, 0x6b, 0xa7, 0x82, 0x8b, 0x30, 0x7a, 0x0b, 0x3b, 0x05, 0xa8, 0x75, 0xa9, 0xa8, 0xe8, 0x72,
+	0xd5, 0xf2, 0x7d, 0x2a, 0x65, 0x36, 0xe2, 0x27, 0xc6, 0xd9, 0x7b, 0xf3, 0x1b, 0x02, 0xd6, 0x4e,
+	0xd8, 0x90, 0x5e, 0xa7, 0x99, 0x74, 0x60, 0x99, 0x32, 0x32, 0x18, 0xd3, 0xa1, 0x49, 0x5e, 0x05,
+	0xa7, 0xd7, 0xf7, 0xce, 0xd7, 0x36, 0xac, 0xb2, 0x38, 0xa2, 0x22, 0xf4, 0x7b, 0x24, 0xd0, 0x99,
+	0x2a, 0xef, 0xac, 0xe0, 0x2c, 0xa4, 0x7d, 0xbe, 0x89, 0xb9, 0x22, 0xa9, 0xcf, 0x2d, 0xa8, 0x44,
+	0xe4, 0xfa, 0x70, 0xaa, 0x68, 0x5a, 0xb1, 0xd9, 0x1d, 0xbd, 0x80, 0x2a, 0xbd, 0xf6, 0x29, 0x1d,
+	0xd2, 0x61, 0xcb, 0xd7, 0x74, 0xe3, 0xb5, 0xba, 0x5f, 0xdf, 0xbb, 0xeb, 0x11, 0x63, 0xcc, 0xcd,
+	0xb1, 0x70, 0x41, 0xab, 0xa1, 0xe0, 0x59, 0x9b, 0x47, 0x13, 0x41, 0xa5, 0x0c, 0x39, 0x3b, 0x0a,
+	0x0d, 0x4a, 0xc4, 0xf4, 0xff, 0xe3, 0xae, 0x03, 0x48, 0x12, 0x4d, 0xc6, 0x54, 0x87, 0x78, 0x1b,
+	0x73, 0x06, 0xc9, 0xbd, 0xbe, 0x9c, 0x7f, 0x7d, 0xe3, 0x47, 0xa8, 0x79, 0x23, 0x22, 0x86, 0x21,
+	0x0b, 0x52, 0x47, 0xdf, 0xc1, 0xda, 0x88, 0xc8, 0x91, 0xa7, 0x74, 0xe7, 0x07, 0x53, 0xe3, 0xad,
+	0xba, 0xff, 0x34, 0x13, 0xce, 0xcb, 0x8c, 0x18, 0xe7, 0xc8, 0xda, 0x97, 0x22, 0x41, 0x57, 0x53,
+	0x9d, 0x92, 0x49, 0xec, 0xec, 0xde, 0x78, 0xb7, 0x0c, 0x76, 0x37, 0x35, 0x92, 0x7a, 0xdb, 0x05,
+	0x7b, 0xc0, 0xb9, 0x92, 0x4a, 0x90, 0x89, 0x9b, 0x8b, 0x6f, 0x0e, 0x47, 0x0d, 0x58, 0xbb, 0x1c,
+	0xc7, 0x72, 0x94, 0xf2, 0x4a, 0x86, 0x97, 0xc3, 0xf4, 0x30, 0xfc, 0x22, 0x42, 0x45, 0x65, 0x8f,
+	0xb7, 0x79, 0x14, 0x85, 0xea, 0x35, 0x0f, 0x4c, 0xd4, 0x15, 0x3c, 0x2f, 0xd0, 0x2d, 0xe3, 0x8f,
+	0x29, 0x61, 0xf1, 0xcc, 0xf7, 0xa2, 0xa1, 0x16, 0x50, 0xf4, 0x19, 0xac, 0x0b, 0x3a, 0x21, 0xa1,
+	0x48, 0x69, 0xc9, 0x20, 0xe4, 0x41, 0x74, 0x0c, 0xb6, 0x28, 0x0c, 0xbe, 0x69, 0xf7, 0xd5, 0xfd,
+	0x4f, 0x32, 0xd9, 0x2b, 0xee, 0x06, 0x3c, 0xa7, 0xa4, 0x27, 0x4f, 0x32, 0x32, 0x91, 0x23, 0xae,
+	0x52, 0x87, 0xcb, 0xc9, 0xe4, 0x15, 0x60, 0x5d, 0xac, 0x30, 0x33, 0x1d, 0x4e, 0xc5, 0xb8, 0xcb,
+	0x16, 0x2b, 0x3b, 0x3c, 0x38, 0x47, 0xd6, 0xca, 0x3f, 0x65, 0xda, 0xdc, 0x59, 0x99, 0x53, 0xce,
+	0x4e, 0x01, 0xce, 0x91, 0xd1, 0x15, 0x3c, 0xf3, 0x1f, 0xe9, 0x57, 0x07, 0x8c, 0xb1, 0x2f, 0x33,
+	0xc6, 0x1e, 0x6b, 0x6f, 0xfc, 0xa8, 0x31, 0x5d, 0xf9, 0x90, 0x75, 0x68, 0xc4, 0xc5, 0xf4, 0x94,
+	0x8d, 0xa7, 0xce, 0x6a, 0x52, 0xf9, 0x2c, 0xa6, 0x2b, 0x4f, 0x99, 0x2f, 0xa6, 0x46, 0x25, 0x4d,
+	0xdb, 0x5a, 0x52, 0xf9, 0x39, 0x81, 0x5e, 0x02, 0xfe, 0x98, 0x33, 0xea, 0xf1, 0x58, 0xf8, 0xd4,
+	0x59, 0xdf, 0xb6, 0xf4, 0x12, 0xc8, 0x40, 0xe8, 0x08, 0x6a, 0x32, 0x3f, 0x1a, 0x4e, 0xd5, 0xc4,
+	0xb4, 0x95, 0x89, 0xa9, 0x30, 0x3c, 0xb8, 0xa8, 0x82, 0x9e, 0xc3, 0x53, 0x15, 0x46, 0x54, 0x2a,
+	0x12, 0x4d, 0xce, 0x04, 0xf5, 0x43, 0x1d, 0x60, 0xb2, 0x9d, 0x6a, 0x66, 0x16, 0x1f, 0x12, 0xa3,
+	0x97, 0xb0, 0xe1, 0x73, 0xa6, 0x42, 0x16, 0xf3, 0x58, 0xbe, 0x89, 0xa9, 0x08, 0xa9, 0x74, 0xec,
+	0xed, 0x72, 0xe1, 0x05, 0xed, 0x1c, 0x67, 0x8a, 0xe7, 0x95, 0xd0, 0x19, 0x6c, 0xce, 0x5a, 0xac,
+	0x27, 0x08, 0x93, 0xa1, 0x3e, 0x39, 0x1b, 0x26, 0x9a, 0xfa, 0x7d, 0xad, 0x79, 0xc7, 0xc2, 0xf7,
+	0xa9, 0x36, 0x7e, 0xb7, 0xa0, 0x82, 0x69, 0x10, 0x4a, 0x25, 0xa6, 0xa8, 0x0d, 0x30, 0x33, 0xa1,
+	0xf7, 0xa3, 0x7e, 0xe1, 0xa7, 0x39, 0xab, 0x09, 0x71, 0x6f, 0x36, 0xfc, 0xd2, 0x65, 0x4a, 0x4c,
+	0x71, 0x46, 0x6d, 0xeb, 0x2d, 0xd4, 0x0a, 0x62, 0x64, 0x43, 0xf9, 0x8a, 0x26, 0xfb, 0x67, 0x05,
+	0xeb, 0x23, 0xfa, 0x1a, 0x96, 0x7e, 0x26, 0xe3, 0x38, 0x59, 0x72, 0xf9, 0xa9, 0x2a, 0x2e, 0x16,
+	0x9c, 0x30, 0xbf, 0x2d, 0x3d, 0xb7, 0x1a, 0x7f, 0x58, 0x50, 0x2b, 0xa4, 0x49, 0x8f, 0x98, 0x22,
+	0x22, 0xa0, 0x6a, 0xa6, 0x78, 0xeb, 0xa8, 0x08, 0x6b, 0xa6, 0xa0, 0x92, 0x8f, 0x63, 0x35, 0xab,
+	0x5c, 0xb2, 0x63, 0x8b, 0x30, 0x3a, 0x86, 0x55, 0x12, 0x04, 0x82, 0x06, 0xc4, 0xe4, 0xb7, 0x6c,
+	0x16, 0xe7, 0xe7, 0x0f, 0xd7, 0xaa, 0x75, 0x47, 0xc6, 0x59, 0xcd, 0xc6, 0x9f, 0x16, 0x6c, 0xde,
+	0x53, 0x8b, 0x0f, 0xfa, 0xd1, 0xbb, 0x27, 0xb0, 0x79, 0xcf, 0x77, 0x0e, 0x55, 0x60, 0xf1, 0xbc,
+	0x85, 0xbb, 0xf6, 0x02, 0xfa, 0x18, 0x36, 0xb0, 0xfb, 0xca, 0x6d, 0xf7, 0x2e, 0xba, 0xee, 0xf9,
+	0x85, 0xe7, 0xe2, 0x13, 0xd7, 0xb3, 0x2d, 0xb4, 0x01, 0xeb, 0xb7, 0xf0, 0x39, 0x3e, 0xe9, 0xb9,
+	0x9e, 0x5d, 0xda, 0xfd, 0x06, 0xd6, 0xb2, 0xdf, 0x18, 0xb4, 0x0a, 0xcb, 0x9d, 0x3e, 0xee, 0xf4,
+	0xf1, 0x81, 0xbd, 0xa0, 0x0d, 0xbe, 0xea, 0x77, 0xce, 0x6c, 0x0b, 0x55, 0x01, 0x7a, 0xad, 0xe3,
+	0x0b, 0xaf, 0x7f, 0xe8, 0xb9, 0x3d, 0xbb, 0xb4, 0xfb, 0x3d, 0x6c, 0x3d, 0xfc, 0x58, 0xb4, 0x0c,
+	0xe5, 0x4e, 0xeb, 0x07, 0x7b, 0xc1, 0x1c, 0x4e, 0xba, 0xb6, 0xa5, 0x0f, 0x5e, 0xbf, 0x63, 0x97,
+	0xd0, 0x0a, 0x2c, 0xb5, 0x4f, 0xfb, 0xdd, 0x9e, 0x5d, 0xd6, 0xd6, 0x3b, 0x6e, 0xab, 0x6b, 0x2f,
+	0xea, 0xd3, 0xeb, 0x96, 0xd7, 0xb3, 0x97, 0x0e, 0xed, 0x77, 0x37, 0x75, 0xeb, 0xef, 0x9b, 0xba,
+	0xf5, 0xcf, 0x4d, 0xdd, 0xfa, 0xed, 0xdf, 0xfa, 0xc2, 0xe0, 0x89, 0xf9, 0xd7, 0x77, 0xf0, 0xdf,
+	0x00, 0xb4, 0x12, 0x97, 0x0f, 0x40, 0x0a, 0x00, 0x00,
 }
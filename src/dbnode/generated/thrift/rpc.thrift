@@ -29,12 +29,17 @@ enum TimeType {
 
 enum ErrorType {
 	INTERNAL_ERROR,
-	BAD_REQUEST
+	BAD_REQUEST,
+	RESOURCE_EXHAUSTED,
+	UNAVAILABLE,
+	DATA_CORRUPTION,
+	CONSISTENCY_NOT_ACHIEVED
 }
 
 exception Error {
 	1: required ErrorType type = ErrorType.INTERNAL_ERROR
 	2: required string message
+	3: optional i64 retryAfterMillis
 }
 
 exception WriteBatchRawErrors {
@@ -60,6 +65,7 @@ service Node {
 	void writeTaggedBatchRaw(1: WriteTaggedBatchRawRequest req) throws (1: WriteBatchRawErrors err)
 	void repair() throws (1: Error err)
 	TruncateResult truncate(1: TruncateRequest req) throws (1: Error err)
+	SubscribeNewSeriesResult subscribeNewSeries(1: SubscribeNewSeriesRequest req) throws (1: Error err)
 
 	// Management endpoints
 	NodeHealthResult health() throws (1: Error err)
@@ -71,6 +77,11 @@ service Node {
 	NodeWriteNewSeriesBackoffDurationResult setWriteNewSeriesBackoffDuration(1: NodeSetWriteNewSeriesBackoffDurationRequest req) throws (1: Error err)
 	NodeWriteNewSeriesLimitPerShardPerSecondResult getWriteNewSeriesLimitPerShardPerSecond() throws (1: Error err)
 	NodeWriteNewSeriesLimitPerShardPerSecondResult setWriteNewSeriesLimitPerShardPerSecond(1: NodeSetWriteNewSeriesLimitPerShardPerSecondRequest req) throws (1: Error err)
+	NodeDebugSettingsResult getDebugSettings() throws (1: Error err)
+	NodeDebugSettingsResult setDebugSettings(1: NodeSetDebugSettingsRequest req) throws (1: Error err)
+	NodeMaintenanceResult forceFlush(1: NodeMaintenanceRequest req) throws (1: Error err)
+	NodeMaintenanceResult forceCompact(1: NodeMaintenanceRequest req) throws (1: Error err)
+	NodeMaintenanceResult prune(1: NodeMaintenanceRequest req) throws (1: Error err)
 }
 
 struct FetchRequest {
@@ -91,6 +102,7 @@ struct Datapoint {
 	2: required double value
 	3: optional binary annotation
 	4: optional TimeType timestampTimeType = TimeType.UNIX_SECONDS
+	5: optional i64 writeSequence
 }
 
 struct WriteRequest {
@@ -143,6 +155,7 @@ struct FetchTaggedRequest {
 	5: required bool fetchData
 	6: optional i64 limit
 	7: optional TimeType rangeTimeType = TimeType.UNIX_SECONDS
+	8: optional list<binary> tagNames
 }
 
 struct FetchTaggedResult {
@@ -287,6 +300,28 @@ struct TruncateResult {
 	1: required i64 numSeries
 }
 
+struct SubscribeNewSeriesRequest {
+	1: required binary nameSpace
+	2: required binary query
+	3: optional i64 subscriptionID
+	4: optional list<i32> shards
+	5: optional i64 timeoutMillis
+	6: optional i64 limit
+	7: optional bool close
+}
+
+struct SubscribeNewSeriesResult {
+	1: required i64 subscriptionID
+	2: required list<NewSeriesElement> elements
+	3: required i64 dropped
+}
+
+struct NewSeriesElement {
+	1: required binary id
+	2: required binary encodedTags
+	3: required i32 shard
+}
+
 struct NodeHealthResult {
 	1: required bool ok
 	2: required string status
@@ -331,6 +366,27 @@ struct NodeSetWriteNewSeriesLimitPerShardPerSecondRequest {
 	1: required i64 writeNewSeriesLimitPerShardPerSecond
 }
 
+struct NodeDebugSettingsResult {
+	1: required string logLevel
+	2: required list<string> debugSubsystems
+	3: required bool debugMetricsEnabled
+}
+
+struct NodeSetDebugSettingsRequest {
+	1: optional string logLevel
+	2: optional list<string> debugSubsystems
+	3: optional bool debugMetricsEnabled
+}
+
+struct NodeMaintenanceRequest {
+	1: required binary nameSpace
+	2: optional list<i32> shards
+}
+
+struct NodeMaintenanceResult {
+	1: required list<i32> shards
+}
+
 service Cluster {
 	HealthResult health() throws (1: Error err)
 	void write(1: WriteRequest req) throws (1: Error err)
@@ -0,0 +1,92 @@
+// +build integration
+
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/integration/generate"
+	"github.com/m3db/m3/src/dbnode/x/fault"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskFlushFailedFlushRetried(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // Just skip if we're doing a short run
+	}
+
+	// Fail all flushes until the fault is cleared
+	faults := fault.NewRegistry()
+	faults.Set(fault.PointPersistPrepare, fault.Fault{Err: fault.ErrInjected})
+
+	// Test setup
+	testOpts := newTestOptions(t).
+		SetTickMinimumInterval(time.Second).
+		SetFaultInjector(faults)
+	testSetup, err := newTestSetup(t, testOpts, nil)
+	require.NoError(t, err)
+	defer testSetup.close()
+
+	md := testSetup.namespaceMetadataOrFail(testNamespaces[0])
+	blockSize := md.Options().RetentionOptions().BlockSize()
+	filePathPrefix := testSetup.storageOpts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+
+	// Start the server
+	log := testSetup.storageOpts.InstrumentOptions().Logger()
+	log.Debug("disk flush failed flush test")
+	require.NoError(t, testSetup.startServer())
+	log.Debug("server is now up")
+
+	// Stop the server
+	defer func() {
+		require.NoError(t, testSetup.stopServer())
+		log.Debug("server is now down")
+	}()
+
+	// Write test data
+	now := testSetup.getNowFn()
+	seriesMaps := make(map[xtime.UnixNano]generate.SeriesBlock)
+	input := generate.BlockConfig{IDs: []string{"foo", "bar"}, NumPoints: 100, Start: now}
+	testData := generate.Block(input)
+	seriesMaps[xtime.ToUnixNano(input.Start)] = testData
+	require.NoError(t, testSetup.writeBatch(testNamespaces[0], testData))
+	log.Debug("test data is now written")
+
+	// Advance time so the block is flushable and wait for the flush to fail
+	testSetup.setNowFn(testSetup.getNowFn().Add(blockSize * 2))
+	maxWaitTime := time.Minute
+	require.True(t, waitUntil(func() bool {
+		return faults.Triggered(fault.PointPersistPrepare) > 0
+	}, maxWaitTime))
+	require.Error(t, waitUntilDataFilesFlushed(filePathPrefix, testSetup.shardSet,
+		testNamespaces[0], seriesMaps, 5*time.Second))
+
+	// Clear the fault and verify the failed flush is retried
+	faults.Clear(fault.PointPersistPrepare)
+	require.NoError(t, waitUntilDataFilesFlushed(filePathPrefix, testSetup.shardSet,
+		testNamespaces[0], seriesMaps, maxWaitTime))
+	verifyFlushedDataFiles(t, testSetup.shardSet, testSetup.storageOpts, testNamespaces[0], seriesMaps)
+}
@@ -22,6 +22,8 @@ package integration
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -74,11 +76,11 @@ func newMultiAddrTestOptions(opts testOptions, instance int) testOptions {
 	start := multiAddrPortStart + (instance * multiAddrPortEach)
 	return opts.
 		SetID(fmt.Sprintf("testhost%d", instance)).
-		SetTChannelNodeAddr(fmt.Sprintf("%s:%d", bind, start)).
-		SetTChannelClusterAddr(fmt.Sprintf("%s:%d", bind, start+1)).
-		SetHTTPNodeAddr(fmt.Sprintf("%s:%d", bind, start+2)).
-		SetHTTPClusterAddr(fmt.Sprintf("%s:%d", bind, start+3)).
-		SetHTTPDebugAddr(fmt.Sprintf("%s:%d", bind, start+4))
+		SetTChannelNodeAddr(net.JoinHostPort(bind, strconv.Itoa(start))).
+		SetTChannelClusterAddr(net.JoinHostPort(bind, strconv.Itoa(start+1))).
+		SetHTTPNodeAddr(net.JoinHostPort(bind, strconv.Itoa(start+2))).
+		SetHTTPClusterAddr(net.JoinHostPort(bind, strconv.Itoa(start+3))).
+		SetHTTPDebugAddr(net.JoinHostPort(bind, strconv.Itoa(start+4)))
 }
 
 func newMultiAddrAdminClient(
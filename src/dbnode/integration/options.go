@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/x/fault"
 
 	"github.com/stretchr/testify/require"
 )
@@ -258,6 +259,14 @@ type testOptions interface {
 	// SetWriteNewSeriesAsync sets whether we insert/index asynchronously.
 	SetWriteNewSeriesAsync(bool) testOptions
 
+	// SetFaultInjector sets the fault injector used to inject failed
+	// flushes, slow disk writes and delayed or failed RPCs.
+	SetFaultInjector(value fault.Injector) testOptions
+
+	// FaultInjector returns the fault injector used to inject failed
+	// flushes, slow disk writes and delayed or failed RPCs.
+	FaultInjector() fault.Injector
+
 	// WriteNewSeriesAsync returns whether we insert/index asynchronously.
 	WriteNewSeriesAsync() bool
 
@@ -304,6 +313,7 @@ type options struct {
 	useTChannelClientForWriting        bool
 	useTChannelClientForTruncation     bool
 	writeNewSeriesAsync                bool
+	faultInjector                      fault.Injector
 }
 
 func newTestOptions(t *testing.T) testOptions {
@@ -337,6 +347,7 @@ func newTestOptions(t *testing.T) testOptions {
 		useTChannelClientForWriting:    defaultUseTChannelClientForWriting,
 		useTChannelClientForTruncation: defaultUseTChannelClientForTruncation,
 		writeNewSeriesAsync:            defaultWriteNewSeriesAsync,
+		faultInjector:                  fault.NewNoopInjector(),
 	}
 }
 
@@ -631,3 +642,13 @@ func (o *options) SetMinimumSnapshotInterval(value time.Duration) testOptions {
 func (o *options) MinimumSnapshotInterval() time.Duration {
 	return o.minimumSnapshotInterval
 }
+
+func (o *options) SetFaultInjector(value fault.Injector) testOptions {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() fault.Injector {
+	return o.faultInjector
+}
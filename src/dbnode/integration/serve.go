@@ -23,8 +23,8 @@ package integration
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"strings"
 
 	"github.com/m3db/m3/src/dbnode/client"
 	hjcluster "github.com/m3db/m3/src/dbnode/network/server/httpjson/cluster"
@@ -35,6 +35,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/x/fault"
 	"github.com/m3db/m3cluster/shard"
 )
 
@@ -55,12 +56,11 @@ func newTopologyInitializerForShardSet(
 	tchannelNodeAddr string,
 	shardSet sharding.ShardSet,
 ) (topology.Initializer, error) {
-	var localNodeAddr string
-	if !strings.ContainsRune(tchannelNodeAddr, ':') {
+	_, port, err := net.SplitHostPort(tchannelNodeAddr)
+	if err != nil || port == "" {
 		return nil, errors.New("tchannelthrift address does not specify port")
 	}
-	localNodeAddrComponents := strings.Split(tchannelNodeAddr, ":")
-	localNodeAddr = fmt.Sprintf("127.0.0.1:%s", localNodeAddrComponents[len(localNodeAddrComponents)-1])
+	localNodeAddr := net.JoinHostPort("127.0.0.1", port)
 
 	hostShardSet := topology.NewHostShardSet(topology.NewHost(hostID, localNodeAddr), shardSet)
 	staticOptions := topology.NewStaticOptions().
@@ -87,6 +87,7 @@ func openAndServe(
 	db storage.Database,
 	client client.Client,
 	opts storage.Options,
+	faultInjector fault.Injector,
 	doneCh <-chan struct{},
 ) error {
 	logger := opts.InstrumentOptions().Logger()
@@ -95,7 +96,8 @@ func openAndServe(
 	}
 
 	contextPool := opts.ContextPool()
-	ttopts := tchannelthrift.NewOptions()
+	ttopts := tchannelthrift.NewOptions().
+		SetFaultInjector(faultInjector)
 	nativeNodeClose, err := ttnode.NewServer(db, tchannelNodeAddr, contextPool, nil, ttopts).ListenAndServe()
 	if err != nil {
 		return fmt.Errorf("could not open tchannelthrift interface %s: %v", tchannelNodeAddr, err)
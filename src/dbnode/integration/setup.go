@@ -247,6 +247,7 @@ func newTestSetup(t *testing.T, opts testOptions, fsOpts fs.Options) (*testSetup
 		fsOpts = fs.NewOptions().
 			SetFilePathPrefix(filePathPrefix)
 	}
+	fsOpts = fsOpts.SetFaultInjector(opts.FaultInjector())
 
 	storageOpts = storageOpts.SetCommitLogOptions(
 		storageOpts.CommitLogOptions().
@@ -297,8 +298,13 @@ func newTestSetup(t *testing.T, opts testOptions, fsOpts fs.Options) (*testSetup
 	}
 
 	// Set up wired list if required
-	if storageOpts.SeriesCachePolicy() == series.CacheLRU {
-		wiredList := block.NewWiredList(
+	if policy := storageOpts.SeriesCachePolicy(); policy == series.CacheLRU ||
+		policy == series.CacheTinyLFU {
+		newWiredList := block.NewWiredList
+		if policy == series.CacheTinyLFU {
+			newWiredList = block.NewTinyLFUWiredList
+		}
+		wiredList := newWiredList(
 			runtimeOptsMgr,
 			storageOpts.InstrumentOptions(),
 			storageOpts.ClockOptions(),
@@ -492,7 +498,7 @@ func (ts *testSetup) startServer() error {
 		if err := openAndServe(
 			httpClusterAddr, tchannelClusterAddr,
 			httpNodeAddr, tchannelNodeAddr, httpDebugAddr,
-			ts.db, ts.m3dbClient, ts.storageOpts, ts.doneCh,
+			ts.db, ts.m3dbClient, ts.storageOpts, ts.opts.FaultInjector(), ts.doneCh,
 		); err != nil {
 			select {
 			case resultCh <- err:
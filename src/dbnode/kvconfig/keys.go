@@ -44,4 +44,13 @@ const (
 	// ClientWriteConsistencyLevel is the KV config key for the runtime
 	// configuration specifying the client write consistency level
 	ClientWriteConsistencyLevel = "m3db.client.write-consistency-level"
+
+	// ReadOnlyKey is the KV config key for the runtime configuration
+	// specifying whether nodes reject writes to all namespaces as a bool.
+	ReadOnlyKey = "m3db.node.read-only"
+
+	// ReadOnlyNamespacesKey is the KV config key for the runtime
+	// configuration specifying the namespaces that reject writes as a
+	// string array.
+	ReadOnlyNamespacesKey = "m3db.node.read-only-namespaces"
 )
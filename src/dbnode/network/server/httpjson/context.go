@@ -21,6 +21,7 @@
 package httpjson
 
 import (
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	m3dbcontext "github.com/m3db/m3x/context"
 
 	apachethrift "github.com/apache/thrift/lib/go/thrift"
@@ -36,6 +37,9 @@ const (
 func NewDefaultContextFn(contextPool m3dbcontext.Pool) ContextFn {
 	return func(ctx context.Context, method string, headers map[string]string) thrift.Context {
 		ctxWithValue := context.WithValue(ctx, interface{}(contextKey), contextPool.Get())
+		if sc, ok := tracing.Extract(tracing.MapCarrier(headers)); ok {
+			ctxWithValue = tracing.NewContext(ctxWithValue, sc)
+		}
 		return thrift.WithHeaders(ctxWithValue, headers)
 	}
 }
@@ -123,11 +123,11 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 
 			httpMethod := strings.ToUpper(r.Method)
 			if reqIn == nil && httpMethod != "GET" {
-				writeError(w, errRequestMustBeGet)
+				WriteError(w, errRequestMustBeGet)
 				return
 			}
 			if reqIn != nil && httpMethod != "POST" {
-				writeError(w, errRequestMustBePost)
+				WriteError(w, errRequestMustBePost)
 				return
 			}
 
@@ -142,7 +142,7 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 			if reqIn != nil {
 				in = reflect.New(reqIn.Elem()).Interface()
 				if err := json.NewDecoder(r.Body).Decode(in); err != nil {
-					writeError(w, errInvalidRequestBody)
+					WriteError(w, errInvalidRequestBody)
 					return
 				}
 			}
@@ -177,7 +177,7 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 
 				// Deal with error case
 				if !ret[0].IsNil() {
-					writeError(w, ret[0].Interface())
+					WriteError(w, ret[0].Interface())
 					return
 				}
 				json.NewEncoder(w).Encode(&respSuccess{})
@@ -197,13 +197,13 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 
 			// Deal with error case
 			if !ret[1].IsNil() {
-				writeError(w, ret[1].Interface())
+				WriteError(w, ret[1].Interface())
 				return
 			}
 
 			buff := bytes.NewBuffer(nil)
 			if err := json.NewEncoder(buff).Encode(ret[0].Interface()); err != nil {
-				writeError(w, errEncodeResponseBody)
+				WriteError(w, errEncodeResponseBody)
 				return
 			}
 
@@ -213,7 +213,8 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 	return nil
 }
 
-func writeError(w http.ResponseWriter, errValue interface{}) {
+// WriteError writes an error JSON response with a status code derived from the error
+func WriteError(w http.ResponseWriter, errValue interface{}) {
 	result := respErrorResult{respError{}}
 	if value, ok := errValue.(error); ok {
 		result.Error.Message = value.Error()
@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/blocksize"
+	"github.com/m3db/m3/src/dbnode/network/server/httpjson"
+	"github.com/m3db/m3/src/dbnode/storage"
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+const (
+	// BlockSizeURL is the URL for the block size recommendation handler.
+	BlockSizeURL = "/blocksize"
+
+	blockSizeNamespaceParam = "namespace"
+)
+
+var (
+	errBlockSizeRequestMustBeGet = xerrors.NewInvalidParamsError(errors.New("block size request must be GET"))
+)
+
+type blockSizeResult struct {
+	Namespaces []namespaceBlockSizeJSON `json:"namespaces"`
+}
+
+type namespaceBlockSizeJSON struct {
+	Namespace   string            `json:"namespace"`
+	Confident   bool              `json:"confident"`
+	Changed     bool              `json:"changed"`
+	SafeToApply *retentionJSON    `json:"safeToApply,omitempty"`
+	Current     retentionJSON     `json:"current"`
+	Recommended retentionJSON     `json:"recommended"`
+	Patterns    writePatternsJSON `json:"patterns"`
+	Reasons     []string          `json:"reasons"`
+}
+
+type retentionJSON struct {
+	BlockSize    string `json:"blockSize"`
+	BufferPast   string `json:"bufferPast"`
+	BufferFuture string `json:"bufferFuture"`
+}
+
+type writePatternsJSON struct {
+	NumSeries      int64         `json:"numSeries"`
+	SampledWrites  int64         `json:"sampledWrites"`
+	SampledQueries int64         `json:"sampledQueries"`
+	WriteInterval  quantilesJSON `json:"writeInterval"`
+	Lateness       quantilesJSON `json:"lateness"`
+	Earliness      quantilesJSON `json:"earliness"`
+	QueryRange     quantilesJSON `json:"queryRange"`
+}
+
+type quantilesJSON struct {
+	Samples int    `json:"samples"`
+	P50     string `json:"p50"`
+	P90     string `json:"p90"`
+	P99     string `json:"p99"`
+	Max     string `json:"max"`
+}
+
+type blockSizeHandler struct {
+	db   storage.Database
+	opts blocksize.Options
+}
+
+// newBlockSizeHandler returns a handler that reports the block size, buffer
+// past and buffer future recommended for each namespace from the write
+// patterns sampled by the database, along with the part of the
+// recommendation that is safe to apply.
+func newBlockSizeHandler(db storage.Database, opts blocksize.Options) http.Handler {
+	return &blockSizeHandler{db: db, opts: opts}
+}
+
+func (h *blockSizeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if strings.ToUpper(r.Method) != http.MethodGet {
+		httpjson.WriteError(w, errBlockSizeRequestMustBeGet)
+		return
+	}
+
+	namespace := r.URL.Query().Get(blockSizeNamespaceParam)
+	result := blockSizeResult{Namespaces: []namespaceBlockSizeJSON{}}
+	for _, rec := range blocksize.RecommendDatabase(h.db, h.opts) {
+		if namespace != "" && rec.Namespace != namespace {
+			continue
+		}
+		result.Namespaces = append(result.Namespaces, newNamespaceBlockSizeJSON(rec))
+	}
+
+	json.NewEncoder(w).Encode(&result)
+}
+
+func newNamespaceBlockSizeJSON(rec blocksize.Recommendation) namespaceBlockSizeJSON {
+	result := namespaceBlockSizeJSON{
+		Namespace:   rec.Namespace,
+		Confident:   rec.Confident,
+		Changed:     rec.Changed(),
+		Current:     newRetentionJSON(rec.Current),
+		Recommended: newRetentionJSON(rec.Recommended),
+		Patterns: writePatternsJSON{
+			NumSeries:      rec.Patterns.NumSeries,
+			SampledWrites:  rec.Patterns.SampledWrites,
+			SampledQueries: rec.Patterns.SampledQueries,
+			WriteInterval:  newQuantilesJSON(rec.Patterns.WriteInterval),
+			Lateness:       newQuantilesJSON(rec.Patterns.Lateness),
+			Earliness:      newQuantilesJSON(rec.Patterns.Earliness),
+			QueryRange:     newQuantilesJSON(rec.Patterns.QueryRange),
+		},
+		Reasons: rec.Reasons,
+	}
+	if result.Reasons == nil {
+		result.Reasons = []string{}
+	}
+	if retention, ok := blocksize.SafeToApply(rec); ok {
+		safe := newRetentionJSON(retention)
+		result.SafeToApply = &safe
+	}
+	return result
+}
+
+func newRetentionJSON(r blocksize.Retention) retentionJSON {
+	return retentionJSON{
+		BlockSize:    r.BlockSize.String(),
+		BufferPast:   r.BufferPast.String(),
+		BufferFuture: r.BufferFuture.String(),
+	}
+}
+
+func newQuantilesJSON(q storage.DurationQuantiles) quantilesJSON {
+	return quantilesJSON{
+		Samples: q.Samples,
+		P50:     q.P50.String(),
+		P90:     q.P90.String(),
+		P99:     q.P99.String(),
+		Max:     q.Max.String(),
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/blocksize"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockSizeHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns := storage.NewMockNamespace(ctrl)
+	ns.EXPECT().ID().Return(ident.StringID("metrics")).AnyTimes()
+	ns.EXPECT().Options().Return(namespace.NewOptions()).AnyTimes()
+	ns.EXPECT().WritePatterns().Return(storage.WritePatterns{
+		NumSeries:     1000,
+		SampledWrites: 10000,
+		WriteInterval: storage.DurationQuantiles{Samples: 1024, P50: time.Minute},
+		Lateness:      storage.DurationQuantiles{Samples: 1024, P99: 20 * time.Minute},
+		Earliness:     storage.DurationQuantiles{Samples: 1024},
+	}).AnyTimes()
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Namespaces().Return([]storage.Namespace{ns}).AnyTimes()
+
+	handler := newBlockSizeHandler(db, blocksize.NewOptions())
+
+	req := httptest.NewRequest(http.MethodGet, BlockSizeURL, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result blockSizeResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	require.Equal(t, 1, len(result.Namespaces))
+
+	rec := result.Namespaces[0]
+	require.Equal(t, "metrics", rec.Namespace)
+	require.True(t, rec.Confident)
+	require.True(t, rec.Changed)
+	require.Equal(t, retentionJSON{
+		BlockSize:    "2h0m0s",
+		BufferPast:   "10m0s",
+		BufferFuture: "2m0s",
+	}, rec.Current)
+	require.Equal(t, retentionJSON{
+		BlockSize:    "12h0m0s",
+		BufferPast:   "22m0s",
+		BufferFuture: "1m0s",
+	}, rec.Recommended)
+	require.Equal(t, &retentionJSON{
+		BlockSize:    "2h0m0s",
+		BufferPast:   "22m0s",
+		BufferFuture: "2m0s",
+	}, rec.SafeToApply)
+	require.Equal(t, "1m0s", rec.Patterns.WriteInterval.P50)
+
+	req = httptest.NewRequest(http.MethodGet, BlockSizeURL+"?namespace=other", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	result = blockSizeResult{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	require.Equal(t, 0, len(result.Namespaces))
+
+	req = httptest.NewRequest(http.MethodPost, BlockSizeURL, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
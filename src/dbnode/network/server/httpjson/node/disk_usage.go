@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/network/server/httpjson"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+const (
+	// DiskUsageURL is the URL for the disk usage handler.
+	DiskUsageURL = "/diskusage"
+
+	diskUsageNamespaceParam = "namespace"
+	diskUsageShardParam     = "shard"
+)
+
+var (
+	errDiskUsageRequestMustBeGet = xerrors.NewInvalidParamsError(errors.New("disk usage request must be GET"))
+	errDiskUsageInvalidShard     = xerrors.NewInvalidParamsError(errors.New("disk usage shard must be an unsigned integer"))
+)
+
+type diskUsageResult struct {
+	TotalBytes     int64                    `json:"totalBytes"`
+	CommitLogBytes int64                    `json:"commitLogBytes"`
+	Namespaces     []namespaceDiskUsageJSON `json:"namespaces"`
+}
+
+type namespaceDiskUsageJSON struct {
+	Namespace   string               `json:"namespace"`
+	TotalBytes  int64                `json:"totalBytes"`
+	Shards      []shardDiskUsageJSON `json:"shards"`
+	IndexBlocks []blockDiskUsageJSON `json:"indexBlocks"`
+}
+
+type shardDiskUsageJSON struct {
+	Shard      uint32               `json:"shard"`
+	TotalBytes int64                `json:"totalBytes"`
+	Blocks     []blockDiskUsageJSON `json:"blocks"`
+}
+
+type blockDiskUsageJSON struct {
+	BlockStart    int64 `json:"blockStart"`
+	DataBytes     int64 `json:"dataBytes"`
+	IndexBytes    int64 `json:"indexBytes"`
+	SnapshotBytes int64 `json:"snapshotBytes"`
+}
+
+type diskUsageHandler struct {
+	filePathPrefix string
+}
+
+// newDiskUsageHandler returns a handler that reports the bytes on disk per
+// namespace, shard and block start, optionally filtered by the namespace and
+// shard query parameters.
+func newDiskUsageHandler(filePathPrefix string) http.Handler {
+	return &diskUsageHandler{filePathPrefix: filePathPrefix}
+}
+
+func (h *diskUsageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if strings.ToUpper(r.Method) != http.MethodGet {
+		httpjson.WriteError(w, errDiskUsageRequestMustBeGet)
+		return
+	}
+
+	var (
+		query        = r.URL.Query()
+		namespace    = query.Get(diskUsageNamespaceParam)
+		shard        uint32
+		filterShards bool
+	)
+	if str := query.Get(diskUsageShardParam); str != "" {
+		value, err := strconv.ParseUint(str, 10, 32)
+		if err != nil {
+			httpjson.WriteError(w, errDiskUsageInvalidShard)
+			return
+		}
+		shard = uint32(value)
+		filterShards = true
+	}
+
+	usage, err := fs.ComputeDiskUsage(h.filePathPrefix)
+	if err != nil {
+		httpjson.WriteError(w, err)
+		return
+	}
+
+	result := diskUsageResult{
+		CommitLogBytes: usage.CommitLogBytes,
+		Namespaces:     []namespaceDiskUsageJSON{},
+	}
+	for _, ns := range usage.Namespaces {
+		if namespace != "" && ns.Namespace != namespace {
+			continue
+		}
+
+		nsResult := namespaceDiskUsageJSON{
+			Namespace:   ns.Namespace,
+			Shards:      []shardDiskUsageJSON{},
+			IndexBlocks: newBlockDiskUsagesJSON(ns.IndexBlocks),
+		}
+		if !filterShards {
+			for _, block := range ns.IndexBlocks {
+				nsResult.TotalBytes += block.TotalBytes()
+			}
+		}
+		for _, s := range ns.Shards {
+			if filterShards && s.Shard != shard {
+				continue
+			}
+			shardResult := shardDiskUsageJSON{
+				Shard:      s.Shard,
+				TotalBytes: s.TotalBytes(),
+				Blocks:     newBlockDiskUsagesJSON(s.Blocks),
+			}
+			nsResult.TotalBytes += shardResult.TotalBytes
+			nsResult.Shards = append(nsResult.Shards, shardResult)
+		}
+
+		result.TotalBytes += nsResult.TotalBytes
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+	if namespace == "" && !filterShards {
+		result.TotalBytes += result.CommitLogBytes
+	}
+
+	json.NewEncoder(w).Encode(&result)
+}
+
+func newBlockDiskUsagesJSON(blocks []fs.BlockDiskUsage) []blockDiskUsageJSON {
+	result := make([]blockDiskUsageJSON, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, blockDiskUsageJSON{
+			BlockStart:    block.BlockStart.Unix(),
+			DataBytes:     block.DataBytes,
+			IndexBytes:    block.IndexBytes,
+			SnapshotBytes: block.SnapshotBytes,
+		})
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/network/server/httpjson"
+	"github.com/m3db/m3/src/dbnode/storage"
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+const (
+	// DrainURL is the URL for the drain handler.
+	DrainURL = "/drain"
+
+	drainSnapshotParam = "snapshot"
+)
+
+var (
+	errDrainRequestMustBePost = xerrors.NewInvalidParamsError(errors.New("drain request must be POST"))
+	errDrainInvalidSnapshot   = xerrors.NewInvalidParamsError(errors.New("drain snapshot must be a boolean"))
+)
+
+type drainResult struct {
+	Draining bool `json:"draining"`
+	Snapshot bool `json:"snapshot"`
+}
+
+type drainHandler struct {
+	db storage.Database
+}
+
+// newDrainHandler returns a handler that drains the database ahead of a
+// planned shutdown, reporting the node as unhealthy and optionally flushing
+// and snapshotting unflushed series when the snapshot query parameter is set.
+func newDrainHandler(db storage.Database) http.Handler {
+	return &drainHandler{db: db}
+}
+
+func (h *drainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if strings.ToUpper(r.Method) != http.MethodPost {
+		httpjson.WriteError(w, errDrainRequestMustBePost)
+		return
+	}
+
+	var snapshot bool
+	if str := r.URL.Query().Get(drainSnapshotParam); str != "" {
+		value, err := strconv.ParseBool(str)
+		if err != nil {
+			httpjson.WriteError(w, errDrainInvalidSnapshot)
+			return
+		}
+		snapshot = value
+	}
+
+	if err := h.db.Drain(snapshot); err != nil {
+		httpjson.WriteError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(&drainResult{
+		Draining: true,
+		Snapshot: snapshot,
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/network/server/httpjson"
+	"github.com/m3db/m3/src/dbnode/storage"
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+const (
+	// HotShardsURL is the URL for the hot shards handler.
+	HotShardsURL = "/hotshards"
+
+	hotShardsNamespaceParam = "namespace"
+	hotShardsFactorParam    = "factor"
+	hotShardsMinOpsParam    = "minOps"
+
+	defaultHotShardsFactor = 2.0
+	defaultHotShardsMinOps = 1000
+)
+
+var (
+	errHotShardsRequestMustBeGet = xerrors.NewInvalidParamsError(errors.New("hot shards request must be GET"))
+	errHotShardsInvalidFactor    = xerrors.NewInvalidParamsError(errors.New("hot shards factor must be a number greater than one"))
+	errHotShardsInvalidMinOps    = xerrors.NewInvalidParamsError(errors.New("hot shards minOps must be a non-negative integer"))
+)
+
+type hotShardsResult struct {
+	Namespaces []namespaceHotShardsJSON `json:"namespaces"`
+}
+
+type namespaceHotShardsJSON struct {
+	Namespace string         `json:"namespace"`
+	Window    string         `json:"window"`
+	MeanOps   float64        `json:"meanOps"`
+	HotShards []hotShardJSON `json:"hotShards"`
+}
+
+type hotShardJSON struct {
+	Shard     uint32          `json:"shard"`
+	Writes    int64           `json:"writes"`
+	Reads     int64           `json:"reads"`
+	TopSeries []hotSeriesJSON `json:"topSeries"`
+}
+
+type hotSeriesJSON struct {
+	ID      string `json:"id"`
+	Pattern string `json:"pattern,omitempty"`
+	Writes  int64  `json:"writes"`
+	Reads   int64  `json:"reads"`
+}
+
+type hotShardsHandler struct {
+	db storage.Database
+}
+
+// newHotShardsHandler returns a handler that reports the shards that received
+// more than factor times the mean operations of the shards of their namespace
+// over the last window, along with the series estimated to be responsible.
+func newHotShardsHandler(db storage.Database) http.Handler {
+	return &hotShardsHandler{db: db}
+}
+
+func (h *hotShardsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if strings.ToUpper(r.Method) != http.MethodGet {
+		httpjson.WriteError(w, errHotShardsRequestMustBeGet)
+		return
+	}
+
+	var (
+		query     = r.URL.Query()
+		namespace = query.Get(hotShardsNamespaceParam)
+		factor    = defaultHotShardsFactor
+		minOps    = int64(defaultHotShardsMinOps)
+	)
+	if str := query.Get(hotShardsFactorParam); str != "" {
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil || value <= 1 {
+			httpjson.WriteError(w, errHotShardsInvalidFactor)
+			return
+		}
+		factor = value
+	}
+	if str := query.Get(hotShardsMinOpsParam); str != "" {
+		value, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || value < 0 {
+			httpjson.WriteError(w, errHotShardsInvalidMinOps)
+			return
+		}
+		minOps = value
+	}
+
+	result := hotShardsResult{Namespaces: []namespaceHotShardsJSON{}}
+	for _, ns := range h.db.Namespaces() {
+		if namespace != "" && ns.ID().String() != namespace {
+			continue
+		}
+
+		var (
+			shards  = ns.Shards()
+			traffic = make([]storage.ShardTraffic, 0, len(shards))
+			total   int64
+		)
+		for _, shard := range shards {
+			t := shard.Traffic()
+			traffic = append(traffic, t)
+			total += t.Ops()
+		}
+
+		nsResult := namespaceHotShardsJSON{
+			Namespace: ns.ID().String(),
+			HotShards: []hotShardJSON{},
+		}
+		if len(traffic) > 0 {
+			nsResult.Window = traffic[0].Window.String()
+			nsResult.MeanOps = float64(total) / float64(len(traffic))
+		}
+		for _, t := range storage.DetectHotShards(traffic, factor, minOps) {
+			nsResult.HotShards = append(nsResult.HotShards, newHotShardJSON(t))
+		}
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+
+	json.NewEncoder(w).Encode(&result)
+}
+
+func newHotShardJSON(t storage.ShardTraffic) hotShardJSON {
+	result := hotShardJSON{
+		Shard:     t.Shard,
+		Writes:    t.Writes,
+		Reads:     t.Reads,
+		TopSeries: make([]hotSeriesJSON, 0, len(t.TopSeries)),
+	}
+	for _, s := range t.TopSeries {
+		result.TopSeries = append(result.TopSeries, hotSeriesJSON{
+			ID:      s.ID,
+			Pattern: s.Pattern,
+			Writes:  s.Writes,
+			Reads:   s.Reads,
+		})
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotShardsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var shards []storage.Shard
+	for i, ops := range []int64{1000, 1000, 1000, 9000} {
+		shard := storage.NewMockShard(ctrl)
+		traffic := storage.ShardTraffic{
+			Shard:  uint32(i),
+			Window: time.Minute,
+			Writes: ops,
+		}
+		if ops > 1000 {
+			traffic.TopSeries = []storage.SeriesTraffic{
+				{ID: "foo", Pattern: "foo{host}", Writes: 8000},
+			}
+		}
+		shard.EXPECT().Traffic().Return(traffic).AnyTimes()
+		shards = append(shards, shard)
+	}
+	ns := storage.NewMockNamespace(ctrl)
+	ns.EXPECT().ID().Return(ident.StringID("metrics")).AnyTimes()
+	ns.EXPECT().Shards().Return(shards).AnyTimes()
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Namespaces().Return([]storage.Namespace{ns}).AnyTimes()
+
+	handler := newHotShardsHandler(db)
+
+	req := httptest.NewRequest(http.MethodGet, HotShardsURL, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result hotShardsResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	require.Equal(t, hotShardsResult{
+		Namespaces: []namespaceHotShardsJSON{
+			{
+				Namespace: "metrics",
+				Window:    "1m0s",
+				MeanOps:   3000,
+				HotShards: []hotShardJSON{
+					{
+						Shard:  3,
+						Writes: 9000,
+						TopSeries: []hotSeriesJSON{
+							{ID: "foo", Pattern: "foo{host}", Writes: 8000},
+						},
+					},
+				},
+			},
+		},
+	}, result)
+
+	req = httptest.NewRequest(http.MethodGet, HotShardsURL+"?namespace=other", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	result = hotShardsResult{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	require.Equal(t, 0, len(result.Namespaces))
+
+	req = httptest.NewRequest(http.MethodGet, HotShardsURL+"?factor=0.5", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, HotShardsURL, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
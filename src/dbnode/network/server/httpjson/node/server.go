@@ -24,6 +24,7 @@ import (
 	"net"
 	"net/http"
 
+	"github.com/m3db/m3/src/dbnode/blocksize"
 	ns "github.com/m3db/m3/src/dbnode/network/server"
 	"github.com/m3db/m3/src/dbnode/network/server/httpjson"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
@@ -70,6 +71,12 @@ func (s *server) ListenAndServe() (ns.Close, error) {
 		return nil, err
 	}
 
+	filePathPrefix := s.db.Options().CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	mux.Handle(DiskUsageURL, newDiskUsageHandler(filePathPrefix))
+	mux.Handle(DrainURL, newDrainHandler(s.db))
+	mux.Handle(HotShardsURL, newHotShardsHandler(s.db))
+	mux.Handle(BlockSizeURL, newBlockSizeHandler(s.db, blocksize.NewOptions()))
+
 	listener, err := net.Listen("tcp", s.address)
 	if err != nil {
 		return nil, err
@@ -23,6 +23,7 @@ package tchannelthrift
 import (
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	"github.com/m3db/m3x/context"
 
 	apachethrift "github.com/apache/thrift/lib/go/thrift"
@@ -41,6 +42,9 @@ func RegisterServer(channel *tchannel.Channel, service thrift.TChanServer, conte
 	server.Register(service, thrift.OptPostResponse(postResponseFn))
 	server.SetContextFn(func(ctx xnetcontext.Context, method string, headers map[string]string) thrift.Context {
 		ctxWithValue := xnetcontext.WithValue(ctx, contextKey, contextPool.Get())
+		if sc, ok := tracing.Extract(tracing.MapCarrier(headers)); ok {
+			ctxWithValue = tracing.NewContext(ctxWithValue, sc)
+		}
 		return thrift.WithHeaders(ctxWithValue, headers)
 	})
 }
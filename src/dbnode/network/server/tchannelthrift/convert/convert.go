@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
@@ -187,10 +188,10 @@ func ToRPCError(err error) *rpc.Error {
 	if err == nil {
 		return nil
 	}
-	if xerrors.IsInvalidParams(err) {
-		return tterrors.NewBadRequestError(err)
+	if retryAfter, ok := m3dberrors.RetryAfter(err); ok {
+		return tterrors.NewResourceExhaustedError(err, retryAfter)
 	}
-	return tterrors.NewInternalError(err)
+	return tterrors.NewCodedError(m3dberrors.GetCode(err), err)
 }
 
 // FetchTaggedConversionPools allows users to pass a pool for conversions.
@@ -223,6 +224,9 @@ func FromRPCFetchTaggedRequest(
 	if l := req.Limit; l != nil {
 		opts.Limit = int(*l)
 	}
+	if len(req.TagNames) > 0 {
+		opts.TagNames = req.TagNames
+	}
 
 	q, err := idx.Unmarshal(req.Query)
 	if err != nil {
@@ -273,6 +277,9 @@ func ToRPCFetchTaggedRequest(
 		l := int64(opts.Limit)
 		request.Limit = &l
 	}
+	if len(opts.TagNames) > 0 {
+		request.TagNames = opts.TagNames
+	}
 
 	return request, nil
 }
@@ -21,15 +21,18 @@
 package convert_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/m3ninx/idx"
+	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/pool"
 
@@ -44,6 +47,27 @@ func mustToRpcTime(t *testing.T, ts time.Time) int64 {
 	return r
 }
 
+func TestToRPCError(t *testing.T) {
+	err := errors.New("an error")
+	tests := []struct {
+		err     error
+		errType rpc.ErrorType
+	}{
+		{err: err, errType: rpc.ErrorType_INTERNAL_ERROR},
+		{err: xerrors.NewInvalidParamsError(err), errType: rpc.ErrorType_BAD_REQUEST},
+		{err: m3dberrors.NewResourceExhaustedError(err, time.Second), errType: rpc.ErrorType_RESOURCE_EXHAUSTED},
+		{err: xerrors.NewRetryableError(m3dberrors.NewUnavailableError(err)), errType: rpc.ErrorType_UNAVAILABLE},
+		{err: m3dberrors.NewDataCorruptionError(err), errType: rpc.ErrorType_DATA_CORRUPTION},
+	}
+	for _, test := range tests {
+		rpcErr := convert.ToRPCError(test.err)
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, test.errType, rpcErr.Type)
+		assert.Equal(t, test.err.Error(), rpcErr.Message)
+	}
+	assert.Nil(t, convert.ToRPCError(nil))
+}
+
 func termQueryTestCase(t *testing.T) (idx.Query, []byte) {
 	q1 := idx.NewTermQuery([]byte("dat"), []byte("baz"))
 	data, err := idx.Marshal(q1)
@@ -92,6 +116,7 @@ func TestConvertFetchTaggedRequest(t *testing.T) {
 		StartInclusive: time.Now().Add(-900 * time.Hour),
 		EndExclusive:   time.Now(),
 		Limit:          10,
+		TagNames:       [][]byte{[]byte("foo"), []byte("bar")},
 	}
 	fetchData := true
 	var limit int64 = 10
@@ -101,6 +126,7 @@ func TestConvertFetchTaggedRequest(t *testing.T) {
 		RangeEnd:   mustToRpcTime(t, opts.EndExclusive),
 		FetchData:  fetchData,
 		Limit:      &limit,
+		TagNames:   opts.TagNames,
 	}
 	requireEqual := func(a, b interface{}) {
 		d := cmp.Diff(a, b)
@@ -22,8 +22,10 @@ package errors
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 )
 
 func newError(errType rpc.ErrorType, err error) *rpc.Error {
@@ -43,6 +45,51 @@ func IsBadRequestError(err *rpc.Error) bool {
 	return err != nil && err.Type == rpc.ErrorType_BAD_REQUEST
 }
 
+// IsResourceExhaustedError returns whether the error is a resource exhausted error
+func IsResourceExhaustedError(err *rpc.Error) bool {
+	return err != nil && err.Type == rpc.ErrorType_RESOURCE_EXHAUSTED
+}
+
+// ErrorTypeFromCode returns the RPC error type of an error code
+func ErrorTypeFromCode(code m3dberrors.Code) rpc.ErrorType {
+	switch code {
+	case m3dberrors.CodeBadRequest:
+		return rpc.ErrorType_BAD_REQUEST
+	case m3dberrors.CodeResourceExhausted:
+		return rpc.ErrorType_RESOURCE_EXHAUSTED
+	case m3dberrors.CodeUnavailable:
+		return rpc.ErrorType_UNAVAILABLE
+	case m3dberrors.CodeDataCorruption:
+		return rpc.ErrorType_DATA_CORRUPTION
+	case m3dberrors.CodeConsistencyNotAchieved:
+		return rpc.ErrorType_CONSISTENCY_NOT_ACHIEVED
+	}
+	return rpc.ErrorType_INTERNAL_ERROR
+}
+
+// CodeFromErrorType returns the error code of an RPC error type, error types
+// unknown to the receiver such as those added by newer servers are internal
+func CodeFromErrorType(errType rpc.ErrorType) m3dberrors.Code {
+	switch errType {
+	case rpc.ErrorType_BAD_REQUEST:
+		return m3dberrors.CodeBadRequest
+	case rpc.ErrorType_RESOURCE_EXHAUSTED:
+		return m3dberrors.CodeResourceExhausted
+	case rpc.ErrorType_UNAVAILABLE:
+		return m3dberrors.CodeUnavailable
+	case rpc.ErrorType_DATA_CORRUPTION:
+		return m3dberrors.CodeDataCorruption
+	case rpc.ErrorType_CONSISTENCY_NOT_ACHIEVED:
+		return m3dberrors.CodeConsistencyNotAchieved
+	}
+	return m3dberrors.CodeInternal
+}
+
+// NewCodedError creates a new error with the RPC error type of the code
+func NewCodedError(code m3dberrors.Code, err error) *rpc.Error {
+	return newError(ErrorTypeFromCode(code), err)
+}
+
 // NewInternalError creates a new internal error
 func NewInternalError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_INTERNAL_ERROR, err)
@@ -53,6 +100,17 @@ func NewBadRequestError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_BAD_REQUEST, err)
 }
 
+// NewResourceExhaustedError creates a new resource exhausted error with a hint
+// of how long the caller should wait before retrying
+func NewResourceExhaustedError(err error, retryAfter time.Duration) *rpc.Error {
+	rpcErr := newError(rpc.ErrorType_RESOURCE_EXHAUSTED, err)
+	if retryAfter > 0 {
+		retryAfterMillis := int64(retryAfter / time.Millisecond)
+		rpcErr.RetryAfterMillis = &retryAfterMillis
+	}
+	return rpcErr
+}
+
 // NewWriteBatchRawError creates a new write batch error
 func NewWriteBatchRawError(index int, err error) *rpc.WriteBatchRawError {
 	batchErr := rpc.NewWriteBatchRawError()
@@ -68,3 +126,15 @@ func NewBadRequestWriteBatchRawError(index int, err error) *rpc.WriteBatchRawErr
 	batchErr.Err = NewBadRequestError(err)
 	return batchErr
 }
+
+// NewResourceExhaustedWriteBatchRawError creates a new resource exhausted write batch error
+func NewResourceExhaustedWriteBatchRawError(
+	index int,
+	err error,
+	retryAfter time.Duration,
+) *rpc.WriteBatchRawError {
+	batchErr := rpc.NewWriteBatchRawError()
+	batchErr.Index = int64(index)
+	batchErr.Err = NewResourceExhaustedError(err, retryAfter)
+	return batchErr
+}
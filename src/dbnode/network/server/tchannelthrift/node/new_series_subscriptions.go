@@ -0,0 +1,175 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage"
+)
+
+const (
+	newSeriesSubscriptionDefaultPollTimeout = 10 * time.Second
+	newSeriesSubscriptionMaxPollTimeout     = time.Minute
+	newSeriesSubscriptionDefaultPollLimit   = 1024
+	newSeriesSubscriptionIdleTimeout        = 5 * time.Minute
+)
+
+// newSeriesSubscriptions tracks the new series subscriptions made by clients
+// over RPC. Clients long poll a subscription by ID to receive new series,
+// subscriptions not polled within the idle timeout are closed so that
+// subscriptions abandoned by clients do not keep buffering new series.
+type newSeriesSubscriptions struct {
+	sync.Mutex
+
+	nowFn     clock.NowFn
+	nextID    int64
+	subs      map[int64]*newSeriesSubscriptionEntry
+	lastSweep time.Time
+}
+
+type newSeriesSubscriptionEntry struct {
+	// pollLock serializes polls of the subscription.
+	pollLock sync.Mutex
+
+	sub      storage.NewSeriesSubscription
+	lastPoll time.Time
+}
+
+func newNewSeriesSubscriptions(nowFn clock.NowFn) *newSeriesSubscriptions {
+	return &newSeriesSubscriptions{
+		nowFn: nowFn,
+		subs:  make(map[int64]*newSeriesSubscriptionEntry),
+	}
+}
+
+// add registers the subscription and returns its ID.
+func (s *newSeriesSubscriptions) add(
+	sub storage.NewSeriesSubscription,
+) (int64, *newSeriesSubscriptionEntry) {
+	s.Lock()
+	now := s.nowFn()
+	expired := s.sweepWithLock(now)
+	s.nextID++
+	id := s.nextID
+	entry := &newSeriesSubscriptionEntry{sub: sub, lastPoll: now}
+	s.subs[id] = entry
+	s.Unlock()
+
+	closeNewSeriesSubscriptions(expired)
+	return id, entry
+}
+
+// get returns the subscription with the given ID and marks it as polled.
+func (s *newSeriesSubscriptions) get(id int64) (*newSeriesSubscriptionEntry, bool) {
+	s.Lock()
+	now := s.nowFn()
+	expired := s.sweepWithLock(now)
+	entry, ok := s.subs[id]
+	if ok {
+		entry.lastPoll = now
+	}
+	s.Unlock()
+
+	closeNewSeriesSubscriptions(expired)
+	return entry, ok
+}
+
+// remove unregisters the subscription with the given ID and closes it.
+func (s *newSeriesSubscriptions) remove(id int64) {
+	s.Lock()
+	entry, ok := s.subs[id]
+	delete(s.subs, id)
+	s.Unlock()
+
+	if ok {
+		entry.sub.Close()
+	}
+}
+
+func (s *newSeriesSubscriptions) sweepWithLock(
+	now time.Time,
+) []*newSeriesSubscriptionEntry {
+	if now.Sub(s.lastSweep) < newSeriesSubscriptionIdleTimeout {
+		return nil
+	}
+	s.lastSweep = now
+
+	var expired []*newSeriesSubscriptionEntry
+	for id, entry := range s.subs {
+		if now.Sub(entry.lastPoll) >= newSeriesSubscriptionIdleTimeout {
+			expired = append(expired, entry)
+			delete(s.subs, id)
+		}
+	}
+	return expired
+}
+
+func closeNewSeriesSubscriptions(entries []*newSeriesSubscriptionEntry) {
+	for _, entry := range entries {
+		entry.sub.Close()
+	}
+}
+
+// poll waits until at least one new series is available, the timeout
+// elapses or done is closed, then returns up to limit new series.
+func (e *newSeriesSubscriptionEntry) poll(
+	timeout time.Duration,
+	limit int,
+	done <-chan struct{},
+) []storage.NewSeries {
+	e.pollLock.Lock()
+	defer e.pollLock.Unlock()
+
+	var (
+		ch     = e.sub.C()
+		result []storage.NewSeries
+	)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case series, ok := <-ch:
+		if !ok {
+			return nil
+		}
+		result = append(result, series)
+	case <-timer.C:
+		return nil
+	case <-done:
+		return nil
+	}
+
+	for len(result) < limit {
+		select {
+		case series, ok := <-ch:
+			if !ok {
+				return result
+			}
+			result = append(result, series)
+		default:
+			return result
+		}
+	}
+	return result
+}
@@ -21,6 +21,8 @@
 package node
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	ns "github.com/m3db/m3/src/dbnode/network/server"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
@@ -31,6 +33,10 @@ import (
 	"github.com/uber/tchannel-go"
 )
 
+const (
+	closeDrainCheckInterval = 10 * time.Millisecond
+)
+
 type server struct {
 	db          storage.Database
 	address     string
@@ -75,5 +81,17 @@ func (s *server) ListenAndServe() (ns.Close, error) {
 
 	channel.ListenAndServe(s.address)
 
-	return channel.Close, nil
+	drainTimeout := s.ttopts.CloseDrainTimeout()
+	return func() {
+		// NB: closing the channel stops accepting new calls and closes
+		// connections once their in flight calls complete
+		channel.Close()
+		if drainTimeout <= 0 {
+			return
+		}
+		deadline := time.Now().Add(drainTimeout)
+		for !channel.Closed() && time.Now().Before(deadline) {
+			time.Sleep(closeDrainCheckInterval)
+		}
+	}, nil
 }
@@ -21,6 +21,7 @@
 package node
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"sync"
@@ -35,9 +36,15 @@ import (
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/x/fault"
+	"github.com/m3db/m3/src/dbnode/x/loglevel"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
+	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
@@ -63,6 +70,9 @@ var (
 const (
 	initSegmentArrayPoolLength  = 4
 	maxSegmentArrayPooledLength = 32
+
+	healthStatusUp       = "up"
+	healthStatusDraining = "draining"
 )
 
 var (
@@ -74,37 +84,61 @@ var (
 
 	// errRequiresDatapoint raised when a datapoint is not provided
 	errRequiresDatapoint = fmt.Errorf("requires datapoint")
+
+	// errUnknownNewSeriesSubscription raised when polling a new series
+	// subscription that does not exist or has expired
+	errUnknownNewSeriesSubscription = errors.New("unknown new series subscription")
+
+	// errLogLevelNotControlled raised when setting debug log settings and the
+	// node logger is not controlled at runtime
+	errLogLevelNotControlled = errors.New("log level is not controlled at runtime")
+
+	// errForceCompactShards raised when forcing an index compaction for
+	// specific shards as index filesets span every shard of a namespace
+	errForceCompactShards = errors.New("index compaction can not be forced for specific shards")
 )
 
 type serviceMetrics struct {
-	fetch               instrument.MethodMetrics
-	fetchTagged         instrument.MethodMetrics
-	write               instrument.MethodMetrics
-	writeTagged         instrument.MethodMetrics
-	fetchBlocks         instrument.MethodMetrics
-	fetchBlocksMetadata instrument.MethodMetrics
-	repair              instrument.MethodMetrics
-	truncate            instrument.MethodMetrics
-	fetchBatchRaw       instrument.BatchMethodMetrics
-	writeBatchRaw       instrument.BatchMethodMetrics
-	writeTaggedBatchRaw instrument.BatchMethodMetrics
+	fetch               xmetrics.MethodMetrics
+	fetchTagged         xmetrics.MethodMetrics
+	write               xmetrics.MethodMetrics
+	writeTagged         xmetrics.MethodMetrics
+	fetchBlocks         xmetrics.MethodMetrics
+	fetchBlocksMetadata xmetrics.MethodMetrics
+	repair              xmetrics.MethodMetrics
+	truncate            xmetrics.MethodMetrics
+	forceFlush          xmetrics.MethodMetrics
+	forceCompact        xmetrics.MethodMetrics
+	prune               xmetrics.MethodMetrics
+	subscribeNewSeries  xmetrics.MethodMetrics
+	fetchBatchRaw       xmetrics.BatchMethodMetrics
+	writeBatchRaw       xmetrics.BatchMethodMetrics
+	writeTaggedBatchRaw xmetrics.BatchMethodMetrics
 	overloadRejected    tally.Counter
+	fenceRejected       tally.Counter
+	writeRetries        tally.Counter
 }
 
-func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
+func newServiceMetrics(scope tally.Scope, buckets tally.Buckets) serviceMetrics {
 	return serviceMetrics{
-		fetch:               instrument.NewMethodMetrics(scope, "fetch", samplingRate),
-		fetchTagged:         instrument.NewMethodMetrics(scope, "fetchTagged", samplingRate),
-		write:               instrument.NewMethodMetrics(scope, "write", samplingRate),
-		writeTagged:         instrument.NewMethodMetrics(scope, "writeTagged", samplingRate),
-		fetchBlocks:         instrument.NewMethodMetrics(scope, "fetchBlocks", samplingRate),
-		fetchBlocksMetadata: instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", samplingRate),
-		repair:              instrument.NewMethodMetrics(scope, "repair", samplingRate),
-		truncate:            instrument.NewMethodMetrics(scope, "truncate", samplingRate),
-		fetchBatchRaw:       instrument.NewBatchMethodMetrics(scope, "fetchBatchRaw", samplingRate),
-		writeBatchRaw:       instrument.NewBatchMethodMetrics(scope, "writeBatchRaw", samplingRate),
-		writeTaggedBatchRaw: instrument.NewBatchMethodMetrics(scope, "writeTaggedBatchRaw", samplingRate),
+		fetch:               xmetrics.NewMethodMetrics(scope, "fetch", buckets),
+		fetchTagged:         xmetrics.NewMethodMetrics(scope, "fetchTagged", buckets),
+		write:               xmetrics.NewMethodMetrics(scope, "write", buckets),
+		writeTagged:         xmetrics.NewMethodMetrics(scope, "writeTagged", buckets),
+		fetchBlocks:         xmetrics.NewMethodMetrics(scope, "fetchBlocks", buckets),
+		fetchBlocksMetadata: xmetrics.NewMethodMetrics(scope, "fetchBlocksMetadata", buckets),
+		repair:              xmetrics.NewMethodMetrics(scope, "repair", buckets),
+		truncate:            xmetrics.NewMethodMetrics(scope, "truncate", buckets),
+		forceFlush:          xmetrics.NewMethodMetrics(scope, "forceFlush", buckets),
+		forceCompact:        xmetrics.NewMethodMetrics(scope, "forceCompact", buckets),
+		prune:               xmetrics.NewMethodMetrics(scope, "prune", buckets),
+		subscribeNewSeries:  xmetrics.NewMethodMetrics(scope, "subscribeNewSeries", buckets),
+		fetchBatchRaw:       xmetrics.NewBatchMethodMetrics(scope, "fetchBatchRaw", buckets),
+		writeBatchRaw:       xmetrics.NewBatchMethodMetrics(scope, "writeBatchRaw", buckets),
+		writeTaggedBatchRaw: xmetrics.NewBatchMethodMetrics(scope, "writeTaggedBatchRaw", buckets),
 		overloadRejected:    scope.Counter("overload-rejected"),
+		fenceRejected:       scope.Counter("fence-rejected"),
+		writeRetries:        scope.Counter("write-sequence-retries"),
 	}
 }
 
@@ -112,13 +146,16 @@ func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
 type service struct {
 	sync.RWMutex
 
-	db      storage.Database
-	logger  log.Logger
-	opts    tchannelthrift.Options
-	nowFn   clock.NowFn
-	pools   pools
-	metrics serviceMetrics
-	health  *rpc.NodeHealthResult_
+	db            storage.Database
+	logger        log.Logger
+	logLevels     *loglevel.Controller
+	opts          tchannelthrift.Options
+	nowFn         clock.NowFn
+	pools         pools
+	metrics       serviceMetrics
+	health        *rpc.NodeHealthResult_
+	writeTokens   *writeTokens
+	newSeriesSubs *newSeriesSubscriptions
 }
 
 type pools struct {
@@ -179,12 +216,16 @@ func NewService(db storage.Database, opts tchannelthrift.Options) rpc.TChanNode
 	writeBatchPooledReqPool := newWriteBatchPooledReqPool(iopts)
 	writeBatchPooledReqPool.Init(opts.TagDecoderPool())
 
+	logLevels, _ := loglevel.ControllerFrom(iopts.Logger())
 	s := &service{
-		db:      db,
-		logger:  iopts.Logger(),
-		opts:    opts,
-		nowFn:   db.Options().ClockOptions().NowFn(),
-		metrics: newServiceMetrics(scope, iopts.MetricsSamplingRate()),
+		db:            db,
+		logger:        iopts.Logger(),
+		logLevels:     logLevels,
+		opts:          opts,
+		nowFn:         db.Options().ClockOptions().NowFn(),
+		metrics:       newServiceMetrics(scope, opts.LatencyBuckets()),
+		writeTokens:   newWriteTokens(db.Options().ClockOptions().NowFn()),
+		newSeriesSubs: newNewSeriesSubscriptions(db.Options().ClockOptions().NowFn()),
 		pools: pools{
 			checkedBytesWrapper:     wrapperPool,
 			tagEncoder:              opts.TagEncoderPool(),
@@ -201,7 +242,7 @@ func NewService(db storage.Database, opts tchannelthrift.Options) rpc.TChanNode
 		},
 		health: &rpc.NodeHealthResult_{
 			Ok:           true,
-			Status:       "up",
+			Status:       healthStatusUp,
 			Bootstrapped: false,
 		},
 	}
@@ -217,9 +258,18 @@ func (s *service) Health(ctx thrift.Context) (*rpc.NodeHealthResult_, error) {
 	// Update bootstrapped field if not up to date
 	bootstrapped := s.db.IsBootstrapped()
 
-	if health.Bootstrapped != bootstrapped {
+	// Report unhealthy while draining so that health checks deregister
+	// the node ahead of a planned shutdown
+	ok, status := true, healthStatusUp
+	if s.db.IsDraining() {
+		ok, status = false, healthStatusDraining
+	}
+
+	if health.Bootstrapped != bootstrapped || health.Ok != ok {
 		newHealth := &rpc.NodeHealthResult_{}
 		*newHealth = *health
+		newHealth.Ok = ok
+		newHealth.Status = status
 		newHealth.Bootstrapped = bootstrapped
 
 		s.Lock()
@@ -239,6 +289,14 @@ func (s *service) Query(tctx thrift.Context, req *rpc.QueryRequest) (*rpc.QueryR
 		return nil, tterrors.NewInternalError(errServerIsOverloaded)
 	}
 
+	if err := s.injectFault("query"); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return nil, err
+	}
+
 	ctx := tchannelthrift.Context(tctx)
 
 	start, rangeStartErr := convert.ToTime(req.RangeStart, req.RangeType)
@@ -307,6 +365,14 @@ func (s *service) Fetch(tctx thrift.Context, req *rpc.FetchRequest) (*rpc.FetchR
 		return nil, tterrors.NewInternalError(errServerIsOverloaded)
 	}
 
+	if err := s.injectFault("fetch"); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return nil, err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -321,6 +387,11 @@ func (s *service) Fetch(tctx thrift.Context, req *rpc.FetchRequest) (*rpc.FetchR
 	tsID := s.pools.id.GetStringID(ctx, req.ID)
 	nsID := s.pools.id.GetStringID(ctx, req.NameSpace)
 
+	if err := s.checkFenceID(tctx, tsID); err != nil {
+		s.metrics.fetch.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
 	// Make datapoints an initialized empty array for JSON serialization as empty array than null
 	datapoints, err := s.readDatapoints(ctx, nsID, tsID, start, end,
 		req.ResultTimeType)
@@ -380,6 +451,14 @@ func (s *service) FetchTagged(tctx thrift.Context, req *rpc.FetchTaggedRequest)
 		return nil, tterrors.NewInternalError(errServerIsOverloaded)
 	}
 
+	if err := s.injectFault("fetchTagged"); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return nil, err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 	ns, query, opts, fetchData, err := convert.FromRPCFetchTaggedRequest(req, s.pools)
@@ -391,6 +470,9 @@ func (s *service) FetchTagged(tctx thrift.Context, req *rpc.FetchTaggedRequest)
 	queryResult, err := s.db.QueryIDs(ctx, ns, query, opts)
 	if err != nil {
 		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+		if xerrors.IsInvalidParams(err) {
+			return nil, tterrors.NewBadRequestError(err)
+		}
 		return nil, tterrors.NewInternalError(err)
 	}
 
@@ -400,9 +482,14 @@ func (s *service) FetchTagged(tctx thrift.Context, req *rpc.FetchTaggedRequest)
 	results := queryResult.Results
 	nsID := results.Namespace()
 	tagsIter := ident.NewTagsIterator(ident.Tags{})
+	var projected []ident.Tag
 	for _, entry := range results.Map().Iter() {
 		tsID := entry.Key()
 		tags := entry.Value()
+		if len(opts.TagNames) > 0 {
+			projected = projectTags(projected[:0], tags.Values(), opts.TagNames)
+			tags = ident.NewTags(projected...)
+		}
 		enc := s.pools.tagEncoder.Get()
 		ctx.RegisterFinalizer(enc)
 		tagsIter.Reset(tags)
@@ -433,6 +520,19 @@ func (s *service) FetchTagged(tctx thrift.Context, req *rpc.FetchTaggedRequest)
 	return response, nil
 }
 
+// projectTags appends the tags with one of the given names to dst.
+func projectTags(dst []ident.Tag, tags []ident.Tag, names [][]byte) []ident.Tag {
+	for _, tag := range tags {
+		for _, name := range names {
+			if bytes.Equal(tag.Name.Bytes(), name) {
+				dst = append(dst, tag)
+				break
+			}
+		}
+	}
+	return dst
+}
+
 func (s *service) encodeTags(
 	enc serialize.TagEncoder,
 	tags ident.TagIterator,
@@ -461,6 +561,14 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 		return nil, tterrors.NewInternalError(errServerIsOverloaded)
 	}
 
+	if err := s.injectFault("fetchBatchRaw"); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return nil, err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -488,6 +596,12 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 		result.Elements = append(result.Elements, rawResult)
 
 		tsID := s.newID(ctx, req.Ids[i])
+		if err := s.checkFenceID(tctx, tsID); err != nil {
+			rawResult.Err = convert.ToRPCError(err)
+			retryableErrors++
+			continue
+		}
+
 		segments, rpcErr := s.readEncoded(ctx, nsID, tsID, start, end)
 		if rpcErr != nil {
 			rawResult.Err = rpcErr
@@ -517,6 +631,14 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 		return nil, tterrors.NewInternalError(errServerIsOverloaded)
 	}
 
+	if err := s.injectFault("fetchBlocksRaw"); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFenceShard(tctx, uint32(req.Shard)); err != nil {
+		return nil, err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -590,6 +712,14 @@ func (s *service) FetchBlocksMetadataRaw(tctx thrift.Context, req *rpc.FetchBloc
 		return nil, tterrors.NewInternalError(errServerIsOverloaded)
 	}
 
+	if err := s.injectFault("fetchBlocksMetadataRaw"); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFenceShard(tctx, uint32(req.Shard)); err != nil {
+		return nil, err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -694,6 +824,14 @@ func (s *service) FetchBlocksMetadataRawV2(tctx thrift.Context, req *rpc.FetchBl
 		return nil, tterrors.NewInternalError(errServerIsOverloaded)
 	}
 
+	if err := s.injectFault("fetchBlocksMetadataRawV2"); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFenceShard(tctx, uint32(req.Shard)); err != nil {
+		return nil, err
+	}
+
 	var err error
 	callStart := s.nowFn()
 	defer func() {
@@ -824,6 +962,14 @@ func (s *service) getBlocksMetadataV2FromResult(
 }
 
 func (s *service) Write(tctx thrift.Context, req *rpc.WriteRequest) error {
+	if err := s.injectFault("write"); err != nil {
+		return err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -846,10 +992,30 @@ func (s *service) Write(tctx thrift.Context, req *rpc.WriteRequest) error {
 		return tterrors.NewBadRequestError(err)
 	}
 
-	if err = s.db.Write(
-		ctx, s.pools.id.GetStringID(ctx, req.NameSpace), s.pools.id.GetStringID(ctx, req.ID),
-		xtime.FromNormalizedTime(dp.Timestamp, d), dp.Value, unit, dp.Annotation,
-	); err != nil {
+	var (
+		nsID      = s.pools.id.GetStringID(ctx, req.NameSpace)
+		id        = s.pools.id.GetStringID(ctx, req.ID)
+		timestamp = xtime.FromNormalizedTime(dp.Timestamp, d)
+	)
+	if err := s.checkFenceID(tctx, id); err != nil {
+		s.metrics.write.ReportError(s.nowFn().Sub(callStart))
+		return convert.ToRPCError(err)
+	}
+
+	token, ok, err := s.acquireWriteToken(nsID, id, timestamp, dp)
+	if err != nil {
+		s.metrics.write.ReportError(s.nowFn().Sub(callStart))
+		return convert.ToRPCError(err)
+	}
+	if !ok {
+		// Retry of an already accepted write
+		s.metrics.write.ReportSuccess(s.nowFn().Sub(callStart))
+		return nil
+	}
+
+	err = s.db.Write(ctx, nsID, id, timestamp, dp.Value, unit, dp.Annotation)
+	token.release(err)
+	if err != nil {
 		s.metrics.write.ReportError(s.nowFn().Sub(callStart))
 		return convert.ToRPCError(err)
 	}
@@ -860,6 +1026,14 @@ func (s *service) Write(tctx thrift.Context, req *rpc.WriteRequest) error {
 }
 
 func (s *service) WriteTagged(tctx thrift.Context, req *rpc.WriteTaggedRequest) error {
+	if err := s.injectFault("writeTagged"); err != nil {
+		return err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -893,11 +1067,31 @@ func (s *service) WriteTagged(tctx thrift.Context, req *rpc.WriteTaggedRequest)
 		return tterrors.NewBadRequestError(err)
 	}
 
-	if err = s.db.WriteTagged(ctx,
-		s.pools.id.GetStringID(ctx, req.NameSpace),
-		s.pools.id.GetStringID(ctx, req.ID),
-		iter, xtime.FromNormalizedTime(dp.Timestamp, d),
-		dp.Value, unit, dp.Annotation); err != nil {
+	var (
+		nsID      = s.pools.id.GetStringID(ctx, req.NameSpace)
+		id        = s.pools.id.GetStringID(ctx, req.ID)
+		timestamp = xtime.FromNormalizedTime(dp.Timestamp, d)
+	)
+	if err := s.checkFenceID(tctx, id); err != nil {
+		s.metrics.writeTagged.ReportError(s.nowFn().Sub(callStart))
+		return convert.ToRPCError(err)
+	}
+
+	token, ok, err := s.acquireWriteToken(nsID, id, timestamp, dp)
+	if err != nil {
+		s.metrics.writeTagged.ReportError(s.nowFn().Sub(callStart))
+		return convert.ToRPCError(err)
+	}
+	if !ok {
+		// Retry of an already accepted write
+		s.metrics.writeTagged.ReportSuccess(s.nowFn().Sub(callStart))
+		return nil
+	}
+
+	err = s.db.WriteTagged(ctx, nsID, id, iter, timestamp,
+		dp.Value, unit, dp.Annotation)
+	token.release(err)
+	if err != nil {
 		s.metrics.writeTagged.ReportError(s.nowFn().Sub(callStart))
 		return convert.ToRPCError(err)
 	}
@@ -908,6 +1102,14 @@ func (s *service) WriteTagged(tctx thrift.Context, req *rpc.WriteTaggedRequest)
 }
 
 func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawRequest) error {
+	if err := s.injectFault("writeBatchRaw"); err != nil {
+		return err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -922,57 +1124,88 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 	nsID := s.newPooledID(ctx, req.NameSpace, pooledReq)
 
 	var (
-		errs               []*rpc.WriteBatchRawError
-		success            int
-		retryableErrors    int
-		nonRetryableErrors int
+		results   writeBatchRawResults
+		writes    []storage.WriteBatchEntry
+		writeIdxs []int
 	)
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
 		if unitErr != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, unitErr))
+			results.addBadRequest(i, unitErr)
 			continue
 		}
 
 		d, err := unit.Value()
 		if err != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
+			results.addBadRequest(i, err)
 			continue
 		}
 
 		seriesID := s.newPooledID(ctx, elem.ID, pooledReq)
-		if err = s.db.Write(
-			ctx, nsID, seriesID,
-			xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d),
-			elem.Datapoint.Value, unit, elem.Datapoint.Annotation,
-		); err != nil && xerrors.IsInvalidParams(err) {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
-		} else if err != nil {
-			retryableErrors++
-			errs = append(errs, tterrors.NewWriteBatchRawError(i, err))
-		} else {
-			success++
+		if err := s.checkFenceID(tctx, seriesID); err != nil {
+			results.add(i, err)
+			continue
 		}
-	}
 
-	s.metrics.writeBatchRaw.ReportSuccess(success)
-	s.metrics.writeBatchRaw.ReportRetryableErrors(retryableErrors)
-	s.metrics.writeBatchRaw.ReportNonRetryableErrors(nonRetryableErrors)
-	s.metrics.writeBatchRaw.ReportLatency(s.nowFn().Sub(callStart))
+		timestamp := xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d)
+		if elem.Datapoint.IsSetWriteSequence() {
+			// Writes with a sequence reserve their write token while being
+			// written so are written individually rather than batched
+			token, ok, err := s.acquireWriteToken(nsID, seriesID, timestamp, elem.Datapoint)
+			if err != nil {
+				results.add(i, err)
+				continue
+			}
+			if !ok {
+				// Retry of an already accepted write
+				results.add(i, nil)
+				continue
+			}
+			err = s.db.Write(ctx, nsID, seriesID, timestamp,
+				elem.Datapoint.Value, unit, elem.Datapoint.Annotation)
+			token.release(err)
+			results.add(i, err)
+			continue
+		}
 
-	if len(errs) > 0 {
-		batchErrs := rpc.NewWriteBatchRawErrors()
-		batchErrs.Errors = errs
-		return batchErrs
+		writes = append(writes, storage.WriteBatchEntry{
+			ID:         seriesID,
+			Timestamp:  timestamp,
+			Value:      elem.Datapoint.Value,
+			Unit:       unit,
+			Annotation: elem.Datapoint.Annotation,
+		})
+		writeIdxs = append(writeIdxs, i)
 	}
 
-	return nil
+	if len(writes) > 0 {
+		if err := s.db.WriteBatch(ctx, nsID, writes); err != nil {
+			for j := range writes {
+				writes[j].Err = err
+			}
+		}
+		for j, i := range writeIdxs {
+			results.add(i, writes[j].Err)
+		}
+	}
+
+	s.metrics.writeBatchRaw.ReportSuccess(results.success)
+	s.metrics.writeBatchRaw.ReportRetryableErrors(results.retryableErrors)
+	s.metrics.writeBatchRaw.ReportNonRetryableErrors(results.nonRetryableErrors)
+	s.metrics.writeBatchRaw.ReportLatency(s.nowFn().Sub(callStart))
+
+	return results.err()
 }
 
 func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedBatchRawRequest) error {
+	if err := s.injectFault("writeTaggedBatchRaw"); err != nil {
+		return err
+	}
+
+	if err := s.checkFence(tctx); err != nil {
+		return err
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -987,61 +1220,121 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 	nsID := s.newPooledID(ctx, req.NameSpace, pooledReq)
 
 	var (
-		errs               []*rpc.WriteBatchRawError
-		success            int
-		retryableErrors    int
-		nonRetryableErrors int
+		results   writeBatchRawResults
+		writes    []storage.WriteBatchEntry
+		writeIdxs []int
 	)
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
 		if unitErr != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, unitErr))
+			results.addBadRequest(i, unitErr)
 			continue
 		}
 
 		d, err := unit.Value()
 		if err != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
+			results.addBadRequest(i, err)
 			continue
 		}
 
 		dec, err := s.newPooledTagsDecoder(ctx, elem.EncodedTags, pooledReq)
 		if err != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
+			results.addBadRequest(i, err)
 			continue
 		}
 
 		seriesID := s.newPooledID(ctx, elem.ID, pooledReq)
-		if err = s.db.WriteTagged(
-			ctx, nsID, seriesID, dec,
-			xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d),
-			elem.Datapoint.Value, unit, elem.Datapoint.Annotation,
-		); err != nil && xerrors.IsInvalidParams(err) {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
-		} else if err != nil {
-			retryableErrors++
-			errs = append(errs, tterrors.NewWriteBatchRawError(i, err))
-		} else {
-			success++
+		if err := s.checkFenceID(tctx, seriesID); err != nil {
+			results.add(i, err)
+			continue
+		}
+
+		timestamp := xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d)
+		if elem.Datapoint.IsSetWriteSequence() {
+			// Writes with a sequence reserve their write token while being
+			// written so are written individually rather than batched
+			token, ok, err := s.acquireWriteToken(nsID, seriesID, timestamp, elem.Datapoint)
+			if err != nil {
+				results.add(i, err)
+				continue
+			}
+			if !ok {
+				// Retry of an already accepted write
+				results.add(i, nil)
+				continue
+			}
+			err = s.db.WriteTagged(ctx, nsID, seriesID, dec, timestamp,
+				elem.Datapoint.Value, unit, elem.Datapoint.Annotation)
+			token.release(err)
+			results.add(i, err)
+			continue
+		}
+
+		writes = append(writes, storage.WriteBatchEntry{
+			ID:         seriesID,
+			Tags:       dec,
+			Timestamp:  timestamp,
+			Value:      elem.Datapoint.Value,
+			Unit:       unit,
+			Annotation: elem.Datapoint.Annotation,
+		})
+		writeIdxs = append(writeIdxs, i)
+	}
+
+	if len(writes) > 0 {
+		if err := s.db.WriteTaggedBatch(ctx, nsID, writes); err != nil {
+			for j := range writes {
+				writes[j].Err = err
+			}
+		}
+		for j, i := range writeIdxs {
+			results.add(i, writes[j].Err)
 		}
 	}
 
-	s.metrics.writeTaggedBatchRaw.ReportSuccess(success)
-	s.metrics.writeTaggedBatchRaw.ReportRetryableErrors(retryableErrors)
-	s.metrics.writeTaggedBatchRaw.ReportNonRetryableErrors(nonRetryableErrors)
+	s.metrics.writeTaggedBatchRaw.ReportSuccess(results.success)
+	s.metrics.writeTaggedBatchRaw.ReportRetryableErrors(results.retryableErrors)
+	s.metrics.writeTaggedBatchRaw.ReportNonRetryableErrors(results.nonRetryableErrors)
 	s.metrics.writeTaggedBatchRaw.ReportLatency(s.nowFn().Sub(callStart))
 
-	if len(errs) > 0 {
-		batchErrs := rpc.NewWriteBatchRawErrors()
-		batchErrs.Errors = errs
-		return batchErrs
+	return results.err()
+}
+
+// writeBatchRawResults accumulates the results of the elements of a batch
+// write request.
+type writeBatchRawResults struct {
+	errs               []*rpc.WriteBatchRawError
+	success            int
+	retryableErrors    int
+	nonRetryableErrors int
+}
+
+func (r *writeBatchRawResults) addBadRequest(i int, err error) {
+	r.nonRetryableErrors++
+	r.errs = append(r.errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
+}
+
+func (r *writeBatchRawResults) add(i int, err error) {
+	if err != nil && xerrors.IsInvalidParams(err) {
+		r.addBadRequest(i, err)
+	} else if retryAfter, ok := m3dberrors.RetryAfter(err); ok {
+		r.retryableErrors++
+		r.errs = append(r.errs, tterrors.NewResourceExhaustedWriteBatchRawError(i, err, retryAfter))
+	} else if err != nil {
+		r.retryableErrors++
+		r.errs = append(r.errs, tterrors.NewWriteBatchRawError(i, err))
+	} else {
+		r.success++
 	}
+}
 
-	return nil
+func (r *writeBatchRawResults) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	batchErrs := rpc.NewWriteBatchRawErrors()
+	batchErrs.Errors = r.errs
+	return batchErrs
 }
 
 func (s *service) Repair(tctx thrift.Context) error {
@@ -1075,6 +1368,166 @@ func (s *service) Truncate(tctx thrift.Context, req *rpc.TruncateRequest) (r *rp
 	return res, nil
 }
 
+func (s *service) ForceFlush(
+	tctx thrift.Context,
+	req *rpc.NodeMaintenanceRequest,
+) (*rpc.NodeMaintenanceResult_, error) {
+	callStart := s.nowFn()
+	ctx := tchannelthrift.Context(tctx)
+	flushed, err := s.db.ForceFlush(s.newID(ctx, req.NameSpace), toShardIDs(req.Shards))
+	if err != nil {
+		s.metrics.forceFlush.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
+	s.metrics.forceFlush.ReportSuccess(s.nowFn().Sub(callStart))
+	return newMaintenanceResult(flushed), nil
+}
+
+func (s *service) ForceCompact(
+	tctx thrift.Context,
+	req *rpc.NodeMaintenanceRequest,
+) (*rpc.NodeMaintenanceResult_, error) {
+	if len(req.Shards) > 0 {
+		return nil, tterrors.NewBadRequestError(errForceCompactShards)
+	}
+
+	callStart := s.nowFn()
+	ctx := tchannelthrift.Context(tctx)
+	if err := s.db.ForceCompact(s.newID(ctx, req.NameSpace)); err != nil {
+		s.metrics.forceCompact.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
+	s.metrics.forceCompact.ReportSuccess(s.nowFn().Sub(callStart))
+	return newMaintenanceResult(nil), nil
+}
+
+func (s *service) Prune(
+	tctx thrift.Context,
+	req *rpc.NodeMaintenanceRequest,
+) (*rpc.NodeMaintenanceResult_, error) {
+	callStart := s.nowFn()
+	ctx := tchannelthrift.Context(tctx)
+	pruned, err := s.db.Prune(s.newID(ctx, req.NameSpace), toShardIDs(req.Shards))
+	if err != nil {
+		s.metrics.prune.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
+	s.metrics.prune.ReportSuccess(s.nowFn().Sub(callStart))
+	return newMaintenanceResult(pruned), nil
+}
+
+func toShardIDs(shards []int32) []uint32 {
+	if len(shards) == 0 {
+		return nil
+	}
+	ids := make([]uint32, 0, len(shards))
+	for _, shard := range shards {
+		ids = append(ids, uint32(shard))
+	}
+	return ids
+}
+
+func newMaintenanceResult(shards []uint32) *rpc.NodeMaintenanceResult_ {
+	res := rpc.NewNodeMaintenanceResult_()
+	res.Shards = make([]int32, 0, len(shards))
+	for _, shard := range shards {
+		res.Shards = append(res.Shards, int32(shard))
+	}
+	return res
+}
+
+func (s *service) SubscribeNewSeries(
+	tctx thrift.Context,
+	req *rpc.SubscribeNewSeriesRequest,
+) (*rpc.SubscribeNewSeriesResult_, error) {
+	callStart := s.nowFn()
+	ctx := tchannelthrift.Context(tctx)
+
+	var (
+		id    int64
+		entry *newSeriesSubscriptionEntry
+	)
+	if req.SubscriptionID == nil {
+		q, err := idx.Unmarshal(req.Query)
+		if err != nil {
+			s.metrics.subscribeNewSeries.ReportError(s.nowFn().Sub(callStart))
+			return nil, tterrors.NewBadRequestError(err)
+		}
+
+		var opts storage.NewSeriesSubscriptionOptions
+		for _, shard := range req.Shards {
+			opts.Shards = append(opts.Shards, uint32(shard))
+		}
+
+		sub, err := s.db.SubscribeNewSeries(s.newID(ctx, req.NameSpace),
+			index.Query{Query: q}, opts)
+		if err != nil {
+			s.metrics.subscribeNewSeries.ReportError(s.nowFn().Sub(callStart))
+			if xerrors.IsInvalidParams(err) {
+				return nil, tterrors.NewBadRequestError(err)
+			}
+			return nil, convert.ToRPCError(err)
+		}
+		id, entry = s.newSeriesSubs.add(sub)
+	} else {
+		id = *req.SubscriptionID
+		if req.Close != nil && *req.Close {
+			s.newSeriesSubs.remove(id)
+			s.metrics.subscribeNewSeries.ReportSuccess(s.nowFn().Sub(callStart))
+			return &rpc.SubscribeNewSeriesResult_{SubscriptionID: id}, nil
+		}
+
+		var ok bool
+		entry, ok = s.newSeriesSubs.get(id)
+		if !ok {
+			s.metrics.subscribeNewSeries.ReportError(s.nowFn().Sub(callStart))
+			return nil, tterrors.NewBadRequestError(errUnknownNewSeriesSubscription)
+		}
+	}
+
+	timeout := newSeriesSubscriptionDefaultPollTimeout
+	if req.TimeoutMillis != nil {
+		timeout = time.Duration(*req.TimeoutMillis) * time.Millisecond
+		if timeout > newSeriesSubscriptionMaxPollTimeout {
+			timeout = newSeriesSubscriptionMaxPollTimeout
+		}
+	}
+	limit := newSeriesSubscriptionDefaultPollLimit
+	if req.Limit != nil && *req.Limit > 0 {
+		limit = int(*req.Limit)
+	}
+
+	newSeries := entry.poll(timeout, limit, tctx.Done())
+	result := &rpc.SubscribeNewSeriesResult_{
+		SubscriptionID: id,
+		Elements:       make([]*rpc.NewSeriesElement, 0, len(newSeries)),
+		Dropped:        entry.sub.Dropped(),
+	}
+	tagsIter := ident.NewTagsIterator(ident.Tags{})
+	for _, series := range newSeries {
+		enc := s.pools.tagEncoder.Get()
+		ctx.RegisterFinalizer(enc)
+		tagsIter.Reset(series.Tags)
+		encodedTags, err := s.encodeTags(enc, tagsIter)
+		if err != nil { // This is an invariant, should never happen
+			s.metrics.subscribeNewSeries.ReportError(s.nowFn().Sub(callStart))
+			return nil, tterrors.NewInternalError(err)
+		}
+
+		result.Elements = append(result.Elements, &rpc.NewSeriesElement{
+			ID:          series.ID.Bytes(),
+			EncodedTags: encodedTags.Bytes(),
+			Shard:       int32(series.Shard),
+		})
+	}
+
+	s.metrics.subscribeNewSeries.ReportSuccess(s.nowFn().Sub(callStart))
+	return result, nil
+}
+
 func (s *service) GetPersistRateLimit(
 	ctx thrift.Context,
 ) (*rpc.NodePersistRateLimitResult_, error) {
@@ -1198,6 +1651,110 @@ func (s *service) SetWriteNewSeriesLimitPerShardPerSecond(
 	return s.GetWriteNewSeriesLimitPerShardPerSecond(ctx)
 }
 
+func (s *service) GetDebugSettings(
+	ctx thrift.Context,
+) (*rpc.NodeDebugSettingsResult_, error) {
+	result := &rpc.NodeDebugSettingsResult_{
+		DebugSubsystems: []string{},
+		DebugMetricsEnabled: s.db.Options().RuntimeOptionsManager().Get().
+			DebugMetricsEnabled(),
+	}
+	if s.logLevels != nil {
+		result.LogLevel = loglevel.LevelString(s.logLevels.Level())
+		result.DebugSubsystems = s.logLevels.DebugSubsystems()
+	}
+	return result, nil
+}
+
+func (s *service) SetDebugSettings(
+	ctx thrift.Context,
+	req *rpc.NodeSetDebugSettingsRequest,
+) (*rpc.NodeDebugSettingsResult_, error) {
+	if (req.LogLevel != nil || req.DebugSubsystems != nil) && s.logLevels == nil {
+		return nil, tterrors.NewBadRequestError(errLogLevelNotControlled)
+	}
+	var level *log.Level
+	if req.LogLevel != nil {
+		parsed, err := loglevel.ParseLevel(*req.LogLevel)
+		if err != nil {
+			return nil, tterrors.NewBadRequestError(err)
+		}
+		level = &parsed
+	}
+	if req.DebugSubsystems != nil {
+		if err := s.logLevels.SetDebugSubsystems(req.DebugSubsystems); err != nil {
+			return nil, tterrors.NewBadRequestError(err)
+		}
+	}
+	if level != nil {
+		s.logLevels.SetLevel(*level)
+	}
+	if req.DebugMetricsEnabled != nil {
+		runtimeOptsMgr := s.db.Options().RuntimeOptionsManager()
+		set := runtimeOptsMgr.Get().SetDebugMetricsEnabled(*req.DebugMetricsEnabled)
+		if err := runtimeOptsMgr.Update(set); err != nil {
+			return nil, tterrors.NewBadRequestError(err)
+		}
+	}
+	return s.GetDebugSettings(ctx)
+}
+
+func (s *service) injectFault(method string) error {
+	if err := s.opts.FaultInjector().Inject(fault.RPCPoint(method)); err != nil {
+		return tterrors.NewInternalError(err)
+	}
+	return nil
+}
+
+// checkFence rejects the request if the node is fenced by the placement
+// fence, the request is checked with the placement generation it was routed
+// with.
+func (s *service) checkFence(tctx thrift.Context) error {
+	fence := s.opts.PlacementFence()
+	if fence == nil {
+		return nil
+	}
+	generation := topology.PlacementGenerationFromHeaders(tctx.Headers())
+	if err := fence.Check(generation); err != nil {
+		s.metrics.fenceRejected.Inc(1)
+		return convert.ToRPCError(err)
+	}
+	return nil
+}
+
+// checkFenceShard rejects the request for the shard if the node is fenced
+// by the placement fence, or if the request was routed with an older
+// placement than the newest placement known and the node no longer owns
+// the shard.
+func (s *service) checkFenceShard(tctx thrift.Context, shard uint32) error {
+	fence := s.opts.PlacementFence()
+	if fence == nil {
+		return nil
+	}
+	generation := topology.PlacementGenerationFromHeaders(tctx.Headers())
+	if err := fence.CheckShard(generation, shard); err != nil {
+		s.metrics.fenceRejected.Inc(1)
+		return convert.ToRPCError(err)
+	}
+	return nil
+}
+
+// checkFenceID rejects the request for the series as checkFenceShard does
+// for the shard of the series, the error is returned unconverted so that
+// batch requests can reject individual elements.
+func (s *service) checkFenceID(tctx thrift.Context, id ident.ID) error {
+	fence := s.opts.PlacementFence()
+	if fence == nil {
+		return nil
+	}
+	generation := topology.PlacementGenerationFromHeaders(tctx.Headers())
+	if err := fence.CheckID(generation, id); err != nil {
+		s.metrics.fenceRejected.Inc(1)
+		return err
+	}
+	return nil
+}
+
 func (s *service) isOverloaded() bool {
 	// NB(xichen): for now we only use the database load to determine
 	// whether the server is overloaded. In the future we may also take
@@ -1205,6 +1762,32 @@ func (s *service) isOverloaded() bool {
 	return s.db.IsOverloaded()
 }
 
+// acquireWriteToken returns a token to release once the write is performed
+// and whether the write should be performed, writes without a sequence are
+// always performed.
+func (s *service) acquireWriteToken(
+	nsID ident.ID,
+	id ident.ID,
+	timestamp time.Time,
+	dp *rpc.Datapoint,
+) (writeToken, bool, error) {
+	if dp == nil || !dp.IsSetWriteSequence() {
+		return writeToken{}, true, nil
+	}
+	ns, ok := s.db.Namespace(nsID)
+	if !ok {
+		// Let the write surface the missing namespace error
+		return writeToken{}, true, nil
+	}
+	retentionOpts := ns.Options().RetentionOptions()
+	token, ok, err := s.writeTokens.acquire(nsID, id, timestamp,
+		dp.GetWriteSequence(), retentionOpts.BlockSize(), retentionOpts.BufferPast())
+	if err == nil && !ok {
+		s.metrics.writeRetries.Inc(1)
+	}
+	return token, ok, err
+}
+
 func (s *service) newID(ctx context.Context, id []byte) ident.ID {
 	checkedBytes := s.pools.checkedBytesWrapper.Get(id)
 	return s.pools.id.GetBinaryID(ctx, checkedBytes)
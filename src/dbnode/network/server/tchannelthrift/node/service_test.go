@@ -38,11 +38,16 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/loglevel"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+	xlog "github.com/m3db/m3x/log"
 	xtime "github.com/m3db/m3x/time"
 
 	"github.com/golang/mock/gomock"
@@ -74,6 +79,7 @@ func TestServiceHealth(t *testing.T) {
 
 	// Assert bootstrapped false
 	mockDB.EXPECT().IsBootstrapped().Return(false)
+	mockDB.EXPECT().IsDraining().Return(false)
 
 	tctx, _ := thrift.NewContext(time.Minute)
 	result, err := service.Health(tctx)
@@ -85,6 +91,7 @@ func TestServiceHealth(t *testing.T) {
 
 	// Assert bootstrapped true
 	mockDB.EXPECT().IsBootstrapped().Return(true)
+	mockDB.EXPECT().IsDraining().Return(false)
 
 	tctx, _ = thrift.NewContext(time.Minute)
 	result, err = service.Health(tctx)
@@ -93,6 +100,18 @@ func TestServiceHealth(t *testing.T) {
 	assert.Equal(t, true, result.Ok)
 	assert.Equal(t, "up", result.Status)
 	assert.Equal(t, true, result.Bootstrapped)
+
+	// Assert draining
+	mockDB.EXPECT().IsBootstrapped().Return(true)
+	mockDB.EXPECT().IsDraining().Return(true)
+
+	tctx, _ = thrift.NewContext(time.Minute)
+	result, err = service.Health(tctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, false, result.Ok)
+	assert.Equal(t, "draining", result.Status)
+	assert.Equal(t, true, result.Bootstrapped)
 }
 
 func TestServiceQuery(t *testing.T) {
@@ -1239,6 +1258,76 @@ func TestServiceFetchTaggedNoData(t *testing.T) {
 	}
 }
 
+func TestServiceFetchTaggedTagNames(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	service := NewService(mockDB, nil).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+	nsID := "metrics"
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	qry := index.Query{Query: req}
+
+	tagNames := [][]byte{[]byte("foo"), []byte("qux")}
+	resMap := index.NewResults(index.NewOptions())
+	resMap.Reset(ident.StringID(nsID))
+	resMap.Map().Set(ident.StringID("foo"), ident.NewTags(
+		ident.StringTag("foo", "bar"),
+		ident.StringTag("baz", "dxk"),
+		ident.StringTag("qux", "quz"),
+	))
+	mockDB.EXPECT().QueryIDs(
+		ctx,
+		ident.NewIDMatcher(nsID),
+		index.NewQueryMatcher(qry),
+		index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+			TagNames:       tagNames,
+		}).Return(index.QueryResults{Results: resMap, Exhaustive: true}, nil)
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+	r, err := service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:  []byte(nsID),
+		Query:      data,
+		RangeStart: startNanos,
+		RangeEnd:   endNanos,
+		FetchData:  false,
+		TagNames:   tagNames,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(r.Elements))
+
+	encodedTags := checked.NewBytes(r.Elements[0].EncodedTags, nil)
+	decoder := service.pools.tagDecoder.Get()
+	decoder.Reset(encodedTags)
+	expectedTags := ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("foo", "bar"),
+		ident.StringTag("qux", "quz"),
+	))
+	require.True(t, ident.NewTagIterMatcher(expectedTags).Matches(decoder))
+	decoder.Close()
+}
+
 func TestServiceFetchTaggedErrs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1327,6 +1416,55 @@ func TestServiceWrite(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestServiceWriteFenced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	topoMap := topology.NewMockMap(ctrl)
+	topoMap.EXPECT().Generation().Return(2).AnyTimes()
+	topo := topology.NewMockTopology(ctrl)
+	topo.EXPECT().Get().Return(topoMap).AnyTimes()
+
+	opts := tchannelthrift.NewOptions().
+		SetPlacementFence(topology.NewFence(topo, "testhost"))
+	service := NewService(mockDB, opts).(*service)
+
+	write := func(generation int) error {
+		tctx, _ := tchannelthrift.NewContext(time.Minute)
+		tctx = thrift.WithHeaders(tctx, topology.PlacementGenerationHeaders(generation))
+		ctx := tchannelthrift.Context(tctx)
+		defer ctx.Close()
+
+		return service.Write(tctx, &rpc.WriteRequest{
+			NameSpace: "metrics",
+			ID:        "foo",
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         time.Now().Unix(),
+				TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+				Value:             42.42,
+			},
+		})
+	}
+
+	mockDB.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	require.NoError(t, write(2))
+
+	// A write routed with a newer placement fences the node, and writes
+	// replayed with the older placement are then rejected too.
+	for _, generation := range []int{3, 2} {
+		err := write(generation)
+		require.Error(t, err)
+		rpcErr, ok := err.(*rpc.Error)
+		require.True(t, ok)
+		assert.Equal(t, rpc.ErrorType_UNAVAILABLE, rpcErr.Type)
+	}
+}
+
 func TestServiceWriteTagged(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1400,11 +1538,18 @@ func TestServiceWriteBatchRaw(t *testing.T) {
 		{"foo", time.Now().Truncate(time.Second), 12.34},
 		{"bar", time.Now().Truncate(time.Second), 42.42},
 	}
-	for _, w := range values {
-		mockDB.EXPECT().
-			Write(ctx, ident.NewIDMatcher(nsID), ident.NewIDMatcher(w.id), w.t, w.v, xtime.Second, nil).
-			Return(nil)
-	}
+	mockDB.EXPECT().
+		WriteBatch(ctx, ident.NewIDMatcher(nsID), gomock.Any()).
+		Do(func(_ context.Context, _ ident.ID, writes []storage.WriteBatchEntry) {
+			require.Equal(t, len(values), len(writes))
+			for i, w := range values {
+				assert.Equal(t, w.id, writes[i].ID.String())
+				assert.True(t, w.t.Equal(writes[i].Timestamp))
+				assert.Equal(t, w.v, writes[i].Value)
+				assert.Equal(t, xtime.Second, writes[i].Unit)
+			}
+		}).
+		Return(nil)
 
 	var elements []*rpc.WriteBatchRawRequestElement
 	for _, w := range values {
@@ -1460,13 +1605,19 @@ func TestServiceWriteTaggedBatchRaw(t *testing.T) {
 		{"foo", "a|b", time.Now().Truncate(time.Second), 12.34},
 		{"bar", "c|dd", time.Now().Truncate(time.Second), 42.42},
 	}
-	for _, w := range values {
-		mockDB.EXPECT().
-			WriteTagged(ctx, ident.NewIDMatcher(nsID), ident.NewIDMatcher(w.id),
-				mockDecoder,
-				w.t, w.v, xtime.Second, nil).
-			Return(nil)
-	}
+	mockDB.EXPECT().
+		WriteTaggedBatch(ctx, ident.NewIDMatcher(nsID), gomock.Any()).
+		Do(func(_ context.Context, _ ident.ID, writes []storage.WriteBatchEntry) {
+			require.Equal(t, len(values), len(writes))
+			for i, w := range values {
+				assert.Equal(t, w.id, writes[i].ID.String())
+				assert.Equal(t, mockDecoder, writes[i].Tags)
+				assert.True(t, w.t.Equal(writes[i].Timestamp))
+				assert.Equal(t, w.v, writes[i].Value)
+				assert.Equal(t, xtime.Second, writes[i].Unit)
+			}
+		}).
+		Return(nil)
 
 	var elements []*rpc.WriteTaggedBatchRawRequestElement
 	for _, w := range values {
@@ -1531,6 +1682,169 @@ func TestServiceTruncate(t *testing.T) {
 	assert.Equal(t, truncated, r.NumSeries)
 }
 
+func TestServiceForceFlushAndPrune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, nil).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+
+	mockDB.EXPECT().ForceFlush(ident.NewIDMatcher(nsID), []uint32{1, 3}).Return([]uint32{1, 3}, nil)
+	r, err := service.ForceFlush(tctx, &rpc.NodeMaintenanceRequest{
+		NameSpace: []byte(nsID),
+		Shards:    []int32{1, 3},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 3}, r.Shards)
+
+	mockDB.EXPECT().Prune(ident.NewIDMatcher(nsID), nil).Return([]uint32{0, 1, 2, 3}, nil)
+	r, err = service.Prune(tctx, &rpc.NodeMaintenanceRequest{NameSpace: []byte(nsID)})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{0, 1, 2, 3}, r.Shards)
+
+	mockDB.EXPECT().Prune(ident.NewIDMatcher(nsID), nil).Return(nil, fmt.Errorf("prune error"))
+	_, err = service.Prune(tctx, &rpc.NodeMaintenanceRequest{NameSpace: []byte(nsID)})
+	require.Error(t, err)
+}
+
+func TestServiceForceCompact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, nil).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+
+	mockDB.EXPECT().ForceCompact(ident.NewIDMatcher(nsID)).Return(nil)
+	r, err := service.ForceCompact(tctx, &rpc.NodeMaintenanceRequest{NameSpace: []byte(nsID)})
+	require.NoError(t, err)
+	assert.Empty(t, r.Shards)
+
+	_, err = service.ForceCompact(tctx, &rpc.NodeMaintenanceRequest{
+		NameSpace: []byte(nsID),
+		Shards:    []int32{1},
+	})
+	require.Equal(t, tterrors.NewBadRequestError(errForceCompactShards), err)
+}
+
+func TestServiceSubscribeNewSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, nil).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+	q, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	data, err := idx.Marshal(q)
+	require.NoError(t, err)
+
+	ch := make(chan storage.NewSeries, 2)
+	ch <- storage.NewSeries{
+		Shard: 3,
+		ID:    ident.StringID("foo"),
+		Tags:  ident.NewTags(ident.StringTag("foo", "bar")),
+	}
+	ch <- storage.NewSeries{
+		Shard: 1,
+		ID:    ident.StringID("baz"),
+		Tags:  ident.NewTags(ident.StringTag("foo", "baz")),
+	}
+
+	sub := storage.NewMockNewSeriesSubscription(ctrl)
+	sub.EXPECT().C().Return((<-chan storage.NewSeries)(ch)).AnyTimes()
+	sub.EXPECT().Dropped().Return(int64(2)).AnyTimes()
+	mockDB.EXPECT().
+		SubscribeNewSeries(ident.NewIDMatcher(nsID), index.Query{Query: q},
+			storage.NewSeriesSubscriptionOptions{Shards: []uint32{1, 3}}).
+		Return(sub, nil)
+
+	var (
+		limit   = int64(1)
+		timeout = int64(10)
+	)
+	r, err := service.SubscribeNewSeries(tctx, &rpc.SubscribeNewSeriesRequest{
+		NameSpace:     []byte(nsID),
+		Query:         data,
+		Shards:        []int32{1, 3},
+		TimeoutMillis: &timeout,
+		Limit:         &limit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(r.Elements))
+	assert.Equal(t, "foo", string(r.Elements[0].ID))
+	assert.Equal(t, int32(3), r.Elements[0].Shard)
+	assert.Equal(t, int64(2), r.Dropped)
+
+	decoder := service.pools.tagDecoder.Get()
+	decoder.Reset(checked.NewBytes(r.Elements[0].EncodedTags, nil))
+	expectedTags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("foo", "bar")))
+	require.True(t, ident.NewTagIterMatcher(expectedTags).Matches(decoder))
+	decoder.Close()
+
+	// Poll the remaining new series and then time out with none left.
+	subscriptionID := r.SubscriptionID
+	for _, expected := range []string{"baz", ""} {
+		r, err = service.SubscribeNewSeries(tctx, &rpc.SubscribeNewSeriesRequest{
+			NameSpace:      []byte(nsID),
+			Query:          data,
+			SubscriptionID: &subscriptionID,
+			TimeoutMillis:  &timeout,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, subscriptionID, r.SubscriptionID)
+		if expected == "" {
+			require.Equal(t, 0, len(r.Elements))
+			continue
+		}
+		require.Equal(t, 1, len(r.Elements))
+		assert.Equal(t, expected, string(r.Elements[0].ID))
+	}
+
+	// Closing removes the subscription.
+	sub.EXPECT().Close()
+	closeSub := true
+	_, err = service.SubscribeNewSeries(tctx, &rpc.SubscribeNewSeriesRequest{
+		NameSpace:      []byte(nsID),
+		Query:          data,
+		SubscriptionID: &subscriptionID,
+		Close:          &closeSub,
+	})
+	require.NoError(t, err)
+
+	_, err = service.SubscribeNewSeries(tctx, &rpc.SubscribeNewSeriesRequest{
+		NameSpace:      []byte(nsID),
+		Query:          data,
+		SubscriptionID: &subscriptionID,
+		TimeoutMillis:  &timeout,
+	})
+	rpcErr, ok := err.(*rpc.Error)
+	require.True(t, ok)
+	require.True(t, tterrors.IsBadRequestError(rpcErr))
+}
+
 func TestServiceSetPersistRateLimit(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1660,3 +1974,55 @@ func TestServiceSetWriteNewSeriesLimitPerShardPerSecond(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(84), setResp.WriteNewSeriesLimitPerShardPerSecond)
 }
+
+func TestServiceSetDebugSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	runtimeOptsMgr := runtime.NewOptionsManager()
+	opts := testStorageOpts.SetRuntimeOptionsManager(runtimeOptsMgr)
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(opts).AnyTimes()
+
+	logLevels := loglevel.NewController(xlog.LevelInfo)
+	logger := logLevels.Logger(xlog.NullLogger)
+	loglevel.Subsystem(logger, "flush")
+	iopts := instrument.NewOptions().SetLogger(logger)
+	service := NewService(mockDB,
+		tchannelthrift.NewOptions().SetInstrumentOptions(iopts)).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	getResp, err := service.GetDebugSettings(tctx)
+	require.NoError(t, err)
+	assert.Equal(t, "info", getResp.LogLevel)
+	assert.Equal(t, []string{}, getResp.DebugSubsystems)
+	assert.False(t, getResp.DebugMetricsEnabled)
+
+	logLevel := "debug"
+	debugMetricsEnabled := true
+	setResp, err := service.SetDebugSettings(tctx, &rpc.NodeSetDebugSettingsRequest{
+		LogLevel:            &logLevel,
+		DebugSubsystems:     []string{"flush"},
+		DebugMetricsEnabled: &debugMetricsEnabled,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", setResp.LogLevel)
+	assert.Equal(t, []string{"flush"}, setResp.DebugSubsystems)
+	assert.True(t, setResp.DebugMetricsEnabled)
+	assert.True(t, runtimeOptsMgr.Get().DebugMetricsEnabled())
+
+	// Invalid settings are rejected without applying any of the settings.
+	logLevel = "warn"
+	_, err = service.SetDebugSettings(tctx, &rpc.NodeSetDebugSettingsRequest{
+		LogLevel:        &logLevel,
+		DebugSubsystems: []string{"unknown"},
+	})
+	rpcErr, ok := err.(*rpc.Error)
+	require.True(t, ok)
+	assert.True(t, tterrors.IsBadRequestError(rpcErr))
+	assert.Equal(t, xlog.LevelDebug, logLevels.Level())
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/spaolacci/murmur3"
+)
+
+const (
+	writeTokensNumStripes    = 1024
+	writeTokensSweepInterval = time.Minute
+)
+
+var errWriteSequenceInFlight = errors.New("write with the same sequence is in flight")
+
+// writeTokens tracks the write sequences accepted for each series and block
+// so that writes retried after ambiguous failures are acknowledged without
+// being written again. A write is considered a retry only if a write with
+// the exact same series, block and sequence was accepted, sequences may
+// arrive in any order. Sequences are tracked until the block no longer
+// accepts writes.
+type writeTokens struct {
+	nowFn   clock.NowFn
+	stripes []writeTokensStripe
+}
+
+type writeTokensStripe struct {
+	sync.Mutex
+	lastSweep time.Time
+	tokens    map[string]map[string][]writeTokenBlock
+}
+
+type writeTokenState uint8
+
+const (
+	writeTokenInFlight writeTokenState = iota
+	writeTokenAccepted
+)
+
+type writeTokenBlock struct {
+	blockStart time.Time
+	expireAt   time.Time
+	sequences  map[int64]writeTokenState
+}
+
+// writeToken reserves the sequence of a write while the write is performed,
+// the zero value is a no-op.
+type writeToken struct {
+	stripe     *writeTokensStripe
+	namespace  string
+	id         string
+	blockStart time.Time
+	sequence   int64
+}
+
+func newWriteTokens(nowFn clock.NowFn) *writeTokens {
+	stripes := make([]writeTokensStripe, writeTokensNumStripes)
+	for i := range stripes {
+		stripes[i].tokens = make(map[string]map[string][]writeTokenBlock)
+	}
+	return &writeTokens{nowFn: nowFn, stripes: stripes}
+}
+
+// acquire reserves the sequence of a write and returns a token and true if
+// the write should be performed, or false if it is a retry of an already
+// accepted write. An error is returned if a write with the same sequence is
+// still in flight. The token must be released once the write is performed.
+func (t *writeTokens) acquire(
+	namespace, id ident.ID,
+	timestamp time.Time,
+	sequence int64,
+	blockSize, bufferPast time.Duration,
+) (writeToken, bool, error) {
+	idBytes := id.Bytes()
+	stripe := &t.stripes[murmur3.Sum32(idBytes)%uint32(len(t.stripes))]
+
+	stripe.Lock()
+	defer stripe.Unlock()
+
+	now := t.nowFn()
+	if now.Sub(stripe.lastSweep) >= writeTokensSweepInterval {
+		stripe.sweep(now)
+	}
+
+	token := writeToken{
+		stripe:     stripe,
+		namespace:  namespace.String(),
+		id:         string(idBytes),
+		blockStart: timestamp.Truncate(blockSize),
+		sequence:   sequence,
+	}
+	byID, ok := stripe.tokens[token.namespace]
+	if !ok {
+		byID = make(map[string][]writeTokenBlock)
+		stripe.tokens[token.namespace] = byID
+	}
+	blocks := byID[token.id]
+	for i := range blocks {
+		if !blocks[i].blockStart.Equal(token.blockStart) {
+			continue
+		}
+		state, ok := blocks[i].sequences[sequence]
+		if !ok {
+			blocks[i].sequences[sequence] = writeTokenInFlight
+			return token, true, nil
+		}
+		if state == writeTokenInFlight {
+			return writeToken{}, false, errWriteSequenceInFlight
+		}
+		return writeToken{}, false, nil
+	}
+	byID[token.id] = append(blocks, writeTokenBlock{
+		blockStart: token.blockStart,
+		expireAt:   token.blockStart.Add(blockSize).Add(bufferPast),
+		sequences:  map[int64]writeTokenState{sequence: writeTokenInFlight},
+	})
+	return token, true, nil
+}
+
+// release records the sequence of the token as accepted if the write
+// succeeded, otherwise clears it so that the write can be retried.
+func (t writeToken) release(err error) {
+	if t.stripe == nil {
+		return
+	}
+	t.stripe.Lock()
+	defer t.stripe.Unlock()
+
+	blocks := t.stripe.tokens[t.namespace][t.id]
+	for i := range blocks {
+		if !blocks[i].blockStart.Equal(t.blockStart) {
+			continue
+		}
+		if err != nil {
+			delete(blocks[i].sequences, t.sequence)
+			return
+		}
+		blocks[i].sequences[t.sequence] = writeTokenAccepted
+		return
+	}
+	// NB: The block was swept as it no longer accepts writes.
+}
+
+func (s *writeTokensStripe) sweep(now time.Time) {
+	s.lastSweep = now
+	for namespace, byID := range s.tokens {
+		for id, blocks := range byID {
+			remaining := blocks[:0]
+			for _, block := range blocks {
+				if now.Before(block.expireAt) {
+					remaining = append(remaining, block)
+				}
+			}
+			if len(remaining) == 0 {
+				delete(byID, id)
+				continue
+			}
+			byID[id] = remaining
+		}
+		if len(byID) == 0 {
+			delete(s.tokens, namespace)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTokensDeduplicatesRetries(t *testing.T) {
+	var (
+		now        = time.Now().Truncate(time.Hour)
+		nowFn      = func() time.Time { return now }
+		tokens     = newWriteTokens(nowFn)
+		ns         = ident.StringID("ns")
+		id         = ident.StringID("foo")
+		blockSize  = time.Hour
+		bufferPast = 10 * time.Minute
+	)
+
+	token, ok, err := tokens.acquire(ns, id, now, 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+
+	// Retry of the accepted write
+	_, ok, err = tokens.acquire(ns, id, now.Add(time.Second), 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Same sequence in another block or series
+	token, ok, err = tokens.acquire(ns, id, now.Add(blockSize), 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+	token, ok, err = tokens.acquire(ns, ident.StringID("bar"), now, 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+
+	// Failed writes do not record their sequence
+	token, ok, err = tokens.acquire(ns, id, now, 2, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(errors.New("an error"))
+	token, ok, err = tokens.acquire(ns, id, now, 2, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+	_, ok, err = tokens.acquire(ns, id, now, 2, blockSize, bufferPast)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestWriteTokensExpireWithBlock(t *testing.T) {
+	var (
+		start      = time.Now().Truncate(time.Hour)
+		now        = start
+		nowFn      = func() time.Time { return now }
+		tokens     = newWriteTokens(nowFn)
+		ns         = ident.StringID("ns")
+		id         = ident.StringID("foo")
+		blockSize  = time.Hour
+		bufferPast = 10 * time.Minute
+	)
+
+	token, ok, err := tokens.acquire(ns, id, start, 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+
+	now = start.Add(blockSize).Add(bufferPast)
+	token, ok, err = tokens.acquire(ns, id, now, 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+
+	stripe := token.stripe
+	stripe.Lock()
+	blocks := stripe.tokens["ns"]["foo"]
+	stripe.Unlock()
+	require.Equal(t, 1, len(blocks))
+	assert.True(t, blocks[0].blockStart.Equal(start.Add(blockSize)))
+}
+
+func TestWriteTokensAcceptsOutOfOrderSequences(t *testing.T) {
+	var (
+		now        = time.Now().Truncate(time.Hour)
+		nowFn      = func() time.Time { return now }
+		tokens     = newWriteTokens(nowFn)
+		ns         = ident.StringID("ns")
+		id         = ident.StringID("foo")
+		blockSize  = time.Hour
+		bufferPast = 10 * time.Minute
+	)
+
+	token, ok, err := tokens.acquire(ns, id, now, 2, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+
+	// A lower sequence arriving later is a distinct write
+	token, ok, err = tokens.acquire(ns, id, now, 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	token.release(nil)
+
+	for _, sequence := range []int64{1, 2} {
+		_, ok, err = tokens.acquire(ns, id, now, sequence, blockSize, bufferPast)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
+
+func TestWriteTokensRejectsInFlightSequence(t *testing.T) {
+	var (
+		now        = time.Now().Truncate(time.Hour)
+		nowFn      = func() time.Time { return now }
+		tokens     = newWriteTokens(nowFn)
+		ns         = ident.StringID("ns")
+		id         = ident.StringID("foo")
+		blockSize  = time.Hour
+		bufferPast = 10 * time.Minute
+	)
+
+	token, ok, err := tokens.acquire(ns, id, now, 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The stripe is not held while the write is in flight
+	other, ok, err := tokens.acquire(ns, id, now, 2, blockSize, bufferPast)
+	require.NoError(t, err)
+	require.True(t, ok)
+	other.release(nil)
+
+	_, _, err = tokens.acquire(ns, id, now, 1, blockSize, bufferPast)
+	assert.Equal(t, errWriteSequenceInFlight, err)
+
+	token.release(nil)
+	_, ok, err = tokens.acquire(ns, id, now, 1, blockSize, bufferPast)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -21,9 +21,16 @@
 package tchannelthrift
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/x/fault"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3x/instrument"
 	"github.com/m3db/m3x/pool"
+
+	"github.com/uber-go/tally"
 )
 
 type options struct {
@@ -36,6 +43,10 @@ type options struct {
 	blocksMetadataSlicePool  BlocksMetadataSlicePool
 	tagEncoderPool           serialize.TagEncoderPool
 	tagDecoderPool           serialize.TagDecoderPool
+	closeDrainTimeout        time.Duration
+	faultInjector            fault.Injector
+	latencyBuckets           tally.Buckets
+	placementFence           topology.Fence
 }
 
 // NewOptions creates new options
@@ -60,6 +71,8 @@ func NewOptions() Options {
 		blocksMetadataSlicePool:  NewBlocksMetadataSlicePool(nil, 0),
 		tagEncoderPool:           tagEncoderPool,
 		tagDecoderPool:           tagDecoderPool,
+		faultInjector:            fault.NewNoopInjector(),
+		latencyBuckets:           xmetrics.DefaultLatencyBuckets,
 	}
 }
 
@@ -152,3 +165,43 @@ func (o *options) SetTagDecoderPool(value serialize.TagDecoderPool) Options {
 func (o *options) TagDecoderPool() serialize.TagDecoderPool {
 	return o.tagDecoderPool
 }
+
+func (o *options) SetCloseDrainTimeout(value time.Duration) Options {
+	opts := *o
+	opts.closeDrainTimeout = value
+	return &opts
+}
+
+func (o *options) CloseDrainTimeout() time.Duration {
+	return o.closeDrainTimeout
+}
+
+func (o *options) SetFaultInjector(value fault.Injector) Options {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() fault.Injector {
+	return o.faultInjector
+}
+
+func (o *options) SetLatencyBuckets(value tally.Buckets) Options {
+	opts := *o
+	opts.latencyBuckets = value
+	return &opts
+}
+
+func (o *options) LatencyBuckets() tally.Buckets {
+	return o.latencyBuckets
+}
+
+func (o *options) SetPlacementFence(value topology.Fence) Options {
+	opts := *o
+	opts.placementFence = value
+	return &opts
+}
+
+func (o *options) PlacementFence() topology.Fence {
+	return o.placementFence
+}
@@ -21,8 +21,14 @@
 package tchannelthrift
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/x/fault"
 	"github.com/m3db/m3x/instrument"
+
+	"github.com/uber-go/tally"
 )
 
 // Options controls server behavior
@@ -80,4 +86,36 @@ type Options interface {
 
 	// TagDecoderPool returns the tag encoder pool
 	TagDecoderPool() serialize.TagDecoderPool
+
+	// SetCloseDrainTimeout sets how long closing the server waits for in
+	// flight calls to drain, zero closes without waiting
+	SetCloseDrainTimeout(value time.Duration) Options
+
+	// CloseDrainTimeout returns how long closing the server waits for in
+	// flight calls to drain, zero closes without waiting
+	CloseDrainTimeout() time.Duration
+
+	// SetFaultInjector sets the fault injector used by tests to delay
+	// and fail RPCs
+	SetFaultInjector(value fault.Injector) Options
+
+	// FaultInjector returns the fault injector used by tests to delay
+	// and fail RPCs
+	FaultInjector() fault.Injector
+
+	// SetLatencyBuckets sets the buckets of the per-RPC latency histograms
+	SetLatencyBuckets(value tally.Buckets) Options
+
+	// LatencyBuckets returns the buckets of the per-RPC latency histograms
+	LatencyBuckets() tally.Buckets
+
+	// SetPlacementFence sets the fence rejecting reads and writes while the
+	// placement known to the node is older than placements requests were
+	// routed with, nil disables fencing
+	SetPlacementFence(value topology.Fence) Options
+
+	// PlacementFence returns the fence rejecting reads and writes while the
+	// placement known to the node is older than placements requests were
+	// routed with
+	PlacementFence() topology.Fence
 }
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	nonceSize = 12
+	tagSize   = 16
+
+	// Overhead is the number of bytes a sealed value is larger than its
+	// plaintext, the sealed value is prefixed with a random nonce and
+	// suffixed with the authentication tag.
+	Overhead = nonceSize + tagSize
+)
+
+var (
+	errCiphertextTooShort = errors.New("ciphertext shorter than encryption overhead")
+)
+
+type gcmCipher struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+// NewCipher returns a new AES-GCM cipher for the key, the key material must
+// be 16, 24 or 32 bytes to select AES-128, AES-192 or AES-256 respectively.
+func NewCipher(key Key) (Cipher, error) {
+	if key.ID == "" {
+		return nil, errKeyIDEmpty
+	}
+	block, err := aes.NewCipher(key.Material)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %v", key.ID, err)
+	}
+	aead, err := cipher.NewGCMWithNonceSize(block, nonceSize)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmCipher{keyID: key.ID, aead: aead}, nil
+}
+
+// NewCurrentCipher returns a cipher for the current key of the provider.
+func NewCurrentCipher(provider KeyProvider) (Cipher, error) {
+	key, err := provider.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(key)
+}
+
+// NewCipherForKeyID returns a cipher for the key with the specified ID.
+func NewCipherForKeyID(provider KeyProvider, id string) (Cipher, error) {
+	key, err := provider.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(key)
+}
+
+func (c *gcmCipher) KeyID() string {
+	return c.keyID
+}
+
+func (c *gcmCipher) Seal(dst, plaintext []byte) []byte {
+	start := len(dst)
+	dst = append(dst, make([]byte, nonceSize)...)
+	nonce := dst[start:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		// The system random source failing is not recoverable.
+		panic(fmt.Errorf("unable to read random nonce: %v", err))
+	}
+	return c.aead.Seal(dst, nonce, plaintext, nil)
+}
+
+func (c *gcmCipher) Open(dst, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < Overhead {
+		return nil, errCiphertextTooShort
+	}
+	nonce, sealed := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return c.aead.Open(dst, nonce, sealed, nil)
+}
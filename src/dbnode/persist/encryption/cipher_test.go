@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(id string) Key {
+	return Key{ID: id, Material: bytes.Repeat([]byte{byte(len(id))}, 32)}
+}
+
+func TestCipherSealOpen(t *testing.T) {
+	c, err := NewCipher(testKey("foo"))
+	require.NoError(t, err)
+	require.Equal(t, "foo", c.KeyID())
+
+	plaintext := []byte("some datapoints")
+	sealed := c.Seal(nil, plaintext)
+	require.Equal(t, len(plaintext)+Overhead, len(sealed))
+	require.False(t, bytes.Contains(sealed, plaintext))
+
+	// Sealing twice must use a different nonce.
+	require.NotEqual(t, sealed, c.Seal(nil, plaintext))
+
+	opened, err := c.Open(nil, sealed)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, opened)
+}
+
+func TestCipherOpenTampered(t *testing.T) {
+	c, err := NewCipher(testKey("foo"))
+	require.NoError(t, err)
+
+	sealed := c.Seal(nil, []byte("some datapoints"))
+	sealed[len(sealed)-1] ^= 0xff
+	_, err = c.Open(nil, sealed)
+	require.Error(t, err)
+
+	_, err = c.Open(nil, sealed[:Overhead-1])
+	require.Equal(t, errCiphertextTooShort, err)
+}
+
+func TestCipherOpenWrongKey(t *testing.T) {
+	c, err := NewCipher(testKey("foo"))
+	require.NoError(t, err)
+	other, err := NewCipher(testKey("quux"))
+	require.NoError(t, err)
+
+	_, err = other.Open(nil, c.Seal(nil, []byte("some datapoints")))
+	require.Error(t, err)
+}
+
+func TestNewCipherInvalidKey(t *testing.T) {
+	_, err := NewCipher(Key{ID: "foo", Material: []byte("short")})
+	require.Error(t, err)
+
+	_, err = NewCipher(Key{Material: bytes.Repeat([]byte{1}, 32)})
+	require.Equal(t, errKeyIDEmpty, err)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	kmsKeyIDPrefix = "kms:"
+)
+
+var (
+	errKMSMasterKeyIDEmpty = errors.New("kms master key ID empty")
+	errKMSKeyIDInvalid     = errors.New("kms key ID invalid")
+)
+
+type kmsKeyProvider struct {
+	sync.RWMutex
+
+	kms            KMS
+	masterKeyID    string
+	rotationPeriod time.Duration
+	nowFn          func() time.Time
+
+	current        Key
+	currentCreated time.Time
+	keys           map[string]Key
+}
+
+// NewKMSKeyProvider returns a key provider that uses envelope encryption with
+// data keys generated by a KMS under the specified master key. The wrapped
+// data key is embedded in the key ID so data can always be decrypted by
+// asking the KMS to unwrap it, which allows the master key to be rotated
+// within the KMS without rewriting data. A new data key is generated every
+// rotation period, a zero rotation period uses a single data key for the
+// lifetime of the provider.
+func NewKMSKeyProvider(
+	kms KMS,
+	masterKeyID string,
+	rotationPeriod time.Duration,
+) (KeyProvider, error) {
+	if masterKeyID == "" {
+		return nil, errKMSMasterKeyIDEmpty
+	}
+	return &kmsKeyProvider{
+		kms:            kms,
+		masterKeyID:    masterKeyID,
+		rotationPeriod: rotationPeriod,
+		nowFn:          time.Now,
+		keys:           make(map[string]Key),
+	}, nil
+}
+
+func (p *kmsKeyProvider) CurrentKey() (Key, error) {
+	now := p.nowFn()
+
+	p.RLock()
+	current, ok := p.currentIfValidWithRLock(now)
+	p.RUnlock()
+	if ok {
+		return current, nil
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	// Check again in case another caller generated a key while
+	// waiting for the write lock.
+	if current, ok := p.currentIfValidWithRLock(now); ok {
+		return current, nil
+	}
+
+	plaintext, wrapped, err := p.kms.GenerateDataKey(p.masterKeyID)
+	if err != nil {
+		return Key{}, err
+	}
+	key := Key{
+		ID:       kmsKeyIDPrefix + base64.RawURLEncoding.EncodeToString(wrapped),
+		Material: plaintext,
+	}
+	p.current = key
+	p.currentCreated = now
+	p.keys[key.ID] = key
+	return key, nil
+}
+
+func (p *kmsKeyProvider) currentIfValidWithRLock(now time.Time) (Key, bool) {
+	if p.current.ID == "" {
+		return Key{}, false
+	}
+	if p.rotationPeriod > 0 && now.Sub(p.currentCreated) >= p.rotationPeriod {
+		return Key{}, false
+	}
+	return p.current, true
+}
+
+func (p *kmsKeyProvider) Key(id string) (Key, error) {
+	p.RLock()
+	key, ok := p.keys[id]
+	p.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if !strings.HasPrefix(id, kmsKeyIDPrefix) {
+		return Key{}, errKMSKeyIDInvalid
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(
+		strings.TrimPrefix(id, kmsKeyIDPrefix))
+	if err != nil {
+		return Key{}, errKMSKeyIDInvalid
+	}
+	plaintext, err := p.kms.Decrypt(wrapped)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key = Key{ID: id, Material: plaintext}
+	p.Lock()
+	p.keys[id] = key
+	p.Unlock()
+	return key, nil
+}
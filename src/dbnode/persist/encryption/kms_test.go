@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testKMS wraps data keys by XORing them with the master key, which is
+// sufficient to exercise the envelope handling.
+type testKMS struct {
+	masterKey    byte
+	nextKey      byte
+	generated    int
+	decrypted    int
+	decryptError error
+}
+
+func (k *testKMS) GenerateDataKey(masterKeyID string) ([]byte, []byte, error) {
+	k.generated++
+	k.nextKey++
+	plaintext := bytes.Repeat([]byte{k.nextKey}, 32)
+	return plaintext, k.xor(plaintext), nil
+}
+
+func (k *testKMS) Decrypt(wrapped []byte) ([]byte, error) {
+	k.decrypted++
+	if k.decryptError != nil {
+		return nil, k.decryptError
+	}
+	return k.xor(wrapped), nil
+}
+
+func (k *testKMS) xor(b []byte) []byte {
+	result := make([]byte, len(b))
+	for i := range b {
+		result[i] = b[i] ^ k.masterKey
+	}
+	return result
+}
+
+func TestKMSKeyProviderEnvelope(t *testing.T) {
+	kms := &testKMS{masterKey: 0x5a}
+	provider, err := NewKMSKeyProvider(kms, "master", 0)
+	require.NoError(t, err)
+
+	current, err := provider.CurrentKey()
+	require.NoError(t, err)
+	again, err := provider.CurrentKey()
+	require.NoError(t, err)
+	require.Equal(t, current, again)
+	require.Equal(t, 1, kms.generated)
+
+	// A new provider, i.e. after a restart, can resolve the key by asking
+	// the KMS to unwrap the data key embedded in the ID.
+	restarted, err := NewKMSKeyProvider(kms, "master", 0)
+	require.NoError(t, err)
+	key, err := restarted.Key(current.ID)
+	require.NoError(t, err)
+	require.Equal(t, current, key)
+	require.Equal(t, 1, kms.decrypted)
+
+	// Subsequent lookups are cached.
+	_, err = restarted.Key(current.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, kms.decrypted)
+}
+
+func TestKMSKeyProviderRotation(t *testing.T) {
+	kms := &testKMS{masterKey: 0x5a}
+	provider, err := NewKMSKeyProvider(kms, "master", time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	provider.(*kmsKeyProvider).nowFn = func() time.Time { return now }
+
+	first, err := provider.CurrentKey()
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	second, err := provider.CurrentKey()
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID)
+	require.Equal(t, 2, kms.generated)
+
+	// The rotated out key remains available for decryption.
+	key, err := provider.Key(first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first, key)
+}
+
+func TestKMSKeyProviderInvalid(t *testing.T) {
+	kms := &testKMS{decryptError: errors.New("access denied")}
+	_, err := NewKMSKeyProvider(kms, "", 0)
+	require.Equal(t, errKMSMasterKeyIDEmpty, err)
+
+	provider, err := NewKMSKeyProvider(kms, "master", 0)
+	require.NoError(t, err)
+
+	_, err = provider.Key("foo")
+	require.Equal(t, errKMSKeyIDInvalid, err)
+
+	_, err = provider.Key(kmsKeyIDPrefix + "!!!")
+	require.Equal(t, errKMSKeyIDInvalid, err)
+
+	_, err = provider.Key(kmsKeyIDPrefix + "AAAA")
+	require.Equal(t, kms.decryptError, err)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	errKeyIDEmpty    = errors.New("encryption key ID empty")
+	errNoKeys        = errors.New("no encryption keys specified")
+	errKeyIDNotFound = errors.New("encryption key ID not found")
+)
+
+type staticKeyProvider struct {
+	current Key
+	keys    map[string]Key
+}
+
+// NewStaticKeyProvider returns a key provider backed by a fixed set of keys,
+// the first key is used to encrypt new data and all keys can be used to
+// decrypt. Keys are rotated by prepending a new key and retaining the
+// previous keys until no data encrypted with them remains.
+func NewStaticKeyProvider(keys []Key) (KeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, errNoKeys
+	}
+	p := &staticKeyProvider{
+		current: keys[0],
+		keys:    make(map[string]Key, len(keys)),
+	}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errKeyIDEmpty
+		}
+		if _, ok := p.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate encryption key ID: %s", key.ID)
+		}
+		// Validate the key material up front rather than on first use.
+		if _, err := NewCipher(key); err != nil {
+			return nil, err
+		}
+		p.keys[key.ID] = key
+	}
+	return p, nil
+}
+
+// NewStaticKeyFileProvider returns a static key provider with keys read from
+// a file. Each line of the file holds a key in the form "<id>:<hex material>",
+// blank lines and lines beginning with "#" are ignored. The key on the first
+// line is the current key.
+func NewStaticKeyFileProvider(filePath string) (KeyProvider, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var (
+		keys    []Key
+		scanner = bufio.NewScanner(fd)
+		lineNum = 0
+	)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(
+				"invalid encryption key file %s: line %d not in form <id>:<hex material>",
+				filePath, lineNum)
+		}
+		material, err := hex.DecodeString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid encryption key file %s: line %d: %v", filePath, lineNum, err)
+		}
+		keys = append(keys, Key{
+			ID:       strings.TrimSpace(parts[0]),
+			Material: material,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewStaticKeyProvider(keys)
+}
+
+func (p *staticKeyProvider) CurrentKey() (Key, error) {
+	return p.current, nil
+}
+
+func (p *staticKeyProvider) Key(id string) (Key, error) {
+	key, ok := p.keys[id]
+	if !ok {
+		return Key{}, fmt.Errorf("%v: %s", errKeyIDNotFound, id)
+	}
+	return key, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticKeyProviderRotation(t *testing.T) {
+	oldKey, newKey := testKey("old"), testKey("new")
+
+	oldProvider, err := NewStaticKeyProvider([]Key{oldKey})
+	require.NoError(t, err)
+	oldCipher, err := NewCurrentCipher(oldProvider)
+	require.NoError(t, err)
+	sealed := oldCipher.Seal(nil, []byte("some datapoints"))
+
+	rotated, err := NewStaticKeyProvider([]Key{newKey, oldKey})
+	require.NoError(t, err)
+	current, err := rotated.CurrentKey()
+	require.NoError(t, err)
+	require.Equal(t, newKey, current)
+
+	c, err := NewCipherForKeyID(rotated, oldCipher.KeyID())
+	require.NoError(t, err)
+	opened, err := c.Open(nil, sealed)
+	require.NoError(t, err)
+	require.Equal(t, []byte("some datapoints"), opened)
+
+	_, err = rotated.Key("missing")
+	require.Error(t, err)
+}
+
+func TestStaticKeyProviderInvalid(t *testing.T) {
+	_, err := NewStaticKeyProvider(nil)
+	require.Equal(t, errNoKeys, err)
+
+	_, err = NewStaticKeyProvider([]Key{testKey("foo"), testKey("foo")})
+	require.Error(t, err)
+
+	_, err = NewStaticKeyProvider([]Key{{ID: "foo", Material: []byte("short")}})
+	require.Error(t, err)
+}
+
+func TestStaticKeyFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryption")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, "keys")
+	contents := "# current key first\n" +
+		"new:" + hex.EncodeToString(bytes.Repeat([]byte{0x02}, 32)) + "\n" +
+		"\n" +
+		"old:" + hex.EncodeToString(bytes.Repeat([]byte{0x01}, 16)) + "\n"
+	require.NoError(t, ioutil.WriteFile(filePath, []byte(contents), 0600))
+
+	provider, err := NewStaticKeyFileProvider(filePath)
+	require.NoError(t, err)
+
+	current, err := provider.CurrentKey()
+	require.NoError(t, err)
+	require.Equal(t, "new", current.ID)
+	require.Equal(t, bytes.Repeat([]byte{0x02}, 32), current.Material)
+
+	old, err := provider.Key("old")
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte{0x01}, 16), old.Material)
+}
+
+func TestStaticKeyFileProviderInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryption")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, contents := range []string{
+		"",
+		"no-separator\n",
+		"foo:not-hex\n",
+	} {
+		filePath := filepath.Join(dir, "keys")
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(contents), 0600))
+		_, err := NewStaticKeyFileProvider(filePath)
+		require.Error(t, err, contents)
+	}
+
+	_, err = NewStaticKeyFileProvider(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
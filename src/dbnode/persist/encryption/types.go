@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package encryption provides authenticated encryption of persisted data
+// with pluggable key providers.
+package encryption
+
+// Key is a symmetric encryption key identified by an ID, the ID is persisted
+// alongside the data it was used to encrypt so the key can be resolved again
+// when the data is read back.
+type Key struct {
+	ID       string
+	Material []byte
+}
+
+// KeyProvider resolves the keys used to encrypt and decrypt data. Key
+// rotation is supported by changing the current key while continuing to
+// resolve older keys by their ID for as long as data encrypted with them
+// remains on disk.
+type KeyProvider interface {
+	// CurrentKey returns the key that should be used to encrypt new data.
+	CurrentKey() (Key, error)
+
+	// Key returns the key with the specified ID.
+	Key(id string) (Key, error)
+}
+
+// KMS is a key management service that generates data keys wrapped by a
+// master key that never leaves the service.
+type KMS interface {
+	// GenerateDataKey returns a new data key in plaintext and wrapped by the
+	// specified master key.
+	GenerateDataKey(masterKeyID string) (plaintext []byte, wrapped []byte, err error)
+
+	// Decrypt unwraps a data key previously returned by GenerateDataKey.
+	Decrypt(wrapped []byte) ([]byte, error)
+}
+
+// Cipher encrypts and decrypts data with a single key, it is safe for
+// concurrent use.
+type Cipher interface {
+	// KeyID returns the ID of the key used by the cipher.
+	KeyID() string
+
+	// Seal encrypts and authenticates the plaintext, appending the result to
+	// dst and returning the updated slice.
+	Seal(dst, plaintext []byte) []byte
+
+	// Open decrypts and authenticates the ciphertext, appending the result to
+	// dst and returning the updated slice.
+	Open(dst, ciphertext []byte) ([]byte, error)
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3x/ident"
+)
+
+const cloneStagingDirName = "clone"
+
+// CloneNamespaceFileSets links the complete data and index filesets of the
+// source namespace into the target namespace using hard links.
+//
+// Fileset files are never modified once written, so the linked files are
+// shared copy-on-write: blocks flushed by either namespace afterwards only
+// exist in that namespace and cleaning up the files of one namespace leaves
+// the other untouched. Linked data filesets keep the block size of the source
+// namespace until they expire from the target namespace.
+//
+// Filesets are only linked when the target namespace has no data directory
+// yet, which is created once the clone completes, so that restarts do not
+// pull in blocks the source namespace flushed after the clone. The returned
+// bool is whether the clone was performed.
+func CloneNamespaceFileSets(
+	opts Options,
+	source ident.ID,
+	target ident.ID,
+) (bool, error) {
+	var (
+		prefix        = opts.FilePathPrefix()
+		volumes       = opts.DataVolumes()
+		dirMode       = opts.NewDirectoryMode()
+		targetDataDir = NamespaceDataDirPath(prefix, target)
+	)
+	if _, err := os.Stat(targetDataDir); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+
+	// Index filesets are not striped across data volumes and can be linked in
+	// place, leftovers of an interrupted clone are removed first.
+	targetIndexDir := NamespaceIndexDataDirPath(prefix, target)
+	if err := os.RemoveAll(targetIndexDir); err != nil {
+		return false, err
+	}
+	indexFileSets, err := IndexFiles(prefix, source)
+	if err != nil {
+		return false, err
+	}
+	if err := linkFileSets(indexFileSets, targetIndexDir, dirMode); err != nil {
+		return false, fmt.Errorf("unable to link index filesets: %v", err)
+	}
+
+	// Data filesets are linked into a staging directory on the volume each
+	// shard is striped to, since hard links cannot span devices, and renamed
+	// into place once all shards are linked.
+	roots := volumes
+	if len(roots) == 0 {
+		roots = []string{prefix}
+	}
+	for _, root := range roots {
+		if err := os.RemoveAll(cloneStagingPath(root, target)); err != nil {
+			return false, err
+		}
+		if root == prefix {
+			continue
+		}
+		if err := os.RemoveAll(NamespaceDataDirPath(root, target)); err != nil {
+			return false, err
+		}
+	}
+
+	shardDirs, err := findSubDirectoriesAndPaths(NamespaceDataDirPath(prefix, source))
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	for name := range shardDirs {
+		shard, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		dataFileSets, err := DataFiles(prefix, source, uint32(shard))
+		if err != nil {
+			return false, err
+		}
+		root := prefix
+		if len(volumes) > 0 {
+			root = DataVolumeForShard(volumes, uint32(shard))
+		}
+		dir := filepath.Join(cloneStagingPath(root, target), name)
+		if err := linkFileSets(dataFileSets, dir, dirMode); err != nil {
+			return false, fmt.Errorf(
+				"unable to link shard %d data filesets: %v", shard, err)
+		}
+	}
+
+	// The data directory of the target namespace under the file path prefix
+	// marks the clone as complete, so it is renamed or created last.
+	for _, root := range roots {
+		if root == prefix {
+			continue
+		}
+		if err := renameIfExists(
+			cloneStagingPath(root, target),
+			NamespaceDataDirPath(root, target),
+			dirMode,
+		); err != nil {
+			return false, err
+		}
+	}
+	if err := renameIfExists(
+		cloneStagingPath(prefix, target),
+		targetDataDir,
+		dirMode,
+	); err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(targetDataDir, dirMode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func cloneStagingPath(root string, namespace ident.ID) string {
+	return filepath.Join(root, cloneStagingDirName, dataDirName, namespace.String())
+}
+
+// linkFileSets hard links the files of the complete filesets into the given
+// directory, the checkpoint file of each fileset is linked last so that an
+// interrupted link leaves an incomplete fileset that readers ignore.
+func linkFileSets(
+	fileSets FileSetFilesSlice,
+	dir string,
+	dirMode os.FileMode,
+) error {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+	for _, fileSet := range fileSets {
+		if !fileSet.HasCheckpointFile() {
+			continue
+		}
+		var checkpointFile string
+		for _, file := range fileSet.AbsoluteFilepaths {
+			if strings.Contains(file, checkpointFileSuffix) {
+				checkpointFile = file
+				continue
+			}
+			if err := os.Link(file, filepath.Join(dir, filepath.Base(file))); err != nil {
+				return err
+			}
+		}
+		if err := os.Link(
+			checkpointFile,
+			filepath.Join(dir, filepath.Base(checkpointFile)),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renameIfExists(src, dst string, dirMode os.FileMode) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), dirMode); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneNamespaceFileSets(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		source     = ident.StringID("source")
+		target     = ident.StringID("target")
+		blockStart = time.Now().Truncate(time.Hour)
+		opts       = testDefaultOpts.SetFilePathPrefix(dir)
+	)
+
+	// A complete fileset and an incomplete fileset without a checkpoint file
+	shardDir := ShardDataDirPath(dir, source, 0)
+	require.NoError(t, os.MkdirAll(shardDir, opts.NewDirectoryMode()))
+	for _, suffix := range []string{infoFileSuffix, dataFileSuffix, checkpointFileSuffix} {
+		createDataFile(t, shardDir, blockStart, suffix, []byte(suffix))
+	}
+	createDataFile(t, shardDir, blockStart.Add(time.Hour), infoFileSuffix, nil)
+
+	indexFileSetFileIdentifiers{
+		{
+			FileSetFileIdentifier: FileSetFileIdentifier{
+				BlockStart:         blockStart,
+				Namespace:          source,
+				FileSetContentType: persist.FileSetIndexContentType,
+			},
+			Suffix: infoFileSuffix,
+		},
+		{
+			FileSetFileIdentifier: FileSetFileIdentifier{
+				BlockStart:         blockStart,
+				Namespace:          source,
+				FileSetContentType: persist.FileSetIndexContentType,
+			},
+			Suffix: checkpointFileSuffix,
+		},
+	}.create(t, dir)
+
+	cloned, err := CloneNamespaceFileSets(opts, source, target)
+	require.NoError(t, err)
+	require.True(t, cloned)
+
+	dataFileSets, err := DataFiles(dir, target, 0)
+	require.NoError(t, err)
+	require.Len(t, dataFileSets, 1)
+	assert.True(t, dataFileSets[0].ID.BlockStart.Equal(blockStart))
+	assert.True(t, dataFileSets[0].HasCheckpointFile())
+
+	indexFileSets, err := IndexFiles(dir, target)
+	require.NoError(t, err)
+	require.Len(t, indexFileSets, 1)
+	assert.True(t, indexFileSets[0].HasCheckpointFile())
+
+	// The files are shared with the source namespace
+	sourceDataFile := filesetPathFromTime(shardDir, blockStart, dataFileSuffix)
+	targetDataFile := filesetPathFromTime(
+		ShardDataDirPath(dir, target, 0), blockStart, dataFileSuffix)
+	sourceInfo, err := os.Stat(sourceDataFile)
+	require.NoError(t, err)
+	targetInfo, err := os.Stat(targetDataFile)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(sourceInfo, targetInfo))
+
+	// Removing the files of the source namespace leaves the target untouched
+	require.NoError(t, os.RemoveAll(NamespaceDataDirPath(dir, source)))
+	assert.True(t, FileExists(targetDataFile))
+
+	// Cloning again is a no-op
+	cloned, err = CloneNamespaceFileSets(opts, source, target)
+	require.NoError(t, err)
+	assert.False(t, cloned)
+}
+
+func TestCloneNamespaceFileSetsDataVolumes(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		prefix     = filepath.Join(dir, "prefix")
+		volumes    = []string{filepath.Join(dir, "vol0"), filepath.Join(dir, "vol1")}
+		source     = ident.StringID("source")
+		target     = ident.StringID("target")
+		shards     = []uint32{0, 1}
+		blockStart = time.Now().Truncate(time.Hour)
+		opts       = testDefaultOpts.
+				SetFilePathPrefix(prefix).
+				SetDataVolumes(volumes)
+	)
+
+	require.NoError(t, StripeShardDirectories(opts, source, shards))
+	for _, shard := range shards {
+		shardDir := ShardDataDirPath(prefix, source, shard)
+		for _, suffix := range []string{infoFileSuffix, checkpointFileSuffix} {
+			createDataFile(t, shardDir, blockStart, suffix, nil)
+		}
+	}
+
+	cloned, err := CloneNamespaceFileSets(opts, source, target)
+	require.NoError(t, err)
+	require.True(t, cloned)
+	assert.True(t, FileExists(NamespaceDataDirPath(prefix, target)))
+
+	// Shards are linked on the volume they are striped to
+	require.NoError(t, StripeShardDirectories(opts, target, shards))
+	for _, shard := range shards {
+		volumeShardDir := ShardDataDirPath(volumes[shard], target, shard)
+		assert.True(t, FileExists(
+			filesetPathFromTime(volumeShardDir, blockStart, checkpointFileSuffix)))
+
+		dataFileSets, err := DataFiles(prefix, target, shard)
+		require.NoError(t, err)
+		require.Len(t, dataFileSets, 1)
+		assert.True(t, dataFileSets[0].HasCheckpointFile())
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/ts"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3x/context"
 	xlog "github.com/m3db/m3x/log"
 	xtime "github.com/m3db/m3x/time"
@@ -121,7 +122,7 @@ func NewCommitLog(opts Options) (CommitLog, error) {
 		return nil, err
 	}
 	iopts := opts.InstrumentOptions().SetMetricsScope(
-		opts.InstrumentOptions().MetricsScope().SubScope("commitlog"))
+		xmetrics.SubsystemScope(opts.InstrumentOptions().MetricsScope(), xmetrics.SubsystemCommitLog))
 	scope := iopts.MetricsScope()
 
 	commitLog := &commitLog{
@@ -21,6 +21,7 @@
 package commitlog
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -33,6 +34,7 @@ import (
 
 	"github.com/m3db/bitset"
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3x/context"
@@ -368,6 +370,83 @@ func TestCommitLogWrite(t *testing.T) {
 	assertCommitLogWritesByIterating(t, commitLog, writes)
 }
 
+func TestCommitLogWriteReportsNamespaceBytes(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+
+	writes := []testWrite{
+		{testSeries(0, "foo.bar", ident.NewTags(ident.StringTag("name1", "val1")), 127), time.Now(), 123.456, xtime.Second, []byte{1, 2, 3}, nil},
+		{testSeries(0, "foo.bar", ident.NewTags(ident.StringTag("name1", "val1")), 127), time.Now(), 456.789, xtime.Second, nil, nil},
+	}
+	writeCommitLogs(t, scope, commitLog, writes).Wait()
+	require.NoError(t, commitLog.Close())
+
+	tags := map[string]string{"namespace": "testNS"}
+	snapshot := scope.Snapshot()
+	datapoints, ok := snapshot.Counters()[tally.KeyForPrefixedStringMap("commitlog.writes.datapoints", tags)]
+	require.True(t, ok)
+	require.Equal(t, int64(2), datapoints.Value())
+
+	written, ok := snapshot.Counters()[tally.KeyForPrefixedStringMap("commitlog.writes.bytes", tags)]
+	require.True(t, ok)
+	require.True(t, written.Value() > 0)
+
+	// The gauge reports the bytes per datapoint since the previous flush.
+	bytesPerDatapoint, ok := snapshot.Gauges()[tally.KeyForPrefixedStringMap("commitlog.writes.bytes-per-datapoint", tags)]
+	require.True(t, ok)
+	require.True(t, bytesPerDatapoint.Value() > 0)
+	require.True(t, bytesPerDatapoint.Value() <= float64(written.Value()))
+}
+
+func TestCommitLogWriteWithEncryption(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	defer cleanup(t, opts)
+
+	keyProvider, err := encryption.NewStaticKeyProvider([]encryption.Key{
+		{ID: "current", Material: bytes.Repeat([]byte{1}, 32)},
+	})
+	require.NoError(t, err)
+	opts = opts.SetFilesystemOptions(opts.FilesystemOptions().
+		SetEncryptionKeyProvider(keyProvider))
+
+	commitLog := newTestCommitLog(t, opts)
+
+	writes := []testWrite{
+		{testSeries(0, "foo.bar", ident.NewTags(ident.StringTag("name1", "val1")), 127), time.Now(), 123.456, xtime.Second, []byte{1, 2, 3}, nil},
+		{testSeries(1, "foo.baz", ident.NewTags(ident.StringTag("name2", "val2")), 150), time.Now(), 456.789, xtime.Second, nil, nil},
+	}
+
+	// Call write sync
+	writeCommitLogs(t, scope, commitLog, writes).Wait()
+
+	// Close the commit log and consequently flush
+	require.NoError(t, commitLog.Close())
+
+	// Assert the series IDs are not written to disk in plaintext
+	fsopts := opts.FilesystemOptions()
+	files, err := fs.SortedCommitLogFiles(fs.CommitLogsDirPath(fsopts.FilePathPrefix()))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+	data, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(data, []byte("foo.bar")))
+
+	// Assert writes occurred by reading the commit log
+	assertCommitLogWritesByIterating(t, commitLog, writes)
+
+	// Assert the commit log cannot be read without the key provider
+	reader := newCommitLogReader(opts.SetFilesystemOptions(fsopts.
+		SetEncryptionKeyProvider(nil)), ReadAllSeriesPredicate())
+	_, _, _, err = reader.Open(files[0])
+	require.Equal(t, errCommitLogReaderKeyProviderNotSet, err)
+}
+
 func TestReadCommitLogMissingMetadata(t *testing.T) {
 	readConc := 4
 	// Make sure we're not leaking goroutines
@@ -47,14 +47,15 @@ const (
 
 	// defaultBlockSize is the default commit log block size
 	defaultBlockSize = 15 * time.Minute
-
-	// defaultReadConcurrency is the default read concurrency
-	defaultReadConcurrency = 4
 )
 
 var (
 	// defaultBacklogQueueSize is the default commit log backlog queue size
 	defaultBacklogQueueSize = 1024 * runtime.NumCPU()
+
+	// defaultReadConcurrency is the default read concurrency, one decoder
+	// per CPU so that replaying the commit log is not bound by decoding
+	defaultReadConcurrency = runtime.NumCPU()
 )
 
 var (
@@ -30,6 +30,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/dbnode/serialize"
@@ -51,6 +52,7 @@ var (
 	errCommitLogReaderIsNotReusable             = errors.New("commit log reader is not reusable")
 	errCommitLogReaderMultipleReadloops         = errors.New("commit log reader tried to open multiple readLoops, do not call Read() concurrently")
 	errCommitLogReaderMissingMetadata           = errors.New("commit log reader encountered a datapoint without corresponding metadata")
+	errCommitLogReaderKeyProviderNotSet         = errors.New("commit log reader encountered encrypted commit log but no encryption key provider set")
 )
 
 // ReadAllSeriesPredicate can be passed as the seriesPredicate for callers
@@ -115,6 +117,7 @@ type reader struct {
 	hasBeenOpened        bool
 	bgWorkersInitialized int64
 	seriesPredicate      SeriesFilterPredicate
+	cipher               encryption.Cipher
 }
 
 func newCommitLogReader(opts Options, seriesPredicate SeriesFilterPredicate) commitLogReader {
@@ -174,6 +177,18 @@ func (r *reader) Open(filePath string) (time.Time, time.Duration, int64, error)
 		r.Close()
 		return timeZero, 0, 0, err
 	}
+	if len(info.EncryptionKeyID) > 0 {
+		keyProvider := r.opts.FilesystemOptions().EncryptionKeyProvider()
+		if keyProvider == nil {
+			r.Close()
+			return timeZero, 0, 0, errCommitLogReaderKeyProviderNotSet
+		}
+		r.cipher, err = encryption.NewCipherForKeyID(keyProvider, string(info.EncryptionKeyID))
+		if err != nil {
+			r.Close()
+			return timeZero, 0, 0, err
+		}
+	}
 	start := time.Unix(0, info.Start)
 	duration := time.Duration(info.Duration)
 	index := info.Index
@@ -237,6 +252,7 @@ func (r *reader) readLoop() {
 	decoderStream := msgpack.NewDecoderStream(nil)
 
 	reusedBytes := make([]byte, 0, r.opts.FlushSize())
+	openedBytes := make([]byte, 0, r.opts.FlushSize())
 
 	for {
 		select {
@@ -244,6 +260,10 @@ func (r *reader) readLoop() {
 			return
 		default:
 			data, err := r.readChunk(reusedBytes)
+			if err == nil && r.cipher != nil {
+				openedBytes, err = r.cipher.Open(openedBytes[:0], data)
+				data = openedBytes
+			}
 			if err != nil {
 				if err == io.EOF {
 					return
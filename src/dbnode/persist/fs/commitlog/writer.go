@@ -30,13 +30,17 @@ import (
 	"github.com/m3db/bitset"
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3/src/dbnode/ts"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
 )
 
 const (
@@ -98,6 +102,22 @@ type writer struct {
 	metadataEncoder    *msgpack.Encoder
 	tagEncoder         serialize.TagEncoder
 	tagSliceIter       ident.TagsIterator
+	keyProvider        encryption.KeyProvider
+	cipher             encryption.Cipher
+	sealedBuf          []byte
+	scope              tally.Scope
+	namespaceMetrics   map[string]*writerNamespaceMetrics
+}
+
+// writerNamespaceMetrics are the metrics of the bytes written to the commit
+// log for the datapoints of a namespace, the bytes per datapoint include the
+// metadata written with the first datapoint of each series in a commit log.
+type writerNamespaceMetrics struct {
+	bytes             tally.Counter
+	datapoints        tally.Counter
+	bytesPerDatapoint tally.Gauge
+	pendingBytes      int64
+	pendingDatapoints int64
 }
 
 func newCommitLogWriter(
@@ -120,6 +140,9 @@ func newCommitLogWriter(
 		metadataEncoder:    msgpack.NewEncoder(),
 		tagEncoder:         opts.FilesystemOptions().TagEncoderPool().Get(),
 		tagSliceIter:       ident.NewTagsIterator(ident.Tags{}),
+		keyProvider:        opts.FilesystemOptions().EncryptionKeyProvider(),
+		scope:              xmetrics.SubsystemScope(opts.InstrumentOptions().MetricsScope(), xmetrics.SubsystemCommitLog),
+		namespaceMetrics:   make(map[string]*writerNamespaceMetrics),
 	}
 }
 
@@ -133,12 +156,27 @@ func (w *writer) Open(start time.Time, duration time.Duration) error {
 		return err
 	}
 
+	// The commit log is shared by all namespaces so it is encrypted whenever
+	// a key provider is set, the current key is resolved once per file so
+	// rotation takes effect with the next commit log file.
+	w.cipher = nil
+	if w.keyProvider != nil {
+		cipher, err := encryption.NewCurrentCipher(w.keyProvider)
+		if err != nil {
+			return err
+		}
+		w.cipher = cipher
+	}
+
 	filePath, index := fs.NextCommitLogsFile(w.filePathPrefix, start)
 	logInfo := schema.LogInfo{
 		Start:    start.UnixNano(),
 		Duration: int64(duration),
 		Index:    int64(index),
 	}
+	if w.cipher != nil {
+		logInfo.EncryptionKeyID = []byte(w.cipher.KeyID())
+	}
 	w.logEncoder.Reset()
 	if err := w.logEncoder.EncodeLogInfo(logInfo); err != nil {
 		return err
@@ -150,7 +188,7 @@ func (w *writer) Open(start time.Time, duration time.Duration) error {
 
 	w.chunkWriter.fd = fd
 	w.buffer.Reset(w.chunkWriter)
-	if err := w.write(w.logEncoder.Bytes()); err != nil {
+	if _, err := w.write(w.logEncoder.Bytes()); err != nil {
 		w.Close()
 		return err
 	}
@@ -219,9 +257,16 @@ func (w *writer) Write(
 	if err := w.logEncoder.EncodeLogEntry(logEntry); err != nil {
 		return err
 	}
-	if err := w.write(w.logEncoder.Bytes()); err != nil {
+	data := w.logEncoder.Bytes()
+	if w.cipher != nil {
+		w.sealedBuf = w.cipher.Seal(w.sealedBuf[:0], data)
+		data = w.sealedBuf
+	}
+	n, err := w.write(data)
+	if err != nil {
 		return err
 	}
+	w.recordWrite(series.Namespace, n)
 
 	if !seen {
 		// Record we have written this series and metadata to this commit log
@@ -231,9 +276,41 @@ func (w *writer) Write(
 }
 
 func (w *writer) Flush() error {
+	w.reportBytesPerDatapoint()
 	return w.buffer.Flush()
 }
 
+// recordWrite records the bytes written for a datapoint of a namespace.
+func (w *writer) recordWrite(namespace ident.ID, n int) {
+	m, ok := w.namespaceMetrics[string(namespace.Bytes())]
+	if !ok {
+		scope := xmetrics.NamespaceScope(w.scope, namespace.String())
+		m = &writerNamespaceMetrics{
+			bytes:             scope.Counter("writes.bytes"),
+			datapoints:        scope.Counter("writes.datapoints"),
+			bytesPerDatapoint: scope.Gauge("writes.bytes-per-datapoint"),
+		}
+		w.namespaceMetrics[namespace.String()] = m
+	}
+	m.bytes.Inc(int64(n))
+	m.datapoints.Inc(1)
+	m.pendingBytes += int64(n)
+	m.pendingDatapoints++
+}
+
+// reportBytesPerDatapoint reports the bytes per datapoint written for each
+// namespace since the last flush.
+func (w *writer) reportBytesPerDatapoint() {
+	for _, m := range w.namespaceMetrics {
+		if m.pendingDatapoints == 0 {
+			continue
+		}
+		m.bytesPerDatapoint.Update(float64(m.pendingBytes) / float64(m.pendingDatapoints))
+		m.pendingBytes = 0
+		m.pendingDatapoints = 0
+	}
+}
+
 func (w *writer) Close() error {
 	if !w.isOpen() {
 		return nil
@@ -253,7 +330,9 @@ func (w *writer) Close() error {
 	return nil
 }
 
-func (w *writer) write(data []byte) error {
+// write writes the data prefixed by its size, returning the number of
+// bytes written.
+func (w *writer) write(data []byte) (int, error) {
 	dataLen := len(data)
 	sizeLen := binary.PutUvarint(w.sizeBuffer, uint64(dataLen))
 	totalLen := sizeLen + dataLen
@@ -261,17 +340,19 @@ func (w *writer) write(data []byte) error {
 	// Avoid writing across the checksum boundary if we can avoid it
 	if w.buffer.Buffered() > 0 && totalLen > w.buffer.Available() {
 		if err := w.buffer.Flush(); err != nil {
-			return err
+			return 0, err
 		}
 		return w.write(data)
 	}
 
 	// Write size and then data
 	if _, err := w.buffer.Write(w.sizeBuffer[:sizeLen]); err != nil {
-		return err
+		return 0, err
+	}
+	if _, err := w.buffer.Write(data); err != nil {
+		return 0, err
 	}
-	_, err := w.buffer.Write(data)
-	return err
+	return totalLen, nil
 }
 
 type chunkWriter struct {
@@ -0,0 +1,202 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+)
+
+// DataVolumeForShard returns the data volume a shard is striped to, shards
+// are striped across the volumes in order by shard ID.
+func DataVolumeForShard(volumes []string, shard uint32) string {
+	if len(volumes) == 0 {
+		return ""
+	}
+	return volumes[int(shard)%len(volumes)]
+}
+
+// StripeShardDirectories stripes the data and snapshot directories of the
+// given shards of a namespace across the data volumes set in the options.
+//
+// The directory of each shard under the file path prefix is a symbolic link
+// to the directory of the shard on the data volume it is striped to, so that
+// all readers and writers resolve shard directories from the file path
+// prefix alone. Shard directories that are not yet striped, or that are
+// striped to a different volume after a volume was added or removed, have
+// their files moved to the volume the shard is now striped to. It is a no-op
+// if no data volumes are set.
+func StripeShardDirectories(
+	opts Options,
+	namespace ident.ID,
+	shards []uint32,
+) error {
+	volumes := opts.DataVolumes()
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	var (
+		prefix   = opts.FilePathPrefix()
+		dirMode  = opts.NewDirectoryMode()
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, shard := range shards {
+		volume := DataVolumeForShard(volumes, shard)
+		for _, dirPathFn := range []func(string, ident.ID, uint32) string{
+			ShardDataDirPath,
+			ShardSnapshotsDirPath,
+		} {
+			linkPath := dirPathFn(prefix, namespace, shard)
+			volumePath := dirPathFn(volume, namespace, shard)
+			if err := stripeShardDirectory(linkPath, volumePath, dirMode); err != nil {
+				multiErr = multiErr.Add(fmt.Errorf(
+					"unable to stripe shard %d directory %s to %s: %v",
+					shard, linkPath, volumePath, err))
+			}
+		}
+	}
+
+	return multiErr.FinalError()
+}
+
+func stripeShardDirectory(
+	linkPath string,
+	volumePath string,
+	dirMode os.FileMode,
+) error {
+	linkPath, volumePath = filepath.Clean(linkPath), filepath.Clean(volumePath)
+
+	info, err := os.Lstat(linkPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	switch {
+	case os.IsNotExist(err):
+		// Not yet created, only need to create the volume directory
+	case info.Mode()&os.ModeSymlink != 0:
+		currPath, err := os.Readlink(linkPath)
+		if err != nil {
+			return err
+		}
+		if filepath.Clean(currPath) == volumePath {
+			return nil
+		}
+		// Striped to a different volume, move it to the new volume
+		if err := os.Remove(linkPath); err != nil {
+			return err
+		}
+		if err := moveDirectoryFiles(currPath, volumePath, dirMode); err != nil {
+			return err
+		}
+	case info.IsDir():
+		if linkPath == volumePath {
+			return nil
+		}
+		// Not yet striped, move it to the volume
+		if err := moveDirectoryFiles(linkPath, volumePath, dirMode); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("path is not a directory or symbolic link")
+	}
+
+	if err := os.MkdirAll(volumePath, dirMode); err != nil {
+		return err
+	}
+	if linkPath == volumePath {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(linkPath), dirMode); err != nil {
+		return err
+	}
+	return os.Symlink(volumePath, linkPath)
+}
+
+// moveDirectoryFiles moves the files of a directory to another directory
+// and then removes the source directory, files are copied when they cannot
+// be renamed such as when the directories are on different devices.
+func moveDirectoryFiles(srcDir, dstDir string, dirMode os.FileMode) error {
+	if err := os.MkdirAll(dstDir, dirMode); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(srcDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		src, dst := filepath.Join(srcDir, name), filepath.Join(dstDir, name)
+		if err := os.Rename(src, dst); err == nil {
+			continue
+		}
+		if err := copyFile(src, dst); err != nil {
+			return err
+		}
+		if err := os.Remove(src); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(srcDir)
+}
+
+func copyFile(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
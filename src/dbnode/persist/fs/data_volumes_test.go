@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataVolumeForShard(t *testing.T) {
+	volumes := []string{"a", "b", "c"}
+	assert.Equal(t, "", DataVolumeForShard(nil, 1))
+	assert.Equal(t, "a", DataVolumeForShard(volumes, 0))
+	assert.Equal(t, "b", DataVolumeForShard(volumes, 1))
+	assert.Equal(t, "a", DataVolumeForShard(volumes, 3))
+}
+
+func TestStripeShardDirectories(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		prefix    = filepath.Join(dir, "prefix")
+		volumes   = []string{filepath.Join(dir, "vol0"), filepath.Join(dir, "vol1")}
+		namespace = ident.StringID("testns")
+		opts      = testDefaultOpts.SetFilePathPrefix(prefix)
+	)
+
+	// Write a file to an unstriped shard directory
+	shardDir := ShardDataDirPath(prefix, namespace, 0)
+	require.NoError(t, os.MkdirAll(shardDir, opts.NewDirectoryMode()))
+	testFile := filepath.Join(shardDir, "fileset-0-0-data.db")
+	require.NoError(t, ioutil.WriteFile(testFile, []byte("data"), opts.NewFileMode()))
+
+	// Stripe across two volumes
+	opts = opts.SetDataVolumes(volumes)
+	require.NoError(t, StripeShardDirectories(opts, namespace, []uint32{0, 1}))
+
+	for shard, volume := range volumes {
+		for _, dirPathFn := range []func(string, ident.ID, uint32) string{
+			ShardDataDirPath,
+			ShardSnapshotsDirPath,
+		} {
+			target, err := os.Readlink(dirPathFn(prefix, namespace, uint32(shard)))
+			require.NoError(t, err)
+			assert.Equal(t, dirPathFn(volume, namespace, uint32(shard)), target)
+		}
+	}
+	data, err := ioutil.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+	assert.True(t, FileExists(filepath.Join(
+		ShardDataDirPath(volumes[0], namespace, 0), "fileset-0-0-data.db")))
+
+	// Striping again is a no-op
+	require.NoError(t, StripeShardDirectories(opts, namespace, []uint32{0, 1}))
+
+	// Remove the first volume and rebalance
+	opts = opts.SetDataVolumes(volumes[1:])
+	require.NoError(t, StripeShardDirectories(opts, namespace, []uint32{0, 1}))
+
+	target, err := os.Readlink(shardDir)
+	require.NoError(t, err)
+	assert.Equal(t, ShardDataDirPath(volumes[1], namespace, 0), target)
+	data, err = ioutil.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+	assert.False(t, FileExists(ShardDataDirPath(volumes[0], namespace, 0)))
+
+	// Deleting the shard directory removes the striped directory
+	require.NoError(t, DeleteDirectories([]string{shardDir}))
+	assert.False(t, FileExists(target))
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3x/ident"
+)
+
+// DiskUsage contains the bytes on disk used by a database.
+type DiskUsage struct {
+	Namespaces     []NamespaceDiskUsage
+	CommitLogBytes int64
+}
+
+// TotalBytes returns the total bytes on disk.
+func (u DiskUsage) TotalBytes() int64 {
+	total := u.CommitLogBytes
+	for _, ns := range u.Namespaces {
+		total += ns.TotalBytes()
+	}
+	return total
+}
+
+// NamespaceDiskUsage contains the bytes on disk used by a namespace.
+type NamespaceDiskUsage struct {
+	Namespace string
+	// Shards contains the data and snapshot bytes for each shard.
+	Shards []ShardDiskUsage
+	// IndexBlocks contains the index and index snapshot bytes for each
+	// index block start.
+	IndexBlocks []BlockDiskUsage
+}
+
+// TotalBytes returns the total bytes on disk for the namespace.
+func (u NamespaceDiskUsage) TotalBytes() int64 {
+	var total int64
+	for _, shard := range u.Shards {
+		total += shard.TotalBytes()
+	}
+	for _, block := range u.IndexBlocks {
+		total += block.TotalBytes()
+	}
+	return total
+}
+
+// ShardDiskUsage contains the bytes on disk used by a shard.
+type ShardDiskUsage struct {
+	Shard  uint32
+	Blocks []BlockDiskUsage
+}
+
+// TotalBytes returns the total bytes on disk for the shard.
+func (u ShardDiskUsage) TotalBytes() int64 {
+	var total int64
+	for _, block := range u.Blocks {
+		total += block.TotalBytes()
+	}
+	return total
+}
+
+// BlockDiskUsage contains the bytes on disk used by a block start.
+type BlockDiskUsage struct {
+	BlockStart    time.Time
+	DataBytes     int64
+	IndexBytes    int64
+	SnapshotBytes int64
+}
+
+// TotalBytes returns the total bytes on disk for the block start.
+func (u BlockDiskUsage) TotalBytes() int64 {
+	return u.DataBytes + u.IndexBytes + u.SnapshotBytes
+}
+
+// ComputeDiskUsage walks the filesystem beneath a file path prefix and
+// accounts for the bytes used by data, index, snapshot and commit log files.
+func ComputeDiskUsage(filePathPrefix string) (DiskUsage, error) {
+	var result DiskUsage
+
+	namespaces, err := diskUsageNamespaces(filePathPrefix)
+	if err != nil {
+		return DiskUsage{}, err
+	}
+
+	for _, namespace := range namespaces {
+		nsUsage, err := ComputeNamespaceDiskUsage(filePathPrefix, ident.StringID(namespace))
+		if err != nil {
+			return DiskUsage{}, err
+		}
+		result.Namespaces = append(result.Namespaces, nsUsage)
+	}
+
+	commitLogs, err := SortedCommitLogFiles(CommitLogsDirPath(filePathPrefix))
+	if err != nil {
+		return DiskUsage{}, err
+	}
+	result.CommitLogBytes, err = filesBytes(commitLogs)
+	if err != nil {
+		return DiskUsage{}, err
+	}
+
+	return result, nil
+}
+
+// ComputeNamespaceDiskUsage walks the filesystem beneath a file path prefix and
+// accounts for the bytes used by the data, index and snapshot files of a
+// namespace.
+func ComputeNamespaceDiskUsage(
+	filePathPrefix string,
+	namespace ident.ID,
+) (NamespaceDiskUsage, error) {
+	result := NamespaceDiskUsage{Namespace: namespace.String()}
+
+	shards, err := diskUsageShards(filePathPrefix, namespace)
+	if err != nil {
+		return NamespaceDiskUsage{}, err
+	}
+
+	for _, shard := range shards {
+		blocks := newBlockDiskUsages()
+		err := blocks.add(filesetFilesSelector{
+			fileSetType:    persist.FileSetFlushType,
+			contentType:    persist.FileSetDataContentType,
+			filePathPrefix: filePathPrefix,
+			namespace:      namespace,
+			shard:          shard,
+			pattern:        filesetFilePattern,
+		}, func(u *BlockDiskUsage, bytes int64) { u.DataBytes += bytes })
+		if err != nil {
+			return NamespaceDiskUsage{}, err
+		}
+
+		err = blocks.add(filesetFilesSelector{
+			fileSetType:    persist.FileSetSnapshotType,
+			contentType:    persist.FileSetDataContentType,
+			filePathPrefix: filePathPrefix,
+			namespace:      namespace,
+			shard:          shard,
+			pattern:        filesetFilePattern,
+		}, func(u *BlockDiskUsage, bytes int64) { u.SnapshotBytes += bytes })
+		if err != nil {
+			return NamespaceDiskUsage{}, err
+		}
+
+		result.Shards = append(result.Shards, ShardDiskUsage{
+			Shard:  shard,
+			Blocks: blocks.sorted(),
+		})
+	}
+
+	indexBlocks := newBlockDiskUsages()
+	err = indexBlocks.add(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetIndexContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		pattern:        filesetFilePattern,
+	}, func(u *BlockDiskUsage, bytes int64) { u.IndexBytes += bytes })
+	if err != nil {
+		return NamespaceDiskUsage{}, err
+	}
+
+	err = indexBlocks.add(filesetFilesSelector{
+		fileSetType:    persist.FileSetSnapshotType,
+		contentType:    persist.FileSetIndexContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		pattern:        filesetFilePattern,
+	}, func(u *BlockDiskUsage, bytes int64) { u.SnapshotBytes += bytes })
+	if err != nil {
+		return NamespaceDiskUsage{}, err
+	}
+	result.IndexBlocks = indexBlocks.sorted()
+
+	return result, nil
+}
+
+type blockDiskUsages map[int64]*BlockDiskUsage
+
+func newBlockDiskUsages() blockDiskUsages {
+	return make(blockDiskUsages)
+}
+
+func (b blockDiskUsages) add(
+	args filesetFilesSelector,
+	fn func(u *BlockDiskUsage, bytes int64),
+) error {
+	files, err := filesetFiles(args)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		bytes, err := filesBytes(file.AbsoluteFilepaths)
+		if err != nil {
+			return err
+		}
+
+		key := file.ID.BlockStart.UnixNano()
+		usage, ok := b[key]
+		if !ok {
+			usage = &BlockDiskUsage{BlockStart: file.ID.BlockStart}
+			b[key] = usage
+		}
+		fn(usage, bytes)
+	}
+	return nil
+}
+
+func (b blockDiskUsages) sorted() []BlockDiskUsage {
+	result := make([]BlockDiskUsage, 0, len(b))
+	for _, usage := range b {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].BlockStart.Before(result[j].BlockStart)
+	})
+	return result
+}
+
+// diskUsageNamespaces returns the sorted names of all namespaces with a data,
+// snapshot or index directory.
+func diskUsageNamespaces(filePathPrefix string) ([]string, error) {
+	return unionSubDirectories(
+		DataDirPath(filePathPrefix),
+		SnapshotDirPath(filePathPrefix),
+		path.Join(filePathPrefix, indexDirName, dataDirName),
+		path.Join(filePathPrefix, indexDirName, snapshotDirName),
+	)
+}
+
+// diskUsageShards returns the sorted shards of a namespace with a data or
+// snapshot directory.
+func diskUsageShards(filePathPrefix string, namespace ident.ID) ([]uint32, error) {
+	dirs, err := unionSubDirectories(
+		NamespaceDataDirPath(filePathPrefix, namespace),
+		NamespaceSnapshotsDirPath(filePathPrefix, namespace),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]uint32, 0, len(dirs))
+	for _, dir := range dirs {
+		shard, err := strconv.ParseUint(dir, 10, 32)
+		if err != nil {
+			// Not a shard directory
+			continue
+		}
+		shards = append(shards, uint32(shard))
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i] < shards[j]
+	})
+	return shards, nil
+}
+
+func unionSubDirectories(dirPaths ...string) ([]string, error) {
+	set := make(map[string]struct{})
+	for _, dirPath := range dirPaths {
+		subDirs, err := findSubDirectoriesAndPaths(dirPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for name, subDirPath := range subDirs {
+			info, err := os.Stat(subDirPath)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				set[name] = struct{}{}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for name := range set {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func filesBytes(filePaths []string) (int64, error) {
+	var total int64
+	for _, filePath := range filePaths {
+		info, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			// File removed since listed, for instance by cleanup
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
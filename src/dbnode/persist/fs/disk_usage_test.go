@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeDiskUsage(t *testing.T) {
+	dir := createCommitLogFiles(t, 2, 1)
+	defer os.RemoveAll(dir)
+
+	commitLogs, err := SortedCommitLogFiles(CommitLogsDirPath(dir))
+	require.NoError(t, err)
+	for _, file := range commitLogs {
+		createFile(t, file, make([]byte, 5))
+	}
+
+	var (
+		blockStart = time.Unix(7200, 0)
+		shardDir   = ShardDataDirPath(dir, testNs1ID, 3)
+		snapDir    = ShardSnapshotsDirPath(dir, testNs1ID, 3)
+		indexDir   = NamespaceIndexDataDirPath(dir, testNs1ID)
+	)
+	require.NoError(t, os.MkdirAll(shardDir, 0755))
+	require.NoError(t, os.MkdirAll(snapDir, 0755))
+	require.NoError(t, os.MkdirAll(indexDir, 0755))
+
+	createDataFile(t, shardDir, blockStart, infoFileSuffix, make([]byte, 10))
+	createDataFile(t, shardDir, blockStart, dataFileSuffix, make([]byte, 100))
+	createFile(t, filesetPathFromTimeAndIndex(snapDir, blockStart, 0, dataFileSuffix),
+		make([]byte, 20))
+	createFile(t, filesetPathFromTimeAndIndex(indexDir, blockStart, 0, infoFileSuffix),
+		make([]byte, 30))
+
+	usage, err := ComputeDiskUsage(dir)
+	require.NoError(t, err)
+
+	require.Equal(t, DiskUsage{
+		Namespaces: []NamespaceDiskUsage{
+			{
+				Namespace: testNs1ID.String(),
+				Shards: []ShardDiskUsage{
+					{
+						Shard: 3,
+						Blocks: []BlockDiskUsage{
+							{BlockStart: blockStart, DataBytes: 110, SnapshotBytes: 20},
+						},
+					},
+				},
+				IndexBlocks: []BlockDiskUsage{
+					{BlockStart: blockStart, IndexBytes: 30},
+				},
+			},
+		},
+		CommitLogBytes: 10,
+	}, usage)
+	require.Equal(t, int64(170), usage.TotalBytes())
+}
+
+func TestComputeDiskUsageEmpty(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	usage, err := ComputeDiskUsage(dir)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(usage.Namespaces))
+	require.Equal(t, int64(0), usage.TotalBytes())
+}
@@ -234,6 +234,13 @@ func DeleteFiles(filePaths []string) error {
 func DeleteDirectories(dirPaths []string) error {
 	multiErr := xerrors.NewMultiError()
 	for _, dir := range dirPaths {
+		// Remove the directory on the data volume a striped directory links to
+		if volumeDir, err := os.Readlink(dir); err == nil {
+			if err := os.RemoveAll(volumeDir); err != nil {
+				detailedErr := fmt.Errorf("failed to remove dir %s: %v", volumeDir, err)
+				multiErr = multiErr.Add(detailedErr)
+			}
+		}
 		if err := os.RemoveAll(dir); err != nil {
 			detailedErr := fmt.Errorf("failed to remove dir %s: %v", dir, err)
 			multiErr = multiErr.Add(detailedErr)
@@ -614,6 +621,31 @@ func ReadIndexInfoFiles(
 	return infoFileResults
 }
 
+// DataFiles returns a slice of all the names for all the flushed data fileset
+// files for a given namespace and shard combination.
+func DataFiles(filePathPrefix string, namespace ident.ID, shard uint32) (FileSetFilesSlice, error) {
+	return filesetFiles(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetDataContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		shard:          shard,
+		pattern:        filesetFilePattern,
+	})
+}
+
+// IndexFiles returns a slice of all the names for all the flushed index
+// fileset files for a given namespace.
+func IndexFiles(filePathPrefix string, namespace ident.ID) (FileSetFilesSlice, error) {
+	return filesetFiles(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetIndexContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		pattern:        filesetFilePattern,
+	})
+}
+
 // SnapshotFiles returns a slice of all the names for all the fileset files
 // for a given namespace and shard combination.
 func SnapshotFiles(filePathPrefix string, namespace ident.ID, shard uint32) (FileSetFilesSlice, error) {
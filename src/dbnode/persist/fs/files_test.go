@@ -350,6 +350,21 @@ func TestFileSetFilesBefore(t *testing.T) {
 	}
 }
 
+func TestDataFiles(t *testing.T) {
+	shard := uint32(0)
+	numIters := 5
+	dir := createDataCheckpointFilesDir(t, testNs1ID, shard, numIters)
+	defer os.RemoveAll(dir)
+
+	files, err := DataFiles(dir, testNs1ID, shard)
+	require.NoError(t, err)
+	require.Equal(t, numIters, len(files))
+	for i, file := range files {
+		require.True(t, time.Unix(0, int64(i)).Equal(file.ID.BlockStart))
+		require.True(t, file.HasCheckpointFile())
+	}
+}
+
 func TestFileSetAt(t *testing.T) {
 	shard := uint32(0)
 	numIters := 20
@@ -28,6 +28,7 @@ const (
 	dataFileSuffix           = "data"
 	digestFileSuffix         = "digest"
 	checkpointFileSuffix     = "checkpoint"
+	dictionaryFileSuffix     = "dictionary"
 	filesetFilePrefix        = "fileset"
 	commitLogFilePrefix      = "commitlog"
 	segmentFileSetFilePrefix = "segment"
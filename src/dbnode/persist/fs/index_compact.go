@@ -0,0 +1,317 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fs"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
+	m3ninxpersist "github.com/m3db/m3/src/m3ninx/persist"
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3x/ident"
+)
+
+const (
+	// defaultIndexCompactionMinVolumes is the default minimum number of
+	// complete index fileset volumes for a block start before compacting.
+	defaultIndexCompactionMinVolumes = 2
+)
+
+var (
+	errMemSegmentOptionsNotSpecified = errors.New("mem segment options not specified")
+)
+
+type newIndexWriterFn func(Options) (IndexFileSetWriter, error)
+
+// CompactIndexFileSetsOptions is a set of options used when compacting the
+// index fileset volumes of a single namespace block start.
+type CompactIndexFileSetsOptions struct {
+	// Namespace is the namespace of the index fileset volumes to compact.
+	Namespace ident.ID
+
+	// BlockStart is the block start of the index fileset volumes to compact.
+	BlockStart time.Time
+
+	// BlockSize is the index block size of the namespace.
+	BlockSize time.Duration
+
+	// MinVolumes is the minimum number of complete volumes required for the
+	// block start to be compacted, values less than two use the default.
+	MinVolumes int
+
+	// FilesystemOptions is the filesystem options which is
+	// required for reading and writing index filesets.
+	FilesystemOptions Options
+
+	// MemSegmentOptions is the options used to build the consolidated
+	// segment which is required for compacting index filesets.
+	MemSegmentOptions mem.Options
+
+	// Unexported fields that are hooks used for testing.
+	newReaderFn            newIndexReaderFn
+	newWriterFn            newIndexWriterFn
+	newPersistentSegmentFn newPersistentSegmentFn
+}
+
+// CompactIndexFileSetsResult describes the result of compacting the index
+// fileset volumes of a single namespace block start.
+type CompactIndexFileSetsResult struct {
+	// Compacted is whether the volumes were compacted.
+	Compacted bool
+
+	// VolumesCompacted is the number of volumes merged and removed.
+	VolumesCompacted int
+
+	// VolumeIndex is the volume index of the consolidated volume.
+	VolumeIndex int
+
+	// Documents is the number of documents in the consolidated volume.
+	Documents int64
+}
+
+// CompactIndexFileSets merges all complete index fileset volumes for a
+// namespace block start into a single consolidated volume, covering the union
+// of the shards of the merged volumes, and then removes the merged volumes.
+// The consolidated volume is completely written, including its digests and
+// checkpoint file, before any of the merged volumes are removed so that a
+// failure at any point leaves a readable set of volumes on disk.
+func CompactIndexFileSets(
+	opts CompactIndexFileSetsOptions,
+) (CompactIndexFileSetsResult, error) {
+	var result CompactIndexFileSetsResult
+
+	fsOpts := opts.FilesystemOptions
+	if fsOpts == nil {
+		return result, errFilesystemOptionsNotSpecified
+	}
+	if opts.MemSegmentOptions == nil {
+		return result, errMemSegmentOptionsNotSpecified
+	}
+
+	minVolumes := opts.MinVolumes
+	if minVolumes < defaultIndexCompactionMinVolumes {
+		minVolumes = defaultIndexCompactionMinVolumes
+	}
+
+	filesets, err := IndexFileSetsAt(fsOpts.FilePathPrefix(),
+		opts.Namespace, opts.BlockStart)
+	if err != nil {
+		return result, err
+	}
+	if len(filesets) < minVolumes {
+		return result, nil
+	}
+	filesets.sortByTimeAndVolumeIndexAscending()
+
+	newReader := opts.newReaderFn
+	if newReader == nil {
+		newReader = NewIndexReader
+	}
+
+	newWriter := opts.newWriterFn
+	if newWriter == nil {
+		newWriter = NewIndexWriter
+	}
+
+	newPersistentSegment := opts.newPersistentSegmentFn
+	if newPersistentSegment == nil {
+		newPersistentSegment = m3ninxpersist.NewSegment
+	}
+
+	merged, err := mem.NewSegment(postings.ID(0), opts.MemSegmentOptions)
+	if err != nil {
+		return result, err
+	}
+	defer merged.Close()
+
+	// NB: The documents inserted into the merged segment reference the bytes
+	// of the persistent segments they were read from, so the persistent
+	// segments must remain open until the merged segment has been written.
+	var segments []segment.Segment
+	defer func() {
+		for _, seg := range segments {
+			seg.Close()
+		}
+	}()
+
+	shards := make(map[uint32]struct{})
+	for _, fileset := range filesets {
+		reader, err := newReader(fsOpts)
+		if err != nil {
+			return result, err
+		}
+
+		volumeSegments, err := readIndexFileSetVolume(reader, fileset.ID,
+			shards, newPersistentSegment, fsOpts)
+		segments = append(segments, volumeSegments...)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for _, seg := range segments {
+		if err := mergeIndexSegment(merged, seg); err != nil {
+			return result, err
+		}
+	}
+
+	if _, err := merged.Seal(); err != nil {
+		return result, err
+	}
+
+	volumeIndex := filesets[len(filesets)-1].ID.VolumeIndex + 1
+	if err := writeIndexFileSetVolume(newWriter, fsOpts, merged,
+		IndexWriterOpenOptions{
+			Identifier: FileSetFileIdentifier{
+				FileSetContentType: persist.FileSetIndexContentType,
+				Namespace:          opts.Namespace,
+				BlockStart:         opts.BlockStart,
+				VolumeIndex:        volumeIndex,
+			},
+			BlockSize:   opts.BlockSize,
+			FileSetType: persist.FileSetFlushType,
+			Shards:      shards,
+		}); err != nil {
+		return result, err
+	}
+
+	result.Compacted = true
+	result.VolumesCompacted = len(filesets)
+	result.VolumeIndex = volumeIndex
+	result.Documents = merged.Size()
+
+	var toDelete []string
+	for _, fileset := range filesets {
+		toDelete = append(toDelete, fileset.AbsoluteFilepaths...)
+	}
+	return result, DeleteFiles(toDelete)
+}
+
+func readIndexFileSetVolume(
+	reader IndexFileSetReader,
+	id FileSetFileIdentifier,
+	shards map[uint32]struct{},
+	newPersistentSegment newPersistentSegmentFn,
+	fsOpts Options,
+) ([]segment.Segment, error) {
+	defer reader.Close()
+
+	openResult, err := reader.Open(IndexReaderOpenOptions{
+		Identifier:  id,
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for shard := range openResult.Shards {
+		shards[shard] = struct{}{}
+	}
+
+	segments := make([]segment.Segment, 0, reader.SegmentFileSets())
+	for {
+		fileset, err := reader.ReadSegmentFileSet()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return segments, err
+		}
+
+		seg, err := newPersistentSegment(fileset, m3ninxfs.NewSegmentOpts{
+			PostingsListPool: fsOpts.PostingsListPool(),
+		})
+		if err != nil {
+			return segments, err
+		}
+
+		segments = append(segments, seg)
+	}
+
+	return segments, reader.Validate()
+}
+
+func mergeIndexSegment(
+	target segment.MutableSegment,
+	src segment.Segment,
+) error {
+	reader, err := src.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	iter, err := reader.AllDocs()
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for iter.Next() {
+		d := iter.Current()
+		exists, err := target.ContainsID(d.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := target.Insert(d); err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
+
+func writeIndexFileSetVolume(
+	newWriter newIndexWriterFn,
+	fsOpts Options,
+	seg segment.MutableSegment,
+	opts IndexWriterOpenOptions,
+) error {
+	writer, err := newWriter(fsOpts)
+	if err != nil {
+		return err
+	}
+
+	segmentWriter, err := m3ninxpersist.NewMutableSegmentFileSetWriterWithOpts(
+		fsOpts.IndexSegmentWriterOpts())
+	if err != nil {
+		return err
+	}
+	if err := segmentWriter.Reset(seg); err != nil {
+		return err
+	}
+
+	if err := writer.Open(opts); err != nil {
+		return err
+	}
+	if err := writer.WriteSegmentFileSet(segmentWriter); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
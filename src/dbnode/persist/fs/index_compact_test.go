@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
+	"github.com/m3db/m3/src/m3ninx/postings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestIndexVolume(
+	t *testing.T,
+	test indexWriteTestSetup,
+	fsOpts Options,
+	volumeIndex int,
+	shards map[uint32]struct{},
+	ids ...string,
+) {
+	seg, err := mem.NewSegment(postings.ID(0), mem.NewOptions())
+	require.NoError(t, err)
+	defer seg.Close()
+
+	for _, id := range ids {
+		_, err := seg.Insert(doc.Document{
+			ID: []byte(id),
+			Fields: []doc.Field{
+				{Name: []byte("name"), Value: []byte(id)},
+			},
+		})
+		require.NoError(t, err)
+	}
+	_, err = seg.Seal()
+	require.NoError(t, err)
+
+	id := test.fileSetID
+	id.VolumeIndex = volumeIndex
+	require.NoError(t, writeIndexFileSetVolume(NewIndexWriter, fsOpts, seg,
+		IndexWriterOpenOptions{
+			Identifier:  id,
+			BlockSize:   test.blockSize,
+			FileSetType: persist.FileSetFlushType,
+			Shards:      shards,
+		}))
+}
+
+func TestCompactIndexFileSets(t *testing.T) {
+	test := newIndexWriteTestSetup(t)
+	defer test.cleanup()
+
+	fsOpts := testDefaultOpts.SetFilePathPrefix(test.filePathPrefix)
+	writeTestIndexVolume(t, test, fsOpts, 0, shardsSet(1), "foo", "bar")
+	writeTestIndexVolume(t, test, fsOpts, 1, shardsSet(2), "baz")
+	writeTestIndexVolume(t, test, fsOpts, 2, shardsSet(1, 3), "foo", "qux")
+
+	result, err := CompactIndexFileSets(CompactIndexFileSetsOptions{
+		Namespace:         test.fileSetID.Namespace,
+		BlockStart:        test.blockStart,
+		BlockSize:         test.blockSize,
+		MinVolumes:        3,
+		FilesystemOptions: fsOpts,
+		MemSegmentOptions: mem.NewOptions(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, CompactIndexFileSetsResult{
+		Compacted:        true,
+		VolumesCompacted: 3,
+		VolumeIndex:      3,
+		Documents:        4,
+	}, result)
+
+	filesets, err := IndexFileSetsAt(test.filePathPrefix,
+		test.fileSetID.Namespace, test.blockStart)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(filesets))
+	require.Equal(t, 3, filesets[0].ID.VolumeIndex)
+
+	reader := newTestIndexReader(t, test.filePathPrefix)
+	openResult, err := reader.Open(IndexReaderOpenOptions{
+		Identifier:  filesets[0].ID,
+		FileSetType: persist.FileSetFlushType,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, shardsSet(1, 2, 3), openResult.Shards)
+	require.NoError(t, reader.Close())
+
+	segments, err := ReadIndexSegments(ReadIndexSegmentsOptions{
+		ReaderOptions: IndexReaderOpenOptions{
+			Identifier:  filesets[0].ID,
+			FileSetType: persist.FileSetFlushType,
+		},
+		FilesystemOptions: fsOpts,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(segments))
+	defer segments[0].Close()
+
+	for _, id := range []string{"foo", "bar", "baz", "qux"} {
+		exists, err := segments[0].ContainsID([]byte(id))
+		require.NoError(t, err)
+		assert.True(t, exists, fmt.Sprintf("missing id %s", id))
+	}
+}
+
+func TestCompactIndexFileSetsBelowMinVolumes(t *testing.T) {
+	test := newIndexWriteTestSetup(t)
+	defer test.cleanup()
+
+	fsOpts := testDefaultOpts.SetFilePathPrefix(test.filePathPrefix)
+	writeTestIndexVolume(t, test, fsOpts, 0, shardsSet(1), "foo")
+	writeTestIndexVolume(t, test, fsOpts, 1, shardsSet(2), "bar")
+
+	result, err := CompactIndexFileSets(CompactIndexFileSetsOptions{
+		Namespace:         test.fileSetID.Namespace,
+		BlockStart:        test.blockStart,
+		BlockSize:         test.blockSize,
+		MinVolumes:        3,
+		FilesystemOptions: fsOpts,
+		MemSegmentOptions: mem.NewOptions(),
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Compacted)
+
+	filesets, err := IndexFileSetsAt(test.filePathPrefix,
+		test.fileSetID.Namespace, test.blockStart)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(filesets))
+}
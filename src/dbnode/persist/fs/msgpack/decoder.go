@@ -34,6 +34,7 @@ var (
 	emptyIndexInfo              schema.IndexInfo
 	emptyIndexSummariesInfo     schema.IndexSummariesInfo
 	emptyIndexBloomFilterInfo   schema.IndexBloomFilterInfo
+	emptyIndexDictionaryInfo    schema.IndexDictionaryInfo
 	emptyIndexEntry             schema.IndexEntry
 	emptyIndexSummary           schema.IndexSummary
 	emptyIndexSummaryToken      IndexSummaryToken
@@ -231,6 +232,12 @@ func (dec *Decoder) decodeIndexInfo() schema.IndexInfo {
 		opts.numExpectedMinFields = 6
 		opts.numExpectedCurrFields = 6
 	}
+	if dec.legacy.decodeLegacyV2IndexInfo {
+		// v2 had 10 fields
+		opts.override = true
+		opts.numExpectedMinFields = 6
+		opts.numExpectedCurrFields = 10
+	}
 	numFieldsToSkip, actual, ok := dec.checkNumFieldsFor(indexInfoType, opts)
 	if !ok {
 		return emptyIndexInfo
@@ -252,6 +259,27 @@ func (dec *Decoder) decodeIndexInfo() schema.IndexInfo {
 	indexInfo.SnapshotTime = dec.decodeVarint()
 	indexInfo.FileType = persist.FileSetType(dec.decodeVarint())
 
+	if actual < 9 {
+		dec.skip(numFieldsToSkip)
+		return indexInfo
+	}
+
+	indexInfo.Dictionary = dec.decodeIndexDictionaryInfo()
+
+	if actual < 10 {
+		dec.skip(numFieldsToSkip)
+		return indexInfo
+	}
+
+	indexInfo.EncryptionKeyID, _, _ = dec.decodeBytes()
+
+	if dec.legacy.decodeLegacyV2IndexInfo || actual < 11 {
+		dec.skip(numFieldsToSkip)
+		return indexInfo
+	}
+
+	indexInfo.MinorVersion = dec.decodeVarint()
+
 	dec.skip(numFieldsToSkip)
 	return indexInfo
 }
@@ -285,6 +313,21 @@ func (dec *Decoder) decodeIndexBloomFilterInfo() schema.IndexBloomFilterInfo {
 	return indexBloomFilterInfo
 }
 
+func (dec *Decoder) decodeIndexDictionaryInfo() schema.IndexDictionaryInfo {
+	numFieldsToSkip, _, ok := dec.checkNumFieldsFor(indexDictionaryInfoType, checkNumFieldsOptions{})
+	if !ok {
+		return emptyIndexDictionaryInfo
+	}
+	var indexDictionaryInfo schema.IndexDictionaryInfo
+	indexDictionaryInfo.Size = dec.decodeVarint()
+	indexDictionaryInfo.Checksum = dec.decodeVarint()
+	dec.skip(numFieldsToSkip)
+	if dec.err != nil {
+		return emptyIndexDictionaryInfo
+	}
+	return indexDictionaryInfo
+}
+
 func (dec *Decoder) decodeIndexEntry() schema.IndexEntry {
 	var opts checkNumFieldsOptions
 	if dec.legacy.decodeLegacyV1IndexInfo {
@@ -345,7 +388,7 @@ func (dec *Decoder) decodeIndexSummary() (schema.IndexSummary, IndexSummaryToken
 }
 
 func (dec *Decoder) decodeLogInfo() schema.LogInfo {
-	numFieldsToSkip, _, ok := dec.checkNumFieldsFor(logInfoType, checkNumFieldsOptions{})
+	numFieldsToSkip, actual, ok := dec.checkNumFieldsFor(logInfoType, checkNumFieldsOptions{})
 	if !ok {
 		return emptyLogInfo
 	}
@@ -353,6 +396,9 @@ func (dec *Decoder) decodeLogInfo() schema.LogInfo {
 	logInfo.Start = dec.decodeVarint()
 	logInfo.Duration = dec.decodeVarint()
 	logInfo.Index = dec.decodeVarint()
+	if actual >= 4 {
+		logInfo.EncryptionKeyID, _, _ = dec.decodeBytes()
+	}
 	dec.skip(numFieldsToSkip)
 	if dec.err != nil {
 		return emptyLogInfo
@@ -55,15 +55,19 @@ type Encoder struct {
 
 type legacyEncodingOptions struct {
 	encodeLegacyV1IndexInfo  bool
+	encodeLegacyV2IndexInfo  bool
 	encodeLegacyV1IndexEntry bool
 	decodeLegacyV1IndexInfo  bool
+	decodeLegacyV2IndexInfo  bool
 	decodeLegacyV1IndexEntry bool
 }
 
 var defaultlegacyEncodingOptions = legacyEncodingOptions{
 	encodeLegacyV1IndexInfo:  false,
+	encodeLegacyV2IndexInfo:  false,
 	encodeLegacyV1IndexEntry: false,
 	decodeLegacyV1IndexInfo:  false,
+	decodeLegacyV2IndexInfo:  false,
 	decodeLegacyV1IndexEntry: false,
 }
 
@@ -108,10 +112,13 @@ func (enc *Encoder) EncodeIndexInfo(info schema.IndexInfo) error {
 		return enc.err
 	}
 	enc.encodeRootObject(indexInfoVersion, indexInfoType)
-	if enc.legacy.encodeLegacyV1IndexInfo {
+	switch {
+	case enc.legacy.encodeLegacyV1IndexInfo:
 		enc.encodeIndexInfoV1(info)
-	} else {
+	case enc.legacy.encodeLegacyV2IndexInfo:
 		enc.encodeIndexInfoV2(info)
+	default:
+		enc.encodeIndexInfoV3(info)
 	}
 	return enc.err
 }
@@ -183,7 +190,24 @@ func (enc *Encoder) encodeIndexInfoV1(info schema.IndexInfo) {
 	enc.encodeIndexBloomFilterInfo(info.BloomFilter)
 }
 
+// We only keep this method around for the sake of testing
+// backwards-compatbility
 func (enc *Encoder) encodeIndexInfoV2(info schema.IndexInfo) {
+	// Manually encode num fields for testing purposes
+	enc.encodeArrayLenFn(10) // v2 had 10 fields
+	enc.encodeVarintFn(info.BlockStart)
+	enc.encodeVarintFn(info.BlockSize)
+	enc.encodeVarintFn(info.Entries)
+	enc.encodeVarintFn(info.MajorVersion)
+	enc.encodeIndexSummariesInfo(info.Summaries)
+	enc.encodeIndexBloomFilterInfo(info.BloomFilter)
+	enc.encodeVarintFn(info.SnapshotTime)
+	enc.encodeVarintFn(int64(info.FileType))
+	enc.encodeIndexDictionaryInfo(info.Dictionary)
+	enc.encodeBytesFn(info.EncryptionKeyID)
+}
+
+func (enc *Encoder) encodeIndexInfoV3(info schema.IndexInfo) {
 	enc.encodeNumObjectFieldsForFn(indexInfoType)
 	enc.encodeVarintFn(info.BlockStart)
 	enc.encodeVarintFn(info.BlockSize)
@@ -193,6 +217,9 @@ func (enc *Encoder) encodeIndexInfoV2(info schema.IndexInfo) {
 	enc.encodeIndexBloomFilterInfo(info.BloomFilter)
 	enc.encodeVarintFn(info.SnapshotTime)
 	enc.encodeVarintFn(int64(info.FileType))
+	enc.encodeIndexDictionaryInfo(info.Dictionary)
+	enc.encodeBytesFn(info.EncryptionKeyID)
+	enc.encodeVarintFn(info.MinorVersion)
 }
 
 func (enc *Encoder) encodeIndexSummariesInfo(info schema.IndexSummariesInfo) {
@@ -206,6 +233,12 @@ func (enc *Encoder) encodeIndexBloomFilterInfo(info schema.IndexBloomFilterInfo)
 	enc.encodeVarintFn(info.NumHashesK)
 }
 
+func (enc *Encoder) encodeIndexDictionaryInfo(info schema.IndexDictionaryInfo) {
+	enc.encodeNumObjectFieldsForFn(indexDictionaryInfoType)
+	enc.encodeVarintFn(info.Size)
+	enc.encodeVarintFn(info.Checksum)
+}
+
 // We only keep this method around for the sake of testing
 // backwards-compatbility
 func (enc *Encoder) encodeIndexEntryV1(entry schema.IndexEntry) {
@@ -240,6 +273,7 @@ func (enc *Encoder) encodeLogInfo(info schema.LogInfo) {
 	enc.encodeVarintFn(info.Start)
 	enc.encodeVarintFn(info.Duration)
 	enc.encodeVarintFn(info.Index)
+	enc.encodeBytesFn(info.EncryptionKeyID)
 }
 
 func (enc *Encoder) encodeLogEntry(entry schema.LogEntry) {
@@ -64,6 +64,7 @@ func testExpectedResultForIndexInfo(t *testing.T, indexInfo schema.IndexInfo) []
 	_, currIndexInfo := numFieldsForType(indexInfoType)
 	_, currSummariesInfo := numFieldsForType(indexSummariesInfoType)
 	_, currIndexBloomFilterInfo := numFieldsForType(indexBloomFilterInfoType)
+	_, currIndexDictionaryInfo := numFieldsForType(indexDictionaryInfoType)
 	return []interface{}{
 		int64(indexInfoVersion),
 		currRoot,
@@ -80,6 +81,10 @@ func testExpectedResultForIndexInfo(t *testing.T, indexInfo schema.IndexInfo) []
 		indexInfo.BloomFilter.NumHashesK,
 		indexInfo.SnapshotTime,
 		int64(indexInfo.FileType),
+		currIndexDictionaryInfo,
+		indexInfo.Dictionary.Size,
+		indexInfo.Dictionary.Checksum,
+		indexInfo.EncryptionKeyID,
 	}
 }
 
@@ -111,6 +116,7 @@ func testExpectedResultForLogInfo(t *testing.T, logInfo schema.LogInfo) []interf
 		logInfo.Start,
 		logInfo.Duration,
 		logInfo.Index,
+		logInfo.EncryptionKeyID,
 	}
 }
 
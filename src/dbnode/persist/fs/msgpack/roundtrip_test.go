@@ -98,6 +98,37 @@ func TestIndexInfoRoundtrip(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
+func TestIndexInfoWithDictionaryRoundtrip(t *testing.T) {
+	var (
+		enc  = NewEncoder()
+		dec  = NewDecoder(nil)
+		info = testIndexInfo
+	)
+	info.Dictionary = schema.IndexDictionaryInfo{
+		Size:     65536,
+		Checksum: 3724287213,
+	}
+	require.NoError(t, enc.EncodeIndexInfo(info))
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, info, res)
+}
+
+func TestIndexInfoWithEncryptionKeyIDRoundtrip(t *testing.T) {
+	var (
+		enc  = NewEncoder()
+		dec  = NewDecoder(nil)
+		info = testIndexInfo
+	)
+	info.EncryptionKeyID = []byte("testEncryptionKeyID")
+	require.NoError(t, enc.EncodeIndexInfo(info))
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, info, res)
+}
+
 // Make sure the new decoding code can handle the old file format
 func TestIndexInfoRoundTripBackwardsCompatibilityV1(t *testing.T) {
 	var (
@@ -157,6 +188,67 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
+func TestIndexInfoWithMinorVersionRoundtrip(t *testing.T) {
+	var (
+		enc  = NewEncoder()
+		dec  = NewDecoder(nil)
+		info = testIndexInfo
+	)
+	info.MinorVersion = schema.MinorVersion
+	require.NoError(t, enc.EncodeIndexInfo(info))
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, info, res)
+	require.Equal(t, schema.CurrentFormatVersion, res.FormatVersion())
+}
+
+// Make sure the new decoding code can handle files written before the
+// minor version was encoded
+func TestIndexInfoRoundTripBackwardsCompatibilityV2(t *testing.T) {
+	var (
+		opts = legacyEncodingOptions{encodeLegacyV2IndexInfo: true}
+		enc  = newEncoder(opts)
+		dec  = newDecoder(opts, nil)
+		info = testIndexInfo
+	)
+	info.EncryptionKeyID = []byte("testEncryptionKeyID")
+	info.MinorVersion = schema.MinorVersion
+
+	enc.EncodeIndexInfo(info)
+
+	// The minor version did not exist in V2 so it decodes as zero
+	info.MinorVersion = 0
+
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, info, res)
+}
+
+// Make sure the old decoder code can handle the file format with the minor
+// version
+func TestIndexInfoRoundTripForwardsCompatibilityV3(t *testing.T) {
+	var (
+		opts = legacyEncodingOptions{decodeLegacyV2IndexInfo: true}
+		enc  = newEncoder(opts)
+		dec  = newDecoder(opts, nil)
+		info = testIndexInfo
+	)
+	info.EncryptionKeyID = []byte("testEncryptionKeyID")
+	info.MinorVersion = schema.MinorVersion
+
+	enc.EncodeIndexInfo(info)
+
+	// The old decoder won't read the minor version
+	info.MinorVersion = 0
+
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, info, res)
+}
+
 func TestIndexEntryRoundtrip(t *testing.T) {
 	var (
 		enc = NewEncoder()
@@ -246,6 +338,20 @@ func TestLogInfoRoundtrip(t *testing.T) {
 	require.Equal(t, testLogInfo, res)
 }
 
+func TestLogInfoWithEncryptionKeyIDRoundtrip(t *testing.T) {
+	var (
+		enc  = NewEncoder()
+		dec  = NewDecoder(nil)
+		info = testLogInfo
+	)
+	info.EncryptionKeyID = []byte("testEncryptionKeyID")
+	require.NoError(t, enc.EncodeLogInfo(info))
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeLogInfo()
+	require.NoError(t, err)
+	require.Equal(t, info, res)
+}
+
 func TestLogEntryRoundtrip(t *testing.T) {
 	var (
 		enc = NewEncoder()
@@ -70,6 +70,7 @@ const (
 	logInfoType
 	logEntryType
 	logMetadataType
+	indexDictionaryInfoType
 
 	// Total number of object types
 	numObjectTypes = iota
@@ -93,20 +94,22 @@ const (
 	minNumLogInfoFields              = 3
 	minNumLogEntryFields             = 7
 	minNumLogMetadataFields          = 3
+	minNumIndexDictionaryInfoFields  = 2
 
 	// curr number of fields specifies the number of fields that the current
 	// version of the M3DB will encode. This is used to ensure that the
 	// correct number of fields is encoded into the files. These values need
 	// to be incremened whenever we add new fields to an object.
 	currNumRootObjectFields           = 2
-	currNumIndexInfoFields            = 8
+	currNumIndexInfoFields            = 11
 	currNumIndexSummariesInfoFields   = 1
 	currNumIndexBloomFilterInfoFields = 2
 	currNumIndexEntryFields           = 6
 	currNumIndexSummaryFields         = 3
-	currNumLogInfoFields              = 3
+	currNumLogInfoFields              = 4
 	currNumLogEntryFields             = 7
 	currNumLogMetadataFields          = 3
+	currNumIndexDictionaryInfoFields  = 2
 )
 
 var minNumObjectFields []int
@@ -137,6 +140,7 @@ func init() {
 	setMinNumObjectFieldsForType(logInfoType, minNumLogInfoFields)
 	setMinNumObjectFieldsForType(logEntryType, minNumLogEntryFields)
 	setMinNumObjectFieldsForType(logMetadataType, minNumLogMetadataFields)
+	setMinNumObjectFieldsForType(indexDictionaryInfoType, minNumIndexDictionaryInfoFields)
 
 	// Verify all current values are larger than their respective minimum values
 	mustBeGreaterThanOrEqual(currNumRootObjectFields, minNumRootObjectFields)
@@ -148,6 +152,7 @@ func init() {
 	mustBeGreaterThanOrEqual(currNumLogInfoFields, minNumLogInfoFields)
 	mustBeGreaterThanOrEqual(currNumLogEntryFields, minNumLogEntryFields)
 	mustBeGreaterThanOrEqual(currNumLogMetadataFields, minNumLogMetadataFields)
+	mustBeGreaterThanOrEqual(currNumIndexDictionaryInfoFields, minNumIndexDictionaryInfoFields)
 
 	setCurrNumObjectFieldsForType(rootObjectType, currNumRootObjectFields)
 	setCurrNumObjectFieldsForType(indexInfoType, currNumIndexInfoFields)
@@ -158,6 +163,7 @@ func init() {
 	setCurrNumObjectFieldsForType(logInfoType, currNumLogInfoFields)
 	setCurrNumObjectFieldsForType(logEntryType, currNumLogEntryFields)
 	setCurrNumObjectFieldsForType(logMetadataType, currNumLogMetadataFields)
+	setCurrNumObjectFieldsForType(indexDictionaryInfoType, currNumIndexDictionaryInfoFields)
 }
 
 func mustBeGreaterThanOrEqual(x, y int) {
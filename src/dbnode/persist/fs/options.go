@@ -26,9 +26,12 @@ import (
 	"os"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/x/fault"
+	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fs"
 	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3/src/m3ninx/postings/roaring"
 	"github.com/m3db/m3x/instrument"
@@ -76,6 +79,7 @@ type options struct {
 	runtimeOptsMgr                       runtime.OptionsManager
 	decodingOpts                         msgpack.DecodingOptions
 	filePathPrefix                       string
+	dataVolumes                          []string
 	newFileMode                          os.FileMode
 	newDirectoryMode                     os.FileMode
 	indexSummariesPercent                float64
@@ -89,6 +93,9 @@ type options struct {
 	tagEncoderPool                       serialize.TagEncoderPool
 	tagDecoderPool                       serialize.TagDecoderPool
 	postingsPool                         postings.Pool
+	faultInjector                        fault.Injector
+	encryptionKeyProvider                encryption.KeyProvider
+	indexSegmentWriterOpts               m3ninxfs.NewWriterOpts
 }
 
 // NewOptions creates a new set of fs options
@@ -121,6 +128,7 @@ func NewOptions() Options {
 		tagEncoderPool:                       tagEncoderPool,
 		tagDecoderPool:                       tagDecoderPool,
 		postingsPool:                         postingsPool,
+		faultInjector:                        fault.NewNoopInjector(),
 	}
 }
 
@@ -141,6 +149,9 @@ func (o *options) Validate() error {
 	if o.tagDecoderPool == nil {
 		return errTagDecoderPoolNotSet
 	}
+	if err := o.indexSegmentWriterOpts.Validate(); err != nil {
+		return fmt.Errorf("invalid index segment writer options: %v", err)
+	}
 	return nil
 }
 
@@ -194,6 +205,16 @@ func (o *options) FilePathPrefix() string {
 	return o.filePathPrefix
 }
 
+func (o *options) SetDataVolumes(value []string) Options {
+	opts := *o
+	opts.dataVolumes = value
+	return &opts
+}
+
+func (o *options) DataVolumes() []string {
+	return o.dataVolumes
+}
+
 func (o *options) SetNewFileMode(value os.FileMode) Options {
 	opts := *o
 	opts.newFileMode = value
@@ -323,3 +344,33 @@ func (o *options) SetPostingsListPool(value postings.Pool) Options {
 func (o *options) PostingsListPool() postings.Pool {
 	return o.postingsPool
 }
+
+func (o *options) SetFaultInjector(value fault.Injector) Options {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() fault.Injector {
+	return o.faultInjector
+}
+
+func (o *options) SetEncryptionKeyProvider(value encryption.KeyProvider) Options {
+	opts := *o
+	opts.encryptionKeyProvider = value
+	return &opts
+}
+
+func (o *options) EncryptionKeyProvider() encryption.KeyProvider {
+	return o.encryptionKeyProvider
+}
+
+func (o *options) SetIndexSegmentWriterOpts(value m3ninxfs.NewWriterOpts) Options {
+	opts := *o
+	opts.indexSegmentWriterOpts = value
+	return &opts
+}
+
+func (o *options) IndexSegmentWriterOpts() m3ninxfs.NewWriterOpts {
+	return o.indexSegmentWriterOpts
+}
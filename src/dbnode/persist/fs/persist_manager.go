@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/ratelimit"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/fault"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fs"
 	m3ninxpersist "github.com/m3db/m3/src/m3ninx/persist"
@@ -149,7 +150,8 @@ func NewPersistManager(opts Options) (persist.Manager, error) {
 	if err != nil {
 		return nil, err
 	}
-	segmentWriter, err := m3ninxpersist.NewMutableSegmentFileSetWriter()
+	segmentWriter, err := m3ninxpersist.NewMutableSegmentFileSetWriterWithOpts(
+		opts.IndexSegmentWriterOpts())
 	if err != nil {
 		return nil, err
 	}
@@ -380,6 +382,10 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 		return prepared, errPersistManagerCannotPrepareDataNotPersisting
 	}
 
+	if err := pm.opts.FaultInjector().Inject(fault.PointPersistPrepare); err != nil {
+		return prepared, err
+	}
+
 	exists, err := pm.dataFilesetExistsAt(opts)
 	if err != nil {
 		return prepared, err
@@ -435,6 +441,16 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 			VolumeIndex: volumeIndex,
 		},
 	}
+	if dictOpts := nsMetadata.Options().CompressionDictionaryOptions(); dictOpts.Enabled() {
+		dataWriterOpts.CompressionDictionary = DataWriterCompressionDictionaryOptions{
+			Enabled:    true,
+			SampleSize: dictOpts.SampleSize(),
+			MaxBytes:   dictOpts.MaxBytes(),
+		}
+	}
+	if nsMetadata.Options().EncryptionEnabled() {
+		dataWriterOpts.Encryption = DataWriterEncryptionOptions{Enabled: true}
+	}
 	if err := pm.dataPM.writer.Open(dataWriterOpts); err != nil {
 		return prepared, err
 	}
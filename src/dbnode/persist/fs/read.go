@@ -31,6 +31,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/dbnode/serialize"
@@ -48,6 +49,9 @@ var (
 
 	// errReadNotExpectedSize returned when the size of the next read does not match size specified by the index
 	errReadNotExpectedSize = errors.New("next read not expected size")
+
+	// errReadEncryptionKeyProviderNotSet returned when the fileset is encrypted but no key provider is set
+	errReadEncryptionKeyProviderNotSet = errors.New("fileset is encrypted but no encryption key provider set")
 )
 
 type reader struct {
@@ -75,6 +79,14 @@ type reader struct {
 
 	bloomFilterFd *os.File
 
+	dictionaryInfo         schema.IndexDictionaryInfo
+	dictionaryDecompressor *tagsDictionaryDecompressor
+	dictionaryBuf          []byte
+
+	encryptionKeyID []byte
+	cipher          encryption.Cipher
+	sealedBuf       []byte
+
 	entries         int
 	bloomFilterInfo schema.IndexBloomFilterInfo
 	entriesRead     int
@@ -141,6 +153,7 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		bloomFilterFilepath string
 		indexFilepath       string
 		dataFilepath        string
+		dictionaryFilepath  string
 	)
 	switch opts.FileSetType {
 	case persist.FileSetSnapshotType:
@@ -151,6 +164,7 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		bloomFilterFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, bloomFilterFileSuffix)
 		indexFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, indexFileSuffix)
 		dataFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, dataFileSuffix)
+		dictionaryFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, dictionaryFileSuffix)
 	case persist.FileSetFlushType:
 		shardDir = ShardDataDirPath(r.filePathPrefix, namespace, shard)
 		checkpointFilepath = filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)
@@ -159,10 +173,18 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		bloomFilterFilepath = filesetPathFromTime(shardDir, blockStart, bloomFilterFileSuffix)
 		indexFilepath = filesetPathFromTime(shardDir, blockStart, indexFileSuffix)
 		dataFilepath = filesetPathFromTime(shardDir, blockStart, dataFileSuffix)
+		dictionaryFilepath = filesetPathFromTime(shardDir, blockStart, dictionaryFileSuffix)
 	default:
 		return fmt.Errorf("unable to open reader with fileset type: %s", opts.FileSetType)
 	}
 
+	if opts.FileSetType == persist.FileSetFlushType {
+		// Do not open a data fileset while it is being upgraded.
+		lock := dataFileSetLocks.lock(shardDir, blockStart)
+		lock.RLock()
+		defer lock.RUnlock()
+	}
+
 	// If there is no checkpoint file, don't read the data files.
 	if err := r.readCheckpointFile(checkpointFilepath); err != nil {
 		return err
@@ -226,6 +248,17 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		r.Close()
 		return err
 	}
+	r.dictionaryDecompressor, err = newTagsDictionaryDecompressorFromFile(
+		dictionaryFilepath, r.dictionaryInfo)
+	if err != nil {
+		r.Close()
+		return err
+	}
+	r.cipher, err = newFileSetCipher(r.opts.EncryptionKeyProvider(), r.encryptionKeyID)
+	if err != nil {
+		r.Close()
+		return err
+	}
 	if err := r.readIndexAndSortByOffsetAsc(); err != nil {
 		r.Close()
 		return err
@@ -296,12 +329,17 @@ func (r *reader) readInfo(size int) error {
 	if err != nil {
 		return err
 	}
+	if err := validateFormatVersion(info); err != nil {
+		return err
+	}
 	r.start = xtime.FromNanoseconds(info.BlockStart)
 	r.blockSize = time.Duration(info.BlockSize)
 	r.entries = int(info.Entries)
 	r.entriesRead = 0
 	r.metadataRead = 0
 	r.bloomFilterInfo = info.BloomFilter
+	r.dictionaryInfo = info.Dictionary
+	r.encryptionKeyID = info.EncryptionKeyID
 	return nil
 }
 
@@ -335,28 +373,57 @@ func (r *reader) Read() (ident.ID, ident.TagIterator, checked.Bytes, uint32, err
 
 	entry := r.indexEntriesByOffsetAsc[r.entriesRead]
 
+	size := int(entry.Size)
+	if r.cipher != nil {
+		size = dataSizeFromSealedSize(size)
+	}
+
 	var data checked.Bytes
 	if r.bytesPool != nil {
-		data = r.bytesPool.Get(int(entry.Size))
+		data = r.bytesPool.Get(size)
 		data.IncRef()
 		defer data.DecRef()
-		data.Resize(int(entry.Size))
+		data.Resize(size)
 	} else {
-		data = checked.NewBytes(make([]byte, entry.Size), nil)
+		data = checked.NewBytes(make([]byte, size), nil)
 		data.IncRef()
 		defer data.DecRef()
 	}
 
-	n, err := r.dataReader.Read(data.Bytes())
+	if r.cipher != nil {
+		if cap(r.sealedBuf) < int(entry.Size) {
+			r.sealedBuf = make([]byte, int(entry.Size))
+		}
+		r.sealedBuf = r.sealedBuf[:entry.Size]
+		n, err := r.dataReader.Read(r.sealedBuf)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		if n != int(entry.Size) {
+			return nil, nil, nil, 0, errReadNotExpectedSize
+		}
+		opened, err := r.cipher.Open(data.Bytes()[:0], r.sealedBuf)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		if len(opened) != size {
+			return nil, nil, nil, 0, errReadNotExpectedSize
+		}
+	} else {
+		n, err := r.dataReader.Read(data.Bytes())
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		if n != int(entry.Size) {
+			return nil, nil, nil, 0, errReadNotExpectedSize
+		}
+	}
+
+	tags, err := r.entryClonedEncodedTagsIter(entry.EncodedTags)
 	if err != nil {
 		return nil, nil, nil, 0, err
 	}
-	if n != int(entry.Size) {
-		return nil, nil, nil, 0, errReadNotExpectedSize
-	}
-
 	id := r.entryClonedID(entry.ID)
-	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
 
 	r.entriesRead++
 	return id, tags, data, uint32(entry.Checksum), nil
@@ -368,9 +435,15 @@ func (r *reader) ReadMetadata() (ident.ID, ident.TagIterator, int, uint32, error
 	}
 
 	entry := r.indexEntriesByOffsetAsc[r.metadataRead]
+	tags, err := r.entryClonedEncodedTagsIter(entry.EncodedTags)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
 	id := r.entryClonedID(entry.ID)
-	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
 	length := int(entry.Size)
+	if r.cipher != nil {
+		length = dataSizeFromSealedSize(length)
+	}
 	checksum := uint32(entry.Checksum)
 
 	r.metadataRead++
@@ -404,14 +477,22 @@ func (r *reader) entryClonedID(id []byte) ident.ID {
 	return ident.BinaryID(r.entryClonedBytes(id))
 }
 
-func (r *reader) entryClonedEncodedTagsIter(encodedTags []byte) ident.TagIterator {
+func (r *reader) entryClonedEncodedTagsIter(encodedTags []byte) (ident.TagIterator, error) {
 	if len(encodedTags) == 0 {
 		// No tags set for this entry, return an empty tag iterator
-		return ident.EmptyTagIterator
+		return ident.EmptyTagIterator, nil
+	}
+	if r.dictionaryDecompressor != nil {
+		var err error
+		r.dictionaryBuf, err = r.dictionaryDecompressor.decompress(r.dictionaryBuf, encodedTags)
+		if err != nil {
+			return nil, err
+		}
+		encodedTags = r.dictionaryBuf
 	}
 	decoder := r.tagDecoderPool.Get()
 	decoder.Reset(r.entryClonedBytes(encodedTags))
-	return decoder
+	return decoder, nil
 }
 
 // NB(xichen): Validate should be called after all data is read because
@@ -488,6 +569,8 @@ func (r *reader) Close() error {
 	bytesPool := r.bytesPool
 	tagDecoderPool := r.tagDecoderPool
 	indexEntriesByOffsetAsc := r.indexEntriesByOffsetAsc
+	dictionaryBuf := r.dictionaryBuf
+	sealedBuf := r.sealedBuf
 
 	// Reset struct
 	*r = reader{}
@@ -506,10 +589,35 @@ func (r *reader) Close() error {
 	r.bytesPool = bytesPool
 	r.tagDecoderPool = tagDecoderPool
 	r.indexEntriesByOffsetAsc = indexEntriesByOffsetAsc
+	r.dictionaryBuf = dictionaryBuf
+	r.sealedBuf = sealedBuf
 
 	return multiErr.FinalError()
 }
 
+// newFileSetCipher returns the cipher to decrypt the data of a fileset
+// encrypted with the specified key, or nil if the fileset is not encrypted.
+func newFileSetCipher(
+	provider encryption.KeyProvider,
+	keyID []byte,
+) (encryption.Cipher, error) {
+	if len(keyID) == 0 {
+		return nil, nil
+	}
+	if provider == nil {
+		return nil, errReadEncryptionKeyProviderNotSet
+	}
+	return encryption.NewCipherForKeyID(provider, string(keyID))
+}
+
+// dataSizeFromSealedSize returns the size of the data of an encrypted entry.
+func dataSizeFromSealedSize(size int) int {
+	if size < encryption.Overhead {
+		return 0
+	}
+	return size - encryption.Overhead
+}
+
 // indexEntriesByOffsetAsc implements sort.Sort
 type indexEntriesByOffsetAsc []schema.IndexEntry
 
@@ -23,6 +23,8 @@ package fs
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -32,6 +34,7 @@ import (
 	"github.com/m3db/bloom"
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
@@ -253,6 +256,172 @@ func TestSimpleReadWrite(t *testing.T) {
 	readTestData(t, r, 0, testWriterStart, entries)
 }
 
+func TestReadWriteWithCompressionDictionary(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	var entries []testEntry
+	for i := 0; i < 500; i++ {
+		entries = append(entries, testEntry{
+			id: fmt.Sprintf("http_requests_total.%d", i),
+			tags: map[string]string{
+				"__name__": "http_requests_total",
+				"cluster":  "production-us-east-1",
+				"service":  fmt.Sprintf("frontend-%d", i%5),
+				"instance": fmt.Sprintf("host-%d.example.com:9090", i),
+			},
+			data: []byte{byte(i), 1, 2, 3},
+		})
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	require.NoError(t, w.Open(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: persist.FileSetFlushType,
+		CompressionDictionary: DataWriterCompressionDictionaryOptions{
+			Enabled:    true,
+			SampleSize: 100,
+			MaxBytes:   4096,
+		},
+	}))
+	for i := range entries {
+		require.NoError(t, w.Write(
+			entries[i].ID(),
+			entries[i].Tags(),
+			bytesRefd(entries[i].data),
+			digest.Checksum(entries[i].data)))
+	}
+	require.NoError(t, w.Close())
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	require.True(t, FileExists(
+		filesetPathFromTime(shardDir, testWriterStart, dictionaryFileSuffix)))
+
+	r := newTestReader(t, filePathPrefix)
+	readTestData(t, r, 0, testWriterStart, entries)
+
+	s := newTestSeeker(filePathPrefix)
+	require.NoError(t, s.Open(testNs1ID, 0, testWriterStart))
+	defer s.Close()
+
+	for _, entry := range entries {
+		indexEntry, err := s.SeekIndexEntry(entry.ID())
+		require.NoError(t, err)
+
+		decoder := testDefaultOpts.TagDecoderPool().Get()
+		decoder.Reset(checked.NewBytes(indexEntry.EncodedTags, nil))
+		tagMatcher := ident.NewTagIterMatcher(ident.NewTagsIterator(entry.Tags()))
+		require.True(t, tagMatcher.Matches(decoder))
+		decoder.Close()
+	}
+}
+
+func TestReadWriteWithEncryption(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	oldKey := encryption.Key{ID: "old", Material: bytes.Repeat([]byte{1}, 32)}
+	newKey := encryption.Key{ID: "new", Material: bytes.Repeat([]byte{2}, 32)}
+	keyProvider, err := encryption.NewStaticKeyProvider([]encryption.Key{oldKey})
+	require.NoError(t, err)
+
+	entries := []testEntry{
+		{"foo", map[string]string{"a": "b"}, []byte("plaintext datapoints foo")},
+		{"bar", nil, []byte("plaintext datapoints bar")},
+		{"baz", nil, []byte{1, 2, 3}},
+	}
+
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetEncryptionKeyProvider(keyProvider)
+	w, err := NewWriter(opts)
+	require.NoError(t, err)
+	require.NoError(t, w.Open(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: persist.FileSetFlushType,
+		Encryption:  DataWriterEncryptionOptions{Enabled: true},
+	}))
+	for i := range entries {
+		require.NoError(t, w.Write(
+			entries[i].ID(),
+			entries[i].Tags(),
+			bytesRefd(entries[i].data),
+			digest.Checksum(entries[i].data)))
+	}
+	require.NoError(t, w.Close())
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	data, err := ioutil.ReadFile(filesetPathFromTime(shardDir, testWriterStart, dataFileSuffix))
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(data, []byte("plaintext datapoints")))
+
+	// Rotate the key, data written with the previous key must remain readable.
+	keyProvider, err = encryption.NewStaticKeyProvider([]encryption.Key{newKey, oldKey})
+	require.NoError(t, err)
+	opts = opts.SetEncryptionKeyProvider(keyProvider)
+
+	r, err := NewReader(testBytesPool, opts.
+		SetInfoReaderBufferSize(testReaderBufferSize).
+		SetDataReaderBufferSize(testReaderBufferSize))
+	require.NoError(t, err)
+	readTestData(t, r, 0, testWriterStart, entries)
+
+	s := NewSeeker(filePathPrefix, testReaderBufferSize, testReaderBufferSize,
+		testReaderBufferSize, testBytesPool, false, nil, opts)
+	require.NoError(t, s.Open(testNs1ID, 0, testWriterStart))
+	defer s.Close()
+	for _, entry := range entries {
+		seeked, err := s.SeekByID(entry.ID())
+		require.NoError(t, err)
+		seeked.IncRef()
+		assert.Equal(t, entry.data, seeked.Bytes())
+		seeked.DecRef()
+	}
+
+	// Reading without a key provider must fail rather than return ciphertext.
+	r = newTestReader(t, filePathPrefix)
+	err = r.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	})
+	require.Equal(t, errReadEncryptionKeyProviderNotSet, err)
+}
+
+func TestWriterEncryptionWithoutKeyProvider(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	err := w.Open(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: persist.FileSetFlushType,
+		Encryption:  DataWriterEncryptionOptions{Enabled: true},
+	})
+	require.Equal(t, errWriterEncryptionKeyProviderNotSet, err)
+}
+
 func TestDuplicateWrite(t *testing.T) {
 	dir := createTempDir(t)
 	filePathPrefix := filepath.Join(dir, "")
@@ -28,6 +28,11 @@
 // The block retriever also handles batching of requests for data, as well as
 // re-arranging the order of requests to increase data locality when seeking
 // through and across files.
+//
+// When the requests being fetched span multiple block starts, such as for a
+// long range query, the block retriever fetches the earliest block start
+// first and prefetches the subsequent block starts concurrently, overlapping
+// disk latency of later blocks with decoding of the earlier blocks.
 
 package fs
 
@@ -164,7 +169,11 @@ func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 	var (
 		inFlight      []*retrieveRequest
 		currBatchReqs []*retrieveRequest
+		prefetchCh    chan struct{}
 	)
+	if n := r.opts.PrefetchBlocks(); n > 0 {
+		prefetchCh = make(chan struct{}, n)
+	}
 	for {
 		// Free references to the inflight requests
 		for i := range inFlight {
@@ -216,12 +225,27 @@ func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 		// so we re-arrange the order of the requests to achieve that
 		sort.Sort(retrieveRequestByStartAscShardAsc(inFlight))
 
+		// Prefetch requests for block starts after the earliest block start
+		// concurrently while the earliest block start is fetched
+		var prefetchWg sync.WaitGroup
+		fetchReqs := inFlight
+		if prefetchCh != nil {
+			if idx := firstRequestAfterStart(inFlight); idx > 0 {
+				prefetchWg.Add(1)
+				go func(reqs []*retrieveRequest) {
+					r.prefetch(seekerMgr, reqs, prefetchCh)
+					prefetchWg.Done()
+				}(inFlight[idx:])
+				fetchReqs = inFlight[:idx]
+			}
+		}
+
 		// Iterate through all in flight requests and send them to the seeker in
 		// batches of block time + shard.
 		currBatchShard := uint32(0)
 		currBatchStart := time.Time{}
 		currBatchReqs = currBatchReqs[:0]
-		for _, req := range inFlight {
+		for _, req := range fetchReqs {
 			if !req.start.Equal(currBatchStart) ||
 				req.shard != currBatchShard {
 				// Fetch any outstanding in the current batch
@@ -250,11 +274,58 @@ func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 			}
 			currBatchReqs = currBatchReqs[:0]
 		}
+
+		// Wait for prefetches so that no more than one seeker per shard and
+		// block start is borrowed by this fetch loop at once
+		prefetchWg.Wait()
 	}
 
 	r.fetchLoopsHaveShutdownCh <- struct{}{}
 }
 
+// firstRequestAfterStart returns the index of the first request with a block
+// start after the block start of the first request, or -1 if all requests
+// share the same block start. Requests must be sorted by block start.
+func firstRequestAfterStart(reqs []*retrieveRequest) int {
+	for i, req := range reqs {
+		if !req.start.Equal(reqs[0].start) {
+			return i
+		}
+	}
+	return -1
+}
+
+// prefetch fetches requests in batches of block time + shard with at most
+// the capacity of the prefetch channel fetched concurrently. Requests must
+// be sorted by block start and shard.
+func (r *blockRetriever) prefetch(
+	seekerMgr DataFileSetSeekerManager,
+	reqs []*retrieveRequest,
+	prefetchCh chan struct{},
+) {
+	var wg sync.WaitGroup
+	for len(reqs) > 0 {
+		end := 1
+		for end < len(reqs) &&
+			reqs[end].start.Equal(reqs[0].start) &&
+			reqs[end].shard == reqs[0].shard {
+			end++
+		}
+
+		batch := reqs[:end]
+		reqs = reqs[end:]
+
+		prefetchCh <- struct{}{}
+		wg.Add(1)
+		go func() {
+			r.fetchBatch(seekerMgr, batch[0].shard, batch[0].start, batch)
+			<-prefetchCh
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
 func (r *blockRetriever) fetchBatch(
 	seekerMgr DataFileSetSeekerManager,
 	shard uint32,
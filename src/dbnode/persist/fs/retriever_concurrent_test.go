@@ -306,3 +306,89 @@ func TestBlockRetrieverIDDoesNotExist(t *testing.T) {
 	assert.Equal(t, nil, segment.Head)
 	assert.Equal(t, nil, segment.Tail)
 }
+
+// TestBlockRetrieverPrefetchMultipleBlockStarts verifies that streams spanning
+// multiple block starts are all fulfilled when subsequent block starts are
+// prefetched while the earliest block start is being fetched.
+func TestBlockRetrieverPrefetchMultipleBlockStarts(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Minute)()
+
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePathPrefix := filepath.Join(dir, "")
+
+	fsOpts := testDefaultOpts.SetFilePathPrefix(filePathPrefix)
+	rOpts := testNs1Metadata(t).Options().RetentionOptions()
+	now := time.Now().Truncate(rOpts.BlockSize())
+
+	opts := testBlockRetrieverOptions{
+		retrieverOpts: NewBlockRetrieverOptions().
+			SetFetchConcurrency(1).
+			SetPrefetchBlocks(2),
+		fsOpts: fsOpts,
+	}
+	retriever, cleanup := newOpenTestBlockRetriever(t, opts)
+	defer cleanup()
+
+	var (
+		shards      = []uint32{0, 1}
+		id          = ident.StringID("foo")
+		blockStarts []time.Time
+		expected    = make(map[uint32]map[xtime.UnixNano]checked.Bytes)
+	)
+	for i := 4; i > 0; i-- {
+		blockStarts = append(blockStarts, now.Add(-time.Duration(i)*rOpts.BlockSize()))
+	}
+	for _, shard := range shards {
+		expected[shard] = make(map[xtime.UnixNano]checked.Bytes)
+		for _, blockStart := range blockStarts {
+			w, closer := newOpenTestWriter(t, fsOpts, shard, blockStart)
+			data := checked.NewBytes([]byte(fmt.Sprintf("%d-%d", shard, blockStart.UnixNano())), nil)
+			data.IncRef()
+			defer data.DecRef()
+			err := w.Write(id, ident.Tags{}, data, digest.Checksum(data.Bytes()))
+			require.NoError(t, err)
+			closer()
+			expected[shard][xtime.ToUnixNano(blockStart)] = data
+		}
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var results []streamResult
+	for _, shard := range shards {
+		for _, blockStart := range blockStarts {
+			stream, err := retriever.Stream(ctx, shard, id, blockStart, nil)
+			require.NoError(t, err)
+			results = append(results, streamResult{
+				ctx:        ctx,
+				shard:      shard,
+				id:         id.String(),
+				blockStart: blockStart,
+				stream:     stream,
+			})
+		}
+	}
+
+	compare := ts.Segment{}
+	for _, r := range results {
+		seg, err := r.stream.Segment()
+		require.NoError(t, err)
+		compare.Head = expected[r.shard][xtime.ToUnixNano(r.blockStart)]
+		assert.True(t, seg.Equal(&compare))
+	}
+}
+
+func TestFirstRequestAfterStart(t *testing.T) {
+	start := time.Now().Truncate(time.Hour)
+	reqs := []*retrieveRequest{
+		{shard: 0, start: start},
+		{shard: 1, start: start},
+		{shard: 0, start: start.Add(time.Hour)},
+	}
+	assert.Equal(t, 2, firstRequestAfterStart(reqs))
+	assert.Equal(t, -1, firstRequestAfterStart(reqs[:2]))
+	assert.Equal(t, -1, firstRequestAfterStart(reqs[2:]))
+}
@@ -29,6 +29,7 @@ import (
 const (
 	defaultRequestPoolSize  = 16384
 	defaultFetchConcurrency = 2
+	defaultPrefetchBlocks   = 1
 )
 
 type blockRetrieverOptions struct {
@@ -36,6 +37,7 @@ type blockRetrieverOptions struct {
 	bytesPool         pool.CheckedBytesPool
 	segmentReaderPool xio.SegmentReaderPool
 	fetchConcurrency  int
+	prefetchBlocks    int
 	identifierPool    ident.Pool
 }
 
@@ -53,6 +55,7 @@ func NewBlockRetrieverOptions() BlockRetrieverOptions {
 		bytesPool:         bytesPool,
 		segmentReaderPool: xio.NewSegmentReaderPool(nil),
 		fetchConcurrency:  defaultFetchConcurrency,
+		prefetchBlocks:    defaultPrefetchBlocks,
 		identifierPool:    ident.NewPool(bytesPool, ident.PoolOptions{}),
 	}
 	o.segmentReaderPool.Init()
@@ -99,6 +102,16 @@ func (o *blockRetrieverOptions) FetchConcurrency() int {
 	return o.fetchConcurrency
 }
 
+func (o *blockRetrieverOptions) SetPrefetchBlocks(value int) BlockRetrieverOptions {
+	opts := *o
+	opts.prefetchBlocks = value
+	return &opts
+}
+
+func (o *blockRetrieverOptions) PrefetchBlocks() int {
+	return o.prefetchBlocks
+}
+
 func (o *blockRetrieverOptions) SetIdentifierPool(value ident.Pool) BlockRetrieverOptions {
 	opts := *o
 	opts.identifierPool = value
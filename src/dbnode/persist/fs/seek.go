@@ -28,8 +28,10 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/x/mmap"
 	"github.com/m3db/m3x/checked"
 	xerrors "github.com/m3db/m3x/errors"
@@ -43,7 +45,8 @@ var (
 	errSeekIDNotFound = errors.New("id not found in shard")
 
 	// errSeekChecksumMismatch returned when data checksum does not match the expected checksum
-	errSeekChecksumMismatch = errors.New("checksum does not match expected checksum")
+	errSeekChecksumMismatch = m3dberrors.NewDataCorruptionError(
+		errors.New("checksum does not match expected checksum"))
 
 	// errInvalidDataFileOffset returned when the provided offset into the data file is not valid
 	errInvalidDataFileOffset = errors.New("invalid data file offset")
@@ -65,6 +68,7 @@ type seeker struct {
 	entries         int
 	bloomFilterInfo schema.IndexBloomFilterInfo
 	summariesInfo   schema.IndexSummariesInfo
+	dictionaryInfo  schema.IndexDictionaryInfo
 
 	dataMmap  []byte
 	indexMmap []byte
@@ -80,6 +84,15 @@ type seeker struct {
 	bloomFilter *ManagedConcurrentBloomFilter
 	indexLookup *nearestIndexOffsetLookup
 
+	// Decompresses the encoded tags of index entries if the fileset was
+	// written with a compression dictionary, concurrency safe.
+	dictionaryDecompressor *tagsDictionaryDecompressor
+
+	// Decrypts the data if the fileset was written with encryption enabled,
+	// concurrency safe.
+	encryptionKeyID []byte
+	cipher          encryption.Cipher
+
 	keepUnreadBuf bool
 
 	isClone bool
@@ -246,6 +259,21 @@ func (s *seeker) Open(namespace ident.ID, shard uint32, blockStart time.Time) er
 		return err
 	}
 
+	s.dictionaryDecompressor, err = newTagsDictionaryDecompressorFromFile(
+		filesetPathFromTime(shardDir, blockStart, dictionaryFileSuffix),
+		s.dictionaryInfo,
+	)
+	if err != nil {
+		s.Close()
+		return err
+	}
+
+	s.cipher, err = newFileSetCipher(s.opts.opts.EncryptionKeyProvider(), s.encryptionKeyID)
+	if err != nil {
+		s.Close()
+		return err
+	}
+
 	if digest.Checksum(s.indexMmap) != expectedDigests.indexDigest {
 		s.Close()
 		return fmt.Errorf(
@@ -315,12 +343,18 @@ func (s *seeker) readInfo(size int, infoDigestReader digest.FdWithDigestReader,
 	if err != nil {
 		return err
 	}
+	if err := validateFormatVersion(info); err != nil {
+		return err
+	}
 
 	s.start = xtime.FromNanoseconds(info.BlockStart)
 	s.blockSize = time.Duration(info.BlockSize)
 	s.entries = int(info.Entries)
 	s.bloomFilterInfo = info.BloomFilter
 	s.summariesInfo = info.Summaries
+	s.dictionaryInfo = info.Dictionary
+	// Copy the key ID as the info is decoded from the reused unread buffer.
+	s.encryptionKeyID = append([]byte(nil), info.EncryptionKeyID...)
 
 	return nil
 }
@@ -355,22 +389,37 @@ func (s *seeker) SeekByIndexEntry(entry IndexEntry) (checked.Bytes, error) {
 		return nil, errNotEnoughBytes
 	}
 
+	size := int(entry.Size)
+	if s.cipher != nil {
+		size = dataSizeFromSealedSize(size)
+	}
+
 	// Obtain an appropriately sized buffer
 	var buffer checked.Bytes
 	if s.bytesPool != nil {
-		buffer = s.bytesPool.Get(int(entry.Size))
+		buffer = s.bytesPool.Get(size)
 		buffer.IncRef()
 		defer buffer.DecRef()
-		buffer.Resize(int(entry.Size))
+		buffer.Resize(size)
 	} else {
-		buffer = checked.NewBytes(make([]byte, entry.Size), nil)
+		buffer = checked.NewBytes(make([]byte, size), nil)
 		buffer.IncRef()
 		defer buffer.DecRef()
 	}
 
-	// Copy the actual data into the underlying buffer
+	// Copy, or decrypt, the actual data into the underlying buffer
 	underlyingBuf := buffer.Bytes()
-	copy(underlyingBuf, data[:entry.Size])
+	if s.cipher != nil {
+		opened, err := s.cipher.Open(underlyingBuf[:0], data[:entry.Size])
+		if err != nil {
+			return nil, err
+		}
+		if len(opened) != size {
+			return nil, errNotEnoughBytes
+		}
+	} else {
+		copy(underlyingBuf, data[:entry.Size])
+	}
 
 	// NB(r): _must_ check the checksum against known checksum as the data
 	// file might not have been verified if we haven't read through the file yet.
@@ -403,11 +452,18 @@ func (s *seeker) SeekIndexEntry(id ident.ID) (IndexEntry, error) {
 		}
 		comparison := bytes.Compare(entry.ID, idBytes)
 		if comparison == 0 {
+			encodedTags := entry.EncodedTags
+			if s.dictionaryDecompressor != nil && len(encodedTags) != 0 {
+				encodedTags, err = s.dictionaryDecompressor.decompress(nil, encodedTags)
+				if err != nil {
+					return IndexEntry{}, err
+				}
+			}
 			return IndexEntry{
 				Size:        uint32(entry.Size),
 				Checksum:    uint32(entry.Checksum),
 				Offset:      entry.Offset,
-				EncodedTags: entry.EncodedTags,
+				EncodedTags: encodedTags,
 			}, nil
 		}
 
@@ -446,6 +502,7 @@ func (s *seeker) Close() error {
 		multiErr = multiErr.Add(s.indexLookup.close())
 		s.indexLookup = nil
 	}
+	s.dictionaryDecompressor = nil
 	if s.indexMmap != nil {
 		multiErr = multiErr.Add(mmap.Munmap(s.indexMmap))
 		s.indexMmap = nil
@@ -476,6 +533,10 @@ func (s *seeker) ConcurrentClone() (ConcurrentDataFileSetSeeker, error) {
 		// bloomFilter is concurrency safe
 		bloomFilter: s.bloomFilter,
 		indexLookup: indexLookupClone,
-		isClone:     true,
+		// dictionaryDecompressor is concurrency safe
+		dictionaryDecompressor: s.dictionaryDecompressor,
+		// cipher is concurrency safe
+		cipher:  s.cipher,
+		isClone: true,
 	}, nil
 }
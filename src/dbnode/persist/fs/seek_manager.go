@@ -360,6 +360,11 @@ func (m *seekerManager) newOpenSeeker(
 	shard uint32,
 	blockStart time.Time,
 ) (DataFileSetSeeker, error) {
+	// Do not open a data fileset while it is being upgraded.
+	lock := dataFileSetLocks.lock(ShardDataDirPath(m.filePathPrefix, m.namespace, shard), blockStart)
+	lock.RLock()
+	defer lock.RUnlock()
+
 	exists, err := DataFileSetExistsAt(m.filePathPrefix, m.namespace, shard, blockStart)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist/schema"
+)
+
+const (
+	// tagsDictionaryMaxBytes is the max size of a dictionary, deflate only
+	// matches against the last 32KiB of a preset dictionary.
+	tagsDictionaryMaxBytes = 32 * 1024
+)
+
+var (
+	errTagsDictionaryChecksumMismatch = errors.New(
+		"tags dictionary checksum does not match expected checksum")
+)
+
+// tagsDictionaryCompressor compresses encoded tags using a deflate preset
+// dictionary built from a sample of the encoded tags written to a fileset.
+type tagsDictionaryCompressor struct {
+	dict   []byte
+	buf    *bytes.Buffer
+	writer *flate.Writer
+}
+
+// newTagsDictionaryCompressor builds a dictionary from the samples, it returns
+// a nil compressor if no samples were provided to build a dictionary.
+func newTagsDictionaryCompressor(
+	samples [][]byte,
+	maxBytes int,
+) (*tagsDictionaryCompressor, error) {
+	trained := buildTagsDictionary(samples, maxBytes)
+	if len(trained) == 0 {
+		// Nothing to build a dictionary from, the fileset is written without
+		// compressing the encoded tags.
+		return nil, nil
+	}
+
+	// Encoded tags are short, lower levels write short inputs without
+	// matching them against the dictionary.
+	buf := bytes.NewBuffer(nil)
+	writer, err := flate.NewWriterDict(buf, flate.BestCompression, trained)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tagsDictionaryCompressor{
+		dict:   trained,
+		buf:    buf,
+		writer: writer,
+	}, nil
+}
+
+// buildTagsDictionary concatenates the distinct samples into a dictionary of
+// at most maxBytes. Samples seen most often are placed at the end of the
+// dictionary where matches against them are encoded with the shortest offsets.
+func buildTagsDictionary(samples [][]byte, maxBytes int) []byte {
+	if maxBytes <= 0 || maxBytes > tagsDictionaryMaxBytes {
+		maxBytes = tagsDictionaryMaxBytes
+	}
+
+	var (
+		counts   = make(map[string]int, len(samples))
+		distinct = make([][]byte, 0, len(samples))
+	)
+	for _, sample := range samples {
+		if len(sample) == 0 {
+			continue
+		}
+		if counts[string(sample)] == 0 {
+			distinct = append(distinct, sample)
+		}
+		counts[string(sample)]++
+	}
+	sort.SliceStable(distinct, func(i, j int) bool {
+		return counts[string(distinct[i])] < counts[string(distinct[j])]
+	})
+
+	var trained []byte
+	for _, sample := range distinct {
+		trained = append(trained, sample...)
+	}
+	if len(trained) > maxBytes {
+		trained = trained[len(trained)-maxBytes:]
+	}
+	return trained
+}
+
+func (c *tagsDictionaryCompressor) compress(dst, encodedTags []byte) []byte {
+	// Writes to a bytes buffer never fail so errors can be ignored.
+	c.buf.Reset()
+	c.writer.Reset(c.buf)
+	c.writer.Write(encodedTags)
+	c.writer.Close()
+	return append(dst[:0], c.buf.Bytes()...)
+}
+
+func (c *tagsDictionaryCompressor) info() schema.IndexDictionaryInfo {
+	return schema.IndexDictionaryInfo{
+		Size:     int64(len(c.dict)),
+		Checksum: int64(digest.Checksum(c.dict)),
+	}
+}
+
+// tagsDictionaryDecompressor decompresses encoded tags that were compressed
+// with a fileset's dictionary, it is safe for concurrent use.
+type tagsDictionaryDecompressor struct {
+	dict    []byte
+	readers sync.Pool
+}
+
+// newTagsDictionaryDecompressorFromFile reads and validates the dictionary file
+// of a fileset, it returns a nil decompressor if the fileset has no dictionary.
+func newTagsDictionaryDecompressorFromFile(
+	filePath string,
+	info schema.IndexDictionaryInfo,
+) (*tagsDictionaryDecompressor, error) {
+	if info.Size == 0 {
+		return nil, nil
+	}
+
+	trained, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(trained)) != info.Size {
+		return nil, fmt.Errorf("tags dictionary size %d does not match expected size %d",
+			len(trained), info.Size)
+	}
+	if int64(digest.Checksum(trained)) != info.Checksum {
+		return nil, errTagsDictionaryChecksumMismatch
+	}
+
+	d := &tagsDictionaryDecompressor{dict: trained}
+	d.readers.New = func() interface{} {
+		return flate.NewReaderDict(bytes.NewReader(nil), d.dict)
+	}
+	return d, nil
+}
+
+func (d *tagsDictionaryDecompressor) decompress(dst, compressed []byte) ([]byte, error) {
+	reader := d.readers.Get().(io.ReadCloser)
+	defer d.readers.Put(reader)
+
+	if err := reader.(flate.Resetter).Reset(bytes.NewReader(compressed), d.dict); err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(dst[:0])
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,570 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/pool"
+	xtime "github.com/m3db/m3x/time"
+)
+
+const (
+	// transitionDirName is the name of the directory within a shard directory
+	// that transitioned filesets are written to before they replace the
+	// filesets of the target namespace. It is within the shard directory so
+	// that the transitioned files are on the same volume as the files they
+	// replace.
+	transitionDirName = ".transition"
+)
+
+var (
+	errTransitionSourceNotSpecified       = errors.New("transition source namespace not specified")
+	errTransitionTargetNotSpecified       = errors.New("transition target namespace not specified")
+	errTransitionResolutionNotPositive    = errors.New("transition resolution must be positive")
+	errTransitionEncoderPoolNotSpecified  = errors.New("transition encoder pool not specified")
+	errTransitionIteratorPoolNotSpecified = errors.New("transition reader iterator pool not specified")
+)
+
+// TransitionDataFileSetOptions is a set of options used when transitioning
+// the expired data filesets of a namespace shard into a single block of a
+// namespace with a lower resolution.
+type TransitionDataFileSetOptions struct {
+	// Source is the namespace of the expired data filesets.
+	Source namespace.Metadata
+
+	// SourceBlockStarts are the block starts of the expired data filesets of
+	// the source namespace, datapoints outside of the target block are
+	// not transitioned.
+	SourceBlockStarts []time.Time
+
+	// Target is the namespace the downsampled series are written to.
+	Target namespace.Metadata
+
+	// TargetBlockStart is the block start of the target namespace fileset
+	// the downsampled series are written to.
+	TargetBlockStart time.Time
+
+	// Shard is the shard of both the source and target filesets.
+	Shard uint32
+
+	// Resolution is the size of the windows the series are downsampled to.
+	Resolution time.Duration
+
+	// Aggregation is the aggregation applied to the values of each window.
+	Aggregation namespace.ContinuousQueryAggregation
+
+	// FilesystemOptions is the filesystem options which is
+	// required for reading and writing data filesets.
+	FilesystemOptions Options
+
+	// EncoderPool is the pool of encoders the downsampled series are
+	// encoded with.
+	EncoderPool encoding.EncoderPool
+
+	// ReaderIteratorPool is the pool of iterators the series of the source
+	// and target filesets are decoded with.
+	ReaderIteratorPool encoding.ReaderIteratorPool
+
+	// MemSegmentOptions is the options used to build the index segment of
+	// the series added to the target namespace, which is required if the
+	// target namespace is indexed.
+	MemSegmentOptions mem.Options
+
+	// BytesPool is the pool the series of the filesets are read with, nil
+	// allocates the series instead.
+	BytesPool pool.CheckedBytesPool
+
+	// Unexported fields that are hooks used for testing.
+	newReaderFn      newUpgradeReaderFn
+	newWriterFn      newUpgradeWriterFn
+	newIndexWriterFn newIndexWriterFn
+}
+
+// TransitionDataFileSetResult describes the result of transitioning the
+// expired data filesets of a namespace shard.
+type TransitionDataFileSetResult struct {
+	// Series is the number of series of the source filesets that were
+	// downsampled.
+	Series int
+
+	// NewSeries is the number of downsampled series the target fileset did
+	// not have before, which are also written to an index fileset volume if
+	// the target namespace is indexed.
+	NewSeries int
+
+	// Windows is the number of downsampled windows written to the target
+	// fileset, windows the target fileset already had a datapoint for are
+	// not written.
+	Windows int
+}
+
+// TransitionDataFileSet downsamples the series of the expired data filesets
+// of a namespace shard into windows of the resolution and merges them into
+// the data fileset of a block of the target namespace. Each window is
+// written at its start so that all windows of the source blocks are within
+// the target block, datapoints the target fileset already has take
+// precedence over the windows starting at the same time.
+//
+// If the target namespace is indexed the series new to the target fileset
+// are written to a new index fileset volume first so that the series are
+// never in the data fileset without being indexed. The merged data fileset
+// is then written to a directory within the target shard directory and
+// replaces the existing target fileset the same way data filesets are
+// upgraded, so a failure at any point leaves the existing target fileset
+// complete and the transition can be retried.
+//
+// The merged fileset is read from disk by the target namespace once it is
+// next bootstrapped, as the readers of a fileset that are already open are
+// not reopened when the fileset is replaced.
+func TransitionDataFileSet(
+	opts TransitionDataFileSetOptions,
+) (TransitionDataFileSetResult, error) {
+	var result TransitionDataFileSetResult
+	if err := opts.validate(); err != nil {
+		return result, err
+	}
+
+	if opts.newReaderFn == nil {
+		opts.newReaderFn = NewReader
+	}
+	if opts.newWriterFn == nil {
+		opts.newWriterFn = NewWriter
+	}
+	if opts.newIndexWriterFn == nil {
+		opts.newIndexWriterFn = NewIndexWriter
+	}
+
+	var (
+		fsOpts      = opts.FilesystemOptions
+		targetID    = opts.Target.ID()
+		targetOpts  = opts.Target.Options()
+		blockSize   = targetOpts.RetentionOptions().BlockSize()
+		blockStart  = opts.TargetBlockStart
+		blockEnd    = blockStart.Add(blockSize)
+		transitions = make(map[string]*transitionSeries)
+	)
+	for _, sourceBlockStart := range opts.SourceBlockStarts {
+		err := opts.readDataFileSet(opts.Source.ID(), sourceBlockStart, func(
+			id ident.ID,
+			tags ident.Tags,
+			iter encoding.ReaderIterator,
+		) error {
+			series := transitionSeriesFor(transitions, id, tags)
+			for iter.Next() {
+				dp, unit, _ := iter.Current()
+				if dp.Timestamp.Before(blockStart) || !dp.Timestamp.Before(blockEnd) {
+					continue
+				}
+				series.add(dp, unit, opts.Resolution, opts.Aggregation)
+			}
+			return iter.Err()
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for _, series := range transitions {
+		if len(series.windows) > 0 {
+			result.Series++
+		}
+	}
+	if result.Series == 0 {
+		return result, nil
+	}
+
+	exists, err := DataFileSetExistsAt(fsOpts.FilePathPrefix(), targetID,
+		opts.Shard, blockStart)
+	if err != nil {
+		return result, err
+	}
+	if exists {
+		err := opts.readDataFileSet(targetID, blockStart, func(
+			id ident.ID,
+			tags ident.Tags,
+			iter encoding.ReaderIterator,
+		) error {
+			series := transitionSeriesFor(transitions, id, tags)
+			series.existing = true
+			for iter.Next() {
+				dp, unit, annotation := iter.Current()
+				series.datapoints = append(series.datapoints, transitionDatapoint{
+					dp:         dp,
+					unit:       unit,
+					annotation: append(ts.Annotation(nil), annotation...),
+				})
+			}
+			return iter.Err()
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	ordered := make([]*transitionSeries, 0, len(transitions))
+	for _, series := range transitions {
+		ordered = append(ordered, series)
+		if !series.existing && len(series.windows) > 0 {
+			result.NewSeries++
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return bytes.Compare(ordered[i].id.Bytes(), ordered[j].id.Bytes()) < 0
+	})
+
+	if result.NewSeries > 0 && targetOpts.IndexOptions().Enabled() {
+		if err := opts.writeIndexFileSetVolume(ordered); err != nil {
+			return result, err
+		}
+	}
+
+	shardDir := ShardDataDirPath(fsOpts.FilePathPrefix(), targetID, opts.Shard)
+	if err := os.MkdirAll(shardDir, fsOpts.NewDirectoryMode()); err != nil {
+		return result, err
+	}
+	transitionPrefix := filepath.Join(shardDir, transitionDirName)
+	// Remove any files left by a transition that did not complete.
+	if err := os.RemoveAll(transitionPrefix); err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(transitionPrefix)
+
+	windows, err := opts.writeDataFileSet(transitionPrefix, ordered)
+	if err != nil {
+		return result, err
+	}
+	result.Windows = windows
+
+	transitionShardDir := ShardDataDirPath(transitionPrefix, targetID, opts.Shard)
+	return result, replaceDataFileSet(transitionShardDir, shardDir, blockStart)
+}
+
+func (o TransitionDataFileSetOptions) validate() error {
+	if o.FilesystemOptions == nil {
+		return errFilesystemOptionsNotSpecified
+	}
+	if o.Source == nil {
+		return errTransitionSourceNotSpecified
+	}
+	if o.Target == nil {
+		return errTransitionTargetNotSpecified
+	}
+	if o.Resolution <= 0 {
+		return errTransitionResolutionNotPositive
+	}
+	if o.EncoderPool == nil {
+		return errTransitionEncoderPoolNotSpecified
+	}
+	if o.ReaderIteratorPool == nil {
+		return errTransitionIteratorPoolNotSpecified
+	}
+	if o.Target.Options().IndexOptions().Enabled() && o.MemSegmentOptions == nil {
+		return errMemSegmentOptionsNotSpecified
+	}
+	return o.FilesystemOptions.Validate()
+}
+
+type transitionSeriesFn func(
+	id ident.ID,
+	tags ident.Tags,
+	iter encoding.ReaderIterator,
+) error
+
+// readDataFileSet calls the function with an iterator over the datapoints
+// of each series of the data fileset for the namespace block start.
+func (o TransitionDataFileSetOptions) readDataFileSet(
+	namespace ident.ID,
+	blockStart time.Time,
+	fn transitionSeriesFn,
+) error {
+	reader, err := o.newReaderFn(o.BytesPool, o.FilesystemOptions)
+	if err != nil {
+		return err
+	}
+	if err := reader.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  namespace,
+			Shard:      o.Shard,
+			BlockStart: blockStart,
+		},
+		FileSetType: persist.FileSetFlushType,
+	}); err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	iter := o.ReaderIteratorPool.Get()
+	defer iter.Close()
+
+	for {
+		id, tagsIter, data, _, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var tags ident.Tags
+		for tagsIter.Next() {
+			curr := tagsIter.Current()
+			tags.Append(ident.StringTag(curr.Name.String(), curr.Value.String()))
+		}
+		err = tagsIter.Err()
+		tagsIter.Close()
+
+		data.IncRef()
+		if err == nil {
+			iter.Reset(bytes.NewReader(data.Bytes()))
+			err = fn(id, tags, iter)
+		}
+		id.Finalize()
+		data.DecRef()
+		data.Finalize()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeDataFileSet writes the merged series to the data fileset for the
+// target block start under the file path prefix and returns the number of
+// downsampled windows written.
+func (o TransitionDataFileSetOptions) writeDataFileSet(
+	filePathPrefix string,
+	ordered []*transitionSeries,
+) (int, error) {
+	writer, err := o.newWriterFn(o.FilesystemOptions.SetFilePathPrefix(filePathPrefix))
+	if err != nil {
+		return 0, err
+	}
+
+	targetOpts := o.Target.Options()
+	writerOpts := DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  o.Target.ID(),
+			Shard:      o.Shard,
+			BlockStart: o.TargetBlockStart,
+		},
+		BlockSize:   targetOpts.RetentionOptions().BlockSize(),
+		FileSetType: persist.FileSetFlushType,
+	}
+	if dictOpts := targetOpts.CompressionDictionaryOptions(); dictOpts.Enabled() {
+		writerOpts.CompressionDictionary = DataWriterCompressionDictionaryOptions{
+			Enabled:    true,
+			SampleSize: dictOpts.SampleSize(),
+			MaxBytes:   dictOpts.MaxBytes(),
+		}
+	}
+	if targetOpts.EncryptionEnabled() {
+		writerOpts.Encryption = DataWriterEncryptionOptions{Enabled: true}
+	}
+	if err := writer.Open(writerOpts); err != nil {
+		return 0, err
+	}
+
+	var (
+		windows int
+		data    = make([]checked.Bytes, 2)
+	)
+	for _, series := range ordered {
+		encoder := o.EncoderPool.Get()
+		encoder.Reset(o.TargetBlockStart, 0)
+		written, err := series.encode(encoder)
+		if err != nil {
+			encoder.Close()
+			writer.Close()
+			return 0, err
+		}
+		windows += written
+
+		segment := encoder.Discard()
+		data[0], data[1] = segment.Head, segment.Tail
+		err = writer.WriteAll(series.id, series.tags, data,
+			digest.SegmentChecksum(segment))
+		segment.Finalize()
+		if err != nil {
+			writer.Close()
+			return 0, err
+		}
+	}
+
+	return windows, writer.Close()
+}
+
+// writeIndexFileSetVolume writes the series new to the target fileset to a
+// new index fileset volume for the target index block start.
+func (o TransitionDataFileSetOptions) writeIndexFileSetVolume(
+	ordered []*transitionSeries,
+) error {
+	var (
+		fsOpts         = o.FilesystemOptions
+		indexBlockSize = o.Target.Options().IndexOptions().BlockSize()
+		indexStart     = o.TargetBlockStart.Truncate(indexBlockSize)
+	)
+	seg, err := mem.NewSegment(postings.ID(0), o.MemSegmentOptions)
+	if err != nil {
+		return err
+	}
+	defer seg.Close()
+
+	for _, series := range ordered {
+		if series.existing || len(series.windows) == 0 {
+			continue
+		}
+		d, err := convert.FromMetric(series.id, series.tags)
+		if err != nil {
+			return err
+		}
+		if _, err := seg.Insert(d); err != nil {
+			return err
+		}
+	}
+	if _, err := seg.Seal(); err != nil {
+		return err
+	}
+
+	volumeIndex, err := NextIndexFileSetVolumeIndex(fsOpts.FilePathPrefix(),
+		o.Target.ID(), indexStart)
+	if err != nil {
+		return err
+	}
+	return writeIndexFileSetVolume(o.newIndexWriterFn, fsOpts, seg,
+		IndexWriterOpenOptions{
+			Identifier: FileSetFileIdentifier{
+				FileSetContentType: persist.FileSetIndexContentType,
+				Namespace:          o.Target.ID(),
+				BlockStart:         indexStart,
+				VolumeIndex:        volumeIndex,
+			},
+			BlockSize:   indexBlockSize,
+			FileSetType: persist.FileSetFlushType,
+			Shards:      map[uint32]struct{}{o.Shard: struct{}{}},
+		})
+}
+
+// transitionSeries is a series of the target fileset along with the windows
+// its series in the source filesets are downsampled to.
+type transitionSeries struct {
+	id         ident.ID
+	tags       ident.Tags
+	existing   bool
+	datapoints []transitionDatapoint
+	windows    []transitionWindow
+}
+
+type transitionDatapoint struct {
+	dp         ts.Datapoint
+	unit       xtime.Unit
+	annotation ts.Annotation
+}
+
+type transitionWindow struct {
+	start time.Time
+	unit  xtime.Unit
+	agg   namespace.ContinuousQueryAggregator
+}
+
+func transitionSeriesFor(
+	transitions map[string]*transitionSeries,
+	id ident.ID,
+	tags ident.Tags,
+) *transitionSeries {
+	if series, ok := transitions[id.String()]; ok {
+		return series
+	}
+	series := &transitionSeries{
+		id:   ident.BytesID(append([]byte(nil), id.Bytes()...)),
+		tags: tags,
+	}
+	transitions[series.id.String()] = series
+	return series
+}
+
+// add adds a datapoint of the source filesets to its window, the datapoints
+// of a series are read in time order so a datapoint is either in the last
+// window or starts a new window.
+func (s *transitionSeries) add(
+	dp ts.Datapoint,
+	unit xtime.Unit,
+	resolution time.Duration,
+	aggregation namespace.ContinuousQueryAggregation,
+) {
+	start := dp.Timestamp.Truncate(resolution)
+	if n := len(s.windows); n == 0 || !s.windows[n-1].start.Equal(start) {
+		s.windows = append(s.windows, transitionWindow{
+			start: start,
+			agg:   namespace.NewContinuousQueryAggregator(aggregation),
+		})
+	}
+	window := &s.windows[len(s.windows)-1]
+	window.unit = unit
+	window.agg.Add(dp.Value)
+}
+
+// encode encodes the datapoints of the target fileset merged with the
+// downsampled windows and returns the number of windows encoded.
+func (s *transitionSeries) encode(encoder encoding.Encoder) (int, error) {
+	var (
+		windows int
+		i, j    int
+	)
+	for i < len(s.datapoints) || j < len(s.windows) {
+		if j == len(s.windows) ||
+			(i < len(s.datapoints) && !s.datapoints[i].dp.Timestamp.After(s.windows[j].start)) {
+			curr := s.datapoints[i]
+			if j < len(s.windows) && curr.dp.Timestamp.Equal(s.windows[j].start) {
+				j++
+			}
+			if err := encoder.Encode(curr.dp, curr.unit, curr.annotation); err != nil {
+				return windows, err
+			}
+			i++
+			continue
+		}
+
+		window := s.windows[j]
+		dp := ts.Datapoint{Timestamp: window.start, Value: window.agg.Value()}
+		if err := encoder.Encode(dp, window.unit, nil); err != nil {
+			return windows, err
+		}
+		windows++
+		j++
+	}
+	return windows, nil
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTransitionTargetBlockSize = 2 * testBlockSize
+	testTransitionResolution      = 10 * time.Minute
+)
+
+type testTransitionSeries map[string][]ts.Datapoint
+
+func newTestTransitionMetadata(
+	t *testing.T,
+	id ident.ID,
+	blockSize time.Duration,
+	indexEnabled bool,
+) namespace.Metadata {
+	md, err := namespace.NewMetadata(id, namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().SetBlockSize(blockSize)).
+		SetIndexOptions(namespace.NewIndexOptions().
+			SetEnabled(indexEnabled).
+			SetBlockSize(blockSize)))
+	require.NoError(t, err)
+	return md
+}
+
+func writeTestTransitionFileSet(
+	t *testing.T,
+	filePathPrefix string,
+	md namespace.Metadata,
+	blockStart time.Time,
+	series testTransitionSeries,
+) {
+	w := newTestWriter(t, filePathPrefix)
+	require.NoError(t, w.Open(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  md.ID(),
+			Shard:      0,
+			BlockStart: blockStart,
+		},
+		BlockSize:   md.Options().RetentionOptions().BlockSize(),
+		FileSetType: persist.FileSetFlushType,
+	}))
+
+	for id, datapoints := range series {
+		encoder := m3tsz.NewEncoder(blockStart, nil, true, encoding.NewOptions())
+		for _, dp := range datapoints {
+			require.NoError(t, encoder.Encode(dp, xtime.Second, nil))
+		}
+		segment := encoder.Discard()
+		require.NoError(t, w.WriteAll(ident.StringID(id), ident.Tags{},
+			[]checked.Bytes{segment.Head, segment.Tail},
+			digest.SegmentChecksum(segment)))
+	}
+	require.NoError(t, w.Close())
+}
+
+func readTestTransitionFileSet(
+	t *testing.T,
+	filePathPrefix string,
+	md namespace.Metadata,
+	blockStart time.Time,
+) testTransitionSeries {
+	r := newTestReader(t, filePathPrefix)
+	require.NoError(t, r.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  md.ID(),
+			Shard:      0,
+			BlockStart: blockStart,
+		},
+		FileSetType: persist.FileSetFlushType,
+	}))
+	defer r.Close()
+
+	series := make(testTransitionSeries)
+	for {
+		id, tags, data, _, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		tags.Close()
+
+		data.IncRef()
+		iter := m3tsz.NewReaderIterator(bytes.NewReader(data.Bytes()), true,
+			encoding.NewOptions())
+		for iter.Next() {
+			dp, _, _ := iter.Current()
+			series[id.String()] = append(series[id.String()], dp)
+		}
+		require.NoError(t, iter.Err())
+		data.DecRef()
+	}
+	return series
+}
+
+func testTransitionOptions(
+	filePathPrefix string,
+	source, target namespace.Metadata,
+	sourceBlockStarts []time.Time,
+	targetBlockStart time.Time,
+) TransitionDataFileSetOptions {
+	encodingOpts := encoding.NewOptions()
+	encoderPool := encoding.NewEncoderPool(nil)
+	encoderPool.Init(func() encoding.Encoder {
+		return m3tsz.NewEncoder(time.Time{}, nil, true, encodingOpts)
+	})
+	iteratorPool := encoding.NewReaderIteratorPool(nil)
+	iteratorPool.Init(func(r io.Reader) encoding.ReaderIterator {
+		return m3tsz.NewReaderIterator(r, true, encodingOpts)
+	})
+	return TransitionDataFileSetOptions{
+		Source:             source,
+		SourceBlockStarts:  sourceBlockStarts,
+		Target:             target,
+		TargetBlockStart:   targetBlockStart,
+		Shard:              0,
+		Resolution:         testTransitionResolution,
+		Aggregation:        namespace.ContinuousQueryMean,
+		FilesystemOptions:  testDefaultOpts.SetFilePathPrefix(filePathPrefix),
+		EncoderPool:        encoderPool,
+		ReaderIteratorPool: iteratorPool,
+		MemSegmentOptions:  mem.NewOptions(),
+	}
+}
+
+func TestTransitionDataFileSetMergesTargetFileSet(t *testing.T) {
+	filePathPrefix := createTempDir(t)
+	defer os.RemoveAll(filePathPrefix)
+
+	var (
+		source = newTestTransitionMetadata(t, testNs1ID, testBlockSize, false)
+		target = newTestTransitionMetadata(t, testNs2ID, testTransitionTargetBlockSize, false)
+		start  = testWriterStart.Truncate(testTransitionTargetBlockSize)
+		second = start.Add(testBlockSize)
+	)
+	writeTestTransitionFileSet(t, filePathPrefix, source, start, testTransitionSeries{
+		"foo": {
+			{Timestamp: start, Value: 1},
+			{Timestamp: start.Add(time.Minute), Value: 3},
+			{Timestamp: start.Add(12 * time.Minute), Value: 4},
+		},
+		"bar": {
+			{Timestamp: start.Add(5 * time.Minute), Value: 1},
+		},
+	})
+	writeTestTransitionFileSet(t, filePathPrefix, source, second, testTransitionSeries{
+		"foo": {
+			{Timestamp: second.Add(time.Minute), Value: 10},
+			{Timestamp: second.Add(2 * time.Minute), Value: 20},
+		},
+	})
+	writeTestTransitionFileSet(t, filePathPrefix, target, start, testTransitionSeries{
+		"foo": {
+			{Timestamp: start.Add(testTransitionResolution), Value: 100},
+		},
+		"baz": {
+			{Timestamp: start, Value: 7},
+		},
+	})
+
+	result, err := TransitionDataFileSet(testTransitionOptions(filePathPrefix,
+		source, target, []time.Time{start, second}, start))
+	require.NoError(t, err)
+	require.Equal(t, TransitionDataFileSetResult{
+		Series:    2,
+		NewSeries: 1,
+		Windows:   3,
+	}, result)
+
+	require.Equal(t, testTransitionSeries{
+		"foo": {
+			{Timestamp: start, Value: 2},
+			{Timestamp: start.Add(testTransitionResolution), Value: 100},
+			{Timestamp: second, Value: 15},
+		},
+		"bar": {
+			{Timestamp: start, Value: 1},
+		},
+		"baz": {
+			{Timestamp: start, Value: 7},
+		},
+	}, readTestTransitionFileSet(t, filePathPrefix, target, start))
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs2ID, 0)
+	_, err = os.Stat(filepath.Join(shardDir, transitionDirName))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestTransitionDataFileSetWithoutTargetFileSet(t *testing.T) {
+	filePathPrefix := createTempDir(t)
+	defer os.RemoveAll(filePathPrefix)
+
+	var (
+		source = newTestTransitionMetadata(t, testNs1ID, testBlockSize, false)
+		target = newTestTransitionMetadata(t, testNs2ID, testTransitionTargetBlockSize, true)
+		start  = testWriterStart.Truncate(testTransitionTargetBlockSize)
+	)
+	writeTestTransitionFileSet(t, filePathPrefix, source, start, testTransitionSeries{
+		"foo": {
+			{Timestamp: start.Add(time.Minute), Value: 1},
+			{Timestamp: start.Add(2 * time.Minute), Value: 5},
+		},
+	})
+
+	result, err := TransitionDataFileSet(testTransitionOptions(filePathPrefix,
+		source, target, []time.Time{start}, start))
+	require.NoError(t, err)
+	require.Equal(t, TransitionDataFileSetResult{
+		Series:    1,
+		NewSeries: 1,
+		Windows:   1,
+	}, result)
+
+	require.Equal(t, testTransitionSeries{
+		"foo": {
+			{Timestamp: start, Value: 3},
+		},
+	}, readTestTransitionFileSet(t, filePathPrefix, target, start))
+
+	// The new series is written to an index fileset volume of the target.
+	filesets, err := IndexFileSetsAt(filePathPrefix, testNs2ID, start)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(filesets))
+}
+
+func TestTransitionDataFileSetNoSourceData(t *testing.T) {
+	filePathPrefix := createTempDir(t)
+	defer os.RemoveAll(filePathPrefix)
+
+	var (
+		source = newTestTransitionMetadata(t, testNs1ID, testBlockSize, false)
+		target = newTestTransitionMetadata(t, testNs2ID, testTransitionTargetBlockSize, false)
+		start  = testWriterStart.Truncate(testTransitionTargetBlockSize)
+	)
+	writeTestTransitionFileSet(t, filePathPrefix, source, start, testTransitionSeries{})
+
+	result, err := TransitionDataFileSet(testTransitionOptions(filePathPrefix,
+		source, target, []time.Time{start}, start))
+	require.NoError(t, err)
+	require.Equal(t, TransitionDataFileSetResult{}, result)
+
+	exists, err := DataFileSetExistsAt(filePathPrefix, testNs2ID, 0, start)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -27,12 +27,15 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/x/fault"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fs"
 	idxpersist "github.com/m3db/m3/src/m3ninx/persist"
 	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3x/checked"
@@ -61,6 +64,10 @@ type DataWriterOpenOptions struct {
 	BlockSize          time.Duration
 	// Only used when writing snapshot files
 	Snapshot DataWriterSnapshotOptions
+	// Controls compression of the encoded tags with a trained dictionary
+	CompressionDictionary DataWriterCompressionDictionaryOptions
+	// Controls encryption of the data file contents
+	Encryption DataWriterEncryptionOptions
 }
 
 // DataWriterSnapshotOptions is the options struct for Open method on the DataFileSetWriter
@@ -69,6 +76,21 @@ type DataWriterSnapshotOptions struct {
 	SnapshotTime time.Time
 }
 
+// DataWriterCompressionDictionaryOptions is the options struct for Open method on the
+// DataFileSetWriter that controls training a dictionary to compress encoded tags
+type DataWriterCompressionDictionaryOptions struct {
+	Enabled    bool
+	SampleSize int
+	MaxBytes   int
+}
+
+// DataWriterEncryptionOptions is the options struct for Open method on the
+// DataFileSetWriter that controls encryption of the data file contents, the
+// IDs and tags in the index are not encrypted as they are required for lookups
+type DataWriterEncryptionOptions struct {
+	Enabled bool
+}
+
 // DataFileSetWriter provides an unsynchronized writer for a TSDB file set
 type DataFileSetWriter interface {
 	io.Closer
@@ -347,6 +369,16 @@ type Options interface {
 	// FilePathPrefix returns the file path prefix for sharded TSDB files
 	FilePathPrefix() string
 
+	// SetDataVolumes sets the data volumes to stripe shard data and snapshot
+	// directories across, if empty all files are stored under the file path
+	// prefix
+	SetDataVolumes(value []string) Options
+
+	// DataVolumes returns the data volumes to stripe shard data and snapshot
+	// directories across, if empty all files are stored under the file path
+	// prefix
+	DataVolumes() []string
+
 	// SetNewFileMode sets the new file mode
 	SetNewFileMode(value os.FileMode) Options
 
@@ -426,6 +458,32 @@ type Options interface {
 
 	// PostingsListPool returns the postings list pool
 	PostingsListPool() postings.Pool
+
+	// SetFaultInjector sets the fault injector used by tests to inject
+	// failed flushes and slow disk writes
+	SetFaultInjector(value fault.Injector) Options
+
+	// FaultInjector returns the fault injector used by tests to inject
+	// failed flushes and slow disk writes
+	FaultInjector() fault.Injector
+
+	// SetEncryptionKeyProvider sets the key provider used to encrypt the
+	// data of namespaces with encryption enabled and the commit logs, when
+	// not set data is written unencrypted
+	SetEncryptionKeyProvider(value encryption.KeyProvider) Options
+
+	// EncryptionKeyProvider returns the key provider used to encrypt the
+	// data of namespaces with encryption enabled and the commit logs, when
+	// not set data is written unencrypted
+	EncryptionKeyProvider() encryption.KeyProvider
+
+	// SetIndexSegmentWriterOpts sets the options of the writer of index
+	// segments, such as the encoding of their postings lists
+	SetIndexSegmentWriterOpts(value m3ninxfs.NewWriterOpts) Options
+
+	// IndexSegmentWriterOpts returns the options of the writer of index
+	// segments, such as the encoding of their postings lists
+	IndexSegmentWriterOpts() m3ninxfs.NewWriterOpts
 }
 
 // BlockRetrieverOptions represents the options for block retrieval
@@ -454,6 +512,16 @@ type BlockRetrieverOptions interface {
 	// FetchConcurrency returns the fetch concurrency
 	FetchConcurrency() int
 
+	// SetPrefetchBlocks sets the number of subsequent block starts to fetch
+	// ahead while fetching the earliest block start requested, zero disables
+	// prefetching
+	SetPrefetchBlocks(value int) BlockRetrieverOptions
+
+	// PrefetchBlocks returns the number of subsequent block starts to fetch
+	// ahead while fetching the earliest block start requested, zero disables
+	// prefetching
+	PrefetchBlocks() int
+
 	// SetIdentifierPool sets the identifierPool
 	SetIdentifierPool(value ident.Pool) BlockRetrieverOptions
 
@@ -0,0 +1,373 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/schema"
+	"github.com/m3db/m3/src/dbnode/ratelimit"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/pool"
+	xtime "github.com/m3db/m3x/time"
+)
+
+const (
+	// upgradeDirName is the name of the directory within a shard directory
+	// that upgraded filesets are written to before they replace the filesets
+	// they upgrade. It is within the shard directory so that the upgraded
+	// files are on the same volume as the files they replace.
+	upgradeDirName = ".upgrade"
+
+	dataFileSetLocksNumStripes = 256
+)
+
+// dataFileSetLocks takes data filesets out of service while their files are
+// replaced by an upgrade, readers hold a read lock while they open the files
+// of a fileset and an upgrade holds the write lock while it replaces them so
+// that a reader never opens a mix of the outdated and upgraded files or finds
+// the fileset incomplete. Files opened before an upgrade are unaffected by it
+// as the upgraded files are renamed over the outdated files.
+var dataFileSetLocks = newFileSetLocks(dataFileSetLocksNumStripes)
+
+type fileSetLocks struct {
+	stripes []sync.RWMutex
+}
+
+func newFileSetLocks(numStripes int) *fileSetLocks {
+	return &fileSetLocks{stripes: make([]sync.RWMutex, numStripes)}
+}
+
+// lock returns the lock of the fileset for the block start in the shard
+// directory.
+func (l *fileSetLocks) lock(shardDir string, blockStart time.Time) *sync.RWMutex {
+	h := fnv.New32a()
+	h.Write([]byte(filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)))
+	return &l.stripes[h.Sum32()%uint32(len(l.stripes))]
+}
+
+// dataFileSetSuffixes are the suffixes of the files of a data fileset other
+// than its checkpoint file.
+var dataFileSetSuffixes = []string{
+	infoFileSuffix,
+	indexFileSuffix,
+	summariesFileSuffix,
+	bloomFilterFileSuffix,
+	dataFileSuffix,
+	digestFileSuffix,
+	dictionaryFileSuffix,
+}
+
+type newUpgradeReaderFn func(pool.CheckedBytesPool, Options) (DataFileSetReader, error)
+
+type newUpgradeWriterFn func(Options) (DataFileSetWriter, error)
+
+// UpgradeDataFileSetsOptions is a set of options used when upgrading the
+// data filesets of a single namespace shard to the current format version.
+type UpgradeDataFileSetsOptions struct {
+	// Namespace is the namespace of the data filesets to upgrade.
+	Namespace ident.ID
+
+	// Shard is the shard of the data filesets to upgrade.
+	Shard uint32
+
+	// MaxFileSets is the max number of filesets upgraded, zero upgrades all
+	// filesets with an older format version.
+	MaxFileSets int
+
+	// FilesystemOptions is the filesystem options which is
+	// required for reading and writing data filesets.
+	FilesystemOptions Options
+
+	// RateLimitOptions if set limits the rate the upgraded filesets are
+	// written at so that upgrades do not compete with flushes for disk.
+	RateLimitOptions ratelimit.Options
+
+	// BytesPool is the pool the series of the filesets are read with, nil
+	// allocates the series instead.
+	BytesPool pool.CheckedBytesPool
+
+	// Unexported fields that are hooks used for testing.
+	newReaderFn newUpgradeReaderFn
+	newWriterFn newUpgradeWriterFn
+	nowFn       func() time.Time
+	sleepFn     func(time.Duration)
+}
+
+// UpgradeDataFileSetsResult describes the result of upgrading the data
+// filesets of a single namespace shard.
+type UpgradeDataFileSetsResult struct {
+	// Upgraded are the block starts of the filesets that were upgraded.
+	Upgraded []time.Time
+
+	// Remaining is the number of filesets with an older format version that
+	// were not upgraded as the max number of filesets was reached.
+	Remaining int
+}
+
+// UpgradeDataFileSets rewrites the complete data filesets of a namespace shard
+// with an older format version than the current format version in the
+// current format version. Filesets with a newer major version than the
+// current major version can not be read and are left as they are.
+//
+// Each fileset is written to a directory within the shard directory, then
+// the fileset is taken out of service so that no reader, such as the seekers
+// of the block retriever, opens it while its checkpoint file is removed and
+// its files are replaced by the upgraded files, and the checkpoint file of the
+// upgraded fileset is moved into place last.
+func UpgradeDataFileSets(
+	opts UpgradeDataFileSetsOptions,
+) (UpgradeDataFileSetsResult, error) {
+	var (
+		result         UpgradeDataFileSetsResult
+		fsOpts         = opts.FilesystemOptions
+		filePathPrefix = fsOpts.FilePathPrefix()
+	)
+	if err := fsOpts.Validate(); err != nil {
+		return result, err
+	}
+
+	infoFiles := ReadInfoFiles(filePathPrefix, opts.Namespace, opts.Shard,
+		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	var outdated []schema.IndexInfo
+	for _, infoFile := range infoFiles {
+		if infoFile.Err.Error() != nil {
+			// Filesets with info files that can not be read are left to be
+			// handled by the bootstrappers and cleanup.
+			continue
+		}
+
+		version := infoFile.Info.FormatVersion()
+		if version.Readable() && version.Before(schema.CurrentFormatVersion) {
+			outdated = append(outdated, infoFile.Info)
+		}
+	}
+
+	if opts.MaxFileSets > 0 && len(outdated) > opts.MaxFileSets {
+		result.Remaining = len(outdated) - opts.MaxFileSets
+		outdated = outdated[:opts.MaxFileSets]
+	}
+
+	if opts.newReaderFn == nil {
+		opts.newReaderFn = NewReader
+	}
+	if opts.newWriterFn == nil {
+		opts.newWriterFn = NewWriter
+	}
+	if opts.nowFn == nil {
+		opts.nowFn = time.Now
+	}
+	if opts.sleepFn == nil {
+		opts.sleepFn = time.Sleep
+	}
+
+	shardDir := ShardDataDirPath(filePathPrefix, opts.Namespace, opts.Shard)
+	upgradePrefix := filepath.Join(shardDir, upgradeDirName)
+	// Remove any files left by an upgrade that did not complete.
+	if err := os.RemoveAll(upgradePrefix); err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(upgradePrefix)
+
+	for _, info := range outdated {
+		blockStart := xtime.FromNanoseconds(info.BlockStart)
+		if err := upgradeDataFileSet(opts, info, upgradePrefix); err != nil {
+			result.Remaining += len(outdated) - len(result.Upgraded)
+			return result, fmt.Errorf(
+				"unable to upgrade fileset for block start %v from version %s: %v",
+				blockStart, info.FormatVersion(), err)
+		}
+		result.Upgraded = append(result.Upgraded, blockStart)
+	}
+
+	return result, nil
+}
+
+func upgradeDataFileSet(
+	opts UpgradeDataFileSetsOptions,
+	info schema.IndexInfo,
+	upgradePrefix string,
+) error {
+	var (
+		fsOpts     = opts.FilesystemOptions
+		blockStart = xtime.FromNanoseconds(info.BlockStart)
+		id         = FileSetFileIdentifier{
+			Namespace:  opts.Namespace,
+			Shard:      opts.Shard,
+			BlockStart: blockStart,
+		}
+	)
+	reader, err := opts.newReaderFn(opts.BytesPool, fsOpts)
+	if err != nil {
+		return err
+	}
+	if err := reader.Open(DataReaderOpenOptions{
+		Identifier:  id,
+		FileSetType: persist.FileSetFlushType,
+	}); err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := opts.newWriterFn(fsOpts.SetFilePathPrefix(upgradePrefix))
+	if err != nil {
+		return err
+	}
+	if err := writer.Open(DataWriterOpenOptions{
+		Identifier:  id,
+		BlockSize:   time.Duration(info.BlockSize),
+		FileSetType: persist.FileSetFlushType,
+	}); err != nil {
+		return err
+	}
+
+	limiter := newUpgradeRateLimiter(opts)
+	for {
+		seriesID, tagsIter, data, checksum, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writer.Close()
+			return err
+		}
+
+		var tags ident.Tags
+		for tagsIter.Next() {
+			curr := tagsIter.Current()
+			tags.Append(ident.StringTag(curr.Name.String(), curr.Value.String()))
+		}
+		err = tagsIter.Err()
+		tagsIter.Close()
+		data.IncRef()
+		if err == nil {
+			err = writer.Write(seriesID, tags, data, checksum)
+		}
+
+		limiter.wait(data.Len())
+		seriesID.Finalize()
+		data.DecRef()
+		data.Finalize()
+		if err != nil {
+			writer.Close()
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	upgradeShardDir := ShardDataDirPath(upgradePrefix, opts.Namespace, opts.Shard)
+	shardDir := ShardDataDirPath(fsOpts.FilePathPrefix(), opts.Namespace, opts.Shard)
+	return replaceDataFileSet(upgradeShardDir, shardDir, blockStart)
+}
+
+// replaceDataFileSet moves the files of the data fileset for the block start
+// in the source directory over the files of the fileset in the destination
+// directory, the checkpoint file of the destination fileset is removed before
+// any other file is replaced and the checkpoint file of the source fileset is
+// moved last. The destination fileset is out of service while it is replaced.
+func replaceDataFileSet(srcDir, dstDir string, blockStart time.Time) error {
+	lock := dataFileSetLocks.lock(dstDir, blockStart)
+	lock.Lock()
+	defer lock.Unlock()
+
+	dstCheckpoint := filesetPathFromTime(dstDir, blockStart, checkpointFileSuffix)
+	if err := os.Remove(dstCheckpoint); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, suffix := range dataFileSetSuffixes {
+		src := filesetPathFromTime(srcDir, blockStart, suffix)
+		dst := filesetPathFromTime(dstDir, blockStart, suffix)
+		if !FileExists(src) {
+			// Remove files the source fileset does not have, such as the
+			// dictionary file of a fileset written without a dictionary.
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(src, dst); err != nil {
+			return err
+		}
+	}
+
+	srcCheckpoint := filesetPathFromTime(srcDir, blockStart, checkpointFileSuffix)
+	return os.Rename(srcCheckpoint, dstCheckpoint)
+}
+
+// upgradeRateLimiter limits the rate series are upgraded at to the rate
+// limit of the rate limit options, the same as flushes are rate limited.
+type upgradeRateLimiter struct {
+	opts         ratelimit.Options
+	nowFn        func() time.Time
+	sleepFn      func(time.Duration)
+	start        time.Time
+	bytesWritten int
+	count        int
+}
+
+func newUpgradeRateLimiter(opts UpgradeDataFileSetsOptions) *upgradeRateLimiter {
+	return &upgradeRateLimiter{
+		opts:    opts.RateLimitOptions,
+		nowFn:   opts.nowFn,
+		sleepFn: opts.sleepFn,
+		start:   opts.nowFn(),
+	}
+}
+
+func (l *upgradeRateLimiter) wait(bytes int) {
+	if l.opts == nil || !l.opts.LimitEnabled() || l.opts.LimitMbps() <= 0 {
+		return
+	}
+
+	l.bytesWritten += bytes
+	l.count++
+	if l.count < l.opts.LimitCheckEvery() {
+		return
+	}
+	l.count = 0
+
+	target := time.Duration(float64(time.Second) * float64(l.bytesWritten) /
+		(l.opts.LimitMbps() * bytesPerMegabit))
+	if elapsed := l.nowFn().Sub(l.start); elapsed < target {
+		l.sleepFn(target - elapsed)
+	}
+}
+
+// validateFormatVersion returns an error if the format version of a fileset
+// can not be read.
+func validateFormatVersion(info schema.IndexInfo) error {
+	if version := info.FormatVersion(); !version.Readable() {
+		return fmt.Errorf("fileset format version %s is newer than the "+
+			"supported format version %s", version, schema.CurrentFormatVersion)
+	}
+	return nil
+}
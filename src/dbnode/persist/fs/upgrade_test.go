@@ -0,0 +1,269 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
+	"github.com/m3db/m3/src/dbnode/persist/schema"
+	"github.com/m3db/m3/src/dbnode/ratelimit"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testUpgradeEntries = []testEntry{
+	{"foo", nil, []byte{1, 2, 3}},
+	{"bar", nil, []byte{4, 5, 6}},
+	{"foo+bar=baz,qux=qaz", map[string]string{
+		"bar": "baz",
+		"qux": "qaz",
+	}, []byte{7, 8, 9}},
+}
+
+// rewriteTestInfoFile rewrites the info file of a data fileset with the
+// result of the update function along with the digests of the fileset so
+// that the fileset remains valid.
+func rewriteTestInfoFile(
+	t *testing.T,
+	filePathPrefix string,
+	shard uint32,
+	blockStart time.Time,
+	update func(info *schema.IndexInfo),
+) {
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, shard)
+	infoPath := filesetPathFromTime(shardDir, blockStart, infoFileSuffix)
+	digestPath := filesetPathFromTime(shardDir, blockStart, digestFileSuffix)
+	checkpointPath := filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)
+
+	data, err := ioutil.ReadFile(infoPath)
+	require.NoError(t, err)
+	dec := msgpack.NewDecoder(nil)
+	dec.Reset(msgpack.NewDecoderStream(data))
+	info, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+
+	update(&info)
+	enc := msgpack.NewEncoder()
+	require.NoError(t, enc.EncodeIndexInfo(info))
+	data = enc.Bytes()
+	require.NoError(t, ioutil.WriteFile(infoPath, data, testDefaultOpts.NewFileMode()))
+
+	// The info file digest is the first digest of the digest file.
+	digests, err := ioutil.ReadFile(digestPath)
+	require.NoError(t, err)
+	buf := digest.NewBuffer()
+	buf.WriteDigest(digest.Checksum(data))
+	copy(digests, buf)
+	require.NoError(t, ioutil.WriteFile(digestPath, digests, testDefaultOpts.NewFileMode()))
+
+	buf.WriteDigest(digest.Checksum(digests))
+	require.NoError(t, ioutil.WriteFile(checkpointPath, buf, testDefaultOpts.NewFileMode()))
+}
+
+func readTestInfoFiles(t *testing.T, filePathPrefix string, shard uint32) []schema.IndexInfo {
+	var infos []schema.IndexInfo
+	for _, result := range ReadInfoFiles(filePathPrefix, testNs1ID, shard,
+		testDefaultOpts.InfoReaderBufferSize(), testDefaultOpts.DecodingOptions()) {
+		require.NoError(t, result.Err.Error())
+		infos = append(infos, result.Info)
+	}
+	return infos
+}
+
+func testUpgradeOptions(filePathPrefix string) UpgradeDataFileSetsOptions {
+	return UpgradeDataFileSetsOptions{
+		Namespace:         testNs1ID,
+		Shard:             0,
+		FilesystemOptions: testDefaultOpts.SetFilePathPrefix(filePathPrefix),
+	}
+}
+
+func TestUpgradeDataFileSetsOlderMinorVersion(t *testing.T) {
+	filePathPrefix := createTempDir(t)
+	defer os.RemoveAll(filePathPrefix)
+
+	var (
+		outdated = testWriterStart
+		current  = testWriterStart.Add(testBlockSize)
+	)
+	for _, blockStart := range []time.Time{outdated, current} {
+		w := newTestWriter(t, filePathPrefix)
+		writeTestData(t, w, 0, blockStart, testUpgradeEntries, persist.FileSetFlushType)
+	}
+	rewriteTestInfoFile(t, filePathPrefix, 0, outdated, func(info *schema.IndexInfo) {
+		info.MinorVersion = 0
+	})
+
+	result, err := UpgradeDataFileSets(testUpgradeOptions(filePathPrefix))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Upgraded))
+	require.True(t, outdated.Equal(result.Upgraded[0]))
+	require.Equal(t, 0, result.Remaining)
+
+	infos := readTestInfoFiles(t, filePathPrefix, 0)
+	require.Equal(t, 2, len(infos))
+	for _, info := range infos {
+		require.Equal(t, schema.CurrentFormatVersion, info.FormatVersion())
+	}
+
+	for _, blockStart := range []time.Time{outdated, current} {
+		r := newTestReader(t, filePathPrefix)
+		readTestData(t, r, 0, blockStart, testUpgradeEntries)
+	}
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	_, err = os.Stat(filepath.Join(shardDir, upgradeDirName))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestUpgradeDataFileSetsWaitsForOpeningReaders(t *testing.T) {
+	filePathPrefix := createTempDir(t)
+	defer os.RemoveAll(filePathPrefix)
+
+	blockStart := testWriterStart
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, blockStart, testUpgradeEntries, persist.FileSetFlushType)
+	rewriteTestInfoFile(t, filePathPrefix, 0, blockStart, func(info *schema.IndexInfo) {
+		info.MinorVersion = 0
+	})
+
+	// Hold the fileset as if a reader is opening it.
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	lock := dataFileSetLocks.lock(shardDir, blockStart)
+	lock.RLock()
+
+	doneCh := make(chan error)
+	go func() {
+		_, err := UpgradeDataFileSets(testUpgradeOptions(filePathPrefix))
+		doneCh <- err
+	}()
+
+	select {
+	case <-doneCh:
+		require.FailNow(t, "upgrade replaced fileset while it was being opened")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.True(t, FileExists(filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)))
+
+	lock.RUnlock()
+	require.NoError(t, <-doneCh)
+
+	infos := readTestInfoFiles(t, filePathPrefix, 0)
+	require.Equal(t, 1, len(infos))
+	require.Equal(t, schema.CurrentFormatVersion, infos[0].FormatVersion())
+	r := newTestReader(t, filePathPrefix)
+	readTestData(t, r, 0, blockStart, testUpgradeEntries)
+}
+
+func TestUpgradeDataFileSetsMaxFileSets(t *testing.T) {
+	filePathPrefix := createTempDir(t)
+	defer os.RemoveAll(filePathPrefix)
+
+	blockStarts := []time.Time{
+		testWriterStart,
+		testWriterStart.Add(testBlockSize),
+		testWriterStart.Add(2 * testBlockSize),
+	}
+	for _, blockStart := range blockStarts {
+		w := newTestWriter(t, filePathPrefix)
+		writeTestData(t, w, 0, blockStart, testUpgradeEntries, persist.FileSetFlushType)
+		rewriteTestInfoFile(t, filePathPrefix, 0, blockStart, func(info *schema.IndexInfo) {
+			info.MinorVersion = 0
+		})
+	}
+
+	opts := testUpgradeOptions(filePathPrefix)
+	opts.MaxFileSets = 2
+	result, err := UpgradeDataFileSets(opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(result.Upgraded))
+	require.Equal(t, 1, result.Remaining)
+
+	result, err = UpgradeDataFileSets(opts)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Upgraded))
+	require.Equal(t, 0, result.Remaining)
+
+	for _, blockStart := range blockStarts {
+		r := newTestReader(t, filePathPrefix)
+		readTestData(t, r, 0, blockStart, testUpgradeEntries)
+	}
+}
+
+func TestUpgradeDataFileSetsNewerMajorVersion(t *testing.T) {
+	filePathPrefix := createTempDir(t)
+	defer os.RemoveAll(filePathPrefix)
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, testUpgradeEntries, persist.FileSetFlushType)
+	rewriteTestInfoFile(t, filePathPrefix, 0, testWriterStart, func(info *schema.IndexInfo) {
+		info.MajorVersion = schema.MajorVersion + 1
+	})
+
+	result, err := UpgradeDataFileSets(testUpgradeOptions(filePathPrefix))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(result.Upgraded))
+	require.Equal(t, 0, result.Remaining)
+
+	r := newTestReader(t, filePathPrefix)
+	err = r.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	})
+	require.Error(t, err)
+}
+
+func TestUpgradeRateLimiter(t *testing.T) {
+	var (
+		now   = time.Unix(0, 0)
+		slept time.Duration
+		opts  = UpgradeDataFileSetsOptions{
+			RateLimitOptions: ratelimit.NewOptions().
+				SetLimitEnabled(true).
+				SetLimitMbps(8).
+				SetLimitCheckEvery(2),
+			nowFn:   func() time.Time { return now },
+			sleepFn: func(d time.Duration) { slept += d },
+		}
+		limiter = newUpgradeRateLimiter(opts)
+	)
+
+	// 8Mbps is 1MiB per second, the first write is not checked.
+	limiter.wait(512 * 1024)
+	require.Equal(t, time.Duration(0), slept)
+	limiter.wait(512 * 1024)
+	require.Equal(t, time.Second, slept)
+
+	now = now.Add(2 * time.Second)
+	limiter.wait(512 * 1024)
+	limiter.wait(512 * 1024)
+	require.Equal(t, time.Second, slept)
+}
@@ -32,9 +32,11 @@ import (
 	"github.com/m3db/bloom"
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/x/fault"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
@@ -43,6 +45,8 @@ import (
 var (
 	errWriterEncodeTagsDataNotAccessible = errors.New(
 		"failed to encode tags: cannot get data")
+	errWriterEncryptionKeyProviderNotSet = errors.New(
+		"failed to open writer: encryption enabled but no key provider set")
 )
 
 type writer struct {
@@ -61,8 +65,18 @@ type writer struct {
 	dataFdWithDigest           digest.FdWithDigestWriter
 	digestFdWithDigestContents digest.FdWithDigestContentsWriter
 	checkpointFilePath         string
+	dictionaryFilePath         string
 	indexEntries               indexEntries
 
+	dictionaryOpts       DataWriterCompressionDictionaryOptions
+	dictionaryCompressor *tagsDictionaryCompressor
+	dictionaryBuf        []byte
+
+	encryptionKeyProvider encryption.KeyProvider
+	cipher                encryption.Cipher
+	plaintextBuf          []byte
+	sealedBuf             []byte
+
 	start              time.Time
 	snapshotTime       time.Time
 	currIdx            int64
@@ -71,6 +85,7 @@ type writer struct {
 	digestBuf          digest.Buffer
 	singleCheckedBytes []checked.Bytes
 	tagEncoderPool     serialize.TagEncoderPool
+	faultInjector      fault.Injector
 	err                error
 }
 
@@ -126,6 +141,8 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 		digestBuf:                       digest.NewBuffer(),
 		singleCheckedBytes:              make([]checked.Bytes, 1),
 		tagEncoderPool:                  opts.TagEncoderPool(),
+		faultInjector:                   opts.FaultInjector(),
+		encryptionKeyProvider:           opts.EncryptionKeyProvider(),
 	}, nil
 }
 
@@ -144,10 +161,25 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 	w.blockSize = opts.BlockSize
 	w.start = blockStart
 	w.snapshotTime = opts.Snapshot.SnapshotTime
+	w.dictionaryOpts = opts.CompressionDictionary
 	w.currIdx = 0
 	w.currOffset = 0
 	w.err = nil
 
+	w.cipher = nil
+	if opts.Encryption.Enabled {
+		if w.encryptionKeyProvider == nil {
+			return errWriterEncryptionKeyProviderNotSet
+		}
+		// Resolve the current key once per fileset so all entries are
+		// encrypted with the same key, rotation takes effect on the next
+		// fileset written.
+		w.cipher, err = encryption.NewCurrentCipher(w.encryptionKeyProvider)
+		if err != nil {
+			return err
+		}
+	}
+
 	var (
 		shardDir            string
 		infoFilepath        string
@@ -174,6 +206,7 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 		bloomFilterFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, bloomFilterFileSuffix)
 		dataFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, dataFileSuffix)
 		digestFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, digestFileSuffix)
+		w.dictionaryFilePath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, dictionaryFileSuffix)
 	case persist.FileSetFlushType:
 		shardDir = ShardDataDirPath(w.filePathPrefix, namespace, shard)
 		if err := os.MkdirAll(shardDir, w.newDirectoryMode); err != nil {
@@ -187,6 +220,7 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 		bloomFilterFilepath = filesetPathFromTime(shardDir, blockStart, bloomFilterFileSuffix)
 		dataFilepath = filesetPathFromTime(shardDir, blockStart, dataFileSuffix)
 		digestFilepath = filesetPathFromTime(shardDir, blockStart, digestFileSuffix)
+		w.dictionaryFilePath = filesetPathFromTime(shardDir, blockStart, dictionaryFileSuffix)
 	default:
 		return fmt.Errorf("unable to open reader with fileset type: %s", opts.FileSetType)
 	}
@@ -272,6 +306,10 @@ func (w *writer) writeAll(
 		return nil
 	}
 
+	if err := w.faultInjector.Inject(fault.PointDiskWrite); err != nil {
+		return err
+	}
+
 	entry := indexEntry{
 		index:          w.currIdx,
 		id:             id,
@@ -280,13 +318,30 @@ func (w *writer) writeAll(
 		size:           uint32(size),
 		checksum:       checksum,
 	}
-	for _, d := range data {
-		if d == nil {
-			continue
+	if w.cipher != nil {
+		// The checksum remains that of the plaintext so that it matches the
+		// checksum of the block in memory and on other replicas.
+		w.plaintextBuf = w.plaintextBuf[:0]
+		for _, d := range data {
+			if d == nil {
+				continue
+			}
+			w.plaintextBuf = append(w.plaintextBuf, d.Bytes()...)
 		}
-		if err := w.writeData(d.Bytes()); err != nil {
+		w.sealedBuf = w.cipher.Seal(w.sealedBuf[:0], w.plaintextBuf)
+		entry.size = uint32(len(w.sealedBuf))
+		if err := w.writeData(w.sealedBuf); err != nil {
 			return err
 		}
+	} else {
+		for _, d := range data {
+			if d == nil {
+				continue
+			}
+			if err := w.writeData(d.Bytes()); err != nil {
+				return err
+			}
+		}
 	}
 
 	w.indexEntries = append(w.indexEntries, entry)
@@ -369,6 +424,13 @@ func (w *writer) writeIndexRelatedFiles() error {
 	m, k := bloom.EstimateFalsePositiveRate(n, p)
 	bloomFilter := bloom.NewBloomFilter(m, k)
 
+	if w.dictionaryOpts.Enabled {
+		if err := w.trainDictionary(); err != nil {
+			return err
+		}
+		defer w.closeDictionary()
+	}
+
 	err := w.writeIndexFileContents(bloomFilter, summaryEvery)
 	if err != nil {
 		return err
@@ -391,9 +453,58 @@ func (w *writer) writeIndexRelatedFiles() error {
 		return err
 	}
 
+	if err := w.writeDictionaryFileContents(); err != nil {
+		return err
+	}
+
 	return w.writeInfoFileContents(bloomFilter, summaries)
 }
 
+// trainDictionary trains a compression dictionary over the encoded tags of
+// an evenly spaced sample of the index entries.
+func (w *writer) trainDictionary() error {
+	sampleEvery := 1
+	if sampleSize := w.dictionaryOpts.SampleSize; sampleSize > 0 &&
+		len(w.indexEntries) > sampleSize {
+		sampleEvery = len(w.indexEntries) / sampleSize
+	}
+
+	var (
+		samples     [][]byte
+		tagsIter    = ident.NewTagsIterator(ident.Tags{})
+		tagsEncoder = w.tagEncoderPool.Get()
+	)
+	defer tagsEncoder.Finalize()
+	for i := 0; i < len(w.indexEntries); i += sampleEvery {
+		tags := w.indexEntries[i].tags
+		if tags.Values() == nil {
+			continue
+		}
+		tagsIter.Reset(tags)
+		tagsEncoder.Reset()
+		if err := tagsEncoder.Encode(tagsIter); err != nil {
+			return err
+		}
+		data, ok := tagsEncoder.Data()
+		if !ok {
+			return errWriterEncodeTagsDataNotAccessible
+		}
+		samples = append(samples, append([]byte(nil), data.Bytes()...))
+	}
+
+	compressor, err := newTagsDictionaryCompressor(samples,
+		w.dictionaryOpts.MaxBytes)
+	if err != nil {
+		return err
+	}
+	w.dictionaryCompressor = compressor
+	return nil
+}
+
+func (w *writer) closeDictionary() {
+	w.dictionaryCompressor = nil
+}
+
 func (w *writer) writeIndexFileContents(
 	bloomFilter *bloom.BloomFilter,
 	summaryEvery int,
@@ -434,6 +545,10 @@ func (w *writer) writeIndexFileContents(
 				return errWriterEncodeTagsDataNotAccessible
 			}
 			encodedTags = data.Bytes()
+			if w.dictionaryCompressor != nil {
+				w.dictionaryBuf = w.dictionaryCompressor.compress(w.dictionaryBuf, encodedTags)
+				encodedTags = w.dictionaryBuf
+			}
 		}
 
 		entry := schema.IndexEntry{
@@ -511,16 +626,45 @@ func (w *writer) writeBloomFilterFileContents(
 	return bloomFilter.BitSet().Write(w.bloomFilterFdWithDigest)
 }
 
+func (w *writer) writeDictionaryFileContents() error {
+	if w.dictionaryCompressor == nil {
+		return nil
+	}
+
+	fd, err := w.openWritable(w.dictionaryFilePath)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(w.dictionaryCompressor.dict); err != nil {
+		// Intentionally skipping fd.Close() error, as failure to write takes
+		// precedence over failure to close the file
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
 func (w *writer) writeInfoFileContents(
 	bloomFilter *bloom.BloomFilter,
 	summaries int,
 ) error {
+	var dictionary schema.IndexDictionaryInfo
+	if w.dictionaryCompressor != nil {
+		dictionary = w.dictionaryCompressor.info()
+	}
+
+	var encryptionKeyID []byte
+	if w.cipher != nil {
+		encryptionKeyID = []byte(w.cipher.KeyID())
+	}
+
 	info := schema.IndexInfo{
 		BlockStart:   xtime.ToNanoseconds(w.start),
 		SnapshotTime: xtime.ToNanoseconds(w.snapshotTime),
 		BlockSize:    int64(w.blockSize),
 		Entries:      w.currIdx,
 		MajorVersion: schema.MajorVersion,
+		MinorVersion: schema.MinorVersion,
 		Summaries: schema.IndexSummariesInfo{
 			Summaries: int64(summaries),
 		},
@@ -528,6 +672,8 @@ func (w *writer) writeInfoFileContents(
 			NumElementsM: int64(bloomFilter.M()),
 			NumHashesK:   int64(bloomFilter.K()),
 		},
+		Dictionary:      dictionary,
+		EncryptionKeyID: encryptionKeyID,
 	}
 
 	w.encoder.Reset()
@@ -21,6 +21,8 @@
 package schema
 
 import (
+	"fmt"
+
 	"github.com/m3db/m3/src/dbnode/persist"
 )
 
@@ -29,9 +31,46 @@ import (
 // tooling needs to upgrade older files to newer files before a server restart
 const MajorVersion = 1
 
+// MinorVersion is the minor schema version for a set of fileset files, this
+// is incremented when backwards compatible changes are introduced. Filesets
+// with an older minor version can still be read and are upgraded to the
+// current minor version in the background, filesets written before minor
+// versions were recorded have a minor version of zero.
+const MinorVersion = 1
+
+// FormatVersion is the major and minor schema version of a set of fileset
+// files.
+type FormatVersion struct {
+	Major int64
+	Minor int64
+}
+
+// CurrentFormatVersion is the format version of filesets written by this
+// version of M3DB.
+var CurrentFormatVersion = FormatVersion{Major: MajorVersion, Minor: MinorVersion}
+
+// Before returns whether the format version is older than the other.
+func (v FormatVersion) Before(other FormatVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	return v.Minor < other.Minor
+}
+
+// Readable returns whether filesets with the format version can be read by
+// this version of M3DB, filesets with a newer major version can not be read.
+func (v FormatVersion) Readable() bool {
+	return v.Major <= MajorVersion
+}
+
+func (v FormatVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
 // IndexInfo stores metadata information about block filesets
 type IndexInfo struct {
 	MajorVersion int64
+	MinorVersion int64
 	BlockStart   int64
 	BlockSize    int64
 	Entries      int64
@@ -39,6 +78,15 @@ type IndexInfo struct {
 	BloomFilter  IndexBloomFilterInfo
 	SnapshotTime int64
 	FileType     persist.FileSetType
+	Dictionary   IndexDictionaryInfo
+	// EncryptionKeyID is the ID of the key used to encrypt the data file
+	// contents, empty means the data file is not encrypted
+	EncryptionKeyID []byte
+}
+
+// FormatVersion returns the format version of the fileset.
+func (i IndexInfo) FormatVersion() FormatVersion {
+	return FormatVersion{Major: i.MajorVersion, Minor: i.MinorVersion}
 }
 
 // IndexSummariesInfo stores metadata about the summaries
@@ -52,6 +100,13 @@ type IndexBloomFilterInfo struct {
 	NumHashesK   int64
 }
 
+// IndexDictionaryInfo stores metadata about the compression dictionary used
+// for the encoded tags of the index entries, a zero size means no dictionary
+type IndexDictionaryInfo struct {
+	Size     int64
+	Checksum int64
+}
+
 // IndexEntry stores entry-level data indexing
 type IndexEntry struct {
 	Index       int64
@@ -74,6 +129,9 @@ type LogInfo struct {
 	Start    int64
 	Duration int64
 	Index    int64
+	// EncryptionKeyID is the ID of the key used to encrypt the commit log
+	// entries, empty means the entries are not encrypted
+	EncryptionKeyID []byte
 }
 
 // LogEntry stores per-entry data in a commit log
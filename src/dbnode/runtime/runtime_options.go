@@ -46,6 +46,8 @@ const (
 	defaultWriteNewSeriesAsync                  = false
 	defaultWriteNewSeriesBackoffDuration        = time.Duration(0)
 	defaultWriteNewSeriesLimitPerShardPerSecond = 0
+	defaultWriteNewSeriesBurstPerShard          = 0
+	defaultWriteNewSeriesOverflowPerShard       = 0
 	defaultTickSeriesBatchSize                  = 512
 	defaultTickPerSeriesSleepDuration           = 100 * time.Microsecond
 	defaultTickMinimumInterval                  = time.Minute
@@ -57,6 +59,12 @@ var (
 		"write new series backoff duration cannot be negative")
 	errWriteNewSeriesLimitPerShardPerSecondIsNegative = errors.New(
 		"write new series limit per shard per cannot be negative")
+	errWriteNewSeriesBurstPerShardIsNegative = errors.New(
+		"write new series burst per shard cannot be negative")
+	errWriteNewSeriesOverflowPerShardIsNegative = errors.New(
+		"write new series overflow per shard cannot be negative")
+	errWriteNewSeriesNamespaceLimitIsNegative = errors.New(
+		"write new series namespace limit per shard per second cannot be negative")
 	errTickSeriesBatchSizeMustBePositive = errors.New(
 		"tick series batch size must be positive")
 	errTickPerSeriesSleepDurationMustBePositive = errors.New(
@@ -68,6 +76,9 @@ type options struct {
 	writeNewSeriesAsync                  bool
 	writeNewSeriesBackoffDuration        time.Duration
 	writeNewSeriesLimitPerShardPerSecond int
+	writeNewSeriesBurstPerShard          int
+	writeNewSeriesOverflowPerShard       int
+	writeNewSeriesNamespaceLimits        map[string]int
 	tickSeriesBatchSize                  int
 	tickPerSeriesSleepDuration           time.Duration
 	tickMinimumInterval                  time.Duration
@@ -76,6 +87,9 @@ type options struct {
 	clientReadConsistencyLevel           topology.ReadConsistencyLevel
 	clientWriteConsistencyLevel          topology.ConsistencyLevel
 	flushIndexBlockNumSegments           uint
+	readOnly                             bool
+	readOnlyNamespaces                   []string
+	debugMetricsEnabled                  bool
 }
 
 // NewOptions creates a new set of runtime options with defaults
@@ -85,6 +99,8 @@ func NewOptions() Options {
 		writeNewSeriesAsync:                  defaultWriteNewSeriesAsync,
 		writeNewSeriesBackoffDuration:        defaultWriteNewSeriesBackoffDuration,
 		writeNewSeriesLimitPerShardPerSecond: defaultWriteNewSeriesLimitPerShardPerSecond,
+		writeNewSeriesBurstPerShard:          defaultWriteNewSeriesBurstPerShard,
+		writeNewSeriesOverflowPerShard:       defaultWriteNewSeriesOverflowPerShard,
 		tickSeriesBatchSize:                  defaultTickSeriesBatchSize,
 		tickPerSeriesSleepDuration:           defaultTickPerSeriesSleepDuration,
 		tickMinimumInterval:                  defaultTickMinimumInterval,
@@ -108,6 +124,20 @@ func (o *options) Validate() error {
 		return errWriteNewSeriesLimitPerShardPerSecondIsNegative
 	}
 
+	if o.writeNewSeriesBurstPerShard < 0 {
+		return errWriteNewSeriesBurstPerShardIsNegative
+	}
+
+	if o.writeNewSeriesOverflowPerShard < 0 {
+		return errWriteNewSeriesOverflowPerShardIsNegative
+	}
+
+	for _, limit := range o.writeNewSeriesNamespaceLimits {
+		if limit < 0 {
+			return errWriteNewSeriesNamespaceLimitIsNegative
+		}
+	}
+
 	if !(o.tickSeriesBatchSize > 0) {
 		return errTickSeriesBatchSizeMustBePositive
 	}
@@ -161,6 +191,36 @@ func (o *options) WriteNewSeriesLimitPerShardPerSecond() int {
 	return o.writeNewSeriesLimitPerShardPerSecond
 }
 
+func (o *options) SetWriteNewSeriesBurstPerShard(value int) Options {
+	opts := *o
+	opts.writeNewSeriesBurstPerShard = value
+	return &opts
+}
+
+func (o *options) WriteNewSeriesBurstPerShard() int {
+	return o.writeNewSeriesBurstPerShard
+}
+
+func (o *options) SetWriteNewSeriesOverflowPerShard(value int) Options {
+	opts := *o
+	opts.writeNewSeriesOverflowPerShard = value
+	return &opts
+}
+
+func (o *options) WriteNewSeriesOverflowPerShard() int {
+	return o.writeNewSeriesOverflowPerShard
+}
+
+func (o *options) SetWriteNewSeriesNamespaceLimitsPerShardPerSecond(value map[string]int) Options {
+	opts := *o
+	opts.writeNewSeriesNamespaceLimits = value
+	return &opts
+}
+
+func (o *options) WriteNewSeriesNamespaceLimitsPerShardPerSecond() map[string]int {
+	return o.writeNewSeriesNamespaceLimits
+}
+
 func (o *options) SetTickSeriesBatchSize(value int) Options {
 	opts := *o
 	opts.tickSeriesBatchSize = value
@@ -240,3 +300,33 @@ func (o *options) SetFlushIndexBlockNumSegments(value uint) Options {
 func (o *options) FlushIndexBlockNumSegments() uint {
 	return o.flushIndexBlockNumSegments
 }
+
+func (o *options) SetReadOnly(value bool) Options {
+	opts := *o
+	opts.readOnly = value
+	return &opts
+}
+
+func (o *options) ReadOnly() bool {
+	return o.readOnly
+}
+
+func (o *options) SetReadOnlyNamespaces(value []string) Options {
+	opts := *o
+	opts.readOnlyNamespaces = value
+	return &opts
+}
+
+func (o *options) ReadOnlyNamespaces() []string {
+	return o.readOnlyNamespaces
+}
+
+func (o *options) SetDebugMetricsEnabled(value bool) Options {
+	opts := *o
+	opts.debugMetricsEnabled = value
+	return &opts
+}
+
+func (o *options) DebugMetricsEnabled() bool {
+	return o.debugMetricsEnabled
+}
@@ -30,3 +30,23 @@ func TestRuntimeOptionsDefaultsIsValid(t *testing.T) {
 	v := NewOptions()
 	assert.NoError(t, v.Validate())
 }
+
+func TestRuntimeOptionsValidateNewSeriesLimits(t *testing.T) {
+	v := NewOptions().
+		SetWriteNewSeriesLimitPerShardPerSecond(100).
+		SetWriteNewSeriesBurstPerShard(50).
+		SetWriteNewSeriesOverflowPerShard(10).
+		SetWriteNewSeriesNamespaceLimitsPerShardPerSecond(map[string]int{
+			"metrics": 20,
+		})
+	assert.NoError(t, v.Validate())
+
+	assert.Equal(t, errWriteNewSeriesBurstPerShardIsNegative,
+		v.SetWriteNewSeriesBurstPerShard(-1).Validate())
+	assert.Equal(t, errWriteNewSeriesOverflowPerShardIsNegative,
+		v.SetWriteNewSeriesOverflowPerShard(-1).Validate())
+	assert.Equal(t, errWriteNewSeriesNamespaceLimitIsNegative,
+		v.SetWriteNewSeriesNamespaceLimitsPerShardPerSecond(map[string]int{
+			"metrics": -1,
+		}).Validate())
+}
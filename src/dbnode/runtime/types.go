@@ -77,6 +77,35 @@ type Options interface {
 	// time series being inserted.
 	WriteNewSeriesLimitPerShardPerSecond() int
 
+	// SetWriteNewSeriesBurstPerShard sets the max number of new series
+	// insertions that can exceed the insert rate limit per second by using
+	// capacity left unused in previous seconds, zero disables bursting.
+	SetWriteNewSeriesBurstPerShard(value int) Options
+
+	// WriteNewSeriesBurstPerShard returns the max number of new series
+	// insertions that can exceed the insert rate limit per second by using
+	// capacity left unused in previous seconds, zero disables bursting.
+	WriteNewSeriesBurstPerShard() int
+
+	// SetWriteNewSeriesOverflowPerShard sets the max number of new series
+	// insertions exceeding the insert rate limit that are deferred to the next
+	// second rather than rejected, zero rejects all insertions over the limit.
+	SetWriteNewSeriesOverflowPerShard(value int) Options
+
+	// WriteNewSeriesOverflowPerShard returns the max number of new series
+	// insertions exceeding the insert rate limit that are deferred to the next
+	// second rather than rejected, zero rejects all insertions over the limit.
+	WriteNewSeriesOverflowPerShard() int
+
+	// SetWriteNewSeriesNamespaceLimitsPerShardPerSecond sets the insert rate
+	// limits per second for specific namespaces keyed by namespace ID, when
+	// both a namespace limit and the insert rate limit are set the lower applies.
+	SetWriteNewSeriesNamespaceLimitsPerShardPerSecond(value map[string]int) Options
+
+	// WriteNewSeriesNamespaceLimitsPerShardPerSecond returns the insert rate
+	// limits per second for specific namespaces keyed by namespace ID.
+	WriteNewSeriesNamespaceLimitsPerShardPerSecond() map[string]int
+
 	// SetTickSeriesBatchSize sets the batch size to process series together
 	// during a tick before yielding and sleeping the per series duration
 	// multiplied by the batch size.
@@ -172,6 +201,32 @@ type Options interface {
 	// greater amount of segments that need to be searched independently but
 	// a higher number reduces the memory pressure when flushing an index block.
 	FlushIndexBlockNumSegments() uint
+
+	// SetReadOnly sets whether the node rejects writes to all namespaces
+	// while continuing to serve reads, this can be used during maintenance,
+	// migrations or when disks are approaching capacity.
+	SetReadOnly(value bool) Options
+
+	// ReadOnly returns whether the node rejects writes to all namespaces
+	// while continuing to serve reads.
+	ReadOnly() bool
+
+	// SetReadOnlyNamespaces sets the IDs of namespaces that reject writes
+	// while continuing to serve reads, independent of whether the node
+	// is read only.
+	SetReadOnlyNamespaces(value []string) Options
+
+	// ReadOnlyNamespaces returns the IDs of namespaces that reject writes
+	// while continuing to serve reads.
+	ReadOnlyNamespaces() []string
+
+	// SetDebugMetricsEnabled sets whether to emit debug metrics that are too
+	// expensive to emit at all times, such as per shard method timings.
+	SetDebugMetricsEnabled(value bool) Options
+
+	// DebugMetricsEnabled returns whether to emit debug metrics that are too
+	// expensive to emit at all times.
+	DebugMetricsEnabled() bool
 }
 
 // OptionsManager updates and supplies runtime options.
@@ -33,6 +33,8 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/cmd/services/m3dbnode/config"
+	"github.com/m3db/m3/src/dbnode/backup"
+	"github.com/m3db/m3/src/dbnode/blocksize"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
@@ -58,6 +60,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/loglevel"
 	"github.com/m3db/m3/src/dbnode/x/mmap"
 	"github.com/m3db/m3/src/dbnode/x/tchannel"
 	"github.com/m3db/m3/src/dbnode/x/xio"
@@ -129,11 +132,21 @@ func Run(runOpts RunOptions) {
 		cfg = runOpts.Config
 	}
 
-	logger, err := cfg.Logging.BuildLogger()
+	// Build the logger logging at every level and control its level with the
+	// configured level so that the level can be changed at runtime.
+	logLevel, err := loglevel.ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to parse log level: %v", err)
+		os.Exit(1)
+	}
+	loggingCfg := cfg.Logging
+	loggingCfg.Level = loglevel.LevelString(xlog.LevelDebug)
+	underlyingLogger, err := loggingCfg.BuildLogger()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "unable to create logger: %v", err)
 		os.Exit(1)
 	}
+	logger := loglevel.NewController(logLevel).Logger(underlyingLogger)
 
 	debug.SetGCPercent(cfg.GCPercentage)
 
@@ -219,7 +232,8 @@ func Run(runOpts RunOptions) {
 			SetLimitMbps(cfg.Filesystem.ThroughputLimitMbps).
 			SetLimitCheckEvery(cfg.Filesystem.ThroughputCheckEvery)).
 		SetWriteNewSeriesAsync(cfg.WriteNewSeriesAsync).
-		SetWriteNewSeriesBackoffDuration(cfg.WriteNewSeriesBackoffDuration)
+		SetWriteNewSeriesBackoffDuration(cfg.WriteNewSeriesBackoffDuration).
+		SetWriteNewSeriesOverflowPerShard(cfg.WriteNewSeriesOverflowPerShard)
 	if lruCfg := cfg.Cache.SeriesConfiguration().LRU; lruCfg != nil {
 		runtimeOpts = runtimeOpts.SetMaxWiredBlocks(lruCfg.MaxBlocks)
 	}
@@ -233,6 +247,21 @@ func Run(runOpts RunOptions) {
 	opts = opts.SetIndexOptions(
 		indexOpts.SetInsertMode(insertMode))
 
+	if budgetCfg := cfg.Index.MemoryBudget; budgetCfg != nil {
+		memoryBudget, err := index.NewMemoryBudget(index.MemoryBudgetOptions{
+			LimitBytes:        budgetCfg.LimitBytes,
+			TargetRatio:       budgetCfg.TargetRatio,
+			Directory:         budgetCfg.Directory,
+			InstrumentOptions: iopts,
+		})
+		if err != nil {
+			logger.Fatalf("could not create index memory budget: %v", err)
+		}
+		defer memoryBudget.Close()
+
+		opts = opts.SetIndexOptions(opts.IndexOptions().SetMemoryBudget(memoryBudget))
+	}
+
 	if tick := cfg.Tick; tick != nil {
 		runtimeOpts = runtimeOpts.
 			SetTickSeriesBatchSize(tick.SeriesBatchSize).
@@ -258,6 +287,11 @@ func Run(runOpts RunOptions) {
 		logger.Fatalf("could not parse new directory mode: %v", err)
 	}
 
+	encryptionKeyProvider, err := cfg.Filesystem.EncryptionKeyProvider()
+	if err != nil {
+		logger.Fatalf("could not create encryption key provider: %v", err)
+	}
+
 	mmapCfg := cfg.Filesystem.MmapConfiguration()
 	shouldUseHugeTLB := mmapCfg.HugeTLB.Enabled
 	if shouldUseHugeTLB {
@@ -287,6 +321,7 @@ func Run(runOpts RunOptions) {
 		SetInstrumentOptions(opts.InstrumentOptions().
 			SetMetricsScope(scope.SubScope("database.fs"))).
 		SetFilePathPrefix(cfg.Filesystem.FilePathPrefix).
+		SetDataVolumes(cfg.Filesystem.DataVolumes).
 		SetNewFileMode(newFileMode).
 		SetNewDirectoryMode(newDirectoryMode).
 		SetWriterBufferSize(cfg.Filesystem.WriteBufferSize).
@@ -297,7 +332,9 @@ func Run(runOpts RunOptions) {
 		SetMmapHugeTLBThreshold(mmapCfg.HugeTLB.Threshold).
 		SetRuntimeOptionsManager(runtimeOptsMgr).
 		SetTagEncoderPool(tagEncoderPool).
-		SetTagDecoderPool(tagDecoderPool)
+		SetTagDecoderPool(tagDecoderPool).
+		SetEncryptionKeyProvider(encryptionKeyProvider).
+		SetIndexSegmentWriterOpts(cfg.Filesystem.IndexSegmentWriterOpts())
 
 	var commitLogQueueSize int
 	specified := cfg.CommitLog.Queue.Size
@@ -342,6 +379,9 @@ func Run(runOpts RunOptions) {
 		if blockRetrieveCfg := cfg.BlockRetrieve; blockRetrieveCfg != nil {
 			retrieverOpts = retrieverOpts.
 				SetFetchConcurrency(blockRetrieveCfg.FetchConcurrency)
+			if v := blockRetrieveCfg.PrefetchBlocks; v != nil {
+				retrieverOpts = retrieverOpts.SetPrefetchBlocks(*v)
+			}
 		}
 		blockRetrieverMgr := block.NewDatabaseBlockRetrieverManager(
 			func(md namespace.Metadata) (block.DatabaseBlockRetriever, error) {
@@ -413,7 +453,8 @@ func Run(runOpts RunOptions) {
 		client.ConfigurationParameters{
 			InstrumentOptions: iopts.
 				SetMetricsScope(iopts.MetricsScope().SubScope("m3dbclient")),
-			TopologyInitializer: envCfg.TopologyInitializer,
+			TopologyInitializer:  envCfg.TopologyInitializer,
+			NamespaceInitializer: envCfg.NamespaceInitializer,
 		},
 		func(opts client.AdminOptions) client.AdminOptions {
 			return opts.SetRuntimeOptionsManager(runtimeOptsMgr).(client.AdminOptions)
@@ -436,6 +477,7 @@ func Run(runOpts RunOptions) {
 	clientAdminOpts := m3dbClient.Options().(client.AdminOptions)
 	kvWatchClientConsistencyLevels(envCfg.KVStore, logger,
 		clientAdminOpts, runtimeOptsMgr)
+	kvWatchReadOnly(envCfg.KVStore, logger, runtimeOptsMgr)
 
 	// Set bootstrap options
 	bs, err := cfg.Bootstrap.New(opts, m3dbClient)
@@ -469,16 +511,42 @@ func Run(runOpts RunOptions) {
 			scope.SubScope("host-block-metadata-slice-pool")),
 		policy.HostBlockMetadataSlicePool.Capacity)
 
+	repairOpts := opts.RepairOptions().
+		SetAdminClient(m3dbClient).
+		SetRepairInterval(cfg.Repair.Interval).
+		SetRepairTimeOffset(cfg.Repair.Offset).
+		SetRepairTimeJitter(cfg.Repair.Jitter).
+		SetRepairThrottle(cfg.Repair.Throttle).
+		SetRepairCheckInterval(cfg.Repair.CheckInterval).
+		SetHostBlockMetadataSlicePool(hostBlockMetadataSlicePool).
+		SetConsistencyCheckInterval(cfg.Repair.ConsistencyCheckInterval)
+	if cfg.Repair.ConsistencyCheckSampleSize > 0 {
+		repairOpts = repairOpts.
+			SetConsistencyCheckSampleSize(cfg.Repair.ConsistencyCheckSampleSize)
+	}
+
 	opts = opts.
 		SetRepairEnabled(cfg.Repair.Enabled).
-		SetRepairOptions(opts.RepairOptions().
-			SetAdminClient(m3dbClient).
-			SetRepairInterval(cfg.Repair.Interval).
-			SetRepairTimeOffset(cfg.Repair.Offset).
-			SetRepairTimeJitter(cfg.Repair.Jitter).
-			SetRepairThrottle(cfg.Repair.Throttle).
-			SetRepairCheckInterval(cfg.Repair.CheckInterval).
-			SetHostBlockMetadataSlicePool(hostBlockMetadataSlicePool))
+		SetRepairOptions(repairOpts)
+
+	if cleanupCfg := cfg.Cleanup; cleanupCfg != nil {
+		opts = opts.SetCleanupDryRun(cleanupCfg.DryRun)
+	}
+
+	if initCfg := cfg.InitializingShards; initCfg != nil {
+		opts = opts.SetInitializingShardWriteBufferSize(initCfg.WriteBufferSize)
+	}
+
+	if cdcCfg := cfg.ChangeDataCapture; cdcCfg != nil {
+		changeEmitter, err := cdcCfg.NewEmitter(iopts.SetMetricsScope(
+			scope.SubScope("change-data-capture")))
+		if err != nil {
+			logger.Fatalf("could not create change data capture emitter: %v", err)
+		}
+		defer changeEmitter.Close()
+		opts = opts.SetChangeEmitter(changeEmitter)
+		logger.Infof("emitting change events to %s", cdcCfg.Webhook.URL)
+	}
 
 	// Set tchannelthrift options
 	blockMetadataPool := tchannelthrift.NewBlockMetadataPool(
@@ -499,7 +567,18 @@ func Run(runOpts RunOptions) {
 		SetBlocksMetadataPool(blocksMetadataPool).
 		SetBlocksMetadataSlicePool(blocksMetadataSlicePool).
 		SetTagEncoderPool(tagEncoderPool).
-		SetTagDecoderPool(tagDecoderPool)
+		SetTagDecoderPool(tagDecoderPool).
+		SetPlacementFence(topology.NewFence(topo, hostID))
+	if shutdownCfg := cfg.GracefulShutdown; shutdownCfg != nil {
+		ttopts = ttopts.SetCloseDrainTimeout(shutdownCfg.DrainTimeout)
+	}
+	if bucketsCfg := cfg.LatencyBuckets; bucketsCfg != nil {
+		buckets, err := bucketsCfg.NewBuckets()
+		if err != nil {
+			logger.Fatalf("could not create latency buckets: %v", err)
+		}
+		ttopts = ttopts.SetLatencyBuckets(buckets)
+	}
 
 	db, err := cluster.NewDatabase(hostID, envCfg.TopologyInitializer, opts)
 	if err != nil {
@@ -571,8 +650,28 @@ func Run(runOpts RunOptions) {
 		logger.Infof("bootstrapped")
 
 		// Only set the write new series limit after bootstrapping
+		err := setNewSeriesBurstAndNamespaceLimitsPerShard(topo, runtimeOptsMgr,
+			cfg.WriteNewSeriesBurst, cfg.WriteNewSeriesNamespaceLimitsPerSecond)
+		if err != nil {
+			logger.Warnf("unable to set new series burst and namespace limits: %v", err)
+		}
 		kvWatchNewSeriesLimitPerShard(envCfg.KVStore, logger, topo,
 			runtimeOptsMgr, cfg.WriteNewSeriesLimitPerSecond)
+
+		// Only take part in cluster backups once bootstrapped so that
+		// snapshots contain all the data owned by this node
+		backupNode := backup.NewNode(envCfg.KVStore, db, hostID,
+			cfg.Filesystem.FilePathPrefix, logger)
+		if err := backupNode.Start(); err != nil {
+			logger.Errorf("could not watch for cluster backups: %v", err)
+		}
+
+		// Only apply block size recommendations once bootstrapped so that
+		// recommendations are made from the writes the node accepts
+		if policy := cfg.BlockSizeRecommendation; policy != nil && policy.AutoApply {
+			blocksize.NewApplier(envCfg.KVStore, db, blocksize.NewOptions(),
+				logger).Start(policy.Interval)
+		}
 	}()
 
 	// Handle interrupt
@@ -592,6 +691,10 @@ func Run(runOpts RunOptions) {
 
 	logger.Warnf("interrupt: %v", interruptErr)
 
+	if shutdownCfg := cfg.GracefulShutdown; shutdownCfg != nil {
+		gracefulShutdown(logger, db, shutdownCfg, tchannelthriftNodeClose)
+	}
+
 	// Attempt graceful server close
 	closedCh := make(chan struct{})
 	go func() {
@@ -612,6 +715,39 @@ func Run(runOpts RunOptions) {
 	}
 }
 
+// gracefulShutdown reports the node as unhealthy for the drain period so that
+// clients route away from it, stops accepting RPCs and waits for in flight
+// RPCs to complete, and then optionally flushes and snapshots unflushed series
+// so that the commit log does not need to be replayed on restart.
+func gracefulShutdown(
+	logger xlog.Logger,
+	db storage.Database,
+	cfg *config.GracefulShutdownPolicy,
+	nodeClose func(),
+) {
+	drainStart := time.Now()
+	if err := db.Drain(false); err != nil {
+		logger.Errorf("drain database error: %v", err)
+	}
+
+	if remaining := cfg.DrainPeriod - time.Since(drainStart); remaining > 0 {
+		logger.Infof("draining for %s before closing node service", remaining.String())
+		time.Sleep(remaining)
+	}
+
+	// Stop accepting RPCs and wait for in flight RPCs to complete
+	nodeClose()
+	logger.Infof("node service closed")
+
+	if cfg.SnapshotOnExit {
+		if err := db.Drain(true); err != nil {
+			logger.Errorf("snapshot on exit error: %v", err)
+		} else {
+			logger.Infof("snapshot on exit complete")
+		}
+	}
+}
+
 func interrupt() <-chan os.Signal {
 	c := make(chan os.Signal)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
@@ -747,6 +883,73 @@ func kvWatchClientConsistencyLevels(
 		})
 }
 
+func kvWatchReadOnly(
+	store kv.Store,
+	logger xlog.Logger,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	opts := util.NewOptions().SetLogger(logger)
+
+	kvWatchValue(store, logger, kvconfig.ReadOnlyKey,
+		func(value kv.Value) error {
+			readOnly, err := util.BoolFromValue(value,
+				kvconfig.ReadOnlyKey, false, opts)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetReadOnly(readOnly))
+		})
+
+	kvWatchValue(store, logger, kvconfig.ReadOnlyNamespacesKey,
+		func(value kv.Value) error {
+			namespaces, err := util.StringArrayFromValue(value,
+				kvconfig.ReadOnlyNamespacesKey, nil, opts)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetReadOnlyNamespaces(namespaces))
+		})
+}
+
+// kvWatchValue calls onValue with the current value of a KV key and then
+// with each update, the value is nil if the key is not set or deleted.
+func kvWatchValue(
+	store kv.Store,
+	logger xlog.Logger,
+	key string,
+	onValue func(value kv.Value) error,
+) {
+	// First try to eagerly set the value so it doesn't flap if the
+	// watch returns but not immediately for an existing value
+	value, err := store.Get(key)
+	if err != nil && err != kv.ErrNotFound {
+		logger.Errorf("could not resolve KV key %s: %v", key, err)
+	}
+	if err == nil {
+		if err := onValue(value); err != nil {
+			logger.Errorf("could not process value of KV key %s: %v", key, err)
+		}
+	}
+
+	watch, err := store.Watch(key)
+	if err != nil {
+		logger.Errorf("could not watch KV key %s: %v", key, err)
+		return
+	}
+
+	go func() {
+		for range watch.C() {
+			if err := onValue(watch.Get()); err != nil {
+				logger.Warnf("could not process change for KV key %s: %v", key, err)
+				continue
+			}
+			logger.Infof("updated KV key %s", key)
+		}
+	}()
+}
+
 func kvWatchStringValue(
 	store kv.Store,
 	logger xlog.Logger,
@@ -819,6 +1022,27 @@ func setNewSeriesLimitPerShardOnChange(
 	return runtimeOptsMgr.Update(newRuntimeOpts)
 }
 
+func setNewSeriesBurstAndNamespaceLimitsPerShard(
+	topo topology.Topology,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+	clusterBurst int,
+	clusterNamespaceLimits map[string]int,
+) error {
+	if clusterBurst < 1 && len(clusterNamespaceLimits) == 0 {
+		return nil
+	}
+
+	namespaceLimits := make(map[string]int, len(clusterNamespaceLimits))
+	for namespace, clusterLimit := range clusterNamespaceLimits {
+		namespaceLimits[namespace] = clusterLimitToPlacedShardLimit(topo, clusterLimit)
+	}
+
+	newRuntimeOpts := runtimeOptsMgr.Get().
+		SetWriteNewSeriesBurstPerShard(clusterLimitToPlacedShardLimit(topo, clusterBurst)).
+		SetWriteNewSeriesNamespaceLimitsPerShardPerSecond(namespaceLimits)
+	return runtimeOptsMgr.Update(newRuntimeOpts)
+}
+
 func clusterLimitToPlacedShardLimit(topo topology.Topology, clusterLimit int) int {
 	if clusterLimit < 1 {
 		return 0
@@ -1002,10 +1226,15 @@ func withEncodingAndPoolingOptions(
 		SetSegmentReaderPool(segmentReaderPool).
 		SetBytesPool(bytesPool)
 
-	if opts.SeriesCachePolicy() == series.CacheLRU {
+	switch opts.SeriesCachePolicy() {
+	case series.CacheLRU:
 		runtimeOpts := opts.RuntimeOptionsManager()
 		wiredList := block.NewWiredList(runtimeOpts, iopts, opts.ClockOptions())
 		blockOpts = blockOpts.SetWiredList(wiredList)
+	case series.CacheTinyLFU:
+		runtimeOpts := opts.RuntimeOptionsManager()
+		wiredList := block.NewTinyLFUWiredList(runtimeOpts, iopts, opts.ClockOptions())
+		blockOpts = blockOpts.SetWiredList(wiredList)
 	}
 	blockPool := block.NewDatabaseBlockPool(poolOptions(policy.BlockPool,
 		scope.SubScope("block-pool")))
@@ -39,10 +39,11 @@ var (
 )
 
 type shardSet struct {
-	shards   []shard.Shard
-	ids      []uint32
-	shardMap map[uint32]shard.Shard
-	fn       HashFn
+	shards    []shard.Shard
+	ids       []uint32
+	shardMap  map[uint32]shard.Shard
+	fn        HashFn
+	numShards int
 }
 
 // NewShardSet creates a new sharding scheme with a set of shards
@@ -50,15 +51,28 @@ func NewShardSet(shards []shard.Shard, fn HashFn) (ShardSet, error) {
 	if err := validateShards(shards); err != nil {
 		return nil, err
 	}
-	return newValidatedShardSet(shards, fn), nil
+	return newValidatedShardSet(shards, fn, 0), nil
+}
+
+// NewShardSetWithNumShards creates a new sharding scheme with a set of shards
+// that are a subset of a total number of shards in the cluster
+func NewShardSetWithNumShards(
+	shards []shard.Shard,
+	fn HashFn,
+	numShards int,
+) (ShardSet, error) {
+	if err := validateShards(shards); err != nil {
+		return nil, err
+	}
+	return newValidatedShardSet(shards, fn, numShards), nil
 }
 
 // NewEmptyShardSet creates a new sharding scheme with an empty set of shards
 func NewEmptyShardSet(fn HashFn) ShardSet {
-	return newValidatedShardSet(nil, fn)
+	return newValidatedShardSet(nil, fn, 0)
 }
 
-func newValidatedShardSet(shards []shard.Shard, fn HashFn) ShardSet {
+func newValidatedShardSet(shards []shard.Shard, fn HashFn, numShards int) ShardSet {
 	ids := make([]uint32, len(shards))
 	shardMap := make(map[uint32]shard.Shard, len(shards))
 	for i, shard := range shards {
@@ -66,10 +80,11 @@ func newValidatedShardSet(shards []shard.Shard, fn HashFn) ShardSet {
 		shardMap[shard.ID()] = shard
 	}
 	return &shardSet{
-		shards:   shards,
-		ids:      ids,
-		shardMap: shardMap,
-		fn:       fn,
+		shards:    shards,
+		ids:       ids,
+		shardMap:  shardMap,
+		fn:        fn,
+		numShards: numShards,
 	}
 }
 
@@ -117,6 +132,10 @@ func (s *shardSet) HashFn() HashFn {
 	return s.fn
 }
 
+func (s *shardSet) NumShards() int {
+	return s.numShards
+}
+
 // NewShards returns a new slice of shards with a specified state
 func NewShards(ids []uint32, state shard.State) []shard.Shard {
 	shards := make([]shard.Shard, len(ids))
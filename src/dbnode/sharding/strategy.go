@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3x/ident"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// HashStrategy is a strategy for hashing series IDs to shards.
+type HashStrategy uint
+
+const (
+	// Murmur3HashStrategy hashes the whole series ID with murmur3 modulo the
+	// number of shards, this is the original and default strategy.
+	Murmur3HashStrategy HashStrategy = iota
+	// JumpHashStrategy hashes the whole series ID with jump consistent
+	// hashing so that increasing the number of shards moves the minimum
+	// number of series between shards.
+	JumpHashStrategy
+	// TagSubsetHashStrategy hashes only a subset of the tags encoded in the
+	// series ID so that all series sharing the same values for those tags
+	// land on the same shard.
+	TagSubsetHashStrategy
+
+	// DefaultHashStrategy is the default hash strategy.
+	DefaultHashStrategy = Murmur3HashStrategy
+)
+
+var (
+	errHashStrategyUnspecified  = errors.New("hash strategy not specified")
+	errJumpHashNumShardsUnknown = errors.New(
+		"jump hash strategy requires the total number of shards")
+	errTagSubsetNoTagNames = errors.New(
+		"tag subset hash strategy requires at least one tag name")
+)
+
+const (
+	tagSeparator      = byte(',')
+	tagValueSeparator = byte('=')
+)
+
+// ValidHashStrategies returns the valid hash strategies.
+func ValidHashStrategies() []HashStrategy {
+	return []HashStrategy{Murmur3HashStrategy, JumpHashStrategy, TagSubsetHashStrategy}
+}
+
+func (s HashStrategy) String() string {
+	switch s {
+	case Murmur3HashStrategy:
+		return "murmur3"
+	case JumpHashStrategy:
+		return "jump"
+	case TagSubsetHashStrategy:
+		return "tag_subset"
+	}
+	return "unknown"
+}
+
+// ValidateHashStrategy validates a hash strategy.
+func ValidateHashStrategy(v HashStrategy) error {
+	for _, valid := range ValidHashStrategies() {
+		if valid == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid hash strategy '%d' valid types are: %v",
+		uint(v), ValidHashStrategies())
+}
+
+// ParseHashStrategy parses a HashStrategy from a string.
+func ParseHashStrategy(str string) (HashStrategy, error) {
+	var r HashStrategy
+	if str == "" {
+		return r, errHashStrategyUnspecified
+	}
+	for _, valid := range ValidHashStrategies() {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return r, fmt.Errorf("invalid hash strategy '%s' valid types are: %v",
+		str, ValidHashStrategies())
+}
+
+// UnmarshalYAML unmarshals a HashStrategy into a valid type from string.
+func (s *HashStrategy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseHashStrategy(str)
+	if err != nil {
+		return err
+	}
+	*s = r
+	return nil
+}
+
+// NewStrategyHashFn returns a HashFn for a shard set that uses the given
+// hash strategy, tag names are only used by the tag subset strategy and
+// are matched against series IDs encoded as sorted "name=value," pairs.
+func NewStrategyHashFn(
+	shardSet ShardSet,
+	strategy HashStrategy,
+	tagNames []string,
+) (HashFn, error) {
+	switch strategy {
+	case Murmur3HashStrategy:
+		return shardSet.HashFn(), nil
+	case JumpHashStrategy:
+		numShards := shardSet.NumShards()
+		if numShards <= 0 {
+			return nil, errJumpHashNumShardsUnknown
+		}
+		return NewJumpHashFn(numShards), nil
+	case TagSubsetHashStrategy:
+		if len(tagNames) == 0 {
+			return nil, errTagSubsetNoTagNames
+		}
+		return newTagSubsetHashFn(shardSet.HashFn(), tagNames), nil
+	}
+	return nil, ValidateHashStrategy(strategy)
+}
+
+// NewJumpHashFn generates a HashFn based on jump consistent hashing of the
+// 64 bit murmur3 hash of the ID.
+func NewJumpHashFn(numShards int) HashFn {
+	return func(id ident.ID) uint32 {
+		return uint32(jumpHash(murmur3.Sum64(id.Bytes()), numShards))
+	}
+}
+
+// jumpHash is the jump consistent hash from "A Fast, Minimal Memory,
+// Consistent Hash Algorithm" by Lamping and Veach.
+func jumpHash(key uint64, numBuckets int) int32 {
+	var b, j int64 = -1, 0
+	for j < int64(numBuckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int32(b)
+}
+
+func newTagSubsetHashFn(fn HashFn, tagNames []string) HashFn {
+	names := make([][]byte, 0, len(tagNames))
+	for _, name := range tagNames {
+		names = append(names, []byte(name))
+	}
+	return func(id ident.ID) uint32 {
+		var (
+			remaining = id.Bytes()
+			key       []byte
+		)
+		for len(remaining) > 0 {
+			pair := remaining
+			if idx := bytes.IndexByte(remaining, tagSeparator); idx >= 0 {
+				pair, remaining = remaining[:idx], remaining[idx+1:]
+			} else {
+				remaining = nil
+			}
+			idx := bytes.IndexByte(pair, tagValueSeparator)
+			if idx < 0 {
+				continue
+			}
+			for _, name := range names {
+				if bytes.Equal(pair[:idx], name) {
+					key = append(key, pair...)
+					key = append(key, tagSeparator)
+					break
+				}
+			}
+		}
+		if len(key) == 0 {
+			// None of the tags are present, fall back to the whole ID.
+			return fn(id)
+		}
+		return fn(ident.BytesID(key))
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3cluster/shard"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newTestStrategyShardSet(t *testing.T, numShards int) ShardSet {
+	ids := make([]uint32, numShards)
+	for i := range ids {
+		ids[i] = uint32(i)
+	}
+	ss, err := NewShardSetWithNumShards(NewShards(ids, shard.Available),
+		DefaultHashFn(numShards), numShards)
+	require.NoError(t, err)
+	return ss
+}
+
+func TestHashStrategyUnmarshalYAML(t *testing.T) {
+	for _, valid := range ValidHashStrategies() {
+		var s HashStrategy
+		require.NoError(t, yaml.Unmarshal([]byte(valid.String()), &s))
+		require.Equal(t, valid, s)
+	}
+
+	var s HashStrategy
+	require.Error(t, yaml.Unmarshal([]byte("unknown"), &s))
+}
+
+func TestStrategyHashFnMurmur3(t *testing.T) {
+	ss := newTestStrategyShardSet(t, 64)
+	fn, err := NewStrategyHashFn(ss, Murmur3HashStrategy, nil)
+	require.NoError(t, err)
+
+	id := ident.StringID("foo")
+	require.Equal(t, ss.Lookup(id), fn(id))
+}
+
+func TestStrategyHashFnJump(t *testing.T) {
+	_, err := NewStrategyHashFn(NewEmptyShardSet(DefaultHashFn(1)),
+		JumpHashStrategy, nil)
+	require.Equal(t, errJumpHashNumShardsUnknown, err)
+
+	small, err := NewStrategyHashFn(newTestStrategyShardSet(t, 64),
+		JumpHashStrategy, nil)
+	require.NoError(t, err)
+	large, err := NewStrategyHashFn(newTestStrategyShardSet(t, 65),
+		JumpHashStrategy, nil)
+	require.NoError(t, err)
+
+	// Growing the number of shards should only move series to the new shard.
+	for i := 0; i < 1000; i++ {
+		id := ident.StringID(fmt.Sprintf("foo.%d", i))
+		before, after := small(id), large(id)
+		require.True(t, before < 64)
+		require.True(t, after == before || after == 64)
+	}
+}
+
+func TestStrategyHashFnTagSubset(t *testing.T) {
+	ss := newTestStrategyShardSet(t, 1024)
+	_, err := NewStrategyHashFn(ss, TagSubsetHashStrategy, nil)
+	require.Equal(t, errTagSubsetNoTagNames, err)
+
+	fn, err := NewStrategyHashFn(ss, TagSubsetHashStrategy,
+		[]string{"service"})
+	require.NoError(t, err)
+
+	first := fn(ident.StringID("__name__=cpu,host=a,service=foo,"))
+	require.Equal(t, first, fn(ident.StringID("__name__=mem,host=b,service=foo,")))
+	require.Equal(t, first, fn(ident.StringID("service=foo")))
+	require.Equal(t, ss.Lookup(ident.StringID("service=foo,")), first)
+
+	// IDs without any of the tags fall back to hashing the whole ID.
+	id := ident.StringID("__name__=cpu,host=a,")
+	require.Equal(t, ss.Lookup(id), fn(id))
+}
@@ -54,4 +54,8 @@ type ShardSet interface {
 
 	// HashFn returns the sharding hash function
 	HashFn() HashFn
+
+	// NumShards returns the total number of shards in the cluster this
+	// shard set is drawn from, or zero if it is not known
+	NumShards() int
 }
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package block
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+const (
+	// frequencySketchDepth is the number of counters each block increments.
+	frequencySketchDepth = 4
+	// frequencySketchWidth is the number of counters in each row, must be a
+	// power of two.
+	frequencySketchWidth = 1 << 16
+	// frequencySketchMaxCount is the maximum value of a counter.
+	frequencySketchMaxCount = 15
+	// frequencySketchSampleSize is the number of increments after which all
+	// counters are halved so that the sketch favors recent reads.
+	frequencySketchSampleSize = 10 * frequencySketchWidth
+)
+
+// frequencySketch is a count-min sketch estimating how often blocks are read
+// which the wired list uses for its TinyLFU admission policy. Counters are
+// small and periodically halved, so the sketch uses a fixed amount of memory
+// and the estimates of blocks which stop being read decay over time.
+type frequencySketch struct {
+	counters  [frequencySketchDepth][frequencySketchWidth]uint8
+	additions int
+}
+
+func newFrequencySketch() *frequencySketch {
+	return &frequencySketch{}
+}
+
+// increment increments the estimated read frequency of the block.
+func (s *frequencySketch) increment(entry wiredListEntry) {
+	h1, h2 := frequencySketchHashes(entry)
+	added := false
+	for i := 0; i < frequencySketchDepth; i++ {
+		idx := frequencySketchIndex(h1, h2, i)
+		if s.counters[i][idx] < frequencySketchMaxCount {
+			s.counters[i][idx]++
+			added = true
+		}
+	}
+
+	if !added {
+		return
+	}
+
+	s.additions++
+	if s.additions >= frequencySketchSampleSize {
+		s.reset()
+	}
+}
+
+// estimate returns the estimated read frequency of the block.
+func (s *frequencySketch) estimate(entry wiredListEntry) uint8 {
+	h1, h2 := frequencySketchHashes(entry)
+	min := uint8(frequencySketchMaxCount)
+	for i := 0; i < frequencySketchDepth; i++ {
+		if v := s.counters[i][frequencySketchIndex(h1, h2, i)]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// reset halves all counters.
+func (s *frequencySketch) reset() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func frequencySketchHashes(entry wiredListEntry) (uint32, uint32) {
+	h := fnv.New64a()
+	if entry.retrieveID != nil {
+		h.Write(entry.retrieveID.Bytes())
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(entry.startTime.UnixNano()))
+	h.Write(buf[:])
+	sum := h.Sum64()
+	return uint32(sum), uint32(sum >> 32)
+}
+
+func frequencySketchIndex(h1, h2 uint32, i int) uint32 {
+	return (h1 + uint32(i)*h2) & (frequencySketchWidth - 1)
+}
@@ -46,6 +46,12 @@
 // be provided to the WiredList if it wasn't read from disk. This prevents tricky
 // ownership semantics where both the background tick and and the WiredList are
 // competing for ownership / trying to close the same blocks.
+//
+// The WiredList can optionally use a TinyLFU admission policy, in which case
+// it estimates how frequently blocks are read and once full only admits a block
+// if it is read more frequently than the least recently used block which it
+// would evict. This prevents scans of blocks which are read once, such as long
+// range queries, from evicting frequently read blocks.
 
 package block
 
@@ -87,6 +93,9 @@ type WiredList struct {
 	updatesCh chan DatabaseBlock
 	doneCh    chan struct{}
 
+	// Only set when using the TinyLFU admission policy.
+	sketch *frequencySketch
+
 	metrics wiredListMetrics
 	logger  xlog.Logger
 }
@@ -97,6 +106,7 @@ type wiredListMetrics struct {
 	evicted              tally.Counter
 	pushedBack           tally.Counter
 	inserted             tally.Counter
+	rejected             tally.Counter
 	evictedAfterDuration tally.Timer
 }
 
@@ -113,6 +123,10 @@ func newWiredListMetrics(scope tally.Scope) wiredListMetrics {
 		// Incremented when a block is inserted into the list, I.E
 		// it wasn't already present
 		inserted: scope.Counter("inserted"),
+		// Incremented when a block is not admitted into the list by the
+		// admission policy, I.E it is read less frequently than the block
+		// it would evict
+		rejected: scope.Counter("rejected"),
 		// Measure how much time blocks spend in the list before being evicted
 		evictedAfterDuration: scope.Timer("evicted-after-duration"),
 	}
@@ -123,11 +137,32 @@ func NewWiredList(
 	runtimeOptsMgr runtime.OptionsManager,
 	iopts instrument.Options,
 	copts clock.Options,
+) *WiredList {
+	return newWiredList(runtimeOptsMgr, iopts, copts, nil)
+}
+
+// NewTinyLFUWiredList returns a new database block wired list which uses a
+// TinyLFU admission policy, once full blocks are only admitted if they are
+// read more frequently than the least recently used block.
+func NewTinyLFUWiredList(
+	runtimeOptsMgr runtime.OptionsManager,
+	iopts instrument.Options,
+	copts clock.Options,
+) *WiredList {
+	return newWiredList(runtimeOptsMgr, iopts, copts, newFrequencySketch())
+}
+
+func newWiredList(
+	runtimeOptsMgr runtime.OptionsManager,
+	iopts instrument.Options,
+	copts clock.Options,
+	sketch *frequencySketch,
 ) *WiredList {
 	scope := iopts.MetricsScope().
 		SubScope("wired-list")
 	l := &WiredList{
 		nowFn:   copts.NowFn(),
+		sketch:  sketch,
 		metrics: newWiredListMetrics(scope),
 		logger:  iopts.Logger(),
 	}
@@ -221,7 +256,10 @@ func (l *WiredList) processUpdateBlock(v DatabaseBlock) {
 	// If a block is still unwireable then its worth keeping track of in the wired list
 	// so we push it back.
 	if unwireable {
-		l.pushBack(v)
+		if l.sketch != nil {
+			l.sketch.increment(entry)
+		}
+		l.pushBack(v, entry)
 		return
 	}
 
@@ -287,17 +325,54 @@ func (l *WiredList) remove(v DatabaseBlock) {
 	l.length--
 }
 
-func (l *WiredList) pushBack(v DatabaseBlock) {
+func (l *WiredList) pushBack(v DatabaseBlock, entry wiredListEntry) {
 	if l.exists(v) {
 		l.metrics.pushedBack.Inc(1)
 		l.moveToBack(v)
 		return
 	}
 
+	if !l.admit(entry) {
+		l.metrics.rejected.Inc(1)
+		l.reject(v, entry)
+		return
+	}
+
 	l.metrics.inserted.Inc(1)
 	l.insertAfter(v, l.root.prev())
 }
 
+// admit returns whether a block not in the list should be inserted, which is
+// always the case unless using the TinyLFU admission policy and the list is
+// full, in which case the block must be read more frequently than the least
+// recently used block that would be evicted to make room for it.
+func (l *WiredList) admit(entry wiredListEntry) bool {
+	if l.sketch == nil {
+		return true
+	}
+
+	maxWired := int(atomic.LoadInt64(&l.maxWired))
+	if maxWired <= 0 || l.length < maxWired {
+		return true
+	}
+
+	victim := l.root.next()
+	if victim == &l.root {
+		return true
+	}
+
+	return l.sketch.estimate(entry) > l.sketch.estimate(victim.wiredListEntry())
+}
+
+// reject closes a block which was not admitted into the list, as the list
+// owns all blocks retrieved from disk.
+func (l *WiredList) reject(v DatabaseBlock, entry wiredListEntry) {
+	if onEvict := v.OnEvictedFromWiredList(); onEvict != nil {
+		onEvict.OnEvictedFromWiredList(entry.retrieveID, entry.startTime)
+	}
+	v.Close()
+}
+
 func (l *WiredList) moveToBack(v DatabaseBlock) {
 	if !l.exists(v) || l.root.prev() == v {
 		return
@@ -169,3 +169,57 @@ func wiredListTestWiredBlocksString(l *WiredList) string { // nolint: unused
 	}
 	return b.String()
 }
+
+func TestTinyLFUWiredListRejectsInfrequentlyReadBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	runtimeOptsMgr := runtime.NewOptionsManager()
+	require.NoError(t, runtimeOptsMgr.Update(runtime.NewOptions().SetMaxWiredBlocks(2)))
+	l := NewTinyLFUWiredList(runtimeOptsMgr, instrument.NewOptions(), clock.NewOptions())
+
+	opts := testOptions.SetWiredList(l)
+
+	l.Start()
+
+	// Blocks read frequently, such as by dashboards
+	var hot []*dbBlock
+	for i := 0; i < 2; i++ {
+		bl := newTestUnwireableBlock(ctrl, fmt.Sprintf("hot.%d", i), opts)
+		hot = append(hot, bl)
+		for j := 0; j < 3; j++ {
+			l.Update(bl)
+		}
+	}
+
+	// Blocks read once, such as by a long range query
+	var scanned []*dbBlock
+	for i := 0; i < 5; i++ {
+		bl := newTestUnwireableBlock(ctrl, fmt.Sprintf("scan.%d", i), opts)
+		scanned = append(scanned, bl)
+		l.Update(bl)
+	}
+
+	l.Stop()
+
+	require.Equal(t, 2, l.length)
+	require.Equal(t, hot[0], l.root.next())
+	require.Equal(t, hot[1], l.root.next().next())
+	for _, bl := range scanned {
+		require.True(t, bl.closed)
+	}
+
+	// A block which is read again more frequently is admitted
+	l.Start()
+	var admitted *dbBlock
+	for i := 0; i < 3; i++ {
+		admitted = newTestUnwireableBlock(ctrl, "scan.0", opts)
+		l.Update(admitted)
+	}
+	l.Stop()
+
+	require.Equal(t, 2, l.length)
+	require.Equal(t, hot[1], l.root.next())
+	require.Equal(t, admitted, l.root.prev())
+	require.True(t, hot[0].closed)
+}
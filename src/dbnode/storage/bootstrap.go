@@ -26,6 +26,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	xerrors "github.com/m3db/m3x/errors"
 	xlog "github.com/m3db/m3x/log"
 
@@ -49,7 +50,8 @@ var (
 	errShardNotBootstrappedToSnapshot = errors.New("shard is not yet bootstrapped to snapshot")
 
 	// errShardNotBootstrappedToRead raised when trying to read data for a shard that's not yet bootstrapped.
-	errShardNotBootstrappedToRead = errors.New("shard is not yet bootstrapped to read")
+	errShardNotBootstrappedToRead = m3dberrors.NewUnavailableError(
+		errors.New("shard is not yet bootstrapped to read"))
 
 	// errBootstrapEnqueued raised when trying to bootstrap and bootstrap becomes enqueued.
 	errBootstrapEnqueued = errors.New("database bootstrapping enqueued bootstrap")
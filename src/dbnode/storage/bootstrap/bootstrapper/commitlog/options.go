@@ -22,14 +22,18 @@ package commitlog
 
 import (
 	"errors"
+	"runtime"
 
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 )
 
-const (
-	defaultEncodingConcurrency   = 4
-	defaultMergeShardConcurrency = 4
+var (
+	// defaultEncodingConcurrency bounds the number of shard encoding workers
+	// by the number of CPUs, the actual number of workers is the lesser of
+	// this and the number of shards being bootstrapped
+	defaultEncodingConcurrency   = runtime.NumCPU()
+	defaultMergeShardConcurrency = runtime.NumCPU()
 )
 
 var (
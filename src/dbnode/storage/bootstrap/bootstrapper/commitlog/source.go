@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 	"time"
 
@@ -51,7 +52,14 @@ var (
 	errIndexingNotEnableForNamespace = errors.New("indexing not enabled for namespace")
 )
 
-const encoderChanBufSize = 1000
+const (
+	// encoderBatchSize is the number of datapoints handed to an encoding
+	// worker at a time, batching amortizes the cost of the channel send
+	// across many datapoints.
+	encoderBatchSize = 128
+
+	encoderChanBufSize = 64
+)
 
 type newIteratorFn func(opts commitlog.IteratorOpts) (commitlog.Iterator, error)
 type snapshotFilesFn func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error)
@@ -103,6 +111,11 @@ func (s *commitLogSource) AvailableData(
 	ns namespace.Metadata,
 	shardsTimeRanges result.ShardTimeRanges,
 ) result.ShardTimeRanges {
+	if ns.Options().InMemoryOnly() {
+		// In-memory only namespaces never write to the commit log, leave
+		// the ranges for the peers bootstrapper.
+		return result.ShardTimeRanges{}
+	}
 	// Commit log bootstrapper is a last ditch effort, so fulfill all
 	// time ranges requested even if not enough data, just to succeed
 	// the bootstrap
@@ -252,16 +265,18 @@ func (s *commitLogSource) ReadData(
 	var (
 		// +1 so we can use the shard number as an index throughout without constantly
 		// remembering to subtract 1 to convert to zero-based indexing
-		numShards        = s.findHighestShard(shardsTimeRanges) + 1
-		numConc          = s.opts.EncodingConcurrency()
-		encoderPool      = blOpts.EncoderPool()
-		workerErrs       = make([]int, numConc)
-		shardDataByShard = s.newShardDataByShard(shardsTimeRanges, numShards)
+		numShards              = s.findHighestShard(shardsTimeRanges) + 1
+		workerByShard, numConc = s.newEncodingWorkerByShard(shardsTimeRanges, numShards)
+		encoderPool            = blOpts.EncoderPool()
+		workerErrs             = make([]int, numConc)
+		shardDataByShard       = s.newShardDataByShard(shardsTimeRanges, numShards)
 	)
 
-	encoderChans := make([]chan encoderArg, numConc)
+	encoderChans := make([]chan []encoderArg, numConc)
+	encoderBatches := make([][]encoderArg, numConc)
 	for i := 0; i < numConc; i++ {
-		encoderChans[i] = make(chan encoderArg, encoderChanBufSize)
+		encoderChans[i] = make(chan []encoderArg, encoderChanBufSize)
+		encoderBatches[i] = make([]encoderArg, 0, encoderBatchSize)
 	}
 
 	// Spin up numConc background go-routines to handle M3TSZ encoding. This must
@@ -285,26 +300,40 @@ func (s *commitLogSource) ReadData(
 		datapointsRead++
 
 		// Distribute work such that each encoder goroutine is responsible for
-		// approximately numShards / numConc shards. This also means that all
+		// a fixed set of shards, ideally just one. This also means that all
 		// datapoints for a given shard/series will be processed in a serialized
-		// manner.
+		// manner and in the order they were read from the commit log, which
+		// keeps the resulting encoders deterministic.
 		// We choose to distribute work by shard instead of series.UniqueIndex
 		// because it means that all accesses to the shardDataByShard slice don't need
 		// to be synchronized because each index belongs to a single shard so it
 		// will only be accessed serially from a single worker routine.
-		encoderChans[series.Shard%uint32(numConc)] <- encoderArg{
+		workerNum := workerByShard[series.Shard]
+		batch := append(encoderBatches[workerNum], encoderArg{
 			series:     series,
 			dp:         dp,
 			unit:       unit,
 			annotation: annotation,
 			blockStart: dp.Timestamp.Truncate(blockSize),
+		})
+		if len(batch) < encoderBatchSize {
+			encoderBatches[workerNum] = batch
+			continue
 		}
+		encoderChans[workerNum] <- batch
+		encoderBatches[workerNum] = make([]encoderArg, 0, encoderBatchSize)
 	}
 
 	if iterErr := iter.Err(); iterErr != nil {
 		return nil, iterErr
 	}
 
+	for workerNum, batch := range encoderBatches {
+		if len(batch) > 0 {
+			encoderChans[workerNum] <- batch
+		}
+	}
+
 	for _, encoderChan := range encoderChans {
 		close(encoderChan)
 	}
@@ -817,73 +846,116 @@ func (s *commitLogSource) startM3TSZEncodingWorker(
 	ns namespace.Metadata,
 	runOpts bootstrap.RunOptions,
 	workerNum int,
-	ec <-chan encoderArg,
+	ec <-chan []encoderArg,
 	unmerged []shardData,
 	encoderPool encoding.EncoderPool,
 	workerErrs []int,
 	blopts block.Options,
 	wg *sync.WaitGroup,
 ) {
-	for arg := range ec {
-		var (
-			series     = arg.series
-			dp         = arg.dp
-			unit       = arg.unit
-			annotation = arg.annotation
-			blockStart = arg.blockStart
-		)
-
-		var (
-			unmergedShard      = unmerged[series.Shard].series
-			unmergedSeries, ok = unmergedShard.Get(series.ID)
-		)
-		if !ok {
-			unmergedSeries = metadataAndEncodersByTime{
-				id:       series.ID,
-				tags:     series.Tags,
-				encoders: make(map[xtime.UnixNano][]encoder)}
-			// Have to use unsafe because we don't want to copy the IDs we put
-			// into this map because its lifecycle is much shorter than that of
-			// the IDs we're putting into it so copying would waste too much
-			// memory unnecessarily, and we don't want to finalize the IDs for the
-			// same reason.
-			unmergedShard.SetUnsafe(
-				series.ID, unmergedSeries,
-				SetUnsafeOptions{NoCopyKey: true, NoFinalizeKey: true})
-		}
-
-		var (
-			err            error
-			blockStartNano = xtime.ToUnixNano(blockStart)
-			unmergedBlock  = unmergedSeries.encoders[blockStartNano]
-			wroteExisting  = false
-		)
-		for i := range unmergedBlock {
-			if unmergedBlock[i].lastWriteAt.Before(dp.Timestamp) {
-				unmergedBlock[i].lastWriteAt = dp.Timestamp
-				err = unmergedBlock[i].enc.Encode(dp, unit, annotation)
-				wroteExisting = true
-				break
+	for batch := range ec {
+		for _, arg := range batch {
+			if err := s.encodeArg(arg, unmerged, encoderPool, blopts); err != nil {
+				workerErrs[workerNum]++
 			}
 		}
-		if !wroteExisting {
-			enc := encoderPool.Get()
-			enc.Reset(blockStart, blopts.DatabaseBlockAllocSize())
+	}
+	wg.Done()
+}
 
-			err = enc.Encode(dp, unit, annotation)
-			if err == nil {
-				unmergedBlock = append(unmergedBlock, encoder{
-					lastWriteAt: dp.Timestamp,
-					enc:         enc,
-				})
-				unmergedSeries.encoders[blockStartNano] = unmergedBlock
-			}
+func (s *commitLogSource) encodeArg(
+	arg encoderArg,
+	unmerged []shardData,
+	encoderPool encoding.EncoderPool,
+	blopts block.Options,
+) error {
+	var (
+		series     = arg.series
+		dp         = arg.dp
+		unit       = arg.unit
+		annotation = arg.annotation
+		blockStart = arg.blockStart
+	)
+
+	var (
+		unmergedShard      = unmerged[series.Shard].series
+		unmergedSeries, ok = unmergedShard.Get(series.ID)
+	)
+	if !ok {
+		unmergedSeries = metadataAndEncodersByTime{
+			id:       series.ID,
+			tags:     series.Tags,
+			encoders: make(map[xtime.UnixNano][]encoder)}
+		// Have to use unsafe because we don't want to copy the IDs we put
+		// into this map because its lifecycle is much shorter than that of
+		// the IDs we're putting into it so copying would waste too much
+		// memory unnecessarily, and we don't want to finalize the IDs for the
+		// same reason.
+		unmergedShard.SetUnsafe(
+			series.ID, unmergedSeries,
+			SetUnsafeOptions{NoCopyKey: true, NoFinalizeKey: true})
+	}
+
+	var (
+		err            error
+		blockStartNano = xtime.ToUnixNano(blockStart)
+		unmergedBlock  = unmergedSeries.encoders[blockStartNano]
+		wroteExisting  = false
+	)
+	for i := range unmergedBlock {
+		if unmergedBlock[i].lastWriteAt.Before(dp.Timestamp) {
+			unmergedBlock[i].lastWriteAt = dp.Timestamp
+			err = unmergedBlock[i].enc.Encode(dp, unit, annotation)
+			wroteExisting = true
+			break
 		}
-		if err != nil {
-			workerErrs[workerNum]++
+	}
+	if !wroteExisting {
+		enc := encoderPool.Get()
+		enc.Reset(blockStart, blopts.DatabaseBlockAllocSize())
+
+		err = enc.Encode(dp, unit, annotation)
+		if err == nil {
+			unmergedBlock = append(unmergedBlock, encoder{
+				lastWriteAt: dp.Timestamp,
+				enc:         enc,
+			})
+			unmergedSeries.encoders[blockStartNano] = unmergedBlock
 		}
 	}
-	wg.Done()
+	return err
+}
+
+// newEncodingWorkerByShard assigns each shard being bootstrapped to an
+// encoding worker and returns the worker for every shard along with the
+// number of workers. There is one worker per shard bounded by the encoding
+// concurrency, shards are assigned in order so each worker owns an even
+// share of them.
+func (s *commitLogSource) newEncodingWorkerByShard(
+	shardsTimeRanges result.ShardTimeRanges,
+	numShards uint32,
+) ([]int, int) {
+	shards := make([]uint32, 0, len(shardsTimeRanges))
+	for shard := range shardsTimeRanges {
+		shards = append(shards, shard)
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i] < shards[j]
+	})
+
+	numWorkers := s.opts.EncodingConcurrency()
+	if len(shards) < numWorkers {
+		numWorkers = len(shards)
+	}
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	workerByShard := make([]int, numShards)
+	for i, shard := range shards {
+		workerByShard[shard] = i % numWorkers
+	}
+	return workerByShard, numWorkers
 }
 
 func (s *commitLogSource) shouldEncodeForData(
@@ -1247,6 +1319,11 @@ func (s *commitLogSource) AvailableIndex(
 	ns namespace.Metadata,
 	shardsTimeRanges result.ShardTimeRanges,
 ) result.ShardTimeRanges {
+	if ns.Options().InMemoryOnly() {
+		// In-memory only namespaces never write to the commit log, leave
+		// the ranges for the peers bootstrapper.
+		return result.ShardTimeRanges{}
+	}
 	// Commit log bootstrapper is a last ditch effort, so fulfill all
 	// time ranges requested even if not enough data, just to succeed
 	// the bootstrap
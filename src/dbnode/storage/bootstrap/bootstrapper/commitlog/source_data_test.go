@@ -297,6 +297,27 @@ func TestReadTrimsToRanges(t *testing.T) {
 		values[1:3], blockSize, res.ShardResults(), opts))
 }
 
+func TestNewEncodingWorkerByShard(t *testing.T) {
+	opts := testOptions().SetEncodingConcurrency(2)
+	src := newCommitLogSource(opts, fs.Inspection{}).(*commitLogSource)
+
+	ranges := xtime.Ranges{}
+	shardsTimeRanges := result.ShardTimeRanges{1: ranges, 4: ranges, 5: ranges}
+	workerByShard, numWorkers := src.newEncodingWorkerByShard(shardsTimeRanges, 6)
+	require.Equal(t, 2, numWorkers)
+	require.Equal(t, 0, workerByShard[1])
+	require.Equal(t, 1, workerByShard[4])
+	require.Equal(t, 0, workerByShard[5])
+
+	// One worker per shard when there is enough concurrency.
+	src.opts = opts.SetEncodingConcurrency(8)
+	workerByShard, numWorkers = src.newEncodingWorkerByShard(shardsTimeRanges, 6)
+	require.Equal(t, 3, numWorkers)
+	require.Equal(t, 0, workerByShard[1])
+	require.Equal(t, 1, workerByShard[4])
+	require.Equal(t, 2, workerByShard[5])
+}
+
 func TestItMergesSnapshotsAndCommitLogs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -42,10 +42,11 @@ type Options interface {
 	// CommitLogOptions returns the commit log options
 	CommitLogOptions() commitlog.Options
 
-	// SetEncodingConcurrency sets the concurrency for encoding
+	// SetEncodingConcurrency sets the maximum concurrency for encoding, at
+	// most one encoding worker is started per shard being bootstrapped
 	SetEncodingConcurrency(value int) Options
 
-	// EncodingConcurrency returns the concurrency for encoding
+	// EncodingConcurrency returns the maximum concurrency for encoding
 	EncodingConcurrency() int
 
 	// SetMergeShardConcurrency sets the concurrency for merging shards
@@ -23,6 +23,7 @@ package fs
 import (
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -78,8 +79,9 @@ type persistManager struct {
 }
 
 type fileSystemSourceMetrics struct {
-	persistedIndexBlocksRead  tally.Counter
-	persistedIndexBlocksWrite tally.Counter
+	persistedIndexBlocksRead    tally.Counter
+	persistedIndexBlocksWrite   tally.Counter
+	persistedIndexBlocksSkipped tally.Counter
 }
 
 func newFileSystemSource(opts Options) bootstrap.Source {
@@ -105,8 +107,9 @@ func newFileSystemSource(opts Options) bootstrap.Source {
 			mgr: opts.PersistManager(),
 		},
 		metrics: fileSystemSourceMetrics{
-			persistedIndexBlocksRead:  scope.Counter("persist-index-blocks-read"),
-			persistedIndexBlocksWrite: scope.Counter("persist-index-blocks-write"),
+			persistedIndexBlocksRead:    scope.Counter("persist-index-blocks-read"),
+			persistedIndexBlocksWrite:   scope.Counter("persist-index-blocks-write"),
+			persistedIndexBlocksSkipped: scope.Counter("persist-index-blocks-skipped"),
 		},
 	}
 	s.newReaderPoolOpts.alloc = s.newReader
@@ -165,6 +168,11 @@ func (s *fileSystemSource) availability(
 	shardsTimeRanges result.ShardTimeRanges,
 ) result.ShardTimeRanges {
 	result := make(map[uint32]xtime.Ranges)
+	if md.Options().InMemoryOnly() {
+		// In-memory only namespaces never write filesets, leave the ranges
+		// for the peers bootstrapper.
+		return result
+	}
 	for shard, ranges := range shardsTimeRanges {
 		result[shard] = s.shardAvailability(md.ID(), shard, ranges)
 	}
@@ -934,10 +942,9 @@ func (s *fileSystemSource) read(
 		// NB(r): We only need to cache shard indices and marks blocks as
 		// fulfilled when bootstrapping data, because the data can be retrieved
 		// lazily from disk during reads.
-		// On the other hand, if we're bootstrapping the index then currently we
-		// need to rebuild it from scratch by reading all the IDs/tags until
-		// we can natively bootstrap persisted segments from disk and compact them
-		// with series metadata from other shards if topology has changed.
+		// On the other hand, if we're bootstrapping the index then we first
+		// load any persisted segments from disk and only need to rebuild the
+		// remaining shard and time ranges by reading all the IDs/tags.
 		if mgr := s.opts.DatabaseBlockRetrieverManager(); mgr != nil {
 			shards := make([]uint32, 0, len(shardsTimeRanges))
 			for shard := range shardsTimeRanges {
@@ -1075,6 +1082,17 @@ func (s *fileSystemSource) bootstrapFromIndexPersistedBlocks(
 	infoFiles := fs.ReadIndexInfoFiles(s.fsopts.FilePathPrefix(), ns.ID(),
 		s.fsopts.InfoReaderBufferSize())
 
+	// Visit the most recent volume for each block first so that older
+	// volumes whose shards have already been fulfilled by a newer volume
+	// are not also loaded into memory.
+	sort.SliceStable(infoFiles, func(i, j int) bool {
+		a, b := infoFiles[i].ID, infoFiles[j].ID
+		if !a.BlockStart.Equal(b.BlockStart) {
+			return a.BlockStart.Before(b.BlockStart)
+		}
+		return a.VolumeIndex > b.VolumeIndex
+	})
+
 	for _, infoFile := range infoFiles {
 		if infoFile.Err.Error() != nil {
 			s.log.WithFields(
@@ -1116,6 +1134,14 @@ func (s *fileSystemSource) bootstrapFromIndexPersistedBlocks(
 			continue
 		}
 
+		remaining := willFulfill.Copy()
+		remaining.Subtract(res.fulfilled)
+		if remaining.IsEmpty() {
+			// Already fulfilled by a more recent volume for this block
+			s.metrics.persistedIndexBlocksSkipped.Inc(1)
+			continue
+		}
+
 		segments, err := fs.ReadIndexSegments(fs.ReadIndexSegmentsOptions{
 			ReaderOptions: fs.IndexReaderOpenOptions{
 				Identifier:  infoFile.ID,
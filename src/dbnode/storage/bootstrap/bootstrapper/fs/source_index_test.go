@@ -386,3 +386,52 @@ func TestBootstrapIndexIncrementalPrefersPersistedIndexBlocks(t *testing.T) {
 	require.Equal(t, int64(1), counters["fs-bootstrapper.persist-index-blocks-read+"].Value())
 	require.Equal(t, int64(0), counters["fs-bootstrapper.persist-index-blocks-write+"].Value())
 }
+
+func TestBootstrapIndexIncrementalSkipsSupersededIndexVolumes(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	times := newTestBootstrapIndexTimes(testTimesOptions{
+		numBlocks: 2,
+	})
+
+	// Write data files
+	writeTSDBGoodTaggedSeriesDataFiles(t, dir, testNs1ID, times.start)
+
+	// Write two volumes of the same index block, the second supersedes
+	// the first
+	testData := testGoodTaggedSeriesDataBlocks()
+	shards := map[uint32]struct{}{testShard: struct{}{}}
+	writeTSDBPersistedIndexBlock(t, dir, testNsMetadata(t), times.start, shards,
+		testData[0])
+	writeTSDBPersistedIndexBlock(t, dir, testNsMetadata(t), times.start, shards,
+		append(testData[0], testData[1]...))
+
+	opts := newTestOptionsWithPersistManager(t, dir)
+	scope := tally.NewTestScope("", nil)
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(scope))
+
+	runOpts := testDefaultRunOpts.SetIncremental(true)
+
+	src := newFileSystemSource(opts).(*fileSystemSource)
+	res, err := src.ReadIndex(testNsMetadata(t), times.shardTimeRanges,
+		runOpts)
+	require.NoError(t, err)
+
+	indexResults := res.IndexResults()
+
+	// Check that only the most recent volume was loaded for the block
+	block, ok := indexResults[xtime.ToUnixNano(times.start)]
+	require.True(t, ok)
+	require.Equal(t, 1, len(block.Segments()))
+	_, mutable := block.Segments()[0].(segment.MutableSegment)
+	require.False(t, mutable)
+
+	// Validate results
+	validateGoodTaggedSeries(t, times.start, indexResults)
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["fs-bootstrapper.persist-index-blocks-read+"].Value())
+	require.Equal(t, int64(1), counters["fs-bootstrapper.persist-index-blocks-skipped+"].Value())
+	require.Equal(t, int64(0), counters["fs-bootstrapper.persist-index-blocks-write+"].Value())
+}
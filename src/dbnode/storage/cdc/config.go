@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cdc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+	xretry "github.com/m3db/m3x/retry"
+)
+
+const (
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// WebhookConfiguration is the configuration of a webhook sink.
+type WebhookConfiguration struct {
+	// URL is the URL batches of events are posted to.
+	URL string `yaml:"url" validate:"nonzero"`
+
+	// Headers are headers set on each request, e.g. authorization.
+	Headers map[string]string `yaml:"headers"`
+
+	// Timeout is the timeout of each request, defaults to 10s.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// NewSink returns a webhook sink for the configuration.
+func (c WebhookConfiguration) NewSink() Sink {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return NewWebhookSink(c.URL, c.Headers, &http.Client{Timeout: timeout})
+}
+
+// Configuration is the configuration for emitting change events.
+type Configuration struct {
+	// EventTypes are the types of events emitted, defaults to all of
+	// series_created, block_flushed, block_expired and namespace_changed.
+	EventTypes []EventType `yaml:"eventTypes"`
+
+	// QueueSize is the max number of events waiting to be written before
+	// events are dropped.
+	QueueSize int `yaml:"queueSize"`
+
+	// BatchSize is the max number of events written in a batch.
+	BatchSize int `yaml:"batchSize"`
+
+	// FlushInterval is the max time events wait before being written.
+	FlushInterval time.Duration `yaml:"flushInterval"`
+
+	// Retry is the retry configuration of writes to the sink.
+	Retry xretry.Configuration `yaml:"retry"`
+
+	// Webhook is the configuration of the webhook events are posted to.
+	Webhook WebhookConfiguration `yaml:"webhook"`
+}
+
+// NewEmitter returns an emitter for the configuration.
+func (c Configuration) NewEmitter(iopts instrument.Options) (Emitter, error) {
+	opts := NewOptions().
+		SetInstrumentOptions(iopts).
+		SetRetrier(c.Retry.NewRetrier(iopts.MetricsScope().SubScope("retry")))
+	if len(c.EventTypes) > 0 {
+		opts = opts.SetEventTypes(c.EventTypes)
+	}
+	if c.QueueSize > 0 {
+		opts = opts.SetQueueSize(c.QueueSize)
+	}
+	if c.BatchSize > 0 {
+		opts = opts.SetBatchSize(c.BatchSize)
+	}
+	if c.FlushInterval > 0 {
+		opts = opts.SetFlushInterval(c.FlushInterval)
+	}
+	return NewEmitter(c.Webhook.NewSink(), opts)
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cdc
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	xlog "github.com/m3db/m3x/log"
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errEmitterClosed = errors.New("change event emitter is closed")
+)
+
+type emitterMetrics struct {
+	emitted     tally.Counter
+	dropped     tally.Counter
+	written     tally.Counter
+	writeErrors tally.Counter
+	writeFailed tally.Counter
+}
+
+func newEmitterMetrics(scope tally.Scope) emitterMetrics {
+	return emitterMetrics{
+		emitted:     scope.Counter("emitted"),
+		dropped:     scope.Counter("dropped"),
+		written:     scope.Counter("written"),
+		writeErrors: scope.Counter("write-errors"),
+		writeFailed: scope.Counter("write-failed"),
+	}
+}
+
+type emitter struct {
+	sync.RWMutex
+
+	sink          Sink
+	enabled       EventType
+	queue         chan Event
+	batchSize     int
+	flushInterval time.Duration
+	retrier       xretry.Retrier
+	nowFn         clock.NowFn
+	logger        xlog.Logger
+	metrics       emitterMetrics
+
+	closed bool
+	doneCh chan struct{}
+}
+
+// NewEmitter returns an emitter writing events to the sink in batches of
+// up to the batch size at least every flush interval.
+func NewEmitter(sink Sink, opts Options) (Emitter, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var enabled EventType
+	for _, t := range opts.EventTypes() {
+		enabled |= t
+	}
+
+	iopts := opts.InstrumentOptions()
+	e := &emitter{
+		sink:          sink,
+		enabled:       enabled,
+		queue:         make(chan Event, opts.QueueSize()),
+		batchSize:     opts.BatchSize(),
+		flushInterval: opts.FlushInterval(),
+		retrier:       opts.Retrier(),
+		nowFn:         opts.ClockOptions().NowFn(),
+		logger:        iopts.Logger(),
+		metrics:       newEmitterMetrics(iopts.MetricsScope()),
+		doneCh:        make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+func (e *emitter) Enabled(t EventType) bool {
+	return e.enabled&t != 0
+}
+
+func (e *emitter) Emit(event Event) {
+	if !e.Enabled(event.Type) {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = e.nowFn()
+	}
+
+	e.RLock()
+	if e.closed {
+		e.RUnlock()
+		e.metrics.dropped.Inc(1)
+		return
+	}
+	select {
+	case e.queue <- event:
+		e.RUnlock()
+		e.metrics.emitted.Inc(1)
+	default:
+		e.RUnlock()
+		e.metrics.dropped.Inc(1)
+	}
+}
+
+func (e *emitter) run() {
+	defer close(e.doneCh)
+
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, e.batchSize)
+	for {
+		select {
+		case event, ok := <-e.queue:
+			if !ok {
+				e.write(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= e.batchSize {
+				e.write(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.write(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// write writes the batch to the sink, retrying on error, the batch is
+// dropped if it can not be written.
+func (e *emitter) write(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	err := e.retrier.Attempt(func() error {
+		err := e.sink.Write(batch)
+		if err != nil {
+			e.metrics.writeErrors.Inc(1)
+		}
+		return err
+	})
+	if err != nil {
+		e.metrics.writeFailed.Inc(1)
+		e.metrics.dropped.Inc(int64(len(batch)))
+		e.logger.Errorf("dropped %d change events, could not write to sink: %v",
+			len(batch), err)
+		return
+	}
+	e.metrics.written.Inc(int64(len(batch)))
+}
+
+func (e *emitter) Close() error {
+	e.Lock()
+	if e.closed {
+		e.Unlock()
+		return errEmitterClosed
+	}
+	e.closed = true
+	close(e.queue)
+	e.Unlock()
+
+	<-e.doneCh
+	return e.sink.Close()
+}
+
+type noopEmitter struct{}
+
+// NewNoopEmitter returns an emitter that emits no events.
+func NewNoopEmitter() Emitter {
+	return noopEmitter{}
+}
+
+func (noopEmitter) Enabled(t EventType) bool { return false }
+func (noopEmitter) Emit(event Event)         {}
+func (noopEmitter) Close() error             { return nil }
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cdc
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testSink struct {
+	sync.Mutex
+	batches [][]Event
+	err     error
+	writes  int
+	closed  bool
+}
+
+func (s *testSink) Write(events []Event) error {
+	s.Lock()
+	defer s.Unlock()
+	s.writes++
+	if s.err != nil {
+		return s.err
+	}
+	// The batch is reused once written so it must be copied.
+	s.batches = append(s.batches, append([]Event(nil), events...))
+	return nil
+}
+
+func (s *testSink) Close() error {
+	s.Lock()
+	s.closed = true
+	s.Unlock()
+	return nil
+}
+
+func newTestEmitter(t *testing.T, sink Sink, opts Options) Emitter {
+	e, err := NewEmitter(sink, opts.
+		SetFlushInterval(time.Hour).
+		SetRetrier(xretry.NewRetrier(xretry.NewOptions().
+			SetInitialBackoff(time.Millisecond).
+			SetMaxRetries(1))))
+	require.NoError(t, err)
+	return e
+}
+
+func TestEmitterWritesBatches(t *testing.T) {
+	var (
+		sink = &testSink{}
+		now  = time.Unix(1000, 0)
+		e    = newTestEmitter(t, sink, NewOptions().SetBatchSize(2))
+	)
+	for i := 0; i < 3; i++ {
+		e.Emit(Event{Type: BlockFlushed, Time: now, Namespace: "ns", Shard: uint32(i)})
+	}
+	require.NoError(t, e.Close())
+
+	require.True(t, sink.closed)
+	require.Equal(t, [][]Event{
+		{
+			{Type: BlockFlushed, Time: now, Namespace: "ns", Shard: 0},
+			{Type: BlockFlushed, Time: now, Namespace: "ns", Shard: 1},
+		},
+		{
+			{Type: BlockFlushed, Time: now, Namespace: "ns", Shard: 2},
+		},
+	}, sink.batches)
+}
+
+func TestEmitterDropsEventsNotEnabledOrAfterClose(t *testing.T) {
+	var (
+		sink  = &testSink{}
+		scope = tally.NewTestScope("", nil)
+		opts  = NewOptions().
+			SetEventTypes([]EventType{SeriesCreated, NamespaceChanged}).
+			SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+		e = newTestEmitter(t, sink, opts)
+	)
+	require.True(t, e.Enabled(SeriesCreated))
+	require.False(t, e.Enabled(BlockFlushed))
+
+	e.Emit(Event{Type: BlockFlushed})
+	e.Emit(Event{Type: NamespaceChanged, NamespaceChange: NamespaceAdded})
+	require.NoError(t, e.Close())
+	require.Error(t, e.Close())
+	e.Emit(Event{Type: SeriesCreated})
+
+	require.Len(t, sink.batches, 1)
+	require.Len(t, sink.batches[0], 1)
+	require.Equal(t, NamespaceAdded, sink.batches[0][0].NamespaceChange)
+	require.False(t, sink.batches[0][0].Time.IsZero())
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["emitted+"].Value())
+	require.Equal(t, int64(1), counters["written+"].Value())
+	require.Equal(t, int64(1), counters["dropped+"].Value())
+}
+
+func TestEmitterDropsBatchesFailingToWrite(t *testing.T) {
+	var (
+		sink  = &testSink{err: errors.New("unavailable")}
+		scope = tally.NewTestScope("", nil)
+		opts  = NewOptions().
+			SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+		e = newTestEmitter(t, sink, opts)
+	)
+	e.Emit(Event{Type: BlockExpired})
+	e.Emit(Event{Type: BlockExpired})
+	require.NoError(t, e.Close())
+
+	// The batch is retried once before being dropped.
+	require.Equal(t, 2, sink.writes)
+	require.Empty(t, sink.batches)
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(2), counters["write-errors+"].Value())
+	require.Equal(t, int64(1), counters["write-failed+"].Value())
+	require.Equal(t, int64(2), counters["dropped+"].Value())
+}
+
+func TestNewEmitterInvalidOptions(t *testing.T) {
+	_, err := NewEmitter(&testSink{}, NewOptions().SetEventTypes(nil))
+	require.Equal(t, errNoEventTypes, err)
+
+	_, err = NewEmitter(&testSink{}, NewOptions().SetBatchSize(0))
+	require.Equal(t, errInvalidBatchSize, err)
+}
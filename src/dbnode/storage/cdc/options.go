@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cdc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3x/instrument"
+	xretry "github.com/m3db/m3x/retry"
+)
+
+const (
+	defaultQueueSize     = 65536
+	defaultBatchSize     = 1024
+	defaultFlushInterval = time.Second
+)
+
+var (
+	errInvalidQueueSize     = errors.New("invalid change event queue size")
+	errInvalidBatchSize     = errors.New("invalid change event batch size")
+	errInvalidFlushInterval = errors.New("invalid change event flush interval")
+	errNoEventTypes         = errors.New("no change event types emitted")
+	errNoRetrier            = errors.New("no change event sink retrier")
+)
+
+type options struct {
+	clockOpts      clock.Options
+	instrumentOpts instrument.Options
+	eventTypes     []EventType
+	queueSize      int
+	batchSize      int
+	flushInterval  time.Duration
+	retrier        xretry.Retrier
+}
+
+// NewOptions creates new emitter options, all event types are emitted by
+// default.
+func NewOptions() Options {
+	return &options{
+		clockOpts:      clock.NewOptions(),
+		instrumentOpts: instrument.NewOptions(),
+		eventTypes:     ValidEventTypes(),
+		queueSize:      defaultQueueSize,
+		batchSize:      defaultBatchSize,
+		flushInterval:  defaultFlushInterval,
+		retrier:        xretry.NewRetrier(xretry.NewOptions()),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.queueSize <= 0 {
+		return errInvalidQueueSize
+	}
+	if o.batchSize <= 0 {
+		return errInvalidBatchSize
+	}
+	if o.flushInterval <= 0 {
+		return errInvalidFlushInterval
+	}
+	if len(o.eventTypes) == 0 {
+		return errNoEventTypes
+	}
+	if o.retrier == nil {
+		return errNoRetrier
+	}
+	return nil
+}
+
+func (o *options) SetClockOptions(value clock.Options) Options {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *options) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetEventTypes(value []EventType) Options {
+	opts := *o
+	opts.eventTypes = value
+	return &opts
+}
+
+func (o *options) EventTypes() []EventType {
+	return o.eventTypes
+}
+
+func (o *options) SetQueueSize(value int) Options {
+	opts := *o
+	opts.queueSize = value
+	return &opts
+}
+
+func (o *options) QueueSize() int {
+	return o.queueSize
+}
+
+func (o *options) SetBatchSize(value int) Options {
+	opts := *o
+	opts.batchSize = value
+	return &opts
+}
+
+func (o *options) BatchSize() int {
+	return o.batchSize
+}
+
+func (o *options) SetFlushInterval(value time.Duration) Options {
+	opts := *o
+	opts.flushInterval = value
+	return &opts
+}
+
+func (o *options) FlushInterval() time.Duration {
+	return o.flushInterval
+}
+
+func (o *options) SetRetrier(value xretry.Retrier) Options {
+	opts := *o
+	opts.retrier = value
+	return &opts
+}
+
+func (o *options) Retrier() xretry.Retrier {
+	return o.retrier
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cdc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3x/instrument"
+	xretry "github.com/m3db/m3x/retry"
+)
+
+// EventType is the type of a change event.
+type EventType uint
+
+const (
+	// SeriesCreated is emitted when a series is first inserted into a
+	// shard once the shard has bootstrapped.
+	SeriesCreated EventType = 1 << iota
+	// BlockFlushed is emitted when a block of a shard has been flushed to
+	// a data fileset.
+	BlockFlushed
+	// BlockExpired is emitted when the data fileset of a block of a shard
+	// has been deleted as it is past the retention of its namespace.
+	BlockExpired
+	// NamespaceChanged is emitted when a namespace is added, updated or
+	// removed from the namespace registry.
+	NamespaceChanged
+)
+
+// ValidEventTypes returns the valid event types.
+func ValidEventTypes() []EventType {
+	return []EventType{SeriesCreated, BlockFlushed, BlockExpired, NamespaceChanged}
+}
+
+func (t EventType) String() string {
+	switch t {
+	case SeriesCreated:
+		return "series_created"
+	case BlockFlushed:
+		return "block_flushed"
+	case BlockExpired:
+		return "block_expired"
+	case NamespaceChanged:
+		return "namespace_changed"
+	}
+	return "unknown"
+}
+
+// ParseEventType parses an event type from a string.
+func ParseEventType(str string) (EventType, error) {
+	for _, valid := range ValidEventTypes() {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid change event type '%s' valid types are: %v",
+		str, ValidEventTypes())
+}
+
+// UnmarshalYAML unmarshals an event type.
+func (t *EventType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseEventType(str)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// NamespaceChange is the change made to a namespace.
+type NamespaceChange string
+
+const (
+	// NamespaceAdded is a namespace added to the registry.
+	NamespaceAdded NamespaceChange = "added"
+	// NamespaceUpdated is a namespace whose options changed, updates only
+	// take effect once the node is restarted.
+	NamespaceUpdated NamespaceChange = "updated"
+	// NamespaceRemoved is a namespace removed from the registry, removals
+	// only take effect once the node is restarted.
+	NamespaceRemoved NamespaceChange = "removed"
+)
+
+// Event is a change event, fields that do not apply to the type of the
+// event are left unset.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+
+	// Time is when the change happened.
+	Time time.Time
+
+	// Namespace is the namespace changed.
+	Namespace string
+
+	// Shard is the shard changed, set for all but namespace events.
+	Shard uint32
+
+	// ID and Tags are the ID and tags of created series.
+	ID   []byte
+	Tags map[string]string
+
+	// BlockStart is the start of flushed and expired blocks.
+	BlockStart time.Time
+
+	// NamespaceChange is the change made to a changed namespace.
+	NamespaceChange NamespaceChange
+}
+
+// Sink writes batches of change events to an external system such as a
+// message queue or a webhook.
+type Sink interface {
+	// Write writes a batch of events, the events are retried on error. The
+	// slice of events is reused once Write returns and must not be retained.
+	Write(events []Event) error
+
+	// Close closes the sink.
+	Close() error
+}
+
+// Emitter emits change events to a sink asynchronously, events are
+// dropped rather than blocking the caller if the sink falls behind.
+type Emitter interface {
+	// Enabled returns whether events of the type are emitted, callers
+	// check it before building events that are expensive to build.
+	Enabled(t EventType) bool
+
+	// Emit enqueues an event to be written to the sink.
+	Emit(event Event)
+
+	// Close writes the events enqueued and closes the sink.
+	Close() error
+}
+
+// Options are the options of an emitter.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetClockOptions sets the clock options.
+	SetClockOptions(value clock.Options) Options
+
+	// ClockOptions returns the clock options.
+	ClockOptions() clock.Options
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetEventTypes sets the types of events emitted.
+	SetEventTypes(value []EventType) Options
+
+	// EventTypes returns the types of events emitted.
+	EventTypes() []EventType
+
+	// SetQueueSize sets the max number of events enqueued to be written.
+	SetQueueSize(value int) Options
+
+	// QueueSize returns the max number of events enqueued to be written.
+	QueueSize() int
+
+	// SetBatchSize sets the max number of events written in a batch.
+	SetBatchSize(value int) Options
+
+	// BatchSize returns the max number of events written in a batch.
+	BatchSize() int
+
+	// SetFlushInterval sets the max time events wait to be written.
+	SetFlushInterval(value time.Duration) Options
+
+	// FlushInterval returns the max time events wait to be written.
+	FlushInterval() time.Duration
+
+	// SetRetrier sets the retrier of writes to the sink.
+	SetRetrier(value xretry.Retrier) Options
+
+	// Retrier returns the retrier of writes to the sink.
+	Retrier() xretry.Retrier
+}
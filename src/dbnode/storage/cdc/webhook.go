@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cdc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type webhookPayload struct {
+	Events []webhookEvent `json:"events"`
+}
+
+type webhookEvent struct {
+	Type            string            `json:"type"`
+	Time            time.Time         `json:"time"`
+	Namespace       string            `json:"namespace"`
+	Shard           *uint32           `json:"shard,omitempty"`
+	ID              string            `json:"id,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	BlockStart      *time.Time        `json:"blockStart,omitempty"`
+	NamespaceChange NamespaceChange   `json:"namespaceChange,omitempty"`
+}
+
+func newWebhookEvent(event Event) webhookEvent {
+	e := webhookEvent{
+		Type:            event.Type.String(),
+		Time:            event.Time,
+		Namespace:       event.Namespace,
+		ID:              string(event.ID),
+		Tags:            event.Tags,
+		NamespaceChange: event.NamespaceChange,
+	}
+	if event.Type != NamespaceChanged {
+		shard := event.Shard
+		e.Shard = &shard
+	}
+	if !event.BlockStart.IsZero() {
+		blockStart := event.BlockStart
+		e.BlockStart = &blockStart
+	}
+	return e
+}
+
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookSink returns a sink that posts each batch of events as a JSON
+// object with an "events" array to the URL.
+func NewWebhookSink(
+	url string,
+	headers map[string]string,
+	client *http.Client,
+) Sink {
+	return &webhookSink{url: url, headers: headers, client: client}
+}
+
+func (s *webhookSink) Write(events []Event) error {
+	payload := webhookPayload{Events: make([]webhookEvent, 0, len(events))}
+	for _, event := range events {
+		payload.Events = append(payload.Events, newWebhookEvent(event))
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("change event webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cdc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSinkWrite(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			var err error
+			body, err = ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+		}))
+	defer server.Close()
+
+	var (
+		now  = time.Unix(1000, 0).UTC()
+		sink = NewWebhookSink(server.URL,
+			map[string]string{"Authorization": "Bearer token"}, &http.Client{})
+	)
+	require.NoError(t, sink.Write([]Event{
+		{
+			Type:      SeriesCreated,
+			Time:      now,
+			Namespace: "metrics",
+			Shard:     3,
+			ID:        []byte("foo"),
+			Tags:      map[string]string{"host": "a"},
+		},
+		{
+			Type:            NamespaceChanged,
+			Time:            now,
+			Namespace:       "metrics",
+			NamespaceChange: NamespaceAdded,
+		},
+	}))
+
+	var payload map[string][]map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Equal(t, []map[string]interface{}{
+		{
+			"type":      "series_created",
+			"time":      "1970-01-01T00:16:40Z",
+			"namespace": "metrics",
+			"shard":     float64(3),
+			"id":        "foo",
+			"tags":      map[string]interface{}{"host": "a"},
+		},
+		{
+			"type":            "namespace_changed",
+			"time":            "1970-01-01T00:16:40Z",
+			"namespace":       "metrics",
+			"namespaceChange": "added",
+		},
+	}, payload["events"])
+}
+
+func TestWebhookSinkWriteErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, nil, &http.Client{})
+	require.Error(t, sink.Write([]Event{{Type: BlockFlushed}}))
+}
@@ -21,7 +21,9 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -29,8 +31,12 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
 
 	"github.com/uber-go/tally"
 )
@@ -41,19 +47,75 @@ type deleteFilesFn func(files []string) error
 
 type deleteInactiveDirectoriesFn func(parentDirPath string, activeDirNames []string) error
 
+type fileSizeFn func(filePath string) (int64, error)
+
+type upgradeDataFileSetsFn func(fs.UpgradeDataFileSetsOptions) (fs.UpgradeDataFileSetsResult, error)
+
+type transitionDataFileSetFn func(fs.TransitionDataFileSetOptions) (fs.TransitionDataFileSetResult, error)
+
+const (
+	cleanupFileTypeData      = "data"
+	cleanupFileTypeIndex     = "index"
+	cleanupFileTypeSnapshot  = "snapshot"
+	cleanupFileTypeCommitLog = "commitlog"
+
+	// commitLogs are not owned by a single namespace so their reclaimed
+	// bytes are reported under this namespace tag value instead.
+	cleanupCommitLogNamespace = "_commitlog"
+
+	// maxUpgradeFileSetsPerCleanup is the max number of data filesets that
+	// are upgraded to the current format version by a single cleanup run so
+	// that upgrading filesets does not hold up cleanups.
+	maxUpgradeFileSetsPerCleanup = 4
+)
+
+var (
+	errRetentionTransitionTargetNotFound         = errors.New("retention transition target namespace not found")
+	errRetentionTransitionTargetIsSource         = errors.New("retention transition target namespace must not be the source namespace")
+	errRetentionTransitionTargetShardingInvalid  = errors.New("retention transition target namespace must be sharded the same as the source namespace")
+	errRetentionTransitionTargetBlockSizeInvalid = errors.New("retention transition target namespace block size must be a multiple of the source block size")
+	errRetentionTransitionTargetFlushDisabled    = errors.New("retention transition target namespace must have flush enabled")
+)
+
 type cleanupManager struct {
 	sync.RWMutex
 
 	database                    database
 	opts                        Options
 	nowFn                       clock.NowFn
+	log                         xlog.Logger
+	scope                       tally.Scope
 	filePathPrefix              string
 	commitLogsDir               string
 	commitLogFilesFn            commitLogFilesFn
 	deleteFilesFn               deleteFilesFn
 	deleteInactiveDirectoriesFn deleteInactiveDirectoriesFn
+	fileSizeFn                  fileSizeFn
+	upgradeDataFileSetsFn       upgradeDataFileSetsFn
+	transitionDataFileSetFn     transitionDataFileSetFn
 	cleanupInProgress           bool
 	status                      tally.Gauge
+	metrics                     cleanupManagerMetrics
+}
+
+type cleanupManagerMetrics struct {
+	upgradedFileSets     tally.Counter
+	upgradeErrors        tally.Counter
+	transitionedFileSets tally.Counter
+	transitionedSeries   tally.Counter
+	transitionErrors     tally.Counter
+}
+
+func newCleanupManagerMetrics(scope tally.Scope) cleanupManagerMetrics {
+	upgradeScope := scope.SubScope("upgrade")
+	transitionScope := scope.SubScope("transition")
+	return cleanupManagerMetrics{
+		upgradedFileSets:     upgradeScope.Counter("filesets"),
+		upgradeErrors:        upgradeScope.Counter("errors"),
+		transitionedFileSets: transitionScope.Counter("filesets"),
+		transitionedSeries:   transitionScope.Counter("series"),
+		transitionErrors:     transitionScope.Counter("errors"),
+	}
 }
 
 func newCleanupManager(database database, scope tally.Scope) databaseCleanupManager {
@@ -65,15 +127,63 @@ func newCleanupManager(database database, scope tally.Scope) databaseCleanupMana
 		database:                    database,
 		opts:                        opts,
 		nowFn:                       opts.ClockOptions().NowFn(),
+		log:                         opts.InstrumentOptions().Logger(),
+		scope:                       scope.SubScope("cleanup"),
 		filePathPrefix:              filePathPrefix,
 		commitLogsDir:               commitLogsDir,
 		commitLogFilesFn:            commitlog.Files,
 		deleteFilesFn:               fs.DeleteFiles,
 		deleteInactiveDirectoriesFn: fs.DeleteInactiveDirectories,
-		status: scope.Gauge("cleanup"),
+		fileSizeFn:                  fileSize,
+		upgradeDataFileSetsFn:       fs.UpgradeDataFileSets,
+		transitionDataFileSetFn:     fs.TransitionDataFileSet,
+		status:                      scope.Gauge("cleanup"),
+		metrics:                     newCleanupManagerMetrics(scope.SubScope("cleanup")),
 	}
 }
 
+// namespaceCleanupPlan is the set of expired files of a single namespace.
+type namespaceCleanupPlan struct {
+	namespace     ident.ID
+	dataFiles     []string
+	indexFiles    []string
+	snapshotFiles []string
+	expiredBlocks []expiredDataBlock
+
+	// The expired data filesets that are downsampled into the target
+	// namespace of the retention transition of the namespace before they
+	// are deleted.
+	transition       namespace.RetentionTransition
+	transitionSource namespace.Metadata
+	transitionTarget namespace.Metadata
+	transitions      []dataTransition
+	transitionErr    error
+}
+
+// expiredDataBlock is a block of a shard whose data fileset has expired.
+type expiredDataBlock struct {
+	shard      uint32
+	blockStart time.Time
+}
+
+// dataTransition is a block of the retention transition target namespace
+// along with the expired data filesets of a shard that are downsampled into
+// it.
+type dataTransition struct {
+	shard            uint32
+	targetBlockStart time.Time
+	files            []string
+	expiredBlocks    []expiredDataBlock
+}
+
+// cleanupPlan is the set of all files a cleanup run will delete. The plan is
+// computed in full before any file is deleted so that a failure to determine
+// what has expired in one place never leaves the others partially cleaned up.
+type cleanupPlan struct {
+	namespaces     []namespaceCleanupPlan
+	commitLogFiles []commitlog.File
+}
+
 func (m *cleanupManager) Cleanup(t time.Time) error {
 	m.Lock()
 	m.cleanupInProgress = true
@@ -85,20 +195,31 @@ func (m *cleanupManager) Cleanup(t time.Time) error {
 		m.Unlock()
 	}()
 
+	plan, err := m.planCleanup(t)
+	if err != nil {
+		return fmt.Errorf(
+			"encountered errors when planning cleanup for %v, no files deleted: %v", t, err)
+	}
+
+	if m.opts.CleanupDryRun() {
+		m.reportCleanupPlan(t, plan)
+		return nil
+	}
+
 	multiErr := xerrors.NewMultiError()
-	if err := m.cleanupExpiredDataFiles(t); err != nil {
+	if err := m.executeCleanupPlan(plan); err != nil {
 		multiErr = multiErr.Add(fmt.Errorf(
-			"encountered errors when cleaning up data files for %v: %v", t, err))
+			"encountered errors when cleaning up expired files for %v: %v", t, err))
 	}
 
-	if err := m.cleanupExpiredIndexFiles(t); err != nil {
+	if err := m.compactIndexFiles(t); err != nil {
 		multiErr = multiErr.Add(fmt.Errorf(
-			"encountered errors when cleaning up index files for %v: %v", t, err))
+			"encountered errors when compacting index files for %v: %v", t, err))
 	}
 
-	if err := m.cleanupDataSnapshotFiles(t); err != nil {
+	if err := m.upgradeDataFiles(); err != nil {
 		multiErr = multiErr.Add(fmt.Errorf(
-			"encountered errors when cleaning up snapshot files for %v: %v", t, err))
+			"encountered errors when upgrading data files for %v: %v", t, err))
 	}
 
 	if err := m.deleteInactiveDataFiles(); err != nil {
@@ -116,22 +237,458 @@ func (m *cleanupManager) Cleanup(t time.Time) error {
 			"encountered errors when deleting inactive namespace files for %v: %v", t, err))
 	}
 
-	filesToCleanup, err := m.commitLogTimes(t)
-	if err != nil {
+	if err := m.cleanupCommitLogs(plan.commitLogFiles); err != nil {
 		multiErr = multiErr.Add(fmt.Errorf(
-			"encountered errors when cleaning up commit logs: %v", err))
-		return multiErr.FinalError()
+			"encountered errors when cleaning up commit logs for commitLogFiles %v: %v",
+			plan.commitLogFiles, err))
 	}
 
-	if err := m.cleanupCommitLogs(filesToCleanup); err != nil {
+	return multiErr.FinalError()
+}
+
+// planCleanup determines the expired data, index and snapshot filesets of
+// every owned namespace as well as the commit logs that are safe to delete.
+func (m *cleanupManager) planCleanup(t time.Time) (cleanupPlan, error) {
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return cleanupPlan{}, err
+	}
+
+	var (
+		plan     cleanupPlan
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, n := range namespaces {
+		if !n.Options().CleanupEnabled() {
+			continue
+		}
+		nsPlan, err := m.planNamespaceCleanup(n, t)
+		if err != nil {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"encountered errors when planning cleanup for namespace %s: %v", n.ID(), err))
+			continue
+		}
+		plan.namespaces = append(plan.namespaces, nsPlan)
+	}
+
+	commitLogFiles, err := m.commitLogTimes(t)
+	if err != nil {
 		multiErr = multiErr.Add(fmt.Errorf(
-			"encountered errors when cleaning up commit logs for commitLogFiles %v: %v",
-			filesToCleanup, err))
+			"encountered errors when planning commit log cleanup: %v", err))
+	}
+	plan.commitLogFiles = commitLogFiles
+
+	if err := multiErr.FinalError(); err != nil {
+		return cleanupPlan{}, err
+	}
+	return plan, nil
+}
+
+func (m *cleanupManager) planNamespaceCleanup(
+	n databaseNamespace,
+	t time.Time,
+) (namespaceCleanupPlan, error) {
+	return m.planShardsCleanup(n, n.GetOwnedShards(), true, t)
+}
+
+// planShardsCleanup determines the expired data and snapshot filesets of the
+// given shards of a namespace, and its expired index filesets if includeIndex
+// is set as index filesets span every shard of the namespace.
+func (m *cleanupManager) planShardsCleanup(
+	n databaseNamespace,
+	shards []databaseShard,
+	includeIndex bool,
+	t time.Time,
+) (namespaceCleanupPlan, error) {
+	var (
+		plan             = namespaceCleanupPlan{namespace: n.ID()}
+		earliestToRetain = retention.FlushTimeStart(n.Options().RetentionOptions(), t)
+		multiErr         = xerrors.NewMultiError()
+		target           databaseNamespace
+	)
+	plan.transition = n.Options().RetentionTransition()
+	if plan.transition.TargetNamespace != "" {
+		target, plan.transitionErr = m.planRetentionTransition(&plan, n)
+	}
+	for _, shard := range shards {
+		dataFiles, err := shard.ExpiredFileSets(earliestToRetain)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+		switch {
+		case plan.transition.TargetNamespace == "":
+			plan.dataFiles = append(plan.dataFiles, dataFiles...)
+			if len(dataFiles) > 0 {
+				plan.expiredBlocks = append(plan.expiredBlocks,
+					expiredDataBlocks(shard.ID(), dataFiles)...)
+			}
+		case plan.transitionErr == nil:
+			m.planDataTransitions(&plan, n, target, shard, dataFiles, t)
+		default:
+			// The expired data filesets are retained until the retention
+			// transition is fixed so that their data is not lost.
+		}
+
+		snapshotFiles, err := shard.SnapshotFilesToCleanup(earliestToRetain)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+		plan.snapshotFiles = append(plan.snapshotFiles, snapshotFiles...)
+	}
+
+	if includeIndex && n.Options().IndexOptions().Enabled() {
+		idx, err := n.GetIndex()
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		} else {
+			indexFiles, err := idx.ExpiredFileSets(t)
+			if err != nil {
+				multiErr = multiErr.Add(err)
+			}
+			plan.indexFiles = indexFiles
+		}
+	}
+
+	return plan, multiErr.FinalError()
+}
+
+// planRetentionTransition returns the target namespace of the retention
+// transition of the namespace, or an error if the expired data filesets of
+// the namespace can not be transitioned into it.
+func (m *cleanupManager) planRetentionTransition(
+	plan *namespaceCleanupPlan,
+	source databaseNamespace,
+) (databaseNamespace, error) {
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var target databaseNamespace
+	for _, n := range namespaces {
+		if n.ID().String() == plan.transition.TargetNamespace {
+			target = n
+			break
+		}
+	}
+	if target == nil {
+		return nil, errRetentionTransitionTargetNotFound
+	}
+	if target.ID().Equal(source.ID()) {
+		return nil, errRetentionTransitionTargetIsSource
+	}
+
+	var (
+		sourceOpts = source.Options()
+		targetOpts = target.Options()
+	)
+	// Downsampled series are written to the shard of the target namespace
+	// with the same ID as the shard of the source namespace.
+	if !sourceOpts.ShardingOptions().Equal(targetOpts.ShardingOptions()) {
+		return nil, errRetentionTransitionTargetShardingInvalid
+	}
+	if targetOpts.RetentionOptions().BlockSize()%sourceOpts.RetentionOptions().BlockSize() != 0 {
+		return nil, errRetentionTransitionTargetBlockSizeInvalid
+	}
+	if !targetOpts.FlushEnabled() {
+		return nil, errRetentionTransitionTargetFlushDisabled
+	}
+
+	plan.transitionSource, err = namespace.NewMetadata(source.ID(), sourceOpts)
+	if err != nil {
+		return nil, err
+	}
+	plan.transitionTarget, err = namespace.NewMetadata(target.ID(), targetOpts)
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// planDataTransitions groups the expired data filesets of a shard by the
+// block of the retention transition target namespace they are downsampled
+// into. The filesets are retained until all blocks of the source namespace
+// within the target block have expired and the target shard has flushed the
+// target block, so that each target block is only transitioned once. The
+// filesets of target blocks that have also expired in the target namespace
+// are deleted without being transitioned.
+func (m *cleanupManager) planDataTransitions(
+	plan *namespaceCleanupPlan,
+	source, target databaseNamespace,
+	shard databaseShard,
+	dataFiles []string,
+	t time.Time,
+) {
+	var (
+		sourceRetain = retention.FlushTimeStart(source.Options().RetentionOptions(), t)
+		targetRetain = retention.FlushTimeStart(target.Options().RetentionOptions(), t)
+		blockSize    = target.Options().RetentionOptions().BlockSize()
+		targetShard  databaseShard
+		expiredFiles []string
+		transitions  []*dataTransition
+		byBlockStart = make(map[int64]*dataTransition)
+	)
+	for _, s := range target.GetOwnedShards() {
+		if s.ID() == shard.ID() {
+			targetShard = s
+			break
+		}
+	}
+
+	for _, file := range dataFiles {
+		blockStart, err := fs.TimeFromFileName(file)
+		if err != nil {
+			expiredFiles = append(expiredFiles, file)
+			continue
+		}
+
+		targetBlockStart := blockStart.Truncate(blockSize)
+		if targetBlockStart.Before(targetRetain) {
+			expiredFiles = append(expiredFiles, file)
+			continue
+		}
+		if targetBlockStart.Add(blockSize).After(sourceRetain) ||
+			targetShard == nil ||
+			targetShard.FlushState(targetBlockStart).Status != fileOpSuccess {
+			continue
+		}
+
+		key := targetBlockStart.UnixNano()
+		transition, ok := byBlockStart[key]
+		if !ok {
+			transition = &dataTransition{
+				shard:            shard.ID(),
+				targetBlockStart: targetBlockStart,
+			}
+			byBlockStart[key] = transition
+			transitions = append(transitions, transition)
+		}
+		transition.files = append(transition.files, file)
+	}
+
+	plan.dataFiles = append(plan.dataFiles, expiredFiles...)
+	if len(expiredFiles) > 0 {
+		plan.expiredBlocks = append(plan.expiredBlocks,
+			expiredDataBlocks(shard.ID(), expiredFiles)...)
+	}
+	for _, transition := range transitions {
+		transition.expiredBlocks = expiredDataBlocks(shard.ID(), transition.files)
+		plan.transitions = append(plan.transitions, *transition)
+	}
+}
+
+// cleanupNamespace deletes the expired filesets of the given shards of a
+// single namespace, see planShardsCleanup.
+func (m *cleanupManager) cleanupNamespace(
+	n databaseNamespace,
+	shards []databaseShard,
+	includeIndex bool,
+	t time.Time,
+) error {
+	nsPlan, err := m.planShardsCleanup(n, shards, includeIndex, t)
+	if err != nil {
+		return fmt.Errorf(
+			"encountered errors when planning cleanup of namespace %s for %v, no files deleted: %v",
+			n.ID(), t, err)
+	}
+
+	plan := cleanupPlan{namespaces: []namespaceCleanupPlan{nsPlan}}
+	if m.opts.CleanupDryRun() {
+		m.reportCleanupPlan(t, plan)
+		return nil
 	}
+	return m.executeCleanupPlan(plan)
+}
+
+// executeCleanupPlan deletes the expired filesets of each namespace, data
+// filesets first, then index filesets and finally snapshots.
+func (m *cleanupManager) executeCleanupPlan(plan cleanupPlan) error {
+	multiErr := xerrors.NewMultiError()
+	for _, ns := range plan.namespaces {
+		namespace := ns.namespace.String()
+		if ns.transitionErr != nil {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"unable to transition expired data files of namespace %s to namespace %s: %v",
+				namespace, ns.transition.TargetNamespace, ns.transitionErr))
+		}
+		multiErr = multiErr.Add(m.transitionDataFiles(ns))
+		err := m.deleteFiles(namespace, cleanupFileTypeData, ns.dataFiles)
+		if err == nil {
+			m.emitExpiredBlocks(namespace, ns.expiredBlocks)
+		}
+		multiErr = multiErr.Add(err)
+		multiErr = multiErr.Add(m.deleteFiles(namespace, cleanupFileTypeIndex, ns.indexFiles))
+		multiErr = multiErr.Add(m.deleteFiles(namespace, cleanupFileTypeSnapshot, ns.snapshotFiles))
+	}
+	return multiErr.FinalError()
+}
+
+// transitionDataFiles downsamples the expired data filesets of each
+// transition of the namespace into the target namespace and deletes them
+// once they are transitioned, filesets that fail to be transitioned are
+// retained and transitioned by a later run.
+func (m *cleanupManager) transitionDataFiles(ns namespaceCleanupPlan) error {
+	var (
+		nsName   = ns.namespace.String()
+		fsOpts   = m.opts.CommitLogOptions().FilesystemOptions()
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, transition := range ns.transitions {
+		sourceBlockStarts := make([]time.Time, 0, len(transition.expiredBlocks))
+		for _, block := range transition.expiredBlocks {
+			sourceBlockStarts = append(sourceBlockStarts, block.blockStart)
+		}
+
+		result, err := m.transitionDataFileSetFn(fs.TransitionDataFileSetOptions{
+			Source:             ns.transitionSource,
+			SourceBlockStarts:  sourceBlockStarts,
+			Target:             ns.transitionTarget,
+			TargetBlockStart:   transition.targetBlockStart,
+			Shard:              transition.shard,
+			Resolution:         ns.transition.Resolution,
+			Aggregation:        ns.transition.Aggregation,
+			FilesystemOptions:  fsOpts,
+			EncoderPool:        m.opts.EncoderPool(),
+			ReaderIteratorPool: m.opts.ReaderIteratorPool(),
+			MemSegmentOptions:  m.opts.IndexOptions().MemSegmentOptions(),
+			BytesPool:          m.opts.BytesPool(),
+		})
+		if err != nil {
+			m.metrics.transitionErrors.Inc(1)
+			multiErr = multiErr.Add(fmt.Errorf(
+				"unable to transition data files of namespace %s shard %d to namespace %s block start %v: %v",
+				nsName, transition.shard, ns.transition.TargetNamespace,
+				transition.targetBlockStart, err))
+			continue
+		}
 
+		m.metrics.transitionedFileSets.Inc(1)
+		m.metrics.transitionedSeries.Inc(int64(result.Series))
+		m.log.Infof("transitioned %d series of namespace %s shard %d to namespace %s block start %v",
+			result.Series, nsName, transition.shard, ns.transition.TargetNamespace,
+			transition.targetBlockStart)
+
+		if err := m.deleteFiles(nsName, cleanupFileTypeData, transition.files); err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		m.emitExpiredBlocks(nsName, transition.expiredBlocks)
+	}
 	return multiErr.FinalError()
 }
 
+// expiredDataBlocks returns the distinct blocks of the data fileset files
+// of a shard.
+func expiredDataBlocks(shard uint32, files []string) []expiredDataBlock {
+	var (
+		blocks []expiredDataBlock
+		seen   = make(map[int64]struct{})
+	)
+	for _, file := range files {
+		blockStart, err := fs.TimeFromFileName(file)
+		if err != nil {
+			continue
+		}
+		key := blockStart.UnixNano()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		blocks = append(blocks, expiredDataBlock{shard: shard, blockStart: blockStart})
+	}
+	return blocks
+}
+
+// emitExpiredBlocks emits a change event for each block whose expired data
+// fileset was deleted.
+func (m *cleanupManager) emitExpiredBlocks(namespace string, blocks []expiredDataBlock) {
+	changes := m.opts.ChangeEmitter()
+	if !changes.Enabled(cdc.BlockExpired) {
+		return
+	}
+	for _, block := range blocks {
+		changes.Emit(cdc.Event{
+			Type:       cdc.BlockExpired,
+			Namespace:  namespace,
+			Shard:      block.shard,
+			BlockStart: block.blockStart,
+		})
+	}
+}
+
+// deleteFiles deletes the files and, if all of them were deleted, records the
+// bytes reclaimed for the namespace and file type.
+func (m *cleanupManager) deleteFiles(namespace, fileType string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	size := m.filesSize(files)
+	if err := m.deleteFilesFn(files); err != nil {
+		return err
+	}
+	m.reclaimedScope(namespace, fileType).Counter("reclaimed-bytes").Inc(size)
+	return nil
+}
+
+// reportCleanupPlan logs and emits the bytes a cleanup run would reclaim
+// without deleting any files.
+func (m *cleanupManager) reportCleanupPlan(t time.Time, plan cleanupPlan) {
+	report := func(namespace, fileType string, files []string) {
+		size := m.filesSize(files)
+		m.reclaimedScope(namespace, fileType).Gauge("reclaimable-bytes").Update(float64(size))
+		if len(files) == 0 {
+			return
+		}
+		m.log.WithFields(
+			xlog.NewField("time", t.String()),
+			xlog.NewField("namespace", namespace),
+			xlog.NewField("type", fileType),
+			xlog.NewField("files", files),
+			xlog.NewField("bytes", size),
+		).Info("cleanup dry run would delete files")
+	}
+
+	for _, ns := range plan.namespaces {
+		namespace := ns.namespace.String()
+		dataFiles := ns.dataFiles
+		for _, transition := range ns.transitions {
+			dataFiles = append(dataFiles, transition.files...)
+		}
+		report(namespace, cleanupFileTypeData, dataFiles)
+		report(namespace, cleanupFileTypeIndex, ns.indexFiles)
+		report(namespace, cleanupFileTypeSnapshot, ns.snapshotFiles)
+	}
+	report(cleanupCommitLogNamespace, cleanupFileTypeCommitLog, commitLogFilePaths(plan.commitLogFiles))
+}
+
+func (m *cleanupManager) reclaimedScope(namespace, fileType string) tally.Scope {
+	return xmetrics.NamespaceScope(m.scope, namespace).Tagged(map[string]string{
+		"type": fileType,
+	})
+}
+
+// filesSize returns the total size of the files, files that can no longer
+// be found are counted as empty.
+func (m *cleanupManager) filesSize(files []string) int64 {
+	var total int64
+	for _, f := range files {
+		size, err := m.fileSizeFn(f)
+		if err != nil {
+			continue
+		}
+		total += size
+	}
+	return total
+}
+
+func fileSize(filePath string) (int64, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
 func (m *cleanupManager) Report() {
 	m.RLock()
 	cleanupInProgress := m.cleanupInProgress
@@ -192,24 +749,7 @@ func (m *cleanupManager) deleteInactiveDataFileSetFiles(filesetFilesDirPathFn fu
 	return multiErr.FinalError()
 }
 
-func (m *cleanupManager) cleanupExpiredDataFiles(t time.Time) error {
-	multiErr := xerrors.NewMultiError()
-	namespaces, err := m.database.GetOwnedNamespaces()
-	if err != nil {
-		return err
-	}
-	for _, n := range namespaces {
-		if !n.Options().CleanupEnabled() {
-			continue
-		}
-		earliestToRetain := retention.FlushTimeStart(n.Options().RetentionOptions(), t)
-		shards := n.GetOwnedShards()
-		multiErr = multiErr.Add(m.cleanupExpiredNamespaceDataFiles(earliestToRetain, shards))
-	}
-	return multiErr.FinalError()
-}
-
-func (m *cleanupManager) cleanupExpiredIndexFiles(t time.Time) error {
+func (m *cleanupManager) compactIndexFiles(t time.Time) error {
 	namespaces, err := m.database.GetOwnedNamespaces()
 	if err != nil {
 		return err
@@ -224,46 +764,56 @@ func (m *cleanupManager) cleanupExpiredIndexFiles(t time.Time) error {
 			multiErr = multiErr.Add(err)
 			continue
 		}
-		multiErr = multiErr.Add(idx.CleanupExpiredFileSets(t))
+		multiErr = multiErr.Add(idx.CompactFileSets(t))
 	}
 	return multiErr.FinalError()
 }
 
-func (m *cleanupManager) cleanupDataSnapshotFiles(t time.Time) error {
-	multiErr := xerrors.NewMultiError()
+// upgradeDataFiles rewrites data filesets with an older format version in the
+// current format version, at most maxUpgradeFileSetsPerCleanup filesets are
+// upgraded per run and the remaining filesets are upgraded by later runs.
+func (m *cleanupManager) upgradeDataFiles() error {
 	namespaces, err := m.database.GetOwnedNamespaces()
 	if err != nil {
 		return err
 	}
-	for _, n := range namespaces {
-		earliestToRetain := retention.FlushTimeStart(n.Options().RetentionOptions(), t)
-		shards := n.GetOwnedShards()
-		if n.Options().CleanupEnabled() {
-			multiErr = multiErr.Add(m.cleanupNamespaceSnapshotFiles(earliestToRetain, shards))
-		}
-	}
-	return multiErr.FinalError()
-}
 
-func (m *cleanupManager) cleanupExpiredNamespaceDataFiles(earliestToRetain time.Time, shards []databaseShard) error {
-	multiErr := xerrors.NewMultiError()
-	for _, shard := range shards {
-		if err := shard.CleanupExpiredFileSets(earliestToRetain); err != nil {
-			multiErr = multiErr.Add(err)
+	var (
+		fsOpts   = m.opts.CommitLogOptions().FilesystemOptions()
+		rateOpts = m.opts.RuntimeOptionsManager().Get().PersistRateLimitOptions()
+		budget   = maxUpgradeFileSetsPerCleanup
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, n := range namespaces {
+		if !n.Options().CleanupEnabled() {
+			continue
 		}
-	}
-
-	return multiErr.FinalError()
-}
-
-func (m *cleanupManager) cleanupNamespaceSnapshotFiles(earliestToRetain time.Time, shards []databaseShard) error {
-	multiErr := xerrors.NewMultiError()
-	for _, shard := range shards {
-		if err := shard.CleanupSnapshots(earliestToRetain); err != nil {
-			multiErr = multiErr.Add(err)
+		for _, shard := range n.GetOwnedShards() {
+			if budget <= 0 {
+				return multiErr.FinalError()
+			}
+			result, err := m.upgradeDataFileSetsFn(fs.UpgradeDataFileSetsOptions{
+				Namespace:         n.ID(),
+				Shard:             shard.ID(),
+				MaxFileSets:       budget,
+				FilesystemOptions: fsOpts,
+				RateLimitOptions:  rateOpts,
+				BytesPool:         m.opts.BytesPool(),
+			})
+			budget -= len(result.Upgraded)
+			m.metrics.upgradedFileSets.Inc(int64(len(result.Upgraded)))
+			for _, blockStart := range result.Upgraded {
+				m.log.Infof("upgraded data fileset for namespace %s shard %d block start %v",
+					n.ID(), shard.ID(), blockStart)
+			}
+			if err != nil {
+				m.metrics.upgradeErrors.Inc(1)
+				multiErr = multiErr.Add(fmt.Errorf(
+					"unable to upgrade data files for namespace %s shard %d: %v",
+					n.ID(), shard.ID(), err))
+			}
 		}
 	}
-
 	return multiErr.FinalError()
 }
 
@@ -386,9 +936,14 @@ func commitLogNamespaceBlockTimes(
 }
 
 func (m *cleanupManager) cleanupCommitLogs(filesToCleanup []commitlog.File) error {
-	filesToDelete := make([]string, 0, len(filesToCleanup))
-	for _, f := range filesToCleanup {
-		filesToDelete = append(filesToDelete, f.FilePath)
+	return m.deleteFiles(cleanupCommitLogNamespace, cleanupFileTypeCommitLog,
+		commitLogFilePaths(filesToCleanup))
+}
+
+func commitLogFilePaths(files []commitlog.File) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.FilePath)
 	}
-	return m.deleteFilesFn(filesToDelete)
+	return paths
 }
@@ -27,8 +27,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3x/ident"
 	xtest "github.com/m3db/m3x/test"
@@ -116,14 +118,15 @@ func TestCleanupManagerNamespaceCleanup(t *testing.T) {
 	ns.EXPECT().GetOwnedShards().Return(nil).AnyTimes()
 
 	idx := NewMocknamespaceIndex(ctrl)
-	ns.EXPECT().GetIndex().Return(idx, nil)
+	ns.EXPECT().GetIndex().Return(idx, nil).Times(2)
 
 	nses := []databaseNamespace{ns}
 	db := newMockdatabase(ctrl, ns)
 	db.EXPECT().GetOwnedNamespaces().Return(nses, nil).AnyTimes()
 
 	mgr := newCleanupManager(db, tally.NoopScope).(*cleanupManager)
-	idx.EXPECT().CleanupExpiredFileSets(ts).Return(nil)
+	idx.EXPECT().ExpiredFileSets(ts).Return(nil, nil)
+	idx.EXPECT().CompactFileSets(ts).Return(nil)
 	require.NoError(t, mgr.Cleanup(ts))
 }
 
@@ -172,8 +175,8 @@ func TestCleanupDataAndSnapshotFileSetFiles(t *testing.T) {
 
 	shard := NewMockdatabaseShard(ctrl)
 	expectedEarliestToRetain := retention.FlushTimeStart(ns.Options().RetentionOptions(), ts)
-	shard.EXPECT().CleanupExpiredFileSets(expectedEarliestToRetain).Return(nil)
-	shard.EXPECT().CleanupSnapshots(expectedEarliestToRetain)
+	shard.EXPECT().ExpiredFileSets(expectedEarliestToRetain).Return(nil, nil)
+	shard.EXPECT().SnapshotFilesToCleanup(expectedEarliestToRetain).Return(nil, nil)
 	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
 	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
 	ns.EXPECT().ID().Return(ident.StringID("nsID")).AnyTimes()
@@ -187,6 +190,165 @@ func TestCleanupDataAndSnapshotFileSetFiles(t *testing.T) {
 	require.NoError(t, mgr.Cleanup(ts))
 }
 
+func TestCleanupManagerUpgradeDataFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nsOpts := namespace.NewOptions()
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("ns")).AnyTimes()
+
+	var shards []databaseShard
+	for i := 0; i < 3; i++ {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(uint32(i)).AnyTimes()
+		shards = append(shards, shard)
+	}
+	ns.EXPECT().GetOwnedShards().Return(shards).AnyTimes()
+
+	namespaces := []databaseNamespace{ns}
+	db := newMockdatabase(ctrl, namespaces...)
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil).AnyTimes()
+	mgr := newCleanupManager(db, tally.NoopScope).(*cleanupManager)
+
+	var calls []fs.UpgradeDataFileSetsOptions
+	mgr.upgradeDataFileSetsFn = func(
+		opts fs.UpgradeDataFileSetsOptions,
+	) (fs.UpgradeDataFileSetsResult, error) {
+		calls = append(calls, opts)
+		// Each shard has three filesets to upgrade.
+		var result fs.UpgradeDataFileSetsResult
+		for i := 0; i < 3; i++ {
+			if i < opts.MaxFileSets {
+				result.Upgraded = append(result.Upgraded, timeFor(int64(i)))
+			} else {
+				result.Remaining++
+			}
+		}
+		return result, nil
+	}
+
+	// The budget of the run is exhausted by the second shard.
+	require.NoError(t, mgr.upgradeDataFiles())
+	require.Equal(t, 2, len(calls))
+	require.Equal(t, uint32(0), calls[0].Shard)
+	require.Equal(t, maxUpgradeFileSetsPerCleanup, calls[0].MaxFileSets)
+	require.Equal(t, uint32(1), calls[1].Shard)
+	require.Equal(t, 1, calls[1].MaxFileSets)
+}
+
+func newCleanupPlanTestManager(
+	ctrl *gomock.Controller,
+	shardErr error,
+) (*cleanupManager, tally.TestScope) {
+	ts := timeFor(36000)
+	nsOpts := namespace.NewOptions()
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("ns")).AnyTimes()
+	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+
+	earliestToRetain := retention.FlushTimeStart(nsOpts.RetentionOptions(), ts)
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	shard.EXPECT().ExpiredFileSets(earliestToRetain).Return([]string{"data"}, nil)
+	shard.EXPECT().SnapshotFilesToCleanup(earliestToRetain).Return([]string{"snapshot"}, shardErr)
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+
+	namespaces := []databaseNamespace{ns}
+	db := newMockdatabase(ctrl, namespaces...)
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil).AnyTimes()
+
+	scope := tally.NewTestScope("", nil)
+	mgr := newCleanupManager(db, scope).(*cleanupManager)
+	mgr.commitLogFilesFn = func(_ commitlog.Options) ([]commitlog.File, error) {
+		return []commitlog.File{
+			commitlog.File{FilePath: "commitlog", Start: time10},
+		}, nil
+	}
+	mgr.fileSizeFn = func(filePath string) (int64, error) {
+		return int64(len(filePath)), nil
+	}
+	return mgr, scope
+}
+
+func TestCleanupManagerCleanupReportsReclaimedBytes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr, scope := newCleanupPlanTestManager(ctrl, nil)
+	var deletedFiles []string
+	mgr.deleteFilesFn = func(files []string) error {
+		deletedFiles = append(deletedFiles, files...)
+		return nil
+	}
+
+	require.NoError(t, mgr.Cleanup(timeFor(36000)))
+	require.Equal(t, []string{"data", "snapshot", "commitlog"}, deletedFiles)
+
+	counters := scope.Snapshot().Counters()
+	for key, expected := range map[string]int64{
+		"cleanup.reclaimed-bytes+namespace=ns,type=data":              4,
+		"cleanup.reclaimed-bytes+namespace=ns,type=snapshot":          8,
+		"cleanup.reclaimed-bytes+namespace=_commitlog,type=commitlog": 9,
+	} {
+		counter, ok := counters[key]
+		require.True(t, ok, key)
+		require.Equal(t, expected, counter.Value(), key)
+	}
+}
+
+func TestCleanupManagerCleanupDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr, scope := newCleanupPlanTestManager(ctrl, nil)
+	mgr.opts = mgr.opts.SetCleanupDryRun(true)
+	mgr.deleteFilesFn = func(files []string) error {
+		require.FailNow(t, "unexpected delete during dry run", "%v", files)
+		return nil
+	}
+	mgr.deleteInactiveDirectoriesFn = func(string, []string) error {
+		require.FailNow(t, "unexpected inactive directory delete during dry run")
+		return nil
+	}
+
+	require.NoError(t, mgr.Cleanup(timeFor(36000)))
+
+	gauges := scope.Snapshot().Gauges()
+	for key, expected := range map[string]float64{
+		"cleanup.reclaimable-bytes+namespace=ns,type=data":              4,
+		"cleanup.reclaimable-bytes+namespace=ns,type=snapshot":          8,
+		"cleanup.reclaimable-bytes+namespace=_commitlog,type=commitlog": 9,
+	} {
+		gauge, ok := gauges[key]
+		require.True(t, ok, key)
+		require.Equal(t, expected, gauge.Value(), key)
+	}
+	require.Empty(t, scope.Snapshot().Counters())
+}
+
+func TestCleanupManagerCleanupPlanErrorDeletesNothing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr, _ := newCleanupPlanTestManager(ctrl, errors.New("snapshot files error"))
+	mgr.deleteFilesFn = func(files []string) error {
+		require.FailNow(t, "unexpected delete after planning error", "%v", files)
+		return nil
+	}
+	mgr.deleteInactiveDirectoriesFn = func(string, []string) error {
+		require.FailNow(t, "unexpected inactive directory delete after planning error")
+		return nil
+	}
+
+	err := mgr.Cleanup(timeFor(36000))
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "no files deleted"))
+}
+
 type deleteInactiveDirectoriesCall struct {
 	parentDirPath  string
 	activeDirNames []string
@@ -741,3 +903,220 @@ func TestCleanupManagerCommitLogTimesMultiNS(t *testing.T) {
 	require.True(t, contains(filesToCleanup, time10))
 	require.True(t, contains(filesToCleanup, time20))
 }
+
+func TestCleanupManagerEmitsExpiredBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr, _ := newCleanupPlanTestManager(ctrl, nil)
+	changes := &testChangeEmitter{}
+	mgr.opts = mgr.opts.SetChangeEmitter(changes)
+
+	files := []string{
+		"/var/lib/m3db/data/ns/1/fileset-1000-0-data.db",
+		"/var/lib/m3db/data/ns/1/fileset-1000-0-checkpoint.db",
+		"/var/lib/m3db/data/ns/1/fileset-2000-0-data.db",
+	}
+	plan := cleanupPlan{namespaces: []namespaceCleanupPlan{{
+		namespace:     ident.StringID("ns"),
+		dataFiles:     files,
+		expiredBlocks: expiredDataBlocks(1, files),
+	}}}
+
+	// No events are emitted for blocks that failed to be deleted.
+	mgr.deleteFilesFn = func(files []string) error {
+		return errors.New("delete failed")
+	}
+	require.Error(t, mgr.executeCleanupPlan(plan))
+	require.Empty(t, changes.events)
+
+	mgr.deleteFilesFn = func(files []string) error { return nil }
+	require.NoError(t, mgr.executeCleanupPlan(plan))
+	require.Equal(t, []cdc.Event{
+		{
+			Type:       cdc.BlockExpired,
+			Namespace:  "ns",
+			Shard:      1,
+			BlockStart: time.Unix(0, 1000),
+		},
+		{
+			Type:       cdc.BlockExpired,
+			Namespace:  "ns",
+			Shard:      1,
+			BlockStart: time.Unix(0, 2000),
+		},
+	}, changes.events)
+}
+
+func newRetentionTransitionTestManager(
+	ctrl *gomock.Controller,
+	t time.Time,
+	dataFiles []string,
+	targetFlushed time.Time,
+) (*cleanupManager, databaseNamespace) {
+	sourceOpts := namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().
+			SetRetentionPeriod(10 * time.Hour).
+			SetBlockSize(2 * time.Hour)).
+		SetRetentionTransition(namespace.RetentionTransition{
+			TargetNamespace: "ns_5m",
+			Resolution:      5 * time.Minute,
+			Aggregation:     namespace.ContinuousQueryMax,
+		})
+	targetOpts := namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().
+			SetRetentionPeriod(48 * time.Hour).
+			SetBlockSize(4 * time.Hour))
+
+	source := NewMockdatabaseNamespace(ctrl)
+	source.EXPECT().Options().Return(sourceOpts).AnyTimes()
+	source.EXPECT().ID().Return(ident.StringID("ns")).AnyTimes()
+
+	sourceShard := NewMockdatabaseShard(ctrl)
+	sourceShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	sourceShard.EXPECT().
+		ExpiredFileSets(retention.FlushTimeStart(sourceOpts.RetentionOptions(), t)).
+		Return(dataFiles, nil)
+	sourceShard.EXPECT().SnapshotFilesToCleanup(gomock.Any()).Return(nil, nil)
+	source.EXPECT().GetOwnedShards().Return([]databaseShard{sourceShard}).AnyTimes()
+
+	target := NewMockdatabaseNamespace(ctrl)
+	target.EXPECT().Options().Return(targetOpts).AnyTimes()
+	target.EXPECT().ID().Return(ident.StringID("ns_5m")).AnyTimes()
+
+	targetShard := NewMockdatabaseShard(ctrl)
+	targetShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	targetShard.EXPECT().FlushState(targetFlushed).
+		Return(fileOpState{Status: fileOpSuccess}).AnyTimes()
+	targetShard.EXPECT().FlushState(gomock.Any()).
+		Return(fileOpState{Status: fileOpNotStarted}).AnyTimes()
+	target.EXPECT().GetOwnedShards().Return([]databaseShard{targetShard}).AnyTimes()
+
+	db := newMockdatabase(ctrl, source, target)
+	return newCleanupManager(db, tally.NoopScope).(*cleanupManager), source
+}
+
+func TestCleanupManagerPlanRetentionTransitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		epoch  = time.Unix(0, 0)
+		now    = epoch.Add(4012*time.Hour + 30*time.Minute)
+		fileAt = func(hours time.Duration) string {
+			return fmt.Sprintf("/var/lib/m3db/data/ns/0/fileset-%d-data.db",
+				epoch.Add(hours*time.Hour).UnixNano())
+		}
+		// Expired in the target namespace as well.
+		expired = fileAt(3900)
+		// The target block has not been flushed by the target shard.
+		unflushed = fileAt(3992)
+		// All source blocks of the target block have expired.
+		first  = fileAt(3996)
+		second = fileAt(3998)
+		// The target block still has a source block within retention.
+		partial = fileAt(4000)
+	)
+	mgr, source := newRetentionTransitionTestManager(ctrl, now,
+		[]string{expired, unflushed, first, second, partial},
+		epoch.Add(3996*time.Hour))
+
+	plan, err := mgr.planNamespaceCleanup(source, now)
+	require.NoError(t, err)
+	require.NoError(t, plan.transitionErr)
+	require.Equal(t, []string{expired}, plan.dataFiles)
+	require.Equal(t, []expiredDataBlock{
+		{shard: 0, blockStart: epoch.Add(3900 * time.Hour)},
+	}, plan.expiredBlocks)
+	require.Equal(t, []dataTransition{{
+		shard:            0,
+		targetBlockStart: epoch.Add(3996 * time.Hour),
+		files:            []string{first, second},
+		expiredBlocks: []expiredDataBlock{
+			{shard: 0, blockStart: epoch.Add(3996 * time.Hour)},
+			{shard: 0, blockStart: epoch.Add(3998 * time.Hour)},
+		},
+	}}, plan.transitions)
+
+	var (
+		transitioned []fs.TransitionDataFileSetOptions
+		deleted      []string
+	)
+	mgr.deleteFilesFn = func(files []string) error {
+		deleted = append(deleted, files...)
+		return nil
+	}
+
+	// Filesets that fail to be transitioned are retained.
+	mgr.transitionDataFileSetFn = func(
+		opts fs.TransitionDataFileSetOptions,
+	) (fs.TransitionDataFileSetResult, error) {
+		return fs.TransitionDataFileSetResult{}, errors.New("transition failed")
+	}
+	require.Error(t, mgr.executeCleanupPlan(cleanupPlan{
+		namespaces: []namespaceCleanupPlan{plan},
+	}))
+	require.Equal(t, []string{expired}, deleted)
+
+	deleted = nil
+	mgr.transitionDataFileSetFn = func(
+		opts fs.TransitionDataFileSetOptions,
+	) (fs.TransitionDataFileSetResult, error) {
+		transitioned = append(transitioned, opts)
+		return fs.TransitionDataFileSetResult{Series: 1}, nil
+	}
+	require.NoError(t, mgr.executeCleanupPlan(cleanupPlan{
+		namespaces: []namespaceCleanupPlan{plan},
+	}))
+	require.Equal(t, []string{first, second, expired}, deleted)
+
+	require.Equal(t, 1, len(transitioned))
+	opts := transitioned[0]
+	require.Equal(t, "ns", opts.Source.ID().String())
+	require.Equal(t, "ns_5m", opts.Target.ID().String())
+	require.Equal(t, []time.Time{
+		epoch.Add(3996 * time.Hour),
+		epoch.Add(3998 * time.Hour),
+	}, opts.SourceBlockStarts)
+	require.True(t, epoch.Add(3996*time.Hour).Equal(opts.TargetBlockStart))
+	require.Equal(t, 5*time.Minute, opts.Resolution)
+	require.Equal(t, namespace.ContinuousQueryMax, opts.Aggregation)
+}
+
+func TestCleanupManagerPlanRetentionTransitionTargetNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sourceOpts := namespace.NewOptions().
+		SetRetentionTransition(namespace.RetentionTransition{
+			TargetNamespace: "missing",
+			Resolution:      5 * time.Minute,
+		})
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(sourceOpts).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("ns")).AnyTimes()
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	shard.EXPECT().ExpiredFileSets(gomock.Any()).Return([]string{"data"}, nil)
+	shard.EXPECT().SnapshotFilesToCleanup(gomock.Any()).Return(nil, nil)
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+
+	db := newMockdatabase(ctrl, ns)
+	mgr := newCleanupManager(db, tally.NoopScope).(*cleanupManager)
+
+	// The expired data filesets are retained while the transition is invalid.
+	plan, err := mgr.planNamespaceCleanup(ns, timeFor(36000))
+	require.NoError(t, err)
+	require.Equal(t, errRetentionTransitionTargetNotFound, plan.transitionErr)
+	require.Empty(t, plan.dataFiles)
+	require.Empty(t, plan.transitions)
+
+	mgr.deleteFilesFn = func(files []string) error {
+		require.FailNow(t, "no files should be deleted")
+		return nil
+	}
+	require.Error(t, mgr.executeCleanupPlan(cleanupPlan{
+		namespaces: []namespaceCleanupPlan{plan},
+	}))
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage/repair"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
+	"github.com/m3db/m3x/context"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errConsistencyCheckInProgress = errors.New("consistency check already in progress")
+)
+
+type shardMetadataCompareFn func(
+	ctx context.Context,
+	namespace ident.ID,
+	tr xtime.Range,
+	shard databaseShard,
+) (repair.MetadataComparisonResult, error)
+
+// dbConsistencyChecker periodically compares the metadata of randomly
+// sampled shard blocks of each namespace with the metadata of the peers
+// and publishes the percentage of blocks which diverge across replicas,
+// which serves as a signal of when a full repair is due. The checks only
+// compare metadata and never repair any data.
+type dbConsistencyChecker struct {
+	database    database
+	compareFn   shardMetadataCompareFn
+	contextPool context.Pool
+	sleepFn     sleepFn
+	nowFn       clock.NowFn
+	rand        *rand.Rand
+	logger      xlog.Logger
+	scope       tally.Scope
+	interval    time.Duration
+	sampleSize  int
+
+	closedLock sync.Mutex
+	running    int32
+	closed     bool
+}
+
+func newDatabaseConsistencyChecker(
+	database database,
+	opts Options,
+) (databaseConsistencyChecker, error) {
+	ropts := opts.RepairOptions()
+	if ropts == nil {
+		return nil, errNoRepairOptions
+	}
+	if err := ropts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		nowFn = opts.ClockOptions().NowFn()
+		iopts = opts.InstrumentOptions()
+	)
+	return &dbConsistencyChecker{
+		database:    database,
+		compareFn:   newShardMetadataComparer(opts, ropts).compare,
+		contextPool: opts.ContextPool(),
+		sleepFn:     time.Sleep,
+		nowFn:       nowFn,
+		rand:        rand.New(rand.NewSource(nowFn().UnixNano())),
+		logger:      iopts.Logger(),
+		scope:       xmetrics.SubsystemScope(iopts.MetricsScope(), xmetrics.SubsystemConsistencyCheck),
+		interval:    ropts.ConsistencyCheckInterval(),
+		sampleSize:  ropts.ConsistencyCheckSampleSize(),
+	}, nil
+}
+
+func (c *dbConsistencyChecker) run() {
+	for {
+		c.sleepFn(c.interval)
+
+		c.closedLock.Lock()
+		closed := c.closed
+		c.closedLock.Unlock()
+
+		if closed {
+			return
+		}
+
+		if err := c.Check(); err != nil {
+			c.logger.Errorf("error checking replica consistency: %v", err)
+		}
+	}
+}
+
+func (c *dbConsistencyChecker) Start() {
+	if c.interval <= 0 {
+		return
+	}
+
+	go c.run()
+}
+
+func (c *dbConsistencyChecker) Stop() {
+	c.closedLock.Lock()
+	c.closed = true
+	c.closedLock.Unlock()
+}
+
+func (c *dbConsistencyChecker) Check() error {
+	// Don't attempt a check if the database is not bootstrapped yet
+	if !c.database.IsBootstrapped() {
+		return nil
+	}
+
+	if !atomic.CompareAndSwapInt32(&c.running, 0, 1) {
+		return errConsistencyCheckInProgress
+	}
+
+	defer func() {
+		atomic.StoreInt32(&c.running, 0)
+	}()
+
+	namespaces, err := c.database.GetOwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, n := range namespaces {
+		if !n.Options().RepairEnabled() {
+			continue
+		}
+		multiErr = multiErr.Add(c.checkNamespace(n))
+	}
+	return multiErr.FinalError()
+}
+
+func (c *dbConsistencyChecker) checkNamespace(n databaseNamespace) error {
+	var (
+		now       = c.nowFn()
+		rtopts    = n.Options().RetentionOptions()
+		blockSize = rtopts.BlockSize()
+		start     = now.Add(-rtopts.RetentionPeriod()).Truncate(blockSize)
+		end       = now.Add(-rtopts.BufferPast()).Truncate(blockSize)
+		numStarts = int(end.Sub(start) / blockSize)
+		shards    = n.GetOwnedShards()
+	)
+	if len(shards) == 0 || numStarts <= 0 {
+		return nil
+	}
+
+	var (
+		numBlocks          int64
+		numDivergentBlocks int64
+		numErrors          int64
+		multiErr           = xerrors.NewMultiError()
+	)
+	for i := 0; i < c.sampleSize; i++ {
+		var (
+			shard      = shards[c.rand.Intn(len(shards))]
+			blockStart = start.Add(time.Duration(c.rand.Intn(numStarts)) * blockSize)
+			tr         = xtime.Range{Start: blockStart, End: blockStart.Add(blockSize)}
+			ctx        = c.contextPool.Get()
+		)
+
+		// The comparison result is only valid until the context is closed.
+		res, err := c.compareFn(ctx, n.ID(), tr, shard)
+		if err == nil {
+			numBlocks += res.NumBlocks
+			numDivergentBlocks += res.ChecksumDifferences.NumBlocks()
+		}
+		ctx.Close()
+
+		if err != nil {
+			numErrors++
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	scope := xmetrics.NamespaceScope(c.scope, n.ID().String())
+	scope.Counter("sampled-blocks").Inc(numBlocks)
+	scope.Counter("divergent-blocks").Inc(numDivergentBlocks)
+	scope.Counter("errors").Inc(numErrors)
+	if numBlocks > 0 {
+		divergence := 100 * float64(numDivergentBlocks) / float64(numBlocks)
+		scope.Gauge("divergence-percent").Update(divergence)
+	}
+
+	return multiErr.FinalError()
+}
+
+var noOpConsistencyChecker databaseConsistencyChecker = consistencyCheckerNoOp{}
+
+type consistencyCheckerNoOp struct{}
+
+func newNoopDatabaseConsistencyChecker() databaseConsistencyChecker {
+	return noOpConsistencyChecker
+}
+
+func (c consistencyCheckerNoOp) Start()       {}
+func (c consistencyCheckerNoOp) Stop()        {}
+func (c consistencyCheckerNoOp) Check() error { return nil }
@@ -0,0 +1,175 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3x/context"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestConsistencyChecker(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	now time.Time,
+	scope tally.Scope,
+	db database,
+) *dbConsistencyChecker {
+	opts := testDatabaseOptions()
+	opts = opts.
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time { return now })).
+		SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(scope)).
+		SetRepairOptions(testRepairOptions(ctrl).
+			SetConsistencyCheckInterval(time.Minute).
+			SetConsistencyCheckSampleSize(2))
+
+	checker, err := newDatabaseConsistencyChecker(db, opts)
+	require.NoError(t, err)
+	return checker.(*dbConsistencyChecker)
+}
+
+func TestDatabaseConsistencyCheckerCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		now    = time.Now()
+		scope  = tally.NewTestScope("", nil)
+		nsOpts = namespace.NewOptions().SetRepairEnabled(true)
+		rtopts = nsOpts.RetentionOptions()
+	)
+
+	shard := NewMockdatabaseShard(ctrl)
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(ident.StringID("testns")).AnyTimes()
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard})
+
+	// Namespaces without repairs enabled are not checked.
+	skipped := NewMockdatabaseNamespace(ctrl)
+	skipped.EXPECT().Options().Return(nsOpts.SetRepairEnabled(false))
+
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().IsBootstrapped().Return(true)
+	db.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns, skipped}, nil)
+
+	c := newTestConsistencyChecker(t, ctrl, now, scope, db)
+
+	var ranges []xtime.Range
+	c.compareFn = func(
+		ctx context.Context,
+		namespace ident.ID,
+		tr xtime.Range,
+		s databaseShard,
+	) (repair.MetadataComparisonResult, error) {
+		require.Equal(t, "testns", namespace.String())
+		require.True(t, shard == s)
+		ranges = append(ranges, tr)
+
+		diff := repair.NewReplicaSeriesMetadata()
+		diff.GetOrAdd(ident.StringID("foo")).Add(repair.NewReplicaBlockMetadata(tr.Start, nil))
+		return repair.MetadataComparisonResult{
+			NumBlocks:           4,
+			ChecksumDifferences: diff,
+		}, nil
+	}
+
+	require.NoError(t, c.Check())
+
+	var (
+		blockSize = rtopts.BlockSize()
+		start     = now.Add(-rtopts.RetentionPeriod()).Truncate(blockSize)
+		end       = now.Add(-rtopts.BufferPast()).Truncate(blockSize)
+	)
+	require.Equal(t, 2, len(ranges))
+	for _, tr := range ranges {
+		require.Equal(t, blockSize, tr.End.Sub(tr.Start))
+		require.False(t, tr.Start.Before(start))
+		require.False(t, tr.End.After(end))
+	}
+
+	snapshot := scope.Snapshot()
+	tags := "+namespace=testns"
+	require.Equal(t, 25.0,
+		snapshot.Gauges()["consistency-check.divergence-percent"+tags].Value())
+	require.Equal(t, int64(8),
+		snapshot.Counters()["consistency-check.sampled-blocks"+tags].Value())
+	require.Equal(t, int64(2),
+		snapshot.Counters()["consistency-check.divergent-blocks"+tags].Value())
+}
+
+func TestDatabaseConsistencyCheckerCheckErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		scope  = tally.NewTestScope("", nil)
+		nsOpts = namespace.NewOptions().SetRepairEnabled(true)
+	)
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(ident.StringID("testns")).AnyTimes()
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{NewMockdatabaseShard(ctrl)})
+
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().IsBootstrapped().Return(true)
+	db.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil)
+
+	c := newTestConsistencyChecker(t, ctrl, time.Now(), scope, db)
+	c.compareFn = func(
+		ctx context.Context,
+		namespace ident.ID,
+		tr xtime.Range,
+		s databaseShard,
+	) (repair.MetadataComparisonResult, error) {
+		return repair.MetadataComparisonResult{}, errors.New("peers unavailable")
+	}
+
+	require.Error(t, c.Check())
+
+	snapshot := scope.Snapshot()
+	require.Equal(t, int64(2),
+		snapshot.Counters()["consistency-check.errors+namespace=testns"].Value())
+	_, ok := snapshot.Gauges()["consistency-check.divergence-percent+namespace=testns"]
+	require.False(t, ok)
+}
+
+func TestDatabaseConsistencyCheckerCheckNotBootstrapped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().IsBootstrapped().Return(false)
+
+	c := newTestConsistencyChecker(t, ctrl, time.Now(), tally.NoopScope, db)
+	require.NoError(t, c.Check())
+}
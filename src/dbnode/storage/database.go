@@ -30,12 +30,17 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/dbnode/x/xcounter"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	xclose "github.com/m3db/m3x/close"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
@@ -57,6 +62,9 @@ var (
 
 	// errDatabaseIsClosed raised when trying to perform an action that requires an open database
 	errDatabaseIsClosed = errors.New("database is closed")
+
+	// errDatabaseNotBootstrapped raised when trying to snapshot a database that is not bootstrapped
+	errDatabaseNotBootstrapped = errors.New("database is not bootstrapped")
 )
 
 type databaseState int
@@ -85,8 +93,11 @@ type db struct {
 	state    databaseState
 	mediator databaseMediator
 
+	maintenance *maintenanceManager
+
 	created    uint64
 	bootstraps int
+	draining   bool
 
 	scope   tally.Scope
 	metrics databaseMetrics
@@ -95,6 +106,11 @@ type db struct {
 	errors       xcounter.FrequencyCounter
 	errWindow    time.Duration
 	errThreshold int64
+
+	runtimeOptsListener xclose.SimpleCloser
+	readOnlyLock        sync.RWMutex
+	readOnly            bool
+	readOnlyNamespaces  map[string]struct{}
 }
 
 type databaseMetrics struct {
@@ -106,6 +122,8 @@ type databaseMetrics struct {
 	unknownNamespaceQueryIDs            tally.Counter
 	errQueryIDsIndexDisabled            tally.Counter
 	errWriteTaggedIndexDisabled         tally.Counter
+	readOnlyWrites                      tally.Counter
+	drains                              tally.Counter
 }
 
 func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
@@ -120,6 +138,8 @@ func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
 		unknownNamespaceQueryIDs:            unknownNamespaceScope.Counter("query-ids"),
 		errQueryIDsIndexDisabled:            indexDisabledScope.Counter("err-query-ids"),
 		errWriteTaggedIndexDisabled:         indexDisabledScope.Counter("err-write-tagged"),
+		readOnlyWrites:                      scope.Counter("read-only-writes"),
+		drains:                              scope.Counter("drains"),
 	}
 }
 
@@ -141,7 +161,7 @@ func NewDatabase(
 	}
 
 	iopts := opts.InstrumentOptions()
-	scope := iopts.MetricsScope().SubScope("database")
+	scope := xmetrics.SubsystemScope(iopts.MetricsScope(), xmetrics.SubsystemDatabase)
 	logger := iopts.Logger()
 
 	d := &db{
@@ -191,6 +211,9 @@ func NewDatabase(
 		return nil, err
 	}
 	d.mediator = mediator
+	d.maintenance = newMaintenanceManager(d, scope.SubScope("maintenance"))
+
+	d.runtimeOptsListener = opts.RuntimeOptionsManager().RegisterListener(d)
 
 	return d, nil
 }
@@ -218,6 +241,8 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 		d.log.Warnf("skipping namespace removals and updates, restart process if you want changes to take effect.")
 	}
 
+	d.emitNamespaceChanges(removes, adds, updates)
+
 	// enqueue bootstraps if new namespaces
 	if len(adds) > 0 {
 		d.queueBootstrapWithLock()
@@ -226,6 +251,32 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 	return nil
 }
 
+// emitNamespaceChanges emits a change event for each namespace added,
+// updated or removed, updates and removals are emitted as they are observed
+// even though they only take effect once the process restarts.
+func (d *db) emitNamespaceChanges(removes []ident.ID, adds, updates []namespace.Metadata) {
+	changes := d.opts.ChangeEmitter()
+	if !changes.Enabled(cdc.NamespaceChanged) {
+		return
+	}
+	emit := func(id ident.ID, change cdc.NamespaceChange) {
+		changes.Emit(cdc.Event{
+			Type:            cdc.NamespaceChanged,
+			Namespace:       id.String(),
+			NamespaceChange: change,
+		})
+	}
+	for _, md := range adds {
+		emit(md.ID(), cdc.NamespaceAdded)
+	}
+	for _, md := range updates {
+		emit(md.ID(), cdc.NamespaceUpdated)
+	}
+	for _, id := range removes {
+		emit(id, cdc.NamespaceRemoved)
+	}
+}
+
 func (d *db) namespaceDeltaWithLock(newNamespaces namespace.Map) ([]ident.ID, []namespace.Metadata, []namespace.Metadata) {
 	var (
 		existing = d.namespaces
@@ -428,6 +479,9 @@ func (d *db) terminateWithLock() error {
 		return err
 	}
 
+	// stop listening for runtime options changes
+	d.runtimeOptsListener.Close()
+
 	// Stop the wired list
 	if wiredList := d.opts.DatabaseBlockOptions().WiredList(); wiredList != nil {
 		err := wiredList.Stop()
@@ -472,6 +526,39 @@ func (d *db) Close() error {
 	return multiErr.FinalError()
 }
 
+func (d *db) SetRuntimeOptions(value runtime.Options) {
+	readOnlyNamespaces := make(map[string]struct{}, len(value.ReadOnlyNamespaces()))
+	for _, namespace := range value.ReadOnlyNamespaces() {
+		readOnlyNamespaces[namespace] = struct{}{}
+	}
+
+	d.readOnlyLock.Lock()
+	d.readOnly = value.ReadOnly()
+	d.readOnlyNamespaces = readOnlyNamespaces
+	d.readOnlyLock.Unlock()
+}
+
+// checkWritable returns an error if the database or namespace is read only.
+func (d *db) checkWritable(namespace ident.ID) error {
+	d.readOnlyLock.RLock()
+	readOnly := d.readOnly
+	namespaceReadOnly := false
+	if len(d.readOnlyNamespaces) > 0 {
+		_, namespaceReadOnly = d.readOnlyNamespaces[namespace.String()]
+	}
+	d.readOnlyLock.RUnlock()
+
+	if readOnly {
+		d.metrics.readOnlyWrites.Inc(1)
+		return m3dberrors.ErrReadOnly
+	}
+	if namespaceReadOnly {
+		d.metrics.readOnlyWrites.Inc(1)
+		return m3dberrors.ErrNamespaceReadOnly
+	}
+	return nil
+}
+
 func (d *db) Write(
 	ctx context.Context,
 	namespace ident.ID,
@@ -481,6 +568,10 @@ func (d *db) Write(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if err := d.checkWritable(namespace); err != nil {
+		return err
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWrite.Inc(1)
@@ -504,6 +595,10 @@ func (d *db) WriteTagged(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if err := d.checkWritable(namespace); err != nil {
+		return err
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWriteTagged.Inc(1)
@@ -517,6 +612,58 @@ func (d *db) WriteTagged(
 	return err
 }
 
+func (d *db) WriteBatch(
+	ctx context.Context,
+	namespace ident.ID,
+	writes []WriteBatchEntry,
+) error {
+	if err := d.checkWritable(namespace); err != nil {
+		return err
+	}
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceWrite.Inc(1)
+		return err
+	}
+
+	err = n.WriteBatch(ctx, writes)
+	d.recordWriteBatchErrors(writes)
+	return err
+}
+
+func (d *db) WriteTaggedBatch(
+	ctx context.Context,
+	namespace ident.ID,
+	writes []WriteBatchEntry,
+) error {
+	if err := d.checkWritable(namespace); err != nil {
+		return err
+	}
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceWriteTagged.Inc(1)
+		return err
+	}
+
+	err = n.WriteTaggedBatch(ctx, writes)
+	d.recordWriteBatchErrors(writes)
+	return err
+}
+
+func (d *db) recordWriteBatchErrors(writes []WriteBatchEntry) {
+	var commitLogQueueFull int64
+	for i := range writes {
+		if writes[i].Err == commitlog.ErrCommitLogQueueFull {
+			commitLogQueueFull++
+		}
+	}
+	if commitLogQueueFull > 0 {
+		d.errors.Record(commitLogQueueFull)
+	}
+}
+
 func (d *db) QueryIDs(
 	ctx context.Context,
 	namespace ident.ID,
@@ -634,10 +781,69 @@ func (d *db) Truncate(namespace ident.ID) (int64, error) {
 	return n.Truncate()
 }
 
+func (d *db) SubscribeNewSeries(
+	namespace ident.ID,
+	query index.Query,
+	opts NewSeriesSubscriptionOptions,
+) (NewSeriesSubscription, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return n.SubscribeNewSeries(query, opts)
+}
+
 func (d *db) IsOverloaded() bool {
 	return d.errors.Count(d.errWindow) > d.errThreshold
 }
 
+func (d *db) Drain(snapshot bool) error {
+	d.Lock()
+	if !d.draining {
+		d.draining = true
+		d.log.Infof("database draining")
+		d.metrics.drains.Inc(1)
+	}
+	mediator := d.mediator
+	d.Unlock()
+
+	if !snapshot || !mediator.IsBootstrapped() {
+		return nil
+	}
+
+	// Wait for any in progress file operations and prevent further ones from
+	// starting so the forced tick below performs the final flush and snapshot
+	mediator.DisableFileOps()
+	if err := mediator.Tick(syncRun, force); err != nil {
+		return err
+	}
+
+	d.log.Infof("database drained with flush and snapshot")
+	return nil
+}
+
+func (d *db) IsDraining() bool {
+	d.RLock()
+	defer d.RUnlock()
+	return d.draining
+}
+
+func (d *db) Snapshot() error {
+	d.RLock()
+	mediator := d.mediator
+	d.RUnlock()
+
+	if !mediator.IsBootstrapped() {
+		return errDatabaseNotBootstrapped
+	}
+
+	// Wait for any in progress file operations and prevent further ones from
+	// starting so the forced tick below performs the snapshot
+	mediator.DisableFileOps()
+	defer mediator.EnableFileOps()
+	return mediator.Tick(syncRun, force)
+}
+
 func (d *db) BootstrapState() DatabaseBootstrapState {
 	nsBootstrapStates := NamespaceBootstrapStates{}
 
@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
@@ -517,6 +518,30 @@ func TestDatabaseBootstrappedAssignShardSet(t *testing.T) {
 	wg.Wait()
 }
 
+func TestDatabaseSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	mediator.EXPECT().IsBootstrapped().Return(false)
+	require.Equal(t, errDatabaseNotBootstrapped, d.Snapshot())
+
+	gomock.InOrder(
+		mediator.EXPECT().IsBootstrapped().Return(true),
+		mediator.EXPECT().DisableFileOps(),
+		mediator.EXPECT().Tick(syncRun, force).Return(nil),
+		mediator.EXPECT().EnableFileOps(),
+	)
+	require.NoError(t, d.Snapshot())
+}
+
 func TestDatabaseRemoveNamespace(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -716,6 +741,51 @@ func TestDatabaseNamespaceIndexFunctions(t *testing.T) {
 	require.NoError(t, d.Close())
 }
 
+func TestDatabaseReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	ns1 := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns2 := dbAddNewMockNamespace(ctrl, d, "testns2")
+
+	ctx := context.NewContext()
+	write := func(ns string) error {
+		return d.Write(ctx, ident.StringID(ns), ident.StringID("foo"),
+			time.Time{}, 1.0, xtime.Second, nil)
+	}
+	writeTagged := func(ns string) error {
+		return d.WriteTagged(ctx, ident.StringID(ns), ident.StringID("foo"),
+			ident.EmptyTagIterator, time.Time{}, 1.0, xtime.Second, nil)
+	}
+
+	// Only the read only namespace rejects writes.
+	d.SetRuntimeOptions(runtime.NewOptions().
+		SetReadOnlyNamespaces([]string{"testns1"}))
+	require.Equal(t, m3dberrors.ErrNamespaceReadOnly, write("testns1"))
+	require.Equal(t, m3dberrors.ErrNamespaceReadOnly, writeTagged("testns1"))
+	require.True(t, xerrors.IsInvalidParams(write("testns1")))
+
+	ns2.EXPECT().Write(ctx, ident.NewIDMatcher("foo"),
+		time.Time{}, 1.0, xtime.Second, nil).Return(nil)
+	require.NoError(t, write("testns2"))
+
+	// All namespaces reject writes when the database is read only.
+	d.SetRuntimeOptions(runtime.NewOptions().SetReadOnly(true))
+	require.Equal(t, m3dberrors.ErrReadOnly, write("testns1"))
+	require.Equal(t, m3dberrors.ErrReadOnly, writeTagged("testns2"))
+
+	// Writes are accepted again once read only mode is disabled.
+	d.SetRuntimeOptions(runtime.NewOptions())
+	ns1.EXPECT().WriteTagged(ctx, ident.NewIDMatcher("foo"), gomock.Any(),
+		time.Time{}, 1.0, xtime.Second, nil).Return(nil)
+	require.NoError(t, writeTagged("testns1"))
+}
+
 func TestDatabaseBootstrapState(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errors
+
+import (
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+// Code classifies an error so that callers can programmatically distinguish
+// errors worth retrying from errors that will not succeed on retry.
+type Code int
+
+const (
+	// CodeInternal is an unclassified internal error.
+	CodeInternal Code = iota
+	// CodeBadRequest is an error caused by an invalid request.
+	CodeBadRequest
+	// CodeResourceExhausted is an error caused by a saturated resource
+	// applying backpressure.
+	CodeResourceExhausted
+	// CodeUnavailable is an error caused by the data being temporarily
+	// unavailable, such as a shard that is not owned or bootstrapped.
+	CodeUnavailable
+	// CodeDataCorruption is an error caused by data failing validation.
+	CodeDataCorruption
+	// CodeConsistencyNotAchieved is an error caused by too few hosts
+	// responding with success to meet the requested consistency level.
+	CodeConsistencyNotAchieved
+)
+
+// ValidCodes returns all valid codes.
+func ValidCodes() []Code {
+	return []Code{
+		CodeInternal,
+		CodeBadRequest,
+		CodeResourceExhausted,
+		CodeUnavailable,
+		CodeDataCorruption,
+		CodeConsistencyNotAchieved,
+	}
+}
+
+func (c Code) String() string {
+	switch c {
+	case CodeInternal:
+		return "internal"
+	case CodeBadRequest:
+		return "bad-request"
+	case CodeResourceExhausted:
+		return "resource-exhausted"
+	case CodeUnavailable:
+		return "unavailable"
+	case CodeDataCorruption:
+		return "data-corruption"
+	case CodeConsistencyNotAchieved:
+		return "consistency-not-achieved"
+	}
+	return "unknown"
+}
+
+// Retryable returns whether an error with the code may succeed on retry.
+func (c Code) Retryable() bool {
+	switch c {
+	case CodeBadRequest, CodeDataCorruption:
+		return false
+	}
+	return true
+}
+
+type codedError struct {
+	err  error
+	code Code
+}
+
+// NewCodedError creates a new error classified with the given code.
+func NewCodedError(code Code, err error) error {
+	return codedError{err: err, code: code}
+}
+
+// NewUnavailableError creates a new error that signals the data is
+// temporarily unavailable.
+func NewUnavailableError(err error) error {
+	return NewCodedError(CodeUnavailable, err)
+}
+
+// NewDataCorruptionError creates a new error that signals data failed
+// validation and will not succeed on retry.
+func NewDataCorruptionError(err error) error {
+	return NewCodedError(CodeDataCorruption, err)
+}
+
+func (e codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e codedError) InnerError() error {
+	return e.err
+}
+
+// GetCode returns the code of the error, the code of the first classified
+// error found in the error or its inner errors, or CodeInternal if none is.
+func GetCode(err error) Code {
+	for err != nil {
+		if e, ok := err.(codedError); ok {
+			return e.code
+		}
+		if _, ok := err.(resourceExhaustedError); ok {
+			return CodeResourceExhausted
+		}
+		if xerrors.IsInvalidParams(err) {
+			return CodeBadRequest
+		}
+		err = xerrors.InnerError(err)
+	}
+	return CodeInternal
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errors
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	xerrors "github.com/m3db/m3x/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCode(t *testing.T) {
+	err := errors.New("an error")
+	tests := []struct {
+		err  error
+		code Code
+	}{
+		{err: err, code: CodeInternal},
+		{err: xerrors.NewInvalidParamsError(err), code: CodeBadRequest},
+		{err: NewResourceExhaustedError(err, time.Second), code: CodeResourceExhausted},
+		{err: NewUnavailableError(err), code: CodeUnavailable},
+		{err: NewDataCorruptionError(err), code: CodeDataCorruption},
+		{err: NewCodedError(CodeConsistencyNotAchieved, err), code: CodeConsistencyNotAchieved},
+		{err: xerrors.NewRetryableError(NewUnavailableError(err)), code: CodeUnavailable},
+		{err: ErrTooPast, code: CodeBadRequest},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.code, GetCode(test.err), test.err.Error())
+	}
+}
+
+func TestCodeRetryable(t *testing.T) {
+	for _, code := range ValidCodes() {
+		expected := code != CodeBadRequest && code != CodeDataCorruption
+		assert.Equal(t, expected, code.Retryable(), code.String())
+	}
+}
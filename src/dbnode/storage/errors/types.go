@@ -22,6 +22,7 @@ package errors
 
 import (
 	"errors"
+	"time"
 
 	xerrors "github.com/m3db/m3x/errors"
 )
@@ -32,4 +33,48 @@ var (
 
 	// ErrTooPast is returned for a write which is too far in the past.
 	ErrTooPast = xerrors.NewInvalidParamsError(errors.New("datapoint is too far in the past"))
+
+	// ErrReadOnly is returned for a write when the database is read only.
+	ErrReadOnly = xerrors.NewInvalidParamsError(errors.New("database is read only, writes are rejected"))
+
+	// ErrNamespaceReadOnly is returned for a write to a read only namespace.
+	ErrNamespaceReadOnly = xerrors.NewInvalidParamsError(errors.New("namespace is read only, writes are rejected"))
 )
+
+type resourceExhaustedError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+// NewResourceExhaustedError creates a new error that signals a resource such
+// as an insert queue is saturated and the operation should be retried after
+// the specified duration.
+func NewResourceExhaustedError(err error, retryAfter time.Duration) error {
+	return resourceExhaustedError{err: err, retryAfter: retryAfter}
+}
+
+func (e resourceExhaustedError) Error() string {
+	return e.err.Error()
+}
+
+func (e resourceExhaustedError) InnerError() error {
+	return e.err
+}
+
+// IsResourceExhaustedError returns true if this is a resource exhausted error.
+func IsResourceExhaustedError(err error) bool {
+	_, ok := RetryAfter(err)
+	return ok
+}
+
+// RetryAfter returns the retry after hint of a resource exhausted error and
+// whether the error or any of its inner errors is a resource exhausted error.
+func RetryAfter(err error) (time.Duration, bool) {
+	for err != nil {
+		if e, ok := err.(resourceExhaustedError); ok {
+			return e.retryAfter, true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return 0, false
+}
@@ -28,13 +28,22 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/x/loglevel"
 	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+	xtime "github.com/m3db/m3x/time"
 
 	"github.com/uber-go/tally"
 )
 
 var (
-	errFlushOperationsInProgress = errors.New("flush operations already in progress")
+	errFlushOperationsInProgress            = errors.New("flush operations already in progress")
+	errContinuousQueryTargetNotFound        = errors.New("continuous query target namespace not found")
+	errContinuousQueryTargetIsSource        = errors.New("continuous query target namespace must not be the source namespace")
+	errContinuousQueryTargetShardingInvalid = errors.New("continuous query target namespace must be sharded the same as the source namespace")
+	errContinuousQueryTargetBufferPastShort = errors.New("continuous query target namespace buffer past must be at least the source block size and buffer past")
 )
 
 type flushManagerState int
@@ -55,6 +64,7 @@ type flushManager struct {
 	database database
 	opts     Options
 	pm       persist.Manager
+	log      xlog.Logger
 	// isFlushingOrSnapshotting is used to protect the flush manager against
 	// concurrent use, while flushInProgress and snapshotInProgress are more
 	// granular and are used for emitting granular gauges.
@@ -70,6 +80,7 @@ func newFlushManager(database database, scope tally.Scope) databaseFlushManager
 		database:        database,
 		opts:            opts,
 		pm:              opts.PersistManager(),
+		log:             loglevel.Subsystem(opts.InstrumentOptions().Logger(), "flush"),
 		isFlushing:      scope.Gauge("flush"),
 		isSnapshotting:  scope.Gauge("snapshot"),
 		isIndexFlushing: scope.Gauge("index-flush"),
@@ -114,6 +125,8 @@ func (m *flushManager) Flush(
 				"tried to flush ns: %s, but did not have shard bootstrap times", ns.ID().String()))
 			continue
 		}
+		m.log.Debugf("flushing namespace: id=%s, blocks=%d",
+			ns.ID().String(), len(flushTimes))
 		multiErr = multiErr.Add(m.flushNamespaceWithTimes(ns, shardBootstrapTimes, flushTimes, flush))
 	}
 
@@ -132,6 +145,8 @@ func (m *flushManager) Flush(
 		// Only perform snapshots if the previous block (I.E the block directly before
 		// the block that we would snapshot) has been flushed.
 		if !ns.NeedsFlush(prevBlockStart, prevBlockStart) {
+			m.log.Debugf("snapshotting namespace: id=%s, blockStart=%s",
+				ns.ID().String(), snapshotBlockStart.String())
 			if err := ns.Snapshot(snapshotBlockStart, tickStart, flush); err != nil {
 				detailedErr := fmt.Errorf("namespace %s failed to snapshot data: %v",
 					ns.ID().String(), err)
@@ -160,6 +175,7 @@ func (m *flushManager) Flush(
 		if !indexEnabled {
 			continue
 		}
+		m.log.Debugf("flushing namespace index: id=%s", ns.ID().String())
 		multiErr = multiErr.Add(ns.FlushIndex(indexFlush))
 	}
 	// mark index flush finished
@@ -168,6 +184,54 @@ func (m *flushManager) Flush(
 	return multiErr.FinalError()
 }
 
+// flushNamespace flushes the flushable blocks of the shards of a single
+// namespace in the shard bootstrap states followed by its index, it does not
+// snapshot the namespace as snapshots are only taken for all namespaces.
+func (m *flushManager) flushNamespace(
+	ns databaseNamespace,
+	shardBootstrapStates ShardBootstrapStates,
+	tickStart time.Time,
+) error {
+	m.Lock()
+	if m.state != flushManagerIdle {
+		m.Unlock()
+		return errFlushOperationsInProgress
+	}
+	m.state = flushManagerNotIdle
+	m.Unlock()
+
+	defer m.setState(flushManagerIdle)
+
+	flush, err := m.pm.StartDataPersist()
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	m.setState(flushManagerFlushInProgress)
+	flushTimes := m.namespaceFlushTimes(ns, tickStart)
+	m.log.Debugf("force flushing namespace: id=%s, blocks=%d, shards=%d",
+		ns.ID().String(), len(flushTimes), len(shardBootstrapStates))
+	multiErr = multiErr.Add(m.flushNamespaceWithTimes(ns, shardBootstrapStates, flushTimes, flush))
+	multiErr = multiErr.Add(flush.DoneData())
+
+	if !ns.Options().IndexOptions().Enabled() {
+		return multiErr.FinalError()
+	}
+
+	indexFlush, err := m.pm.StartIndexPersist()
+	if err != nil {
+		multiErr = multiErr.Add(err)
+		return multiErr.FinalError()
+	}
+
+	m.setState(flushManagerIndexFlushInProgress)
+	multiErr = multiErr.Add(ns.FlushIndex(indexFlush))
+	multiErr = multiErr.Add(indexFlush.DoneIndex())
+
+	return multiErr.FinalError()
+}
+
 func (m *flushManager) Report() {
 	m.RLock()
 	state := m.state
@@ -249,7 +313,84 @@ func (m *flushManager) flushNamespaceWithTimes(
 			detailedErr := fmt.Errorf("namespace %s failed to flush data: %v",
 				ns.ID().String(), err)
 			multiErr = multiErr.Add(detailedErr)
+			continue
+		}
+		if err := m.runContinuousQueries(ns, t); err != nil {
+			detailedErr := fmt.Errorf("namespace %s failed to run continuous queries: %v",
+				ns.ID().String(), err)
+			multiErr = multiErr.Add(detailedErr)
 		}
 	}
 	return multiErr.FinalError()
 }
+
+// runContinuousQueries writes the aggregates of a flushed block to the
+// target namespaces of the continuous queries of the namespace. The
+// aggregated series keep their IDs and tags and are written at the end of
+// each window, so the target namespace must accept writes as old as the
+// source block once it is flushed. Aggregates are written as regular
+// writes which makes retrying a block after a partial failure idempotent.
+func (m *flushManager) runContinuousQueries(
+	ns databaseNamespace,
+	blockStart time.Time,
+) error {
+	queries := ns.Options().ContinuousQueries()
+	if len(queries) == 0 {
+		return nil
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, query := range queries {
+		if err := m.runContinuousQuery(ns, blockStart, query); err != nil {
+			detailedErr := fmt.Errorf("continuous query to namespace %s failed: %v",
+				query.TargetNamespace, err)
+			multiErr = multiErr.Add(detailedErr)
+		}
+	}
+	return multiErr.FinalError()
+}
+
+func (m *flushManager) runContinuousQuery(
+	ns databaseNamespace,
+	blockStart time.Time,
+	query namespace.ContinuousQuery,
+) error {
+	targetID := ident.StringID(query.TargetNamespace)
+	target, ok := m.database.Namespace(targetID)
+	if !ok {
+		return errContinuousQueryTargetNotFound
+	}
+	if target.ID().Equal(ns.ID()) {
+		return errContinuousQueryTargetIsSource
+	}
+
+	var (
+		sourceOpts  = ns.Options()
+		targetOpts  = target.Options()
+		sourceRopts = sourceOpts.RetentionOptions()
+	)
+	// Aggregated series are written to the shard of the target namespace
+	// with the same ID which must be a shard this node owns.
+	if !sourceOpts.ShardingOptions().Equal(targetOpts.ShardingOptions()) {
+		return errContinuousQueryTargetShardingInvalid
+	}
+	if targetOpts.RetentionOptions().BufferPast() <
+		sourceRopts.BlockSize()+sourceRopts.BufferPast() {
+		return errContinuousQueryTargetBufferPastShort
+	}
+
+	contextPool := m.opts.ContextPool()
+	return ns.AggregateBlock(blockStart, query, func(
+		id ident.ID,
+		tags ident.Tags,
+		timestamp time.Time,
+		value float64,
+		unit xtime.Unit,
+	) error {
+		ctx := contextPool.Get()
+		err := m.database.WriteTagged(ctx, targetID, id,
+			ident.NewTagsIterator(tags), timestamp, value, unit, nil)
+		ctx.BlockingClose()
+		return err
+	})
+}
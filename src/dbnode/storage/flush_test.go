@@ -29,9 +29,11 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3x/ident"
 	xtest "github.com/m3db/m3x/test"
+	xtime "github.com/m3db/m3x/time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -457,6 +459,107 @@ func TestFlushManagerSnapshotBlockStart(t *testing.T) {
 	}
 }
 
+func TestFlushManagerRunContinuousQueries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		blockStart = time.Unix(7200, 0)
+		targetID   = ident.StringID("agg")
+		query      = namespace.ContinuousQuery{
+			TargetNamespace: "agg",
+			Resolution:      time.Minute,
+			Aggregation:     namespace.ContinuousQueryMax,
+		}
+		sourceOpts = namespace.NewOptions().
+				SetContinuousQueries([]namespace.ContinuousQuery{query})
+		ropts      = sourceOpts.RetentionOptions()
+		targetOpts = namespace.NewOptions().SetRetentionOptions(
+			ropts.SetBufferPast(ropts.BlockSize() + ropts.BufferPast()))
+	)
+
+	source := NewMockdatabaseNamespace(ctrl)
+	source.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	source.EXPECT().Options().Return(sourceOpts).AnyTimes()
+	target := NewMockdatabaseNamespace(ctrl)
+	target.EXPECT().ID().Return(targetID).AnyTimes()
+	target.EXPECT().Options().Return(targetOpts).AnyTimes()
+
+	db := newMockdatabase(ctrl)
+	db.EXPECT().Namespace(targetID).Return(target, true)
+	fm := newFlushManager(db, tally.NoopScope).(*flushManager)
+
+	var (
+		id        = ident.StringID("foo")
+		tags      = ident.NewTags(ident.StringTag("bar", "baz"))
+		timestamp = blockStart.Add(time.Minute)
+	)
+	source.EXPECT().
+		AggregateBlock(blockStart, query, gomock.Any()).
+		DoAndReturn(func(_ time.Time, _ namespace.ContinuousQuery, fn continuousQueryFn) error {
+			return fn(id, tags, timestamp, 42, xtime.Second)
+		})
+	db.EXPECT().
+		WriteTagged(gomock.Any(), targetID, id, gomock.Any(), timestamp, 42.0, xtime.Second, nil).
+		Return(nil)
+
+	require.NoError(t, fm.runContinuousQueries(source, blockStart))
+}
+
+func TestFlushManagerRunContinuousQueryInvalidTarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		blockStart = time.Unix(7200, 0)
+		targetID   = ident.StringID("agg")
+		query      = namespace.ContinuousQuery{
+			TargetNamespace: "agg",
+			Resolution:      time.Minute,
+		}
+		opts  = namespace.NewOptions()
+		ropts = opts.RetentionOptions()
+	)
+
+	source := NewMockdatabaseNamespace(ctrl)
+	source.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	source.EXPECT().Options().Return(opts).AnyTimes()
+
+	db := newMockdatabase(ctrl)
+	fm := newFlushManager(db, tally.NoopScope).(*flushManager)
+
+	db.EXPECT().Namespace(targetID).Return(nil, false)
+	require.Equal(t, errContinuousQueryTargetNotFound,
+		fm.runContinuousQuery(source, blockStart, query))
+
+	db.EXPECT().Namespace(targetID).Return(source, true)
+	require.Equal(t, errContinuousQueryTargetIsSource,
+		fm.runContinuousQuery(source, blockStart, query))
+
+	for _, test := range []struct {
+		opts     namespace.Options
+		expected error
+	}{
+		{
+			opts: opts.SetShardingOptions(namespace.NewShardingOptions().
+				SetHashStrategy(sharding.JumpHashStrategy)),
+			expected: errContinuousQueryTargetShardingInvalid,
+		},
+		{
+			opts:     opts,
+			expected: errContinuousQueryTargetBufferPastShort,
+		},
+	} {
+		target := NewMockdatabaseNamespace(ctrl)
+		target.EXPECT().ID().Return(targetID).AnyTimes()
+		target.EXPECT().Options().Return(test.opts.SetRetentionOptions(
+			ropts.SetBufferPast(ropts.BlockSize()))).AnyTimes()
+		db.EXPECT().Namespace(targetID).Return(target, true)
+		require.Equal(t, test.expected,
+			fm.runContinuousQuery(source, blockStart, query))
+	}
+}
+
 type timesInOrder []time.Time
 
 func (a timesInOrder) Len() int           { return len(a) }
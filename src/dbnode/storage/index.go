@@ -38,7 +38,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/generated/proto/querypb"
 	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
@@ -60,6 +62,7 @@ var (
 	errDbIndexUnableToQueryClosed         = errors.New("unable to query database index, already closed")
 	errDbIndexUnableToFlushClosed         = errors.New("unable to flush database index, already closed")
 	errDbIndexUnableToCleanupClosed       = errors.New("unable to cleanup database index, already closed")
+	errDbIndexUnableToCompactClosed       = errors.New("unable to compact database index filesets, already closed")
 	errDbIndexTerminatingTickCancellation = errors.New("terminating tick early due to cancellation")
 	errDbIndexIsBootstrapping             = errors.New("index is already bootstrapping")
 )
@@ -80,10 +83,13 @@ type nsIndex struct {
 	bufferPast      time.Duration
 	bufferFuture    time.Duration
 
-	indexFilesetsBeforeFn indexFilesetsBeforeFn
-	deleteFilesFn         deleteFilesFn
+	indexFilesetsBeforeFn  indexFilesetsBeforeFn
+	deleteFilesFn          deleteFilesFn
+	readIndexInfoFilesFn   readIndexInfoFilesFn
+	compactIndexFileSetsFn compactIndexFileSetsFn
 
 	newBlockFn          newBlockFn
+	memoryBudget        index.MemoryBudget
 	logger              xlog.Logger
 	opts                Options
 	nsMetadata          namespace.Metadata
@@ -133,6 +139,15 @@ type indexFilesetsBeforeFn func(dir string,
 	exclusiveTime time.Time,
 ) ([]string, error)
 
+type readIndexInfoFilesFn func(filePathPrefix string,
+	namespace ident.ID,
+	readerBufferSize int,
+) []fs.ReadIndexInfoFileResult
+
+type compactIndexFileSetsFn func(
+	opts fs.CompactIndexFileSetsOptions,
+) (fs.CompactIndexFileSetsResult, error)
+
 type newNamespaceIndexOpts struct {
 	md              namespace.Metadata
 	opts            Options
@@ -197,11 +212,9 @@ func newNamespaceIndexWithOptions(
 		return nil, err
 	}
 
-	scope := instrumentOpts.MetricsScope().
-		SubScope("dbindex").
-		Tagged(map[string]string{
-			"namespace": nsMD.ID().String(),
-		})
+	scope := xmetrics.NamespaceScope(
+		xmetrics.SubsystemScope(instrumentOpts.MetricsScope(), xmetrics.SubsystemIndex),
+		nsMD.ID().String())
 	instrumentOpts = instrumentOpts.SetMetricsScope(scope)
 	indexOpts = indexOpts.SetInstrumentOptions(instrumentOpts)
 
@@ -221,13 +234,16 @@ func newNamespaceIndexWithOptions(
 		bufferPast:      nsMD.Options().RetentionOptions().BufferPast(),
 		bufferFuture:    nsMD.Options().RetentionOptions().BufferFuture(),
 
-		indexFilesetsBeforeFn: fs.IndexFileSetsBefore,
-		deleteFilesFn:         fs.DeleteFiles,
+		indexFilesetsBeforeFn:  fs.IndexFileSetsBefore,
+		deleteFilesFn:          fs.DeleteFiles,
+		readIndexInfoFilesFn:   fs.ReadIndexInfoFiles,
+		compactIndexFileSetsFn: fs.CompactIndexFileSets,
 
-		newBlockFn: newBlockFn,
-		opts:       newIndexOpts.opts,
-		logger:     indexOpts.InstrumentOptions().Logger(),
-		nsMetadata: nsMD,
+		newBlockFn:   newBlockFn,
+		memoryBudget: indexOpts.MemoryBudget(),
+		opts:         newIndexOpts.opts,
+		logger:       indexOpts.InstrumentOptions().Logger(),
+		nsMetadata:   nsMD,
 
 		metrics: newNamespaceIndexMetrics(instrumentOpts),
 	}
@@ -326,6 +342,15 @@ func (i *nsIndex) WriteBatch(
 	return nil
 }
 
+func (i *nsIndex) WriteBackpressure() error {
+	i.state.RLock()
+	defer i.state.RUnlock()
+	if !i.isOpenWithRLock() {
+		return errDbIndexUnableToWriteClosed
+	}
+	return i.state.insertQueue.Backpressure()
+}
+
 // WriteBatches is called by the indexInsertQueue.
 func (i *nsIndex) writeBatches(
 	batches []*index.WriteBatch,
@@ -366,6 +391,11 @@ func (i *nsIndex) writeBatches(
 		// with a result.
 		batch.ForEachUnmarkedBatchByBlockStart(writeBatchFn)
 	}
+
+	// Writes grow the mutable segments so check they're still within budget.
+	if i.memoryBudget != nil {
+		i.memoryBudget.Notify()
+	}
 }
 
 func (i *nsIndex) writeBatchForBlockStartWithRLock(
@@ -475,6 +505,9 @@ func (i *nsIndex) Tick(c context.Cancellable, tickStart time.Time) (namespaceInd
 
 		// drop any blocks past the retention period
 		if blockStart.ToTime().Before(earliestBlockStartToRetain) {
+			if i.memoryBudget != nil {
+				i.memoryBudget.Untrack(block)
+			}
 			multiErr = multiErr.Add(block.Close())
 			delete(i.state.blocksByTime, blockStart)
 			result.NumBlocksEvicted++
@@ -721,6 +754,10 @@ func (i *nsIndex) Query(
 		return index.QueryResults{}, errDbIndexUnableToQueryClosed
 	}
 
+	if err := i.validateNumericRangeQueries(query.SearchQuery().ToProto()); err != nil {
+		return index.QueryResults{}, err
+	}
+
 	// override query response limit if needed.
 	if i.state.runtimeOpts.maxQueryLimit > 0 && (opts.Limit == 0 ||
 		int64(opts.Limit) > i.state.runtimeOpts.maxQueryLimit) {
@@ -789,6 +826,37 @@ func (i *nsIndex) Query(
 	}, nil
 }
 
+// validateNumericRangeQueries ensures every range matcher in the query targets
+// a tag declared numeric in the namespace index options.
+func (i *nsIndex) validateNumericRangeQueries(q *querypb.Query) error {
+	if q == nil {
+		return nil
+	}
+	switch qry := q.Query.(type) {
+	case *querypb.Query_Range:
+		field := qry.Range.Field
+		if !i.nsMetadata.Options().IndexOptions().IsNumericTag(field) {
+			return xerrors.NewInvalidParamsError(fmt.Errorf(
+				"tag %s is not declared numeric and cannot be queried by range", field))
+		}
+	case *querypb.Query_Negation:
+		return i.validateNumericRangeQueries(qry.Negation.Query)
+	case *querypb.Query_Conjunction:
+		for _, inner := range qry.Conjunction.Queries {
+			if err := i.validateNumericRangeQueries(inner); err != nil {
+				return err
+			}
+		}
+	case *querypb.Query_Disjunction:
+		for _, inner := range qry.Disjunction.Queries {
+			if err := i.validateNumericRangeQueries(inner); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // ensureBlockPresentWithRLock guarantees an index.Block exists for the specified
 // blockStart, allocating one if it does not. It returns the desired block, or
 // error if it's unable to do so.
@@ -833,6 +901,9 @@ func (i *nsIndex) ensureBlockPresentWithRLock(blockStart time.Time) (index.Block
 
 	// add to tracked blocks map
 	i.state.blocksByTime[blockStartNanos] = block
+	if i.memoryBudget != nil {
+		i.memoryBudget.Track(block)
+	}
 
 	// update ordered blockStarts slice, and latestBlock
 	i.updateBlockStartsWithLock()
@@ -869,6 +940,14 @@ func (i *nsIndex) isOpenWithRLock() bool {
 }
 
 func (i *nsIndex) CleanupExpiredFileSets(t time.Time) error {
+	filesets, err := i.ExpiredFileSets(t)
+	if err != nil {
+		return err
+	}
+	return i.deleteFilesFn(filesets)
+}
+
+func (i *nsIndex) ExpiredFileSets(t time.Time) ([]string, error) {
 	// we only expire data on drive that we don't hold a reference to, and is
 	// past the expiration period. the earliest data we have to retain is given
 	// by the following computation:
@@ -876,7 +955,7 @@ func (i *nsIndex) CleanupExpiredFileSets(t time.Time) error {
 	i.state.RLock()
 	defer i.state.RUnlock()
 	if i.state.closed {
-		return errDbIndexUnableToCleanupClosed
+		return nil, errDbIndexUnableToCleanupClosed
 	}
 
 	// earliest block to retain based on retention period
@@ -894,13 +973,71 @@ func (i *nsIndex) CleanupExpiredFileSets(t time.Time) error {
 		pathPrefix = i.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 		nsID       = i.nsMetadata.ID()
 	)
-	filesets, err := i.indexFilesetsBeforeFn(pathPrefix, nsID, earliestBlockStartToRetain)
-	if err != nil {
-		return err
+	return i.indexFilesetsBeforeFn(pathPrefix, nsID, earliestBlockStartToRetain)
+}
+
+func (i *nsIndex) CompactFileSets(t time.Time) error {
+	minVolumes := i.opts.IndexOptions().FileSetCompactionMinVolumes()
+	if minVolumes <= 0 {
+		return nil
 	}
 
-	// and delete them
-	return i.deleteFilesFn(filesets)
+	i.state.RLock()
+	closed := i.state.closed
+	i.state.RUnlock()
+	if closed {
+		return errDbIndexUnableToCompactClosed
+	}
+
+	var (
+		fsOpts = i.opts.CommitLogOptions().FilesystemOptions()
+		nsID   = i.nsMetadata.ID()
+		// expired blocks are removed by cleanup rather than compacted, and the
+		// current block has not been flushed yet
+		earliestBlockStart = retention.FlushTimeStartForRetentionPeriod(i.retentionPeriod, i.blockSize, t)
+		currentBlockStart  = t.Truncate(i.blockSize)
+		volumesByBlock     = make(map[xtime.UnixNano]int)
+	)
+	infoFiles := i.readIndexInfoFilesFn(fsOpts.FilePathPrefix(), nsID,
+		fsOpts.InfoReaderBufferSize())
+	for _, infoFile := range infoFiles {
+		if infoFile.Err.Error() != nil {
+			continue
+		}
+		blockStart := infoFile.ID.BlockStart
+		if blockStart.Before(earliestBlockStart) || !blockStart.Before(currentBlockStart) {
+			continue
+		}
+		volumesByBlock[xtime.ToUnixNano(blockStart)]++
+	}
+
+	var multiErr xerrors.MultiError
+	for blockStart, volumes := range volumesByBlock {
+		if volumes < minVolumes {
+			continue
+		}
+		result, err := i.compactIndexFileSetsFn(fs.CompactIndexFileSetsOptions{
+			Namespace:         nsID,
+			BlockStart:        blockStart.ToTime(),
+			BlockSize:         i.blockSize,
+			MinVolumes:        minVolumes,
+			FilesystemOptions: fsOpts,
+			MemSegmentOptions: i.opts.IndexOptions().MemSegmentOptions(),
+		})
+		if err != nil {
+			i.metrics.FileSetCompactionErrors.Inc(1)
+			multiErr = multiErr.Add(fmt.Errorf(
+				"unable to compact index filesets for block start %v: %v",
+				blockStart.ToTime(), err))
+			continue
+		}
+		if result.Compacted {
+			i.metrics.FileSetCompactions.Inc(1)
+			i.metrics.FileSetVolumesCompacted.Inc(int64(result.VolumesCompacted))
+		}
+	}
+
+	return multiErr.FinalError()
 }
 
 func (i *nsIndex) Close() error {
@@ -916,6 +1053,9 @@ func (i *nsIndex) Close() error {
 
 	for t := range i.state.blocksByTime {
 		blk := i.state.blocksByTime[t]
+		if i.memoryBudget != nil {
+			i.memoryBudget.Untrack(blk)
+		}
 		multiErr = multiErr.Add(blk.Close())
 	}
 
@@ -949,6 +1089,9 @@ type nsIndexMetrics struct {
 	QueryAfterClose             tally.Counter
 	InsertEndToEndLatency       tally.Timer
 	FlushEvictedMutableSegments tally.Counter
+	FileSetCompactions          tally.Counter
+	FileSetVolumesCompacted     tally.Counter
+	FileSetCompactionErrors     tally.Counter
 }
 
 func newNamespaceIndexMetrics(
@@ -969,6 +1112,11 @@ func newNamespaceIndexMetrics(
 			scope.Timer("insert-end-to-end-latency"),
 			iopts.MetricsSamplingRate()),
 		FlushEvictedMutableSegments: scope.Counter("mutable-segment-evicted"),
+		FileSetCompactions:          scope.Counter("fileset-compactions"),
+		FileSetVolumesCompacted:     scope.Counter("fileset-volumes-compacted"),
+		FileSetCompactionErrors: scope.Tagged(map[string]string{
+			"error_type": "fileset-compaction",
+		}).Counter("index-error"),
 	}
 }
 
@@ -24,10 +24,12 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/m3ninx/doc"
 	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
@@ -47,11 +49,22 @@ var (
 	errUnableToBootstrapBlockClosed = errors.New("unable to bootstrap, block is closed")
 	errUnableToTickBlockClosed      = errors.New("unable to tick, block is closed")
 	errBlockAlreadyClosed           = errors.New("unable to close, block already closed")
+	errUnableToFlushBlockClosed     = errors.New("unable to flush mutable segments, block is closed")
 
 	errUnableToSealBlockIllegalStateFmtString  = "unable to seal, index block state: %v"
 	errUnableToWriteBlockUnknownStateFmtString = "unable to write, unknown index block state: %v"
 )
 
+const (
+	// estimatedDocumentOverheadBytes is the estimated per document overhead
+	// of a mutable segment, i.e. the ID map entry and stored document.
+	estimatedDocumentOverheadBytes = 128
+
+	// estimatedFieldOverheadBytes is the estimated per field overhead of a
+	// mutable segment, i.e. the terms dictionary and postings list entries.
+	estimatedFieldOverheadBytes = 64
+)
+
 type blockState byte
 
 const (
@@ -66,14 +79,30 @@ type block struct {
 	sync.RWMutex
 	state               blockState
 	activeSegment       segment.MutableSegment
+	activeSegmentBytes  int64
 	shardRangesSegments []blockShardRangesSegments
 
-	newExecutorFn newExecutorFn
-	startTime     time.Time
-	endTime       time.Time
-	blockSize     time.Duration
-	opts          Options
-	nsMD          namespace.Metadata
+	// flushingSegments are sealed mutable segments that were previously
+	// active and are being flushed to disk backed segments, they remain
+	// queryable until the flush completes.
+	flushingSegments      []segment.MutableSegment
+	flushingSegmentsBytes int64
+
+	// flushedSegments are disk backed segments flushed from previously
+	// active mutable segments to keep within the index memory budget.
+	flushedSegments []segment.Segment
+
+	// lastQueried is the unix nanos the block was last queried at, it is
+	// accessed atomically as queries only hold a read lock.
+	lastQueried int64
+
+	newExecutorFn    newExecutorFn
+	newDiskSegmentFn newDiskSegmentFn
+	startTime        time.Time
+	endTime          time.Time
+	blockSize        time.Duration
+	opts             Options
+	nsMD             namespace.Metadata
 }
 
 // blockShardsSegments is a collection of segments that has a mapping of what shards
@@ -111,6 +140,8 @@ func NewBlock(
 		blockSize: blockSize,
 		opts:      opts,
 		nsMD:      md,
+
+		newDiskSegmentFn: newDiskSegment,
 	}
 	b.newExecutorFn = b.executorWithRLock
 
@@ -147,10 +178,18 @@ func (b *block) WriteBatch(inserts *WriteBatch) (WriteBatchResult, error) {
 		}, err
 	}
 
+	pendingDocs := inserts.PendingDocs()
 	err := b.activeSegment.InsertBatch(m3ninxindex.Batch{
-		Docs:                inserts.PendingDocs(),
+		Docs:                pendingDocs,
 		AllowPartialUpdates: true,
 	})
+
+	// Estimates are deliberately not adjusted for partial errors, slightly
+	// overestimating memory is preferable to underestimating it.
+	for _, d := range pendingDocs {
+		b.activeSegmentBytes += estimateDocumentBytes(d)
+	}
+
 	if err == nil {
 		inserts.MarkUnmarkedEntriesSuccess()
 		return WriteBatchResult{
@@ -185,6 +224,7 @@ func (b *block) executorWithRLock() (search.Executor, error) {
 	if b.activeSegment != nil {
 		expectedReaders++
 	}
+	expectedReaders += len(b.flushingSegments) + len(b.flushedSegments)
 	for _, group := range b.shardRangesSegments {
 		expectedReaders += len(group.segments)
 	}
@@ -212,6 +252,22 @@ func (b *block) executorWithRLock() (search.Executor, error) {
 		readers = append(readers, reader)
 	}
 
+	// segments flushed from previously active segments to keep within budget
+	for _, seg := range b.flushingSegments {
+		reader, err := seg.Reader()
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, reader)
+	}
+	for _, seg := range b.flushedSegments {
+		reader, err := seg.Reader()
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, reader)
+	}
+
 	// loop over the segments associated to shard time ranges
 	for _, group := range b.shardRangesSegments {
 		for _, seg := range group.segments {
@@ -238,6 +294,9 @@ func (b *block) Query(
 		return false, errUnableToQueryBlockClosed
 	}
 
+	now := b.opts.ClockOptions().NowFn()()
+	atomic.StoreInt64(&b.lastQueried, now.UnixNano())
+
 	exec, err := b.newExecutorFn()
 	if err != nil {
 		return false, err
@@ -383,6 +442,16 @@ func (b *block) Tick(c context.Cancellable, tickStart time.Time) (BlockTickResul
 		result.NumDocs += b.activeSegment.Size()
 	}
 
+	// segments flushed from previously active segments
+	for _, seg := range b.flushingSegments {
+		result.NumSegments++
+		result.NumDocs += seg.Size()
+	}
+	for _, seg := range b.flushedSegments {
+		result.NumSegments++
+		result.NumDocs += seg.Size()
+	}
+
 	// any other segments
 	for _, group := range b.shardRangesSegments {
 		for _, seg := range group.segments {
@@ -438,6 +507,11 @@ func (b *block) NeedsMutableSegmentsEvicted() bool {
 	defer b.RUnlock()
 	anyMutableSegmentNeedsEviction := b.activeSegment != nil && b.activeSegment.Size() > 0
 
+	// segments flushed to keep within the memory budget are only held until
+	// the block is flushed.
+	anyMutableSegmentNeedsEviction = anyMutableSegmentNeedsEviction ||
+		len(b.flushingSegments) > 0 || len(b.flushedSegments) > 0
+
 	// can early terminate if we already know we need to flush.
 	if anyMutableSegmentNeedsEviction {
 		return true
@@ -471,7 +545,23 @@ func (b *block) EvictMutableSegments() (EvictMutableSegmentResults, error) {
 		results.NumDocs += b.activeSegment.Size()
 		multiErr = multiErr.Add(b.activeSegment.Close())
 		b.activeSegment = nil
+		b.activeSegmentBytes = 0
+	}
+
+	// close any segments flushed from previously active segments, they are
+	// covered by the results added to the block. Segments still being flushed
+	// are closed by the flush once it completes.
+	for _, seg := range b.flushingSegments {
+		results.NumMutableSegments++
+		results.NumDocs += seg.Size()
+	}
+	b.flushingSegments = nil
+	b.flushingSegmentsBytes = 0
+	for _, seg := range b.flushedSegments {
+		results.NumDocs += seg.Size()
+		multiErr = multiErr.Add(seg.Close())
 	}
+	b.flushedSegments = nil
 
 	// close any other mutable segments too.
 	for idx := range b.shardRangesSegments {
@@ -492,6 +582,102 @@ func (b *block) EvictMutableSegments() (EvictMutableSegmentResults, error) {
 	return results, multiErr.FinalError()
 }
 
+func (b *block) MutableSegmentsMemorySize() int64 {
+	b.RLock()
+	defer b.RUnlock()
+	return b.activeSegmentBytes + b.flushingSegmentsBytes
+}
+
+func (b *block) LastQueried() time.Time {
+	lastQueried := atomic.LoadInt64(&b.lastQueried)
+	if lastQueried == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, lastQueried)
+}
+
+func (b *block) FlushMutableSegments(dir string) (FlushMutableSegmentsResult, error) {
+	var result FlushMutableSegmentsResult
+
+	// Swap out the active segment under lock, the expensive conversion to a
+	// disk backed segment happens without holding the lock so writes and
+	// queries are not blocked while it's built.
+	b.Lock()
+	if b.state == blockStateClosed {
+		b.Unlock()
+		return result, errUnableToFlushBlockClosed
+	}
+	if b.activeSegment == nil || b.activeSegment.Size() == 0 {
+		b.Unlock()
+		return result, nil
+	}
+
+	flushing := b.activeSegment
+	result.NumDocs = flushing.Size()
+	result.NumBytes = b.activeSegmentBytes
+
+	if !flushing.IsSealed() {
+		if _, err := flushing.Seal(); err != nil {
+			b.Unlock()
+			return result, err
+		}
+	}
+
+	var next segment.MutableSegment
+	if b.state == blockStateOpen {
+		seg, err := mem.NewSegment(postings.ID(0), b.opts.MemSegmentOptions())
+		if err != nil {
+			b.Unlock()
+			return result, err
+		}
+		next = seg
+	}
+	b.activeSegment = next
+	b.activeSegmentBytes = 0
+	b.flushingSegments = append(b.flushingSegments, flushing)
+	b.flushingSegmentsBytes += result.NumBytes
+	b.Unlock()
+
+	flushed, err := b.newDiskSegmentFn(flushing, dir, b.opts)
+
+	b.Lock()
+	defer b.Unlock()
+
+	// The flushing segment may have already been released by a block flush
+	// or close while the disk backed segment was being built.
+	stillFlushing := false
+	for i, seg := range b.flushingSegments {
+		if seg == flushing {
+			b.flushingSegments = append(b.flushingSegments[:i], b.flushingSegments[i+1:]...)
+			b.flushingSegmentsBytes -= result.NumBytes
+			stillFlushing = true
+			break
+		}
+	}
+
+	if !stillFlushing {
+		var multiErr xerrors.MultiError
+		multiErr = multiErr.Add(err)
+		if err == nil {
+			multiErr = multiErr.Add(flushed.Close())
+		}
+		multiErr = multiErr.Add(flushing.Close())
+		return FlushMutableSegmentsResult{}, multiErr.FinalError()
+	}
+
+	if err != nil {
+		// Keep the segment queryable in memory, it will be released when
+		// the block is flushed.
+		b.flushingSegments = append(b.flushingSegments, flushing)
+		b.flushingSegmentsBytes += result.NumBytes
+		return FlushMutableSegmentsResult{}, err
+	}
+
+	b.flushedSegments = append(b.flushedSegments, flushed)
+	result.NumMutableSegments++
+	return result, flushing.Close()
+}
+
 func (b *block) Close() error {
 	b.Lock()
 	defer b.Unlock()
@@ -506,8 +692,18 @@ func (b *block) Close() error {
 	if b.activeSegment != nil {
 		multiErr = multiErr.Add(b.activeSegment.Close())
 		b.activeSegment = nil
+		b.activeSegmentBytes = 0
 	}
 
+	// close any segments flushed from previously active segments, segments
+	// still being flushed are closed by the flush once it completes.
+	b.flushingSegments = nil
+	b.flushingSegmentsBytes = 0
+	for _, seg := range b.flushedSegments {
+		multiErr = multiErr.Add(seg.Close())
+	}
+	b.flushedSegments = nil
+
 	// close any other added segments too.
 	for _, group := range b.shardRangesSegments {
 		for _, seg := range group.segments {
@@ -550,6 +746,17 @@ func (b *block) openBlockHasNilActiveSegmentInvariantErrorWithRLock() error {
 	return err
 }
 
+// estimateDocumentBytes returns a rough estimate of the memory held by a
+// mutable segment for the document, including the overhead of the terms
+// dictionary and postings list entries for each field.
+func estimateDocumentBytes(d doc.Document) int64 {
+	size := estimatedDocumentOverheadBytes + len(d.ID)
+	for _, f := range d.Fields {
+		size += estimatedFieldOverheadBytes + len(f.Name) + len(f.Value)
+	}
+	return int64(size)
+}
+
 type closable interface {
 	Close() error
 }
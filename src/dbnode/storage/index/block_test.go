@@ -22,6 +22,8 @@ package index
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -1456,6 +1458,115 @@ func TestBlockE2EInsertAddResultsMergeQuery(t *testing.T) {
 		ident.NewTagsIterator(t2)))
 }
 
+func TestBlockE2EInsertFlushMutableSegmentsQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	dir, err := ioutil.TempDir("", "index-flush-mutable-segments")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	blk, err := NewBlock(blockStart, testMD, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+	defer b.Close()
+
+	writeDoc := func(d doc.Document) {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+
+		batch := NewWriteBatch(WriteBatchOptions{
+			IndexBlockSize: blockSize,
+		})
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+
+		res, err := b.WriteBatch(batch)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), res.NumSuccess)
+	}
+
+	writeDoc(testDoc1())
+	require.Equal(t, estimateDocumentBytes(testDoc1()), b.MutableSegmentsMemorySize())
+	require.True(t, b.LastQueried().IsZero())
+
+	res, err := b.FlushMutableSegments(dir)
+	require.NoError(t, err)
+	require.Equal(t, FlushMutableSegmentsResult{
+		NumMutableSegments: 1,
+		NumDocs:            1,
+		NumBytes:           estimateDocumentBytes(testDoc1()),
+	}, res)
+	require.Equal(t, int64(0), b.MutableSegmentsMemorySize())
+	require.Equal(t, 1, len(b.flushedSegments))
+
+	// Flushed files are unlinked once they are mapped.
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(files))
+
+	// Block continues to take writes in a new active segment.
+	writeDoc(testDoc2())
+
+	q, err := idx.NewRegexpQuery([]byte("bar"), []byte("b.*"))
+	require.NoError(t, err)
+	results := NewResults(testOpts)
+	exhaustive, err := b.Query(Query{q}, QueryOptions{}, results)
+	require.NoError(t, err)
+	require.True(t, exhaustive)
+	require.Equal(t, 2, results.Size())
+	require.False(t, b.LastQueried().IsZero())
+
+	_, ok = results.Map().Get(ident.StringID(string(testDoc1().ID)))
+	require.True(t, ok)
+	_, ok = results.Map().Get(ident.StringID(string(testDoc2().ID)))
+	require.True(t, ok)
+
+	tickResult, err := b.Tick(nil, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), tickResult.NumSegments)
+	require.Equal(t, int64(2), tickResult.NumDocs)
+
+	// Flushed segments are released when the block's mutable segments are evicted.
+	require.NoError(t, b.Seal())
+	require.True(t, b.NeedsMutableSegmentsEvicted())
+	evictResult, err := b.EvictMutableSegments()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), evictResult.NumDocs)
+	require.Equal(t, 0, len(b.flushedSegments))
+	require.False(t, b.NeedsMutableSegmentsEvicted())
+}
+
+func TestBlockFlushMutableSegmentsEmpty(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	blockStart := time.Now().Truncate(time.Hour)
+
+	b, err := NewBlock(blockStart, testMD, testOpts)
+	require.NoError(t, err)
+
+	res, err := b.FlushMutableSegments("")
+	require.NoError(t, err)
+	require.Equal(t, FlushMutableSegmentsResult{}, res)
+
+	require.NoError(t, b.Close())
+	_, err = b.FlushMutableSegments("")
+	require.Equal(t, errUnableToFlushBlockClosed, err)
+}
+
 func testSegment(t *testing.T, docs ...doc.Document) segment.Segment {
 	seg, err := mem.NewSegment(0, testOpts.MemSegmentOptions())
 	require.NoError(t, err)
@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/m3db/m3/src/dbnode/x/mmap"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fs"
+	m3ninxpersist "github.com/m3db/m3/src/m3ninx/persist"
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+const (
+	diskSegmentDirPrefix = "m3db-index-segment-"
+	diskSegmentFileMode  = 0600
+)
+
+type newDiskSegmentFn func(
+	seg segment.MutableSegment,
+	dir string,
+	opts Options,
+) (segment.Segment, error)
+
+// newDiskSegment writes out the sealed mutable segment as FST files in a
+// temporary directory under dir and returns a segment backed by read only
+// mmaps of the files. The files are unlinked as soon as they are mapped so
+// they never outlive the process, the mapped pages remain backed by disk and
+// can be reclaimed by the kernel under memory pressure.
+func newDiskSegment(
+	seg segment.MutableSegment,
+	dir string,
+	opts Options,
+) (segment.Segment, error) {
+	writer, err := m3ninxpersist.NewMutableSegmentFileSetWriter()
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.Reset(seg); err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	tmpDir, err := ioutil.TempDir(dir, diskSegmentDirPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var (
+		data = m3ninxfs.SegmentData{
+			MajorVersion: writer.MajorVersion(),
+			MinorVersion: writer.MinorVersion(),
+			Metadata:     writer.SegmentMetadata(),
+		}
+		closer  = &diskSegmentCloser{}
+		success = false
+	)
+	defer func() {
+		if !success {
+			closer.Close()
+		}
+	}()
+
+	for _, fileType := range writer.Files() {
+		bytes, err := writeAndMmapSegmentFile(writer, fileType,
+			filepath.Join(tmpDir, string(fileType)))
+		if err != nil {
+			return nil, err
+		}
+		closer.mmaps = append(closer.mmaps, bytes)
+
+		switch fileType {
+		case m3ninxpersist.DocumentDataIndexSegmentFileType:
+			data.DocsData = bytes
+		case m3ninxpersist.DocumentIndexIndexSegmentFileType:
+			data.DocsIdxData = bytes
+		case m3ninxpersist.PostingsIndexSegmentFileType:
+			data.PostingsData = bytes
+		case m3ninxpersist.FSTFieldsIndexSegmentFileType:
+			data.FSTFieldsData = bytes
+		case m3ninxpersist.FSTTermsIndexSegmentFileType:
+			data.FSTTermsData = bytes
+		}
+	}
+	data.Closer = closer
+
+	diskSeg, err := m3ninxfs.NewSegment(data, m3ninxfs.NewSegmentOpts{
+		PostingsListPool: opts.MemSegmentOptions().PostingsListPool(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	success = true
+	return diskSeg, nil
+}
+
+func writeAndMmapSegmentFile(
+	writer m3ninxpersist.MutableSegmentFileSetWriter,
+	fileType m3ninxpersist.IndexSegmentFileType,
+	filePath string,
+) ([]byte, error) {
+	fd, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_RDWR, diskSegmentFileMode)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	buffered := bufio.NewWriter(fd)
+	if err := writer.WriteFile(fileType, buffered); err != nil {
+		return nil, err
+	}
+	if err := buffered.Flush(); err != nil {
+		return nil, err
+	}
+
+	result, err := mmap.File(fd, mmap.Options{Read: true})
+	if err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+type diskSegmentCloser struct {
+	mmaps [][]byte
+}
+
+func (c *diskSegmentCloser) Close() error {
+	var multiErr xerrors.MultiError
+	for _, bytes := range c.mmaps {
+		multiErr = multiErr.Add(mmap.Munmap(bytes))
+	}
+	c.mmaps = nil
+	return multiErr.FinalError()
+}
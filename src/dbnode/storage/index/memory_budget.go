@@ -0,0 +1,244 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/instrument"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// defaultMemoryBudgetTargetRatio is the default fraction of the budget
+	// that mutable segments are flushed down to once it is exceeded.
+	defaultMemoryBudgetTargetRatio = 0.8
+)
+
+var (
+	errMemoryBudgetLimitInvalid       = errors.New("memory budget limit must be positive")
+	errMemoryBudgetTargetRatioInvalid = errors.New("memory budget target ratio must be between 0 and 1")
+	errMemoryBudgetAlreadyClosed      = errors.New("memory budget already closed")
+)
+
+// MemoryBudgetOptions is a set of options for a memory budget.
+type MemoryBudgetOptions struct {
+	// LimitBytes is the estimated number of bytes the mutable segments of all
+	// tracked blocks may hold before they are flushed to disk.
+	LimitBytes int64
+
+	// TargetRatio is the fraction of the limit that mutable segments are
+	// flushed down to once the limit is exceeded, this avoids flushing small
+	// segments every time the limit is reached, defaults to 0.8.
+	TargetRatio float64
+
+	// Directory is the directory that disk backed segments are written to,
+	// defaults to the system temporary directory.
+	Directory string
+
+	// InstrumentOptions is the instrument options.
+	InstrumentOptions instrument.Options
+}
+
+type memoryBudgetMetrics struct {
+	usedBytes       tally.Gauge
+	flushedSegments tally.Counter
+	flushedBytes    tally.Counter
+	flushErrors     tally.Counter
+}
+
+func newMemoryBudgetMetrics(scope tally.Scope) memoryBudgetMetrics {
+	return memoryBudgetMetrics{
+		usedBytes:       scope.Gauge("used-bytes"),
+		flushedSegments: scope.Counter("flushed-segments"),
+		flushedBytes:    scope.Counter("flushed-bytes"),
+		flushErrors:     scope.Counter("flush-errors"),
+	}
+}
+
+type memoryBudget struct {
+	sync.RWMutex
+
+	// enforceLock serializes enforcement of the budget.
+	enforceLock sync.Mutex
+
+	limitBytes  int64
+	targetBytes int64
+	dir         string
+	blocks      map[Block]struct{}
+	closed      bool
+	notifyCh    chan struct{}
+	doneCh      chan struct{}
+	logger      xlog.Logger
+	metrics     memoryBudgetMetrics
+}
+
+// NewMemoryBudget returns a new memory budget that enforces itself in the
+// background whenever it is notified that tracked blocks have grown.
+func NewMemoryBudget(opts MemoryBudgetOptions) (MemoryBudget, error) {
+	if opts.LimitBytes <= 0 {
+		return nil, errMemoryBudgetLimitInvalid
+	}
+	if opts.TargetRatio == 0 {
+		opts.TargetRatio = defaultMemoryBudgetTargetRatio
+	}
+	if opts.TargetRatio < 0 || opts.TargetRatio > 1 {
+		return nil, errMemoryBudgetTargetRatioInvalid
+	}
+	if opts.InstrumentOptions == nil {
+		opts.InstrumentOptions = instrument.NewOptions()
+	}
+
+	scope := opts.InstrumentOptions.MetricsScope().SubScope("index-memory-budget")
+	m := &memoryBudget{
+		limitBytes:  opts.LimitBytes,
+		targetBytes: int64(float64(opts.LimitBytes) * opts.TargetRatio),
+		dir:         opts.Directory,
+		blocks:      make(map[Block]struct{}),
+		notifyCh:    make(chan struct{}, 1),
+		doneCh:      make(chan struct{}),
+		logger:      opts.InstrumentOptions.Logger(),
+		metrics:     newMemoryBudgetMetrics(scope),
+	}
+	go m.enforceLoop()
+	return m, nil
+}
+
+func (m *memoryBudget) Track(b Block) {
+	m.Lock()
+	m.blocks[b] = struct{}{}
+	m.Unlock()
+}
+
+func (m *memoryBudget) Untrack(b Block) {
+	m.Lock()
+	delete(m.blocks, b)
+	m.Unlock()
+}
+
+func (m *memoryBudget) Notify() {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return
+	}
+
+	select {
+	case m.notifyCh <- struct{}{}:
+	default:
+		// Enforcement is already pending.
+	}
+}
+
+func (m *memoryBudget) enforceLoop() {
+	defer close(m.doneCh)
+	for range m.notifyCh {
+		if _, err := m.Enforce(); err != nil {
+			m.logger.Errorf("error enforcing index memory budget: %v", err)
+		}
+	}
+}
+
+type memoryBudgetBlock struct {
+	block Block
+	bytes int64
+}
+
+func (m *memoryBudget) Enforce() (FlushMutableSegmentsResult, error) {
+	m.enforceLock.Lock()
+	defer m.enforceLock.Unlock()
+
+	var (
+		result    FlushMutableSegmentsResult
+		usedBytes int64
+	)
+	m.RLock()
+	blocks := make([]memoryBudgetBlock, 0, len(m.blocks))
+	for b := range m.blocks {
+		bytes := b.MutableSegmentsMemorySize()
+		usedBytes += bytes
+		if bytes > 0 {
+			blocks = append(blocks, memoryBudgetBlock{block: b, bytes: bytes})
+		}
+	}
+	m.RUnlock()
+
+	m.metrics.usedBytes.Update(float64(usedBytes))
+	if usedBytes <= m.limitBytes {
+		return result, nil
+	}
+
+	// Flush the least recently queried blocks first, older blocks are
+	// preferred amongst blocks queried at the same time.
+	sort.Slice(blocks, func(i, j int) bool {
+		iQueried, jQueried := blocks[i].block.LastQueried(), blocks[j].block.LastQueried()
+		if !iQueried.Equal(jQueried) {
+			return iQueried.Before(jQueried)
+		}
+		return blocks[i].block.StartTime().Before(blocks[j].block.StartTime())
+	})
+
+	var multiErr xerrors.MultiError
+	for _, b := range blocks {
+		if usedBytes <= m.targetBytes {
+			break
+		}
+
+		flushResult, err := b.block.FlushMutableSegments(m.dir)
+		if err == errUnableToFlushBlockClosed {
+			// Block was closed since it was tracked, it no longer holds memory.
+			usedBytes -= b.bytes
+			continue
+		}
+		if err != nil {
+			m.metrics.flushErrors.Inc(1)
+			multiErr = multiErr.Add(err)
+			continue
+		}
+
+		result.Add(flushResult)
+		usedBytes -= flushResult.NumBytes
+	}
+
+	m.metrics.usedBytes.Update(float64(usedBytes))
+	m.metrics.flushedSegments.Inc(result.NumMutableSegments)
+	m.metrics.flushedBytes.Inc(result.NumBytes)
+	return result, multiErr.FinalError()
+}
+
+func (m *memoryBudget) Close() error {
+	m.Lock()
+	if m.closed {
+		m.Unlock()
+		return errMemoryBudgetAlreadyClosed
+	}
+	m.closed = true
+	close(m.notifyCh)
+	m.Unlock()
+
+	<-m.doneCh
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryBudget(t *testing.T, limitBytes int64) *memoryBudget {
+	budget, err := NewMemoryBudget(MemoryBudgetOptions{
+		LimitBytes:  limitBytes,
+		TargetRatio: 0.5,
+	})
+	require.NoError(t, err)
+	m, ok := budget.(*memoryBudget)
+	require.True(t, ok)
+	return m
+}
+
+func newTestMemoryBudgetBlock(
+	ctrl *gomock.Controller,
+	start time.Time,
+	lastQueried time.Time,
+	bytes int64,
+) *MockBlock {
+	b := NewMockBlock(ctrl)
+	b.EXPECT().StartTime().Return(start).AnyTimes()
+	b.EXPECT().LastQueried().Return(lastQueried).AnyTimes()
+	b.EXPECT().MutableSegmentsMemorySize().Return(bytes).AnyTimes()
+	return b
+}
+
+func TestNewMemoryBudgetInvalidOptions(t *testing.T) {
+	_, err := NewMemoryBudget(MemoryBudgetOptions{})
+	require.Equal(t, errMemoryBudgetLimitInvalid, err)
+
+	_, err = NewMemoryBudget(MemoryBudgetOptions{
+		LimitBytes:  100,
+		TargetRatio: 1.5,
+	})
+	require.Equal(t, errMemoryBudgetTargetRatioInvalid, err)
+}
+
+func TestMemoryBudgetEnforceWithinLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newTestMemoryBudget(t, 100)
+	defer m.Close()
+
+	now := time.Now().Truncate(time.Hour)
+	m.Track(newTestMemoryBudgetBlock(ctrl, now, now, 40))
+	m.Track(newTestMemoryBudgetBlock(ctrl, now.Add(time.Hour), now, 60))
+
+	result, err := m.Enforce()
+	require.NoError(t, err)
+	require.Equal(t, FlushMutableSegmentsResult{}, result)
+}
+
+func TestMemoryBudgetEnforceFlushesLeastRecentlyQueried(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newTestMemoryBudget(t, 100)
+	defer m.Close()
+
+	var (
+		now    = time.Now().Truncate(time.Hour)
+		recent = newTestMemoryBudgetBlock(ctrl, now.Add(-time.Hour), now, 40)
+		oldest = newTestMemoryBudgetBlock(ctrl, now, now.Add(-2*time.Minute), 40)
+		older  = newTestMemoryBudgetBlock(ctrl, now.Add(-2*time.Hour), now.Add(-time.Minute), 40)
+	)
+	m.Track(recent)
+	m.Track(oldest)
+	m.Track(older)
+
+	// Usage of 120 bytes exceeds the 100 byte limit, blocks are flushed
+	// until usage is within the 50 byte target.
+	oldest.EXPECT().FlushMutableSegments("").Return(FlushMutableSegmentsResult{
+		NumMutableSegments: 1,
+		NumDocs:            4,
+		NumBytes:           40,
+	}, nil)
+	older.EXPECT().FlushMutableSegments("").Return(FlushMutableSegmentsResult{
+		NumMutableSegments: 1,
+		NumDocs:            4,
+		NumBytes:           40,
+	}, nil)
+
+	result, err := m.Enforce()
+	require.NoError(t, err)
+	require.Equal(t, FlushMutableSegmentsResult{
+		NumMutableSegments: 2,
+		NumDocs:            8,
+		NumBytes:           80,
+	}, result)
+}
+
+func TestMemoryBudgetEnforceUntracked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newTestMemoryBudget(t, 100)
+	defer m.Close()
+
+	now := time.Now().Truncate(time.Hour)
+	b := newTestMemoryBudgetBlock(ctrl, now, now, 200)
+	m.Track(b)
+	m.Untrack(b)
+
+	result, err := m.Enforce()
+	require.NoError(t, err)
+	require.Equal(t, FlushMutableSegmentsResult{}, result)
+}
+
+func TestMemoryBudgetClose(t *testing.T) {
+	m := newTestMemoryBudget(t, 100)
+	require.NoError(t, m.Close())
+
+	// Notifying a closed budget is a no-op.
+	m.Notify()
+	require.Equal(t, errMemoryBudgetAlreadyClosed, m.Close())
+}
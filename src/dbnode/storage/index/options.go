@@ -33,6 +33,10 @@ import (
 const (
 	// defaultIndexInsertMode sets the default indexing mode to synchronous.
 	defaultIndexInsertMode = InsertSync
+
+	// defaultFileSetCompactionMinVolumes is the default minimum number of
+	// index fileset volumes for a block start before they are compacted.
+	defaultFileSetCompactionMinVolumes = 4
 )
 
 var (
@@ -50,6 +54,9 @@ type opts struct {
 	idPool         ident.Pool
 	bytesPool      pool.CheckedBytesPool
 	resultsPool    ResultsPool
+
+	fileSetCompactionMinVolumes int
+	memoryBudget                MemoryBudget
 }
 
 var undefinedUUIDFn = func() ([]byte, error) { return nil, errIDGenerationDisabled }
@@ -70,6 +77,8 @@ func NewOptions() Options {
 		bytesPool:      bytesPool,
 		idPool:         idPool,
 		resultsPool:    resultsPool,
+
+		fileSetCompactionMinVolumes: defaultFileSetCompactionMinVolumes,
 	}
 	resultsPool.Init(func() Results { return NewResults(opts) })
 	return opts
@@ -159,3 +168,23 @@ func (o *opts) SetResultsPool(value ResultsPool) Options {
 func (o *opts) ResultsPool() ResultsPool {
 	return o.resultsPool
 }
+
+func (o *opts) SetFileSetCompactionMinVolumes(value int) Options {
+	opts := *o
+	opts.fileSetCompactionMinVolumes = value
+	return &opts
+}
+
+func (o *opts) FileSetCompactionMinVolumes() int {
+	return o.fileSetCompactionMinVolumes
+}
+
+func (o *opts) SetMemoryBudget(value MemoryBudget) Options {
+	opts := *o
+	opts.memoryBudget = value
+	return &opts
+}
+
+func (o *opts) MemoryBudget() MemoryBudget {
+	return o.memoryBudget
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
@@ -62,6 +63,12 @@ type QueryOptions struct {
 	StartInclusive time.Time
 	EndExclusive   time.Time
 	Limit          int
+	// Trace is the trace context forwarded to the nodes serving the query.
+	Trace tracing.SpanContext
+	// TagNames if set restricts the tags returned with each result to
+	// those named, the remaining tags are dropped by the nodes serving
+	// the query.
+	TagNames [][]byte
 }
 
 // QueryResults is the collection of results for a query.
@@ -170,10 +177,60 @@ type Block interface {
 	// data the mutable segments should have held at this time.
 	EvictMutableSegments() (EvictMutableSegmentResults, error)
 
+	// MutableSegmentsMemorySize returns the estimated number of bytes held in
+	// memory by the block's active mutable segment.
+	MutableSegmentsMemorySize() int64
+
+	// LastQueried returns the time the block was last queried, or the zero
+	// time if it has never been queried.
+	LastQueried() time.Time
+
+	// FlushMutableSegments flushes the block's active mutable segment to a
+	// disk backed FST segment in the given directory to release the memory
+	// it holds, open blocks continue to take writes in a new active segment.
+	FlushMutableSegments(dir string) (FlushMutableSegmentsResult, error)
+
 	// Close will release any held resources and close the Block.
 	Close() error
 }
 
+// FlushMutableSegmentsResult returns statistics about the FlushMutableSegments
+// execution.
+type FlushMutableSegmentsResult struct {
+	NumMutableSegments int64
+	NumDocs            int64
+	NumBytes           int64
+}
+
+// Add adds the provided results to the receiver.
+func (r *FlushMutableSegmentsResult) Add(o FlushMutableSegmentsResult) {
+	r.NumMutableSegments += o.NumMutableSegments
+	r.NumDocs += o.NumDocs
+	r.NumBytes += o.NumBytes
+}
+
+// MemoryBudget bounds the estimated memory held by the mutable segments of
+// index blocks, flushing the segments of the least recently queried blocks to
+// disk backed FST segments when the budget is exceeded.
+type MemoryBudget interface {
+	// Track starts accounting for the mutable segments of the block.
+	Track(b Block)
+
+	// Untrack stops accounting for the mutable segments of the block.
+	Untrack(b Block)
+
+	// Notify signals that tracked blocks may have grown, asynchronously
+	// enforcing the budget if it has been exceeded.
+	Notify()
+
+	// Enforce flushes mutable segments of the least recently queried blocks
+	// until the estimated memory held is within the budget.
+	Enforce() (FlushMutableSegmentsResult, error)
+
+	// Close stops enforcing the budget.
+	Close() error
+}
+
 // EvictMutableSegmentResults returns statistics about the EvictMutableSegments execution.
 type EvictMutableSegmentResults struct {
 	NumMutableSegments int64
@@ -552,4 +609,21 @@ type Options interface {
 
 	// ResultsPool returns the results pool.
 	ResultsPool() ResultsPool
+
+	// SetFileSetCompactionMinVolumes sets the minimum number of index fileset
+	// volumes for a block start before they are compacted into a single
+	// volume, zero disables compaction.
+	SetFileSetCompactionMinVolumes(value int) Options
+
+	// FileSetCompactionMinVolumes returns the minimum number of index fileset
+	// volumes for a block start before they are compacted into a single
+	// volume, zero disables compaction.
+	FileSetCompactionMinVolumes() int
+
+	// SetMemoryBudget sets the memory budget for mutable segments, nil
+	// disables the budget.
+	SetMemoryBudget(value MemoryBudget) Options
+
+	// MemoryBudget returns the memory budget for mutable segments.
+	MemoryBudget() MemoryBudget
 }
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 
 	"github.com/uber-go/tally"
@@ -35,6 +36,7 @@ var (
 	errIndexInsertQueueNotOpen             = errors.New("index insert queue is not open")
 	errIndexInsertQueueAlreadyOpenOrClosed = errors.New("index insert queue already open or is closed")
 	errNewSeriesIndexRateLimitExceeded     = errors.New("indexing new series exceeds rate limit")
+	errIndexInsertQueueSaturated           = errors.New("index insert queue is saturated")
 )
 
 type nsIndexInsertQueueState int
@@ -49,6 +51,7 @@ var (
 	// TODO(prateek): runtime options for this stuff
 	defaultIndexBatchBackoff   = time.Millisecond
 	defaultIndexPerSecondLimit = 1000000
+	defaultIndexMaxPending     = 1 << 20
 )
 
 type nsIndexInsertQueue struct {
@@ -61,6 +64,13 @@ type nsIndexInsertQueue struct {
 	indexPerSecondLimitWindowNanos  int64
 	indexPerSecondLimitWindowValues int
 
+	// backpressure, pending is the number of entries enqueued that have
+	// not yet been indexed and lastBatchDuration is how long the last
+	// batch took to index which is used as a hint of when to retry
+	indexMaxPending   int
+	pending           int
+	lastBatchDuration time.Duration
+
 	// active batch pending execution
 	currBatch *nsIndexInsertBatch
 
@@ -89,6 +99,7 @@ func newNamespaceIndexInsertQueue(
 		currBatch:           currBatch,
 		indexBatchBackoff:   defaultIndexBatchBackoff,
 		indexPerSecondLimit: defaultIndexPerSecondLimit,
+		indexMaxPending:     defaultIndexMaxPending,
 		indexBatchFn:        indexBatchFn,
 		nowFn:               nowFn,
 		sleepFn:             time.Sleep,
@@ -137,11 +148,19 @@ func (q *nsIndexInsertQueue) insertLoop() {
 			q.Unlock()
 		}
 
+		batchStart := q.nowFn()
 		if len(batch.inserts) > 0 {
 			q.indexBatchFn(batch.inserts)
 		}
 		batch.wg.Done()
 
+		q.Lock()
+		q.pending -= batch.pending
+		q.lastBatchDuration = q.nowFn().Sub(batchStart)
+		pending := q.pending
+		q.Unlock()
+		q.metrics.pending.Update(float64(pending))
+
 		// Set the free batch
 		batch.Reset()
 		freeBatch = batch
@@ -157,13 +176,23 @@ func (q *nsIndexInsertQueue) insertLoop() {
 func (q *nsIndexInsertQueue) InsertBatch(
 	batch *index.WriteBatch,
 ) (*sync.WaitGroup, error) {
-	windowNanos := q.nowFn().Truncate(time.Second).UnixNano()
+	now := q.nowFn()
+	windowStart := now.Truncate(time.Second)
+	windowNanos := windowStart.UnixNano()
+	batchLen := batch.Len()
 
 	q.Lock()
 	if q.state != nsIndexInsertQueueStateOpen {
 		q.Unlock()
 		return nil, errIndexInsertQueueNotOpen
 	}
+	if err := q.backpressureWithLock(batchLen); err != nil {
+		// Signal to the caller to back off rather than growing the queue
+		// and the latency of every insert waiting on it
+		q.Unlock()
+		q.metrics.numSaturated.Inc(1)
+		return nil, err
+	}
 	if limit := q.indexPerSecondLimit; limit > 0 {
 		if q.indexPerSecondLimitWindowNanos != windowNanos {
 			// Rolled into to a new window
@@ -173,11 +202,17 @@ func (q *nsIndexInsertQueue) InsertBatch(
 		q.indexPerSecondLimitWindowValues++
 		if q.indexPerSecondLimitWindowValues > limit {
 			q.Unlock()
-			return nil, errNewSeriesIndexRateLimitExceeded
+			q.metrics.numRateLimited.Inc(1)
+			// Can retry once rolled into the next window
+			retryAfter := windowStart.Add(time.Second).Sub(now)
+			return nil, m3dberrors.NewResourceExhaustedError(
+				errNewSeriesIndexRateLimitExceeded, retryAfter)
 		}
 	}
-	batchLen := batch.Len()
 	q.currBatch.inserts = append(q.currBatch.inserts, batch)
+	q.currBatch.pending += batchLen
+	q.pending += batchLen
+	pending := q.pending
 	wg := q.currBatch.wg
 	q.Unlock()
 
@@ -189,9 +224,33 @@ func (q *nsIndexInsertQueue) InsertBatch(
 	}
 
 	q.metrics.numPending.Inc(int64(batchLen))
+	q.metrics.pending.Update(float64(pending))
 	return wg, nil
 }
 
+func (q *nsIndexInsertQueue) Backpressure() error {
+	q.RLock()
+	// Saturated if unable to accept even a single insert
+	err := q.backpressureWithLock(1)
+	q.RUnlock()
+	return err
+}
+
+func (q *nsIndexInsertQueue) backpressureWithLock(inserts int) error {
+	max := q.indexMaxPending
+	if max <= 0 || q.pending+inserts <= max {
+		return nil
+	}
+	// The queue drains at most a batch per batch backoff, use the time the
+	// last batch took to index as a hint for when to retry
+	retryAfter := q.lastBatchDuration
+	if retryAfter < q.indexBatchBackoff {
+		retryAfter = q.indexBatchBackoff
+	}
+	return m3dberrors.NewResourceExhaustedError(
+		errIndexInsertQueueSaturated, retryAfter)
+}
+
 func (q *nsIndexInsertQueue) Start() error {
 	q.Lock()
 	defer q.Unlock()
@@ -234,6 +293,7 @@ type nsIndexInsertBatchFn func(inserts []*index.WriteBatch)
 type nsIndexInsertBatch struct {
 	wg      *sync.WaitGroup
 	inserts []*index.WriteBatch
+	pending int
 }
 
 func (b *nsIndexInsertBatch) Reset() {
@@ -245,10 +305,14 @@ func (b *nsIndexInsertBatch) Reset() {
 		b.inserts[i] = nil
 	}
 	b.inserts = b.inserts[:0]
+	b.pending = 0
 }
 
 type nsIndexInsertQueueMetrics struct {
-	numPending tally.Counter
+	numPending     tally.Counter
+	pending        tally.Gauge
+	numSaturated   tally.Counter
+	numRateLimited tally.Counter
 }
 
 func newNamespaceIndexInsertQueueMetrics(
@@ -256,6 +320,9 @@ func newNamespaceIndexInsertQueueMetrics(
 ) nsIndexInsertQueueMetrics {
 	subScope := scope.SubScope("index-queue")
 	return nsIndexInsertQueueMetrics{
-		numPending: subScope.Counter("num-pending"),
+		numPending:     subScope.Counter("num-pending"),
+		pending:        subScope.Gauge("pending"),
+		numSaturated:   subScope.Counter("num-saturated"),
+		numRateLimited: subScope.Counter("num-rate-limited"),
 	}
 }
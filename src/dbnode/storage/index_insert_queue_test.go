@@ -27,7 +27,9 @@ import (
 	"testing"
 	"time"
 
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
 
 	"github.com/fortytw2/leaktest"
@@ -152,7 +154,10 @@ func TestIndexInsertQueueRateLimit(t *testing.T) {
 		_, err = q.InsertBatch(testWriteBatch(testWriteBatchEntry(testID(i+2),
 			testTags(i+2), time.Time{}, callback)))
 		assert.Error(t, err)
-		assert.Equal(t, errNewSeriesIndexRateLimitExceeded, err)
+		assert.Equal(t, errNewSeriesIndexRateLimitExceeded, xerrors.InnerError(err))
+		retryAfter, ok := m3dberrors.RetryAfter(err)
+		assert.True(t, ok)
+		assert.Equal(t, 750*time.Millisecond, retryAfter)
 	}
 
 	// Start 2nd second should not be an issue
@@ -170,7 +175,10 @@ func TestIndexInsertQueueRateLimit(t *testing.T) {
 	_, err = q.InsertBatch(testWriteBatch(testWriteBatchEntry(testID(112),
 		testTags(112), time.Time{}, callback)))
 	assert.Error(t, err)
-	assert.Equal(t, errNewSeriesIndexRateLimitExceeded, err)
+	assert.Equal(t, errNewSeriesIndexRateLimitExceeded, xerrors.InnerError(err))
+	retryAfter, ok := m3dberrors.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 800*time.Millisecond, retryAfter)
 
 	// Start 3rd second
 	addTime(800 * time.Millisecond)
@@ -185,6 +193,54 @@ func TestIndexInsertQueueRateLimit(t *testing.T) {
 	q.Unlock()
 }
 
+func TestIndexInsertQueueBackpressureWhenSaturated(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		releaseCh = make(chan struct{})
+		callback  = index.NewMockOnIndexSeries(ctrl)
+	)
+	q := newTestIndexInsertQueue()
+	q.indexBatchFn = func(inserts []*index.WriteBatch) {
+		<-releaseCh
+	}
+	q.indexMaxPending = 1
+
+	assert.NoError(t, q.Start())
+	defer func() {
+		assert.NoError(t, q.Stop())
+	}()
+
+	assert.NoError(t, q.Backpressure())
+
+	wg, err := q.InsertBatch(testWriteBatch(testWriteBatchEntry(testID(1),
+		testTags(1), time.Time{}, callback)))
+	require.NoError(t, err)
+
+	// Queue is full until the pending batch is indexed
+	_, err = q.InsertBatch(testWriteBatch(testWriteBatchEntry(testID(3),
+		testTags(3), time.Time{}, callback)))
+	require.Error(t, err)
+	assert.Equal(t, errIndexInsertQueueSaturated, xerrors.InnerError(err))
+	retryAfter, ok := m3dberrors.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, q.indexBatchBackoff, retryAfter)
+	assert.True(t, m3dberrors.IsResourceExhaustedError(q.Backpressure()))
+
+	close(releaseCh)
+	wg.Wait()
+
+	// Pending count is released after the batch is indexed
+	for q.Backpressure() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = q.InsertBatch(testWriteBatch(testWriteBatchEntry(testID(3),
+		testTags(3), time.Time{}, callback)))
+	require.NoError(t, err)
+}
+
 func TestIndexInsertQueueBatchBackoff(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
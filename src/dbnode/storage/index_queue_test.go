@@ -32,7 +32,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	m3ninxidx "github.com/m3db/m3/src/m3ninx/idx"
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3x/context"
+	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
 
@@ -323,3 +325,68 @@ func TestNamespaceIndexInsertQuery(t *testing.T) {
 		ident.MustNewTagStringsIterator("name", "value")).Matches(
 		ident.NewTagsIterator(tags)))
 }
+
+func TestNamespaceIndexInsertQueryNumericRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer leaktest.CheckTimeout(t, 2*time.Second)()
+
+	newFn := func(fn nsIndexInsertBatchFn, nowFn clock.NowFn, s tally.Scope) namespaceIndexInsertQueue {
+		q := newNamespaceIndexInsertQueue(fn, nowFn, s)
+		q.(*nsIndexInsertQueue).indexBatchBackoff = 10 * time.Millisecond
+		return q
+	}
+	nsOpts := defaultTestNs1Opts.SetIndexOptions(defaultTestNs1Opts.IndexOptions().
+		SetNumericTags([]string{"port"}))
+	md, err := namespace.NewMetadata(defaultTestNs1ID, nsOpts)
+	require.NoError(t, err)
+	idx, err := newNamespaceIndexWithInsertQueueFn(md, newFn, testDatabaseOptions().
+		SetIndexOptions(testNamespaceIndexOptions().SetInsertMode(index.InsertSync)))
+	assert.NoError(t, err)
+	defer idx.Close()
+
+	var (
+		blockSize  = idx.(*nsIndex).blockSize
+		indexState = idx.(*nsIndex).state
+		ts         = indexState.latestBlock.StartTime()
+		now        = time.Now()
+		id         = ident.StringID("foo")
+		tags       = ident.NewTags(
+			ident.StringTag("name", "value"),
+			ident.StringTag("port", "8080"),
+		)
+		ctx          = context.NewContext()
+		lifecycleFns = index.NewMockOnIndexSeries(ctrl)
+		queryOpts    = index.QueryOptions{
+			StartInclusive: now.Add(-1 * time.Minute),
+			EndExclusive:   now.Add(1 * time.Minute),
+		}
+	)
+
+	lifecycleFns.EXPECT().OnIndexFinalize(xtime.ToUnixNano(ts))
+	lifecycleFns.EXPECT().OnIndexSuccess(xtime.ToUnixNano(ts))
+
+	entry, doc := testWriteBatchEntry(id, tags, now, lifecycleFns)
+	batch := testWriteBatch(entry, doc, testWriteBatchBlockSizeOption(blockSize))
+	assert.NoError(t, idx.WriteBatch(batch))
+
+	rangeQuery := m3ninxidx.NewNumericRangeQuery([]byte("port"),
+		m3ninxindex.NewNumericRangeGreaterThan(8000, true))
+	res, err := idx.Query(ctx, index.Query{rangeQuery}, queryOpts)
+	require.NoError(t, err)
+	_, ok := res.Results.Map().Get(ident.StringID("foo"))
+	assert.True(t, ok)
+
+	rangeQuery = m3ninxidx.NewNumericRangeQuery([]byte("port"),
+		m3ninxindex.NewNumericRangeLessThan(8000, false))
+	res, err = idx.Query(ctx, index.Query{rangeQuery}, queryOpts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.Results.Size())
+
+	// Range queries against tags not declared numeric are rejected.
+	rangeQuery = m3ninxidx.NewNumericRangeQuery([]byte("name"),
+		m3ninxindex.NewNumericRangeGreaterThan(8000, true))
+	_, err = idx.Query(ctx, index.Query{m3ninxidx.NewConjunctionQuery(rangeQuery)}, queryOpts)
+	require.Error(t, err)
+	assert.True(t, xerrors.IsInvalidParams(err))
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
@@ -83,6 +84,51 @@ func TestNamespaceIndexCleanupExpiredFilesetsWithBlocks(t *testing.T) {
 	require.NoError(t, idx.CleanupExpiredFileSets(now))
 }
 
+func TestNamespaceIndexCompactFilesets(t *testing.T) {
+	md := testNamespaceMetadata(time.Hour, time.Hour*8)
+	opts := testDatabaseOptions()
+	opts = opts.SetIndexOptions(opts.IndexOptions().
+		SetFileSetCompactionMinVolumes(2))
+	nsIdx, err := newNamespaceIndex(md, opts)
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Hour)
+	idx := nsIdx.(*nsIndex)
+
+	var (
+		expired   = now.Add(-time.Hour * 9)
+		flushed   = now.Add(-time.Hour * 2)
+		singleVol = now.Add(-time.Hour * 3)
+		infoFiles []fs.ReadIndexInfoFileResult
+	)
+	for _, blockStart := range []time.Time{expired, expired, flushed, flushed, singleVol, now, now} {
+		infoFiles = append(infoFiles, fs.ReadIndexInfoFileResult{
+			ID:  fs.FileSetFileIdentifier{BlockStart: blockStart},
+			Err: nilReadInfoFileResultError{},
+		})
+	}
+	idx.readIndexInfoFilesFn = func(string, ident.ID, int) []fs.ReadIndexInfoFileResult {
+		return infoFiles
+	}
+
+	var compacted []time.Time
+	idx.compactIndexFileSetsFn = func(
+		opts fs.CompactIndexFileSetsOptions,
+	) (fs.CompactIndexFileSetsResult, error) {
+		require.Equal(t, 2, opts.MinVolumes)
+		compacted = append(compacted, opts.BlockStart)
+		return fs.CompactIndexFileSetsResult{Compacted: true, VolumesCompacted: 2}, nil
+	}
+	require.NoError(t, idx.CompactFileSets(now))
+	require.Equal(t, 1, len(compacted))
+	require.True(t, flushed.Equal(compacted[0]))
+}
+
+type nilReadInfoFileResultError struct{}
+
+func (e nilReadInfoFileResultError) Error() error     { return nil }
+func (e nilReadInfoFileResultError) Filepath() string { return "" }
+
 func TestNamespaceIndexFlushSuccess(t *testing.T) {
 	ctrl := gomock.NewController(xtest.Reporter{t})
 	defer ctrl.Finish()
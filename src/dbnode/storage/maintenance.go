@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errMaintenanceDatabaseDraining = errors.New("database is draining")
+	errMaintenanceIndexDisabled    = errors.New("namespace index is not enabled")
+	errMaintenanceCleanupDisabled  = errors.New("namespace cleanup is not enabled")
+)
+
+// maintenanceFn performs a file operation for the shards of a namespace.
+type maintenanceFn func(n databaseNamespace, shards []databaseShard) error
+
+// maintenanceManager performs the flushes, index compactions and cleanups of
+// a single namespace on demand, it has flush and cleanup managers of its own
+// so that the file operations that follow ticks are left undisturbed.
+type maintenanceManager struct {
+	sync.Mutex
+
+	flush   *flushManager
+	cleanup *cleanupManager
+}
+
+func newMaintenanceManager(database database, scope tally.Scope) *maintenanceManager {
+	return &maintenanceManager{
+		flush:   newFlushManager(database, scope).(*flushManager),
+		cleanup: newCleanupManager(database, scope).(*cleanupManager),
+	}
+}
+
+func (d *db) ForceFlush(namespace ident.ID, shards []uint32) ([]uint32, error) {
+	return d.maintain(namespace, shards, func(n databaseNamespace, shards []databaseShard) error {
+		// Blocks may only become flushable once a tick has completed after
+		// they were sealed and the shards were bootstrapped, see mediator.Tick.
+		tickStart, err := d.mediator.ForceTick()
+		if err != nil {
+			return err
+		}
+		states := make(ShardBootstrapStates, len(shards))
+		for _, shard := range shards {
+			states[shard.ID()] = Bootstrapped
+		}
+		return d.maintenance.flush.flushNamespace(n, states, tickStart)
+	})
+}
+
+func (d *db) ForceCompact(namespace ident.ID) error {
+	_, err := d.maintain(namespace, nil, func(n databaseNamespace, _ []databaseShard) error {
+		if !n.Options().IndexOptions().Enabled() {
+			return errMaintenanceIndexDisabled
+		}
+		idx, err := n.GetIndex()
+		if err != nil {
+			return err
+		}
+		return idx.CompactFileSets(d.nowFn())
+	})
+	return err
+}
+
+func (d *db) Prune(namespace ident.ID, shards []uint32) ([]uint32, error) {
+	// Index filesets span every shard of the namespace so they are only
+	// pruned along with all of its shards.
+	includeIndex := len(shards) == 0
+	return d.maintain(namespace, shards, func(n databaseNamespace, shards []databaseShard) error {
+		if !n.Options().CleanupEnabled() {
+			return errMaintenanceCleanupDisabled
+		}
+		return d.maintenance.cleanup.cleanupNamespace(n, shards, includeIndex, d.nowFn())
+	})
+}
+
+// maintain performs a file operation for the given shards of a namespace, or
+// all of its bootstrapped shards if none are given, once the file operations
+// in progress have completed and prevents further ones from starting until it
+// returns, it returns the shards the file operation was performed for.
+func (d *db) maintain(
+	namespace ident.ID,
+	shardIDs []uint32,
+	fn maintenanceFn,
+) ([]uint32, error) {
+	d.RLock()
+	mediator, draining := d.mediator, d.draining
+	d.RUnlock()
+
+	if draining {
+		return nil, errMaintenanceDatabaseDraining
+	}
+	if !mediator.IsBootstrapped() {
+		return nil, errDatabaseNotBootstrapped
+	}
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+	shards, err := maintenanceShards(n, shardIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	d.maintenance.Lock()
+	defer d.maintenance.Unlock()
+
+	// Wait for any in progress file operations and prevent further ones from
+	// starting, unless the database started draining in the meantime as file
+	// operations remain disabled once it is drained.
+	mediator.DisableFileOps()
+	defer func() {
+		if !d.IsDraining() {
+			mediator.EnableFileOps()
+		}
+	}()
+
+	if err := fn(n, shards); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint32, 0, len(shards))
+	for _, shard := range shards {
+		ids = append(ids, shard.ID())
+	}
+	return ids, nil
+}
+
+// maintenanceShards returns the given shards of the namespace, or all of its
+// bootstrapped shards if none are given, the given shards must be owned by
+// the namespace and bootstrapped.
+func maintenanceShards(n databaseNamespace, shardIDs []uint32) ([]databaseShard, error) {
+	owned := n.GetOwnedShards()
+	if len(shardIDs) == 0 {
+		shards := make([]databaseShard, 0, len(owned))
+		for _, shard := range owned {
+			if shard.IsBootstrapped() {
+				shards = append(shards, shard)
+			}
+		}
+		return shards, nil
+	}
+
+	byID := make(map[uint32]databaseShard, len(owned))
+	for _, shard := range owned {
+		byID[shard.ID()] = shard
+	}
+	var (
+		shards = make([]databaseShard, 0, len(shardIDs))
+		seen   = make(map[uint32]struct{}, len(shardIDs))
+	)
+	for _, id := range shardIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		shard, ok := byID[id]
+		if !ok {
+			return nil, xerrors.NewInvalidParamsError(fmt.Errorf(
+				"shard %d is not owned by namespace %s", id, n.ID()))
+		}
+		if !shard.IsBootstrapped() {
+			return nil, fmt.Errorf("shard %d of namespace %s is not bootstrapped", id, n.ID())
+		}
+		shards = append(shards, shard)
+	}
+	return shards, nil
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMaintenanceTestShards(ctrl *gomock.Controller, bootstrapped ...bool) []databaseShard {
+	shards := make([]databaseShard, 0, len(bootstrapped))
+	for i, b := range bootstrapped {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(uint32(i)).AnyTimes()
+		shard.EXPECT().IsBootstrapped().Return(b).AnyTimes()
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+func maintenanceShardIDs(shards []databaseShard) []uint32 {
+	ids := make([]uint32, 0, len(shards))
+	for _, shard := range shards {
+		ids = append(ids, shard.ID())
+	}
+	return ids
+}
+
+func TestMaintenanceShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(ident.StringID("testns")).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return(newMaintenanceTestShards(ctrl, true, false, true)).AnyTimes()
+
+	shards, err := maintenanceShards(ns, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0, 2}, maintenanceShardIDs(shards))
+
+	shards, err = maintenanceShards(ns, []uint32{2, 0, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{2, 0}, maintenanceShardIDs(shards))
+
+	_, err = maintenanceShards(ns, []uint32{1})
+	require.Error(t, err)
+	assert.False(t, xerrors.IsInvalidParams(err))
+
+	_, err = maintenanceShards(ns, []uint32{3})
+	require.Error(t, err)
+	assert.True(t, xerrors.IsInvalidParams(err))
+}
+
+func TestDatabaseMaintenanceRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	mediator.EXPECT().IsBootstrapped().Return(false)
+	_, err := d.ForceFlush(ident.StringID("testns"), nil)
+	require.Equal(t, errDatabaseNotBootstrapped, err)
+
+	mediator.EXPECT().IsBootstrapped().Return(true)
+	_, err = d.Prune(ident.StringID("unknown"), nil)
+	require.Error(t, err)
+	assert.True(t, xerrors.IsInvalidParams(err))
+
+	d.draining = true
+	require.Equal(t, errMaintenanceDatabaseDraining, d.ForceCompact(ident.StringID("testns")))
+}
+
+func TestDatabaseForceCompact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	ns.EXPECT().GetOwnedShards().Return(newMaintenanceTestShards(ctrl, true)).AnyTimes()
+	ns.EXPECT().Options().Return(namespace.NewOptions().
+		SetIndexOptions(namespace.NewIndexOptions().SetEnabled(true)))
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns.EXPECT().GetIndex().Return(idx, nil)
+
+	gomock.InOrder(
+		mediator.EXPECT().IsBootstrapped().Return(true),
+		mediator.EXPECT().DisableFileOps(),
+		idx.EXPECT().CompactFileSets(gomock.Any()).Return(nil),
+		mediator.EXPECT().EnableFileOps(),
+	)
+	require.NoError(t, d.ForceCompact(ident.StringID("testns")))
+}
+
+func TestDatabasePruneCleanupDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	ns.EXPECT().GetOwnedShards().Return(newMaintenanceTestShards(ctrl, true, true)).AnyTimes()
+	ns.EXPECT().Options().Return(namespace.NewOptions().SetCleanupEnabled(false))
+
+	gomock.InOrder(
+		mediator.EXPECT().IsBootstrapped().Return(true),
+		mediator.EXPECT().DisableFileOps(),
+		mediator.EXPECT().EnableFileOps(),
+	)
+	_, err := d.Prune(ident.StringID("testns"), []uint32{1})
+	require.Equal(t, errMaintenanceCleanupDisabled, err)
+}
+
+func TestDatabaseForceFlushTickError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	ns.EXPECT().GetOwnedShards().Return(newMaintenanceTestShards(ctrl, true)).AnyTimes()
+
+	tickErr := errors.New("tick error")
+	gomock.InOrder(
+		mediator.EXPECT().IsBootstrapped().Return(true),
+		mediator.EXPECT().DisableFileOps(),
+		mediator.EXPECT().ForceTick().Return(time.Time{}, tickErr),
+		mediator.EXPECT().EnableFileOps(),
+	)
+	_, err := d.ForceFlush(ident.StringID("testns"), nil)
+	require.Equal(t, tickErr, err)
+}
@@ -71,6 +71,8 @@ type mediator struct {
 	databaseTickManager
 	databaseRepairer
 
+	consistencyChecker databaseConsistencyChecker
+
 	opts     Options
 	nowFn    clock.NowFn
 	sleepFn  sleepFn
@@ -95,12 +97,20 @@ func newMediator(database database, opts Options) (databaseMediator, error) {
 	d.databaseFileSystemManager = fsm
 
 	d.databaseRepairer = newNoopDatabaseRepairer()
+	d.consistencyChecker = newNoopDatabaseConsistencyChecker()
 	if opts.RepairEnabled() {
 		var err error
 		d.databaseRepairer, err = newDatabaseRepairer(database, opts)
 		if err != nil {
 			return nil, err
 		}
+
+		if opts.RepairOptions().ConsistencyCheckInterval() > 0 {
+			d.consistencyChecker, err = newDatabaseConsistencyChecker(database, opts)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	d.databaseTickManager = newTickManager(database, opts)
@@ -118,6 +128,7 @@ func (m *mediator) Open() error {
 	go m.reportLoop()
 	go m.ongoingTick()
 	m.databaseRepairer.Start()
+	m.consistencyChecker.Start()
 	return nil
 }
 
@@ -169,6 +180,14 @@ func (m *mediator) Tick(runType runType, forceType forceType) error {
 	return nil
 }
 
+func (m *mediator) ForceTick() (time.Time, error) {
+	tickStart := m.nowFn()
+	if err := m.databaseTickManager.Tick(force, tickStart); err != nil {
+		return time.Time{}, err
+	}
+	return tickStart, nil
+}
+
 func (m *mediator) Report() {
 	m.databaseBootstrapManager.Report()
 	m.databaseRepairer.Report()
@@ -187,6 +206,7 @@ func (m *mediator) Close() error {
 	m.state = mediatorClosed
 	close(m.closedCh)
 	m.databaseRepairer.Stop()
+	m.consistencyChecker.Stop()
 	return nil
 }
 
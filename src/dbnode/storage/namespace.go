@@ -36,11 +36,14 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/ts"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3cluster/shard"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
@@ -102,6 +105,7 @@ type dbNamespace struct {
 	shutdownCh         chan struct{}
 	id                 ident.ID
 	shardSet           sharding.ShardSet
+	shardFn            sharding.HashFn
 	blockRetriever     block.DatabaseBlockRetriever
 	namespaceReaderMgr databaseNamespaceReaderManager
 	opts               Options
@@ -120,6 +124,15 @@ type dbNamespace struct {
 	increasingIndex increasingIndex
 	commitLogWriter commitLogWriter
 	reverseIndex    namespaceIndex
+	quota           *namespaceQuota
+	bytes           *namespaceBytes
+	writePatterns   *namespaceWritePatterns
+	newSeries       *namespaceNewSeriesSubscriptions
+
+	// The timestamp precision of the namespace and its time unit, writes with
+	// finer timestamps are truncated to the precision when written.
+	timestampPrecision     time.Duration
+	timestampPrecisionUnit xtime.Unit
 
 	tickWorkers            xsync.WorkerPool
 	tickWorkersConcurrency int
@@ -130,9 +143,11 @@ type dbNamespace struct {
 
 type databaseNamespaceStatsLastTick struct {
 	sync.RWMutex
-	activeSeries int64
-	activeBlocks int64
-	index        databaseNamespaceIndexStatsLastTick
+	activeSeries  int64
+	createdSeries int64
+	expiredSeries int64
+	activeBlocks  int64
+	index         databaseNamespaceIndexStatsLastTick
 }
 
 type databaseNamespaceIndexStatsLastTick struct {
@@ -148,6 +163,8 @@ type databaseNamespaceMetrics struct {
 	snapshot            instrument.MethodMetrics
 	write               instrument.MethodMetrics
 	writeTagged         instrument.MethodMetrics
+	writeBatch          instrument.MethodMetrics
+	writeTaggedBatch    instrument.MethodMetrics
 	read                instrument.MethodMetrics
 	fetchBlocks         instrument.MethodMetrics
 	fetchBlocksMetadata instrument.MethodMetrics
@@ -155,19 +172,22 @@ type databaseNamespaceMetrics struct {
 	unfulfilled         tally.Counter
 	bootstrapStart      tally.Counter
 	bootstrapEnd        tally.Counter
+	cloneErrors         tally.Counter
 	shards              databaseNamespaceShardMetrics
 	tick                databaseNamespaceTickMetrics
 	status              databaseNamespaceStatusMetrics
 }
 
 type databaseNamespaceShardMetrics struct {
-	add         tally.Counter
-	close       tally.Counter
-	closeErrors tally.Counter
+	add          tally.Counter
+	close        tally.Counter
+	closeErrors  tally.Counter
+	stripeErrors tally.Counter
 }
 
 type databaseNamespaceTickMetrics struct {
 	activeSeries           tally.Gauge
+	createdSeries          tally.Counter
 	expiredSeries          tally.Counter
 	activeBlocks           tally.Gauge
 	openBlocks             tally.Gauge
@@ -193,10 +213,14 @@ type databaseNamespaceIndexTickMetrics struct {
 // so that summing the value of gauges across hosts when graphed summarizing
 // values at the same fixed intervals can show meaningful results (vs variably
 // emitted values that can be aggregated across hosts to see a snapshot).
+// The created and expired series gauges report the counts observed during
+// the last tick interval, estimating the churn of series in the namespace.
 type databaseNamespaceStatusMetrics struct {
-	activeSeries tally.Gauge
-	activeBlocks tally.Gauge
-	index        databaseNamespaceIndexStatusMetrics
+	activeSeries  tally.Gauge
+	createdSeries tally.Gauge
+	expiredSeries tally.Gauge
+	activeBlocks  tally.Gauge
+	index         databaseNamespaceIndexStatusMetrics
 }
 
 type databaseNamespaceIndexStatusMetrics struct {
@@ -218,6 +242,8 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		snapshot:            instrument.NewMethodMetrics(scope, "snapshot", samplingRate),
 		write:               instrument.NewMethodMetrics(scope, "write", samplingRate),
 		writeTagged:         instrument.NewMethodMetrics(scope, "write-tagged", samplingRate),
+		writeBatch:          instrument.NewMethodMetrics(scope, "write-batch", samplingRate),
+		writeTaggedBatch:    instrument.NewMethodMetrics(scope, "write-tagged-batch", samplingRate),
 		read:                instrument.NewMethodMetrics(scope, "read", samplingRate),
 		fetchBlocks:         instrument.NewMethodMetrics(scope, "fetchBlocks", samplingRate),
 		fetchBlocksMetadata: instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", samplingRate),
@@ -225,13 +251,16 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		unfulfilled:         scope.Counter("bootstrap.unfulfilled"),
 		bootstrapStart:      scope.Counter("bootstrap.start"),
 		bootstrapEnd:        scope.Counter("bootstrap.end"),
+		cloneErrors:         scope.Counter("clone-errors"),
 		shards: databaseNamespaceShardMetrics{
-			add:         shardsScope.Counter("add"),
-			close:       shardsScope.Counter("close"),
-			closeErrors: shardsScope.Counter("close-errors"),
+			add:          shardsScope.Counter("add"),
+			close:        shardsScope.Counter("close"),
+			closeErrors:  shardsScope.Counter("close-errors"),
+			stripeErrors: shardsScope.Counter("stripe-errors"),
 		},
 		tick: databaseNamespaceTickMetrics{
 			activeSeries:           tickScope.Gauge("active-series"),
+			createdSeries:          tickScope.Counter("created-series"),
 			expiredSeries:          tickScope.Counter("expired-series"),
 			activeBlocks:           tickScope.Gauge("active-blocks"),
 			openBlocks:             tickScope.Gauge("open-blocks"),
@@ -251,8 +280,10 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 			},
 		},
 		status: databaseNamespaceStatusMetrics{
-			activeSeries: statusScope.Gauge("active-series"),
-			activeBlocks: statusScope.Gauge("active-blocks"),
+			activeSeries:  statusScope.Gauge("active-series"),
+			createdSeries: statusScope.Gauge("created-series"),
+			expiredSeries: statusScope.Gauge("expired-series"),
+			activeBlocks:  statusScope.Gauge("active-blocks"),
 			index: databaseNamespaceIndexStatusMetrics{
 				numDocs:     indexStatusScope.Gauge("num-docs"),
 				numBlocks:   indexStatusScope.Gauge("num-blocks"),
@@ -283,10 +314,13 @@ func newDatabaseNamespace(
 	iops = iops.SetLogger(logger)
 	opts = opts.SetInstrumentOptions(iops)
 
-	scope := iops.MetricsScope().SubScope("database").
-		Tagged(map[string]string{
-			"namespace": id.String(),
-		})
+	if nopts.InMemoryOnly() {
+		logger.Warn("namespace is in-memory only, its data is never flushed " +
+			"or written to the commit log and is lost if every replica restarts")
+	}
+
+	scope := xmetrics.NamespaceScope(
+		xmetrics.SubsystemScope(iops.MetricsScope(), xmetrics.SubsystemDatabase), id.String())
 
 	tickWorkersConcurrency := int(math.Max(1, float64(runtime.NumCPU())/8))
 	tickWorkers := xsync.NewWorkerPool(tickWorkersConcurrency)
@@ -300,10 +334,14 @@ func newDatabaseNamespace(
 			metadata.ID().String(), err)
 	}
 
-	var (
-		index namespaceIndex
-		err   error
-	)
+	timestampPrecisionUnit, err := xtime.UnitFromDuration(nopts.TimestampPrecision())
+	if err != nil {
+		return nil, fmt.Errorf(
+			"unable to create namespace %v, invalid timestamp precision: %v",
+			metadata.ID().String(), err)
+	}
+
+	var index namespaceIndex
 	if metadata.Options().IndexOptions().Enabled() {
 		index, err = newNamespaceIndex(metadata, opts)
 		if err != nil {
@@ -327,17 +365,43 @@ func newDatabaseNamespace(
 		increasingIndex:        increasingIndex,
 		commitLogWriter:        commitLogWriter,
 		reverseIndex:           index,
+		quota:                  newNamespaceQuota(metadata, opts, scope),
+		bytes:                  newNamespaceBytes(metadata, opts.ClockOptions().NowFn()(), scope),
+		writePatterns:          newNamespaceWritePatterns(opts.ClockOptions().NowFn()),
+		newSeries:              newNamespaceNewSeriesSubscriptions(scope),
+		timestampPrecision:     nopts.TimestampPrecision(),
+		timestampPrecisionUnit: timestampPrecisionUnit,
 		tickWorkers:            tickWorkers,
 		tickWorkersConcurrency: tickWorkersConcurrency,
 		metrics:                newDatabaseNamespaceMetrics(scope, iops.MetricsSamplingRate()),
 	}
 
+	n.shardFn = n.newShardFn(shardSet)
+	n.cloneFileSets()
 	n.initShards(nopts.BootstrapEnabled())
 	go n.reportStatusLoop()
 
 	return n, nil
 }
 
+// newShardFn returns the function that assigns series IDs to shards using the
+// hash strategy of the namespace, if the strategy cannot be used with the
+// shard set then the shard set hash function is used instead.
+func (n *dbNamespace) newShardFn(shardSet sharding.ShardSet) sharding.HashFn {
+	sopts := n.nopts.ShardingOptions()
+	fn, err := sharding.NewStrategyHashFn(shardSet, sopts.HashStrategy(),
+		sopts.TagNames())
+	if err != nil {
+		n.log.WithFields(
+			xlog.NewField("namespace", n.id.String()),
+			xlog.NewField("hashStrategy", sopts.HashStrategy().String()),
+			xlog.NewField("error", err.Error()),
+		).Error("unable to use namespace hash strategy, using shard set hash function")
+		return shardSet.HashFn()
+	}
+	return fn
+}
+
 func (n *dbNamespace) reportStatusLoop() {
 	reportInterval := n.opts.InstrumentOptions().ReportInterval()
 	ticker := time.NewTicker(reportInterval)
@@ -349,6 +413,8 @@ func (n *dbNamespace) reportStatusLoop() {
 		case <-ticker.C:
 			n.statsLastTick.RLock()
 			n.metrics.status.activeSeries.Update(float64(n.statsLastTick.activeSeries))
+			n.metrics.status.createdSeries.Update(float64(n.statsLastTick.createdSeries))
+			n.metrics.status.expiredSeries.Update(float64(n.statsLastTick.expiredSeries))
 			n.metrics.status.activeBlocks.Update(float64(n.statsLastTick.activeBlocks))
 			n.metrics.status.index.numDocs.Update(float64(n.statsLastTick.index.numDocs))
 			n.metrics.status.index.numBlocks.Update(float64(n.statsLastTick.index.numBlocks))
@@ -374,6 +440,12 @@ func (n *dbNamespace) NumSeries() int64 {
 	return count
 }
 
+func (n *dbNamespace) WritePatterns() WritePatterns {
+	patterns := n.writePatterns.WritePatterns()
+	patterns.NumSeries = n.NumSeries()
+	return patterns
+}
+
 func (n *dbNamespace) Shards() []Shard {
 	n.RLock()
 	shards := n.shardSet.AllIDs()
@@ -395,6 +467,8 @@ func (n *dbNamespace) AssignShardSet(shardSet sharding.ShardSet) {
 		incoming[shard] = struct{}{}
 	}
 
+	n.stripeShardDirectories(shardSet.AllIDs())
+
 	n.Lock()
 	existing = n.shards
 	for _, shard := range existing {
@@ -406,19 +480,21 @@ func (n *dbNamespace) AssignShardSet(shardSet sharding.ShardSet) {
 		}
 	}
 	n.shardSet = shardSet
+	n.shardFn = n.newShardFn(shardSet)
 	n.shards = make([]databaseShard, n.shardSet.Max()+1)
 	for _, shard := range n.shardSet.AllIDs() {
 		if int(shard) < len(existing) && existing[shard] != nil {
 			n.shards[shard] = existing[shard]
 		} else {
 			bootstrapEnabled := n.nopts.BootstrapEnabled()
-			n.shards[shard] = newDatabaseShard(n.metadata, shard, n.blockRetriever,
-				n.namespaceReaderMgr, n.increasingIndex, n.commitLogWriter, n.reverseIndex,
-				bootstrapEnabled, n.opts, n.seriesOpts)
+			n.shards[shard] = n.newShardWithLock(shard, bootstrapEnabled)
 			n.metrics.shards.add.Inc(1)
 		}
 	}
 	n.Unlock()
+	// Blocks that already started may hold data of the new shards that was
+	// not written to the namespace.
+	n.bytes.resetSince(n.nowFn())
 	n.closeShards(closing, false)
 }
 
@@ -461,6 +537,9 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 	// Allow the reader cache to tick
 	n.namespaceReaderMgr.tick()
 
+	// Account for the bytes on disk used by the namespace
+	n.quota.tick()
+
 	// Fetch the owned shards
 	shards := n.GetOwnedShards()
 	if len(shards) == 0 {
@@ -515,6 +594,8 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 
 	n.statsLastTick.Lock()
 	n.statsLastTick.activeSeries = int64(r.activeSeries)
+	n.statsLastTick.createdSeries = int64(r.createdSeries)
+	n.statsLastTick.expiredSeries = int64(r.expiredSeries)
 	n.statsLastTick.activeBlocks = int64(r.activeBlocks)
 	n.statsLastTick.index = databaseNamespaceIndexStatsLastTick{
 		numDocs:     indexTickResults.NumTotalDocs,
@@ -524,6 +605,7 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 	n.statsLastTick.Unlock()
 
 	n.metrics.tick.activeSeries.Update(float64(r.activeSeries))
+	n.metrics.tick.createdSeries.Inc(int64(r.createdSeries))
 	n.metrics.tick.expiredSeries.Inc(int64(r.expiredSeries))
 	n.metrics.tick.activeBlocks.Update(float64(r.activeBlocks))
 	n.metrics.tick.openBlocks.Update(float64(r.openBlocks))
@@ -552,12 +634,21 @@ func (n *dbNamespace) Write(
 	annotation []byte,
 ) error {
 	callStart := n.nowFn()
+	if err := n.quota.checkWrite(); err != nil {
+		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
+		return err
+	}
 	shard, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
 		return err
 	}
+	timestamp, unit = n.withTimestampPrecision(timestamp, unit)
+	n.writePatterns.recordWrite(id, timestamp)
 	err = shard.Write(ctx, id, timestamp, value, unit, annotation)
+	if err == nil {
+		n.bytes.recordWrite(timestamp, annotation)
+	}
 	n.metrics.write.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
 	return err
 }
@@ -576,16 +667,125 @@ func (n *dbNamespace) WriteTagged(
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
 		return errNamespaceIndexingDisabled
 	}
+	if err := n.quota.checkWrite(); err != nil {
+		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
+		return err
+	}
 	shard, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
 		return err
 	}
+	timestamp, unit = n.withTimestampPrecision(timestamp, unit)
+	n.writePatterns.recordWrite(id, timestamp)
 	err = shard.WriteTagged(ctx, id, tags, timestamp, value, unit, annotation)
+	if err == nil {
+		n.bytes.recordWrite(timestamp, annotation)
+	}
 	n.metrics.writeTagged.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
 	return err
 }
 
+func (n *dbNamespace) WriteBatch(
+	ctx context.Context,
+	writes []WriteBatchEntry,
+) error {
+	callStart := n.nowFn()
+	err := n.writeBatch(ctx, writes, false)
+	n.metrics.writeBatch.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+	return err
+}
+
+func (n *dbNamespace) WriteTaggedBatch(
+	ctx context.Context,
+	writes []WriteBatchEntry,
+) error {
+	callStart := n.nowFn()
+	if n.reverseIndex == nil { // only happens if indexing is enabled.
+		n.metrics.writeTaggedBatch.ReportError(n.nowFn().Sub(callStart))
+		return errNamespaceIndexingDisabled
+	}
+	err := n.writeBatch(ctx, writes, true)
+	n.metrics.writeTaggedBatch.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+	return err
+}
+
+// withTimestampPrecision returns the timestamp and unit of a write at the
+// timestamp precision of the namespace, writes with a unit finer than the
+// precision are truncated to it and take the unit of the precision so that
+// the encoder uses the cheaper time encoding scheme of the coarser unit.
+func (n *dbNamespace) withTimestampPrecision(
+	timestamp time.Time,
+	unit xtime.Unit,
+) (time.Time, xtime.Unit) {
+	unitDuration, err := unit.Value()
+	if err != nil || unitDuration >= n.timestampPrecision {
+		return timestamp, unit
+	}
+	return timestamp.Truncate(n.timestampPrecision), n.timestampPrecisionUnit
+}
+
+type namespaceShardWrites struct {
+	shard databaseShard
+	idxs  []int
+}
+
+// writeBatch groups the writes by shard, resolving the shards of all writes
+// under a single lock acquisition, and writes each group as a single batch.
+func (n *dbNamespace) writeBatch(
+	ctx context.Context,
+	writes []WriteBatchEntry,
+	tagged bool,
+) error {
+	if err := n.quota.checkWrite(); err != nil {
+		return err
+	}
+
+	var (
+		groups        []namespaceShardWrites
+		groupsByShard = make(map[uint32]int)
+	)
+	n.RLock()
+	for i := range writes {
+		shardID := n.shardFn(writes[i].ID)
+		shard, err := n.shardAtWithRLock(shardID)
+		if err != nil {
+			writes[i].Err = err
+			continue
+		}
+		g, ok := groupsByShard[shardID]
+		if !ok {
+			g = len(groups)
+			groupsByShard[shardID] = g
+			groups = append(groups, namespaceShardWrites{shard: shard})
+		}
+		groups[g].idxs = append(groups[g].idxs, i)
+	}
+	n.RUnlock()
+
+	for _, group := range groups {
+		batch := make([]WriteBatchEntry, 0, len(group.idxs))
+		for _, i := range group.idxs {
+			write := writes[i]
+			write.Timestamp, write.Unit = n.withTimestampPrecision(write.Timestamp, write.Unit)
+			n.writePatterns.recordWrite(write.ID, write.Timestamp)
+			batch = append(batch, write)
+		}
+		if tagged {
+			group.shard.WriteTaggedBatch(ctx, batch)
+		} else {
+			group.shard.WriteBatch(ctx, batch)
+		}
+		for j, i := range group.idxs {
+			writes[i].Err = batch[j].Err
+			if batch[j].Err == nil {
+				n.bytes.recordWrite(batch[j].Timestamp, batch[j].Annotation)
+			}
+		}
+	}
+	return nil
+}
+
 func (n *dbNamespace) QueryIDs(
 	ctx context.Context,
 	query index.Query,
@@ -596,11 +796,19 @@ func (n *dbNamespace) QueryIDs(
 		n.metrics.queryIDs.ReportError(n.nowFn().Sub(callStart))
 		return index.QueryResults{}, errNamespaceIndexingDisabled
 	}
+	n.writePatterns.recordQuery(opts.StartInclusive, opts.EndExclusive)
 	res, err := n.reverseIndex.Query(ctx, query, opts)
 	n.metrics.queryIDs.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
 	return res, err
 }
 
+func (n *dbNamespace) SubscribeNewSeries(
+	query index.Query,
+	opts NewSeriesSubscriptionOptions,
+) (NewSeriesSubscription, error) {
+	return n.newSeries.subscribe(query, opts)
+}
+
 func (n *dbNamespace) ReadEncoded(
 	ctx context.Context,
 	id ident.ID,
@@ -612,6 +820,7 @@ func (n *dbNamespace) ReadEncoded(
 		n.metrics.read.ReportError(n.nowFn().Sub(callStart))
 		return nil, err
 	}
+	n.writePatterns.recordQuery(start, end)
 	res, err := shard.ReadEncoded(ctx, id, start, end)
 	n.metrics.read.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
 	return res, err
@@ -831,8 +1040,12 @@ func (n *dbNamespace) Flush(
 		return fmt.Errorf("failed to flush at time %v, not aligned to blockSize", blockStart.String())
 	}
 
-	multiErr := xerrors.NewMultiError()
-	shards := n.GetOwnedShards()
+	var (
+		multiErr      = xerrors.NewMultiError()
+		shards        = n.GetOwnedShards()
+		countingFlush = newBytesCountingDataFlush(flush)
+		complete      = true
+	)
 	for _, shard := range shards {
 		// This is different than calling shard.IsBootstrapped() because it was determined
 		// before the start of the tick that preceded this flush, meaning it can be reliably
@@ -844,16 +1057,18 @@ func (n *dbNamespace) Flush(
 			// before the previous tick which means that we have no guarantee that all
 			// bootstrapped blocks have been rotated out of the series buffer buckets,
 			// so we wait until the next opportunity.
+			complete = false
 			continue
 		}
 
 		// skip flushing if the shard has already flushed data for the `blockStart`
 		if s := shard.FlushState(blockStart); s.Status == fileOpSuccess {
+			complete = false
 			continue
 		}
 		// NB(xichen): we still want to proceed if a shard fails to flush its data.
 		// Probably want to emit a counter here, but for now just log it.
-		if err := shard.Flush(blockStart, flush); err != nil {
+		if err := shard.Flush(blockStart, countingFlush); err != nil {
 			detailedErr := fmt.Errorf("shard %d failed to flush data: %v",
 				shard.ID(), err)
 			multiErr = multiErr.Add(detailedErr)
@@ -861,10 +1076,33 @@ func (n *dbNamespace) Flush(
 	}
 
 	res := multiErr.FinalError()
+	n.bytes.recordFlush(blockStart, countingFlush.Bytes(), complete && res == nil)
 	n.metrics.flush.ReportSuccessOrError(res, n.nowFn().Sub(callStart))
 	return res
 }
 
+func (n *dbNamespace) AggregateBlock(
+	blockStart time.Time,
+	query namespace.ContinuousQuery,
+	fn continuousQueryFn,
+) error {
+	multiErr := xerrors.NewMultiError()
+	shards := n.GetOwnedShards()
+	for _, shard := range shards {
+		// Only shards that have flushed the block are guaranteed to hold all
+		// of its data, the others are aggregated once they have flushed it.
+		if s := shard.FlushState(blockStart); s.Status != fileOpSuccess {
+			continue
+		}
+		if err := shard.AggregateBlock(blockStart, query, fn); err != nil {
+			detailedErr := fmt.Errorf("shard %d failed to aggregate block: %v",
+				shard.ID(), err)
+			multiErr = multiErr.Add(detailedErr)
+		}
+	}
+	return multiErr.FinalError()
+}
+
 func (n *dbNamespace) FlushIndex(
 	flush persist.IndexFlush,
 ) error {
@@ -1141,7 +1379,7 @@ func (n *dbNamespace) GetIndex() (namespaceIndex, error) {
 
 func (n *dbNamespace) shardFor(id ident.ID) (databaseShard, error) {
 	n.RLock()
-	shardID := n.shardSet.Lookup(id)
+	shardID := n.shardFn(id)
 	shard, err := n.shardAtWithRLock(shardID)
 	n.RUnlock()
 	return shard, err
@@ -1149,7 +1387,7 @@ func (n *dbNamespace) shardFor(id ident.ID) (databaseShard, error) {
 
 func (n *dbNamespace) readableShardFor(id ident.ID) (databaseShard, error) {
 	n.RLock()
-	shardID := n.shardSet.Lookup(id)
+	shardID := n.shardFn(id)
 	shard, err := n.readableShardAtWithRLock(shardID)
 	n.RUnlock()
 	return shard, err
@@ -1166,13 +1404,13 @@ func (n *dbNamespace) shardAtWithRLock(shardID uint32) (databaseShard, error) {
 	// NB(r): These errors are retryable as they will occur
 	// during a topology change and must be retried by the client.
 	if int(shardID) >= len(n.shards) {
-		return nil, xerrors.NewRetryableError(
-			fmt.Errorf("not responsible for shard %d", shardID))
+		return nil, xerrors.NewRetryableError(m3dberrors.NewUnavailableError(
+			fmt.Errorf("not responsible for shard %d", shardID)))
 	}
 	shard := n.shards[shardID]
 	if shard == nil {
-		return nil, xerrors.NewRetryableError(
-			fmt.Errorf("not responsible for shard %d", shardID))
+		return nil, xerrors.NewRetryableError(m3dberrors.NewUnavailableError(
+			fmt.Errorf("not responsible for shard %d", shardID)))
 	}
 	return shard, nil
 }
@@ -1188,19 +1426,74 @@ func (n *dbNamespace) readableShardAtWithRLock(shardID uint32) (databaseShard, e
 	return shard, nil
 }
 
+// cloneFileSets links the filesets of the namespace this namespace is cloned
+// from, if any, before the shards are created so that they bootstrap from the
+// linked filesets.
+func (n *dbNamespace) cloneFileSets() {
+	source := n.nopts.CloneSource()
+	if source == "" {
+		return
+	}
+	fsOpts := n.opts.CommitLogOptions().FilesystemOptions()
+	cloned, err := fs.CloneNamespaceFileSets(fsOpts, ident.StringID(source), n.id)
+	if err != nil {
+		n.metrics.cloneErrors.Inc(1)
+		n.log.WithFields(
+			xlog.NewField("source", source),
+			xlog.NewField("error", err.Error()),
+		).Error("unable to clone filesets of source namespace")
+		return
+	}
+	if cloned {
+		n.log.WithFields(
+			xlog.NewField("source", source),
+		).Info("cloned filesets of source namespace")
+	}
+}
+
+// stripeShardDirectories stripes the shard directories across the data
+// volumes before the shards are created so that bootstrapping and flushing
+// shards always read and write their striped directories.
+func (n *dbNamespace) stripeShardDirectories(shards []uint32) {
+	fsOpts := n.opts.CommitLogOptions().FilesystemOptions()
+	if len(fsOpts.DataVolumes()) == 0 {
+		return
+	}
+	if err := fs.StripeShardDirectories(fsOpts, n.id, shards); err != nil {
+		n.metrics.shards.stripeErrors.Inc(1)
+		n.log.WithFields(
+			xlog.NewField("namespace", n.id.String()),
+			xlog.NewField("error", err.Error()),
+		).Error("unable to stripe shard directories across data volumes")
+	}
+}
+
 func (n *dbNamespace) initShards(needBootstrap bool) {
+	n.stripeShardDirectories(n.shardSet.AllIDs())
+
 	n.Lock()
 	shards := n.shardSet.AllIDs()
 	dbShards := make([]databaseShard, n.shardSet.Max()+1)
 	for _, shard := range shards {
-		dbShards[shard] = newDatabaseShard(n.metadata, shard, n.blockRetriever,
-			n.namespaceReaderMgr, n.increasingIndex, n.commitLogWriter, n.reverseIndex,
-			needBootstrap, n.opts, n.seriesOpts)
+		dbShards[shard] = n.newShardWithLock(shard, needBootstrap)
 	}
 	n.shards = dbShards
 	n.Unlock()
 }
 
+// newShardWithLock creates a shard of the current shard set, the writes to
+// a shard that is initializing are buffered until it is bootstrapped.
+func (n *dbNamespace) newShardWithLock(
+	shardID uint32,
+	needBootstrap bool,
+) databaseShard {
+	state, err := n.shardSet.LookupStateByID(shardID)
+	initializing := err == nil && state == shard.Initializing
+	return newDatabaseShard(n.metadata, shardID, n.blockRetriever,
+		n.namespaceReaderMgr, n.increasingIndex, n.commitLogWriter, n.reverseIndex,
+		n.quota, n.newSeries, needBootstrap, initializing, n.opts, n.seriesOpts)
+}
+
 func (n *dbNamespace) Close() error {
 	n.Lock()
 	if n.closed {
@@ -1211,6 +1504,7 @@ func (n *dbNamespace) Close() error {
 	shards := n.shards
 	n.shards = shards[:0]
 	n.shardSet = sharding.NewEmptyShardSet(sharding.DefaultHashFn(1))
+	n.shardFn = n.shardSet.HashFn()
 	n.Unlock()
 	n.namespaceReaderMgr.close()
 	n.closeShards(shards, true)
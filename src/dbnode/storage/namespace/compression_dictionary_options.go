@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+const (
+	// defaultCompressionDictionaryEnabled disables dictionary compression by default.
+	defaultCompressionDictionaryEnabled = false
+
+	// defaultCompressionDictionarySampleSize is the default number of series
+	// sampled when training a dictionary.
+	defaultCompressionDictionarySampleSize = 4096
+
+	// defaultCompressionDictionaryMaxBytes is the default max size of a
+	// trained dictionary.
+	defaultCompressionDictionaryMaxBytes = 32 * 1024
+)
+
+type compressionDictionaryOpts struct {
+	enabled    bool
+	sampleSize int
+	maxBytes   int
+}
+
+// NewCompressionDictionaryOptions returns a new CompressionDictionaryOptions.
+func NewCompressionDictionaryOptions() CompressionDictionaryOptions {
+	return &compressionDictionaryOpts{
+		enabled:    defaultCompressionDictionaryEnabled,
+		sampleSize: defaultCompressionDictionarySampleSize,
+		maxBytes:   defaultCompressionDictionaryMaxBytes,
+	}
+}
+
+func (c *compressionDictionaryOpts) Equal(value CompressionDictionaryOptions) bool {
+	return c.Enabled() == value.Enabled() &&
+		c.SampleSize() == value.SampleSize() &&
+		c.MaxBytes() == value.MaxBytes()
+}
+
+func (c *compressionDictionaryOpts) SetEnabled(value bool) CompressionDictionaryOptions {
+	co := *c
+	co.enabled = value
+	return &co
+}
+
+func (c *compressionDictionaryOpts) Enabled() bool {
+	return c.enabled
+}
+
+func (c *compressionDictionaryOpts) SetSampleSize(value int) CompressionDictionaryOptions {
+	co := *c
+	co.sampleSize = value
+	return &co
+}
+
+func (c *compressionDictionaryOpts) SampleSize() int {
+	return c.sampleSize
+}
+
+func (c *compressionDictionaryOpts) SetMaxBytes(value int) CompressionDictionaryOptions {
+	co := *c
+	co.maxBytes = value
+	return &co
+}
+
+func (c *compressionDictionaryOpts) MaxBytes() int {
+	return c.maxBytes
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3x/ident"
 )
 
@@ -48,14 +49,23 @@ func (m *MapConfiguration) Map() (Map, error) {
 
 // MetadataConfiguration is the configuration for a single namespace
 type MetadataConfiguration struct {
-	ID                string                  `yaml:"id" validate:"nonzero"`
-	BootstrapEnabled  *bool                   `yaml:"bootstrapEnabled"`
-	FlushEnabled      *bool                   `yaml:"flushEnabled"`
-	WritesToCommitLog *bool                   `yaml:"writesToCommitLog"`
-	CleanupEnabled    *bool                   `yaml:"cleanupEnabled"`
-	RepairEnabled     *bool                   `yaml:"repairEnabled"`
-	Retention         retention.Configuration `yaml:"retention" validate:"nonzero"`
-	Index             IndexConfiguration      `yaml:"index"`
+	ID                    string                              `yaml:"id" validate:"nonzero"`
+	BootstrapEnabled      *bool                               `yaml:"bootstrapEnabled"`
+	FlushEnabled          *bool                               `yaml:"flushEnabled"`
+	WritesToCommitLog     *bool                               `yaml:"writesToCommitLog"`
+	CleanupEnabled        *bool                               `yaml:"cleanupEnabled"`
+	RepairEnabled         *bool                               `yaml:"repairEnabled"`
+	InMemoryOnly          *bool                               `yaml:"inMemoryOnly"`
+	EncryptionEnabled     *bool                               `yaml:"encryptionEnabled"`
+	CloneSource           string                              `yaml:"cloneSource"`
+	TimestampPrecision    time.Duration                       `yaml:"timestampPrecision"`
+	Retention             retention.Configuration             `yaml:"retention" validate:"nonzero"`
+	Index                 IndexConfiguration                  `yaml:"index"`
+	Quota                 QuotaConfiguration                  `yaml:"quota"`
+	CompressionDictionary *CompressionDictionaryConfiguration `yaml:"compressionDictionary"`
+	Sharding              ShardingConfiguration               `yaml:"sharding"`
+	ContinuousQueries     []ContinuousQueryConfiguration      `yaml:"continuousQueries"`
+	RetentionTransition   *RetentionTransitionConfiguration   `yaml:"retentionTransition"`
 }
 
 // Metadata returns a Metadata corresponding to the receiver struct
@@ -64,7 +74,21 @@ func (mc *MetadataConfiguration) Metadata() (Metadata, error) {
 	ropts := mc.Retention.Options()
 	opts := NewOptions().
 		SetRetentionOptions(ropts).
-		SetIndexOptions(iopts)
+		SetIndexOptions(iopts).
+		SetQuotaOptions(mc.Quota.Options()).
+		SetShardingOptions(mc.Sharding.Options())
+	if v := mc.CompressionDictionary; v != nil {
+		opts = opts.SetCompressionDictionaryOptions(v.Options())
+	}
+	if v := mc.InMemoryOnly; v != nil && *v {
+		// In-memory only namespaces default to never touching disk, explicitly
+		// enabling any of these is rejected when the options are validated.
+		opts = opts.
+			SetInMemoryOnly(true).
+			SetFlushEnabled(false).
+			SetSnapshotEnabled(false).
+			SetWritesToCommitLog(false)
+	}
 	if v := mc.BootstrapEnabled; v != nil {
 		opts = opts.SetBootstrapEnabled(*v)
 	}
@@ -80,18 +104,124 @@ func (mc *MetadataConfiguration) Metadata() (Metadata, error) {
 	if v := mc.RepairEnabled; v != nil {
 		opts = opts.SetRepairEnabled(*v)
 	}
+	if v := mc.EncryptionEnabled; v != nil {
+		opts = opts.SetEncryptionEnabled(*v)
+	}
+	if v := mc.CloneSource; v != "" {
+		opts = opts.SetCloneSource(v)
+	}
+	if v := mc.TimestampPrecision; v != 0 {
+		opts = opts.SetTimestampPrecision(v)
+	}
+	if len(mc.ContinuousQueries) > 0 {
+		queries := make([]ContinuousQuery, 0, len(mc.ContinuousQueries))
+		for _, q := range mc.ContinuousQueries {
+			queries = append(queries, q.ContinuousQuery())
+		}
+		opts = opts.SetContinuousQueries(queries)
+	}
+	if v := mc.RetentionTransition; v != nil {
+		opts = opts.SetRetentionTransition(v.RetentionTransition())
+	}
 	return NewMetadata(ident.StringID(mc.ID), opts)
 }
 
 // IndexConfiguration controls the knobs to tweak indexing configuration.
 type IndexConfiguration struct {
-	Enabled   bool          `yaml:"enabled" validate:"nonzero"`
-	BlockSize time.Duration `yaml:"blockSize" validate:"nonzero"`
+	Enabled     bool          `yaml:"enabled" validate:"nonzero"`
+	BlockSize   time.Duration `yaml:"blockSize" validate:"nonzero"`
+	NumericTags []string      `yaml:"numericTags"`
 }
 
 // Options returns the IndexOptions corresponding to the receiver struct.
 func (ic *IndexConfiguration) Options() IndexOptions {
 	return NewIndexOptions().
 		SetEnabled(ic.Enabled).
-		SetBlockSize(ic.BlockSize)
+		SetBlockSize(ic.BlockSize).
+		SetNumericTags(ic.NumericTags)
+}
+
+// QuotaConfiguration controls the storage quota for a namespace.
+type QuotaConfiguration struct {
+	MaxBytes       int64               `yaml:"maxBytes"`
+	ExceededAction QuotaExceededAction `yaml:"exceededAction"`
+}
+
+// Options returns the QuotaOptions corresponding to the receiver struct.
+func (qc *QuotaConfiguration) Options() QuotaOptions {
+	return NewQuotaOptions().
+		SetMaxBytes(qc.MaxBytes).
+		SetExceededAction(qc.ExceededAction)
+}
+
+// ShardingConfiguration controls how the series of a namespace are assigned
+// to shards.
+type ShardingConfiguration struct {
+	HashStrategy sharding.HashStrategy `yaml:"hashStrategy"`
+	TagNames     []string              `yaml:"tagNames"`
+}
+
+// Options returns the ShardingOptions corresponding to the receiver struct.
+func (sc *ShardingConfiguration) Options() ShardingOptions {
+	return NewShardingOptions().
+		SetHashStrategy(sc.HashStrategy).
+		SetTagNames(sc.TagNames)
+}
+
+// ContinuousQueryConfiguration is the configuration for a continuous query
+// of a namespace.
+type ContinuousQueryConfiguration struct {
+	TargetNamespace string                     `yaml:"targetNamespace" validate:"nonzero"`
+	Resolution      time.Duration              `yaml:"resolution" validate:"nonzero"`
+	Aggregation     ContinuousQueryAggregation `yaml:"aggregation"`
+}
+
+// ContinuousQuery returns the ContinuousQuery corresponding to the receiver
+// struct.
+func (cc *ContinuousQueryConfiguration) ContinuousQuery() ContinuousQuery {
+	return ContinuousQuery{
+		TargetNamespace: cc.TargetNamespace,
+		Resolution:      cc.Resolution,
+		Aggregation:     cc.Aggregation,
+	}
+}
+
+// RetentionTransitionConfiguration is the configuration for the retention
+// transition of a namespace.
+type RetentionTransitionConfiguration struct {
+	TargetNamespace string                     `yaml:"targetNamespace" validate:"nonzero"`
+	Resolution      time.Duration              `yaml:"resolution" validate:"nonzero"`
+	Aggregation     ContinuousQueryAggregation `yaml:"aggregation"`
+}
+
+// RetentionTransition returns the RetentionTransition corresponding to the
+// receiver struct.
+func (rc *RetentionTransitionConfiguration) RetentionTransition() RetentionTransition {
+	return RetentionTransition{
+		TargetNamespace: rc.TargetNamespace,
+		Resolution:      rc.Resolution,
+		Aggregation:     rc.Aggregation,
+	}
+}
+
+// CompressionDictionaryConfiguration controls the compression dictionary training
+// for a namespace.
+type CompressionDictionaryConfiguration struct {
+	Enabled    bool `yaml:"enabled"`
+	SampleSize *int `yaml:"sampleSize"`
+	MaxBytes   *int `yaml:"maxBytes"`
+}
+
+// Options returns the CompressionDictionaryOptions corresponding to the
+// receiver struct.
+func (dc *CompressionDictionaryConfiguration) Options() CompressionDictionaryOptions {
+	opts := NewCompressionDictionaryOptions().
+		SetEnabled(dc.Enabled)
+	if v := dc.SampleSize; v != nil {
+		opts = opts.SetSampleSize(*v)
+	}
+	if v := dc.MaxBytes; v != nil {
+		opts = opts.SetMaxBytes(*v)
+	}
+	return opts
 }
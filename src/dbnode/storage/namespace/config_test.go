@@ -232,3 +232,129 @@ metadatas:
 	require.True(t, testRetentionOpts.Equal(opts.RetentionOptions()))
 
 }
+
+func TestMetadataConfigInMemoryOnly(t *testing.T) {
+	yamlBytes := []byte(`
+id: "cache"
+inMemoryOnly: true
+retention:
+  retentionPeriod: 2h
+  blockSize: 1h
+  bufferFuture: 10m
+  bufferPast: 10m
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	opts := md.Options()
+	require.True(t, opts.InMemoryOnly())
+	require.False(t, opts.FlushEnabled())
+	require.False(t, opts.SnapshotEnabled())
+	require.False(t, opts.WritesToCommitLog())
+
+	flushEnabled := true
+	conf.FlushEnabled = &flushEnabled
+	_, err = conf.Metadata()
+	require.Error(t, err)
+}
+
+func TestMetadataConfigEncryptionEnabled(t *testing.T) {
+	yamlBytes := []byte(`
+id: "secure"
+encryptionEnabled: true
+retention:
+  retentionPeriod: 2h
+  blockSize: 1h
+  bufferFuture: 10m
+  bufferPast: 10m
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.True(t, md.Options().EncryptionEnabled())
+
+	// Round trips through the registry protobuf representation.
+	roundtripped, err := ToMetadata(md.ID().String(), OptionsToProto(md.Options()))
+	require.NoError(t, err)
+	require.True(t, roundtripped.Options().EncryptionEnabled())
+}
+
+func TestMetadataConfigCloneSource(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics-clone"
+cloneSource: "metrics"
+retention:
+  retentionPeriod: 2h
+  blockSize: 1h
+  bufferFuture: 10m
+  bufferPast: 10m
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, "metrics", md.Options().CloneSource())
+
+	// Round trips through the registry protobuf representation.
+	roundtripped, err := ToMetadata(md.ID().String(), OptionsToProto(md.Options()))
+	require.NoError(t, err)
+	require.Equal(t, "metrics", roundtripped.Options().CloneSource())
+}
+
+func TestMetadataConfigTimestampPrecision(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+timestampPrecision: 1s
+retention:
+  retentionPeriod: 2h
+  blockSize: 1h
+  bufferFuture: 10m
+  bufferPast: 10m
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, time.Second, md.Options().TimestampPrecision())
+}
+
+func TestMetadataConfigRetentionTransition(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 720h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+retentionTransition:
+  targetNamespace: metrics_5m
+  resolution: 5m
+  aggregation: max
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, RetentionTransition{
+		TargetNamespace: "metrics_5m",
+		Resolution:      5 * time.Minute,
+		Aggregation:     ContinuousQueryMax,
+	}, md.Options().RetentionTransition())
+
+	// Round trips through the registry protobuf representation.
+	roundtripped, err := ToMetadata(md.ID().String(), OptionsToProto(md.Options()))
+	require.NoError(t, err)
+	require.True(t, md.Equal(roundtripped))
+}
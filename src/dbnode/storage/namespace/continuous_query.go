@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var validContinuousQueryAggregations = []ContinuousQueryAggregation{
+	ContinuousQueryMax,
+	ContinuousQueryMin,
+	ContinuousQuerySum,
+	ContinuousQueryCount,
+	ContinuousQueryMean,
+	ContinuousQueryLast,
+}
+
+func (a ContinuousQueryAggregation) String() string {
+	switch a {
+	case ContinuousQueryMax:
+		return "max"
+	case ContinuousQueryMin:
+		return "min"
+	case ContinuousQuerySum:
+		return "sum"
+	case ContinuousQueryCount:
+		return "count"
+	case ContinuousQueryMean:
+		return "mean"
+	case ContinuousQueryLast:
+		return "last"
+	}
+	return "unknown"
+}
+
+// UnmarshalYAML unmarshals a ContinuousQueryAggregation from a string.
+func (a *ContinuousQueryAggregation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	if str == "" {
+		*a = ContinuousQueryMax
+		return nil
+	}
+	for _, valid := range validContinuousQueryAggregations {
+		if strings.EqualFold(str, valid.String()) {
+			*a = valid
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid continuous query aggregation '%s' valid aggregations are: %v",
+		str, validContinuousQueryAggregations)
+}
+
+// ValidateContinuousQueryAggregation returns an error if the aggregation
+// is not a known aggregation.
+func ValidateContinuousQueryAggregation(value ContinuousQueryAggregation) error {
+	for _, valid := range validContinuousQueryAggregations {
+		if value == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid continuous query aggregation: %d", int(value))
+}
+
+// ContinuousQueryAggregator aggregates the values of a single resolution
+// window of a continuous query.
+type ContinuousQueryAggregator struct {
+	aggregation ContinuousQueryAggregation
+	count       int
+	value       float64
+}
+
+// NewContinuousQueryAggregator returns a new aggregator for the aggregation.
+func NewContinuousQueryAggregator(
+	aggregation ContinuousQueryAggregation,
+) ContinuousQueryAggregator {
+	return ContinuousQueryAggregator{aggregation: aggregation}
+}
+
+// Add adds a value to the window.
+func (a *ContinuousQueryAggregator) Add(value float64) {
+	a.count++
+	if a.count == 1 {
+		a.value = value
+		return
+	}
+	switch a.aggregation {
+	case ContinuousQueryMax:
+		if value > a.value {
+			a.value = value
+		}
+	case ContinuousQueryMin:
+		if value < a.value {
+			a.value = value
+		}
+	case ContinuousQuerySum, ContinuousQueryMean:
+		a.value += value
+	case ContinuousQueryLast:
+		a.value = value
+	}
+}
+
+// Count returns the number of values added to the window.
+func (a *ContinuousQueryAggregator) Count() int {
+	return a.count
+}
+
+// Value returns the aggregated value of the window.
+func (a *ContinuousQueryAggregator) Value() float64 {
+	switch a.aggregation {
+	case ContinuousQueryCount:
+		return float64(a.count)
+	case ContinuousQueryMean:
+		if a.count == 0 {
+			return 0
+		}
+		return a.value / float64(a.count)
+	}
+	return a.value
+}
+
+// Reset resets the aggregator for the next window.
+func (a *ContinuousQueryAggregator) Reset() {
+	a.count = 0
+	a.value = 0
+}
+
+// ContinuousQueryWindowEnd returns the end of the resolution window the
+// timestamp falls in, aggregated values are written at the end of their
+// window so that a window is never visible before all its values are.
+func ContinuousQueryWindowEnd(t time.Time, resolution time.Duration) time.Time {
+	return t.Truncate(resolution).Add(resolution)
+}
+
+func continuousQueriesEqual(a, b []ContinuousQuery) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestContinuousQueryAggregator(t *testing.T) {
+	values := []float64{3, 1, 4, 1, 5}
+	expected := map[ContinuousQueryAggregation]float64{
+		ContinuousQueryMax:   5,
+		ContinuousQueryMin:   1,
+		ContinuousQuerySum:   14,
+		ContinuousQueryCount: 5,
+		ContinuousQueryMean:  2.8,
+		ContinuousQueryLast:  5,
+	}
+	for _, aggregation := range validContinuousQueryAggregations {
+		agg := NewContinuousQueryAggregator(aggregation)
+		for _, v := range values {
+			agg.Add(v)
+		}
+		require.Equal(t, len(values), agg.Count())
+		require.InDelta(t, expected[aggregation], agg.Value(), 1e-9, aggregation.String())
+
+		agg.Reset()
+		require.Equal(t, 0, agg.Count())
+		agg.Add(-1)
+		require.Equal(t, 1, agg.Count())
+	}
+}
+
+func TestContinuousQueryWindowEnd(t *testing.T) {
+	start := time.Unix(0, 0)
+	require.Equal(t, start.Add(time.Minute),
+		ContinuousQueryWindowEnd(start, time.Minute))
+	require.Equal(t, start.Add(time.Minute),
+		ContinuousQueryWindowEnd(start.Add(59*time.Second), time.Minute))
+	require.Equal(t, start.Add(2*time.Minute),
+		ContinuousQueryWindowEnd(start.Add(time.Minute), time.Minute))
+}
+
+func TestContinuousQueryAggregationUnmarshalYAML(t *testing.T) {
+	for _, aggregation := range validContinuousQueryAggregations {
+		var cfg ContinuousQueryConfiguration
+		str := "targetNamespace: agg\nresolution: 1m\naggregation: " +
+			aggregation.String() + "\n"
+		require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+		require.Equal(t, ContinuousQuery{
+			TargetNamespace: "agg",
+			Resolution:      time.Minute,
+			Aggregation:     aggregation,
+		}, cfg.ContinuousQuery())
+	}
+
+	var cfg ContinuousQueryConfiguration
+	require.Error(t, yaml.Unmarshal([]byte("aggregation: foo\n"), &cfg))
+}
+
+func TestOptionsValidateContinuousQueries(t *testing.T) {
+	valid := ContinuousQuery{
+		TargetNamespace: "agg",
+		Resolution:      time.Minute,
+		Aggregation:     ContinuousQueryMean,
+	}
+	opts := NewOptions()
+	require.NoError(t, opts.SetContinuousQueries([]ContinuousQuery{valid}).Validate())
+	require.False(t, opts.Equal(opts.SetContinuousQueries([]ContinuousQuery{valid})))
+
+	noTarget := valid
+	noTarget.TargetNamespace = ""
+	require.Equal(t, errContinuousQueryTargetNamespaceEmpty,
+		opts.SetContinuousQueries([]ContinuousQuery{noTarget}).Validate())
+
+	noResolution := valid
+	noResolution.Resolution = 0
+	require.Equal(t, errContinuousQueryResolutionPositive,
+		opts.SetContinuousQueries([]ContinuousQuery{noResolution}).Validate())
+
+	unaligned := valid
+	unaligned.Resolution = 7 * time.Minute
+	require.Equal(t, errContinuousQueryResolutionNotBlockAligned,
+		opts.SetContinuousQueries([]ContinuousQuery{unaligned}).Validate())
+
+	unknown := valid
+	unknown.Aggregation = ContinuousQueryAggregation(100)
+	require.Error(t, opts.SetContinuousQueries([]ContinuousQuery{unknown}).Validate())
+
+	inMemory := opts.SetInMemoryOnly(true).
+		SetFlushEnabled(false).
+		SetSnapshotEnabled(false).
+		SetWritesToCommitLog(false)
+	require.Equal(t, errContinuousQueryInMemoryOnly,
+		inMemory.SetContinuousQueries([]ContinuousQuery{valid}).Validate())
+}
+
+func TestMetadataConfigContinuousQueries(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 48h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+continuousQueries:
+  - targetNamespace: metrics_5m
+    resolution: 5m
+    aggregation: mean
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	expected := []ContinuousQuery{{
+		TargetNamespace: "metrics_5m",
+		Resolution:      5 * time.Minute,
+		Aggregation:     ContinuousQueryMean,
+	}}
+	require.Equal(t, expected, md.Options().ContinuousQueries())
+
+	// Round trips through the registry protobuf representation.
+	roundtripped, err := ToMetadata(md.ID().String(), OptionsToProto(md.Options()))
+	require.NoError(t, err)
+	require.True(t, md.Equal(roundtripped))
+}
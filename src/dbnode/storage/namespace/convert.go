@@ -22,10 +22,12 @@ package namespace
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
 )
@@ -73,11 +75,156 @@ func ToIndexOptions(
 	}
 
 	iopts = iopts.SetEnabled(io.Enabled).
-		SetBlockSize(fromNanos(io.BlockSizeNanos))
+		SetBlockSize(fromNanos(io.BlockSizeNanos)).
+		SetNumericTags(io.NumericTags)
 
 	return iopts, nil
 }
 
+// ToQuotaOptions converts nsproto.QuotaOptions to QuotaOptions
+func ToQuotaOptions(
+	qo *nsproto.QuotaOptions,
+) (QuotaOptions, error) {
+	qopts := NewQuotaOptions()
+	if qo == nil {
+		return qopts, nil
+	}
+
+	var action QuotaExceededAction
+	switch qo.ExceededAction {
+	case nsproto.QuotaExceededAction_WARN:
+		action = QuotaExceededWarn
+	case nsproto.QuotaExceededAction_REJECT_NEW_SERIES:
+		action = QuotaExceededRejectNewSeries
+	case nsproto.QuotaExceededAction_REJECT_WRITES:
+		action = QuotaExceededRejectWrites
+	default:
+		return nil, fmt.Errorf("unknown quota exceeded action: %v", qo.ExceededAction)
+	}
+
+	qopts = qopts.SetMaxBytes(qo.MaxBytes).
+		SetExceededAction(action)
+
+	return qopts, nil
+}
+
+// ToCompressionDictionaryOptions converts nsproto.CompressionDictionaryOptions
+// to CompressionDictionaryOptions
+func ToCompressionDictionaryOptions(
+	co *nsproto.CompressionDictionaryOptions,
+) CompressionDictionaryOptions {
+	copts := NewCompressionDictionaryOptions()
+	if co == nil {
+		return copts
+	}
+
+	copts = copts.SetEnabled(co.Enabled)
+	if co.SampleSize > 0 {
+		copts = copts.SetSampleSize(int(co.SampleSize))
+	}
+	if co.MaxBytes > 0 {
+		copts = copts.SetMaxBytes(int(co.MaxBytes))
+	}
+
+	return copts
+}
+
+// ToShardingOptions converts nsproto.ShardingOptions to ShardingOptions
+func ToShardingOptions(
+	so *nsproto.ShardingOptions,
+) (ShardingOptions, error) {
+	sopts := NewShardingOptions()
+	if so == nil {
+		return sopts, nil
+	}
+
+	var strategy sharding.HashStrategy
+	switch so.HashStrategy {
+	case nsproto.HashStrategy_MURMUR3:
+		strategy = sharding.Murmur3HashStrategy
+	case nsproto.HashStrategy_JUMP:
+		strategy = sharding.JumpHashStrategy
+	case nsproto.HashStrategy_TAG_SUBSET:
+		strategy = sharding.TagSubsetHashStrategy
+	default:
+		return nil, fmt.Errorf("unknown hash strategy: %v", so.HashStrategy)
+	}
+
+	sopts = sopts.SetHashStrategy(strategy).
+		SetTagNames(so.TagNames)
+
+	return sopts, nil
+}
+
+// ToContinuousQueries converts nsproto.ContinuousQuery to ContinuousQuery
+func ToContinuousQueries(
+	cqs []*nsproto.ContinuousQuery,
+) ([]ContinuousQuery, error) {
+	if len(cqs) == 0 {
+		return nil, nil
+	}
+
+	queries := make([]ContinuousQuery, 0, len(cqs))
+	for _, cq := range cqs {
+		if cq == nil {
+			continue
+		}
+
+		aggregation, err := continuousQueryAggregationFromProto(cq.Aggregation)
+		if err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, ContinuousQuery{
+			TargetNamespace: cq.TargetNamespace,
+			Resolution:      fromNanos(cq.ResolutionNanos),
+			Aggregation:     aggregation,
+		})
+	}
+
+	return queries, nil
+}
+
+// ToRetentionTransition converts nsproto.RetentionTransition to RetentionTransition
+func ToRetentionTransition(
+	rt *nsproto.RetentionTransition,
+) (RetentionTransition, error) {
+	if rt == nil {
+		return RetentionTransition{}, nil
+	}
+
+	aggregation, err := continuousQueryAggregationFromProto(rt.Aggregation)
+	if err != nil {
+		return RetentionTransition{}, err
+	}
+
+	return RetentionTransition{
+		TargetNamespace: rt.TargetNamespace,
+		Resolution:      fromNanos(rt.ResolutionNanos),
+		Aggregation:     aggregation,
+	}, nil
+}
+
+func continuousQueryAggregationFromProto(
+	value nsproto.ContinuousQueryAggregation,
+) (ContinuousQueryAggregation, error) {
+	switch value {
+	case nsproto.ContinuousQueryAggregation_MAX:
+		return ContinuousQueryMax, nil
+	case nsproto.ContinuousQueryAggregation_MIN:
+		return ContinuousQueryMin, nil
+	case nsproto.ContinuousQueryAggregation_SUM:
+		return ContinuousQuerySum, nil
+	case nsproto.ContinuousQueryAggregation_COUNT:
+		return ContinuousQueryCount, nil
+	case nsproto.ContinuousQueryAggregation_MEAN:
+		return ContinuousQueryMean, nil
+	case nsproto.ContinuousQueryAggregation_LAST:
+		return ContinuousQueryLast, nil
+	}
+	return 0, fmt.Errorf("unknown continuous query aggregation: %v", value)
+}
+
 // ToMetadata converts nsproto.Options to Metadata
 func ToMetadata(
 	id string,
@@ -97,6 +244,33 @@ func ToMetadata(
 		return nil, err
 	}
 
+	qopts, err := ToQuotaOptions(opts.QuotaOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	sopts, err := ToShardingOptions(opts.ShardingOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := ToContinuousQueries(opts.ContinuousQueries)
+	if err != nil {
+		return nil, err
+	}
+
+	transition, err := ToRetentionTransition(opts.RetentionTransition)
+	if err != nil {
+		return nil, err
+	}
+
+	// Namespaces registered before timestamp precision was configurable have
+	// no precision set and keep their timestamps at nanosecond precision.
+	timestampPrecision := defaultTimestampPrecision
+	if v := opts.TimestampPrecisionNanos; v != 0 {
+		timestampPrecision = time.Duration(v)
+	}
+
 	mopts := NewOptions().
 		SetBootstrapEnabled(opts.BootstrapEnabled).
 		SetFlushEnabled(opts.FlushEnabled).
@@ -104,8 +278,17 @@ func ToMetadata(
 		SetRepairEnabled(opts.RepairEnabled).
 		SetWritesToCommitLog(opts.WritesToCommitLog).
 		SetSnapshotEnabled(opts.SnapshotEnabled).
+		SetInMemoryOnly(opts.InMemoryOnly).
+		SetEncryptionEnabled(opts.EncryptionEnabled).
+		SetCloneSource(opts.CloneSource).
+		SetTimestampPrecision(timestampPrecision).
 		SetRetentionOptions(ropts).
-		SetIndexOptions(iopts)
+		SetIndexOptions(iopts).
+		SetQuotaOptions(qopts).
+		SetCompressionDictionaryOptions(ToCompressionDictionaryOptions(opts.CompressionDictionaryOptions)).
+		SetShardingOptions(sopts).
+		SetContinuousQueries(queries).
+		SetRetentionTransition(transition)
 
 	return NewMetadata(ident.StringID(id), mopts)
 }
@@ -140,14 +323,21 @@ func FromProto(protoRegistry nsproto.Registry) (Map, error) {
 func OptionsToProto(opts Options) *nsproto.NamespaceOptions {
 	ropts := opts.RetentionOptions()
 	iopts := opts.IndexOptions()
+	qopts := opts.QuotaOptions()
+	copts := opts.CompressionDictionaryOptions()
+	sopts := opts.ShardingOptions()
 
 	return &nsproto.NamespaceOptions{
-		BootstrapEnabled:  opts.BootstrapEnabled(),
-		FlushEnabled:      opts.FlushEnabled(),
-		CleanupEnabled:    opts.CleanupEnabled(),
-		SnapshotEnabled:   opts.SnapshotEnabled(),
-		RepairEnabled:     opts.RepairEnabled(),
-		WritesToCommitLog: opts.WritesToCommitLog(),
+		BootstrapEnabled:        opts.BootstrapEnabled(),
+		FlushEnabled:            opts.FlushEnabled(),
+		CleanupEnabled:          opts.CleanupEnabled(),
+		SnapshotEnabled:         opts.SnapshotEnabled(),
+		RepairEnabled:           opts.RepairEnabled(),
+		WritesToCommitLog:       opts.WritesToCommitLog(),
+		InMemoryOnly:            opts.InMemoryOnly(),
+		EncryptionEnabled:       opts.EncryptionEnabled(),
+		CloneSource:             opts.CloneSource(),
+		TimestampPrecisionNanos: opts.TimestampPrecision().Nanoseconds(),
 		RetentionOptions: &nsproto.RetentionOptions{
 			BlockSizeNanos:                           ropts.BlockSize().Nanoseconds(),
 			RetentionPeriodNanos:                     ropts.RetentionPeriod().Nanoseconds(),
@@ -159,6 +349,86 @@ func OptionsToProto(opts Options) *nsproto.NamespaceOptions {
 		IndexOptions: &nsproto.IndexOptions{
 			Enabled:        iopts.Enabled(),
 			BlockSizeNanos: iopts.BlockSize().Nanoseconds(),
+			NumericTags:    iopts.NumericTags(),
 		},
+		QuotaOptions: &nsproto.QuotaOptions{
+			MaxBytes:       qopts.MaxBytes(),
+			ExceededAction: quotaExceededActionToProto(qopts.ExceededAction()),
+		},
+		CompressionDictionaryOptions: &nsproto.CompressionDictionaryOptions{
+			Enabled:    copts.Enabled(),
+			SampleSize: int64(copts.SampleSize()),
+			MaxBytes:   int64(copts.MaxBytes()),
+		},
+		ShardingOptions: &nsproto.ShardingOptions{
+			HashStrategy: hashStrategyToProto(sopts.HashStrategy()),
+			TagNames:     sopts.TagNames(),
+		},
+		ContinuousQueries:   continuousQueriesToProto(opts.ContinuousQueries()),
+		RetentionTransition: retentionTransitionToProto(opts.RetentionTransition()),
+	}
+}
+
+func continuousQueriesToProto(queries []ContinuousQuery) []*nsproto.ContinuousQuery {
+	if len(queries) == 0 {
+		return nil
+	}
+	result := make([]*nsproto.ContinuousQuery, 0, len(queries))
+	for _, q := range queries {
+		result = append(result, &nsproto.ContinuousQuery{
+			TargetNamespace: q.TargetNamespace,
+			ResolutionNanos: q.Resolution.Nanoseconds(),
+			Aggregation:     continuousQueryAggregationToProto(q.Aggregation),
+		})
+	}
+	return result
+}
+
+func retentionTransitionToProto(value RetentionTransition) *nsproto.RetentionTransition {
+	if value.TargetNamespace == "" {
+		return nil
+	}
+	return &nsproto.RetentionTransition{
+		TargetNamespace: value.TargetNamespace,
+		ResolutionNanos: value.Resolution.Nanoseconds(),
+		Aggregation:     continuousQueryAggregationToProto(value.Aggregation),
+	}
+}
+
+func continuousQueryAggregationToProto(
+	value ContinuousQueryAggregation,
+) nsproto.ContinuousQueryAggregation {
+	switch value {
+	case ContinuousQueryMin:
+		return nsproto.ContinuousQueryAggregation_MIN
+	case ContinuousQuerySum:
+		return nsproto.ContinuousQueryAggregation_SUM
+	case ContinuousQueryCount:
+		return nsproto.ContinuousQueryAggregation_COUNT
+	case ContinuousQueryMean:
+		return nsproto.ContinuousQueryAggregation_MEAN
+	case ContinuousQueryLast:
+		return nsproto.ContinuousQueryAggregation_LAST
+	}
+	return nsproto.ContinuousQueryAggregation_MAX
+}
+
+func quotaExceededActionToProto(value QuotaExceededAction) nsproto.QuotaExceededAction {
+	switch value {
+	case QuotaExceededRejectNewSeries:
+		return nsproto.QuotaExceededAction_REJECT_NEW_SERIES
+	case QuotaExceededRejectWrites:
+		return nsproto.QuotaExceededAction_REJECT_WRITES
+	}
+	return nsproto.QuotaExceededAction_WARN
+}
+
+func hashStrategyToProto(value sharding.HashStrategy) nsproto.HashStrategy {
+	switch value {
+	case sharding.JumpHashStrategy:
+		return nsproto.HashStrategy_JUMP
+	case sharding.TagSubsetHashStrategy:
+		return nsproto.HashStrategy_TAG_SUBSET
 	}
+	return nsproto.HashStrategy_MURMUR3
 }
@@ -26,6 +26,7 @@ import (
 
 	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3x/ident"
 
@@ -239,3 +240,84 @@ func assertEqualRetentions(t *testing.T, expected nsproto.RetentionOptions, obse
 	require.Equal(t, expected.BlockDataExpiryAfterNotAccessPeriodNanos,
 		observed.BlockDataExpiryAfterNotAccessedPeriod().Nanoseconds())
 }
+
+func TestQuotaOptionsRoundTrip(t *testing.T) {
+	md, err := namespace.NewMetadata(ident.StringID("ns1"),
+		namespace.NewOptions().SetQuotaOptions(namespace.NewQuotaOptions().
+			SetMaxBytes(1024).
+			SetExceededAction(namespace.QuotaExceededRejectNewSeries)))
+	require.NoError(t, err)
+
+	protoOpts := namespace.OptionsToProto(md.Options())
+	require.Equal(t, int64(1024), protoOpts.QuotaOptions.MaxBytes)
+	require.Equal(t, nsproto.QuotaExceededAction_REJECT_NEW_SERIES,
+		protoOpts.QuotaOptions.ExceededAction)
+
+	observed, err := namespace.ToMetadata("ns1", protoOpts)
+	require.NoError(t, err)
+	require.True(t, md.Equal(observed))
+}
+
+func TestIndexOptionsNumericTagsRoundTrip(t *testing.T) {
+	md, err := namespace.NewMetadata(ident.StringID("ns1"),
+		namespace.NewOptions().SetIndexOptions(namespace.NewIndexOptions().
+			SetEnabled(true).
+			SetNumericTags([]string{"port"})))
+	require.NoError(t, err)
+
+	protoOpts := namespace.OptionsToProto(md.Options())
+	require.Equal(t, []string{"port"}, protoOpts.IndexOptions.NumericTags)
+
+	observed, err := namespace.ToMetadata("ns1", protoOpts)
+	require.NoError(t, err)
+	require.True(t, md.Equal(observed))
+	require.True(t, observed.Options().IndexOptions().IsNumericTag([]byte("port")))
+}
+
+func TestToQuotaOptionsNil(t *testing.T) {
+	qopts, err := namespace.ToQuotaOptions(nil)
+	require.NoError(t, err)
+	require.True(t, namespace.NewQuotaOptions().Equal(qopts))
+}
+
+func TestShardingOptionsRoundTrip(t *testing.T) {
+	md, err := namespace.NewMetadata(ident.StringID("ns1"),
+		namespace.NewOptions().SetShardingOptions(namespace.NewShardingOptions().
+			SetHashStrategy(sharding.TagSubsetHashStrategy).
+			SetTagNames([]string{"service"})))
+	require.NoError(t, err)
+
+	protoOpts := namespace.OptionsToProto(md.Options())
+	require.Equal(t, nsproto.HashStrategy_TAG_SUBSET,
+		protoOpts.ShardingOptions.HashStrategy)
+	require.Equal(t, []string{"service"}, protoOpts.ShardingOptions.TagNames)
+
+	observed, err := namespace.ToMetadata("ns1", protoOpts)
+	require.NoError(t, err)
+	require.True(t, md.Equal(observed))
+}
+
+func TestToShardingOptionsNil(t *testing.T) {
+	sopts, err := namespace.ToShardingOptions(nil)
+	require.NoError(t, err)
+	require.True(t, namespace.NewShardingOptions().Equal(sopts))
+}
+
+func TestTimestampPrecisionRoundTrip(t *testing.T) {
+	md, err := namespace.NewMetadata(ident.StringID("ns1"),
+		namespace.NewOptions().SetTimestampPrecision(time.Millisecond))
+	require.NoError(t, err)
+
+	protoOpts := namespace.OptionsToProto(md.Options())
+	require.Equal(t, time.Millisecond.Nanoseconds(), protoOpts.TimestampPrecisionNanos)
+
+	observed, err := namespace.ToMetadata("ns1", protoOpts)
+	require.NoError(t, err)
+	require.True(t, md.Equal(observed))
+
+	// Namespaces registered without a precision default to nanoseconds.
+	protoOpts.TimestampPrecisionNanos = 0
+	observed, err = namespace.ToMetadata("ns1", protoOpts)
+	require.NoError(t, err)
+	require.Equal(t, time.Nanosecond, observed.Options().TimestampPrecision())
+}
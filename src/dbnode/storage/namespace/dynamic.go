@@ -26,6 +26,8 @@ import (
 	"time"
 
 	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/x/fault"
+	"github.com/m3db/m3/src/dbnode/x/logsample"
 	"github.com/m3db/m3cluster/kv"
 	xlog "github.com/m3db/m3x/log"
 	xwatch "github.com/m3db/m3x/watch"
@@ -39,6 +41,14 @@ var (
 	errInvalidRegistry       = errors.New("could not parse latest value from config service")
 )
 
+const (
+	droppedUpdateLogClass   = "namespace-registry-dropped-update"
+	nilUpdateLogClass       = "namespace-registry-nil-update"
+	olderUpdateLogClass     = "namespace-registry-older-update"
+	invalidUpdateLogClass   = "namespace-registry-invalid-update"
+	identicalUpdateLogClass = "namespace-registry-identical-update"
+)
+
 type dynamicInitializer struct {
 	sync.Mutex
 	opts DynamicOptions
@@ -73,14 +83,15 @@ func (i *dynamicInitializer) Init() (Registry, error) {
 
 type dynamicRegistry struct {
 	sync.RWMutex
-	opts         DynamicOptions
-	logger       xlog.Logger
-	metrics      dynamicRegistryMetrics
-	watchable    xwatch.Watchable
-	kvWatch      kv.ValueWatch
-	currentValue kv.Value
-	currentMap   Map
-	closed       bool
+	opts          DynamicOptions
+	logger        xlog.Logger
+	sampledLogger *logsample.Logger
+	metrics       dynamicRegistryMetrics
+	watchable     xwatch.Watchable
+	kvWatch       kv.ValueWatch
+	currentValue  kv.Value
+	currentMap    Map
+	closed        bool
 }
 
 type dynamicRegistryMetrics struct {
@@ -125,14 +136,19 @@ func newDynamicRegistry(opts DynamicOptions) (Registry, error) {
 	watchable := xwatch.NewWatchable()
 	watchable.Update(m)
 
+	scope := opts.InstrumentOptions().MetricsScope().SubScope("namespace-registry")
+	logSampler := logsample.NewSampler(logsample.DefaultPolicy, nil,
+		scope.SubScope("log-sampling"))
+
 	dt := &dynamicRegistry{
-		opts:         opts,
-		logger:       logger,
-		metrics:      newDynamicRegistryMetrics(opts),
-		watchable:    watchable,
-		kvWatch:      watch,
-		currentValue: initValue,
-		currentMap:   m,
+		opts:          opts,
+		logger:        logger,
+		sampledLogger: logsample.NewLogger(logger, logSampler),
+		metrics:       newDynamicRegistryMetrics(opts),
+		watchable:     watchable,
+		kvWatch:       watch,
+		currentValue:  initValue,
+		currentMap:    m,
 	}
 	go dt.run()
 	go dt.reportMetrics()
@@ -178,16 +194,24 @@ func (r *dynamicRegistry) run() {
 			break
 		}
 
+		if err := r.opts.FaultInjector().Inject(fault.PointKVWatchUpdate); err != nil {
+			r.sampledLogger.Warnf(droppedUpdateLogClass,
+				"dynamic namespace registry dropped update: %v", err)
+			continue
+		}
+
 		val := r.kvWatch.Get()
 		if val == nil {
 			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warnf("dynamic namespace registry received nil, skipping")
+			r.sampledLogger.Warnf(nilUpdateLogClass,
+				"dynamic namespace registry received nil, skipping")
 			continue
 		}
 
 		if !val.IsNewer(r.currentValue) {
 			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warnf("dynamic namespace registry received older version: %v, skipping",
+			r.sampledLogger.Warnf(olderUpdateLogClass,
+				"dynamic namespace registry received older version: %v, skipping",
 				val.Version())
 			continue
 		}
@@ -195,14 +219,16 @@ func (r *dynamicRegistry) run() {
 		m, err := getMapFromUpdate(val)
 		if err != nil {
 			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warnf("dynamic namespace registry received invalid update: %v, skipping",
+			r.sampledLogger.Warnf(invalidUpdateLogClass,
+				"dynamic namespace registry received invalid update: %v, skipping",
 				err)
 			continue
 		}
 
 		if m.Equal(r.maps()) {
 			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warnf("dynamic namespace registry received identical update, skipping")
+			r.sampledLogger.Warnf(identicalUpdateLogClass,
+				"dynamic namespace registry received identical update, skipping")
 			continue
 		}
 
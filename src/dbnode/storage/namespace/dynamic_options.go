@@ -24,6 +24,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/x/fault"
 	"github.com/m3db/m3cluster/client"
 	"github.com/m3db/m3x/instrument"
 )
@@ -44,6 +45,7 @@ type dynamicOpts struct {
 	csClient      client.Client
 	nsRegistryKey string
 	initTimeout   time.Duration
+	faultInjector fault.Injector
 }
 
 // NewDynamicOptions creates a new DynamicOptions
@@ -52,6 +54,7 @@ func NewDynamicOptions() DynamicOptions {
 		iopts:         instrument.NewOptions(),
 		nsRegistryKey: defaultNsRegistryKey,
 		initTimeout:   defaultInitTimeout,
+		faultInjector: fault.NewNoopInjector(),
 	}
 }
 
@@ -107,3 +110,13 @@ func (o *dynamicOpts) SetInitTimeout(value time.Duration) DynamicOptions {
 func (o *dynamicOpts) InitTimeout() time.Duration {
 	return o.initTimeout
 }
+
+func (o *dynamicOpts) SetFaultInjector(value fault.Injector) DynamicOptions {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *dynamicOpts) FaultInjector() fault.Injector {
+	return o.faultInjector
+}
@@ -21,6 +21,7 @@
 package namespace
 
 import (
+	"sort"
 	"time"
 )
 
@@ -33,8 +34,9 @@ var (
 )
 
 type indexOpts struct {
-	enabled   bool
-	blockSize time.Duration
+	enabled     bool
+	blockSize   time.Duration
+	numericTags map[string]struct{}
 }
 
 // NewIndexOptions returns a new IndexOptions.
@@ -47,7 +49,8 @@ func NewIndexOptions() IndexOptions {
 
 func (i *indexOpts) Equal(value IndexOptions) bool {
 	return i.Enabled() == value.Enabled() &&
-		i.BlockSize() == value.BlockSize()
+		i.BlockSize() == value.BlockSize() &&
+		stringsEqual(i.NumericTags(), value.NumericTags())
 }
 
 func (i *indexOpts) SetEnabled(value bool) IndexOptions {
@@ -69,3 +72,45 @@ func (i *indexOpts) SetBlockSize(value time.Duration) IndexOptions {
 func (i *indexOpts) BlockSize() time.Duration {
 	return i.blockSize
 }
+
+func (i *indexOpts) SetNumericTags(value []string) IndexOptions {
+	io := *i
+	io.numericTags = nil
+	if len(value) == 0 {
+		return &io
+	}
+	io.numericTags = make(map[string]struct{}, len(value))
+	for _, tag := range value {
+		io.numericTags[tag] = struct{}{}
+	}
+	return &io
+}
+
+func (i *indexOpts) NumericTags() []string {
+	if len(i.numericTags) == 0 {
+		return nil
+	}
+	tags := make([]string, 0, len(i.numericTags))
+	for tag := range i.numericTags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (i *indexOpts) IsNumericTag(name []byte) bool {
+	_, ok := i.numericTags[string(name)]
+	return ok
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -45,3 +45,17 @@ func TestIndexOptionsBlockSize(t *testing.T) {
 	opts := NewIndexOptions()
 	require.Equal(t, time.Hour, opts.SetBlockSize(time.Hour).BlockSize())
 }
+
+func TestIndexOptionsNumericTags(t *testing.T) {
+	opts := NewIndexOptions()
+	require.Nil(t, opts.NumericTags())
+	require.False(t, opts.IsNumericTag([]byte("port")))
+
+	opts = opts.SetNumericTags([]string{"port", "instance"})
+	require.Equal(t, []string{"instance", "port"}, opts.NumericTags())
+	require.True(t, opts.IsNumericTag([]byte("port")))
+	require.False(t, opts.IsNumericTag([]byte("host")))
+
+	require.True(t, opts.Equal(NewIndexOptions().SetNumericTags([]string{"instance", "port"})))
+	require.False(t, opts.Equal(NewIndexOptions()))
+}
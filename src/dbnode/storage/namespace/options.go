@@ -22,8 +22,10 @@ package namespace
 
 import (
 	"errors"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
 )
 
 const (
@@ -44,36 +46,76 @@ const (
 
 	// Namespace requires repair disabled by default
 	defaultRepairEnabled = false
+
+	// Namespace data is durable by default
+	defaultInMemoryOnly = false
+
+	// Namespace data is not encrypted at rest by default
+	defaultEncryptionEnabled = false
+
+	// Namespace timestamps are kept at nanosecond precision by default
+	defaultTimestampPrecision = time.Nanosecond
 )
 
 var (
 	errIndexBlockSizePositive                       = errors.New("index block size must positive")
 	errIndexBlockSizeTooLarge                       = errors.New("index block size needs to be <= namespace retention period")
 	errIndexBlockSizeMustBeAMultipleOfDataBlockSize = errors.New("index block size must be a multiple of data block size")
+	errQuotaMaxBytesNegative                        = errors.New("quota max bytes must not be negative")
+	errCompressionDictionarySampleSizePositive      = errors.New("compression dictionary sample size must be positive")
+	errCompressionDictionaryMaxBytesPositive        = errors.New("compression dictionary max bytes must be positive")
+	errInMemoryOnlyFlushEnabled                     = errors.New("in-memory only namespace must not have flush enabled")
+	errInMemoryOnlySnapshotEnabled                  = errors.New("in-memory only namespace must not have snapshot enabled")
+	errInMemoryOnlyWritesToCommitLog                = errors.New("in-memory only namespace must not write to commit log")
+	errInMemoryOnlyCloneSource                      = errors.New("in-memory only namespace must not be cloned from another namespace")
+	errShardingTagSubsetNoTagNames                  = errors.New("tag subset sharding requires at least one tag name")
+	errTimestampPrecisionInvalid                    = errors.New("timestamp precision must be one of 1s, 1ms, 1us or 1ns")
+	errContinuousQueryTargetNamespaceEmpty          = errors.New("continuous query target namespace must not be empty")
+	errContinuousQueryResolutionPositive            = errors.New("continuous query resolution must be positive")
+	errContinuousQueryResolutionNotBlockAligned     = errors.New("block size must be a multiple of continuous query resolution")
+	errContinuousQueryInMemoryOnly                  = errors.New("in-memory only namespace must not have continuous queries")
+	errRetentionTransitionResolutionPositive        = errors.New("retention transition resolution must be positive")
+	errRetentionTransitionResolutionNotBlockAligned = errors.New("block size must be a multiple of retention transition resolution")
+	errRetentionTransitionInMemoryOnly              = errors.New("in-memory only namespace must not have a retention transition")
 )
 
 type options struct {
-	bootstrapEnabled  bool
-	flushEnabled      bool
-	snapshotEnabled   bool
-	writesToCommitLog bool
-	cleanupEnabled    bool
-	repairEnabled     bool
-	retentionOpts     retention.Options
-	indexOpts         IndexOptions
+	bootstrapEnabled   bool
+	flushEnabled       bool
+	snapshotEnabled    bool
+	writesToCommitLog  bool
+	cleanupEnabled     bool
+	repairEnabled      bool
+	inMemoryOnly       bool
+	encryptionEnabled  bool
+	cloneSource        string
+	timestampPrecision time.Duration
+	retentionOpts      retention.Options
+	indexOpts          IndexOptions
+	quotaOpts          QuotaOptions
+	dictOpts           CompressionDictionaryOptions
+	shardingOpts       ShardingOptions
+	continuousQueries  []ContinuousQuery
+	transition         RetentionTransition
 }
 
 // NewOptions creates a new namespace options
 func NewOptions() Options {
 	return &options{
-		bootstrapEnabled:  defaultBootstrapEnabled,
-		flushEnabled:      defaultFlushEnabled,
-		snapshotEnabled:   defaultSnapshotEnabled,
-		writesToCommitLog: defaultWritesToCommitLog,
-		cleanupEnabled:    defaultCleanupEnabled,
-		repairEnabled:     defaultRepairEnabled,
-		retentionOpts:     retention.NewOptions(),
-		indexOpts:         NewIndexOptions(),
+		bootstrapEnabled:   defaultBootstrapEnabled,
+		flushEnabled:       defaultFlushEnabled,
+		snapshotEnabled:    defaultSnapshotEnabled,
+		writesToCommitLog:  defaultWritesToCommitLog,
+		cleanupEnabled:     defaultCleanupEnabled,
+		repairEnabled:      defaultRepairEnabled,
+		inMemoryOnly:       defaultInMemoryOnly,
+		encryptionEnabled:  defaultEncryptionEnabled,
+		timestampPrecision: defaultTimestampPrecision,
+		retentionOpts:      retention.NewOptions(),
+		indexOpts:          NewIndexOptions(),
+		quotaOpts:          NewQuotaOptions(),
+		dictOpts:           NewCompressionDictionaryOptions(),
+		shardingOpts:       NewShardingOptions(),
 	}
 }
 
@@ -81,6 +123,47 @@ func (o *options) Validate() error {
 	if err := o.retentionOpts.Validate(); err != nil {
 		return err
 	}
+	if o.quotaOpts.MaxBytes() < 0 {
+		return errQuotaMaxBytesNegative
+	}
+	if o.inMemoryOnly {
+		if o.flushEnabled {
+			return errInMemoryOnlyFlushEnabled
+		}
+		if o.snapshotEnabled {
+			return errInMemoryOnlySnapshotEnabled
+		}
+		if o.writesToCommitLog {
+			return errInMemoryOnlyWritesToCommitLog
+		}
+		if o.cloneSource != "" {
+			return errInMemoryOnlyCloneSource
+		}
+	}
+	if !ValidTimestampPrecision(o.timestampPrecision) {
+		return errTimestampPrecisionInvalid
+	}
+	if err := o.validateContinuousQueries(); err != nil {
+		return err
+	}
+	if err := o.validateRetentionTransition(); err != nil {
+		return err
+	}
+	if err := sharding.ValidateHashStrategy(o.shardingOpts.HashStrategy()); err != nil {
+		return err
+	}
+	if o.shardingOpts.HashStrategy() == sharding.TagSubsetHashStrategy &&
+		len(o.shardingOpts.TagNames()) == 0 {
+		return errShardingTagSubsetNoTagNames
+	}
+	if o.dictOpts.Enabled() {
+		if o.dictOpts.SampleSize() <= 0 {
+			return errCompressionDictionarySampleSizePositive
+		}
+		if o.dictOpts.MaxBytes() <= 0 {
+			return errCompressionDictionaryMaxBytesPositive
+		}
+	}
 	if !o.indexOpts.Enabled() {
 		return nil
 	}
@@ -108,8 +191,17 @@ func (o *options) Equal(value Options) bool {
 		o.snapshotEnabled == value.SnapshotEnabled() &&
 		o.cleanupEnabled == value.CleanupEnabled() &&
 		o.repairEnabled == value.RepairEnabled() &&
+		o.inMemoryOnly == value.InMemoryOnly() &&
+		o.encryptionEnabled == value.EncryptionEnabled() &&
+		o.cloneSource == value.CloneSource() &&
+		o.timestampPrecision == value.TimestampPrecision() &&
 		o.retentionOpts.Equal(value.RetentionOptions()) &&
-		o.indexOpts.Equal(value.IndexOptions())
+		o.indexOpts.Equal(value.IndexOptions()) &&
+		o.quotaOpts.Equal(value.QuotaOptions()) &&
+		o.dictOpts.Equal(value.CompressionDictionaryOptions()) &&
+		o.shardingOpts.Equal(value.ShardingOptions()) &&
+		continuousQueriesEqual(o.continuousQueries, value.ContinuousQueries()) &&
+		o.transition == value.RetentionTransition()
 }
 
 func (o *options) SetBootstrapEnabled(value bool) Options {
@@ -172,6 +264,46 @@ func (o *options) RepairEnabled() bool {
 	return o.repairEnabled
 }
 
+func (o *options) SetInMemoryOnly(value bool) Options {
+	opts := *o
+	opts.inMemoryOnly = value
+	return &opts
+}
+
+func (o *options) InMemoryOnly() bool {
+	return o.inMemoryOnly
+}
+
+func (o *options) SetEncryptionEnabled(value bool) Options {
+	opts := *o
+	opts.encryptionEnabled = value
+	return &opts
+}
+
+func (o *options) EncryptionEnabled() bool {
+	return o.encryptionEnabled
+}
+
+func (o *options) SetCloneSource(value string) Options {
+	opts := *o
+	opts.cloneSource = value
+	return &opts
+}
+
+func (o *options) CloneSource() string {
+	return o.cloneSource
+}
+
+func (o *options) SetTimestampPrecision(value time.Duration) Options {
+	opts := *o
+	opts.timestampPrecision = value
+	return &opts
+}
+
+func (o *options) TimestampPrecision() time.Duration {
+	return o.timestampPrecision
+}
+
 func (o *options) SetRetentionOptions(value retention.Options) Options {
 	opts := *o
 	opts.retentionOpts = value
@@ -191,3 +323,107 @@ func (o *options) SetIndexOptions(value IndexOptions) Options {
 func (o *options) IndexOptions() IndexOptions {
 	return o.indexOpts
 }
+
+func (o *options) SetQuotaOptions(value QuotaOptions) Options {
+	opts := *o
+	opts.quotaOpts = value
+	return &opts
+}
+
+func (o *options) QuotaOptions() QuotaOptions {
+	return o.quotaOpts
+}
+
+func (o *options) SetCompressionDictionaryOptions(value CompressionDictionaryOptions) Options {
+	opts := *o
+	opts.dictOpts = value
+	return &opts
+}
+
+func (o *options) CompressionDictionaryOptions() CompressionDictionaryOptions {
+	return o.dictOpts
+}
+
+func (o *options) SetShardingOptions(value ShardingOptions) Options {
+	opts := *o
+	opts.shardingOpts = value
+	return &opts
+}
+
+func (o *options) ShardingOptions() ShardingOptions {
+	return o.shardingOpts
+}
+
+func (o *options) SetContinuousQueries(value []ContinuousQuery) Options {
+	opts := *o
+	opts.continuousQueries = value
+	return &opts
+}
+
+func (o *options) ContinuousQueries() []ContinuousQuery {
+	return o.continuousQueries
+}
+
+func (o *options) validateContinuousQueries() error {
+	if len(o.continuousQueries) == 0 {
+		return nil
+	}
+	// Continuous queries are computed when blocks are flushed.
+	if o.inMemoryOnly {
+		return errContinuousQueryInMemoryOnly
+	}
+	blockSize := o.retentionOpts.BlockSize()
+	for _, q := range o.continuousQueries {
+		if q.TargetNamespace == "" {
+			return errContinuousQueryTargetNamespaceEmpty
+		}
+		if q.Resolution <= 0 {
+			return errContinuousQueryResolutionPositive
+		}
+		if blockSize%q.Resolution != 0 {
+			return errContinuousQueryResolutionNotBlockAligned
+		}
+		if err := ValidateContinuousQueryAggregation(q.Aggregation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *options) SetRetentionTransition(value RetentionTransition) Options {
+	opts := *o
+	opts.transition = value
+	return &opts
+}
+
+func (o *options) RetentionTransition() RetentionTransition {
+	return o.transition
+}
+
+func (o *options) validateRetentionTransition() error {
+	if o.transition.TargetNamespace == "" {
+		return nil
+	}
+	// Expired blocks are transitioned from their filesets.
+	if o.inMemoryOnly {
+		return errRetentionTransitionInMemoryOnly
+	}
+	if o.transition.Resolution <= 0 {
+		return errRetentionTransitionResolutionPositive
+	}
+	if o.retentionOpts.BlockSize()%o.transition.Resolution != 0 {
+		return errRetentionTransitionResolutionNotBlockAligned
+	}
+	return ValidateContinuousQueryAggregation(o.transition.Aggregation)
+}
+
+// ValidTimestampPrecision returns whether the value is a supported timestamp
+// precision, the precisions supported are the time units the encoder has a
+// time encoding scheme for.
+func ValidTimestampPrecision(value time.Duration) bool {
+	switch value {
+	case time.Second, time.Millisecond, time.Microsecond, time.Nanosecond:
+		return true
+	}
+	return false
+}
@@ -164,3 +164,72 @@ func TestOptionsValidateNoIndexing(t *testing.T) {
 	rOpts.EXPECT().Validate().Return(nil)
 	require.NoError(t, o1.Validate())
 }
+
+func TestOptionsValidateInMemoryOnly(t *testing.T) {
+	o1 := NewOptions().SetInMemoryOnly(true)
+	require.Equal(t, errInMemoryOnlyFlushEnabled, o1.Validate())
+
+	o1 = o1.SetFlushEnabled(false)
+	require.Equal(t, errInMemoryOnlyWritesToCommitLog, o1.Validate())
+
+	o1 = o1.SetWritesToCommitLog(false).SetSnapshotEnabled(true)
+	require.Equal(t, errInMemoryOnlySnapshotEnabled, o1.Validate())
+
+	o1 = o1.SetSnapshotEnabled(false).SetCloneSource("metrics")
+	require.Equal(t, errInMemoryOnlyCloneSource, o1.Validate())
+
+	o1 = o1.SetCloneSource("")
+	require.NoError(t, o1.Validate())
+	require.False(t, o1.Equal(o1.SetInMemoryOnly(false)))
+}
+
+func TestOptionsValidateTimestampPrecision(t *testing.T) {
+	o1 := NewOptions()
+	require.Equal(t, time.Nanosecond, o1.TimestampPrecision())
+
+	for _, precision := range []time.Duration{
+		time.Second, time.Millisecond, time.Microsecond, time.Nanosecond,
+	} {
+		require.NoError(t, o1.SetTimestampPrecision(precision).Validate())
+	}
+
+	for _, precision := range []time.Duration{0, 10 * time.Millisecond, time.Minute} {
+		require.Equal(t, errTimestampPrecisionInvalid,
+			o1.SetTimestampPrecision(precision).Validate())
+	}
+
+	require.False(t, o1.Equal(o1.SetTimestampPrecision(time.Second)))
+}
+
+func TestOptionsValidateRetentionTransition(t *testing.T) {
+	valid := RetentionTransition{
+		TargetNamespace: "metrics_5m",
+		Resolution:      5 * time.Minute,
+		Aggregation:     ContinuousQueryMean,
+	}
+	opts := NewOptions()
+	require.NoError(t, opts.SetRetentionTransition(RetentionTransition{}).Validate())
+	require.NoError(t, opts.SetRetentionTransition(valid).Validate())
+	require.False(t, opts.Equal(opts.SetRetentionTransition(valid)))
+
+	noResolution := valid
+	noResolution.Resolution = 0
+	require.Equal(t, errRetentionTransitionResolutionPositive,
+		opts.SetRetentionTransition(noResolution).Validate())
+
+	unaligned := valid
+	unaligned.Resolution = 7 * time.Minute
+	require.Equal(t, errRetentionTransitionResolutionNotBlockAligned,
+		opts.SetRetentionTransition(unaligned).Validate())
+
+	unknown := valid
+	unknown.Aggregation = ContinuousQueryAggregation(100)
+	require.Error(t, opts.SetRetentionTransition(unknown).Validate())
+
+	inMemory := opts.SetInMemoryOnly(true).
+		SetFlushEnabled(false).
+		SetSnapshotEnabled(false).
+		SetWritesToCommitLog(false)
+	require.Equal(t, errRetentionTransitionInMemoryOnly,
+		inMemory.SetRetentionTransition(valid).Validate())
+}
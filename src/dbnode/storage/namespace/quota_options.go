@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// defaultQuotaMaxBytes disables the quota by default.
+	defaultQuotaMaxBytes int64
+
+	// defaultQuotaExceededAction only warns by default.
+	defaultQuotaExceededAction = QuotaExceededWarn
+
+	validQuotaExceededActions = []QuotaExceededAction{
+		QuotaExceededWarn,
+		QuotaExceededRejectNewSeries,
+		QuotaExceededRejectWrites,
+	}
+)
+
+func (a QuotaExceededAction) String() string {
+	switch a {
+	case QuotaExceededWarn:
+		return "warn"
+	case QuotaExceededRejectNewSeries:
+		return "reject-new-series"
+	case QuotaExceededRejectWrites:
+		return "reject-writes"
+	}
+	return "unknown"
+}
+
+// UnmarshalYAML unmarshals a QuotaExceededAction from a string.
+func (a *QuotaExceededAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	if str == "" {
+		*a = defaultQuotaExceededAction
+		return nil
+	}
+	for _, valid := range validQuotaExceededActions {
+		if strings.EqualFold(str, valid.String()) {
+			*a = valid
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid quota exceeded action '%s' valid actions are: %v",
+		str, validQuotaExceededActions)
+}
+
+type quotaOpts struct {
+	maxBytes       int64
+	exceededAction QuotaExceededAction
+}
+
+// NewQuotaOptions returns a new QuotaOptions.
+func NewQuotaOptions() QuotaOptions {
+	return &quotaOpts{
+		maxBytes:       defaultQuotaMaxBytes,
+		exceededAction: defaultQuotaExceededAction,
+	}
+}
+
+func (q *quotaOpts) Equal(value QuotaOptions) bool {
+	return q.MaxBytes() == value.MaxBytes() &&
+		q.ExceededAction() == value.ExceededAction()
+}
+
+func (q *quotaOpts) SetMaxBytes(value int64) QuotaOptions {
+	qo := *q
+	qo.maxBytes = value
+	return &qo
+}
+
+func (q *quotaOpts) MaxBytes() int64 {
+	return q.maxBytes
+}
+
+func (q *quotaOpts) SetExceededAction(value QuotaExceededAction) QuotaOptions {
+	qo := *q
+	qo.exceededAction = value
+	return &qo
+}
+
+func (q *quotaOpts) ExceededAction() QuotaExceededAction {
+	return q.exceededAction
+}
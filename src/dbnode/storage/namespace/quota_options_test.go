@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestQuotaOptionsEqual(t *testing.T) {
+	opts := NewQuotaOptions()
+	require.True(t, opts.Equal(opts.SetMaxBytes(0)))
+	require.False(t, opts.SetMaxBytes(1).Equal(opts.SetMaxBytes(2)))
+	require.False(t, opts.SetExceededAction(QuotaExceededWarn).Equal(
+		opts.SetExceededAction(QuotaExceededRejectWrites)))
+}
+
+func TestQuotaOptionsDefaults(t *testing.T) {
+	opts := NewQuotaOptions()
+	require.Equal(t, int64(0), opts.MaxBytes())
+	require.Equal(t, QuotaExceededWarn, opts.ExceededAction())
+}
+
+func TestQuotaExceededActionUnmarshalYAML(t *testing.T) {
+	for _, action := range validQuotaExceededActions {
+		var cfg QuotaConfiguration
+		str := "maxBytes: 10\nexceededAction: " + action.String() + "\n"
+		require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+		require.Equal(t, int64(10), cfg.MaxBytes)
+		require.Equal(t, action, cfg.ExceededAction)
+	}
+
+	var cfg QuotaConfiguration
+	require.Error(t, yaml.Unmarshal([]byte("exceededAction: foo\n"), &cfg))
+}
+
+func TestOptionsValidateQuotaMaxBytesNegative(t *testing.T) {
+	opts := NewOptions().SetQuotaOptions(NewQuotaOptions().SetMaxBytes(-1))
+	require.Equal(t, errQuotaMaxBytesNegative, opts.Validate())
+}
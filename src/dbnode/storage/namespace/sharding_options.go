@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"github.com/m3db/m3/src/dbnode/sharding"
+)
+
+type shardingOpts struct {
+	hashStrategy sharding.HashStrategy
+	tagNames     []string
+}
+
+// NewShardingOptions returns a new ShardingOptions.
+func NewShardingOptions() ShardingOptions {
+	return &shardingOpts{
+		hashStrategy: sharding.DefaultHashStrategy,
+	}
+}
+
+func (s *shardingOpts) Equal(value ShardingOptions) bool {
+	if s.HashStrategy() != value.HashStrategy() {
+		return false
+	}
+	tagNames := value.TagNames()
+	if len(s.tagNames) != len(tagNames) {
+		return false
+	}
+	for i := range s.tagNames {
+		if s.tagNames[i] != tagNames[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *shardingOpts) SetHashStrategy(value sharding.HashStrategy) ShardingOptions {
+	so := *s
+	so.hashStrategy = value
+	return &so
+}
+
+func (s *shardingOpts) HashStrategy() sharding.HashStrategy {
+	return s.hashStrategy
+}
+
+func (s *shardingOpts) SetTagNames(value []string) ShardingOptions {
+	so := *s
+	so.tagNames = value
+	return &so
+}
+
+func (s *shardingOpts) TagNames() []string {
+	return s.tagNames
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/sharding"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestShardingOptionsEqual(t *testing.T) {
+	opts := NewShardingOptions()
+	require.True(t, opts.Equal(opts.SetHashStrategy(sharding.Murmur3HashStrategy)))
+	require.False(t, opts.Equal(opts.SetHashStrategy(sharding.JumpHashStrategy)))
+	require.False(t, opts.SetTagNames([]string{"a"}).Equal(
+		opts.SetTagNames([]string{"b"})))
+	require.False(t, opts.SetTagNames([]string{"a"}).Equal(opts))
+}
+
+func TestShardingConfiguration(t *testing.T) {
+	var cfg ShardingConfiguration
+	str := "hashStrategy: tag_subset\ntagNames:\n  - service\n"
+	require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+
+	opts := cfg.Options()
+	require.Equal(t, sharding.TagSubsetHashStrategy, opts.HashStrategy())
+	require.Equal(t, []string{"service"}, opts.TagNames())
+
+	require.Error(t, yaml.Unmarshal([]byte("hashStrategy: foo\n"), &cfg))
+}
+
+func TestOptionsValidateShardingTagSubsetNoTagNames(t *testing.T) {
+	opts := NewOptions().SetShardingOptions(NewShardingOptions().
+		SetHashStrategy(sharding.TagSubsetHashStrategy))
+	require.Equal(t, errShardingTagSubsetNoTagNames, opts.Validate())
+
+	opts = opts.SetShardingOptions(opts.ShardingOptions().
+		SetTagNames([]string{"service"}))
+	require.NoError(t, opts.Validate())
+}
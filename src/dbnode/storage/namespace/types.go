@@ -24,6 +24,8 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/x/fault"
 	"github.com/m3db/m3cluster/client"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/instrument"
@@ -73,6 +75,42 @@ type Options interface {
 	// RepairEnabled returns whether the data for this namespace needs to be repaired
 	RepairEnabled() bool
 
+	// SetInMemoryOnly sets whether this namespace keeps its data purely in memory,
+	// an in-memory only namespace never flushes, snapshots or writes to the commit
+	// log and can only be bootstrapped from peers, so its data is lost if every
+	// replica restarts
+	SetInMemoryOnly(value bool) Options
+
+	// InMemoryOnly returns whether this namespace keeps its data purely in memory
+	InMemoryOnly() bool
+
+	// SetEncryptionEnabled sets whether the data filesets of this namespace are
+	// encrypted at rest, this requires an encryption key provider to be set on
+	// the filesystem options
+	SetEncryptionEnabled(value bool) Options
+
+	// EncryptionEnabled returns whether the data filesets of this namespace are
+	// encrypted at rest
+	EncryptionEnabled() bool
+
+	// SetCloneSource sets the namespace this namespace is cloned from, the
+	// filesets of the source are linked into this namespace copy-on-write
+	// when it is first created on a node
+	SetCloneSource(value string) Options
+
+	// CloneSource returns the namespace this namespace is cloned from, empty
+	// if it was not cloned
+	CloneSource() string
+
+	// SetTimestampPrecision sets the precision of the timestamps of this
+	// namespace, one of a second, millisecond, microsecond or nanosecond,
+	// writes are encoded at this precision and clients reject writes with
+	// timestamps that are not aligned to it
+	SetTimestampPrecision(value time.Duration) Options
+
+	// TimestampPrecision returns the precision of the timestamps of this namespace
+	TimestampPrecision() time.Duration
+
 	// SetRetentionOptions sets the retention options for this namespace
 	SetRetentionOptions(value retention.Options) Options
 
@@ -84,6 +122,40 @@ type Options interface {
 
 	// IndexOptions returns the IndexOptions.
 	IndexOptions() IndexOptions
+
+	// SetQuotaOptions sets the QuotaOptions.
+	SetQuotaOptions(value QuotaOptions) Options
+
+	// QuotaOptions returns the QuotaOptions.
+	QuotaOptions() QuotaOptions
+
+	// SetCompressionDictionaryOptions sets the CompressionDictionaryOptions.
+	SetCompressionDictionaryOptions(value CompressionDictionaryOptions) Options
+
+	// CompressionDictionaryOptions returns the CompressionDictionaryOptions.
+	CompressionDictionaryOptions() CompressionDictionaryOptions
+
+	// SetShardingOptions sets the ShardingOptions.
+	SetShardingOptions(value ShardingOptions) Options
+
+	// ShardingOptions returns the ShardingOptions.
+	ShardingOptions() ShardingOptions
+
+	// SetContinuousQueries sets the continuous queries whose aggregates of
+	// each block of this namespace are written to another namespace when
+	// the block is flushed.
+	SetContinuousQueries(value []ContinuousQuery) Options
+
+	// ContinuousQueries returns the continuous queries of this namespace.
+	ContinuousQueries() []ContinuousQuery
+
+	// SetRetentionTransition sets the retention transition that downsamples
+	// the expired blocks of this namespace into another namespace when they
+	// are cleaned up instead of only deleting them.
+	SetRetentionTransition(value RetentionTransition) Options
+
+	// RetentionTransition returns the retention transition of this namespace.
+	RetentionTransition() RetentionTransition
 }
 
 // IndexOptions controls the indexing options for a namespace.
@@ -102,6 +174,141 @@ type IndexOptions interface {
 
 	// BlockSize returns the block size.
 	BlockSize() time.Duration
+
+	// SetNumericTags sets the names of the tags whose values are numeric,
+	// only these tags may be queried with numeric range matchers.
+	SetNumericTags(value []string) IndexOptions
+
+	// NumericTags returns the names of the tags whose values are numeric.
+	NumericTags() []string
+
+	// IsNumericTag returns whether the tag with the given name is numeric.
+	IsNumericTag(name []byte) bool
+}
+
+// QuotaExceededAction is the action taken when a namespace exceeds its quota.
+type QuotaExceededAction int
+
+const (
+	// QuotaExceededWarn only logs and emits metrics when the quota is exceeded.
+	QuotaExceededWarn QuotaExceededAction = iota
+	// QuotaExceededRejectNewSeries rejects writes for new series when the
+	// quota is exceeded.
+	QuotaExceededRejectNewSeries
+	// QuotaExceededRejectWrites rejects all writes when the quota is exceeded.
+	QuotaExceededRejectWrites
+)
+
+// QuotaOptions controls the storage quota for a namespace.
+type QuotaOptions interface {
+	// Equal returns true if the provide value is equal to this one.
+	Equal(value QuotaOptions) bool
+
+	// SetMaxBytes sets the max bytes on disk, zero disables the quota.
+	SetMaxBytes(value int64) QuotaOptions
+
+	// MaxBytes returns the max bytes on disk, zero disables the quota.
+	MaxBytes() int64
+
+	// SetExceededAction sets the action taken when the quota is exceeded.
+	SetExceededAction(value QuotaExceededAction) QuotaOptions
+
+	// ExceededAction returns the action taken when the quota is exceeded.
+	ExceededAction() QuotaExceededAction
+}
+
+// CompressionDictionaryOptions controls training of compression dictionaries
+// over the series IDs and tags written to the filesets of a namespace.
+type CompressionDictionaryOptions interface {
+	// Equal returns true if the provide value is equal to this one.
+	Equal(value CompressionDictionaryOptions) bool
+
+	// SetEnabled sets whether dictionaries are trained and used.
+	SetEnabled(value bool) CompressionDictionaryOptions
+
+	// Enabled returns whether dictionaries are trained and used.
+	Enabled() bool
+
+	// SetSampleSize sets the max number of series sampled to train a dictionary.
+	SetSampleSize(value int) CompressionDictionaryOptions
+
+	// SampleSize returns the max number of series sampled to train a dictionary.
+	SampleSize() int
+
+	// SetMaxBytes sets the max size of a trained dictionary.
+	SetMaxBytes(value int) CompressionDictionaryOptions
+
+	// MaxBytes returns the max size of a trained dictionary.
+	MaxBytes() int
+}
+
+// ShardingOptions controls how the series of a namespace are assigned to shards.
+type ShardingOptions interface {
+	// Equal returns true if the provide value is equal to this one.
+	Equal(value ShardingOptions) bool
+
+	// SetHashStrategy sets the strategy used to hash series IDs to shards.
+	SetHashStrategy(value sharding.HashStrategy) ShardingOptions
+
+	// HashStrategy returns the strategy used to hash series IDs to shards.
+	HashStrategy() sharding.HashStrategy
+
+	// SetTagNames sets the names of the tags hashed by the tag subset
+	// strategy, all series with the same values for these tags are assigned
+	// to the same shard.
+	SetTagNames(value []string) ShardingOptions
+
+	// TagNames returns the names of the tags hashed by the tag subset strategy.
+	TagNames() []string
+}
+
+// ContinuousQueryAggregation is the aggregation a continuous query applies
+// to the datapoints of each resolution window.
+type ContinuousQueryAggregation int
+
+const (
+	// ContinuousQueryMax keeps the largest value of each window.
+	ContinuousQueryMax ContinuousQueryAggregation = iota
+	// ContinuousQueryMin keeps the smallest value of each window.
+	ContinuousQueryMin
+	// ContinuousQuerySum keeps the sum of the values of each window.
+	ContinuousQuerySum
+	// ContinuousQueryCount keeps the number of values of each window.
+	ContinuousQueryCount
+	// ContinuousQueryMean keeps the mean of the values of each window.
+	ContinuousQueryMean
+	// ContinuousQueryLast keeps the last value of each window.
+	ContinuousQueryLast
+)
+
+// ContinuousQuery describes an aggregate of a namespace that is computed
+// when each block of the namespace is flushed and written to a target
+// namespace, every series is aggregated into windows of the resolution
+// and written to the target with the same ID and tags at the end of each
+// window.
+type ContinuousQuery struct {
+	// TargetNamespace is the namespace the aggregated series are written to.
+	TargetNamespace string
+	// Resolution is the size of the windows the series are aggregated into.
+	Resolution time.Duration
+	// Aggregation is the aggregation applied to the values of each window.
+	Aggregation ContinuousQueryAggregation
+}
+
+// RetentionTransition describes a namespace with a lower resolution that the
+// expired blocks of a namespace are downsampled into during cleanup, such as
+// raw datapoints that are downsampled into five minute windows once they are
+// older than the retention period. Every series is aggregated into windows of
+// the resolution and written to the target with the same ID and tags at the
+// start of each window, no transition is made if the target namespace is
+// empty.
+type RetentionTransition struct {
+	// TargetNamespace is the namespace the downsampled series are written to.
+	TargetNamespace string
+	// Resolution is the size of the windows the series are downsampled to.
+	Resolution time.Duration
+	// Aggregation is the aggregation applied to the values of each window.
+	Aggregation ContinuousQueryAggregation
 }
 
 // Metadata represents namespace metadata information
@@ -188,4 +395,12 @@ type DynamicOptions interface {
 
 	// InitTimeout returns the waiting time for dynamic topology to be initialized
 	InitTimeout() time.Duration
+
+	// SetFaultInjector sets the fault injector used by tests to drop
+	// namespace registry updates
+	SetFaultInjector(value fault.Injector) DynamicOptions
+
+	// FaultInjector returns the fault injector used by tests to drop
+	// namespace registry updates
+	FaultInjector() fault.Injector
 }
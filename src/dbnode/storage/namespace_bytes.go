@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// rawDatapointBytes is the size of a datapoint before it is encoded,
+	// i.e. an eight byte timestamp and an eight byte value.
+	rawDatapointBytes = 16
+
+	// m3tszCodec is the name of the codec series are encoded with.
+	m3tszCodec = "m3tsz"
+)
+
+// namespaceBytes tracks the raw bytes of the datapoints written to a
+// namespace and the encoded bytes flushed for them, so that the compression
+// ratio of the blocks of the namespace can be reported once they are
+// flushed.
+type namespaceBytes struct {
+	sync.RWMutex
+
+	blockSize    time.Duration
+	flushEnabled bool
+
+	// since is the first block start whose writes are all observed, the
+	// blocks before it may hold bootstrapped data or data of shards that
+	// were assigned after the block started.
+	since           time.Time
+	rawBytesByBlock map[xtime.UnixNano]*int64
+
+	metrics namespaceBytesMetrics
+}
+
+type namespaceBytesMetrics struct {
+	rawIn            tally.Counter
+	flushed          tally.Counter
+	compressionRatio tally.Gauge
+}
+
+func newNamespaceBytesMetrics(scope tally.Scope) namespaceBytesMetrics {
+	subScope := scope.SubScope("bytes")
+	codecScope := subScope.Tagged(map[string]string{"codec": m3tszCodec})
+	return namespaceBytesMetrics{
+		rawIn:            subScope.Counter("raw-in"),
+		flushed:          subScope.Counter("flushed"),
+		compressionRatio: codecScope.Gauge("compression-ratio"),
+	}
+}
+
+func newNamespaceBytes(
+	metadata namespace.Metadata,
+	now time.Time,
+	scope tally.Scope,
+) *namespaceBytes {
+	b := &namespaceBytes{
+		blockSize:       metadata.Options().RetentionOptions().BlockSize(),
+		flushEnabled:    metadata.Options().FlushEnabled(),
+		rawBytesByBlock: make(map[xtime.UnixNano]*int64),
+		metrics:         newNamespaceBytesMetrics(scope),
+	}
+	b.resetSince(now)
+	return b
+}
+
+// resetSince only tracks the raw bytes of the blocks that start after now,
+// it is called when the shards of the namespace change.
+func (b *namespaceBytes) resetSince(now time.Time) {
+	b.Lock()
+	b.since = now.Truncate(b.blockSize).Add(b.blockSize)
+	for blockStart := range b.rawBytesByBlock {
+		if blockStart.ToTime().Before(b.since) {
+			delete(b.rawBytesByBlock, blockStart)
+		}
+	}
+	b.Unlock()
+}
+
+// recordWrite records the raw bytes of a datapoint written to the
+// namespace.
+func (b *namespaceBytes) recordWrite(timestamp time.Time, annotation []byte) {
+	rawBytes := int64(rawDatapointBytes + len(annotation))
+	b.metrics.rawIn.Inc(rawBytes)
+	if !b.flushEnabled {
+		return
+	}
+
+	blockStart := timestamp.Truncate(b.blockSize)
+	key := xtime.ToUnixNano(blockStart)
+	b.RLock()
+	if blockStart.Before(b.since) {
+		b.RUnlock()
+		return
+	}
+	blockRawBytes, ok := b.rawBytesByBlock[key]
+	b.RUnlock()
+
+	if !ok {
+		b.Lock()
+		blockRawBytes, ok = b.rawBytesByBlock[key]
+		if !ok {
+			blockRawBytes = new(int64)
+			b.rawBytesByBlock[key] = blockRawBytes
+		}
+		b.Unlock()
+	}
+	atomic.AddInt64(blockRawBytes, rawBytes)
+}
+
+// recordFlush records the encoded bytes flushed for a block, the
+// compression ratio of the block is only reported if every shard flushed
+// the block at once and all of its writes were observed.
+func (b *namespaceBytes) recordFlush(
+	blockStart time.Time,
+	flushedBytes int64,
+	complete bool,
+) {
+	b.metrics.flushed.Inc(flushedBytes)
+
+	b.Lock()
+	blockRawBytes, ok := b.rawBytesByBlock[xtime.ToUnixNano(blockStart)]
+	// Flushes are in order of block start so the blocks before the flushed
+	// block are never flushed again.
+	for key := range b.rawBytesByBlock {
+		if !key.ToTime().After(blockStart) {
+			delete(b.rawBytesByBlock, key)
+		}
+	}
+	b.Unlock()
+
+	if !ok || !complete || flushedBytes == 0 {
+		return
+	}
+	rawBytes := atomic.LoadInt64(blockRawBytes)
+	b.metrics.compressionRatio.Update(float64(rawBytes) / float64(flushedBytes))
+}
+
+// bytesCountingDataFlush counts the encoded bytes of the segments
+// persisted by a data flush.
+type bytesCountingDataFlush struct {
+	persist.DataFlush
+
+	bytes int64
+}
+
+func newBytesCountingDataFlush(flush persist.DataFlush) *bytesCountingDataFlush {
+	return &bytesCountingDataFlush{DataFlush: flush}
+}
+
+func (f *bytesCountingDataFlush) PrepareData(
+	opts persist.DataPrepareOptions,
+) (persist.PreparedDataPersist, error) {
+	prepared, err := f.DataFlush.PrepareData(opts)
+	if err != nil {
+		return prepared, err
+	}
+
+	persistFn := prepared.Persist
+	prepared.Persist = func(
+		id ident.ID,
+		tags ident.Tags,
+		segment ts.Segment,
+		checksum uint32,
+	) error {
+		if err := persistFn(id, tags, segment, checksum); err != nil {
+			return err
+		}
+		atomic.AddInt64(&f.bytes, int64(segment.Len()))
+		return nil
+	}
+	return prepared, nil
+}
+
+// Bytes returns the encoded bytes of the segments persisted so far.
+func (f *bytesCountingDataFlush) Bytes() int64 {
+	return atomic.LoadInt64(&f.bytes)
+}
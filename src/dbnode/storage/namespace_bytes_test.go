@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestNamespaceBytes(
+	t *testing.T,
+	now time.Time,
+) (*namespaceBytes, tally.TestScope) {
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID, defaultTestNs1Opts)
+	require.NoError(t, err)
+
+	scope := tally.NewTestScope("", nil)
+	return newNamespaceBytes(metadata, now, scope), scope
+}
+
+func TestNamespaceBytesCompressionRatio(t *testing.T) {
+	blockSize := defaultTestNs1Opts.RetentionOptions().BlockSize()
+	now := time.Now().Truncate(blockSize)
+	b, scope := newTestNamespaceBytes(t, now)
+
+	// Writes to the block the namespace was created in are counted but the
+	// block is not tracked as it may hold data that was not written.
+	b.recordWrite(now, nil)
+
+	blockStart := now.Add(blockSize)
+	for i := 0; i < 10; i++ {
+		b.recordWrite(blockStart.Add(time.Duration(i)*time.Second), []byte("ab"))
+	}
+	require.Len(t, b.rawBytesByBlock, 1)
+
+	b.recordFlush(now, 4, true)
+	snapshot := scope.Snapshot()
+	require.Equal(t, int64(16+10*18), snapshot.Counters()["bytes.raw-in+"].Value())
+	require.Equal(t, int64(4), snapshot.Counters()["bytes.flushed+"].Value())
+	_, ok := snapshot.Gauges()["bytes.compression-ratio+codec=m3tsz"]
+	require.False(t, ok)
+
+	b.recordFlush(blockStart, 45, true)
+	snapshot = scope.Snapshot()
+	require.Equal(t, int64(49), snapshot.Counters()["bytes.flushed+"].Value())
+	require.Equal(t, 4.0, snapshot.Gauges()["bytes.compression-ratio+codec=m3tsz"].Value())
+	require.Len(t, b.rawBytesByBlock, 0)
+}
+
+func TestNamespaceBytesIncompleteFlush(t *testing.T) {
+	blockSize := defaultTestNs1Opts.RetentionOptions().BlockSize()
+	now := time.Now().Truncate(blockSize)
+	b, scope := newTestNamespaceBytes(t, now)
+
+	blockStart := now.Add(blockSize)
+	b.recordWrite(blockStart, nil)
+	b.recordFlush(blockStart, 4, false)
+
+	_, ok := scope.Snapshot().Gauges()["bytes.compression-ratio+codec=m3tsz"]
+	require.False(t, ok)
+	require.Len(t, b.rawBytesByBlock, 0)
+}
+
+func TestNamespaceBytesResetSince(t *testing.T) {
+	blockSize := defaultTestNs1Opts.RetentionOptions().BlockSize()
+	now := time.Now().Truncate(blockSize)
+	b, _ := newTestNamespaceBytes(t, now)
+
+	blockStart := now.Add(blockSize)
+	b.recordWrite(blockStart, nil)
+	require.Len(t, b.rawBytesByBlock, 1)
+
+	b.resetSince(blockStart)
+	require.Len(t, b.rawBytesByBlock, 0)
+	b.recordWrite(blockStart, nil)
+	require.Len(t, b.rawBytesByBlock, 0)
+}
+
+func TestBytesCountingDataFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var persisted int
+	flush := persist.NewMockDataFlush(ctrl)
+	flush.EXPECT().PrepareData(gomock.Any()).Return(persist.PreparedDataPersist{
+		Persist: func(ident.ID, ident.Tags, ts.Segment, uint32) error {
+			persisted++
+			return nil
+		},
+	}, nil)
+
+	countingFlush := newBytesCountingDataFlush(flush)
+	prepared, err := countingFlush.PrepareData(persist.DataPrepareOptions{})
+	require.NoError(t, err)
+
+	head := checked.NewBytes([]byte("abc"), nil)
+	tail := checked.NewBytes([]byte("de"), nil)
+	segment := ts.NewSegment(head, tail, ts.FinalizeNone)
+	require.NoError(t, prepared.Persist(ident.StringID("foo"), ident.Tags{}, segment, 0))
+	require.NoError(t, prepared.Persist(ident.StringID("bar"), ident.Tags{}, segment, 0))
+
+	require.Equal(t, 2, persisted)
+	require.Equal(t, int64(10), countingFlush.Bytes())
+}
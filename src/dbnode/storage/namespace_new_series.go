@@ -0,0 +1,240 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/search/query"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+const defaultNewSeriesSubscriptionBufferSize = 4096
+
+var (
+	errNewSeriesSubscriptionInvalidBufferSize = errors.New("new series subscription buffer size must be non-negative")
+)
+
+// namespaceNewSeriesSubscriptions fans out series created in a namespace to
+// the subscriptions whose query matches the series tags. Notifications are
+// delivered without blocking the shard, a subscriber that falls behind has
+// new series dropped rather than slowing down inserts.
+type namespaceNewSeriesSubscriptions struct {
+	sync.RWMutex
+
+	subs    map[*newSeriesSubscription]struct{}
+	numSubs int32
+
+	metrics newSeriesSubscriptionsMetrics
+}
+
+type newSeriesSubscriptionsMetrics struct {
+	subscribed tally.Counter
+	notified   tally.Counter
+	dropped    tally.Counter
+}
+
+func newNamespaceNewSeriesSubscriptions(
+	scope tally.Scope,
+) *namespaceNewSeriesSubscriptions {
+	scope = scope.SubScope("new-series-subscriptions")
+	return &namespaceNewSeriesSubscriptions{
+		subs: make(map[*newSeriesSubscription]struct{}),
+		metrics: newSeriesSubscriptionsMetrics{
+			subscribed: scope.Counter("subscribed"),
+			notified:   scope.Counter("notified"),
+			dropped:    scope.Counter("dropped"),
+		},
+	}
+}
+
+func (s *namespaceNewSeriesSubscriptions) subscribe(
+	q index.Query,
+	opts NewSeriesSubscriptionOptions,
+) (NewSeriesSubscription, error) {
+	if opts.BufferSize < 0 {
+		return nil, errNewSeriesSubscriptionInvalidBufferSize
+	}
+	matcher, err := query.NewDocumentMatcher(q.SearchQuery())
+	if err != nil {
+		return nil, err
+	}
+
+	bufferSize := opts.BufferSize
+	if bufferSize == 0 {
+		bufferSize = defaultNewSeriesSubscriptionBufferSize
+	}
+
+	var shards map[uint32]struct{}
+	if len(opts.Shards) > 0 {
+		shards = make(map[uint32]struct{}, len(opts.Shards))
+		for _, shard := range opts.Shards {
+			shards[shard] = struct{}{}
+		}
+	}
+
+	sub := &newSeriesSubscription{
+		parent:  s,
+		matcher: matcher,
+		shards:  shards,
+		ch:      make(chan NewSeries, bufferSize),
+	}
+
+	s.Lock()
+	s.subs[sub] = struct{}{}
+	atomic.StoreInt32(&s.numSubs, int32(len(s.subs)))
+	s.Unlock()
+
+	s.metrics.subscribed.Inc(1)
+	return sub, nil
+}
+
+func (s *namespaceNewSeriesSubscriptions) unsubscribe(sub *newSeriesSubscription) {
+	s.Lock()
+	delete(s.subs, sub)
+	atomic.StoreInt32(&s.numSubs, int32(len(s.subs)))
+	s.Unlock()
+}
+
+// notify is called by shards as new series are inserted and must be cheap
+// when there are no subscriptions as it is called with the shard lock held.
+func (s *namespaceNewSeriesSubscriptions) notify(
+	shard uint32,
+	id ident.ID,
+	tags ident.Tags,
+) {
+	if atomic.LoadInt32(&s.numSubs) == 0 {
+		return
+	}
+
+	d, err := convert.FromMetricNoClone(id, tags)
+	if err != nil {
+		return
+	}
+
+	var (
+		series  NewSeries
+		cloned  bool
+		matched int64
+		dropped int64
+	)
+	s.RLock()
+	for sub := range s.subs {
+		if !sub.matches(shard, d) {
+			continue
+		}
+		if !cloned {
+			// Only copy the series once it is known that a subscriber needs it.
+			series = newSeriesFromDocument(shard, d)
+			cloned = true
+		}
+		if sub.deliver(series) {
+			matched++
+		} else {
+			dropped++
+		}
+	}
+	s.RUnlock()
+
+	if matched > 0 {
+		s.metrics.notified.Inc(matched)
+	}
+	if dropped > 0 {
+		s.metrics.dropped.Inc(dropped)
+	}
+}
+
+func newSeriesFromDocument(shard uint32, d doc.Document) NewSeries {
+	tags := make([]ident.Tag, 0, len(d.Fields))
+	for _, f := range d.Fields {
+		tags = append(tags, ident.Tag{
+			Name:  ident.BytesID(append([]byte(nil), f.Name...)),
+			Value: ident.BytesID(append([]byte(nil), f.Value...)),
+		})
+	}
+	return NewSeries{
+		Shard: shard,
+		ID:    ident.BytesID(append([]byte(nil), d.ID...)),
+		Tags:  ident.NewTags(tags...),
+	}
+}
+
+type newSeriesSubscription struct {
+	sync.RWMutex
+
+	parent  *namespaceNewSeriesSubscriptions
+	matcher query.DocumentMatcher
+	shards  map[uint32]struct{}
+	ch      chan NewSeries
+	dropped int64
+	closed  bool
+}
+
+func (s *newSeriesSubscription) matches(shard uint32, d doc.Document) bool {
+	if s.shards != nil {
+		if _, ok := s.shards[shard]; !ok {
+			return false
+		}
+	}
+	return s.matcher.Matches(d)
+}
+
+func (s *newSeriesSubscription) deliver(series NewSeries) bool {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return true
+	}
+	select {
+	case s.ch <- series:
+		return true
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+		return false
+	}
+}
+
+func (s *newSeriesSubscription) C() <-chan NewSeries {
+	return s.ch
+}
+
+func (s *newSeriesSubscription) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+func (s *newSeriesSubscription) Close() {
+	s.parent.unsubscribe(s)
+
+	s.Lock()
+	defer s.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+}
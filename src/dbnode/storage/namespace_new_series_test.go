@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	m3ninxidx "github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestNamespaceNewSeriesSubscriptions(t *testing.T) {
+	subs := newNamespaceNewSeriesSubscriptions(tally.NoopScope)
+
+	q := index.Query{m3ninxidx.NewTermQuery([]byte("app"), []byte("foo"))}
+	sub, err := subs.subscribe(q, NewSeriesSubscriptionOptions{
+		Shards:     []uint32{1},
+		BufferSize: 1,
+	})
+	require.NoError(t, err)
+
+	fooTags := ident.NewTags(ident.StringTag("app", "foo"))
+	barTags := ident.NewTags(ident.StringTag("app", "bar"))
+
+	// Only series matching the query in the subscribed shards are delivered.
+	subs.notify(1, ident.StringID("bar"), barTags)
+	subs.notify(2, ident.StringID("foo-2"), fooTags)
+	subs.notify(1, ident.StringID("foo-1"), fooTags)
+
+	series := <-sub.C()
+	require.Equal(t, uint32(1), series.Shard)
+	require.Equal(t, "foo-1", series.ID.String())
+	require.True(t, series.Tags.Equal(fooTags))
+
+	// Series are dropped once the buffer is full.
+	subs.notify(1, ident.StringID("foo-3"), fooTags)
+	subs.notify(1, ident.StringID("foo-4"), fooTags)
+	require.Equal(t, int64(1), sub.Dropped())
+
+	sub.Close()
+	series, ok := <-sub.C()
+	require.True(t, ok)
+	require.Equal(t, "foo-3", series.ID.String())
+	_, ok = <-sub.C()
+	require.False(t, ok)
+
+	// Notifying after close is a no-op.
+	subs.notify(1, ident.StringID("foo-5"), fooTags)
+	require.Equal(t, int32(0), subs.numSubs)
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errNamespaceQuotaExceeded = errors.New("namespace storage quota exceeded")
+)
+
+type namespaceDiskUsageFn func(
+	filePathPrefix string,
+	namespace ident.ID,
+) (fs.NamespaceDiskUsage, error)
+
+// namespaceQuota tracks the bytes on disk used by a namespace and enforces
+// the namespace quota options when the quota is exceeded.
+type namespaceQuota struct {
+	sync.RWMutex
+
+	namespace      ident.ID
+	opts           namespace.QuotaOptions
+	filePathPrefix string
+	diskUsageFn    namespaceDiskUsageFn
+	log            xlog.Logger
+
+	usedBytes int64
+	exceeded  bool
+
+	metrics namespaceQuotaMetrics
+}
+
+type namespaceQuotaMetrics struct {
+	usedBytes         tally.Gauge
+	maxBytes          tally.Gauge
+	exceeded          tally.Gauge
+	rejectedWrites    tally.Counter
+	rejectedNewSeries tally.Counter
+	usageErrors       tally.Counter
+}
+
+func newNamespaceQuotaMetrics(scope tally.Scope) namespaceQuotaMetrics {
+	subScope := scope.SubScope("quota")
+	return namespaceQuotaMetrics{
+		usedBytes:         subScope.Gauge("used-bytes"),
+		maxBytes:          subScope.Gauge("max-bytes"),
+		exceeded:          subScope.Gauge("exceeded"),
+		rejectedWrites:    subScope.Counter("rejected-writes"),
+		rejectedNewSeries: subScope.Counter("rejected-new-series"),
+		usageErrors:       subScope.Counter("usage-errors"),
+	}
+}
+
+func newNamespaceQuota(
+	metadata namespace.Metadata,
+	opts Options,
+	scope tally.Scope,
+) *namespaceQuota {
+	return &namespaceQuota{
+		namespace:      metadata.ID(),
+		opts:           metadata.Options().QuotaOptions(),
+		filePathPrefix: opts.CommitLogOptions().FilesystemOptions().FilePathPrefix(),
+		diskUsageFn:    fs.ComputeNamespaceDiskUsage,
+		log:            opts.InstrumentOptions().Logger(),
+		metrics:        newNamespaceQuotaMetrics(scope),
+	}
+}
+
+func (q *namespaceQuota) enabled() bool {
+	return q.opts.MaxBytes() > 0
+}
+
+// tick recomputes the bytes on disk used by the namespace and updates
+// whether the quota is exceeded.
+func (q *namespaceQuota) tick() {
+	if !q.enabled() {
+		return
+	}
+
+	usage, err := q.diskUsageFn(q.filePathPrefix, q.namespace)
+	if err != nil {
+		q.metrics.usageErrors.Inc(1)
+		q.log.Errorf("unable to compute namespace disk usage: %v", err)
+		return
+	}
+
+	var (
+		maxBytes  = q.opts.MaxBytes()
+		usedBytes = usage.TotalBytes()
+		exceeded  = usedBytes > maxBytes
+	)
+
+	q.Lock()
+	prevExceeded := q.exceeded
+	q.usedBytes = usedBytes
+	q.exceeded = exceeded
+	q.Unlock()
+
+	if exceeded && !prevExceeded {
+		q.log.Warnf("namespace storage quota exceeded: used=%d, max=%d, action=%s",
+			usedBytes, maxBytes, q.opts.ExceededAction().String())
+	} else if !exceeded && prevExceeded {
+		q.log.Infof("namespace storage quota no longer exceeded: used=%d, max=%d",
+			usedBytes, maxBytes)
+	}
+
+	q.metrics.usedBytes.Update(float64(usedBytes))
+	q.metrics.maxBytes.Update(float64(maxBytes))
+	if exceeded {
+		q.metrics.exceeded.Update(1)
+	} else {
+		q.metrics.exceeded.Update(0)
+	}
+}
+
+func (q *namespaceQuota) isExceeded() bool {
+	q.RLock()
+	exceeded := q.exceeded
+	q.RUnlock()
+	return exceeded
+}
+
+// checkWrite returns an error if writes are rejected by the quota.
+func (q *namespaceQuota) checkWrite() error {
+	if q.opts.ExceededAction() != namespace.QuotaExceededRejectWrites ||
+		!q.isExceeded() {
+		return nil
+	}
+	q.metrics.rejectedWrites.Inc(1)
+	return m3dberrors.NewResourceExhaustedError(errNamespaceQuotaExceeded, 0)
+}
+
+// checkNewSeries returns an error if inserting new series is rejected by
+// the quota.
+func (q *namespaceQuota) checkNewSeries() error {
+	if q.opts.ExceededAction() != namespace.QuotaExceededRejectNewSeries ||
+		!q.isExceeded() {
+		return nil
+	}
+	q.metrics.rejectedNewSeries.Inc(1)
+	return m3dberrors.NewResourceExhaustedError(errNamespaceQuotaExceeded, 0)
+}
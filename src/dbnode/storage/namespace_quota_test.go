@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3x/context"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestNamespaceQuota(
+	t *testing.T,
+	action namespace.QuotaExceededAction,
+	usedBytes int64,
+) *namespaceQuota {
+	opts := defaultTestNs1Opts.SetQuotaOptions(namespace.NewQuotaOptions().
+		SetMaxBytes(100).
+		SetExceededAction(action))
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID, opts)
+	require.NoError(t, err)
+
+	q := newNamespaceQuota(metadata, testDatabaseOptions(), tally.NoopScope)
+	q.diskUsageFn = func(string, ident.ID) (fs.NamespaceDiskUsage, error) {
+		return fs.NamespaceDiskUsage{
+			IndexBlocks: []fs.BlockDiskUsage{{IndexBytes: usedBytes}},
+		}, nil
+	}
+	return q
+}
+
+func TestNamespaceQuotaWarn(t *testing.T) {
+	q := newTestNamespaceQuota(t, namespace.QuotaExceededWarn, 200)
+	q.tick()
+
+	require.True(t, q.isExceeded())
+	require.NoError(t, q.checkWrite())
+	require.NoError(t, q.checkNewSeries())
+}
+
+func TestNamespaceQuotaRejectNewSeries(t *testing.T) {
+	q := newTestNamespaceQuota(t, namespace.QuotaExceededRejectNewSeries, 200)
+	require.NoError(t, q.checkNewSeries())
+
+	q.tick()
+	require.True(t, q.isExceeded())
+	require.NoError(t, q.checkWrite())
+
+	err := q.checkNewSeries()
+	require.Error(t, err)
+	require.True(t, m3dberrors.IsResourceExhaustedError(err))
+}
+
+func TestNamespaceQuotaRejectWrites(t *testing.T) {
+	q := newTestNamespaceQuota(t, namespace.QuotaExceededRejectWrites, 200)
+	q.tick()
+
+	err := q.checkWrite()
+	require.Error(t, err)
+	require.True(t, m3dberrors.IsResourceExhaustedError(err))
+}
+
+func TestNamespaceQuotaNotExceeded(t *testing.T) {
+	q := newTestNamespaceQuota(t, namespace.QuotaExceededRejectWrites, 50)
+	q.tick()
+
+	require.False(t, q.isExceeded())
+	require.NoError(t, q.checkWrite())
+}
+
+func TestNamespaceQuotaUsageErrorKeepsState(t *testing.T) {
+	q := newTestNamespaceQuota(t, namespace.QuotaExceededRejectWrites, 200)
+	q.tick()
+	require.True(t, q.isExceeded())
+
+	q.diskUsageFn = func(string, ident.ID) (fs.NamespaceDiskUsage, error) {
+		return fs.NamespaceDiskUsage{}, errors.New("an error")
+	}
+	q.tick()
+	require.True(t, q.isExceeded())
+}
+
+func TestNamespaceWriteRejectedByQuota(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	ns.quota = newTestNamespaceQuota(t, namespace.QuotaExceededRejectWrites, 200)
+	ns.quota.tick()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	err := ns.Write(ctx, ident.StringID("foo"), time.Now(), 1.0, xtime.Second, nil)
+	require.Error(t, err)
+	require.True(t, m3dberrors.IsResourceExhaustedError(err))
+}
@@ -169,6 +169,40 @@ func TestNamespaceWriteShardOwned(t *testing.T) {
 	require.NoError(t, ns.Write(ctx, id, ts, val, unit, ant))
 }
 
+func TestNamespaceWriteTimestampPrecision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		id  = ident.StringID("foo")
+		ts  = time.Unix(1500000000, 123456789)
+		val = 0.0
+		ant = []byte(nil)
+	)
+
+	ns, closer := newTestNamespaceWithIDOpts(t, defaultTestNs1ID,
+		defaultTestNs1Opts.SetTimestampPrecision(time.Millisecond))
+	defer closer()
+	shard := NewMockdatabaseShard(ctrl)
+	gomock.InOrder(
+		// Finer writes are truncated and encoded at the namespace precision.
+		shard.EXPECT().
+			Write(ctx, id, ts.Truncate(time.Millisecond), val, xtime.Millisecond, ant).
+			Return(nil),
+		// Coarser writes are left untouched.
+		shard.EXPECT().
+			Write(ctx, id, ts, val, xtime.Second, ant).
+			Return(nil),
+	)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	require.NoError(t, ns.Write(ctx, id, ts, val, xtime.Nanosecond, ant))
+	require.NoError(t, ns.Write(ctx, id, ts, val, xtime.Second, ant))
+}
+
 func TestNamespaceReadEncodedShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -445,7 +479,7 @@ func TestNamespaceFlushSkipFlushed(t *testing.T) {
 		shard.EXPECT().ID().Return(testShardIDs[i].ID())
 		shard.EXPECT().FlushState(blockStart).Return(s)
 		if s.Status != fileOpSuccess {
-			shard.EXPECT().Flush(blockStart, nil).Return(nil)
+			shard.EXPECT().Flush(blockStart, gomock.Any()).Return(nil)
 		}
 		ns.shards[testShardIDs[i].ID()] = shard
 	}
@@ -458,6 +492,38 @@ func TestNamespaceFlushSkipFlushed(t *testing.T) {
 	require.NoError(t, ns.Flush(blockStart, ShardBootstrapStates, nil))
 }
 
+func TestNamespaceAggregateBlockSkipUnflushed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	blockStart := time.Now().Truncate(ns.Options().RetentionOptions().BlockSize())
+	query := namespace.ContinuousQuery{
+		TargetNamespace: "agg",
+		Resolution:      time.Minute,
+	}
+
+	states := []fileOpState{
+		{Status: fileOpFailed},
+		{Status: fileOpSuccess},
+	}
+	for i, s := range states {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().FlushState(blockStart).Return(s)
+		if s.Status == fileOpSuccess {
+			shard.EXPECT().AggregateBlock(blockStart, query, gomock.Any()).Return(nil)
+		}
+		ns.shards[testShardIDs[i].ID()] = shard
+	}
+
+	fn := func(ident.ID, ident.Tags, time.Time, float64, xtime.Unit) error {
+		return nil
+	}
+	require.NoError(t, ns.AggregateBlock(blockStart, query, fn))
+}
+
 func TestNamespaceFlushSkipShardNotBootstrappedBeforeTick(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1366,3 +1432,34 @@ func waitForStats(
 
 	wg.Wait()
 }
+
+func TestNamespaceShardForHashStrategy(t *testing.T) {
+	numShards := 64
+	ids := make([]uint32, numShards)
+	for i := range ids {
+		ids[i] = uint32(i)
+	}
+	shardSet, err := sharding.NewShardSetWithNumShards(
+		sharding.NewShards(ids, shard.Available),
+		sharding.DefaultHashFn(numShards), numShards)
+	require.NoError(t, err)
+
+	opts := namespace.NewOptions().SetShardingOptions(namespace.NewShardingOptions().
+		SetHashStrategy(sharding.TagSubsetHashStrategy).
+		SetTagNames([]string{"service"}))
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID, opts)
+	require.NoError(t, err)
+
+	dopts := testDatabaseOptions().SetRuntimeOptionsManager(runtime.NewOptionsManager())
+	defer dopts.RuntimeOptionsManager().Close()
+	oNs, err := newDatabaseNamespace(metadata, shardSet, nil, nil, nil, dopts)
+	require.NoError(t, err)
+	ns := oNs.(*dbNamespace)
+
+	first, err := ns.shardFor(ident.StringID("__name__=cpu,host=a,service=foo,"))
+	require.NoError(t, err)
+	second, err := ns.shardFor(ident.StringID("__name__=mem,host=b,service=foo,"))
+	require.NoError(t, err)
+	require.Equal(t, first.ID(), second.ID())
+	require.Equal(t, shardSet.Lookup(ident.StringID("service=foo,")), first.ID())
+}
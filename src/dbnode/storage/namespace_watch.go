@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/x/loglevel"
 	"github.com/m3db/m3x/instrument"
 	xlog "github.com/m3db/m3x/log"
 
@@ -75,7 +76,7 @@ func newDatabaseNamespaceWatch(
 	return &dbNamespaceWatch{
 		watch:          w,
 		db:             db,
-		log:            iopts.Logger(),
+		log:            loglevel.Subsystem(iopts.Logger(), "namespace-watch"),
 		metrics:        newWatchMetrics(scope),
 		reportInterval: iopts.ReportInterval(),
 	}
@@ -142,6 +143,12 @@ func (w *dbNamespaceWatch) startWatch() {
 			w.metrics.updates.Inc(1)
 			newMap := w.watch.Get()
 			w.log.Info("received update from kv namespace watch")
+			if w.log.Enabled(xlog.LevelDebug) && newMap != nil {
+				for _, md := range newMap.Metadatas() {
+					w.log.Debugf("namespace watch update: id=%s, retention=%s",
+						md.ID().String(), md.Options().RetentionOptions().RetentionPeriod().String())
+				}
+			}
 			w.db.UpdateOwnedNamespaces(newMap)
 		}
 	}
@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+
+	"github.com/spaolacci/murmur3"
+)
+
+const (
+	// writePatternsSampleSeriesEvery is the rate at which series are sampled
+	// to measure the interval between their datapoints, a series is sampled by
+	// its hash so that every write of a sampled series is observed.
+	writePatternsSampleSeriesEvery = 64
+
+	// writePatternsMaxTrackedSeries is the maximum number of sampled series
+	// whose last datapoint is tracked to measure write intervals.
+	writePatternsMaxTrackedSeries = 1024
+
+	// writePatternsSampleQueriesEvery is the rate at which queries are sampled
+	// to measure their ranges.
+	writePatternsSampleQueriesEvery = 16
+
+	// writePatternsSamples is the number of most recent samples kept per
+	// measurement.
+	writePatternsSamples = 1024
+)
+
+// WritePatterns summarizes the writes and queries a namespace received,
+// measured from the most recent samples.
+type WritePatterns struct {
+	// NumSeries is the number of series in the namespace.
+	NumSeries int64
+	// SampledWrites is the number of writes sampled since the namespace
+	// was created.
+	SampledWrites int64
+	// SampledQueries is the number of queries sampled since the namespace
+	// was created.
+	SampledQueries int64
+	// WriteInterval is the interval between consecutive datapoints of a series.
+	WriteInterval DurationQuantiles
+	// Lateness is how far datapoints were behind the time they were written.
+	Lateness DurationQuantiles
+	// Earliness is how far datapoints were ahead of the time they were written.
+	Earliness DurationQuantiles
+	// QueryRange is the time range of queries.
+	QueryRange DurationQuantiles
+}
+
+// DurationQuantiles are the quantiles of a sampled duration, all zero if
+// nothing was sampled.
+type DurationQuantiles struct {
+	Samples int
+	P50     time.Duration
+	P90     time.Duration
+	P99     time.Duration
+	Max     time.Duration
+}
+
+// durationSamples keeps the most recent samples of a duration.
+type durationSamples struct {
+	values []time.Duration
+	next   int
+}
+
+func (s *durationSamples) add(value time.Duration) {
+	if len(s.values) < writePatternsSamples {
+		s.values = append(s.values, value)
+		return
+	}
+	s.values[s.next] = value
+	s.next = (s.next + 1) % writePatternsSamples
+}
+
+func (s *durationSamples) quantiles() DurationQuantiles {
+	if len(s.values) == 0 {
+		return DurationQuantiles{}
+	}
+
+	sorted := make([]time.Duration, len(s.values))
+	copy(sorted, s.values)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	quantile := func(q float64) time.Duration {
+		return sorted[int(q*float64(len(sorted)-1))]
+	}
+	return DurationQuantiles{
+		Samples: len(sorted),
+		P50:     quantile(0.5),
+		P90:     quantile(0.9),
+		P99:     quantile(0.99),
+		Max:     sorted[len(sorted)-1],
+	}
+}
+
+// namespaceWritePatterns samples the writes and queries of a namespace to
+// measure the interval between datapoints, how late or early datapoints are
+// written and the ranges queried.
+type namespaceWritePatterns struct {
+	sync.Mutex
+
+	nowFn          func() time.Time
+	sampledWrites  int64
+	sampledQueries int64
+	queries        uint64
+
+	lastTimestamps map[uint64]time.Time
+	writeIntervals durationSamples
+	lateness       durationSamples
+	earliness      durationSamples
+	queryRanges    durationSamples
+}
+
+func newNamespaceWritePatterns(nowFn func() time.Time) *namespaceWritePatterns {
+	return &namespaceWritePatterns{
+		nowFn:          nowFn,
+		lastTimestamps: make(map[uint64]time.Time, writePatternsMaxTrackedSeries),
+	}
+}
+
+func (p *namespaceWritePatterns) recordWrite(id ident.ID, timestamp time.Time) {
+	hash := murmur3.Sum64(id.Bytes())
+	if hash%writePatternsSampleSeriesEvery != 0 {
+		return
+	}
+
+	atomic.AddInt64(&p.sampledWrites, 1)
+	now := p.nowFn()
+	p.Lock()
+	if timestamp.Before(now) {
+		p.lateness.add(now.Sub(timestamp))
+		p.earliness.add(0)
+	} else {
+		p.lateness.add(0)
+		p.earliness.add(timestamp.Sub(now))
+	}
+	last, ok := p.lastTimestamps[hash]
+	switch {
+	case ok && timestamp.After(last):
+		p.writeIntervals.add(timestamp.Sub(last))
+		p.lastTimestamps[hash] = timestamp
+	case !ok:
+		// Start over once full so that series which stopped being written
+		// do not take the place of new series forever.
+		if len(p.lastTimestamps) >= writePatternsMaxTrackedSeries {
+			p.lastTimestamps = make(map[uint64]time.Time, writePatternsMaxTrackedSeries)
+		}
+		p.lastTimestamps[hash] = timestamp
+	}
+	p.Unlock()
+}
+
+func (p *namespaceWritePatterns) recordQuery(start, end time.Time) {
+	if atomic.AddUint64(&p.queries, 1)%writePatternsSampleQueriesEvery != 0 {
+		return
+	}
+	if !end.After(start) {
+		return
+	}
+
+	atomic.AddInt64(&p.sampledQueries, 1)
+	p.Lock()
+	p.queryRanges.add(end.Sub(start))
+	p.Unlock()
+}
+
+// WritePatterns returns the write patterns measured from the most recent
+// samples, the number of series is left for the caller to set.
+func (p *namespaceWritePatterns) WritePatterns() WritePatterns {
+	p.Lock()
+	defer p.Unlock()
+	return WritePatterns{
+		SampledWrites:  atomic.LoadInt64(&p.sampledWrites),
+		SampledQueries: atomic.LoadInt64(&p.sampledQueries),
+		WriteInterval:  p.writeIntervals.quantiles(),
+		Lateness:       p.lateness.quantiles(),
+		Earliness:      p.earliness.quantiles(),
+		QueryRange:     p.queryRanges.quantiles(),
+	}
+}
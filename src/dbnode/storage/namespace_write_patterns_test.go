@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+
+	"github.com/spaolacci/murmur3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampledSeriesID returns an ID of a series whose writes are sampled.
+func sampledSeriesID(t *testing.T) ident.ID {
+	for i := 0; i < 100*writePatternsSampleSeriesEvery; i++ {
+		id := fmt.Sprintf("series-%d", i)
+		if murmur3.Sum64([]byte(id))%writePatternsSampleSeriesEvery == 0 {
+			return ident.StringID(id)
+		}
+	}
+	require.FailNow(t, "no sampled series found")
+	return nil
+}
+
+func TestNamespaceWritePatterns(t *testing.T) {
+	now := time.Now()
+	nowFn := func() time.Time { return now }
+	patterns := newNamespaceWritePatterns(nowFn)
+
+	id := sampledSeriesID(t)
+	for i := 0; i < 100; i++ {
+		// Datapoints every 10s written 30s late.
+		patterns.recordWrite(id, now.Add(-30*time.Second))
+		now = now.Add(10 * time.Second)
+	}
+	for i := 0; i < 2*writePatternsSampleQueriesEvery; i++ {
+		patterns.recordQuery(now.Add(-time.Hour), now)
+	}
+
+	result := patterns.WritePatterns()
+	assert.Equal(t, int64(100), result.SampledWrites)
+	assert.Equal(t, int64(2), result.SampledQueries)
+	assert.Equal(t, 99, result.WriteInterval.Samples)
+	assert.Equal(t, 10*time.Second, result.WriteInterval.P50)
+	assert.Equal(t, 30*time.Second, result.Lateness.P99)
+	assert.Equal(t, time.Duration(0), result.Earliness.Max)
+	assert.Equal(t, time.Hour, result.QueryRange.P90)
+}
+
+func TestDurationSamplesKeepsMostRecent(t *testing.T) {
+	var samples durationSamples
+	for i := 0; i < 2*writePatternsSamples; i++ {
+		samples.add(time.Duration(i))
+	}
+
+	result := samples.quantiles()
+	assert.Equal(t, writePatternsSamples, result.Samples)
+	assert.Equal(t, time.Duration(2*writePatternsSamples-1), result.Max)
+	assert.True(t, result.P50 >= time.Duration(writePatternsSamples))
+}
@@ -37,6 +37,7 @@ import (
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
@@ -71,6 +72,10 @@ const (
 
 	// defaultMinSnapshotInterval is the default minimum interval that must elapse between snapshots
 	defaultMinSnapshotInterval = time.Minute
+
+	// defaultShardMetricsBucketSize is the default number of consecutive shards
+	// that share a set of per shard metrics
+	defaultShardMetricsBucketSize = 16
 )
 
 var (
@@ -88,6 +93,9 @@ var (
 	errRepairOptionsNotSet        = errors.New("repair enabled but repair options are not set")
 	errIndexOptionsNotSet         = errors.New("index enabled but index options are not set")
 	errPersistManagerNotSet       = errors.New("persist manager is not set")
+	errShardMetricsBucketSize     = errors.New("shard metrics bucket size must be positive")
+	errInitShardWriteBufferSize   = errors.New("initializing shard write buffer size must not be negative")
+	errChangeEmitterNotSet        = errors.New("change emitter is not set")
 )
 
 // NewSeriesOptionsFromOptions creates a new set of database series options from provided options.
@@ -119,6 +127,10 @@ type options struct {
 	errWindowForLoad               time.Duration
 	errThresholdForLoad            int64
 	indexingEnabled                bool
+	cleanupDryRun                  bool
+	shardMetricsBucketSize         int
+	initShardWriteBufferSize       int
+	changeEmitter                  cdc.Emitter
 	repairEnabled                  bool
 	indexOpts                      index.Options
 	repairOpts                     repair.Options
@@ -175,6 +187,8 @@ func newOptions(poolOpts pool.ObjectPoolOptions) Options {
 		repairOpts:               repair.NewOptions(),
 		bootstrapProcessProvider: defaultBootstrapProcessProvider,
 		minSnapshotInterval:      defaultMinSnapshotInterval,
+		shardMetricsBucketSize:   defaultShardMetricsBucketSize,
+		changeEmitter:            cdc.NewNoopEmitter(),
 		poolOpts:                 poolOpts,
 		contextPool: context.NewPool(context.NewOptions().
 			SetContextPoolOptions(poolOpts).
@@ -239,6 +253,18 @@ func (o *options) Validate() error {
 		return errPersistManagerNotSet
 	}
 
+	if o.shardMetricsBucketSize <= 0 {
+		return errShardMetricsBucketSize
+	}
+
+	if o.initShardWriteBufferSize < 0 {
+		return errInitShardWriteBufferSize
+	}
+
+	if o.changeEmitter == nil {
+		return errChangeEmitterNotSet
+	}
+
 	// validate series cache policy
 	return series.ValidateCachePolicy(o.seriesCachePolicy)
 }
@@ -350,6 +376,46 @@ func (o *options) IndexOptions() index.Options {
 	return o.indexOpts
 }
 
+func (o *options) SetCleanupDryRun(value bool) Options {
+	opts := *o
+	opts.cleanupDryRun = value
+	return &opts
+}
+
+func (o *options) CleanupDryRun() bool {
+	return o.cleanupDryRun
+}
+
+func (o *options) SetShardMetricsBucketSize(value int) Options {
+	opts := *o
+	opts.shardMetricsBucketSize = value
+	return &opts
+}
+
+func (o *options) ShardMetricsBucketSize() int {
+	return o.shardMetricsBucketSize
+}
+
+func (o *options) SetInitializingShardWriteBufferSize(value int) Options {
+	opts := *o
+	opts.initShardWriteBufferSize = value
+	return &opts
+}
+
+func (o *options) InitializingShardWriteBufferSize() int {
+	return o.initShardWriteBufferSize
+}
+
+func (o *options) SetChangeEmitter(value cdc.Emitter) Options {
+	opts := *o
+	opts.changeEmitter = value
+	return &opts
+}
+
+func (o *options) ChangeEmitter() cdc.Emitter {
+	return o.changeEmitter
+}
+
 func (o *options) SetRepairEnabled(b bool) Options {
 	opts := *o
 	opts.repairEnabled = b
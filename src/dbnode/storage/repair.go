@@ -25,7 +25,6 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -35,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
@@ -62,10 +62,19 @@ type shardRepairer struct {
 }
 
 func newShardRepairer(opts Options, rpopts repair.Options) databaseShardRepairer {
+	r := newShardMetadataComparer(opts, rpopts)
+	r.recordFn = r.recordDifferences
+
+	return r
+}
+
+// newShardMetadataComparer returns a shard repairer which does not record
+// the differences it finds, for comparisons made outside of repairs.
+func newShardMetadataComparer(opts Options, rpopts repair.Options) shardRepairer {
 	iopts := opts.InstrumentOptions()
-	scope := iopts.MetricsScope().SubScope("repair")
+	scope := xmetrics.SubsystemScope(iopts.MetricsScope(), xmetrics.SubsystemRepair)
 
-	r := shardRepairer{
+	return shardRepairer{
 		opts:   opts,
 		rpopts: rpopts,
 		client: rpopts.AdminClient(),
@@ -73,9 +82,6 @@ func newShardRepairer(opts Options, rpopts repair.Options) databaseShardRepairer
 		scope:  scope,
 		nowFn:  opts.ClockOptions().NowFn(),
 	}
-	r.recordFn = r.recordDifferences
-
-	return r
 }
 
 func (r shardRepairer) Options() repair.Options {
@@ -87,6 +93,24 @@ func (r shardRepairer) Repair(
 	namespace ident.ID,
 	tr xtime.Range,
 	shard databaseShard,
+) (repair.MetadataComparisonResult, error) {
+	metadataRes, err := r.compare(ctx, namespace, tr, shard)
+	if err != nil {
+		return repair.MetadataComparisonResult{}, err
+	}
+
+	r.recordFn(namespace, shard, metadataRes)
+
+	return metadataRes, nil
+}
+
+// compare compares the block metadata of the shard with the metadata of
+// its peers, the result is only valid until the context is closed.
+func (r shardRepairer) compare(
+	ctx context.Context,
+	namespace ident.ID,
+	tr xtime.Range,
+	shard databaseShard,
 ) (repair.MetadataComparisonResult, error) {
 	session, err := r.client.DefaultAdminSession()
 	if err != nil {
@@ -131,11 +155,7 @@ func (r shardRepairer) Repair(
 		return repair.MetadataComparisonResult{}, err
 	}
 
-	metadataRes := metadata.Compare()
-
-	r.recordFn(namespace, shard, metadataRes)
-
-	return metadataRes, nil
+	return metadata.Compare(), nil
 }
 
 func (r shardRepairer) recordDifferences(
@@ -144,10 +164,7 @@ func (r shardRepairer) recordDifferences(
 	diffRes repair.MetadataComparisonResult,
 ) {
 	var (
-		shardScope = r.scope.Tagged(map[string]string{
-			"namespace": namespace.String(),
-			"shard":     strconv.Itoa(int(shard.ID())),
-		})
+		shardScope        = xmetrics.NamespaceShardScope(r.scope, namespace.String(), shard.ID())
 		totalScope        = shardScope.Tagged(map[string]string{"resultType": "total"})
 		sizeDiffScope     = shardScope.Tagged(map[string]string{"resultType": "sizeDiff"})
 		checksumDiffScope = shardScope.Tagged(map[string]string{"resultType": "checksumDiff"})
@@ -37,6 +37,8 @@ const (
 	defaultRepairThrottle         = 90 * time.Second
 	defaultRepairMaxRetries       = 3
 	defaultRepairShardConcurrency = 1
+
+	defaultConsistencyCheckSampleSize = 4
 )
 
 var (
@@ -50,6 +52,9 @@ var (
 	errInvalidRepairThrottle        = errors.New("invalid repair throttle in repair options")
 	errInvalidRepairMaxRetries      = errors.New("invalid repair max retries in repair options")
 	errNoHostBlockMetadataSlicePool = errors.New("no host block metadata pool in repair options")
+
+	errInvalidConsistencyCheckInterval   = errors.New("invalid consistency check interval in repair options")
+	errInvalidConsistencyCheckSampleSize = errors.New("invalid consistency check sample size in repair options")
 )
 
 type options struct {
@@ -63,6 +68,8 @@ type options struct {
 	repairThrottle             time.Duration
 	repairMaxRetries           int
 	hostBlockMetadataSlicePool HostBlockMetadataSlicePool
+	consistencyCheckInterval   time.Duration
+	consistencyCheckSampleSize int
 }
 
 // NewOptions creates new bootstrap options
@@ -77,6 +84,7 @@ func NewOptions() Options {
 		repairThrottle:             defaultRepairThrottle,
 		repairMaxRetries:           defaultRepairMaxRetries,
 		hostBlockMetadataSlicePool: NewHostBlockMetadataSlicePool(nil, 0),
+		consistencyCheckSampleSize: defaultConsistencyCheckSampleSize,
 	}
 }
 
@@ -180,6 +188,26 @@ func (o *options) HostBlockMetadataSlicePool() HostBlockMetadataSlicePool {
 	return o.hostBlockMetadataSlicePool
 }
 
+func (o *options) SetConsistencyCheckInterval(value time.Duration) Options {
+	opts := *o
+	opts.consistencyCheckInterval = value
+	return &opts
+}
+
+func (o *options) ConsistencyCheckInterval() time.Duration {
+	return o.consistencyCheckInterval
+}
+
+func (o *options) SetConsistencyCheckSampleSize(value int) Options {
+	opts := *o
+	opts.consistencyCheckSampleSize = value
+	return &opts
+}
+
+func (o *options) ConsistencyCheckSampleSize() int {
+	return o.consistencyCheckSampleSize
+}
+
 func (o *options) Validate() error {
 	if o.adminClient == nil {
 		return errNoAdminClient
@@ -211,5 +239,11 @@ func (o *options) Validate() error {
 	if o.hostBlockMetadataSlicePool == nil {
 		return errNoHostBlockMetadataSlicePool
 	}
+	if o.consistencyCheckInterval < 0 {
+		return errInvalidConsistencyCheckInterval
+	}
+	if o.consistencyCheckSampleSize <= 0 {
+		return errInvalidConsistencyCheckSampleSize
+	}
 	return nil
 }
@@ -212,6 +212,22 @@ type Options interface {
 	// HostBlockMetadataSlicePool returns the hostBlockMetadataSlice pool
 	HostBlockMetadataSlicePool() HostBlockMetadataSlicePool
 
+	// SetConsistencyCheckInterval sets the interval at which sampled blocks
+	// are compared across replicas, zero disables the consistency checks
+	SetConsistencyCheckInterval(value time.Duration) Options
+
+	// ConsistencyCheckInterval returns the interval at which sampled blocks
+	// are compared across replicas
+	ConsistencyCheckInterval() time.Duration
+
+	// SetConsistencyCheckSampleSize sets the number of shard blocks sampled
+	// per namespace by each consistency check
+	SetConsistencyCheckSampleSize(value int) Options
+
+	// ConsistencyCheckSampleSize returns the number of shard blocks sampled
+	// per namespace by each consistency check
+	ConsistencyCheckSampleSize() int
+
 	// Validate checks if the options are valid
 	Validate() error
 }
@@ -22,6 +22,7 @@ package storage
 
 type tickResult struct {
 	activeSeries           int
+	createdSeries          int
 	expiredSeries          int
 	activeBlocks           int
 	openBlocks             int
@@ -37,6 +38,7 @@ type tickResult struct {
 func (r tickResult) merge(other tickResult) tickResult {
 	return tickResult{
 		activeSeries:           r.activeSeries + other.activeSeries,
+		createdSeries:          r.createdSeries + other.createdSeries,
 		expiredSeries:          r.expiredSeries + other.expiredSeries,
 		activeBlocks:           r.activeBlocks + other.activeBlocks,
 		openBlocks:             r.openBlocks + other.openBlocks,
@@ -52,6 +52,13 @@ const (
 	// using an LRU of fixed capacity. Series that are least recently
 	// used will be evicted first.
 	CacheLRU
+	// CacheTinyLFU specifies that series that are read will be cached
+	// using an LRU of fixed capacity with a TinyLFU admission policy.
+	// Once the LRU is full series are only cached if they are read more
+	// frequently than the least recently used series they would evict,
+	// so that series read once by long range queries do not evict
+	// frequently read series.
+	CacheTinyLFU
 
 	// DefaultCachePolicy is the default cache policy.
 	DefaultCachePolicy = CacheRecentlyRead
@@ -59,7 +66,8 @@ const (
 
 // ValidCachePolicies returns the valid series cache policies.
 func ValidCachePolicies() []CachePolicy {
-	return []CachePolicy{CacheNone, CacheAll, CacheAllMetadata, CacheRecentlyRead, CacheLRU}
+	return []CachePolicy{CacheNone, CacheAll, CacheAllMetadata, CacheRecentlyRead, CacheLRU,
+		CacheTinyLFU}
 }
 
 func (p CachePolicy) String() string {
@@ -74,6 +82,8 @@ func (p CachePolicy) String() string {
 		return "recently_read"
 	case CacheLRU:
 		return "lru"
+	case CacheTinyLFU:
+		return "tinylfu"
 	}
 	return "unknown"
 }
@@ -184,7 +184,8 @@ func (s *dbSeries) updateBlocksWithLock() (updateBlocksResult, error) {
 			// 		4) WiredList tries to close the block, not knowing that it has
 			// 		   already been closed, and re-opened / re-used leading to
 			// 		   unexpected behavior or data loss.
-			if cachePolicy == CacheLRU && currBlock.WasRetrievedFromDisk() {
+			if (cachePolicy == CacheLRU || cachePolicy == CacheTinyLFU) &&
+				currBlock.WasRetrievedFromDisk() {
 				// Do nothing
 			} else {
 				currBlock.Close()
@@ -222,7 +223,7 @@ func (s *dbSeries) updateBlocksWithLock() (updateBlocksResult, error) {
 			case CacheRecentlyRead:
 				sinceLastRead := now.Sub(currBlock.LastReadTime())
 				shouldUnwire = sinceLastRead >= wiredTimeout
-			case CacheLRU:
+			case CacheLRU, CacheTinyLFU:
 				// The tick is responsible for managing the lifecycle of blocks that were not
 				// read from disk (not retrieved), and the WiredList will manage those that were
 				// retrieved from disk.
@@ -700,7 +701,7 @@ func (s *dbSeries) Close() {
 	s.tags = ident.Tags{}
 
 	switch s.opts.CachePolicy() {
-	case CacheLRU:
+	case CacheLRU, CacheTinyLFU:
 		// In the CacheLRU case, blocks that were retrieved from disk are owned
 		// by the WiredList and should not be closed here. They will eventually
 		// be evicted and closed by  the WiredList when it needs to make room
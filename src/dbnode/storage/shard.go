@@ -28,9 +28,11 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/generated/proto/pagetoken"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
@@ -39,6 +41,8 @@ import (
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
@@ -46,6 +50,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
 	"github.com/m3db/m3/src/dbnode/ts"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	xclose "github.com/m3db/m3x/close"
@@ -148,7 +153,11 @@ type dbShard struct {
 	seriesPool               series.DatabaseSeriesPool
 	commitLogWriter          commitLogWriter
 	reverseIndex             namespaceIndex
+	quota                    *namespaceQuota
+	newSeries                *namespaceNewSeriesSubscriptions
+	changes                  cdc.Emitter
 	insertQueue              *dbShardInsertQueue
+	initializingWrites       *shardInitializingWrites
 	lookup                   *shardMap
 	list                     *list.List
 	bootstrapState           BootstrapState
@@ -165,8 +174,10 @@ type dbShard struct {
 	currRuntimeOptions       dbShardRuntimeOptions
 	logger                   xlog.Logger
 	metrics                  dbShardMetrics
+	traffic                  *shardTraffic
 	newSeriesBootstrapped    bool
 	ticking                  bool
+	seriesCreatedSinceTick   int
 	shard                    uint32
 }
 
@@ -191,10 +202,127 @@ type dbShardMetrics struct {
 	insertAsyncWriteErrors        tally.Counter
 	seriesBootstrapBlocksToBuffer tally.Counter
 	seriesBootstrapBlocksMerged   tally.Counter
+	insertIndexBackpressure       tally.Counter
+	write                         dbShardMethodMetrics
+	read                          dbShardMethodMetrics
+	fetchBlocks                   dbShardMethodMetrics
+	debug                         *dbShardDebugMetrics
 }
 
-func newDatabaseShardMetrics(scope tally.Scope) dbShardMetrics {
+// dbShardMethodMetrics are the latency and error metrics of a shard method,
+// tagged with the bucket of consecutive shards the shard belongs to so that
+// hot shards are visible without emitting a series per shard.
+type dbShardMethodMetrics struct {
+	method       xmetrics.MethodMetrics
+	errorsByCode map[m3dberrors.Code]tally.Counter
+}
+
+func newDatabaseShardMethodMetrics(scope tally.Scope, name string) dbShardMethodMetrics {
+	errorsByCode := make(map[m3dberrors.Code]tally.Counter)
+	for _, code := range m3dberrors.ValidCodes() {
+		errorsByCode[code] = scope.Tagged(map[string]string{
+			"error_type": code.String(),
+		}).Counter(name + ".error-types")
+	}
+	return dbShardMethodMetrics{
+		method:       xmetrics.NewMethodMetrics(scope, name, xmetrics.DefaultLatencyBuckets),
+		errorsByCode: errorsByCode,
+	}
+}
+
+func (m dbShardMethodMetrics) report(err error, d time.Duration) {
+	m.method.ReportSuccessOrError(err, d)
+	if err != nil {
+		m.errorsByCode[m3dberrors.GetCode(err)].Inc(1)
+	}
+}
+
+// dbShardDebugMetrics are the method metrics of a shard tagged with the
+// shard itself, which are only emitted while debug metrics are enabled at
+// runtime as they emit series for every shard.
+type dbShardDebugMetrics struct {
+	scope   tally.Scope
+	methods atomic.Value // dbShardDebugMethodMetrics
+}
+
+type dbShardDebugMethodMetrics struct {
+	enabled     bool
+	write       dbShardMethodMetrics
+	read        dbShardMethodMetrics
+	fetchBlocks dbShardMethodMetrics
+}
+
+func newDatabaseShardDebugMetrics(shard uint32, scope tally.Scope) *dbShardDebugMetrics {
+	m := &dbShardDebugMetrics{
+		scope: xmetrics.ShardScope(scope.SubScope("debug"), shard),
+	}
+	m.methods.Store(dbShardDebugMethodMetrics{})
+	return m
+}
+
+func (m *dbShardDebugMetrics) setEnabled(enabled bool) {
+	current := m.methods.Load().(dbShardDebugMethodMetrics)
+	if current.enabled == enabled {
+		return
+	}
+	if !enabled {
+		m.methods.Store(dbShardDebugMethodMetrics{})
+		return
+	}
+	m.methods.Store(dbShardDebugMethodMetrics{
+		enabled:     true,
+		write:       newDatabaseShardMethodMetrics(m.scope, "write"),
+		read:        newDatabaseShardMethodMetrics(m.scope, "read"),
+		fetchBlocks: newDatabaseShardMethodMetrics(m.scope, "fetch-blocks"),
+	})
+}
+
+func (m *dbShardDebugMetrics) load() (dbShardDebugMethodMetrics, bool) {
+	methods := m.methods.Load().(dbShardDebugMethodMetrics)
+	return methods, methods.enabled
+}
+
+func (m dbShardMetrics) reportWrite(err error, d time.Duration) {
+	m.write.report(err, d)
+	if debug, ok := m.debug.load(); ok {
+		debug.write.report(err, d)
+	}
+}
+
+func (m dbShardMetrics) reportRead(err error, d time.Duration) {
+	m.read.report(err, d)
+	if debug, ok := m.debug.load(); ok {
+		debug.read.report(err, d)
+	}
+}
+
+func (m dbShardMetrics) reportFetchBlocks(err error, d time.Duration) {
+	m.fetchBlocks.report(err, d)
+	if debug, ok := m.debug.load(); ok {
+		debug.fetchBlocks.report(err, d)
+	}
+}
+
+// shardMetricsBucket returns the name of the bucket of consecutive shards the
+// shard belongs to, i.e. "16-31" for shard 20 with a bucket size of 16.
+func shardMetricsBucket(shard uint32, bucketSize int) string {
+	size := uint32(bucketSize)
+	if size == 0 {
+		size = 1
+	}
+	start := shard / size * size
+	return fmt.Sprintf("%d-%d", start, start+size-1)
+}
+
+func newDatabaseShardMetrics(
+	shard uint32,
+	scope tally.Scope,
+	bucketSize int,
+) dbShardMetrics {
 	seriesBootstrapScope := scope.SubScope("series-bootstrap")
+	bucketScope := scope.Tagged(map[string]string{
+		"shard_bucket": shardMetricsBucket(shard, bucketSize),
+	})
 	return dbShardMetrics{
 		create:       scope.Counter("create"),
 		close:        scope.Counter("close"),
@@ -211,6 +339,11 @@ func newDatabaseShardMetrics(scope tally.Scope) dbShardMetrics {
 		}).Counter("insert-async.errors"),
 		seriesBootstrapBlocksToBuffer: seriesBootstrapScope.Counter("blocks-to-buffer"),
 		seriesBootstrapBlocksMerged:   seriesBootstrapScope.Counter("blocks-merged"),
+		insertIndexBackpressure:       scope.Counter("insert-index-backpressure"),
+		write:                         newDatabaseShardMethodMetrics(bucketScope, "write"),
+		read:                          newDatabaseShardMethodMetrics(bucketScope, "read"),
+		fetchBlocks:                   newDatabaseShardMethodMetrics(bucketScope, "fetch-blocks"),
+		debug:                         newDatabaseShardDebugMetrics(shard, scope),
 	}
 }
 
@@ -245,7 +378,10 @@ func newDatabaseShard(
 	increasingIndex increasingIndex,
 	commitLogWriter commitLogWriter,
 	reverseIndex namespaceIndex,
+	quota *namespaceQuota,
+	newSeries *namespaceNewSeriesSubscriptions,
 	needsBootstrap bool,
+	initializing bool,
 	opts Options,
 	seriesOpts series.Options,
 ) databaseShard {
@@ -264,6 +400,9 @@ func newDatabaseShard(
 		seriesPool:         opts.DatabaseSeriesPool(),
 		commitLogWriter:    commitLogWriter,
 		reverseIndex:       reverseIndex,
+		quota:              quota,
+		newSeries:          newSeries,
+		changes:            opts.ChangeEmitter(),
 		lookup:             newShardMap(shardMapOptions{}),
 		list:               list.New(),
 		filesetBeforeFn:    fs.DataFileSetsBefore,
@@ -275,10 +414,11 @@ func newDatabaseShard(
 		flushState:         newShardFlushState(),
 		tickWg:             &sync.WaitGroup{},
 		logger:             opts.InstrumentOptions().Logger(),
-		metrics:            newDatabaseShardMetrics(scope),
+		metrics:            newDatabaseShardMetrics(shard, scope, opts.ShardMetricsBucketSize()),
 	}
-	s.insertQueue = newDatabaseShardInsertQueue(s.insertSeriesBatch,
-		s.nowFn, scope)
+	s.traffic = newShardTraffic(shard, s.nowFn)
+	s.insertQueue = newDatabaseShardInsertQueue(namespaceMetadata.ID(),
+		s.insertSeriesBatch, s.nowFn, scope)
 
 	registerRuntimeOptionsListener := func(listener runtime.OptionsListener) {
 		elem := opts.RuntimeOptionsManager().RegisterListener(listener)
@@ -296,6 +436,14 @@ func newDatabaseShard(
 		s.newSeriesBootstrapped = true
 	}
 
+	// Buffer the writes to a shard that is initializing separately until
+	// the shard is bootstrapped from its peers.
+	if maxWrites := opts.InitializingShardWriteBufferSize(); needsBootstrap &&
+		initializing && maxWrites > 0 {
+		s.initializingWrites = newShardInitializingWrites(maxWrites,
+			increasingIndex, s.identifierPool, scope.SubScope("initializing-writes"))
+	}
+
 	if blockRetriever != nil {
 		s.setBlockRetriever(blockRetriever)
 	}
@@ -322,6 +470,7 @@ func (s *dbShard) SetRuntimeOptions(value runtime.Options) {
 		tickSleepPerSeries:       value.TickPerSeriesSleepDuration(),
 	}
 	s.Unlock()
+	s.metrics.debug.setEnabled(value.DebugMetricsEnabled())
 }
 
 func (s *dbShard) ID() uint32 {
@@ -336,6 +485,10 @@ func (s *dbShard) NumSeries() int64 {
 }
 
 // Stream implements series.QueryableBlockRetriever
+func (s *dbShard) Traffic() ShardTraffic {
+	return s.traffic.Traffic()
+}
+
 func (s *dbShard) Stream(
 	ctx context.Context,
 	id ident.ID,
@@ -602,6 +755,10 @@ func (s *dbShard) tickAndExpire(
 	// enable Close() to track the lifecycle of the tick
 	s.ticking = true
 	s.tickWg.Add(1)
+	// take the count of series created since the last tick so
+	// churn can be reported alongside the series expired by this tick
+	seriesCreated := s.seriesCreatedSinceTick
+	s.seriesCreatedSinceTick = 0
 	s.Unlock()
 
 	// reset ticking state
@@ -619,6 +776,8 @@ func (s *dbShard) tickAndExpire(
 		slept                         time.Duration
 		expired                       []*lookup.Entry
 	)
+	r.createdSeries = seriesCreated
+
 	s.RLock()
 	tickSleepBatch := s.currRuntimeOptions.tickSleepSeriesBatchSize
 	tickSleepPerSeries := s.currRuntimeOptions.tickSleepPerSeries
@@ -754,8 +913,12 @@ func (s *dbShard) WriteTagged(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
-	return s.writeAndIndex(ctx, id, tags, timestamp,
+	s.traffic.record(trafficOpWrite, id, tags)
+	callStart := s.nowFn()
+	err := s.writeAndIndex(ctx, id, tags, timestamp,
 		value, unit, annotation, true)
+	s.metrics.reportWrite(err, s.nowFn().Sub(callStart))
+	return err
 }
 
 func (s *dbShard) Write(
@@ -766,8 +929,12 @@ func (s *dbShard) Write(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
-	return s.writeAndIndex(ctx, id, ident.EmptyTagIterator, timestamp,
+	s.traffic.record(trafficOpWrite, id, nil)
+	callStart := s.nowFn()
+	err := s.writeAndIndex(ctx, id, ident.EmptyTagIterator, timestamp,
 		value, unit, annotation, false)
+	s.metrics.reportWrite(err, s.nowFn().Sub(callStart))
+	return err
 }
 
 func (s *dbShard) writeAndIndex(
@@ -779,6 +946,35 @@ func (s *dbShard) writeAndIndex(
 	unit xtime.Unit,
 	annotation []byte,
 	shouldReverseIndex bool,
+) error {
+	if s.initializingWrites != nil {
+		write, buffered, err := s.initializingWrites.buffer(id, tags,
+			shouldReverseIndex, timestamp, value, unit, annotation)
+		if buffered {
+			if err != nil {
+				return err
+			}
+			return s.writeInitializingCommitLog(ctx, write)
+		}
+	}
+
+	return s.writeSeriesAndIndex(ctx, id, tags, timestamp, value, unit,
+		annotation, shouldReverseIndex, true)
+}
+
+// writeSeriesAndIndex writes to the series of the shard, writing to the
+// commit log too unless the write has been written to the commit log
+// already, i.e. when replaying the writes buffered while initializing.
+func (s *dbShard) writeSeriesAndIndex(
+	ctx context.Context,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+	shouldReverseIndex bool,
+	writeCommitLog bool,
 ) error {
 	// Prepare write
 	entry, opts, err := s.tryRetrieveWritableSeries(id)
@@ -788,6 +984,22 @@ func (s *dbShard) writeAndIndex(
 
 	writable := entry != nil
 
+	// If the series is new make sure the namespace quota allows new series
+	if !writable && s.quota != nil {
+		if err := s.quota.checkNewSeries(); err != nil {
+			return err
+		}
+	}
+
+	// If the series is new and needs to be indexed make sure that the index
+	// can accept it rather than silently growing the index insert queue
+	if !writable && shouldReverseIndex {
+		if err := s.reverseIndex.WriteBackpressure(); err != nil {
+			s.metrics.insertIndexBackpressure.Inc(1)
+			return err
+		}
+	}
+
 	// If no entry and we are not writing new series asynchronously
 	if !writable && !opts.writeNewSeriesAsync {
 		// Avoid double lookup by enqueueing insert immediately
@@ -873,6 +1085,10 @@ func (s *dbShard) writeAndIndex(
 		commitLogSeriesUniqueIndex = result.entry.Index
 	}
 
+	if !writeCommitLog {
+		return nil
+	}
+
 	// Write commit log
 	series := commitlog.Series{
 		UniqueIndex: commitLogSeriesUniqueIndex,
@@ -891,10 +1107,311 @@ func (s *dbShard) writeAndIndex(
 		unit, annotation)
 }
 
+// writeInitializingCommitLog writes a write buffered while the shard is
+// initializing to the commit log, the write is acknowledged only once it is
+// in the commit log as the buffer does not survive a restart.
+func (s *dbShard) writeInitializingCommitLog(
+	ctx context.Context,
+	w shardInitializingWrite,
+) error {
+	series := commitlog.Series{
+		UniqueIndex: w.uniqueIndex,
+		Namespace:   s.namespace.ID(),
+		ID:          w.id,
+		Tags:        w.tags,
+		Shard:       s.shard,
+	}
+	datapoint := ts.Datapoint{
+		Timestamp: w.timestamp,
+		Value:     w.value,
+	}
+	return s.commitLogWriter.Write(ctx, series, datapoint,
+		w.unit, w.annotation)
+}
+
+func (s *dbShard) WriteBatch(
+	ctx context.Context,
+	writes []WriteBatchEntry,
+) {
+	s.writeBatchAndIndex(ctx, writes, false)
+}
+
+func (s *dbShard) WriteTaggedBatch(
+	ctx context.Context,
+	writes []WriteBatchEntry,
+) {
+	s.writeBatchAndIndex(ctx, writes, true)
+}
+
+// writeBatchAndIndex performs a batch of writes looking up the series of all
+// the writes under a single lock acquisition and enqueueing the inserts of
+// new series and the index updates for the batch into the insert queue at
+// once, rather than once per write as writeAndIndex does.
+func (s *dbShard) writeBatchAndIndex(
+	ctx context.Context,
+	writes []WriteBatchEntry,
+	shouldReverseIndex bool,
+) {
+	callStart := s.nowFn()
+	defer s.reportWriteBatch(writes, callStart)
+
+	for i := range writes {
+		s.traffic.record(trafficOpWrite, writes[i].ID,
+			writeBatchEntryTags(&writes[i], shouldReverseIndex))
+	}
+
+	if s.initializingWrites != nil {
+		buffered, ok := s.initializingWrites.bufferBatch(writes, shouldReverseIndex)
+		if ok {
+			for i := range writes {
+				if writes[i].Err != nil {
+					continue
+				}
+				writes[i].Err = s.writeInitializingCommitLog(ctx, buffered[i])
+			}
+			return
+		}
+	}
+
+	var (
+		entries = make([]*lookup.Entry, len(writes))
+		series  = make([]commitlog.Series, len(writes))
+		indexed = make([]bool, len(writes))
+	)
+
+	// Lookup the series of all writes
+	s.RLock()
+	writeNewSeriesAsync := s.currRuntimeOptions.writeNewSeriesAsync
+	for i := range writes {
+		entry, _, err := s.lookupEntryWithLock(writes[i].ID)
+		if err == nil {
+			entry.IncrementReaderWriterCount()
+			entries[i] = entry
+		} else if err != errShardEntryNotFound {
+			writes[i].Err = err
+		}
+	}
+	s.RUnlock()
+
+	// Enqueue the inserts for all new series
+	var (
+		now        = s.nowFn()
+		inserts    []dbShardInsert
+		insertIdxs []int
+	)
+	for i := range writes {
+		w := &writes[i]
+		if entries[i] != nil || w.Err != nil {
+			continue
+		}
+		if s.quota != nil {
+			if err := s.quota.checkNewSeries(); err != nil {
+				w.Err = err
+				continue
+			}
+		}
+		if shouldReverseIndex {
+			if err := s.reverseIndex.WriteBackpressure(); err != nil {
+				s.metrics.insertIndexBackpressure.Inc(1)
+				w.Err = err
+				continue
+			}
+		}
+
+		entry, err := s.newShardEntry(w.ID, newTagsIterArg(writeBatchEntryTags(w, shouldReverseIndex)))
+		if err != nil {
+			w.Err = err
+			continue
+		}
+		insert := dbShardInsert{
+			entry: entry,
+			opts: dbShardInsertAsyncOptions{
+				hasPendingIndexing: shouldReverseIndex,
+				pendingIndex: dbShardPendingIndex{
+					timestamp:  w.Timestamp,
+					enqueuedAt: now,
+				},
+			},
+		}
+		if writeNewSeriesAsync {
+			insert.opts.hasPendingWrite = true
+			insert.opts.pendingWrite = dbShardPendingWrite{
+				timestamp:  w.Timestamp,
+				value:      w.Value,
+				unit:       w.Unit,
+				annotation: w.Annotation,
+			}
+		}
+		inserts = append(inserts, insert)
+		insertIdxs = append(insertIdxs, i)
+	}
+
+	results := s.insertQueue.InsertBatch(inserts)
+	for j, i := range insertIdxs {
+		if err := results[j].err; err != nil {
+			writes[i].Err = err
+			continue
+		}
+		if writeNewSeriesAsync {
+			// The write is performed by the insert, use the copied ID which
+			// will eventually be set to the newly inserted series ID.
+			entry := inserts[j].entry
+			series[i] = commitlog.Series{
+				UniqueIndex: entry.Index,
+				ID:          entry.Series.ID(),
+				Tags:        entry.Series.Tags(),
+			}
+			indexed[i] = true
+			continue
+		}
+		// Wait for the insert to be batched together and inserted
+		results[j].wg.Wait()
+	}
+
+	if !writeNewSeriesAsync && len(insertIdxs) > 0 {
+		// Retrieve the inserted entries
+		s.RLock()
+		for j, i := range insertIdxs {
+			if results[j].err != nil {
+				continue
+			}
+			if entry, _, err := s.lookupEntryWithLock(writes[i].ID); err == nil {
+				entry.IncrementReaderWriterCount()
+				entries[i] = entry
+			}
+		}
+		s.RUnlock()
+
+		for j, i := range insertIdxs {
+			if results[j].err != nil {
+				continue
+			}
+			if entries[i] == nil {
+				entry, err := s.writableSeries(writes[i].ID,
+					writeBatchEntryTags(&writes[i], shouldReverseIndex))
+				if err != nil {
+					writes[i].Err = err
+					continue
+				}
+				entries[i] = entry
+			}
+			// The insert indexed this series if shouldReverseIndex was true
+			indexed[i] = true
+		}
+	}
+
+	// Perform the writes to existing series and enqueue their index updates
+	var (
+		indexInserts []dbShardInsert
+		indexIdxs    []int
+	)
+	for i := range writes {
+		w, entry := &writes[i], entries[i]
+		if entry == nil {
+			continue
+		}
+		if w.Err = entry.Series.Write(ctx, w.Timestamp, w.Value, w.Unit, w.Annotation); w.Err != nil {
+			continue
+		}
+		series[i] = commitlog.Series{
+			UniqueIndex: entry.Index,
+			ID:          entry.Series.ID(),
+			Tags:        entry.Series.Tags(),
+		}
+		if !shouldReverseIndex || indexed[i] ||
+			!entry.NeedsIndexUpdate(s.reverseIndex.BlockStartForWriteTime(w.Timestamp)) {
+			continue
+		}
+		// inc a ref on the entry to ensure it's valid until the queue acts upon it.
+		entry.OnIndexPrepare()
+		indexInserts = append(indexInserts, dbShardInsert{
+			entry: entry,
+			opts: dbShardInsertAsyncOptions{
+				hasPendingIndexing: true,
+				pendingIndex: dbShardPendingIndex{
+					timestamp:  w.Timestamp,
+					enqueuedAt: now,
+				},
+				entryRefCountIncremented: true,
+			},
+		})
+		indexIdxs = append(indexIdxs, i)
+	}
+
+	indexResults := s.insertQueue.InsertBatch(indexInserts)
+	for j, i := range indexIdxs {
+		indexBlockStart := s.reverseIndex.BlockStartForWriteTime(writes[i].Timestamp)
+		if err := indexResults[j].err; err != nil {
+			// release any reference's we've held for indexing
+			entries[i].OnIndexFinalize(indexBlockStart)
+			writes[i].Err = err
+			continue
+		}
+		if writeNewSeriesAsync {
+			continue
+		}
+		indexResults[j].wg.Wait()
+		if !entries[i].IndexedForBlockStart(indexBlockStart) {
+			writes[i].Err = fmt.Errorf("internal error: unable to index series")
+		}
+	}
+
+	// release the references we got on the entries
+	for _, entry := range entries {
+		if entry != nil {
+			entry.DecrementReaderWriterCount()
+		}
+	}
+
+	// Write the commit log for the successful writes
+	for i := range writes {
+		w := &writes[i]
+		if w.Err != nil || series[i].ID == nil {
+			continue
+		}
+		series[i].Namespace = s.namespace.ID()
+		series[i].Shard = s.shard
+		datapoint := ts.Datapoint{
+			Timestamp: w.Timestamp,
+			Value:     w.Value,
+		}
+		w.Err = s.commitLogWriter.Write(ctx, series[i], datapoint,
+			w.Unit, w.Annotation)
+	}
+}
+
+// reportWriteBatch reports the result of each write of a batch with the
+// latency of the whole batch, as the writes of a batch are performed together.
+func (s *dbShard) reportWriteBatch(writes []WriteBatchEntry, callStart time.Time) {
+	d := s.nowFn().Sub(callStart)
+	for i := range writes {
+		s.metrics.reportWrite(writes[i].Err, d)
+	}
+}
+
+func writeBatchEntryTags(w *WriteBatchEntry, tagged bool) ident.TagIterator {
+	if !tagged || w.Tags == nil {
+		return ident.EmptyTagIterator
+	}
+	return w.Tags
+}
+
 func (s *dbShard) ReadEncoded(
 	ctx context.Context,
 	id ident.ID,
 	start, end time.Time,
+) ([][]xio.BlockReader, error) {
+	s.traffic.record(trafficOpRead, id, nil)
+	callStart := s.nowFn()
+	result, err := s.readEncoded(ctx, id, start, end)
+	s.metrics.reportRead(err, s.nowFn().Sub(callStart))
+	return result, err
+}
+
+func (s *dbShard) readEncoded(
+	ctx context.Context,
+	id ident.ID,
+	start, end time.Time,
 ) ([][]xio.BlockReader, error) {
 	s.RLock()
 	entry, _, err := s.lookupEntryWithLock(id)
@@ -1199,6 +1716,33 @@ func (s *dbShard) insertNewShardEntryWithLock(entry *lookup.Entry) {
 		NoCopyKey:     true,
 		NoFinalizeKey: true,
 	})
+	s.seriesCreatedSinceTick++
+
+	// Only series created once the shard has bootstrapped are new, series
+	// inserted while bootstrapping already existed before the node started.
+	if s.newSeries != nil && s.newSeriesBootstrapped {
+		s.newSeries.notify(s.shard, copiedID, entry.Series.Tags())
+	}
+	if s.newSeriesBootstrapped && s.changes.Enabled(cdc.SeriesCreated) {
+		s.emitSeriesCreated(copiedID, entry.Series.Tags())
+	}
+}
+
+// emitSeriesCreated emits a change event for a new series, the ID and tags
+// are copied as the event is written to the sink asynchronously.
+func (s *dbShard) emitSeriesCreated(id ident.ID, tags ident.Tags) {
+	values := tags.Values()
+	tagsMap := make(map[string]string, len(values))
+	for _, tag := range values {
+		tagsMap[tag.Name.String()] = tag.Value.String()
+	}
+	s.changes.Emit(cdc.Event{
+		Type:      cdc.SeriesCreated,
+		Namespace: s.namespace.ID().String(),
+		Shard:     s.shard,
+		ID:        append([]byte(nil), id.Bytes()...),
+		Tags:      tagsMap,
+	})
 }
 
 func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
@@ -1351,6 +1895,17 @@ func (s *dbShard) FetchBlocks(
 	ctx context.Context,
 	id ident.ID,
 	starts []time.Time,
+) ([]block.FetchBlockResult, error) {
+	callStart := s.nowFn()
+	result, err := s.fetchBlocks(ctx, id, starts)
+	s.metrics.reportFetchBlocks(err, s.nowFn().Sub(callStart))
+	return result, err
+}
+
+func (s *dbShard) fetchBlocks(
+	ctx context.Context,
+	id ident.ID,
+	starts []time.Time,
 ) ([]block.FetchBlockResult, error) {
 	s.RLock()
 	entry, _, err := s.lookupEntryWithLock(id)
@@ -1810,6 +2365,12 @@ func (s *dbShard) Bootstrap(
 		s.markFlushStateSuccess(at)
 	}
 
+	// Merge the writes buffered while initializing before the shard is
+	// marked bootstrapped so that they are readable once it is.
+	if err := s.replayInitializingWrites(); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+
 	s.Lock()
 	s.bootstrapState = Bootstrapped
 	s.Unlock()
@@ -1817,6 +2378,52 @@ func (s *dbShard) Bootstrap(
 	return multiErr.FinalError()
 }
 
+// replayInitializingWrites writes the writes buffered while the shard was
+// initializing to the bootstrapped series of the shard. The writes are in
+// the commit log already so they are not written to the commit log again,
+// a failed write fails the bootstrap of the shard.
+func (s *dbShard) replayInitializingWrites() error {
+	if s.initializingWrites == nil {
+		return nil
+	}
+
+	writes := s.initializingWrites.drain()
+	if len(writes) == 0 {
+		return nil
+	}
+
+	ctx := s.contextPool.Get()
+	defer ctx.Close()
+
+	var (
+		metrics = s.initializingWrites.metrics
+		errs    int
+		lastErr error
+	)
+	for _, w := range writes {
+		tags := ident.EmptyTagIterator
+		if w.tagged {
+			tags = ident.NewTagsIterator(w.tags)
+		}
+		err := s.writeSeriesAndIndex(ctx, w.id, tags, w.timestamp,
+			w.value, w.unit, w.annotation, w.tagged, false)
+		if err != nil {
+			errs++
+			lastErr = err
+			metrics.replayErrors.Inc(1)
+			continue
+		}
+		metrics.replayed.Inc(1)
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("unable to replay %d of %d writes buffered while "+
+			"shard %d was initializing, last error: %v", errs, len(writes),
+			s.ID(), lastErr)
+	}
+	return nil
+}
+
 func (s *dbShard) Flush(
 	blockStart time.Time,
 	flush persist.DataFlush,
@@ -1874,7 +2481,94 @@ func (s *dbShard) Flush(
 		multiErr = multiErr.Add(err)
 	}
 
-	return s.markFlushStateSuccessOrError(blockStart, multiErr.FinalError())
+	flushErr := multiErr.FinalError()
+	if flushErr == nil && s.changes.Enabled(cdc.BlockFlushed) {
+		s.changes.Emit(cdc.Event{
+			Type:       cdc.BlockFlushed,
+			Namespace:  s.namespace.ID().String(),
+			Shard:      s.shard,
+			BlockStart: blockStart,
+		})
+	}
+
+	return s.markFlushStateSuccessOrError(blockStart, flushErr)
+}
+
+func (s *dbShard) AggregateBlock(
+	blockStart time.Time,
+	query namespace.ContinuousQuery,
+	fn continuousQueryFn,
+) error {
+	var (
+		blockSize = s.namespace.Options().RetentionOptions().BlockSize()
+		blockEnd  = blockStart.Add(blockSize)
+		multiIt   = s.opts.MultiReaderIteratorPool().Get()
+		tmpCtx    = context.NewContext()
+		multiErr  xerrors.MultiError
+	)
+	defer multiIt.Close()
+
+	s.forEachShardEntry(func(entry *lookup.Entry) bool {
+		// Use a temporary context here so the stream readers can be returned to
+		// the pool after we finish aggregating the series.
+		tmpCtx.Reset()
+		err := aggregateSeriesBlock(tmpCtx, entry.Series, multiIt,
+			blockStart, blockEnd, query, fn)
+		tmpCtx.BlockingClose()
+
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			return false
+		}
+		return true
+	})
+
+	return multiErr.FinalError()
+}
+
+func aggregateSeriesBlock(
+	ctx context.Context,
+	curr series.DatabaseSeries,
+	multiIt encoding.MultiReaderIterator,
+	blockStart, blockEnd time.Time,
+	query namespace.ContinuousQuery,
+	fn continuousQueryFn,
+) error {
+	encoded, err := curr.ReadEncoded(ctx, blockStart, blockEnd)
+	if err != nil {
+		return err
+	}
+	if len(encoded) == 0 {
+		return nil
+	}
+
+	multiIt.ResetSliceOfSlices(xio.NewReaderSliceOfSlicesFromBlockReadersIterator(encoded))
+
+	var (
+		agg       = namespace.NewContinuousQueryAggregator(query.Aggregation)
+		windowEnd time.Time
+		lastUnit  xtime.Unit
+	)
+	for multiIt.Next() {
+		dp, unit, _ := multiIt.Current()
+		end := namespace.ContinuousQueryWindowEnd(dp.Timestamp, query.Resolution)
+		if agg.Count() > 0 && !end.Equal(windowEnd) {
+			if err := fn(curr.ID(), curr.Tags(), windowEnd, agg.Value(), lastUnit); err != nil {
+				return err
+			}
+			agg.Reset()
+		}
+		windowEnd = end
+		lastUnit = unit
+		agg.Add(dp.Value)
+	}
+	if err := multiIt.Err(); err != nil {
+		return err
+	}
+	if agg.Count() == 0 {
+		return nil
+	}
+	return fn(curr.ID(), curr.Tags(), windowEnd, agg.Value(), lastUnit)
 }
 
 func (s *dbShard) Snapshot(
@@ -2023,10 +2717,18 @@ func (s *dbShard) markDoneSnapshotting(success bool, completionTime time.Time) {
 // 		   This is because snapshot files are cumulative, so once a new one has been
 //         written out it's safe to delete any previous ones for that block start.
 func (s *dbShard) CleanupSnapshots(earliestToRetain time.Time) error {
+	filesToDelete, err := s.SnapshotFilesToCleanup(earliestToRetain)
+	if err != nil {
+		return err
+	}
+	return s.deleteFilesFn(filesToDelete)
+}
+
+func (s *dbShard) SnapshotFilesToCleanup(earliestToRetain time.Time) ([]string, error) {
 	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 	snapshotFiles, err := s.snapshotFilesFn(filePathPrefix, s.namespace.ID(), s.ID())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	sort.Slice(snapshotFiles, func(i, j int) bool {
@@ -2068,18 +2770,14 @@ func (s *dbShard) CleanupSnapshots(earliestToRetain time.Time) error {
 		}
 	}
 
-	return s.deleteFilesFn(filesToDelete)
+	return filesToDelete, nil
 }
 
 func (s *dbShard) CleanupExpiredFileSets(earliestToRetain time.Time) error {
-	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 	multiErr := xerrors.NewMultiError()
-	expired, err := s.filesetBeforeFn(filePathPrefix, s.namespace.ID(), s.ID(), earliestToRetain)
+	expired, err := s.ExpiredFileSets(earliestToRetain)
 	if err != nil {
-		detailedErr :=
-			fmt.Errorf("encountered errors when getting fileset files for prefix %s namespace %s shard %d: %v",
-				filePathPrefix, s.namespace.ID(), s.ID(), err)
-		multiErr = multiErr.Add(detailedErr)
+		multiErr = multiErr.Add(err)
 	}
 	if err := s.deleteFilesFn(expired); err != nil {
 		multiErr = multiErr.Add(err)
@@ -2087,6 +2785,16 @@ func (s *dbShard) CleanupExpiredFileSets(earliestToRetain time.Time) error {
 	return multiErr.FinalError()
 }
 
+func (s *dbShard) ExpiredFileSets(earliestToRetain time.Time) ([]string, error) {
+	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	expired, err := s.filesetBeforeFn(filePathPrefix, s.namespace.ID(), s.ID(), earliestToRetain)
+	if err != nil {
+		return expired, fmt.Errorf("encountered errors when getting fileset files for prefix %s namespace %s shard %d: %v",
+			filePathPrefix, s.namespace.ID(), s.ID(), err)
+	}
+	return expired, nil
+}
+
 func (s *dbShard) Repair(
 	ctx context.Context,
 	tr xtime.Range,
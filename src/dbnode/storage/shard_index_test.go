@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/runtime"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/m3ninx/doc"
@@ -57,6 +58,7 @@ func TestShardInsertNamespaceIndex(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().WriteBackpressure().Return(nil).AnyTimes()
 	idx.EXPECT().BlockStartForWriteTime(gomock.Any()).Return(blockStart).AnyTimes()
 	idx.EXPECT().WriteBatch(gomock.Any()).Do(
 		func(batch *index.WriteBatch) {
@@ -100,6 +102,43 @@ func TestShardInsertNamespaceIndex(t *testing.T) {
 	require.Equal(t, []byte("value"), indexWrites[0].Fields[0].Value)
 }
 
+func TestShardInsertNamespaceIndexBackpressure(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 2*time.Second)()
+	opts := testDatabaseOptions()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	retryAfter := 50 * time.Millisecond
+	backpressureErr := m3dberrors.NewResourceExhaustedError(
+		errIndexInsertQueueSaturated, retryAfter)
+
+	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().WriteBackpressure().Return(backpressureErr)
+
+	shard := testDatabaseShardWithIndexFn(t, opts, idx)
+	shard.SetRuntimeOptions(runtime.NewOptions().SetWriteNewSeriesAsync(false))
+	defer shard.Close()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	err := shard.WriteTagged(ctx, ident.StringID("foo"),
+		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
+		time.Now(), 1.0, xtime.Second, nil)
+	require.Error(t, err)
+
+	hint, ok := m3dberrors.RetryAfter(err)
+	require.True(t, ok)
+	require.Equal(t, retryAfter, hint)
+
+	// Series should not have been inserted
+	shard.RLock()
+	_, _, err = shard.lookupEntryWithLock(ident.StringID("foo"))
+	shard.RUnlock()
+	require.Equal(t, errShardEntryNotFound, err)
+}
+
 func TestShardAsyncInsertNamespaceIndex(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 2*time.Second)()
 
@@ -110,6 +149,7 @@ func TestShardAsyncInsertNamespaceIndex(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().WriteBackpressure().Return(nil).AnyTimes()
 	idx.EXPECT().WriteBatch(gomock.Any()).Do(
 		func(batch *index.WriteBatch) {
 			lock.Lock()
@@ -181,6 +221,7 @@ func TestShardAsyncIndexOnlyWhenNotIndexed(t *testing.T) {
 	now := time.Now()
 	nextWriteTime := now.Truncate(blockSize)
 	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().WriteBackpressure().Return(nil).AnyTimes()
 	idx.EXPECT().BlockStartForWriteTime(gomock.Any()).
 		DoAndReturn(func(t time.Time) xtime.UnixNano {
 			return xtime.ToUnixNano(t.Truncate(blockSize))
@@ -245,6 +286,7 @@ func TestShardAsyncIndexIfExpired(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().WriteBackpressure().Return(nil).AnyTimes()
 	idx.EXPECT().BlockStartForWriteTime(gomock.Any()).
 		DoAndReturn(func(t time.Time) xtime.UnixNano {
 			return xtime.ToUnixNano(t.Truncate(blockSize))
@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+)
+
+var errShardInitializingWritesFull = errors.New("shard initializing writes buffer is full")
+
+// shardInitializingWrite is a write to a shard that is initializing, its ID,
+// tags and annotation are copied as the write outlives the request.
+type shardInitializingWrite struct {
+	uniqueIndex uint64
+	id          ident.ID
+	tags        ident.Tags
+	tagged      bool
+	timestamp   time.Time
+	value       float64
+	unit        xtime.Unit
+	annotation  []byte
+}
+
+type shardInitializingWritesMetrics struct {
+	buffered     tally.Counter
+	rejected     tally.Counter
+	replayed     tally.Counter
+	replayErrors tally.Counter
+}
+
+func newShardInitializingWritesMetrics(scope tally.Scope) shardInitializingWritesMetrics {
+	return shardInitializingWritesMetrics{
+		buffered:     scope.Counter("buffered"),
+		rejected:     scope.Counter("rejected"),
+		replayed:     scope.Counter("replayed"),
+		replayErrors: scope.Counter("replay-errors"),
+	}
+}
+
+// shardInitializingWrites buffers the writes to a shard that is initializing
+// separately from the series of the shard until the shard is bootstrapped,
+// so that the shard accepts writes while it streams its data from peers
+// without the bootstrapped blocks racing with the writes. Once drained the
+// writes are replayed into the shard before it is marked bootstrapped and any
+// further writes go straight to the series of the shard.
+// NB: Buffered writes are written to the commit log by the shard as they are
+// buffered so that they survive a restart before they are replayed, each
+// buffered series is assigned a unique index for the commit log that is
+// separate from the unique index of the series once replayed.
+type shardInitializingWrites struct {
+	sync.Mutex
+
+	writes          []shardInitializingWrite
+	uniqueIndexes   map[string]uint64
+	maxWrites       int
+	drained         bool
+	increasingIndex increasingIndex
+	identifierPool  ident.Pool
+	metrics         shardInitializingWritesMetrics
+}
+
+func newShardInitializingWrites(
+	maxWrites int,
+	increasingIndex increasingIndex,
+	identifierPool ident.Pool,
+	scope tally.Scope,
+) *shardInitializingWrites {
+	return &shardInitializingWrites{
+		uniqueIndexes:   make(map[string]uint64),
+		maxWrites:       maxWrites,
+		increasingIndex: increasingIndex,
+		identifierPool:  identifierPool,
+		metrics:         newShardInitializingWritesMetrics(scope),
+	}
+}
+
+// buffer buffers a write unless the writes have been drained already, it
+// returns the buffered write, whether the write was buffered and an error
+// if the buffer is full.
+func (w *shardInitializingWrites) buffer(
+	id ident.ID,
+	tags ident.TagIterator,
+	tagged bool,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) (shardInitializingWrite, bool, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.drained {
+		return shardInitializingWrite{}, false, nil
+	}
+	write, err := w.bufferWithLock(id, tags, tagged, timestamp, value, unit, annotation)
+	return write, true, err
+}
+
+// bufferBatch buffers a batch of writes unless the writes have been drained
+// already, it returns the buffered writes indexed by their position in the
+// batch, whether the batch was buffered and sets the error of each write
+// that did not fit in the buffer.
+func (w *shardInitializingWrites) bufferBatch(
+	writes []WriteBatchEntry,
+	tagged bool,
+) ([]shardInitializingWrite, bool) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.drained {
+		return nil, false
+	}
+	buffered := make([]shardInitializingWrite, len(writes))
+	for i := range writes {
+		entry := &writes[i]
+		buffered[i], entry.Err = w.bufferWithLock(entry.ID,
+			writeBatchEntryTags(entry, tagged), tagged, entry.Timestamp,
+			entry.Value, entry.Unit, entry.Annotation)
+	}
+	return buffered, true
+}
+
+func (w *shardInitializingWrites) bufferWithLock(
+	id ident.ID,
+	tags ident.TagIterator,
+	tagged bool,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) (shardInitializingWrite, error) {
+	if len(w.writes) >= w.maxWrites {
+		w.metrics.rejected.Inc(1)
+		return shardInitializingWrite{}, errShardInitializingWritesFull
+	}
+
+	write := shardInitializingWrite{
+		id:        ident.BytesID(append([]byte(nil), id.Bytes()...)),
+		tagged:    tagged,
+		timestamp: timestamp,
+		value:     value,
+		unit:      unit,
+	}
+	if len(annotation) > 0 {
+		write.annotation = append([]byte(nil), annotation...)
+	}
+	if tagged {
+		// NB: Take a duplicate so that the tag iterator passed in is
+		// left untouched for the caller.
+		tagsIter := tags.Duplicate()
+		copied, err := convert.TagsFromTagsIter(write.id, tagsIter, w.identifierPool)
+		tagsIter.Close()
+		if err != nil {
+			return shardInitializingWrite{}, err
+		}
+		write.tags = copied
+	}
+
+	uniqueIndex, ok := w.uniqueIndexes[write.id.String()]
+	if !ok {
+		uniqueIndex = w.increasingIndex.nextIndex()
+		w.uniqueIndexes[write.id.String()] = uniqueIndex
+	}
+	write.uniqueIndex = uniqueIndex
+
+	w.writes = append(w.writes, write)
+	w.metrics.buffered.Inc(1)
+	return write, nil
+}
+
+// drain returns the buffered writes and stops buffering any further writes.
+func (w *shardInitializingWrites) drain() []shardInitializingWrite {
+	w.Lock()
+	writes := w.writes
+	w.writes = nil
+	w.uniqueIndexes = nil
+	w.drained = true
+	w.Unlock()
+	return writes
+}
+
+// Len returns the number of buffered writes.
+func (w *shardInitializingWrites) Len() int {
+	w.Lock()
+	n := len(w.writes)
+	w.Unlock()
+	return n
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestShardInitializingWritesCopiesWrites(t *testing.T) {
+	w := newShardInitializingWrites(4, &testIncreasingIndex{},
+		testDatabaseOptions().IdentifierPool(), tally.NoopScope)
+
+	var (
+		id         = []byte("foo")
+		annotation = []byte("annotation")
+		tags       = ident.NewTagsIterator(ident.NewTags(ident.StringTag("app", "foo")))
+		now        = time.Now()
+	)
+	write, buffered, err := w.buffer(ident.BytesID(id), tags, true, now, 1.0, xtime.Second, annotation)
+	require.True(t, buffered)
+	require.NoError(t, err)
+	require.Equal(t, 0, tags.CurrentIndex())
+	require.Equal(t, "foo", write.id.String())
+
+	// Mutate the write after it is buffered.
+	id[0] = 'b'
+	annotation[0] = 'b'
+
+	writes := w.drain()
+	require.Equal(t, 1, len(writes))
+	require.Equal(t, "foo", writes[0].id.String())
+	require.Equal(t, "annotation", string(writes[0].annotation))
+	require.True(t, writes[0].tagged)
+	require.Equal(t, 1, len(writes[0].tags.Values()))
+	require.Equal(t, "app", writes[0].tags.Values()[0].Name.String())
+	require.Equal(t, "foo", writes[0].tags.Values()[0].Value.String())
+	require.Equal(t, 1.0, writes[0].value)
+	require.True(t, now.Equal(writes[0].timestamp))
+}
+
+func TestShardInitializingWritesStopsBufferingOnceDrained(t *testing.T) {
+	w := newShardInitializingWrites(1, &testIncreasingIndex{},
+		testDatabaseOptions().IdentifierPool(), tally.NoopScope)
+
+	now := time.Now()
+	writes := []WriteBatchEntry{
+		{ID: ident.StringID("foo"), Timestamp: now, Value: 1.0, Unit: xtime.Second},
+		{ID: ident.StringID("bar"), Timestamp: now, Value: 2.0, Unit: xtime.Second},
+	}
+	buffered, ok := w.bufferBatch(writes, false)
+	require.True(t, ok)
+	require.Equal(t, 2, len(buffered))
+	require.Equal(t, "foo", buffered[0].id.String())
+	require.NoError(t, writes[0].Err)
+	require.Equal(t, errShardInitializingWritesFull, writes[1].Err)
+	require.Equal(t, 1, w.Len())
+
+	require.Equal(t, 1, len(w.drain()))
+	require.Equal(t, 0, w.Len())
+
+	_, ok, err := w.buffer(ident.StringID("baz"), ident.EmptyTagIterator,
+		false, now, 3.0, xtime.Second, nil)
+	require.False(t, ok)
+	require.NoError(t, err)
+	_, ok = w.bufferBatch(writes, false)
+	require.False(t, ok)
+	require.Equal(t, 0, w.Len())
+}
+
+func TestShardInitializingWritesReusesUniqueIndexPerSeries(t *testing.T) {
+	w := newShardInitializingWrites(4, &testIncreasingIndex{},
+		testDatabaseOptions().IdentifierPool(), tally.NoopScope)
+
+	now := time.Now()
+	foo1, _, err := w.buffer(ident.StringID("foo"), ident.EmptyTagIterator,
+		false, now, 1.0, xtime.Second, nil)
+	require.NoError(t, err)
+	bar, _, err := w.buffer(ident.StringID("bar"), ident.EmptyTagIterator,
+		false, now, 2.0, xtime.Second, nil)
+	require.NoError(t, err)
+	foo2, _, err := w.buffer(ident.StringID("foo"), ident.EmptyTagIterator,
+		false, now.Add(time.Second), 3.0, xtime.Second, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, foo1.uniqueIndex, foo2.uniqueIndex)
+	require.NotEqual(t, foo1.uniqueIndex, bar.uniqueIndex)
+}
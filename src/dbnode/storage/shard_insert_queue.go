@@ -53,17 +53,29 @@ type dbShardInsertQueue struct {
 	sync.RWMutex
 
 	state              dbShardInsertQueueState
+	namespace          ident.ID
 	nowFn              clock.NowFn
 	insertEntryBatchFn dbShardInsertEntryBatchFn
 	sleepFn            func(time.Duration)
+	afterFn            func(time.Duration, func()) *time.Timer
 
 	// rate limits, protected by mutex
 	insertBatchBackoff   time.Duration
 	insertPerSecondLimit int
+	insertBurstLimit     int
+	insertOverflowLimit  int
 
 	insertPerSecondLimitWindowNanos  int64
 	insertPerSecondLimitWindowValues int
 
+	// burst capacity accrued from capacity unused in previous windows
+	insertBurstTokens int
+
+	// inserts over the rate limit deferred until the next window
+	overflowBatch    *dbShardInsertBatch
+	overflowTimer    *time.Timer
+	overflowPromoted []*dbShardInsertBatch
+
 	currBatch    *dbShardInsertBatch
 	notifyInsert chan struct{}
 	closeCh      chan struct{}
@@ -74,6 +86,9 @@ type dbShardInsertQueue struct {
 type dbShardInsertQueueMetrics struct {
 	insertsNoPendingWrite tally.Counter
 	insertsPendingWrite   tally.Counter
+	insertsBurst          tally.Counter
+	insertsDeferred       tally.Counter
+	insertsRejected       tally.Counter
 }
 
 func newDatabaseShardInsertQueueMetrics(
@@ -88,6 +103,65 @@ func newDatabaseShardInsertQueueMetrics(
 		insertsPendingWrite: scope.Tagged(map[string]string{
 			insertPendingWriteTagName: "yes",
 		}).Counter(insertName),
+		insertsBurst:    scope.Counter("inserts-burst"),
+		insertsDeferred: scope.Counter("inserts-deferred"),
+		insertsRejected: scope.Counter("inserts-rejected"),
+	}
+}
+
+// rotateWindowWithLock moves the rate limit to a new window, accruing
+// burst capacity from the capacity unused in the last window and charging
+// the inserts deferred during the last window against the new window.
+func (q *dbShardInsertQueue) rotateWindowWithLock(windowNanos int64) {
+	if q.insertPerSecondLimitWindowNanos != 0 {
+		unused := q.insertPerSecondLimit - q.insertPerSecondLimitWindowValues
+		if unused > 0 {
+			q.insertBurstTokens += unused
+		}
+		if q.insertBurstTokens > q.insertBurstLimit {
+			q.insertBurstTokens = q.insertBurstLimit
+		}
+	}
+	q.insertPerSecondLimitWindowNanos = windowNanos
+	q.insertPerSecondLimitWindowValues = len(q.overflowBatch.inserts)
+	q.promoteOverflowWithLock()
+}
+
+func (q *dbShardInsertQueue) promoteOverflowWithLock() {
+	if len(q.overflowBatch.inserts) == 0 {
+		return
+	}
+	q.overflowPromoted = append(q.overflowPromoted, q.overflowBatch)
+	q.overflowBatch = &dbShardInsertBatch{}
+	q.overflowBatch.reset()
+}
+
+func (q *dbShardInsertQueue) promoteOverflow() {
+	now := q.nowFn()
+	windowStart := now.Truncate(time.Second)
+	windowNanos := windowStart.UnixNano()
+
+	q.Lock()
+	if q.state != dbShardInsertQueueStateOpen {
+		// Deferred inserts are inserted by the final flush on stop
+		q.Unlock()
+		return
+	}
+	q.overflowTimer = nil
+	if q.insertPerSecondLimitWindowNanos != windowNanos {
+		q.rotateWindowWithLock(windowNanos)
+	} else if len(q.overflowBatch.inserts) > 0 {
+		// Still within the same window, check again at the next window
+		nextWindow := windowStart.Add(time.Second)
+		q.overflowTimer = q.afterFn(nextWindow.Sub(now), q.promoteOverflow)
+	}
+	q.Unlock()
+
+	// Notify insert loop
+	select {
+	case q.notifyInsert <- struct{}{}:
+	default:
+		// Loop busy, already ready to consume notification
 	}
 }
 
@@ -118,6 +192,11 @@ type dbShardInsert struct {
 	opts  dbShardInsertAsyncOptions
 }
 
+type dbShardInsertResult struct {
+	wg  *sync.WaitGroup
+	err error
+}
+
 var dbShardInsertZeroed = dbShardInsert{}
 
 type dbShardPendingWrite struct {
@@ -167,18 +246,24 @@ type dbShardInsertEntryBatchFn func(inserts []dbShardInsert) error
 // trigger and hot looping when being flooded improved by a factor of roughly
 // 4x during floods of new series.
 func newDatabaseShardInsertQueue(
+	namespace ident.ID,
 	insertEntryBatchFn dbShardInsertEntryBatchFn,
 	nowFn clock.NowFn,
 	scope tally.Scope,
 ) *dbShardInsertQueue {
 	currBatch := &dbShardInsertBatch{}
 	currBatch.reset()
+	overflowBatch := &dbShardInsertBatch{}
+	overflowBatch.reset()
 	subscope := scope.SubScope("insert-queue")
 	return &dbShardInsertQueue{
+		namespace:          namespace,
 		nowFn:              nowFn,
 		insertEntryBatchFn: insertEntryBatchFn,
 		sleepFn:            time.Sleep,
+		afterFn:            time.AfterFunc,
 		currBatch:          currBatch,
+		overflowBatch:      overflowBatch,
 		notifyInsert:       make(chan struct{}, 1),
 		closeCh:            make(chan struct{}, 1),
 		metrics:            newDatabaseShardInsertQueueMetrics(subscope),
@@ -186,9 +271,24 @@ func newDatabaseShardInsertQueue(
 }
 
 func (q *dbShardInsertQueue) SetRuntimeOptions(value runtime.Options) {
+	limit := value.WriteNewSeriesLimitPerShardPerSecond()
+	if q.namespace != nil {
+		nsLimits := value.WriteNewSeriesNamespaceLimitsPerShardPerSecond()
+		nsLimit, ok := nsLimits[q.namespace.String()]
+		if ok && nsLimit > 0 && (limit <= 0 || nsLimit < limit) {
+			// The lower of the namespace and global limits applies
+			limit = nsLimit
+		}
+	}
+
 	q.Lock()
 	q.insertBatchBackoff = value.WriteNewSeriesBackoffDuration()
-	q.insertPerSecondLimit = value.WriteNewSeriesLimitPerShardPerSecond()
+	q.insertPerSecondLimit = limit
+	q.insertBurstLimit = value.WriteNewSeriesBurstPerShard()
+	q.insertOverflowLimit = value.WriteNewSeriesOverflowPerShard()
+	if q.insertBurstTokens > q.insertBurstLimit {
+		q.insertBurstTokens = q.insertBurstLimit
+	}
 	q.Unlock()
 }
 
@@ -231,11 +331,24 @@ func (q *dbShardInsertQueue) insertLoop() {
 			q.Unlock()
 		}
 
+		// Take any deferred inserts that are now within the rate limit
+		q.Lock()
+		promoted := q.overflowPromoted
+		q.overflowPromoted = nil
+		q.Unlock()
+
 		if len(batch.inserts) > 0 {
 			q.insertEntryBatchFn(batch.inserts)
 		}
 		batch.wg.Done()
 
+		for _, overflow := range promoted {
+			if len(overflow.inserts) > 0 {
+				q.insertEntryBatchFn(overflow.inserts)
+			}
+			overflow.wg.Done()
+		}
+
 		// Set the free batch
 		batch.reset()
 		freeBatch = batch
@@ -270,6 +383,12 @@ func (q *dbShardInsertQueue) Stop() error {
 	}
 
 	q.state = dbShardInsertQueueStateClosed
+	if q.overflowTimer != nil {
+		q.overflowTimer.Stop()
+		q.overflowTimer = nil
+	}
+	// Insert any deferred inserts with the final flush so no waiters hang
+	q.promoteOverflowWithLock()
 	q.Unlock()
 
 	// Final flush
@@ -286,41 +405,118 @@ func (q *dbShardInsertQueue) Stop() error {
 }
 
 func (q *dbShardInsertQueue) Insert(insert dbShardInsert) (*sync.WaitGroup, error) {
-	windowNanos := q.nowFn().Truncate(time.Second).UnixNano()
+	now := q.nowFn()
 
 	q.Lock()
 	if q.state != dbShardInsertQueueStateOpen {
 		q.Unlock()
 		return nil, errShardInsertQueueNotOpen
 	}
+	wg, deferred, err := q.insertWithLock(insert, now)
+	q.Unlock()
+	if err != nil || deferred {
+		return wg, err
+	}
+
+	q.notifyInsertLoop()
+	q.reportInsert(insert)
+	return wg, nil
+}
+
+// InsertBatch inserts a batch of inserts acquiring the queue lock and
+// notifying the insert loop once for the whole batch.
+func (q *dbShardInsertQueue) InsertBatch(
+	inserts []dbShardInsert,
+) []dbShardInsertResult {
+	if len(inserts) == 0 {
+		return nil
+	}
+
+	var (
+		now     = q.nowFn()
+		results = make([]dbShardInsertResult, len(inserts))
+		notify  bool
+	)
+	q.Lock()
+	if q.state != dbShardInsertQueueStateOpen {
+		q.Unlock()
+		for i := range results {
+			results[i].err = errShardInsertQueueNotOpen
+		}
+		return results
+	}
+	for i, insert := range inserts {
+		wg, deferred, err := q.insertWithLock(insert, now)
+		results[i] = dbShardInsertResult{wg: wg, err: err}
+		if err == nil && !deferred {
+			notify = true
+		}
+	}
+	q.Unlock()
+
+	if notify {
+		q.notifyInsertLoop()
+	}
+	for i, insert := range inserts {
+		if results[i].err == nil {
+			q.reportInsert(insert)
+		}
+	}
+	return results
+}
+
+// insertWithLock adds the insert to the current batch, or defers it to the
+// next window if the insert rate limit is exceeded, returning whether the
+// insert was deferred.
+func (q *dbShardInsertQueue) insertWithLock(
+	insert dbShardInsert,
+	now time.Time,
+) (*sync.WaitGroup, bool, error) {
+	windowStart := now.Truncate(time.Second)
+	windowNanos := windowStart.UnixNano()
 	if limit := q.insertPerSecondLimit; limit > 0 {
 		if q.insertPerSecondLimitWindowNanos != windowNanos {
 			// Rolled into to a new window
-			q.insertPerSecondLimitWindowNanos = windowNanos
-			q.insertPerSecondLimitWindowValues = 0
+			q.rotateWindowWithLock(windowNanos)
 		}
 		q.insertPerSecondLimitWindowValues++
 		if q.insertPerSecondLimitWindowValues > limit {
-			q.Unlock()
-			return nil, errNewSeriesInsertRateLimitExceeded
+			switch {
+			case q.insertBurstTokens > 0:
+				// Use capacity left unused in previous windows
+				q.insertBurstTokens--
+				q.metrics.insertsBurst.Inc(1)
+			case len(q.overflowBatch.inserts) < q.insertOverflowLimit:
+				// Defer the insert until the next window
+				q.overflowBatch.inserts = append(q.overflowBatch.inserts, insert)
+				if q.overflowTimer == nil {
+					nextWindow := windowStart.Add(time.Second)
+					q.overflowTimer = q.afterFn(nextWindow.Sub(now), q.promoteOverflow)
+				}
+				q.metrics.insertsDeferred.Inc(1)
+				return q.overflowBatch.wg, true, nil
+			default:
+				q.metrics.insertsRejected.Inc(1)
+				return nil, false, errNewSeriesInsertRateLimitExceeded
+			}
 		}
 	}
 	q.currBatch.inserts = append(q.currBatch.inserts, insert)
-	wg := q.currBatch.wg
-	q.Unlock()
+	return q.currBatch.wg, false, nil
+}
 
-	// Notify insert loop
+func (q *dbShardInsertQueue) notifyInsertLoop() {
 	select {
 	case q.notifyInsert <- struct{}{}:
 	default:
 		// Loop busy, already ready to consume notification
 	}
+}
 
+func (q *dbShardInsertQueue) reportInsert(insert dbShardInsert) {
 	if insert.opts.hasPendingWrite {
 		q.metrics.insertsPendingWrite.Inc(1)
 	} else {
 		q.metrics.insertsNoPendingWrite.Inc(1)
 	}
-
-	return wg, nil
 }
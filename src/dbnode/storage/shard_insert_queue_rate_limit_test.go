@@ -0,0 +1,367 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestShardInsertQueueRateLimitBurst(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	var (
+		currTime = time.Now().Truncate(time.Second)
+		timeLock = sync.Mutex{}
+		addTime  = func(d time.Duration) {
+			timeLock.Lock()
+			defer timeLock.Unlock()
+			currTime = currTime.Add(d)
+		}
+	)
+	q := newDatabaseShardInsertQueue(nil, func(value []dbShardInsert) error {
+		return nil
+	}, func() time.Time {
+		timeLock.Lock()
+		defer timeLock.Unlock()
+		return currTime
+	}, tally.NoopScope)
+
+	q.insertPerSecondLimit = 4
+	q.insertBurstLimit = 3
+
+	require.NoError(t, q.Start())
+	defer func() {
+		require.NoError(t, q.Stop())
+	}()
+
+	// Use only one of four inserts in the first window
+	_, err := q.Insert(dbShardInsert{})
+	require.NoError(t, err)
+
+	// Next window has the limit plus the burst capped at three
+	addTime(time.Second)
+	for i := 0; i < 7; i++ {
+		_, err = q.Insert(dbShardInsert{})
+		require.NoError(t, err)
+	}
+
+	_, err = q.Insert(dbShardInsert{})
+	require.Equal(t, errNewSeriesInsertRateLimitExceeded, err)
+
+	q.Lock()
+	assert.Equal(t, 0, q.insertBurstTokens)
+	q.Unlock()
+}
+
+func TestShardInsertQueueRateLimitOverflowDeferred(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	var (
+		currTime = time.Now().Truncate(time.Second)
+		timeLock = sync.Mutex{}
+		addTime  = func(d time.Duration) {
+			timeLock.Lock()
+			defer timeLock.Unlock()
+			currTime = currTime.Add(d)
+		}
+		insertsLock sync.Mutex
+		inserts     int
+		afterDelay  time.Duration
+		afterCalled func()
+	)
+	q := newDatabaseShardInsertQueue(nil, func(value []dbShardInsert) error {
+		insertsLock.Lock()
+		inserts += len(value)
+		insertsLock.Unlock()
+		return nil
+	}, func() time.Time {
+		timeLock.Lock()
+		defer timeLock.Unlock()
+		return currTime
+	}, tally.NoopScope)
+	q.afterFn = func(d time.Duration, fn func()) *time.Timer {
+		afterDelay = d
+		afterCalled = fn
+		return time.NewTimer(time.Hour)
+	}
+
+	q.insertPerSecondLimit = 1
+	q.insertOverflowLimit = 2
+
+	require.NoError(t, q.Start())
+	defer func() {
+		require.NoError(t, q.Stop())
+	}()
+
+	wg, err := q.Insert(dbShardInsert{})
+	require.NoError(t, err)
+	wg.Wait()
+
+	addTime(250 * time.Millisecond)
+
+	// Next two inserts are deferred to the next window
+	var deferredWg *sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		deferredWg, err = q.Insert(dbShardInsert{})
+		require.NoError(t, err)
+	}
+	require.NotNil(t, afterCalled)
+	assert.Equal(t, 750*time.Millisecond, afterDelay)
+
+	// Overflow is full, the rest are rejected
+	_, err = q.Insert(dbShardInsert{})
+	require.Equal(t, errNewSeriesInsertRateLimitExceeded, err)
+
+	insertsLock.Lock()
+	assert.Equal(t, 1, inserts)
+	insertsLock.Unlock()
+
+	// Rolling into the next window inserts the deferred inserts
+	addTime(750 * time.Millisecond)
+	afterCalled()
+	deferredWg.Wait()
+
+	insertsLock.Lock()
+	assert.Equal(t, 3, inserts)
+	insertsLock.Unlock()
+
+	// The deferred inserts count against the new window
+	q.Lock()
+	assert.Equal(t, 2, q.insertPerSecondLimitWindowValues)
+	q.Unlock()
+}
+
+func TestShardInsertQueueRateLimitNamespaceLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		namespace   ident.ID
+		globalLimit int
+		nsLimits    map[string]int
+		expected    int
+	}{
+		{
+			name:        "namespace limit lower than global",
+			namespace:   ident.StringID("foo"),
+			globalLimit: 10,
+			nsLimits:    map[string]int{"foo": 2},
+			expected:    2,
+		},
+		{
+			name:        "global limit lower than namespace",
+			namespace:   ident.StringID("foo"),
+			globalLimit: 5,
+			nsLimits:    map[string]int{"foo": 20},
+			expected:    5,
+		},
+		{
+			name:        "namespace limit without global",
+			namespace:   ident.StringID("foo"),
+			globalLimit: 0,
+			nsLimits:    map[string]int{"foo": 3},
+			expected:    3,
+		},
+		{
+			name:        "other namespace uses global",
+			namespace:   ident.StringID("bar"),
+			globalLimit: 10,
+			nsLimits:    map[string]int{"foo": 2},
+			expected:    10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := newDatabaseShardInsertQueue(test.namespace, func(value []dbShardInsert) error {
+				return nil
+			}, time.Now, tally.NoopScope)
+
+			q.SetRuntimeOptions(runtime.NewOptions().
+				SetWriteNewSeriesLimitPerShardPerSecond(test.globalLimit).
+				SetWriteNewSeriesNamespaceLimitsPerShardPerSecond(test.nsLimits))
+
+			q.Lock()
+			assert.Equal(t, test.expected, q.insertPerSecondLimit)
+			q.Unlock()
+		})
+	}
+}
+
+func TestShardInsertQueueRateLimitNamespaceLimitEnforced(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	currTime := time.Now().Truncate(time.Second)
+	q := newDatabaseShardInsertQueue(ident.StringID("foo"), func(value []dbShardInsert) error {
+		return nil
+	}, func() time.Time { return currTime }, tally.NoopScope)
+
+	q.SetRuntimeOptions(runtime.NewOptions().
+		SetWriteNewSeriesLimitPerShardPerSecond(10).
+		SetWriteNewSeriesNamespaceLimitsPerShardPerSecond(map[string]int{"foo": 2}))
+
+	require.NoError(t, q.Start())
+	defer func() {
+		require.NoError(t, q.Stop())
+	}()
+
+	for i := 0; i < 2; i++ {
+		_, err := q.Insert(dbShardInsert{})
+		require.NoError(t, err)
+	}
+
+	// The namespace limit applies before the global limit is reached
+	_, err := q.Insert(dbShardInsert{})
+	require.Equal(t, errNewSeriesInsertRateLimitExceeded, err)
+}
+
+func TestShardInsertQueueRateLimitStopInsertsDeferred(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	var (
+		currTime    = time.Now().Truncate(time.Second)
+		insertsLock sync.Mutex
+		inserts     int
+		timer       *time.Timer
+	)
+	q := newDatabaseShardInsertQueue(nil, func(value []dbShardInsert) error {
+		insertsLock.Lock()
+		inserts += len(value)
+		insertsLock.Unlock()
+		return nil
+	}, func() time.Time { return currTime }, tally.NoopScope)
+	q.afterFn = func(d time.Duration, fn func()) *time.Timer {
+		// Never fires, the deferred inserts are only inserted on stop
+		timer = time.NewTimer(time.Hour)
+		return timer
+	}
+
+	q.insertPerSecondLimit = 1
+	q.insertOverflowLimit = 2
+
+	require.NoError(t, q.Start())
+
+	wg, err := q.Insert(dbShardInsert{})
+	require.NoError(t, err)
+	wg.Wait()
+
+	var deferredWg *sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		deferredWg, err = q.Insert(dbShardInsert{})
+		require.NoError(t, err)
+	}
+	require.NotNil(t, timer)
+
+	insertsLock.Lock()
+	assert.Equal(t, 1, inserts)
+	insertsLock.Unlock()
+
+	// Stopping promotes the deferred inserts into the final flush
+	require.NoError(t, q.Stop())
+	deferredWg.Wait()
+
+	insertsLock.Lock()
+	assert.Equal(t, 3, inserts)
+	insertsLock.Unlock()
+
+	q.Lock()
+	assert.Nil(t, q.overflowTimer)
+	assert.Equal(t, 0, len(q.overflowBatch.inserts))
+	assert.Equal(t, 0, len(q.overflowPromoted))
+	q.Unlock()
+}
+
+func TestShardInsertQueueRateLimitMetrics(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	var (
+		currTime = time.Now().Truncate(time.Second)
+		timeLock = sync.Mutex{}
+		addTime  = func(d time.Duration) {
+			timeLock.Lock()
+			defer timeLock.Unlock()
+			currTime = currTime.Add(d)
+		}
+		scope = tally.NewTestScope("", nil)
+	)
+	q := newDatabaseShardInsertQueue(nil, func(value []dbShardInsert) error {
+		return nil
+	}, func() time.Time {
+		timeLock.Lock()
+		defer timeLock.Unlock()
+		return currTime
+	}, scope)
+	q.afterFn = func(d time.Duration, fn func()) *time.Timer {
+		return time.NewTimer(time.Hour)
+	}
+
+	q.insertPerSecondLimit = 2
+	q.insertBurstLimit = 1
+	q.insertOverflowLimit = 1
+
+	require.NoError(t, q.Start())
+
+	// Leave one insert of capacity unused in the first window
+	_, err := q.Insert(dbShardInsert{})
+	require.NoError(t, err)
+
+	addTime(time.Second)
+	for i := 0; i < 2; i++ {
+		_, err = q.Insert(dbShardInsert{opts: dbShardInsertAsyncOptions{
+			hasPendingWrite: true,
+		}})
+		require.NoError(t, err)
+	}
+
+	// Uses the burst token accrued from the first window
+	_, err = q.Insert(dbShardInsert{})
+	require.NoError(t, err)
+
+	// Deferred to the next window
+	_, err = q.Insert(dbShardInsert{})
+	require.NoError(t, err)
+
+	// Overflow is full
+	_, err = q.Insert(dbShardInsert{})
+	require.Equal(t, errNewSeriesInsertRateLimitExceeded, err)
+
+	require.NoError(t, q.Stop())
+
+	counters := scope.Snapshot().Counters()
+	counter := func(name string) int64 {
+		c, ok := counters[name]
+		require.True(t, ok, "missing counter: %s", name)
+		return c.Value()
+	}
+	assert.Equal(t, int64(1), counter("insert-queue.inserts-burst+"))
+	assert.Equal(t, int64(1), counter("insert-queue.inserts-deferred+"))
+	assert.Equal(t, int64(1), counter("insert-queue.inserts-rejected+"))
+	assert.Equal(t, int64(2), counter("insert-queue.inserts+pending-write=yes"))
+	assert.Equal(t, int64(2), counter("insert-queue.inserts+pending-write=no"))
+}
@@ -55,7 +55,7 @@ func TestShardInsertQueueBatchBackoff(t *testing.T) {
 	for i := range insertProgressWgs {
 		insertProgressWgs[i].Add(1)
 	}
-	q := newDatabaseShardInsertQueue(func(value []dbShardInsert) error {
+	q := newDatabaseShardInsertQueue(nil, func(value []dbShardInsert) error {
 		inserts = append(inserts, value)
 		insertWgs[len(inserts)-1].Done()
 		insertProgressWgs[len(inserts)-1].Wait()
@@ -136,7 +136,7 @@ func TestShardInsertQueueRateLimit(t *testing.T) {
 			currTime = currTime.Add(d)
 		}
 	)
-	q := newDatabaseShardInsertQueue(func(value []dbShardInsert) error {
+	q := newDatabaseShardInsertQueue(nil, func(value []dbShardInsert) error {
 		return nil
 	}, func() time.Time {
 		timeLock.Lock()
@@ -200,7 +200,7 @@ func TestShardInsertQueueFlushedOnClose(t *testing.T) {
 		currTime          = time.Now().Truncate(time.Second)
 	)
 
-	q := newDatabaseShardInsertQueue(func(value []dbShardInsert) error {
+	q := newDatabaseShardInsertQueue(nil, func(value []dbShardInsert) error {
 		atomic.AddInt64(&numInsertObserved, int64(len(value)))
 		return nil
 	}, func() time.Time { return currTime }, tally.NoopScope)
@@ -117,6 +117,7 @@ func TestShardWriteTaggedSyncRefCountMockIndex(t *testing.T) {
 	blockSize := namespace.NewIndexOptions().BlockSize()
 
 	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().WriteBackpressure().Return(nil).AnyTimes()
 	idx.EXPECT().BlockStartForWriteTime(gomock.Any()).
 		DoAndReturn(func(t time.Time) xtime.UnixNano {
 			return xtime.ToUnixNano(t.Truncate(blockSize))
@@ -314,6 +315,7 @@ func TestShardWriteTaggedAsyncRefCountMockIndex(t *testing.T) {
 	blockSize := namespace.NewIndexOptions().BlockSize()
 
 	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().WriteBackpressure().Return(nil).AnyTimes()
 	idx.EXPECT().BlockStartForWriteTime(gomock.Any()).
 		DoAndReturn(func(t time.Time) xtime.UnixNano {
 			return xtime.ToUnixNano(t.Truncate(blockSize))
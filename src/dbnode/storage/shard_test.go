@@ -32,16 +32,20 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
 	"github.com/m3db/m3/src/dbnode/ts"
 	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	m3ninxidx "github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
@@ -77,7 +81,7 @@ func testDatabaseShardWithIndexFn(
 	nsReaderMgr := newNamespaceReaderManager(metadata, tally.NoopScope, opts)
 	seriesOpts := NewSeriesOptionsFromOptions(opts, defaultTestNs1Opts.RetentionOptions())
 	return newDatabaseShard(metadata, 0, nil, nsReaderMgr,
-		&testIncreasingIndex{}, commitLogWriteNoOp, idx, true, opts, seriesOpts).(*dbShard)
+		&testIncreasingIndex{}, commitLogWriteNoOp, idx, nil, nil, true, false, opts, seriesOpts).(*dbShard)
 }
 
 func addMockSeries(ctrl *gomock.Controller, shard *dbShard, id ident.ID, tags ident.Tags, index uint64) *series.MockDatabaseSeries {
@@ -97,7 +101,7 @@ func TestShardDontNeedBootstrap(t *testing.T) {
 	defer closer()
 	seriesOpts := NewSeriesOptionsFromOptions(opts, testNs.Options().RetentionOptions())
 	shard := newDatabaseShard(testNs.metadata, 0, nil, nil,
-		&testIncreasingIndex{}, commitLogWriteNoOp, nil, false, opts, seriesOpts).(*dbShard)
+		&testIncreasingIndex{}, commitLogWriteNoOp, nil, nil, nil, false, false, opts, seriesOpts).(*dbShard)
 	defer shard.Close()
 
 	require.Equal(t, Bootstrapped, shard.bootstrapState)
@@ -110,7 +114,7 @@ func TestShardBootstrapState(t *testing.T) {
 	defer closer()
 	seriesOpts := NewSeriesOptionsFromOptions(opts, testNs.Options().RetentionOptions())
 	shard := newDatabaseShard(testNs.metadata, 0, nil, nil,
-		&testIncreasingIndex{}, commitLogWriteNoOp, nil, false, opts, seriesOpts).(*dbShard)
+		&testIncreasingIndex{}, commitLogWriteNoOp, nil, nil, nil, false, false, opts, seriesOpts).(*dbShard)
 	defer shard.Close()
 
 	require.Equal(t, Bootstrapped, shard.bootstrapState)
@@ -462,6 +466,7 @@ func TestShardTick(t *testing.T) {
 	r, err := shard.Tick(context.NewNoOpCanncellable(), nowFn())
 	require.NoError(t, err)
 	require.Equal(t, 3, r.activeSeries)
+	require.Equal(t, 3, r.createdSeries)
 	require.Equal(t, 0, r.expiredSeries)
 	require.Equal(t, 2*sleepPerSeries, slept) // Never sleeps on the first series
 
@@ -555,6 +560,174 @@ func TestShardWriteAsync(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestShardMetricsBucket(t *testing.T) {
+	assert.Equal(t, "0-15", shardMetricsBucket(0, 16))
+	assert.Equal(t, "16-31", shardMetricsBucket(20, 16))
+	assert.Equal(t, "7-7", shardMetricsBucket(7, 1))
+}
+
+func TestShardReportsMethodMetricsByShardBucket(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	opts := testDatabaseOptions()
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(scope))
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	now := time.Now()
+	require.NoError(t, shard.Write(ctx, ident.StringID("foo"),
+		now, 1.0, xtime.Second, nil))
+	require.Error(t, shard.Write(ctx, ident.StringID("foo"),
+		now.Add(24*time.Hour), 1.0, xtime.Second, nil))
+	_, err := shard.ReadEncoded(ctx, ident.StringID("foo"), now.Add(-time.Minute), now.Add(time.Minute))
+	require.NoError(t, err)
+
+	counters := scope.Snapshot().Counters()
+	counter := func(name string) int64 {
+		c, ok := counters[name]
+		require.True(t, ok, "missing counter: %s", name)
+		return c.Value()
+	}
+	assert.Equal(t, int64(1), counter("dbshard.write.success+shard_bucket=0-15"))
+	assert.Equal(t, int64(1), counter("dbshard.write.errors+shard_bucket=0-15"))
+	assert.Equal(t, int64(1),
+		counter("dbshard.write.error-types+error_type=bad-request,shard_bucket=0-15"))
+	assert.Equal(t, int64(0),
+		counter("dbshard.write.error-types+error_type=internal,shard_bucket=0-15"))
+	assert.Equal(t, int64(1), counter("dbshard.read.success+shard_bucket=0-15"))
+}
+
+func TestShardReportsDebugMetricsWhenEnabled(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	opts := testDatabaseOptions()
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(scope))
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	write := func() {
+		require.NoError(t, shard.Write(ctx, ident.StringID("foo"),
+			time.Now(), 1.0, xtime.Second, nil))
+	}
+	debugWrites := func() (int64, bool) {
+		c, ok := scope.Snapshot().Counters()["dbshard.debug.write.success+shard=0"]
+		if !ok {
+			return 0, false
+		}
+		return c.Value(), true
+	}
+
+	write()
+	_, ok := debugWrites()
+	assert.False(t, ok)
+
+	shard.SetRuntimeOptions(runtime.NewOptions().SetDebugMetricsEnabled(true))
+	write()
+	value, ok := debugWrites()
+	require.True(t, ok)
+	assert.Equal(t, int64(1), value)
+
+	shard.SetRuntimeOptions(runtime.NewOptions().SetDebugMetricsEnabled(false))
+	write()
+	value, _ = debugWrites()
+	assert.Equal(t, int64(1), value)
+}
+
+func TestShardWriteBatchReportsMethodMetrics(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	opts := testDatabaseOptions()
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(scope))
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+	shard.SetRuntimeOptions(runtime.NewOptions().SetDebugMetricsEnabled(true))
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	now := time.Now()
+	writes := []WriteBatchEntry{
+		{ID: ident.StringID("foo"), Timestamp: now, Value: 1.0, Unit: xtime.Second},
+		{ID: ident.StringID("bar"), Timestamp: now, Value: 2.0, Unit: xtime.Second},
+		{ID: ident.StringID("baz"), Timestamp: now.Add(24 * time.Hour), Value: 3.0, Unit: xtime.Second},
+	}
+	shard.WriteBatch(ctx, writes)
+
+	require.NoError(t, writes[0].Err)
+	require.NoError(t, writes[1].Err)
+	require.Error(t, writes[2].Err)
+
+	snapshot := scope.Snapshot()
+	counters := snapshot.Counters()
+	counter := func(name string) int64 {
+		c, ok := counters[name]
+		require.True(t, ok, "missing counter: %s", name)
+		return c.Value()
+	}
+	assert.Equal(t, int64(2), counter("dbshard.write.success+shard_bucket=0-15"))
+	assert.Equal(t, int64(1), counter("dbshard.write.errors+shard_bucket=0-15"))
+	assert.Equal(t, int64(1),
+		counter("dbshard.write.error-types+error_type=bad-request,shard_bucket=0-15"))
+	assert.Equal(t, int64(2), counter("dbshard.debug.write.success+shard=0"))
+	assert.Equal(t, int64(1), counter("dbshard.debug.write.errors+shard=0"))
+
+	// Each write of the batch records the latency of the batch
+	var latencies int64
+	for _, name := range []string{
+		"dbshard.write.success-latency+shard_bucket=0-15",
+		"dbshard.write.errors-latency+shard_bucket=0-15",
+	} {
+		h, ok := snapshot.Histograms()[name]
+		require.True(t, ok, "missing histogram: %s", name)
+		for _, count := range h.Durations() {
+			latencies += count
+		}
+	}
+	assert.Equal(t, int64(3), latencies)
+}
+
+func TestShardWriteBatch(t *testing.T) {
+	opts := testDatabaseOptions()
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	now := time.Now()
+	require.NoError(t, shard.Write(ctx, ident.StringID("foo"), now, 1.0, xtime.Second, nil))
+
+	writes := []WriteBatchEntry{
+		{ID: ident.StringID("foo"), Timestamp: now.Add(time.Second), Value: 2.0, Unit: xtime.Second},
+		{ID: ident.StringID("bar"), Timestamp: now, Value: 3.0, Unit: xtime.Second},
+		{ID: ident.StringID("baz"), Timestamp: now, Value: 4.0, Unit: xtime.Second},
+	}
+	shard.WriteBatch(ctx, writes)
+
+	for _, w := range writes {
+		require.NoError(t, w.Err)
+	}
+
+	for _, id := range []string{"foo", "bar", "baz"} {
+		shard.RLock()
+		entry, _, err := shard.lookupEntryWithLock(ident.StringID(id))
+		shard.RUnlock()
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		assert.Equal(t, int32(0), entry.ReaderWriterCount())
+
+		r, err := shard.ReadEncoded(ctx, ident.StringID(id), now, now.Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(r))
+	}
+}
+
 // This tests a race in shard ticking with an empty series pending expiration.
 func TestShardTickRace(t *testing.T) {
 	opts := testDatabaseOptions()
@@ -731,7 +904,14 @@ func TestPurgeExpiredSeriesNonEmptySeries(t *testing.T) {
 	r, err := shard.tickAndExpire(context.NewNoOpCanncellable(), tickPolicyRegular)
 	require.NoError(t, err)
 	require.Equal(t, 1, r.activeSeries)
+	require.Equal(t, 1, r.createdSeries)
 	require.Equal(t, 0, r.expiredSeries)
+
+	// Series created before the last tick are not counted again
+	r, err = shard.tickAndExpire(context.NewNoOpCanncellable(), tickPolicyRegular)
+	require.NoError(t, err)
+	require.Equal(t, 1, r.activeSeries)
+	require.Equal(t, 0, r.createdSeries)
 }
 
 // This tests the scenario where a series is empty when series.Tick() is called,
@@ -1251,3 +1431,229 @@ func TestShardIterateBatchSize(t *testing.T) {
 
 	require.True(t, shardIterateBatchMinSize < iterateBatchSize(2000))
 }
+
+func TestShardNotifiesNewSeriesSubscriptionsOnceBootstrapped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := testDatabaseOptions()
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	shard.newSeries = newNamespaceNewSeriesSubscriptions(tally.NoopScope)
+	q := index.Query{m3ninxidx.NewTermQuery([]byte("app"), []byte("foo"))}
+	sub, err := shard.newSeries.subscribe(q, NewSeriesSubscriptionOptions{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	tags := ident.NewTags(ident.StringTag("app", "foo"))
+
+	// Series inserted while bootstrapping are not new.
+	shard.newSeriesBootstrapped = false
+	addMockSeries(ctrl, shard, ident.StringID("existing"), tags, 0)
+	require.Equal(t, 0, len(sub.C()))
+
+	shard.newSeriesBootstrapped = true
+	addMockSeries(ctrl, shard, ident.StringID("new"), tags, 1)
+	require.Equal(t, 1, len(sub.C()))
+	series := <-sub.C()
+	require.Equal(t, "new", series.ID.String())
+}
+
+func TestShardAggregateBlock(t *testing.T) {
+	opts := testDatabaseOptions()
+	blockSize := defaultTestRetentionOpts.BlockSize()
+	blockStart := time.Now().Truncate(blockSize)
+	now := blockStart.Add(90 * time.Second)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	for _, write := range []struct {
+		offset time.Duration
+		value  float64
+	}{
+		{10 * time.Second, 1},
+		{20 * time.Second, 2},
+		{70 * time.Second, 3},
+	} {
+		require.NoError(t, shard.Write(ctx, ident.StringID("foo"),
+			blockStart.Add(write.offset), write.value, xtime.Second, nil))
+	}
+
+	type aggregated struct {
+		id        string
+		timestamp time.Time
+		value     float64
+	}
+	var results []aggregated
+	query := namespace.ContinuousQuery{
+		TargetNamespace: "agg",
+		Resolution:      time.Minute,
+		Aggregation:     namespace.ContinuousQuerySum,
+	}
+	err := shard.AggregateBlock(blockStart, query, func(
+		id ident.ID,
+		_ ident.Tags,
+		timestamp time.Time,
+		value float64,
+		_ xtime.Unit,
+	) error {
+		results = append(results, aggregated{
+			id:        id.String(),
+			timestamp: timestamp,
+			value:     value,
+		})
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []aggregated{
+		{id: "foo", timestamp: blockStart.Add(time.Minute), value: 3},
+		{id: "foo", timestamp: blockStart.Add(2 * time.Minute), value: 3},
+	}, results)
+
+	fakeErr := errors.New("fake error")
+	err = shard.AggregateBlock(blockStart, query, func(
+		ident.ID, ident.Tags, time.Time, float64, xtime.Unit,
+	) error {
+		return fakeErr
+	})
+	require.Error(t, err)
+}
+
+type testChangeEmitter struct {
+	sync.Mutex
+	events []cdc.Event
+}
+
+func (e *testChangeEmitter) Enabled(t cdc.EventType) bool { return true }
+func (e *testChangeEmitter) Close() error                 { return nil }
+
+func (e *testChangeEmitter) Emit(event cdc.Event) {
+	e.Lock()
+	e.events = append(e.events, event)
+	e.Unlock()
+}
+
+func TestShardEmitsChangeEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		blockStart = time.Unix(21600, 0)
+		changes    = &testChangeEmitter{}
+		opts       = testDatabaseOptions().SetChangeEmitter(changes)
+	)
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+
+	// Series inserted while bootstrapping are not new.
+	existing := addMockSeries(ctrl, s, ident.StringID("bootstrapped"), ident.Tags{}, 0)
+	require.Empty(t, changes.events)
+
+	s.bootstrapState = Bootstrapped
+	s.newSeriesBootstrapped = true
+	created := addMockSeries(ctrl, s, ident.StringID("foo"),
+		ident.NewTags(ident.StringTag("host", "a")), 1)
+
+	flush := persist.NewMockDataFlush(ctrl)
+	flush.EXPECT().PrepareData(gomock.Any()).Return(persist.PreparedDataPersist{
+		Persist: func(ident.ID, ident.Tags, ts.Segment, uint32) error { return nil },
+		Close:   func() error { return nil },
+	}, nil)
+	for _, curr := range []*series.MockDatabaseSeries{existing, created} {
+		curr.EXPECT().Flush(gomock.Any(), blockStart, gomock.Any()).
+			Return(series.FlushOutcomeFlushedToDisk, nil)
+	}
+	require.NoError(t, s.Flush(blockStart, flush))
+
+	require.Equal(t, []cdc.Event{
+		{
+			Type:      cdc.SeriesCreated,
+			Namespace: defaultTestNs1ID.String(),
+			ID:        []byte("foo"),
+			Tags:      map[string]string{"host": "a"},
+		},
+		{
+			Type:       cdc.BlockFlushed,
+			Namespace:  defaultTestNs1ID.String(),
+			BlockStart: blockStart,
+		},
+	}, changes.events)
+}
+
+func TestShardBuffersWritesWhileInitializing(t *testing.T) {
+	opts := testDatabaseOptions().SetInitializingShardWriteBufferSize(2)
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID, defaultTestNs1Opts)
+	require.NoError(t, err)
+	nsReaderMgr := newNamespaceReaderManager(metadata, tally.NoopScope, opts)
+	seriesOpts := NewSeriesOptionsFromOptions(opts, defaultTestNs1Opts.RetentionOptions())
+
+	var commitLogWrites []string
+	mockCommitLogWriter := commitLogWriter(commitLogWriterFn(func(
+		ctx context.Context,
+		series commitlog.Series,
+		datapoint ts.Datapoint,
+		unit xtime.Unit,
+		annotation ts.Annotation,
+	) error {
+		commitLogWrites = append(commitLogWrites, series.ID.String())
+		return nil
+	}))
+
+	shard := newDatabaseShard(metadata, 0, nil, nsReaderMgr,
+		&testIncreasingIndex{}, mockCommitLogWriter, nil, nil, nil, true, true, opts, seriesOpts).(*dbShard)
+	defer shard.Close()
+	require.NotNil(t, shard.initializingWrites)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	now := time.Now()
+	require.NoError(t, shard.Write(ctx, ident.StringID("foo"), now, 1.0, xtime.Second, nil))
+	require.NoError(t, shard.Write(ctx, ident.StringID("bar"), now, 2.0, xtime.Second, nil))
+	err = shard.Write(ctx, ident.StringID("baz"), now, 3.0, xtime.Second, nil)
+	require.Equal(t, errShardInitializingWritesFull, err)
+
+	writes := []WriteBatchEntry{
+		{ID: ident.StringID("qux"), Timestamp: now, Value: 4.0, Unit: xtime.Second},
+	}
+	shard.WriteBatch(ctx, writes)
+	require.Equal(t, errShardInitializingWritesFull, writes[0].Err)
+
+	// The buffered writes are kept separate from the series of the shard
+	// but are written to the commit log as they are buffered.
+	require.Equal(t, int64(0), shard.NumSeries())
+	require.Equal(t, 2, shard.initializingWrites.Len())
+	require.Equal(t, []string{"foo", "bar"}, commitLogWrites)
+
+	// Replaying the buffered writes does not write them to the commit log again.
+	require.NoError(t, shard.Bootstrap(result.NewMap(result.MapOptions{})))
+	require.Equal(t, int64(2), shard.NumSeries())
+	require.Equal(t, 0, shard.initializingWrites.Len())
+	require.Equal(t, []string{"foo", "bar"}, commitLogWrites)
+
+	for _, id := range []string{"foo", "bar"} {
+		r, err := shard.ReadEncoded(ctx, ident.StringID(id), now, now.Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(r))
+	}
+
+	// Writes once bootstrapped go straight to the series of the shard.
+	require.NoError(t, shard.Write(ctx, ident.StringID("baz"), now, 3.0, xtime.Second, nil))
+	require.Equal(t, int64(3), shard.NumSeries())
+	require.Equal(t, 0, shard.initializingWrites.Len())
+}
+
+func TestShardDoesNotBufferWritesWhenNotInitializing(t *testing.T) {
+	opts := testDatabaseOptions().SetInitializingShardWriteBufferSize(2)
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+	require.Nil(t, shard.initializingWrites)
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+)
+
+const (
+	// shardTrafficWindow is the window over which the traffic of a shard is
+	// counted before being reported as the last window's traffic.
+	shardTrafficWindow = time.Minute
+
+	// shardTrafficSampleEvery is the rate at which operations are sampled to
+	// estimate the series responsible for the traffic of a shard.
+	shardTrafficSampleEvery = 32
+
+	// shardTrafficTrackedSeries is the number of series tracked per shard to
+	// estimate the series responsible for the traffic of a shard.
+	shardTrafficTrackedSeries = 32
+
+	// shardTrafficTopSeries is the number of series reported per shard.
+	shardTrafficTopSeries = 10
+
+	metricNameTag = "__name__"
+)
+
+// ShardTraffic is the traffic a shard received over the last window.
+type ShardTraffic struct {
+	Shard     uint32
+	Window    time.Duration
+	Writes    int64
+	Reads     int64
+	TopSeries []SeriesTraffic
+}
+
+// Ops returns the total number of operations the shard received.
+func (t ShardTraffic) Ops() int64 {
+	return t.Writes + t.Reads
+}
+
+// SeriesTraffic is the estimated traffic a series received, estimated by
+// sampling operations so low traffic series may not be reported.
+type SeriesTraffic struct {
+	ID string
+	// Pattern is the metric name and sorted tag names of the series such as
+	// http_requests{host,method}, empty if the series was written untagged.
+	Pattern string
+	Writes  int64
+	Reads   int64
+}
+
+// Ops returns the total number of operations the series received.
+func (t SeriesTraffic) Ops() int64 {
+	return t.Writes + t.Reads
+}
+
+// DetectHotShards returns the shards that received more than factor times
+// the mean operations across the shards and at least minOps operations,
+// sorted by descending operations.
+func DetectHotShards(
+	traffic []ShardTraffic,
+	factor float64,
+	minOps int64,
+) []ShardTraffic {
+	if len(traffic) == 0 {
+		return nil
+	}
+
+	var total int64
+	for _, t := range traffic {
+		total += t.Ops()
+	}
+	mean := float64(total) / float64(len(traffic))
+
+	var hot []ShardTraffic
+	for _, t := range traffic {
+		if t.Ops() >= minOps && float64(t.Ops()) > factor*mean {
+			hot = append(hot, t)
+		}
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		return hot[i].Ops() > hot[j].Ops()
+	})
+	return hot
+}
+
+type trafficOp int
+
+const (
+	trafficOpWrite trafficOp = iota
+	trafficOpRead
+)
+
+// shardTraffic counts the operations a shard receives per window and
+// estimates the series responsible by tracking the heaviest hitters of
+// sampled operations.
+type shardTraffic struct {
+	sync.Mutex
+
+	shard   uint32
+	nowFn   func() time.Time
+	writes  int64
+	reads   int64
+	sampled uint64
+
+	windowStart time.Time
+	series      map[string]*SeriesTraffic
+	last        ShardTraffic
+}
+
+func newShardTraffic(shard uint32, nowFn func() time.Time) *shardTraffic {
+	return &shardTraffic{
+		shard:       shard,
+		nowFn:       nowFn,
+		windowStart: nowFn(),
+		series:      make(map[string]*SeriesTraffic, shardTrafficTrackedSeries),
+		last:        ShardTraffic{Shard: shard, Window: shardTrafficWindow},
+	}
+}
+
+func (t *shardTraffic) record(op trafficOp, id ident.ID, tags ident.TagIterator) {
+	switch op {
+	case trafficOpWrite:
+		atomic.AddInt64(&t.writes, 1)
+	case trafficOpRead:
+		atomic.AddInt64(&t.reads, 1)
+	}
+
+	if atomic.AddUint64(&t.sampled, 1)%shardTrafficSampleEvery != 0 {
+		return
+	}
+
+	key := id.String()
+	t.Lock()
+	t.rotateWithLock(t.nowFn())
+	entry, ok := t.series[key]
+	if !ok {
+		entry = t.trackWithLock(key, tags)
+	}
+	switch op {
+	case trafficOpWrite:
+		entry.Writes += shardTrafficSampleEvery
+	case trafficOpRead:
+		entry.Reads += shardTrafficSampleEvery
+	}
+	t.Unlock()
+}
+
+// trackWithLock starts tracking a series, replacing the series with the least
+// operations once the tracked series are full and carrying over its count so
+// that a heavy hitter arriving late in the window is not underestimated.
+func (t *shardTraffic) trackWithLock(key string, tags ident.TagIterator) *SeriesTraffic {
+	entry := &SeriesTraffic{ID: key, Pattern: seriesPattern(tags)}
+	if len(t.series) >= shardTrafficTrackedSeries {
+		var min *SeriesTraffic
+		for _, s := range t.series {
+			if min == nil || s.Ops() < min.Ops() {
+				min = s
+			}
+		}
+		delete(t.series, min.ID)
+		entry.Writes, entry.Reads = min.Writes, min.Reads
+	}
+	t.series[key] = entry
+	return entry
+}
+
+func (t *shardTraffic) rotateWithLock(now time.Time) {
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < shardTrafficWindow {
+		return
+	}
+
+	// Rotation happens lazily so the counts may span more than one window,
+	// scale them to the window so that shards are comparable.
+	scale := func(v int64) int64 {
+		return int64(float64(v) * float64(shardTrafficWindow) / float64(elapsed))
+	}
+	last := ShardTraffic{
+		Shard:  t.shard,
+		Window: shardTrafficWindow,
+		Writes: scale(atomic.SwapInt64(&t.writes, 0)),
+		Reads:  scale(atomic.SwapInt64(&t.reads, 0)),
+	}
+	for _, s := range t.series {
+		last.TopSeries = append(last.TopSeries, SeriesTraffic{
+			ID:      s.ID,
+			Pattern: s.Pattern,
+			Writes:  scale(s.Writes),
+			Reads:   scale(s.Reads),
+		})
+	}
+	sort.Slice(last.TopSeries, func(i, j int) bool {
+		return last.TopSeries[i].Ops() > last.TopSeries[j].Ops()
+	})
+	if len(last.TopSeries) > shardTrafficTopSeries {
+		last.TopSeries = last.TopSeries[:shardTrafficTopSeries]
+	}
+
+	t.last = last
+	t.windowStart = now
+	t.series = make(map[string]*SeriesTraffic, shardTrafficTrackedSeries)
+}
+
+// Traffic returns the traffic of the last completed window.
+func (t *shardTraffic) Traffic() ShardTraffic {
+	t.Lock()
+	t.rotateWithLock(t.nowFn())
+	last := t.last
+	t.Unlock()
+	return last
+}
+
+// seriesPattern returns the metric name followed by the sorted tag names of
+// the tags, or empty if there are no tags.
+func seriesPattern(tags ident.TagIterator) string {
+	if tags == nil || tags.Remaining() == 0 {
+		return ""
+	}
+
+	var (
+		iter  = tags.Duplicate()
+		name  string
+		names []string
+	)
+	for iter.Next() {
+		tag := iter.Current()
+		if tag.Name.String() == metricNameTag {
+			name = tag.Value.String()
+			continue
+		}
+		names = append(names, tag.Name.String())
+	}
+	iter.Close()
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	buf.WriteString("{")
+	for i, n := range names {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(n)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
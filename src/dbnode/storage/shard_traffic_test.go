@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardTrafficReportsLastWindow(t *testing.T) {
+	now := time.Now()
+	nowFn := func() time.Time { return now }
+	traffic := newShardTraffic(3, nowFn)
+
+	hot := ident.StringID("hot")
+	tags := ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("__name__", "requests"),
+		ident.StringTag("method", "GET"),
+		ident.StringTag("host", "a"),
+	))
+	for i := 0; i < 10*shardTrafficSampleEvery; i++ {
+		traffic.record(trafficOpWrite, hot, tags)
+	}
+	for i := 0; i < shardTrafficSampleEvery; i++ {
+		traffic.record(trafficOpRead, ident.StringID("cold"), nil)
+	}
+
+	// Nothing is reported until the window completes.
+	assert.Equal(t, int64(0), traffic.Traffic().Ops())
+
+	now = now.Add(shardTrafficWindow)
+	result := traffic.Traffic()
+	assert.Equal(t, uint32(3), result.Shard)
+	assert.Equal(t, int64(10*shardTrafficSampleEvery), result.Writes)
+	assert.Equal(t, int64(shardTrafficSampleEvery), result.Reads)
+	require.True(t, len(result.TopSeries) > 0)
+	assert.Equal(t, "hot", result.TopSeries[0].ID)
+	assert.Equal(t, "requests{host,method}", result.TopSeries[0].Pattern)
+	assert.Equal(t, int64(10*shardTrafficSampleEvery), result.TopSeries[0].Writes)
+
+	// The counts of a window spanning two windows are scaled to one window.
+	for i := 0; i < 2*shardTrafficSampleEvery; i++ {
+		traffic.record(trafficOpWrite, hot, nil)
+	}
+	now = now.Add(2 * shardTrafficWindow)
+	assert.Equal(t, int64(shardTrafficSampleEvery), traffic.Traffic().Writes)
+}
+
+func TestShardTrafficTracksHeavyHitters(t *testing.T) {
+	now := time.Now()
+	traffic := newShardTraffic(0, func() time.Time { return now })
+
+	// Fill the tracked series with series sampled once.
+	for i := 0; i < shardTrafficTrackedSeries*shardTrafficSampleEvery; i++ {
+		id := ident.StringID(string(rune('a' + i/shardTrafficSampleEvery)))
+		traffic.record(trafficOpWrite, id, nil)
+	}
+	// A heavy hitter arriving late is still tracked.
+	for i := 0; i < 5*shardTrafficSampleEvery; i++ {
+		traffic.record(trafficOpWrite, ident.StringID("late"), nil)
+	}
+
+	now = now.Add(shardTrafficWindow)
+	result := traffic.Traffic()
+	require.Len(t, result.TopSeries, shardTrafficTopSeries)
+	assert.Equal(t, "late", result.TopSeries[0].ID)
+}
+
+func TestDetectHotShards(t *testing.T) {
+	traffic := []ShardTraffic{
+		{Shard: 0, Writes: 100},
+		{Shard: 1, Writes: 100, Reads: 20},
+		{Shard: 2, Writes: 1000},
+		{Shard: 3, Writes: 90},
+		{Shard: 4, Reads: 2000},
+		{Shard: 5, Writes: 100},
+		{Shard: 6, Writes: 100},
+		{Shard: 7, Writes: 100},
+		{Shard: 8, Writes: 100},
+	}
+
+	hot := DetectHotShards(traffic, 2, 0)
+	require.Len(t, hot, 2)
+	assert.Equal(t, uint32(4), hot[0].Shard)
+	assert.Equal(t, uint32(2), hot[1].Shard)
+
+	hot = DetectHotShards(traffic, 2, 1500)
+	require.Len(t, hot, 1)
+	assert.Equal(t, uint32(4), hot[0].Shard)
+
+	assert.Nil(t, DetectHotShards(nil, 2, 0))
+}
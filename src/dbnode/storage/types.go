@@ -34,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/cdc"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
@@ -51,6 +52,19 @@ import (
 // PageToken is an opaque paging token.
 type PageToken []byte
 
+// WriteBatchEntry is a single write of a batch of writes.
+type WriteBatchEntry struct {
+	ID         ident.ID
+	Tags       ident.TagIterator
+	Timestamp  time.Time
+	Value      float64
+	Unit       xtime.Unit
+	Annotation []byte
+
+	// Err is the error of the write, set once the batch is written.
+	Err error
+}
+
 // Database is a time series database
 type Database interface {
 	// Options returns the database options
@@ -103,6 +117,23 @@ type Database interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of values to the database, the error of each
+	// write is set on its entry and the returned error is set only if the
+	// batch could not be written at all
+	WriteBatch(
+		ctx context.Context,
+		namespace ident.ID,
+		writes []WriteBatchEntry,
+	) error
+
+	// WriteTaggedBatch writes a batch of tagged values to the database, see
+	// WriteBatch
+	WriteTaggedBatch(
+		ctx context.Context,
+		namespace ident.ID,
+		writes []WriteBatchEntry,
+	) error
+
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
 		ctx context.Context,
@@ -163,16 +194,87 @@ type Database interface {
 	// IsOverloaded determines whether the database is overloaded
 	IsOverloaded() bool
 
+	// Drain marks the database as draining ahead of a planned shutdown so that
+	// it reports itself as unhealthy, and if snapshot is true waits for any in
+	// progress file operations and then flushes and snapshots all unflushed
+	// series so they do not need to be replayed from the commit log on restart.
+	// Drain may be called again while draining to snapshot again.
+	Drain(snapshot bool) error
+
+	// IsDraining determines whether the database is draining.
+	IsDraining() bool
+
+	// Snapshot forces a tick that flushes and snapshots all owned namespaces
+	// and returns once the snapshot files have been written.
+	Snapshot() error
+
 	// Repair will issue a repair and return nil on success or error on error.
 	Repair() error
 
 	// Truncate truncates data for the given namespace
 	Truncate(namespace ident.ID) (int64, error)
 
+	// ForceFlush flushes the flushable blocks of the given shards of the
+	// namespace, or of all of its bootstrapped shards if none are given,
+	// followed by its index without waiting for the file operations that
+	// follow ticks, it returns the shards that were flushed.
+	ForceFlush(namespace ident.ID, shards []uint32) ([]uint32, error)
+
+	// ForceCompact compacts the index filesets of the namespace without
+	// waiting for the file operations that follow ticks.
+	ForceCompact(namespace ident.ID) error
+
+	// Prune deletes the expired filesets of the given shards of the namespace,
+	// or of all of its bootstrapped shards and its expired index filesets if
+	// none are given, without waiting for the file operations that follow
+	// ticks, it returns the shards that were pruned.
+	Prune(namespace ident.ID, shards []uint32) ([]uint32, error)
+
+	// SubscribeNewSeries subscribes to series created in the namespace
+	// after it has bootstrapped whose tags match the query.
+	SubscribeNewSeries(
+		namespace ident.ID,
+		query index.Query,
+		opts NewSeriesSubscriptionOptions,
+	) (NewSeriesSubscription, error)
+
 	// BootstrapState captures and returns a snapshot of the databases' bootstrap state.
 	BootstrapState() DatabaseBootstrapState
 }
 
+// NewSeries is a series created after a new series subscription was made.
+type NewSeries struct {
+	Shard uint32
+	ID    ident.ID
+	Tags  ident.Tags
+}
+
+// NewSeriesSubscriptionOptions are options for subscribing to new series.
+type NewSeriesSubscriptionOptions struct {
+	// Shards restricts the subscription to series in the given shards, series
+	// in any shard are delivered if no shards are specified.
+	Shards []uint32
+
+	// BufferSize is the number of new series buffered for the subscriber
+	// before new series are dropped, a default is used if zero.
+	BufferSize int
+}
+
+// NewSeriesSubscription delivers series created in a namespace whose tags
+// match the query of the subscription.
+type NewSeriesSubscription interface {
+	// C returns the channel new series are delivered on, the channel is
+	// closed when the subscription is closed.
+	C() <-chan NewSeries
+
+	// Dropped returns the number of new series that were dropped because
+	// the subscriber did not keep up.
+	Dropped() int64
+
+	// Close closes the subscription.
+	Close()
+}
+
 // database is the internal database interface
 type database interface {
 	Database
@@ -197,6 +299,10 @@ type Namespace interface {
 
 	// Shards returns the shard description
 	Shards() []Shard
+
+	// WritePatterns returns the write patterns of the namespace measured
+	// from sampling its most recent writes and queries
+	WritePatterns() WritePatterns
 }
 
 // NamespacesByID is a sortable slice of namespaces by ID
@@ -247,6 +353,12 @@ type databaseNamespace interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of values to the namespace
+	WriteBatch(ctx context.Context, writes []WriteBatchEntry) error
+
+	// WriteTaggedBatch writes a batch of tagged values to the namespace
+	WriteTaggedBatch(ctx context.Context, writes []WriteBatchEntry) error
+
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
 		ctx context.Context,
@@ -254,6 +366,13 @@ type databaseNamespace interface {
 		opts index.QueryOptions,
 	) (index.QueryResults, error)
 
+	// SubscribeNewSeries subscribes to series created in the namespace
+	// whose tags match the query.
+	SubscribeNewSeries(
+		query index.Query,
+		opts NewSeriesSubscriptionOptions,
+	) (NewSeriesSubscription, error)
+
 	// ReadEncoded reads data for given id within [start, end)
 	ReadEncoded(
 		ctx context.Context,
@@ -299,6 +418,15 @@ type databaseNamespace interface {
 		flush persist.DataFlush,
 	) error
 
+	// AggregateBlock aggregates the series of a flushed block into windows
+	// of the continuous query resolution for the shards that have flushed
+	// the block.
+	AggregateBlock(
+		blockStart time.Time,
+		query namespace.ContinuousQuery,
+		fn continuousQueryFn,
+	) error
+
 	// FlushIndex flushes in-memory index data.
 	FlushIndex(
 		flush persist.IndexFlush,
@@ -345,6 +473,10 @@ type Shard interface {
 
 	// BootstrapState returns the shards' bootstrap state.
 	BootstrapState() BootstrapState
+
+	// Traffic returns the operations the shard received over the last
+	// window along with the series estimated to be responsible.
+	Traffic() ShardTraffic
 }
 
 type databaseShard interface {
@@ -381,6 +513,14 @@ type databaseShard interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of values to the shard, setting the error
+	// of each write on its entry
+	WriteBatch(ctx context.Context, writes []WriteBatchEntry)
+
+	// WriteTaggedBatch writes a batch of tagged values to the shard, setting
+	// the error of each write on its entry
+	WriteTaggedBatch(ctx context.Context, writes []WriteBatchEntry)
+
 	ReadEncoded(
 		ctx context.Context,
 		id ident.ID,
@@ -423,6 +563,14 @@ type databaseShard interface {
 		flush persist.DataFlush,
 	) error
 
+	// AggregateBlock aggregates the series' in this shard for a flushed
+	// block into windows of the continuous query resolution.
+	AggregateBlock(
+		blockStart time.Time,
+		query namespace.ContinuousQuery,
+		fn continuousQueryFn,
+	) error
+
 	// Snapshot snapshot's the unflushed series' in this shard.
 	Snapshot(blockStart, snapshotStart time.Time, flush persist.DataFlush) error
 
@@ -435,9 +583,17 @@ type databaseShard interface {
 	// CleanupSnapshots cleans up snapshot files.
 	CleanupSnapshots(earliestToRetain time.Time) error
 
+	// SnapshotFilesToCleanup returns the snapshot files that CleanupSnapshots
+	// would delete, without deleting them.
+	SnapshotFilesToCleanup(earliestToRetain time.Time) ([]string, error)
+
 	// CleanupExpiredFileSets removes expired fileset files.
 	CleanupExpiredFileSets(earliestToRetain time.Time) error
 
+	// ExpiredFileSets returns the fileset files that CleanupExpiredFileSets
+	// would delete, without deleting them.
+	ExpiredFileSets(earliestToRetain time.Time) ([]string, error)
+
 	// Repair repairs the shard data for a given time.
 	Repair(
 		ctx context.Context,
@@ -459,6 +615,10 @@ type namespaceIndex interface {
 		batch *index.WriteBatch,
 	) error
 
+	// WriteBackpressure returns a resource exhausted error with a hint of
+	// when to retry if the index is unable to accept any new series.
+	WriteBackpressure() error
+
 	// Query resolves the given query into known IDs.
 	Query(
 		ctx context.Context,
@@ -475,6 +635,15 @@ type namespaceIndex interface {
 	// using the provided `t` as the frame of reference.
 	CleanupExpiredFileSets(t time.Time) error
 
+	// ExpiredFileSets returns the fileset files that CleanupExpiredFileSets
+	// would delete, without deleting them.
+	ExpiredFileSets(t time.Time) ([]string, error)
+
+	// CompactFileSets merges the fileset volumes of flushed block starts
+	// with many volumes into a single volume per block start, using the
+	// provided `t` as the frame of reference.
+	CompactFileSets(t time.Time) error
+
 	// Tick performs internal house keeping in the index, including block rotation,
 	// data eviction, and so on.
 	Tick(c context.Cancellable, tickStart time.Time) (namespaceIndexTickResult, error)
@@ -514,6 +683,10 @@ type namespaceIndexInsertQueue interface {
 	// based on the result of the execution. The returned wait group can be used
 	// if the insert is required to be synchronous.
 	InsertBatch(batch *index.WriteBatch) (*sync.WaitGroup, error)
+
+	// Backpressure returns a resource exhausted error with a hint of when
+	// to retry if the queue is saturated and is not accepting inserts.
+	Backpressure() error
 }
 
 // databaseBootstrapManager manages the bootstrap process.
@@ -528,6 +701,16 @@ type databaseBootstrapManager interface {
 	Report()
 }
 
+// continuousQueryFn is called with each datapoint aggregated by a
+// continuous query, the timestamp is the end of the aggregated window.
+type continuousQueryFn func(
+	id ident.ID,
+	tags ident.Tags,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+) error
+
 // databaseFlushManager manages flushing in-memory data to persistent storage.
 type databaseFlushManager interface {
 	// Flush flushes in-memory data to persistent storage.
@@ -606,6 +789,18 @@ type databaseRepairer interface {
 	Report()
 }
 
+// databaseConsistencyChecker compares sampled blocks across replicas
+type databaseConsistencyChecker interface {
+	// Start starts the consistency checks
+	Start()
+
+	// Stop stops the consistency checks
+	Stop()
+
+	// Check compares sampled blocks of each namespace across replicas
+	Check() error
+}
+
 // databaseTickManager performs periodic ticking
 type databaseTickManager interface {
 	// Tick performs maintenance operations, restarting the current
@@ -634,6 +829,10 @@ type databaseMediator interface {
 	// Tick performs a tick
 	Tick(runType runType, forceType forceType) error
 
+	// ForceTick forces a tick without the file operations that follow it and
+	// returns the tick start
+	ForceTick() (time.Time, error)
+
 	// Repair repairs the database
 	Repair() error
 
@@ -724,6 +923,40 @@ type Options interface {
 	// IndexOptions returns the indexing options.
 	IndexOptions() index.Options
 
+	// SetCleanupDryRun sets whether cleanup only reports the files it would
+	// reclaim instead of deleting them.
+	SetCleanupDryRun(value bool) Options
+
+	// CleanupDryRun returns whether cleanup only reports the files it would
+	// reclaim instead of deleting them.
+	CleanupDryRun() bool
+
+	// SetShardMetricsBucketSize sets the number of consecutive shards that
+	// share a set of per shard latency and error metrics.
+	SetShardMetricsBucketSize(value int) Options
+
+	// ShardMetricsBucketSize returns the number of consecutive shards that
+	// share a set of per shard latency and error metrics.
+	ShardMetricsBucketSize() int
+
+	// SetInitializingShardWriteBufferSize sets the maximum number of writes
+	// buffered per shard while the shard is initializing, until the shard
+	// is bootstrapped, zero writes to the series of the shard directly.
+	SetInitializingShardWriteBufferSize(value int) Options
+
+	// InitializingShardWriteBufferSize returns the maximum number of writes
+	// buffered per shard while the shard is initializing, until the shard
+	// is bootstrapped, zero writes to the series of the shard directly.
+	InitializingShardWriteBufferSize() int
+
+	// SetChangeEmitter sets the emitter of change events, such as series
+	// created and blocks flushed or expired.
+	SetChangeEmitter(value cdc.Emitter) Options
+
+	// ChangeEmitter returns the emitter of change events, such as series
+	// created and blocks flushed or expired.
+	ChangeEmitter() cdc.Emitter
+
 	// SetRepairEnabled sets whether or not to enable the repair.
 	SetRepairEnabled(b bool) Options
 
@@ -0,0 +1,315 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package testsetup
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	ttnode "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/node"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3cluster/shard"
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	errNodeAlreadyStarted = errors.New("node already started")
+	errNodeNotStarted     = errors.New("node not started")
+	errNodeClosed         = errors.New("node closed")
+)
+
+type nodeState int
+
+const (
+	nodeStateNotStarted nodeState = iota
+	nodeStateStarted
+	nodeStateClosed
+)
+
+type node struct {
+	sync.Mutex
+
+	hostID         string
+	address        string
+	namespaces     []namespace.Metadata
+	filePathPrefix string
+	ownsDir        bool
+	shardSet       sharding.ShardSet
+	storageOpts    storage.Options
+	clientOpts     client.Options
+
+	state       nodeState
+	db          storage.Database
+	client      client.Client
+	serverClose func()
+}
+
+// NewNode creates a new in-process node, the node does not serve traffic
+// until it is started.
+func NewNode(opts Options) (Node, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	namespaces := opts.Namespaces()
+	if len(namespaces) == 0 {
+		md, err := namespace.NewMetadata(ident.StringID(DefaultNamespaceID),
+			namespace.NewOptions().
+				SetIndexOptions(namespace.NewIndexOptions().SetEnabled(true)))
+		if err != nil {
+			return nil, err
+		}
+		namespaces = []namespace.Metadata{md}
+	}
+
+	address := opts.Address()
+	if address == "" {
+		var err error
+		address, err = freeLocalAddress()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shardSet, err := newShardSet(opts.NumShards())
+	if err != nil {
+		return nil, err
+	}
+
+	filePathPrefix := opts.FilePathPrefix()
+	ownsDir := false
+	if filePathPrefix == "" {
+		filePathPrefix, err = ioutil.TempDir("", "m3db-testsetup")
+		if err != nil {
+			return nil, err
+		}
+		ownsDir = true
+	}
+
+	storageOpts, err := newStorageOptions(opts.StorageOptions(),
+		namespaces, filePathPrefix)
+	if err != nil {
+		if ownsDir {
+			os.RemoveAll(filePathPrefix)
+		}
+		return nil, err
+	}
+
+	hostShardSet := topology.NewHostShardSet(
+		topology.NewHost(opts.HostID(), address), shardSet)
+	topoInit := topology.NewStaticInitializer(topology.NewStaticOptions().
+		SetShardSet(shardSet).
+		SetReplicas(1).
+		SetHostShardSets([]topology.HostShardSet{hostShardSet}))
+
+	return &node{
+		hostID:         opts.HostID(),
+		address:        address,
+		namespaces:     namespaces,
+		filePathPrefix: filePathPrefix,
+		ownsDir:        ownsDir,
+		shardSet:       shardSet,
+		storageOpts:    storageOpts,
+		clientOpts:     opts.ClientOptions().SetTopologyInitializer(topoInit),
+	}, nil
+}
+
+func (n *node) Start() error {
+	n.Lock()
+	defer n.Unlock()
+
+	switch n.state {
+	case nodeStateStarted:
+		return errNodeAlreadyStarted
+	case nodeStateClosed:
+		return errNodeClosed
+	}
+
+	db, err := storage.NewDatabase(n.shardSet, n.storageOpts)
+	if err != nil {
+		return err
+	}
+	if err := db.Open(); err != nil {
+		return fmt.Errorf("could not open database: %v", err)
+	}
+
+	serverClose, err := ttnode.NewServer(db, n.address,
+		n.storageOpts.ContextPool(), nil, tchannelthrift.NewOptions()).ListenAndServe()
+	if err != nil {
+		db.Terminate()
+		return fmt.Errorf("could not open tchannelthrift interface %s: %v",
+			n.address, err)
+	}
+
+	if err := db.Bootstrap(); err != nil {
+		serverClose()
+		db.Terminate()
+		return fmt.Errorf("bootstrapping database encountered error: %v", err)
+	}
+
+	c, err := client.NewClient(n.clientOpts)
+	if err != nil {
+		serverClose()
+		db.Terminate()
+		return err
+	}
+
+	n.db = db
+	n.client = c
+	n.serverClose = serverClose
+	n.state = nodeStateStarted
+	return nil
+}
+
+func (n *node) Stop() error {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.state != nodeStateStarted {
+		return errNodeNotStarted
+	}
+	return n.stopWithLock()
+}
+
+func (n *node) stopWithLock() error {
+	var sessionErr error
+	if n.client.DefaultSessionActive() {
+		session, err := n.client.DefaultSession()
+		if err == nil {
+			sessionErr = session.Close()
+		}
+	}
+
+	n.serverClose()
+	err := n.db.Terminate()
+
+	n.db = nil
+	n.client = nil
+	n.serverClose = nil
+	n.state = nodeStateNotStarted
+
+	if err != nil {
+		return err
+	}
+	return sessionErr
+}
+
+func (n *node) Close() error {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.state == nodeStateClosed {
+		return errNodeClosed
+	}
+
+	var err error
+	if n.state == nodeStateStarted {
+		err = n.stopWithLock()
+	}
+	n.state = nodeStateClosed
+
+	if n.ownsDir {
+		if rmErr := os.RemoveAll(n.filePathPrefix); err == nil {
+			err = rmErr
+		}
+	}
+	return err
+}
+
+func (n *node) HostID() string {
+	return n.hostID
+}
+
+func (n *node) Address() string {
+	return n.address
+}
+
+func (n *node) Namespaces() []namespace.Metadata {
+	return n.namespaces
+}
+
+func (n *node) Database() storage.Database {
+	n.Lock()
+	defer n.Unlock()
+	return n.db
+}
+
+func (n *node) Client() client.Client {
+	n.Lock()
+	defer n.Unlock()
+	return n.client
+}
+
+func newStorageOptions(
+	opts storage.Options,
+	namespaces []namespace.Metadata,
+	filePathPrefix string,
+) (storage.Options, error) {
+	fsOpts := opts.CommitLogOptions().FilesystemOptions().
+		SetFilePathPrefix(filePathPrefix)
+	pm, err := fs.NewPersistManager(fsOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep every series in memory and index new series synchronously so
+	// that writes are immediately visible to reads and queries.
+	return opts.
+		SetNamespaceInitializer(namespace.NewStaticInitializer(namespaces)).
+		SetSeriesCachePolicy(series.CacheAll).
+		SetRepairEnabled(false).
+		SetIndexOptions(opts.IndexOptions().SetInsertMode(index.InsertSync)).
+		SetCommitLogOptions(opts.CommitLogOptions().SetFilesystemOptions(fsOpts)).
+		SetPersistManager(pm), nil
+}
+
+func newShardSet(numShards int) (sharding.ShardSet, error) {
+	ids := make([]uint32, 0, numShards)
+	for i := uint32(0); i < uint32(numShards); i++ {
+		ids = append(ids, i)
+	}
+	shards := sharding.NewShards(ids, shard.Available)
+	return sharding.NewShardSet(shards, sharding.DefaultHashFn(numShards))
+}
+
+func freeLocalAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	address := listener.Addr().String()
+	if err := listener.Close(); err != nil {
+		return "", err
+	}
+	return address, nil
+}
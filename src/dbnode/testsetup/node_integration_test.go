@@ -0,0 +1,72 @@
+// +build integration
+
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package testsetup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeWriteFetch(t *testing.T) {
+	n, err := NewNode(NewOptions())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, n.Close())
+	}()
+
+	require.NoError(t, n.Start())
+	require.Equal(t, errNodeAlreadyStarted, n.Start())
+
+	session, err := n.Client().DefaultSession()
+	require.NoError(t, err)
+
+	var (
+		nsID  = ident.StringID(DefaultNamespaceID)
+		id    = ident.StringID("foo")
+		start = time.Now().Truncate(time.Second)
+	)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, session.Write(nsID, id,
+			start.Add(time.Duration(i)*time.Second), float64(i), xtime.Second, nil))
+	}
+
+	iter, err := session.Fetch(nsID, id, start, start.Add(time.Minute))
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var values []float64
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		values = append(values, dp.Value)
+	}
+	require.NoError(t, iter.Err())
+	require.Equal(t, []float64{0, 1, 2}, values)
+
+	require.NoError(t, n.Stop())
+	require.Equal(t, errNodeNotStarted, n.Stop())
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package testsetup
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+)
+
+const (
+	// DefaultNamespaceID is the ID of the namespace created when no
+	// namespaces are configured.
+	DefaultNamespaceID = "default"
+
+	// defaultHostID is the default host ID of the node.
+	defaultHostID = "testsetup"
+
+	// defaultNumShards is the default number of shards owned by the node.
+	defaultNumShards = 16
+)
+
+var (
+	errHostIDEmpty           = errors.New("host ID is empty")
+	errNumShardsNotPositive  = errors.New("number of shards must be positive")
+	errStorageOptionsNotSet  = errors.New("storage options not set")
+	errClientOptionsNotSet   = errors.New("client options not set")
+	errDuplicateNamespaceIDs = errors.New("namespace IDs must be unique")
+)
+
+type options struct {
+	hostID         string
+	address        string
+	numShards      int
+	namespaces     []namespace.Metadata
+	filePathPrefix string
+	storageOpts    storage.Options
+	clientOpts     client.Options
+}
+
+// NewOptions creates a new set of in-process node options.
+func NewOptions() Options {
+	return &options{
+		hostID:      defaultHostID,
+		numShards:   defaultNumShards,
+		storageOpts: storage.NewOptions(),
+		clientOpts:  client.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.hostID == "" {
+		return errHostIDEmpty
+	}
+	if o.numShards <= 0 {
+		return errNumShardsNotPositive
+	}
+	if o.storageOpts == nil {
+		return errStorageOptionsNotSet
+	}
+	if o.clientOpts == nil {
+		return errClientOptionsNotSet
+	}
+	seen := make(map[string]struct{}, len(o.namespaces))
+	for _, md := range o.namespaces {
+		id := md.ID().String()
+		if _, ok := seen[id]; ok {
+			return errDuplicateNamespaceIDs
+		}
+		seen[id] = struct{}{}
+	}
+	return nil
+}
+
+func (o *options) SetHostID(value string) Options {
+	opts := *o
+	opts.hostID = value
+	return &opts
+}
+
+func (o *options) HostID() string {
+	return o.hostID
+}
+
+func (o *options) SetAddress(value string) Options {
+	opts := *o
+	opts.address = value
+	return &opts
+}
+
+func (o *options) Address() string {
+	return o.address
+}
+
+func (o *options) SetNumShards(value int) Options {
+	opts := *o
+	opts.numShards = value
+	return &opts
+}
+
+func (o *options) NumShards() int {
+	return o.numShards
+}
+
+func (o *options) SetNamespaces(value []namespace.Metadata) Options {
+	opts := *o
+	opts.namespaces = value
+	return &opts
+}
+
+func (o *options) Namespaces() []namespace.Metadata {
+	return o.namespaces
+}
+
+func (o *options) SetFilePathPrefix(value string) Options {
+	opts := *o
+	opts.filePathPrefix = value
+	return &opts
+}
+
+func (o *options) FilePathPrefix() string {
+	return o.filePathPrefix
+}
+
+func (o *options) SetStorageOptions(value storage.Options) Options {
+	opts := *o
+	opts.storageOpts = value
+	return &opts
+}
+
+func (o *options) StorageOptions() storage.Options {
+	return o.storageOpts
+}
+
+func (o *options) SetClientOptions(value client.Options) Options {
+	opts := *o
+	opts.clientOpts = value
+	return &opts
+}
+
+func (o *options) ClientOptions() client.Options {
+	return o.clientOpts
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package testsetup provides an in-process, single node M3DB for use in
+// integration tests of downstream services. The node runs without etcd or
+// any external processes, serves the node TChannel API on a local address
+// and exposes a client connected to it.
+package testsetup
+
+import (
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+)
+
+// Node is an in-process, single node M3DB.
+type Node interface {
+	// Start opens and bootstraps the database and starts serving traffic,
+	// it returns once the node is ready to accept reads and writes.
+	Start() error
+
+	// Stop stops serving traffic and terminates the database.
+	Stop() error
+
+	// Close stops the node if it is running and releases any resources
+	// held by the node, including its temporary data directory if one
+	// was created.
+	Close() error
+
+	// HostID returns the host ID of the node.
+	HostID() string
+
+	// Address returns the address the node TChannel API listens on.
+	Address() string
+
+	// Namespaces returns the namespaces the node was configured with.
+	Namespaces() []namespace.Metadata
+
+	// Database returns the underlying database.
+	Database() storage.Database
+
+	// Client returns a client connected to the node.
+	Client() client.Client
+}
+
+// Options is a set of options for an in-process node.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetHostID sets the host ID of the node.
+	SetHostID(value string) Options
+
+	// HostID returns the host ID of the node.
+	HostID() string
+
+	// SetAddress sets the address the node TChannel API listens on, when
+	// empty a free local port is chosen.
+	SetAddress(value string) Options
+
+	// Address returns the address the node TChannel API listens on.
+	Address() string
+
+	// SetNumShards sets the number of shards owned by the node.
+	SetNumShards(value int) Options
+
+	// NumShards returns the number of shards owned by the node.
+	NumShards() int
+
+	// SetNamespaces sets the namespaces the node is configured with, when
+	// empty a single indexed namespace named DefaultNamespaceID is created.
+	SetNamespaces(value []namespace.Metadata) Options
+
+	// Namespaces returns the namespaces the node is configured with.
+	Namespaces() []namespace.Metadata
+
+	// SetFilePathPrefix sets the file path prefix for the node data, when
+	// empty a temporary directory is created and removed on close.
+	SetFilePathPrefix(value string) Options
+
+	// FilePathPrefix returns the file path prefix for the node data.
+	FilePathPrefix() string
+
+	// SetStorageOptions sets the storage options the node is built from.
+	SetStorageOptions(value storage.Options) Options
+
+	// StorageOptions returns the storage options the node is built from.
+	StorageOptions() storage.Options
+
+	// SetClientOptions sets the options for the client connected to the node.
+	SetClientOptions(value client.Options) Options
+
+	// ClientOptions returns the options for the client connected to the node.
+	ClientOptions() client.Options
+}
@@ -224,6 +224,11 @@ const (
 
 	// ReadConsistencyLevelAll corresponds to reading from all of the nodes
 	ReadConsistencyLevelAll
+
+	// ReadConsistencyLevelFollower corresponds to reading from a single node
+	// with the data available without fanning out to the other replicas,
+	// trading staleness for lower read load
+	ReadConsistencyLevelFollower
 )
 
 // String returns the consistency level as a string
@@ -239,6 +244,8 @@ func (l ReadConsistencyLevel) String() string {
 		return majority
 	case ReadConsistencyLevelAll:
 		return all
+	case ReadConsistencyLevelFollower:
+		return follower
 	}
 	return unknown
 }
@@ -249,6 +256,7 @@ var validReadConsistencyLevels = []ReadConsistencyLevel{
 	ReadConsistencyLevelUnstrictMajority,
 	ReadConsistencyLevelMajority,
 	ReadConsistencyLevelAll,
+	ReadConsistencyLevelFollower,
 }
 
 var (
@@ -303,6 +311,7 @@ const (
 	any              = "any"
 	all              = "all"
 	one              = "one"
+	follower         = "follower"
 	none             = "none"
 	majority         = "majority"
 	unstrictMajority = "unstrict_majority"
@@ -344,7 +353,7 @@ func ReadConsistencyTermination(
 ) bool {
 	doneAll := remaining == 0
 	switch level {
-	case ReadConsistencyLevelOne, ReadConsistencyLevelNone:
+	case ReadConsistencyLevelOne, ReadConsistencyLevelFollower, ReadConsistencyLevelNone:
 		return success > 0 || doneAll
 	case ReadConsistencyLevelMajority, ReadConsistencyLevelUnstrictMajority:
 		return success >= majority || doneAll
@@ -366,10 +375,37 @@ func ReadConsistencyAchieved(
 		return numSuccess == numPeers // Meets all
 	case ReadConsistencyLevelMajority:
 		return numSuccess >= majority // Meets majority
-	case ReadConsistencyLevelOne, ReadConsistencyLevelUnstrictMajority:
+	case ReadConsistencyLevelOne, ReadConsistencyLevelFollower, ReadConsistencyLevelUnstrictMajority:
 		return numSuccess > 0 // Meets one
 	case ReadConsistencyLevelNone:
 		return true // Always meets none
 	}
 	panic(fmt.Errorf("unrecognized consistency level: %s", level.String()))
 }
+
+// ReadConsistencyCompleteness returns the fraction of the responses a read at
+// the given level waits for that were successful, capped at one. Unstrict
+// levels can succeed with a completeness below one.
+func ReadConsistencyCompleteness(
+	level ReadConsistencyLevel,
+	majority, numPeers, numSuccess int,
+) float64 {
+	var required int
+	switch level {
+	case ReadConsistencyLevelAll:
+		required = numPeers
+	case ReadConsistencyLevelMajority, ReadConsistencyLevelUnstrictMajority:
+		required = majority
+	case ReadConsistencyLevelOne, ReadConsistencyLevelFollower:
+		required = 1
+	case ReadConsistencyLevelNone:
+		required = 0
+	default:
+		panic(fmt.Errorf("unrecognized consistency level: %s", level.String()))
+	}
+
+	if required <= 0 || numSuccess >= required {
+		return 1
+	}
+	return float64(numSuccess) / float64(required)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadConsistencyCompleteness(t *testing.T) {
+	tests := []struct {
+		level      ReadConsistencyLevel
+		numSuccess int
+		expected   float64
+	}{
+		{ReadConsistencyLevelAll, 3, 1},
+		{ReadConsistencyLevelAll, 2, 2.0 / 3.0},
+		{ReadConsistencyLevelMajority, 2, 1},
+		{ReadConsistencyLevelMajority, 3, 1},
+		{ReadConsistencyLevelUnstrictMajority, 1, 0.5},
+		{ReadConsistencyLevelUnstrictMajority, 0, 0},
+		{ReadConsistencyLevelOne, 1, 1},
+		{ReadConsistencyLevelFollower, 1, 1},
+		{ReadConsistencyLevelNone, 0, 1},
+	}
+
+	for _, tt := range tests {
+		actual := ReadConsistencyCompleteness(tt.level, 2, 3, tt.numSuccess)
+		assert.InDelta(t, tt.expected, actual, 1e-9,
+			"level %s with %d successes", tt.level, tt.numSuccess)
+	}
+}
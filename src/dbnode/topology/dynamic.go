@@ -83,6 +83,10 @@ type dynamicTopology struct {
 	closed    bool
 	hashGen   sharding.HashGen
 	logger    xlog.Logger
+
+	// generation is the last known placement generation, only accessed by
+	// the watch loop once initialized.
+	generation int
 }
 
 func newDynamicTopology(opts DynamicOptions) (DynamicTopology, error) {
@@ -102,24 +106,23 @@ func newDynamicTopology(opts DynamicOptions) (DynamicTopology, error) {
 		return nil, err
 	}
 
-	m, err := getMapFromUpdate(watch.Get(), opts.HashGen())
+	dt := &dynamicTopology{
+		opts:     opts,
+		services: services,
+		watch:    watch,
+		hashGen:  opts.HashGen(),
+		logger:   logger,
+	}
+
+	m, err := getMapFromUpdate(watch.Get(), opts.HashGen(), dt.placementGeneration())
 	if err != nil {
 		logger.Errorf("dynamic topology received invalid initial value: %v",
 			err)
 		return nil, err
 	}
 
-	watchable := xwatch.NewWatchable()
-	watchable.Update(m)
-
-	dt := &dynamicTopology{
-		opts:      opts,
-		services:  services,
-		watch:     watch,
-		watchable: watchable,
-		hashGen:   opts.HashGen(),
-		logger:    logger,
-	}
+	dt.watchable = xwatch.NewWatchable()
+	dt.watchable.Update(m)
 	go dt.run()
 	return dt, nil
 }
@@ -138,7 +141,8 @@ func (t *dynamicTopology) run() {
 			break
 		}
 
-		m, err := getMapFromUpdate(t.watch.Get(), t.hashGen)
+		m, err := getMapFromUpdate(t.watch.Get(), t.hashGen,
+			t.placementGeneration())
 		if err != nil {
 			t.logger.Warnf("dynamic topology received invalid update: %v", err)
 			continue
@@ -185,6 +189,28 @@ func (t *dynamicTopology) MarkShardsAvailable(
 	return ps.MarkShardsAvailable(instanceID, shardIDs...)
 }
 
+// placementGeneration returns the version of the placement, read after each
+// watched update so that the generation is at least that of the update. If
+// the placement cannot be read the last known generation is returned, so a
+// node partitioned from the config service keeps its stale generation and
+// is fenced by requests routed with newer placements.
+func (t *dynamicTopology) placementGeneration() int {
+	ps, err := t.services.PlacementService(t.opts.ServiceID(), placement.NewOptions())
+	if err != nil {
+		t.logger.Warnf("dynamic topology could not read placement generation: %v", err)
+		return t.generation
+	}
+	_, version, err := ps.Placement()
+	if err != nil {
+		t.logger.Warnf("dynamic topology could not read placement generation: %v", err)
+		return t.generation
+	}
+	if version > t.generation {
+		t.generation = version
+	}
+	return t.generation
+}
+
 func waitOnInit(w services.Watch, d time.Duration) error {
 	if d <= 0 {
 		<-w.C() // Wait for the first placement indefinitely
@@ -198,7 +224,11 @@ func waitOnInit(w services.Watch, d time.Duration) error {
 	}
 }
 
-func getMapFromUpdate(data interface{}, hashGen sharding.HashGen) (Map, error) {
+func getMapFromUpdate(
+	data interface{},
+	hashGen sharding.HashGen,
+	generation int,
+) (Map, error) {
 	service, ok := data.(services.Service)
 	if !ok {
 		return nil, errInvalidTopology
@@ -207,6 +237,7 @@ func getMapFromUpdate(data interface{}, hashGen sharding.HashGen) (Map, error) {
 	if err != nil {
 		return nil, err
 	}
+	to = to.SetGeneration(generation)
 	if err := to.Validate(); err != nil {
 		return nil, err
 	}
@@ -232,14 +263,14 @@ func getStaticOptions(service services.Service, hashGen sharding.HashGen) (Stati
 	}
 
 	fn := hashGen(numShards)
-	allShardSet, err := sharding.NewShardSet(allShards, fn)
+	allShardSet, err := sharding.NewShardSetWithNumShards(allShards, fn, numShards)
 	if err != nil {
 		return nil, err
 	}
 
 	hostShardSets := make([]HostShardSet, len(instances))
 	for i, instance := range instances {
-		hs, err := NewHostShardSetFromServiceInstance(instance, fn)
+		hs, err := newHostShardSetFromServiceInstance(instance, fn, numShards)
 		if err != nil {
 			return nil, err
 		}
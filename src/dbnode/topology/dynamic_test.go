@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/placement"
 	"github.com/m3db/m3cluster/services"
 	"github.com/m3db/m3cluster/shard"
 
@@ -35,12 +36,26 @@ import (
 )
 
 func testSetup(ctrl *gomock.Controller) (DynamicOptions, *testWatch) {
+	return testSetupWithGeneration(ctrl, 1)
+}
+
+func testSetupWithGeneration(
+	ctrl *gomock.Controller,
+	generation int,
+) (DynamicOptions, *testWatch) {
 	opts := NewDynamicOptions()
 
 	watch := newTestWatch(ctrl, time.Millisecond, time.Millisecond, 100, 100)
 	mockCSServices := services.NewMockServices(ctrl)
 	mockCSServices.EXPECT().Watch(opts.ServiceID(), opts.QueryOptions()).Return(watch, nil)
 
+	mockPlacementService := placement.NewMockService(ctrl)
+	mockPlacementService.EXPECT().Placement().Return(nil, generation, nil).AnyTimes()
+	mockCSServices.EXPECT().
+		PlacementService(opts.ServiceID(), gomock.Any()).
+		Return(mockPlacementService, nil).
+		AnyTimes()
+
 	mockCSClient := client.NewMockClient(ctrl)
 	mockCSClient.EXPECT().Services(gomock.Any()).Return(mockCSServices, nil)
 	opts = opts.SetConfigServiceClient(mockCSClient)
@@ -111,6 +126,19 @@ func TestGet(t *testing.T) {
 	assert.Equal(t, 2, m.Replicas())
 }
 
+func TestGetGeneration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	opts, w := testSetupWithGeneration(ctrl, 7)
+	defer testFinish(ctrl, w)
+
+	go w.run()
+	topo, err := newDynamicTopology(opts)
+	require.NoError(t, err)
+	defer topo.Close()
+
+	assert.Equal(t, 7, topo.Get().Generation())
+}
+
 func TestWatch(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	opts, watch := testSetup(ctrl)
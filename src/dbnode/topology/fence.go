@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	"github.com/m3db/m3x/ident"
+)
+
+const (
+	// PlacementGenerationHeader is the RPC header carrying the generation
+	// of the placement a request was routed with.
+	PlacementGenerationHeader = "m3-placement-generation"
+)
+
+// Fence rejects requests to a host once a request routed with a newer
+// placement than the placement known to the host is seen. A host replaced
+// while partitioned from the config service keeps its stale placement, so
+// the fence stops it accepting writes or serving reads for shards it may no
+// longer own, including requests replayed from clients equally stale. The
+// host is unfenced once its placement catches up.
+//
+// Requests routed with an older placement than the newest placement known
+// are checked against the newest placement instead, so that clients still
+// routing with the placement from before a replace are rejected by a host
+// for the shards moved away from it, while the shards it still owns are
+// served.
+type Fence interface {
+	// Check returns an error if a request routed with the placement
+	// generation must be rejected, requests without a generation are only
+	// checked against the newest generation already seen.
+	Check(generation int) error
+
+	// CheckShard returns an error if a request for the shard routed with
+	// the placement generation must be rejected, in addition to Check a
+	// request routed with an older generation than the newest generation
+	// known is rejected if the host does not own the shard in the newest
+	// placement.
+	CheckShard(generation int, shard uint32) error
+
+	// CheckID returns an error if a request for the series routed with the
+	// placement generation must be rejected, as CheckShard for the shard
+	// of the series.
+	CheckID(generation int, id ident.ID) error
+
+	// NewestGeneration returns the newest placement generation seen.
+	NewestGeneration() int
+}
+
+type fence struct {
+	topo   Topology
+	hostID string
+	newest int64
+}
+
+// NewFence returns a fence checking requests to the host against the
+// placement generation of the topology.
+func NewFence(topo Topology, hostID string) Fence {
+	return &fence{topo: topo, hostID: hostID}
+}
+
+func (f *fence) Check(generation int) error {
+	_, err := f.check(generation)
+	return err
+}
+
+func (f *fence) CheckShard(generation int, shard uint32) error {
+	m, err := f.check(generation)
+	if err != nil || !routedWithOlderMap(m, generation) {
+		return err
+	}
+	return f.checkOwned(m, generation, shard)
+}
+
+func (f *fence) CheckID(generation int, id ident.ID) error {
+	m, err := f.check(generation)
+	if err != nil || !routedWithOlderMap(m, generation) {
+		return err
+	}
+	return f.checkOwned(m, generation, m.ShardSet().Lookup(id))
+}
+
+func (f *fence) NewestGeneration() int {
+	return int(atomic.LoadInt64(&f.newest))
+}
+
+// check returns an error if the host is fenced, otherwise the map of the
+// newest placement known.
+func (f *fence) check(generation int) (Map, error) {
+	m := f.topo.Get()
+	current := int64(m.Generation())
+	f.observe(current)
+	newest := f.observe(int64(generation))
+	if current >= newest {
+		return m, nil
+	}
+	return nil, m3dberrors.NewUnavailableError(fmt.Errorf(
+		"host fenced: placement generation %d is older than generation %d seen from requests",
+		current, newest))
+}
+
+// checkOwned returns an error if the host does not own the shard in the map.
+func (f *fence) checkOwned(m Map, generation int, shard uint32) error {
+	if hostShardSet, ok := m.LookupHostShardSet(f.hostID); ok {
+		if _, err := hostShardSet.ShardSet().LookupStateByID(shard); err == nil {
+			return nil
+		}
+	}
+	return m3dberrors.NewUnavailableError(fmt.Errorf(
+		"request routed with placement generation %d for shard %d not owned by host in generation %d",
+		generation, shard, m.Generation()))
+}
+
+// routedWithOlderMap returns whether a request routed with the placement
+// generation was routed with an older placement than the map.
+func routedWithOlderMap(m Map, generation int) bool {
+	return generation > 0 && generation < m.Generation()
+}
+
+func (f *fence) observe(generation int64) int64 {
+	for {
+		newest := atomic.LoadInt64(&f.newest)
+		if generation <= newest {
+			return newest
+		}
+		if atomic.CompareAndSwapInt64(&f.newest, newest, generation) {
+			return generation
+		}
+	}
+}
+
+// PlacementGenerationFromHeaders returns the placement generation carried by
+// the RPC headers, zero if not set.
+func PlacementGenerationFromHeaders(headers map[string]string) int {
+	value, ok := headers[PlacementGenerationHeader]
+	if !ok {
+		return 0
+	}
+	generation, err := strconv.Atoi(value)
+	if err != nil || generation < 0 {
+		return 0
+	}
+	return generation
+}
+
+// PlacementGenerationHeaders returns the RPC headers carrying the placement
+// generation, nil if the generation is zero.
+func PlacementGenerationHeaders(generation int) map[string]string {
+	if generation <= 0 {
+		return nil
+	}
+	return map[string]string{
+		PlacementGenerationHeader: strconv.Itoa(generation),
+	}
+}
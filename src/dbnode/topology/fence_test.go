@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"testing"
+
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	generation := 3
+	topoMap := NewMockMap(ctrl)
+	topoMap.EXPECT().Generation().DoAndReturn(func() int {
+		return generation
+	}).AnyTimes()
+	topo := NewMockTopology(ctrl)
+	topo.EXPECT().Get().Return(topoMap).AnyTimes()
+
+	f := NewFence(topo, "h1")
+
+	// Requests without a generation pass until a generation is seen.
+	require.NoError(t, f.Check(0))
+
+	// Requests routed with the same or older placements pass.
+	require.NoError(t, f.Check(3))
+	require.NoError(t, f.Check(2))
+	assert.Equal(t, 3, f.NewestGeneration())
+
+	// A request routed with a newer placement fences the host.
+	err := f.Check(4)
+	require.Error(t, err)
+	assert.Equal(t, m3dberrors.CodeUnavailable, m3dberrors.GetCode(err))
+	assert.Equal(t, 4, f.NewestGeneration())
+
+	// Requests replayed with older or no generations are rejected too.
+	require.Error(t, f.Check(3))
+	require.Error(t, f.Check(0))
+
+	// Once the placement catches up the host is unfenced.
+	generation = 4
+	require.NoError(t, f.Check(3))
+	require.NoError(t, f.Check(0))
+}
+
+func TestFenceReplacedHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hashFn := func(id ident.ID) uint32 {
+		if id.String() == "foo" {
+			return 0
+		}
+		return 1
+	}
+	newMap := func(generation int, shards map[string][]uint32) Map {
+		var hostShardSets []HostShardSet
+		for _, id := range []string{"h1", "h2", "h3"} {
+			if _, ok := shards[id]; !ok {
+				continue
+			}
+			hostShardSets = append(hostShardSets, NewHostShardSet(
+				NewHost(id, id+":9000"),
+				newTestShardSet(t, shards[id], hashFn)))
+		}
+		return NewStaticMap(NewStaticOptions().
+			SetShardSet(newTestShardSet(t, []uint32{0, 1}, hashFn)).
+			SetReplicas(1).
+			SetHostShardSets(hostShardSets).
+			SetGeneration(generation))
+	}
+
+	// Generation 3 places shard 0 on h1 and shard 1 on h2, generation 4
+	// replaces h1 by h3 which takes shard 0 while h2 keeps shard 1, and
+	// generation 5 places shard 1 back on h1.
+	var (
+		beforeReplace = newMap(3, map[string][]uint32{"h1": {0}, "h2": {1}})
+		afterReplace  = newMap(4, map[string][]uint32{"h2": {1}, "h3": {0}})
+		afterReturn   = newMap(5, map[string][]uint32{"h1": {1}, "h3": {0}})
+		topoMap       = beforeReplace
+	)
+	topo := NewMockTopology(ctrl)
+	topo.EXPECT().Get().DoAndReturn(func() Map {
+		return topoMap
+	}).AnyTimes()
+
+	h1 := NewFence(topo, "h1")
+	require.NoError(t, h1.CheckID(3, ident.StringID("foo")))
+
+	// h1 is partitioned from the config service during the replace, a
+	// client routing with the new placement fences it and clients still
+	// routing with the old placement are rejected too.
+	err := h1.CheckShard(4, 1)
+	require.Error(t, err)
+	assert.Equal(t, m3dberrors.CodeUnavailable, m3dberrors.GetCode(err))
+	require.Error(t, h1.CheckID(3, ident.StringID("foo")))
+	require.Error(t, h1.CheckID(0, ident.StringID("foo")))
+
+	// Once h1 returns and sees the replace, requests replayed from clients
+	// routing with the old placement for the shard moved away are rejected.
+	topoMap = afterReplace
+	err = h1.CheckID(3, ident.StringID("foo"))
+	require.Error(t, err)
+	assert.Equal(t, m3dberrors.CodeUnavailable, m3dberrors.GetCode(err))
+	require.Error(t, h1.CheckShard(3, 0))
+
+	// A host still owning the shard in the newest placement serves requests
+	// routed with the old placement.
+	h2 := NewFence(topo, "h2")
+	require.NoError(t, h2.CheckID(3, ident.StringID("bar")))
+	require.NoError(t, h2.CheckShard(3, 1))
+	require.NoError(t, h2.CheckShard(4, 1))
+
+	// Requests without a generation are not checked for ownership.
+	require.NoError(t, h1.CheckID(0, ident.StringID("foo")))
+
+	// h1 is placed again and serves the shard it now owns, also for
+	// clients still routing with an older placement.
+	topoMap = afterReturn
+	require.NoError(t, h1.CheckShard(5, 1))
+	require.NoError(t, h1.CheckID(4, ident.StringID("bar")))
+	require.Error(t, h1.CheckID(3, ident.StringID("foo")))
+	assert.Equal(t, 5, h1.NewestGeneration())
+}
+
+func TestPlacementGenerationHeaders(t *testing.T) {
+	assert.Nil(t, PlacementGenerationHeaders(0))
+
+	headers := PlacementGenerationHeaders(12)
+	assert.Equal(t, map[string]string{PlacementGenerationHeader: "12"}, headers)
+	assert.Equal(t, 12, PlacementGenerationFromHeaders(headers))
+
+	assert.Equal(t, 0, PlacementGenerationFromHeaders(nil))
+	assert.Equal(t, 0, PlacementGenerationFromHeaders(map[string]string{
+		PlacementGenerationHeader: "invalid",
+	}))
+	assert.Equal(t, 0, PlacementGenerationFromHeaders(map[string]string{
+		PlacementGenerationHeader: "-1",
+	}))
+}
@@ -75,6 +75,14 @@ func NewHostShardSet(host Host, shardSet sharding.ShardSet) HostShardSet {
 func NewHostShardSetFromServiceInstance(
 	si services.ServiceInstance,
 	fn sharding.HashFn,
+) (HostShardSet, error) {
+	return newHostShardSetFromServiceInstance(si, fn, 0)
+}
+
+func newHostShardSetFromServiceInstance(
+	si services.ServiceInstance,
+	fn sharding.HashFn,
+	numShards int,
 ) (HostShardSet, error) {
 	if si.Shards() == nil {
 		return nil, errInstanceHasNoShardsAssignment
@@ -82,7 +90,7 @@ func NewHostShardSetFromServiceInstance(
 	all := si.Shards().All()
 	shards := make([]shard.Shard, len(all))
 	copy(shards, all)
-	shardSet, err := sharding.NewShardSet(shards, fn)
+	shardSet, err := sharding.NewShardSetWithNumShards(shards, fn, numShards)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +35,7 @@ type staticMap struct {
 	orderedHostsByShard [][]orderedHost
 	replicas            int
 	majority            int
+	generation          int
 }
 
 // NewStaticMap creates a new static topology map
@@ -50,6 +51,7 @@ func NewStaticMap(opts StaticOptions) Map {
 		orderedHostsByShard: make([][]orderedHost, totalShards),
 		replicas:            opts.Replicas(),
 		majority:            Majority(opts.Replicas()),
+		generation:          opts.Generation(),
 	}
 
 	for idx, hostShardSet := range hostShardSets {
@@ -132,6 +134,10 @@ func (t *staticMap) MajorityReplicas() int {
 	return t.majority
 }
 
+func (t *staticMap) Generation() int {
+	return t.generation
+}
+
 type mapWatch struct {
 	xwatch.Watch
 }
@@ -47,6 +47,7 @@ type staticOptions struct {
 	shardSet      sharding.ShardSet
 	replicas      int
 	hostShardSets []HostShardSet
+	generation    int
 }
 
 // NewStaticOptions creates a new set of static topology options
@@ -116,6 +117,16 @@ func (o *staticOptions) HostShardSets() []HostShardSet {
 	return o.hostShardSets
 }
 
+func (o *staticOptions) SetGeneration(value int) StaticOptions {
+	opts := *o
+	opts.generation = value
+	return &opts
+}
+
+func (o *staticOptions) Generation() int {
+	return o.generation
+}
+
 type dynamicOptions struct {
 	configServiceClient     client.Client
 	serviceID               services.ServiceID
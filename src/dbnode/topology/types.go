@@ -127,6 +127,10 @@ type Map interface {
 
 	// MajorityReplicas returns the number of replicas to establish majority in the topology
 	MajorityReplicas() int
+
+	// Generation returns the generation of the placement the map was built
+	// from, zero if the topology is not versioned
+	Generation() int
 }
 
 // RouteForEachFn is a function to execute for each routed to host
@@ -167,6 +171,14 @@ type StaticOptions interface {
 
 	// HostShardSets returns the hostShardSets
 	HostShardSets() []HostShardSet
+
+	// SetGeneration sets the generation of the placement the topology was
+	// built from
+	SetGeneration(value int) StaticOptions
+
+	// Generation returns the generation of the placement the topology was
+	// built from
+	Generation() int
 }
 
 // DynamicOptions is a set of options for dynamic topology
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package fault provides injectable fault points so that tests can
+// deterministically exercise behavior under RPC delays, dropped KV watch
+// updates, failed flushes and slow disks. Production code uses the noop
+// injector which never injects faults.
+package fault
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Point is a named location in the code at which faults can be injected.
+type Point string
+
+const (
+	// PointPersistPrepare is evaluated before preparing to persist a data
+	// fileset, an injected error fails the flush or snapshot.
+	PointPersistPrepare Point = "persist-prepare"
+
+	// PointDiskWrite is evaluated before writing each series to a data
+	// fileset, an injected delay simulates a slow disk.
+	PointDiskWrite Point = "disk-write"
+
+	// PointKVWatchUpdate is evaluated for each namespace registry KV watch
+	// update, an injected error drops the update.
+	PointKVWatchUpdate Point = "kv-watch-update"
+
+	rpcPointPrefix = "rpc."
+)
+
+// RPCPoint returns the point evaluated before serving the named node RPC,
+// an injected delay delays the RPC and an injected error fails it.
+func RPCPoint(method string) Point {
+	return Point(rpcPointPrefix + method)
+}
+
+// ErrInjected is a generic error to inject at fault points.
+var ErrInjected = errors.New("injected fault")
+
+// Fault describes the fault to inject at a point.
+type Fault struct {
+	// Delay is how long to delay the caller.
+	Delay time.Duration
+
+	// Wait if set blocks the caller until it is closed, allowing tests to
+	// deterministically control when the caller proceeds.
+	Wait <-chan struct{}
+
+	// Err is the error to return to the caller.
+	Err error
+
+	// Skip is the number of evaluations to skip before the fault triggers.
+	Skip int
+
+	// Count is the number of times the fault triggers, zero triggers
+	// the fault indefinitely.
+	Count int
+}
+
+// Injector evaluates fault points.
+type Injector interface {
+	// Inject evaluates the fault point, delaying the caller and returning
+	// an error if a fault is set for the point.
+	Inject(p Point) error
+}
+
+// Registry is an injector with faults that are set at runtime.
+type Registry interface {
+	Injector
+
+	// Set sets the fault to inject at a point, replacing any existing fault.
+	Set(p Point, f Fault)
+
+	// Clear clears any fault set at a point.
+	Clear(p Point)
+
+	// ClearAll clears all faults.
+	ClearAll()
+
+	// Triggered returns the number of times a fault triggered at a point.
+	Triggered(p Point) int
+}
+
+type noopInjector struct{}
+
+// NewNoopInjector returns an injector that never injects faults.
+func NewNoopInjector() Injector {
+	return noopInjector{}
+}
+
+func (noopInjector) Inject(p Point) error {
+	return nil
+}
+
+type pointState struct {
+	fault     Fault
+	evaluated int
+}
+
+type registry struct {
+	sync.Mutex
+
+	points    map[Point]*pointState
+	triggered map[Point]int
+}
+
+// NewRegistry returns a new fault registry with no faults set.
+func NewRegistry() Registry {
+	return &registry{
+		points:    make(map[Point]*pointState),
+		triggered: make(map[Point]int),
+	}
+}
+
+func (r *registry) Inject(p Point) error {
+	r.Lock()
+	state, ok := r.points[p]
+	if !ok {
+		r.Unlock()
+		return nil
+	}
+
+	state.evaluated++
+	if state.evaluated <= state.fault.Skip {
+		r.Unlock()
+		return nil
+	}
+	if state.fault.Count > 0 && r.triggered[p] >= state.fault.Count {
+		r.Unlock()
+		return nil
+	}
+	r.triggered[p]++
+	f := state.fault
+	r.Unlock()
+
+	if f.Wait != nil {
+		<-f.Wait
+	}
+	if f.Delay > 0 {
+		time.Sleep(f.Delay)
+	}
+	return f.Err
+}
+
+func (r *registry) Set(p Point, f Fault) {
+	r.Lock()
+	r.points[p] = &pointState{fault: f}
+	r.triggered[p] = 0
+	r.Unlock()
+}
+
+func (r *registry) Clear(p Point) {
+	r.Lock()
+	delete(r.points, p)
+	r.Unlock()
+}
+
+func (r *registry) ClearAll() {
+	r.Lock()
+	r.points = make(map[Point]*pointState)
+	r.Unlock()
+}
+
+func (r *registry) Triggered(p Point) int {
+	r.Lock()
+	defer r.Unlock()
+	return r.triggered[p]
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fault
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoopInjector(t *testing.T) {
+	assert.NoError(t, NewNoopInjector().Inject(PointPersistPrepare))
+}
+
+func TestRegistrySkipAndCount(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Inject(PointPersistPrepare))
+
+	r.Set(PointPersistPrepare, Fault{Err: ErrInjected, Skip: 1, Count: 2})
+	assert.NoError(t, r.Inject(PointPersistPrepare))
+	assert.Equal(t, ErrInjected, r.Inject(PointPersistPrepare))
+	assert.Equal(t, ErrInjected, r.Inject(PointPersistPrepare))
+	assert.NoError(t, r.Inject(PointPersistPrepare))
+	assert.Equal(t, 2, r.Triggered(PointPersistPrepare))
+
+	// Other points are unaffected
+	assert.NoError(t, r.Inject(PointDiskWrite))
+	assert.Equal(t, 0, r.Triggered(PointDiskWrite))
+
+	// Setting a fault again resets its state
+	r.Set(PointPersistPrepare, Fault{Err: ErrInjected})
+	assert.Equal(t, 0, r.Triggered(PointPersistPrepare))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ErrInjected, r.Inject(PointPersistPrepare))
+	}
+
+	r.Clear(PointPersistPrepare)
+	assert.NoError(t, r.Inject(PointPersistPrepare))
+	assert.Equal(t, 3, r.Triggered(PointPersistPrepare))
+}
+
+func TestRegistryDelayAndWait(t *testing.T) {
+	var (
+		r       = NewRegistry()
+		waitCh  = make(chan struct{})
+		doneCh  = make(chan error)
+		rpcErr  = errors.New("rpc error")
+		rpcPt   = RPCPoint("fetch")
+		delay   = 10 * time.Millisecond
+		started = time.Now()
+	)
+	r.Set(rpcPt, Fault{Delay: delay, Wait: waitCh, Err: rpcErr})
+
+	go func() {
+		doneCh <- r.Inject(rpcPt)
+	}()
+
+	select {
+	case <-doneCh:
+		assert.FailNow(t, "inject returned before wait channel closed")
+	case <-time.After(delay):
+	}
+
+	close(waitCh)
+	assert.Equal(t, rpcErr, <-doneCh)
+	assert.True(t, time.Since(started) >= 2*delay)
+
+	r.ClearAll()
+	assert.NoError(t, r.Inject(rpcPt))
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package loglevel controls the level of loggers at runtime and which
+// subsystems log at debug level regardless of that level, so that a single
+// node can be debugged without restarting it.
+package loglevel
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	xlog "github.com/m3db/m3x/log"
+)
+
+const (
+	subsystemField = "subsystem"
+)
+
+var (
+	// levels are ordered from the most to the least verbose.
+	levels = []xlog.Level{
+		xlog.LevelDebug,
+		xlog.LevelInfo,
+		xlog.LevelWarn,
+		xlog.LevelError,
+		xlog.LevelFatal,
+	}
+	levelNames = []string{
+		"debug",
+		"info",
+		"warn",
+		"error",
+		"fatal",
+	}
+
+	errLevelUnknown = errors.New("log level unknown")
+)
+
+// ParseLevel returns the level with the name, an empty name is the info level.
+func ParseLevel(name string) (xlog.Level, error) {
+	if name == "" {
+		return xlog.LevelInfo, nil
+	}
+	for i, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return levels[i], nil
+		}
+	}
+	return 0, fmt.Errorf("%v: %s, must be one of %s", errLevelUnknown,
+		name, strings.Join(levelNames, ", "))
+}
+
+// LevelString returns the name of the level.
+func LevelString(level xlog.Level) string {
+	return levelNames[rank(level)]
+}
+
+func rank(level xlog.Level) int {
+	for i, l := range levels {
+		if l == level {
+			return i
+		}
+	}
+	return 0
+}
+
+// Controller controls the level of the loggers it wraps and the subsystems
+// logging at debug level. Subsystems are registered by creating their
+// loggers with Subsystem.
+type Controller struct {
+	sync.RWMutex
+
+	level      int32
+	registered map[string]struct{}
+	debug      map[string]struct{}
+}
+
+// NewController returns a new controller logging at the level.
+func NewController(level xlog.Level) *Controller {
+	c := &Controller{
+		registered: make(map[string]struct{}),
+		debug:      make(map[string]struct{}),
+	}
+	c.SetLevel(level)
+	return c
+}
+
+// Level returns the current level.
+func (c *Controller) Level() xlog.Level {
+	return levels[atomic.LoadInt32(&c.level)]
+}
+
+// SetLevel sets the current level.
+func (c *Controller) SetLevel(level xlog.Level) {
+	atomic.StoreInt32(&c.level, int32(rank(level)))
+}
+
+// Subsystems returns the registered subsystems.
+func (c *Controller) Subsystems() []string {
+	c.RLock()
+	defer c.RUnlock()
+	return sortedKeys(c.registered)
+}
+
+// DebugSubsystems returns the subsystems logging at debug level.
+func (c *Controller) DebugSubsystems() []string {
+	c.RLock()
+	defer c.RUnlock()
+	return sortedKeys(c.debug)
+}
+
+// SetDebugSubsystems sets the subsystems logging at debug level, replacing
+// any set before. Every subsystem must be registered.
+func (c *Controller) SetDebugSubsystems(names []string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	debug := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if _, ok := c.registered[name]; !ok {
+			return fmt.Errorf("log subsystem unknown: %s, must be one of %s",
+				name, strings.Join(sortedKeys(c.registered), ", "))
+		}
+		debug[name] = struct{}{}
+	}
+	c.debug = debug
+	return nil
+}
+
+// Logger returns the logger controlled by the controller, the logger itself
+// should log at every level.
+func (c *Controller) Logger(logger xlog.Logger) xlog.Logger {
+	return &controlledLogger{Logger: logger, controller: c}
+}
+
+func (c *Controller) register(subsystem string) {
+	c.Lock()
+	c.registered[subsystem] = struct{}{}
+	c.Unlock()
+}
+
+func (c *Controller) enabled(level xlog.Level, subsystem string) bool {
+	if rank(level) >= int(atomic.LoadInt32(&c.level)) {
+		return true
+	}
+	if subsystem == "" || level != xlog.LevelDebug {
+		return false
+	}
+	c.RLock()
+	_, ok := c.debug[subsystem]
+	c.RUnlock()
+	return ok
+}
+
+// ControllerFrom returns the controller of a logger returned by a controller.
+func ControllerFrom(logger xlog.Logger) (*Controller, bool) {
+	l, ok := logger.(*controlledLogger)
+	if !ok {
+		return nil, false
+	}
+	return l.controller, true
+}
+
+// Subsystem returns the logger of a subsystem which logs at debug level when
+// its subsystem is set to, if the logger was returned by a controller,
+// otherwise it returns the logger.
+func Subsystem(logger xlog.Logger, name string) xlog.Logger {
+	l, ok := logger.(*controlledLogger)
+	if !ok {
+		return logger
+	}
+	l.controller.register(name)
+	return &controlledLogger{
+		Logger:     l.Logger.WithFields(xlog.NewField(subsystemField, name)),
+		controller: l.controller,
+		subsystem:  name,
+	}
+}
+
+type controlledLogger struct {
+	xlog.Logger
+
+	controller *Controller
+	subsystem  string
+}
+
+func (l *controlledLogger) Enabled(level xlog.Level) bool {
+	return l.controller.enabled(level, l.subsystem) && l.Logger.Enabled(level)
+}
+
+func (l *controlledLogger) Errorf(msg string, args ...interface{}) {
+	if l.controller.enabled(xlog.LevelError, l.subsystem) {
+		l.Logger.Errorf(msg, args...)
+	}
+}
+
+func (l *controlledLogger) Error(msg string) {
+	if l.controller.enabled(xlog.LevelError, l.subsystem) {
+		l.Logger.Error(msg)
+	}
+}
+
+func (l *controlledLogger) Warnf(msg string, args ...interface{}) {
+	if l.controller.enabled(xlog.LevelWarn, l.subsystem) {
+		l.Logger.Warnf(msg, args...)
+	}
+}
+
+func (l *controlledLogger) Warn(msg string) {
+	if l.controller.enabled(xlog.LevelWarn, l.subsystem) {
+		l.Logger.Warn(msg)
+	}
+}
+
+func (l *controlledLogger) Infof(msg string, args ...interface{}) {
+	if l.controller.enabled(xlog.LevelInfo, l.subsystem) {
+		l.Logger.Infof(msg, args...)
+	}
+}
+
+func (l *controlledLogger) Info(msg string) {
+	if l.controller.enabled(xlog.LevelInfo, l.subsystem) {
+		l.Logger.Info(msg)
+	}
+}
+
+func (l *controlledLogger) Debugf(msg string, args ...interface{}) {
+	if l.controller.enabled(xlog.LevelDebug, l.subsystem) {
+		l.Logger.Debugf(msg, args...)
+	}
+}
+
+func (l *controlledLogger) Debug(msg string) {
+	if l.controller.enabled(xlog.LevelDebug, l.subsystem) {
+		l.Logger.Debug(msg)
+	}
+}
+
+func (l *controlledLogger) WithFields(fields ...xlog.Field) xlog.Logger {
+	return &controlledLogger{
+		Logger:     l.Logger.WithFields(fields...),
+		controller: l.controller,
+		subsystem:  l.subsystem,
+	}
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loglevel
+
+import (
+	"fmt"
+	"testing"
+
+	xlog "github.com/m3db/m3x/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	xlog.Logger
+
+	records *[]string
+	prefix  string
+}
+
+func newRecordingLogger() (xlog.Logger, *[]string) {
+	var records []string
+	return recordingLogger{Logger: xlog.NullLogger, records: &records}, &records
+}
+
+func (l recordingLogger) Enabled(level xlog.Level) bool { return true }
+
+func (l recordingLogger) Debugf(msg string, args ...interface{}) {
+	*l.records = append(*l.records, l.prefix+"debug "+fmt.Sprintf(msg, args...))
+}
+
+func (l recordingLogger) Infof(msg string, args ...interface{}) {
+	*l.records = append(*l.records, l.prefix+"info "+fmt.Sprintf(msg, args...))
+}
+
+func (l recordingLogger) Warn(msg string) {
+	*l.records = append(*l.records, l.prefix+"warn "+msg)
+}
+
+func (l recordingLogger) WithFields(fields ...xlog.Field) xlog.Logger {
+	prefix := l.prefix
+	for _, f := range fields {
+		prefix += fmt.Sprintf("%s=%v ", f.Key(), f.Value())
+	}
+	return recordingLogger{Logger: l.Logger, records: l.records, prefix: prefix}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("")
+	require.NoError(t, err)
+	assert.Equal(t, xlog.LevelInfo, level)
+
+	level, err = ParseLevel("WARN")
+	require.NoError(t, err)
+	assert.Equal(t, xlog.LevelWarn, level)
+	assert.Equal(t, "warn", LevelString(level))
+
+	_, err = ParseLevel("verbose")
+	require.Error(t, err)
+}
+
+func TestControllerLevel(t *testing.T) {
+	underlying, records := newRecordingLogger()
+	c := NewController(xlog.LevelInfo)
+	logger := c.Logger(underlying)
+
+	logger.Debugf("a %d", 1)
+	logger.Infof("b %d", 2)
+	assert.False(t, logger.Enabled(xlog.LevelDebug))
+
+	c.SetLevel(xlog.LevelDebug)
+	logger.Debugf("c %d", 3)
+	assert.True(t, logger.Enabled(xlog.LevelDebug))
+
+	c.SetLevel(xlog.LevelError)
+	logger.WithFields(xlog.NewField("k", "v")).Warn("d")
+
+	assert.Equal(t, []string{"info b 2", "debug c 3"}, *records)
+	assert.Equal(t, xlog.LevelError, c.Level())
+}
+
+func TestControllerDebugSubsystems(t *testing.T) {
+	underlying, records := newRecordingLogger()
+	c := NewController(xlog.LevelInfo)
+	logger := c.Logger(underlying)
+	flush := Subsystem(logger, "flush")
+	watch := Subsystem(logger, "namespace-watch")
+
+	assert.Equal(t, []string{"flush", "namespace-watch"}, c.Subsystems())
+	require.Error(t, c.SetDebugSubsystems([]string{"unknown"}))
+	require.NoError(t, c.SetDebugSubsystems([]string{"flush"}))
+	assert.Equal(t, []string{"flush"}, c.DebugSubsystems())
+
+	logger.Debugf("a")
+	flush.Debugf("b")
+	watch.Debugf("c")
+	flush.WithFields(xlog.NewField("k", "v")).Debugf("d")
+
+	require.NoError(t, c.SetDebugSubsystems(nil))
+	flush.Debugf("e")
+
+	assert.Equal(t, []string{
+		"subsystem=flush debug b",
+		"subsystem=flush k=v debug d",
+	}, *records)
+}
+
+func TestSubsystemUncontrolledLogger(t *testing.T) {
+	logger, _ := newRecordingLogger()
+	assert.Equal(t, logger, Subsystem(logger, "flush"))
+
+	_, ok := ControllerFrom(logger)
+	assert.False(t, ok)
+
+	c := NewController(xlog.LevelInfo)
+	controlled, ok := ControllerFrom(Subsystem(c.Logger(logger), "flush"))
+	require.True(t, ok)
+	assert.Equal(t, c, controlled)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logsample
+
+import (
+	xlog "github.com/m3db/m3x/log"
+)
+
+const (
+	classField      = "class"
+	suppressedField = "suppressed"
+)
+
+// Logger logs records of error classes through a sampler, annotating each
+// logged record with its class and the number of records suppressed since
+// the last one logged.
+type Logger struct {
+	logger  xlog.Logger
+	sampler *Sampler
+}
+
+// NewLogger returns a logger sampling records with the sampler.
+func NewLogger(logger xlog.Logger, sampler *Sampler) *Logger {
+	return &Logger{logger: logger, sampler: sampler}
+}
+
+// Warnf logs a warning of the class if sampled.
+func (l *Logger) Warnf(class string, format string, args ...interface{}) {
+	if logger, ok := l.sample(class); ok {
+		logger.Warnf(format, args...)
+	}
+}
+
+// Errorf logs an error of the class if sampled.
+func (l *Logger) Errorf(class string, format string, args ...interface{}) {
+	if logger, ok := l.sample(class); ok {
+		logger.Errorf(format, args...)
+	}
+}
+
+func (l *Logger) sample(class string) (xlog.Logger, bool) {
+	ok, suppressed := l.sampler.Sample(class)
+	if !ok {
+		return nil, false
+	}
+	fields := []xlog.Field{xlog.NewField(classField, class)}
+	if suppressed > 0 {
+		fields = append(fields, xlog.NewField(suppressedField, suppressed))
+	}
+	return l.logger.WithFields(fields...), true
+}
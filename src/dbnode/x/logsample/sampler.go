@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package logsample samples and rate limits log records per error class so
+// that errors repeated in hot paths, such as a write path rejecting invalid
+// datapoints, are logged with the number of records suppressed rather than
+// once per occurrence.
+package logsample
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	classTag = "class"
+)
+
+// DefaultPolicy is the policy of classes when no policy is configured,
+// logging at most one record of each class every ten seconds.
+var DefaultPolicy = Policy{Interval: 10 * time.Second}
+
+// Policy is how often records of an error class are logged.
+type Policy struct {
+	// Every logs the first of every Every records, zero or one logs every
+	// record.
+	Every int64 `yaml:"every"`
+
+	// Interval logs at most one record per interval, zero does not limit
+	// records by time.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// IsZero returns whether the policy is unset.
+func (p Policy) IsZero() bool {
+	return p.Every == 0 && p.Interval == 0
+}
+
+// Configuration is the log sampling configuration.
+type Configuration struct {
+	// Default is the policy of classes without their own policy, if unset
+	// the default policy is used.
+	Default *Policy `yaml:"default"`
+
+	// Classes are the policies of individual error classes.
+	Classes map[string]Policy `yaml:"classes"`
+}
+
+// NewSampler returns a sampler for the configuration.
+func (c Configuration) NewSampler(scope tally.Scope) *Sampler {
+	defaultPolicy := DefaultPolicy
+	if c.Default != nil {
+		defaultPolicy = *c.Default
+	}
+	return NewSampler(defaultPolicy, c.Classes, scope)
+}
+
+// Sampler decides which records of each error class are logged and counts
+// the records suppressed in between. A nil sampler logs every record.
+type Sampler struct {
+	sync.Mutex
+
+	defaultPolicy Policy
+	policies      map[string]Policy
+	classes       map[string]*classState
+	scope         tally.Scope
+	nowFn         clock.NowFn
+}
+
+type classState struct {
+	policy     Policy
+	seen       int64
+	suppressed int64
+	lastLogged time.Time
+
+	loggedCounter     tally.Counter
+	suppressedCounter tally.Counter
+}
+
+// NewSampler returns a sampler applying the policies to their classes and
+// the default policy to all other classes.
+func NewSampler(
+	defaultPolicy Policy,
+	policies map[string]Policy,
+	scope tally.Scope,
+) *Sampler {
+	return &Sampler{
+		defaultPolicy: defaultPolicy,
+		policies:      policies,
+		classes:       make(map[string]*classState),
+		scope:         scope,
+		nowFn:         time.Now,
+	}
+}
+
+// Sample returns whether a record of the class should be logged and, if so,
+// the number of records of the class suppressed since the last one logged.
+func (s *Sampler) Sample(class string) (bool, int64) {
+	if s == nil {
+		return true, 0
+	}
+
+	now := s.nowFn()
+
+	s.Lock()
+	state, ok := s.classes[class]
+	if !ok {
+		state = s.newClassState(class)
+		s.classes[class] = state
+	}
+
+	state.seen++
+	logged := true
+	if every := state.policy.Every; every > 1 && (state.seen-1)%every != 0 {
+		logged = false
+	}
+	if interval := state.policy.Interval; logged && interval > 0 &&
+		!state.lastLogged.IsZero() && now.Sub(state.lastLogged) < interval {
+		logged = false
+	}
+
+	var suppressed int64
+	if logged {
+		suppressed = state.suppressed
+		state.suppressed = 0
+		state.lastLogged = now
+	} else {
+		state.suppressed++
+	}
+	s.Unlock()
+
+	if logged {
+		state.loggedCounter.Inc(1)
+	} else {
+		state.suppressedCounter.Inc(1)
+	}
+	return logged, suppressed
+}
+
+func (s *Sampler) newClassState(class string) *classState {
+	policy, ok := s.policies[class]
+	if !ok {
+		policy = s.defaultPolicy
+	}
+	scope := s.scope.Tagged(map[string]string{classTag: class})
+	return &classState{
+		policy:            policy,
+		loggedCounter:     scope.Counter("logged"),
+		suppressedCounter: scope.Counter("suppressed"),
+	}
+}
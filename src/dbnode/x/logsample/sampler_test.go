@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logsample
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type sampleResult struct {
+	logged     bool
+	suppressed int64
+}
+
+func sample(s *Sampler, class string) sampleResult {
+	logged, suppressed := s.Sample(class)
+	return sampleResult{logged: logged, suppressed: suppressed}
+}
+
+func TestSamplerEvery(t *testing.T) {
+	s := NewSampler(Policy{Every: 3}, nil, tally.NoopScope)
+
+	assert.Equal(t, sampleResult{true, 0}, sample(s, "a"))
+	assert.Equal(t, sampleResult{false, 0}, sample(s, "a"))
+	assert.Equal(t, sampleResult{false, 0}, sample(s, "a"))
+	assert.Equal(t, sampleResult{true, 2}, sample(s, "a"))
+
+	// Classes are sampled independently.
+	assert.Equal(t, sampleResult{true, 0}, sample(s, "b"))
+}
+
+func TestSamplerInterval(t *testing.T) {
+	now := time.Now()
+	s := NewSampler(Policy{Interval: time.Minute}, nil, tally.NoopScope)
+	s.nowFn = func() time.Time { return now }
+
+	assert.Equal(t, sampleResult{true, 0}, sample(s, "a"))
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, sampleResult{false, 0}, sample(s, "a"))
+	assert.Equal(t, sampleResult{false, 0}, sample(s, "a"))
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, sampleResult{true, 2}, sample(s, "a"))
+}
+
+func TestSamplerClassPolicies(t *testing.T) {
+	s := NewSampler(Policy{}, map[string]Policy{
+		"invalid-datapoint": {Every: 1000},
+	}, tally.NoopScope)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, sampleResult{true, 0}, sample(s, "other"))
+	}
+
+	var logged int
+	for i := 0; i < 2500; i++ {
+		if ok, _ := s.Sample("invalid-datapoint"); ok {
+			logged++
+		}
+	}
+	assert.Equal(t, 3, logged)
+}
+
+func TestSamplerMetrics(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	s := NewSampler(Policy{Every: 2}, nil, scope)
+	for i := 0; i < 5; i++ {
+		s.Sample("a")
+	}
+
+	counters := scope.Snapshot().Counters()
+	logged, ok := counters["logged+class=a"]
+	require.True(t, ok)
+	assert.Equal(t, int64(3), logged.Value())
+	suppressed, ok := counters["suppressed+class=a"]
+	require.True(t, ok)
+	assert.Equal(t, int64(2), suppressed.Value())
+}
+
+func TestNilSamplerLogsEverything(t *testing.T) {
+	var s *Sampler
+	assert.Equal(t, sampleResult{true, 0}, sample(s, "a"))
+}
+
+func TestConfigurationDefaultPolicy(t *testing.T) {
+	s := Configuration{}.NewSampler(tally.NoopScope)
+	assert.Equal(t, DefaultPolicy, s.defaultPolicy)
+
+	every := Policy{Every: 10}
+	s = Configuration{Default: &every}.NewSampler(tally.NoopScope)
+	assert.Equal(t, every, s.defaultPolicy)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xmetrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultLatencyBucketsStart  = 100 * time.Microsecond
+	defaultLatencyBucketsFactor = 2
+	defaultLatencyBucketsCount  = 20
+)
+
+var (
+	// DefaultLatencyBuckets are the latency histogram buckets used when
+	// none are configured, exponentially spaced from 100us to ~52s.
+	DefaultLatencyBuckets = tally.MustMakeExponentialDurationBuckets(
+		defaultLatencyBucketsStart,
+		defaultLatencyBucketsFactor,
+		defaultLatencyBucketsCount,
+	)
+
+	errLatencyBucketsValuesAndExponential = errors.New(
+		"latency buckets can specify either values or exponential buckets, not both")
+)
+
+// LatencyBucketsConfiguration configures the buckets of latency histograms,
+// either as explicit upper bounds or as exponentially spaced buckets.
+type LatencyBucketsConfiguration struct {
+	// Values are the explicit upper bounds of the buckets.
+	Values []time.Duration `yaml:"values"`
+
+	// Start is the upper bound of the first exponential bucket.
+	Start time.Duration `yaml:"start"`
+
+	// Factor is the growth factor between exponential buckets.
+	Factor float64 `yaml:"factor"`
+
+	// Count is the number of exponential buckets.
+	Count int `yaml:"count"`
+}
+
+// NewBuckets returns the configured latency buckets.
+func (c LatencyBucketsConfiguration) NewBuckets() (tally.DurationBuckets, error) {
+	exponential := c.Start != 0 || c.Factor != 0 || c.Count != 0
+	if len(c.Values) > 0 {
+		if exponential {
+			return nil, errLatencyBucketsValuesAndExponential
+		}
+		return tally.DurationBuckets(c.Values), nil
+	}
+	if !exponential {
+		return DefaultLatencyBuckets, nil
+	}
+	return tally.ExponentialDurationBuckets(c.Start, c.Factor, c.Count)
+}
+
+// MethodMetrics is a bundle of common metrics with a uniform naming scheme
+// that records call latencies as histograms rather than sampled timers.
+type MethodMetrics struct {
+	Errors         tally.Counter
+	Success        tally.Counter
+	ErrorsLatency  tally.Histogram
+	SuccessLatency tally.Histogram
+}
+
+// NewMethodMetrics returns a new MethodMetrics for the given method name
+// whose latencies are recorded in the given buckets.
+func NewMethodMetrics(
+	scope tally.Scope,
+	methodName string,
+	buckets tally.Buckets,
+) MethodMetrics {
+	return MethodMetrics{
+		Errors:         scope.Counter(methodName + ".errors"),
+		Success:        scope.Counter(methodName + ".success"),
+		ErrorsLatency:  scope.Histogram(methodName+".errors-latency", buckets),
+		SuccessLatency: scope.Histogram(methodName+".success-latency", buckets),
+	}
+}
+
+// ReportSuccess reports a successful call along with its latency.
+func (m MethodMetrics) ReportSuccess(d time.Duration) {
+	m.Success.Inc(1)
+	m.SuccessLatency.RecordDuration(d)
+}
+
+// ReportError reports a failed call along with its latency.
+func (m MethodMetrics) ReportError(d time.Duration) {
+	m.Errors.Inc(1)
+	m.ErrorsLatency.RecordDuration(d)
+}
+
+// ReportSuccessOrError reports a call as failed if the error is
+// non-nil, and as successful otherwise.
+func (m MethodMetrics) ReportSuccessOrError(err error, d time.Duration) {
+	if err != nil {
+		m.ReportError(d)
+		return
+	}
+	m.ReportSuccess(d)
+}
+
+// BatchMethodMetrics is a bundle of common metrics for methods that
+// operate on batches, recording call latencies as histograms.
+type BatchMethodMetrics struct {
+	RetryableErrors    tally.Counter
+	NonRetryableErrors tally.Counter
+	Errors             tally.Counter
+	Success            tally.Counter
+	Latency            tally.Histogram
+}
+
+// NewBatchMethodMetrics returns a new BatchMethodMetrics for the given
+// method name whose latencies are recorded in the given buckets.
+func NewBatchMethodMetrics(
+	scope tally.Scope,
+	methodName string,
+	buckets tally.Buckets,
+) BatchMethodMetrics {
+	return BatchMethodMetrics{
+		RetryableErrors:    scope.Counter(methodName + ".retryable-errors"),
+		NonRetryableErrors: scope.Counter(methodName + ".non-retryable-errors"),
+		Errors:             scope.Counter(methodName + ".errors"),
+		Success:            scope.Counter(methodName + ".success"),
+		Latency:            scope.Histogram(methodName+".latency", buckets),
+	}
+}
+
+// ReportSuccess reports successes for n elements of the batch.
+func (m BatchMethodMetrics) ReportSuccess(n int) {
+	m.Success.Inc(int64(n))
+}
+
+// ReportRetryableErrors reports retryable errors for n elements of the batch.
+func (m BatchMethodMetrics) ReportRetryableErrors(n int) {
+	m.RetryableErrors.Inc(int64(n))
+	m.Errors.Inc(int64(n))
+}
+
+// ReportNonRetryableErrors reports non-retryable errors for n elements
+// of the batch.
+func (m BatchMethodMetrics) ReportNonRetryableErrors(n int) {
+	m.NonRetryableErrors.Inc(int64(n))
+	m.Errors.Inc(int64(n))
+}
+
+// ReportLatency reports the latency of the batch call.
+func (m BatchMethodMetrics) ReportLatency(d time.Duration) {
+	m.Latency.RecordDuration(d)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xmetrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestLatencyBucketsConfigurationNewBuckets(t *testing.T) {
+	buckets, err := LatencyBucketsConfiguration{}.NewBuckets()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLatencyBuckets, buckets)
+
+	buckets, err = LatencyBucketsConfiguration{
+		Values: []time.Duration{time.Millisecond, time.Second},
+	}.NewBuckets()
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Millisecond, time.Second}, buckets.AsDurations())
+
+	buckets, err = LatencyBucketsConfiguration{
+		Start:  time.Millisecond,
+		Factor: 10,
+		Count:  3,
+	}.NewBuckets()
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{
+		time.Millisecond, 10 * time.Millisecond, 100 * time.Millisecond,
+	}, buckets.AsDurations())
+
+	_, err = LatencyBucketsConfiguration{
+		Values: []time.Duration{time.Millisecond},
+		Count:  3,
+	}.NewBuckets()
+	assert.Error(t, err)
+}
+
+func TestMethodMetricsRecordsLatencyHistograms(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	buckets := tally.DurationBuckets{time.Millisecond, time.Second}
+	m := NewMethodMetrics(scope, "fetch", buckets)
+
+	m.ReportSuccess(500 * time.Microsecond)
+	m.ReportSuccessOrError(nil, 2*time.Millisecond)
+	m.ReportSuccessOrError(errors.New("boom"), 2*time.Second)
+
+	snapshot := scope.Snapshot()
+	assert.Equal(t, int64(2), snapshot.Counters()["fetch.success+"].Value())
+	assert.Equal(t, int64(1), snapshot.Counters()["fetch.errors+"].Value())
+
+	success := snapshot.Histograms()["fetch.success-latency+"].Durations()
+	assert.Equal(t, int64(1), success[time.Millisecond])
+	assert.Equal(t, int64(1), success[time.Second])
+
+	errs := snapshot.Histograms()["fetch.errors-latency+"].Durations()
+	var total int64
+	for _, n := range errs {
+		total += n
+	}
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, int64(0), errs[time.Second])
+}
+
+func TestBatchMethodMetricsRecordsLatencyHistogram(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	m := NewBatchMethodMetrics(scope, "writeBatchRaw", DefaultLatencyBuckets)
+
+	m.ReportSuccess(3)
+	m.ReportRetryableErrors(1)
+	m.ReportNonRetryableErrors(2)
+	m.ReportLatency(time.Millisecond)
+
+	snapshot := scope.Snapshot()
+	assert.Equal(t, int64(3), snapshot.Counters()["writeBatchRaw.success+"].Value())
+	assert.Equal(t, int64(3), snapshot.Counters()["writeBatchRaw.errors+"].Value())
+	assert.Equal(t, int64(1), snapshot.Counters()["writeBatchRaw.retryable-errors+"].Value())
+	assert.Equal(t, int64(2), snapshot.Counters()["writeBatchRaw.non-retryable-errors+"].Value())
+
+	var total int64
+	for _, n := range snapshot.Histograms()["writeBatchRaw.latency+"].Durations() {
+		total += n
+	}
+	assert.Equal(t, int64(1), total)
+}
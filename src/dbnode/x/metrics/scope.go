@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xmetrics
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// NamespaceTagName is the tag name of namespace identifiers.
+	NamespaceTagName = "namespace"
+
+	// ShardTagName is the tag name of shard identifiers.
+	ShardTagName = "shard"
+
+	prometheusSeparator = '_'
+)
+
+// Subsystem is a top level scope in the metrics hierarchy, every metric of
+// a subsystem is named under the subsystem's scope so that metrics can be
+// selected per subsystem.
+type Subsystem string
+
+const (
+	// SubsystemDatabase is the subsystem of database and namespace metrics.
+	SubsystemDatabase Subsystem = "database"
+
+	// SubsystemIndex is the subsystem of namespace index metrics.
+	SubsystemIndex Subsystem = "dbindex"
+
+	// SubsystemCommitLog is the subsystem of commit log metrics.
+	SubsystemCommitLog Subsystem = "commitlog"
+
+	// SubsystemRepair is the subsystem of repair metrics.
+	SubsystemRepair Subsystem = "repair"
+
+	// SubsystemConsistencyCheck is the subsystem of consistency check metrics.
+	SubsystemConsistencyCheck Subsystem = "consistency-check"
+)
+
+// SubsystemScope returns the scope of a subsystem.
+func SubsystemScope(scope tally.Scope, subsystem Subsystem) tally.Scope {
+	return scope.SubScope(string(subsystem))
+}
+
+// NamespaceScope returns a scope tagged with a namespace.
+func NamespaceScope(scope tally.Scope, namespace string) tally.Scope {
+	return scope.Tagged(map[string]string{
+		NamespaceTagName: namespace,
+	})
+}
+
+// ShardScope returns a scope tagged with a shard.
+func ShardScope(scope tally.Scope, shard uint32) tally.Scope {
+	return scope.Tagged(map[string]string{
+		ShardTagName: strconv.Itoa(int(shard)),
+	})
+}
+
+// NamespaceShardScope returns a scope tagged with a namespace and a shard.
+func NamespaceShardScope(scope tally.Scope, namespace string, shard uint32) tally.Scope {
+	return scope.Tagged(map[string]string{
+		NamespaceTagName: namespace,
+		ShardTagName:     strconv.Itoa(int(shard)),
+	})
+}
+
+// SubsystemFilter matches the names of metrics exported by the Prometheus
+// reporter against a set of subsystems.
+type SubsystemFilter struct {
+	prefixes []string
+}
+
+// NewSubsystemFilter returns a filter that matches metrics of the given
+// subsystems, rootPrefix is the prefix of the root metrics scope.
+func NewSubsystemFilter(rootPrefix string, subsystems []Subsystem) SubsystemFilter {
+	prefixes := make([]string, 0, len(subsystems))
+	for _, subsystem := range subsystems {
+		prefix := sanitizePrometheusName(string(subsystem))
+		if rootPrefix != "" {
+			prefix = sanitizePrometheusName(rootPrefix) +
+				string(prometheusSeparator) + prefix
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return SubsystemFilter{prefixes: prefixes}
+}
+
+// Match returns whether a metric name belongs to one of the subsystems.
+func (f SubsystemFilter) Match(name string) bool {
+	for _, prefix := range f.prefixes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if len(name) == len(prefix) || name[len(prefix)] == prometheusSeparator {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizePrometheusName replaces the characters that the Prometheus
+// reporter does not allow in metric names, such as '-' and '.'.
+func sanitizePrometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return prometheusSeparator
+	}, name)
+}
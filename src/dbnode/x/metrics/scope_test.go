@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+)
+
+func TestNamespaceShardScopes(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+
+	SubsystemScope(scope, SubsystemRepair).Counter("a").Inc(1)
+	NamespaceScope(scope, "ns").Counter("b").Inc(1)
+	ShardScope(scope, 3).Counter("c").Inc(1)
+	NamespaceShardScope(scope, "ns", 3).Counter("d").Inc(1)
+
+	counters := scope.Snapshot().Counters()
+	assert.Contains(t, counters, "repair.a+")
+	assert.Contains(t, counters, "b+namespace=ns")
+	assert.Contains(t, counters, "c+shard=3")
+	assert.Contains(t, counters, "d+namespace=ns,shard=3")
+}
+
+func TestSubsystemFilter(t *testing.T) {
+	filter := NewSubsystemFilter("coordinator",
+		[]Subsystem{SubsystemConsistencyCheck, "request"})
+
+	assert.True(t, filter.Match("coordinator_consistency_check_errors"))
+	assert.True(t, filter.Match("coordinator_request"))
+	assert.True(t, filter.Match("coordinator_request_latency_bucket"))
+	assert.False(t, filter.Match("coordinator_requests"))
+	assert.False(t, filter.Match("coordinator_repair_total"))
+	assert.False(t, filter.Match("request_latency"))
+
+	filter = NewSubsystemFilter("", []Subsystem{SubsystemCommitLog})
+	assert.True(t, filter.Match("commitlog_writes_bytes"))
+	assert.False(t, filter.Match("coordinator_commitlog_writes_bytes"))
+
+	assert.False(t, NewSubsystemFilter("coordinator", nil).Match("coordinator_request"))
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandlerExtractsTraceContext(t *testing.T) {
+	var (
+		sc SpanContext
+		ok bool
+	)
+	handler := NewHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc, ok = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/query", nil)
+	req.Header.Set(B3Header, testTraceID+"-"+testSpanID+"-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.True(t, ok)
+	assert.Equal(t, SpanContext{
+		TraceID:  testTraceID,
+		SpanID:   testSpanID,
+		Sampling: SamplingAccept,
+	}, sc)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/query", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, ok)
+}
+
+func TestInjectHTTP(t *testing.T) {
+	sc := SpanContext{TraceID: testTraceID, SpanID: testSpanID}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(NewContext(req.Context(), sc))
+	InjectHTTP(req)
+
+	assert.Equal(t, "00-"+testTraceID+"-"+testSpanID+"-00", req.Header.Get(TraceparentHeader))
+	assert.Empty(t, req.Header.Get(B3SampledHeader))
+
+	extracted, ok := Extract(HTTPHeaderCarrier(req.Header))
+	require.True(t, ok)
+	assert.Equal(t, testTraceID, extracted.TraceID)
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TraceparentHeader is the W3C trace context header.
+	TraceparentHeader = "traceparent"
+	// TracestateHeader is the W3C vendor specific trace state header.
+	TracestateHeader = "tracestate"
+	// B3Header is the B3 single header.
+	B3Header = "b3"
+	// B3TraceIDHeader is the B3 multi header trace ID header.
+	B3TraceIDHeader = "X-B3-TraceId"
+	// B3SpanIDHeader is the B3 multi header span ID header.
+	B3SpanIDHeader = "X-B3-SpanId"
+	// B3ParentSpanIDHeader is the B3 multi header parent span ID header.
+	B3ParentSpanIDHeader = "X-B3-ParentSpanId"
+	// B3SampledHeader is the B3 multi header sampling decision header.
+	B3SampledHeader = "X-B3-Sampled"
+	// B3FlagsHeader is the B3 multi header debug flag header.
+	B3FlagsHeader = "X-B3-Flags"
+
+	traceparentVersion = "00"
+	traceparentInvalid = "ff"
+	traceFlagSampled   = 0x01
+)
+
+// Carrier is a set of headers trace context is extracted from and injected
+// into. Header names are case insensitive.
+type Carrier interface {
+	// Get returns the value of the header, or empty if not set.
+	Get(key string) string
+	// Set sets the value of the header.
+	Set(key, value string)
+}
+
+// HTTPHeaderCarrier carries trace context in HTTP headers.
+type HTTPHeaderCarrier http.Header
+
+// Get returns the value of the header.
+func (c HTTPHeaderCarrier) Get(key string) string { return http.Header(c).Get(key) }
+
+// Set sets the value of the header.
+func (c HTTPHeaderCarrier) Set(key, value string) { http.Header(c).Set(key, value) }
+
+// MapCarrier carries trace context in a map of headers, such as the
+// application headers of node RPCs.
+type MapCarrier map[string]string
+
+// Get returns the value of the header matched case insensitively.
+func (c MapCarrier) Get(key string) string {
+	if v, ok := c[key]; ok {
+		return v
+	}
+	for k, v := range c {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Set sets the value of the header, replacing any header matched case
+// insensitively.
+func (c MapCarrier) Set(key, value string) {
+	for k := range c {
+		if k != key && strings.EqualFold(k, key) {
+			delete(c, k)
+		}
+	}
+	c[key] = value
+}
+
+// Extract extracts the trace context from the carrier, preferring the W3C
+// traceparent header over the B3 single header over the B3 multi headers.
+func Extract(carrier Carrier) (SpanContext, bool) {
+	if sc, ok := extractTraceparent(carrier); ok {
+		return sc, true
+	}
+	if sc, ok := extractB3Single(carrier); ok {
+		return sc, true
+	}
+	return extractB3Multi(carrier)
+}
+
+// Inject injects the trace context into the carrier in both the W3C
+// traceparent and B3 multi header formats.
+func Inject(sc SpanContext, carrier Carrier) {
+	if !sc.IsValid() {
+		return
+	}
+
+	flags := 0
+	if sc.Sampled() {
+		flags |= traceFlagSampled
+	}
+	carrier.Set(TraceparentHeader, fmt.Sprintf("%s-%s-%s-%02x",
+		traceparentVersion, sc.TraceID, sc.SpanID, flags))
+	if sc.TraceState != "" {
+		carrier.Set(TracestateHeader, sc.TraceState)
+	}
+
+	carrier.Set(B3TraceIDHeader, sc.TraceID)
+	carrier.Set(B3SpanIDHeader, sc.SpanID)
+	if sc.ParentSpanID != "" {
+		carrier.Set(B3ParentSpanIDHeader, sc.ParentSpanID)
+	}
+	switch sc.Sampling {
+	case SamplingAccept:
+		carrier.Set(B3SampledHeader, "1")
+	case SamplingDeny:
+		carrier.Set(B3SampledHeader, "0")
+	case SamplingDebug:
+		carrier.Set(B3FlagsHeader, "1")
+	}
+}
+
+// InjectMap returns the headers carrying the trace context, or nil if the
+// trace context is not valid.
+func InjectMap(sc SpanContext) map[string]string {
+	if !sc.IsValid() {
+		return nil
+	}
+	headers := make(map[string]string)
+	Inject(sc, MapCarrier(headers))
+	return headers
+}
+
+func extractTraceparent(carrier Carrier) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(carrier.Get(TraceparentHeader)), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, false
+	}
+	version := strings.ToLower(parts[0])
+	if len(version) != 2 || version == traceparentInvalid {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields, later versions may append more.
+	if version == traceparentVersion && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	if len(parts[1]) != traceIDLen || len(parts[2]) != spanIDLen || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+
+	sc := SpanContext{
+		TraceID: strings.ToLower(parts[1]),
+		SpanID:  strings.ToLower(parts[2]),
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampling = SamplingDeny
+	if flags&traceFlagSampled != 0 {
+		sc.Sampling = SamplingAccept
+	}
+	sc.TraceState = carrier.Get(TracestateHeader)
+	return sc, true
+}
+
+func extractB3Single(carrier Carrier) (SpanContext, bool) {
+	// {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}, with the sampling
+	// state and parent span ID optional. A lone sampling state carries no
+	// trace context.
+	parts := strings.Split(strings.TrimSpace(carrier.Get(B3Header)), "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return SpanContext{}, false
+	}
+
+	var (
+		sc SpanContext
+		ok bool
+	)
+	if sc.TraceID, ok = normalizeID(parts[0], traceIDLen); !ok {
+		return SpanContext{}, false
+	}
+	if len(parts[1]) != spanIDLen {
+		return SpanContext{}, false
+	}
+	if sc.SpanID, ok = normalizeID(parts[1], spanIDLen); !ok {
+		return SpanContext{}, false
+	}
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "1":
+			sc.Sampling = SamplingAccept
+		case "0":
+			sc.Sampling = SamplingDeny
+		case "d":
+			sc.Sampling = SamplingDebug
+		default:
+			return SpanContext{}, false
+		}
+	}
+	if len(parts) > 3 {
+		if sc.ParentSpanID, ok = normalizeID(parts[3], spanIDLen); !ok {
+			return SpanContext{}, false
+		}
+	}
+	return sc, true
+}
+
+func extractB3Multi(carrier Carrier) (SpanContext, bool) {
+	var (
+		sc SpanContext
+		ok bool
+	)
+	if sc.TraceID, ok = normalizeID(carrier.Get(B3TraceIDHeader), traceIDLen); !ok {
+		return SpanContext{}, false
+	}
+	if sc.SpanID, ok = normalizeID(carrier.Get(B3SpanIDHeader), spanIDLen); !ok {
+		return SpanContext{}, false
+	}
+	if parent := carrier.Get(B3ParentSpanIDHeader); parent != "" {
+		if sc.ParentSpanID, ok = normalizeID(parent, spanIDLen); !ok {
+			return SpanContext{}, false
+		}
+	}
+
+	switch strings.ToLower(carrier.Get(B3SampledHeader)) {
+	case "1", "true":
+		sc.Sampling = SamplingAccept
+	case "0", "false":
+		sc.Sampling = SamplingDeny
+	}
+	if carrier.Get(B3FlagsHeader) == "1" {
+		sc.Sampling = SamplingDebug
+	}
+	return sc, true
+}
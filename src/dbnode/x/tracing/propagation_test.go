@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestExtractTraceparent(t *testing.T) {
+	carrier := MapCarrier{
+		"Traceparent": "00-" + testTraceID + "-" + testSpanID + "-01",
+		"Tracestate":  "congo=t61rcWkgMzE",
+	}
+	sc, ok := Extract(carrier)
+	require.True(t, ok)
+	assert.Equal(t, SpanContext{
+		TraceID:    testTraceID,
+		SpanID:     testSpanID,
+		Sampling:   SamplingAccept,
+		TraceState: "congo=t61rcWkgMzE",
+	}, sc)
+}
+
+func TestExtractTraceparentInvalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"00-" + testTraceID + "-" + testSpanID,
+		"ff-" + testTraceID + "-" + testSpanID + "-01",
+		"00-" + testTraceID + "-" + testSpanID + "-01-extra",
+		"00-00000000000000000000000000000000-" + testSpanID + "-01",
+		"00-" + testTraceID + "-0000000000000000-01",
+		"00-" + testTraceID + "-" + testSpanID + "-zz",
+		"00-" + testTraceID[1:] + "-" + testSpanID + "-01",
+	} {
+		_, ok := Extract(MapCarrier{TraceparentHeader: value})
+		assert.False(t, ok, value)
+	}
+}
+
+func TestExtractTraceparentFutureVersion(t *testing.T) {
+	sc, ok := Extract(MapCarrier{
+		TraceparentHeader: "01-" + testTraceID + "-" + testSpanID + "-00-extra",
+	})
+	require.True(t, ok)
+	assert.Equal(t, SamplingDeny, sc.Sampling)
+}
+
+func TestExtractB3Single(t *testing.T) {
+	sc, ok := Extract(MapCarrier{
+		B3Header: "a3ce929d0e0e4736-" + testSpanID + "-d-05e3ac9a4f6e3b90",
+	})
+	require.True(t, ok)
+	assert.Equal(t, SpanContext{
+		TraceID:      "0000000000000000a3ce929d0e0e4736",
+		SpanID:       testSpanID,
+		ParentSpanID: "05e3ac9a4f6e3b90",
+		Sampling:     SamplingDebug,
+	}, sc)
+
+	sc, ok = Extract(MapCarrier{B3Header: testTraceID + "-" + testSpanID})
+	require.True(t, ok)
+	assert.Equal(t, SamplingDeferred, sc.Sampling)
+
+	for _, value := range []string{"0", "1", testTraceID + "-" + testSpanID + "-x"} {
+		_, ok := Extract(MapCarrier{B3Header: value})
+		assert.False(t, ok, value)
+	}
+}
+
+func TestExtractB3Multi(t *testing.T) {
+	header := http.Header{}
+	header.Set(B3TraceIDHeader, testTraceID)
+	header.Set(B3SpanIDHeader, testSpanID)
+	header.Set(B3ParentSpanIDHeader, "05e3ac9a4f6e3b90")
+	header.Set(B3SampledHeader, "0")
+
+	sc, ok := Extract(HTTPHeaderCarrier(header))
+	require.True(t, ok)
+	assert.Equal(t, SpanContext{
+		TraceID:      testTraceID,
+		SpanID:       testSpanID,
+		ParentSpanID: "05e3ac9a4f6e3b90",
+		Sampling:     SamplingDeny,
+	}, sc)
+
+	header.Set(B3FlagsHeader, "1")
+	sc, ok = Extract(HTTPHeaderCarrier(header))
+	require.True(t, ok)
+	assert.Equal(t, SamplingDebug, sc.Sampling)
+
+	header.Del(B3SpanIDHeader)
+	_, ok = Extract(HTTPHeaderCarrier(header))
+	assert.False(t, ok)
+}
+
+func TestExtractPrefersTraceparent(t *testing.T) {
+	sc, ok := Extract(MapCarrier{
+		TraceparentHeader: "00-" + testTraceID + "-" + testSpanID + "-00",
+		B3Header:          "a3ce929d0e0e4736-05e3ac9a4f6e3b90-1",
+	})
+	require.True(t, ok)
+	assert.Equal(t, testTraceID, sc.TraceID)
+	assert.Equal(t, testSpanID, sc.SpanID)
+}
+
+func TestInjectRoundTrip(t *testing.T) {
+	sc := SpanContext{
+		TraceID:      testTraceID,
+		SpanID:       testSpanID,
+		ParentSpanID: "05e3ac9a4f6e3b90",
+		Sampling:     SamplingAccept,
+		TraceState:   "congo=t61rcWkgMzE",
+	}
+	headers := InjectMap(sc)
+	assert.Equal(t, map[string]string{
+		TraceparentHeader:    "00-" + testTraceID + "-" + testSpanID + "-01",
+		TracestateHeader:     "congo=t61rcWkgMzE",
+		B3TraceIDHeader:      testTraceID,
+		B3SpanIDHeader:       testSpanID,
+		B3ParentSpanIDHeader: "05e3ac9a4f6e3b90",
+		B3SampledHeader:      "1",
+	}, headers)
+
+	// Receivers only understanding B3 see the same trace.
+	delete(headers, TraceparentHeader)
+	extracted, ok := Extract(MapCarrier(headers))
+	require.True(t, ok)
+	sc.TraceState = ""
+	assert.Equal(t, sc, extracted)
+}
+
+func TestInjectInvalid(t *testing.T) {
+	assert.Nil(t, InjectMap(SpanContext{}))
+
+	header := http.Header{}
+	Inject(SpanContext{TraceID: testTraceID}, HTTPHeaderCarrier(header))
+	assert.Empty(t, header)
+}
+
+func TestMapCarrierCaseInsensitive(t *testing.T) {
+	carrier := MapCarrier{"X-B3-Traceid": "a"}
+	assert.Equal(t, "a", carrier.Get(B3TraceIDHeader))
+
+	carrier.Set(B3TraceIDHeader, "b")
+	assert.Equal(t, MapCarrier{B3TraceIDHeader: "b"}, carrier)
+}
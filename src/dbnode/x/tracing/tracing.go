@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tracing propagates trace context across the HTTP, gRPC and node
+// RPC surfaces in both the B3 (Zipkin) and W3C traceparent formats, so that
+// traces started by heterogeneous infrastructure flow through M3. Trace
+// context is extracted from whichever format is present and injected in
+// both formats, without recording spans itself.
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+)
+
+// Sampling is the sampling decision carried by a trace context.
+type Sampling int
+
+const (
+	// SamplingDeferred leaves the sampling decision to the receiver.
+	SamplingDeferred Sampling = iota
+	// SamplingDeny records the trace is not sampled.
+	SamplingDeny
+	// SamplingAccept records the trace is sampled.
+	SamplingAccept
+	// SamplingDebug records the trace is sampled and forced through
+	// downstream sampling.
+	SamplingDebug
+)
+
+// SpanContext is the trace context propagated between services. Identifiers
+// are lower case hex, trace IDs are 32 characters and span IDs 16.
+type SpanContext struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Sampling     Sampling
+	// TraceState is the opaque vendor specific W3C tracestate.
+	TraceState string
+}
+
+// IsValid returns whether the span context identifies a trace and span.
+func (c SpanContext) IsValid() bool {
+	return validID(c.TraceID, traceIDLen) && validID(c.SpanID, spanIDLen)
+}
+
+// Sampled returns whether the trace is sampled.
+func (c SpanContext) Sampled() bool {
+	return c.Sampling == SamplingAccept || c.Sampling == SamplingDebug
+}
+
+const (
+	traceIDLen = 32
+	spanIDLen  = 16
+)
+
+// normalizeID lower cases a hex identifier and left pads it with zeros to
+// the length, 64 bit B3 trace IDs are padded to 128 bits.
+func normalizeID(id string, length int) (string, bool) {
+	id = strings.ToLower(id)
+	if len(id) == 0 || len(id) > length {
+		return "", false
+	}
+	if len(id) < length {
+		id = strings.Repeat("0", length-len(id)) + id
+	}
+	if !validID(id, length) {
+		return "", false
+	}
+	return id, true
+}
+
+func validID(id string, length int) bool {
+	if len(id) != length {
+		return false
+	}
+	b, err := hex.DecodeString(id)
+	if err != nil {
+		return false
+	}
+	// All zero identifiers are invalid.
+	for _, v := range b {
+		if v != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the span context.
+func NewContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// FromContext returns the span context carried by the context, if any.
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(contextKey{}).(SpanContext)
+	return sc, ok
+}
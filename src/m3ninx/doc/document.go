@@ -36,6 +36,11 @@ var (
 // IDReservedFieldName is the field name reserved for IDs.
 var IDReservedFieldName = []byte("_m3ninx_id")
 
+// MetricNameFieldName is the field name of the names of metrics, segments
+// keep a dedicated dictionary for its terms since most queries select
+// series by an exact metric name.
+var MetricNameFieldName = []byte("__name__")
+
 // Field represents a field in a document. It is composed of a name and a value.
 type Field struct {
 	Name  []byte
@@ -101,13 +101,18 @@ type PostingsFormat int32
 
 const (
 	PostingsFormat_PILOSAV1_POSTINGS_FORMAT PostingsFormat = 0
+	// Each postings list is prefixed with a byte identifying its encoding,
+	// one of roaring, delta-varint or bitset.
+	PostingsFormat_TYPEDV1_POSTINGS_FORMAT PostingsFormat = 1
 )
 
 var PostingsFormat_name = map[int32]string{
 	0: "PILOSAV1_POSTINGS_FORMAT",
+	1: "TYPEDV1_POSTINGS_FORMAT",
 }
 var PostingsFormat_value = map[string]int32{
 	"PILOSAV1_POSTINGS_FORMAT": 0,
+	"TYPEDV1_POSTINGS_FORMAT":  1,
 }
 
 func (x PostingsFormat) String() string {
@@ -405,26 +410,26 @@ func init() {
 }
 
 var fileDescriptorFswriter = []byte{
-	// 322 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0xc1, 0x4e, 0x83, 0x40,
-	0x10, 0x86, 0xa1, 0x35, 0x5a, 0xc7, 0x14, 0xd7, 0xd5, 0x03, 0x07, 0x43, 0x1a, 0xbd, 0x34, 0x3d,
-	0x40, 0x94, 0x17, 0x10, 0x05, 0x1a, 0x92, 0x52, 0x08, 0xbb, 0x1a, 0x3d, 0x11, 0xda, 0x6e, 0x91,
-	0xc4, 0x65, 0x1b, 0xd8, 0x46, 0x7d, 0x0b, 0x1f, 0xcb, 0xa3, 0x8f, 0x60, 0xea, 0x8b, 0x98, 0x36,
-	0x6d, 0x4d, 0xbd, 0xed, 0xff, 0xed, 0x37, 0x33, 0x99, 0x01, 0x2f, 0x2f, 0xe4, 0xf3, 0x7c, 0x64,
-	0x8e, 0x05, 0xb7, 0xb8, 0x3d, 0x19, 0x59, 0xdc, 0xb6, 0xea, 0x6a, 0x6c, 0x71, 0xbb, 0x2c, 0xca,
-	0x37, 0x2b, 0x67, 0x25, 0xab, 0x32, 0xc9, 0x26, 0xd6, 0xac, 0x12, 0x52, 0x58, 0xd3, 0xfa, 0xb5,
-	0x2a, 0x24, 0xab, 0xb6, 0x0f, 0x73, 0xc5, 0x71, 0x6b, 0x93, 0x2f, 0xa6, 0xd0, 0x0a, 0x99, 0xcc,
-	0x26, 0x99, 0xcc, 0xf0, 0x0d, 0x68, 0x33, 0x51, 0xcb, 0xa2, 0xcc, 0x6b, 0x5f, 0x54, 0x3c, 0x93,
-	0xba, 0xda, 0x51, 0xbb, 0xda, 0xb5, 0x6e, 0x6e, 0xcb, 0xe3, 0x9d, 0xff, 0xe4, 0x9f, 0x8f, 0x75,
-	0x38, 0x28, 0xe7, 0xdc, 0x15, 0xe3, 0x5a, 0x6f, 0x74, 0xd4, 0x6e, 0x33, 0xd9, 0xc4, 0xde, 0x25,
-	0x1c, 0x11, 0x96, 0x73, 0x56, 0x4a, 0xfa, 0x3e, 0x63, 0xf8, 0x0c, 0x90, 0x4f, 0x68, 0x4a, 0xbc,
-	0x7e, 0xe8, 0x0d, 0x69, 0x4a, 0x9f, 0x62, 0x0f, 0x29, 0x3d, 0x01, 0xd8, 0x27, 0x74, 0xed, 0xf9,
-	0xc5, 0x0b, 0x5b, 0xb9, 0xa7, 0x70, 0xec, 0x46, 0x77, 0xf7, 0x4b, 0x91, 0xa4, 0xc1, 0xd0, 0xf5,
-	0x1e, 0x91, 0x82, 0x31, 0x68, 0x7f, 0xd0, 0x75, 0xa8, 0x83, 0x54, 0x7c, 0x02, 0xed, 0x38, 0x22,
-	0x34, 0x18, 0xf6, 0xd7, 0xa8, 0x81, 0xdb, 0x70, 0xb8, 0x9c, 0x43, 0xbd, 0x24, 0x24, 0xa8, 0x89,
-	0x35, 0x80, 0x65, 0xf4, 0x03, 0x6f, 0xe0, 0x12, 0xb4, 0xd7, 0x33, 0x41, 0xdb, 0xdd, 0x08, 0x9f,
-	0x83, 0x1e, 0x07, 0x83, 0x88, 0x38, 0x0f, 0x57, 0xe9, 0xb6, 0x99, 0x1f, 0x25, 0xa1, 0x43, 0x91,
-	0x72, 0x8b, 0x3e, 0x17, 0x86, 0xfa, 0xb5, 0x30, 0xd4, 0xef, 0x85, 0xa1, 0x7e, 0xfc, 0x18, 0xca,
-	0x68, 0x7f, 0x75, 0x50, 0xfb, 0x37, 0x00, 0x00, 0xff, 0xff, 0xe2, 0x48, 0x39, 0x25, 0x99, 0x01,
-	0x00, 0x00,
+	// 329 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x90, 0x4f, 0x4e, 0x83, 0x40,
+	0x18, 0xc5, 0x99, 0xd6, 0x68, 0xfd, 0x4c, 0x71, 0x1c, 0x4d, 0x24, 0xd1, 0x90, 0x46, 0x37, 0x4d,
+	0x17, 0x25, 0xca, 0x05, 0x44, 0x81, 0x86, 0x58, 0x0a, 0x61, 0x46, 0xa3, 0x2b, 0x42, 0xdb, 0x29,
+	0x92, 0x08, 0x34, 0x30, 0x8d, 0x7a, 0x0b, 0x8f, 0xe5, 0xd2, 0x23, 0x98, 0x7a, 0x11, 0x43, 0xd3,
+	0xd6, 0xd4, 0xb8, 0x9b, 0xf7, 0xde, 0x6f, 0xbe, 0x7f, 0x60, 0xc5, 0x89, 0x78, 0x9a, 0x0d, 0xbb,
+	0xa3, 0x3c, 0xd5, 0x52, 0x7d, 0x3c, 0xd4, 0x52, 0x5d, 0x2b, 0x8b, 0x91, 0x96, 0xea, 0x59, 0x92,
+	0xbd, 0x6a, 0x31, 0xcf, 0x78, 0x11, 0x09, 0x3e, 0xd6, 0xa6, 0x45, 0x2e, 0x72, 0x6d, 0x52, 0xbe,
+	0x14, 0x89, 0xe0, 0xc5, 0xfa, 0xd1, 0x5d, 0xf8, 0xa4, 0xb1, 0xd2, 0x67, 0x13, 0x68, 0xb8, 0x5c,
+	0x44, 0xe3, 0x48, 0x44, 0xe4, 0x0a, 0xe4, 0x69, 0x5e, 0x8a, 0x24, 0x8b, 0x4b, 0x3b, 0x2f, 0xd2,
+	0x48, 0x28, 0xa8, 0x85, 0xda, 0xf2, 0xa5, 0xd2, 0x5d, 0x7f, 0xf7, 0x37, 0xf2, 0xe0, 0x0f, 0x4f,
+	0x14, 0xd8, 0xc9, 0x66, 0xa9, 0x99, 0x8f, 0x4a, 0xa5, 0xd6, 0x42, 0xed, 0x7a, 0xb0, 0x92, 0x9d,
+	0x73, 0xd8, 0xa3, 0x3c, 0x4e, 0x79, 0x26, 0xd8, 0xdb, 0x94, 0x93, 0x23, 0xc0, 0x36, 0x65, 0x21,
+	0xb5, 0x7a, 0xae, 0x35, 0x60, 0x21, 0x7b, 0xf4, 0x2d, 0x2c, 0x75, 0x72, 0x20, 0x36, 0x65, 0x4b,
+	0xce, 0x4e, 0x9e, 0xf9, 0x82, 0x3d, 0x84, 0x7d, 0xd3, 0xbb, 0xb9, 0xab, 0x40, 0x1a, 0x3a, 0x03,
+	0xd3, 0x7a, 0xc0, 0x12, 0x21, 0x20, 0xff, 0x9a, 0xa6, 0xc1, 0x0c, 0x8c, 0xc8, 0x01, 0x34, 0x7d,
+	0x8f, 0x32, 0x67, 0xd0, 0x5b, 0x5a, 0x35, 0xd2, 0x84, 0xdd, 0xaa, 0x0f, 0xb3, 0x02, 0x97, 0xe2,
+	0x3a, 0x91, 0x01, 0x2a, 0x69, 0x3b, 0x56, 0xdf, 0xa4, 0x78, 0xab, 0x73, 0x0b, 0xf2, 0xe6, 0x46,
+	0xe4, 0x14, 0x14, 0xdf, 0xe9, 0x7b, 0xd4, 0xb8, 0xbf, 0x08, 0xd7, 0xc5, 0x6c, 0x2f, 0x70, 0x0d,
+	0x86, 0x25, 0x72, 0x02, 0xc7, 0xd5, 0xa8, 0xe6, 0x3f, 0x21, 0xba, 0xc6, 0x1f, 0x73, 0x15, 0x7d,
+	0xce, 0x55, 0xf4, 0x35, 0x57, 0xd1, 0xfb, 0xb7, 0x2a, 0x0d, 0xb7, 0x17, 0xd7, 0xd6, 0x7f, 0x06,
+	0x00, 0xa3, 0x45, 0x33, 0x5b, 0xb6, 0x01, 0x00, 0x00,
 }
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// NumericRange is a range of numeric values. Each bound is inclusive unless
+// marked as exclusive, an unbounded side of the range is represented by an
+// infinite bound.
+type NumericRange struct {
+	Min          float64
+	Max          float64
+	MinExclusive bool
+	MaxExclusive bool
+}
+
+// NewNumericRangeGreaterThan returns a range over all values greater than min.
+func NewNumericRangeGreaterThan(min float64, inclusive bool) NumericRange {
+	return NumericRange{
+		Min:          min,
+		Max:          math.Inf(1),
+		MinExclusive: !inclusive,
+	}
+}
+
+// NewNumericRangeLessThan returns a range over all values less than max.
+func NewNumericRangeLessThan(max float64, inclusive bool) NumericRange {
+	return NumericRange{
+		Min:          math.Inf(-1),
+		Max:          max,
+		MaxExclusive: !inclusive,
+	}
+}
+
+// Contains returns a bool indicating whether the value lies within the range.
+func (r NumericRange) Contains(v float64) bool {
+	if r.MinExclusive {
+		if !(v > r.Min) {
+			return false
+		}
+	} else if !(v >= r.Min) {
+		return false
+	}
+	if r.MaxExclusive {
+		return v < r.Max
+	}
+	return v <= r.Max
+}
+
+// ContainsTerm returns a bool indicating whether the term is a number which lies
+// within the range. Terms which are not numbers never lie within a range.
+func (r NumericRange) ContainsTerm(term []byte) bool {
+	v, err := strconv.ParseFloat(string(term), 64)
+	if err != nil {
+		return false
+	}
+	return r.Contains(v)
+}
+
+func (r NumericRange) String() string {
+	lower, upper := "[", "]"
+	if r.MinExclusive {
+		lower = "("
+	}
+	if r.MaxExclusive {
+		upper = ")"
+	}
+	return fmt.Sprintf("%s%v, %v%s", lower, r.Min, r.Max, upper)
+}
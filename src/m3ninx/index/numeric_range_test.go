@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumericRangeContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        NumericRange
+		value    float64
+		expected bool
+	}{
+		{
+			name:     "greater than or equal at bound",
+			r:        NewNumericRangeGreaterThan(8000, true),
+			value:    8000,
+			expected: true,
+		},
+		{
+			name:     "greater than at bound",
+			r:        NewNumericRangeGreaterThan(8000, false),
+			value:    8000,
+			expected: false,
+		},
+		{
+			name:     "less than or equal at bound",
+			r:        NewNumericRangeLessThan(10, true),
+			value:    10,
+			expected: true,
+		},
+		{
+			name:     "less than at bound",
+			r:        NewNumericRangeLessThan(10, false),
+			value:    10,
+			expected: false,
+		},
+		{
+			name:     "within bounded range",
+			r:        NumericRange{Min: -1, Max: 1},
+			value:    0.5,
+			expected: true,
+		},
+		{
+			name:     "outside bounded range",
+			r:        NumericRange{Min: -1, Max: 1},
+			value:    1.5,
+			expected: false,
+		},
+		{
+			name:     "NaN is never contained",
+			r:        NumericRange{Min: math.Inf(-1), Max: math.Inf(1)},
+			value:    math.NaN(),
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.r.Contains(test.value))
+		})
+	}
+}
+
+func TestNumericRangeContainsTerm(t *testing.T) {
+	r := NewNumericRangeGreaterThan(8000, true)
+	require.True(t, r.ContainsTerm([]byte("8080")))
+	require.True(t, r.ContainsTerm([]byte("8.5e3")))
+	require.False(t, r.ContainsTerm([]byte("80")))
+	require.False(t, r.ContainsTerm([]byte("not-a-number")))
+	require.False(t, r.ContainsTerm(nil))
+}
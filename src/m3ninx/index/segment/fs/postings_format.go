@@ -0,0 +1,305 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/postings/bitset"
+	"github.com/m3db/m3/src/m3ninx/postings/pilosa"
+	"github.com/m3db/m3/src/m3ninx/postings/varint"
+)
+
+// PostingsFormat is the encoding of the postings lists of a segment.
+type PostingsFormat uint8
+
+const (
+	// RoaringPostingsFormat encodes postings lists as roaring bitmaps.
+	RoaringPostingsFormat PostingsFormat = iota
+
+	// DeltaVarintPostingsFormat encodes postings lists as the uvarint deltas
+	// between IDs, which is compact for sparse postings lists.
+	DeltaVarintPostingsFormat
+
+	// BitsetPostingsFormat encodes postings lists as a bitset offset from the
+	// minimum ID, which is compact for dense postings lists.
+	BitsetPostingsFormat
+
+	// AutoPostingsFormat selects the most compact encoding for each postings
+	// list based on its density.
+	AutoPostingsFormat
+)
+
+const (
+	// minBitsetDensity is the minimum density of a postings list, the number
+	// of IDs over the range between its min and max ID, for the bitset to be
+	// considered by the auto format. Below it the bitset takes more than 8
+	// bytes per ID which the roaring and delta-varint encodings never exceed.
+	minBitsetDensity = 1.0 / 64
+)
+
+var (
+	errPostingsFormatUnspecified = errors.New("postings format not specified")
+	errPostingsFormatMissing     = errors.New("postings list is missing its format")
+)
+
+// ValidPostingsFormats returns the valid postings formats.
+func ValidPostingsFormats() []PostingsFormat {
+	return []PostingsFormat{RoaringPostingsFormat, DeltaVarintPostingsFormat,
+		BitsetPostingsFormat, AutoPostingsFormat}
+}
+
+func (f PostingsFormat) String() string {
+	switch f {
+	case RoaringPostingsFormat:
+		return "roaring"
+	case DeltaVarintPostingsFormat:
+		return "delta-varint"
+	case BitsetPostingsFormat:
+		return "bitset"
+	case AutoPostingsFormat:
+		return "auto"
+	}
+	return "unknown"
+}
+
+// Validate validates that the postings format is valid.
+func (f PostingsFormat) Validate() error {
+	for _, valid := range ValidPostingsFormats() {
+		if valid == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid postings format '%d' valid types are: %v",
+		uint(f), ValidPostingsFormats())
+}
+
+// ParsePostingsFormat parses a PostingsFormat from a string.
+func ParsePostingsFormat(str string) (PostingsFormat, error) {
+	var f PostingsFormat
+	if str == "" {
+		return f, errPostingsFormatUnspecified
+	}
+	for _, valid := range ValidPostingsFormats() {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return f, fmt.Errorf("invalid postings format '%s' valid types are: %v",
+		str, ValidPostingsFormats())
+}
+
+// UnmarshalYAML unmarshals a PostingsFormat into a valid type from string.
+func (f *PostingsFormat) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParsePostingsFormat(str)
+	if err != nil {
+		return err
+	}
+	*f = r
+	return nil
+}
+
+// NewWriterOpts represent the collection of knobs used by the Writer.
+type NewWriterOpts struct {
+	// PostingsFormat is the encoding of the postings lists of all fields
+	// without a postings format of their own, defaults to roaring.
+	PostingsFormat PostingsFormat
+
+	// FieldPostingsFormats is the encoding of the postings lists of
+	// specific fields.
+	FieldPostingsFormats map[string]PostingsFormat
+}
+
+// Validate validates the writer options, returning an error if they're not.
+func (o NewWriterOpts) Validate() error {
+	if err := o.PostingsFormat.Validate(); err != nil {
+		return err
+	}
+	for field, format := range o.FieldPostingsFormats {
+		if err := format.Validate(); err != nil {
+			return fmt.Errorf("field %s: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// typed returns whether any postings list is encoded with a format other
+// than roaring, in which case each postings list is prefixed by its format.
+func (o NewWriterOpts) typed() bool {
+	if o.PostingsFormat != RoaringPostingsFormat {
+		return true
+	}
+	for _, format := range o.FieldPostingsFormats {
+		if format != RoaringPostingsFormat {
+			return true
+		}
+	}
+	return false
+}
+
+func (o NewWriterOpts) fieldPostingsFormat(field []byte) PostingsFormat {
+	if format, ok := o.FieldPostingsFormats[string(field)]; ok {
+		return format
+	}
+	return o.PostingsFormat
+}
+
+// postingsEncoder encodes postings lists with one of the postings formats,
+// untyped postings lists are always encoded as roaring bitmaps without being
+// prefixed by their format to remain readable by the v1 postings format.
+type postingsEncoder struct {
+	typed   bool
+	roaring *pilosa.Encoder
+	varint  *varint.Encoder
+	bitset  *bitset.Encoder
+	buf     []byte
+}
+
+func newPostingsEncoder(typed bool) *postingsEncoder {
+	return &postingsEncoder{
+		typed:   typed,
+		roaring: pilosa.NewEncoder(),
+		varint:  varint.NewEncoder(),
+		bitset:  bitset.NewEncoder(),
+	}
+}
+
+func (e *postingsEncoder) Reset() {
+	e.roaring.Reset()
+	e.varint.Reset()
+	e.bitset.Reset()
+	e.buf = e.buf[:0]
+}
+
+// Encode encodes the provided postings list with the given format, the bytes
+// returned are invalidated on a subsequent call to Encode() or Reset().
+func (e *postingsEncoder) Encode(
+	pl postings.List,
+	format PostingsFormat,
+) ([]byte, error) {
+	if !e.typed {
+		return e.roaring.Encode(pl)
+	}
+
+	var (
+		encoded []byte
+		err     error
+	)
+	switch format {
+	case RoaringPostingsFormat:
+		encoded, err = e.roaring.Encode(pl)
+	case DeltaVarintPostingsFormat:
+		encoded, err = e.varint.Encode(pl)
+	case BitsetPostingsFormat:
+		encoded, err = e.bitset.Encode(pl)
+	case AutoPostingsFormat:
+		format, encoded, err = e.encodeSmallest(pl)
+	default:
+		err = format.Validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.buf = append(e.buf[:0], byte(format))
+	e.buf = append(e.buf, encoded...)
+	return e.buf, nil
+}
+
+// encodeSmallest encodes the postings list with each format that may be the
+// most compact given the density of the postings list and returns the
+// smallest encoding, preferring roaring bitmaps which are fastest to decode.
+func (e *postingsEncoder) encodeSmallest(
+	pl postings.List,
+) (PostingsFormat, []byte, error) {
+	format := RoaringPostingsFormat
+	smallest, err := e.roaring.Encode(pl)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	encoded, err := e.varint.Encode(pl)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(encoded) < len(smallest) {
+		format, smallest = DeltaVarintPostingsFormat, encoded
+	}
+
+	if postingsDensity(pl) < minBitsetDensity {
+		return format, smallest, nil
+	}
+
+	encoded, err = e.bitset.Encode(pl)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(encoded) < len(smallest) {
+		format, smallest = BitsetPostingsFormat, encoded
+	}
+	return format, smallest, nil
+}
+
+// postingsDensity returns the number of IDs of the postings list over the
+// range between its min and max ID.
+func postingsDensity(pl postings.List) float64 {
+	min, err := pl.Min()
+	if err != nil {
+		return 0
+	}
+	max, err := pl.Max()
+	if err != nil {
+		return 0
+	}
+	return float64(pl.Len()) / (float64(max-min) + 1)
+}
+
+// unmarshalPostings unmarshals a postings list encoded by the postings
+// encoder, typed postings lists are prefixed by their format.
+func unmarshalPostings(
+	data []byte,
+	typed bool,
+	allocFn postings.PoolAllocateFn,
+) (postings.List, error) {
+	if !typed {
+		return pilosa.Unmarshal(data, allocFn)
+	}
+	if len(data) == 0 {
+		return nil, errPostingsFormatMissing
+	}
+
+	format, data := PostingsFormat(data[0]), data[1:]
+	switch format {
+	case RoaringPostingsFormat:
+		return pilosa.Unmarshal(data, allocFn)
+	case DeltaVarintPostingsFormat:
+		return varint.Unmarshal(data, allocFn)
+	case BitsetPostingsFormat:
+		return bitset.Unmarshal(data, allocFn)
+	}
+	return nil, fmt.Errorf("unsupported postings list format: %d", uint(format))
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/postings/roaring"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePostingsFormat(t *testing.T) {
+	for _, format := range ValidPostingsFormats() {
+		parsed, err := ParsePostingsFormat(format.String())
+		require.NoError(t, err)
+		require.Equal(t, format, parsed)
+	}
+
+	_, err := ParsePostingsFormat("")
+	require.Error(t, err)
+	_, err = ParsePostingsFormat("gzip")
+	require.Error(t, err)
+}
+
+func TestNewWriterWithOptsInvalidPostingsFormat(t *testing.T) {
+	_, err := NewWriterWithOpts(NewWriterOpts{PostingsFormat: PostingsFormat(42)})
+	require.Error(t, err)
+
+	_, err = NewWriterWithOpts(NewWriterOpts{
+		FieldPostingsFormats: map[string]PostingsFormat{"foo": PostingsFormat(42)},
+	})
+	require.Error(t, err)
+}
+
+func TestNewWriterWithOptsTypedPostingsFormat(t *testing.T) {
+	require.False(t, NewWriterOpts{}.typed())
+	require.False(t, NewWriterOpts{
+		FieldPostingsFormats: map[string]PostingsFormat{"foo": RoaringPostingsFormat},
+	}.typed())
+	require.True(t, NewWriterOpts{PostingsFormat: AutoPostingsFormat}.typed())
+	require.True(t, NewWriterOpts{
+		FieldPostingsFormats: map[string]PostingsFormat{"foo": BitsetPostingsFormat},
+	}.typed())
+
+	opts := NewWriterOpts{
+		PostingsFormat: DeltaVarintPostingsFormat,
+		FieldPostingsFormats: map[string]PostingsFormat{
+			"foo": BitsetPostingsFormat,
+		},
+	}
+	require.Equal(t, BitsetPostingsFormat, opts.fieldPostingsFormat([]byte("foo")))
+	require.Equal(t, DeltaVarintPostingsFormat, opts.fieldPostingsFormat([]byte("bar")))
+}
+
+func TestPostingsEncoderAutoSelectsSmallestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		pl       postings.List
+		expected PostingsFormat
+	}{
+		{
+			name:     "sparse",
+			pl:       newTestSparsePostingsList(1000),
+			expected: DeltaVarintPostingsFormat,
+		},
+		{
+			name:     "dense",
+			pl:       newTestDensePostingsList(10000),
+			expected: BitsetPostingsFormat,
+		},
+	}
+
+	e := newPostingsEncoder(true)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := e.Encode(test.pl, AutoPostingsFormat)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, PostingsFormat(encoded[0]))
+
+			for _, format := range []PostingsFormat{RoaringPostingsFormat,
+				DeltaVarintPostingsFormat, BitsetPostingsFormat} {
+				size := len(encoded)
+				other, err := newPostingsEncoder(true).Encode(test.pl, format)
+				require.NoError(t, err)
+				require.True(t, size <= len(other),
+					fmt.Sprintf("%s is %d bytes, auto is %d bytes", format, len(other), size))
+			}
+
+			pl, err := unmarshalPostings(encoded, true, roaring.NewPostingsList)
+			require.NoError(t, err)
+			require.True(t, test.pl.Equal(pl))
+		})
+	}
+}
+
+func TestPostingsEncoderUntypedIsRoaring(t *testing.T) {
+	pl := newTestDensePostingsList(100)
+
+	encoded, err := newPostingsEncoder(false).Encode(pl, BitsetPostingsFormat)
+	require.NoError(t, err)
+
+	decoded, err := unmarshalPostings(encoded, false, roaring.NewPostingsList)
+	require.NoError(t, err)
+	require.True(t, pl.Equal(decoded))
+}
+
+func TestUnmarshalPostingsInvalidFormat(t *testing.T) {
+	_, err := unmarshalPostings(nil, true, roaring.NewPostingsList)
+	require.Error(t, err)
+
+	_, err = unmarshalPostings([]byte{byte(AutoPostingsFormat)}, true, roaring.NewPostingsList)
+	require.Error(t, err)
+}
+
+func BenchmarkPostingsFormats(b *testing.B) {
+	lists := []struct {
+		name string
+		pl   postings.List
+	}{
+		{name: "sparse", pl: newTestSparsePostingsList(10000)},
+		{name: "dense", pl: newTestDensePostingsList(100000)},
+	}
+	for _, list := range lists {
+		for _, format := range ValidPostingsFormats() {
+			b.Run(fmt.Sprintf("%s/%s", list.name, format), func(b *testing.B) {
+				e := newPostingsEncoder(true)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					encoded, err := e.Encode(list.pl, format)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := unmarshalPostings(encoded, true, roaring.NewPostingsList); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// newTestSparsePostingsList returns a postings list with IDs far apart.
+func newTestSparsePostingsList(n int) postings.List {
+	pl := roaring.NewPostingsList()
+	for i := 0; i < n; i++ {
+		pl.Insert(postings.ID(i * 100003))
+	}
+	return pl
+}
+
+// newTestDensePostingsList returns a postings list with most IDs present.
+func newTestDensePostingsList(n int) postings.List {
+	pl := roaring.NewPostingsList()
+	for i := 0; i < n; i++ {
+		if i%7 != 0 {
+			pl.Insert(postings.ID(i))
+		}
+	}
+	return pl
+}
@@ -21,6 +21,7 @@
 package fs
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -34,7 +35,6 @@ import (
 	"github.com/m3db/m3/src/m3ninx/index/segment/fs/encoding"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fs/encoding/docs"
 	"github.com/m3db/m3/src/m3ninx/postings"
-	"github.com/m3db/m3/src/m3ninx/postings/pilosa"
 	"github.com/m3db/m3/src/m3ninx/postings/roaring"
 	"github.com/m3db/m3/src/m3ninx/x"
 	xerrors "github.com/m3db/m3x/errors"
@@ -116,7 +116,12 @@ func NewSegment(data SegmentData, opts NewSegmentOpts) (Segment, error) {
 		return nil, err
 	}
 
-	if metadata.PostingsFormat != fswriter.PostingsFormat_PILOSAV1_POSTINGS_FORMAT {
+	var typedPostings bool
+	switch metadata.PostingsFormat {
+	case fswriter.PostingsFormat_PILOSAV1_POSTINGS_FORMAT:
+	case fswriter.PostingsFormat_TYPEDV1_POSTINGS_FORMAT:
+		typedPostings = true
+	default:
 		return nil, fmt.Errorf("unsupported postings format: %v", metadata.PostingsFormat.String())
 	}
 
@@ -136,7 +141,7 @@ func NewSegment(data SegmentData, opts NewSegmentOpts) (Segment, error) {
 
 	docsDataReader := docs.NewDataReader(data.DocsData)
 
-	return &fsSegment{
+	segment := &fsSegment{
 		fieldsFST:       fieldsFST,
 		docsDataReader:  docsDataReader,
 		docsIndexReader: docsIndexReader,
@@ -144,9 +149,21 @@ func NewSegment(data SegmentData, opts NewSegmentOpts) (Segment, error) {
 		data:           data,
 		opts:           opts,
 		numDocs:        metadata.NumDocs,
+		typedPostings:  typedPostings,
 		startInclusive: startInclusive,
 		endExclusive:   endExclusive,
-	}, nil
+	}
+
+	// Load the terms FST of the metric name field once so exact matches of
+	// metric names do not need to look up the field first.
+	namesFST, _, err := segment.retrieveTermsFSTWithRLock(doc.MetricNameFieldName)
+	if err != nil {
+		fieldsFST.Close()
+		return nil, fmt.Errorf("unable to load metric names fst: %v", err)
+	}
+	segment.namesFST = namesFST
+
+	return segment, nil
 }
 
 type fsSegment struct {
@@ -154,6 +171,7 @@ type fsSegment struct {
 	closed bool
 
 	fieldsFST       *vellum.FST
+	namesFST        *vellum.FST
 	docsDataReader  *docs.DataReader
 	docsIndexReader *docs.IndexReader
 
@@ -161,6 +179,7 @@ type fsSegment struct {
 	opts NewSegmentOpts
 
 	numDocs        int64
+	typedPostings  bool
 	startInclusive postings.ID
 	endExclusive   postings.ID
 }
@@ -221,6 +240,9 @@ func (r *fsSegment) Close() error {
 	r.closed = true
 	var multiErr xerrors.MultiError
 	multiErr = multiErr.Add(r.fieldsFST.Close())
+	if r.namesFST != nil {
+		multiErr = multiErr.Add(r.namesFST.Close())
+	}
 	if r.data.Closer != nil {
 		multiErr = multiErr.Add(r.data.Closer.Close())
 	}
@@ -279,6 +301,10 @@ func (r *fsSegment) MatchTerm(field []byte, term []byte) (postings.List, error)
 		return nil, errReaderClosed
 	}
 
+	if bytes.Equal(field, doc.MetricNameFieldName) {
+		return r.matchMetricNameWithRLock(term)
+	}
+
 	termsFST, exists, err := r.retrieveTermsFSTWithRLock(field)
 	if err != nil {
 		return nil, err
@@ -314,6 +340,26 @@ func (r *fsSegment) MatchTerm(field []byte, term []byte) (postings.List, error)
 	return pl, nil
 }
 
+// matchMetricNameWithRLock returns the postings list of the documents with
+// the metric name using the metric names FST loaded with the segment.
+func (r *fsSegment) matchMetricNameWithRLock(name []byte) (postings.List, error) {
+	if r.namesFST == nil {
+		// i.e. no documents have a metric name
+		return r.opts.PostingsListPool.Get(), nil
+	}
+
+	postingsOffset, exists, err := r.namesFST.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return r.opts.PostingsListPool.Get(), nil
+	}
+
+	return r.retrievePostingsListWithRLock(postingsOffset)
+}
+
 func (r *fsSegment) MatchRegexp(field []byte, regexp []byte, compiled *regexp.Regexp) (postings.List, error) {
 	r.RLock()
 	defer r.RUnlock()
@@ -379,6 +425,70 @@ func (r *fsSegment) MatchRegexp(field []byte, regexp []byte, compiled *regexp.Re
 	return pl, nil
 }
 
+func (r *fsSegment) MatchNumericRange(field []byte, nr index.NumericRange) (postings.List, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if r.closed {
+		return nil, errReaderClosed
+	}
+
+	termsFST, exists, err := r.retrieveTermsFSTWithRLock(field)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		// i.e. we don't know anything about the field, so can early return an empty postings list
+		return r.opts.PostingsListPool.Get(), nil
+	}
+
+	// Terms are ordered lexicographically rather than numerically so every term
+	// for the field needs to be checked against the range.
+	var (
+		fstCloser     = x.NewSafeCloser(termsFST)
+		pl            = r.opts.PostingsListPool.Get()
+		iter, iterErr = termsFST.Iterator(minByteKey, maxByteKey)
+		iterCloser    = x.NewSafeCloser(iter)
+	)
+	defer func() {
+		iterCloser.Close()
+		fstCloser.Close()
+	}()
+
+	for {
+		if iterErr == vellum.ErrIteratorDone {
+			break
+		}
+
+		if iterErr != nil {
+			return nil, iterErr
+		}
+
+		term, postingsOffset := iter.Current()
+		if nr.ContainsTerm(term) {
+			nextPl, err := r.retrievePostingsListWithRLock(postingsOffset)
+			if err != nil {
+				return nil, err
+			}
+			if err := pl.Union(nextPl); err != nil {
+				return nil, err
+			}
+		}
+
+		iterErr = iter.Next()
+	}
+
+	if err := iterCloser.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := fstCloser.Close(); err != nil {
+		return nil, err
+	}
+
+	return pl, nil
+}
+
 func (r *fsSegment) MatchAll() (postings.MutableList, error) {
 	r.RLock()
 	defer r.RUnlock()
@@ -433,7 +543,7 @@ func (r *fsSegment) retrievePostingsListWithRLock(postingsOffset uint64) (postin
 		return nil, fmt.Errorf("unable to retrieve postings data: %v", err)
 	}
 
-	return pilosa.Unmarshal(postingsBytes, roaring.NewPostingsList)
+	return unmarshalPostings(postingsBytes, r.typedPostings, roaring.NewPostingsList)
 }
 
 func (r *fsSegment) allKeys(fst *vellum.FST) ([][]byte, error) {
@@ -561,6 +671,15 @@ func (sr *fsSegmentReader) MatchRegexp(field []byte, regexp []byte, compiled *re
 	return sr.fsSegment.MatchRegexp(field, regexp, compiled)
 }
 
+func (sr *fsSegmentReader) MatchNumericRange(field []byte, nr index.NumericRange) (postings.List, error) {
+	sr.RLock()
+	defer sr.RUnlock()
+	if sr.closed {
+		return nil, errReaderClosed
+	}
+	return sr.fsSegment.MatchNumericRange(field, nr)
+}
+
 func (sr *fsSegmentReader) MatchAll() (postings.MutableList, error) {
 	sr.RLock()
 	defer sr.RUnlock()
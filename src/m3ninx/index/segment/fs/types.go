@@ -33,8 +33,9 @@ const (
 	// MajorVersion is the currently supported MajorVersion.
 	MajorVersion = 1
 
-	// MinorVersion is the current MinorVersion.
-	MinorVersion = 0
+	// MinorVersion is the current MinorVersion, minor version 1 added the
+	// typed postings format.
+	MinorVersion = 1
 )
 
 // Segment represents a FST segment.
@@ -34,7 +34,6 @@ import (
 	"github.com/m3db/m3/src/m3ninx/index/segment/fs/encoding"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fs/encoding/docs"
 	"github.com/m3db/m3/src/m3ninx/postings"
-	"github.com/m3db/m3/src/m3ninx/postings/pilosa"
 	"github.com/m3db/m3/src/m3ninx/x"
 )
 
@@ -53,11 +52,12 @@ type writer struct {
 	segReader index.Reader
 
 	intEncoder      *encoding.Encoder
-	postingsEncoder *pilosa.Encoder
+	postingsEncoder *postingsEncoder
 	fstWriter       *fstWriter
 	docDataWriter   *docs.DataWriter
 	docIndexWriter  *docs.IndexWriter
 
+	opts                NewWriterOpts
 	metadata            []byte
 	docsDataFileWritten bool
 	postingsFileWritten bool
@@ -67,17 +67,32 @@ type writer struct {
 	docOffsets          []docOffset
 }
 
-// NewWriter returns a new writer.
+// NewWriter returns a new writer which encodes postings lists as roaring
+// bitmaps.
 func NewWriter() Writer {
+	return newWriter(NewWriterOpts{})
+}
+
+// NewWriterWithOpts returns a new writer which encodes postings lists with
+// the postings formats of the provided options.
+func NewWriterWithOpts(opts NewWriterOpts) (Writer, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return newWriter(opts), nil
+}
+
+func newWriter(opts NewWriterOpts) *writer {
 	return &writer{
 		intEncoder:      encoding.NewEncoder(defaultInitialIntEncoderSize),
-		postingsEncoder: pilosa.NewEncoder(),
+		postingsEncoder: newPostingsEncoder(opts.typed()),
 		fstWriter:       newFSTWriter(),
 		docDataWriter:   docs.NewDataWriter(nil),
 		docIndexWriter:  docs.NewIndexWriter(nil),
 		postingsOffsets: newPostingsOffsetsMap(defaultInitialPostingsOffsetsMapSize),
 		fstTermsOffsets: newFSTTermsOffsetsMap(defaultInitialFSTTermsOffsetsMapSize),
 		docOffsets:      make([]docOffset, 0, defaultInitialDocOffsetsSize),
+		opts:            opts,
 	}
 }
 
@@ -110,6 +125,9 @@ func (w *writer) Reset(s sgmt.MutableSegment) error {
 	numDocs := s.Size()
 	metadata := defaultV1Metadata()
 	metadata.NumDocs = numDocs
+	if w.opts.typed() {
+		metadata.PostingsFormat = fswriter.PostingsFormat_TYPEDV1_POSTINGS_FORMAT
+	}
 	metadataBytes, err := metadata.Marshal()
 	if err != nil {
 		return err
@@ -199,6 +217,9 @@ func (w *writer) WritePostingsOffsets(iow io.Writer) error {
 			return err
 		}
 
+		// retrieve the postings format of the current field
+		format := w.opts.fieldPostingsFormat(f)
+
 		// for each term corresponding to the current field
 		for _, t := range terms {
 			// retrieve the postings list for this (field, term) combination
@@ -209,7 +230,7 @@ func (w *writer) WritePostingsOffsets(iow io.Writer) error {
 
 			// serialize the postings list
 			w.postingsEncoder.Reset()
-			postingsBytes, err := w.postingsEncoder.Encode(pl)
+			postingsBytes, err := w.postingsEncoder.Encode(pl, format)
 			if err != nil {
 				return err
 			}
@@ -28,6 +28,7 @@ import (
 	"testing"
 
 	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index"
 	sgmt "github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
 	"github.com/m3db/m3/src/m3ninx/index/util"
@@ -241,6 +242,99 @@ func TestPostingsListEqualForMatchTerm(t *testing.T) {
 	}
 }
 
+func TestPostingsListEqualForPostingsFormats(t *testing.T) {
+	for _, format := range ValidPostingsFormats() {
+		for _, test := range testDocuments {
+			t.Run(fmt.Sprintf("%s/%s", format, test.name), func(t *testing.T) {
+				w, err := NewWriterWithOpts(NewWriterOpts{
+					PostingsFormat: format,
+					FieldPostingsFormats: map[string]PostingsFormat{
+						"fruit": BitsetPostingsFormat,
+					},
+				})
+				require.NoError(t, err)
+
+				memSeg := newTestMemSegment(t)
+				for _, d := range test.docs {
+					_, err := memSeg.Insert(d)
+					require.NoError(t, err)
+				}
+				fstSeg := newFSTSegmentWithWriter(t, memSeg, w)
+
+				memReader, err := memSeg.Reader()
+				require.NoError(t, err)
+				fstReader, err := fstSeg.Reader()
+				require.NoError(t, err)
+
+				memFields, err := memSeg.Fields()
+				require.NoError(t, err)
+
+				for _, f := range memFields {
+					memTerms, err := memSeg.Terms(f)
+					require.NoError(t, err)
+
+					for _, term := range memTerms {
+						memPl, err := memReader.MatchTerm(f, term)
+						require.NoError(t, err)
+						fstPl, err := fstReader.MatchTerm(f, term)
+						require.NoError(t, err)
+						require.True(t, memPl.Equal(fstPl),
+							fmt.Sprintf("%s:%s - [%v] != [%v]", string(f), string(term), pprintIter(memPl), pprintIter(fstPl)))
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPostingsListEqualForMatchMetricName(t *testing.T) {
+	docs := []doc.Document{
+		doc.Document{
+			Fields: []doc.Field{
+				doc.Field{Name: doc.MetricNameFieldName, Value: []byte("cpu")},
+				doc.Field{Name: []byte("host"), Value: []byte("a")},
+			},
+		},
+		doc.Document{
+			Fields: []doc.Field{
+				doc.Field{Name: doc.MetricNameFieldName, Value: []byte("cpu")},
+				doc.Field{Name: []byte("host"), Value: []byte("b")},
+			},
+		},
+		doc.Document{
+			Fields: []doc.Field{
+				doc.Field{Name: doc.MetricNameFieldName, Value: []byte("mem")},
+				doc.Field{Name: []byte("host"), Value: []byte("a")},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		name string
+		docs []doc.Document
+	}{
+		{name: "documents with metric names", docs: docs},
+		{name: "documents without metric names", docs: fewTestDocuments},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			memSeg, fstSeg := newTestSegments(t, test.docs)
+			memReader, err := memSeg.Reader()
+			require.NoError(t, err)
+			fstReader, err := fstSeg.Reader()
+			require.NoError(t, err)
+
+			for _, name := range []string{"cpu", "mem", "disk"} {
+				memPl, err := memReader.MatchTerm(doc.MetricNameFieldName, []byte(name))
+				require.NoError(t, err)
+				fstPl, err := fstReader.MatchTerm(doc.MetricNameFieldName, []byte(name))
+				require.NoError(t, err)
+				require.True(t, memPl.Equal(fstPl),
+					fmt.Sprintf("%s - [%v] != [%v]", name, pprintIter(memPl), pprintIter(fstPl)))
+			}
+		})
+	}
+}
+
 func TestPostingsListContainsID(t *testing.T) {
 	for _, test := range testDocuments {
 		t.Run(test.name, func(t *testing.T) {
@@ -278,6 +372,65 @@ func TestPostingsListRegexAll(t *testing.T) {
 	}
 }
 
+func TestPostingsListNumericRange(t *testing.T) {
+	var docs []doc.Document
+	for _, port := range []string{"80", "443", "8000", "8080", "9000", "not-a-port"} {
+		docs = append(docs, doc.Document{
+			ID: []byte("instance-" + port),
+			Fields: []doc.Field{
+				doc.Field{
+					Name:  []byte("port"),
+					Value: []byte(port),
+				},
+			},
+		})
+	}
+
+	memSeg, fstSeg := newTestSegments(t, docs)
+	memReader, err := memSeg.Reader()
+	require.NoError(t, err)
+	fstReader, err := fstSeg.Reader()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		r        index.NumericRange
+		expected int
+	}{
+		{
+			name:     "greater than or equal",
+			r:        index.NewNumericRangeGreaterThan(8000, true),
+			expected: 3,
+		},
+		{
+			name:     "greater than",
+			r:        index.NewNumericRangeGreaterThan(8000, false),
+			expected: 2,
+		},
+		{
+			name:     "less than",
+			r:        index.NewNumericRangeLessThan(443, false),
+			expected: 1,
+		},
+		{
+			name:     "bounded",
+			r:        index.NumericRange{Min: 443, Max: 8080},
+			expected: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			memPl, err := memReader.MatchNumericRange([]byte("port"), test.r)
+			require.NoError(t, err)
+			fstPl, err := fstReader.MatchNumericRange([]byte("port"), test.r)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, memPl.Len())
+			require.True(t, memPl.Equal(fstPl))
+		})
+	}
+}
+
 func TestSegmentDocs(t *testing.T) {
 	for _, test := range testDocuments {
 		t.Run(test.name, func(t *testing.T) {
@@ -372,10 +525,13 @@ func newTestMemSegment(t *testing.T) sgmt.MutableSegment {
 }
 
 func newFSTSegment(t *testing.T, s sgmt.MutableSegment) sgmt.Segment {
+	return newFSTSegmentWithWriter(t, s, NewWriter())
+}
+
+func newFSTSegmentWithWriter(t *testing.T, s sgmt.MutableSegment, w Writer) sgmt.Segment {
 	_, err := s.Seal()
 	require.NoError(t, err)
 
-	w := NewWriter()
 	require.NoError(t, w.Reset(s))
 
 	var (
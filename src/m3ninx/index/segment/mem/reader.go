@@ -87,6 +87,19 @@ func (r *reader) MatchRegexp(field, regexp []byte, compiled *regexp.Regexp) (pos
 	return pl, err
 }
 
+func (r *reader) MatchNumericRange(field []byte, nr index.NumericRange) (postings.List, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if r.closed {
+		return nil, errSegmentReaderClosed
+	}
+
+	// As with the other match methods, the postings list may contain IDs beyond
+	// the reader's limits which are filtered out when retrieving documents.
+	pl, err := r.segment.matchNumericRange(field, nr)
+	return pl, err
+}
+
 func (r *reader) MatchAll() (postings.MutableList, error) {
 	r.RLock()
 	defer r.RUnlock()
@@ -340,6 +340,16 @@ func (s *segment) matchRegexp(name, regexp []byte, compiled *re.Regexp) (posting
 	return s.termsDict.MatchRegexp(name, regexp, compiled), nil
 }
 
+func (s *segment) matchNumericRange(field []byte, r index.NumericRange) (postings.List, error) {
+	s.state.RLock()
+	defer s.state.RUnlock()
+	if s.state.closed {
+		return nil, sgmt.ErrClosed
+	}
+
+	return s.termsDict.MatchNumericRange(field, r), nil
+}
+
 func (s *segment) getDoc(id postings.ID) (doc.Document, error) {
 	s.state.RLock()
 	defer s.state.RUnlock()
@@ -21,10 +21,12 @@
 package mem
 
 import (
+	"bytes"
 	re "regexp"
 	"sync"
 
 	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/postings"
 )
 
@@ -36,11 +38,18 @@ type termsDict struct {
 		sync.RWMutex
 		*fieldsMap
 	}
+
+	// names is the dictionary of the metric name field, it is also added to
+	// the fields once the first metric name is inserted. Exact matches of
+	// metric names are looked up directly in it without looking up the
+	// field first.
+	names *concurrentPostingsMap
 }
 
 func newTermsDict(opts Options) termsDictionary {
 	dict := &termsDict{
-		opts: opts,
+		opts:  opts,
+		names: newConcurrentPostingsMap(opts),
 	}
 	dict.fields.fieldsMap = newFieldsMap(opts.InitialCapacity())
 	return dict
@@ -85,6 +94,10 @@ func (d *termsDict) Terms(field []byte) [][]byte {
 }
 
 func (d *termsDict) matchTerm(field, term []byte) (postings.List, bool) {
+	if bytes.Equal(field, doc.MetricNameFieldName) {
+		return d.names.Get(term)
+	}
+
 	d.fields.RLock()
 	postingsMap, ok := d.fields.Get(field)
 	d.fields.RUnlock()
@@ -115,6 +128,20 @@ func (d *termsDict) MatchRegexp(
 	return pl
 }
 
+func (d *termsDict) MatchNumericRange(field []byte, r index.NumericRange) postings.List {
+	d.fields.RLock()
+	postingsMap, ok := d.fields.Get(field)
+	d.fields.RUnlock()
+	if !ok {
+		return d.opts.PostingsListPool().Get()
+	}
+	pl, ok := postingsMap.GetNumericRange(r)
+	if !ok {
+		return d.opts.PostingsListPool().Get()
+	}
+	return pl
+}
+
 func (d *termsDict) getOrAddName(name []byte) *concurrentPostingsMap {
 	// Cheap read lock to see if it already exists.
 	d.fields.RLock()
@@ -134,7 +161,11 @@ func (d *termsDict) getOrAddName(name []byte) *concurrentPostingsMap {
 		return postingsMap
 	}
 
-	postingsMap = newConcurrentPostingsMap(d.opts)
+	if bytes.Equal(name, doc.MetricNameFieldName) {
+		postingsMap = d.names
+	} else {
+		postingsMap = newConcurrentPostingsMap(d.opts)
+	}
 	d.fields.SetUnsafe(name, postingsMap, fieldsMapSetUnsafeOptions{
 		NoCopyKey:     true,
 		NoFinalizeKey: true,
@@ -28,10 +28,12 @@ import (
 
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/postings/roaring"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -280,6 +282,26 @@ func (t *termsDictionaryTestSuite) TestMatchRegexNoResults() {
 	props.TestingRun(t.T())
 }
 
+func TestTermsDictionaryMetricNames(t *testing.T) {
+	dict := newTermsDict(NewOptions()).(*termsDict)
+	require.Empty(t, dict.Fields())
+	require.False(t, dict.ContainsTerm(doc.MetricNameFieldName, []byte("cpu")))
+
+	dict.Insert(doc.Field{Name: doc.MetricNameFieldName, Value: []byte("cpu")}, 1)
+	dict.Insert(doc.Field{Name: doc.MetricNameFieldName, Value: []byte("cpu")}, 2)
+	dict.Insert(doc.Field{Name: doc.MetricNameFieldName, Value: []byte("mem")}, 3)
+
+	// The metric names dictionary is also a field of the terms dictionary.
+	require.Equal(t, [][]byte{doc.MetricNameFieldName}, dict.Fields())
+	require.Len(t, dict.Terms(doc.MetricNameFieldName), 2)
+
+	expected := roaring.NewPostingsList()
+	expected.Insert(1)
+	expected.Insert(2)
+	require.True(t, dict.MatchTerm(doc.MetricNameFieldName, []byte("cpu")).Equal(expected))
+	require.True(t, dict.MatchTerm(doc.MetricNameFieldName, []byte("disk")).IsEmpty())
+}
+
 func TestTermsDictionary(t *testing.T) {
 	opts := NewOptions()
 	suite.Run(t, &termsDictionaryTestSuite{
@@ -24,6 +24,7 @@ import (
 	re "regexp"
 
 	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/postings"
 )
 
@@ -44,6 +45,10 @@ type termsDictionary interface {
 	// given egular expression.
 	MatchRegexp(field, regexp []byte, compiled *re.Regexp) postings.List
 
+	// MatchNumericRange returns the postings list corresponding to documents whose
+	// value for the given field is a number within the given range.
+	MatchNumericRange(field []byte, r index.NumericRange) postings.List
+
 	// Fields returns the list of known fields.
 	Fields() [][]byte
 
@@ -64,6 +69,10 @@ type ReadableSegment interface {
 	// matchRegexp returns the postings list of documents which match the given regular expression.
 	matchRegexp(name, regexp []byte, compiled *re.Regexp) (postings.List, error)
 
+	// matchNumericRange returns the postings list of documents whose value for the given
+	// field is a number within the given range.
+	matchNumericRange(field []byte, r index.NumericRange) (postings.List, error)
+
 	// getDoc returns the document associated with the given ID.
 	getDoc(id postings.ID) (doc.Document, error)
 }
@@ -68,6 +68,10 @@ type Readable interface {
 	// regular expression.
 	MatchRegexp(field, regexp []byte, compiled *regexp.Regexp) (postings.List, error)
 
+	// MatchNumericRange returns a postings list over all documents whose value for the
+	// given field is a number within the given range.
+	MatchNumericRange(field []byte, r NumericRange) (postings.List, error)
+
 	// MatchAll returns a postings list for all documents known to the Reader.
 	MatchAll() (postings.MutableList, error)
 
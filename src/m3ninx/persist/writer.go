@@ -34,6 +34,18 @@ func NewMutableSegmentFileSetWriter() (MutableSegmentFileSetWriter, error) {
 	return newMutableSegmentFileSetWriter(fs.NewWriter())
 }
 
+// NewMutableSegmentFileSetWriterWithOpts returns a new IndexSegmentFileSetWriter
+// for writing out the provided Mutable Segment with the provided fs writer options.
+func NewMutableSegmentFileSetWriterWithOpts(
+	opts fs.NewWriterOpts,
+) (MutableSegmentFileSetWriter, error) {
+	fsWriter, err := fs.NewWriterWithOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	return newMutableSegmentFileSetWriter(fsWriter)
+}
+
 func newMutableSegmentFileSetWriter(fsWriter fs.Writer) (MutableSegmentFileSetWriter, error) {
 	return &writer{
 		fsWriter: fsWriter,
@@ -57,7 +69,7 @@ func (w *writer) MajorVersion() int {
 }
 
 func (w *writer) MinorVersion() int {
-	return fs.MinorVersion
+	return w.fsWriter.MinorVersion()
 }
 
 func (w *writer) SegmentMetadata() []byte {
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bitset
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/m3db/m3/src/m3ninx/postings"
+)
+
+var (
+	errInvalidLength   = errors.New("bitset length exceeds encoded data")
+	errUnexpectedEnd   = errors.New("unexpected end of postings data")
+	errUvarintOverflow = errors.New("uvarint overflows 64 bits")
+	errMaxIDExceeded   = errors.New("bitset exceeds max postings ID")
+)
+
+// Encoder serializes a postings list as the minimum ID followed by a bitset
+// with a bit set for each ID offset from the minimum ID. It is compact for
+// dense postings lists where most IDs between the min and max are present.
+type Encoder struct {
+	buf []byte
+	tmp [binary.MaxVarintLen64]byte
+}
+
+// NewEncoder returns a new Encoder.
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// Reset resets the internal state of the encoder to allow
+// for re-use.
+func (e *Encoder) Reset() {
+	e.buf = e.buf[:0]
+}
+
+// Encode encodes the provided postings list in serialized form.
+// The bytes returned are invalidate on a subsequent call to Encode(),
+// or Reset().
+func (e *Encoder) Encode(pl postings.List) ([]byte, error) {
+	e.buf = e.buf[:0]
+	if pl.IsEmpty() {
+		e.putUvarint(0)
+		e.putUvarint(0)
+		return e.buf, nil
+	}
+
+	min, err := pl.Min()
+	if err != nil {
+		return nil, err
+	}
+	max, err := pl.Max()
+	if err != nil {
+		return nil, err
+	}
+
+	numBytes := int(max-min)/8 + 1
+	e.putUvarint(uint64(min))
+	e.putUvarint(uint64(numBytes))
+
+	start := len(e.buf)
+	if cap(e.buf)-start < numBytes {
+		buf := make([]byte, start, start+numBytes)
+		copy(buf, e.buf)
+		e.buf = buf
+	}
+	e.buf = e.buf[:start+numBytes]
+	bits := e.buf[start:]
+	for i := range bits {
+		bits[i] = 0
+	}
+
+	iter := pl.Iterator()
+	for iter.Next() {
+		offset := iter.Current() - min
+		bits[offset/8] |= 1 << (offset % 8)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+func (e *Encoder) putUvarint(x uint64) {
+	n := binary.PutUvarint(e.tmp[:], x)
+	e.buf = append(e.buf, e.tmp[:n]...)
+}
+
+// Unmarshal unmarshals the provided bytes into a postings.List.
+func Unmarshal(data []byte, allocFn postings.PoolAllocateFn) (postings.List, error) {
+	min, data, err := uvarint(data)
+	if err != nil {
+		return nil, err
+	}
+	numBytes, data, err := uvarint(data)
+	if err != nil {
+		return nil, err
+	}
+	if numBytes > uint64(len(data)) {
+		return nil, errInvalidLength
+	}
+
+	pl := allocFn()
+	for i, b := range data[:numBytes] {
+		for j := uint64(0); b != 0; j++ {
+			if b&1 != 0 {
+				id := min + uint64(i)*8 + j
+				if id > uint64(postings.MaxID) {
+					return nil, errMaxIDExceeded
+				}
+				pl.Insert(postings.ID(id))
+			}
+			b >>= 1
+		}
+	}
+
+	return pl, nil
+}
+
+func uvarint(data []byte) (uint64, []byte, error) {
+	x, n := binary.Uvarint(data)
+	if n == 0 {
+		return 0, nil, errUnexpectedEnd
+	}
+	if n < 0 {
+		return 0, nil, errUvarintOverflow
+	}
+	return x, data[n:], nil
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package varint
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/m3ninx/postings"
+)
+
+var (
+	errInvalidLength   = errors.New("postings list length exceeds encoded data")
+	errUnexpectedEnd   = errors.New("unexpected end of postings data")
+	errUvarintOverflow = errors.New("uvarint overflows 64 bits")
+)
+
+// Encoder serializes a postings list as the number of IDs followed by the
+// delta of each ID from the previous ID, each encoded as a uvarint. It is
+// compact for sparse postings lists where the IDs are far apart.
+type Encoder struct {
+	buf []byte
+	tmp [binary.MaxVarintLen64]byte
+}
+
+// NewEncoder returns a new Encoder.
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// Reset resets the internal state of the encoder to allow
+// for re-use.
+func (e *Encoder) Reset() {
+	e.buf = e.buf[:0]
+}
+
+// Encode encodes the provided postings list in serialized form.
+// The bytes returned are invalidate on a subsequent call to Encode(),
+// or Reset().
+func (e *Encoder) Encode(pl postings.List) ([]byte, error) {
+	e.buf = e.buf[:0]
+	e.putUvarint(uint64(pl.Len()))
+
+	var (
+		iter = pl.Iterator()
+		prev postings.ID
+	)
+	for iter.Next() {
+		curr := iter.Current()
+		e.putUvarint(uint64(curr - prev))
+		prev = curr
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+func (e *Encoder) putUvarint(x uint64) {
+	n := binary.PutUvarint(e.tmp[:], x)
+	e.buf = append(e.buf, e.tmp[:n]...)
+}
+
+// Unmarshal unmarshals the provided bytes into a postings.List.
+func Unmarshal(data []byte, allocFn postings.PoolAllocateFn) (postings.List, error) {
+	n, data, err := uvarint(data)
+	if err != nil {
+		return nil, err
+	}
+	// NB: Each ID takes at least a byte, bound the length by the data
+	// so that corrupt data does not cause an unbounded loop.
+	if n > uint64(len(data)) {
+		return nil, errInvalidLength
+	}
+
+	var (
+		pl   = allocFn()
+		curr uint64
+	)
+	for i := uint64(0); i < n; i++ {
+		var delta uint64
+		delta, data, err = uvarint(data)
+		if err != nil {
+			return nil, err
+		}
+		curr += delta
+		if curr > uint64(postings.MaxID) {
+			return nil, fmt.Errorf("postings ID %d exceeds max ID", curr)
+		}
+		pl.Insert(postings.ID(curr))
+	}
+
+	return pl, nil
+}
+
+func uvarint(data []byte) (uint64, []byte, error) {
+	x, n := binary.Uvarint(data)
+	if n == 0 {
+		return 0, nil, errUnexpectedEnd
+	}
+	if n < 0 {
+		return 0, nil, errUvarintOverflow
+	}
+	return x, data[n:], nil
+}
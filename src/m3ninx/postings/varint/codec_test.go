@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package varint
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/postings/roaring"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	sparse := roaring.NewPostingsList()
+	for _, id := range []postings.ID{3, 17, 1 << 10, 1 << 20, postings.MaxID} {
+		sparse.Insert(id)
+	}
+	dense := roaring.NewPostingsList()
+	dense.AddRange(postings.ID(1), postings.ID(1000))
+	dense.RemoveRange(postings.ID(500), postings.ID(510))
+
+	e := NewEncoder()
+	for _, pl := range []postings.List{roaring.NewPostingsList(), sparse, dense} {
+		bytes, err := e.Encode(pl)
+		require.NoError(t, err)
+
+		unmarshaled, err := Unmarshal(bytes, roaring.NewPostingsList)
+		require.NoError(t, err)
+		require.True(t, pl.Equal(unmarshaled))
+	}
+}
+
+func TestUnmarshalTruncated(t *testing.T) {
+	pl := roaring.NewPostingsList()
+	pl.AddRange(postings.ID(100), postings.ID(200))
+
+	bytes, err := NewEncoder().Encode(pl)
+	require.NoError(t, err)
+
+	_, err = Unmarshal(bytes[:len(bytes)/2], roaring.NewPostingsList)
+	require.Error(t, err)
+}
@@ -25,6 +25,7 @@ import (
 	"fmt"
 
 	"github.com/m3db/m3/src/m3ninx/generated/proto/querypb"
+	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/search"
 )
 
@@ -57,6 +58,14 @@ func unmarshal(q *querypb.Query) (search.Query, error) {
 	case *querypb.Query_Regexp:
 		return NewRegexpQuery(q.Regexp.Field, q.Regexp.Regexp)
 
+	case *querypb.Query_Range:
+		return NewNumericRangeQuery(q.Range.Field, index.NumericRange{
+			Min:          q.Range.Min,
+			Max:          q.Range.Max,
+			MinExclusive: q.Range.MinExclusive,
+			MaxExclusive: q.Range.MaxExclusive,
+		}), nil
+
 	case *querypb.Query_Negation:
 		inner, err := unmarshal(q.Negation.Query)
 		if err != nil {
@@ -23,6 +23,7 @@ package query
 import (
 	"testing"
 
+	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/search"
 	"github.com/stretchr/testify/require"
 )
@@ -40,6 +41,11 @@ func TestMarshal(t *testing.T) {
 			name:  "regexp query",
 			query: MustCreateRegexpQuery([]byte("fruit"), []byte(".*ple")),
 		},
+		{
+			name: "numeric range query",
+			query: NewNumericRangeQuery([]byte("port"),
+				index.NewNumericRangeGreaterThan(8000, true)),
+		},
 		{
 			name:  "negation query",
 			query: NewNegationQuery(NewTermQuery([]byte("fruit"), []byte("apple"))),
@@ -45,6 +45,7 @@ func NewConjunctionQuery(queries []search.Query) search.Query {
 		case *ConjuctionQuery:
 			// Merge conjunction queries into slice of top-level queries.
 			qs = append(qs, query.queries...)
+			ns = append(ns, query.negations...)
 			continue
 		case *NegationQuery:
 			ns = append(ns, query.query)
@@ -76,22 +77,59 @@ func (q *ConjuctionQuery) Searcher(rs index.Readers) (search.Searcher, error) {
 		return q.queries[0].Searcher(rs)
 	}
 
-	qsrs := make(search.Searchers, 0, len(q.queries))
-	for _, q := range q.queries {
+	// Negation queries kept as top-level queries are searched as negations
+	// so their complement is only ever taken against the other queries.
+	queries := make([]search.Query, 0, len(q.queries))
+	negations := make([]search.Query, 0, len(q.queries)+len(q.negations))
+	for _, query := range q.queries {
+		if neg, ok := query.(*NegationQuery); ok {
+			negations = append(negations, neg.query)
+			continue
+		}
+		queries = append(queries, query)
+	}
+	negations = append(negations, q.negations...)
+
+	// Resolve an exact match of the metric name first, it is looked up
+	// directly in the metric names dictionary of segments and the other
+	// queries are intersected with the usually small set of documents of
+	// the metric.
+	for i, query := range queries {
+		if isMetricNameTermQuery(query) {
+			copy(queries[1:i+1], queries[:i])
+			queries[0] = query
+			break
+		}
+	}
+
+	nsrs := make(search.Searchers, 0, len(negations))
+	for _, q := range negations {
 		sr, err := q.Searcher(rs)
 		if err != nil {
 			return nil, err
 		}
-		qsrs = append(qsrs, sr)
+		nsrs = append(nsrs, sr)
 	}
 
-	nsrs := make(search.Searchers, 0, len(q.negations))
-	for _, q := range q.negations {
+	if len(queries) == 0 {
+		// A conjunction of only negations matches all documents which match
+		// none of the negated queries, including documents without the fields
+		// of the negated queries. Rather than taking the complement of each
+		// negated query, take the complement of their union once per segment.
+		sr, err := searcher.NewDisjunctionSearcher(len(rs), nsrs)
+		if err != nil {
+			return nil, err
+		}
+		return searcher.NewNegationSearcher(rs, sr)
+	}
+
+	qsrs := make(search.Searchers, 0, len(queries))
+	for _, q := range queries {
 		sr, err := q.Searcher(rs)
 		if err != nil {
 			return nil, err
 		}
-		nsrs = append(nsrs, sr)
+		qsrs = append(qsrs, sr)
 	}
 
 	return searcher.NewConjunctionSearcher(len(rs), qsrs, nsrs)
@@ -152,5 +190,8 @@ func (q *ConjuctionQuery) ToProto() *querypb.Query {
 }
 
 func (q *ConjuctionQuery) String() string {
-	return fmt.Sprintf("conjunction(%s)", join(q.queries))
+	if len(q.negations) == 0 {
+		return fmt.Sprintf("conjunction(%s)", join(q.queries))
+	}
+	return fmt.Sprintf("conjunction(%s, %s)", join(q.queries), joinNegation(q.negations))
 }
@@ -24,8 +24,11 @@ import (
 	"testing"
 
 	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/postings/roaring"
 	"github.com/m3db/m3/src/m3ninx/search"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -64,6 +67,13 @@ func TestConjunctionQuery(t *testing.T) {
 				NewNegationQuery(NewTermQuery([]byte("fruit"), []byte("banana"))),
 			},
 		},
+		{
+			name: "multiple negation queries",
+			queries: []search.Query{
+				NewNegationQuery(NewTermQuery([]byte("fruit"), []byte("banana"))),
+				NewNegationQuery(NewTermQuery([]byte("fruit"), []byte("apple"))),
+			},
+		},
 	}
 
 	rs := index.Readers{}
@@ -76,6 +86,80 @@ func TestConjunctionQuery(t *testing.T) {
 	}
 }
 
+func TestConjunctionQueryOnlyNegations(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	allPL := roaring.NewPostingsList()
+	allPL.AddRange(postings.ID(0), postings.ID(6))
+	bananaPL := roaring.NewPostingsList()
+	bananaPL.Insert(postings.ID(1))
+	applePL := roaring.NewPostingsList()
+	applePL.Insert(postings.ID(2))
+	applePL.Insert(postings.ID(3))
+
+	reader := index.NewMockReader(mockCtrl)
+	reader.EXPECT().MatchTerm([]byte("fruit"), []byte("banana")).Return(bananaPL, nil)
+	reader.EXPECT().MatchTerm([]byte("fruit"), []byte("apple")).Return(applePL, nil)
+	reader.EXPECT().MatchAll().Return(allPL, nil).Times(1)
+
+	q := NewConjunctionQuery([]search.Query{
+		NewNegationQuery(NewTermQuery([]byte("fruit"), []byte("banana"))),
+		NewNegationQuery(NewTermQuery([]byte("fruit"), []byte("apple"))),
+	})
+	require.Equal(t,
+		"conjunction(negation(term(fruit, banana)), negation(term(fruit, apple)))",
+		q.String())
+
+	s, err := q.Searcher(index.Readers{reader})
+	require.NoError(t, err)
+	require.True(t, s.Next())
+
+	expected := roaring.NewPostingsList()
+	expected.Insert(postings.ID(0))
+	expected.Insert(postings.ID(4))
+	expected.Insert(postings.ID(5))
+	require.True(t, s.Current().Equal(expected))
+
+	require.False(t, s.Next())
+	require.NoError(t, s.Err())
+}
+
+func TestConjunctionQueryMatchesMetricNameFirst(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	namePL := roaring.NewPostingsList()
+	namePL.Insert(postings.ID(1))
+	namePL.Insert(postings.ID(2))
+	hostPL := roaring.NewPostingsList()
+	hostPL.Insert(postings.ID(2))
+	hostPL.Insert(postings.ID(3))
+
+	reader := index.NewMockReader(mockCtrl)
+	gomock.InOrder(
+		reader.EXPECT().MatchTerm([]byte("__name__"), []byte("cpu")).Return(namePL, nil),
+		reader.EXPECT().MatchTerm([]byte("host"), []byte("a")).Return(hostPL, nil),
+	)
+
+	q := NewConjunctionQuery([]search.Query{
+		NewTermQuery([]byte("host"), []byte("a")),
+		NewTermQuery([]byte("__name__"), []byte("cpu")),
+	})
+	require.Equal(t, "conjunction(term(host, a), term(__name__, cpu))", q.String())
+
+	s, err := q.Searcher(index.Readers{reader})
+	require.NoError(t, err)
+	require.True(t, s.Next())
+
+	expected := roaring.NewPostingsList()
+	expected.Insert(postings.ID(2))
+	require.True(t, s.Current().Equal(expected))
+
+	require.False(t, s.Next())
+	require.NoError(t, s.Err())
+}
+
 func TestConjunctionQueryEqual(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package query
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/search"
+)
+
+// DocumentMatcher evaluates a query against a single document without
+// requiring the document to be indexed.
+type DocumentMatcher interface {
+	// Matches returns whether the document matches the query.
+	Matches(d doc.Document) bool
+}
+
+type documentMatcherFn func(d doc.Document) bool
+
+func (fn documentMatcherFn) Matches(d doc.Document) bool {
+	return fn(d)
+}
+
+// NewDocumentMatcher returns a matcher for the given query, it returns an
+// error if the query contains a query type that cannot be evaluated against
+// a single document.
+func NewDocumentMatcher(q search.Query) (DocumentMatcher, error) {
+	switch q := q.(type) {
+	case *TermQuery:
+		return documentMatcherFn(func(d doc.Document) bool {
+			return anyFieldValue(d, q.field, func(v []byte) bool {
+				return bytes.Equal(v, q.term)
+			})
+		}), nil
+
+	case *RegexpQuery:
+		return documentMatcherFn(func(d doc.Document) bool {
+			return anyFieldValue(d, q.field, q.compiled.Match)
+		}), nil
+
+	case *NumericRangeQuery:
+		return documentMatcherFn(func(d doc.Document) bool {
+			return anyFieldValue(d, q.field, q.r.ContainsTerm)
+		}), nil
+
+	case *NegationQuery:
+		m, err := NewDocumentMatcher(q.query)
+		if err != nil {
+			return nil, err
+		}
+		return documentMatcherFn(func(d doc.Document) bool {
+			return !m.Matches(d)
+		}), nil
+
+	case *ConjuctionQuery:
+		matchers, err := newDocumentMatchers(q.queries)
+		if err != nil {
+			return nil, err
+		}
+		negations, err := newDocumentMatchers(q.negations)
+		if err != nil {
+			return nil, err
+		}
+		return documentMatcherFn(func(d doc.Document) bool {
+			for _, m := range matchers {
+				if !m.Matches(d) {
+					return false
+				}
+			}
+			for _, m := range negations {
+				if m.Matches(d) {
+					return false
+				}
+			}
+			return true
+		}), nil
+
+	case *DisjuctionQuery:
+		matchers, err := newDocumentMatchers(q.queries)
+		if err != nil {
+			return nil, err
+		}
+		return documentMatcherFn(func(d doc.Document) bool {
+			for _, m := range matchers {
+				if m.Matches(d) {
+					return true
+				}
+			}
+			return false
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unable to match documents against query: %v", q)
+}
+
+func newDocumentMatchers(qs []search.Query) ([]DocumentMatcher, error) {
+	matchers := make([]DocumentMatcher, 0, len(qs))
+	for _, q := range qs {
+		m, err := NewDocumentMatcher(q)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+func anyFieldValue(d doc.Document, name []byte, fn func(v []byte) bool) bool {
+	for _, f := range d.Fields {
+		if bytes.Equal(f.Name, name) && fn(f.Value) {
+			return true
+		}
+	}
+	return false
+}
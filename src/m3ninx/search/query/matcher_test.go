@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/search"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentMatcher(t *testing.T) {
+	d := doc.Document{
+		ID: []byte("apple"),
+		Fields: []doc.Field{
+			{Name: []byte("fruit"), Value: []byte("apple")},
+			{Name: []byte("color"), Value: []byte("red")},
+			{Name: []byte("weight"), Value: []byte("150")},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		query   search.Query
+		matches bool
+	}{
+		{
+			name:    "term",
+			query:   NewTermQuery([]byte("fruit"), []byte("apple")),
+			matches: true,
+		},
+		{
+			name:    "term mismatch",
+			query:   NewTermQuery([]byte("fruit"), []byte("banana")),
+			matches: false,
+		},
+		{
+			name:    "regexp",
+			query:   MustCreateRegexpQuery([]byte("color"), []byte("r.*")),
+			matches: true,
+		},
+		{
+			name:    "numeric range",
+			query:   NewNumericRangeQuery([]byte("weight"), index.NewNumericRangeGreaterThan(100, false)),
+			matches: true,
+		},
+		{
+			name:    "negation",
+			query:   NewNegationQuery(NewTermQuery([]byte("color"), []byte("red"))),
+			matches: false,
+		},
+		{
+			name: "conjunction with negation",
+			query: NewConjunctionQuery([]search.Query{
+				NewTermQuery([]byte("fruit"), []byte("apple")),
+				NewNegationQuery(NewTermQuery([]byte("color"), []byte("green"))),
+			}),
+			matches: true,
+		},
+		{
+			name: "disjunction",
+			query: NewDisjunctionQuery([]search.Query{
+				NewTermQuery([]byte("fruit"), []byte("banana")),
+				NewTermQuery([]byte("color"), []byte("red")),
+			}),
+			matches: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := NewDocumentMatcher(test.query)
+			require.NoError(t, err)
+			require.Equal(t, test.matches, m.Matches(d))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package query
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/m3db/m3/src/m3ninx/generated/proto/querypb"
+	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/search"
+	"github.com/m3db/m3/src/m3ninx/search/searcher"
+)
+
+// NumericRangeQuery finds documents whose value for a field is a number within a range.
+type NumericRangeQuery struct {
+	field []byte
+	r     index.NumericRange
+}
+
+// NewNumericRangeQuery constructs a new NumericRangeQuery for the given field and range.
+func NewNumericRangeQuery(field []byte, r index.NumericRange) search.Query {
+	return &NumericRangeQuery{
+		field: field,
+		r:     r,
+	}
+}
+
+// Searcher returns a searcher over the provided readers.
+func (q *NumericRangeQuery) Searcher(rs index.Readers) (search.Searcher, error) {
+	return searcher.NewNumericRangeSearcher(rs, q.field, q.r), nil
+}
+
+// Equal reports whether q is equivalent to o.
+func (q *NumericRangeQuery) Equal(o search.Query) bool {
+	o, ok := singular(o)
+	if !ok {
+		return false
+	}
+
+	inner, ok := o.(*NumericRangeQuery)
+	if !ok {
+		return false
+	}
+
+	return bytes.Equal(q.field, inner.field) && q.r == inner.r
+}
+
+// ToProto returns the Protobuf query struct corresponding to the numeric range query.
+func (q *NumericRangeQuery) ToProto() *querypb.Query {
+	r := querypb.RangeQuery{
+		Field:        q.field,
+		Min:          q.r.Min,
+		Max:          q.r.Max,
+		MinExclusive: q.r.MinExclusive,
+		MaxExclusive: q.r.MaxExclusive,
+	}
+
+	return &querypb.Query{
+		Query: &querypb.Query_Range{Range: &r},
+	}
+}
+
+func (q *NumericRangeQuery) String() string {
+	return fmt.Sprintf("range(%s, %s)", q.field, q.r)
+}
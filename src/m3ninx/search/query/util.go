@@ -23,6 +23,7 @@ package query
 import (
 	"bytes"
 
+	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/search"
 )
 
@@ -31,7 +32,7 @@ import (
 func singular(q search.Query) (search.Query, bool) {
 	switch q := q.(type) {
 	case *ConjuctionQuery:
-		if len(q.queries) == 1 {
+		if len(q.queries) == 1 && len(q.negations) == 0 {
 			return q.queries[0], true
 		}
 		return nil, false
@@ -44,6 +45,13 @@ func singular(q search.Query) (search.Query, bool) {
 	return q, true
 }
 
+// isMetricNameTermQuery returns a bool indicating whether a given query is an exact
+// match of the metric name.
+func isMetricNameTermQuery(q search.Query) bool {
+	term, ok := q.(*TermQuery)
+	return ok && bytes.Equal(term.field, doc.MetricNameFieldName)
+}
+
 // join concatenates a slice of queries.
 func join(qs []search.Query) string {
 	switch len(qs) {
@@ -62,3 +70,18 @@ func join(qs []search.Query) string {
 
 	return b.String()
 }
+
+// joinNegation concatenates a slice of negated queries.
+func joinNegation(qs []search.Query) string {
+	var b bytes.Buffer
+	for i, q := range qs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("negation(")
+		b.WriteString(q.String())
+		b.WriteString(")")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/backup"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	backupIDVar = "id"
+
+	defaultCutDelay = 10 * time.Second
+	defaultTimeout  = 5 * time.Minute
+
+	// StartURL is the url for the backup start handler.
+	StartURL = handler.RoutePrefixV1 + "/backup"
+
+	// StartHTTPMethod is the HTTP method used with the start resource.
+	StartHTTPMethod = http.MethodPost
+
+	// GetHTTPMethod is the HTTP method used with the get resource.
+	GetHTTPMethod = http.MethodGet
+)
+
+var (
+	// GetURL is the url for the backup manifest get handler.
+	GetURL = fmt.Sprintf("%s/backup/{%s}", handler.RoutePrefixV1, backupIDVar)
+
+	errEmptyID = errors.New("must specify backup ID")
+
+	errNoInstances = errors.New("placement has no instances to back up")
+)
+
+// Handler represents a generic handler for backup endpoints.
+type Handler struct {
+	// This is used by other backup Handlers
+	// nolint: structcheck
+	client clusterclient.Client
+	nowFn  func() time.Time
+}
+
+// StartRequest is the request to start a backup.
+type StartRequest struct {
+	// Namespaces restricts the backup to the given namespaces, all namespaces
+	// are backed up if empty.
+	Namespaces []string `json:"namespaces"`
+
+	// CutDelay is how long from now all nodes snapshot, it must be long
+	// enough for every node to observe the backup request.
+	CutDelay string `json:"cutDelay"`
+
+	// Timeout is how long after the cut time nodes may still report their
+	// snapshots before the backup expires.
+	Timeout string `json:"timeout"`
+}
+
+// StartHandler is the handler for starting backups.
+type StartHandler Handler
+
+// NewStartHandler returns a new instance of StartHandler.
+func NewStartHandler(client clusterclient.Client) *StartHandler {
+	return &StartHandler{client: client, nowFn: time.Now}
+}
+
+func (h *StartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	startReq, cutDelay, timeout, rErr := parseStartRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	service, err := placement.Service(h.client, r.Header)
+	if err != nil {
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	p, _, err := service.Placement()
+	if err != nil {
+		logger.Error("unable to get placement", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	instances := p.Instances()
+	hostIDs := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		hostIDs = append(hostIDs, instance.ID())
+	}
+	sort.Strings(hostIDs)
+	if len(hostIDs) == 0 {
+		handler.Error(w, errNoInstances, http.StatusBadRequest)
+		return
+	}
+
+	store, err := h.client.KV()
+	if err != nil {
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	req, err := backup.NewCoordinator(store, h.nowFn).
+		Start(hostIDs, startReq.Namespaces, cutDelay, timeout)
+	if err != nil {
+		logger.Error("unable to start backup", zap.Any("error", err))
+		if err == backup.ErrBackupInProgress {
+			handler.Error(w, err, http.StatusConflict)
+		} else {
+			handler.Error(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	handler.WriteJSONResponse(w, req, logger)
+}
+
+func parseStartRequest(
+	r *http.Request,
+) (StartRequest, time.Duration, time.Duration, *handler.ParseError) {
+	defer r.Body.Close()
+
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, 0, 0, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	cutDelay, err := parseDuration(req.CutDelay, defaultCutDelay)
+	if err != nil {
+		return req, 0, 0, handler.NewParseError(
+			fmt.Errorf("invalid cutDelay: %v", err), http.StatusBadRequest)
+	}
+	timeout, err := parseDuration(req.Timeout, defaultTimeout)
+	if err != nil {
+		return req, 0, 0, handler.NewParseError(
+			fmt.Errorf("invalid timeout: %v", err), http.StatusBadRequest)
+	}
+	return req, cutDelay, timeout, nil
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %s", value)
+	}
+	return d, nil
+}
+
+// GetHandler is the handler for backup manifest gets.
+type GetHandler Handler
+
+// NewGetHandler returns a new instance of GetHandler.
+func NewGetHandler(client clusterclient.Client) *GetHandler {
+	return &GetHandler{client: client, nowFn: time.Now}
+}
+
+func (h *GetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+	id := strings.TrimSpace(mux.Vars(r)[backupIDVar])
+	if id == "" {
+		handler.Error(w, errEmptyID, http.StatusBadRequest)
+		return
+	}
+
+	store, err := h.client.KV()
+	if err != nil {
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	manifest, err := backup.NewCoordinator(store, h.nowFn).Manifest(id)
+	if err != nil {
+		logger.Error("unable to get backup manifest", zap.Any("error", err))
+		if err == backup.ErrBackupNotFound {
+			handler.Error(w, err, http.StatusNotFound)
+		} else {
+			handler.Error(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	handler.WriteJSONResponse(w, manifest, logger)
+}
+
+// RegisterRoutes registers the backup routes
+func RegisterRoutes(r *mux.Router, client clusterclient.Client) {
+	logged := logging.WithResponseTimeLogging
+
+	r.HandleFunc(StartURL, logged(NewStartHandler(client)).ServeHTTP).Methods(StartHTTPMethod)
+	r.HandleFunc(GetURL, logged(NewGetHandler(client)).ServeHTTP).Methods(GetHTTPMethod)
+}
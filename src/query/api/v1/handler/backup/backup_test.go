@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/backup"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/generated/proto/placementpb"
+	"github.com/m3db/m3cluster/kv/mem"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/services"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupStartAndGetHandlers(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := client.NewMockClient(ctrl)
+	mockServices := services.NewMockServices(ctrl)
+	mockPlacementService := placement.NewMockService(ctrl)
+	mockClient.EXPECT().Services(gomock.Any()).Return(mockServices, nil).AnyTimes()
+	mockServices.EXPECT().PlacementService(gomock.Any(), gomock.Any()).Return(mockPlacementService, nil).AnyTimes()
+	mockClient.EXPECT().KV().Return(mem.NewStore(), nil).AnyTimes()
+
+	placementObj, err := placement.NewPlacementFromProto(&placementpb.Placement{
+		Instances: map[string]*placementpb.Instance{
+			"host2": &placementpb.Instance{Id: "host2"},
+			"host1": &placementpb.Instance{Id: "host1"},
+		},
+	})
+	require.NoError(t, err)
+	mockPlacementService.EXPECT().Placement().Return(placementObj, 0, nil).AnyTimes()
+
+	now := time.Unix(1000, 0)
+	startHandler := NewStartHandler(mockClient)
+	startHandler.nowFn = func() time.Time { return now }
+	getHandler := NewGetHandler(mockClient)
+	getHandler.nowFn = func() time.Time { return now }
+
+	start := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		startHandler.ServeHTTP(w, httptest.NewRequest("POST", StartURL, strings.NewReader(body)))
+		return w
+	}
+
+	w := start(`{"cutDelay":"1x"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = start(`{"namespaces":["metrics"],"cutDelay":"5s"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var req backup.Request
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&req))
+	require.Equal(t, []string{"host1", "host2"}, req.HostIDs)
+	require.Equal(t, []string{"metrics"}, req.Namespaces)
+	require.True(t, now.Add(5*time.Second).Equal(req.CutTime))
+	require.True(t, now.Add(5*time.Second+defaultTimeout).Equal(req.Expires))
+
+	w = start(`{}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/backup/"+id, nil)
+		r = mux.SetURLVars(r, map[string]string{backupIDVar: id})
+		getHandler.ServeHTTP(w, r)
+		return w
+	}
+
+	w = get(req.ID)
+	require.Equal(t, http.StatusOK, w.Code)
+	var manifest backup.Manifest
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&manifest))
+	require.Equal(t, backup.PendingStatus, manifest.Status)
+	require.Equal(t, []string{"host1", "host2"}, manifest.Pending)
+
+	w = get("unknown")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package configreload provides HTTP handlers to reload the coordinator
+// configuration and return the diff applied by the last reload.
+package configreload
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config/reload"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ReloadURL is the url for the config reload handlers.
+	ReloadURL = handler.RoutePrefixV1 + "/config/reload"
+
+	// ReloadHTTPMethod is the HTTP method used to reload the configuration.
+	ReloadHTTPMethod = http.MethodPost
+
+	// LastReloadHTTPMethod is the HTTP method used to get the result of
+	// the last reload.
+	LastReloadHTTPMethod = http.MethodGet
+)
+
+var (
+	errNoReloader = errors.New("no config reloader set")
+	errNoReload   = errors.New("configuration has not been reloaded")
+)
+
+// ReloadHandler reloads the configuration, responding with the result of
+// the reload including the diff of every changed section.
+type ReloadHandler struct {
+	reloader *reload.Reloader
+}
+
+// NewReloadHandler returns a new instance of the config reload handler.
+func NewReloadHandler(reloader *reload.Reloader) (http.Handler, error) {
+	if reloader == nil {
+		return nil, errNoReloader
+	}
+	return &ReloadHandler{reloader: reloader}, nil
+}
+
+func (h *ReloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	result, err := h.reloader.Reload(reload.APITrigger)
+	switch {
+	case err == nil:
+		handler.WriteJSONResponse(w, result, logger)
+	case reload.IsValidationError(err):
+		writeJSONResponse(w, result, http.StatusBadRequest, logger)
+	default:
+		writeJSONResponse(w, result, http.StatusInternalServerError, logger)
+	}
+}
+
+// LastReloadHandler responds with the result of the last reload.
+type LastReloadHandler struct {
+	reloader *reload.Reloader
+}
+
+// NewLastReloadHandler returns a new instance of the last reload handler.
+func NewLastReloadHandler(reloader *reload.Reloader) (http.Handler, error) {
+	if reloader == nil {
+		return nil, errNoReloader
+	}
+	return &LastReloadHandler{reloader: reloader}, nil
+}
+
+func (h *LastReloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	result, ok := h.reloader.LastResult()
+	if !ok {
+		handler.Error(w, errNoReload, http.StatusNotFound)
+		return
+	}
+	handler.WriteJSONResponse(w, result, logger)
+}
+
+// writeJSONResponse writes the result of a failed reload with the status
+// code of the failure so that clients can inspect what was rolled back.
+func writeJSONResponse(
+	w http.ResponseWriter,
+	data interface{},
+	code int,
+	logger *zap.Logger,
+) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("unable to marshal json", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(jsonData)
+}
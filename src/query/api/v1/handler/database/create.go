@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -315,11 +316,18 @@ func defaultedPlacementInitRequest(
 		}
 
 		addr := embeddedDbCfg.ListenAddress
-		port, err := portFromEmbeddedDBConfigListenAddress(addr)
+		listenHost, port, err := hostPortFromEmbeddedDBConfigListenAddress(addr)
 		if err != nil {
 			return nil, err
 		}
 
+		// Connect over loopback with the same address family the embedded
+		// database is listening on.
+		loopbackHost := "127.0.0.1"
+		if ip := net.ParseIP(listenHost); ip != nil && ip.To4() == nil {
+			loopbackHost = "::1"
+		}
+
 		numShards = shardMultiplier
 		replicationFactor = 1
 		instances = []*placementpb.Instance{
@@ -328,7 +336,7 @@ func defaultedPlacementInitRequest(
 				IsolationGroup: "local",
 				Zone:           "embedded",
 				Weight:         1,
-				Endpoint:       fmt.Sprintf("127.0.0.1:%d", port),
+				Endpoint:       net.JoinHostPort(loopbackHost, strconv.Itoa(port)),
 				Hostname:       "localhost",
 				Port:           uint32(port),
 			},
@@ -365,13 +373,17 @@ func defaultedPlacementInitRequest(
 				weight = 1
 			}
 
+			// Accept bracketed IPv6 literals, the endpoint is bracketed as
+			// required when joined with the port.
+			address := strings.TrimSuffix(strings.TrimPrefix(host.Address, "["), "]")
+
 			instances = append(instances, &placementpb.Instance{
 				Id:             id,
 				IsolationGroup: isolationGroup,
 				Zone:           zone,
 				Weight:         weight,
-				Endpoint:       fmt.Sprintf("%s:%d", host.Address, host.Port),
-				Hostname:       host.Address,
+				Endpoint:       net.JoinHostPort(address, strconv.Itoa(int(host.Port))),
+				Hostname:       address,
 				Port:           host.Port,
 			})
 		}
@@ -386,13 +398,17 @@ func defaultedPlacementInitRequest(
 	}, nil
 }
 
-func portFromEmbeddedDBConfigListenAddress(address string) (int, error) {
-	colonIdx := strings.LastIndex(address, ":")
-	if colonIdx == -1 || colonIdx == len(address)-1 {
-		return 0, errMissingEmbeddedDBPort
+func hostPortFromEmbeddedDBConfigListenAddress(address string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil || portStr == "" {
+		return "", 0, errMissingEmbeddedDBPort
 	}
 
-	return strconv.Atoi(address[colonIdx+1:])
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, err
+	}
+	return host, port, nil
 }
 
 func powerOfTwoAtLeast(num float64) float64 {
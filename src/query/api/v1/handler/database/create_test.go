@@ -35,6 +35,7 @@ import (
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	xtest "github.com/m3db/m3/src/dbnode/x/test"
 	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
 	"github.com/m3db/m3/src/query/util/logging"
 	"github.com/m3db/m3cluster/client"
 	"github.com/m3db/m3cluster/generated/proto/placementpb"
@@ -139,8 +140,28 @@ func TestLocalType(t *testing.T) {
 						"snapshotEnabled": false,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "3600000000000"
-						}
+							"blockSizeNanos": "3600000000000",
+							"numericTags": []
+						},
+						"quotaOptions": {
+							"maxBytes": "0",
+							"exceededAction": "WARN"
+						},
+						"compressionDictionaryOptions": {
+							"enabled": false,
+							"sampleSize": "4096",
+							"maxBytes": "65536"
+						},
+						"inMemoryOnly": false,
+						"encryptionEnabled": false,
+						"cloneSource": "",
+						"shardingOptions": {
+							"hashStrategy": "MURMUR3",
+							"tagNames": []
+						},
+						"timestampPrecisionNanos": "1",
+						"continuousQueries": [],
+						"retentionTransition": null
 					}
 				}
 			}
@@ -240,8 +261,28 @@ func TestLocalWithBlockSizeNanos(t *testing.T) {
 						"snapshotEnabled": false,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "10800000000000"
-						}
+							"blockSizeNanos": "10800000000000",
+							"numericTags": []
+						},
+						"quotaOptions": {
+							"maxBytes": "0",
+							"exceededAction": "WARN"
+						},
+						"compressionDictionaryOptions": {
+							"enabled": false,
+							"sampleSize": "4096",
+							"maxBytes": "65536"
+						},
+						"inMemoryOnly": false,
+						"encryptionEnabled": false,
+						"cloneSource": "",
+						"shardingOptions": {
+							"hashStrategy": "MURMUR3",
+							"tagNames": []
+						},
+						"timestampPrecisionNanos": "1",
+						"continuousQueries": [],
+						"retentionTransition": null
 					}
 				}
 			}
@@ -344,8 +385,28 @@ func TestLocalWithBlockSizeExpectedSeriesDatapointsPerHour(t *testing.T) {
 						"snapshotEnabled": false,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "%d"
-						}
+							"blockSizeNanos": "%d",
+							"numericTags": []
+						},
+						"quotaOptions": {
+							"maxBytes": "0",
+							"exceededAction": "WARN"
+						},
+						"compressionDictionaryOptions": {
+							"enabled": false,
+							"sampleSize": "4096",
+							"maxBytes": "65536"
+						},
+						"inMemoryOnly": false,
+						"encryptionEnabled": false,
+						"cloneSource": "",
+						"shardingOptions": {
+							"hashStrategy": "MURMUR3",
+							"tagNames": []
+						},
+						"timestampPrecisionNanos": "1",
+						"continuousQueries": [],
+						"retentionTransition": null
 					}
 				}
 			}
@@ -455,8 +516,28 @@ func TestClusterTypeHosts(t *testing.T) {
 						"snapshotEnabled": false,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "3600000000000"
-						}
+							"blockSizeNanos": "3600000000000",
+							"numericTags": []
+						},
+						"quotaOptions": {
+							"maxBytes": "0",
+							"exceededAction": "WARN"
+						},
+						"compressionDictionaryOptions": {
+							"enabled": false,
+							"sampleSize": "4096",
+							"maxBytes": "65536"
+						},
+						"inMemoryOnly": false,
+						"encryptionEnabled": false,
+						"cloneSource": "",
+						"shardingOptions": {
+							"hashStrategy": "MURMUR3",
+							"tagNames": []
+						},
+						"timestampPrecisionNanos": "1",
+						"continuousQueries": [],
+						"retentionTransition": null
 					}
 				}
 			}
@@ -576,8 +657,28 @@ func TestClusterTypeHostsWithIsolationGroup(t *testing.T) {
 						"snapshotEnabled": false,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "3600000000000"
-						}
+							"blockSizeNanos": "3600000000000",
+							"numericTags": []
+						},
+						"quotaOptions": {
+							"maxBytes": "0",
+							"exceededAction": "WARN"
+						},
+						"compressionDictionaryOptions": {
+							"enabled": false,
+							"sampleSize": "4096",
+							"maxBytes": "65536"
+						},
+						"inMemoryOnly": false,
+						"encryptionEnabled": false,
+						"cloneSource": "",
+						"shardingOptions": {
+							"hashStrategy": "MURMUR3",
+							"tagNames": []
+						},
+						"timestampPrecisionNanos": "1",
+						"continuousQueries": [],
+						"retentionTransition": null
 					}
 				}
 			}
@@ -689,3 +790,34 @@ func mustPrettyJSON(t *testing.T, str string) string {
 func withEndline(str string) string {
 	return str + "\n"
 }
+
+func TestDefaultedPlacementInitRequestIPv6(t *testing.T) {
+	req, err := defaultedPlacementInitRequest(&admin.DatabaseCreateRequest{
+		Type: string(dbTypeLocal),
+	}, &dbconfig.DBConfiguration{ListenAddress: "[::]:9000"})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(req.Instances))
+	assert.Equal(t, "[::1]:9000", req.Instances[0].Endpoint)
+	assert.Equal(t, uint32(9000), req.Instances[0].Port)
+
+	req, err = defaultedPlacementInitRequest(&admin.DatabaseCreateRequest{
+		Type: string(dbTypeCluster),
+		Hosts: []*admin.Host{
+			{Id: "host1", Address: "2001:db8::1", Port: 9000},
+			{Id: "host2", Address: "[2001:db8::2]", Port: 9000},
+			{Id: "host3", Address: "10.0.0.3", Port: 9000},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(req.Instances))
+	assert.Equal(t, "[2001:db8::1]:9000", req.Instances[0].Endpoint)
+	assert.Equal(t, "2001:db8::1", req.Instances[0].Hostname)
+	assert.Equal(t, "[2001:db8::2]:9000", req.Instances[1].Endpoint)
+	assert.Equal(t, "2001:db8::2", req.Instances[1].Hostname)
+	assert.Equal(t, "10.0.0.3:9000", req.Instances[2].Endpoint)
+
+	_, err = defaultedPlacementInitRequest(&admin.DatabaseCreateRequest{
+		Type: string(dbTypeLocal),
+	}, &dbconfig.DBConfiguration{ListenAddress: "[::]"})
+	require.Equal(t, errMissingEmbeddedDBPort, err)
+}
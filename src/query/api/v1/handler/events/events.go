@@ -0,0 +1,233 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package events provides HTTP handlers to write events, such as deployment
+// markers, and query them by time range so they can be overlaid on graphs.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser/promql"
+	"github.com/m3db/m3/src/query/storage/events"
+	"github.com/m3db/m3/src/query/util"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// EventsURL is the url for the events handlers.
+	EventsURL = handler.RoutePrefixV1 + "/events"
+
+	// WriteHTTPMethod is the HTTP method used to write events.
+	WriteHTTPMethod = http.MethodPost
+
+	// QueryHTTPMethod is the HTTP method used to query events.
+	QueryHTTPMethod = http.MethodGet
+
+	namespaceParam = "namespace"
+	startParam     = "start"
+	endParam       = "end"
+	matchParam     = "match"
+	limitParam     = "limit"
+
+	// defaultQueryRange is the range queried when no start is given.
+	defaultQueryRange = 24 * time.Hour
+
+	// maxBodyBytes limits the size of a write request body.
+	maxBodyBytes = 1 << 20
+)
+
+var errNoStore = errors.New("no event store set")
+
+// WriteRequest is a request to write an event.
+type WriteRequest struct {
+	// Namespace is the namespace to write to, if empty the event is
+	// written to the unaggregated namespace.
+	Namespace string `json:"namespace"`
+	// Timestamp is either RFC3339 or fractional seconds since the unix
+	// epoch, if empty the current time is used.
+	Timestamp string      `json:"timestamp"`
+	Tags      models.Tags `json:"tags"`
+	Text      string      `json:"text"`
+}
+
+// QueryResponse is the response to an events query.
+type QueryResponse struct {
+	Events []events.Event `json:"events"`
+}
+
+// WriteHandler writes events.
+type WriteHandler struct {
+	store events.Store
+	nowFn func() time.Time
+}
+
+// NewWriteHandler returns a new instance of the events write handler.
+func NewWriteHandler(store events.Store) (http.Handler, error) {
+	if store == nil {
+		return nil, errNoStore
+	}
+	return &WriteHandler{store: store, nowFn: time.Now}, nil
+}
+
+func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	var req WriteRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	event := events.Event{
+		Timestamp: h.nowFn(),
+		Tags:      req.Tags,
+		Text:      req.Text,
+	}
+	if req.Timestamp != "" {
+		event.Timestamp, err = util.ParseTimeString(req.Timestamp)
+		if err != nil {
+			handler.Error(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := events.Validate(event); err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.Write(r.Context(), req.Namespace, event); err != nil {
+		if err == events.ErrNamespaceNotFound {
+			handler.Error(w, err, http.StatusNotFound)
+			return
+		}
+		logger.Error("unable to write event", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteJSONResponse(w, event, logger)
+}
+
+// QueryHandler queries events by time range.
+type QueryHandler struct {
+	store events.Store
+	nowFn func() time.Time
+}
+
+// NewQueryHandler returns a new instance of the events query handler.
+func NewQueryHandler(store events.Store) (http.Handler, error) {
+	if store == nil {
+		return nil, errNoStore
+	}
+	return &QueryHandler{store: store, nowFn: time.Now}, nil
+}
+
+func (h *QueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	query, rErr := h.parseQuery(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	result, err := h.store.Query(r.Context(), query)
+	if err != nil {
+		if err == events.ErrNamespaceNotFound {
+			handler.Error(w, err, http.StatusNotFound)
+			return
+		}
+		logger.Error("unable to query events", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if result == nil {
+		result = []events.Event{}
+	}
+	handler.WriteJSONResponse(w, QueryResponse{Events: result}, logger)
+}
+
+func (h *QueryHandler) parseQuery(r *http.Request) (events.Query, *handler.ParseError) {
+	query := events.Query{
+		Namespace: r.FormValue(namespaceParam),
+		End:       h.nowFn(),
+	}
+
+	var err error
+	if end := r.FormValue(endParam); end != "" {
+		query.End, err = util.ParseTimeString(end)
+		if err != nil {
+			return query, handler.NewParseError(err, http.StatusBadRequest)
+		}
+	}
+	query.Start = query.End.Add(-defaultQueryRange)
+	if start := r.FormValue(startParam); start != "" {
+		query.Start, err = util.ParseTimeString(start)
+		if err != nil {
+			return query, handler.NewParseError(err, http.StatusBadRequest)
+		}
+	}
+	if !query.Start.Before(query.End) {
+		return query, handler.NewParseError(fmt.Errorf("%s: %s must be before %s",
+			handler.ErrInvalidParams, startParam, endParam), http.StatusBadRequest)
+	}
+
+	if match := r.FormValue(matchParam); match != "" {
+		query.Matchers, err = promql.ParseMatchers(match)
+		if err != nil {
+			return query, handler.NewParseError(err, http.StatusBadRequest)
+		}
+		for _, matcher := range query.Matchers {
+			if matcher.Name == models.MetricName {
+				return query, handler.NewParseError(fmt.Errorf(
+					"%s: %s may only match tags, not a metric name",
+					handler.ErrInvalidParams, matchParam), http.StatusBadRequest)
+			}
+		}
+	}
+
+	if limit := r.FormValue(limitParam); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 0 {
+			return query, handler.NewParseError(fmt.Errorf("%s: invalid %s",
+				handler.ErrInvalidParams, limitParam), http.StatusBadRequest)
+		}
+	}
+
+	return query, nil
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/events"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStore struct {
+	written    []events.Event
+	namespaces []string
+	queries    []events.Query
+	results    []events.Event
+	err        error
+}
+
+func (s *testStore) Write(ctx context.Context, namespace string, event events.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.namespaces = append(s.namespaces, namespace)
+	s.written = append(s.written, event)
+	return nil
+}
+
+func (s *testStore) Query(ctx context.Context, query events.Query) ([]events.Event, error) {
+	s.queries = append(s.queries, query)
+	return s.results, s.err
+}
+
+func TestWriteHandler(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := &testStore{}
+	h, err := NewWriteHandler(store)
+	require.NoError(t, err)
+
+	body := `{"namespace":"default","timestamp":"1500000000","tags":{"service":"api"},"text":"deployed v1.2"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(WriteHTTPMethod, EventsURL, strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.Len(t, store.written, 1)
+	assert.Equal(t, []string{"default"}, store.namespaces)
+	assert.Equal(t, time.Unix(1500000000, 0), store.written[0].Timestamp)
+	assert.Equal(t, models.Tags{"service": "api"}, store.written[0].Tags)
+	assert.Equal(t, "deployed v1.2", store.written[0].Text)
+}
+
+func TestWriteHandlerDefaultsTimestamp(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := &testStore{}
+	h, err := NewWriteHandler(store)
+	require.NoError(t, err)
+	now := time.Unix(1500000000, 0)
+	h.(*WriteHandler).nowFn = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(WriteHTTPMethod, EventsURL,
+		strings.NewReader(`{"text":"incident opened"}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, store.written, 1)
+	assert.Equal(t, now, store.written[0].Timestamp)
+	assert.Equal(t, []string{""}, store.namespaces)
+}
+
+func TestWriteHandlerInvalid(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := &testStore{}
+	h, err := NewWriteHandler(store)
+	require.NoError(t, err)
+
+	for _, body := range []string{
+		`not json`,
+		`{"timestamp":"yesterday","text":"x"}`,
+		`{"text":""}`,
+		`{"tags":{"__name__":"foo"},"text":"x"}`,
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(WriteHTTPMethod, EventsURL, strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	assert.Len(t, store.written, 0)
+
+	store.err = events.ErrNamespaceNotFound
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(WriteHTTPMethod, EventsURL,
+		strings.NewReader(`{"namespace":"unknown","text":"x"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestQueryHandler(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	at := time.Unix(1500000000, 0).UTC()
+	store := &testStore{results: []events.Event{
+		{Timestamp: at, Tags: models.Tags{"service": "api"}, Text: "deployed v1.2"},
+	}}
+	h, err := NewQueryHandler(store)
+	require.NoError(t, err)
+
+	params := url.Values{}
+	params.Set(startParam, "1499990000")
+	params.Set(endParam, "1500010000")
+	params.Set(matchParam, `{service="api"}`)
+	params.Set(limitParam, "10")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(QueryHTTPMethod, EventsURL+"?"+params.Encode(), nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.Len(t, store.queries, 1)
+	query := store.queries[0]
+	assert.Equal(t, time.Unix(1499990000, 0), query.Start)
+	assert.Equal(t, time.Unix(1500010000, 0), query.End)
+	assert.Equal(t, 10, query.Limit)
+	require.Len(t, query.Matchers, 1)
+	assert.Equal(t, "service", query.Matchers[0].Name)
+
+	var resp QueryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Events, 1)
+	assert.True(t, at.Equal(resp.Events[0].Timestamp))
+	assert.Equal(t, "deployed v1.2", resp.Events[0].Text)
+}
+
+func TestQueryHandlerDefaultsRange(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := &testStore{}
+	h, err := NewQueryHandler(store)
+	require.NoError(t, err)
+	now := time.Unix(1500000000, 0)
+	h.(*QueryHandler).nowFn = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(QueryHTTPMethod, EventsURL, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"events":[]}`, w.Body.String())
+
+	require.Len(t, store.queries, 1)
+	assert.Equal(t, now, store.queries[0].End)
+	assert.Equal(t, now.Add(-defaultQueryRange), store.queries[0].Start)
+}
+
+func TestQueryHandlerInvalid(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := &testStore{}
+	h, err := NewQueryHandler(store)
+	require.NoError(t, err)
+
+	for _, params := range []map[string]string{
+		{startParam: "soon"},
+		{startParam: "1500000000", endParam: "1400000000"},
+		{matchParam: `up{service="api"}`},
+		{matchParam: `{service=`},
+		{limitParam: "-1"},
+	} {
+		values := url.Values{}
+		for k, v := range params {
+			values.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(QueryHTTPMethod, EventsURL+"?"+values.Encode(), nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, values.Encode())
+	}
+	assert.Len(t, store.queries, 0)
+}
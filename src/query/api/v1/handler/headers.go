@@ -32,4 +32,8 @@ const (
 
 	// DeprecatedHeader is the M3 deprecated header
 	DeprecatedHeader = "M3-Deprecated"
+
+	// ReadConsistencyHeader is the M3 header with the read consistency
+	// achieved by the storage fetches of a query
+	ReadConsistencyHeader = "M3-Read-Consistency"
 )
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ingest provides an HTTP handler that writes ad-hoc CSV or
+// newline delimited JSON data as series.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/m3db/m3/src/dbnode/x/logsample"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3x/errors"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// IngestURL is the url for the ingest handler.
+	IngestURL = handler.RoutePrefixV1 + "/ingest"
+
+	// IngestHTTPMethod is the HTTP method used with this resource.
+	IngestHTTPMethod = http.MethodPost
+
+	// maxBodyBytes limits the size of a request body since all rows are
+	// validated before any are written.
+	maxBodyBytes = 64 << 20
+
+	// maxRowErrors limits the number of row errors returned.
+	maxRowErrors = 100
+
+	// InvalidRowsLogClass is the log sampling class of requests with rows
+	// failing validation.
+	InvalidRowsLogClass = "ingest-invalid-rows"
+
+	// WriteErrorLogClass is the log sampling class of write errors.
+	WriteErrorLogClass = "ingest-write"
+)
+
+var (
+	errNoStorage = errors.New("no storage set")
+	errRowErrors = errors.New("rows failed validation, no rows were written")
+)
+
+// Handler represents a handler for the ingest endpoint.
+type Handler struct {
+	store         storage.Storage
+	metrics       ingestMetrics
+	sampledLogger *logging.SampledLogger
+}
+
+// NewHandler returns a new instance of the ingest handler, errors are
+// logged through the log sampler.
+func NewHandler(
+	store storage.Storage,
+	logSampler *logsample.Sampler,
+	scope tally.Scope,
+) (http.Handler, error) {
+	if store == nil {
+		return nil, errNoStorage
+	}
+	return &Handler{
+		store:         store,
+		metrics:       newIngestMetrics(scope),
+		sampledLogger: logging.NewSampledLogger(logSampler),
+	}, nil
+}
+
+type ingestMetrics struct {
+	rowsWritten       tally.Counter
+	rowsInvalid       tally.Counter
+	writeErrorsServer tally.Counter
+	writeErrorsClient tally.Counter
+}
+
+func newIngestMetrics(scope tally.Scope) ingestMetrics {
+	return ingestMetrics{
+		rowsWritten:       scope.Counter("rows.written"),
+		rowsInvalid:       scope.Counter("rows.invalid"),
+		writeErrorsServer: scope.Tagged(map[string]string{"code": "5XX"}).Counter("write.errors"),
+		writeErrorsClient: scope.Tagged(map[string]string{"code": "4XX"}).Counter("write.errors"),
+	}
+}
+
+// Response is the response to an ingest request.
+type Response struct {
+	Rows      int        `json:"rows"`
+	Series    int        `json:"series"`
+	Error     string     `json:"error,omitempty"`
+	RowErrors []RowError `json:"rowErrors,omitempty"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	schema, rErr := parseSchema(r)
+	if rErr != nil {
+		h.metrics.writeErrorsClient.Inc(1)
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	rows, rowErrors, err := readRows(body, schema)
+	if err != nil {
+		h.metrics.writeErrorsClient.Inc(1)
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Rows are only written if all rows are valid so that a corrected
+	// body can be resubmitted without duplicating datapoints.
+	if len(rowErrors) > 0 {
+		h.metrics.writeErrorsClient.Inc(1)
+		h.metrics.rowsInvalid.Inc(int64(len(rowErrors)))
+		h.sampledLogger.Warn(r.Context(), InvalidRowsLogClass,
+			"ingest rows failed validation",
+			zap.Int("invalidRows", len(rowErrors)),
+			zap.Int("firstRow", rowErrors[0].Row),
+			zap.String("firstError", rowErrors[0].Error))
+		if len(rowErrors) > maxRowErrors {
+			rowErrors = rowErrors[:maxRowErrors]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		handler.WriteJSONResponse(w, Response{
+			Error:     errRowErrors.Error(),
+			RowErrors: rowErrors,
+		}, logger)
+		return
+	}
+
+	writes := rowsToWrites(rows)
+	if err := h.write(r.Context(), writes); err != nil {
+		h.metrics.writeErrorsServer.Inc(1)
+		h.sampledLogger.Error(r.Context(), WriteErrorLogClass,
+			"ingest write error", zap.Any("err", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.metrics.rowsWritten.Inc(int64(len(rows)))
+	handler.WriteJSONResponse(w, Response{
+		Rows:   len(rows),
+		Series: len(writes),
+	}, logger)
+}
+
+// rowsToWrites groups rows into one write per series with datapoints
+// sorted by time.
+func rowsToWrites(rows []row) []*storage.WriteQuery {
+	var (
+		writes []*storage.WriteQuery
+		byID   = make(map[string]*storage.WriteQuery)
+	)
+	for _, row := range rows {
+		id := row.tags.ID()
+		write, ok := byID[id]
+		if !ok {
+			write = &storage.WriteQuery{
+				Tags: row.tags,
+				Unit: xtime.Millisecond,
+				Attributes: storage.Attributes{
+					MetricsType: storage.UnaggregatedMetricsType,
+				},
+			}
+			byID[id] = write
+			writes = append(writes, write)
+		}
+		write.Datapoints = append(write.Datapoints, ts.Datapoint{
+			Timestamp: row.timestamp,
+			Value:     row.value,
+		})
+	}
+
+	for _, write := range writes {
+		dps := write.Datapoints
+		sort.SliceStable(dps, func(i, j int) bool {
+			return dps[i].Timestamp.Before(dps[j].Timestamp)
+		})
+	}
+
+	return writes
+}
+
+func (h *Handler) write(ctx context.Context, writes []*storage.WriteQuery) error {
+	var multiErr xerrors.MultiError
+	for _, write := range writes {
+		multiErr = multiErr.Add(h.store.Write(ctx, write))
+	}
+	return multiErr.FinalError()
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newIngestRequest(params url.Values, contentType, body string) *http.Request {
+	req := httptest.NewRequest(IngestHTTPMethod, IngestURL+"?"+params.Encode(),
+		strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+func defaultParams() url.Values {
+	params := url.Values{}
+	params.Set(nameParam, "temperature")
+	params.Set(valueParam, "reading")
+	params.Set(timestampParam, "time")
+	params.Add(tagParam, "sensor")
+	params.Add(tagParam, "site_name:site")
+	return params
+}
+
+func TestIngestCSV(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := mock.NewMockStorage()
+	h, err := NewHandler(store, nil, tally.NoopScope)
+	require.NoError(t, err)
+
+	body := "time,sensor,site_name,reading,ignored\n" +
+		"1500000010,a,nyc,2.5,x\n" +
+		"2017-07-14T02:40:00Z,a,nyc,1.5,y\n" +
+		"1500000000.5,b,sfo,3,z\n"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newIngestRequest(defaultParams(), "text/csv", body))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Rows)
+	assert.Equal(t, 2, resp.Series)
+
+	writes := store.Writes()
+	require.Equal(t, 2, len(writes))
+	assert.Equal(t, models.Tags{
+		models.MetricName: "temperature",
+		"sensor":          "a",
+		"site":            "nyc",
+	}, writes[0].Tags)
+	require.Equal(t, 2, len(writes[0].Datapoints))
+	assert.Equal(t, time.Unix(1500000000, 0), writes[0].Datapoints[0].Timestamp.Local())
+	assert.Equal(t, 1.5, writes[0].Datapoints[0].Value)
+	assert.Equal(t, 2.5, writes[0].Datapoints[1].Value)
+
+	require.Equal(t, 1, len(writes[1].Datapoints))
+	assert.Equal(t, time.Unix(1500000000, int64(500*time.Millisecond)),
+		writes[1].Datapoints[0].Timestamp)
+}
+
+func TestIngestNDJSON(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := mock.NewMockStorage()
+	h, err := NewHandler(store, nil, tally.NoopScope)
+	require.NoError(t, err)
+
+	params := defaultParams()
+	params.Del(nameParam)
+	params.Set(nameColumnParam, "metric")
+	params.Set(formatParam, "ndjson")
+
+	body := `{"metric":"a","time":1500000000,"sensor":"s1","site_name":"nyc","reading":1}` +
+		"\n\n" +
+		`{"metric":"b","time":"2017-07-14T02:40:00Z","sensor":2,"site_name":"nyc","reading":"4.5"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newIngestRequest(params, "", body))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	writes := store.Writes()
+	require.Equal(t, 2, len(writes))
+	assert.Equal(t, "a", writes[0].Tags[models.MetricName])
+	assert.Equal(t, "2", writes[1].Tags["sensor"])
+	assert.Equal(t, 4.5, writes[1].Datapoints[0].Value)
+}
+
+func TestIngestRowErrors(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := mock.NewMockStorage()
+	h, err := NewHandler(store, nil, tally.NoopScope)
+	require.NoError(t, err)
+
+	body := "time,sensor,site_name,reading\n" +
+		"1500000000,a,nyc,1\n" +
+		"1500000000,a,nyc,abc\n" +
+		"yesterday,a,nyc,1\n" +
+		"1500000000,,nyc,1\n" +
+		"1500000000,a\n"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newIngestRequest(defaultParams(), "text/csv", body))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []RowError{
+		{Row: 2, Error: "invalid value 'abc' in column reading"},
+		{Row: 3, Error: "invalid timestamp 'yesterday' in column time"},
+		{Row: 4, Error: "missing tag column sensor"},
+		{Row: 5, Error: "missing value column reading"},
+	}, resp.RowErrors)
+
+	// No rows are written if any row is invalid.
+	assert.Equal(t, 0, len(store.Writes()))
+}
+
+func TestIngestInvalidSchema(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	store := mock.NewMockStorage()
+	h, err := NewHandler(store, nil, tally.NoopScope)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		params      func(url.Values)
+		contentType string
+	}{
+		{"no format", func(url.Values) {}, ""},
+		{"bad format", func(p url.Values) { p.Set(formatParam, "xml") }, ""},
+		{"no name", func(p url.Values) { p.Del(nameParam) }, "text/csv"},
+		{"name and column", func(p url.Values) { p.Set(nameColumnParam, "n") }, "text/csv"},
+		{"no value", func(p url.Values) { p.Del(valueParam) }, "text/csv"},
+		{"no timestamp", func(p url.Values) { p.Del(timestampParam) }, "text/csv"},
+		{"empty tag", func(p url.Values) { p.Add(tagParam, "col:") }, "text/csv"},
+		{"reserved tag", func(p url.Values) { p.Add(tagParam, "col:__name__") }, "text/csv"},
+	}
+
+	for _, test := range tests {
+		params := defaultParams()
+		test.params(params)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newIngestRequest(params, test.contentType, "time\n"))
+		assert.Equal(t, http.StatusBadRequest, w.Code, test.name)
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/models"
+)
+
+const (
+	formatParam     = "format"
+	nameParam       = "name"
+	nameColumnParam = "name_column"
+	valueParam      = "value"
+	timestampParam  = "timestamp"
+	tagParam        = "tag"
+
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+var (
+	errNoName           = errors.New("either name or name_column must be set")
+	errNameAndColumn    = errors.New("only one of name or name_column may be set")
+	errNoValueColumn    = errors.New("value column must be set")
+	errNoTimeColumn     = errors.New("timestamp column must be set")
+	errNoCSVHeader      = errors.New("csv body has no header row")
+	errEmptyTagMapping  = errors.New("tag mapping must name a column")
+	errInvalidTimestamp = errors.New("invalid timestamp")
+)
+
+// Format is the format of an ingest request body.
+type Format int
+
+const (
+	// CSVFormat is comma separated values with a header row naming the
+	// columns.
+	CSVFormat Format = iota
+	// NDJSONFormat is newline delimited JSON with one object per row.
+	NDJSONFormat
+)
+
+func (f Format) String() string {
+	switch f {
+	case CSVFormat:
+		return "csv"
+	case NDJSONFormat:
+		return "ndjson"
+	}
+	return "unknown"
+}
+
+// Schema maps the columns of ingested rows to series.
+type Schema struct {
+	// Format is the format of the body.
+	Format Format
+	// Name is the metric name of all rows, if empty NameColumn is used.
+	Name string
+	// NameColumn is the column holding the metric name of each row.
+	NameColumn string
+	// ValueColumn is the column holding the value of each row.
+	ValueColumn string
+	// TimestampColumn is the column holding the timestamp of each row as
+	// either RFC3339 or fractional seconds since the unix epoch.
+	TimestampColumn string
+	// Tags maps columns to the tag names they are written as.
+	Tags map[string]string
+}
+
+// parseSchema parses the schema of an ingest request from its params.
+func parseSchema(r *http.Request) (Schema, *handler.ParseError) {
+	query := r.URL.Query()
+	schema := Schema{
+		Name:            query.Get(nameParam),
+		NameColumn:      query.Get(nameColumnParam),
+		ValueColumn:     query.Get(valueParam),
+		TimestampColumn: query.Get(timestampParam),
+		Tags:            make(map[string]string),
+	}
+
+	format, err := parseFormat(r)
+	if err != nil {
+		return schema, handler.NewParseError(err, http.StatusBadRequest)
+	}
+	schema.Format = format
+
+	switch {
+	case schema.Name == "" && schema.NameColumn == "":
+		return schema, handler.NewParseError(errNoName, http.StatusBadRequest)
+	case schema.Name != "" && schema.NameColumn != "":
+		return schema, handler.NewParseError(errNameAndColumn, http.StatusBadRequest)
+	case schema.ValueColumn == "":
+		return schema, handler.NewParseError(errNoValueColumn, http.StatusBadRequest)
+	case schema.TimestampColumn == "":
+		return schema, handler.NewParseError(errNoTimeColumn, http.StatusBadRequest)
+	}
+
+	// Tag mappings are either a column name, written as a tag of the same
+	// name, or column:tag to write the column as a differently named tag.
+	for _, mapping := range query[tagParam] {
+		column, tag := mapping, mapping
+		if idx := strings.Index(mapping, ":"); idx >= 0 {
+			column, tag = mapping[:idx], mapping[idx+1:]
+		}
+		if column == "" || tag == "" {
+			return schema, handler.NewParseError(errEmptyTagMapping, http.StatusBadRequest)
+		}
+		if tag == models.MetricName {
+			return schema, handler.NewParseError(
+				fmt.Errorf("tag mapping for column %s may not use reserved tag %s",
+					column, models.MetricName), http.StatusBadRequest)
+		}
+		schema.Tags[column] = tag
+	}
+
+	return schema, nil
+}
+
+func parseFormat(r *http.Request) (Format, error) {
+	format := r.URL.Query().Get(formatParam)
+	if format == "" {
+		contentType := r.Header.Get("Content-Type")
+		if idx := strings.Index(contentType, ";"); idx >= 0 {
+			contentType = contentType[:idx]
+		}
+		switch strings.TrimSpace(contentType) {
+		case csvContentType:
+			return CSVFormat, nil
+		case ndjsonContentType:
+			return NDJSONFormat, nil
+		}
+		return 0, fmt.Errorf("unable to determine format from content type '%s', "+
+			"set the %s param to csv or ndjson", contentType, formatParam)
+	}
+
+	for _, f := range []Format{CSVFormat, NDJSONFormat} {
+		if format == f.String() {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid format '%s', must be csv or ndjson", format)
+}
+
+// row is a parsed and validated row.
+type row struct {
+	tags      models.Tags
+	timestamp time.Time
+	value     float64
+}
+
+// RowError is a validation error for a single row of a request body.
+type RowError struct {
+	// Row is the one based row number, not counting a CSV header.
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// readRows reads and validates all rows of a body, rows that fail
+// validation are returned as row errors. A non-nil error is returned when
+// the body itself can not be read.
+func readRows(body io.Reader, schema Schema) ([]row, []RowError, error) {
+	switch schema.Format {
+	case CSVFormat:
+		return readCSVRows(body, schema)
+	case NDJSONFormat:
+		return readNDJSONRows(body, schema)
+	}
+	return nil, nil, fmt.Errorf("unsupported format: %v", schema.Format)
+}
+
+func readCSVRows(body io.Reader, schema Schema) ([]row, []RowError, error) {
+	reader := csv.NewReader(body)
+	// Allow rows with a different number of fields so that short rows are
+	// reported as missing columns rather than failing the whole body.
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errNoCSVHeader
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		rows      []row
+		rowErrors []RowError
+		fields    = make(map[string]string, len(header))
+	)
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				rowErrors = append(rowErrors, RowError{Row: n, Error: err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		for k := range fields {
+			delete(fields, k)
+		}
+		for i, column := range header {
+			if i < len(record) {
+				fields[column] = record[i]
+			}
+		}
+
+		parsed, err := schema.row(fields)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: n, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, parsed)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func readNDJSONRows(body io.Reader, schema Schema) ([]row, []RowError, error) {
+	var (
+		rows      []row
+		rowErrors []RowError
+		reader    = bufio.NewReader(body)
+	)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err == io.EOF {
+				break
+			}
+			continue
+		}
+
+		parsed, err := schema.ndjsonRow(line)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: n, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, parsed)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func (s Schema) ndjsonRow(line []byte) (row, error) {
+	var object map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&object); err != nil {
+		return row{}, err
+	}
+
+	fields := make(map[string]string, len(object))
+	for k, v := range object {
+		switch v := v.(type) {
+		case string:
+			fields[k] = v
+		case json.Number:
+			fields[k] = v.String()
+		case bool:
+			fields[k] = strconv.FormatBool(v)
+		case nil:
+		default:
+			return row{}, fmt.Errorf("field %s must be a string, number or bool", k)
+		}
+	}
+
+	return s.row(fields)
+}
+
+// row validates the fields of a row against the schema.
+func (s Schema) row(fields map[string]string) (row, error) {
+	name := s.Name
+	if s.NameColumn != "" {
+		name = fields[s.NameColumn]
+		if name == "" {
+			return row{}, fmt.Errorf("missing name column %s", s.NameColumn)
+		}
+	}
+
+	valueStr, ok := fields[s.ValueColumn]
+	if !ok || valueStr == "" {
+		return row{}, fmt.Errorf("missing value column %s", s.ValueColumn)
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return row{}, fmt.Errorf("invalid value '%s' in column %s",
+			valueStr, s.ValueColumn)
+	}
+
+	timestampStr, ok := fields[s.TimestampColumn]
+	if !ok || timestampStr == "" {
+		return row{}, fmt.Errorf("missing timestamp column %s", s.TimestampColumn)
+	}
+	timestamp, err := parseTimestamp(timestampStr)
+	if err != nil {
+		return row{}, fmt.Errorf("invalid timestamp '%s' in column %s",
+			timestampStr, s.TimestampColumn)
+	}
+
+	tags := make(models.Tags, len(s.Tags)+1)
+	tags[models.MetricName] = name
+	for column, tag := range s.Tags {
+		v, ok := fields[column]
+		if !ok || v == "" {
+			return row{}, fmt.Errorf("missing tag column %s", column)
+		}
+		tags[tag] = v
+	}
+
+	return row{tags: tags, timestamp: timestamp, value: value}, nil
+}
+
+func parseTimestamp(str string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return t, nil
+	}
+
+	secs, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(secs) || math.IsInf(secs, 0) {
+		return time.Time{}, errInvalidTimestamp
+	}
+	whole, frac := math.Modf(secs)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), nil
+}
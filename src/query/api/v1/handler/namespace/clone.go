@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"go.uber.org/zap"
+)
+
+const (
+	// CloneURL is the url for the namespace clone handler.
+	CloneURL = handler.RoutePrefixV1 + "/namespace/clone"
+
+	// CloneHTTPMethod is the HTTP method used with this resource.
+	CloneHTTPMethod = http.MethodPost
+)
+
+// CloneHandler is the handler for namespace clones.
+type CloneHandler Handler
+
+// NewCloneHandler returns a new instance of CloneHandler.
+func NewCloneHandler(client clusterclient.Client) *CloneHandler {
+	return &CloneHandler{client: client}
+}
+
+func (h *CloneHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	cloneReq, rErr := h.parseRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	nsRegistry, err := h.Clone(cloneReq)
+	if err != nil {
+		logger.Error("unable to clone namespace", zap.Any("error", err))
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	resp := &admin.NamespaceGetResponse{
+		Registry: &nsRegistry,
+	}
+
+	handler.WriteProtoMsgJSONResponse(w, resp, logger)
+}
+
+func (h *CloneHandler) parseRequest(r *http.Request) (*admin.NamespaceCloneRequest, *handler.ParseError) {
+	defer r.Body.Close()
+	rBody, err := handler.DurationToNanosBytes(r.Body)
+	if err != nil {
+		return nil, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	cloneReq := new(admin.NamespaceCloneRequest)
+	if err := jsonpb.Unmarshal(bytes.NewReader(rBody), cloneReq); err != nil {
+		return nil, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	return cloneReq, nil
+}
+
+// Clone adds a namespace with the options of an existing namespace, with its
+// retention and index options replaced by those of the request if set. Each
+// node links the filesets of the existing namespace into the new namespace
+// copy-on-write when it creates the new namespace, so that new block sizes or
+// index options can be tested against existing data.
+func (h *CloneHandler) Clone(cloneReq *admin.NamespaceCloneRequest) (nsproto.Registry, error) {
+	var emptyReg = nsproto.Registry{}
+
+	store, err := h.client.KV()
+	if err != nil {
+		return emptyReg, err
+	}
+
+	currentMetadata, version, err := Metadata(store)
+	if err != nil {
+		return emptyReg, err
+	}
+
+	var source namespace.Metadata
+	for _, md := range currentMetadata {
+		if md.ID().String() == cloneReq.Source {
+			source = md
+			break
+		}
+	}
+	if source == nil {
+		return emptyReg, fmt.Errorf("source namespace %q does not exist", cloneReq.Source)
+	}
+	if source.Options().InMemoryOnly() {
+		return emptyReg, fmt.Errorf("source namespace %q is in-memory only and has no filesets to clone",
+			cloneReq.Source)
+	}
+
+	opts := namespace.OptionsToProto(source.Options())
+	if cloneReq.RetentionOptions != nil {
+		opts.RetentionOptions = cloneReq.RetentionOptions
+	}
+	if cloneReq.IndexOptions != nil {
+		opts.IndexOptions = cloneReq.IndexOptions
+	}
+	opts.CloneSource = cloneReq.Source
+
+	md, err := namespace.ToMetadata(cloneReq.Name, opts)
+	if err != nil {
+		return emptyReg, fmt.Errorf("unable to get metadata: %v", err)
+	}
+
+	nsMap, err := namespace.NewMap(append(currentMetadata, md))
+	if err != nil {
+		return emptyReg, err
+	}
+
+	protoRegistry := namespace.ToProto(nsMap)
+	_, err = store.CheckAndSet(M3DBNodeNamespacesKey, version, protoRegistry)
+	if err != nil {
+		return emptyReg, fmt.Errorf("failed to clone namespace: %v", err)
+	}
+
+	return *protoRegistry, nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceCloneHandler(t *testing.T) {
+	mockClient, mockKV, ctrl := SetupNamespaceTest(t)
+	cloneHandler := NewCloneHandler(mockClient)
+
+	registry := nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"metrics": &nsproto.NamespaceOptions{
+				BootstrapEnabled:  true,
+				FlushEnabled:      true,
+				WritesToCommitLog: true,
+				RetentionOptions: &nsproto.RetentionOptions{
+					RetentionPeriodNanos: 172800000000000,
+					BlockSizeNanos:       7200000000000,
+					BufferFutureNanos:    600000000000,
+					BufferPastNanos:      600000000000,
+				},
+				IndexOptions: &nsproto.IndexOptions{
+					Enabled:        true,
+					BlockSizeNanos: 7200000000000,
+				},
+			},
+		},
+	}
+
+	// Error case where the source namespace does not exist
+	w := httptest.NewRecorder()
+
+	jsonInput := `
+        {
+            "name": "metrics-clone",
+            "source": "unknown"
+        }
+    `
+
+	req := httptest.NewRequest("POST", "/namespace/clone", strings.NewReader(jsonInput))
+	require.NotNil(t, req)
+
+	mockValue := kv.NewMockValue(ctrl)
+	mockValue.EXPECT().Unmarshal(gomock.Any()).Return(nil).SetArg(0, registry)
+	mockValue.EXPECT().Version().Return(1)
+	mockKV.EXPECT().Get(M3DBNodeNamespacesKey).Return(mockValue, nil)
+	cloneHandler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"source namespace \\\"unknown\\\" does not exist\"}\n", string(body))
+
+	// Test good case with the block sizes overridden
+	w = httptest.NewRecorder()
+
+	jsonInput = `
+        {
+            "name": "metrics-clone",
+            "source": "metrics",
+            "retentionOptions": {
+              "retentionPeriodNanos": 172800000000000,
+              "blockSizeNanos": 14400000000000,
+              "bufferFutureNanos": 600000000000,
+              "bufferPastNanos": 600000000000
+            },
+            "indexOptions": {
+              "enabled": true,
+              "blockSizeNanos": 14400000000000
+            }
+        }
+    `
+
+	req = httptest.NewRequest("POST", "/namespace/clone", strings.NewReader(jsonInput))
+	require.NotNil(t, req)
+
+	mockValue = kv.NewMockValue(ctrl)
+	mockValue.EXPECT().Unmarshal(gomock.Any()).Return(nil).SetArg(0, registry)
+	mockValue.EXPECT().Version().Return(1)
+	mockKV.EXPECT().Get(M3DBNodeNamespacesKey).Return(mockValue, nil)
+
+	var updated *nsproto.Registry
+	mockKV.EXPECT().CheckAndSet(M3DBNodeNamespacesKey, 1, gomock.Not(nil)).
+		DoAndReturn(func(_ string, _ int, value *nsproto.Registry) (int, error) {
+			updated = value
+			return 2, nil
+		})
+	cloneHandler.ServeHTTP(w, req)
+
+	resp = w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NotNil(t, updated)
+	require.Len(t, updated.Namespaces, 2)
+	cloned := updated.Namespaces["metrics-clone"]
+	require.NotNil(t, cloned)
+	assert.Equal(t, "metrics", cloned.CloneSource)
+	assert.True(t, cloned.FlushEnabled)
+	assert.Equal(t, int64(14400000000000), cloned.RetentionOptions.BlockSizeNanos)
+	assert.Equal(t, int64(14400000000000), cloned.IndexOptions.BlockSizeNanos)
+	assert.Equal(t, "", updated.Namespaces["metrics"].CloneSource)
+}
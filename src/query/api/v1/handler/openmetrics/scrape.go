@@ -0,0 +1,257 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package openmetrics provides an HTTP handler that proxies the internal
+// metrics scrape endpoint, optionally filtered to a set of subsystems.
+package openmetrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3x/instrument"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ScrapeURL is the url for the scrape handler.
+	ScrapeURL = handler.RoutePrefixV1 + "/openmetrics"
+
+	// ScrapeHTTPMethod is the HTTP method used with this resource.
+	ScrapeHTTPMethod = http.MethodGet
+
+	subsystemParam = "subsystem"
+
+	defaultTimeout     = 10 * time.Second
+	defaultHandlerPath = "/metrics"
+	maxLineBytes       = 1 << 20
+)
+
+var (
+	errNoPrometheusReporter = errors.New(
+		"scrape endpoint requires a prometheus reporter with a listen address")
+)
+
+// Configuration configures the scrape endpoint.
+type Configuration struct {
+	// Subsystems is the allowlist of subsystems whose metrics can be
+	// scraped, metrics of every subsystem are exposed if empty.
+	Subsystems []xmetrics.Subsystem `yaml:"subsystems"`
+
+	// URL is the internal metrics endpoint to proxy, defaults to the
+	// endpoint of the Prometheus reporter.
+	URL string `yaml:"url"`
+
+	// Timeout is the timeout of requests to the internal metrics endpoint.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// NewScrapeHandler returns a scrape handler for the metrics configuration.
+func (c Configuration) NewScrapeHandler(
+	metricsCfg instrument.MetricsConfiguration,
+) (*ScrapeHandler, error) {
+	url := c.URL
+	if url == "" {
+		reporterCfg := metricsCfg.PrometheusReporter
+		if reporterCfg == nil || reporterCfg.ListenAddress == "" {
+			return nil, errNoPrometheusReporter
+		}
+
+		host, port, err := net.SplitHostPort(reporterCfg.ListenAddress)
+		if err != nil {
+			return nil, err
+		}
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			host = "localhost"
+		}
+
+		handlerPath := reporterCfg.HandlerPath
+		if handlerPath == "" {
+			handlerPath = defaultHandlerPath
+		}
+		url = "http://" + net.JoinHostPort(host, port) + handlerPath
+	}
+
+	var rootPrefix string
+	if metricsCfg.RootScope != nil {
+		rootPrefix = metricsCfg.RootScope.Prefix
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	client := &http.Client{Timeout: timeout}
+	return NewScrapeHandler(url, rootPrefix, c.Subsystems, client), nil
+}
+
+// ScrapeHandler proxies the internal metrics endpoint, only exposing
+// metrics of allowed subsystems. A subset of the allowed subsystems can be
+// selected with the subsystem query parameter.
+type ScrapeHandler struct {
+	url        string
+	rootPrefix string
+	allowlist  []xmetrics.Subsystem
+	client     *http.Client
+}
+
+// NewScrapeHandler returns a new scrape handler, all subsystems are
+// allowed if the allowlist is empty.
+func NewScrapeHandler(
+	url string,
+	rootPrefix string,
+	allowlist []xmetrics.Subsystem,
+	client *http.Client,
+) *ScrapeHandler {
+	return &ScrapeHandler{
+		url:        url,
+		rootPrefix: rootPrefix,
+		allowlist:  allowlist,
+		client:     client,
+	}
+}
+
+func (h *ScrapeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	subsystems, err := h.subsystems(r)
+	if err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, h.url, nil)
+	if err != nil {
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := h.client.Do(req.WithContext(r.Context()))
+	if err != nil {
+		logger.Error("unable to scrape internal metrics", zap.Any("error", err))
+		handler.Error(w, err, http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("internal metrics endpoint returned status %d", resp.StatusCode)
+		handler.Error(w, err, http.StatusBadGateway)
+		return
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if len(subsystems) == 0 {
+		_, err = io.Copy(w, resp.Body)
+	} else {
+		filter := xmetrics.NewSubsystemFilter(h.rootPrefix, subsystems)
+		err = filterMetrics(w, resp.Body, filter)
+	}
+	if err != nil {
+		logger.Error("unable to write scraped metrics", zap.Any("error", err))
+	}
+}
+
+// subsystems returns the subsystems to expose, or none if every subsystem
+// is exposed.
+func (h *ScrapeHandler) subsystems(r *http.Request) ([]xmetrics.Subsystem, error) {
+	requested := r.URL.Query()[subsystemParam]
+	if len(requested) == 0 {
+		return h.allowlist, nil
+	}
+
+	subsystems := make([]xmetrics.Subsystem, 0, len(requested))
+	for _, name := range requested {
+		subsystem := xmetrics.Subsystem(name)
+		if !h.allowed(subsystem) {
+			return nil, fmt.Errorf("subsystem %s is not allowed", name)
+		}
+		subsystems = append(subsystems, subsystem)
+	}
+	return subsystems, nil
+}
+
+func (h *ScrapeHandler) allowed(subsystem xmetrics.Subsystem) bool {
+	if len(h.allowlist) == 0 {
+		return true
+	}
+	for _, allowed := range h.allowlist {
+		if allowed == subsystem {
+			return true
+		}
+	}
+	return false
+}
+
+// filterMetrics copies the metrics of the text exposition format that match
+// the filter, along with their HELP and TYPE comments.
+func filterMetrics(w io.Writer, r io.Reader, filter xmetrics.SubsystemFilter) error {
+	var (
+		scanner = bufio.NewScanner(r)
+		writer  = bufio.NewWriter(w)
+	)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name := metricName(line); name != "" && !filter.Match(name) {
+			continue
+		}
+		if _, err := writer.WriteString(line); err != nil {
+			return err
+		}
+		if err := writer.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// metricName returns the metric name of a sample or of a HELP or TYPE
+// comment, or an empty string for any other line.
+func metricName(line string) string {
+	if strings.HasPrefix(line, "#") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || (fields[1] != "HELP" && fields[1] != "TYPE") {
+			return ""
+		}
+		return fields[2]
+	}
+	if end := strings.IndexAny(line, "{ "); end >= 0 {
+		return line[:end]
+	}
+	return line
+}
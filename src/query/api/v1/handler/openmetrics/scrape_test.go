@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openmetrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMetrics = `# HELP coordinator_fetch_retry_attempts fetch_retry_attempts counter
+# TYPE coordinator_fetch_retry_attempts counter
+coordinator_fetch_retry_attempts{host="a"} 3
+# HELP coordinator_request_latency request_latency histogram
+# TYPE coordinator_request_latency histogram
+coordinator_request_latency_bucket{le="0.1"} 1
+coordinator_request_latency_count 1
+# HELP coordinator_requests requests counter
+# TYPE coordinator_requests counter
+coordinator_requests 7
+`
+
+func newTestUpstream(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(testMetrics))
+	}))
+}
+
+func scrape(t *testing.T, h http.Handler, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(ScrapeHTTPMethod, ScrapeURL+query, nil)
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestScrapeHandlerProxiesAllMetrics(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	upstream := newTestUpstream(t)
+	defer upstream.Close()
+
+	h := NewScrapeHandler(upstream.URL, "coordinator", nil, http.DefaultClient)
+	recorder := scrape(t, h, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/plain; version=0.0.4", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, testMetrics, recorder.Body.String())
+}
+
+func TestScrapeHandlerFiltersAllowedSubsystems(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	upstream := newTestUpstream(t)
+	defer upstream.Close()
+
+	allowlist := []xmetrics.Subsystem{"fetch-retry", "request"}
+	h := NewScrapeHandler(upstream.URL, "coordinator", allowlist, http.DefaultClient)
+
+	recorder := scrape(t, h, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	expected := `# HELP coordinator_fetch_retry_attempts fetch_retry_attempts counter
+# TYPE coordinator_fetch_retry_attempts counter
+coordinator_fetch_retry_attempts{host="a"} 3
+# HELP coordinator_request_latency request_latency histogram
+# TYPE coordinator_request_latency histogram
+coordinator_request_latency_bucket{le="0.1"} 1
+coordinator_request_latency_count 1
+`
+	assert.Equal(t, expected, recorder.Body.String())
+
+	recorder = scrape(t, h, "?subsystem=request")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	expected = `# HELP coordinator_request_latency request_latency histogram
+# TYPE coordinator_request_latency histogram
+coordinator_request_latency_bucket{le="0.1"} 1
+coordinator_request_latency_count 1
+`
+	assert.Equal(t, expected, recorder.Body.String())
+}
+
+func TestScrapeHandlerRejectsSubsystemNotAllowed(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	upstream := newTestUpstream(t)
+	defer upstream.Close()
+
+	allowlist := []xmetrics.Subsystem{"request"}
+	h := NewScrapeHandler(upstream.URL, "coordinator", allowlist, http.DefaultClient)
+	recorder := scrape(t, h, "?subsystem=requests")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestScrapeHandlerUpstreamError(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	h := NewScrapeHandler(upstream.URL, "coordinator", nil, http.DefaultClient)
+	recorder := scrape(t, h, "")
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+
+	body, err := ioutil.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "status 500")
+}
+
+func TestConfigurationNewScrapeHandler(t *testing.T) {
+	metricsCfg := instrument.MetricsConfiguration{
+		RootScope: &instrument.ScopeConfiguration{Prefix: "coordinator"},
+		PrometheusReporter: &instrument.PrometheusConfiguration{
+			HandlerPath:   "/metrics",
+			ListenAddress: "0.0.0.0:7203",
+		},
+	}
+
+	h, err := Configuration{}.NewScrapeHandler(metricsCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:7203/metrics", h.url)
+	assert.Equal(t, "coordinator", h.rootPrefix)
+
+	_, err = Configuration{}.NewScrapeHandler(instrument.MetricsConfiguration{})
+	assert.Equal(t, errNoPrometheusReporter, err)
+
+	h, err = Configuration{URL: "http://m3query:7203/metrics"}.
+		NewScrapeHandler(instrument.MetricsConfiguration{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://m3query:7203/metrics", h.url)
+}
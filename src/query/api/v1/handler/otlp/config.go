@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/query/storage"
+)
+
+// Configuration is the configuration of the OTLP metrics receiver.
+type Configuration struct {
+	// GRPCListenAddress is the address OTLP/gRPC exports are received on,
+	// OTLP/HTTP exports are always received by the HTTP server.
+	GRPCListenAddress string `yaml:"grpcListenAddress"`
+
+	// Namespaces are the namespaces datapoints are written to, defaults to
+	// the unaggregated namespace.
+	Namespaces []NamespaceConfiguration `yaml:"namespaces"`
+
+	// ResourceAttributes are the resource attributes written as tags,
+	// defaults to all resource attributes.
+	ResourceAttributes []string `yaml:"resourceAttributes"`
+}
+
+// NamespaceConfiguration selects a namespace by its attributes.
+type NamespaceConfiguration struct {
+	// Type is the metrics type of the namespace.
+	Type storage.MetricsType `yaml:"type"`
+
+	// Retention is the retention of an aggregated namespace.
+	Retention time.Duration `yaml:"retention" validate:"min=0"`
+
+	// Resolution is the resolution of an aggregated namespace.
+	Resolution time.Duration `yaml:"resolution" validate:"min=0"`
+}
+
+func (c Configuration) attributes() []storage.Attributes {
+	if len(c.Namespaces) == 0 {
+		return []storage.Attributes{
+			{MetricsType: storage.UnaggregatedMetricsType},
+		}
+	}
+	attrs := make([]storage.Attributes, 0, len(c.Namespaces))
+	for _, ns := range c.Namespaces {
+		attrs = append(attrs, storage.Attributes{
+			MetricsType: ns.Type,
+			Retention:   ns.Retention,
+			Resolution:  ns.Resolution,
+		})
+	}
+	return attrs
+}
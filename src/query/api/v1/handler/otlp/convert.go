@@ -0,0 +1,505 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/otlppb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/temporality"
+	"github.com/m3db/m3/src/query/ts"
+	xtime "github.com/m3db/m3x/time"
+)
+
+const (
+	serviceNameAttribute       = "service.name"
+	serviceNamespaceAttribute  = "service.namespace"
+	serviceInstanceIDAttribute = "service.instance.id"
+
+	jobTag          = "job"
+	instanceTag     = "instance"
+	scopeNameTag    = "otel_scope_name"
+	scopeVersionTag = "otel_scope_version"
+	bucketTag       = "le"
+	quantileTag     = "quantile"
+
+	bucketSuffix = "_bucket"
+	countSuffix  = "_count"
+	sumSuffix    = "_sum"
+
+	// noRecordedValueFlag is set on datapoints that mark the absence of a
+	// value, they are not written.
+	noRecordedValueFlag = uint32(otlppb.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK)
+
+	deltaTemporality      = otlppb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	cumulativeTemporality = otlppb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+)
+
+// converter converts OTLP metrics into one write per series, resource and
+// scope attributes are written as tags of every series of the resource and
+// scope with datapoint attributes taking precedence.
+type converter struct {
+	// resourceAttributes are the resource attributes written as tags, all
+	// are written when nil.
+	resourceAttributes map[string]struct{}
+
+	writes     []*storage.WriteQuery
+	byID       map[string]*storage.WriteQuery
+	datapoints int
+	rejected   int
+	rejectErr  error
+}
+
+func newConverter(resourceAttributes []string) *converter {
+	c := &converter{byID: make(map[string]*storage.WriteQuery)}
+	if len(resourceAttributes) > 0 {
+		c.resourceAttributes = make(map[string]struct{}, len(resourceAttributes))
+		for _, attr := range resourceAttributes {
+			c.resourceAttributes[attr] = struct{}{}
+		}
+	}
+	return c
+}
+
+// convert converts all metrics of the request.
+func (c *converter) convert(req *otlppb.ExportMetricsServiceRequest) {
+	for _, rm := range req.ResourceMetrics {
+		resourceTags := c.resourceTags(rm.Resource)
+		for _, sm := range rm.ScopeMetrics {
+			scopeTags := copyTags(resourceTags, 2)
+			if name := sm.GetScope().GetName(); name != "" {
+				scopeTags[scopeNameTag] = name
+			}
+			if version := sm.GetScope().GetVersion(); version != "" {
+				scopeTags[scopeVersionTag] = version
+			}
+			for _, metric := range sm.Metrics {
+				c.convertMetric(scopeTags, metric)
+			}
+		}
+	}
+
+	for _, write := range c.writes {
+		dps := write.Datapoints
+		sort.SliceStable(dps, func(i, j int) bool {
+			return dps[i].Timestamp.Before(dps[j].Timestamp)
+		})
+	}
+}
+
+// resourceTags returns the tags of a resource, the service name and
+// instance ID identify the job and instance the same way as scraped series.
+func (c *converter) resourceTags(resource *otlppb.Resource) models.Tags {
+	var (
+		attributes     = resource.GetAttributes()
+		tags           = make(models.Tags, len(attributes))
+		service, svcNS string
+	)
+	for _, kv := range attributes {
+		switch kv.Key {
+		case serviceNameAttribute:
+			service = attributeValue(kv.Value)
+			continue
+		case serviceNamespaceAttribute:
+			svcNS = attributeValue(kv.Value)
+			continue
+		case serviceInstanceIDAttribute:
+			tags[instanceTag] = attributeValue(kv.Value)
+			continue
+		}
+		if c.resourceAttributes != nil {
+			if _, ok := c.resourceAttributes[kv.Key]; !ok {
+				continue
+			}
+		}
+		tags[sanitizeTagName(kv.Key)] = attributeValue(kv.Value)
+	}
+	if service != "" {
+		if svcNS != "" {
+			service = svcNS + "/" + service
+		}
+		tags[jobTag] = service
+	}
+	return tags
+}
+
+func (c *converter) convertMetric(scopeTags models.Tags, metric *otlppb.Metric) {
+	name := sanitizeMetricName(metric.Name)
+	if name == "" {
+		c.reject(metricDataPoints(metric), fmt.Errorf("metric has no name"))
+		return
+	}
+
+	switch data := metric.Data.(type) {
+	case *otlppb.Metric_Gauge:
+		for _, p := range data.Gauge.GetDataPoints() {
+			if p.Flags&noRecordedValueFlag != 0 {
+				continue
+			}
+			tags := pointTags(scopeTags, name, p.Attributes, false)
+			c.add(tags, p.TimeUnixNano, numberValue(p))
+		}
+
+	case *otlppb.Metric_Sum:
+		sum := data.Sum
+		if err := validateTemporality(sum.GetAggregationTemporality()); err != nil {
+			c.reject(len(sum.GetDataPoints()), fmt.Errorf("sum %s: %v", name, err))
+			return
+		}
+		delta := sum.GetAggregationTemporality() == deltaTemporality
+		if delta && !sum.GetIsMonotonic() {
+			// Delta conversion only accumulates monotonic counters.
+			c.reject(len(sum.GetDataPoints()), fmt.Errorf(
+				"sum %s: non-monotonic delta sums are not supported", name))
+			return
+		}
+		for _, p := range sum.GetDataPoints() {
+			if p.Flags&noRecordedValueFlag != 0 {
+				continue
+			}
+			tags := pointTags(scopeTags, name, p.Attributes, delta)
+			c.add(tags, p.TimeUnixNano, numberValue(p))
+		}
+
+	case *otlppb.Metric_Histogram:
+		hist := data.Histogram
+		if err := validateTemporality(hist.GetAggregationTemporality()); err != nil {
+			c.reject(len(hist.GetDataPoints()), fmt.Errorf("histogram %s: %v", name, err))
+			return
+		}
+		delta := hist.GetAggregationTemporality() == deltaTemporality
+		for _, p := range hist.GetDataPoints() {
+			if p.Flags&noRecordedValueFlag != 0 {
+				continue
+			}
+			c.convertHistogram(scopeTags, name, p, delta)
+		}
+
+	case *otlppb.Metric_ExponentialHistogram:
+		hist := data.ExponentialHistogram
+		if err := validateTemporality(hist.GetAggregationTemporality()); err != nil {
+			c.reject(len(hist.GetDataPoints()),
+				fmt.Errorf("exponential histogram %s: %v", name, err))
+			return
+		}
+		delta := hist.GetAggregationTemporality() == deltaTemporality
+		for _, p := range hist.GetDataPoints() {
+			if p.Flags&noRecordedValueFlag != 0 {
+				continue
+			}
+			c.convertExponentialHistogram(scopeTags, name, p, delta)
+		}
+
+	case *otlppb.Metric_Summary:
+		for _, p := range data.Summary.GetDataPoints() {
+			if p.Flags&noRecordedValueFlag != 0 {
+				continue
+			}
+			c.convertSummary(scopeTags, name, p)
+		}
+
+	default:
+		c.reject(0, fmt.Errorf("metric %s has no data", name))
+	}
+}
+
+// convertHistogram writes a histogram as cumulative bucket series tagged
+// by their upper bound with a count and a sum series.
+func (c *converter) convertHistogram(
+	scopeTags models.Tags,
+	name string,
+	p *otlppb.HistogramDataPoint,
+	delta bool,
+) {
+	if len(p.BucketCounts) > 0 && len(p.BucketCounts) != len(p.ExplicitBounds)+1 {
+		c.reject(1, fmt.Errorf(
+			"histogram %s: %d bucket counts do not match %d explicit bounds",
+			name, len(p.BucketCounts), len(p.ExplicitBounds)))
+		return
+	}
+
+	var (
+		bucketTags = pointTags(scopeTags, name+bucketSuffix, p.Attributes, delta)
+		cumulative uint64
+	)
+	if len(p.BucketCounts) > 0 {
+		for i, bound := range p.ExplicitBounds {
+			cumulative += p.BucketCounts[i]
+			c.addBucket(bucketTags, bound, p.TimeUnixNano, float64(cumulative))
+		}
+	}
+	c.addBucket(bucketTags, math.Inf(1), p.TimeUnixNano, float64(p.Count))
+
+	c.add(pointTags(scopeTags, name+countSuffix, p.Attributes, delta),
+		p.TimeUnixNano, float64(p.Count))
+	if sum, ok := p.OptionalSum.(*otlppb.HistogramDataPoint_Sum); ok {
+		c.add(pointTags(scopeTags, name+sumSuffix, p.Attributes, delta),
+			p.TimeUnixNano, sum.Sum)
+	}
+}
+
+// convertExponentialHistogram writes an exponential histogram the same way
+// as a histogram with the bounds of its buckets, negative buckets first
+// followed by the zero bucket and the positive buckets.
+func (c *converter) convertExponentialHistogram(
+	scopeTags models.Tags,
+	name string,
+	p *otlppb.ExponentialHistogramDataPoint,
+	delta bool,
+) {
+	var (
+		bucketTags = pointTags(scopeTags, name+bucketSuffix, p.Attributes, delta)
+		cumulative uint64
+	)
+
+	// Bucket index i holds values with absolute values in
+	// (base^i, base^(i+1)] where base is 2^(2^-scale).
+	bound := func(index int32) float64 {
+		return math.Exp2(float64(index) * math.Exp2(-float64(p.Scale)))
+	}
+	negative := p.Negative.GetBucketCounts()
+	for i := len(negative) - 1; i >= 0; i-- {
+		cumulative += negative[i]
+		c.addBucket(bucketTags, -bound(p.Negative.GetOffset()+int32(i)),
+			p.TimeUnixNano, float64(cumulative))
+	}
+	cumulative += p.ZeroCount
+	c.addBucket(bucketTags, p.ZeroThreshold, p.TimeUnixNano, float64(cumulative))
+	for i, count := range p.Positive.GetBucketCounts() {
+		cumulative += count
+		c.addBucket(bucketTags, bound(p.Positive.GetOffset()+int32(i)+1),
+			p.TimeUnixNano, float64(cumulative))
+	}
+	c.addBucket(bucketTags, math.Inf(1), p.TimeUnixNano, float64(p.Count))
+
+	c.add(pointTags(scopeTags, name+countSuffix, p.Attributes, delta),
+		p.TimeUnixNano, float64(p.Count))
+	if sum, ok := p.OptionalSum.(*otlppb.ExponentialHistogramDataPoint_Sum); ok {
+		c.add(pointTags(scopeTags, name+sumSuffix, p.Attributes, delta),
+			p.TimeUnixNano, sum.Sum)
+	}
+}
+
+// convertSummary writes a summary as quantile series tagged by their
+// quantile with a count and a sum series.
+func (c *converter) convertSummary(
+	scopeTags models.Tags,
+	name string,
+	p *otlppb.SummaryDataPoint,
+) {
+	quantileTags := pointTags(scopeTags, name, p.Attributes, false)
+	for _, q := range p.QuantileValues {
+		tags := copyTags(quantileTags, 0)
+		tags[quantileTag] = formatFloat(q.Quantile)
+		c.add(tags, p.TimeUnixNano, q.Value)
+	}
+	c.add(pointTags(scopeTags, name+countSuffix, p.Attributes, false),
+		p.TimeUnixNano, float64(p.Count))
+	c.add(pointTags(scopeTags, name+sumSuffix, p.Attributes, false),
+		p.TimeUnixNano, p.Sum)
+}
+
+func (c *converter) addBucket(
+	bucketTags models.Tags,
+	bound float64,
+	timeUnixNano uint64,
+	value float64,
+) {
+	tags := copyTags(bucketTags, 0)
+	tags[bucketTag] = formatFloat(bound)
+	c.add(tags, timeUnixNano, value)
+}
+
+func (c *converter) add(tags models.Tags, timeUnixNano uint64, value float64) {
+	if timeUnixNano == 0 {
+		c.reject(1, fmt.Errorf("series %s has no timestamp", tags.ID()))
+		return
+	}
+
+	id := tags.ID()
+	write, ok := c.byID[id]
+	if !ok {
+		write = &storage.WriteQuery{
+			Tags: tags,
+			Unit: xtime.Millisecond,
+		}
+		c.byID[id] = write
+		c.writes = append(c.writes, write)
+	}
+	write.Datapoints = append(write.Datapoints, ts.Datapoint{
+		Timestamp: time.Unix(0, int64(timeUnixNano)),
+		Value:     value,
+	})
+	c.datapoints++
+}
+
+// reject counts rejected datapoints, the first error is kept as the reason
+// returned to the exporter.
+func (c *converter) reject(datapoints int, err error) {
+	c.rejected += datapoints
+	if c.rejectErr == nil {
+		c.rejectErr = err
+	}
+}
+
+func validateTemporality(t otlppb.AggregationTemporality) error {
+	switch t {
+	case deltaTemporality, cumulativeTemporality:
+		return nil
+	}
+	return fmt.Errorf("invalid aggregation temporality %d", t)
+}
+
+func metricDataPoints(metric *otlppb.Metric) int {
+	switch data := metric.Data.(type) {
+	case *otlppb.Metric_Gauge:
+		return len(data.Gauge.GetDataPoints())
+	case *otlppb.Metric_Sum:
+		return len(data.Sum.GetDataPoints())
+	case *otlppb.Metric_Histogram:
+		return len(data.Histogram.GetDataPoints())
+	case *otlppb.Metric_ExponentialHistogram:
+		return len(data.ExponentialHistogram.GetDataPoints())
+	case *otlppb.Metric_Summary:
+		return len(data.Summary.GetDataPoints())
+	}
+	return 0
+}
+
+// numberValue returns the value of a datapoint.
+func numberValue(p *otlppb.NumberDataPoint) float64 {
+	switch v := p.Value.(type) {
+	case *otlppb.NumberDataPoint_AsInt:
+		return float64(v.AsInt)
+	case *otlppb.NumberDataPoint_AsDouble:
+		return v.AsDouble
+	}
+	return 0
+}
+
+// attributeValue returns an attribute value as a tag value, arrays and
+// lists of attributes are rendered as JSON.
+func attributeValue(v *otlppb.AnyValue) string {
+	switch v := v.GetValue().(type) {
+	case *otlppb.AnyValue_StringValue:
+		return v.StringValue
+	case *otlppb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *otlppb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *otlppb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case *otlppb.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case *otlppb.AnyValue_ArrayValue:
+		values := make([]string, 0, len(v.ArrayValue.GetValues()))
+		for _, value := range v.ArrayValue.GetValues() {
+			data, _ := json.Marshal(attributeValue(value))
+			values = append(values, string(data))
+		}
+		return "[" + strings.Join(values, ",") + "]"
+	case *otlppb.AnyValue_KvlistValue:
+		values := make([]string, 0, len(v.KvlistValue.GetValues()))
+		for _, kv := range v.KvlistValue.GetValues() {
+			key, _ := json.Marshal(kv.Key)
+			value, _ := json.Marshal(attributeValue(kv.Value))
+			values = append(values, string(key)+":"+string(value))
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	}
+	return ""
+}
+
+// pointTags returns the tags of a series of a datapoint, delta series are
+// tagged to be converted to cumulative series as they are written.
+func pointTags(
+	scopeTags models.Tags,
+	name string,
+	attributes []*otlppb.KeyValue,
+	delta bool,
+) models.Tags {
+	tags := copyTags(scopeTags, len(attributes)+2)
+	for _, kv := range attributes {
+		tags[sanitizeTagName(kv.Key)] = attributeValue(kv.Value)
+	}
+	tags[models.MetricName] = name
+	if delta {
+		tags[temporality.DefaultTagName] = temporality.DeltaTagValue
+	}
+	return tags
+}
+
+func copyTags(tags models.Tags, extra int) models.Tags {
+	copied := make(models.Tags, len(tags)+extra)
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return copied
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sanitizeMetricName replaces characters not valid in Prometheus metric
+// names, such as the dots of OTel metric names, with underscores.
+func sanitizeMetricName(name string) string {
+	return sanitize(name, true)
+}
+
+// sanitizeTagName replaces characters not valid in Prometheus label names
+// with underscores.
+func sanitizeTagName(name string) string {
+	return sanitize(name, false)
+}
+
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return ""
+	}
+	b := []byte(name)
+	for i, ch := range b {
+		valid := ch == '_' ||
+			(ch >= 'a' && ch <= 'z') ||
+			(ch >= 'A' && ch <= 'Z') ||
+			(ch >= '0' && ch <= '9') ||
+			(ch == ':' && allowColon)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return "_" + string(b)
+	}
+	return string(b)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/generated/proto/otlppb"
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertExponentialHistogram(t *testing.T) {
+	c := newConverter(nil)
+	c.convert(&otlppb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*otlppb.ResourceMetrics{{
+			ScopeMetrics: []*otlppb.ScopeMetrics{{
+				Metrics: []*otlppb.Metric{{
+					Name: "latency",
+					Data: &otlppb.Metric_ExponentialHistogram{
+						ExponentialHistogram: &otlppb.ExponentialHistogram{
+							AggregationTemporality: otlppb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+							DataPoints: []*otlppb.ExponentialHistogramDataPoint{{
+								TimeUnixNano: 1500000000000000000,
+								Count:        6,
+								OptionalSum:  &otlppb.ExponentialHistogramDataPoint_Sum{Sum: 3},
+								Scale:        0,
+								ZeroCount:    1,
+								Negative: &otlppb.ExponentialHistogramDataPoint_Buckets{
+									Offset:       1,
+									BucketCounts: []uint64{2},
+								},
+								Positive: &otlppb.ExponentialHistogramDataPoint_Buckets{
+									Offset:       0,
+									BucketCounts: []uint64{2, 1},
+								},
+							}},
+						},
+					},
+				}},
+			}},
+		}},
+	})
+
+	// With scale 0 bucket i holds absolute values in (2^i, 2^(i+1)].
+	buckets := make(map[string]float64)
+	for _, write := range c.writes {
+		if write.Tags[models.MetricName] == "latency_bucket" {
+			buckets[write.Tags["le"]] = write.Datapoints[0].Value
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"-2":   2,
+		"0":    3,
+		"2":    5,
+		"4":    6,
+		"+Inf": 6,
+	}, buckets)
+	assert.Equal(t, 0, c.rejected)
+	assert.Equal(t, 7, c.datapoints)
+}
+
+func TestSanitizeNames(t *testing.T) {
+	assert.Equal(t, "http_server_duration", sanitizeMetricName("http.server.duration"))
+	assert.Equal(t, "ns:rate_5m", sanitizeMetricName("ns:rate_5m"))
+	assert.Equal(t, "_5xx_count", sanitizeMetricName("5xx.count"))
+	assert.Equal(t, "service_version", sanitizeTagName("service.version"))
+	assert.Equal(t, "a_b", sanitizeTagName("a:b"))
+}
+
+func TestAttributeValue(t *testing.T) {
+	tests := []struct {
+		value    *otlppb.AnyValue
+		expected string
+	}{
+		{nil, ""},
+		{&otlppb.AnyValue{}, ""},
+		{&otlppb.AnyValue{Value: &otlppb.AnyValue_StringValue{StringValue: "a"}}, "a"},
+		{&otlppb.AnyValue{Value: &otlppb.AnyValue_BoolValue{BoolValue: true}}, "true"},
+		{&otlppb.AnyValue{Value: &otlppb.AnyValue_IntValue{IntValue: -3}}, "-3"},
+		{&otlppb.AnyValue{Value: &otlppb.AnyValue_DoubleValue{DoubleValue: 0.5}}, "0.5"},
+		{&otlppb.AnyValue{Value: &otlppb.AnyValue_BytesValue{BytesValue: []byte("ab")}}, "YWI="},
+		{&otlppb.AnyValue{Value: &otlppb.AnyValue_ArrayValue{ArrayValue: &otlppb.ArrayValue{
+			Values: []*otlppb.AnyValue{
+				{Value: &otlppb.AnyValue_StringValue{StringValue: "a"}},
+				{Value: &otlppb.AnyValue_IntValue{IntValue: 1}},
+			},
+		}}}, `["a","1"]`},
+		{&otlppb.AnyValue{Value: &otlppb.AnyValue_KvlistValue{KvlistValue: &otlppb.KeyValueList{
+			Values: []*otlppb.KeyValue{
+				{Key: "k", Value: &otlppb.AnyValue{Value: &otlppb.AnyValue_BoolValue{BoolValue: false}}},
+			},
+		}}}, `{"k":"false"}`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, attributeValue(test.value))
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"net"
+
+	"github.com/m3db/m3/src/dbnode/x/tracing"
+	"github.com/m3db/m3/src/query/generated/proto/otlppb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type metricsService struct {
+	ingester *Ingester
+}
+
+// NewGRPCServer returns a server receiving OTLP/gRPC metrics exports.
+func NewGRPCServer(ingester *Ingester) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(tracing.UnaryServerInterceptor()),
+		// OTLP exporters compress exports with gzip by default.
+		grpc.RPCDecompressor(grpc.NewGZIPDecompressor()),
+	)
+	otlppb.RegisterMetricsServiceServer(server, &metricsService{ingester: ingester})
+	return server
+}
+
+// StartGRPCServer starts the server on the given address, then notifies
+// the channel.
+func StartGRPCServer(
+	server *grpc.Server,
+	address string,
+	waitForStart chan<- struct{},
+) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	waitForStart <- struct{}{}
+	return server.Serve(lis)
+}
+
+func (s *metricsService) Export(
+	ctx context.Context,
+	req *otlppb.ExportMetricsServiceRequest,
+) (*otlppb.ExportMetricsServiceResponse, error) {
+	result, err := s.ingester.Ingest(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return newExportResponse(result), nil
+}
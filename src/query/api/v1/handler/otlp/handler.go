@@ -0,0 +1,215 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/m3db/m3/src/dbnode/x/logsample"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/generated/proto/otlppb"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// MetricsURL is the url OTLP/HTTP metrics exports are received on.
+	MetricsURL = handler.RoutePrefixV1 + "/otlp/v1/metrics"
+
+	// MetricsHTTPMethod is the HTTP method used with this resource.
+	MetricsHTTPMethod = http.MethodPost
+
+	// WriteErrorLogClass is the log sampling class of write errors.
+	WriteErrorLogClass = "otlp-write"
+
+	// RejectedLogClass is the log sampling class of exports with rejected
+	// datapoints.
+	RejectedLogClass = "otlp-rejected"
+
+	protobufContentType = "application/x-protobuf"
+	jsonContentType     = "application/json"
+
+	// maxBodyBytes limits the size of a decompressed request body.
+	maxBodyBytes = 64 << 20
+)
+
+var errBodyTooLarge = fmt.Errorf("request body exceeds %d bytes", maxBodyBytes)
+
+func errUnsupportedContentType(contentType string) error {
+	return fmt.Errorf("unsupported content type %q, expected %s or %s",
+		contentType, protobufContentType, jsonContentType)
+}
+
+func errUnsupportedContentEncoding(encoding string) error {
+	return fmt.Errorf("unsupported content encoding %q", encoding)
+}
+
+// Handler receives OTLP/HTTP metrics exports in either the protobuf or the
+// JSON encoding.
+type Handler struct {
+	ingester      *Ingester
+	metrics       handlerMetrics
+	sampledLogger *logging.SampledLogger
+}
+
+// NewHandler returns a new instance of the OTLP/HTTP handler, errors are
+// logged through the log sampler.
+func NewHandler(
+	ingester *Ingester,
+	logSampler *logsample.Sampler,
+	scope tally.Scope,
+) http.Handler {
+	return &Handler{
+		ingester:      ingester,
+		metrics:       newHandlerMetrics(scope),
+		sampledLogger: logging.NewSampledLogger(logSampler),
+	}
+}
+
+type handlerMetrics struct {
+	writeErrorsServer tally.Counter
+	writeErrorsClient tally.Counter
+}
+
+func newHandlerMetrics(scope tally.Scope) handlerMetrics {
+	return handlerMetrics{
+		writeErrorsServer: scope.Tagged(map[string]string{"code": "5XX"}).Counter("write.errors"),
+		writeErrorsClient: scope.Tagged(map[string]string{"code": "4XX"}).Counter("write.errors"),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType := protobufContentType
+	if header := r.Header.Get("Content-Type"); header != "" {
+		mediaType, _, err := mime.ParseMediaType(header)
+		if err != nil || (mediaType != protobufContentType && mediaType != jsonContentType) {
+			h.metrics.writeErrorsClient.Inc(1)
+			handler.Error(w, errUnsupportedContentType(header),
+				http.StatusUnsupportedMediaType)
+			return
+		}
+		contentType = mediaType
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		h.metrics.writeErrorsClient.Inc(1)
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	var req otlppb.ExportMetricsServiceRequest
+	if contentType == jsonContentType {
+		// Fields added by later versions of the protocol are ignored, as
+		// they are by the protobuf decoding.
+		unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+		err = unmarshaler.Unmarshal(bytes.NewReader(body), &req)
+	} else {
+		err = req.Unmarshal(body)
+	}
+	if err != nil {
+		h.metrics.writeErrorsClient.Inc(1)
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.ingester.Ingest(r.Context(), &req)
+	if err != nil {
+		h.metrics.writeErrorsServer.Inc(1)
+		h.sampledLogger.Error(r.Context(), WriteErrorLogClass,
+			"otlp write error", zap.Any("err", err))
+		// OTLP exporters only retry exports rejected as unavailable.
+		handler.Error(w, err, http.StatusServiceUnavailable)
+		return
+	}
+	if result.Rejected > 0 {
+		h.sampledLogger.Warn(r.Context(), RejectedLogClass,
+			"otlp export datapoints rejected",
+			zap.Int("rejected", result.Rejected),
+			zap.String("reason", result.RejectedReason))
+	}
+
+	var (
+		resp = newExportResponse(result)
+		data []byte
+	)
+	if contentType == jsonContentType {
+		var buf bytes.Buffer
+		err = (&jsonpb.Marshaler{}).Marshal(&buf, resp)
+		data = buf.Bytes()
+	} else {
+		data, err = resp.Marshal()
+	}
+	if err != nil {
+		h.metrics.writeErrorsServer.Inc(1)
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	var body io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	default:
+		return nil, errUnsupportedContentEncoding(r.Header.Get("Content-Encoding"))
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBodyBytes {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
+
+// newExportResponse returns the response to an export, whose partial
+// success is only set when datapoints were rejected.
+func newExportResponse(result Result) *otlppb.ExportMetricsServiceResponse {
+	resp := &otlppb.ExportMetricsServiceResponse{}
+	if result.Rejected > 0 {
+		resp.PartialSuccess = &otlppb.ExportMetricsPartialSuccess{
+			RejectedDataPoints: int64(result.Rejected),
+			ErrorMessage:       result.RejectedReason,
+		}
+	}
+	return resp
+}
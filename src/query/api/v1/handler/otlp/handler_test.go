@@ -0,0 +1,311 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/otlppb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestHandler(t *testing.T, cfg Configuration) (http.Handler, mock.Storage) {
+	logging.InitWithCores(nil)
+
+	store := mock.NewMockStorage()
+	ingester, err := NewIngester(store, cfg, tally.NoopScope)
+	require.NoError(t, err)
+	return NewHandler(ingester, nil, tally.NoopScope), store
+}
+
+func stringAttribute(key, value string) *otlppb.KeyValue {
+	return &otlppb.KeyValue{
+		Key:   key,
+		Value: &otlppb.AnyValue{Value: &otlppb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func newGaugeExport(name string, t time.Time, value float64) *otlppb.ExportMetricsServiceRequest {
+	return &otlppb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*otlppb.ResourceMetrics{{
+			Resource: &otlppb.Resource{
+				Attributes: []*otlppb.KeyValue{
+					stringAttribute("service.name", "checkout"),
+					stringAttribute("service.instance.id", "pod-1"),
+					stringAttribute("k8s.namespace.name", "prod"),
+				},
+			},
+			ScopeMetrics: []*otlppb.ScopeMetrics{{
+				Scope: &otlppb.InstrumentationScope{
+					Name:    "io.opentelemetry.runtime",
+					Version: "1.0",
+				},
+				Metrics: []*otlppb.Metric{{
+					Name: name,
+					Data: &otlppb.Metric_Gauge{
+						Gauge: &otlppb.Gauge{
+							DataPoints: []*otlppb.NumberDataPoint{{
+								Attributes:   []*otlppb.KeyValue{stringAttribute("http.method", "GET")},
+								TimeUnixNano: uint64(t.UnixNano()),
+								Value:        &otlppb.NumberDataPoint_AsDouble{AsDouble: value},
+							}},
+						},
+					},
+				}},
+			}},
+		}},
+	}
+}
+
+func TestOTLPProtobuf(t *testing.T) {
+	h, store := newTestHandler(t, Configuration{})
+
+	now := time.Unix(1500000000, 0)
+	var body bytes.Buffer
+	data, err := newGaugeExport("process.memory.usage", now, 42).Marshal()
+	require.NoError(t, err)
+	gz := gzip.NewWriter(&body)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	req := httptest.NewRequest(MetricsHTTPMethod, MetricsURL, &body)
+	req.Header.Set("Content-Type", protobufContentType)
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, protobufContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, 0, w.Body.Len())
+
+	writes := store.Writes()
+	require.Equal(t, 1, len(writes))
+	assert.Equal(t, models.Tags{
+		models.MetricName:    "process_memory_usage",
+		"http_method":        "GET",
+		"job":                "checkout",
+		"instance":           "pod-1",
+		"k8s_namespace_name": "prod",
+		"otel_scope_name":    "io.opentelemetry.runtime",
+		"otel_scope_version": "1.0",
+	}, writes[0].Tags)
+	require.Equal(t, 1, len(writes[0].Datapoints))
+	assert.True(t, now.Equal(writes[0].Datapoints[0].Timestamp))
+	assert.Equal(t, 42.0, writes[0].Datapoints[0].Value)
+	assert.Equal(t, storage.UnaggregatedMetricsType, writes[0].Attributes.MetricsType)
+}
+
+func TestOTLPJSONHistogram(t *testing.T) {
+	h, store := newTestHandler(t, Configuration{
+		Namespaces: []NamespaceConfiguration{
+			{
+				Type:       storage.AggregatedMetricsType,
+				Retention:  48 * time.Hour,
+				Resolution: time.Minute,
+			},
+		},
+	})
+
+	body := `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{
+		"name":"http.server.duration",
+		"histogram":{"aggregationTemporality":2,"dataPoints":[{
+			"timeUnixNano":"1500000000000000000",
+			"count":"6","sum":4.5,
+			"bucketCounts":["1","3","2"],
+			"explicitBounds":[0.5,1]
+		}]}}]}]}]}`
+	req := httptest.NewRequest(MetricsHTTPMethod, MetricsURL, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "{}", w.Body.String())
+
+	values := make(map[string]float64)
+	for _, write := range store.Writes() {
+		require.Equal(t, 1, len(write.Datapoints))
+		assert.Equal(t, storage.Attributes{
+			MetricsType: storage.AggregatedMetricsType,
+			Retention:   48 * time.Hour,
+			Resolution:  time.Minute,
+		}, write.Attributes)
+		values[write.Tags[models.MetricName]+"{"+write.Tags["le"]+"}"] =
+			write.Datapoints[0].Value
+	}
+	assert.Equal(t, map[string]float64{
+		"http_server_duration_bucket{0.5}":  1,
+		"http_server_duration_bucket{1}":    4,
+		"http_server_duration_bucket{+Inf}": 6,
+		"http_server_duration_count{}":      6,
+		"http_server_duration_sum{}":        4.5,
+	}, values)
+}
+
+func TestOTLPPartialSuccess(t *testing.T) {
+	h, store := newTestHandler(t, Configuration{})
+
+	body := `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[
+		{"name":"requests","sum":{"aggregationTemporality":1,"isMonotonic":true,
+			"dataPoints":[{"timeUnixNano":"1500000000000000000","asInt":"3"}]}},
+		{"name":"queue.depth","sum":{"aggregationTemporality":1,
+			"dataPoints":[{"timeUnixNano":"1500000000000000000","asInt":"-1"}]}}
+	]}]}]}`
+	req := httptest.NewRequest(MetricsHTTPMethod, MetricsURL, strings.NewReader(body))
+	req.Header.Set("Content-Type", jsonContentType)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `{"partialSuccess":{"rejectedDataPoints":"1",`+
+		`"errorMessage":"sum queue_depth: non-monotonic delta sums are not supported"}}`,
+		w.Body.String())
+
+	writes := store.Writes()
+	require.Equal(t, 1, len(writes))
+	assert.Equal(t, "delta", writes[0].Tags["__temporality__"])
+	assert.Equal(t, 3.0, writes[0].Datapoints[0].Value)
+}
+
+func TestOTLPUnsupportedContentType(t *testing.T) {
+	h, _ := newTestHandler(t, Configuration{})
+
+	req := httptest.NewRequest(MetricsHTTPMethod, MetricsURL, strings.NewReader(""))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+// The export payloads in testdata were encoded by the upstream OTLP protobuf
+// and JSON encoders from an export of the OpenTelemetry Go SDK.
+
+func readExport(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return data
+}
+
+func TestOTLPExportRoundTrip(t *testing.T) {
+	data := readExport(t, "export.pb")
+
+	var req otlppb.ExportMetricsServiceRequest
+	require.NoError(t, req.Unmarshal(data))
+	require.Equal(t, 1, len(req.ResourceMetrics))
+	require.Equal(t, 2, len(req.ResourceMetrics[0].ScopeMetrics))
+	assert.Equal(t, len(data), req.Size())
+
+	encoded, err := req.Marshal()
+	require.NoError(t, err)
+	var decoded otlppb.ExportMetricsServiceRequest
+	require.NoError(t, decoded.Unmarshal(encoded))
+	assert.Equal(t, req, decoded)
+
+	var fromJSON otlppb.ExportMetricsServiceRequest
+	require.NoError(t, jsonpb.Unmarshal(
+		bytes.NewReader(readExport(t, "export.json")), &fromJSON))
+	assert.Equal(t, req, fromJSON)
+}
+
+func TestOTLPCorruptExport(t *testing.T) {
+	data := readExport(t, "export.pb")
+
+	decode := func(data []byte) {
+		var req otlppb.ExportMetricsServiceRequest
+		if err := req.Unmarshal(data); err != nil {
+			return
+		}
+		// Anything that decodes must encode to an export that decodes and
+		// encodes to the same bytes.
+		encoded, err := req.Marshal()
+		require.NoError(t, err)
+		var decoded otlppb.ExportMetricsServiceRequest
+		require.NoError(t, decoded.Unmarshal(encoded))
+		reencoded, err := decoded.Marshal()
+		require.NoError(t, err)
+		require.Equal(t, encoded, reencoded)
+
+		c := newConverter(nil)
+		c.convert(&req)
+	}
+
+	for i := 0; i < len(data); i++ {
+		decode(data[:i])
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		corrupt := append([]byte(nil), data...)
+		for j := 0; j < 1+rnd.Intn(4); j++ {
+			corrupt[rnd.Intn(len(corrupt))] = byte(rnd.Intn(256))
+		}
+		decode(corrupt)
+	}
+}
+
+func TestOTLPExport(t *testing.T) {
+	h, store := newTestHandler(t, Configuration{})
+
+	for _, test := range []struct {
+		contentType string
+		file        string
+	}{
+		{protobufContentType, "export.pb"},
+		{jsonContentType, "export.json"},
+	} {
+		before := len(store.Writes())
+		req := httptest.NewRequest(MetricsHTTPMethod, MetricsURL,
+			bytes.NewReader(readExport(t, test.file)))
+		req.Header.Set("Content-Type", test.contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		series := make(map[string]models.Tags)
+		for _, write := range store.Writes()[before:] {
+			require.Equal(t, 1, len(write.Datapoints))
+			series[write.Tags.ID()] = write.Tags
+		}
+		// Gauges and sums are a series per datapoint, histograms a series
+		// per bucket with a count and a sum series and summaries a series
+		// per quantile with a count and a sum series.
+		assert.Equal(t, 23, len(series), test.file)
+		for _, tags := range series {
+			assert.Equal(t, "shop/checkout", tags["job"])
+			assert.Equal(t, "pod-1", tags["instance"])
+			assert.Equal(t, `["/bin/checkout","-v"]`, tags["process_command_args"])
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package otlp provides a receiver of OpenTelemetry protocol (OTLP) metrics
+// exports over HTTP and gRPC that writes the metrics as series.
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/query/generated/proto/otlppb"
+	"github.com/m3db/m3/src/query/storage"
+	xerrors "github.com/m3db/m3x/errors"
+
+	"github.com/uber-go/tally"
+)
+
+var errNoStorage = errors.New("no storage set")
+
+// Ingester writes the metrics of OTLP exports to storage.
+type Ingester struct {
+	store              storage.Storage
+	attributes         []storage.Attributes
+	resourceAttributes []string
+	metrics            ingesterMetrics
+}
+
+// NewIngester returns a new ingester writing to the configured namespaces.
+func NewIngester(
+	store storage.Storage,
+	cfg Configuration,
+	scope tally.Scope,
+) (*Ingester, error) {
+	if store == nil {
+		return nil, errNoStorage
+	}
+	attributes := cfg.attributes()
+	for _, attrs := range attributes {
+		if err := storage.ValidateMetricsType(attrs.MetricsType); err != nil {
+			return nil, err
+		}
+		if attrs.MetricsType == storage.AggregatedMetricsType &&
+			(attrs.Retention <= 0 || attrs.Resolution <= 0) {
+			return nil, fmt.Errorf(
+				"aggregated namespace requires retention and resolution")
+		}
+	}
+	return &Ingester{
+		store:              store,
+		attributes:         attributes,
+		resourceAttributes: cfg.ResourceAttributes,
+		metrics:            newIngesterMetrics(scope),
+	}, nil
+}
+
+type ingesterMetrics struct {
+	datapointsWritten  tally.Counter
+	datapointsRejected tally.Counter
+	writeErrors        tally.Counter
+}
+
+func newIngesterMetrics(scope tally.Scope) ingesterMetrics {
+	return ingesterMetrics{
+		datapointsWritten:  scope.Counter("datapoints.written"),
+		datapointsRejected: scope.Counter("datapoints.rejected"),
+		writeErrors:        scope.Counter("write.errors"),
+	}
+}
+
+// Result is the result of an export.
+type Result struct {
+	// Datapoints is the number of datapoints written.
+	Datapoints int
+	// Series is the number of series written.
+	Series int
+	// Rejected is the number of datapoints which were not written.
+	Rejected int
+	// RejectedReason is the reason the first rejected datapoint was not
+	// written.
+	RejectedReason string
+}
+
+// Ingest writes the metrics of an export, invalid metrics are rejected
+// while the remaining metrics are written.
+func (i *Ingester) Ingest(
+	ctx context.Context,
+	req *otlppb.ExportMetricsServiceRequest,
+) (Result, error) {
+	c := newConverter(i.resourceAttributes)
+	c.convert(req)
+
+	result := Result{
+		Datapoints: c.datapoints,
+		Series:     len(c.writes),
+		Rejected:   c.rejected,
+	}
+	if c.rejectErr != nil {
+		result.RejectedReason = c.rejectErr.Error()
+	}
+	i.metrics.datapointsRejected.Inc(int64(c.rejected))
+
+	var multiErr xerrors.MultiError
+	for _, write := range c.writes {
+		for _, attrs := range i.attributes {
+			nsWrite := *write
+			nsWrite.Attributes = attrs
+			multiErr = multiErr.Add(i.store.Write(ctx, &nsWrite))
+		}
+	}
+	if err := multiErr.FinalError(); err != nil {
+		i.metrics.writeErrors.Inc(1)
+		return result, err
+	}
+
+	i.metrics.datapointsWritten.Inc(int64(c.datapoints))
+	return result, nil
+}
@@ -63,6 +63,10 @@ func (h *AddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	placement, err := h.Add(r, req)
 	if err != nil {
 		logger.Error("unable to add placement", zap.Any("error", err))
+		if constraintErr, ok := err.(*ConstraintError); ok {
+			writeConstraintError(w, constraintErr, logger)
+			return
+		}
 		handler.Error(w, err, http.StatusInternalServerError)
 		return
 	}
@@ -101,6 +105,19 @@ func (h *AddHandler) Add(
 		return nil, err
 	}
 
+	// Placements are computed without being persisted first so that a
+	// placement violating the constraints is never made.
+	if err := checkConstraints(h.cfg, func() (placement.Placement, error) {
+		dryRun, err := dryRunService(h.client, httpReq.Header)
+		if err != nil {
+			return nil, err
+		}
+		p, _, err := dryRun.AddInstances(instances)
+		return p, err
+	}); err != nil {
+		return nil, err
+	}
+
 	service, err := Service(h.client, httpReq.Header)
 	if err != nil {
 		return nil, err
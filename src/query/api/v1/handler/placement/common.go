@@ -21,6 +21,8 @@
 package placement
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
@@ -60,6 +62,20 @@ type Handler struct {
 
 // Service gets a placement service from m3cluster client
 func Service(clusterClient clusterclient.Client, headers http.Header) (placement.Service, error) {
+	return service(clusterClient, headers, false)
+}
+
+// dryRunService gets a placement service that computes placement changes
+// without persisting them.
+func dryRunService(clusterClient clusterclient.Client, headers http.Header) (placement.Service, error) {
+	return service(clusterClient, headers, true)
+}
+
+func service(
+	clusterClient clusterclient.Client,
+	headers http.Header,
+	dryRun bool,
+) (placement.Service, error) {
 	cs, err := clusterClient.Services(services.NewOverrideOptions())
 	if err != nil {
 		return nil, err
@@ -85,7 +101,10 @@ func Service(clusterClient clusterclient.Client, headers http.Header) (placement
 		SetEnvironment(serviceEnvironment).
 		SetZone(serviceZone)
 
-	ps, err := cs.PlacementService(sid, placement.NewOptions().SetValidZone(serviceZone))
+	opts := placement.NewOptions().
+		SetValidZone(serviceZone).
+		SetDryrun(dryRun)
+	ps, err := cs.PlacementService(sid, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +117,11 @@ func ConvertInstancesProto(instancesProto []*placementpb.Instance) ([]placement.
 	res := make([]placement.Instance, 0, len(instancesProto))
 
 	for _, instanceProto := range instancesProto {
+		if err := validateEndpoint(instanceProto.Endpoint); err != nil {
+			return nil, fmt.Errorf("invalid endpoint for instance %s: %v",
+				instanceProto.Id, err)
+		}
+
 		shards, err := shard.NewShardsFromProto(instanceProto.Shards)
 		if err != nil {
 			return nil, err
@@ -120,6 +144,20 @@ func ConvertInstancesProto(instancesProto []*placementpb.Instance) ([]placement.
 	return res, nil
 }
 
+// validateEndpoint validates an endpoint is of the form [scheme://]host:port,
+// IPv6 hosts must be bracketed, e.g. [::1]:9000, as otherwise the port cannot
+// be distinguished from the address.
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	if idx := strings.Index(endpoint, "://"); idx != -1 {
+		endpoint = endpoint[idx+len("://"):]
+	}
+	_, _, err := net.SplitHostPort(endpoint)
+	return err
+}
+
 // RegisterRoutes registers the placement routes
 func RegisterRoutes(r *mux.Router, client clusterclient.Client, cfg config.Configuration) {
 	logged := logging.WithResponseTimeLogging
@@ -129,4 +167,6 @@ func RegisterRoutes(r *mux.Router, client clusterclient.Client, cfg config.Confi
 	r.HandleFunc(DeleteAllURL, logged(NewDeleteAllHandler(client, cfg)).ServeHTTP).Methods(DeleteAllHTTPMethod)
 	r.HandleFunc(AddURL, logged(NewAddHandler(client, cfg)).ServeHTTP).Methods(AddHTTPMethod)
 	r.HandleFunc(DeleteURL, logged(NewDeleteHandler(client, cfg)).ServeHTTP).Methods(DeleteHTTPMethod)
+	r.HandleFunc(ViolationsURL, logged(NewViolationsHandler(client, cfg)).ServeHTTP).Methods(ViolationsHTTPMethod)
+	r.HandleFunc(PlanURL, logged(NewPlanHandler(client, cfg)).ServeHTTP).Methods(PlanHTTPMethod)
 }
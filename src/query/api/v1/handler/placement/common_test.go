@@ -217,3 +217,39 @@ func TestConvertInstancesProto(t *testing.T) {
 	})
 	require.EqualError(t, err, "invalid proto shard state")
 }
+
+func TestConvertInstancesProtoIPv6Endpoints(t *testing.T) {
+	instances, err := ConvertInstancesProto([]*placementpb.Instance{
+		&placementpb.Instance{
+			Id:       "i1",
+			Weight:   1,
+			Endpoint: "[2001:db8::1]:1234",
+			Hostname: "2001:db8::1",
+			Port:     1234,
+		},
+		&placementpb.Instance{
+			Id:       "i2",
+			Weight:   1,
+			Endpoint: "http://[::1]:1234",
+			Hostname: "::1",
+			Port:     1234,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(instances))
+	require.Equal(t, "[2001:db8::1]:1234", instances[0].Endpoint())
+	require.Equal(t, "http://[::1]:1234", instances[1].Endpoint())
+
+	for _, endpoint := range []string{"2001:db8::1:1234", "i1"} {
+		_, err = ConvertInstancesProto([]*placementpb.Instance{
+			&placementpb.Instance{
+				Id:       "i1",
+				Weight:   1,
+				Endpoint: endpoint,
+				Hostname: "i1",
+				Port:     1234,
+			},
+		})
+		require.Error(t, err, endpoint)
+	}
+}
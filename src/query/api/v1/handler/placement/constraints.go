@@ -0,0 +1,341 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/shard"
+)
+
+// FailureDomain is a level of the topology that replicas of a shard are
+// spread across.
+type FailureDomain string
+
+const (
+	// ZoneFailureDomain is the zone of an instance, the part of its
+	// isolation group before a slash if its isolation group is of the form
+	// zone/rack, otherwise the zone of the instance.
+	ZoneFailureDomain FailureDomain = "zone"
+	// RackFailureDomain is the isolation group of an instance.
+	RackFailureDomain FailureDomain = "rack"
+	// HostFailureDomain is the hostname of an instance, or its ID if it has
+	// no hostname.
+	HostFailureDomain FailureDomain = "host"
+)
+
+var (
+	validFailureDomains = []FailureDomain{
+		ZoneFailureDomain,
+		RackFailureDomain,
+		HostFailureDomain,
+	}
+
+	// defaultConstraints are checked by the violations endpoints if no
+	// constraints are configured or requested.
+	defaultConstraints = Constraints{RackFailureDomain}
+)
+
+// ParseFailureDomain parses a failure domain.
+func ParseFailureDomain(str string) (FailureDomain, error) {
+	for _, domain := range validFailureDomains {
+		if str == string(domain) {
+			return domain, nil
+		}
+	}
+	return "", fmt.Errorf("invalid failure domain '%s' valid domains are: %v",
+		str, validFailureDomains)
+}
+
+// Constraints are the failure domains that no two replicas of a shard may
+// share.
+type Constraints []FailureDomain
+
+// NewConstraints returns the constraints of the configuration, none if the
+// configuration is nil.
+func NewConstraints(cfg *config.PlacementConstraintsConfiguration) (Constraints, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	return ParseConstraints(cfg.FailureDomains)
+}
+
+// ParseConstraints parses the failure domains of constraints.
+func ParseConstraints(domains []string) (Constraints, error) {
+	constraints := make(Constraints, 0, len(domains))
+	for _, str := range domains {
+		domain, err := ParseFailureDomain(str)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, domain)
+	}
+	return constraints, nil
+}
+
+// Violation is a failure domain shared by replicas of a shard.
+type Violation struct {
+	Shard     uint32        `json:"shard"`
+	Domain    FailureDomain `json:"domain"`
+	Value     string        `json:"value"`
+	Instances []string      `json:"instances"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("shard %d has replicas on instances %s in %s '%s'",
+		v.Shard, strings.Join(v.Instances, ","), v.Domain, v.Value)
+}
+
+// ConstraintError is returned when a placement change would violate the
+// constraints, the change is not made.
+type ConstraintError struct {
+	Violations []Violation
+}
+
+func (e *ConstraintError) Error() string {
+	msg := fmt.Sprintf("placement violates failure domain constraints: %s",
+		e.Violations[0])
+	if len(e.Violations) > 1 {
+		msg += fmt.Sprintf(" and %d more", len(e.Violations)-1)
+	}
+	return msg
+}
+
+// Move is a move of a replica of a shard between instances.
+type Move struct {
+	Shard uint32 `json:"shard"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Violations returns the violations of the constraints by the placement,
+// instances without a value for a failure domain share the empty value.
+// Leaving shards are ignored since their replicas are being moved away.
+func (c Constraints) Violations(p placement.Placement) []Violation {
+	return newPlanState(p).violations(c)
+}
+
+// Plan returns the moves that resolve the violations of the constraints by
+// the placement, moving each replica to the instance with the fewest shards
+// for its weight that shares no constrained failure domain with the other
+// replicas of the shard. Violations that no move resolves are returned as
+// unresolved.
+func (c Constraints) Plan(p placement.Placement) ([]Move, []Violation) {
+	state := newPlanState(p)
+	var moves []Move
+	for _, shardID := range state.shardIDs() {
+		for {
+			violation, ok := state.shardViolation(c, shardID)
+			if !ok {
+				break
+			}
+			move, ok := state.move(c, shardID, violation)
+			if !ok {
+				break
+			}
+			moves = append(moves, move)
+		}
+	}
+	return moves, state.violations(c)
+}
+
+// planState is the assignment of shards to instances as moves are planned.
+type planState struct {
+	instances []placement.Instance
+	byID      map[string]placement.Instance
+	shards    map[string]map[uint32]struct{}
+	owners    map[uint32][]string
+}
+
+func newPlanState(p placement.Placement) *planState {
+	s := &planState{
+		instances: p.Instances(),
+		byID:      make(map[string]placement.Instance),
+		shards:    make(map[string]map[uint32]struct{}),
+		owners:    make(map[uint32][]string),
+	}
+	sort.Slice(s.instances, func(i, j int) bool {
+		return s.instances[i].ID() < s.instances[j].ID()
+	})
+	for _, instance := range s.instances {
+		id := instance.ID()
+		s.byID[id] = instance
+		s.shards[id] = make(map[uint32]struct{})
+		for _, sh := range instance.Shards().All() {
+			if sh.State() == shard.Leaving {
+				continue
+			}
+			s.shards[id][sh.ID()] = struct{}{}
+			s.owners[sh.ID()] = append(s.owners[sh.ID()], id)
+		}
+	}
+	return s
+}
+
+func (s *planState) shardIDs() []uint32 {
+	ids := make([]uint32, 0, len(s.owners))
+	for id := range s.owners {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (s *planState) violations(c Constraints) []Violation {
+	var violations []Violation
+	for _, shardID := range s.shardIDs() {
+		violations = append(violations, s.shardViolations(c, shardID)...)
+	}
+	return violations
+}
+
+func (s *planState) shardViolations(c Constraints, shardID uint32) []Violation {
+	var violations []Violation
+	for _, domain := range c {
+		byValue := make(map[string][]string)
+		for _, id := range s.owners[shardID] {
+			value := failureDomainValue(s.byID[id], domain)
+			byValue[value] = append(byValue[value], id)
+		}
+		for value, ids := range byValue {
+			if len(ids) < 2 {
+				continue
+			}
+			sort.Strings(ids)
+			violations = append(violations, Violation{
+				Shard:     shardID,
+				Domain:    domain,
+				Value:     value,
+				Instances: ids,
+			})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Domain != violations[j].Domain {
+			return violations[i].Domain < violations[j].Domain
+		}
+		return violations[i].Value < violations[j].Value
+	})
+	return violations
+}
+
+func (s *planState) shardViolation(c Constraints, shardID uint32) (Violation, bool) {
+	violations := s.shardViolations(c, shardID)
+	if len(violations) == 0 {
+		return Violation{}, false
+	}
+	return violations[0], true
+}
+
+// move plans the move of the replica of the violating instance with the most
+// shards, the violation is left unresolved if no instance can take it.
+func (s *planState) move(c Constraints, shardID uint32, v Violation) (Move, bool) {
+	from := v.Instances[0]
+	for _, id := range v.Instances[1:] {
+		if s.load(id) > s.load(from) {
+			from = id
+		}
+	}
+
+	var to placement.Instance
+	for _, candidate := range s.instances {
+		if !s.canTake(c, shardID, from, candidate) {
+			continue
+		}
+		if to == nil || s.load(candidate.ID()) < s.load(to.ID()) {
+			to = candidate
+		}
+	}
+	if to == nil {
+		return Move{}, false
+	}
+
+	delete(s.shards[from], shardID)
+	s.shards[to.ID()][shardID] = struct{}{}
+	owners := s.owners[shardID][:0]
+	for _, id := range s.owners[shardID] {
+		if id != from {
+			owners = append(owners, id)
+		}
+	}
+	s.owners[shardID] = append(owners, to.ID())
+	return Move{Shard: shardID, From: from, To: to.ID()}, true
+}
+
+// canTake returns whether the candidate can take the replica of the shard
+// from the instance given without sharing a constrained failure domain with
+// the remaining replicas.
+func (s *planState) canTake(
+	c Constraints,
+	shardID uint32,
+	from string,
+	candidate placement.Instance,
+) bool {
+	if _, ok := s.shards[candidate.ID()][shardID]; ok {
+		return false
+	}
+	if candidate.Weight() == 0 {
+		return false
+	}
+	for _, id := range s.owners[shardID] {
+		if id == from {
+			continue
+		}
+		for _, domain := range c {
+			if failureDomainValue(s.byID[id], domain) ==
+				failureDomainValue(candidate, domain) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// load is the number of shards of an instance relative to its weight.
+func (s *planState) load(id string) float64 {
+	weight := s.byID[id].Weight()
+	if weight == 0 {
+		weight = 1
+	}
+	return float64(len(s.shards[id])) / float64(weight)
+}
+
+func failureDomainValue(instance placement.Instance, domain FailureDomain) string {
+	switch domain {
+	case ZoneFailureDomain:
+		if idx := strings.Index(instance.IsolationGroup(), "/"); idx != -1 {
+			return instance.IsolationGroup()[:idx]
+		}
+		return instance.Zone()
+	case RackFailureDomain:
+		return instance.IsolationGroup()
+	case HostFailureDomain:
+		if instance.Hostname() != "" {
+			return instance.Hostname()
+		}
+		return instance.ID()
+	}
+	return ""
+}
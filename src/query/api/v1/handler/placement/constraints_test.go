@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"testing"
+
+	"github.com/m3db/m3cluster/generated/proto/placementpb"
+	"github.com/m3db/m3cluster/placement"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestInstance(id, isolationGroup string, shards ...uint32) *placementpb.Instance {
+	instance := &placementpb.Instance{
+		Id:             id,
+		IsolationGroup: isolationGroup,
+		Zone:           "embedded",
+		Weight:         1,
+		Endpoint:       id + ":9000",
+		Hostname:       id,
+		Port:           9000,
+	}
+	for _, id := range shards {
+		instance.Shards = append(instance.Shards, &placementpb.Shard{
+			Id:    id,
+			State: placementpb.ShardState_AVAILABLE,
+		})
+	}
+	return instance
+}
+
+// newTestPlacement returns a placement of three shards with two replicas
+// where both replicas of shard 1 are in rack a.
+func newTestPlacement(t *testing.T) placement.Placement {
+	p, err := placement.NewPlacementFromProto(&placementpb.Placement{
+		Instances: map[string]*placementpb.Instance{
+			"host1": newTestInstance("host1", "a", 0, 1),
+			"host2": newTestInstance("host2", "a", 1, 2),
+			"host3": newTestInstance("host3", "b", 0, 2),
+			"host4": newTestInstance("host4", "c"),
+		},
+		ReplicaFactor: 2,
+		NumShards:     3,
+		IsSharded:     true,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestParseConstraints(t *testing.T) {
+	constraints, err := ParseConstraints([]string{"zone", "rack", "host"})
+	require.NoError(t, err)
+	assert.Equal(t, Constraints{
+		ZoneFailureDomain,
+		RackFailureDomain,
+		HostFailureDomain,
+	}, constraints)
+
+	_, err = ParseConstraints([]string{"region"})
+	assert.Error(t, err)
+}
+
+func TestConstraintsViolations(t *testing.T) {
+	p := newTestPlacement(t)
+
+	assert.Equal(t, []Violation{{
+		Shard:     1,
+		Domain:    RackFailureDomain,
+		Value:     "a",
+		Instances: []string{"host1", "host2"},
+	}}, Constraints{RackFailureDomain}.Violations(p))
+	assert.Empty(t, Constraints{HostFailureDomain}.Violations(p))
+
+	// Every instance is in the same zone.
+	assert.Equal(t, 3, len(Constraints{ZoneFailureDomain}.Violations(p)))
+}
+
+func TestConstraintsPlan(t *testing.T) {
+	p := newTestPlacement(t)
+
+	moves, unresolved := Constraints{RackFailureDomain}.Plan(p)
+	assert.Equal(t, []Move{{Shard: 1, From: "host1", To: "host4"}}, moves)
+	assert.Empty(t, unresolved)
+
+	// No instance is in another zone so the zone violations remain.
+	moves, unresolved = Constraints{ZoneFailureDomain}.Plan(p)
+	assert.Empty(t, moves)
+	assert.Equal(t, 3, len(unresolved))
+}
+
+func TestFailureDomainValueZoneFromIsolationGroup(t *testing.T) {
+	instance := placement.NewInstance().
+		SetID("host1").
+		SetIsolationGroup("us-east-1a/r12").
+		SetZone("embedded")
+	assert.Equal(t, "us-east-1a", failureDomainValue(instance, ZoneFailureDomain))
+	assert.Equal(t, "us-east-1a/r12", failureDomainValue(instance, RackFailureDomain))
+	assert.Equal(t, "host1", failureDomainValue(instance, HostFailureDomain))
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"net/http"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/placement"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ViolationsURL is the url for the placement failure domain violations
+	// handler (with the GET method).
+	ViolationsURL = handler.RoutePrefixV1 + "/placement/violations"
+
+	// ViolationsHTTPMethod is the HTTP method used with this resource.
+	ViolationsHTTPMethod = http.MethodGet
+
+	// PlanURL is the url for the placement failure domain plan handler
+	// (with the GET method).
+	PlanURL = handler.RoutePrefixV1 + "/placement/violations/plan"
+
+	// PlanHTTPMethod is the HTTP method used with this resource.
+	PlanHTTPMethod = http.MethodGet
+
+	// failureDomainParam overrides the configured constraints, it may be
+	// repeated.
+	failureDomainParam = "failureDomain"
+)
+
+// ViolationsResponse is the response of the violations handler.
+type ViolationsResponse struct {
+	FailureDomains Constraints `json:"failureDomains"`
+	Version        int         `json:"version"`
+	Violations     []Violation `json:"violations"`
+}
+
+// PlanResponse is the response of the plan handler, the moves are proposed
+// and not made.
+type PlanResponse struct {
+	FailureDomains Constraints `json:"failureDomains"`
+	Version        int         `json:"version"`
+	Moves          []Move      `json:"moves"`
+	Unresolved     []Violation `json:"unresolved"`
+}
+
+// ViolationsHandler is the handler reporting the replicas of shards that
+// share a failure domain.
+type ViolationsHandler Handler
+
+// NewViolationsHandler returns a new instance of ViolationsHandler.
+func NewViolationsHandler(client clusterclient.Client, cfg config.Configuration) *ViolationsHandler {
+	return &ViolationsHandler{client: client, cfg: cfg}
+}
+
+func (h *ViolationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	constraints, p, version, ok := currentPlacement(w, r, Handler(*h))
+	if !ok {
+		return
+	}
+
+	handler.WriteJSONResponse(w, ViolationsResponse{
+		FailureDomains: constraints,
+		Version:        version,
+		Violations:     nonNilViolations(constraints.Violations(p)),
+	}, logger)
+}
+
+// PlanHandler is the handler proposing the moves that resolve the
+// replicas of shards sharing a failure domain.
+type PlanHandler Handler
+
+// NewPlanHandler returns a new instance of PlanHandler.
+func NewPlanHandler(client clusterclient.Client, cfg config.Configuration) *PlanHandler {
+	return &PlanHandler{client: client, cfg: cfg}
+}
+
+func (h *PlanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	constraints, p, version, ok := currentPlacement(w, r, Handler(*h))
+	if !ok {
+		return
+	}
+
+	moves, unresolved := constraints.Plan(p)
+	if moves == nil {
+		moves = []Move{}
+	}
+	handler.WriteJSONResponse(w, PlanResponse{
+		FailureDomains: constraints,
+		Version:        version,
+		Moves:          moves,
+		Unresolved:     nonNilViolations(unresolved),
+	}, logger)
+}
+
+// currentPlacement returns the requested or configured constraints along
+// with the current placement, writing the error if either is unavailable.
+func currentPlacement(
+	w http.ResponseWriter,
+	r *http.Request,
+	h Handler,
+) (Constraints, placement.Placement, int, bool) {
+	logger := logging.WithContext(r.Context())
+
+	constraints, err := requestConstraints(r, h.cfg)
+	if err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return nil, nil, 0, false
+	}
+
+	service, err := Service(h.client, r.Header)
+	if err != nil {
+		handler.Error(w, err, http.StatusInternalServerError)
+		return nil, nil, 0, false
+	}
+
+	p, version, err := service.Placement()
+	if err != nil {
+		logger.Error("unable to get placement", zap.Any("error", err))
+		handler.Error(w, err, http.StatusNotFound)
+		return nil, nil, 0, false
+	}
+
+	return constraints, p, version, true
+}
+
+func requestConstraints(r *http.Request, cfg config.Configuration) (Constraints, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	if domains := r.Form[failureDomainParam]; len(domains) > 0 {
+		return ParseConstraints(domains)
+	}
+
+	constraints, err := NewConstraints(cfg.PlacementConstraints)
+	if err != nil {
+		return nil, err
+	}
+	if len(constraints) == 0 {
+		return defaultConstraints, nil
+	}
+	return constraints, nil
+}
+
+// checkConstraints builds a placement and returns a ConstraintError if it
+// violates the configured constraints, placements are not built if no
+// constraints are configured.
+func checkConstraints(
+	cfg config.Configuration,
+	build func() (placement.Placement, error),
+) error {
+	constraints, err := NewConstraints(cfg.PlacementConstraints)
+	if err != nil || len(constraints) == 0 {
+		return err
+	}
+
+	p, err := build()
+	if err != nil {
+		return err
+	}
+	if violations := constraints.Violations(p); len(violations) > 0 {
+		return &ConstraintError{Violations: violations}
+	}
+	return nil
+}
+
+func writeConstraintError(w http.ResponseWriter, err *ConstraintError, logger *zap.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	handler.WriteJSONResponse(w, struct {
+		Error      string      `json:"error"`
+		Violations []Violation `json:"violations"`
+	}{
+		Error:      err.Error(),
+		Violations: err.Violations,
+	}, logger)
+}
+
+func nonNilViolations(violations []Violation) []Violation {
+	if violations == nil {
+		return []Violation{}
+	}
+	return violations
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacementViolationsHandler(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	handler := NewViolationsHandler(mockClient, config.Configuration{})
+
+	mockPlacementService.EXPECT().Placement().Return(newTestPlacement(t), 3, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(ViolationsHTTPMethod, ViolationsURL, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp ViolationsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, ViolationsResponse{
+		FailureDomains: Constraints{RackFailureDomain},
+		Version:        3,
+		Violations: []Violation{{
+			Shard:     1,
+			Domain:    RackFailureDomain,
+			Value:     "a",
+			Instances: []string{"host1", "host2"},
+		}},
+	}, resp)
+
+	// Requested failure domains replace the configured ones.
+	mockPlacementService.EXPECT().Placement().Return(newTestPlacement(t), 3, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(ViolationsHTTPMethod,
+		ViolationsURL+"?failureDomain=host", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `{"failureDomains":["host"],"version":3,"violations":[]}`,
+		w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(ViolationsHTTPMethod,
+		ViolationsURL+"?failureDomain=region", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPlacementPlanHandler(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	handler := NewPlanHandler(mockClient, config.Configuration{
+		PlacementConstraints: &config.PlacementConstraintsConfiguration{
+			FailureDomains: []string{"rack"},
+		},
+	})
+
+	mockPlacementService.EXPECT().Placement().Return(newTestPlacement(t), 3, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(PlanHTTPMethod, PlanURL, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `{"failureDomains":["rack"],"version":3,`+
+		`"moves":[{"shard":1,"from":"host1","to":"host4"}],"unresolved":[]}`,
+		w.Body.String())
+}
+
+func TestPlacementInitHandlerConstraints(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	handler := NewInitHandler(mockClient, config.Configuration{
+		PlacementConstraints: &config.PlacementConstraintsConfiguration{
+			FailureDomains: []string{"rack"},
+		},
+	})
+
+	// The placement is only computed, it is not built since it violates
+	// the constraints.
+	mockPlacementService.EXPECT().
+		BuildInitialPlacement(gomock.Not(nil), 3, 2).
+		Return(newTestPlacement(t), nil)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(InitHTTPMethod, InitURL, strings.NewReader(
+		`{"instances": [{"id": "host1", "isolation_group": "a", "zone": "embedded", "weight": 1, "endpoint": "host1:9000", "hostname": "host1", "port": 9000}],`+
+			`"num_shards": 3, "replication_factor": 2}`))
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	var resp struct {
+		Error      string      `json:"error"`
+		Violations []Violation `json:"violations"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "placement violates failure domain constraints: "+
+		"shard 1 has replicas on instances host1,host2 in rack 'a'", resp.Error)
+	assert.Equal(t, 1, len(resp.Violations))
+}
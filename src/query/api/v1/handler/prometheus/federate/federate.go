@@ -0,0 +1,280 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package federate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser/promql"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// FederateURL is the url for the prometheus federation handler
+	FederateURL = "/federate"
+
+	// FederateHTTPMethod is the HTTP method used with this resource.
+	FederateHTTPMethod = http.MethodGet
+
+	// contentType is the prometheus text exposition format content type
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	matchParam = "match[]"
+
+	// lookback is how far back a series is searched for its current value,
+	// this matches the default prometheus staleness period
+	lookback = 5 * time.Minute
+)
+
+var (
+	errNoMatchers = fmt.Errorf("%s: no %s parameter provided",
+		handler.ErrInvalidParams, matchParam)
+
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// FederateHandler renders the current value of every series matching
+// the requested selectors in the prometheus text exposition format.
+type FederateHandler struct {
+	store storage.Storage
+	nowFn func() time.Time
+}
+
+// NewFederateHandler returns a new instance of handler.
+func NewFederateHandler(store storage.Storage) http.Handler {
+	return &FederateHandler{
+		store: store,
+		nowFn: time.Now,
+	}
+}
+
+type sample struct {
+	name  string
+	tags  models.Tags
+	id    string
+	value float64
+	ts    time.Time
+}
+
+func (h *FederateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	selectors, rErr := parseSelectors(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	timeout, err := prometheus.ParseRequestTimeout(r)
+	if err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	samples, err := h.federate(ctx, selectors, timeout)
+	if err != nil {
+		logger.Error("unable to fetch data", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if err := renderSamples(w, samples); err != nil {
+		// Headers have been written so only log the error
+		logger.Error("unable to render samples", zap.Any("error", err))
+	}
+}
+
+func parseSelectors(r *http.Request) ([]models.Matchers, *handler.ParseError) {
+	if err := r.ParseForm(); err != nil {
+		return nil, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	values := r.Form[matchParam]
+	if len(values) == 0 {
+		return nil, handler.NewParseError(errNoMatchers, http.StatusBadRequest)
+	}
+
+	selectors := make([]models.Matchers, 0, len(values))
+	for _, value := range values {
+		matchers, err := promql.ParseMatchers(value)
+		if err != nil {
+			return nil, handler.NewParseError(
+				fmt.Errorf("%s: invalid %s %q: %v", handler.ErrInvalidParams,
+					matchParam, value, err), http.StatusBadRequest)
+		}
+		selectors = append(selectors, matchers)
+	}
+
+	return selectors, nil
+}
+
+func (h *FederateHandler) federate(
+	reqCtx context.Context,
+	selectors []models.Matchers,
+	timeout time.Duration,
+) ([]sample, error) {
+	ctx, cancel := context.WithTimeout(reqCtx, timeout)
+	defer cancel()
+
+	var (
+		end     = h.nowFn()
+		start   = end.Add(-lookback)
+		seen    = make(map[string]struct{})
+		samples []sample
+	)
+	for _, matchers := range selectors {
+		result, err := h.store.Fetch(ctx, &storage.FetchQuery{
+			TagMatchers: matchers,
+			Start:       start,
+			End:         end,
+		}, &storage.FetchOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, series := range result.SeriesList {
+			// Series may match more than one selector, only render them once
+			id := series.Tags.ID()
+			if _, ok := seen[id]; ok {
+				continue
+			}
+
+			dp, ok := latestDatapoint(series, end)
+			if !ok {
+				continue
+			}
+
+			seen[id] = struct{}{}
+			name, ok := series.Tags[models.MetricName]
+			if !ok {
+				name = series.Name()
+			}
+			samples = append(samples, sample{
+				name:  name,
+				tags:  series.Tags,
+				id:    id,
+				value: dp.Value,
+				ts:    dp.Timestamp,
+			})
+		}
+	}
+
+	// Group samples by metric name so each name gets a single type line
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].name != samples[j].name {
+			return samples[i].name < samples[j].name
+		}
+		return samples[i].id < samples[j].id
+	})
+
+	return samples, nil
+}
+
+// latestDatapoint returns the most recent non NaN datapoint at or before end.
+func latestDatapoint(series *ts.Series, end time.Time) (ts.Datapoint, bool) {
+	values := series.Values()
+	for i := values.Len() - 1; i >= 0; i-- {
+		dp := values.DatapointAt(i)
+		if dp.Timestamp.After(end) || math.IsNaN(dp.Value) {
+			continue
+		}
+		return dp, true
+	}
+	return ts.Datapoint{}, false
+}
+
+func renderSamples(w io.Writer, samples []sample) error {
+	bw := bufio.NewWriter(w)
+	lastName := ""
+	for i, s := range samples {
+		if i == 0 || s.name != lastName {
+			fmt.Fprintf(bw, "# TYPE %s untyped\n", s.name)
+			lastName = s.name
+		}
+
+		bw.WriteString(s.name)
+		writeLabels(bw, s.tags)
+		bw.WriteByte(' ')
+		bw.WriteString(formatValue(s.value))
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatInt(
+			s.ts.UnixNano()/int64(time.Millisecond), 10))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func writeLabels(bw *bufio.Writer, tags models.Tags) {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		if name == models.MetricName {
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	bw.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString(name)
+		bw.WriteString(`="`)
+		bw.WriteString(labelValueEscaper.Replace(tags[name]))
+		bw.WriteByte('"')
+	}
+	bw.WriteByte('}')
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package federate
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFederate(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	now := time.Unix(1000, 0)
+	mockStorage := mock.NewMockStorage()
+	mockStorage.SetFetchResult(&storage.FetchResult{
+		SeriesList: ts.SeriesList{
+			ts.NewSeries("b", ts.Datapoints{
+				{Timestamp: now.Add(-time.Minute), Value: 1},
+				{Timestamp: now.Add(-30 * time.Second), Value: 2.5},
+			}, models.Tags{models.MetricName: "up", "job": "b\"q"}),
+			ts.NewSeries("a", ts.Datapoints{
+				{Timestamp: now.Add(-time.Minute), Value: 3},
+				{Timestamp: now.Add(-30 * time.Second), Value: math.NaN()},
+			}, models.Tags{models.MetricName: "up", "job": "a"}),
+			ts.NewSeries("c", ts.Datapoints{
+				{Timestamp: now.Add(-10 * time.Second), Value: math.Inf(1)},
+			}, models.Tags{models.MetricName: "errors_total"}),
+			ts.NewSeries("d", ts.Datapoints{
+				{Timestamp: now.Add(-10 * time.Second), Value: math.NaN()},
+			}, models.Tags{models.MetricName: "stale"}),
+		},
+	}, nil)
+
+	h := &FederateHandler{store: mockStorage, nowFn: func() time.Time { return now }}
+	req := httptest.NewRequest(FederateHTTPMethod, FederateURL+"?"+url.Values{
+		matchParam: []string{`up`, `{__name__=~"errors.*"}`},
+	}.Encode(), nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, contentType, res.Header().Get("Content-Type"))
+	expected := "# TYPE errors_total untyped\n" +
+		"errors_total +Inf 990000\n" +
+		"# TYPE up untyped\n" +
+		"up{job=\"a\"} 3 940000\n" +
+		"up{job=\"b\\\"q\"} 2.5 970000\n"
+	assert.Equal(t, expected, res.Body.String())
+}
+
+func TestFederateNoMatchers(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	h := NewFederateHandler(mock.NewMockStorage())
+	req := httptest.NewRequest(FederateHTTPMethod, FederateURL, nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestFederateInvalidMatcher(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	h := NewFederateHandler(mock.NewMockStorage())
+	req := httptest.NewRequest(FederateHTTPMethod, FederateURL+"?"+url.Values{
+		matchParam: []string{`sum(up)`},
+	}.Encode(), nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
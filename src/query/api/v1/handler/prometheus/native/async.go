@@ -0,0 +1,299 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// PromAsyncReadURL is the url to submit asynchronous native prom queries to
+	PromAsyncReadURL = PromReadURL + "/async"
+
+	// PromAsyncReadHTTPMethod is the HTTP method used to submit asynchronous queries.
+	PromAsyncReadHTTPMethod = http.MethodPost
+
+	// PromAsyncReadResultURL is the url to poll or cancel an asynchronous query
+	PromAsyncReadResultURL = PromAsyncReadURL + "/{" + asyncQueryIDVar + "}"
+
+	// PromAsyncReadResultHTTPMethod is the HTTP method used to poll an asynchronous query.
+	PromAsyncReadResultHTTPMethod = http.MethodGet
+
+	// PromAsyncReadCancelHTTPMethod is the HTTP method used to cancel an asynchronous query.
+	PromAsyncReadCancelHTTPMethod = http.MethodDelete
+
+	// DefaultAsyncQueryTimeout is the default time an asynchronous query may run for.
+	DefaultAsyncQueryTimeout = 30 * time.Minute
+
+	// DefaultAsyncQueryResultTTL is the default time results of a finished
+	// asynchronous query are retained for.
+	DefaultAsyncQueryResultTTL = 10 * time.Minute
+
+	// DefaultAsyncQueryMaxOutstanding is the default maximum number of
+	// asynchronous queries tracked at once, running or retained.
+	DefaultAsyncQueryMaxOutstanding = 64
+
+	asyncQueryIDVar = "id"
+	waitParam       = "wait"
+	maxWait         = time.Minute
+)
+
+var (
+	errAsyncQueryNotFound       = errors.New("async query not found or expired")
+	errAsyncQueryTooManyQueries = errors.New("too many outstanding async queries")
+)
+
+type asyncQueryStatus string
+
+const (
+	asyncQueryRunning   asyncQueryStatus = "running"
+	asyncQuerySucceeded asyncQueryStatus = "success"
+	asyncQueryFailed    asyncQueryStatus = "error"
+)
+
+type asyncQuery struct {
+	id     string
+	cancel context.CancelFunc
+	doneCh chan struct{}
+	stats  *models.QueryStats
+
+	// Set once doneCh is closed
+	series     []*ts.Series
+	err        error
+	finishedAt time.Time
+}
+
+func (q *asyncQuery) status() asyncQueryStatus {
+	select {
+	case <-q.doneCh:
+	default:
+		return asyncQueryRunning
+	}
+	if q.err != nil {
+		return asyncQueryFailed
+	}
+	return asyncQuerySucceeded
+}
+
+// PromAsyncReadHandler runs native prom queries in the background so very
+// large queries do not depend on a single long lived HTTP connection. A
+// submitted query is given an ID which is then used to poll for its results,
+// results are retained for a TTL after the query finishes.
+type PromAsyncReadHandler struct {
+	sync.Mutex
+
+	engine         *executor.Engine
+	timeout        time.Duration
+	ttl            time.Duration
+	maxOutstanding int
+	splitOpts      QuerySplitOptions
+	nowFn          func() time.Time
+	queries        map[string]*asyncQuery
+}
+
+// NewPromAsyncReadHandler returns a new instance of handler.
+func NewPromAsyncReadHandler(
+	engine *executor.Engine,
+	timeout time.Duration,
+	ttl time.Duration,
+	maxOutstanding int,
+	splitOpts QuerySplitOptions,
+) *PromAsyncReadHandler {
+	return &PromAsyncReadHandler{
+		engine:         engine,
+		timeout:        timeout,
+		ttl:            ttl,
+		maxOutstanding: maxOutstanding,
+		splitOpts:      splitOpts,
+		nowFn:          time.Now,
+		queries:        make(map[string]*asyncQuery),
+	}
+}
+
+// SubmitHandler returns the handler that submits asynchronous queries.
+func (h *PromAsyncReadHandler) SubmitHandler() http.Handler {
+	return http.HandlerFunc(h.submit)
+}
+
+// ResultHandler returns the handler that polls asynchronous queries.
+func (h *PromAsyncReadHandler) ResultHandler() http.Handler {
+	return http.HandlerFunc(h.result)
+}
+
+// CancelHandler returns the handler that cancels asynchronous queries.
+func (h *PromAsyncReadHandler) CancelHandler() http.Handler {
+	return http.HandlerFunc(h.cancel)
+}
+
+func (h *PromAsyncReadHandler) submit(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	params, rErr := parseParams(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+	// Async queries are not bound by the synchronous request timeout
+	params.Timeout = h.timeout
+
+	// Detach from the request context so the query outlives the request
+	ctx, cancel := context.WithTimeout(context.Background(), params.Timeout)
+	query := &asyncQuery{
+		id:     uuid.NewRandom().String(),
+		cancel: cancel,
+		doneCh: make(chan struct{}),
+		stats:  models.NewQueryStats(),
+	}
+
+	h.Lock()
+	h.expireWithLock()
+	if len(h.queries) >= h.maxOutstanding {
+		h.Unlock()
+		cancel()
+		handler.Error(w, errAsyncQueryTooManyQueries, http.StatusTooManyRequests)
+		return
+	}
+	h.queries[query.id] = query
+	h.Unlock()
+
+	go h.run(ctx, query, params)
+
+	logger.Info("async query submitted",
+		zap.String("id", query.id), zap.String("target", params.Target))
+	w.Header().Set("Location", PromAsyncReadURL+"/"+query.id)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, `{"id":%q,"status":%q}`, query.id, asyncQueryRunning)
+}
+
+func (h *PromAsyncReadHandler) run(
+	ctx context.Context,
+	query *asyncQuery,
+	params models.RequestParams,
+) {
+	defer query.cancel()
+
+	opts := &executor.EngineOptions{Stats: query.stats}
+	series, err := executeSplitQuery(ctx, h.engine, params, opts, h.splitOpts)
+
+	h.Lock()
+	query.series = series
+	query.err = err
+	query.finishedAt = h.nowFn()
+	close(query.doneCh)
+	h.Unlock()
+}
+
+func (h *PromAsyncReadHandler) result(w http.ResponseWriter, r *http.Request) {
+	wait, err := parseDuration(r, waitParam)
+	if err != nil {
+		wait = 0
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+
+	query, ok := h.get(mux.Vars(r)[asyncQueryIDVar])
+	if !ok {
+		handler.Error(w, errAsyncQueryNotFound, http.StatusNotFound)
+		return
+	}
+
+	// Long poll for the query to finish if requested
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-query.doneCh:
+		case <-timer.C:
+		case <-r.Context().Done():
+		}
+		timer.Stop()
+	}
+
+	switch query.status() {
+	case asyncQueryRunning:
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%q,"status":%q}`, query.id, asyncQueryRunning)
+	case asyncQueryFailed:
+		handler.Error(w, query.err, http.StatusInternalServerError)
+	default:
+		if err := renderResults(w, r, query.series, query.stats.Snapshot()); err != nil {
+			// Headers have been written so only log the error
+			logging.WithContext(r.Context()).Error("unable to render results",
+				zap.Any("error", err))
+		}
+	}
+}
+
+func (h *PromAsyncReadHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)[asyncQueryIDVar]
+
+	h.Lock()
+	query, ok := h.queries[id]
+	delete(h.queries, id)
+	h.Unlock()
+
+	if !ok {
+		handler.Error(w, errAsyncQueryNotFound, http.StatusNotFound)
+		return
+	}
+
+	query.cancel()
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"id":%q,"deleted":true}`, query.id)
+}
+
+func (h *PromAsyncReadHandler) get(id string) (*asyncQuery, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.expireWithLock()
+	query, ok := h.queries[id]
+	return query, ok
+}
+
+// expireWithLock removes finished queries whose results have outlived the TTL.
+func (h *PromAsyncReadHandler) expireWithLock() {
+	now := h.nowFn()
+	for id, query := range h.queries {
+		if query.status() == asyncQueryRunning {
+			continue
+		}
+		if now.Sub(query.finishedAt) >= h.ttl {
+			delete(h.queries, id)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAsyncRouter(h *PromAsyncReadHandler) *mux.Router {
+	router := mux.NewRouter()
+	router.Handle(PromAsyncReadURL, h.SubmitHandler()).Methods(PromAsyncReadHTTPMethod)
+	router.Handle(PromAsyncReadResultURL, h.ResultHandler()).Methods(PromAsyncReadResultHTTPMethod)
+	router.Handle(PromAsyncReadResultURL, h.CancelHandler()).Methods(PromAsyncReadCancelHTTPMethod)
+	return router
+}
+
+func submitTestAsyncQuery(t *testing.T, router *mux.Router) string {
+	req := httptest.NewRequest(PromAsyncReadHTTPMethod,
+		PromAsyncReadURL+"?"+defaultParams().Encode(), nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusAccepted, res.Code)
+
+	var submitted struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &submitted))
+	require.NotEmpty(t, submitted.ID)
+	assert.Equal(t, string(asyncQueryRunning), submitted.Status)
+	assert.Equal(t, PromAsyncReadURL+"/"+submitted.ID, res.Header().Get("Location"))
+	return submitted.ID
+}
+
+func TestPromAsyncRead(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+	b := test.NewBlockFromValues(bounds, values)
+
+	mockStorage := mock.NewMockStorage()
+	mockStorage.SetFetchBlocksResult(block.Result{Blocks: []block.Block{b}}, nil)
+
+	now := time.Now()
+	h := NewPromAsyncReadHandler(executor.NewEngine(mockStorage),
+		time.Minute, time.Minute, 10, QuerySplitOptions{})
+	h.nowFn = func() time.Time { return now }
+	router := newTestAsyncRouter(h)
+
+	id := submitTestAsyncQuery(t, router)
+
+	req := httptest.NewRequest(PromAsyncReadResultHTTPMethod,
+		PromAsyncReadURL+"/"+id+"?wait=30s", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var result struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &result))
+	assert.Equal(t, string(asyncQuerySucceeded), result.Status)
+	assert.Len(t, result.Data.Result, 2)
+
+	// Results expire once the TTL has passed
+	now = now.Add(time.Minute)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(PromAsyncReadResultHTTPMethod,
+		PromAsyncReadURL+"/"+id, nil))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestPromAsyncReadCancel(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	mockStorage := mock.NewMockStorage()
+	mockStorage.SetFetchBlocksResult(block.Result{}, nil)
+
+	h := NewPromAsyncReadHandler(executor.NewEngine(mockStorage),
+		time.Minute, time.Minute, 10, QuerySplitOptions{})
+	router := newTestAsyncRouter(h)
+
+	id := submitTestAsyncQuery(t, router)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(PromAsyncReadCancelHTTPMethod,
+		PromAsyncReadURL+"/"+id, nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(PromAsyncReadResultHTTPMethod,
+		PromAsyncReadURL+"/"+id, nil))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestPromAsyncReadTooManyQueries(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	h := NewPromAsyncReadHandler(executor.NewEngine(mock.NewMockStorage()),
+		time.Minute, time.Minute, 0, QuerySplitOptions{})
+	router := newTestAsyncRouter(h)
+
+	req := httptest.NewRequest(PromAsyncReadHTTPMethod,
+		PromAsyncReadURL+"?"+defaultParams().Encode(), nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+}
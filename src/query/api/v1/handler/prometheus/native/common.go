@@ -23,8 +23,10 @@ package native
 import (
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/m3db/m3/src/query/api/v1/handler"
@@ -33,6 +35,7 @@ import (
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/query/util"
+	"github.com/m3db/m3/src/query/util/export"
 	"github.com/m3db/m3/src/query/util/json"
 	"github.com/m3db/m3/src/query/util/logging"
 
@@ -45,6 +48,12 @@ const (
 	targetParam = "target"
 	stepParam   = "step"
 	debugParam  = "debug"
+	formatParam = "format"
+
+	decimationParam = "decimation"
+	enrichParam     = "enrich"
+
+	jsonFormat = "json"
 
 	formatErrStr = "error parsing param: %s, error: %v"
 )
@@ -66,6 +75,20 @@ func parseDuration(r *http.Request, key string) (time.Duration, error) {
 	return 0, errors.ErrNotFound
 }
 
+// parseEnrichment parses the names of the lookup tables to enrich the series
+// with, which are either repeated or comma separated.
+func parseEnrichment(r *http.Request) []string {
+	var tables []string
+	for _, value := range r.Form[enrichParam] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				tables = append(tables, name)
+			}
+		}
+	}
+	return tables
+}
+
 // parseParams parses all params from the GET request
 func parseParams(r *http.Request) (models.RequestParams, *handler.ParseError) {
 	params := models.RequestParams{
@@ -102,6 +125,16 @@ func parseParams(r *http.Request) (models.RequestParams, *handler.ParseError) {
 	}
 	params.Target = target
 
+	if decimationVal := r.FormValue(decimationParam); decimationVal != "" {
+		decimation, err := models.ParseDecimation(decimationVal)
+		if err != nil {
+			return params, handler.NewParseError(fmt.Errorf(formatErrStr, decimationParam, err), http.StatusBadRequest)
+		}
+		params.Decimation = decimation
+	}
+
+	params.Enrichment = parseEnrichment(r)
+
 	// Skip debug if unable to parse debug param
 	debugVal := r.FormValue(debugParam)
 	if debugVal != "" {
@@ -115,6 +148,23 @@ func parseParams(r *http.Request) (models.RequestParams, *handler.ParseError) {
 	return params, nil
 }
 
+// parseExportFormat returns the export format of the request and whether
+// results should be exported rather than rendered.
+func parseExportFormat(r *http.Request) (export.Format, bool, *handler.ParseError) {
+	formatVal := r.FormValue(formatParam)
+	switch formatVal {
+	case "", jsonFormat, protobufFormat, msgpackFormat:
+		return 0, false, nil
+	}
+
+	format, err := export.ParseFormat(formatVal)
+	if err != nil {
+		return 0, false, handler.NewParseError(fmt.Errorf(formatErrStr, formatParam, err), http.StatusBadRequest)
+	}
+
+	return format, true, nil
+}
+
 func parseTarget(r *http.Request) (string, error) {
 	targetQueries, ok := r.URL.Query()[targetParam]
 	if !ok || len(targetQueries) == 0 || targetQueries[0] == "" {
@@ -129,15 +179,23 @@ func parseTarget(r *http.Request) (string, error) {
 	return targetQueries[0], nil
 }
 
-func renderResultsJSON(w io.Writer, series []*ts.Series) {
+func renderResultsJSON(w io.Writer, series []*ts.Series, stats models.QueryStatsSnapshot) {
 	jw := json.NewWriter(w)
+	jw.BeginObject()
+
+	jw.BeginObjectField("status")
+	jw.WriteString("success")
+
+	jw.BeginObjectField("data")
+	jw.BeginObject()
+	jw.BeginObjectField("resultType")
+	jw.WriteString("matrix")
+
+	jw.BeginObjectField("result")
 	jw.BeginArray()
 	for _, s := range series {
 		jw.BeginObject()
-		jw.BeginObjectField("target")
-		jw.WriteString(s.Name())
-
-		jw.BeginObjectField("tags")
+		jw.BeginObjectField("metric")
 		jw.BeginObject()
 		for k, v := range s.Tags {
 			jw.BeginObjectField(k)
@@ -145,26 +203,119 @@ func renderResultsJSON(w io.Writer, series []*ts.Series) {
 		}
 		jw.EndObject()
 
-		jw.BeginObjectField("datapoints")
+		jw.BeginObjectField("values")
 		jw.BeginArray()
 		vals := s.Values()
 		for i := 0; i < s.Len(); i++ {
 			dp := vals.DatapointAt(i)
+			// Prometheus omits missing values rather than rendering them
+			if math.IsNaN(dp.Value) {
+				continue
+			}
+
 			jw.BeginArray()
-			jw.WriteFloat64(dp.Value)
 			jw.WriteInt(int(dp.Timestamp.Unix()))
+			jw.WriteString(strconv.FormatFloat(dp.Value, 'f', -1, 64))
 			jw.EndArray()
 		}
 		jw.EndArray()
+		jw.EndObject()
+	}
+	jw.EndArray()
+	jw.EndObject()
 
-		fixedStep, ok := s.Values().(ts.FixedResolutionMutableValues)
-		if ok {
-			jw.BeginObjectField("step_size_ms")
-			jw.WriteInt(int(util.DurationToMS(fixedStep.MillisPerStep())))
-			jw.EndObject()
+	if len(stats.Warnings) > 0 {
+		jw.BeginObjectField("warnings")
+		jw.BeginArray()
+		for _, warning := range stats.Warnings {
+			jw.WriteString(warning)
 		}
+		jw.EndArray()
 	}
 
-	jw.EndArray()
+	jw.BeginObjectField("stats")
+	renderStatsJSON(jw, stats)
+
+	jw.EndObject()
 	jw.Close()
 }
+
+func renderResultsExport(w io.Writer, series []*ts.Series, format export.Format) error {
+	ew, err := export.NewWriter(format, w, export.WriterOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range series {
+		if err := ew.Write(s); err != nil {
+			return err
+		}
+	}
+
+	return ew.Close()
+}
+
+func renderStatsJSON(jw *json.Writer, stats models.QueryStatsSnapshot) {
+	jw.BeginObject()
+	jw.BeginObjectField("seriesFetched")
+	jw.WriteInt(stats.SeriesFetched)
+	jw.BeginObjectField("datapointsDecoded")
+	jw.WriteInt(stats.DatapointsDecoded)
+
+	jw.BeginObjectField("stages")
+	jw.BeginArray()
+	for _, stage := range stats.Stages {
+		jw.BeginObject()
+		jw.BeginObjectField("name")
+		jw.WriteString(stage.Name)
+		jw.BeginObjectField("durationSeconds")
+		jw.WriteFloat64(stage.Duration.Seconds())
+		jw.EndObject()
+	}
+	jw.EndArray()
+
+	if stats.Debug != nil {
+		jw.BeginObjectField("debug")
+		renderDebugJSON(jw, stats.Debug)
+	}
+	jw.EndObject()
+}
+
+func renderDebugJSON(jw *json.Writer, debug *models.QueryDebugSnapshot) {
+	jw.BeginObject()
+	jw.BeginObjectField("logicalPlan")
+	jw.WriteString(debug.LogicalPlan)
+	jw.BeginObjectField("physicalPlan")
+	jw.WriteString(debug.PhysicalPlan)
+
+	jw.BeginObjectField("transforms")
+	jw.BeginArray()
+	for _, transform := range debug.Transforms {
+		jw.BeginObject()
+		jw.BeginObjectField("name")
+		jw.WriteString(transform.Name)
+		jw.BeginObjectField("blocks")
+		jw.WriteInt(transform.Blocks)
+		jw.BeginObjectField("durationSeconds")
+		jw.WriteFloat64(transform.Duration.Seconds())
+		jw.EndObject()
+	}
+	jw.EndArray()
+
+	jw.BeginObjectField("storageCalls")
+	jw.BeginArray()
+	for _, call := range debug.StorageCalls {
+		jw.BeginObject()
+		jw.BeginObjectField("namespace")
+		jw.WriteString(call.Namespace)
+		jw.BeginObjectField("durationSeconds")
+		jw.WriteFloat64(call.Duration.Seconds())
+		jw.BeginObjectField("series")
+		jw.WriteInt(call.Series)
+		jw.BeginObjectField("datapoints")
+		jw.WriteInt(call.Datapoints)
+		jw.EndObject()
+	}
+	jw.EndArray()
+	jw.EndObject()
+}
@@ -21,11 +21,17 @@
 package native
 
 import (
+	"bytes"
+	"math"
 	"net/http"
 	"net/url"
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/export"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -74,3 +80,120 @@ func TestInvalidTarget(t *testing.T) {
 	assert.NotNil(t, p.Start)
 	require.Equal(t, err.Code(), http.StatusBadRequest)
 }
+
+func TestParseDecimation(t *testing.T) {
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	vals := defaultParams()
+	req.URL.RawQuery = vals.Encode()
+	p, err := parseParams(req)
+	require.Nil(t, err)
+	assert.Equal(t, models.UnsetDecimation, p.Decimation)
+
+	vals.Set(decimationParam, "lttb")
+	req, _ = http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = vals.Encode()
+	p, err = parseParams(req)
+	require.Nil(t, err)
+	assert.Equal(t, models.LTTBDecimation, p.Decimation)
+
+	vals.Set(decimationParam, "median")
+	req, _ = http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = vals.Encode()
+	_, err = parseParams(req)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Code())
+}
+
+func TestParseEnrichment(t *testing.T) {
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	vals := defaultParams()
+	req.URL.RawQuery = vals.Encode()
+	p, err := parseParams(req)
+	require.Nil(t, err)
+	assert.Nil(t, p.Enrichment)
+
+	vals.Add(enrichParam, "teams, owners")
+	vals.Add(enrichParam, "regions")
+	req, _ = http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = vals.Encode()
+	p, err = parseParams(req)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"teams", "owners", "regions"}, p.Enrichment)
+}
+
+func TestParseExportFormat(t *testing.T) {
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	vals := defaultParams()
+	req.URL.RawQuery = vals.Encode()
+	_, ok, err := parseExportFormat(req)
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	vals.Set(formatParam, "arrow")
+	req, _ = http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = vals.Encode()
+	format, ok, err := parseExportFormat(req)
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, export.ArrowFormat, format)
+
+	vals.Set(formatParam, "csv")
+	req, _ = http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = vals.Encode()
+	_, _, err = parseExportFormat(req)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Code())
+}
+
+func TestRenderResultsJSON(t *testing.T) {
+	start := time.Unix(1535000000, 0)
+	values := ts.NewFixedStepValues(10*time.Second, 3, math.NaN(), start)
+	values.SetValueAt(0, 1)
+	values.SetValueAt(2, 2.5)
+	series := []*ts.Series{
+		ts.NewSeries("foo", values, models.Tags{"__name__": "foo"}),
+	}
+
+	stats := models.QueryStatsSnapshot{
+		SeriesFetched:     1,
+		DatapointsDecoded: 2,
+		Stages:            []models.StageTiming{{Name: "parse", Duration: time.Second}},
+		Warnings:          []string{"truncated"},
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	renderResultsJSON(buffer, series, stats)
+
+	expected := `{"status":"success","data":{"resultType":"matrix","result":[` +
+		`{"metric":{"__name__":"foo"},"values":[[1535000000,"1"],[1535000020,"2.5"]]}]},` +
+		`"warnings":["truncated"],` +
+		`"stats":{"seriesFetched":1,"datapointsDecoded":2,` +
+		`"stages":[{"name":"parse","durationSeconds":1.000000}]}}`
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestRenderResultsJSONDebug(t *testing.T) {
+	stats := models.QueryStatsSnapshot{
+		Debug: &models.QueryDebugSnapshot{
+			LogicalPlan:  "logical",
+			PhysicalPlan: "physical",
+			Transforms: []models.TransformTiming{
+				{Name: "1 sum", Blocks: 2, Duration: time.Second},
+			},
+			StorageCalls: []models.StorageCall{
+				{Namespace: "default", Duration: time.Second, Series: 3, Datapoints: 30},
+			},
+		},
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	renderResultsJSON(buffer, nil, stats)
+
+	expected := `{"status":"success","data":{"resultType":"matrix","result":[]},` +
+		`"stats":{"seriesFetched":0,"datapointsDecoded":0,"stages":[],` +
+		`"debug":{"logicalPlan":"logical","physicalPlan":"physical",` +
+		`"transforms":[{"name":"1 sum","blocks":2,"durationSeconds":1.000000}],` +
+		`"storageCalls":[{"namespace":"default","durationSeconds":1.000000,` +
+		`"series":3,"datapoints":30}]}}}`
+	assert.Equal(t, expected, buffer.String())
+}
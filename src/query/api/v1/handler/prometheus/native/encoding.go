@@ -0,0 +1,276 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/nativepb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/compress"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+const (
+	protobufFormat = "protobuf"
+	msgpackFormat  = "msgpack"
+
+	jsonContentType     = "application/json"
+	protobufContentType = "application/x-protobuf"
+	msgpackContentType  = "application/x-msgpack"
+
+	acceptHeader = "Accept"
+	varyHeader   = "Vary"
+)
+
+// resultsEncoding is an encoding that query results are rendered with.
+type resultsEncoding int
+
+const (
+	jsonResultsEncoding resultsEncoding = iota
+	protobufResultsEncoding
+	msgpackResultsEncoding
+)
+
+// negotiatedContentTypes are the content types that can be requested with
+// the Accept header, in order of server preference.
+var negotiatedContentTypes = []string{
+	jsonContentType,
+	protobufContentType,
+	msgpackContentType,
+}
+
+func (e resultsEncoding) contentType() string {
+	switch e {
+	case protobufResultsEncoding:
+		return protobufContentType
+	case msgpackResultsEncoding:
+		return msgpackContentType
+	}
+	return jsonContentType
+}
+
+// parseResultsEncoding returns the encoding named by the format param, or
+// the encoding negotiated from the Accept header if no format is set.
+// Results are rendered as JSON unless another encoding is requested.
+func parseResultsEncoding(r *http.Request) resultsEncoding {
+	switch r.FormValue(formatParam) {
+	case protobufFormat:
+		return protobufResultsEncoding
+	case msgpackFormat:
+		return msgpackResultsEncoding
+	case "":
+	default:
+		return jsonResultsEncoding
+	}
+
+	switch compress.Negotiate(r.Header.Get(acceptHeader), negotiatedContentTypes) {
+	case protobufContentType:
+		return protobufResultsEncoding
+	case msgpackContentType:
+		return msgpackResultsEncoding
+	}
+	return jsonResultsEncoding
+}
+
+// renderResults renders the results with the encoding requested by the
+// request.
+func renderResults(
+	w http.ResponseWriter,
+	r *http.Request,
+	series []*ts.Series,
+	stats models.QueryStatsSnapshot,
+) error {
+	encoding := parseResultsEncoding(r)
+	w.Header().Add(varyHeader, acceptHeader)
+	w.Header().Set("Content-Type", encoding.contentType())
+	switch encoding {
+	case protobufResultsEncoding:
+		return renderResultsProtobuf(w, series, stats)
+	case msgpackResultsEncoding:
+		return renderResultsMsgpack(w, series, stats)
+	}
+
+	renderResultsJSON(w, series, stats)
+	return nil
+}
+
+// renderResultsProtobuf renders the results as a QueryResult protobuf
+// message, timestamps are in milliseconds and datapoints with NaN values
+// are omitted.
+func renderResultsProtobuf(w io.Writer, series []*ts.Series, stats models.QueryStatsSnapshot) error {
+	result := nativepb.QueryResult{
+		Series:   make([]*nativepb.Series, 0, len(series)),
+		Warnings: stats.Warnings,
+		Stats: &nativepb.Stats{
+			SeriesFetched:     int64(stats.SeriesFetched),
+			DatapointsDecoded: int64(stats.DatapointsDecoded),
+			Stages:            make([]*nativepb.Stage, 0, len(stats.Stages)),
+		},
+	}
+
+	for _, s := range series {
+		pbSeries := &nativepb.Series{
+			Tags: make([]*nativepb.Tag, 0, len(s.Tags)),
+		}
+		for name, value := range s.Tags {
+			pbSeries.Tags = append(pbSeries.Tags, &nativepb.Tag{Name: name, Value: value})
+		}
+
+		values := s.Values()
+		for i := 0; i < s.Len(); i++ {
+			dp := values.DatapointAt(i)
+			if math.IsNaN(dp.Value) {
+				continue
+			}
+			pbSeries.Timestamps = append(pbSeries.Timestamps,
+				dp.Timestamp.UnixNano()/int64(time.Millisecond))
+			pbSeries.Values = append(pbSeries.Values, dp.Value)
+		}
+
+		result.Series = append(result.Series, pbSeries)
+	}
+
+	for _, stage := range stats.Stages {
+		result.Stats.Stages = append(result.Stats.Stages, &nativepb.Stage{
+			Name:            stage.Name,
+			DurationSeconds: stage.Duration.Seconds(),
+		})
+	}
+
+	data, err := result.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// renderResultsMsgpack renders the results as a msgpack map with the same
+// fields as the QueryResult protobuf message.
+func renderResultsMsgpack(w io.Writer, series []*ts.Series, stats models.QueryStatsSnapshot) error {
+	enc := msgpackWriter{enc: msgpack.NewEncoder(w)}
+	enc.mapLen(3)
+
+	enc.string("series")
+	enc.arrayLen(len(series))
+	for _, s := range series {
+		enc.mapLen(3)
+		enc.string("tags")
+		enc.mapLen(len(s.Tags))
+		for name, value := range s.Tags {
+			enc.string(name)
+			enc.string(value)
+		}
+
+		values := s.Values()
+		count := 0
+		for i := 0; i < s.Len(); i++ {
+			if !math.IsNaN(values.ValueAt(i)) {
+				count++
+			}
+		}
+
+		enc.string("timestamps")
+		enc.arrayLen(count)
+		for i := 0; i < s.Len(); i++ {
+			dp := values.DatapointAt(i)
+			if !math.IsNaN(dp.Value) {
+				enc.int64(dp.Timestamp.UnixNano() / int64(time.Millisecond))
+			}
+		}
+
+		enc.string("values")
+		enc.arrayLen(count)
+		for i := 0; i < s.Len(); i++ {
+			if v := values.ValueAt(i); !math.IsNaN(v) {
+				enc.float64(v)
+			}
+		}
+	}
+
+	enc.string("warnings")
+	enc.arrayLen(len(stats.Warnings))
+	for _, warning := range stats.Warnings {
+		enc.string(warning)
+	}
+
+	enc.string("stats")
+	enc.mapLen(3)
+	enc.string("seriesFetched")
+	enc.int64(int64(stats.SeriesFetched))
+	enc.string("datapointsDecoded")
+	enc.int64(int64(stats.DatapointsDecoded))
+	enc.string("stages")
+	enc.arrayLen(len(stats.Stages))
+	for _, stage := range stats.Stages {
+		enc.mapLen(2)
+		enc.string("name")
+		enc.string(stage.Name)
+		enc.string("durationSeconds")
+		enc.float64(stage.Duration.Seconds())
+	}
+
+	return enc.err
+}
+
+// msgpackWriter wraps a msgpack encoder, retaining the first error so
+// the results can be encoded without checking each write.
+type msgpackWriter struct {
+	enc *msgpack.Encoder
+	err error
+}
+
+func (w *msgpackWriter) mapLen(v int) {
+	if w.err == nil {
+		w.err = w.enc.EncodeMapLen(v)
+	}
+}
+
+func (w *msgpackWriter) arrayLen(v int) {
+	if w.err == nil {
+		w.err = w.enc.EncodeArrayLen(v)
+	}
+}
+
+func (w *msgpackWriter) string(v string) {
+	if w.err == nil {
+		w.err = w.enc.EncodeString(v)
+	}
+}
+
+func (w *msgpackWriter) int64(v int64) {
+	if w.err == nil {
+		w.err = w.enc.EncodeInt64(v)
+	}
+}
+
+func (w *msgpackWriter) float64(v float64) {
+	if w.err == nil {
+		w.err = w.enc.EncodeFloat64(v)
+	}
+}
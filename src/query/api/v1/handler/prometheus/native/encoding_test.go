@@ -0,0 +1,155 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/nativepb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+func testResults() ([]*ts.Series, models.QueryStatsSnapshot) {
+	start := time.Unix(1535000000, 0)
+	values := ts.NewFixedStepValues(10*time.Second, 3, math.NaN(), start)
+	values.SetValueAt(0, 1)
+	values.SetValueAt(2, 2.5)
+	series := []*ts.Series{
+		ts.NewSeries("foo", values, models.Tags{"__name__": "foo"}),
+	}
+
+	stats := models.QueryStatsSnapshot{
+		SeriesFetched:     1,
+		DatapointsDecoded: 2,
+		Stages:            []models.StageTiming{{Name: "parse", Duration: time.Second}},
+		Warnings:          []string{"truncated"},
+	}
+
+	return series, stats
+}
+
+func TestParseResultsEncoding(t *testing.T) {
+	tests := []struct {
+		format   string
+		accept   string
+		expected resultsEncoding
+	}{
+		{"", "", jsonResultsEncoding},
+		{"", "*/*", jsonResultsEncoding},
+		{"", "application/x-protobuf", protobufResultsEncoding},
+		{"", "application/json;q=0.5, application/x-msgpack", msgpackResultsEncoding},
+		{"", "application/x-protobuf;q=0.2, application/json;q=0.9", jsonResultsEncoding},
+		{"protobuf", "application/json", protobufResultsEncoding},
+		{"msgpack", "", msgpackResultsEncoding},
+		{"json", "application/x-protobuf", jsonResultsEncoding},
+	}
+
+	for _, tt := range tests {
+		vals := defaultParams()
+		if tt.format != "" {
+			vals.Set(formatParam, tt.format)
+		}
+		req, _ := http.NewRequest("GET", PromReadURL, nil)
+		req.URL.RawQuery = vals.Encode()
+		req.Header.Set(acceptHeader, tt.accept)
+		assert.Equal(t, tt.expected, parseResultsEncoding(req), "format=%s, accept=%s", tt.format, tt.accept)
+	}
+}
+
+func TestRenderResultsProtobuf(t *testing.T) {
+	series, stats := testResults()
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, renderResultsProtobuf(buffer, series, stats))
+
+	var result nativepb.QueryResult
+	require.NoError(t, result.Unmarshal(buffer.Bytes()))
+
+	require.Len(t, result.Series, 1)
+	assert.Equal(t, []*nativepb.Tag{{Name: "__name__", Value: "foo"}}, result.Series[0].Tags)
+	assert.Equal(t, []int64{1535000000000, 1535000020000}, result.Series[0].Timestamps)
+	assert.Equal(t, []float64{1, 2.5}, result.Series[0].Values)
+	assert.Equal(t, []string{"truncated"}, result.Warnings)
+	require.NotNil(t, result.Stats)
+	assert.Equal(t, int64(1), result.Stats.SeriesFetched)
+	assert.Equal(t, int64(2), result.Stats.DatapointsDecoded)
+	assert.Equal(t, []*nativepb.Stage{{Name: "parse", DurationSeconds: 1}}, result.Stats.Stages)
+}
+
+func TestRenderResultsMsgpack(t *testing.T) {
+	series, stats := testResults()
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, renderResultsMsgpack(buffer, series, stats))
+
+	var result struct {
+		Series []struct {
+			Tags       map[string]string `msgpack:"tags"`
+			Timestamps []int64           `msgpack:"timestamps"`
+			Values     []float64         `msgpack:"values"`
+		} `msgpack:"series"`
+		Warnings []string `msgpack:"warnings"`
+		Stats    struct {
+			SeriesFetched     int `msgpack:"seriesFetched"`
+			DatapointsDecoded int `msgpack:"datapointsDecoded"`
+			Stages            []struct {
+				Name            string  `msgpack:"name"`
+				DurationSeconds float64 `msgpack:"durationSeconds"`
+			} `msgpack:"stages"`
+		} `msgpack:"stats"`
+	}
+	require.NoError(t, msgpack.Unmarshal(buffer.Bytes(), &result))
+
+	require.Len(t, result.Series, 1)
+	assert.Equal(t, map[string]string{"__name__": "foo"}, result.Series[0].Tags)
+	assert.Equal(t, []int64{1535000000000, 1535000020000}, result.Series[0].Timestamps)
+	assert.Equal(t, []float64{1, 2.5}, result.Series[0].Values)
+	assert.Equal(t, []string{"truncated"}, result.Warnings)
+	assert.Equal(t, 1, result.Stats.SeriesFetched)
+	assert.Equal(t, 2, result.Stats.DatapointsDecoded)
+	require.Len(t, result.Stats.Stages, 1)
+	assert.Equal(t, "parse", result.Stats.Stages[0].Name)
+	assert.Equal(t, 1.0, result.Stats.Stages[0].DurationSeconds)
+}
+
+func TestRenderResultsContentType(t *testing.T) {
+	series, stats := testResults()
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = defaultParams().Encode()
+	req.Header.Set(acceptHeader, protobufContentType)
+
+	recorder := httptest.NewRecorder()
+	require.NoError(t, renderResults(recorder, req, series, stats))
+	assert.Equal(t, protobufContentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, acceptHeader, recorder.Header().Get(varyHeader))
+
+	var result nativepb.QueryResult
+	require.NoError(t, result.Unmarshal(recorder.Body.Bytes()))
+	assert.Len(t, result.Series, 1)
+}
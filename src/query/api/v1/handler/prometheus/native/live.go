@@ -0,0 +1,316 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PromLiveReadURL is the url to stream live native prom query results from
+	PromLiveReadURL = PromReadURL + "/live"
+
+	// PromLiveReadHTTPMethod is the HTTP method used to stream live queries.
+	PromLiveReadHTTPMethod = http.MethodGet
+
+	// DefaultLiveQueryMaxDuration is the default time a live query streams
+	// results for before the stream is closed, clients are expected to
+	// reconnect.
+	DefaultLiveQueryMaxDuration = time.Hour
+
+	intervalParam = "interval"
+	rangeParam    = "range"
+	modeParam     = "mode"
+
+	defaultLiveQueryInterval = 10 * time.Second
+	minLiveQueryInterval     = time.Second
+
+	liveResultEvent = "result"
+	liveErrorEvent  = "error"
+)
+
+var (
+	errStreamingUnsupported = errors.New("streaming responses are not supported")
+)
+
+type liveQueryMode string
+
+const (
+	// liveQueryModeEvaluate streams the results of the query over the range
+	// ending at the time of each evaluation.
+	liveQueryModeEvaluate liveQueryMode = "evaluate"
+
+	// liveQueryModeTail streams only the datapoints that are newer than
+	// those of the previous update.
+	liveQueryModeTail liveQueryMode = "tail"
+)
+
+type liveQueryParams struct {
+	params   models.RequestParams
+	interval time.Duration
+	window   time.Duration
+	mode     liveQueryMode
+}
+
+// PromLiveReadHandler re-evaluates a query at an interval and streams the
+// results to the client as server-sent events, so that dashboards can
+// update live without polling.
+type PromLiveReadHandler struct {
+	engine      *executor.Engine
+	maxDuration time.Duration
+	nowFn       func() time.Time
+}
+
+// NewPromLiveReadHandler returns a new instance of the live read handler.
+func NewPromLiveReadHandler(
+	engine *executor.Engine,
+	maxDuration time.Duration,
+) http.Handler {
+	return &PromLiveReadHandler{
+		engine:      engine,
+		maxDuration: maxDuration,
+		nowFn:       time.Now,
+	}
+}
+
+func (h *PromLiveReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handler.Error(w, errStreamingUnsupported, http.StatusInternalServerError)
+		return
+	}
+
+	liveParams, rErr := parseLiveParams(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.maxDuration)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(liveParams.interval)
+	defer ticker.Stop()
+
+	var last time.Time
+	for {
+		now := h.nowFn()
+		if err := h.update(ctx, w, liveParams, last, now); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("unable to stream live query update", zap.Any("error", err))
+			if err := writeErrorEvent(w, err); err != nil {
+				return
+			}
+		} else {
+			last = now
+		}
+		flusher.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update evaluates the query at now and writes the results as an event,
+// last is the time of the previous successful update if any.
+func (h *PromLiveReadHandler) update(
+	ctx context.Context,
+	w io.Writer,
+	liveParams liveQueryParams,
+	last time.Time,
+	now time.Time,
+) error {
+	params := liveParams.params
+	params.Now = now
+	params.End = now
+	params.Start = now.Add(-liveParams.window)
+	tail := liveParams.mode == liveQueryModeTail
+	if tail && !last.IsZero() && last.After(params.Start) {
+		params.Start = last
+	}
+
+	evalCtx, cancel := context.WithTimeout(ctx, params.Timeout)
+	defer cancel()
+
+	stats := models.NewQueryStats()
+	opts := &executor.EngineOptions{Stats: stats}
+	series, err := executeQuery(evalCtx, h.engine, params, opts)
+	if err != nil {
+		return err
+	}
+
+	if tail && !last.IsZero() {
+		series = datapointsAfter(series, last)
+	}
+
+	var buf bytes.Buffer
+	renderResultsJSON(&buf, series, stats.Snapshot())
+	return writeEvent(w, liveResultEvent, buf.Bytes())
+}
+
+// datapointsAfter returns the series with only the datapoints after a time,
+// series without any such datapoints are omitted.
+func datapointsAfter(series []*ts.Series, after time.Time) []*ts.Series {
+	result := make([]*ts.Series, 0, len(series))
+	for _, s := range series {
+		var (
+			vals = s.Values()
+			dps  ts.Datapoints
+		)
+		for i := 0; i < vals.Len(); i++ {
+			dp := vals.DatapointAt(i)
+			if math.IsNaN(dp.Value) || !dp.Timestamp.After(after) {
+				continue
+			}
+			dps = append(dps, dp)
+		}
+		if len(dps) == 0 {
+			continue
+		}
+		result = append(result, ts.NewSeries(s.Name(), dps, s.Tags))
+	}
+	return result
+}
+
+func writeErrorEvent(w io.Writer, err error) error {
+	data, mErr := json.Marshal(struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	})
+	if mErr != nil {
+		return mErr
+	}
+	return writeEvent(w, liveErrorEvent, data)
+}
+
+// writeEvent writes a server-sent event, each line of the data is written
+// as a separate data field.
+func writeEvent(w io.Writer, event string, data []byte) error {
+	var buf bytes.Buffer
+	buf.WriteString("event: ")
+	buf.WriteString(event)
+	buf.WriteByte('\n')
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// parseLiveParams parses the params of a live query request.
+func parseLiveParams(r *http.Request) (liveQueryParams, *handler.ParseError) {
+	liveParams := liveQueryParams{
+		interval: defaultLiveQueryInterval,
+		mode:     liveQueryModeEvaluate,
+	}
+
+	timeout, err := prometheus.ParseRequestTimeout(r)
+	if err != nil {
+		return liveParams, handler.NewParseError(err, http.StatusBadRequest)
+	}
+	liveParams.params.Timeout = timeout
+
+	target, err := parseTarget(r)
+	if err != nil {
+		return liveParams, handler.NewParseError(fmt.Errorf(formatErrStr, targetParam, err), http.StatusBadRequest)
+	}
+	liveParams.params.Target = target
+
+	if r.FormValue(intervalParam) != "" {
+		interval, err := parseDuration(r, intervalParam)
+		if err == nil && interval < minLiveQueryInterval {
+			err = fmt.Errorf("must be at least %v", minLiveQueryInterval)
+		}
+		if err != nil {
+			return liveParams, handler.NewParseError(fmt.Errorf(formatErrStr, intervalParam, err), http.StatusBadRequest)
+		}
+		liveParams.interval = interval
+	}
+
+	liveParams.params.Step = liveParams.interval
+	if r.FormValue(stepParam) != "" {
+		step, err := parseDuration(r, stepParam)
+		if err == nil && step <= 0 {
+			err = errors.New("must be positive")
+		}
+		if err != nil {
+			return liveParams, handler.NewParseError(fmt.Errorf(formatErrStr, stepParam, err), http.StatusBadRequest)
+		}
+		liveParams.params.Step = step
+	}
+
+	liveParams.window = liveParams.params.Step
+	if r.FormValue(rangeParam) != "" {
+		window, err := parseDuration(r, rangeParam)
+		if err == nil && window < liveParams.params.Step {
+			err = fmt.Errorf("must be at least the step %v", liveParams.params.Step)
+		}
+		if err != nil {
+			return liveParams, handler.NewParseError(fmt.Errorf(formatErrStr, rangeParam, err), http.StatusBadRequest)
+		}
+		liveParams.window = window
+	}
+
+	if mode := r.FormValue(modeParam); mode != "" {
+		switch liveQueryMode(mode) {
+		case liveQueryModeEvaluate, liveQueryModeTail:
+			liveParams.mode = liveQueryMode(mode)
+		default:
+			err := fmt.Errorf("unknown mode %s", mode)
+			return liveParams, handler.NewParseError(fmt.Errorf(formatErrStr, modeParam, err), http.StatusBadRequest)
+		}
+	}
+
+	return liveParams, nil
+}
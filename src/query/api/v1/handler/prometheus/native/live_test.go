@@ -0,0 +1,197 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flushRecorder records a response and calls onFlush on every flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	onFlush func()
+}
+
+func (r *flushRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.onFlush()
+}
+
+func newLiveRequest(t *testing.T, vals url.Values) *http.Request {
+	req, err := http.NewRequest(PromLiveReadHTTPMethod, PromLiveReadURL, nil)
+	require.NoError(t, err)
+	req.URL.RawQuery = vals.Encode()
+	return req
+}
+
+func TestParseLiveParams(t *testing.T) {
+	vals := url.Values{}
+	vals.Add(targetParam, promQuery)
+	liveParams, rErr := parseLiveParams(newLiveRequest(t, vals))
+	require.Nil(t, rErr)
+	assert.Equal(t, promQuery, liveParams.params.Target)
+	assert.Equal(t, defaultLiveQueryInterval, liveParams.interval)
+	assert.Equal(t, defaultLiveQueryInterval, liveParams.params.Step)
+	assert.Equal(t, defaultLiveQueryInterval, liveParams.window)
+	assert.Equal(t, liveQueryModeEvaluate, liveParams.mode)
+
+	vals.Add(intervalParam, "5s")
+	vals.Add(stepParam, "1m")
+	vals.Add(rangeParam, "1h")
+	vals.Add(modeParam, "tail")
+	liveParams, rErr = parseLiveParams(newLiveRequest(t, vals))
+	require.Nil(t, rErr)
+	assert.Equal(t, 5*time.Second, liveParams.interval)
+	assert.Equal(t, time.Minute, liveParams.params.Step)
+	assert.Equal(t, time.Hour, liveParams.window)
+	assert.Equal(t, liveQueryModeTail, liveParams.mode)
+}
+
+func TestParseLiveParamsInvalid(t *testing.T) {
+	tests := []url.Values{
+		{},
+		{targetParam: {promQuery}, intervalParam: {"10ms"}},
+		{targetParam: {promQuery}, stepParam: {"-1s"}},
+		{targetParam: {promQuery}, stepParam: {"1m"}, rangeParam: {"10s"}},
+		{targetParam: {promQuery}, modeParam: {"follow"}},
+	}
+	for _, vals := range tests {
+		_, rErr := parseLiveParams(newLiveRequest(t, vals))
+		require.NotNil(t, rErr, vals.Encode())
+		assert.Equal(t, http.StatusBadRequest, rErr.Code())
+	}
+}
+
+func TestPromLiveReadStreamsResults(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+	b := test.NewBlockFromValues(bounds, values)
+
+	mockStorage := mock.NewMockStorage()
+	mockStorage.SetFetchBlocksResult(block.Result{Blocks: []block.Block{b}}, nil)
+
+	h := NewPromLiveReadHandler(executor.NewEngine(mockStorage), DefaultLiveQueryMaxDuration)
+
+	vals := url.Values{}
+	vals.Add(targetParam, promQuery)
+	vals.Add(stepParam, "1m")
+	vals.Add(rangeParam, "5m")
+	vals.Add(intervalParam, "1s")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := newLiveRequest(t, vals).WithContext(ctx)
+
+	flushes := 0
+	recorder := &flushRecorder{
+		ResponseRecorder: httptest.NewRecorder(),
+		onFlush: func() {
+			flushes++
+			if flushes == 2 {
+				cancel()
+			}
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(recorder, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for live query to stop")
+	}
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+
+	body := recorder.Body.String()
+	assert.Equal(t, 2, strings.Count(body, "event: result\n"))
+	assert.Contains(t, body, `data: {"status":"success"`)
+}
+
+func TestPromLiveReadRequiresFlusher(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	h := NewPromLiveReadHandler(nil, DefaultLiveQueryMaxDuration)
+	vals := url.Values{}
+	vals.Add(targetParam, promQuery)
+
+	var w struct{ http.ResponseWriter }
+	recorder := httptest.NewRecorder()
+	w.ResponseWriter = recorder
+	h.ServeHTTP(w, newLiveRequest(t, vals))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestDatapointsAfter(t *testing.T) {
+	start := time.Now().Truncate(time.Minute)
+	dps := ts.Datapoints{
+		{Timestamp: start, Value: 1},
+		{Timestamp: start.Add(time.Minute), Value: 2},
+		{Timestamp: start.Add(2 * time.Minute), Value: math.NaN()},
+		{Timestamp: start.Add(3 * time.Minute), Value: 4},
+	}
+	tags := models.Tags{"a": "b"}
+	series := []*ts.Series{
+		ts.NewSeries("new", dps, tags),
+		ts.NewSeries("old", dps[:1], tags),
+	}
+
+	result := datapointsAfter(series, start)
+	require.Len(t, result, 1)
+	assert.Equal(t, "new", result[0].Name())
+	assert.Equal(t, tags, result[0].Tags)
+
+	vals := result[0].Values()
+	require.Equal(t, 2, vals.Len())
+	assert.Equal(t, 2.0, vals.ValueAt(0))
+	assert.Equal(t, 4.0, vals.ValueAt(1))
+}
+
+func TestWriteEvent(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeEvent(&buf, liveResultEvent, []byte("{\n\"a\":1\n}\n")))
+	assert.Equal(t, "event: result\ndata: {\ndata: \"a\":1\ndata: }\n\n", buf.String())
+}
@@ -26,14 +26,18 @@ import (
 	"math"
 	"net/http"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/executor"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser/promql"
+	"github.com/m3db/m3/src/query/policy/rules"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3x/errors"
 
 	"go.uber.org/zap"
 )
@@ -55,7 +59,8 @@ var (
 
 // PromReadHandler represents a handler for prometheus read endpoint.
 type PromReadHandler struct {
-	engine *executor.Engine
+	engine    *executor.Engine
+	splitOpts QuerySplitOptions
 }
 
 // ReadResponse is the response that gets returned to the user
@@ -69,8 +74,11 @@ type blockWithMeta struct {
 }
 
 // NewPromReadHandler returns a new instance of handler.
-func NewPromReadHandler(engine *executor.Engine) http.Handler {
-	return &PromReadHandler{engine: engine}
+func NewPromReadHandler(
+	engine *executor.Engine,
+	splitOpts QuerySplitOptions,
+) http.Handler {
+	return &PromReadHandler{engine: engine, splitOpts: splitOpts}
 }
 
 func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -83,40 +91,86 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format, exportResults, rErr := parseExportFormat(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
 	if params.Debug {
 		logger.Info("Request params", zap.Any("params", params))
 	}
 
-	result, err := h.read(ctx, w, params)
+	stats := models.NewQueryStats()
+	result, err := h.read(ctx, w, params, stats)
 	if err != nil {
+		if rules.IsBlockedError(err) {
+			handler.Error(w, err, http.StatusBadRequest)
+			return
+		}
 		logger.Error("unable to fetch data", zap.Any("error", err))
 		handler.Error(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	// TODO: Support multiple result types
-	w.Header().Set("Content-Type", "application/json")
-	renderResultsJSON(w, result)
+	snapshot := stats.Snapshot()
+	if len(snapshot.ReadConsistency) > 0 {
+		w.Header().Set(handler.ReadConsistencyHeader,
+			strings.Join(snapshot.ReadConsistency, ","))
+	}
+
+	if exportResults {
+		w.Header().Set("Content-Type", format.ContentType())
+		if err := renderResultsExport(w, result, format); err != nil {
+			// Headers have been written so only log the error
+			logger.Error("unable to export results", zap.Any("error", err))
+		}
+		return
+	}
+
+	if err := renderResults(w, r, result, snapshot); err != nil {
+		// Headers have been written so only log the error
+		logger.Error("unable to render results", zap.Any("error", err))
+	}
 }
 
-func (h *PromReadHandler) read(reqCtx context.Context, w http.ResponseWriter, params models.RequestParams) ([]*ts.Series, error) {
+func (h *PromReadHandler) read(
+	reqCtx context.Context,
+	w http.ResponseWriter,
+	params models.RequestParams,
+	stats *models.QueryStats,
+) ([]*ts.Series, error) {
 	ctx, cancel := context.WithTimeout(reqCtx, params.Timeout)
 	defer cancel()
 
-	opts := &executor.EngineOptions{}
+	opts := &executor.EngineOptions{Stats: stats}
 	// Detect clients closing connections
 	abortCh, _ := handler.CloseWatcher(ctx, w)
 	opts.AbortCh = abortCh
 
-	// TODO: Capture timing
+	return executeSplitQuery(ctx, h.engine, params, opts, h.splitOpts)
+}
+
+// executeQuery parses and executes the query, returning the results as a
+// list of series.
+func executeQuery(
+	ctx context.Context,
+	engine *executor.Engine,
+	params models.RequestParams,
+	opts *executor.EngineOptions,
+) ([]*ts.Series, error) {
+	stats := opts.Stats
+	parseStart := time.Now()
 	parser, err := promql.Parse(params.Target)
 	if err != nil {
-		return nil, err
+		// Invalid queries fail again when retried.
+		return nil, xerrors.NewInvalidParamsError(err)
 	}
+	stats.AddStage("parse", time.Since(parseStart))
 
 	// Results is closed by execute
 	results := make(chan executor.Query)
-	go h.engine.ExecuteExpr(ctx, parser, opts, params, results)
+	go engine.ExecuteExpr(ctx, parser, opts, params, results)
 
 	// Block slices are sorted by start time
 	// TODO: Pooling
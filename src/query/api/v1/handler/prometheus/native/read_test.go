@@ -51,7 +51,7 @@ func TestPromRead(t *testing.T) {
 
 	r, parseErr := parseParams(req)
 	require.Nil(t, parseErr)
-	seriesList, err := promRead.read(context.TODO(), httptest.NewRecorder(), r)
+	seriesList, err := promRead.read(context.TODO(), httptest.NewRecorder(), r, nil)
 	require.NoError(t, err)
 	require.Len(t, seriesList, 2)
 	s := seriesList[0]
@@ -0,0 +1,209 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/retry"
+	"github.com/m3db/m3/src/query/ts"
+	xretry "github.com/m3db/m3x/retry"
+)
+
+// QuerySplitOptions are the options for splitting long range queries into
+// sub-range queries that are executed concurrently and stitched back
+// together, the zero value does not split queries.
+type QuerySplitOptions struct {
+	// Interval is the length of the sub-ranges. Sub-ranges are aligned to
+	// multiples of the interval since the Unix epoch, so an interval that is
+	// a multiple of the downsampled resolutions splits queries on the
+	// boundaries of downsampled datapoints. Zero disables splitting.
+	Interval time.Duration
+
+	// MaxParallelism is the max number of sub-range queries of a query that
+	// are executed at once, zero executes all of them at once.
+	MaxParallelism int
+
+	// Retrier retries sub-range queries that fail transiently without
+	// executing the sub-range queries that succeeded again, nil does not
+	// retry sub-range queries.
+	Retrier xretry.Retrier
+}
+
+// splitQueryParams splits the params of a query into the params of its
+// sub-range queries. Sub-ranges start and end on steps of the query so the
+// stitched sub-range results have exactly the steps of the query.
+func splitQueryParams(
+	params models.RequestParams,
+	interval time.Duration,
+) []models.RequestParams {
+	if interval <= 0 || params.Step <= 0 || !params.End.After(params.Start) {
+		return []models.RequestParams{params}
+	}
+
+	var (
+		result []models.RequestParams
+		start  = params.Start
+	)
+	for !start.After(params.End) {
+		nanos := start.UnixNano()
+		boundary := time.Unix(0, nanos-nanos%int64(interval)+int64(interval))
+		// End at the last step before the next boundary.
+		steps := (boundary.Sub(start) + params.Step - 1) / params.Step
+		end := start.Add((steps - 1) * params.Step)
+		if end.After(params.End) {
+			end = params.End
+		}
+
+		subParams := params
+		subParams.Start = start
+		subParams.End = end
+		result = append(result, subParams)
+		start = end.Add(params.Step)
+	}
+	return result
+}
+
+// executeSplitQuery executes a query split into sub-range queries if it
+// spans more than one sub-range, returning the stitched results.
+func executeSplitQuery(
+	ctx context.Context,
+	engine *executor.Engine,
+	params models.RequestParams,
+	opts *executor.EngineOptions,
+	splitOpts QuerySplitOptions,
+) ([]*ts.Series, error) {
+	subParams := splitQueryParams(params, splitOpts.Interval)
+	if len(subParams) == 1 {
+		return executeQuery(ctx, engine, params, opts)
+	}
+
+	// Cancel the remaining sub-range queries once one fails and can not be
+	// retried.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	parallelism := splitOpts.MaxParallelism
+	if parallelism <= 0 || parallelism > len(subParams) {
+		parallelism = len(subParams)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errLock  sync.Mutex
+		firstErr error
+		tokens   = make(chan struct{}, parallelism)
+		results  = make([][]*ts.Series, len(subParams))
+	)
+	for i := range subParams {
+		tokens <- struct{}{}
+		if ctx.Err() != nil {
+			<-tokens
+			break
+		}
+
+		i := i
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+
+			var series []*ts.Series
+			err := retry.Attempt(ctx, splitOpts.Retrier, func() error {
+				var err error
+				series, err = executeQuery(ctx, engine, subParams[i], opts)
+				return err
+			})
+			if err != nil {
+				errLock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errLock.Unlock()
+				cancel()
+				return
+			}
+			results[i] = series
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stitchStart := time.Now()
+	series := stitchSeries(params, subParams, results)
+	if opts.Stats != nil {
+		opts.Stats.AddStage("stitch", time.Since(stitchStart))
+	}
+	return series, nil
+}
+
+// stitchSeries stitches the results of sub-range queries into series over
+// the whole range of the query, series are matched by their tags and steps
+// of sub-ranges a series is missing from are NaN.
+func stitchSeries(
+	params models.RequestParams,
+	subParams []models.RequestParams,
+	results [][]*ts.Series,
+) []*ts.Series {
+	numSteps := int(params.End.Sub(params.Start)/params.Step) + 1
+	var (
+		stitched = make([]*ts.Series, 0, len(results[0]))
+		values   = make([]ts.FixedResolutionMutableValues, 0, len(results[0]))
+		byID     = make(map[string]int, len(results[0]))
+	)
+	for i, seriesList := range results {
+		offset := int(subParams[i].Start.Sub(params.Start) / params.Step)
+		for _, series := range seriesList {
+			id := series.Tags.ID()
+			idx, ok := byID[id]
+			if !ok {
+				idx = len(stitched)
+				byID[id] = idx
+				vals := ts.NewFixedStepValues(params.Step, numSteps, math.NaN(), params.Start)
+				values = append(values, vals)
+				stitched = append(stitched, ts.NewSeries(series.Name(), vals, series.Tags))
+			}
+
+			if completeness := series.Completeness(); completeness < stitched[idx].Completeness() {
+				stitched[idx].SetCompleteness(completeness)
+			}
+
+			seriesValues := series.Values()
+			for j := 0; j < seriesValues.Len() && offset+j < numSteps; j++ {
+				values[idx].SetValueAt(offset+j, seriesValues.ValueAt(j))
+			}
+		}
+	}
+	return stitched
+}
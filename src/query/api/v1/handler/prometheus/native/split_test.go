@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitQueryParams(t *testing.T) {
+	start := time.Date(2018, 9, 1, 22, 0, 0, 0, time.UTC)
+	params := models.RequestParams{
+		Start:  start,
+		End:    start.Add(26*time.Hour + 5*time.Minute),
+		Step:   time.Hour,
+		Target: "foo",
+	}
+
+	subParams := splitQueryParams(params, 24*time.Hour)
+	require.Len(t, subParams, 3)
+	assert.Equal(t, start, subParams[0].Start)
+	assert.Equal(t, start.Add(time.Hour), subParams[0].End)
+	assert.Equal(t, start.Add(2*time.Hour), subParams[1].Start)
+	assert.Equal(t, start.Add(25*time.Hour), subParams[1].End)
+	assert.Equal(t, start.Add(26*time.Hour), subParams[2].Start)
+	assert.Equal(t, params.End, subParams[2].End)
+	assert.Equal(t, "foo", subParams[2].Target)
+
+	// Sub-ranges cover exactly the steps of the query.
+	var steps int
+	for _, p := range subParams {
+		steps += int(p.End.Sub(p.Start)/p.Step) + 1
+	}
+	assert.Equal(t, int(params.End.Sub(params.Start)/params.Step)+1, steps)
+}
+
+func TestSplitQueryParamsUnalignedStep(t *testing.T) {
+	start := time.Date(2018, 9, 1, 23, 59, 0, 0, time.UTC)
+	params := models.RequestParams{
+		Start: start,
+		End:   start.Add(3 * time.Minute),
+		Step:  7 * time.Second,
+	}
+
+	subParams := splitQueryParams(params, 24*time.Hour)
+	require.Len(t, subParams, 2)
+	// The first sub-range ends on the last step before midnight.
+	assert.Equal(t, start.Add(56*time.Second), subParams[0].End)
+	assert.Equal(t, start.Add(63*time.Second), subParams[1].Start)
+}
+
+func TestSplitQueryParamsNoSplit(t *testing.T) {
+	start := time.Date(2018, 9, 1, 1, 0, 0, 0, time.UTC)
+	params := models.RequestParams{
+		Start: start,
+		End:   start.Add(time.Hour),
+		Step:  time.Minute,
+	}
+
+	assert.Equal(t, []models.RequestParams{params}, splitQueryParams(params, 0))
+	assert.Equal(t, []models.RequestParams{params}, splitQueryParams(params, 24*time.Hour))
+}
+
+func TestStitchSeries(t *testing.T) {
+	start := time.Date(2018, 9, 1, 22, 0, 0, 0, time.UTC)
+	params := models.RequestParams{
+		Start: start,
+		End:   start.Add(4 * time.Hour),
+		Step:  time.Hour,
+	}
+	subParams := splitQueryParams(params, 24*time.Hour)
+	require.Len(t, subParams, 2)
+
+	newSeries := func(name string, from time.Time, values ...float64) *ts.Series {
+		vals := ts.NewFixedStepValues(time.Hour, len(values), math.NaN(), from)
+		for i, v := range values {
+			vals.SetValueAt(i, v)
+		}
+		return ts.NewSeries(name, vals, models.Tags{"name": name})
+	}
+	incomplete := newSeries("a", subParams[1].Start, 3, 4, 5)
+	incomplete.SetCompleteness(0.5)
+	results := [][]*ts.Series{
+		{newSeries("a", start, 1, 2)},
+		{newSeries("b", subParams[1].Start, 6, 7, 8), incomplete},
+	}
+
+	stitched := stitchSeries(params, subParams, results)
+	require.Len(t, stitched, 2)
+
+	assert.Equal(t, "a", stitched[0].Name())
+	assert.Equal(t, 0.5, stitched[0].Completeness())
+	assert.Equal(t, "b", stitched[1].Name())
+	assert.Equal(t, 1.0, stitched[1].Completeness())
+
+	values := func(s *ts.Series) []float64 {
+		result := make([]float64, s.Values().Len())
+		for i := range result {
+			result[i] = s.Values().ValueAt(i)
+		}
+		return result
+	}
+	assert.Equal(t, []float64{1, 2, 3, 4, 5}, values(stitched[0]))
+	b := values(stitched[1])
+	assert.True(t, math.IsNaN(b[0]))
+	assert.True(t, math.IsNaN(b[1]))
+	assert.Equal(t, []float64{6, 7, 8}, b[2:])
+}
+
+// flakyStorage fails the first fetch of blocks with a transient error.
+type flakyStorage struct {
+	mock.Storage
+	sync.Mutex
+	fetches int
+}
+
+func (s *flakyStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	s.Lock()
+	s.fetches++
+	fetches := s.fetches
+	s.Unlock()
+	if fetches == 1 {
+		return block.Result{}, errors.New("connection reset")
+	}
+	return s.Storage.FetchBlocks(ctx, query, options)
+}
+
+func TestExecuteSplitQueryRetriesFailedSubRangesOnly(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+	store := &flakyStorage{Storage: mock.NewMockStorage()}
+	store.SetFetchBlocksResult(block.Result{
+		Blocks: []block.Block{test.NewBlockFromValues(bounds, values)},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = defaultParams().Encode()
+	params, parseErr := parseParams(req)
+	require.Nil(t, parseErr)
+
+	splitOpts := QuerySplitOptions{
+		Interval:       20 * time.Minute,
+		MaxParallelism: 1,
+	}
+	numSubRanges := len(splitQueryParams(params, splitOpts.Interval))
+	require.True(t, numSubRanges > 1)
+
+	engine := executor.NewEngine(store)
+	opts := &executor.EngineOptions{}
+	_, err := executeSplitQuery(context.TODO(), engine, params, opts, splitOpts)
+	require.Error(t, err)
+	assert.Equal(t, 1, store.fetches)
+
+	store.fetches = 0
+	splitOpts.Retrier = xretry.NewRetrier(xretry.NewOptions().
+		SetInitialBackoff(time.Millisecond).
+		SetMaxRetries(2).
+		SetJitter(false))
+	_, err = executeSplitQuery(context.TODO(), engine, params, opts, splitOpts)
+	require.NoError(t, err)
+	// Only the sub-range that failed is executed again.
+	assert.Equal(t, numSubRanges+1, store.fetches)
+}
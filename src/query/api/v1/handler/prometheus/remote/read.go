@@ -30,11 +30,12 @@ import (
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
 	"github.com/m3db/m3/src/query/executor"
 	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/policy/rules"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/util/compress"
 	"github.com/m3db/m3/src/query/util/logging"
 
 	"github.com/golang/protobuf/proto"
-	"github.com/golang/snappy"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
@@ -47,6 +48,12 @@ const (
 	PromReadHTTPMethod = http.MethodPost
 )
 
+var (
+	// readResponseEncodings are the encodings read responses can be
+	// compressed with, in order of preference.
+	readResponseEncodings = []string{compress.Snappy, compress.Zstd, compress.Gzip}
+)
+
 // PromReadHandler represents a handler for prometheus read endpoint.
 type PromReadHandler struct {
 	engine          *executor.Engine
@@ -94,6 +101,11 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.read(ctx, w, req, timeout)
+	if err != nil && rules.IsBlockedError(err) {
+		h.promReadMetrics.fetchErrorsClient.Inc(1)
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		h.promReadMetrics.fetchErrorsServer.Inc(1)
 		logger.Error("unable to fetch data", zap.Any("error", err))
@@ -113,13 +125,22 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	encoding := readResponseEncoding(r)
+	compressed, err := compress.Encode(encoding, nil, data)
+	if err != nil {
+		h.promReadMetrics.fetchErrorsServer.Inc(1)
+		logger.Error("unable to compress read results", zap.String("encoding", encoding), zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/x-protobuf")
-	w.Header().Set("Content-Encoding", "snappy")
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Add("Vary", "Accept-Encoding")
 
-	compressed := snappy.Encode(nil, data)
 	if _, err := w.Write(compressed); err != nil {
 		h.promReadMetrics.fetchErrorsServer.Inc(1)
-		logger.Error("unable to encode read results to snappy", zap.Any("err", err))
+		logger.Error("unable to write read results", zap.String("encoding", encoding), zap.Any("err", err))
 		handler.Error(w, err, http.StatusInternalServerError)
 		return
 	}
@@ -127,6 +148,17 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.promReadMetrics.fetchSuccess.Inc(1)
 }
 
+// readResponseEncoding returns the encoding to compress the read response
+// with, Prometheus expects snappy so other encodings are only used if the
+// client explicitly prefers them.
+func readResponseEncoding(r *http.Request) string {
+	encoding := compress.Negotiate(r.Header.Get("Accept-Encoding"), readResponseEncodings)
+	if encoding == "" {
+		return compress.Snappy
+	}
+	return encoding
+}
+
 func (h *PromReadHandler) parseRequest(r *http.Request) (*prompb.ReadRequest, *handler.ParseError) {
 	reqBuf, err := prometheus.ParsePromCompressedRequest(r)
 	if err != nil {
@@ -34,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/test"
 	"github.com/m3db/m3/src/query/test/local"
+	"github.com/m3db/m3/src/query/util/compress"
 	"github.com/m3db/m3/src/query/util/logging"
 	xclock "github.com/m3db/m3x/clock"
 
@@ -82,6 +83,28 @@ func TestPromReadParsingBad(t *testing.T) {
 	require.NotNil(t, err, "unable to parse request")
 }
 
+func TestReadResponseEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{acceptEncoding: "", expected: compress.Snappy},
+		{acceptEncoding: "snappy", expected: compress.Snappy},
+		{acceptEncoding: "gzip, snappy", expected: compress.Snappy},
+		{acceptEncoding: "zstd", expected: compress.Zstd},
+		{acceptEncoding: "gzip", expected: compress.Gzip},
+		{acceptEncoding: "snappy;q=0.5, zstd", expected: compress.Zstd},
+		{acceptEncoding: "br", expected: compress.Snappy},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", PromReadURL, nil)
+		req.Header.Set("Accept-Encoding", test.acceptEncoding)
+		assert.Equal(t, test.expected, readResponseEncoding(req),
+			"accept encoding: %s", test.acceptEncoding)
+	}
+}
+
 func TestPromReadStorageWithFetchError(t *testing.T) {
 	logging.InitWithCores(nil)
 	ctrl := gomock.NewController(t)
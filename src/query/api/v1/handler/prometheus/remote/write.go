@@ -27,6 +27,7 @@ import (
 	"sync"
 
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/dbnode/x/logsample"
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
 	"github.com/m3db/m3/src/query/generated/proto/prompb"
@@ -45,6 +46,9 @@ const (
 
 	// PromWriteHTTPMethod is the HTTP method used with this resource.
 	PromWriteHTTPMethod = http.MethodPost
+
+	// PromWriteErrorLogClass is the log sampling class of write errors.
+	PromWriteErrorLogClass = "prom-remote-write"
 )
 
 var (
@@ -56,12 +60,14 @@ type PromWriteHandler struct {
 	store            storage.Storage
 	downsampler      downsample.Downsampler
 	promWriteMetrics promWriteMetrics
+	sampledLogger    *logging.SampledLogger
 }
 
 // NewPromWriteHandler returns a new instance of handler.
 func NewPromWriteHandler(
 	store storage.Storage,
 	downsampler downsample.Downsampler,
+	logSampler *logsample.Sampler,
 	scope tally.Scope,
 ) (http.Handler, error) {
 	if store == nil && downsampler == nil {
@@ -71,6 +77,7 @@ func NewPromWriteHandler(
 		store:            store,
 		downsampler:      downsampler,
 		promWriteMetrics: newPromWriteMetrics(scope),
+		sampledLogger:    logging.NewSampledLogger(logSampler),
 	}, nil
 }
 
@@ -97,7 +104,7 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := h.write(r.Context(), req); err != nil {
 		h.promWriteMetrics.writeErrorsServer.Inc(1)
-		logging.WithContext(r.Context()).Error("Write error", zap.Any("err", err))
+		h.sampledLogger.Error(r.Context(), PromWriteErrorLogClass, "Write error", zap.Any("err", err))
 		handler.Error(w, err, http.StatusInternalServerError)
 		return
 	}
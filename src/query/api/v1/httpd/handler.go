@@ -31,15 +31,29 @@ import (
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/cmd/services/m3query/config/reload"
+	"github.com/m3db/m3/src/dbnode/x/logsample"
+	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/backup"
+	"github.com/m3db/m3/src/query/api/v1/handler/configreload"
 	"github.com/m3db/m3/src/query/api/v1/handler/database"
+	"github.com/m3db/m3/src/query/api/v1/handler/events"
+	"github.com/m3db/m3/src/query/api/v1/handler/ingest"
 	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
 	"github.com/m3db/m3/src/query/api/v1/handler/openapi"
+	"github.com/m3db/m3/src/query/api/v1/handler/openmetrics"
+	"github.com/m3db/m3/src/query/api/v1/handler/otlp"
 	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/federate"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/native"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
 	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/frontend"
 	"github.com/m3db/m3/src/query/storage"
+	eventstore "github.com/m3db/m3/src/query/storage/events"
+	"github.com/m3db/m3/src/query/util/compress"
+	"github.com/m3db/m3/src/query/util/journal"
 	"github.com/m3db/m3/src/query/util/logging"
 	clusterclient "github.com/m3db/m3cluster/client"
 
@@ -52,6 +66,8 @@ const (
 	healthURL = "/health"
 	pprofURL  = "/debug/pprof/profile"
 	routesURL = "/routes"
+
+	routeMetricsName = "request"
 )
 
 var (
@@ -60,16 +76,22 @@ var (
 
 // Handler represents an HTTP handler.
 type Handler struct {
-	Router        *mux.Router
-	CLFLogger     *log.Logger
-	storage       storage.Storage
-	downsampler   downsample.Downsampler
-	engine        *executor.Engine
-	clusterClient clusterclient.Client
-	config        config.Configuration
-	embeddedDbCfg *dbconfig.DBConfiguration
-	scope         tally.Scope
-	createdAt     time.Time
+	Router         *mux.Router
+	CLFLogger      *log.Logger
+	storage        storage.Storage
+	downsampler    downsample.Downsampler
+	engine         *executor.Engine
+	clusterClient  clusterclient.Client
+	config         config.Configuration
+	embeddedDbCfg  *dbconfig.DBConfiguration
+	scope          tally.Scope
+	latencyBuckets tally.Buckets
+	createdAt      time.Time
+	queryJournal   journal.Writer
+	eventStore     eventstore.Store
+	reloader       *reload.Reloader
+	logSampler     *logsample.Sampler
+	frontend       *frontend.Frontend
 }
 
 // NewHandler returns a new instance of handler with routes.
@@ -89,50 +111,182 @@ func NewHandler(
 	}
 
 	defer logger.Sync() // flushes buffer, if any
+
+	latencyBuckets := tally.Buckets(xmetrics.DefaultLatencyBuckets)
+	if bucketsCfg := cfg.LatencyBuckets; bucketsCfg != nil {
+		latencyBuckets, err = bucketsCfg.NewBuckets()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var queryJournal journal.Writer
+	if journalCfg := cfg.QueryJournal; journalCfg != nil {
+		queryJournal, err = journalCfg.NewWriter(scope.SubScope("query-journal"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	h := &Handler{
-		CLFLogger:     log.New(os.Stderr, "[httpd] ", 0),
-		Router:        r,
-		storage:       storage,
-		downsampler:   downsampler,
-		engine:        engine,
-		clusterClient: clusterClient,
-		config:        cfg,
-		embeddedDbCfg: embeddedDbCfg,
-		scope:         scope,
-		createdAt:     time.Now(),
+		CLFLogger:      log.New(os.Stderr, "[httpd] ", 0),
+		Router:         r,
+		storage:        storage,
+		downsampler:    downsampler,
+		engine:         engine,
+		clusterClient:  clusterClient,
+		config:         cfg,
+		embeddedDbCfg:  embeddedDbCfg,
+		scope:          scope,
+		latencyBuckets: latencyBuckets,
+		createdAt:      time.Now(),
+		queryJournal:   queryJournal,
+		logSampler:     cfg.LogSampling.NewSampler(scope.SubScope("log-sampling")),
+	}
+	if frontendCfg := cfg.QueryFrontend; frontendCfg != nil {
+		h.frontend = frontendCfg.NewFrontend(scope.SubScope("query-frontend"))
 	}
 	return h, nil
 }
 
+// Close closes the handler and flushes any recorded queries.
+func (h *Handler) Close() error {
+	if h.queryJournal == nil {
+		return nil
+	}
+	return h.queryJournal.Close()
+}
+
+// SetEventStore sets the event store used by the events endpoints, the
+// endpoints are only registered if an event store is set.
+func (h *Handler) SetEventStore(store eventstore.Store) {
+	h.eventStore = store
+}
+
+// SetConfigReloader sets the reloader used by the config reload endpoints,
+// the endpoints are only registered if a reloader is set.
+func (h *Handler) SetConfigReloader(reloader *reload.Reloader) {
+	h.reloader = reloader
+}
+
 // RegisterRoutes registers all http routes.
 func (h *Handler) RegisterRoutes() error {
 	logged := logging.WithResponseTimeLogging
+	compressed := compress.WithCompression
+	journaled := func(next http.Handler) http.Handler {
+		if h.queryJournal == nil {
+			return next
+		}
+		return journal.WithJournal(h.queryJournal,
+			h.config.QueryJournal.MaxBodySizeOrDefault(), next)
+	}
+	queued := func(next http.Handler) http.Handler {
+		if h.frontend == nil {
+			return next
+		}
+		return h.frontend.Handler(next)
+	}
 
 	h.Router.HandleFunc(openapi.URL, logged(&openapi.DocHandler{}).ServeHTTP).Methods(openapi.HTTPMethod)
 	h.Router.PathPrefix(openapi.StaticURLPrefix).Handler(logged(openapi.StaticHandler()))
 
-	promRemoteReadHandler := remote.NewPromReadHandler(h.engine, h.scope.Tagged(remoteSource))
-	promRemoteWriteHandler, err := remote.NewPromWriteHandler(h.storage, nil, h.scope.Tagged(remoteSource))
+	promRemoteReadHandler := journaled(queued(remote.NewPromReadHandler(h.engine, h.scope.Tagged(remoteSource))))
+	promRemoteWriteHandler, err := remote.NewPromWriteHandler(h.storage, nil, h.logSampler,
+		h.scope.Tagged(remoteSource))
 	if err != nil {
 		return err
 	}
 
 	h.Router.HandleFunc(remote.PromReadURL, logged(promRemoteReadHandler).ServeHTTP).Methods(remote.PromReadHTTPMethod)
 	h.Router.HandleFunc(remote.PromWriteURL, logged(promRemoteWriteHandler).ServeHTTP).Methods(remote.PromWriteHTTPMethod)
-	h.Router.HandleFunc(native.PromReadURL, logged(native.NewPromReadHandler(h.engine)).ServeHTTP).Methods(native.PromReadHTTPMethod)
-	h.Router.HandleFunc(handler.SearchURL, logged(handler.NewSearchHandler(h.storage)).ServeHTTP).Methods(handler.SearchHTTPMethod)
+
+	var splitOpts native.QuerySplitOptions
+	if splitCfg := h.config.QuerySplitting; splitCfg != nil {
+		splitOpts = native.QuerySplitOptions{
+			Interval:       splitCfg.Interval,
+			MaxParallelism: splitCfg.MaxParallelism,
+		}
+	}
+	if retryCfg := h.config.QueryRetry; retryCfg != nil {
+		splitOpts.Retrier = retryCfg.NewRetrier(h.scope.SubScope("sub-range-retry"))
+	}
+	h.Router.HandleFunc(native.PromReadURL, logged(compressed(journaled(queued(native.NewPromReadHandler(h.engine, splitOpts))))).ServeHTTP).Methods(native.PromReadHTTPMethod)
+
+	promAsyncReadHandler := native.NewPromAsyncReadHandler(h.engine, native.DefaultAsyncQueryTimeout,
+		native.DefaultAsyncQueryResultTTL, native.DefaultAsyncQueryMaxOutstanding, splitOpts)
+	h.Router.HandleFunc(native.PromAsyncReadURL, logged(journaled(promAsyncReadHandler.SubmitHandler())).ServeHTTP).Methods(native.PromAsyncReadHTTPMethod)
+	h.Router.HandleFunc(native.PromAsyncReadResultURL, logged(compressed(promAsyncReadHandler.ResultHandler())).ServeHTTP).Methods(native.PromAsyncReadResultHTTPMethod)
+	h.Router.HandleFunc(native.PromAsyncReadResultURL, logged(promAsyncReadHandler.CancelHandler()).ServeHTTP).Methods(native.PromAsyncReadCancelHTTPMethod)
+	h.Router.HandleFunc(native.PromLiveReadURL, logged(native.NewPromLiveReadHandler(h.engine, native.DefaultLiveQueryMaxDuration)).ServeHTTP).Methods(native.PromLiveReadHTTPMethod)
+
+	h.Router.HandleFunc(handler.SearchURL, logged(compressed(journaled(queued(handler.NewSearchHandler(h.storage))))).ServeHTTP).Methods(handler.SearchHTTPMethod)
+	h.Router.HandleFunc(federate.FederateURL, logged(compressed(queued(federate.NewFederateHandler(h.storage)))).ServeHTTP).Methods(federate.FederateHTTPMethod)
+
+	ingestHandler, err := ingest.NewHandler(h.storage, h.logSampler, h.scope.SubScope("ingest"))
+	if err != nil {
+		return err
+	}
+
+	h.Router.HandleFunc(ingest.IngestURL, logged(ingestHandler).ServeHTTP).Methods(ingest.IngestHTTPMethod)
+
+	if otlpCfg := h.config.OTLP; otlpCfg != nil {
+		otlpScope := h.scope.SubScope("otlp")
+		ingester, err := otlp.NewIngester(h.storage, *otlpCfg, otlpScope)
+		if err != nil {
+			return err
+		}
+		otlpHandler := otlp.NewHandler(ingester, h.logSampler, otlpScope)
+		h.Router.HandleFunc(otlp.MetricsURL, logged(otlpHandler).ServeHTTP).Methods(otlp.MetricsHTTPMethod)
+	}
+
+	if h.eventStore != nil {
+		eventsWriteHandler, err := events.NewWriteHandler(h.eventStore)
+		if err != nil {
+			return err
+		}
+		eventsQueryHandler, err := events.NewQueryHandler(h.eventStore)
+		if err != nil {
+			return err
+		}
+
+		h.Router.HandleFunc(events.EventsURL, logged(eventsWriteHandler).ServeHTTP).Methods(events.WriteHTTPMethod)
+		h.Router.HandleFunc(events.EventsURL, logged(compressed(eventsQueryHandler)).ServeHTTP).Methods(events.QueryHTTPMethod)
+	}
+
+	if h.reloader != nil {
+		reloadHandler, err := configreload.NewReloadHandler(h.reloader)
+		if err != nil {
+			return err
+		}
+		lastReloadHandler, err := configreload.NewLastReloadHandler(h.reloader)
+		if err != nil {
+			return err
+		}
+
+		h.Router.HandleFunc(configreload.ReloadURL, logged(reloadHandler).ServeHTTP).Methods(configreload.ReloadHTTPMethod)
+		h.Router.HandleFunc(configreload.ReloadURL, logged(lastReloadHandler).ServeHTTP).Methods(configreload.LastReloadHTTPMethod)
+	}
 
 	if h.clusterClient != nil {
 		placement.RegisterRoutes(h.Router, h.clusterClient, h.config)
 		namespace.RegisterRoutes(h.Router, h.clusterClient)
 		database.RegisterRoutes(h.Router, h.clusterClient, h.config, h.embeddedDbCfg)
+		backup.RegisterRoutes(h.Router, h.clusterClient)
+	}
+
+	if scrapeCfg := h.config.MetricsScrape; scrapeCfg != nil {
+		scrapeHandler, err := scrapeCfg.NewScrapeHandler(h.config.Metrics)
+		if err != nil {
+			return err
+		}
+		h.Router.HandleFunc(openmetrics.ScrapeURL, compressed(scrapeHandler).ServeHTTP).Methods(openmetrics.ScrapeHTTPMethod)
 	}
 
 	h.registerHealthEndpoints()
 	h.registerProfileEndpoints()
 	h.registerRoutesEndpoint()
 
-	return nil
+	return h.registerRouteMetrics()
 }
 
 // Endpoints useful for profiling the service
@@ -175,3 +329,57 @@ func (h *Handler) registerRoutesEndpoint() {
 		})
 	}).Methods(http.MethodGet)
 }
+
+// registerRouteMetrics wraps every registered route to record its request
+// counts and latencies in histograms tagged by the route's path template.
+func (h *Handler) registerRouteMetrics() error {
+	return h.Router.Walk(
+		func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			next := route.GetHandler()
+			if next == nil {
+				return nil
+			}
+			path, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			scope := h.scope.Tagged(map[string]string{"route": path})
+			metrics := xmetrics.NewMethodMetrics(scope, routeMetricsName, h.latencyBuckets)
+			route.Handler(withRouteMetrics(metrics, next))
+			return nil
+		})
+}
+
+// withRouteMetrics reports requests answered with a server error as errors
+// and all other requests as successes.
+func withRouteMetrics(metrics xmetrics.MethodMetrics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusCodeResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		d := time.Since(start)
+		if sw.statusCode >= http.StatusInternalServerError {
+			metrics.ReportError(d)
+			return
+		}
+		metrics.ReportSuccess(d)
+	})
+}
+
+type statusCodeResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusCodeResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Flush flushes the underlying writer if it supports flushing, which
+// streaming handlers rely on.
+func (w *statusCodeResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -168,3 +168,34 @@ func TestHealthGet(t *testing.T) {
 
 	assert.True(t, result > 0)
 }
+
+func TestRouteMetrics(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	req, _ := http.NewRequest("GET", healthURL, nil)
+	res := httptest.NewRecorder()
+	ctrl := gomock.NewController(t)
+	storage, _ := local.NewStorageAndSession(t, ctrl)
+
+	scope := tally.NewTestScope("", nil)
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+		config.Configuration{}, nil, scope)
+	require.NoError(t, err, "unable to setup handler")
+	require.NoError(t, h.RegisterRoutes())
+
+	h.Router.ServeHTTP(res, req)
+	require.Equal(t, res.Code, http.StatusOK)
+
+	snapshot := scope.Snapshot()
+	success, ok := snapshot.Counters()["request.success+route="+healthURL]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), success.Value())
+
+	latency, ok := snapshot.Histograms()["request.success-latency+route="+healthURL]
+	require.True(t, ok)
+	var samples int64
+	for _, n := range latency.Durations() {
+		samples += n
+	}
+	assert.Equal(t, int64(1), samples)
+}
@@ -33,6 +33,7 @@ func PostEncodedSnappy(url string, body io.Reader) (*http.Response, error) {
 	}
 	req.Header.Set("Content-Type", "application/x-protobuf")
 	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Accept-Encoding", "snappy")
 
 	client := http.DefaultClient
 	return client.Do(req)
@@ -0,0 +1,178 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/native"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+)
+
+type sessionWriter struct {
+	session   client.Session
+	namespace ident.ID
+}
+
+// NewSessionWriter returns a writer that writes directly to M3DB with
+// the given session.
+func NewSessionWriter(session client.Session, namespace string) Writer {
+	return &sessionWriter{
+		session:   session,
+		namespace: ident.StringID(namespace),
+	}
+}
+
+func (w *sessionWriter) Write(points []Datapoint) error {
+	for _, p := range points {
+		tags := make([]ident.Tag, 0, len(p.Series.Tags))
+		for _, t := range p.Series.Tags {
+			tags = append(tags, ident.StringTag(t.Name, t.Value))
+		}
+
+		err := w.session.WriteTagged(w.namespace, ident.StringID(p.Series.ID),
+			ident.NewTagsIterator(ident.NewTags(tags...)), p.Timestamp, p.Value,
+			xtime.Second, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type coordinatorClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+func newCoordinatorClient(c *http.Client, baseURL string) coordinatorClient {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return coordinatorClient{
+		client:  c,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (c coordinatorClient) do(req *http.Request) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s returned status %d",
+			req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return nil
+}
+
+type coordinatorWriter struct {
+	coordinatorClient
+}
+
+// NewCoordinatorWriter returns a writer that writes to the coordinator with
+// Prometheus remote write requests.
+func NewCoordinatorWriter(c *http.Client, baseURL string) Writer {
+	return &coordinatorWriter{newCoordinatorClient(c, baseURL)}
+}
+
+func (w *coordinatorWriter) Write(points []Datapoint) error {
+	req := &prompb.WriteRequest{
+		Timeseries: make([]*prompb.TimeSeries, 0, len(points)),
+	}
+	for _, p := range points {
+		labels := make([]*prompb.Label, 0, len(p.Series.Tags))
+		for _, t := range p.Series.Tags {
+			labels = append(labels, &prompb.Label{Name: t.Name, Value: t.Value})
+		}
+		req.Timeseries = append(req.Timeseries, &prompb.TimeSeries{
+			Labels: labels,
+			Samples: []*prompb.Sample{{
+				Value:     p.Value,
+				Timestamp: p.Timestamp.UnixNano() / int64(time.Millisecond),
+			}},
+		})
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(remote.PromWriteHTTPMethod,
+		w.baseURL+remote.PromWriteURL, bytes.NewReader(snappy.Encode(nil, data)))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("Content-Encoding", "snappy")
+	return w.do(httpReq)
+}
+
+type coordinatorQuerier struct {
+	coordinatorClient
+}
+
+// NewCoordinatorQuerier returns a querier that executes queries with the
+// coordinator native read endpoint.
+func NewCoordinatorQuerier(c *http.Client, baseURL string) Querier {
+	return &coordinatorQuerier{newCoordinatorClient(c, baseURL)}
+}
+
+func (q *coordinatorQuerier) Query(
+	query string,
+	start, end time.Time,
+	step time.Duration,
+) error {
+	params := url.Values{}
+	params.Set("target", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", step.String())
+
+	req, err := http.NewRequest(native.PromReadHTTPMethod,
+		q.baseURL+native.PromReadURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return q.do(req)
+}
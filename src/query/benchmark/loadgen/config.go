@@ -0,0 +1,158 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+)
+
+// WriteTarget is the target writes are sent to.
+type WriteTarget string
+
+const (
+	// WriteTargetCoordinator writes with the coordinator Prometheus
+	// remote write endpoint.
+	WriteTargetCoordinator WriteTarget = "coordinator"
+
+	// WriteTargetDBNode writes directly to M3DB with the dbnode client.
+	WriteTargetDBNode WriteTarget = "dbnode"
+)
+
+// Configuration is the configuration for generating load.
+type Configuration struct {
+	// Duration is how long to generate load for.
+	Duration time.Duration `yaml:"duration" validate:"nonzero"`
+
+	// Series is the configuration of the series written.
+	Series SeriesConfiguration `yaml:"series"`
+
+	// Write is the write load configuration (optional).
+	Write *WriteConfiguration `yaml:"write"`
+
+	// Query is the query load configuration (optional).
+	Query *QueryConfiguration `yaml:"query"`
+
+	// Coordinator is the coordinator to send load to, required for queries
+	// and for writes targeting the coordinator.
+	Coordinator *CoordinatorConfiguration `yaml:"coordinator"`
+
+	// DBNode is the M3DB cluster to send load to, required for writes
+	// targeting the dbnode.
+	DBNode *DBNodeConfiguration `yaml:"dbnode"`
+}
+
+// SeriesConfiguration is the configuration of the series written.
+type SeriesConfiguration struct {
+	// MetricPrefix is prefixed to all generated metric names.
+	MetricPrefix string `yaml:"metricPrefix"`
+
+	// Cardinality is the number of unique series active at any time.
+	Cardinality int `yaml:"cardinality" validate:"min=1"`
+
+	// NumMetricNames is the number of distinct metric names.
+	NumMetricNames int `yaml:"numMetricNames"`
+
+	// NumHosts is the number of distinct host tag values.
+	NumHosts int `yaml:"numHosts"`
+
+	// ChurnFraction is the fraction of series replaced each churn interval.
+	ChurnFraction float64 `yaml:"churnFraction"`
+
+	// ChurnInterval is how often series are churned, zero disables churn.
+	ChurnInterval time.Duration `yaml:"churnInterval"`
+}
+
+// WriteConfiguration is the write load configuration.
+type WriteConfiguration struct {
+	// Target is the target writes are sent to.
+	Target WriteTarget `yaml:"target"`
+
+	// Rate is the number of datapoints written per second.
+	Rate int `yaml:"rate" validate:"min=1"`
+
+	// BatchSize is the number of datapoints written per write call.
+	BatchSize int `yaml:"batchSize"`
+
+	// Concurrency is the maximum number of write calls in flight.
+	Concurrency int `yaml:"concurrency"`
+}
+
+// QueryConfiguration is the query load configuration.
+type QueryConfiguration struct {
+	// Rate is the number of queries executed per second.
+	Rate int `yaml:"rate" validate:"min=1"`
+
+	// Concurrency is the maximum number of queries in flight.
+	Concurrency int `yaml:"concurrency"`
+
+	// Queries is the query mix, defaults to a dashboard query mix.
+	Queries []QueryTemplate `yaml:"queries"`
+}
+
+// CoordinatorConfiguration is the coordinator to send load to.
+type CoordinatorConfiguration struct {
+	// URL is the coordinator base URL, i.e. http://localhost:7201.
+	URL string `yaml:"url" validate:"nonzero"`
+}
+
+// DBNodeConfiguration is the M3DB cluster to send load to.
+type DBNodeConfiguration struct {
+	// Namespace is the namespace to write to.
+	Namespace string `yaml:"namespace" validate:"nonzero"`
+
+	// Client is the dbnode client configuration.
+	Client client.Configuration `yaml:"client"`
+}
+
+// SeriesGeneratorOptions returns the series generator options.
+func (c Configuration) SeriesGeneratorOptions() SeriesGeneratorOptions {
+	return SeriesGeneratorOptions{
+		MetricPrefix:   c.Series.MetricPrefix,
+		Cardinality:    c.Series.Cardinality,
+		NumMetricNames: c.Series.NumMetricNames,
+		NumHosts:       c.Series.NumHosts,
+		ChurnFraction:  c.Series.ChurnFraction,
+	}
+}
+
+// RunOptions returns the run options.
+func (c Configuration) RunOptions() RunOptions {
+	opts := RunOptions{
+		Duration:      c.Duration,
+		ChurnInterval: c.Series.ChurnInterval,
+	}
+	if w := c.Write; w != nil {
+		opts.WriteRate = w.Rate
+		opts.WriteBatchSize = w.BatchSize
+		opts.WriteConcurrency = w.Concurrency
+	}
+	if q := c.Query; q != nil {
+		opts.QueryRate = q.Rate
+		opts.QueryConcurrency = q.Concurrency
+		opts.Queries = q.Queries
+		if len(opts.Queries) == 0 {
+			opts.Queries = DefaultDashboardQueries()
+		}
+	}
+	return opts
+}
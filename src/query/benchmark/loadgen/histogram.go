@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	histogramMinBucket = 50 * time.Microsecond
+	histogramMaxBucket = 5 * time.Minute
+	histogramFactor    = 1.2
+)
+
+// histogramBuckets are the exponentially increasing bucket upper bounds
+// shared by all histograms.
+var histogramBuckets = newHistogramBuckets()
+
+func newHistogramBuckets() []time.Duration {
+	var buckets []time.Duration
+	for b := float64(histogramMinBucket); b < float64(histogramMaxBucket); b *= histogramFactor {
+		buckets = append(buckets, time.Duration(b))
+	}
+	return append(buckets, histogramMaxBucket)
+}
+
+// Histogram is a concurrency safe latency histogram.
+type Histogram struct {
+	sync.Mutex
+
+	counts []int64
+	count  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// NewHistogram returns a new latency histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{
+		// One extra bucket for values larger than the largest bucket
+		counts: make([]int64, len(histogramBuckets)+1),
+	}
+}
+
+// Record records a latency.
+func (h *Histogram) Record(d time.Duration) {
+	idx := sort.Search(len(histogramBuckets), func(i int) bool {
+		return d <= histogramBuckets[i]
+	})
+
+	h.Lock()
+	h.counts[idx]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+	h.Unlock()
+}
+
+// Count returns the number of latencies recorded.
+func (h *Histogram) Count() int64 {
+	h.Lock()
+	defer h.Unlock()
+	return h.count
+}
+
+// Min returns the smallest latency recorded.
+func (h *Histogram) Min() time.Duration {
+	h.Lock()
+	defer h.Unlock()
+	return h.min
+}
+
+// Max returns the largest latency recorded.
+func (h *Histogram) Max() time.Duration {
+	h.Lock()
+	defer h.Unlock()
+	return h.max
+}
+
+// Mean returns the mean latency recorded.
+func (h *Histogram) Mean() time.Duration {
+	h.Lock()
+	defer h.Unlock()
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// Quantile returns the upper bound of the bucket holding the given
+// quantile, capped at the largest latency recorded.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+
+	var (
+		rank       = int64(math.Ceil(q * float64(h.count)))
+		cumulative int64
+	)
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative < rank || c == 0 {
+			continue
+		}
+		if i < len(histogramBuckets) && histogramBuckets[i] < h.max {
+			return histogramBuckets[i]
+		}
+		return h.max
+	}
+	return h.max
+}
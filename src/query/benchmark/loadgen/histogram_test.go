@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram()
+	assert.Equal(t, time.Duration(0), h.Quantile(0.5))
+
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.Equal(t, int64(100), h.Count())
+	assert.Equal(t, time.Millisecond, h.Min())
+	assert.Equal(t, 100*time.Millisecond, h.Max())
+	assert.Equal(t, 50500*time.Microsecond, h.Mean())
+	assert.Equal(t, 100*time.Millisecond, h.Quantile(1))
+
+	// Quantiles are reported as the upper bound of their bucket
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		expected := time.Duration(q * float64(100*time.Millisecond))
+		actual := h.Quantile(q)
+		assert.True(t, actual >= expected, "q=%v actual=%v", q, actual)
+		assert.True(t, actual <= time.Duration(float64(expected)*histogramFactor),
+			"q=%v actual=%v", q, actual)
+	}
+}
+
+func TestHistogramLargerThanMaxBucket(t *testing.T) {
+	h := NewHistogram()
+	h.Record(histogramMaxBucket + time.Minute)
+	assert.Equal(t, histogramMaxBucket+time.Minute, h.Quantile(0.99))
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/query/benchmark/loadgen"
+	xconfig "github.com/m3db/m3x/config"
+	"github.com/m3db/m3x/instrument"
+	xlog "github.com/m3db/m3x/log"
+)
+
+const (
+	httpTimeout = time.Minute
+)
+
+func main() {
+	var (
+		configFileArg = flag.String("f", "", "Load generator configuration file")
+	)
+	flag.Parse()
+
+	if *configFileArg == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log := xlog.NewLogger(os.Stderr)
+
+	var cfg loadgen.Configuration
+	if err := xconfig.LoadFile(&cfg, *configFileArg, xconfig.Options{}); err != nil {
+		log.Fatalf("could not load configuration: %v", err)
+	}
+
+	gen, err := loadgen.NewSeriesGenerator(cfg.SeriesGeneratorOptions())
+	if err != nil {
+		log.Fatalf("could not create series generator: %v", err)
+	}
+
+	var (
+		httpClient = &http.Client{Timeout: httpTimeout}
+		writer     loadgen.Writer
+		querier    loadgen.Querier
+	)
+	if cfg.Write != nil {
+		switch cfg.Write.Target {
+		case loadgen.WriteTargetCoordinator:
+			if cfg.Coordinator == nil {
+				log.Fatalf("coordinator writes require coordinator configuration")
+			}
+			writer = loadgen.NewCoordinatorWriter(httpClient, cfg.Coordinator.URL)
+		case loadgen.WriteTargetDBNode:
+			if cfg.DBNode == nil {
+				log.Fatalf("dbnode writes require dbnode configuration")
+			}
+			m3dbClient, err := cfg.DBNode.Client.NewClient(client.ConfigurationParameters{
+				InstrumentOptions: instrument.NewOptions().SetLogger(log),
+			})
+			if err != nil {
+				log.Fatalf("could not create dbnode client: %v", err)
+			}
+			session, err := m3dbClient.DefaultSession()
+			if err != nil {
+				log.Fatalf("could not create dbnode session: %v", err)
+			}
+			defer session.Close()
+			writer = loadgen.NewSessionWriter(session, cfg.DBNode.Namespace)
+		default:
+			log.Fatalf("unknown write target: %s", cfg.Write.Target)
+		}
+	}
+	if cfg.Query != nil {
+		if cfg.Coordinator == nil {
+			log.Fatalf("queries require coordinator configuration")
+		}
+		querier = loadgen.NewCoordinatorQuerier(httpClient, cfg.Coordinator.URL)
+	}
+
+	log.Infof("generating load for %s", cfg.Duration.String())
+	report, err := loadgen.Run(gen, writer, querier, cfg.RunOptions())
+	if err != nil {
+		log.Fatalf("could not generate load: %v", err)
+	}
+
+	log.Infof("ran for %s", report.Elapsed.String())
+	if cfg.Write != nil {
+		log.Infof("wrote %d datapoints, %d write errors, %d writes missed, %d series churned",
+			report.DatapointsWritten, report.WriteErrors, report.WritesMissed,
+			report.SeriesChurned)
+		logHistogram(log, "write", report.Writes)
+	}
+	if cfg.Query != nil {
+		names := make([]string, 0, len(report.Queries))
+		for name := range report.Queries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		log.Infof("%d queries missed", report.QueriesMissed)
+		for _, name := range names {
+			log.Infof("query %s: %d errors", name, report.QueryErrors[name])
+			logHistogram(log, "query "+name, report.Queries[name])
+		}
+	}
+}
+
+func logHistogram(log xlog.Logger, name string, h *loadgen.Histogram) {
+	log.Infof("%s: count=%d min=%s mean=%s p50=%s p90=%s p99=%s max=%s",
+		name, h.Count(), h.Min().String(), h.Mean().String(),
+		h.Quantile(0.5).String(), h.Quantile(0.9).String(),
+		h.Quantile(0.99).String(), h.Max().String())
+}
@@ -0,0 +1,383 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultWriteBatchSize   = 128
+	defaultWriteConcurrency = 16
+	defaultQueryConcurrency = 4
+
+	metricPlaceholder = "$metric"
+	hostPlaceholder   = "$host"
+)
+
+var (
+	errNoDuration       = errors.New("no load duration set")
+	errNoLoad           = errors.New("no write or query rate set")
+	errNoWriter         = errors.New("write rate set without a writer")
+	errNoQuerier        = errors.New("query rate set without a querier")
+	errNoQueryTemplates = errors.New("query rate set without query templates")
+	errNoQueryWeight    = errors.New("query template weight must be positive")
+)
+
+// QueryTemplate is a query executed as part of a query mix, the placeholders
+// $metric and $host are replaced with a random generated metric name and
+// host tag value each time the query is executed.
+type QueryTemplate struct {
+	// Name is the name the query latencies are reported under.
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// Weight is the relative frequency of the query in the mix.
+	Weight int `yaml:"weight" validate:"min=1"`
+
+	// Query is the query to execute.
+	Query string `yaml:"query" validate:"nonzero"`
+
+	// Range is the time range the query covers, ending now.
+	Range time.Duration `yaml:"range" validate:"nonzero"`
+
+	// Step is the query resolution.
+	Step time.Duration `yaml:"step" validate:"nonzero"`
+}
+
+// DefaultDashboardQueries returns a query mix resembling typical dashboard
+// panels, dominated by short range lookups of a single host.
+func DefaultDashboardQueries() []QueryTemplate {
+	return []QueryTemplate{
+		{
+			Name:   "host-1h",
+			Weight: 6,
+			Query:  `$metric{host="$host"}`,
+			Range:  time.Hour,
+			Step:   15 * time.Second,
+		},
+		{
+			Name:   "host-rate-6h",
+			Weight: 3,
+			Query:  `rate($metric{host="$host"}[5m])`,
+			Range:  6 * time.Hour,
+			Step:   time.Minute,
+		},
+		{
+			Name:   "sum-by-host-1h",
+			Weight: 1,
+			Query:  `sum($metric) by (host)`,
+			Range:  time.Hour,
+			Step:   time.Minute,
+		},
+	}
+}
+
+// RunOptions is a set of options for running load.
+type RunOptions struct {
+	// Duration is how long to run load for.
+	Duration time.Duration
+
+	// WriteRate is the number of datapoints written per second.
+	WriteRate int
+
+	// WriteBatchSize is the number of datapoints written per write call.
+	WriteBatchSize int
+
+	// WriteConcurrency is the maximum number of write calls in flight.
+	WriteConcurrency int
+
+	// ChurnInterval is how often series are churned, zero disables churn.
+	ChurnInterval time.Duration
+
+	// QueryRate is the number of queries executed per second.
+	QueryRate int
+
+	// QueryConcurrency is the maximum number of queries in flight.
+	QueryConcurrency int
+
+	// Queries is the query mix to execute.
+	Queries []QueryTemplate
+}
+
+// Report is the result of running load.
+type Report struct {
+	// Elapsed is how long load ran for.
+	Elapsed time.Duration
+
+	// Writes is the latency histogram of write calls.
+	Writes *Histogram
+
+	// WriteErrors is the number of failed write calls.
+	WriteErrors int64
+
+	// WritesMissed is the number of write calls not issued since the
+	// maximum number of write calls were already in flight.
+	WritesMissed int64
+
+	// DatapointsWritten is the number of datapoints successfully written.
+	DatapointsWritten int64
+
+	// SeriesChurned is the number of series replaced by new series.
+	SeriesChurned int64
+
+	// Queries is the latency histogram of queries by query name.
+	Queries map[string]*Histogram
+
+	// QueryErrors is the number of failed queries by query name.
+	QueryErrors map[string]int64
+
+	// QueriesMissed is the number of queries not issued since the
+	// maximum number of queries were already in flight.
+	QueriesMissed int64
+}
+
+type runner struct {
+	gen     *SeriesGenerator
+	writer  Writer
+	querier Querier
+	opts    RunOptions
+
+	doneCh      chan struct{}
+	wg          sync.WaitGroup
+	writeCursor int64
+
+	queryLock    sync.Mutex
+	rng          *rand.Rand
+	totalWeight  int
+	report       Report
+	reportLock   sync.Mutex
+	writeErrors  int64
+	writesMissed int64
+	written      int64
+	churned      int64
+	queryMissed  int64
+}
+
+// Run generates write load with the writer and query load with the querier
+// for the configured duration and reports the observed latencies.
+func Run(
+	gen *SeriesGenerator,
+	writer Writer,
+	querier Querier,
+	opts RunOptions,
+) (Report, error) {
+	if opts.Duration <= 0 {
+		return Report{}, errNoDuration
+	}
+	if opts.WriteRate <= 0 && opts.QueryRate <= 0 {
+		return Report{}, errNoLoad
+	}
+	if opts.WriteRate > 0 && writer == nil {
+		return Report{}, errNoWriter
+	}
+	if opts.QueryRate > 0 && querier == nil {
+		return Report{}, errNoQuerier
+	}
+	if opts.QueryRate > 0 && len(opts.Queries) == 0 {
+		return Report{}, errNoQueryTemplates
+	}
+	if opts.WriteBatchSize <= 0 {
+		opts.WriteBatchSize = defaultWriteBatchSize
+	}
+	if opts.WriteConcurrency <= 0 {
+		opts.WriteConcurrency = defaultWriteConcurrency
+	}
+	if opts.QueryConcurrency <= 0 {
+		opts.QueryConcurrency = defaultQueryConcurrency
+	}
+
+	r := &runner{
+		gen:     gen,
+		writer:  writer,
+		querier: querier,
+		opts:    opts,
+		doneCh:  make(chan struct{}),
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		report: Report{
+			Writes:      NewHistogram(),
+			Queries:     make(map[string]*Histogram, len(opts.Queries)),
+			QueryErrors: make(map[string]int64, len(opts.Queries)),
+		},
+	}
+	for _, q := range opts.Queries {
+		if q.Weight <= 0 {
+			return Report{}, errNoQueryWeight
+		}
+		r.report.Queries[q.Name] = NewHistogram()
+		r.totalWeight += q.Weight
+	}
+
+	start := time.Now()
+	if opts.WriteRate > 0 {
+		batchesPerSecond := float64(opts.WriteRate) / float64(opts.WriteBatchSize)
+		r.dispatch(batchesPerSecond, opts.WriteConcurrency, &r.writesMissed, r.writeBatch)
+		if opts.ChurnInterval > 0 {
+			r.wg.Add(1)
+			go r.churnLoop()
+		}
+	}
+	if opts.QueryRate > 0 {
+		r.dispatch(float64(opts.QueryRate), opts.QueryConcurrency, &r.queryMissed, r.query)
+	}
+
+	time.Sleep(opts.Duration)
+	close(r.doneCh)
+	r.wg.Wait()
+
+	r.report.Elapsed = time.Since(start)
+	r.report.WriteErrors = atomic.LoadInt64(&r.writeErrors)
+	r.report.WritesMissed = atomic.LoadInt64(&r.writesMissed)
+	r.report.DatapointsWritten = atomic.LoadInt64(&r.written)
+	r.report.SeriesChurned = atomic.LoadInt64(&r.churned)
+	r.report.QueriesMissed = atomic.LoadInt64(&r.queryMissed)
+	return r.report, nil
+}
+
+// dispatch issues operations at the given rate with at most concurrency
+// operations in flight, operations due while the maximum number of
+// operations are in flight are counted as missed rather than queued so
+// that an overloaded target does not distort the offered load.
+func (r *runner) dispatch(
+	perSecond float64,
+	concurrency int,
+	missed *int64,
+	fn func(),
+) {
+	var (
+		interval = time.Duration(float64(time.Second) / perSecond)
+		workCh   = make(chan struct{})
+	)
+	for i := 0; i < concurrency; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for range workCh {
+				fn()
+			}
+		}()
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer close(workCh)
+
+		next := time.Now()
+		for {
+			if wait := time.Until(next); wait > 0 {
+				select {
+				case <-r.doneCh:
+					return
+				case <-time.After(wait):
+				}
+			}
+			select {
+			case <-r.doneCh:
+				return
+			case workCh <- struct{}{}:
+			default:
+				atomic.AddInt64(missed, 1)
+			}
+			next = next.Add(interval)
+		}
+	}()
+}
+
+func (r *runner) writeBatch() {
+	var (
+		size   = r.opts.WriteBatchSize
+		first  = atomic.AddInt64(&r.writeCursor, int64(size)) - int64(size)
+		now    = time.Now()
+		points = make([]Datapoint, 0, size)
+	)
+	for i := 0; i < size; i++ {
+		points = append(points, Datapoint{
+			Series:    r.gen.Series(int((first + int64(i)) % int64(r.gen.Cardinality()))),
+			Timestamp: now,
+			Value:     float64(now.UnixNano()%1000) + float64(i),
+		})
+	}
+
+	start := time.Now()
+	err := r.writer.Write(points)
+	r.report.Writes.Record(time.Since(start))
+	if err != nil {
+		atomic.AddInt64(&r.writeErrors, 1)
+		return
+	}
+	atomic.AddInt64(&r.written, int64(len(points)))
+}
+
+func (r *runner) churnLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.opts.ChurnInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.doneCh:
+			return
+		case <-ticker.C:
+			atomic.AddInt64(&r.churned, int64(r.gen.Churn()))
+		}
+	}
+}
+
+func (r *runner) query() {
+	r.queryLock.Lock()
+	var (
+		pick = r.rng.Intn(r.totalWeight)
+		idx  = r.rng.Intn(r.gen.Cardinality())
+	)
+	r.queryLock.Unlock()
+
+	var template QueryTemplate
+	for _, q := range r.opts.Queries {
+		if pick < q.Weight {
+			template = q
+			break
+		}
+		pick -= q.Weight
+	}
+
+	query := strings.NewReplacer(
+		metricPlaceholder, r.gen.MetricName(idx),
+		hostPlaceholder, r.gen.Host(idx),
+	).Replace(template.Query)
+
+	var (
+		end   = time.Now()
+		start = end.Add(-template.Range)
+	)
+	err := r.querier.Query(query, start, end, template.Step)
+	r.report.Queries[template.Name].Record(time.Since(end))
+	if err != nil {
+		r.reportLock.Lock()
+		r.report.QueryErrors[template.Name]++
+		r.reportLock.Unlock()
+	}
+}
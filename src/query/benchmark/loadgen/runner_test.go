@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWriter struct {
+	sync.Mutex
+	series map[string]struct{}
+}
+
+func (w *testWriter) Write(points []Datapoint) error {
+	w.Lock()
+	defer w.Unlock()
+	for _, p := range points {
+		w.series[p.Series.ID] = struct{}{}
+	}
+	return nil
+}
+
+type testQuerier struct {
+	sync.Mutex
+	queries []string
+}
+
+func (q *testQuerier) Query(query string, start, end time.Time, step time.Duration) error {
+	q.Lock()
+	defer q.Unlock()
+	q.queries = append(q.queries, query)
+	if strings.HasPrefix(query, "sum") {
+		return errors.New("query error")
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	gen, err := NewSeriesGenerator(SeriesGeneratorOptions{
+		Cardinality:    10,
+		NumMetricNames: 1,
+		NumHosts:       10,
+		ChurnFraction:  0.5,
+	})
+	require.NoError(t, err)
+
+	var (
+		writer  = &testWriter{series: make(map[string]struct{})}
+		querier = &testQuerier{}
+	)
+	report, err := Run(gen, writer, querier, RunOptions{
+		Duration:       200 * time.Millisecond,
+		WriteRate:      1000,
+		WriteBatchSize: 10,
+		ChurnInterval:  50 * time.Millisecond,
+		QueryRate:      100,
+		Queries: []QueryTemplate{
+			{Name: "host", Weight: 1, Query: `$metric{host="$host"}`, Range: time.Hour, Step: time.Minute},
+			{Name: "sum", Weight: 1, Query: `sum($metric)`, Range: time.Hour, Step: time.Minute},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, report.Elapsed >= 200*time.Millisecond)
+	assert.True(t, report.Writes.Count() > 0)
+	assert.Equal(t, int64(0), report.WriteErrors)
+	assert.Equal(t, report.Writes.Count()*10, report.DatapointsWritten)
+	assert.True(t, report.SeriesChurned > 0)
+
+	// Churned series are written as new series
+	writer.Lock()
+	assert.True(t, len(writer.series) > gen.Cardinality())
+	writer.Unlock()
+
+	assert.True(t, report.Queries["host"].Count() > 0)
+	assert.True(t, report.Queries["sum"].Count() > 0)
+	assert.Equal(t, int64(0), report.QueryErrors["host"])
+	assert.Equal(t, report.Queries["sum"].Count(), report.QueryErrors["sum"])
+
+	querier.Lock()
+	defer querier.Unlock()
+	for _, q := range querier.queries {
+		assert.NotContains(t, q, metricPlaceholder)
+		assert.NotContains(t, q, hostPlaceholder)
+	}
+}
+
+func TestRunInvalidOptions(t *testing.T) {
+	gen, err := NewSeriesGenerator(SeriesGeneratorOptions{Cardinality: 1})
+	require.NoError(t, err)
+
+	_, err = Run(gen, nil, nil, RunOptions{})
+	assert.Equal(t, errNoDuration, err)
+
+	_, err = Run(gen, nil, nil, RunOptions{Duration: time.Second})
+	assert.Equal(t, errNoLoad, err)
+
+	_, err = Run(gen, nil, nil, RunOptions{Duration: time.Second, WriteRate: 1})
+	assert.Equal(t, errNoWriter, err)
+
+	_, err = Run(gen, nil, &testQuerier{}, RunOptions{Duration: time.Second, QueryRate: 1})
+	assert.Equal(t, errNoQueryTemplates, err)
+}
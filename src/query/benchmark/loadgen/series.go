@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package loadgen generates synthetic write and query load against M3DB and
+// the coordinator for evaluating hardware and configuration changes.
+package loadgen
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultNumMetricNames = 100
+	defaultNumHosts       = 1000
+)
+
+// Tag is a series tag.
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Series is a generated series.
+type Series struct {
+	ID   string
+	Tags []Tag
+}
+
+// SeriesGeneratorOptions is a set of options for generating series.
+type SeriesGeneratorOptions struct {
+	// MetricPrefix is prefixed to all generated metric names.
+	MetricPrefix string
+
+	// Cardinality is the number of unique series active at any time.
+	Cardinality int
+
+	// NumMetricNames is the number of distinct metric names the
+	// series are spread across.
+	NumMetricNames int
+
+	// NumHosts is the number of distinct host tag values the series
+	// are spread across.
+	NumHosts int
+
+	// ChurnFraction is the fraction of series replaced by new series
+	// each time the series are churned.
+	ChurnFraction float64
+}
+
+// SeriesGenerator generates a fixed cardinality set of series, a fraction
+// of which can be replaced by new series to simulate series churn.
+type SeriesGenerator struct {
+	sync.RWMutex
+
+	opts        SeriesGeneratorOptions
+	generations []int
+	churnCursor int
+}
+
+// NewSeriesGenerator returns a new series generator.
+func NewSeriesGenerator(opts SeriesGeneratorOptions) (*SeriesGenerator, error) {
+	if opts.Cardinality <= 0 {
+		return nil, fmt.Errorf("cardinality must be positive: %d", opts.Cardinality)
+	}
+	if opts.ChurnFraction < 0 || opts.ChurnFraction > 1 {
+		return nil, fmt.Errorf("churn fraction must be between 0 and 1: %v",
+			opts.ChurnFraction)
+	}
+	if opts.NumMetricNames <= 0 {
+		opts.NumMetricNames = defaultNumMetricNames
+	}
+	if opts.NumHosts <= 0 {
+		opts.NumHosts = defaultNumHosts
+	}
+	return &SeriesGenerator{
+		opts:        opts,
+		generations: make([]int, opts.Cardinality),
+	}, nil
+}
+
+// Cardinality returns the number of unique series active at any time.
+func (g *SeriesGenerator) Cardinality() int {
+	return g.opts.Cardinality
+}
+
+// MetricName returns the metric name for the given series index.
+func (g *SeriesGenerator) MetricName(idx int) string {
+	return fmt.Sprintf("%smetric_%d", g.opts.MetricPrefix, idx%g.opts.NumMetricNames)
+}
+
+// Host returns the host tag value for the given series index.
+func (g *SeriesGenerator) Host(idx int) string {
+	return fmt.Sprintf("host%d", idx%g.opts.NumHosts)
+}
+
+// Series returns the series currently active at the given index.
+func (g *SeriesGenerator) Series(idx int) Series {
+	idx = idx % g.opts.Cardinality
+
+	g.RLock()
+	generation := g.generations[idx]
+	g.RUnlock()
+
+	var (
+		name     = g.MetricName(idx)
+		host     = g.Host(idx)
+		instance = fmt.Sprintf("%d_%d", idx, generation)
+	)
+	return Series{
+		ID: fmt.Sprintf("%s{host=%s,instance=%s}", name, host, instance),
+		Tags: []Tag{
+			{Name: "__name__", Value: name},
+			{Name: "host", Value: host},
+			{Name: "instance", Value: instance},
+		},
+	}
+}
+
+// Churn replaces the churn fraction of active series with new series and
+// returns the number of series replaced.
+func (g *SeriesGenerator) Churn() int {
+	n := int(g.opts.ChurnFraction * float64(g.opts.Cardinality))
+
+	g.Lock()
+	defer g.Unlock()
+
+	for i := 0; i < n; i++ {
+		g.generations[g.churnCursor]++
+		g.churnCursor = (g.churnCursor + 1) % g.opts.Cardinality
+	}
+	return n
+}
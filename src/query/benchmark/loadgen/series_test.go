@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesGeneratorChurn(t *testing.T) {
+	gen, err := NewSeriesGenerator(SeriesGeneratorOptions{
+		MetricPrefix:   "test_",
+		Cardinality:    4,
+		NumMetricNames: 2,
+		NumHosts:       3,
+		ChurnFraction:  0.5,
+	})
+	require.NoError(t, err)
+
+	series := gen.Series(5)
+	assert.Equal(t, "test_metric_1{host=host1,instance=1_0}", series.ID)
+	assert.Equal(t, []Tag{
+		{Name: "__name__", Value: "test_metric_1"},
+		{Name: "host", Value: "host1"},
+		{Name: "instance", Value: "1_0"},
+	}, series.Tags)
+
+	before := make([]string, gen.Cardinality())
+	for i := range before {
+		before[i] = gen.Series(i).ID
+	}
+
+	assert.Equal(t, 2, gen.Churn())
+	assert.NotEqual(t, before[0], gen.Series(0).ID)
+	assert.NotEqual(t, before[1], gen.Series(1).ID)
+	assert.Equal(t, before[2], gen.Series(2).ID)
+	assert.Equal(t, before[3], gen.Series(3).ID)
+
+	assert.Equal(t, 2, gen.Churn())
+	assert.NotEqual(t, before[2], gen.Series(2).ID)
+	assert.NotEqual(t, before[3], gen.Series(3).ID)
+	assert.Equal(t, "test_metric_0{host=host0,instance=0_1}", gen.Series(0).ID)
+}
+
+func TestNewSeriesGeneratorInvalidOptions(t *testing.T) {
+	_, err := NewSeriesGenerator(SeriesGeneratorOptions{})
+	assert.Error(t, err)
+
+	_, err = NewSeriesGenerator(SeriesGeneratorOptions{
+		Cardinality:   1,
+		ChurnFraction: 2,
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package block
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Interpolation is how values are resampled between the steps of a block.
+type Interpolation int
+
+const (
+	// PreviousInterpolation uses the value of the previous step, values are
+	// held for at most one step of the resampled block.
+	PreviousInterpolation Interpolation = iota
+	// LinearInterpolation linearly interpolates between the values of the
+	// surrounding steps.
+	LinearInterpolation
+	// NoInterpolation only uses values at steps that coincide with a step of
+	// the resampled block, all others are NaN.
+	NoInterpolation
+)
+
+var validInterpolations = []Interpolation{
+	PreviousInterpolation,
+	LinearInterpolation,
+	NoInterpolation,
+}
+
+func (i Interpolation) String() string {
+	switch i {
+	case PreviousInterpolation:
+		return "previous"
+	case LinearInterpolation:
+		return "linear"
+	case NoInterpolation:
+		return "none"
+	}
+	return "unknown"
+}
+
+// ParseInterpolation parses an interpolation.
+func ParseInterpolation(str string) (Interpolation, error) {
+	for _, valid := range validInterpolations {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid interpolation '%s' valid interpolations are: %v",
+		str, validInterpolations)
+}
+
+// UnmarshalYAML unmarshals an interpolation.
+func (i *Interpolation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseInterpolation(str)
+	if err != nil {
+		return err
+	}
+	*i = parsed
+	return nil
+}
+
+// AlignedBounds returns the bounds two blocks are resampled to so they can
+// be combined, the bounds of the block with the finer resolution.
+func AlignedBounds(a, b Bounds) Bounds {
+	if b.StepSize < a.StepSize {
+		return b
+	}
+	return a
+}
+
+// Align resamples two blocks to common bounds if their bounds differ, such as
+// when they are fetched from namespaces with different resolutions.
+func Align(lhs, rhs Block, interpolation Interpolation) (Block, Block, error) {
+	lIter, err := lhs.SeriesIter()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer lIter.Close()
+
+	rIter, err := rhs.SeriesIter()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rIter.Close()
+
+	lBounds, rBounds := lIter.Meta().Bounds, rIter.Meta().Bounds
+	if lBounds.Equals(rBounds) {
+		return lhs, rhs, nil
+	}
+
+	bounds := AlignedBounds(lBounds, rBounds)
+	lResampled, err := resample(lIter, bounds, interpolation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rResampled, err := resample(rIter, bounds, interpolation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lResampled, rResampled, nil
+}
+
+// Resample resamples a block to the bounds, interpolating values between
+// the steps of the block.
+func Resample(b Block, bounds Bounds, interpolation Interpolation) (Block, error) {
+	iter, err := b.SeriesIter()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	if iter.Meta().Bounds.Equals(bounds) {
+		return b, nil
+	}
+
+	return resample(iter, bounds, interpolation)
+}
+
+func resample(
+	iter SeriesIter,
+	bounds Bounds,
+	interpolation Interpolation,
+) (Block, error) {
+	var (
+		src     = iter.Meta().Bounds
+		steps   = bounds.Steps()
+		builder = NewColumnBlockBuilder(Metadata{
+			Bounds: bounds,
+			Tags:   iter.Meta().Tags,
+		}, iter.SeriesMeta())
+	)
+	if err := builder.AddCols(steps); err != nil {
+		return nil, err
+	}
+
+	for iter.Next() {
+		series, err := iter.Current()
+		if err != nil {
+			return nil, err
+		}
+
+		values := series.Values()
+		for i := 0; i < steps; i++ {
+			t := bounds.Start.Add(time.Duration(i) * bounds.StepSize)
+			value := interpolate(values, src, t, interpolation)
+			if err := builder.AppendValue(i, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return builder.Build(), nil
+}
+
+// interpolate returns the value at a time from values with the bounds.
+func interpolate(
+	values []float64,
+	bounds Bounds,
+	t time.Time,
+	interpolation Interpolation,
+) float64 {
+	if bounds.StepSize <= 0 || t.Before(bounds.Start) {
+		return math.NaN()
+	}
+
+	offset := t.Sub(bounds.Start)
+	idx := int(offset / bounds.StepSize)
+	if idx >= len(values) {
+		return math.NaN()
+	}
+
+	remainder := offset % bounds.StepSize
+	if remainder == 0 {
+		return values[idx]
+	}
+
+	switch interpolation {
+	case PreviousInterpolation:
+		return values[idx]
+	case LinearInterpolation:
+		if idx+1 >= len(values) {
+			return math.NaN()
+		}
+		// NaN values on either side result in NaN.
+		fraction := float64(remainder) / float64(bounds.StepSize)
+		return values[idx] + (values[idx+1]-values[idx])*fraction
+	}
+	return math.NaN()
+}
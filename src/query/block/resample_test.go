@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package block
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResampleTestBlock(t *testing.T, bounds Bounds, values []float64) Block {
+	builder := NewColumnBlockBuilder(Metadata{Bounds: bounds},
+		[]SeriesMeta{{Name: "foo"}})
+	require.NoError(t, builder.AddCols(len(values)))
+	for idx, value := range values {
+		require.NoError(t, builder.AppendValue(idx, value))
+	}
+	return builder.Build()
+}
+
+func resampledValues(t *testing.T, b Block, bounds Bounds) []float64 {
+	iter, err := b.SeriesIter()
+	require.NoError(t, err)
+	defer iter.Close()
+
+	require.Equal(t, bounds, iter.Meta().Bounds)
+	require.True(t, iter.Next())
+	series, err := iter.Current()
+	require.NoError(t, err)
+	require.False(t, iter.Next())
+	return series.Values()
+}
+
+func equalsWithNans(t *testing.T, expected, actual []float64) {
+	require.Equal(t, len(expected), len(actual))
+	for i, v := range expected {
+		if math.IsNaN(v) {
+			assert.True(t, math.IsNaN(actual[i]), "expected NaN at %d", i)
+			continue
+		}
+		assert.InDelta(t, v, actual[i], 0.0001, "value at %d", i)
+	}
+}
+
+func TestResample(t *testing.T) {
+	now := time.Now().Truncate(time.Hour)
+	coarse := Bounds{Start: now, End: now.Add(4 * time.Minute), StepSize: 2 * time.Minute}
+	fine := Bounds{Start: now, End: now.Add(4 * time.Minute), StepSize: time.Minute}
+	require.Equal(t, fine, AlignedBounds(coarse, fine))
+	require.Equal(t, fine, AlignedBounds(fine, coarse))
+
+	nan := math.NaN()
+	tests := []struct {
+		interpolation Interpolation
+		expected      []float64
+	}{
+		{PreviousInterpolation, []float64{1, 1, 3, 3, nan}},
+		{LinearInterpolation, []float64{1, 2, 3, nan, nan}},
+		{NoInterpolation, []float64{1, nan, 3, nan, nan}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interpolation.String(), func(t *testing.T) {
+			b := newResampleTestBlock(t, coarse, []float64{1, 3, nan})
+			resampled, err := Resample(b, fine, tt.interpolation)
+			require.NoError(t, err)
+			equalsWithNans(t, tt.expected, resampledValues(t, resampled, fine))
+		})
+	}
+}
+
+func TestResampleSameBounds(t *testing.T) {
+	now := time.Now()
+	bounds := Bounds{Start: now, End: now.Add(time.Minute), StepSize: time.Minute}
+	b := newResampleTestBlock(t, bounds, []float64{1, 2})
+	resampled, err := Resample(b, bounds, LinearInterpolation)
+	require.NoError(t, err)
+	assert.True(t, b == resampled)
+}
+
+func TestAlign(t *testing.T) {
+	now := time.Now().Truncate(time.Hour)
+	coarse := Bounds{Start: now, End: now.Add(2 * time.Minute), StepSize: 2 * time.Minute}
+	fine := Bounds{Start: now, End: now.Add(2 * time.Minute), StepSize: time.Minute}
+
+	lhs := newResampleTestBlock(t, coarse, []float64{1, 3})
+	rhs := newResampleTestBlock(t, fine, []float64{4, 5, 6})
+	lAligned, rAligned, err := Align(lhs, rhs, PreviousInterpolation)
+	require.NoError(t, err)
+	equalsWithNans(t, []float64{1, 1, 3}, resampledValues(t, lAligned, fine))
+	equalsWithNans(t, []float64{4, 5, 6}, resampledValues(t, rAligned, fine))
+}
+
+func TestParseInterpolation(t *testing.T) {
+	for _, valid := range validInterpolations {
+		parsed, err := ParseInterpolation(valid.String())
+		require.NoError(t, err)
+		assert.Equal(t, valid, parsed)
+	}
+
+	_, err := ParseInterpolation("cubic")
+	assert.Error(t, err)
+}
@@ -70,6 +70,12 @@ func (b Bounds) Steps() int {
 	return int(b.End.Sub(b.Start)/b.StepSize) + 1
 }
 
+// Equals returns whether the bounds are equal.
+func (b Bounds) Equals(other Bounds) bool {
+	return b.Start.Equal(other.Start) && b.End.Equal(other.End) &&
+		b.StepSize == other.StepSize
+}
+
 // String representation of the bounds
 func (b Bounds) String() string {
 	return fmt.Sprintf("start: %v, end: %v, stepSize: %v, steps: %d", b.Start, b.End, b.StepSize, b.Steps())
@@ -0,0 +1,410 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package client is a client of the query and write APIs of the
+// coordinator, so that services can query and write series with typed
+// requests and results rather than building HTTP requests and parsing
+// responses themselves. Queries and writes use the HTTP APIs, raw fetches
+// use the gRPC API used by remote coordinators.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/retry"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/tsdb/remote"
+	xerrors "github.com/m3db/m3x/errors"
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/golang/snappy"
+	"google.golang.org/grpc"
+)
+
+const (
+	// queryPath is the path of the native read handler.
+	queryPath = "/api/v1/prom/native/read"
+	// writePath is the path of the Prometheus remote write handler.
+	writePath = "/api/v1/prom/remote/write"
+
+	// timeoutHeader is the request header with the timeout of a query.
+	timeoutHeader = "timeout"
+
+	defaultTimeout = 2 * time.Minute
+)
+
+var (
+	errNoAddress       = errors.New("no coordinator address")
+	errNoGRPCAddresses = errors.New("no coordinator gRPC addresses configured")
+)
+
+// Options are the options of a client.
+type Options struct {
+	// HTTPClient is the client HTTP requests are sent with, defaults to a
+	// client with a two minute timeout.
+	HTTPClient *http.Client
+
+	// Retrier retries requests that fail transiently, nil does not retry
+	// requests.
+	Retrier xretry.Retrier
+
+	// GRPCAddresses are the addresses of the gRPC API of the coordinators
+	// fetches are balanced between, fetches fail if there are none.
+	GRPCAddresses []string
+
+	// GRPCDialOptions are additional options to dial the gRPC API with.
+	GRPCDialOptions []grpc.DialOption
+}
+
+// QueryRequest is a query of a range of time.
+type QueryRequest struct {
+	// Query is the PromQL query.
+	Query string
+	// Start is the start of the range.
+	Start time.Time
+	// End is the end of the range.
+	End time.Time
+	// Step is the interval between the datapoints of the results.
+	Step time.Duration
+	// Timeout is the timeout of the query, zero uses the default timeout of
+	// the coordinator.
+	Timeout time.Duration
+	// Decimation is how series with a resolution far finer than the step are
+	// reduced to a single value per step, unset uses the default decimation
+	// of the coordinator.
+	Decimation models.Decimation
+	// Debug logs the plans of the query on the coordinator.
+	Debug bool
+}
+
+// Series is a series and its datapoints.
+type Series struct {
+	Tags       models.Tags
+	Datapoints ts.Datapoints
+}
+
+// QueryStage is the duration of a stage of a query.
+type QueryStage struct {
+	Name     string
+	Duration time.Duration
+}
+
+// QueryStats are the statistics of a query.
+type QueryStats struct {
+	SeriesFetched     int
+	DatapointsDecoded int
+	Stages            []QueryStage
+}
+
+// QueryMetadata is the metadata of the result of a query.
+type QueryMetadata struct {
+	// Warnings are the warnings of the query, such as when the results are
+	// incomplete.
+	Warnings []string
+	// Stats are the statistics of the query.
+	Stats QueryStats
+}
+
+// QueryResult is the result of a query.
+type QueryResult struct {
+	QueryMetadata
+	Series []Series
+}
+
+// FetchRequest is a fetch of the raw datapoints of the series matching the
+// matchers within a range of time.
+type FetchRequest struct {
+	Matchers models.Matchers
+	Start    time.Time
+	End      time.Time
+}
+
+// ResponseError is returned when the coordinator responds with an error.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("coordinator responded with status %d: %s",
+		e.StatusCode, e.Message)
+}
+
+// Client is a client of a coordinator.
+type Client struct {
+	address    string
+	httpClient *http.Client
+	retrier    xretry.Retrier
+	grpcClient remote.Client
+}
+
+// NewClient returns a new client of the coordinator at the address, the
+// scheme and host of its HTTP APIs such as "http://localhost:7201".
+func NewClient(address string, opts Options) (*Client, error) {
+	if address == "" {
+		return nil, errNoAddress
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	c := &Client{
+		address:    strings.TrimSuffix(address, "/"),
+		httpClient: httpClient,
+		retrier:    opts.Retrier,
+	}
+	if len(opts.GRPCAddresses) > 0 {
+		grpcClient, err := remote.NewGrpcClient(opts.GRPCAddresses,
+			opts.GRPCDialOptions...)
+		if err != nil {
+			return nil, err
+		}
+		c.grpcClient = grpcClient
+	}
+
+	return c, nil
+}
+
+// Query executes a query and returns all of its series.
+func (c *Client) Query(ctx context.Context, req QueryRequest) (QueryResult, error) {
+	var series []Series
+	meta, err := c.QueryStream(ctx, req, func(s Series) error {
+		series = append(series, s)
+		return nil
+	})
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	return QueryResult{QueryMetadata: meta, Series: series}, nil
+}
+
+// QueryStream executes a query and calls fn with each series as it is
+// decoded from the response, so that the series of large results are not
+// all held in memory at once. The query is retried while it fails
+// transiently before its response is received, but not once series have
+// been passed to fn. An error returned by fn stops decoding and is returned.
+func (c *Client) QueryStream(
+	ctx context.Context,
+	req QueryRequest,
+	fn func(Series) error,
+) (QueryMetadata, error) {
+	httpReq, err := c.newQueryRequest(ctx, req)
+	if err != nil {
+		return QueryMetadata{}, err
+	}
+
+	var resp *http.Response
+	err = c.attempt(ctx, func() error {
+		var err error
+		resp, err = c.do(httpReq)
+		return err
+	})
+	if err != nil {
+		return QueryMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	return decodeQueryResponse(resp.Body, fn)
+}
+
+func (c *Client) newQueryRequest(
+	ctx context.Context,
+	req QueryRequest,
+) (*http.Request, error) {
+	params := url.Values{}
+	params.Set("target", req.Query)
+	params.Set("start", req.Start.Format(time.RFC3339Nano))
+	params.Set("end", req.End.Format(time.RFC3339Nano))
+	params.Set("step", req.Step.String())
+	if req.Decimation != models.UnsetDecimation {
+		params.Set("decimation", req.Decimation.String())
+	}
+	if req.Debug {
+		params.Set("debug", "true")
+	}
+
+	httpReq, err := http.NewRequest(http.MethodGet,
+		c.address+queryPath+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.Timeout > 0 {
+		httpReq.Header.Set(timeoutHeader, req.Timeout.String())
+	}
+
+	return httpReq.WithContext(ctx), nil
+}
+
+// Write writes the datapoints of the series, the name of each series is
+// its tag named models.MetricName.
+func (c *Client) Write(ctx context.Context, series []Series) error {
+	req := &prompb.WriteRequest{
+		Timeseries: make([]*prompb.TimeSeries, 0, len(series)),
+	}
+	for _, s := range series {
+		labels := make([]*prompb.Label, 0, len(s.Tags))
+		for name, value := range s.Tags {
+			labels = append(labels, &prompb.Label{Name: name, Value: value})
+		}
+
+		samples := make([]*prompb.Sample, 0, len(s.Datapoints))
+		for _, dp := range s.Datapoints {
+			samples = append(samples, &prompb.Sample{
+				Timestamp: dp.Timestamp.UnixNano() / int64(time.Millisecond),
+				Value:     dp.Value,
+			})
+		}
+
+		req.Timeseries = append(req.Timeseries, &prompb.TimeSeries{
+			Labels:  labels,
+			Samples: samples,
+		})
+	}
+
+	data, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	body := snappy.Encode(nil, data)
+
+	return c.attempt(ctx, func() error {
+		httpReq, err := http.NewRequest(http.MethodPost, c.address+writePath,
+			bytes.NewReader(body))
+		if err != nil {
+			return xerrors.NewNonRetryableError(err)
+		}
+		httpReq.Header.Set("Content-Encoding", "snappy")
+		httpReq.Header.Set("Content-Type", "application/x-protobuf")
+
+		resp, err := c.do(httpReq.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		return drainAndClose(resp.Body)
+	})
+}
+
+// Fetch fetches the raw datapoints of the series matching the request from
+// the gRPC API of the coordinators.
+func (c *Client) Fetch(ctx context.Context, req FetchRequest) ([]Series, error) {
+	if c.grpcClient == nil {
+		return nil, errNoGRPCAddresses
+	}
+
+	query := &storage.FetchQuery{
+		TagMatchers: req.Matchers,
+		Start:       req.Start,
+		End:         req.End,
+	}
+
+	var result *storage.FetchResult
+	err := c.attempt(ctx, func() error {
+		var err error
+		result, err = c.grpcClient.Fetch(ctx, query, &storage.FetchOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]Series, 0, len(result.SeriesList))
+	for _, s := range result.SeriesList {
+		vals := s.Values()
+		dps := make(ts.Datapoints, 0, vals.Len())
+		for i := 0; i < vals.Len(); i++ {
+			dps = append(dps, vals.DatapointAt(i))
+		}
+		series = append(series, Series{Tags: s.Tags, Datapoints: dps})
+	}
+
+	return series, nil
+}
+
+// Close closes the client.
+func (c *Client) Close() error {
+	if c.grpcClient == nil {
+		return nil
+	}
+	return c.grpcClient.Close()
+}
+
+// attempt calls fn, retrying it while it fails transiently, and returns the
+// error returned by fn rather than an error wrapping it.
+func (c *Client) attempt(ctx context.Context, fn func() error) error {
+	err := retry.Attempt(ctx, c.retrier, fn)
+	if inner := xerrors.GetInnerNonRetryableError(err); inner != nil {
+		return inner
+	}
+	return err
+}
+
+// do sends a request and returns the response if it succeeded, error
+// responses other than server errors and rate limiting are not retryable.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	respErr := &ResponseError{StatusCode: resp.StatusCode}
+	body, _ := ioutil.ReadAll(resp.Body)
+	var errBody struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errBody); err == nil && errBody.Error != "" {
+		respErr.Message = errBody.Error
+	} else {
+		respErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, respErr
+	}
+	return nil, xerrors.NewNonRetryableError(respErr)
+}
+
+func drainAndClose(body io.ReadCloser) error {
+	_, err := io.Copy(ioutil.Discard, body)
+	if closeErr := body.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/native"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testQueryResponse = `{
+	"status": "success",
+	"data": {
+		"resultType": "matrix",
+		"result": [
+			{"metric": {"__name__": "foo", "a": "1"}, "values": [[1500000000, "1.5"], [1500000010, "2"]]},
+			{"metric": {"__name__": "foo", "a": "2"}, "values": [[1500000000, "3"]]}
+		]
+	},
+	"warnings": ["incomplete results"],
+	"stats": {
+		"seriesFetched": 2,
+		"datapointsDecoded": 3,
+		"stages": [{"name": "execute", "durationSeconds": 0.5}]
+	}
+}`
+
+func newTestRetrier() xretry.Retrier {
+	return xretry.NewRetrier(xretry.NewOptions().
+		SetInitialBackoff(time.Millisecond).
+		SetMaxRetries(2).
+		SetJitter(false))
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, func()) {
+	server := httptest.NewServer(handler)
+	c, err := NewClient(server.URL, Options{Retrier: newTestRetrier()})
+	require.NoError(t, err)
+	return c, server.Close
+}
+
+func TestPathsMatchHandlers(t *testing.T) {
+	assert.Equal(t, native.PromReadURL, queryPath)
+	assert.Equal(t, remote.PromWriteURL, writePath)
+}
+
+func TestNewClientRequiresAddress(t *testing.T) {
+	_, err := NewClient("", Options{})
+	require.Error(t, err)
+}
+
+func TestQuery(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, queryPath, r.URL.Path)
+		assert.Equal(t, "foo", r.FormValue("target"))
+		assert.Equal(t, "10s", r.FormValue("step"))
+		assert.Equal(t, "max", r.FormValue("decimation"))
+		assert.Equal(t, "30s", r.Header.Get(timeoutHeader))
+		w.Write([]byte(testQueryResponse))
+	})
+	defer closer()
+
+	result, err := c.Query(context.Background(), QueryRequest{
+		Query:      "foo",
+		Start:      start,
+		End:        start.Add(time.Minute),
+		Step:       10 * time.Second,
+		Timeout:    30 * time.Second,
+		Decimation: models.MaxDecimation,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Series, 2)
+	assert.Equal(t, models.Tags{"__name__": "foo", "a": "1"}, result.Series[0].Tags)
+	assert.Equal(t, ts.Datapoints{
+		{Timestamp: start, Value: 1.5},
+		{Timestamp: start.Add(10 * time.Second), Value: 2},
+	}, result.Series[0].Datapoints)
+	assert.Equal(t, models.Tags{"__name__": "foo", "a": "2"}, result.Series[1].Tags)
+
+	assert.Equal(t, []string{"incomplete results"}, result.Warnings)
+	assert.Equal(t, QueryStats{
+		SeriesFetched:     2,
+		DatapointsDecoded: 3,
+		Stages:            []QueryStage{{Name: "execute", Duration: 500 * time.Millisecond}},
+	}, result.Stats)
+}
+
+func TestQueryStreamStopsOnError(t *testing.T) {
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testQueryResponse))
+	})
+	defer closer()
+
+	stopErr := errors.New("stop")
+	calls := 0
+	_, err := c.QueryStream(context.Background(), QueryRequest{Query: "foo"},
+		func(Series) error {
+			calls++
+			return stopErr
+		})
+	assert.Equal(t, stopErr, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestQueryRetriesServerErrors(t *testing.T) {
+	requests := 0
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(testQueryResponse))
+	})
+	defer closer()
+
+	result, err := c.Query(context.Background(), QueryRequest{Query: "foo"})
+	require.NoError(t, err)
+	assert.Len(t, result.Series, 2)
+	assert.Equal(t, 2, requests)
+}
+
+func TestQueryDoesNotRetryClientErrors(t *testing.T) {
+	requests := 0
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid query"}`))
+	})
+	defer closer()
+
+	_, err := c.Query(context.Background(), QueryRequest{Query: "foo("})
+	require.Error(t, err)
+	respErr, ok := err.(*ResponseError)
+	require.True(t, ok, "unexpected error: %v", err)
+	assert.Equal(t, http.StatusBadRequest, respErr.StatusCode)
+	assert.Equal(t, "invalid query", respErr.Message)
+	assert.Equal(t, 1, requests)
+}
+
+func TestWrite(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	var received prompb.WriteRequest
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, writePath, r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		require.NoError(t, received.Unmarshal(data))
+	})
+	defer closer()
+
+	err := c.Write(context.Background(), []Series{{
+		Tags:       models.Tags{models.MetricName: "foo"},
+		Datapoints: ts.Datapoints{{Timestamp: now, Value: 42}},
+	}})
+	require.NoError(t, err)
+
+	require.Len(t, received.Timeseries, 1)
+	series := received.Timeseries[0]
+	require.Len(t, series.Labels, 1)
+	assert.Equal(t, models.MetricName, series.Labels[0].Name)
+	assert.Equal(t, "foo", series.Labels[0].Value)
+	require.Len(t, series.Samples, 1)
+	assert.Equal(t, int64(1500000000000), series.Samples[0].Timestamp)
+	assert.Equal(t, 42.0, series.Samples[0].Value)
+}
+
+func TestFetchRequiresGRPCAddresses(t *testing.T) {
+	c, err := NewClient("http://localhost:7201", Options{})
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Fetch(context.Background(), FetchRequest{})
+	assert.Equal(t, errNoGRPCAddresses, err)
+}
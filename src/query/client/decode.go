@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+)
+
+type jsonSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values []jsonDatapoint   `json:"values"`
+}
+
+// jsonDatapoint is a datapoint rendered as a pair of its time in seconds
+// and its value as a string.
+type jsonDatapoint struct {
+	timestamp float64
+	value     string
+}
+
+func (d *jsonDatapoint) UnmarshalJSON(data []byte) error {
+	pair := [2]interface{}{&d.timestamp, &d.value}
+	return json.Unmarshal(data, &pair)
+}
+
+type jsonStats struct {
+	SeriesFetched     int `json:"seriesFetched"`
+	DatapointsDecoded int `json:"datapointsDecoded"`
+	Stages            []struct {
+		Name            string  `json:"name"`
+		DurationSeconds float64 `json:"durationSeconds"`
+	} `json:"stages"`
+}
+
+// decodeQueryResponse decodes the response of the native read handler,
+// calling fn with each series of the result as it is decoded rather than
+// decoding the whole response at once.
+func decodeQueryResponse(r io.Reader, fn func(Series) error) (QueryMetadata, error) {
+	var (
+		dec  = json.NewDecoder(r)
+		meta QueryMetadata
+	)
+	if err := expectDelim(dec, '{'); err != nil {
+		return meta, err
+	}
+
+	for dec.More() {
+		key, err := decodeKey(dec)
+		if err != nil {
+			return meta, err
+		}
+
+		switch key {
+		case "data":
+			err = decodeQueryData(dec, fn)
+		case "warnings":
+			err = dec.Decode(&meta.Warnings)
+		case "stats":
+			var stats jsonStats
+			if err = dec.Decode(&stats); err == nil {
+				meta.Stats = stats.toQueryStats()
+			}
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return meta, err
+		}
+	}
+
+	return meta, expectDelim(dec, '}')
+}
+
+func decodeQueryData(dec *json.Decoder, fn func(Series) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		key, err := decodeKey(dec)
+		if err != nil {
+			return err
+		}
+
+		if key != "result" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for dec.More() {
+			var s jsonSeries
+			if err := dec.Decode(&s); err != nil {
+				return err
+			}
+
+			series, err := s.toSeries()
+			if err != nil {
+				return err
+			}
+			if err := fn(series); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+func (s jsonSeries) toSeries() (Series, error) {
+	dps := make(ts.Datapoints, 0, len(s.Values))
+	for _, v := range s.Values {
+		value, err := strconv.ParseFloat(v.value, 64)
+		if err != nil {
+			return Series{}, err
+		}
+
+		sec, frac := math.Modf(v.timestamp)
+		dps = append(dps, ts.Datapoint{
+			Timestamp: time.Unix(int64(sec), int64(frac*float64(time.Second))),
+			Value:     value,
+		})
+	}
+
+	return Series{Tags: models.Tags(s.Metric), Datapoints: dps}, nil
+}
+
+func (s jsonStats) toQueryStats() QueryStats {
+	stats := QueryStats{
+		SeriesFetched:     s.SeriesFetched,
+		DatapointsDecoded: s.DatapointsDecoded,
+		Stages:            make([]QueryStage, 0, len(s.Stages)),
+	}
+	for _, stage := range s.Stages {
+		stats.Stages = append(stats.Stages, QueryStage{
+			Name:     stage.Name,
+			Duration: time.Duration(stage.DurationSeconds * float64(time.Second)),
+		})
+	}
+	return stats
+}
+
+func decodeKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got: %v", tok)
+	}
+	return key, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %v, got: %v", delim, tok)
+	}
+	return nil
+}
@@ -22,7 +22,11 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 	"github.com/m3db/m3/src/query/plan"
@@ -32,18 +36,28 @@ import (
 	"go.uber.org/zap"
 )
 
+var errEnrichmentNotConfigured = errors.New("query enrichment is not configured")
+
 // Engine executes a Query.
 type Engine struct {
 	// Used for tracking running queries.
 	tracker *Tracker
 	Stats   *QueryStatistics
 	store   storage.Storage
+	cache   *transform.EvaluationCache
+
+	interpolation block.Interpolation
+	decimation    models.Decimation
+	enricher      storage.TagEnricher
+	spiller       *transform.Spiller
 }
 
 // EngineOptions can be used to pass custom flags to engine
 type EngineOptions struct {
 	// AbortCh is a channel that signals when results are no longer desired by the caller.
 	AbortCh <-chan bool
+	// Stats if set collects statistics and warnings for the query.
+	Stats *models.QueryStats
 }
 
 // Query is the result after execution
@@ -61,6 +75,37 @@ func NewEngine(store storage.Storage) *Engine {
 	}
 }
 
+// SetResampleInterpolation sets the interpolation used to resample blocks
+// of different resolutions combined by binary operations.
+func (e *Engine) SetResampleInterpolation(interpolation block.Interpolation) {
+	e.interpolation = interpolation
+}
+
+// SetDecimation sets the decimation used for queries which do not specify
+// one, which reduces fetched series with a resolution far finer than the
+// step of the query to a single value per step.
+func (e *Engine) SetDecimation(decimation models.Decimation) {
+	e.decimation = decimation
+}
+
+// SetEnricher sets the enricher which adds the tags of lookup tables to the
+// series fetched by queries.
+func (e *Engine) SetEnricher(enricher storage.TagEnricher) {
+	e.enricher = enricher
+}
+
+// SetSpiller sets a spiller shared by all queries which spills blocks held by
+// memory heavy transforms to disk once its memory budget is exceeded.
+func (e *Engine) SetSpiller(spiller *transform.Spiller) {
+	e.spiller = spiller
+}
+
+// SetEvaluationCache sets a cache shared by all queries for the results of
+// cacheable transforms.
+func (e *Engine) SetEvaluationCache(cache *transform.EvaluationCache) {
+	e.cache = cache
+}
+
 // QueryStatistics keeps statistics related to the QueryExecutor.
 type QueryStatistics struct {
 	ActiveQueries          int64
@@ -85,6 +130,7 @@ func (e *Engine) Execute(ctx context.Context, query *storage.FetchQuery, opts *E
 
 	result, err := e.store.Fetch(ctx, query, &storage.FetchOptions{
 		KillChan: task.closing,
+		Stats:    opts.Stats,
 	})
 	if err != nil {
 		results <- &storage.QueryResult{Err: err}
@@ -99,6 +145,7 @@ func (e *Engine) Execute(ctx context.Context, query *storage.FetchQuery, opts *E
 func (e *Engine) ExecuteExpr(ctx context.Context, parser parser.Parser, opts *EngineOptions, params models.RequestParams, results chan Query) {
 	defer close(results)
 
+	planStart := time.Now()
 	nodes, edges, err := parser.DAG()
 	if err != nil {
 		results <- Query{Err: err}
@@ -115,17 +162,36 @@ func (e *Engine) ExecuteExpr(ctx context.Context, parser parser.Parser, opts *En
 		logging.WithContext(ctx).Info("logical plan", zap.String("plan", lp.String()))
 	}
 
+	if len(params.Enrichment) > 0 {
+		if e.enricher == nil {
+			results <- Query{Err: errEnrichmentNotConfigured}
+			return
+		}
+		if err := e.enricher.Validate(params.Enrichment); err != nil {
+			results <- Query{Err: err}
+			return
+		}
+	}
+
+	if params.Decimation == models.UnsetDecimation {
+		params.Decimation = e.decimation
+	}
+
 	pp, err := plan.NewPhysicalPlan(lp, e.store, params)
 	if err != nil {
 		results <- Query{Err: err}
 		return
 	}
+	pp.Interpolation = e.interpolation
+	pp.Enricher = e.enricher
 
 	if params.Debug {
 		logging.WithContext(ctx).Info("physical plan", zap.String("plan", pp.String()))
+		opts.Stats.EnableDebug()
+		opts.Stats.SetPlans(lp.String(), pp.String())
 	}
 
-	state, err := GenerateExecutionState(pp, e.store)
+	state, err := GenerateExecutionState(pp, e.store, opts.Stats, e.cache, e.spiller)
 	// free up resources
 	if err != nil {
 		results <- Query{Err: err}
@@ -136,9 +202,14 @@ func (e *Engine) ExecuteExpr(ctx context.Context, parser parser.Parser, opts *En
 		logging.WithContext(ctx).Info("execution state", zap.String("state", state.String()))
 	}
 
+	opts.Stats.AddStage("plan", time.Since(planStart))
+
 	result := state.resultNode
 	results <- Query{Result: result}
-	if err := state.Execute(ctx); err != nil {
+	executeStart := time.Now()
+	err = state.Execute(ctx)
+	opts.Stats.AddStage("execute", time.Since(executeStart))
+	if err != nil {
 		result.abort(err)
 	} else {
 		result.done()
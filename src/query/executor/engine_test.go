@@ -25,12 +25,15 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser/promql"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/test/local"
 	"github.com/m3db/m3/src/query/util/logging"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExecute(t *testing.T) {
@@ -48,3 +51,36 @@ func TestExecute(t *testing.T) {
 	<-results
 	assert.Equal(t, len(engine.tracker.queries), 1)
 }
+
+type testEnricher struct {
+	storage.TagEnricher
+	err error
+}
+
+func (e testEnricher) Validate(_ []string) error {
+	return e.err
+}
+
+func TestExecuteExprEnrichmentValidation(t *testing.T) {
+	logging.InitWithCores(nil)
+	ctrl := gomock.NewController(t)
+	store, _ := local.NewStorageAndSession(t, ctrl)
+	engine := NewEngine(store)
+
+	execute := func() error {
+		parser, err := promql.Parse("foo")
+		require.NoError(t, err)
+		results := make(chan Query)
+		go engine.ExecuteExpr(context.TODO(), parser, &EngineOptions{},
+			models.RequestParams{Enrichment: []string{"teams"}}, results)
+		result := <-results
+		for range results {
+		}
+		return result.Err
+	}
+
+	assert.Equal(t, errEnrichmentNotConfigured, execute())
+
+	engine.SetEnricher(testEnricher{err: fmt.Errorf("unknown lookup table teams")})
+	assert.EqualError(t, execute(), "unknown lookup table teams")
+}
@@ -25,6 +25,7 @@ import (
 	"fmt"
 
 	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 	"github.com/m3db/m3/src/query/plan"
 	"github.com/m3db/m3/src/query/storage"
@@ -43,13 +44,26 @@ type ExecutionState struct {
 
 // CreateSource creates a source node
 func CreateSource(ID parser.NodeID, params SourceParams, storage storage.Storage, options transform.Options) (parser.Source, *transform.Controller) {
-	controller := &transform.Controller{ID: ID}
+	controller := &transform.Controller{ID: ID, Spiller: options.Spiller}
 	return params.Node(controller, storage, options), controller
 }
 
-// CreateTransform creates a transform node which works on functions and contains state
-func CreateTransform(ID parser.NodeID, params transform.Params) (transform.OpNode, *transform.Controller) {
+// CreateTransform creates a transform node which works on functions and contains state,
+// the results of cacheable transforms are cached in the evaluation cache if set
+func CreateTransform(
+	ID parser.NodeID,
+	params transform.Params,
+	options transform.Options,
+) (transform.OpNode, *transform.Controller) {
 	controller := &transform.Controller{ID: ID}
+	if cacheable, ok := params.(transform.CacheableParams); ok && options.Cache != nil {
+		return transform.NewCachedNode(ID, cacheable, controller, options.Cache), controller
+	}
+
+	if resampling, ok := params.(transform.ResamplingParams); ok {
+		return resampling.ResamplingNode(controller, options.Interpolation), controller
+	}
+
 	node := params.Node(controller)
 
 	switch node.(type) {
@@ -70,8 +84,17 @@ type SourceParams interface {
 	Node(controller *transform.Controller, storage storage.Storage, options transform.Options) parser.Source
 }
 
-// GenerateExecutionState creates an execution state from the physical plan
-func GenerateExecutionState(pplan plan.PhysicalPlan, storage storage.Storage) (*ExecutionState, error) {
+// GenerateExecutionState creates an execution state from the physical plan,
+// stats are optional and collect statistics while executing the plan, the
+// evaluation cache is optional and caches the results of cacheable transforms
+// and the spiller is optional and spills blocks held by transforms to disk
+func GenerateExecutionState(
+	pplan plan.PhysicalPlan,
+	storage storage.Storage,
+	stats *models.QueryStats,
+	cache *transform.EvaluationCache,
+	spiller *transform.Spiller,
+) (*ExecutionState, error) {
 	result := pplan.ResultStep
 	state := &ExecutionState{
 		plan:    pplan,
@@ -86,6 +109,14 @@ func GenerateExecutionState(pplan plan.PhysicalPlan, storage storage.Storage) (*
 	options := transform.Options{
 		TimeSpec: pplan.TimeSpec,
 		Debug:    pplan.Debug,
+		Stats:    stats,
+		Cache:    cache,
+
+		Interpolation: pplan.Interpolation,
+		Spiller:       spiller,
+		Decimation:    pplan.Decimation,
+		Enricher:      pplan.Enricher,
+		Enrichment:    pplan.Enrichment,
 	}
 	controller, err := state.createNode(step, options)
 	if err != nil {
@@ -119,7 +150,11 @@ func (s *ExecutionState) createNode(step plan.LogicalStep, options transform.Opt
 		return nil, fmt.Errorf("invalid transform step, %s", step)
 	}
 
-	transformNode, controller := CreateTransform(step.ID(), transformParams)
+	transformNode, controller := CreateTransform(step.ID(), transformParams, options)
+	if options.Debug && options.Stats != nil {
+		name := fmt.Sprintf("%s %s", step.ID(), transformParams.OpType())
+		transformNode = transform.NewTimedNode(transformNode, name, options.Stats)
+	}
 	for _, parentID := range step.Parents {
 		parentStep, ok := s.plan.Step(parentID)
 		if !ok {
@@ -31,14 +31,30 @@ import (
 
 // BlockCache is used to cache blocks
 type BlockCache struct {
-	blocks map[parser.NodeID]block.Block
-	mu     sync.Mutex
+	blocks  map[parser.NodeID]cachedBlock
+	spiller *Spiller
+	mu      sync.Mutex
+}
+
+// cachedBlock is a block held in memory or spilled to disk
+type cachedBlock struct {
+	block   block.Block
+	spilled *spilledBlock
+	size    int
 }
 
 // NewBlockCache creates a new BlockCache
 func NewBlockCache() *BlockCache {
+	return NewSpillingBlockCache(nil)
+}
+
+// NewSpillingBlockCache creates a new BlockCache which spills blocks to disk
+// when they would exceed the memory budget of the spiller, the spiller is
+// optional
+func NewSpillingBlockCache(spiller *Spiller) *BlockCache {
 	return &BlockCache{
-		blocks: make(map[parser.NodeID]block.Block),
+		blocks:  make(map[parser.NodeID]cachedBlock),
+		spiller: spiller,
 	}
 }
 
@@ -51,7 +67,27 @@ func (c *BlockCache) Add(key parser.NodeID, b block.Block) error {
 		return errors.New("block already exists")
 	}
 
-	c.blocks[key] = b
+	if c.spiller == nil {
+		c.blocks[key] = cachedBlock{block: b}
+		return nil
+	}
+
+	size, steps, err := blockSize(b)
+	if err != nil {
+		return err
+	}
+
+	if c.spiller.reserve(size) {
+		c.blocks[key] = cachedBlock{block: b, size: size}
+		return nil
+	}
+
+	spilled, err := c.spiller.spill(b, steps)
+	if err != nil {
+		return err
+	}
+
+	c.blocks[key] = cachedBlock{spilled: spilled}
 	return nil
 }
 
@@ -59,14 +95,58 @@ func (c *BlockCache) Add(key parser.NodeID, b block.Block) error {
 func (c *BlockCache) Remove(key parser.NodeID) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	cached, ok := c.blocks[key]
+	if !ok {
+		return
+	}
+
+	c.release(cached)
 	delete(c.blocks, key)
 }
 
-// Get the block from the cache
+// Get the block from the cache, restoring it if it was spilled to disk
 // TODO: Evaluate only a single process getting a block at a time
-func (c *BlockCache) Get(key parser.NodeID) (block.Block, bool) {
+func (c *BlockCache) Get(key parser.NodeID) (block.Block, bool, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	b, ok := c.blocks[key]
-	return b, ok
+	cached, ok := c.blocks[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if cached.spilled == nil {
+		return cached.block, true, nil
+	}
+
+	// The restored block is held in memory by the caller
+	b, err := cached.spilled.restore()
+	if err != nil {
+		delete(c.blocks, key)
+		return nil, false, err
+	}
+
+	c.blocks[key] = cachedBlock{block: b}
+	return b, true, nil
+}
+
+func (c *BlockCache) release(cached cachedBlock) {
+	if cached.spilled != nil {
+		cached.spilled.remove()
+	}
+
+	if cached.size > 0 {
+		c.spiller.release(cached.size)
+	}
+}
+
+// blockSize returns the number of values and steps in a block
+func blockSize(b block.Block) (int, int, error) {
+	iter, err := b.StepIter()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer iter.Close()
+	steps := iter.StepCount()
+	return steps * len(iter.SeriesMeta()), steps, nil
 }
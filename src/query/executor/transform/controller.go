@@ -28,6 +28,7 @@ import (
 // Controller controls the caching and forwarding the request to downstream.
 type Controller struct {
 	ID         parser.NodeID
+	Spiller    *Spiller
 	transforms []OpNode
 }
 
@@ -48,6 +49,12 @@ func (t *Controller) Process(block block.Block) error {
 	return nil
 }
 
+// BlockCache returns a BlockCache instance which spills blocks to disk if the
+// controller has a spiller
+func (t *Controller) BlockCache() *BlockCache {
+	return NewSpillingBlockCache(t.Spiller)
+}
+
 // BlockBuilder returns a BlockBuilder instance with associated metadata
 func (t *Controller) BlockBuilder(blockMeta block.Metadata, seriesMeta []block.SeriesMeta) (block.Builder, error) {
 	return block.NewColumnBlockBuilder(blockMeta, seriesMeta), nil
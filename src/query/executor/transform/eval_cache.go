@@ -0,0 +1,294 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transform
+
+import (
+	"container/list"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/parser"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultEvaluationCacheSize = 1024
+)
+
+// CacheableParams is implemented by the params of deterministic and
+// expensive transforms that take a single input, the results of which
+// may be cached by the fingerprint of the input block and reused across
+// queries such as overlapping dashboard panels.
+type CacheableParams interface {
+	Params
+	// CacheKey returns a key unique to the transform and its parameters.
+	CacheKey() string
+}
+
+// EvaluationCacheConfiguration is the configuration for an evaluation cache.
+type EvaluationCacheConfiguration struct {
+	// Size is the maximum number of transform results to cache.
+	Size int `yaml:"size"`
+}
+
+// NewEvaluationCache returns a new evaluation cache from the configuration.
+func (c EvaluationCacheConfiguration) NewEvaluationCache(scope tally.Scope) *EvaluationCache {
+	size := c.Size
+	if size <= 0 {
+		size = defaultEvaluationCacheSize
+	}
+	return NewEvaluationCache(size, scope)
+}
+
+type evaluationCacheMetrics struct {
+	hits      tally.Counter
+	misses    tally.Counter
+	evictions tally.Counter
+}
+
+// EvaluationCache is a least recently used cache of transform results keyed
+// by the transform and the fingerprint of its input block.
+type EvaluationCache struct {
+	sync.Mutex
+
+	size    int
+	entries map[evaluationCacheKey]*list.Element
+	lru     *list.List
+	metrics evaluationCacheMetrics
+}
+
+type evaluationCacheKey struct {
+	transform   string
+	fingerprint uint64
+}
+
+type evaluationCacheEntry struct {
+	key    evaluationCacheKey
+	result *materializedBlock
+}
+
+// NewEvaluationCache returns a new evaluation cache holding at most size
+// transform results.
+func NewEvaluationCache(size int, scope tally.Scope) *EvaluationCache {
+	return &EvaluationCache{
+		size:    size,
+		entries: make(map[evaluationCacheKey]*list.Element, size),
+		lru:     list.New(),
+		metrics: evaluationCacheMetrics{
+			hits:      scope.Counter("hits"),
+			misses:    scope.Counter("misses"),
+			evictions: scope.Counter("evictions"),
+		},
+	}
+}
+
+func (c *EvaluationCache) get(key evaluationCacheKey) (*materializedBlock, bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.metrics.misses.Inc(1)
+		return nil, false
+	}
+	c.metrics.hits.Inc(1)
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*evaluationCacheEntry).result, true
+}
+
+func (c *EvaluationCache) put(key evaluationCacheKey, result *materializedBlock) {
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*evaluationCacheEntry).result = result
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&evaluationCacheEntry{key: key, result: result})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*evaluationCacheEntry).key)
+		c.metrics.evictions.Inc(1)
+	}
+}
+
+// Len returns the number of cached results.
+func (c *EvaluationCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.lru.Len()
+}
+
+// materializedBlock is an immutable copy of a block that can be rebuilt
+// any number of times.
+type materializedBlock struct {
+	meta       block.Metadata
+	seriesMeta []block.SeriesMeta
+	steps      [][]float64
+}
+
+func materialize(b block.Block) (*materializedBlock, error) {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return nil, err
+	}
+	defer stepIter.Close()
+
+	result := &materializedBlock{
+		meta:       stepIter.Meta(),
+		seriesMeta: stepIter.SeriesMeta(),
+		steps:      make([][]float64, 0, stepIter.StepCount()),
+	}
+	for stepIter.Next() {
+		step, err := stepIter.Current()
+		if err != nil {
+			return nil, err
+		}
+		result.steps = append(result.steps, append([]float64(nil), step.Values()...))
+	}
+	return result, nil
+}
+
+func (m *materializedBlock) build(controller *Controller) (block.Block, error) {
+	builder, err := controller.BlockBuilder(m.meta, m.seriesMeta)
+	if err != nil {
+		return nil, err
+	}
+	if err := builder.AddCols(len(m.steps)); err != nil {
+		return nil, err
+	}
+	for idx, values := range m.steps {
+		for _, value := range values {
+			if err := builder.AppendValue(idx, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.Build(), nil
+}
+
+// Fingerprint returns a hash of the bounds, series metadata and values of a
+// block, blocks with equal fingerprints are treated as equal inputs.
+func Fingerprint(b block.Block) (uint64, error) {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return 0, err
+	}
+	defer stepIter.Close()
+
+	var (
+		hash = fnv.New64a()
+		buf  [8]byte
+	)
+	writeUint64 := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		hash.Write(buf[:])
+	}
+
+	meta := stepIter.Meta()
+	writeUint64(uint64(meta.Bounds.Start.UnixNano()))
+	writeUint64(uint64(meta.Bounds.End.UnixNano()))
+	writeUint64(uint64(meta.Bounds.StepSize))
+	hash.Write([]byte(meta.Tags.ID()))
+	for _, series := range stepIter.SeriesMeta() {
+		hash.Write([]byte(series.Name))
+		hash.Write([]byte(series.Tags.ID()))
+	}
+	for stepIter.Next() {
+		step, err := stepIter.Current()
+		if err != nil {
+			return 0, err
+		}
+		for _, value := range step.Values() {
+			writeUint64(math.Float64bits(value))
+		}
+	}
+	return hash.Sum64(), nil
+}
+
+// NewCachedNode creates a node for cacheable params that reuses the result
+// of the transform when an input block with the same fingerprint was
+// previously processed by an equal transform.
+func NewCachedNode(
+	ID parser.NodeID,
+	params CacheableParams,
+	controller *Controller,
+	cache *EvaluationCache,
+) OpNode {
+	node := &cachedNode{
+		controller: controller,
+		cache:      cache,
+		transform:  params.CacheKey(),
+	}
+	// The transform outputs to an inner controller so its result can be
+	// recorded before forwarding it downstream.
+	inner := &Controller{ID: ID}
+	inner.AddTransform(&cacheRecorderNode{parent: node})
+	node.node = params.Node(inner)
+	return node
+}
+
+type cachedNode struct {
+	node       OpNode
+	controller *Controller
+	cache      *EvaluationCache
+	transform  string
+
+	// pending is the key of the input being processed by the transform.
+	pending evaluationCacheKey
+}
+
+func (n *cachedNode) Process(ID parser.NodeID, b block.Block) error {
+	fingerprint, err := Fingerprint(b)
+	if err != nil {
+		return err
+	}
+
+	key := evaluationCacheKey{transform: n.transform, fingerprint: fingerprint}
+	if result, ok := n.cache.get(key); ok {
+		cached, err := result.build(n.controller)
+		if err != nil {
+			return err
+		}
+		defer cached.Close()
+		return n.controller.Process(cached)
+	}
+
+	n.pending = key
+	return n.node.Process(ID, b)
+}
+
+type cacheRecorderNode struct {
+	parent *cachedNode
+}
+
+func (n *cacheRecorderNode) Process(_ parser.NodeID, b block.Block) error {
+	result, err := materialize(b)
+	if err != nil {
+		return err
+	}
+	n.parent.cache.put(n.parent.pending, result)
+	return n.parent.controller.Process(b)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type countingParams struct {
+	nodes []*dummyFunc
+}
+
+func (p *countingParams) OpType() string   { return "counting" }
+func (p *countingParams) String() string   { return "counting" }
+func (p *countingParams) CacheKey() string { return "counting" }
+
+func (p *countingParams) Node(controller *Controller) OpNode {
+	node := &dummyFunc{controller: controller}
+	p.nodes = append(p.nodes, node)
+	return node
+}
+
+func TestCachedNodeReusesResult(t *testing.T) {
+	var (
+		cache  = NewEvaluationCache(10, tally.NoopScope)
+		params = &countingParams{}
+	)
+	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+
+	// Two queries with the same input share the cached result
+	for i := 0; i < 2; i++ {
+		sink := &sinkNode{}
+		controller := &Controller{ID: parser.NodeID("1")}
+		controller.AddTransform(sink)
+		node := NewCachedNode(parser.NodeID("1"), params, controller, cache)
+
+		b := test.NewBlockFromValues(bounds, values)
+		require.NoError(t, node.Process(parser.NodeID("0"), b))
+		require.NotNil(t, sink.block)
+
+		fingerprint, err := Fingerprint(sink.block)
+		require.NoError(t, err)
+		expected, err := Fingerprint(b)
+		require.NoError(t, err)
+		assert.Equal(t, expected, fingerprint)
+	}
+
+	require.Len(t, params.nodes, 2)
+	assert.True(t, params.nodes[0].processed)
+	assert.False(t, params.nodes[1].processed, "second query served from cache")
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestCachedNodeDifferentInputs(t *testing.T) {
+	var (
+		cache  = NewEvaluationCache(10, tally.NoopScope)
+		params = &countingParams{}
+	)
+	_, bounds := test.GenerateValuesAndBounds(nil, nil)
+	for _, v := range []float64{1, 2} {
+		values := [][]float64{{v, v, v, v, v}}
+		sink := &sinkNode{}
+		controller := &Controller{ID: parser.NodeID("1")}
+		controller.AddTransform(sink)
+		node := NewCachedNode(parser.NodeID("1"), params, controller, cache)
+		b := test.NewBlockFromValues(bounds, values)
+		require.NoError(t, node.Process(parser.NodeID("0"), b))
+	}
+
+	for _, node := range params.nodes {
+		assert.True(t, node.processed)
+	}
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestEvaluationCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewEvaluationCache(2, tally.NoopScope)
+	keys := []evaluationCacheKey{
+		{transform: "a", fingerprint: 1},
+		{transform: "a", fingerprint: 2},
+		{transform: "a", fingerprint: 3},
+	}
+	cache.put(keys[0], &materializedBlock{})
+	cache.put(keys[1], &materializedBlock{})
+	_, ok := cache.get(keys[0])
+	require.True(t, ok)
+	cache.put(keys[2], &materializedBlock{})
+
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.get(keys[1])
+	assert.False(t, ok)
+	_, ok = cache.get(keys[0])
+	assert.True(t, ok)
+}
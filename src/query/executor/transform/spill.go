@@ -0,0 +1,254 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transform
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/m3db/m3/src/query/block"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	spillFilePattern = "m3query-spill-"
+)
+
+// SpillConfiguration is the configuration for spilling blocks held by
+// memory heavy transforms to disk.
+type SpillConfiguration struct {
+	// Directory is the directory spilled blocks are written to, defaults to
+	// the system temporary directory.
+	Directory string `yaml:"directory"`
+
+	// MemoryBudget is the maximum number of values held in memory by
+	// transforms across all queries before further blocks are spilled.
+	MemoryBudget int `yaml:"memoryBudget" validate:"min=1"`
+}
+
+// NewSpiller returns a new spiller from the configuration.
+func (c SpillConfiguration) NewSpiller(scope tally.Scope) (*Spiller, error) {
+	dir := c.Directory
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return NewSpiller(dir, c.MemoryBudget, scope), nil
+}
+
+type spillerMetrics struct {
+	spilled        tally.Counter
+	spilledBytes   tally.Counter
+	restored       tally.Counter
+	errors         tally.Counter
+	inMemoryValues tally.Gauge
+}
+
+// Spiller tracks the values held in memory by transforms waiting on other
+// inputs, such as joins, and spills blocks to disk once the memory budget is
+// exceeded so that large queries degrade rather than exhausting memory.
+type Spiller struct {
+	sync.Mutex
+
+	dir     string
+	budget  int
+	used    int
+	metrics spillerMetrics
+}
+
+// NewSpiller returns a new spiller which writes blocks to the directory once
+// more than budget values are held in memory.
+func NewSpiller(dir string, budget int, scope tally.Scope) *Spiller {
+	return &Spiller{
+		dir:    dir,
+		budget: budget,
+		metrics: spillerMetrics{
+			spilled:        scope.Counter("spilled-blocks"),
+			spilledBytes:   scope.Counter("spilled-bytes"),
+			restored:       scope.Counter("restored-blocks"),
+			errors:         scope.Counter("errors"),
+			inMemoryValues: scope.Gauge("in-memory-values"),
+		},
+	}
+}
+
+// reserve reserves memory for the values, returning false if the values
+// would exceed the memory budget.
+func (s *Spiller) reserve(values int) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.used+values > s.budget {
+		return false
+	}
+	s.used += values
+	s.metrics.inMemoryValues.Update(float64(s.used))
+	return true
+}
+
+func (s *Spiller) release(values int) {
+	s.Lock()
+	defer s.Unlock()
+	s.used -= values
+	s.metrics.inMemoryValues.Update(float64(s.used))
+}
+
+// InMemoryValues returns the number of values currently held in memory.
+func (s *Spiller) InMemoryValues() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.used
+}
+
+type spillHeader struct {
+	Meta       block.Metadata
+	SeriesMeta []block.SeriesMeta
+	Steps      int
+}
+
+// spill writes the block with the number of steps to disk.
+func (s *Spiller) spill(b block.Block, steps int) (*spilledBlock, error) {
+	spilled, err := s.writeBlock(b, steps)
+	if err != nil {
+		s.metrics.errors.Inc(1)
+		return nil, err
+	}
+	s.metrics.spilled.Inc(1)
+	return spilled, nil
+}
+
+func (s *Spiller) writeBlock(b block.Block, steps int) (*spilledBlock, error) {
+	iter, err := b.SeriesIter()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	f, err := ioutil.TempFile(s.dir, spillFilePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	spilled := &spilledBlock{spiller: s, path: f.Name()}
+	if err := writeSeries(f, iter, steps); err != nil {
+		f.Close()
+		spilled.remove()
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err == nil {
+		s.metrics.spilledBytes.Inc(info.Size())
+	}
+
+	if err := f.Close(); err != nil {
+		spilled.remove()
+		return nil, err
+	}
+	return spilled, nil
+}
+
+func writeSeries(f *os.File, iter block.SeriesIter, steps int) error {
+	var (
+		w   = bufio.NewWriter(f)
+		enc = gob.NewEncoder(w)
+	)
+	header := spillHeader{
+		Meta:       iter.Meta(),
+		SeriesMeta: iter.SeriesMeta(),
+		Steps:      steps,
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		series, err := iter.Current()
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(series.Values()); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// spilledBlock is a block which has been written to disk.
+type spilledBlock struct {
+	spiller *Spiller
+	path    string
+}
+
+// restore reads the block back from disk and removes the file.
+func (b *spilledBlock) restore() (block.Block, error) {
+	restored, err := b.read()
+	b.remove()
+	if err != nil {
+		b.spiller.metrics.errors.Inc(1)
+		return nil, err
+	}
+	b.spiller.metrics.restored.Inc(1)
+	return restored, nil
+}
+
+func (b *spilledBlock) read() (block.Block, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	var header spillHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, err
+	}
+
+	builder := block.NewColumnBlockBuilder(header.Meta, header.SeriesMeta)
+	if err := builder.AddCols(header.Steps); err != nil {
+		return nil, err
+	}
+
+	for range header.SeriesMeta {
+		var values []float64
+		if err := dec.Decode(&values); err != nil {
+			return nil, err
+		}
+		for idx, value := range values {
+			if err := builder.AppendValue(idx, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.Build(), nil
+}
+
+func (b *spilledBlock) remove() {
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		b.spiller.metrics.errors.Inc(1)
+	}
+}
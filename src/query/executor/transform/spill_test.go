@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transform
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func blockValues(t *testing.T, b block.Block) [][]float64 {
+	iter, err := b.SeriesIter()
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var values [][]float64
+	for iter.Next() {
+		series, err := iter.Current()
+		require.NoError(t, err)
+		values = append(values, series.Values())
+	}
+	return values
+}
+
+func TestBlockCacheSpillsOverBudget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	scope := tally.NewTestScope("", nil)
+	spiller := NewSpiller(dir, 10, scope)
+	cache := NewSpillingBlockCache(spiller)
+
+	values, bounds := test.GenerateValuesAndBounds([][]float64{
+		{0, 1, math.NaN(), 3, 4},
+		{5, 6, 7, 8, 9},
+	}, nil)
+	require.NoError(t, cache.Add(parser.NodeID("0"), test.NewBlockFromValues(bounds, values)))
+	assert.Equal(t, 10, spiller.InMemoryValues())
+
+	require.NoError(t, cache.Add(parser.NodeID("1"), test.NewBlockFromValues(bounds, values)))
+	assert.Equal(t, 10, spiller.InMemoryValues())
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	restored, ok, err := cache.Get(parser.NodeID("1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	test.EqualsWithNans(t, values, blockValues(t, restored))
+
+	iter, err := restored.SeriesIter()
+	require.NoError(t, err)
+	assert.True(t, bounds.Equals(iter.Meta().Bounds))
+	assert.Len(t, iter.SeriesMeta(), 2)
+	iter.Close()
+
+	files, err = ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+
+	cache.Remove(parser.NodeID("0"))
+	cache.Remove(parser.NodeID("1"))
+	assert.Equal(t, 0, spiller.InMemoryValues())
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["spilled-blocks+"].Value())
+	assert.Equal(t, int64(1), counters["restored-blocks+"].Value())
+}
+
+func TestBlockCacheRemovesSpilledBlocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := NewSpillingBlockCache(NewSpiller(dir, 1, tally.NoopScope))
+	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+	require.NoError(t, cache.Add(parser.NodeID("0"), test.NewBlockFromValues(bounds, values)))
+
+	cache.Remove(parser.NodeID("0"))
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+
+	_, ok, err := cache.Get(parser.NodeID("0"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -24,13 +24,29 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/storage"
 )
 
 // Options to create transform nodes
 type Options struct {
 	TimeSpec TimeSpec
 	Debug    bool
+	Stats    *models.QueryStats
+	Cache    *EvaluationCache
+	// Interpolation is used to resample blocks of different resolutions
+	Interpolation block.Interpolation
+	// Spiller spills blocks held by memory heavy transforms to disk
+	Spiller *Spiller
+	// Decimation is how fetched series with a resolution far finer than the
+	// step are reduced to a single value per step
+	Decimation models.Decimation
+	// Enricher adds the tags of lookup tables to the fetched series
+	Enricher storage.TagEnricher
+	// Enrichment are the names of the lookup tables applied to the fetched
+	// series in addition to those of their namespace
+	Enrichment []string
 }
 
 // OpNode represents the execution node
@@ -53,6 +69,14 @@ type Params interface {
 	Node(controller *Controller) OpNode
 }
 
+// ResamplingParams are implemented by transforms which combine blocks that
+// may have different resolutions, the blocks are resampled to a common
+// resolution using the interpolation before they are combined
+type ResamplingParams interface {
+	Params
+	ResamplingNode(controller *Controller, interpolation block.Interpolation) OpNode
+}
+
 // MetaNode is implemented by function nodes which can alter metadata for a block
 type MetaNode interface {
 	// Meta provides the block metadata for the block using the input blocks' metadata as input
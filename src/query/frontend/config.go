@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package frontend
+
+import (
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultMaxQueuedPerTenant = 100
+	defaultBatchEvery         = 4
+)
+
+// Configuration is the configuration of the query frontend.
+type Configuration struct {
+	// MaxConcurrentQueries is the max number of queries executed at once,
+	// further queries wait in the queue of their tenant.
+	MaxConcurrentQueries int `yaml:"maxConcurrentQueries" validate:"nonzero"`
+
+	// MaxQueuedPerTenant is the max number of queries of a tenant waiting to
+	// be executed, further queries of the tenant are rejected (optional).
+	MaxQueuedPerTenant int `yaml:"maxQueuedPerTenant"`
+
+	// MaxQueueWait is the max time a query waits to be executed before it is
+	// rejected, by default queries wait until their request times out.
+	MaxQueueWait time.Duration `yaml:"maxQueueWait"`
+
+	// BatchEvery is the number of interactive queries executed for every
+	// batch query while queries of both priorities are waiting (optional).
+	BatchEvery int `yaml:"batchEvery"`
+
+	// TenantHeader is the request header identifying the tenant of a query,
+	// defaults to M3-Tenant.
+	TenantHeader string `yaml:"tenantHeader"`
+}
+
+// NewFrontend returns a new query frontend for the configuration.
+func (c Configuration) NewFrontend(scope tally.Scope) *Frontend {
+	opts := Options{
+		MaxConcurrentQueries: c.MaxConcurrentQueries,
+		MaxQueuedPerTenant:   c.MaxQueuedPerTenant,
+		MaxQueueWait:         c.MaxQueueWait,
+		BatchEvery:           c.BatchEvery,
+		TenantHeader:         c.TenantHeader,
+		Scope:                scope,
+	}
+	if opts.MaxQueuedPerTenant <= 0 {
+		opts.MaxQueuedPerTenant = defaultMaxQueuedPerTenant
+	}
+	if opts.BatchEvery <= 0 {
+		opts.BatchEvery = defaultBatchEvery
+	}
+	if opts.TenantHeader == "" {
+		opts.TenantHeader = DefaultTenantHeader
+	}
+	return NewFrontend(opts)
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package frontend queues queries in front of their execution so that the
+// queries of each tenant are executed fairly. Queries are queued per tenant
+// and priority and executed by a fixed number of slots, so that a storm of
+// queries from a single tenant, such as a dashboard refreshing many panels,
+// delays its own queries rather than the queries of every tenant.
+package frontend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// DefaultTenantHeader is the default request header identifying the
+	// tenant of a query.
+	DefaultTenantHeader = "M3-Tenant"
+
+	// PriorityHeader is the request header with the priority of a query,
+	// either interactive or batch.
+	PriorityHeader = "M3-Query-Priority"
+
+	// defaultTenant is the tenant of queries without a tenant header.
+	defaultTenant = "default"
+)
+
+var (
+	errTenantQueueFull   = errors.New("too many queued queries for tenant")
+	errQueueWaitExceeded = errors.New("query exceeded max queue wait")
+)
+
+// Options are the options of a query frontend.
+type Options struct {
+	// MaxConcurrentQueries is the max number of queries executed at once.
+	MaxConcurrentQueries int
+
+	// MaxQueuedPerTenant is the max number of queued queries per tenant.
+	MaxQueuedPerTenant int
+
+	// MaxQueueWait is the max time a query is queued, zero does not limit
+	// the time queued.
+	MaxQueueWait time.Duration
+
+	// BatchEvery is the number of interactive queries dequeued for every
+	// batch query.
+	BatchEvery int
+
+	// TenantHeader is the request header identifying the tenant of a query.
+	TenantHeader string
+
+	// Scope is the scope metrics are emitted to.
+	Scope tally.Scope
+}
+
+// Frontend queues queries per tenant and priority and admits them for
+// execution fairly once a slot is free.
+type Frontend struct {
+	sync.Mutex
+
+	maxConcurrent      int
+	maxQueuedPerTenant int
+	maxQueueWait       time.Duration
+	tenantHeader       string
+	nowFn              func() time.Time
+	running            int
+	queue              *fairQueue
+	metrics            frontendMetrics
+}
+
+type frontendMetrics struct {
+	running    tally.Gauge
+	queued     tally.Gauge
+	priorities [numPriorities]priorityMetrics
+}
+
+type priorityMetrics struct {
+	admitted  tally.Counter
+	rejected  tally.Counter
+	expired   tally.Counter
+	queueWait tally.Timer
+}
+
+func newFrontendMetrics(scope tally.Scope) frontendMetrics {
+	m := frontendMetrics{
+		running: scope.Gauge("running"),
+		queued:  scope.Gauge("queued"),
+	}
+	for i := range m.priorities {
+		priorityScope := scope.Tagged(map[string]string{
+			"priority": Priority(i).String(),
+		})
+		m.priorities[i] = priorityMetrics{
+			admitted:  priorityScope.Counter("admitted"),
+			rejected:  priorityScope.Counter("rejected"),
+			expired:   priorityScope.Counter("expired"),
+			queueWait: priorityScope.Timer("queue-wait"),
+		}
+	}
+	return m
+}
+
+// NewFrontend returns a new query frontend.
+func NewFrontend(opts Options) *Frontend {
+	scope := opts.Scope
+	if scope == nil {
+		scope = tally.NoopScope
+	}
+	tenantHeader := opts.TenantHeader
+	if tenantHeader == "" {
+		tenantHeader = DefaultTenantHeader
+	}
+	return &Frontend{
+		maxConcurrent:      opts.MaxConcurrentQueries,
+		maxQueuedPerTenant: opts.MaxQueuedPerTenant,
+		maxQueueWait:       opts.MaxQueueWait,
+		tenantHeader:       tenantHeader,
+		nowFn:              time.Now,
+		queue:              newFairQueue(opts.BatchEvery),
+		metrics:            newFrontendMetrics(scope),
+	}
+}
+
+// Acquire waits until a query of the tenant with the priority is admitted
+// for execution, the returned function must be called once the query is
+// executed to admit the next queued query.
+func (f *Frontend) Acquire(
+	ctx context.Context,
+	tenant string,
+	priority Priority,
+) (func(), error) {
+	m := f.metrics.priorities[priority]
+
+	f.Lock()
+	if f.running < f.maxConcurrent && f.queue.len() == 0 {
+		f.running++
+		f.updateGaugesWithLock()
+		f.Unlock()
+		m.admitted.Inc(1)
+		m.queueWait.Record(0)
+		return f.release, nil
+	}
+	if f.maxQueuedPerTenant > 0 && f.queue.tenantLen(tenant) >= f.maxQueuedPerTenant {
+		f.Unlock()
+		m.rejected.Inc(1)
+		return nil, errTenantQueueFull
+	}
+	query := &queuedQuery{
+		tenant:   tenant,
+		priority: priority,
+		readyCh:  make(chan struct{}),
+	}
+	f.queue.push(query)
+	f.updateGaugesWithLock()
+	f.Unlock()
+
+	var (
+		start   = f.nowFn()
+		expired <-chan time.Time
+	)
+	if f.maxQueueWait > 0 {
+		timer := time.NewTimer(f.maxQueueWait)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var err error
+	select {
+	case <-query.readyCh:
+		m.admitted.Inc(1)
+		m.queueWait.Record(f.nowFn().Sub(start))
+		return f.release, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-expired:
+		err = errQueueWaitExceeded
+	}
+
+	f.Lock()
+	removed := f.queue.remove(query)
+	f.updateGaugesWithLock()
+	f.Unlock()
+	if !removed {
+		// The query was admitted while giving up, pass its slot on.
+		f.release()
+	}
+	m.expired.Inc(1)
+	return nil, err
+}
+
+func (f *Frontend) release() {
+	f.Lock()
+	if next := f.queue.pop(); next != nil {
+		// Hand the slot to the next query rather than freeing it so that
+		// queries arriving meanwhile cannot jump the queue.
+		close(next.readyCh)
+	} else {
+		f.running--
+	}
+	f.updateGaugesWithLock()
+	f.Unlock()
+}
+
+func (f *Frontend) updateGaugesWithLock() {
+	f.metrics.running.Update(float64(f.running))
+	f.metrics.queued.Update(float64(f.queue.len()))
+}
+
+// Handler returns the handler that executes requests with the next handler
+// once they are admitted, rejecting them if the queue of their tenant is
+// full or they are not admitted in time.
+func (f *Frontend) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority, err := ParsePriority(r.Header.Get(PriorityHeader))
+		if err != nil {
+			handler.Error(w, err, http.StatusBadRequest)
+			return
+		}
+
+		tenant := r.Header.Get(f.tenantHeader)
+		if tenant == "" {
+			tenant = defaultTenant
+		}
+
+		release, err := f.Acquire(r.Context(), tenant, priority)
+		if err == errTenantQueueFull {
+			handler.Error(w, err, http.StatusTooManyRequests)
+			return
+		}
+		if err != nil {
+			handler.Error(w, err, http.StatusServiceUnavailable)
+			return
+		}
+
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
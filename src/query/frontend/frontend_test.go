@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestFrontendAdmitsQueuedQueriesFairly(t *testing.T) {
+	f := NewFrontend(Options{
+		MaxConcurrentQueries: 1,
+		MaxQueuedPerTenant:   10,
+		BatchEvery:           4,
+	})
+	ctx := context.Background()
+
+	release, err := f.Acquire(ctx, "storm", PriorityInteractive)
+	require.NoError(t, err)
+
+	admitted := make(chan string, 4)
+	acquire := func(tenant string) {
+		release, err := f.Acquire(ctx, tenant, PriorityInteractive)
+		require.NoError(t, err)
+		admitted <- tenant
+		release()
+	}
+	for i, tenant := range []string{"storm", "storm", "other"} {
+		go acquire(tenant)
+		// Wait for the query to be queued to enqueue in order.
+		queued := i + 1
+		require.True(t, waitFor(func() bool {
+			f.Lock()
+			defer f.Unlock()
+			return f.queue.len() == queued
+		}))
+	}
+
+	release()
+	var order []string
+	for i := 0; i < 3; i++ {
+		order = append(order, <-admitted)
+	}
+	assert.Equal(t, []string{"storm", "other", "storm"}, order)
+
+	f.Lock()
+	assert.Equal(t, 0, f.running)
+	f.Unlock()
+}
+
+func TestFrontendRejectsWhenTenantQueueFull(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	f := NewFrontend(Options{
+		MaxConcurrentQueries: 1,
+		MaxQueuedPerTenant:   1,
+		MaxQueueWait:         10 * time.Millisecond,
+		Scope:                scope,
+	})
+	ctx := context.Background()
+
+	release, err := f.Acquire(ctx, "a", PriorityInteractive)
+	require.NoError(t, err)
+	defer release()
+
+	// Queued until the max queue wait is exceeded.
+	errCh := make(chan error)
+	go func() {
+		_, err := f.Acquire(ctx, "a", PriorityInteractive)
+		errCh <- err
+	}()
+	require.True(t, waitFor(func() bool {
+		f.Lock()
+		defer f.Unlock()
+		return f.queue.len() == 1
+	}))
+
+	_, err = f.Acquire(ctx, "a", PriorityBatch)
+	assert.Equal(t, errTenantQueueFull, err)
+	assert.Equal(t, errQueueWaitExceeded, <-errCh)
+
+	f.Lock()
+	assert.Equal(t, 0, f.queue.len())
+	f.Unlock()
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["rejected+priority=batch"].Value())
+	assert.Equal(t, int64(1), counters["expired+priority=interactive"].Value())
+}
+
+func TestFrontendHandler(t *testing.T) {
+	f := NewFrontend(Options{
+		MaxConcurrentQueries: 1,
+		MaxQueuedPerTenant:   1,
+	})
+
+	var tenants []string
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants = append(tenants, r.Header.Get(DefaultTenantHeader))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(DefaultTenantHeader, "a")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []string{"a"}, tenants)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(PriorityHeader, "urgent")
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Requests cancelled while queued are not executed.
+	release, err := f.Acquire(context.Background(), "a", PriorityInteractive)
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, []string{"a"}, tenants)
+}
+
+func waitFor(fn func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if fn() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
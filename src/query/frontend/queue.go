@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package frontend
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority is the priority class of a query.
+type Priority int
+
+const (
+	// PriorityInteractive is the priority of queries a user is waiting on,
+	// such as dashboard panels, this is the default priority.
+	PriorityInteractive Priority = iota
+
+	// PriorityBatch is the priority of queries no user is waiting on, such
+	// as reports and exports.
+	PriorityBatch
+
+	numPriorities
+)
+
+var priorityNames = [numPriorities]string{
+	PriorityInteractive: "interactive",
+	PriorityBatch:       "batch",
+}
+
+// ParsePriority parses a priority, an empty string is the interactive
+// priority.
+func ParsePriority(str string) (Priority, error) {
+	if str == "" {
+		return PriorityInteractive, nil
+	}
+	for p, name := range priorityNames {
+		if strings.EqualFold(str, name) {
+			return Priority(p), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown query priority: %s, must be one of %s",
+		str, strings.Join(priorityNames[:], ", "))
+}
+
+func (p Priority) String() string {
+	if p < 0 || p >= numPriorities {
+		return "unknown"
+	}
+	return priorityNames[p]
+}
+
+type queuedQuery struct {
+	tenant   string
+	priority Priority
+	readyCh  chan struct{}
+}
+
+// tenantQueues are the queues of the tenants of a priority, tenants with
+// queued queries are dequeued from in round robin order so that a tenant
+// with many queued queries does not delay the queries of other tenants.
+type tenantQueues struct {
+	queues map[string][]*queuedQuery
+	order  []string
+	length int
+}
+
+func newTenantQueues() tenantQueues {
+	return tenantQueues{queues: make(map[string][]*queuedQuery)}
+}
+
+func (q *tenantQueues) push(query *queuedQuery) {
+	queue, ok := q.queues[query.tenant]
+	if !ok || len(queue) == 0 {
+		q.order = append(q.order, query.tenant)
+	}
+	q.queues[query.tenant] = append(queue, query)
+	q.length++
+}
+
+func (q *tenantQueues) pop() *queuedQuery {
+	if len(q.order) == 0 {
+		return nil
+	}
+
+	tenant := q.order[0]
+	q.order = q.order[1:]
+	queue := q.queues[tenant]
+	query := queue[0]
+	queue[0] = nil
+	queue = queue[1:]
+	if len(queue) == 0 {
+		delete(q.queues, tenant)
+	} else {
+		q.queues[tenant] = queue
+		q.order = append(q.order, tenant)
+	}
+	q.length--
+	return query
+}
+
+func (q *tenantQueues) remove(query *queuedQuery) bool {
+	queue := q.queues[query.tenant]
+	for i, queued := range queue {
+		if queued != query {
+			continue
+		}
+
+		queue = append(queue[:i], queue[i+1:]...)
+		q.length--
+		if len(queue) > 0 {
+			q.queues[query.tenant] = queue
+			return true
+		}
+
+		delete(q.queues, query.tenant)
+		for j, tenant := range q.order {
+			if tenant == query.tenant {
+				q.order = append(q.order[:j], q.order[j+1:]...)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// fairQueue queues queries per priority and tenant. Interactive queries
+// are dequeued before batch queries, except that one batch query is
+// dequeued for every batchEvery interactive queries so that batch queries
+// are not starved. The queue is not safe for concurrent use.
+type fairQueue struct {
+	priorities        [numPriorities]tenantQueues
+	batchEvery        int
+	interactiveStreak int
+}
+
+func newFairQueue(batchEvery int) *fairQueue {
+	q := &fairQueue{batchEvery: batchEvery}
+	for i := range q.priorities {
+		q.priorities[i] = newTenantQueues()
+	}
+	return q
+}
+
+func (q *fairQueue) push(query *queuedQuery) {
+	q.priorities[query.priority].push(query)
+}
+
+func (q *fairQueue) pop() *queuedQuery {
+	var (
+		interactive = &q.priorities[PriorityInteractive]
+		batch       = &q.priorities[PriorityBatch]
+	)
+	if interactive.length > 0 &&
+		(batch.length == 0 || q.interactiveStreak < q.batchEvery) {
+		q.interactiveStreak++
+		return interactive.pop()
+	}
+	q.interactiveStreak = 0
+	return batch.pop()
+}
+
+func (q *fairQueue) remove(query *queuedQuery) bool {
+	return q.priorities[query.priority].remove(query)
+}
+
+func (q *fairQueue) len() int {
+	var n int
+	for i := range q.priorities {
+		n += q.priorities[i].length
+	}
+	return n
+}
+
+func (q *fairQueue) tenantLen(tenant string) int {
+	var n int
+	for i := range q.priorities {
+		n += len(q.priorities[i].queues[tenant])
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package frontend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQuery(tenant string, priority Priority) *queuedQuery {
+	return &queuedQuery{
+		tenant:   tenant,
+		priority: priority,
+		readyCh:  make(chan struct{}),
+	}
+}
+
+func popTenants(q *fairQueue, n int) []string {
+	var tenants []string
+	for i := 0; i < n; i++ {
+		query := q.pop()
+		if query == nil {
+			break
+		}
+		tenants = append(tenants, query.tenant+"/"+query.priority.String())
+	}
+	return tenants
+}
+
+func TestFairQueueRoundRobinsTenants(t *testing.T) {
+	q := newFairQueue(4)
+	for i := 0; i < 3; i++ {
+		q.push(newTestQuery("storm", PriorityInteractive))
+	}
+	q.push(newTestQuery("a", PriorityInteractive))
+	q.push(newTestQuery("b", PriorityInteractive))
+
+	assert.Equal(t, 5, q.len())
+	assert.Equal(t, 3, q.tenantLen("storm"))
+	assert.Equal(t, []string{
+		"storm/interactive",
+		"a/interactive",
+		"b/interactive",
+		"storm/interactive",
+		"storm/interactive",
+	}, popTenants(q, 10))
+	assert.Equal(t, 0, q.len())
+	assert.Nil(t, q.pop())
+}
+
+func TestFairQueueDequeuesBatchEvery(t *testing.T) {
+	q := newFairQueue(2)
+	for i := 0; i < 5; i++ {
+		q.push(newTestQuery("a", PriorityInteractive))
+	}
+	q.push(newTestQuery("b", PriorityBatch))
+	q.push(newTestQuery("b", PriorityBatch))
+
+	assert.Equal(t, []string{
+		"a/interactive",
+		"a/interactive",
+		"b/batch",
+		"a/interactive",
+		"a/interactive",
+		"b/batch",
+		"a/interactive",
+	}, popTenants(q, 10))
+}
+
+func TestFairQueueRemove(t *testing.T) {
+	q := newFairQueue(4)
+	first := newTestQuery("a", PriorityInteractive)
+	second := newTestQuery("a", PriorityInteractive)
+	other := newTestQuery("b", PriorityInteractive)
+	q.push(first)
+	q.push(other)
+	q.push(second)
+
+	require.True(t, q.remove(other))
+	require.False(t, q.remove(other))
+	require.True(t, q.remove(first))
+	assert.Equal(t, 1, q.len())
+	assert.Equal(t, second, q.pop())
+	assert.Nil(t, q.pop())
+}
+
+func TestParsePriority(t *testing.T) {
+	p, err := ParsePriority("")
+	require.NoError(t, err)
+	assert.Equal(t, PriorityInteractive, p)
+
+	p, err = ParsePriority("Batch")
+	require.NoError(t, err)
+	assert.Equal(t, PriorityBatch, p)
+
+	_, err = ParsePriority("urgent")
+	require.Error(t, err)
+}
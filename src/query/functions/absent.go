@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
+)
+
+const (
+	// AbsentType returns a series with the value 1 at each step the input
+	// has no values, and no values at steps the input has any values
+	AbsentType = "absent"
+
+	// AbsentOverTimeType returns a series with the value 1 at each step the
+	// input has no values within the range preceding the step, values before
+	// the start of the query are not considered
+	AbsentOverTimeType = "absent_over_time"
+)
+
+// AbsentOp stores required properties for absent and absent_over_time
+type AbsentOp struct {
+	opType string
+	tags   models.Tags
+	window time.Duration
+}
+
+// NewAbsentOp creates a new absent op based on the type, the matchers of the
+// selector the series are tagged from and the range for absent_over_time
+func NewAbsentOp(opType string, matchers models.Matchers, window time.Duration) (AbsentOp, error) {
+	switch opType {
+	case AbsentType:
+		window = 0
+	case AbsentOverTimeType:
+		if window <= 0 {
+			return AbsentOp{}, fmt.Errorf("%s requires a range", opType)
+		}
+	default:
+		return AbsentOp{}, fmt.Errorf("unknown absent type: %s", opType)
+	}
+
+	return AbsentOp{
+		opType: opType,
+		tags:   absentTags(matchers),
+		window: window,
+	}, nil
+}
+
+// absentTags returns the tags of the series returned by absent, which are
+// those the selector matches exactly other than the metric name
+func absentTags(matchers models.Matchers) models.Tags {
+	var (
+		tags    = make(models.Tags)
+		removed = make(map[string]struct{})
+	)
+
+	for _, m := range matchers {
+		if m.Name == models.MetricName {
+			continue
+		}
+
+		if _, ok := tags[m.Name]; ok || m.Type != models.MatchEqual {
+			removed[m.Name] = struct{}{}
+			continue
+		}
+
+		tags[m.Name] = m.Value
+	}
+
+	for name := range removed {
+		delete(tags, name)
+	}
+
+	return tags
+}
+
+// OpType for the operator
+func (o AbsentOp) OpType() string {
+	return o.opType
+}
+
+// String representation
+func (o AbsentOp) String() string {
+	if o.opType == AbsentOverTimeType {
+		return fmt.Sprintf("type: %s, range: %v, tags: %v", o.OpType(), o.window, o.tags)
+	}
+
+	return fmt.Sprintf("type: %s, tags: %v", o.OpType(), o.tags)
+}
+
+// Node creates an execution node
+func (o AbsentOp) Node(controller *transform.Controller) transform.OpNode {
+	return &AbsentNode{op: o, controller: controller}
+}
+
+// AbsentNode is an execution node
+type AbsentNode struct {
+	op         AbsentOp
+	controller *transform.Controller
+}
+
+// Process the block
+func (c *AbsentNode) Process(ID parser.NodeID, b block.Block) error {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return err
+	}
+
+	defer stepIter.Close()
+	meta := block.SeriesMeta{
+		Name: c.op.opType,
+		Tags: c.op.tags,
+	}
+
+	blockMeta := block.Metadata{Bounds: stepIter.Meta().Bounds}
+	builder, err := c.controller.BlockBuilder(blockMeta, []block.SeriesMeta{meta})
+	if err != nil {
+		return err
+	}
+
+	if len(stepIter.SeriesMeta()) == 0 {
+		return c.processEmpty(builder, blockMeta.Bounds)
+	}
+
+	if err := builder.AddCols(stepIter.StepCount()); err != nil {
+		return err
+	}
+
+	var (
+		lastSeen time.Time
+		seen     bool
+	)
+
+	for index := 0; stepIter.Next(); index++ {
+		step, err := stepIter.Current()
+		if err != nil {
+			return err
+		}
+
+		present := !allNaNs(step.Values())
+		if present {
+			lastSeen = step.Time()
+			seen = true
+		} else if seen && c.op.window > 0 {
+			present = step.Time().Sub(lastSeen) < c.op.window
+		}
+
+		value := math.NaN()
+		if !present {
+			value = 1
+		}
+
+		if err := builder.AppendValue(index, value); err != nil {
+			return err
+		}
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return c.controller.Process(nextBlock)
+}
+
+// processEmpty processes a block without any series, which are absent at
+// every step
+func (c *AbsentNode) processEmpty(builder block.Builder, bounds block.Bounds) error {
+	steps := bounds.Steps()
+	if err := builder.AddCols(steps); err != nil {
+		return err
+	}
+
+	for index := 0; index < steps; index++ {
+		if err := builder.AppendValue(index, 1); err != nil {
+			return err
+		}
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return c.controller.Process(nextBlock)
+}
+
+func allNaNs(values []float64) bool {
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			return false
+		}
+	}
+
+	return true
+}
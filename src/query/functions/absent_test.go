@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func processAbsent(
+	t *testing.T,
+	opType string,
+	window time.Duration,
+	b block.Block,
+) [][]float64 {
+	op, err := NewAbsentOp(opType, nil, window)
+	require.NoError(t, err)
+
+	c, sink := executor.NewControllerWithSink(parser.NodeID("1"))
+	err = op.Node(c).Process(parser.NodeID("0"), b)
+	require.NoError(t, err)
+	return sink.Values
+}
+
+func TestAbsent(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		values   [][]float64
+		expected []float64
+	}{
+		{
+			name:     "all values",
+			values:   [][]float64{{0, 1, 2, 3, 4}, {5, 6, 7, 8, 9}},
+			expected: []float64{nan, nan, nan, nan, nan},
+		},
+		{
+			name:     "some values",
+			values:   [][]float64{{nan, 1, nan, 3, nan}, {nan, nan, nan, 4, nan}},
+			expected: []float64{1, nan, 1, nan, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, bounds := test.GenerateValuesAndBounds(tt.values, nil)
+			b := test.NewBlockFromValues(bounds, values)
+			actual := processAbsent(t, AbsentType, 0, b)
+			test.EqualsWithNans(t, [][]float64{tt.expected}, actual)
+		})
+	}
+}
+
+func TestAbsentWithoutSeries(t *testing.T) {
+	now := time.Now()
+	bounds := block.Bounds{Start: now, End: now.Add(2 * time.Minute), StepSize: time.Minute}
+	b := block.NewColumnBlockBuilder(block.Metadata{Bounds: bounds}, nil).Build()
+	actual := processAbsent(t, AbsentType, 0, b)
+	assert.Equal(t, [][]float64{{1, 1, 1}}, actual)
+}
+
+func TestAbsentOverTime(t *testing.T) {
+	nan := math.NaN()
+	values, bounds := test.GenerateValuesAndBounds([][]float64{
+		{nan, 1, nan, nan, nan},
+		{nan, nan, nan, nan, nan},
+	}, nil)
+	b := test.NewBlockFromValues(bounds, values)
+	actual := processAbsent(t, AbsentOverTimeType, 2*time.Minute, b)
+	test.EqualsWithNans(t, [][]float64{{1, nan, nan, 1, 1}}, actual)
+
+	_, err := NewAbsentOp(AbsentOverTimeType, nil, 0)
+	assert.Error(t, err)
+}
+
+func TestAbsentTags(t *testing.T) {
+	var matchers models.Matchers
+	for _, m := range []struct {
+		matchType models.MatchType
+		name      string
+		value     string
+	}{
+		{models.MatchEqual, models.MetricName, "foo"},
+		{models.MatchEqual, "a", "b"},
+		{models.MatchRegexp, "c", "d"},
+		{models.MatchEqual, "e", "f"},
+		{models.MatchEqual, "e", "g"},
+		{models.MatchEqual, "h", "i"},
+		{models.MatchNotEqual, "h", "j"},
+		{models.MatchEqual, "k", "l"},
+	} {
+		matcher, err := models.NewMatcher(m.matchType, m.name, m.value)
+		require.NoError(t, err)
+		matchers = append(matchers, matcher)
+	}
+
+	op, err := NewAbsentOp(AbsentType, matchers, 0)
+	require.NoError(t, err)
+	assert.Equal(t, models.Tags{"a": "b", "k": "l"}, op.tags)
+}
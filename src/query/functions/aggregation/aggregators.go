@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"math"
+	"sort"
+)
+
+// aggregator aggregates the values of a group at a step
+type aggregator interface {
+	add(value float64)
+	value() float64
+	reset()
+}
+
+// varianceAggregator calculates the population variance using Welford's
+// online algorithm, so values are not held in memory
+type varianceAggregator struct {
+	count float64
+	mean  float64
+	m2    float64
+	sqrt  bool
+}
+
+func newStddevAggregator(_ NodeParams) aggregator {
+	return &varianceAggregator{sqrt: true}
+}
+
+func newStdvarAggregator(_ NodeParams) aggregator {
+	return &varianceAggregator{}
+}
+
+func (a *varianceAggregator) add(value float64) {
+	a.count++
+	delta := value - a.mean
+	a.mean += delta / a.count
+	a.m2 += delta * (value - a.mean)
+}
+
+func (a *varianceAggregator) value() float64 {
+	if a.count == 0 {
+		return math.NaN()
+	}
+
+	variance := a.m2 / a.count
+	if a.sqrt {
+		return math.Sqrt(variance)
+	}
+
+	return variance
+}
+
+func (a *varianceAggregator) reset() {
+	*a = varianceAggregator{sqrt: a.sqrt}
+}
+
+// quantileAggregator calculates a quantile, the values of a group at a step
+// are held in a buffer which is reused across steps
+type quantileAggregator struct {
+	q      float64
+	values []float64
+}
+
+func newQuantileAggregator(params NodeParams) aggregator {
+	return &quantileAggregator{q: params.Parameter}
+}
+
+func (a *quantileAggregator) add(value float64) {
+	a.values = append(a.values, value)
+}
+
+func (a *quantileAggregator) value() float64 {
+	return quantile(a.q, a.values)
+}
+
+func (a *quantileAggregator) reset() {
+	a.values = a.values[:0]
+}
+
+// quantile calculates the q-quantile of the values using linear
+// interpolation between the closest ranks, the values are sorted in place
+func quantile(q float64, values []float64) float64 {
+	switch {
+	case len(values) == 0 || math.IsNaN(q):
+		return math.NaN()
+	case q < 0:
+		return math.Inf(-1)
+	case q > 1:
+		return math.Inf(1)
+	}
+
+	sort.Float64s(values)
+	var (
+		n      = float64(len(values))
+		rank   = q * (n - 1)
+		lower  = math.Max(0, math.Floor(rank))
+		upper  = math.Min(n-1, lower+1)
+		weight = rank - math.Floor(rank)
+	)
+
+	return values[int(lower)]*(1-weight) + values[int(upper)]*weight
+}
+
+// groupAggregator returns 1 if the group has any values
+type groupAggregator struct {
+	any bool
+}
+
+func newGroupAggregator(_ NodeParams) aggregator {
+	return &groupAggregator{}
+}
+
+func (a *groupAggregator) add(_ float64) {
+	a.any = true
+}
+
+func (a *groupAggregator) value() float64 {
+	if !a.any {
+		return math.NaN()
+	}
+
+	return 1
+}
+
+func (a *groupAggregator) reset() {
+	a.any = false
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
+)
+
+const (
+	// StandardDeviationType calculates the population standard deviation over dimensions
+	StandardDeviationType = "stddev"
+
+	// StandardVarianceType calculates the population variance over dimensions
+	StandardVarianceType = "stdvar"
+
+	// QuantileType calculates the φ-quantile (0 ≤ φ ≤ 1) over dimensions
+	QuantileType = "quantile"
+
+	// GroupType returns 1 for each group which has any values
+	GroupType = "group"
+)
+
+// NodeParams contains additional parameters required for aggregation ops
+type NodeParams struct {
+	// MatchingTags are the tags series are grouped by, or grouped without
+	MatchingTags []string
+	// Without groups series by all tags other than the matching tags
+	Without bool
+	// Parameter is the scalar parameter of the aggregation, such as φ
+	Parameter float64
+	// StringParameter is the string parameter of the aggregation, such as
+	// the tag name of count_values
+	StringParameter string
+}
+
+// AggregationOp stores required properties for aggregation ops
+type AggregationOp struct {
+	opType       string
+	params       NodeParams
+	aggregatorFn func(params NodeParams) aggregator
+}
+
+// NewAggregationOp creates a new aggregation op based on the type
+func NewAggregationOp(opType string, params NodeParams) (transform.Params, error) {
+	var aggregatorFn func(params NodeParams) aggregator
+	switch opType {
+	case StandardDeviationType:
+		aggregatorFn = newStddevAggregator
+	case StandardVarianceType:
+		aggregatorFn = newStdvarAggregator
+	case QuantileType:
+		aggregatorFn = newQuantileAggregator
+	case GroupType:
+		aggregatorFn = newGroupAggregator
+	case CountValuesType:
+		return newCountValuesOp(params)
+	default:
+		return nil, fmt.Errorf("operator not supported: %s", opType)
+	}
+
+	return AggregationOp{
+		opType:       opType,
+		params:       params,
+		aggregatorFn: aggregatorFn,
+	}, nil
+}
+
+// OpType for the operator
+func (o AggregationOp) OpType() string {
+	return o.opType
+}
+
+// String representation
+func (o AggregationOp) String() string {
+	return fmt.Sprintf("type: %s, matching: %v, without: %t",
+		o.OpType(), o.params.MatchingTags, o.params.Without)
+}
+
+// Node creates an execution node
+func (o AggregationOp) Node(controller *transform.Controller) transform.OpNode {
+	return &AggregationNode{op: o, controller: controller}
+}
+
+// AggregationNode is an execution node
+type AggregationNode struct {
+	op         AggregationOp
+	controller *transform.Controller
+}
+
+// Process the block, the values of each group are aggregated one step at a
+// time so only a step of the block is held in memory
+func (n *AggregationNode) Process(ID parser.NodeID, b block.Block) error {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return err
+	}
+
+	defer stepIter.Close()
+	groups := groupSeries(stepIter.SeriesMeta(), n.op.params)
+	metas := make([]block.SeriesMeta, len(groups))
+	aggregators := make([]aggregator, len(groups))
+	for i, group := range groups {
+		metas[i] = block.SeriesMeta{Name: n.op.opType, Tags: group.tags}
+		aggregators[i] = n.op.aggregatorFn(n.op.params)
+	}
+
+	blockMeta := block.Metadata{Bounds: stepIter.Meta().Bounds}
+	builder, err := n.controller.BlockBuilder(blockMeta, metas)
+	if err != nil {
+		return err
+	}
+
+	if err := builder.AddCols(stepIter.StepCount()); err != nil {
+		return err
+	}
+
+	for index := 0; stepIter.Next(); index++ {
+		step, err := stepIter.Current()
+		if err != nil {
+			return err
+		}
+
+		values := step.Values()
+		for i, group := range groups {
+			agg := aggregators[i]
+			agg.reset()
+			for _, idx := range group.indices {
+				if !math.IsNaN(values[idx]) {
+					agg.add(values[idx])
+				}
+			}
+
+			if err := builder.AppendValue(index, agg.value()); err != nil {
+				return err
+			}
+		}
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return n.controller.Process(nextBlock)
+}
+
+// seriesGroup is a group of series which are aggregated together
+type seriesGroup struct {
+	tags    models.Tags
+	indices []int
+}
+
+// groupSeries groups series by their tags, groups are ordered by the first
+// series in each group
+func groupSeries(metas []block.SeriesMeta, params NodeParams) []seriesGroup {
+	var (
+		groups []seriesGroup
+		byID   = make(map[uint64]int)
+	)
+
+	for i, meta := range metas {
+		var id uint64
+		if params.Without {
+			id = meta.Tags.IDWithExcludes(params.MatchingTags...)
+		} else {
+			id = meta.Tags.IDWithKeys(params.MatchingTags...)
+		}
+
+		idx, ok := byID[id]
+		if !ok {
+			idx = len(groups)
+			byID[id] = idx
+			groups = append(groups, seriesGroup{
+				tags: groupTags(meta.Tags, params),
+			})
+		}
+
+		groups[idx].indices = append(groups[idx].indices, i)
+	}
+
+	return groups
+}
+
+// groupTags returns the tags of the group a series belongs to
+func groupTags(tags models.Tags, params NodeParams) models.Tags {
+	if params.Without {
+		grouped := tags.WithoutName()
+		for _, name := range params.MatchingTags {
+			delete(grouped, name)
+		}
+
+		return grouped
+	}
+
+	grouped := make(models.Tags, len(params.MatchingTags))
+	for _, name := range params.MatchingTags {
+		if value, ok := tags[name]; ok {
+			grouped[name] = value
+		}
+	}
+
+	return grouped
+}
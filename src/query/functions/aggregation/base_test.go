@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	nan = math.NaN()
+
+	seriesTags = []models.Tags{
+		{models.MetricName: "requests", "service": "a", "instance": "1"},
+		{models.MetricName: "requests", "service": "a", "instance": "2"},
+		{models.MetricName: "requests", "service": "b", "instance": "1"},
+		{models.MetricName: "requests", "service": "b", "instance": "2"},
+	}
+
+	seriesValues = [][]float64{
+		{1, 2, nan, 4},
+		{3, 2, nan, 8},
+		{5, 6, 7, nan},
+		{5, 10, nan, nan},
+	}
+)
+
+func newTestBlock(tags []models.Tags, values [][]float64) block.Block {
+	now := time.Now()
+	bounds := block.Bounds{
+		Start:    now,
+		End:      now.Add(time.Duration(len(values[0])-1) * time.Minute),
+		StepSize: time.Minute,
+	}
+
+	metas := make([]block.SeriesMeta, len(tags))
+	for i, t := range tags {
+		metas[i] = block.SeriesMeta{Name: t[models.MetricName], Tags: t}
+	}
+
+	builder := block.NewColumnBlockBuilder(block.Metadata{Bounds: bounds}, metas)
+	builder.AddCols(len(values[0]))
+	for _, series := range values {
+		for idx, value := range series {
+			builder.AppendValue(idx, value)
+		}
+	}
+
+	return builder.Build()
+}
+
+func processAggregation(
+	t *testing.T,
+	opType string,
+	params NodeParams,
+) *executor.SinkNode {
+	op, err := NewAggregationOp(opType, params)
+	require.NoError(t, err)
+
+	c, sink := executor.NewControllerWithSink(parser.NodeID("1"))
+	node := op.Node(c)
+	err = node.Process(parser.NodeID("0"), newTestBlock(seriesTags, seriesValues))
+	require.NoError(t, err)
+	return sink
+}
+
+func TestAggregationGrouping(t *testing.T) {
+	sink := processAggregation(t, GroupType, NodeParams{
+		MatchingTags: []string{"service"},
+	})
+	require.Len(t, sink.Metas, 2)
+	assert.Equal(t, models.Tags{"service": "a"}, sink.Metas[0].Tags)
+	assert.Equal(t, models.Tags{"service": "b"}, sink.Metas[1].Tags)
+	test.EqualsWithNans(t, [][]float64{{1, 1, nan, 1}, {1, 1, 1, nan}}, sink.Values)
+
+	sink = processAggregation(t, GroupType, NodeParams{
+		MatchingTags: []string{"service"},
+		Without:      true,
+	})
+	require.Len(t, sink.Metas, 2)
+	assert.Equal(t, models.Tags{"instance": "1"}, sink.Metas[0].Tags)
+	assert.Equal(t, models.Tags{"instance": "2"}, sink.Metas[1].Tags)
+
+	sink = processAggregation(t, GroupType, NodeParams{})
+	require.Len(t, sink.Metas, 1)
+	assert.Equal(t, models.Tags{}, sink.Metas[0].Tags)
+	test.EqualsWithNans(t, [][]float64{{1, 1, 1, 1}}, sink.Values)
+}
+
+func TestStandardDeviationAndVariance(t *testing.T) {
+	params := NodeParams{MatchingTags: []string{"service"}}
+	sink := processAggregation(t, StandardVarianceType, params)
+	test.EqualsWithNans(t, [][]float64{{1, 0, nan, 4}, {0, 4, 0, nan}}, sink.Values)
+
+	sink = processAggregation(t, StandardDeviationType, params)
+	test.EqualsWithNans(t, [][]float64{{1, 0, nan, 2}, {0, 2, 0, nan}}, sink.Values)
+}
+
+func TestQuantile(t *testing.T) {
+	sink := processAggregation(t, QuantileType, NodeParams{Parameter: 0.5})
+	test.EqualsWithNans(t, [][]float64{{4, 4, 7, 6}}, sink.Values)
+
+	sink = processAggregation(t, QuantileType, NodeParams{Parameter: 0.25})
+	test.EqualsWithNans(t, [][]float64{{2.5, 2, 7, 5}}, sink.Values)
+
+	sink = processAggregation(t, QuantileType, NodeParams{Parameter: 2})
+	test.EqualsWithNans(t, [][]float64{{
+		math.Inf(1), math.Inf(1), math.Inf(1), math.Inf(1),
+	}}, sink.Values)
+}
+
+func TestUnknownAggregation(t *testing.T) {
+	_, err := NewAggregationOp("unknown", NodeParams{})
+	assert.Error(t, err)
+}
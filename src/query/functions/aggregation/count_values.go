@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
+)
+
+// CountValuesType counts the number of series with the same value, each
+// value is returned as a series tagged with the value
+const CountValuesType = "count_values"
+
+// CountValuesOp stores required properties for count_values
+type CountValuesOp struct {
+	params NodeParams
+}
+
+func newCountValuesOp(params NodeParams) (transform.Params, error) {
+	if params.StringParameter == "" {
+		return nil, fmt.Errorf("%s requires a tag name", CountValuesType)
+	}
+
+	return CountValuesOp{params: params}, nil
+}
+
+// OpType for the operator
+func (o CountValuesOp) OpType() string {
+	return CountValuesType
+}
+
+// String representation
+func (o CountValuesOp) String() string {
+	return fmt.Sprintf("type: %s, tag: %s, matching: %v, without: %t",
+		o.OpType(), o.params.StringParameter, o.params.MatchingTags, o.params.Without)
+}
+
+// Node creates an execution node
+func (o CountValuesOp) Node(controller *transform.Controller) transform.OpNode {
+	return &CountValuesNode{op: o, controller: controller}
+}
+
+// CountValuesNode is an execution node
+type CountValuesNode struct {
+	op         CountValuesOp
+	controller *transform.Controller
+}
+
+// countedSeries is a series of counts of a value within a group
+type countedSeries struct {
+	tags   models.Tags
+	counts []float64
+}
+
+// Process the block, the series returned are only known once every step
+// has been counted
+func (n *CountValuesNode) Process(ID parser.NodeID, b block.Block) error {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return err
+	}
+
+	defer stepIter.Close()
+	var (
+		tagName = n.op.params.StringParameter
+		steps   = stepIter.StepCount()
+		groups  = groupSeries(stepIter.SeriesMeta(), n.op.params)
+		series  []countedSeries
+		// Index of the series counting each value of each group
+		byValue = make([]map[string]int, len(groups))
+	)
+
+	for i := range byValue {
+		byValue[i] = make(map[string]int)
+	}
+
+	for index := 0; stepIter.Next(); index++ {
+		step, err := stepIter.Current()
+		if err != nil {
+			return err
+		}
+
+		values := step.Values()
+		for i, group := range groups {
+			for _, idx := range group.indices {
+				if math.IsNaN(values[idx]) {
+					continue
+				}
+
+				value := strconv.FormatFloat(values[idx], 'f', -1, 64)
+				seriesIdx, ok := byValue[i][value]
+				if !ok {
+					seriesIdx = len(series)
+					byValue[i][value] = seriesIdx
+					series = append(series, newCountedSeries(group.tags, tagName, value, steps))
+				}
+
+				counts := series[seriesIdx].counts
+				if math.IsNaN(counts[index]) {
+					counts[index] = 0
+				}
+
+				counts[index]++
+			}
+		}
+	}
+
+	metas := make([]block.SeriesMeta, len(series))
+	for i, s := range series {
+		metas[i] = block.SeriesMeta{Name: CountValuesType, Tags: s.tags}
+	}
+
+	blockMeta := block.Metadata{Bounds: stepIter.Meta().Bounds}
+	builder, err := n.controller.BlockBuilder(blockMeta, metas)
+	if err != nil {
+		return err
+	}
+
+	if err := builder.AddCols(steps); err != nil {
+		return err
+	}
+
+	for index := 0; index < steps; index++ {
+		for _, s := range series {
+			if err := builder.AppendValue(index, s.counts[index]); err != nil {
+				return err
+			}
+		}
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return n.controller.Process(nextBlock)
+}
+
+func newCountedSeries(groupTags models.Tags, tagName, value string, steps int) countedSeries {
+	tags := make(models.Tags, len(groupTags)+1)
+	for k, v := range groupTags {
+		tags[k] = v
+	}
+
+	tags[tagName] = value
+	counts := make([]float64, steps)
+	for i := range counts {
+		counts[i] = math.NaN()
+	}
+
+	return countedSeries{tags: tags, counts: counts}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountValues(t *testing.T) {
+	sink := processAggregation(t, CountValuesType, NodeParams{
+		MatchingTags:    []string{"service"},
+		StringParameter: "value",
+	})
+
+	expectedTags := []models.Tags{
+		{"service": "a", "value": "1"},
+		{"service": "a", "value": "3"},
+		{"service": "b", "value": "5"},
+		{"service": "a", "value": "2"},
+		{"service": "b", "value": "6"},
+		{"service": "b", "value": "10"},
+		{"service": "b", "value": "7"},
+		{"service": "a", "value": "4"},
+		{"service": "a", "value": "8"},
+	}
+
+	require.Len(t, sink.Metas, len(expectedTags))
+	for i, meta := range sink.Metas {
+		assert.Equal(t, expectedTags[i], meta.Tags)
+	}
+
+	expected := [][]float64{
+		{1, nan, nan, nan},
+		{1, nan, nan, nan},
+		{2, nan, nan, nan},
+		{nan, 2, nan, nan},
+		{nan, 1, nan, nan},
+		{nan, 1, nan, nan},
+		{nan, nan, 1, nan},
+		{nan, nan, nan, 1},
+		{nan, nan, nan, 1},
+	}
+	test.EqualsWithNans(t, expected, sink.Values)
+}
+
+func TestCountValuesRequiresTag(t *testing.T) {
+	_, err := NewAggregationOp(CountValuesType, NodeParams{})
+	assert.Error(t, err)
+}
@@ -41,6 +41,11 @@ func (o CountOp) OpType() string {
 	return CountType
 }
 
+// CacheKey returns the key to cache results of the operator by
+func (o CountOp) CacheKey() string {
+	return CountType
+}
+
 // String representation
 func (o CountOp) String() string {
 	return fmt.Sprintf("type: %s", o.OpType())
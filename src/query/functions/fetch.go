@@ -40,6 +40,10 @@ type FetchOp struct {
 	Range    time.Duration
 	Offset   time.Duration
 	Matchers models.Matchers
+	// TagRewrites are label_replace rewrites pushed down into the fetch.
+	TagRewrites []storage.TagRewrite
+	// TagProjection if set are the only tags required by the query.
+	TagProjection []string
 }
 
 // FetchNode is the execution node
@@ -49,6 +53,10 @@ type FetchNode struct {
 	storage    storage.Storage
 	timespec   transform.TimeSpec
 	debug      bool
+	stats      *models.QueryStats
+	decimation models.Decimation
+	enricher   storage.TagEnricher
+	enrichment []string
 }
 
 // OpType for the operator
@@ -58,12 +66,23 @@ func (o FetchOp) OpType() string {
 
 // String representation
 func (o FetchOp) String() string {
-	return fmt.Sprintf("type: %s. name: %s, range: %v, offset: %v, matchers: %v", o.OpType(), o.Name, o.Range, o.Offset, o.Matchers)
+	return fmt.Sprintf("type: %s. name: %s, range: %v, offset: %v, matchers: %v, rewrites: %v, projection: %v",
+		o.OpType(), o.Name, o.Range, o.Offset, o.Matchers, o.TagRewrites, o.TagProjection)
 }
 
 // Node creates an execution node
 func (o FetchOp) Node(controller *transform.Controller, storage storage.Storage, options transform.Options) parser.Source {
-	return &FetchNode{op: o, controller: controller, storage: storage, timespec: options.TimeSpec, debug: options.Debug}
+	return &FetchNode{
+		op:         o,
+		controller: controller,
+		storage:    storage,
+		timespec:   options.TimeSpec,
+		debug:      options.Debug,
+		stats:      options.Stats,
+		decimation: options.Decimation,
+		enricher:   options.Enricher,
+		enrichment: options.Enrichment,
+	}
 }
 
 // Execute runs the fetch node operation
@@ -76,7 +95,14 @@ func (n *FetchNode) Execute(ctx context.Context) error {
 		End:         endTime,
 		TagMatchers: n.op.Matchers,
 		Interval:    timeSpec.Step,
-	}, &storage.FetchOptions{})
+		Decimation:  n.decimation,
+	}, &storage.FetchOptions{
+		Stats:         n.stats,
+		TagProjection: n.op.TagProjection,
+		TagRewrites:   n.op.TagRewrites,
+		Enricher:      n.enricher,
+		Enrichment:    n.enrichment,
+	})
 	if err != nil {
 		return err
 	}
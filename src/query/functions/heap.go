@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"container/heap"
+	"math"
+)
+
+// valueAndIndex is the value of a series at a step and the index of the series.
+type valueAndIndex struct {
+	value float64
+	index int
+}
+
+// lessFn orders values in a heap, the least value is at the root.
+type lessFn func(a, b valueAndIndex) bool
+
+// ascending orders values from smallest to largest with NaNs last, ties are
+// ordered by series index.
+func ascending(a, b valueAndIndex) bool {
+	if math.IsNaN(a.value) || math.IsNaN(b.value) {
+		return !math.IsNaN(a.value) || (math.IsNaN(b.value) && a.index < b.index)
+	}
+	if a.value == b.value {
+		return a.index < b.index
+	}
+	return a.value < b.value
+}
+
+// descending orders values from largest to smallest with NaNs last, ties are
+// ordered by series index.
+func descending(a, b valueAndIndex) bool {
+	if math.IsNaN(a.value) || math.IsNaN(b.value) {
+		return ascending(a, b)
+	}
+	if a.value == b.value {
+		return a.index < b.index
+	}
+	return a.value > b.value
+}
+
+// reverse reverses an ordering so the root of the heap is the greatest value.
+func reverse(less lessFn) lessFn {
+	return func(a, b valueAndIndex) bool {
+		return less(b, a)
+	}
+}
+
+// valueHeap is a heap of values.
+type valueHeap struct {
+	values []valueAndIndex
+	less   lessFn
+}
+
+func (h *valueHeap) Len() int           { return len(h.values) }
+func (h *valueHeap) Less(i, j int) bool { return h.less(h.values[i], h.values[j]) }
+func (h *valueHeap) Swap(i, j int)      { h.values[i], h.values[j] = h.values[j], h.values[i] }
+
+func (h *valueHeap) Push(x interface{}) {
+	h.values = append(h.values, x.(valueAndIndex))
+}
+
+func (h *valueHeap) Pop() interface{} {
+	n := len(h.values) - 1
+	v := h.values[n]
+	h.values = h.values[:n]
+	return v
+}
+
+// boundedHeap keeps the first k values by an ordering out of all values
+// offered to it without holding more than k values.
+type boundedHeap struct {
+	heap valueHeap
+	k    int
+}
+
+// newBoundedHeap returns a heap which keeps the first k values by the ordering.
+func newBoundedHeap(k int, less lessFn) *boundedHeap {
+	return &boundedHeap{
+		// The root of the heap is the last of the values kept, so that it
+		// can be replaced by a value earlier in the ordering.
+		heap: valueHeap{values: make([]valueAndIndex, 0, k), less: reverse(less)},
+		k:    k,
+	}
+}
+
+// add offers a value to the heap.
+func (h *boundedHeap) add(v valueAndIndex) {
+	if h.k <= 0 {
+		return
+	}
+	if h.heap.Len() < h.k {
+		heap.Push(&h.heap, v)
+		return
+	}
+	if h.heap.less(h.heap.values[0], v) {
+		h.heap.values[0] = v
+		heap.Fix(&h.heap, 0)
+	}
+}
+
+// reset removes all values from the heap.
+func (h *boundedHeap) reset() {
+	h.heap.values = h.heap.values[:0]
+}
+
+// values returns the values kept, in no particular order.
+func (h *boundedHeap) values() []valueAndIndex {
+	return h.heap.values
+}
+
+// sorted returns all values in the ordering using a heap sort.
+func sorted(values []valueAndIndex, less lessFn) []valueAndIndex {
+	h := &valueHeap{values: values, less: less}
+	heap.Init(h)
+	result := make([]valueAndIndex, 0, len(values))
+	for h.Len() > 0 {
+		result = append(result, heap.Pop(h).(valueAndIndex))
+	}
+	return result
+}
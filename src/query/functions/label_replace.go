@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/storage"
+)
+
+// LabelReplaceType rewrites a tag of each series using a regular expression
+// matched against the value of another tag
+const LabelReplaceType = "label_replace"
+
+// LabelReplaceOp stores required properties for label_replace
+type LabelReplaceOp struct {
+	rewrite storage.TagRewrite
+}
+
+// NewLabelReplaceOp creates a new label_replace op from the destination tag,
+// replacement, source tag and regular expression arguments
+func NewLabelReplaceOp(args []interface{}) (LabelReplaceOp, error) {
+	if len(args) != 4 {
+		return LabelReplaceOp{}, fmt.Errorf("invalid number of args for %s: %d", LabelReplaceType, len(args))
+	}
+
+	strArgs := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(string)
+		if !ok {
+			return LabelReplaceOp{}, fmt.Errorf("unable to cast to string argument: %v", arg)
+		}
+
+		strArgs[i] = str
+	}
+
+	rewrite, err := storage.NewTagRewrite(strArgs[0], strArgs[1], strArgs[2], strArgs[3])
+	if err != nil {
+		return LabelReplaceOp{}, err
+	}
+
+	return LabelReplaceOp{rewrite: rewrite}, nil
+}
+
+// OpType for the operator
+func (o LabelReplaceOp) OpType() string {
+	return LabelReplaceType
+}
+
+// Rewrite returns the tag rewrite of the operator, used to push the
+// rewrite down into the fetch of the series it is applied to
+func (o LabelReplaceOp) Rewrite() storage.TagRewrite {
+	return o.rewrite
+}
+
+// String representation
+func (o LabelReplaceOp) String() string {
+	return fmt.Sprintf("type: %s, %s", o.OpType(), o.rewrite.String())
+}
+
+// Node creates an execution node
+func (o LabelReplaceOp) Node(controller *transform.Controller) transform.OpNode {
+	return &LabelReplaceNode{op: o, controller: controller}
+}
+
+// LabelReplaceNode is an execution node
+type LabelReplaceNode struct {
+	op         LabelReplaceOp
+	controller *transform.Controller
+}
+
+// Process the block, values are passed through unchanged with the tags of
+// each series rewritten
+func (c *LabelReplaceNode) Process(ID parser.NodeID, b block.Block) error {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return err
+	}
+
+	defer stepIter.Close()
+	seriesMeta := stepIter.SeriesMeta()
+	rewrittenMeta := make([]block.SeriesMeta, len(seriesMeta))
+	for i, meta := range seriesMeta {
+		rewrittenMeta[i] = block.SeriesMeta{
+			Name: meta.Name,
+			Tags: c.op.rewrite.Rewrite(meta.Tags),
+		}
+	}
+
+	builder, err := c.controller.BlockBuilder(stepIter.Meta(), rewrittenMeta)
+	if err != nil {
+		return err
+	}
+
+	if err := builder.AddCols(stepIter.StepCount()); err != nil {
+		return err
+	}
+
+	for index := 0; stepIter.Next(); index++ {
+		step, err := stepIter.Current()
+		if err != nil {
+			return err
+		}
+
+		for _, value := range step.Values() {
+			builder.AppendValue(index, value)
+		}
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return c.controller.Process(nextBlock)
+}
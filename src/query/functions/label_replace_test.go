@@ -18,12 +18,12 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-package linear
+package functions
 
 import (
-	"math"
 	"testing"
 
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 	"github.com/m3db/m3/src/query/test"
 	"github.com/m3db/m3/src/query/test/executor"
@@ -32,37 +32,34 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-var (
-	nan = math.NaN()
-)
+func TestLabelReplace(t *testing.T) {
+	op, err := NewLabelReplaceOp([]interface{}{"copy", "dummy-$1", "dummy", "(.*)"})
+	require.NoError(t, err)
 
-func TestAbsentWithValues(t *testing.T) {
 	values, bounds := test.GenerateValuesAndBounds(nil, nil)
 	block := test.NewBlockFromValues(bounds, values)
-	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
-	node := NewAbsentOp().Node(c)
-	err := node.Process(parser.NodeID(0), block)
+	c, sink := executor.NewControllerWithSink(parser.NodeID("1"))
+	err = op.Node(c).Process(parser.NodeID("0"), block)
 	require.NoError(t, err)
-	assert.Len(t, sink.Values, 2)
-	expected := [][]float64{
-		{nan, nan, nan, nan, nan},
-		{nan, nan, nan, nan, nan},
+
+	assert.Equal(t, values, sink.Values)
+	require.Len(t, sink.Metas, len(values))
+	for i, meta := range sink.Metas {
+		assert.Equal(t, models.Tags{
+			models.MetricName: string(i),
+			"dummy":           string(i),
+			"copy":            "dummy-" + string(i),
+		}, meta.Tags)
 	}
-	test.EqualsWithNans(t, expected, sink.Values)
 }
 
-func TestAbsentWithNoValues(t *testing.T) {
-	v := [][]float64{
-		{nan, nan, nan, nan},
-		{nan, nan, nan, nan},
-	}
+func TestNewLabelReplaceOpInvalidArgs(t *testing.T) {
+	_, err := NewLabelReplaceOp([]interface{}{"dst", "$1", "src"})
+	assert.Error(t, err)
 
-	values, bounds := test.GenerateValuesAndBounds(v, nil)
-	block := test.NewBlockFromValues(bounds, values)
-	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
-	node := NewAbsentOp().Node(c)
-	err := node.Process(parser.NodeID(0), block)
-	require.NoError(t, err)
-	assert.Len(t, sink.Values, 2)
-	assert.Equal(t, [][]float64{{1, 1, 1, 1}, {1, 1, 1, 1}}, sink.Values)
+	_, err = NewLabelReplaceOp([]interface{}{"dst", "$1", "src", 1.0})
+	assert.Error(t, err)
+
+	_, err = NewLabelReplaceOp([]interface{}{"dst", "$1", "src", "(.*"})
+	assert.Error(t, err)
 }
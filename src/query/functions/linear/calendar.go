@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package linear
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/query/executor/transform"
+)
+
+const (
+	// StartOfDayType returns the start of the day of each of the given times
+	// in the time zone given by the optional argument, which defaults to UTC.
+	StartOfDayType = "start_of_day"
+
+	// StartOfWeekType returns the start of the week, weeks start on Monday,
+	// of each of the given times in the time zone given by the optional
+	// argument, which defaults to UTC.
+	StartOfWeekType = "start_of_week"
+
+	// StartOfMonthType returns the start of the month of each of the given
+	// times in the time zone given by the optional argument, which defaults
+	// to UTC.
+	StartOfMonthType = "start_of_month"
+
+	// StartOfYearType returns the start of the year of each of the given
+	// times in the time zone given by the optional argument, which defaults
+	// to UTC.
+	StartOfYearType = "start_of_year"
+)
+
+var (
+	calendarFuncs = map[string]func(time.Time) time.Time{
+		StartOfDayType: func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		},
+		StartOfWeekType: func(t time.Time) time.Time {
+			// Weekday counts from Sunday, shift so that weeks start on Monday.
+			daysSinceMonday := (int(t.Weekday()) + 6) % 7
+			return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday,
+				0, 0, 0, 0, t.Location())
+		},
+		StartOfMonthType: func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		},
+		StartOfYearType: func(t time.Time) time.Time {
+			return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+		},
+	}
+)
+
+type calendarOp struct {
+	calendarFn func(time.Time) time.Time
+	location   *time.Location
+}
+
+// NewCalendarOp creates a new calendar op based on the type and the
+// optional time zone argument, which is an IANA time zone name.
+func NewCalendarOp(optype string, args []interface{}) (BaseOp, error) {
+	calendarFn, ok := calendarFuncs[optype]
+	if !ok {
+		return emptyOp, fmt.Errorf("unknown calendar type: %s", optype)
+	}
+
+	if len(args) > 1 {
+		return emptyOp, fmt.Errorf("invalid number of args for %s: %d", optype, len(args))
+	}
+
+	location := time.UTC
+	if len(args) > 0 {
+		name, ok := args[0].(string)
+		if !ok {
+			return emptyOp, fmt.Errorf("unable to cast to time zone argument: %v", args[0])
+		}
+
+		var err error
+		location, err = time.LoadLocation(name)
+		if err != nil {
+			return emptyOp, fmt.Errorf("invalid time zone for %s: %v", optype, err)
+		}
+	}
+
+	spec := calendarOp{
+		calendarFn: calendarFn,
+		location:   location,
+	}
+
+	return BaseOp{
+		operatorType: optype,
+		processorFn:  makeCalendarProcessor(spec),
+	}, nil
+}
+
+func makeCalendarProcessor(spec calendarOp) makeProcessor {
+	return func(op BaseOp, controller *transform.Controller) Processor {
+		return &calendarNode{op: spec, controller: controller}
+	}
+}
+
+type calendarNode struct {
+	op         calendarOp
+	controller *transform.Controller
+}
+
+func (c *calendarNode) Process(values []float64) []float64 {
+	for i := range values {
+		if math.IsNaN(values[i]) {
+			continue
+		}
+		t := time.Unix(int64(values[i]), 0).In(c.op.location)
+		values[i] = float64(c.op.calendarFn(t).Unix())
+	}
+
+	return values
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package linear
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarOpInvalidArgs(t *testing.T) {
+	_, err := NewCalendarOp("start_of_decade", nil)
+	require.Error(t, err)
+
+	_, err = NewCalendarOp(StartOfDayType, []interface{}{1.0})
+	require.Error(t, err)
+
+	_, err = NewCalendarOp(StartOfDayType, []interface{}{"Not/AZone"})
+	require.Error(t, err)
+
+	_, err = NewCalendarOp(StartOfDayType, []interface{}{"UTC", "UTC"})
+	require.Error(t, err)
+}
+
+func TestCalendarOps(t *testing.T) {
+	// Sunday 2018-03-11 06:30:00 UTC, which is 2018-03-10 22:30:00 PST,
+	// hours before the daylight saving time transition in Los Angeles.
+	ts := float64(time.Date(2018, time.March, 11, 6, 30, 0, 0, time.UTC).Unix())
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	tests := []struct {
+		optype   string
+		args     []interface{}
+		expected time.Time
+	}{
+		{StartOfDayType, nil, time.Date(2018, time.March, 11, 0, 0, 0, 0, time.UTC)},
+		{StartOfDayType, []interface{}{"America/Los_Angeles"},
+			time.Date(2018, time.March, 10, 0, 0, 0, 0, la)},
+		{StartOfWeekType, nil, time.Date(2018, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{StartOfMonthType, []interface{}{"America/Los_Angeles"},
+			time.Date(2018, time.March, 1, 0, 0, 0, 0, la)},
+		{StartOfYearType, nil, time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.optype, func(t *testing.T) {
+			values, bounds := test.GenerateValuesAndBounds([][]float64{{ts, math.NaN()}}, nil)
+			block := test.NewBlockFromValues(bounds, values)
+			c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+			op, err := NewCalendarOp(tt.optype, tt.args)
+			require.NoError(t, err)
+			require.NoError(t, op.Node(c).Process(parser.NodeID(0), block))
+
+			require.Len(t, sink.Values, 1)
+			test.EqualsWithNans(t, []float64{float64(tt.expected.Unix()), math.NaN()},
+				sink.Values[0])
+		})
+	}
+}
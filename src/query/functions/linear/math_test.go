@@ -32,6 +32,10 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+var (
+	nan = math.NaN()
+)
+
 func expectedMathVals(values [][]float64, fn func(x float64) float64) [][]float64 {
 	expected := make([][]float64, 0, len(values))
 	for _, val := range values {
@@ -51,21 +51,32 @@ func (o BaseOp) String() string {
 
 // Node creates an execution node
 func (o BaseOp) Node(controller *transform.Controller) transform.OpNode {
+	return o.ResamplingNode(controller, block.PreviousInterpolation)
+}
+
+// ResamplingNode creates an execution node which resamples blocks of
+// different resolutions using the interpolation
+func (o BaseOp) ResamplingNode(
+	controller *transform.Controller,
+	interpolation block.Interpolation,
+) transform.OpNode {
 	return &BaseNode{
-		controller: controller,
-		cache:      transform.NewBlockCache(),
-		op:         o,
-		processor:  o.ProcessorFn(o, controller),
+		controller:    controller,
+		cache:         controller.BlockCache(),
+		op:            o,
+		processor:     o.ProcessorFn(o, controller),
+		interpolation: interpolation,
 	}
 }
 
 // BaseNode is an execution node
 type BaseNode struct {
-	op         BaseOp
-	controller *transform.Controller
-	cache      *transform.BlockCache
-	processor  Processor
-	mu         sync.Mutex
+	op            BaseOp
+	controller    *transform.Controller
+	cache         *transform.BlockCache
+	processor     Processor
+	interpolation block.Interpolation
+	mu            sync.Mutex
 }
 
 // Process processes a block
@@ -83,6 +94,11 @@ func (c *BaseNode) Process(ID parser.NodeID, b block.Block) error {
 	}
 
 	c.cleanup()
+	lhs, rhs, err = block.Align(lhs, rhs, c.interpolation)
+	if err != nil {
+		return err
+	}
+
 	nextBlock, err := c.processor.Process(lhs, rhs)
 	if err != nil {
 		return err
@@ -98,7 +114,11 @@ func (c *BaseNode) computeOrCache(ID parser.NodeID, b block.Block) (block.Block,
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if c.op.LNode == ID {
-		rBlock, ok := c.cache.Get(c.op.RNode)
+		rBlock, ok, err := c.cache.Get(c.op.RNode)
+		if err != nil {
+			return lhs, rhs, err
+		}
+
 		if !ok {
 			return lhs, rhs, c.cache.Add(ID, b)
 		}
@@ -106,7 +126,11 @@ func (c *BaseNode) computeOrCache(ID parser.NodeID, b block.Block) (block.Block,
 		rhs = rBlock
 		lhs = b
 	} else if c.op.RNode == ID {
-		lBlock, ok := c.cache.Get(c.op.LNode)
+		lBlock, ok, err := c.cache.Get(c.op.LNode)
+		if err != nil {
+			return lhs, rhs, err
+		}
+
 		if !ok {
 			return lhs, rhs, c.cache.Add(ID, b)
 		}
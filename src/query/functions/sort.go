@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/parser"
+)
+
+const (
+	// SortType orders series by their values in ascending order
+	SortType = "sort"
+
+	// SortDescType orders series by their values in descending order
+	SortDescType = "sort_desc"
+)
+
+// SortOp stores required properties for sort and sort_desc
+type SortOp struct {
+	opType string
+}
+
+// NewSortOp creates a new sort op based on the type
+func NewSortOp(opType string) (SortOp, error) {
+	if opType != SortType && opType != SortDescType {
+		return SortOp{}, fmt.Errorf("unknown sort type: %s", opType)
+	}
+
+	return SortOp{opType: opType}, nil
+}
+
+// OpType for the operator
+func (o SortOp) OpType() string {
+	return o.opType
+}
+
+// CacheKey returns the key to cache results of the operator by
+func (o SortOp) CacheKey() string {
+	return o.opType
+}
+
+// String representation
+func (o SortOp) String() string {
+	return fmt.Sprintf("type: %s", o.OpType())
+}
+
+// Node creates an execution node
+func (o SortOp) Node(controller *transform.Controller) transform.OpNode {
+	less := ascending
+	if o.opType == SortDescType {
+		less = descending
+	}
+
+	return &SortNode{op: o, controller: controller, less: less}
+}
+
+// SortNode is an execution node
+type SortNode struct {
+	op         SortOp
+	controller *transform.Controller
+	less       lessFn
+}
+
+// Process the block, series are ordered by their values at the last step
+// which is the only step for instant queries
+func (c *SortNode) Process(ID parser.NodeID, b block.Block) error {
+	order, err := c.order(b)
+	if err != nil {
+		return err
+	}
+
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return err
+	}
+
+	defer stepIter.Close()
+	seriesMeta := stepIter.SeriesMeta()
+	sortedMeta := make([]block.SeriesMeta, len(order))
+	for i, v := range order {
+		sortedMeta[i] = seriesMeta[v.index]
+	}
+
+	builder, err := c.controller.BlockBuilder(stepIter.Meta(), sortedMeta)
+	if err != nil {
+		return err
+	}
+
+	if err := builder.AddCols(stepIter.StepCount()); err != nil {
+		return err
+	}
+
+	for index := 0; stepIter.Next(); index++ {
+		step, err := stepIter.Current()
+		if err != nil {
+			return err
+		}
+
+		values := step.Values()
+		for _, v := range order {
+			if err := builder.AppendValue(index, values[v.index]); err != nil {
+				return err
+			}
+		}
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return c.controller.Process(nextBlock)
+}
+
+// order returns the series indices ordered by their values at the last step
+func (c *SortNode) order(b block.Block) ([]valueAndIndex, error) {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return nil, err
+	}
+
+	defer stepIter.Close()
+	last := make([]valueAndIndex, len(stepIter.SeriesMeta()))
+	for i := range last {
+		last[i] = valueAndIndex{value: math.NaN(), index: i}
+	}
+
+	for stepIter.Next() {
+		step, err := stepIter.Current()
+		if err != nil {
+			return nil, err
+		}
+
+		for i, value := range step.Values() {
+			last[i].value = value
+		}
+	}
+
+	return sorted(last, c.less), nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"math"
+	"testing"
+
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSort(t *testing.T) {
+	v := [][]float64{
+		{0, 3},
+		{1, math.NaN()},
+		{2, 1},
+		{3, 2},
+	}
+
+	tests := []struct {
+		opType   string
+		expected [][]float64
+	}{
+		{SortType, [][]float64{{2, 1}, {3, 2}, {0, 3}, {1, math.NaN()}}},
+		{SortDescType, [][]float64{{0, 3}, {3, 2}, {2, 1}, {1, math.NaN()}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.opType, func(t *testing.T) {
+			op, err := NewSortOp(tt.opType)
+			require.NoError(t, err)
+
+			values, bounds := test.GenerateValuesAndBounds(v, nil)
+			block := test.NewBlockFromValues(bounds, values)
+			c, sink := executor.NewControllerWithSink(parser.NodeID("1"))
+			err = op.Node(c).Process(parser.NodeID("0"), block)
+			require.NoError(t, err)
+			test.EqualsWithNans(t, tt.expected, sink.Values)
+		})
+	}
+}
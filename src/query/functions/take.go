@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/parser"
+)
+
+const (
+	// TopKType selects the k largest series at each step
+	TopKType = "topk"
+
+	// BottomKType selects the k smallest series at each step
+	BottomKType = "bottomk"
+)
+
+// TakeOp stores required properties for topk and bottomk
+type TakeOp struct {
+	opType string
+	k      int
+}
+
+// NewTakeOp creates a new take op based on the type and arguments
+func NewTakeOp(args []interface{}, opType string) (TakeOp, error) {
+	if opType != TopKType && opType != BottomKType {
+		return TakeOp{}, fmt.Errorf("unknown take type: %s", opType)
+	}
+
+	if len(args) != 1 {
+		return TakeOp{}, fmt.Errorf("invalid number of args for %s: %d", opType, len(args))
+	}
+
+	k, ok := args[0].(float64)
+	if !ok {
+		return TakeOp{}, fmt.Errorf("unable to cast to scalar argument: %v", args[0])
+	}
+
+	return TakeOp{opType: opType, k: int(k)}, nil
+}
+
+// OpType for the operator
+func (o TakeOp) OpType() string {
+	return o.opType
+}
+
+// CacheKey returns the key to cache results of the operator by
+func (o TakeOp) CacheKey() string {
+	return fmt.Sprintf("%s_%d", o.opType, o.k)
+}
+
+// String representation
+func (o TakeOp) String() string {
+	return fmt.Sprintf("type: %s, k: %d", o.OpType(), o.k)
+}
+
+// Node creates an execution node
+func (o TakeOp) Node(controller *transform.Controller) transform.OpNode {
+	less := descending
+	if o.opType == BottomKType {
+		less = ascending
+	}
+
+	return &TakeNode{op: o, controller: controller, less: less}
+}
+
+// TakeNode is an execution node
+type TakeNode struct {
+	op         TakeOp
+	controller *transform.Controller
+	less       lessFn
+}
+
+// Process the block, only the series selected at one or more steps are
+// kept and their values are NaN at the steps they are not selected
+func (c *TakeNode) Process(ID parser.NodeID, b block.Block) error {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return err
+	}
+
+	defer stepIter.Close()
+	var (
+		seriesMeta = stepIter.SeriesMeta()
+		selected   = make([][]valueAndIndex, 0, stepIter.StepCount())
+		used       = make([]bool, len(seriesMeta))
+		heap       = newBoundedHeap(c.op.k, c.less)
+	)
+
+	// Only k values are held for each step rather than the whole block
+	for stepIter.Next() {
+		step, err := stepIter.Current()
+		if err != nil {
+			return err
+		}
+
+		heap.reset()
+		for idx, value := range step.Values() {
+			if !math.IsNaN(value) {
+				heap.add(valueAndIndex{value: value, index: idx})
+			}
+		}
+
+		taken := append([]valueAndIndex(nil), heap.values()...)
+		for _, v := range taken {
+			used[v.index] = true
+		}
+
+		selected = append(selected, taken)
+	}
+
+	var (
+		indices  = make([]int, len(seriesMeta))
+		keptMeta = make([]block.SeriesMeta, 0, len(seriesMeta))
+	)
+
+	for idx, ok := range used {
+		if ok {
+			indices[idx] = len(keptMeta)
+			keptMeta = append(keptMeta, seriesMeta[idx])
+		}
+	}
+
+	builder, err := c.controller.BlockBuilder(stepIter.Meta(), keptMeta)
+	if err != nil {
+		return err
+	}
+
+	if err := builder.AddCols(len(selected)); err != nil {
+		return err
+	}
+
+	values := make([]float64, len(keptMeta))
+	for index, taken := range selected {
+		for i := range values {
+			values[i] = math.NaN()
+		}
+
+		for _, v := range taken {
+			values[indices[v.index]] = v.value
+		}
+
+		for _, value := range values {
+			if err := builder.AppendValue(index, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return c.controller.Process(nextBlock)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package functions
+
+import (
+	"math"
+	"testing"
+
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func processTake(t *testing.T, opType string, k float64, v [][]float64) [][]float64 {
+	op, err := NewTakeOp([]interface{}{k}, opType)
+	require.NoError(t, err)
+
+	values, bounds := test.GenerateValuesAndBounds(v, nil)
+	block := test.NewBlockFromValues(bounds, values)
+	c, sink := executor.NewControllerWithSink(parser.NodeID("1"))
+	err = op.Node(c).Process(parser.NodeID("0"), block)
+	require.NoError(t, err)
+	return sink.Values
+}
+
+var takeValues = [][]float64{
+	{1, 5, math.NaN(), 3},
+	{2, 4, 6, math.NaN()},
+	{3, 3, 1, 1},
+	{0, 0, 0, 0},
+}
+
+func TestTopK(t *testing.T) {
+	expected := [][]float64{
+		{math.NaN(), 5, math.NaN(), 3},
+		{2, 4, 6, math.NaN()},
+		{3, math.NaN(), 1, 1},
+	}
+	test.EqualsWithNans(t, expected, processTake(t, TopKType, 2, takeValues))
+}
+
+func TestBottomK(t *testing.T) {
+	expected := [][]float64{
+		{1, math.NaN(), math.NaN(), math.NaN()},
+		{math.NaN(), 3, 1, 1},
+		{0, 0, 0, 0},
+	}
+	test.EqualsWithNans(t, expected, processTake(t, BottomKType, 2, takeValues))
+}
+
+func TestTakeTiesAndLimits(t *testing.T) {
+	v := [][]float64{{1, 1}, {1, 1}}
+	assert.Equal(t, [][]float64{{1, 1}}, processTake(t, TopKType, 1, v))
+	assert.Equal(t, v, processTake(t, TopKType, 10, v))
+	assert.Len(t, processTake(t, TopKType, 0, v), 0)
+}
+
+func TestNewTakeOpErrors(t *testing.T) {
+	_, err := NewTakeOp(nil, TopKType)
+	assert.Error(t, err)
+	_, err = NewTakeOp([]interface{}{"a"}, TopKType)
+	assert.Error(t, err)
+	_, err = NewTakeOp([]interface{}{1.0}, CountType)
+	assert.Error(t, err)
+}
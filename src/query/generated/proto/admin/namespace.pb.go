@@ -75,9 +75,50 @@ func (m *NamespaceAddRequest) GetOptions() *namespace.NamespaceOptions {
 	return nil
 }
 
+type NamespaceCloneRequest struct {
+	Name             string                      `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Source           string                      `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	RetentionOptions *namespace.RetentionOptions `protobuf:"bytes,3,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
+	IndexOptions     *namespace.IndexOptions     `protobuf:"bytes,4,opt,name=indexOptions" json:"indexOptions,omitempty"`
+}
+
+func (m *NamespaceCloneRequest) Reset()                    { *m = NamespaceCloneRequest{} }
+func (m *NamespaceCloneRequest) String() string            { return proto.CompactTextString(m) }
+func (*NamespaceCloneRequest) ProtoMessage()               {}
+func (*NamespaceCloneRequest) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{2} }
+
+func (m *NamespaceCloneRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *NamespaceCloneRequest) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *NamespaceCloneRequest) GetRetentionOptions() *namespace.RetentionOptions {
+	if m != nil {
+		return m.RetentionOptions
+	}
+	return nil
+}
+
+func (m *NamespaceCloneRequest) GetIndexOptions() *namespace.IndexOptions {
+	if m != nil {
+		return m.IndexOptions
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*NamespaceGetResponse)(nil), "admin.NamespaceGetResponse")
 	proto.RegisterType((*NamespaceAddRequest)(nil), "admin.NamespaceAddRequest")
+	proto.RegisterType((*NamespaceCloneRequest)(nil), "admin.NamespaceCloneRequest")
 }
 func (m *NamespaceGetResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
@@ -141,6 +182,56 @@ func (m *NamespaceAddRequest) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *NamespaceCloneRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NamespaceCloneRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
+	}
+	if len(m.Source) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.Source)))
+		i += copy(dAtA[i:], m.Source)
+	}
+	if m.RetentionOptions != nil {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.RetentionOptions.Size()))
+		n3, err := m.RetentionOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n3
+	}
+	if m.IndexOptions != nil {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.IndexOptions.Size()))
+		n4, err := m.IndexOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
+	}
+	return i, nil
+}
+
 func encodeVarintNamespace(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -174,6 +265,28 @@ func (m *NamespaceAddRequest) Size() (n int) {
 	return n
 }
 
+func (m *NamespaceCloneRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	l = len(m.Source)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.RetentionOptions != nil {
+		l = m.RetentionOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.IndexOptions != nil {
+		l = m.IndexOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	return n
+}
+
 func sovNamespace(x uint64) (n int) {
 	for {
 		n++
@@ -382,6 +495,180 @@ func (m *NamespaceAddRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *NamespaceCloneRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NamespaceCloneRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NamespaceCloneRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Source = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetentionOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RetentionOptions == nil {
+				m.RetentionOptions = &namespace.RetentionOptions{}
+			}
+			if err := m.RetentionOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IndexOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.IndexOptions == nil {
+				m.IndexOptions = &namespace.IndexOptions{}
+			}
+			if err := m.IndexOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipNamespace(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -492,20 +779,24 @@ func init() {
 }
 
 var fileDescriptorNamespace = []byte{
-	// 233 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0xcf, 0x3f, 0x4a, 0x04, 0x31,
-	0x14, 0x06, 0x70, 0x23, 0xfe, 0x8d, 0x8d, 0x64, 0x2d, 0x16, 0x85, 0x20, 0x5b, 0x59, 0xcd, 0x03,
-	0x07, 0x0f, 0xe0, 0x36, 0xd3, 0x29, 0xe4, 0x04, 0x26, 0x93, 0xc7, 0x98, 0x22, 0x7f, 0x36, 0xc9,
-	0x14, 0x7b, 0x0b, 0x8f, 0x65, 0xe9, 0x11, 0x64, 0xbc, 0x88, 0x18, 0x9d, 0x28, 0x8a, 0x5d, 0xf8,
-	0xf2, 0x7d, 0x3f, 0x78, 0x74, 0x3d, 0x98, 0xfc, 0x38, 0xaa, 0xa6, 0xf7, 0x16, 0x6c, 0xab, 0x15,
-	0xd8, 0x16, 0x52, 0xec, 0x61, 0x33, 0x62, 0xdc, 0xc2, 0x80, 0x0e, 0xa3, 0xcc, 0xa8, 0x21, 0x44,
-	0x9f, 0x3d, 0x48, 0x6d, 0x8d, 0x03, 0x27, 0x2d, 0xa6, 0x20, 0x7b, 0x6c, 0x4a, 0xca, 0xf6, 0x4b,
-	0x7c, 0xde, 0xfd, 0x43, 0x69, 0xe5, 0xbc, 0xc6, 0x3f, 0x56, 0x55, 0x7e, 0x7b, 0xab, 0x8e, 0x9e,
-	0xdd, 0xcd, 0x51, 0x87, 0x59, 0x60, 0x0a, 0xde, 0x25, 0x64, 0x40, 0x8f, 0x22, 0x0e, 0x26, 0xe5,
-	0xb8, 0x5d, 0x92, 0x4b, 0x72, 0x75, 0x72, 0xbd, 0x68, 0xbe, 0xb7, 0xe2, 0xeb, 0x4b, 0xd4, 0xd2,
-	0xea, 0x81, 0x2e, 0x2a, 0x74, 0xab, 0xb5, 0xc0, 0xcd, 0x88, 0x29, 0x33, 0x46, 0xf7, 0x3e, 0x66,
-	0xc5, 0x38, 0x16, 0xe5, 0xcd, 0x6e, 0xe8, 0xa1, 0x0f, 0xd9, 0x78, 0x97, 0x96, 0xbb, 0x85, 0xbe,
-	0xf8, 0x41, 0x57, 0xe4, 0xfe, 0xb3, 0x22, 0xe6, 0xee, 0xfa, 0xf4, 0x79, 0xe2, 0xe4, 0x65, 0xe2,
-	0xe4, 0x75, 0xe2, 0xe4, 0xe9, 0x8d, 0xef, 0xa8, 0x83, 0x72, 0x43, 0xfb, 0x1e, 0x00, 0x00, 0xff,
-	0xff, 0xa1, 0x66, 0xec, 0x92, 0x59, 0x01, 0x00, 0x00,
+	// 298 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0xd1, 0x4d, 0x4a, 0xc4, 0x30,
+	0x14, 0x07, 0x70, 0xa3, 0xe3, 0xe8, 0x44, 0x17, 0x43, 0xc6, 0x8f, 0xa2, 0x50, 0xa4, 0x2b, 0x57,
+	0x0d, 0x58, 0x5c, 0xb9, 0x72, 0x5c, 0x14, 0x37, 0x0a, 0x39, 0x81, 0x6d, 0xf3, 0xa8, 0x05, 0x9b,
+	0x74, 0x92, 0x14, 0x9c, 0x5b, 0x78, 0x2c, 0xdd, 0x79, 0x04, 0xa9, 0x17, 0x91, 0x89, 0x6d, 0xac,
+	0x53, 0x74, 0x97, 0xbc, 0xf7, 0x7f, 0xbf, 0x17, 0x08, 0x9e, 0xe7, 0x85, 0x79, 0xac, 0xd3, 0x30,
+	0x93, 0x25, 0x2d, 0x23, 0x9e, 0xd2, 0x32, 0xa2, 0x5a, 0x65, 0x74, 0x51, 0x83, 0x5a, 0xd2, 0x1c,
+	0x04, 0xa8, 0xc4, 0x00, 0xa7, 0x95, 0x92, 0x46, 0xd2, 0x84, 0x97, 0x85, 0xa0, 0x22, 0x29, 0x41,
+	0x57, 0x49, 0x06, 0xa1, 0xad, 0x92, 0x6d, 0x5b, 0x3e, 0x89, 0xff, 0xa0, 0x78, 0x2a, 0x24, 0x87,
+	0x81, 0xe5, 0x94, 0x75, 0x2f, 0x88, 0xf1, 0xc1, 0x5d, 0x57, 0x8a, 0xc1, 0x30, 0xd0, 0x95, 0x14,
+	0x1a, 0x08, 0xc5, 0xbb, 0x0a, 0xf2, 0x42, 0x1b, 0xb5, 0xf4, 0xd0, 0x19, 0x3a, 0xdf, 0xbb, 0x98,
+	0x85, 0x3f, 0xb3, 0xac, 0x6d, 0x31, 0x17, 0x0a, 0x1e, 0xf0, 0xcc, 0x41, 0xd7, 0x9c, 0x33, 0x58,
+	0xd4, 0xa0, 0x0d, 0x21, 0x78, 0xb4, 0x1a, 0xb3, 0xc6, 0x84, 0xd9, 0x33, 0xb9, 0xc4, 0x3b, 0xb2,
+	0x32, 0x85, 0x14, 0xda, 0xdb, 0xb4, 0xf4, 0x69, 0x8f, 0x76, 0xc8, 0xfd, 0x77, 0x84, 0x75, 0xd9,
+	0xe0, 0x0d, 0xe1, 0x43, 0xd7, 0xbd, 0x79, 0x92, 0x02, 0xfe, 0x5b, 0x72, 0x84, 0xc7, 0x5a, 0xd6,
+	0x2a, 0x03, 0xbb, 0x63, 0xc2, 0xda, 0x1b, 0x89, 0xf1, 0x54, 0x81, 0x01, 0xb1, 0x32, 0xdb, 0x15,
+	0xde, 0xd6, 0xe0, 0x15, 0x6c, 0x2d, 0xc2, 0x06, 0x43, 0xe4, 0x0a, 0xef, 0x17, 0x82, 0xc3, 0x73,
+	0x87, 0x8c, 0x2c, 0x72, 0xdc, 0x43, 0x6e, 0x7b, 0x6d, 0xf6, 0x2b, 0x3c, 0x9f, 0xbe, 0x36, 0x3e,
+	0x7a, 0x6f, 0x7c, 0xf4, 0xd1, 0xf8, 0xe8, 0xe5, 0xd3, 0xdf, 0x48, 0xc7, 0xf6, 0x3f, 0xa2, 0xaf,
+	0x01, 0x00, 0xa4, 0xe5, 0x11, 0x5c, 0x25, 0x02, 0x00, 0x00,
 }
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+)
+
+// Decimation is how the datapoints of a series are reduced to a single
+// value per step when the step of a query is far coarser than the
+// resolution the series is stored at.
+type Decimation int
+
+const (
+	// UnsetDecimation uses the default decimation of the query engine.
+	UnsetDecimation Decimation = iota
+	// NoDecimation samples the value at or before each step.
+	NoDecimation
+	// MaxDecimation takes the maximum value within each step.
+	MaxDecimation
+	// MinDecimation takes the minimum value within each step.
+	MinDecimation
+	// AvgDecimation takes the average value within each step.
+	AvgDecimation
+	// LTTBDecimation selects a value within each step using the largest
+	// triangle three buckets algorithm, preserving the shape of the series.
+	LTTBDecimation
+)
+
+var validDecimations = []Decimation{
+	NoDecimation,
+	MaxDecimation,
+	MinDecimation,
+	AvgDecimation,
+	LTTBDecimation,
+}
+
+func (d Decimation) String() string {
+	switch d {
+	case UnsetDecimation:
+		return "unset"
+	case NoDecimation:
+		return "none"
+	case MaxDecimation:
+		return "max"
+	case MinDecimation:
+		return "min"
+	case AvgDecimation:
+		return "avg"
+	case LTTBDecimation:
+		return "lttb"
+	}
+	return "unknown"
+}
+
+// Enabled returns whether series are decimated.
+func (d Decimation) Enabled() bool {
+	return d != UnsetDecimation && d != NoDecimation
+}
+
+// ParseDecimation parses a decimation.
+func ParseDecimation(str string) (Decimation, error) {
+	for _, valid := range validDecimations {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid decimation '%s' valid decimations are: %v",
+		str, validDecimations)
+}
+
+// UnmarshalYAML unmarshals a decimation.
+func (d *Decimation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseDecimation(str)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -34,4 +34,10 @@ type RequestParams struct {
 	Step    time.Duration
 	Target  string
 	Debug   bool
+	// Decimation is how series are reduced to a single value per step when
+	// the step is far coarser than their resolution
+	Decimation Decimation
+	// Enrichment are the names of the lookup tables whose tags are added to
+	// the fetched series
+	Enrichment []string
 }
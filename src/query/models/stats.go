@@ -0,0 +1,239 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// QueryStats collects statistics and warnings while executing a query, it is
+// safe for concurrent use and all methods are no-ops on a nil receiver so
+// that callers do not need to check whether stats are being collected.
+type QueryStats struct {
+	sync.Mutex
+	seriesFetched     int
+	datapointsDecoded int
+	stages            []StageTiming
+	warnings          []string
+	readConsistency   []string
+	debug             *QueryDebugSnapshot
+}
+
+// StageTiming is the wall time spent in a single stage of a query.
+type StageTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// QueryStatsSnapshot is a point in time copy of query statistics.
+type QueryStatsSnapshot struct {
+	SeriesFetched     int
+	DatapointsDecoded int
+	Stages            []StageTiming
+	Warnings          []string
+	ReadConsistency   []string
+	// Debug is only set if debug information was enabled for the query.
+	Debug *QueryDebugSnapshot
+}
+
+// QueryDebugSnapshot is the debug information of a query, it is only
+// collected for queries that request it.
+type QueryDebugSnapshot struct {
+	LogicalPlan  string
+	PhysicalPlan string
+	Transforms   []TransformTiming
+	StorageCalls []StorageCall
+}
+
+// TransformTiming is the wall time spent processing blocks in a transform,
+// including the time spent by the transforms it passes its results to.
+type TransformTiming struct {
+	Name     string
+	Blocks   int
+	Duration time.Duration
+}
+
+// StorageCall summarizes a single fetch from a storage namespace.
+type StorageCall struct {
+	Namespace  string
+	Duration   time.Duration
+	Series     int
+	Datapoints int
+}
+
+// NewQueryStats returns a new query statistics collector.
+func NewQueryStats() *QueryStats {
+	return &QueryStats{}
+}
+
+// EnableDebug enables collecting the plans, transform timings and storage
+// calls of the query.
+func (s *QueryStats) EnableDebug() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	if s.debug == nil {
+		s.debug = &QueryDebugSnapshot{}
+	}
+	s.Unlock()
+}
+
+// DebugEnabled returns whether debug information is collected.
+func (s *QueryStats) DebugEnabled() bool {
+	if s == nil {
+		return false
+	}
+	s.Lock()
+	defer s.Unlock()
+	return s.debug != nil
+}
+
+// SetPlans records the logical and physical plans of the query if debug
+// information is collected.
+func (s *QueryStats) SetPlans(logical, physical string) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	if s.debug != nil {
+		s.debug.LogicalPlan = logical
+		s.debug.PhysicalPlan = physical
+	}
+	s.Unlock()
+}
+
+// AddTransformTiming records the wall time spent processing a block in a
+// transform if debug information is collected.
+func (s *QueryStats) AddTransformTiming(name string, duration time.Duration) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.debug == nil {
+		return
+	}
+	for i := range s.debug.Transforms {
+		if s.debug.Transforms[i].Name == name {
+			s.debug.Transforms[i].Blocks++
+			s.debug.Transforms[i].Duration += duration
+			return
+		}
+	}
+	s.debug.Transforms = append(s.debug.Transforms, TransformTiming{
+		Name:     name,
+		Blocks:   1,
+		Duration: duration,
+	})
+}
+
+// AddStorageCall records a fetch from a storage namespace if debug
+// information is collected.
+func (s *QueryStats) AddStorageCall(call StorageCall) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	if s.debug != nil {
+		s.debug.StorageCalls = append(s.debug.StorageCalls, call)
+	}
+	s.Unlock()
+}
+
+// AddFetched records series and datapoints fetched from storage.
+func (s *QueryStats) AddFetched(series, datapoints int) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	s.seriesFetched += series
+	s.datapointsDecoded += datapoints
+	s.Unlock()
+}
+
+// AddStage records the wall time spent in a stage of the query.
+func (s *QueryStats) AddStage(name string, duration time.Duration) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	s.stages = append(s.stages, StageTiming{Name: name, Duration: duration})
+	s.Unlock()
+}
+
+// AddWarning records a warning, such as results being truncated.
+func (s *QueryStats) AddWarning(warning string) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	for _, w := range s.warnings {
+		if w == warning {
+			s.Unlock()
+			return
+		}
+	}
+	s.warnings = append(s.warnings, warning)
+	s.Unlock()
+}
+
+// AddReadConsistency records the read consistency achieved by a fetch.
+func (s *QueryStats) AddReadConsistency(level string) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	for _, l := range s.readConsistency {
+		if l == level {
+			s.Unlock()
+			return
+		}
+	}
+	s.readConsistency = append(s.readConsistency, level)
+	s.Unlock()
+}
+
+// Snapshot returns a copy of the current statistics.
+func (s *QueryStats) Snapshot() QueryStatsSnapshot {
+	if s == nil {
+		return QueryStatsSnapshot{}
+	}
+	s.Lock()
+	defer s.Unlock()
+	snapshot := QueryStatsSnapshot{
+		SeriesFetched:     s.seriesFetched,
+		DatapointsDecoded: s.datapointsDecoded,
+		Stages:            append([]StageTiming(nil), s.stages...),
+		Warnings:          append([]string(nil), s.warnings...),
+		ReadConsistency:   append([]string(nil), s.readConsistency...),
+	}
+	if s.debug != nil {
+		snapshot.Debug = &QueryDebugSnapshot{
+			LogicalPlan:  s.debug.LogicalPlan,
+			PhysicalPlan: s.debug.PhysicalPlan,
+			Transforms:   append([]TransformTiming(nil), s.debug.Transforms...),
+			StorageCalls: append([]StorageCall(nil), s.debug.StorageCalls...),
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryStats(t *testing.T) {
+	stats := NewQueryStats()
+	stats.AddFetched(2, 10)
+	stats.AddFetched(1, 5)
+	stats.AddStage("parse", time.Millisecond)
+	stats.AddWarning("truncated")
+	stats.AddWarning("truncated")
+	stats.AddReadConsistency("follower")
+	stats.AddReadConsistency("follower")
+
+	assert.Equal(t, QueryStatsSnapshot{
+		SeriesFetched:     3,
+		DatapointsDecoded: 15,
+		Stages:            []StageTiming{{Name: "parse", Duration: time.Millisecond}},
+		Warnings:          []string{"truncated"},
+		ReadConsistency:   []string{"follower"},
+	}, stats.Snapshot())
+}
+
+func TestQueryStatsNil(t *testing.T) {
+	var stats *QueryStats
+	stats.AddFetched(1, 1)
+	stats.AddStage("parse", time.Millisecond)
+	stats.AddWarning("truncated")
+	stats.AddReadConsistency("follower")
+	assert.Equal(t, QueryStatsSnapshot{}, stats.Snapshot())
+}
+
+func TestQueryStatsDebug(t *testing.T) {
+	stats := NewQueryStats()
+	stats.SetPlans("ignored", "ignored")
+	stats.AddTransformTiming("ignored", time.Millisecond)
+	stats.AddStorageCall(StorageCall{Namespace: "ignored"})
+	assert.False(t, stats.DebugEnabled())
+	assert.Nil(t, stats.Snapshot().Debug)
+
+	stats.EnableDebug()
+	assert.True(t, stats.DebugEnabled())
+	stats.SetPlans("logical", "physical")
+	stats.AddTransformTiming("1 sum", time.Millisecond)
+	stats.AddTransformTiming("1 sum", 2*time.Millisecond)
+	stats.AddStorageCall(StorageCall{
+		Namespace:  "default",
+		Duration:   time.Millisecond,
+		Series:     2,
+		Datapoints: 10,
+	})
+
+	assert.Equal(t, &QueryDebugSnapshot{
+		LogicalPlan:  "logical",
+		PhysicalPlan: "physical",
+		Transforms: []TransformTiming{
+			{Name: "1 sum", Blocks: 2, Duration: 3 * time.Millisecond},
+		},
+		StorageCalls: []StorageCall{
+			{Namespace: "default", Duration: time.Millisecond, Series: 2, Datapoints: 10},
+		},
+	}, stats.Snapshot().Debug)
+}
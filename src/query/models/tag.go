@@ -25,6 +25,7 @@ import (
 	"hash/fnv"
 	"regexp"
 	"sort"
+	"strconv"
 )
 
 const (
@@ -59,14 +60,22 @@ const (
 	MatchNotEqual
 	MatchRegexp
 	MatchNotRegexp
+	MatchGreaterThan
+	MatchGreaterThanOrEqual
+	MatchLessThan
+	MatchLessThanOrEqual
 )
 
 func (m MatchType) String() string {
 	typeToStr := map[MatchType]string{
-		MatchEqual:     "=",
-		MatchNotEqual:  "!=",
-		MatchRegexp:    "=~",
-		MatchNotRegexp: "!~",
+		MatchEqual:              "=",
+		MatchNotEqual:           "!=",
+		MatchRegexp:             "=~",
+		MatchNotRegexp:          "!~",
+		MatchGreaterThan:        ">",
+		MatchGreaterThanOrEqual: ">=",
+		MatchLessThan:           "<",
+		MatchLessThanOrEqual:    "<=",
 	}
 	if str, ok := typeToStr[m]; ok {
 		return str
@@ -74,13 +83,23 @@ func (m MatchType) String() string {
 	panic("unknown match type")
 }
 
+// IsNumericRange returns whether the match type compares numeric values.
+func (m MatchType) IsNumericRange() bool {
+	switch m {
+	case MatchGreaterThan, MatchGreaterThanOrEqual, MatchLessThan, MatchLessThanOrEqual:
+		return true
+	}
+	return false
+}
+
 // Matcher models the matching of a label.
 type Matcher struct {
 	Type  MatchType `json:"type"`
 	Name  string    `json:"name"`
 	Value string    `json:"value"`
 
-	re *regexp.Regexp
+	re  *regexp.Regexp
+	num float64
 }
 
 // NewMatcher returns a matcher object.
@@ -97,6 +116,13 @@ func NewMatcher(t MatchType, n, v string) (*Matcher, error) {
 		}
 		m.re = re
 	}
+	if t.IsNumericRange() {
+		num, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid numeric value for %s%s matcher: %v", n, t, err)
+		}
+		m.num = num
+	}
 	return m, nil
 }
 
@@ -115,6 +141,21 @@ func (m *Matcher) Matches(s string) bool {
 		return m.re.MatchString(s)
 	case MatchNotRegexp:
 		return !m.re.MatchString(s)
+	case MatchGreaterThan, MatchGreaterThanOrEqual, MatchLessThan, MatchLessThanOrEqual:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return false
+		}
+		switch m.Type {
+		case MatchGreaterThan:
+			return v > m.num
+		case MatchGreaterThanOrEqual:
+			return v >= m.num
+		case MatchLessThan:
+			return v < m.num
+		default:
+			return v <= m.num
+		}
 	}
 	panic("labels.Matcher.Matches: invalid match type")
 }
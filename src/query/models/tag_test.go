@@ -92,6 +92,36 @@ func TestMatcher(t *testing.T) {
 			value:   "foo-bar",
 			match:   false,
 		},
+		{
+			matcher: mustNewMatcher(t, MatchGreaterThan, "8000"),
+			value:   "8000",
+			match:   false,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchGreaterThanOrEqual, "8000"),
+			value:   "8000",
+			match:   true,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchGreaterThanOrEqual, "8000"),
+			value:   "443",
+			match:   false,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchLessThan, "8000"),
+			value:   "443",
+			match:   true,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchLessThanOrEqual, "8000"),
+			value:   "8000.5",
+			match:   false,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchLessThanOrEqual, "8000"),
+			value:   "not-a-number",
+			match:   false,
+		},
 	}
 
 	for _, test := range tests {
@@ -103,6 +133,14 @@ func TestMatcher(t *testing.T) {
 
 func TestMatchType(t *testing.T) {
 	require.Equal(t, MatchEqual.String(), "=")
+	require.Equal(t, MatchGreaterThanOrEqual.String(), ">=")
+	require.True(t, MatchLessThan.IsNumericRange())
+	require.False(t, MatchRegexp.IsNumericRange())
+}
+
+func TestNewMatcherInvalidNumericValue(t *testing.T) {
+	_, err := NewMatcher(MatchGreaterThan, "port", "abc")
+	require.Error(t, err)
 }
 
 func createTags(withName bool) Tags {
@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package promql
+
+import (
+	"github.com/m3db/m3/src/query/functions/linear"
+
+	pql "github.com/prometheus/prometheus/promql"
+)
+
+// calendarFunctions are the calendar functions which are not supported by
+// the prometheus parser, they take a vector of times and an optional time
+// zone name.
+var calendarFunctions = []string{
+	linear.StartOfDayType,
+	linear.StartOfWeekType,
+	linear.StartOfMonthType,
+	linear.StartOfYearType,
+}
+
+func init() {
+	// Register the functions only M3 supports with the prometheus parser
+	// so that queries using them parse, they are executed by M3 only.
+	for _, name := range calendarFunctions {
+		pql.Functions[name] = &pql.Function{
+			Name:       name,
+			ArgTypes:   []pql.ValueType{pql.ValueTypeVector, pql.ValueTypeString},
+			Variadic:   1,
+			ReturnType: pql.ValueTypeVector,
+		}
+	}
+}
@@ -24,27 +24,56 @@ import (
 	"fmt"
 
 	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/functions"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/storage"
 
 	pql "github.com/prometheus/prometheus/promql"
 )
 
 type promParser struct {
-	expr pql.Expr
+	expr   pql.Expr
+	ranges rangeMatchers
 }
 
 // Parse takes a promQL string and converts parses it into a DAG
 func Parse(q string) (parser.Parser, error) {
+	q, ranges, err := extractRangeMatchers(q)
+	if err != nil {
+		return nil, err
+	}
+
 	expr, err := pql.ParseExpr(q)
 	if err != nil {
 		return nil, err
 	}
 
-	return &promParser{expr: expr}, nil
+	return &promParser{expr: expr, ranges: ranges}, nil
+}
+
+// ParseMatchers parses a promQL series selector into matchers
+func ParseMatchers(selector string) (models.Matchers, error) {
+	selector, ranges, err := extractRangeMatchers(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	lMatchers, err := pql.ParseMetricSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	matchers, err := labelMatchersToModelMatcher(lMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return ranges.resolve(matchers), nil
 }
 
 func (p *promParser) DAG() (parser.Nodes, parser.Edges, error) {
-	state := &parseState{}
+	state := &parseState{ranges: p.ranges}
 	err := state.walk(p.expr)
 	if err != nil {
 		return nil, nil, err
@@ -54,12 +83,13 @@ func (p *promParser) DAG() (parser.Nodes, parser.Edges, error) {
 }
 
 func (p *promParser) String() string {
-	return p.expr.String()
+	return p.ranges.restore(p.expr.String())
 }
 
 type parseState struct {
 	edges      parser.Edges
 	transforms parser.Nodes
+	ranges     rangeMatchers
 }
 
 func (p *parseState) lastTransformID() parser.NodeID {
@@ -74,6 +104,21 @@ func (p *parseState) transformLen() int {
 	return len(p.transforms)
 }
 
+// lastFetch returns the last transform if it is a fetch, in which case the
+// fetch is the input of the transform being parsed.
+func (p *parseState) lastFetch() (functions.FetchOp, bool) {
+	if len(p.transforms) == 0 {
+		return functions.FetchOp{}, false
+	}
+
+	fetch, ok := p.transforms[len(p.transforms)-1].Op.(functions.FetchOp)
+	return fetch, ok
+}
+
+func (p *parseState) setLastFetch(fetch functions.FetchOp) {
+	p.transforms[len(p.transforms)-1].Op = fetch
+}
+
 func (p *parseState) walk(node pql.Node) error {
 	if node == nil {
 		return nil
@@ -86,11 +131,20 @@ func (p *parseState) walk(node pql.Node) error {
 			return err
 		}
 
-		op, err := NewOperator(n.Op)
+		op, err := NewOperator(n)
 		if err != nil {
 			return err
 		}
 
+		if !n.Without && len(n.Grouping) > 0 && readsGroupingOnly(op) {
+			// Only the grouping tags of the series are read so the rest
+			// need not be fetched.
+			if fetch, ok := p.lastFetch(); ok {
+				fetch.TagProjection = n.Grouping
+				p.setLastFetch(fetch)
+			}
+		}
+
 		opTransform := parser.NewTransformFromOperation(op, p.transformLen())
 		p.edges = append(p.edges, parser.Edge{
 			ParentID: p.lastTransformID(),
@@ -105,6 +159,7 @@ func (p *parseState) walk(node pql.Node) error {
 			return err
 		}
 
+		operation = p.ranges.resolveFetch(operation)
 		p.transforms = append(p.transforms, parser.NewTransformFromOperation(operation, p.transformLen()))
 		return nil
 
@@ -114,6 +169,7 @@ func (p *parseState) walk(node pql.Node) error {
 			return err
 		}
 
+		operation = p.ranges.resolveFetch(operation)
 		p.transforms = append(p.transforms, parser.NewTransformFromOperation(operation, p.transformLen()))
 		return nil
 
@@ -125,6 +181,9 @@ func (p *parseState) walk(node pql.Node) error {
 			case *pql.NumberLiteral:
 				argValues = append(argValues, e.Val)
 				continue
+			case *pql.StringLiteral:
+				argValues = append(argValues, e.Val)
+				continue
 			}
 
 			err := p.walk(expr)
@@ -133,7 +192,36 @@ func (p *parseState) walk(node pql.Node) error {
 			}
 		}
 
-		op, err := NewFunctionExpr(n.Func.Name, argValues)
+		var (
+			op  parser.Params
+			err error
+		)
+
+		switch n.Func.Name {
+		case functions.AbsentType, functions.AbsentOverTimeType:
+			op, err = NewAbsentExpr(n.Func.Name, n.Args)
+		case functions.LabelReplaceType:
+			var labelReplace functions.LabelReplaceOp
+			labelReplace, err = functions.NewLabelReplaceOp(argValues)
+			if err != nil {
+				return err
+			}
+
+			// Rewrites of a selector are pushed down into its fetch
+			// rather than run as a separate transform.
+			if fetch, ok := p.lastFetch(); ok {
+				rewrites := make([]storage.TagRewrite, 0, len(fetch.TagRewrites)+1)
+				rewrites = append(rewrites, fetch.TagRewrites...)
+				fetch.TagRewrites = append(rewrites, labelReplace.Rewrite())
+				p.setLastFetch(fetch)
+				return nil
+			}
+
+			op = labelReplace
+		default:
+			op, err = NewFunctionExpr(n.Func.Name, argValues)
+		}
+
 		if err != nil {
 			return err
 		}
@@ -24,14 +24,84 @@ import (
 	"testing"
 
 	"github.com/m3db/m3/src/query/functions"
+	"github.com/m3db/m3/src/query/functions/aggregation"
 	"github.com/m3db/m3/src/query/functions/linear"
 	"github.com/m3db/m3/src/query/functions/logical"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+func TestParseMatchers(t *testing.T) {
+	matchers, err := ParseMatchers(`http_requests_total{method!="GET",code=~"5.."}`)
+	require.NoError(t, err)
+	require.Len(t, matchers, 3)
+
+	byName := make(map[string]*models.Matcher, len(matchers))
+	for _, m := range matchers {
+		byName[m.Name] = m
+	}
+	assert.Equal(t, models.MatchEqual, byName[models.MetricName].Type)
+	assert.Equal(t, "http_requests_total", byName[models.MetricName].Value)
+	assert.Equal(t, models.MatchNotEqual, byName["method"].Type)
+	assert.Equal(t, models.MatchRegexp, byName["code"].Type)
+
+	_, err = ParseMatchers("sum(foo)")
+	require.Error(t, err)
+}
+
+func TestParseMatchersNumericRange(t *testing.T) {
+	matchers, err := ParseMatchers(`up{instance_port>=8000, job="api,web", shard < "10"}`)
+	require.NoError(t, err)
+	require.Len(t, matchers, 4)
+
+	byName := make(map[string]*models.Matcher, len(matchers))
+	for _, m := range matchers {
+		byName[m.Name] = m
+	}
+	assert.Equal(t, models.MatchGreaterThanOrEqual, byName["instance_port"].Type)
+	assert.Equal(t, "8000", byName["instance_port"].Value)
+	assert.Equal(t, models.MatchEqual, byName["job"].Type)
+	assert.Equal(t, "api,web", byName["job"].Value)
+	assert.Equal(t, models.MatchLessThan, byName["shard"].Type)
+	assert.Equal(t, "10", byName["shard"].Value)
+
+	_, err = ParseMatchers(`up{instance_port>=abc}`)
+	require.Error(t, err)
+}
+
+func TestDAGWithNumericRangeMatchers(t *testing.T) {
+	q := `sum(rate(http_requests_total{instance_port>8000}[5m])) + up{instance_port<=9000}`
+	p, err := Parse(q)
+	require.NoError(t, err)
+	assert.NotContains(t, p.String(), "__m3_range")
+	transforms, _, err := p.DAG()
+	require.NoError(t, err)
+
+	var fetches []functions.FetchOp
+	for _, transform := range transforms {
+		if fetch, ok := transform.Op.(functions.FetchOp); ok {
+			fetches = append(fetches, fetch)
+		}
+	}
+	require.Len(t, fetches, 2)
+
+	types := make(map[models.MatchType]string)
+	for _, fetch := range fetches {
+		for _, m := range fetch.Matchers {
+			if m.Name == "instance_port" {
+				types[m.Type] = m.Value
+			}
+		}
+	}
+	assert.Equal(t, map[models.MatchType]string{
+		models.MatchGreaterThan:     "8000",
+		models.MatchLessThanOrEqual: "9000",
+	}, types)
+}
+
 func TestDAGWithCountOp(t *testing.T) {
 	q := "count(http_requests_total{method=\"GET\"} offset 5m) by (service)"
 	p, err := Parse(q)
@@ -49,6 +119,77 @@ func TestDAGWithCountOp(t *testing.T) {
 
 }
 
+func TestDAGWithTakeOp(t *testing.T) {
+	q := "topk(3, http_requests_total{method=\"GET\"})"
+	p, err := Parse(q)
+	require.NoError(t, err)
+	transforms, edges, err := p.DAG()
+	require.NoError(t, err)
+	assert.Len(t, transforms, 2)
+	assert.Equal(t, transforms[0].Op.OpType(), functions.FetchType)
+	assert.Equal(t, transforms[1].Op.OpType(), functions.TopKType)
+	assert.Equal(t, transforms[1].Op.String(), "type: topk, k: 3")
+	assert.Len(t, edges, 1)
+
+	p, err = Parse("bottomk(1, up)")
+	require.NoError(t, err)
+	transforms, _, err = p.DAG()
+	require.NoError(t, err)
+	assert.Equal(t, transforms[1].Op.OpType(), functions.BottomKType)
+}
+
+func TestDAGWithSortOp(t *testing.T) {
+	for _, sortType := range []string{functions.SortType, functions.SortDescType} {
+		p, err := Parse(sortType + "(up)")
+		require.NoError(t, err)
+		transforms, edges, err := p.DAG()
+		require.NoError(t, err)
+		assert.Len(t, transforms, 2)
+		assert.Equal(t, transforms[1].Op.OpType(), sortType)
+		assert.Len(t, edges, 1)
+	}
+}
+
+func TestDAGWithAggregationOp(t *testing.T) {
+	tests := []struct {
+		q        string
+		opType   string
+		expected string
+	}{
+		{
+			q:        "stddev(up) by (service)",
+			opType:   aggregation.StandardDeviationType,
+			expected: "type: stddev, matching: [service], without: false",
+		},
+		{
+			q:        "stdvar without (instance) (up)",
+			opType:   aggregation.StandardVarianceType,
+			expected: "type: stdvar, matching: [instance], without: true",
+		},
+		{
+			q:        "quantile(0.9, up)",
+			opType:   aggregation.QuantileType,
+			expected: "type: quantile, matching: [], without: false",
+		},
+		{
+			q:        "count_values(\"version\", build_info) by (service)",
+			opType:   aggregation.CountValuesType,
+			expected: "type: count_values, tag: version, matching: [service], without: false",
+		},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(tt.q)
+		require.NoError(t, err)
+		transforms, edges, err := p.DAG()
+		require.NoError(t, err)
+		assert.Len(t, transforms, 2)
+		assert.Equal(t, tt.opType, transforms[1].Op.OpType())
+		assert.Equal(t, tt.expected, transforms[1].Op.String())
+		assert.Len(t, edges, 1)
+	}
+}
+
 func TestDAGWithEmptyExpression(t *testing.T) {
 	q := ""
 	_, err := Parse(q)
@@ -88,7 +229,8 @@ func TestDAGWithAbsentOp(t *testing.T) {
 	assert.Len(t, transforms, 2)
 	assert.Equal(t, transforms[0].Op.OpType(), functions.FetchType)
 	assert.Equal(t, transforms[0].ID, parser.NodeID("0"))
-	assert.Equal(t, transforms[1].Op.OpType(), linear.AbsentType)
+	assert.Equal(t, transforms[1].Op.OpType(), functions.AbsentType)
+	assert.Equal(t, transforms[1].Op.String(), "type: absent, tags: map[method:GET]")
 	assert.Equal(t, transforms[1].ID, parser.NodeID("1"))
 	assert.Len(t, edges, 1)
 	assert.Equal(t, edges[0].ParentID, parser.NodeID("0"), "fetch should be the parent")
@@ -280,3 +422,95 @@ func TestDAGWithYearOp(t *testing.T) {
 	assert.Len(t, transforms, 2)
 	assert.Equal(t, transforms[1].Op.OpType(), linear.YearType)
 }
+
+func TestDAGWithCalendarOps(t *testing.T) {
+	tests := []struct {
+		q      string
+		opType string
+	}{
+		{"start_of_day(up)", linear.StartOfDayType},
+		{`start_of_week(up, "Europe/Berlin")`, linear.StartOfWeekType},
+		{`start_of_month(up, "America/New_York")`, linear.StartOfMonthType},
+		{"start_of_year(up)", linear.StartOfYearType},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(tt.q)
+		require.NoError(t, err)
+		transforms, _, err := p.DAG()
+		require.NoError(t, err)
+		assert.Len(t, transforms, 2)
+		assert.Equal(t, tt.opType, transforms[1].Op.OpType())
+	}
+}
+
+func TestDAGWithCalendarOpInvalidTimeZone(t *testing.T) {
+	p, err := Parse(`start_of_day(up, "Not/AZone")`)
+	require.NoError(t, err)
+	_, _, err = p.DAG()
+	require.Error(t, err)
+}
+
+func TestDAGWithLabelReplacePushdown(t *testing.T) {
+	q := `label_replace(up{job="api"}, "service", "$1", "job", "(.*)")`
+	p, err := Parse(q)
+	require.NoError(t, err)
+	transforms, edges, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 1)
+	assert.Len(t, edges, 0)
+
+	fetch, ok := transforms[0].Op.(functions.FetchOp)
+	require.True(t, ok)
+	require.Len(t, fetch.TagRewrites, 1)
+	assert.Equal(t, "service", fetch.TagRewrites[0].Dst)
+	assert.Equal(t, "$1", fetch.TagRewrites[0].Replacement)
+	assert.Equal(t, "job", fetch.TagRewrites[0].Src)
+}
+
+func TestDAGWithLabelReplace(t *testing.T) {
+	q := `label_replace(abs(up), "service", "$1", "job", "(.*)")`
+	p, err := Parse(q)
+	require.NoError(t, err)
+	transforms, edges, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 3)
+	assert.Equal(t, functions.FetchType, transforms[0].Op.OpType())
+	assert.Empty(t, transforms[0].Op.(functions.FetchOp).TagRewrites)
+	assert.Equal(t, linear.AbsType, transforms[1].Op.OpType())
+	assert.Equal(t, functions.LabelReplaceType, transforms[2].Op.OpType())
+	assert.Len(t, edges, 2)
+
+	p, err = Parse(`label_replace(up, "service", "$1", "job", "(.*")`)
+	require.NoError(t, err)
+	_, _, err = p.DAG()
+	assert.Error(t, err)
+}
+
+func TestDAGWithTagProjection(t *testing.T) {
+	q := `stddev(label_replace(up, "service", "$1", "job", "(.*)")) by (service, dc)`
+	p, err := Parse(q)
+	require.NoError(t, err)
+	transforms, _, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 2)
+
+	fetch, ok := transforms[0].Op.(functions.FetchOp)
+	require.True(t, ok)
+	assert.Equal(t, []string{"service", "dc"}, fetch.TagProjection)
+	assert.Len(t, fetch.TagRewrites, 1)
+
+	for _, q := range []string{
+		`stddev(up) without (service)`,
+		`topk(3, up) by (service)`,
+		`stddev(abs(up)) by (service)`,
+	} {
+		p, err := Parse(q)
+		require.NoError(t, err)
+		transforms, _, err := p.DAG()
+		require.NoError(t, err)
+		fetch, ok := transforms[0].Op.(functions.FetchOp)
+		require.True(t, ok)
+		assert.Nil(t, fetch.TagProjection, q)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package promql
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/query/functions"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
+)
+
+const rangePlaceholderFmt = "__m3_range_%d__"
+
+var rangeMatcherRegexp = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)\s*(>=|<=|>|<)\s*(.+)$`)
+
+// rangeMatchers holds the numeric range matchers extracted from a query,
+// keyed by the placeholder label name substituted in their place.
+type rangeMatchers map[string]*models.Matcher
+
+// extractRangeMatchers rewrites numeric range matchers (e.g. port>=8000) in
+// the label selectors of a query, which the prometheus parser does not
+// support, into placeholder equality matchers that are resolved back into
+// range matchers once the query has been parsed.
+func extractRangeMatchers(q string) (string, rangeMatchers, error) {
+	var (
+		b      bytes.Buffer
+		ranges rangeMatchers
+	)
+	for i := 0; i < len(q); {
+		switch c := q[i]; c {
+		case '"', '\'', '`':
+			end := skipString(q, i)
+			b.WriteString(q[i:end])
+			i = end
+		case '{':
+			end := closingBrace(q, i)
+			if end < 0 {
+				// Unterminated selector, let the prometheus parser report it.
+				b.WriteString(q[i:])
+				return b.String(), ranges, nil
+			}
+
+			elems := splitSelector(q[i+1 : end])
+			b.WriteByte('{')
+			for idx, elem := range elems {
+				if idx > 0 {
+					b.WriteByte(',')
+				}
+				match := rangeMatcherRegexp.FindStringSubmatch(strings.TrimSpace(elem))
+				if match == nil {
+					b.WriteString(elem)
+					continue
+				}
+
+				matcher, err := newRangeMatcher(match[1], match[2], match[3])
+				if err != nil {
+					return "", nil, err
+				}
+				if ranges == nil {
+					ranges = make(rangeMatchers)
+				}
+				placeholder := fmt.Sprintf(rangePlaceholderFmt, len(ranges))
+				ranges[placeholder] = matcher
+				b.WriteString(placeholder)
+				b.WriteString(`="0"`)
+			}
+			b.WriteByte('}')
+			i = end + 1
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String(), ranges, nil
+}
+
+// closingBrace returns the index of the brace closing the selector opened
+// at i, or -1 if the selector is unterminated.
+func closingBrace(q string, i int) int {
+	for j := i + 1; j < len(q); j++ {
+		switch q[j] {
+		case '"', '\'', '`':
+			j = skipString(q, j) - 1
+		case '}':
+			return j
+		}
+	}
+	return -1
+}
+
+// skipString returns the index following the string literal starting at i.
+func skipString(q string, i int) int {
+	quote := q[i]
+	for j := i + 1; j < len(q); j++ {
+		switch q[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			return j + 1
+		}
+	}
+	return len(q)
+}
+
+// splitSelector splits the contents of a label selector on commas which
+// are not part of a string literal.
+func splitSelector(s string) []string {
+	var (
+		elems []string
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'', '`':
+			i = skipString(s, i) - 1
+		case ',':
+			elems = append(elems, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(elems, s[start:])
+}
+
+func newRangeMatcher(name, op, value string) (*models.Matcher, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 {
+		switch value[0] {
+		case '"', '`':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, err
+			}
+			value = unquoted
+		case '\'':
+			value = value[1 : len(value)-1]
+		}
+	}
+
+	var matchType models.MatchType
+	switch op {
+	case ">":
+		matchType = models.MatchGreaterThan
+	case ">=":
+		matchType = models.MatchGreaterThanOrEqual
+	case "<":
+		matchType = models.MatchLessThan
+	default:
+		matchType = models.MatchLessThanOrEqual
+	}
+
+	return models.NewMatcher(matchType, name, value)
+}
+
+// resolve replaces any placeholder matchers with the range matchers they
+// stand in for.
+func (r rangeMatchers) resolve(matchers models.Matchers) models.Matchers {
+	if len(r) == 0 {
+		return matchers
+	}
+	for i, m := range matchers {
+		if rangeMatcher, ok := r[m.Name]; ok {
+			matchers[i] = rangeMatcher
+		}
+	}
+	return matchers
+}
+
+// resolveFetch resolves placeholder matchers of a fetch operation.
+func (r rangeMatchers) resolveFetch(op parser.Params) parser.Params {
+	fetch, ok := op.(functions.FetchOp)
+	if !ok {
+		return op
+	}
+	fetch.Matchers = r.resolve(fetch.Matchers)
+	return fetch
+}
+
+// restore replaces placeholder matchers in a query string with the range
+// matchers they stand in for.
+func (r rangeMatchers) restore(q string) string {
+	for placeholder, m := range r {
+		q = strings.Replace(q, placeholder+`="0"`, m.String(), -1)
+	}
+	return q
+}
@@ -22,8 +22,10 @@ package promql
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/m3db/m3/src/query/functions"
+	"github.com/m3db/m3/src/query/functions/aggregation"
 	"github.com/m3db/m3/src/query/functions/linear"
 	"github.com/m3db/m3/src/query/functions/logical"
 	"github.com/m3db/m3/src/query/models"
@@ -58,14 +60,44 @@ func NewSelectorFromMatrix(n *promql.MatrixSelector) (parser.Params, error) {
 	return functions.FetchOp{Name: n.Name, Offset: n.Offset, Matchers: matchers, Range: n.Range}, nil
 }
 
-// NewOperator creates a new operator based on the type
-func NewOperator(opType promql.ItemType) (parser.Params, error) {
-	switch getOpType(opType) {
+// NewOperator creates a new operator based on the type and parameter
+func NewOperator(expr *promql.AggregateExpr) (parser.Params, error) {
+	var argValues []interface{}
+	switch param := expr.Param.(type) {
+	case *promql.NumberLiteral:
+		argValues = append(argValues, param.Val)
+	case *promql.StringLiteral:
+		argValues = append(argValues, param.Val)
+	}
+
+	switch name := getOpType(expr.Op); name {
 	case functions.CountType:
 		return functions.CountOp{}, nil
+
+	case functions.TopKType, functions.BottomKType:
+		return functions.NewTakeOp(argValues, name)
+
+	case aggregation.StandardDeviationType, aggregation.StandardVarianceType,
+		aggregation.QuantileType, aggregation.CountValuesType:
+		params := aggregation.NodeParams{
+			MatchingTags: expr.Grouping,
+			Without:      expr.Without,
+		}
+
+		for _, arg := range argValues {
+			switch v := arg.(type) {
+			case float64:
+				params.Parameter = v
+			case string:
+				params.StringParameter = v
+			}
+		}
+
+		return aggregation.NewAggregationOp(name, params)
+
 	default:
 		// TODO: handle other types
-		return nil, fmt.Errorf("operator not supported: %s", opType)
+		return nil, fmt.Errorf("operator not supported: %s", expr.Op)
 	}
 }
 
@@ -88,29 +120,83 @@ func NewFunctionExpr(name string, argValues []interface{}) (parser.Params, error
 		linear.Log10Type, linear.Log2Type, linear.SqrtType:
 		return linear.NewMathOp(name)
 
-	case linear.AbsentType:
-		return linear.NewAbsentOp(), nil
-
 	case linear.ClampMinType, linear.ClampMaxType:
 		return linear.NewClampOp(argValues, name)
 
 	case linear.RoundType:
 		return linear.NewRoundOp(argValues)
 
+	case functions.SortType, functions.SortDescType:
+		return functions.NewSortOp(name)
+
 	case linear.DayOfMonthType, linear.DayOfWeekType, linear.DaysInMonthType, linear.HourType,
 		linear.MinuteType, linear.MonthType, linear.YearType:
 		return linear.NewDateOp(name)
 
+	case linear.StartOfDayType, linear.StartOfWeekType, linear.StartOfMonthType,
+		linear.StartOfYearType:
+		return linear.NewCalendarOp(name, argValues)
+
 	default:
 		// TODO: handle other types
 		return nil, fmt.Errorf("function not supported: %s", name)
 	}
 }
 
+// NewAbsentExpr creates a new absent function expr, the series returned are
+// tagged from the selector passed as the argument
+func NewAbsentExpr(name string, args promql.Expressions) (parser.Params, error) {
+	var (
+		lMatchers []*labels.Matcher
+		window    time.Duration
+	)
+
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case *promql.VectorSelector:
+			lMatchers = a.LabelMatchers
+		case *promql.MatrixSelector:
+			lMatchers = a.LabelMatchers
+			window = a.Range
+		}
+	}
+
+	matchers, err := labelMatchersToModelMatcher(lMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return functions.NewAbsentOp(name, matchers, window)
+}
+
+// readsGroupingOnly returns whether the operator reads no tags of its input
+// series other than those it groups by.
+func readsGroupingOnly(op parser.Params) bool {
+	switch op.OpType() {
+	case aggregation.StandardDeviationType, aggregation.StandardVarianceType,
+		aggregation.QuantileType, aggregation.CountValuesType:
+		return true
+	default:
+		return false
+	}
+}
+
 func getOpType(opType promql.ItemType) string {
 	switch opType {
 	case promql.ItemType(itemCount):
 		return functions.CountType
+	case promql.ItemType(itemStddev):
+		return aggregation.StandardDeviationType
+	case promql.ItemType(itemStdvar):
+		return aggregation.StandardVarianceType
+	case promql.ItemType(itemQuantile):
+		return aggregation.QuantileType
+	case promql.ItemType(itemCountValues):
+		return aggregation.CountValuesType
+	case promql.ItemType(itemTopK):
+		return functions.TopKType
+	case promql.ItemType(itemBottomK):
+		return functions.BottomKType
 	case promql.ItemType(itemLAND):
 		return logical.AndType
 	default:
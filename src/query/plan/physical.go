@@ -23,6 +23,7 @@ package plan
 import (
 	"fmt"
 
+	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/executor/transform"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
@@ -36,6 +37,17 @@ type PhysicalPlan struct {
 	ResultStep ResultOp
 	TimeSpec   transform.TimeSpec
 	Debug      bool
+	// Interpolation is used to resample blocks of different resolutions
+	// combined by binary operations
+	Interpolation block.Interpolation
+	// Decimation is how fetched series with a resolution far finer than the
+	// step are reduced to a single value per step
+	Decimation models.Decimation
+	// Enricher adds the tags of lookup tables to the fetched series
+	Enricher storage.TagEnricher
+	// Enrichment are the names of the lookup tables applied to the fetched
+	// series in addition to those of their namespace
+	Enrichment []string
 }
 
 // ResultOp is resonsible for delivering results to the clients
@@ -58,7 +70,9 @@ func NewPhysicalPlan(lp LogicalPlan, storage storage.Storage, params models.Requ
 			Now:   params.Now,
 			Step:  params.Step,
 		},
-		Debug: params.Debug,
+		Debug:      params.Debug,
+		Decimation: params.Decimation,
+		Enrichment: params.Enrichment,
 	}
 
 	pl, err := p.createResultNode()
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rules
+
+import (
+	"errors"
+
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+var (
+	errNoKVStore = errors.New("query policy rules KV key set without a KV store")
+)
+
+// Configuration is the query policy configuration.
+type Configuration struct {
+	// Rules are the static rules to apply, they are replaced by the rules
+	// set in KV if a KV key is specified and has a value set.
+	Rules Rules `yaml:"rules"`
+
+	// KVKey is the KV key to watch for rules to apply at runtime, the value
+	// is expected to be a string proto containing the YAML encoded rules.
+	KVKey string `yaml:"kvKey"`
+}
+
+// NewEngine creates a new query policy engine, watching the KV key
+// for rule updates if one is configured.
+func (c Configuration) NewEngine(
+	store kv.Store,
+	scope tally.Scope,
+	logger *zap.Logger,
+) (Engine, error) {
+	engine, err := NewEngine(c.Rules, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.KVKey == "" {
+		return engine, nil
+	}
+
+	if store == nil {
+		return nil, errNoKVStore
+	}
+
+	watch, err := store.Watch(c.KVKey)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		protoValue := &commonpb.StringProto{}
+		for range watch.C() {
+			rules := c.Rules
+			if value := watch.Get(); value != nil {
+				if err := value.Unmarshal(protoValue); err != nil {
+					logger.Warn("unable to unmarshal query policy rules",
+						zap.String("key", c.KVKey), zap.Any("error", err))
+					continue
+				}
+
+				parsed, err := ParseRules([]byte(protoValue.Value))
+				if err != nil {
+					logger.Warn("unable to parse query policy rules",
+						zap.String("key", c.KVKey), zap.Any("error", err))
+					continue
+				}
+				rules = parsed
+			}
+
+			if err := engine.SetRules(rules); err != nil {
+				logger.Warn("unable to set query policy rules",
+					zap.String("key", c.KVKey), zap.Any("error", err))
+				continue
+			}
+
+			logger.Info("set query policy rules",
+				zap.String("key", c.KVKey), zap.Int("numRules", len(rules)))
+		}
+	}()
+
+	return engine, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rules
+
+import (
+	"sync"
+
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+)
+
+// Engine applies query policy rules to fetch queries, the rules
+// can be updated at runtime.
+type Engine interface {
+	// Apply applies the rules to a query, returning the query to execute
+	// or a BlockedError if the query was blocked.
+	Apply(query *storage.FetchQuery) (*storage.FetchQuery, error)
+
+	// SetRules validates and sets the rules to apply.
+	SetRules(rules Rules) error
+}
+
+type engine struct {
+	sync.RWMutex
+	rules   []compiledRule
+	scope   tally.Scope
+	metrics engineMetrics
+}
+
+type engineMetrics struct {
+	evaluated  tally.Counter
+	blocked    tally.Counter
+	rewritten  tally.Counter
+	ruleErrors tally.Counter
+}
+
+func newEngineMetrics(scope tally.Scope) engineMetrics {
+	return engineMetrics{
+		evaluated:  scope.Counter("evaluated"),
+		blocked:    scope.Counter("blocked"),
+		rewritten:  scope.Counter("rewritten"),
+		ruleErrors: scope.Counter("rule-errors"),
+	}
+}
+
+// NewEngine returns a new query policy engine.
+func NewEngine(rules Rules, scope tally.Scope) (Engine, error) {
+	e := &engine{
+		scope:   scope,
+		metrics: newEngineMetrics(scope),
+	}
+	if err := e.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *engine) SetRules(rules Rules) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := rule.compile()
+		if err != nil {
+			e.metrics.ruleErrors.Inc(1)
+			return err
+		}
+		compiled = append(compiled, c)
+	}
+
+	e.Lock()
+	e.rules = compiled
+	e.Unlock()
+	return nil
+}
+
+func (e *engine) Apply(query *storage.FetchQuery) (*storage.FetchQuery, error) {
+	e.metrics.evaluated.Inc(1)
+
+	e.RLock()
+	rules := e.rules
+	e.RUnlock()
+
+	result := query
+	for _, rule := range rules {
+		if !rule.matches(result) {
+			continue
+		}
+
+		ruleScope := e.scope.Tagged(map[string]string{"rule": rule.name})
+		switch rule.action {
+		case BlockAction:
+			e.metrics.blocked.Inc(1)
+			ruleScope.Counter("rule-blocked").Inc(1)
+			return nil, BlockedError{Rule: rule.name}
+		case RewriteAction:
+			e.metrics.rewritten.Inc(1)
+			ruleScope.Counter("rule-rewritten").Inc(1)
+			result = rule.rewrite(result)
+		}
+	}
+
+	return result, nil
+}
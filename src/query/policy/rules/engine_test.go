@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestQuery(t *testing.T, rangeDuration time.Duration, matchers ...*models.Matcher) *storage.FetchQuery {
+	end := time.Now().Truncate(time.Hour)
+	return &storage.FetchQuery{
+		TagMatchers: matchers,
+		Start:       end.Add(-rangeDuration),
+		End:         end,
+	}
+}
+
+func newTestMatcher(t *testing.T, matchType models.MatchType, name, value string) *models.Matcher {
+	m, err := models.NewMatcher(matchType, name, value)
+	require.NoError(t, err)
+	return m
+}
+
+func TestEngineBlocksMatchingQueries(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	engine, err := NewEngine(Rules{
+		{Name: "names", Action: BlockAction, MetricNames: []string{"expensive"}},
+		{Name: "noequality", Action: BlockAction, NoEqualityMatcher: true},
+		{Name: "range", Action: BlockAction, RangeExceeds: 30 * 24 * time.Hour},
+	}, scope)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query   *storage.FetchQuery
+		blocked string
+	}{
+		{
+			query: newTestQuery(t, time.Hour,
+				newTestMatcher(t, models.MatchEqual, models.MetricName, "expensive")),
+			blocked: "names",
+		},
+		{
+			query: newTestQuery(t, time.Hour,
+				newTestMatcher(t, models.MatchRegexp, models.MetricName, "foo.*")),
+			blocked: "noequality",
+		},
+		{
+			query: newTestQuery(t, 60*24*time.Hour,
+				newTestMatcher(t, models.MatchEqual, models.MetricName, "cheap")),
+			blocked: "range",
+		},
+		{
+			query: newTestQuery(t, time.Hour,
+				newTestMatcher(t, models.MatchEqual, models.MetricName, "cheap")),
+		},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Apply(test.query)
+		if test.blocked == "" {
+			require.NoError(t, err)
+			assert.Equal(t, test.query, result)
+			continue
+		}
+
+		require.Error(t, err)
+		assert.True(t, IsBlockedError(err))
+		assert.Equal(t, BlockedError{Rule: test.blocked}, err)
+	}
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(4), counters["evaluated+"].Value())
+	assert.Equal(t, int64(3), counters["blocked+"].Value())
+}
+
+func TestEngineRewritesMatchingQueries(t *testing.T) {
+	engine, err := NewEngine(Rules{
+		{
+			Name:             "tenant",
+			Action:           RewriteAction,
+			MetricNameRegexp: "tenant_.*",
+			InjectMatchers: []MatcherConfiguration{
+				{Name: "tenant", Value: "a"},
+			},
+			CapRange: 7 * 24 * time.Hour,
+		},
+	}, tally.NoopScope)
+	require.NoError(t, err)
+
+	query := newTestQuery(t, 30*24*time.Hour,
+		newTestMatcher(t, models.MatchEqual, models.MetricName, "tenant_requests"),
+		newTestMatcher(t, models.MatchRegexp, "tenant", ".*"))
+
+	result, err := engine.Apply(query)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(result.TagMatchers))
+	assert.Equal(t, models.MetricName, result.TagMatchers[0].Name)
+	assert.Equal(t, "tenant", result.TagMatchers[1].Name)
+	assert.Equal(t, models.MatchEqual, result.TagMatchers[1].Type)
+	assert.Equal(t, "a", result.TagMatchers[1].Value)
+	assert.Equal(t, query.End.Add(-7*24*time.Hour), result.Start)
+	assert.Equal(t, query.End, result.End)
+
+	// Original query is left untouched
+	assert.Equal(t, ".*", query.TagMatchers[1].Value)
+	assert.Equal(t, query.End.Add(-30*24*time.Hour), query.Start)
+}
+
+func TestEngineSetRulesValidates(t *testing.T) {
+	engine, err := NewEngine(nil, tally.NoopScope)
+	require.NoError(t, err)
+
+	require.Equal(t, errRuleNoName, engine.SetRules(Rules{{Action: BlockAction}}))
+	require.Equal(t, errRuleNoConditions, engine.SetRules(Rules{{Name: "all", Action: BlockAction}}))
+	require.Equal(t, errRuleNoRewrite, engine.SetRules(Rules{{Name: "noop", Action: RewriteAction}}))
+	require.Error(t, engine.SetRules(Rules{{Name: "bad", Action: "unknown"}}))
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`
+- name: range
+  action: block
+  rangeExceeds: 720h
+`))
+	require.NoError(t, err)
+	require.Equal(t, Rules{
+		{Name: "range", Action: BlockAction, RangeExceeds: 720 * time.Hour},
+	}, rules)
+}
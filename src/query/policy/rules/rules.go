@@ -0,0 +1,286 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	errRuleNoName       = errors.New("query policy rule has no name")
+	errRuleNoConditions = errors.New("query policy rule has no conditions")
+	errRuleNoRewrite    = errors.New("query policy rewrite rule has nothing to rewrite")
+)
+
+// Action is an action to take when a rule matches a query.
+type Action string
+
+const (
+	// BlockAction rejects the query.
+	BlockAction Action = "block"
+	// RewriteAction rewrites the query before it is executed.
+	RewriteAction Action = "rewrite"
+)
+
+// BlockedError is returned when a query is blocked by a rule.
+type BlockedError struct {
+	Rule string
+}
+
+func (e BlockedError) Error() string {
+	return fmt.Sprintf("query blocked by query policy rule: %s", e.Rule)
+}
+
+// IsBlockedError returns true if the error is a query blocked error.
+func IsBlockedError(err error) bool {
+	_, ok := err.(BlockedError)
+	return ok
+}
+
+// MatcherConfiguration is the configuration of a tag matcher.
+type MatcherConfiguration struct {
+	Name  string `yaml:"name" validate:"nonzero"`
+	Type  string `yaml:"type"`
+	Value string `yaml:"value"`
+}
+
+// NewMatcher returns a new matcher from the configuration.
+func (c MatcherConfiguration) NewMatcher() (*models.Matcher, error) {
+	matchType := models.MatchEqual
+	if c.Type != "" {
+		var ok bool
+		matchType, ok = parseMatchType(c.Type)
+		if !ok {
+			return nil, fmt.Errorf("invalid match type: %s", c.Type)
+		}
+	}
+	return models.NewMatcher(matchType, c.Name, c.Value)
+}
+
+func parseMatchType(str string) (models.MatchType, bool) {
+	for _, t := range []models.MatchType{
+		models.MatchEqual,
+		models.MatchNotEqual,
+		models.MatchRegexp,
+		models.MatchNotRegexp,
+		models.MatchGreaterThan,
+		models.MatchGreaterThanOrEqual,
+		models.MatchLessThan,
+		models.MatchLessThanOrEqual,
+	} {
+		if t.String() == str {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// Rule is a query policy rule, all of the specified conditions must
+// be met by a query for the rule to match it.
+type Rule struct {
+	// Name is the name of the rule, used for audit metrics.
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// Action is the action to take when the rule matches.
+	Action Action `yaml:"action"`
+
+	// MetricNames matches queries for any of the specified metric names.
+	MetricNames []string `yaml:"metricNames"`
+
+	// MetricNameRegexp matches queries for metric names matching the regexp.
+	MetricNameRegexp string `yaml:"metricNameRegexp"`
+
+	// NoEqualityMatcher matches queries that only have regexp or negated
+	// matchers and hence have to scan large parts of the index.
+	NoEqualityMatcher bool `yaml:"noEqualityMatcher"`
+
+	// RangeExceeds matches queries with a time range larger than the value.
+	RangeExceeds time.Duration `yaml:"rangeExceeds"`
+
+	// InjectMatchers are matchers added to a query when rewriting,
+	// matchers on a tag already matched by the query are replaced.
+	InjectMatchers []MatcherConfiguration `yaml:"injectMatchers"`
+
+	// CapRange caps the time range of a query when rewriting by
+	// moving the start of the query forward.
+	CapRange time.Duration `yaml:"capRange"`
+}
+
+// Rules is a set of query policy rules.
+type Rules []Rule
+
+// ParseRules parses a YAML (or JSON) encoded set of rules.
+func ParseRules(data []byte) (Rules, error) {
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+type compiledRule struct {
+	name              string
+	action            Action
+	metricNames       map[string]struct{}
+	metricNameRegexp  *regexp.Regexp
+	noEqualityMatcher bool
+	rangeExceeds      time.Duration
+	injectMatchers    models.Matchers
+	capRange          time.Duration
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	if r.Name == "" {
+		return compiledRule{}, errRuleNoName
+	}
+
+	c := compiledRule{
+		name:              r.Name,
+		action:            r.Action,
+		noEqualityMatcher: r.NoEqualityMatcher,
+		rangeExceeds:      r.RangeExceeds,
+		capRange:          r.CapRange,
+	}
+
+	switch c.action {
+	case BlockAction:
+	case RewriteAction:
+		if len(r.InjectMatchers) == 0 && r.CapRange <= 0 {
+			return compiledRule{}, errRuleNoRewrite
+		}
+	default:
+		return compiledRule{}, fmt.Errorf("query policy rule %s has invalid action: %s",
+			r.Name, r.Action)
+	}
+
+	if len(r.MetricNames) > 0 {
+		c.metricNames = make(map[string]struct{}, len(r.MetricNames))
+		for _, name := range r.MetricNames {
+			c.metricNames[name] = struct{}{}
+		}
+	}
+
+	if r.MetricNameRegexp != "" {
+		re, err := regexp.Compile("^(?:" + r.MetricNameRegexp + ")$")
+		if err != nil {
+			return compiledRule{}, err
+		}
+		c.metricNameRegexp = re
+	}
+
+	if c.metricNames == nil && c.metricNameRegexp == nil &&
+		!c.noEqualityMatcher && c.rangeExceeds <= 0 && c.action == BlockAction {
+		// A block rule without conditions would block every query.
+		return compiledRule{}, errRuleNoConditions
+	}
+
+	for _, cfg := range r.InjectMatchers {
+		m, err := cfg.NewMatcher()
+		if err != nil {
+			return compiledRule{}, err
+		}
+		c.injectMatchers = append(c.injectMatchers, m)
+	}
+
+	return c, nil
+}
+
+func (r compiledRule) matches(query *storage.FetchQuery) bool {
+	if r.metricNames != nil || r.metricNameRegexp != nil {
+		name, ok := metricName(query.TagMatchers)
+		if !ok {
+			return false
+		}
+		if _, ok := r.metricNames[name]; !ok && r.metricNames != nil {
+			return false
+		}
+		if r.metricNameRegexp != nil && !r.metricNameRegexp.MatchString(name) {
+			return false
+		}
+	}
+
+	if r.noEqualityMatcher && hasEqualityMatcher(query.TagMatchers) {
+		return false
+	}
+
+	if r.rangeExceeds > 0 && query.End.Sub(query.Start) <= r.rangeExceeds {
+		return false
+	}
+
+	return true
+}
+
+func (r compiledRule) rewrite(query *storage.FetchQuery) *storage.FetchQuery {
+	rewritten := *query
+	if len(r.injectMatchers) > 0 {
+		matchers := make(models.Matchers, 0,
+			len(query.TagMatchers)+len(r.injectMatchers))
+		for _, m := range query.TagMatchers {
+			if !hasMatcherForName(r.injectMatchers, m.Name) {
+				matchers = append(matchers, m)
+			}
+		}
+		rewritten.TagMatchers = append(matchers, r.injectMatchers...)
+	}
+
+	if r.capRange > 0 {
+		if earliest := query.End.Add(-r.capRange); query.Start.Before(earliest) {
+			rewritten.Start = earliest
+		}
+	}
+
+	return &rewritten
+}
+
+func metricName(matchers models.Matchers) (string, bool) {
+	for _, m := range matchers {
+		if m.Name == models.MetricName && m.Type == models.MatchEqual {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+func hasEqualityMatcher(matchers models.Matchers) bool {
+	for _, m := range matchers {
+		if m.Type == models.MatchEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMatcherForName(matchers models.Matchers, name string) bool {
+	for _, m := range matchers {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
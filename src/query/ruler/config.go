@@ -0,0 +1,241 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ruler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser/promql"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultInterval          = time.Minute
+	defaultKVKey             = "_ruler/members"
+	defaultHeartbeatInterval = 10 * time.Second
+	defaultHeartbeatTimeout  = 30 * time.Second
+)
+
+var (
+	errNoKVStore = errors.New("rule evaluation sharding requires a KV store")
+
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Configuration is the configuration of recording rule evaluation.
+type Configuration struct {
+	// Groups are the rule groups to evaluate.
+	Groups []GroupConfiguration `yaml:"groups"`
+
+	// Sharding if set shares the evaluation of the groups between the
+	// coordinator replicas, otherwise every group is evaluated by each
+	// replica which should only be used with a single replica.
+	Sharding *ShardingConfiguration `yaml:"sharding"`
+}
+
+// GroupConfiguration is the configuration of a rule group.
+type GroupConfiguration struct {
+	// Name is the name of the group, names must be unique.
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// Interval is the interval the group is evaluated at, defaults to 1m.
+	Interval time.Duration `yaml:"interval" validate:"min=0"`
+
+	// Rules are the rules of the group, evaluated in order.
+	Rules []RuleConfiguration `yaml:"rules" validate:"nonzero"`
+}
+
+// RuleConfiguration is the configuration of a recording rule.
+type RuleConfiguration struct {
+	// Record is the name of the series the result is written as.
+	Record string `yaml:"record" validate:"nonzero"`
+
+	// Expr is the PromQL expression to evaluate.
+	Expr string `yaml:"expr" validate:"nonzero"`
+
+	// Labels are added to the series of the result, replacing labels of
+	// the same name.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// ShardingConfiguration is the configuration of sharding rule groups
+// between coordinator replicas, each group is evaluated by the replica
+// owning it on a consistent hash ring of the replicas heartbeating to a
+// KV key.
+type ShardingConfiguration struct {
+	// KVKey is the key replicas heartbeat to, replicas sharing groups must
+	// use the same key, defaults to _ruler/members.
+	KVKey string `yaml:"kvKey"`
+
+	// InstanceID identifies the replica, defaults to the hostname.
+	InstanceID string `yaml:"instanceID"`
+
+	// HeartbeatInterval is how often the replica heartbeats, defaults to
+	// 10s.
+	HeartbeatInterval time.Duration `yaml:"heartbeatInterval" validate:"min=0"`
+
+	// HeartbeatTimeout is how long after its last heartbeat a replica stops
+	// being a member and its groups move to other replicas, defaults to
+	// 30s.
+	HeartbeatTimeout time.Duration `yaml:"heartbeatTimeout" validate:"min=0"`
+}
+
+// ShardingOptions are the options of the membership of a replica.
+type ShardingOptions struct {
+	KVKey             string
+	HeartbeatInterval time.Duration
+	HeartbeatTimeout  time.Duration
+}
+
+// Options returns the options of the sharding configuration with defaults
+// applied.
+func (c ShardingConfiguration) Options() (ShardingOptions, error) {
+	opts := ShardingOptions{
+		KVKey:             defaultKVKey,
+		HeartbeatInterval: defaultHeartbeatInterval,
+		HeartbeatTimeout:  defaultHeartbeatTimeout,
+	}
+	if c.KVKey != "" {
+		opts.KVKey = c.KVKey
+	}
+	if c.HeartbeatInterval > 0 {
+		opts.HeartbeatInterval = c.HeartbeatInterval
+	}
+	if c.HeartbeatTimeout > 0 {
+		opts.HeartbeatTimeout = c.HeartbeatTimeout
+	}
+	if opts.HeartbeatTimeout <= opts.HeartbeatInterval {
+		return opts, fmt.Errorf(
+			"heartbeat timeout %v must be greater than heartbeat interval %v",
+			opts.HeartbeatTimeout, opts.HeartbeatInterval)
+	}
+	return opts, nil
+}
+
+// Groups returns the validated groups of the configuration.
+func (c Configuration) Groups() ([]Group, error) {
+	var (
+		groups = make([]Group, 0, len(c.Groups))
+		names  = make(map[string]struct{}, len(c.Groups))
+	)
+	for _, groupCfg := range c.Groups {
+		if _, ok := names[groupCfg.Name]; ok {
+			return nil, fmt.Errorf("duplicate rule group %s", groupCfg.Name)
+		}
+		names[groupCfg.Name] = struct{}{}
+
+		group := Group{
+			Name:     groupCfg.Name,
+			Interval: defaultInterval,
+			Rules:    make([]Rule, 0, len(groupCfg.Rules)),
+		}
+		if groupCfg.Interval > 0 {
+			group.Interval = groupCfg.Interval
+		}
+
+		for _, ruleCfg := range groupCfg.Rules {
+			rule, err := ruleCfg.rule()
+			if err != nil {
+				return nil, fmt.Errorf("rule group %s: %v", groupCfg.Name, err)
+			}
+			group.Rules = append(group.Rules, rule)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (c RuleConfiguration) rule() (Rule, error) {
+	if !metricNameRegex.MatchString(c.Record) {
+		return Rule{}, fmt.Errorf("invalid record name %q", c.Record)
+	}
+	if _, err := promql.Parse(c.Expr); err != nil {
+		return Rule{}, fmt.Errorf("rule %s: invalid expr: %v", c.Record, err)
+	}
+
+	labels := make(models.Tags, len(c.Labels))
+	for name, value := range c.Labels {
+		if !labelNameRegex.MatchString(name) || name == models.MetricName {
+			return Rule{}, fmt.Errorf("rule %s: invalid label name %q",
+				c.Record, name)
+		}
+		labels[name] = value
+	}
+
+	return Rule{
+		Record: c.Record,
+		Expr:   c.Expr,
+		Labels: labels,
+	}, nil
+}
+
+// NewRuler returns a ruler evaluating the configured groups, replicas join
+// the members sharing the groups when the returned membership is started.
+// The membership is nil if sharding is not configured.
+func (c Configuration) NewRuler(
+	engine *executor.Engine,
+	store storage.Storage,
+	kvStore kv.Store,
+	scope tally.Scope,
+	logger *zap.Logger,
+) (*Ruler, *Membership, error) {
+	groups, err := c.Groups()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.Sharding == nil {
+		return NewRuler(engine, store, groups, nil, scope, logger), nil, nil
+	}
+
+	if kvStore == nil {
+		return nil, nil, errNoKVStore
+	}
+
+	opts, err := c.Sharding.Options()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id := c.Sharding.InstanceID
+	if id == "" {
+		id, err = os.Hostname()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	membership := NewMembership(kvStore, id, opts,
+		scope.SubScope("membership"), logger)
+	ruler := NewRuler(engine, store, groups, membership, scope, logger)
+	return ruler, membership, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ruler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestConfigurationGroups(t *testing.T) {
+	var cfg Configuration
+	require.NoError(t, yaml.Unmarshal([]byte(`
+groups:
+  - name: http
+    interval: 30s
+    rules:
+      - record: job:http_requests:rate5m
+        expr: sum(rate(http_requests[5m])) by (job)
+        labels:
+          team: edge
+  - name: cpu
+    rules:
+      - record: instance:cpu:avg
+        expr: avg(cpu) by (instance)
+`), &cfg))
+
+	groups, err := cfg.Groups()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(groups))
+	assert.Equal(t, 30*time.Second, groups[0].Interval)
+	assert.Equal(t, defaultInterval, groups[1].Interval)
+	assert.Equal(t, models.Tags{"team": "edge"}, groups[0].Rules[0].Labels)
+}
+
+func TestConfigurationGroupsInvalid(t *testing.T) {
+	rule := RuleConfiguration{Record: "valid", Expr: "up"}
+	tests := []struct {
+		name   string
+		groups []GroupConfiguration
+	}{
+		{
+			name: "duplicate group",
+			groups: []GroupConfiguration{
+				{Name: "a", Rules: []RuleConfiguration{rule}},
+				{Name: "a", Rules: []RuleConfiguration{rule}},
+			},
+		},
+		{
+			name: "invalid record",
+			groups: []GroupConfiguration{{Name: "a", Rules: []RuleConfiguration{
+				{Record: "not valid", Expr: "up"},
+			}}},
+		},
+		{
+			name: "invalid expr",
+			groups: []GroupConfiguration{{Name: "a", Rules: []RuleConfiguration{
+				{Record: "valid", Expr: "sum(("},
+			}}},
+		},
+		{
+			name: "invalid label",
+			groups: []GroupConfiguration{{Name: "a", Rules: []RuleConfiguration{
+				{Record: "valid", Expr: "up", Labels: map[string]string{"a-b": "c"}},
+			}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Configuration{Groups: test.groups}.Groups()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestShardingOptions(t *testing.T) {
+	opts, err := ShardingConfiguration{}.Options()
+	require.NoError(t, err)
+	assert.Equal(t, ShardingOptions{
+		KVKey:             defaultKVKey,
+		HeartbeatInterval: defaultHeartbeatInterval,
+		HeartbeatTimeout:  defaultHeartbeatTimeout,
+	}, opts)
+
+	_, err = ShardingConfiguration{HeartbeatTimeout: time.Second}.Options()
+	assert.Error(t, err)
+}
@@ -0,0 +1,310 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ruler
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+// maxUpdateAttempts is the number of times an update of the members key is
+// retried when it conflicts with the update of another member.
+const maxUpdateAttempts = 5
+
+var errUpdateConflict = errors.New("members key updated concurrently")
+
+// membersValue is the value of the members key, a string proto holding the
+// JSON encoded unix nanos of the last heartbeat of each member.
+type membersValue struct {
+	Members map[string]int64 `json:"members"`
+}
+
+// Membership tracks the coordinator replicas sharing the evaluation of rule
+// groups by their heartbeats to a KV key, replicas which have not
+// heartbeated within the timeout are no longer members and their groups
+// move to the remaining members.
+type Membership struct {
+	sync.RWMutex
+
+	store   kv.Store
+	key     string
+	id      string
+	opts    ShardingOptions
+	logger  *zap.Logger
+	metrics membershipMetrics
+	nowFn   func() time.Time
+
+	members  []string
+	ring     *Ring
+	closed   bool
+	closedCh chan struct{}
+	doneCh   chan struct{}
+}
+
+type membershipMetrics struct {
+	members         tally.Gauge
+	heartbeatErrors tally.Counter
+	ringChanges     tally.Counter
+}
+
+func newMembershipMetrics(scope tally.Scope) membershipMetrics {
+	return membershipMetrics{
+		members:         scope.Gauge("members"),
+		heartbeatErrors: scope.Counter("heartbeat.errors"),
+		ringChanges:     scope.Counter("ring.changes"),
+	}
+}
+
+// NewMembership returns a membership of the replica with the ID given,
+// the replica joins once the membership is started.
+func NewMembership(
+	store kv.Store,
+	id string,
+	opts ShardingOptions,
+	scope tally.Scope,
+	logger *zap.Logger,
+) *Membership {
+	return &Membership{
+		store:    store,
+		key:      opts.KVKey,
+		id:       id,
+		opts:     opts,
+		logger:   logger,
+		metrics:  newMembershipMetrics(scope),
+		nowFn:    time.Now,
+		ring:     NewRing([]string{id}),
+		members:  []string{id},
+		closedCh: make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// Start joins the members and keeps heartbeating until closed.
+func (m *Membership) Start() error {
+	if err := m.heartbeat(); err != nil {
+		return err
+	}
+
+	watch, err := m.store.Watch(m.key)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer close(m.doneCh)
+		defer watch.Close()
+
+		ticker := time.NewTicker(m.opts.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.heartbeat(); err != nil {
+					// The last known members are kept, evaluating a group on
+					// two replicas writes the same datapoints twice while not
+					// evaluating it at all leaves a gap.
+					m.metrics.heartbeatErrors.Inc(1)
+					m.logger.Warn("unable to heartbeat rule evaluation membership",
+						zap.String("key", m.key), zap.Any("error", err))
+				}
+			case <-watch.C():
+				members, err := decodeMembers(watch.Get())
+				if err != nil {
+					m.logger.Warn("unable to decode rule evaluation members",
+						zap.String("key", m.key), zap.Any("error", err))
+					continue
+				}
+				m.setMembers(members)
+			case <-m.closedCh:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Owns returns whether the replica evaluates the group.
+func (m *Membership) Owns(group string) bool {
+	m.RLock()
+	defer m.RUnlock()
+	return m.ring.Owner(group) == m.id
+}
+
+// Members returns the live members.
+func (m *Membership) Members() []string {
+	m.RLock()
+	defer m.RUnlock()
+	return append([]string(nil), m.members...)
+}
+
+// Close stops heartbeating and leaves the members so that the groups of the
+// replica move to the remaining members without waiting for the timeout.
+func (m *Membership) Close() error {
+	m.Lock()
+	if m.closed {
+		m.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.Unlock()
+
+	close(m.closedCh)
+	<-m.doneCh
+	return m.update(func(members map[string]int64) {
+		delete(members, m.id)
+	})
+}
+
+func (m *Membership) heartbeat() error {
+	return m.update(func(members map[string]int64) {
+		members[m.id] = m.nowFn().UnixNano()
+	})
+}
+
+// update applies the function to the members read from KV and writes them
+// back unless another member updated them in between, members whose last
+// heartbeat is older than the timeout are removed.
+func (m *Membership) update(fn func(members map[string]int64)) error {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		var (
+			members map[string]int64
+			version int
+		)
+		value, err := m.store.Get(m.key)
+		switch err {
+		case nil:
+			version = value.Version()
+			members, err = decodeMembers(value)
+			if err != nil {
+				return err
+			}
+		case kv.ErrNotFound:
+			members = make(map[string]int64)
+		default:
+			return err
+		}
+
+		fn(members)
+		m.expire(members)
+
+		data, err := json.Marshal(membersValue{Members: members})
+		if err != nil {
+			return err
+		}
+		protoValue := &commonpb.StringProto{Value: string(data)}
+		if version == 0 {
+			_, err = m.store.SetIfNotExists(m.key, protoValue)
+		} else {
+			_, err = m.store.CheckAndSet(m.key, version, protoValue)
+		}
+		if err == kv.ErrVersionMismatch || err == kv.ErrAlreadyExists {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		m.setMembers(members)
+		return nil
+	}
+	return errUpdateConflict
+}
+
+func (m *Membership) expire(members map[string]int64) {
+	cutoff := m.nowFn().Add(-m.opts.HeartbeatTimeout).UnixNano()
+	for id, heartbeat := range members {
+		if heartbeat < cutoff {
+			delete(members, id)
+		}
+	}
+}
+
+func (m *Membership) setMembers(heartbeats map[string]int64) {
+	m.expire(heartbeats)
+	members := make([]string, 0, len(heartbeats))
+	for id := range heartbeats {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+
+	if len(members) == 0 {
+		// Every heartbeat expired, e.g. the members key was deleted, keep
+		// evaluating every group until the next heartbeat rejoins.
+		members = []string{m.id}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed || equalMembers(m.members, members) {
+		return
+	}
+
+	m.members = members
+	m.ring = NewRing(members)
+	m.metrics.members.Update(float64(len(members)))
+	m.metrics.ringChanges.Inc(1)
+	m.logger.Info("rule evaluation members changed",
+		zap.Strings("members", members))
+}
+
+func decodeMembers(value kv.Value) (map[string]int64, error) {
+	members := make(map[string]int64)
+	if value == nil {
+		return members, nil
+	}
+
+	protoValue := &commonpb.StringProto{}
+	if err := value.Unmarshal(protoValue); err != nil {
+		return nil, err
+	}
+	var decoded membersValue
+	if err := json.Unmarshal([]byte(protoValue.Value), &decoded); err != nil {
+		return nil, err
+	}
+	for id, heartbeat := range decoded.Members {
+		members[id] = heartbeat
+	}
+	return members, nil
+}
+
+func equalMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
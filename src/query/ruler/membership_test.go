@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ruler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3cluster/kv/mem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+func testShardingOptions() ShardingOptions {
+	return ShardingOptions{
+		KVKey:             defaultKVKey,
+		HeartbeatInterval: time.Hour,
+		HeartbeatTimeout:  2 * time.Hour,
+	}
+}
+
+func TestMembershipShardsGroups(t *testing.T) {
+	store := mem.NewStore()
+	a := NewMembership(store, "a", testShardingOptions(), tally.NoopScope, zap.NewNop())
+	b := NewMembership(store, "b", testShardingOptions(), tally.NoopScope, zap.NewNop())
+	require.NoError(t, a.Start())
+	require.NoError(t, b.Start())
+
+	// a learns of b through the watch of the members key.
+	require.True(t, waitFor(func() bool { return len(a.Members()) == 2 }))
+	assert.Equal(t, []string{"a", "b"}, b.Members())
+
+	var ownedByA, ownedByB int
+	for i := 0; i < 100; i++ {
+		group := fmt.Sprintf("group-%d", i)
+		ownsA, ownsB := a.Owns(group), b.Owns(group)
+		assert.True(t, ownsA != ownsB, "group %s must have one owner", group)
+		if ownsA {
+			ownedByA++
+		} else {
+			ownedByB++
+		}
+	}
+	assert.True(t, ownedByA > 0)
+	assert.True(t, ownedByB > 0)
+
+	// Groups move to the remaining member once a member leaves.
+	require.NoError(t, b.Close())
+	require.True(t, waitFor(func() bool { return len(a.Members()) == 1 }))
+	for i := 0; i < 100; i++ {
+		assert.True(t, a.Owns(fmt.Sprintf("group-%d", i)))
+	}
+	require.NoError(t, a.Close())
+}
+
+func TestMembershipExpiresMembers(t *testing.T) {
+	store := mem.NewStore()
+	now := time.Now()
+	a := NewMembership(store, "a", testShardingOptions(), tally.NoopScope, zap.NewNop())
+	a.nowFn = func() time.Time { return now }
+	b := NewMembership(store, "b", testShardingOptions(), tally.NoopScope, zap.NewNop())
+	b.nowFn = func() time.Time { return now }
+
+	require.NoError(t, b.heartbeat())
+	require.NoError(t, a.heartbeat())
+	assert.Equal(t, []string{"a", "b"}, a.Members())
+
+	// b stops heartbeating and is removed once its heartbeat times out.
+	now = now.Add(3 * time.Hour)
+	require.NoError(t, a.heartbeat())
+	assert.Equal(t, []string{"a"}, a.Members())
+}
+
+func waitFor(fn func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if fn() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
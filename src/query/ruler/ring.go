@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ruler
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// virtualNodes is the number of points each member has on the ring, more
+// points spread groups more evenly between members.
+const virtualNodes = 128
+
+// Ring assigns rule groups to members by consistent hashing of the group
+// name, so that when a member joins or leaves only the groups it owns or
+// takes over move between members.
+type Ring struct {
+	points  []uint64
+	members map[uint64]string
+}
+
+// NewRing returns a ring of the members.
+func NewRing(members []string) *Ring {
+	r := &Ring{
+		points:  make([]uint64, 0, len(members)*virtualNodes),
+		members: make(map[uint64]string, len(members)*virtualNodes),
+	}
+	for _, member := range members {
+		for i := 0; i < virtualNodes; i++ {
+			point := murmur3.Sum64([]byte(member + "/" + strconv.Itoa(i)))
+			if _, ok := r.members[point]; ok {
+				continue
+			}
+			r.points = append(r.points, point)
+			r.members[point] = member
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		return r.points[i] < r.points[j]
+	})
+	return r
+}
+
+// Owner returns the member owning the group, empty if the ring has no
+// members.
+func (r *Ring) Owner(group string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	hash := murmur3.Sum64([]byte(group))
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.members[r.points[i]]
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ruler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingOwnerBalanced(t *testing.T) {
+	ring := NewRing([]string{"a", "b", "c"})
+
+	owned := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		owned[ring.Owner(fmt.Sprintf("group-%d", i))]++
+	}
+	assert.Equal(t, 3, len(owned))
+	for member, count := range owned {
+		assert.True(t, count > 600, "member %s owns %d groups", member, count)
+	}
+}
+
+func TestRingOwnerStable(t *testing.T) {
+	before := NewRing([]string{"a", "b", "c"})
+	after := NewRing([]string{"a", "b", "c", "d"})
+
+	// Adding a member only moves groups to the new member.
+	for i := 0; i < 1000; i++ {
+		group := fmt.Sprintf("group-%d", i)
+		if owner := after.Owner(group); owner != "d" {
+			assert.Equal(t, before.Owner(group), owner, group)
+		}
+	}
+}
+
+func TestRingOwnerEmpty(t *testing.T) {
+	assert.Equal(t, "", NewRing(nil).Owner("group"))
+}
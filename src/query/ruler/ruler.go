@@ -0,0 +1,369 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ruler evaluates recording rules in the coordinator, rule groups
+// are either all evaluated by one replica or sharded between replicas by
+// consistent hashing of their names.
+package ruler
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser/promql"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3x/errors"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/spaolacci/murmur3"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+// Owner decides which rule groups a replica evaluates.
+type Owner interface {
+	// Owns returns whether the replica evaluates the group.
+	Owns(group string) bool
+}
+
+// allGroups is the owner of a replica evaluating every group.
+type allGroups struct{}
+
+func (allGroups) Owns(string) bool { return true }
+
+// Group is a named list of recording rules evaluated in order at an
+// interval, later rules see the results of earlier rules of the group.
+type Group struct {
+	Name     string
+	Interval time.Duration
+	Rules    []Rule
+}
+
+// Rule records the result of an expression as series with the name given.
+type Rule struct {
+	Record string
+	Expr   string
+	Labels models.Tags
+}
+
+// Ruler evaluates the rule groups owned by the replica and writes their
+// results as series.
+type Ruler struct {
+	engine  *executor.Engine
+	store   storage.Storage
+	groups  []Group
+	owner   Owner
+	logger  *zap.Logger
+	metrics rulerMetrics
+	nowFn   func() time.Time
+
+	wg       sync.WaitGroup
+	closedCh chan struct{}
+}
+
+type rulerMetrics struct {
+	evaluations       tally.Counter
+	evaluationErrors  tally.Counter
+	writeErrors       tally.Counter
+	skipped           tally.Counter
+	missedIterations  tally.Counter
+	seriesWritten     tally.Counter
+	evaluationLatency tally.Timer
+}
+
+func newRulerMetrics(scope tally.Scope) rulerMetrics {
+	return rulerMetrics{
+		evaluations:       scope.Counter("evaluations"),
+		evaluationErrors:  scope.Counter("evaluation.errors"),
+		writeErrors:       scope.Counter("write.errors"),
+		skipped:           scope.Counter("groups.skipped"),
+		missedIterations:  scope.Counter("iterations.missed"),
+		seriesWritten:     scope.Counter("series.written"),
+		evaluationLatency: scope.Timer("group.evaluation-latency"),
+	}
+}
+
+// NewRuler returns a ruler evaluating the groups owned by the owner, every
+// group is evaluated if the owner is nil.
+func NewRuler(
+	engine *executor.Engine,
+	store storage.Storage,
+	groups []Group,
+	owner Owner,
+	scope tally.Scope,
+	logger *zap.Logger,
+) *Ruler {
+	if owner == nil {
+		owner = allGroups{}
+	}
+	return &Ruler{
+		engine:   engine,
+		store:    store,
+		groups:   groups,
+		owner:    owner,
+		logger:   logger,
+		metrics:  newRulerMetrics(scope),
+		nowFn:    time.Now,
+		closedCh: make(chan struct{}),
+	}
+}
+
+// Start starts evaluating the groups.
+func (r *Ruler) Start() {
+	for _, group := range r.groups {
+		r.wg.Add(1)
+		go r.run(group)
+	}
+}
+
+// Close stops evaluating the groups and waits for running evaluations.
+func (r *Ruler) Close() {
+	close(r.closedCh)
+	r.wg.Wait()
+}
+
+func (r *Ruler) run(group Group) {
+	defer r.wg.Done()
+
+	// Groups are evaluated at a fixed offset within their interval derived
+	// from their name, spreading the evaluations of groups with the same
+	// interval while keeping evaluation times the same on every replica so
+	// that a group moving between replicas rewrites identical timestamps.
+	offset := time.Duration(murmur3.Sum64([]byte(group.Name)) %
+		uint64(group.Interval))
+	for {
+		now := r.nowFn()
+		next := now.Truncate(group.Interval).Add(offset)
+		if !next.After(now) {
+			next = next.Add(group.Interval)
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C:
+		case <-r.closedCh:
+			timer.Stop()
+			return
+		}
+
+		if !r.owner.Owns(group.Name) {
+			r.metrics.skipped.Inc(1)
+			continue
+		}
+
+		r.evaluate(group, next)
+		if elapsed := r.nowFn().Sub(next); elapsed > group.Interval {
+			r.metrics.missedIterations.Inc(int64(elapsed / group.Interval))
+			r.logger.Warn("rule group evaluation exceeded its interval",
+				zap.String("group", group.Name),
+				zap.Duration("interval", group.Interval),
+				zap.Duration("elapsed", elapsed))
+		}
+	}
+}
+
+// evaluate evaluates the rules of the group in order at the time given.
+func (r *Ruler) evaluate(group Group, at time.Time) {
+	start := r.nowFn()
+	defer func() {
+		r.metrics.evaluationLatency.Record(r.nowFn().Sub(start))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), group.Interval)
+	defer cancel()
+
+	for _, rule := range group.Rules {
+		r.metrics.evaluations.Inc(1)
+		samples, err := r.query(ctx, rule.Expr, at, group.Interval)
+		if err != nil {
+			r.metrics.evaluationErrors.Inc(1)
+			r.logger.Warn("unable to evaluate recording rule",
+				zap.String("group", group.Name),
+				zap.String("record", rule.Record),
+				zap.Any("error", err))
+			continue
+		}
+
+		if err := r.write(ctx, rule, at, samples); err != nil {
+			r.metrics.writeErrors.Inc(1)
+			r.logger.Warn("unable to write recording rule results",
+				zap.String("group", group.Name),
+				zap.String("record", rule.Record),
+				zap.Any("error", err))
+		}
+	}
+}
+
+type sample struct {
+	tags  models.Tags
+	value float64
+}
+
+// query evaluates the expression at the time given, returning the value of
+// every series at that time.
+func (r *Ruler) query(
+	ctx context.Context,
+	expr string,
+	at time.Time,
+	interval time.Duration,
+) ([]sample, error) {
+	parser, err := promql.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	params := models.RequestParams{
+		Start:   at,
+		End:     at,
+		Now:     at,
+		Step:    interval,
+		Timeout: interval,
+		Target:  expr,
+	}
+
+	// Results is closed by ExecuteExpr
+	results := make(chan executor.Query)
+	opts := &executor.EngineOptions{Stats: models.NewQueryStats()}
+	go r.engine.ExecuteExpr(ctx, parser, opts, params, results)
+
+	var (
+		samples    []sample
+		processErr error
+	)
+	for result := range results {
+		if result.Err != nil {
+			if processErr == nil {
+				processErr = result.Err
+			}
+			continue
+		}
+
+		for blkResult := range result.Result.ResultChan() {
+			// Keep draining once an error is seen so the engine is not blocked.
+			if processErr != nil {
+				if blkResult.Block != nil {
+					blkResult.Block.Close()
+				}
+				continue
+			}
+
+			if blkResult.Err != nil {
+				processErr = blkResult.Err
+				continue
+			}
+
+			blockSamples, err := lastSamples(blkResult.Block)
+			blkResult.Block.Close()
+			if err != nil {
+				processErr = err
+				continue
+			}
+
+			samples = append(samples, blockSamples...)
+		}
+	}
+
+	if processErr != nil {
+		return nil, processErr
+	}
+
+	return samples, nil
+}
+
+// lastSamples returns the non-NaN values at the last step of each series in
+// the block, tagged with the block's common tags and the series' own tags.
+func lastSamples(b block.Block) ([]sample, error) {
+	iter, err := b.SeriesIter()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	commonTags := iter.Meta().Tags
+	var samples []sample
+	for iter.Next() {
+		series, err := iter.Current()
+		if err != nil {
+			return nil, err
+		}
+
+		if series.Len() == 0 {
+			continue
+		}
+
+		v := series.ValueAtStep(series.Len() - 1)
+		if math.IsNaN(v) {
+			continue
+		}
+
+		tags := make(models.Tags, len(commonTags)+len(series.Meta.Tags))
+		for k, v := range commonTags {
+			tags[k] = v
+		}
+		for k, v := range series.Meta.Tags {
+			tags[k] = v
+		}
+
+		samples = append(samples, sample{tags: tags, value: v})
+	}
+
+	return samples, nil
+}
+
+// write writes the samples as series named by the rule with the labels of
+// the rule added.
+func (r *Ruler) write(
+	ctx context.Context,
+	rule Rule,
+	at time.Time,
+	samples []sample,
+) error {
+	var multiErr xerrors.MultiError
+	for _, s := range samples {
+		tags := s.tags
+		for name, value := range rule.Labels {
+			tags[name] = value
+		}
+		tags[models.MetricName] = rule.Record
+
+		multiErr = multiErr.Add(r.store.Write(ctx, &storage.WriteQuery{
+			Tags: tags,
+			Datapoints: ts.Datapoints{{
+				Timestamp: at,
+				Value:     s.value,
+			}},
+			Unit: xtime.Millisecond,
+			Attributes: storage.Attributes{
+				MetricsType: storage.UnaggregatedMetricsType,
+			},
+		}))
+	}
+	if err := multiErr.FinalError(); err != nil {
+		return err
+	}
+	r.metrics.seriesWritten.Inc(int64(len(samples)))
+	return nil
+}
@@ -33,28 +33,46 @@ import (
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/cmd/services/m3query/config/reload"
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/environment/backend"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/x/logsample"
+	"github.com/m3db/m3/src/dbnode/x/tracing"
+	"github.com/m3db/m3/src/query/api/v1/handler/otlp"
 	"github.com/m3db/m3/src/query/api/v1/httpd"
 	m3dbcluster "github.com/m3db/m3/src/query/cluster/m3db"
 	"github.com/m3db/m3/src/query/executor"
 	"github.com/m3db/m3/src/query/policy/filter"
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/ruler"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/events"
 	"github.com/m3db/m3/src/query/storage/fanout"
 	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3/src/query/storage/readonly"
 	"github.com/m3db/m3/src/query/storage/remote"
+	"github.com/m3db/m3/src/query/storage/retry"
+	"github.com/m3db/m3/src/query/storage/routing"
+	"github.com/m3db/m3/src/query/storage/validation"
 	"github.com/m3db/m3/src/query/stores/m3db"
 	tsdbRemote "github.com/m3db/m3/src/query/tsdb/remote"
+	"github.com/m3db/m3/src/query/tsdb/thanos"
 	"github.com/m3db/m3/src/query/util/logging"
+	aggclient "github.com/m3db/m3aggregator/client"
 	clusterclient "github.com/m3db/m3cluster/client"
 	etcdclient "github.com/m3db/m3cluster/client/etcd"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3cluster/services"
 	"github.com/m3db/m3x/clock"
 	xconfig "github.com/m3db/m3x/config"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/instrument"
 	"github.com/m3db/m3x/pool"
+	xretry "github.com/m3db/m3x/retry"
 	xsync "github.com/m3db/m3x/sync"
 
+	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -126,14 +144,24 @@ func Run(runOpts RunOptions) {
 
 	var clusterManagementClient clusterclient.Client
 	if clusterClientCh == nil {
-		var etcdCfg *etcdclient.Configuration
+		var (
+			etcdCfg    *etcdclient.Configuration
+			backendCfg *backend.Configuration
+		)
 		switch {
+		case cfg.ClusterManagement != nil && cfg.ClusterManagement.Backend != nil:
+			backendCfg = cfg.ClusterManagement.Backend
+
 		case cfg.ClusterManagement != nil:
 			etcdCfg = &cfg.ClusterManagement.Etcd
 
 		case len(cfg.Clusters) == 1 &&
 			cfg.Clusters[0].Client.EnvironmentConfig.Service != nil:
 			etcdCfg = cfg.Clusters[0].Client.EnvironmentConfig.Service
+
+		case len(cfg.Clusters) == 1 &&
+			cfg.Clusters[0].Client.EnvironmentConfig.Backend != nil:
+			backendCfg = cfg.Clusters[0].Client.EnvironmentConfig.Backend
 		}
 
 		if etcdCfg != nil {
@@ -143,7 +171,19 @@ func Run(runOpts RunOptions) {
 			if err != nil {
 				logger.Fatal("unable to create cluster management etcd client", zap.Any("error", err))
 			}
+		}
 
+		if backendCfg != nil {
+			// We resolved a Consul or ZooKeeper configuration for cluster management endpoints
+			clusterManagementClient, err = backendCfg.NewClient(instrument.NewOptions().
+				SetZapLogger(logger).
+				SetMetricsScope(scope.SubScope("cluster-management")))
+			if err != nil {
+				logger.Fatal("unable to create cluster management client", zap.Any("error", err))
+			}
+		}
+
+		if clusterManagementClient != nil {
 			clusterClientSendableCh := make(chan clusterclient.Client, 1)
 			clusterClientSendableCh <- clusterManagementClient
 			clusterClientCh = clusterClientSendableCh
@@ -211,7 +251,74 @@ func Run(runOpts RunOptions) {
 		return workerPool
 	})
 
-	fanoutStorage, storageCleanup := newStorages(logger, clusters, cfg, objectPool)
+	var (
+		routedClusters map[string]local.Clusters
+		routedStores   map[string]storage.Storage
+	)
+	if cfg.Routing != nil {
+		opts := local.ClustersStaticConfigurationOptions{
+			AsyncSessions: true,
+		}
+		routedClusters, err = cfg.Routing.NewClusters(opts)
+		if err != nil {
+			logger.Fatal("unable to connect to routing clusters", zap.Any("error", err))
+		}
+		routedStores = cfg.Routing.NewStores(routedClusters, objectPool)
+	}
+
+	var readOnlyFlags *readonly.Flags
+	if readOnlyCfg := cfg.ReadOnly; readOnlyCfg != nil {
+		var readOnlyKVStore kv.Store
+		if clusterManagementClient != nil {
+			readOnlyKVStore, err = clusterManagementClient.KV()
+			if err != nil {
+				logger.Fatal("unable to create KV store for read only flags",
+					zap.Any("error", err))
+			}
+		}
+
+		readOnlyFlags, err = readOnlyCfg.NewFlags(readOnlyKVStore, logger)
+		if err != nil {
+			logger.Fatal("unable to create read only flags", zap.Any("error", err))
+		}
+
+		logger.Info("configured read only mode",
+			zap.Bool("enabled", readOnlyCfg.Enabled),
+			zap.Strings("namespaces", readOnlyCfg.Namespaces),
+			zap.String("kvKey", readOnlyCfg.KVKey))
+	}
+
+	var validationEngine validation.Engine
+	if validationCfg := cfg.WriteValidation; validationCfg != nil {
+		var validationKVStore kv.Store
+		if clusterManagementClient != nil {
+			validationKVStore, err = clusterManagementClient.KV()
+			if err != nil {
+				logger.Fatal("unable to create KV store for write validation rules",
+					zap.Any("error", err))
+			}
+		}
+
+		validationEngine, err = validationCfg.NewEngine(validationKVStore,
+			scope.SubScope("write-validation"), logger)
+		if err != nil {
+			logger.Fatal("unable to create write validation engine",
+				zap.Any("error", err))
+		}
+
+		logger.Info("configured write validation",
+			zap.Int("numRules", len(validationCfg.Rules)),
+			zap.String("kvKey", validationCfg.KVKey))
+	}
+
+	var fetchRetrier xretry.Retrier
+	if retryCfg := cfg.QueryRetry; retryCfg != nil {
+		fetchRetrier = retryCfg.NewRetrier(scope.SubScope("fetch-retry"))
+	}
+
+	fanoutStorage, routingStorage, storageCleanup := newStorages(logger,
+		clusters, routedStores, readOnlyFlags, validationEngine, fetchRetrier,
+		cfg, objectPool, scope)
 	defer storageCleanup()
 
 	var clusterClient clusterclient.Client
@@ -227,25 +334,116 @@ func Run(runOpts RunOptions) {
 		namespaces  = clusters.ClusterNamespaces()
 		downsampler downsample.Downsampler
 	)
-	if n := namespaces.NumAggregatedClusterNamespaces(); n > 0 {
+	if n := namespaces.NumDownsampledClusterNamespaces(); n > 0 {
 		logger.Info("configuring downsampler to use with aggregated cluster namespaces",
 			zap.Int("numAggregatedClusterNamespaces", n))
-		downsampler = newDownsampler(logger, clusterManagementClient,
-			fanoutStorage, instrumentOptions)
+		downsampler = newDownsampler(logger, cfg.Downsample,
+			clusterManagementClient, fanoutStorage,
+			cfg.LogSampling.NewSampler(scope.SubScope("log-sampling")),
+			instrumentOptions)
 	}
 
-	engine := executor.NewEngine(fanoutStorage)
+	var (
+		queryStorage = fanoutStorage
+		policyEngine rules.Engine
+	)
+	if policyCfg := cfg.QueryPolicy; policyCfg != nil {
+		var policyKVStore kv.Store
+		if clusterManagementClient != nil {
+			policyKVStore, err = clusterManagementClient.KV()
+			if err != nil {
+				logger.Fatal("unable to create KV store for query policy rules",
+					zap.Any("error", err))
+			}
+		}
 
-	handler, err := httpd.NewHandler(fanoutStorage, downsampler, engine,
+		policyEngine, err = policyCfg.NewEngine(policyKVStore,
+			scope.SubScope("query-policy"), logger)
+		if err != nil {
+			logger.Fatal("unable to create query policy engine", zap.Any("error", err))
+		}
+
+		logger.Info("configured query policy rules",
+			zap.Int("numStaticRules", len(policyCfg.Rules)),
+			zap.String("kvKey", policyCfg.KVKey))
+		queryStorage = rules.NewStorage(fanoutStorage, policyEngine)
+	}
+
+	engine := executor.NewEngine(queryStorage)
+	engine.SetResampleInterpolation(cfg.ResampleInterpolation)
+	engine.SetDecimation(cfg.Decimation)
+	if enrichCfg := cfg.Enrichment; enrichCfg != nil {
+		var enrichKVStore kv.Store
+		if clusterManagementClient != nil {
+			enrichKVStore, err = clusterManagementClient.KV()
+			if err != nil {
+				logger.Fatal("unable to create KV store for enrichment lookup tables",
+					zap.Any("error", err))
+			}
+		}
+
+		enricher, err := enrichCfg.NewEnricher(enrichKVStore,
+			scope.SubScope("enrichment"), logger)
+		if err != nil {
+			logger.Fatal("unable to create enricher", zap.Any("error", err))
+		}
+		defer enricher.Close()
+
+		logger.Info("configured enrichment lookup tables",
+			zap.Int("numTables", len(enrichCfg.Tables)))
+		engine.SetEnricher(enricher)
+	}
+	if cacheCfg := cfg.EvaluationCache; cacheCfg != nil {
+		logger.Info("caching transform results", zap.Int("size", cacheCfg.Size))
+		engine.SetEvaluationCache(cacheCfg.NewEvaluationCache(
+			scope.SubScope("evaluation-cache")))
+	}
+
+	if spillCfg := cfg.Spill; spillCfg != nil {
+		spiller, err := spillCfg.NewSpiller(scope.SubScope("spill"))
+		if err != nil {
+			logger.Fatal("unable to create spiller", zap.Any("error", err))
+		}
+
+		logger.Info("spilling transform blocks to disk",
+			zap.Int("memoryBudget", spillCfg.MemoryBudget))
+		engine.SetSpiller(spiller)
+	}
+
+	handler, err := httpd.NewHandler(queryStorage, downsampler, engine,
 		clusterClient, cfg, runOpts.DBConfig, scope)
 	if err != nil {
 		logger.Fatal("unable to set up handlers", zap.Any("error", err))
 	}
+	defer handler.Close()
+	handler.SetEventStore(events.NewStore(clusters))
+	if reloadCfg := cfg.ConfigReload; reloadCfg != nil {
+		reloader := newReloader(logger, reloadCfg, cfg, runOpts.ConfigFile,
+			clusterManagementClient, policyEngine, validationEngine,
+			routingStorage, routedStores, scope.SubScope("config-reload"))
+		handler.SetConfigReloader(reloader)
+	}
 	handler.RegisterRoutes()
 
+	if thanosCfg := cfg.ThanosStore; thanosCfg != nil {
+		thanosServer := startThanosStoreServer(logger, queryStorage, thanosCfg)
+		defer thanosServer.GracefulStop()
+	}
+
+	if rulerCfg := cfg.Ruler; rulerCfg != nil {
+		rulerInstance := startRuler(logger, engine, queryStorage, rulerCfg,
+			clusterManagementClient, scope.SubScope("ruler"))
+		defer rulerInstance.Close()
+	}
+
+	if otlpCfg := cfg.OTLP; otlpCfg != nil && otlpCfg.GRPCListenAddress != "" {
+		otlpServer := startOTLPServer(logger, queryStorage, otlpCfg, scope.SubScope("otlp"))
+		defer otlpServer.GracefulStop()
+	}
+
 	logger.Info("starting server", zap.String("address", cfg.ListenAddress))
 	go func() {
-		if err := http.ListenAndServe(cfg.ListenAddress, handler.Router); err != nil {
+		if err := http.ListenAndServe(cfg.ListenAddress, tracing.NewHTTPHandler(handler.Router)); err != nil {
 			logger.Fatal("unable to serve on listen address",
 				zap.Any("address", cfg.ListenAddress), zap.Any("error", err))
 		}
@@ -267,12 +465,20 @@ func Run(runOpts RunOptions) {
 	if err := clusters.Close(); err != nil {
 		logger.Fatal("unable to close M3DB cluster sessions", zap.Any("error", err))
 	}
+	for name, routed := range routedClusters {
+		if err := routed.Close(); err != nil {
+			logger.Fatal("unable to close routing cluster sessions",
+				zap.String("cluster", name), zap.Any("error", err))
+		}
+	}
 }
 
 func newDownsampler(
 	logger *zap.Logger,
+	cfg downsample.Configuration,
 	clusterManagementClient clusterclient.Client,
 	storage storage.Storage,
+	logSampler *logsample.Sampler,
 	instrumentOpts instrument.Options,
 ) downsample.Downsampler {
 	if clusterManagementClient == nil {
@@ -297,15 +503,52 @@ func newDownsampler(
 			SetMetricsScope(instrumentOpts.MetricsScope().
 				SubScope("tag-decoder-pool")))
 
+	var remoteAggregatorClient aggclient.Client
+	if remoteCfg := cfg.RemoteAggregator; remoteCfg != nil {
+		logger.Info("configuring downsampler to use remote aggregator")
+		remoteAggregatorClient, err = remoteCfg.NewClient(clusterManagementClient,
+			clock.NewOptions(), instrumentOpts.SetMetricsScope(
+				instrumentOpts.MetricsScope().SubScope("remote-aggregator-client")))
+		if err != nil {
+			logger.Fatal("unable to create remote aggregator client",
+				zap.Any("error", err))
+		}
+	}
+
+	var (
+		leaderService     services.LeaderService
+		leaderValue       string
+		flushTimesKVStore kv.Store
+	)
+	if electionCfg := cfg.LeaderElection; electionCfg != nil {
+		leaderService, leaderValue, err = electionCfg.NewLeaderService(
+			clusterManagementClient)
+		if err != nil {
+			logger.Fatal("unable to create downsampler leader service",
+				zap.Any("error", err))
+		}
+
+		// Share flush times between replicas so a newly elected leader
+		// does not reflush values already flushed by the previous leader.
+		flushTimesKVStore = kvStore
+		logger.Info("configuring downsampler to campaign for leadership",
+			zap.String("leaderValue", leaderValue))
+	}
+
 	downsampler, err := downsample.NewDownsampler(downsample.DownsamplerOptions{
-		Storage:               storage,
-		RulesKVStore:          kvStore,
-		ClockOptions:          clock.NewOptions(),
-		InstrumentOptions:     instrumentOpts,
-		TagEncoderOptions:     tagEncoderOptions,
-		TagDecoderOptions:     tagDecoderOptions,
-		TagEncoderPoolOptions: tagEncoderPoolOptions,
-		TagDecoderPoolOptions: tagDecoderPoolOptions,
+		Storage:                storage,
+		RulesKVStore:           kvStore,
+		ClockOptions:           clock.NewOptions(),
+		InstrumentOptions:      instrumentOpts,
+		TagEncoderOptions:      tagEncoderOptions,
+		TagDecoderOptions:      tagDecoderOptions,
+		TagEncoderPoolOptions:  tagEncoderPoolOptions,
+		TagDecoderPoolOptions:  tagDecoderPoolOptions,
+		LeaderService:          leaderService,
+		LeaderValue:            leaderValue,
+		FlushTimesKVStore:      flushTimesKVStore,
+		RemoteAggregatorClient: remoteAggregatorClient,
+		LogSampler:             logSampler,
 	})
 	if err != nil {
 		logger.Fatal("unable to create downsampler", zap.Any("error", err))
@@ -314,15 +557,154 @@ func newDownsampler(
 	return downsampler
 }
 
+// newReloader returns a reloader for the sections of the configuration that
+// were configured at startup, reloading on SIGHUP and KV changes.
+func newReloader(
+	logger *zap.Logger,
+	reloadCfg *config.ConfigReloadConfiguration,
+	cfg config.Configuration,
+	configFile string,
+	clusterManagementClient clusterclient.Client,
+	policyEngine rules.Engine,
+	validationEngine validation.Engine,
+	routingStorage routing.Storage,
+	routedStores map[string]storage.Storage,
+	scope tally.Scope,
+) *reload.Reloader {
+	var (
+		kvStore kv.Store
+		err     error
+	)
+	if reloadCfg.KVKey != "" && clusterManagementClient != nil {
+		kvStore, err = clusterManagementClient.KV()
+		if err != nil {
+			logger.Fatal("unable to create KV store for configuration reloads",
+				zap.Any("error", err))
+		}
+	}
+
+	loadFn := reload.NewLoadFn(configFile, kvStore, reloadCfg.KVKey)
+	if configFile == "" && reloadCfg.KVKey == "" {
+		// Without a configuration file or KV key the configuration can
+		// only be reloaded as it was provided.
+		loadFn = func() (config.Configuration, error) {
+			return cfg, nil
+		}
+	}
+
+	reloader, err := reload.NewReloader(cfg, loadFn, scope, logger)
+	if err != nil {
+		logger.Fatal("unable to create configuration reloader", zap.Any("error", err))
+	}
+
+	var sections []reload.Section
+	if policyEngine != nil {
+		sections = append(sections, reload.NewQueryPolicySection(policyEngine))
+	}
+	if validationEngine != nil {
+		sections = append(sections, reload.NewWriteValidationSection(validationEngine))
+	}
+	if routingStorage != nil {
+		sections = append(sections, reload.NewRoutingSection(routingStorage,
+			routedStores))
+	}
+	for _, section := range sections {
+		if err := reloader.AddSection(section); err != nil {
+			logger.Fatal("unable to add configuration reload section",
+				zap.String("section", section.Name()), zap.Any("error", err))
+		}
+	}
+
+	if reloadCfg.KVKey != "" {
+		if err := reload.WatchKV(reloader, kvStore, reloadCfg.KVKey, logger); err != nil {
+			logger.Fatal("unable to watch configuration reload KV key",
+				zap.String("kvKey", reloadCfg.KVKey), zap.Any("error", err))
+		}
+	}
+
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			reloader.Reload(reload.SignalTrigger)
+		}
+	}()
+
+	logger.Info("configured configuration reloads",
+		zap.Int("numSections", len(sections)),
+		zap.String("kvKey", reloadCfg.KVKey))
+	return reloader
+}
+
 func newStorages(
 	logger *zap.Logger,
 	clusters local.Clusters,
+	routedStores map[string]storage.Storage,
+	readOnlyFlags *readonly.Flags,
+	validationEngine validation.Engine,
+	fetchRetrier xretry.Retrier,
 	cfg config.Configuration,
 	workerPool pool.ObjectPool,
-) (storage.Storage, func()) {
-	cleanup := func() {}
+	scope tally.Scope,
+) (storage.Storage, routing.Storage, func()) {
+	var (
+		cleanup        = func() {}
+		routingStorage routing.Storage
+	)
 
 	localStorage := local.NewStorage(clusters, workerPool)
+	if cfg.Partitioning != nil {
+		logger.Info("partitioning unaggregated metrics by time",
+			zap.String("namespacePrefix", cfg.Partitioning.NamespacePrefix),
+			zap.Stringer("period", cfg.Partitioning.Period))
+		localStorage = cfg.Partitioning.NewStorage(localStorage, clusters,
+			workerPool)
+	}
+	if cfg.Routing != nil {
+		var err error
+		routingStorage, err = cfg.Routing.NewStorage(localStorage, routedStores)
+		if err != nil {
+			logger.Fatal("unable to create routing storage", zap.Any("error", err))
+		}
+		localStorage = routingStorage
+	}
+	if cfg.HotSeriesSalt != nil {
+		logger.Info("salting writes of hot series",
+			zap.Strings("metrics", cfg.HotSeriesSalt.Metrics),
+			zap.Int("buckets", cfg.HotSeriesSalt.Buckets))
+		localStorage = cfg.HotSeriesSalt.NewStorage(localStorage)
+	}
+	if validationEngine != nil {
+		localStorage = validation.NewStorage(localStorage, validationEngine)
+	}
+	if deltaCfg := cfg.DeltaConversion; deltaCfg != nil {
+		// Converted before validation so that validation rules see the
+		// cumulative values that are written.
+		convertedStorage, err := deltaCfg.NewStorage(localStorage,
+			scope.SubScope("delta-conversion"))
+		if err != nil {
+			logger.Fatal("unable to create delta conversion storage",
+				zap.Any("error", err))
+		}
+		logger.Info("converting delta temporality series to cumulative series",
+			zap.Int("numMatchers", len(deltaCfg.Matchers)))
+		localStorage = convertedStorage
+	}
+	if ingestCfg := cfg.IngestEnrichment; ingestCfg != nil {
+		enrichedStorage, err := ingestCfg.NewStorage(localStorage,
+			scope.SubScope("ingest-enrichment"))
+		if err != nil {
+			logger.Fatal("unable to create ingest enrichment storage",
+				zap.Any("error", err))
+		}
+		logger.Info("enriching ingested series with service discovery metadata",
+			zap.Int("numRules", len(ingestCfg.Rules)))
+		localStorage = enrichedStorage
+	}
+	if readOnlyFlags != nil {
+		localStorage = readonly.NewStorage(localStorage, clusters,
+			readOnlyFlags)
+	}
 	stores := []storage.Storage{localStorage}
 	remoteEnabled := false
 	if cfg.RPC != nil && cfg.RPC.Enabled {
@@ -348,8 +730,16 @@ func newStorages(
 		readFilter = filter.AllowAll
 	}
 
+	if fetchRetrier != nil {
+		// Retry the fetches of each storage separately so that the results
+		// of the storages that succeeded are kept.
+		for i, store := range stores {
+			stores[i] = retry.NewStorage(store, fetchRetrier)
+		}
+	}
+
 	fanoutStorage := fanout.NewStorage(stores, readFilter, filter.LocalOnly)
-	return fanoutStorage, cleanup
+	return fanoutStorage, routingStorage, cleanup
 }
 
 func startGrpcServer(logger *zap.Logger, storage storage.Storage, cfg *config.RPCConfiguration) *grpc.Server {
@@ -366,3 +756,100 @@ func startGrpcServer(logger *zap.Logger, storage storage.Storage, cfg *config.RP
 	<-waitForStart
 	return server
 }
+
+func startThanosStoreServer(logger *zap.Logger, storage storage.Storage, cfg *thanos.Configuration) *grpc.Server {
+	logger.Info("creating thanos store gRPC server",
+		zap.Any("externalLabels", cfg.ExternalLabels))
+	server := cfg.NewServer(storage)
+	waitForStart := make(chan struct{})
+	go func() {
+		logger.Info("starting thanos store gRPC server", zap.String("address", cfg.ListenAddress))
+		err := thanos.StartNewGrpcServer(server, cfg.ListenAddress, waitForStart)
+		if err != nil {
+			logger.Fatal("unable to start thanos store gRPC server", zap.Any("error", err))
+		}
+	}()
+	<-waitForStart
+	return server
+}
+
+func startOTLPServer(
+	logger *zap.Logger,
+	storage storage.Storage,
+	cfg *otlp.Configuration,
+	scope tally.Scope,
+) *grpc.Server {
+	ingester, err := otlp.NewIngester(storage, *cfg, scope)
+	if err != nil {
+		logger.Fatal("unable to create OTLP ingester", zap.Any("error", err))
+	}
+	server := otlp.NewGRPCServer(ingester)
+	waitForStart := make(chan struct{})
+	go func() {
+		logger.Info("starting OTLP gRPC server", zap.String("address", cfg.GRPCListenAddress))
+		err := otlp.StartGRPCServer(server, cfg.GRPCListenAddress, waitForStart)
+		if err != nil {
+			logger.Fatal("unable to start OTLP gRPC server", zap.Any("error", err))
+		}
+	}()
+	<-waitForStart
+	return server
+}
+
+type startedRuler struct {
+	ruler      *ruler.Ruler
+	membership *ruler.Membership
+	logger     *zap.Logger
+}
+
+func (r startedRuler) Close() {
+	r.ruler.Close()
+	if r.membership != nil {
+		if err := r.membership.Close(); err != nil {
+			r.logger.Warn("unable to leave rule evaluation members",
+				zap.Any("error", err))
+		}
+	}
+}
+
+func startRuler(
+	logger *zap.Logger,
+	engine *executor.Engine,
+	storage storage.Storage,
+	cfg *ruler.Configuration,
+	clusterClient clusterclient.Client,
+	scope tally.Scope,
+) startedRuler {
+	var kvStore kv.Store
+	if cfg.Sharding != nil && clusterClient != nil {
+		var err error
+		kvStore, err = clusterClient.KV()
+		if err != nil {
+			logger.Fatal("unable to create KV store for rule evaluation sharding",
+				zap.Any("error", err))
+		}
+	}
+
+	rulerInstance, membership, err := cfg.NewRuler(engine, storage, kvStore,
+		scope, logger)
+	if err != nil {
+		logger.Fatal("unable to create ruler", zap.Any("error", err))
+	}
+
+	if membership != nil {
+		if err := membership.Start(); err != nil {
+			logger.Fatal("unable to join rule evaluation members",
+				zap.Any("error", err))
+		}
+		logger.Info("sharding rule evaluation between coordinator replicas",
+			zap.Strings("members", membership.Members()))
+	}
+
+	logger.Info("evaluating recording rules", zap.Int("numGroups", len(cfg.Groups)))
+	rulerInstance.Start()
+	return startedRuler{
+		ruler:      rulerInstance,
+		membership: membership,
+		logger:     logger,
+	}
+}
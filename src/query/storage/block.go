@@ -30,7 +30,8 @@ import (
 
 // FetchResultToBlockResult converts a fetch result into coordinator blocks
 func FetchResultToBlockResult(result *FetchResult, query *FetchQuery) (block.Result, error) {
-	alignedSeriesList, err := result.SeriesList.Align(query.Start, query.End, query.Interval)
+	alignedSeriesList, err := result.SeriesList.Decimate(query.Start, query.End,
+		query.Interval, query.Decimation)
 	if err != nil {
 		return block.Result{}, err
 	}
@@ -56,6 +57,11 @@ func newMultiSeriesBlock(seriesList ts.SeriesList, query *FetchQuery) (multiSeri
 		return multiSeriesBlock{}, err
 	}
 
+	if len(seriesList) == 0 {
+		// Keep the steps of the query for functions such as absent
+		resolution = query.Interval
+	}
+
 	meta := block.Metadata{
 		Bounds: block.Bounds{
 			Start:    query.Start,
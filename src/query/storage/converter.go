@@ -205,7 +205,9 @@ func iteratorToTsSeries(
 		datapoints = append(datapoints, ts.Datapoint{Timestamp: dp.Timestamp, Value: dp.Value})
 	}
 
-	return ts.NewSeries(metric.ID, datapoints, metric.Tags), nil
+	series := ts.NewSeries(metric.ID, datapoints, metric.Tags)
+	series.SetCompleteness(iter.Completeness())
+	return series, nil
 }
 
 // Fall back to sequential decompression if unable to decompress concurrently
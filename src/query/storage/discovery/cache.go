@@ -0,0 +1,235 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultCacheTTL            = time.Minute
+	defaultCacheMaxStaleness   = 10 * time.Minute
+	defaultCacheErrorRetry     = 10 * time.Second
+	defaultCacheMaxEntries     = 100000
+	defaultCacheResolveTimeout = 2 * time.Second
+)
+
+// CacheOptions are the options of the cache of resolved metadata.
+type CacheOptions struct {
+	// TTL is how long resolved metadata is used before it is resolved
+	// again in the background.
+	TTL time.Duration
+
+	// MaxStaleness is how long resolved metadata is used while it can not
+	// be resolved again, after which writes wait for it to be resolved.
+	MaxStaleness time.Duration
+
+	// ErrorRetryInterval is how long a failure to resolve metadata is
+	// returned for before it is resolved again.
+	ErrorRetryInterval time.Duration
+
+	// MaxEntries is the max number of objects cached.
+	MaxEntries int
+
+	// ResolveTimeout is the timeout of resolving the metadata of an object.
+	ResolveTimeout time.Duration
+}
+
+func (o CacheOptions) withDefaults() CacheOptions {
+	if o.TTL <= 0 {
+		o.TTL = defaultCacheTTL
+	}
+	if o.MaxStaleness < o.TTL {
+		o.MaxStaleness = defaultCacheMaxStaleness
+		if o.MaxStaleness < o.TTL {
+			o.MaxStaleness = o.TTL
+		}
+	}
+	if o.ErrorRetryInterval <= 0 {
+		o.ErrorRetryInterval = defaultCacheErrorRetry
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = defaultCacheMaxEntries
+	}
+	if o.ResolveTimeout <= 0 {
+		o.ResolveTimeout = defaultCacheResolveTimeout
+	}
+	return o
+}
+
+type cacheKey struct {
+	resolver string
+	object   ObjectKey
+}
+
+type cacheEntry struct {
+	metadata Metadata
+	err      error
+	resolved time.Time
+
+	// attempted is when resolution was last attempted, failures to resolve
+	// metadata again are retried no more often than the error retry interval.
+	attempted time.Time
+
+	// done is closed once a resolution in progress completes, nil while
+	// no resolution is in progress.
+	done chan struct{}
+}
+
+type cacheMetrics struct {
+	hits      tally.Counter
+	misses    tally.Counter
+	staleHits tally.Counter
+	refreshes tally.Counter
+	evictions tally.Counter
+}
+
+func newCacheMetrics(scope tally.Scope) cacheMetrics {
+	return cacheMetrics{
+		hits:      scope.Counter("hits"),
+		misses:    scope.Counter("misses"),
+		staleHits: scope.Counter("stale-hits"),
+		refreshes: scope.Counter("refreshes"),
+		evictions: scope.Counter("evictions"),
+	}
+}
+
+// cache caches the metadata of objects with bounded staleness, metadata
+// older than the TTL is resolved again in the background while the cached
+// metadata continues to be used until it is older than the max staleness.
+type cache struct {
+	sync.Mutex
+	opts    CacheOptions
+	entries map[cacheKey]*cacheEntry
+	nowFn   func() time.Time
+	metrics cacheMetrics
+}
+
+func newCache(opts CacheOptions, scope tally.Scope) *cache {
+	return &cache{
+		opts:    opts.withDefaults(),
+		entries: make(map[cacheKey]*cacheEntry),
+		nowFn:   time.Now,
+		metrics: newCacheMetrics(scope),
+	}
+}
+
+// get returns the metadata of the object, resolving it with the resolver if
+// it is not cached or the cached metadata is too stale to be used.
+func (c *cache) get(
+	ctx context.Context,
+	name string,
+	resolver Resolver,
+	object ObjectKey,
+) (Metadata, error) {
+	key := cacheKey{resolver: name, object: object}
+
+	c.Lock()
+	entry, ok := c.entries[key]
+	if ok && !entry.resolved.IsZero() {
+		now := c.nowFn()
+		age := now.Sub(entry.resolved)
+		switch {
+		case entry.err != nil && age < c.opts.ErrorRetryInterval:
+			c.Unlock()
+			c.metrics.hits.Inc(1)
+			return nil, entry.err
+		case entry.err == nil && age < c.opts.TTL:
+			c.Unlock()
+			c.metrics.hits.Inc(1)
+			return entry.metadata, nil
+		case entry.err == nil && age < c.opts.MaxStaleness:
+			retry := now.Sub(entry.attempted) >= c.opts.ErrorRetryInterval
+			if entry.done == nil && retry {
+				entry.done = make(chan struct{})
+				go c.resolve(key, entry, resolver)
+				c.metrics.refreshes.Inc(1)
+			}
+			c.Unlock()
+			c.metrics.staleHits.Inc(1)
+			return entry.metadata, nil
+		}
+	}
+
+	if !ok {
+		c.evictIfFullWithLock()
+		entry = &cacheEntry{}
+		c.entries[key] = entry
+	}
+	if entry.done == nil {
+		entry.done = make(chan struct{})
+		go c.resolve(key, entry, resolver)
+	}
+	done := entry.done
+	c.Unlock()
+	c.metrics.misses.Inc(1)
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.Lock()
+	metadata, err := entry.metadata, entry.err
+	c.Unlock()
+	return metadata, err
+}
+
+// resolve resolves the metadata of the entry, the resolution is not bound
+// to the context of any one write as other writes may be waiting for it.
+func (c *cache) resolve(key cacheKey, entry *cacheEntry, resolver Resolver) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.ResolveTimeout)
+	metadata, err := resolver.Resolve(ctx, key.object)
+	cancel()
+
+	c.Lock()
+	entry.attempted = c.nowFn()
+	if err == nil || entry.resolved.IsZero() || entry.err != nil ||
+		c.nowFn().Sub(entry.resolved) >= c.opts.MaxStaleness {
+		// Keep serving the metadata last resolved if it is not too stale.
+		entry.metadata, entry.err = metadata, err
+		entry.resolved = entry.attempted
+	}
+	close(entry.done)
+	entry.done = nil
+	c.Unlock()
+}
+
+// evictIfFullWithLock evicts an arbitrary entry without a resolution in
+// progress if the cache is full.
+func (c *cache) evictIfFullWithLock() {
+	if len(c.entries) < c.opts.MaxEntries {
+		return
+	}
+	for key, entry := range c.entries {
+		if entry.done != nil {
+			continue
+		}
+		delete(c.entries, key)
+		c.metrics.evictions.Inc(1)
+		return
+	}
+}
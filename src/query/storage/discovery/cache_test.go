@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.Lock()
+	c.now = c.now.Add(d)
+	c.Unlock()
+}
+
+func newTestCache(opts CacheOptions) (*cache, *testClock) {
+	clock := &testClock{now: time.Now()}
+	c := newCache(opts, tally.NoopScope)
+	c.nowFn = clock.Now
+	return c, clock
+}
+
+// waitForRefresh waits for the background resolution of the key to finish.
+func waitForRefresh(c *cache, key cacheKey) {
+	c.Lock()
+	entry := c.entries[key]
+	done := entry.done
+	c.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+func TestCacheServesStaleWhileRefreshing(t *testing.T) {
+	var (
+		key      = ObjectKey{Name: "node-1"}
+		resolver = &testResolver{metadata: map[ObjectKey]Metadata{
+			key: {"zone": "a"},
+		}}
+		c, clock = newTestCache(CacheOptions{
+			TTL:          time.Minute,
+			MaxStaleness: 10 * time.Minute,
+		})
+		ctx = context.Background()
+	)
+
+	metadata, err := c.get(ctx, "nodes", resolver, key)
+	require.NoError(t, err)
+	assert.Equal(t, Metadata{"zone": "a"}, metadata)
+
+	// Cached within the TTL.
+	_, err = c.get(ctx, "nodes", resolver, key)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resolver.numCalls())
+
+	// Past the TTL the cached metadata is returned while it is refreshed,
+	// and kept while it fails to refresh.
+	resolver.Lock()
+	resolver.err = errors.New("unavailable")
+	resolver.Unlock()
+	clock.Add(2 * time.Minute)
+	metadata, err = c.get(ctx, "nodes", resolver, key)
+	require.NoError(t, err)
+	assert.Equal(t, Metadata{"zone": "a"}, metadata)
+	waitForRefresh(c, cacheKey{resolver: "nodes", object: key})
+	assert.Equal(t, 2, resolver.numCalls())
+
+	// Past the max staleness the failure is returned.
+	clock.Add(10 * time.Minute)
+	_, err = c.get(ctx, "nodes", resolver, key)
+	require.Error(t, err)
+	assert.Equal(t, 3, resolver.numCalls())
+
+	// Failures are cached for the error retry interval.
+	_, err = c.get(ctx, "nodes", resolver, key)
+	require.Error(t, err)
+	assert.Equal(t, 3, resolver.numCalls())
+
+	resolver.Lock()
+	resolver.err = nil
+	resolver.metadata[key] = Metadata{"zone": "b"}
+	resolver.Unlock()
+	clock.Add(time.Minute)
+	metadata, err = c.get(ctx, "nodes", resolver, key)
+	require.NoError(t, err)
+	assert.Equal(t, Metadata{"zone": "b"}, metadata)
+}
+
+func TestCacheEvictsWhenFull(t *testing.T) {
+	var (
+		resolver = &testResolver{metadata: map[ObjectKey]Metadata{
+			{Name: "a"}: {},
+			{Name: "b"}: {},
+		}}
+		c, _ = newTestCache(CacheOptions{MaxEntries: 1})
+		ctx  = context.Background()
+	)
+
+	_, err := c.get(ctx, "nodes", resolver, ObjectKey{Name: "a"})
+	require.NoError(t, err)
+	_, err = c.get(ctx, "nodes", resolver, ObjectKey{Name: "b"})
+	require.NoError(t, err)
+	assert.Len(t, c.entries, 1)
+
+	_, err = c.get(ctx, "nodes", resolver, ObjectKey{Name: "a"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, resolver.numCalls())
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/uber-go/tally"
+)
+
+const (
+	inClusterKubernetesURL       = "https://kubernetes.default.svc"
+	inClusterKubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterKubernetesCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	defaultKubernetesTimeout     = 5 * time.Second
+)
+
+var (
+	errInvalidKubernetesCA = errors.New("no certificates found in kubernetes CA file")
+)
+
+// KubernetesConfiguration is the configuration for reading the metadata of
+// pods and nodes from the Kubernetes API server.
+type KubernetesConfiguration struct {
+	// URL is the URL of the API server, defaults to the in cluster API
+	// server with the credentials of the pod's service account.
+	URL string `yaml:"url"`
+
+	// BearerTokenFile is the file of the token used for requests.
+	BearerTokenFile string `yaml:"bearerTokenFile"`
+
+	// CAFile is the file of the CA certificates of the API server.
+	CAFile string `yaml:"caFile"`
+
+	// InsecureSkipVerify skips verifying the certificate of the API server.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+
+	// Timeout is the timeout of requests, defaults to 5s.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (c KubernetesConfiguration) newClient() (*kubernetesClient, error) {
+	var (
+		url       = c.URL
+		tokenFile = c.BearerTokenFile
+		caFile    = c.CAFile
+	)
+	if url == "" {
+		url = inClusterKubernetesURL
+		if tokenFile == "" {
+			tokenFile = inClusterKubernetesTokenFile
+		}
+		if caFile == "" {
+			caFile = inClusterKubernetesCAFile
+		}
+	}
+
+	var token string
+	if tokenFile != "" {
+		data, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errInvalidKubernetesCA
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultKubernetesTimeout
+	}
+
+	return &kubernetesClient{
+		url:   strings.TrimSuffix(url, "/"),
+		token: token,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// ConsulConfiguration is the configuration for reading the metadata of
+// nodes from the Consul catalog.
+type ConsulConfiguration struct {
+	// Address is the address of the Consul agent.
+	Address string `yaml:"address" validate:"nonzero"`
+
+	// Scheme is the URI scheme of the Consul agent, defaults to http.
+	Scheme string `yaml:"scheme"`
+
+	// Datacenter is the datacenter to use, defaults to the agent's.
+	Datacenter string `yaml:"datacenter"`
+
+	// Token is the ACL token used for requests.
+	Token string `yaml:"token"`
+}
+
+func (c ConsulConfiguration) newCatalog() (*api.Catalog, error) {
+	apiCfg := api.DefaultConfig()
+	apiCfg.Address = c.Address
+	if c.Scheme != "" {
+		apiCfg.Scheme = c.Scheme
+	}
+	apiCfg.Datacenter = c.Datacenter
+	apiCfg.Token = c.Token
+
+	apiClient, err := api.NewClient(apiCfg)
+	if err != nil {
+		return nil, err
+	}
+	return apiClient.Catalog(), nil
+}
+
+// CacheConfiguration is the configuration of the cache of resolved metadata.
+type CacheConfiguration struct {
+	// TTL is how long resolved metadata is used before it is resolved
+	// again in the background, defaults to 1m.
+	TTL time.Duration `yaml:"ttl"`
+
+	// MaxStaleness is how long resolved metadata continues to be used
+	// while it can not be resolved again, defaults to 10m.
+	MaxStaleness time.Duration `yaml:"maxStaleness"`
+
+	// ErrorRetryInterval is how often metadata that failed to resolve is
+	// resolved again, defaults to 10s.
+	ErrorRetryInterval time.Duration `yaml:"errorRetryInterval"`
+
+	// MaxEntries is the max number of objects cached, defaults to 100000.
+	MaxEntries int `yaml:"maxEntries"`
+
+	// ResolveTimeout is the timeout of resolving the metadata of an object,
+	// defaults to 2s.
+	ResolveTimeout time.Duration `yaml:"resolveTimeout"`
+}
+
+// RuleConfiguration is the configuration of an enrichment rule.
+type RuleConfiguration struct {
+	// SourceTag is the tag whose value is the name of the object, e.g. pod.
+	SourceTag string `yaml:"sourceTag" validate:"nonzero"`
+
+	// NamespaceTag is the tag whose value is the namespace of the object,
+	// required for Kubernetes pods.
+	NamespaceTag string `yaml:"namespaceTag"`
+
+	// Resolver is the type of resolver of the metadata of the object, one
+	// of kubernetesPod, kubernetesNode or consulNode.
+	Resolver ResolverType `yaml:"resolver" validate:"nonzero"`
+
+	// Tags maps the metadata fields of the object to the tags they are
+	// added to the series as, e.g. deployment: deployment.
+	Tags map[string]string `yaml:"tags" validate:"nonzero"`
+}
+
+// Configuration is the configuration for enriching ingested series with
+// tags resolved from service discovery metadata.
+type Configuration struct {
+	// Kubernetes is the configuration of the Kubernetes API server, required
+	// by the kubernetesPod and kubernetesNode resolvers.
+	Kubernetes *KubernetesConfiguration `yaml:"kubernetes"`
+
+	// Consul is the configuration of the Consul agent, required by the
+	// consulNode resolver.
+	Consul *ConsulConfiguration `yaml:"consul"`
+
+	// Cache is the configuration of the cache of resolved metadata.
+	Cache CacheConfiguration `yaml:"cache"`
+
+	// Rules are the rules applied to writes in order.
+	Rules []RuleConfiguration `yaml:"rules" validate:"nonzero"`
+}
+
+// NewStorage returns a storage that enriches the writes to the base storage
+// with the configured rules.
+func (c Configuration) NewStorage(
+	base storage.Storage,
+	scope tally.Scope,
+) (storage.Storage, error) {
+	resolvers := make(map[ResolverType]Resolver)
+	if c.Kubernetes != nil {
+		client, err := c.Kubernetes.newClient()
+		if err != nil {
+			return nil, err
+		}
+		resolvers[KubernetesPodResolver] = newKubernetesPodResolver(client)
+		resolvers[KubernetesNodeResolver] = newKubernetesNodeResolver(client)
+	}
+	if c.Consul != nil {
+		catalog, err := c.Consul.newCatalog()
+		if err != nil {
+			return nil, err
+		}
+		resolvers[ConsulNodeResolver] = newConsulNodeResolver(catalog)
+	}
+
+	rules := make([]Rule, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		rules = append(rules, Rule{
+			SourceTag:    rule.SourceTag,
+			NamespaceTag: rule.NamespaceTag,
+			Resolver:     rule.Resolver,
+			Tags:         rule.Tags,
+		})
+	}
+
+	cacheOpts := CacheOptions{
+		TTL:                c.Cache.TTL,
+		MaxStaleness:       c.Cache.MaxStaleness,
+		ErrorRetryInterval: c.Cache.ErrorRetryInterval,
+		MaxEntries:         c.Cache.MaxEntries,
+		ResolveTimeout:     c.Cache.ResolveTimeout,
+	}
+	return NewStorage(base, resolvers, rules, cacheOpts, scope)
+}
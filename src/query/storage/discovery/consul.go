@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"context"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const consulMetaPrefix = "meta."
+
+type consulNodeResolver struct {
+	catalog *api.Catalog
+}
+
+// newConsulNodeResolver returns a resolver of the metadata of Consul nodes,
+// the metadata has the fields "datacenter" and "address", and the node meta
+// of the node.
+func newConsulNodeResolver(catalog *api.Catalog) Resolver {
+	return &consulNodeResolver{catalog: catalog}
+}
+
+func (r *consulNodeResolver) Resolve(
+	ctx context.Context,
+	key ObjectKey,
+) (Metadata, error) {
+	if key.Name == "" {
+		return nil, errMissingObjectName
+	}
+
+	opts := &api.QueryOptions{AllowStale: true}
+	result, _, err := r.catalog.Node(key.Name, opts.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Node == nil {
+		return nil, ErrNotFound
+	}
+
+	node := result.Node
+	metadata := make(Metadata, len(node.Meta)+2)
+	for name, value := range node.Meta {
+		metadata[consulMetaPrefix+name] = value
+	}
+	if node.Datacenter != "" {
+		metadata["datacenter"] = node.Datacenter
+	}
+	if node.Address != "" {
+		metadata["address"] = node.Address
+	}
+	return metadata, nil
+}
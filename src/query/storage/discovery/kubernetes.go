@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	kubernetesLabelPrefix     = "label."
+	kubernetesPodTemplateHash = "pod-template-hash"
+)
+
+var (
+	kubernetesZoneLabels = []string{
+		"topology.kubernetes.io/zone",
+		"failure-domain.beta.kubernetes.io/zone",
+	}
+	kubernetesRegionLabels = []string{
+		"topology.kubernetes.io/region",
+		"failure-domain.beta.kubernetes.io/region",
+	}
+	kubernetesInstanceTypeLabels = []string{
+		"node.kubernetes.io/instance-type",
+		"beta.kubernetes.io/instance-type",
+	}
+)
+
+type kubernetesObjectMeta struct {
+	Name            string                     `json:"name"`
+	Namespace       string                     `json:"namespace"`
+	Labels          map[string]string          `json:"labels"`
+	OwnerReferences []kubernetesOwnerReference `json:"ownerReferences"`
+}
+
+type kubernetesOwnerReference struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Controller *bool  `json:"controller"`
+}
+
+type kubernetesPod struct {
+	Metadata kubernetesObjectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+}
+
+type kubernetesNode struct {
+	Metadata kubernetesObjectMeta `json:"metadata"`
+}
+
+// kubernetesClient reads objects from the Kubernetes API server.
+type kubernetesClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (c *kubernetesClient) get(
+	ctx context.Context,
+	path string,
+	result interface{},
+) error {
+	req, err := http.NewRequest(http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("kubernetes API returned status %d for %s",
+			resp.StatusCode, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+type kubernetesPodResolver struct {
+	client *kubernetesClient
+}
+
+// newKubernetesPodResolver returns a resolver of the metadata of pods, the
+// metadata has the fields "node", "replicaset", "deployment", "statefulset",
+// "daemonset" and "job" when set, and the labels of the pod.
+func newKubernetesPodResolver(client *kubernetesClient) Resolver {
+	return &kubernetesPodResolver{client: client}
+}
+
+func (r *kubernetesPodResolver) Resolve(
+	ctx context.Context,
+	key ObjectKey,
+) (Metadata, error) {
+	if key.Name == "" || key.Namespace == "" {
+		return nil, errMissingObjectName
+	}
+
+	var pod kubernetesPod
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s",
+		url.PathEscape(key.Namespace), url.PathEscape(key.Name))
+	if err := r.client.get(ctx, path, &pod); err != nil {
+		return nil, err
+	}
+
+	metadata := kubernetesLabels(pod.Metadata.Labels)
+	if pod.Spec.NodeName != "" {
+		metadata["node"] = pod.Spec.NodeName
+	}
+
+	for _, owner := range pod.Metadata.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		switch owner.Kind {
+		case "ReplicaSet":
+			metadata["replicaset"] = owner.Name
+			// Replica sets created by a deployment are named after the
+			// deployment suffixed with the hash of the pod template.
+			hash := pod.Metadata.Labels[kubernetesPodTemplateHash]
+			if hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+				metadata["deployment"] = strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+		case "StatefulSet":
+			metadata["statefulset"] = owner.Name
+		case "DaemonSet":
+			metadata["daemonset"] = owner.Name
+		case "Job":
+			metadata["job"] = owner.Name
+		}
+	}
+
+	return metadata, nil
+}
+
+type kubernetesNodeResolver struct {
+	client *kubernetesClient
+}
+
+// newKubernetesNodeResolver returns a resolver of the metadata of nodes, the
+// metadata has the fields "zone", "region" and "instance_type" when set, and
+// the labels of the node.
+func newKubernetesNodeResolver(client *kubernetesClient) Resolver {
+	return &kubernetesNodeResolver{client: client}
+}
+
+func (r *kubernetesNodeResolver) Resolve(
+	ctx context.Context,
+	key ObjectKey,
+) (Metadata, error) {
+	if key.Name == "" {
+		return nil, errMissingObjectName
+	}
+
+	var node kubernetesNode
+	path := "/api/v1/nodes/" + url.PathEscape(key.Name)
+	if err := r.client.get(ctx, path, &node); err != nil {
+		return nil, err
+	}
+
+	labels := node.Metadata.Labels
+	metadata := kubernetesLabels(labels)
+	setFirstLabel(metadata, "zone", labels, kubernetesZoneLabels)
+	setFirstLabel(metadata, "region", labels, kubernetesRegionLabels)
+	setFirstLabel(metadata, "instance_type", labels, kubernetesInstanceTypeLabels)
+	return metadata, nil
+}
+
+func kubernetesLabels(labels map[string]string) Metadata {
+	metadata := make(Metadata, len(labels)+4)
+	for name, value := range labels {
+		metadata[kubernetesLabelPrefix+name] = value
+	}
+	return metadata
+}
+
+func setFirstLabel(
+	metadata Metadata,
+	field string,
+	labels map[string]string,
+	names []string,
+) {
+	for _, name := range names {
+		if value := labels[name]; value != "" {
+			metadata[field] = value
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKubernetesPod = `{
+  "metadata": {
+    "name": "web-5d8f7c9b4-x2k4p",
+    "namespace": "default",
+    "labels": {"app": "web", "pod-template-hash": "5d8f7c9b4"},
+    "ownerReferences": [
+      {"kind": "ReplicaSet", "name": "web-5d8f7c9b4", "controller": true}
+    ]
+  },
+  "spec": {"nodeName": "node-1"}
+}`
+	testKubernetesNode = `{
+  "metadata": {
+    "name": "node-1",
+    "labels": {
+      "failure-domain.beta.kubernetes.io/zone": "us-east-1a",
+      "topology.kubernetes.io/region": "us-east-1",
+      "node.kubernetes.io/instance-type": "m5.large"
+    }
+  }
+}`
+)
+
+func newTestKubernetesClient(t *testing.T) (*kubernetesClient, func()) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			switch r.URL.Path {
+			case "/api/v1/namespaces/default/pods/web-5d8f7c9b4-x2k4p":
+				w.Write([]byte(testKubernetesPod))
+			case "/api/v1/nodes/node-1":
+				w.Write([]byte(testKubernetesNode))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	client := &kubernetesClient{
+		url:    server.URL,
+		token:  "token",
+		client: &http.Client{},
+	}
+	return client, server.Close
+}
+
+func TestKubernetesPodResolver(t *testing.T) {
+	client, closer := newTestKubernetesClient(t)
+	defer closer()
+
+	resolver := newKubernetesPodResolver(client)
+	metadata, err := resolver.Resolve(context.Background(), ObjectKey{
+		Namespace: "default",
+		Name:      "web-5d8f7c9b4-x2k4p",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Metadata{
+		"node":                    "node-1",
+		"replicaset":              "web-5d8f7c9b4",
+		"deployment":              "web",
+		"label.app":               "web",
+		"label.pod-template-hash": "5d8f7c9b4",
+	}, metadata)
+
+	_, err = resolver.Resolve(context.Background(), ObjectKey{
+		Namespace: "default",
+		Name:      "missing",
+	})
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = resolver.Resolve(context.Background(), ObjectKey{Name: "web"})
+	assert.Equal(t, errMissingObjectName, err)
+}
+
+func TestKubernetesNodeResolver(t *testing.T) {
+	client, closer := newTestKubernetesClient(t)
+	defer closer()
+
+	resolver := newKubernetesNodeResolver(client)
+	metadata, err := resolver.Resolve(context.Background(),
+		ObjectKey{Name: "node-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1a", metadata["zone"])
+	assert.Equal(t, "us-east-1", metadata["region"])
+	assert.Equal(t, "m5.large", metadata["instance_type"])
+	assert.Equal(t, "us-east-1a",
+		metadata["label.failure-domain.beta.kubernetes.io/zone"])
+}
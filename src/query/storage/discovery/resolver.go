@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ObjectKey identifies an object whose metadata is resolved, the namespace
+// is empty for objects that are not namespaced.
+type ObjectKey struct {
+	Namespace string
+	Name      string
+}
+
+func (k ObjectKey) String() string {
+	if k.Namespace == "" {
+		return k.Name
+	}
+	return k.Namespace + "/" + k.Name
+}
+
+// Metadata is the metadata of an object keyed by field, e.g. "deployment"
+// or "zone", labels of an object are keyed with a "label." prefix.
+type Metadata map[string]string
+
+// Resolver resolves the metadata of objects from a service discovery system.
+type Resolver interface {
+	// Resolve returns the metadata of an object, returning ErrNotFound if
+	// the object does not exist.
+	Resolve(ctx context.Context, key ObjectKey) (Metadata, error)
+}
+
+// ResolverType is a type of resolver.
+type ResolverType string
+
+const (
+	// KubernetesPodResolver resolves the metadata of Kubernetes pods.
+	KubernetesPodResolver ResolverType = "kubernetesPod"
+
+	// KubernetesNodeResolver resolves the metadata of Kubernetes nodes.
+	KubernetesNodeResolver ResolverType = "kubernetesNode"
+
+	// ConsulNodeResolver resolves the metadata of Consul nodes.
+	ConsulNodeResolver ResolverType = "consulNode"
+)
+
+var validResolverTypes = []ResolverType{
+	KubernetesPodResolver,
+	KubernetesNodeResolver,
+	ConsulNodeResolver,
+}
+
+// UnmarshalYAML unmarshals a resolver type.
+func (t *ResolverType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	for _, valid := range validResolverTypes {
+		if str == string(valid) {
+			*t = valid
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid resolver type '%s' valid types are: %v",
+		str, validResolverTypes)
+}
+
+var (
+	// ErrNotFound is returned when the object to resolve does not exist.
+	ErrNotFound = errors.New("object not found")
+
+	errMissingObjectName = errors.New("object name is empty")
+	errMissingSourceTag  = errors.New("rule source tag is empty")
+)
+
+func errResolverNotConfigured(t ResolverType) error {
+	return fmt.Errorf("resolver %s is not configured", t)
+}
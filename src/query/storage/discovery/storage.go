@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package discovery provides a storage that enriches ingested series with
+// tags resolved from the metadata of service discovery systems, e.g. the
+// deployment of a Kubernetes pod or the zone of a node.
+package discovery
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+)
+
+// Rule enriches writes of series with a source tag with tags set to the
+// metadata of the object named by the value of the source tag.
+type Rule struct {
+	// SourceTag is the tag whose value is the name of the object.
+	SourceTag string
+
+	// NamespaceTag is the tag whose value is the namespace of the object,
+	// only required for namespaced objects.
+	NamespaceTag string
+
+	// Resolver is the type of resolver of the metadata of the object.
+	Resolver ResolverType
+
+	// Tags maps the metadata fields of the object to the tags they are
+	// added to the series as.
+	Tags map[string]string
+}
+
+type ruleMetrics struct {
+	resolveErrors tally.Counter
+	notFound      tally.Counter
+}
+
+type rule struct {
+	Rule
+	resolver Resolver
+	metrics  ruleMetrics
+}
+
+type storageMetrics struct {
+	enriched   tally.Counter
+	unenriched tally.Counter
+}
+
+type discoveryStorage struct {
+	storage.Storage
+	rules   []rule
+	cache   *cache
+	metrics storageMetrics
+}
+
+// NewStorage returns a storage that adds tags resolved by the rules to
+// writes of series, rules are applied in order so the tags added by a rule
+// can be the source tags of the rules after it, e.g. the zone of the node a
+// pod is scheduled on. Tags already set on a series are never replaced and
+// writes are not failed by a failure to resolve metadata, the series is
+// written without the tags of the rule instead.
+func NewStorage(
+	base storage.Storage,
+	resolvers map[ResolverType]Resolver,
+	rules []Rule,
+	cacheOpts CacheOptions,
+	scope tally.Scope,
+) (storage.Storage, error) {
+	compiled := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		if r.SourceTag == "" {
+			return nil, errMissingSourceTag
+		}
+		resolver, ok := resolvers[r.Resolver]
+		if !ok {
+			return nil, errResolverNotConfigured(r.Resolver)
+		}
+
+		ruleScope := scope.Tagged(map[string]string{
+			"resolver":   string(r.Resolver),
+			"source-tag": r.SourceTag,
+		})
+		compiled = append(compiled, rule{
+			Rule:     r,
+			resolver: resolver,
+			metrics: ruleMetrics{
+				resolveErrors: ruleScope.Counter("resolve-errors"),
+				notFound:      ruleScope.Counter("not-found"),
+			},
+		})
+	}
+
+	return &discoveryStorage{
+		Storage: base,
+		rules:   compiled,
+		cache:   newCache(cacheOpts, scope.SubScope("cache")),
+		metrics: storageMetrics{
+			enriched:   scope.Counter("enriched"),
+			unenriched: scope.Counter("unenriched"),
+		},
+	}, nil
+}
+
+func (s *discoveryStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	if query == nil || len(s.rules) == 0 {
+		return s.Storage.Write(ctx, query)
+	}
+
+	tags := s.enrich(ctx, query.Tags)
+	if len(tags) == len(query.Tags) {
+		s.metrics.unenriched.Inc(1)
+		return s.Storage.Write(ctx, query)
+	}
+
+	s.metrics.enriched.Inc(1)
+	q := *query
+	q.Tags = tags
+	return s.Storage.Write(ctx, &q)
+}
+
+// enrich returns the tags with the tags resolved by the rules added, the
+// tags are copied before they are first modified.
+func (s *discoveryStorage) enrich(
+	ctx context.Context,
+	tags models.Tags,
+) models.Tags {
+	copied := false
+	for _, r := range s.rules {
+		name, ok := tags[r.SourceTag]
+		if !ok || name == "" || !r.missingTags(tags) {
+			continue
+		}
+
+		key := ObjectKey{Name: name}
+		if r.NamespaceTag != "" {
+			key.Namespace = tags[r.NamespaceTag]
+		}
+
+		metadata, err := s.cache.get(ctx, string(r.Resolver), r.resolver, key)
+		if err == ErrNotFound {
+			r.metrics.notFound.Inc(1)
+			continue
+		}
+		if err != nil {
+			r.metrics.resolveErrors.Inc(1)
+			continue
+		}
+
+		for field, tag := range r.Tags {
+			value, ok := metadata[field]
+			if !ok || value == "" {
+				continue
+			}
+			if _, exists := tags[tag]; exists {
+				continue
+			}
+			if !copied {
+				tags = copyTags(tags, len(r.Tags))
+				copied = true
+			}
+			tags[tag] = value
+		}
+	}
+
+	return tags
+}
+
+// missingTags returns whether any of the tags of the rule are not set.
+func (r rule) missingTags(tags models.Tags) bool {
+	for _, tag := range r.Tags {
+		if _, ok := tags[tag]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func copyTags(tags models.Tags, extra int) models.Tags {
+	copied := make(models.Tags, len(tags)+extra)
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testResolver struct {
+	sync.Mutex
+	metadata map[ObjectKey]Metadata
+	err      error
+	calls    int
+}
+
+func (r *testResolver) Resolve(
+	ctx context.Context,
+	key ObjectKey,
+) (Metadata, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	metadata, ok := r.metadata[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return metadata, nil
+}
+
+func (r *testResolver) numCalls() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.calls
+}
+
+func newTestStorage(
+	t *testing.T,
+	base storage.Storage,
+	scope tally.Scope,
+) (storage.Storage, *testResolver, *testResolver) {
+	pods := &testResolver{metadata: map[ObjectKey]Metadata{
+		{Namespace: "default", Name: "web-5d8f7-x2k4p"}: {
+			"node":       "node-1",
+			"deployment": "web",
+		},
+	}}
+	nodes := &testResolver{metadata: map[ObjectKey]Metadata{
+		{Name: "node-1"}: {"zone": "us-east-1a"},
+	}}
+	store, err := NewStorage(base, map[ResolverType]Resolver{
+		KubernetesPodResolver:  pods,
+		KubernetesNodeResolver: nodes,
+	}, []Rule{
+		{
+			SourceTag:    "pod",
+			NamespaceTag: "namespace",
+			Resolver:     KubernetesPodResolver,
+			Tags:         map[string]string{"node": "node", "deployment": "deployment"},
+		},
+		{
+			SourceTag: "node",
+			Resolver:  KubernetesNodeResolver,
+			Tags:      map[string]string{"zone": "zone"},
+		},
+	}, CacheOptions{}, scope)
+	require.NoError(t, err)
+	return store, pods, nodes
+}
+
+func TestDiscoveryStorageWriteChainsRules(t *testing.T) {
+	base := mock.NewMockStorage()
+	store, _, _ := newTestStorage(t, base, tally.NoopScope)
+
+	tags := models.Tags{
+		models.MetricName: "requests",
+		"pod":             "web-5d8f7-x2k4p",
+		"namespace":       "default",
+	}
+	require.NoError(t, store.Write(context.TODO(), &storage.WriteQuery{Tags: tags}))
+
+	writes := base.Writes()
+	require.Len(t, writes, 1)
+	assert.Equal(t, models.Tags{
+		models.MetricName: "requests",
+		"pod":             "web-5d8f7-x2k4p",
+		"namespace":       "default",
+		"node":            "node-1",
+		"deployment":      "web",
+		"zone":            "us-east-1a",
+	}, writes[0].Tags)
+
+	// The tags of the write are not modified.
+	assert.Len(t, tags, 3)
+}
+
+func TestDiscoveryStorageWriteKeepsExistingTags(t *testing.T) {
+	base := mock.NewMockStorage()
+	store, pods, _ := newTestStorage(t, base, tally.NoopScope)
+
+	tags := models.Tags{
+		"pod":        "web-5d8f7-x2k4p",
+		"namespace":  "default",
+		"node":       "node-1",
+		"deployment": "api",
+		"zone":       "us-west-2b",
+	}
+	require.NoError(t, store.Write(context.TODO(), &storage.WriteQuery{Tags: tags}))
+
+	writes := base.Writes()
+	require.Len(t, writes, 1)
+	assert.Equal(t, tags, writes[0].Tags)
+
+	// Objects are not resolved when the series already has every tag.
+	assert.Equal(t, 0, pods.numCalls())
+}
+
+func TestDiscoveryStorageWriteResolveFailures(t *testing.T) {
+	var (
+		base  = mock.NewMockStorage()
+		scope = tally.NewTestScope("", nil)
+	)
+	store, pods, _ := newTestStorage(t, base, scope)
+	pods.err = errors.New("unavailable")
+
+	notFound := models.Tags{"node": "node-2"}
+	failed := models.Tags{"pod": "web-5d8f7-x2k4p", "namespace": "default"}
+	require.NoError(t, store.Write(context.TODO(), &storage.WriteQuery{Tags: notFound}))
+	require.NoError(t, store.Write(context.TODO(), &storage.WriteQuery{Tags: failed}))
+
+	// Series are written without the tags that failed to resolve.
+	writes := base.Writes()
+	require.Len(t, writes, 2)
+	assert.Equal(t, notFound, writes[0].Tags)
+	assert.Equal(t, failed, writes[1].Tags)
+
+	counters := scope.Snapshot().Counters()
+	notFoundCounter, ok := counters["not-found+resolver=kubernetesNode,source-tag=node"]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), notFoundCounter.Value())
+	errorsCounter, ok := counters["resolve-errors+resolver=kubernetesPod,source-tag=pod"]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), errorsCounter.Value())
+	unenriched, ok := counters["unenriched+"]
+	require.True(t, ok)
+	assert.Equal(t, int64(2), unenriched.Value())
+}
+
+func TestNewStorageResolverNotConfigured(t *testing.T) {
+	_, err := NewStorage(mock.NewMockStorage(), nil, []Rule{
+		{SourceTag: "host", Resolver: ConsulNodeResolver},
+	}, CacheOptions{}, tally.NoopScope)
+	require.Error(t, err)
+}
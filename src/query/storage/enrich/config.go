@@ -0,0 +1,213 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	defaultFetchTimeout    = 30 * time.Second
+)
+
+var (
+	errNoKVStore = errors.New("lookup table KV key set without a KV store")
+)
+
+// TableConfiguration is the configuration of a lookup table, loaded from
+// either a CSV object at a URL or a KV key.
+type TableConfiguration struct {
+	// Name is the name queries refer to the table by.
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// JoinTags are the tags the table joins series on, which must each be
+	// a column of the table.
+	JoinTags []string `yaml:"joinTags" validate:"nonzero"`
+
+	// Overwrite replaces tags the series already have with the tags of the
+	// table, by default the tags of the series are kept.
+	Overwrite bool `yaml:"overwrite"`
+
+	// URL is the URL of the CSV object of the table, such as the URL of an
+	// object in an object store, which is fetched every refresh interval.
+	URL string `yaml:"url"`
+
+	// RefreshInterval is how often the CSV object is fetched.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+
+	// KVKey is the KV key to watch for the table, the value is expected to
+	// be a string proto containing the CSV of the table.
+	KVKey string `yaml:"kvKey"`
+}
+
+// Configuration is the configuration of query time tag enrichment.
+type Configuration struct {
+	// Tables are the lookup tables available to queries.
+	Tables []TableConfiguration `yaml:"tables"`
+
+	// Namespaces are the names of the tables applied to every series
+	// fetched from each namespace, other tables are only applied to the
+	// queries that name them.
+	Namespaces map[string][]string `yaml:"namespaces"`
+}
+
+// NewEnricher creates a new enricher, loading each table from its URL or
+// watching its KV key.
+func (c Configuration) NewEnricher(
+	store kv.Store,
+	scope tally.Scope,
+	logger *zap.Logger,
+) (*Enricher, error) {
+	tables := make([]TableOptions, 0, len(c.Tables))
+	for _, table := range c.Tables {
+		if (table.URL == "") == (table.KVKey == "") {
+			return nil, fmt.Errorf(
+				"lookup table %s must set exactly one of url and kvKey", table.Name)
+		}
+		if table.KVKey != "" && store == nil {
+			return nil, errNoKVStore
+		}
+		tables = append(tables, TableOptions{
+			Name:      table.Name,
+			JoinTags:  table.JoinTags,
+			Overwrite: table.Overwrite,
+		})
+	}
+
+	enricher, err := NewEnricher(tables, c.Namespaces, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range c.Tables {
+		var (
+			table  = table // Capture var
+			logger = logger.With(zap.String("table", table.Name))
+		)
+		if table.KVKey != "" {
+			watch, err := store.Watch(table.KVKey)
+			if err != nil {
+				enricher.Close()
+				return nil, err
+			}
+			go table.watchKV(enricher, watch, logger)
+			continue
+		}
+		go table.refreshURL(enricher, &http.Client{Timeout: defaultFetchTimeout}, logger)
+	}
+
+	return enricher, nil
+}
+
+func (c TableConfiguration) refreshURL(
+	enricher *Enricher,
+	client *http.Client,
+	logger *zap.Logger,
+) {
+	interval := c.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.loadURL(enricher, client); err != nil {
+			logger.Warn("unable to load lookup table",
+				zap.String("url", c.URL), zap.Any("error", err))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-enricher.closeCh:
+			return
+		}
+	}
+}
+
+func (c TableConfiguration) loadURL(enricher *Enricher, client *http.Client) error {
+	resp, err := client.Get(c.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	table, err := ParseCSV(resp.Body, c.JoinTags)
+	if err != nil {
+		return err
+	}
+	return enricher.SetTable(c.Name, table)
+}
+
+func (c TableConfiguration) watchKV(
+	enricher *Enricher,
+	watch kv.ValueWatch,
+	logger *zap.Logger,
+) {
+	defer watch.Close()
+
+	protoValue := &commonpb.StringProto{}
+	for {
+		select {
+		case <-watch.C():
+		case <-enricher.closeCh:
+			return
+		}
+
+		value := watch.Get()
+		if value == nil {
+			continue
+		}
+		if err := value.Unmarshal(protoValue); err != nil {
+			logger.Warn("unable to unmarshal lookup table",
+				zap.String("key", c.KVKey), zap.Any("error", err))
+			continue
+		}
+
+		table, err := ParseCSV(strings.NewReader(protoValue.Value), c.JoinTags)
+		if err == nil {
+			err = enricher.SetTable(c.Name, table)
+		}
+		if err != nil {
+			logger.Warn("unable to set lookup table",
+				zap.String("key", c.KVKey), zap.Any("error", err))
+			continue
+		}
+
+		logger.Info("set lookup table",
+			zap.String("key", c.KVKey), zap.Int("numRows", table.Len()))
+	}
+}
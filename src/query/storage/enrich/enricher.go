@@ -0,0 +1,252 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package enrich
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/uber-go/tally"
+)
+
+// TableOptions are the options of a lookup table of an enricher.
+type TableOptions struct {
+	// Name is the name queries refer to the table by.
+	Name string
+
+	// JoinTags are the tags the table joins series on.
+	JoinTags []string
+
+	// Overwrite replaces tags the series already have with the tags of the
+	// table, by default the tags of the series are kept.
+	Overwrite bool
+}
+
+type enricherTable struct {
+	opts  TableOptions
+	table *Table
+}
+
+// Enricher adds the tags of lookup tables to the tags of series, the tables
+// are loaded and replaced at runtime.
+type Enricher struct {
+	sync.RWMutex
+	tables     map[string]*enricherTable
+	namespaces map[string][]string
+	metrics    enricherMetrics
+	closeCh    chan struct{}
+	closeOnce  sync.Once
+}
+
+type enricherMetrics struct {
+	matched   tally.Counter
+	unmatched tally.Counter
+	unloaded  tally.Counter
+}
+
+func newEnricherMetrics(scope tally.Scope) enricherMetrics {
+	return enricherMetrics{
+		matched:   scope.Counter("matched"),
+		unmatched: scope.Counter("unmatched"),
+		unloaded:  scope.Counter("unloaded"),
+	}
+}
+
+// NewEnricher returns a new enricher of the tables, namespaces are the names
+// of the tables applied to every series fetched from each namespace.
+func NewEnricher(
+	tables []TableOptions,
+	namespaces map[string][]string,
+	scope tally.Scope,
+) (*Enricher, error) {
+	e := &Enricher{
+		tables:     make(map[string]*enricherTable, len(tables)),
+		namespaces: namespaces,
+		metrics:    newEnricherMetrics(scope),
+		closeCh:    make(chan struct{}),
+	}
+	for _, opts := range tables {
+		if opts.Name == "" {
+			return nil, fmt.Errorf("lookup table has no name")
+		}
+		if len(opts.JoinTags) == 0 {
+			return nil, fmt.Errorf("lookup table %s has no join tags", opts.Name)
+		}
+		if _, ok := e.tables[opts.Name]; ok {
+			return nil, fmt.Errorf("duplicate lookup table %s", opts.Name)
+		}
+		e.tables[opts.Name] = &enricherTable{opts: opts}
+	}
+	for namespace, names := range namespaces {
+		if err := e.Validate(names); err != nil {
+			return nil, fmt.Errorf("invalid lookup tables for namespace %s: %v",
+				namespace, err)
+		}
+	}
+	return e, nil
+}
+
+// SetTable sets the contents of a table.
+func (e *Enricher) SetTable(name string, table *Table) error {
+	e.Lock()
+	defer e.Unlock()
+	entry, ok := e.tables[name]
+	if !ok {
+		return fmt.Errorf("unknown lookup table %s", name)
+	}
+	if !equalStrings(entry.opts.JoinTags, table.JoinTags()) {
+		return fmt.Errorf("lookup table %s joins on %v not %v",
+			name, table.JoinTags(), entry.opts.JoinTags)
+	}
+	entry.table = table
+	return nil
+}
+
+// Validate returns an error if any of the tables are unknown.
+func (e *Enricher) Validate(tables []string) error {
+	for _, name := range tables {
+		if _, ok := e.tables[name]; !ok {
+			return fmt.Errorf("unknown lookup table %s", name)
+		}
+	}
+	return nil
+}
+
+// Enrich returns the tags with the tags of the tables of the namespace
+// followed by the named tables added, the tags passed in are never
+// modified. Tables that have not been loaded yet add no tags.
+func (e *Enricher) Enrich(
+	namespace string,
+	tables []string,
+	tags models.Tags,
+) models.Tags {
+	if len(e.namespaces[namespace]) == 0 && len(tables) == 0 {
+		return tags
+	}
+
+	e.RLock()
+	defer e.RUnlock()
+
+	var (
+		enriched = tags
+		copied   = false
+	)
+	e.forEachTable(namespace, tables, func(entry *enricherTable) {
+		if entry.table == nil {
+			e.metrics.unloaded.Inc(1)
+			return
+		}
+
+		row, ok := entry.table.Lookup(tags)
+		if !ok {
+			e.metrics.unmatched.Inc(1)
+			return
+		}
+		e.metrics.matched.Inc(1)
+
+		for name, value := range row {
+			if _, exists := enriched[name]; exists && !entry.opts.Overwrite {
+				continue
+			}
+			if !copied {
+				enriched = make(models.Tags, len(tags)+len(row))
+				for k, v := range tags {
+					enriched[k] = v
+				}
+				copied = true
+			}
+			enriched[name] = value
+		}
+	})
+	return enriched
+}
+
+// JoinTagNames returns the names of the tags the tables of every namespace
+// and the named tables join on, sorted by name.
+func (e *Enricher) JoinTagNames(tables []string) []string {
+	e.RLock()
+	defer e.RUnlock()
+
+	unique := make(map[string]struct{})
+	add := func(entry *enricherTable) {
+		for _, name := range entry.opts.JoinTags {
+			unique[name] = struct{}{}
+		}
+	}
+	for namespace := range e.namespaces {
+		e.forEachTable(namespace, nil, add)
+	}
+	e.forEachTable("", tables, add)
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close stops the loading of the tables.
+func (e *Enricher) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.closeCh)
+	})
+	return nil
+}
+
+// forEachTable calls the function with each table of the namespace followed
+// by each named table, each table is only visited once.
+func (e *Enricher) forEachTable(
+	namespace string,
+	tables []string,
+	fn func(entry *enricherTable),
+) {
+	var (
+		nsTables = e.namespaces[namespace]
+		visited  = make(map[string]struct{}, len(nsTables)+len(tables))
+	)
+	for _, names := range [][]string{nsTables, tables} {
+		for _, name := range names {
+			if _, ok := visited[name]; ok {
+				continue
+			}
+			visited[name] = struct{}{}
+			if entry, ok := e.tables[name]; ok {
+				fn(entry)
+			}
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package enrich
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv/mem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+func newTestEnricher(t *testing.T) *Enricher {
+	enricher, err := NewEnricher([]TableOptions{
+		{Name: "teams", JoinTags: []string{"service"}},
+		{Name: "regions", JoinTags: []string{"dc"}, Overwrite: true},
+	}, map[string][]string{
+		"metrics": []string{"teams"},
+	}, tally.NoopScope)
+	require.NoError(t, err)
+
+	teams, err := ParseCSV(strings.NewReader("service,team\napi,core\n"),
+		[]string{"service"})
+	require.NoError(t, err)
+	require.NoError(t, enricher.SetTable("teams", teams))
+
+	regions, err := ParseCSV(strings.NewReader("dc,region,team\neast,us,infra\n"),
+		[]string{"dc"})
+	require.NoError(t, err)
+	require.NoError(t, enricher.SetTable("regions", regions))
+	return enricher
+}
+
+func TestEnricherNamespaceTables(t *testing.T) {
+	enricher := newTestEnricher(t)
+	tags := models.Tags{"service": "api", "dc": "east"}
+
+	enriched := enricher.Enrich("metrics", nil, tags)
+	assert.Equal(t, models.Tags{"service": "api", "dc": "east", "team": "core"}, enriched)
+	// The tags passed in are never modified.
+	assert.Equal(t, models.Tags{"service": "api", "dc": "east"}, tags)
+
+	assert.Equal(t, tags, enricher.Enrich("other", nil, tags))
+}
+
+func TestEnricherNamedTables(t *testing.T) {
+	enricher := newTestEnricher(t)
+	tags := models.Tags{"service": "api", "dc": "east"}
+
+	assert.Equal(t, models.Tags{
+		"service": "api", "dc": "east", "region": "us", "team": "infra",
+	}, enricher.Enrich("metrics", []string{"regions"}, tags))
+
+	assert.Equal(t, models.Tags{
+		"service": "api", "dc": "east", "region": "us", "team": "infra",
+	}, enricher.Enrich("other", []string{"regions"}, tags))
+
+	// Tables without overwrite keep the tags of the series.
+	tags = models.Tags{"service": "api", "team": "mine"}
+	assert.Equal(t, tags, enricher.Enrich("other", []string{"teams"}, tags))
+}
+
+func TestEnricherUnloadedTable(t *testing.T) {
+	enricher, err := NewEnricher([]TableOptions{
+		{Name: "teams", JoinTags: []string{"service"}},
+	}, nil, tally.NoopScope)
+	require.NoError(t, err)
+
+	tags := models.Tags{"service": "api"}
+	assert.Equal(t, tags, enricher.Enrich("metrics", []string{"teams"}, tags))
+}
+
+func TestEnricherJoinTagNames(t *testing.T) {
+	enricher := newTestEnricher(t)
+	assert.Equal(t, []string{"service"}, enricher.JoinTagNames(nil))
+	assert.Equal(t, []string{"dc", "service"}, enricher.JoinTagNames([]string{"regions"}))
+}
+
+func TestEnricherValidate(t *testing.T) {
+	enricher := newTestEnricher(t)
+	assert.NoError(t, enricher.Validate([]string{"teams", "regions"}))
+	assert.Error(t, enricher.Validate([]string{"teams", "owners"}))
+
+	_, err := NewEnricher(nil, map[string][]string{
+		"metrics": []string{"teams"},
+	}, tally.NoopScope)
+	assert.Error(t, err)
+
+	table, err := ParseCSV(strings.NewReader("dc,region\neast,us\n"), []string{"dc"})
+	require.NoError(t, err)
+	assert.Error(t, enricher.SetTable("teams", table))
+	assert.Error(t, enricher.SetTable("owners", table))
+}
+
+// waitForTable waits for the table to be loaded and add tags to the tags.
+func waitForTable(t *testing.T, enricher *Enricher, table string, tags, expected models.Tags) {
+	deadline := time.Now().Add(5 * time.Second)
+	enriched := enricher.Enrich("", []string{table}, tags)
+	for len(enriched) == len(tags) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		enriched = enricher.Enrich("", []string{table}, tags)
+	}
+	assert.Equal(t, expected, enriched)
+}
+
+func TestConfigurationURLTable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "service,team\napi,core\n")
+	}))
+	defer server.Close()
+
+	cfg := Configuration{
+		Tables: []TableConfiguration{
+			{Name: "teams", JoinTags: []string{"service"}, URL: server.URL},
+		},
+	}
+	enricher, err := cfg.NewEnricher(nil, tally.NoopScope, zap.NewNop())
+	require.NoError(t, err)
+	defer enricher.Close()
+
+	waitForTable(t, enricher, "teams", models.Tags{"service": "api"},
+		models.Tags{"service": "api", "team": "core"})
+}
+
+func TestConfigurationKVTable(t *testing.T) {
+	store := mem.NewStore()
+	_, err := store.Set("teams", &commonpb.StringProto{Value: "service,team\napi,core\n"})
+	require.NoError(t, err)
+
+	cfg := Configuration{
+		Tables: []TableConfiguration{
+			{Name: "teams", JoinTags: []string{"service"}, KVKey: "teams"},
+		},
+	}
+	enricher, err := cfg.NewEnricher(store, tally.NoopScope, zap.NewNop())
+	require.NoError(t, err)
+	defer enricher.Close()
+
+	waitForTable(t, enricher, "teams", models.Tags{"service": "api"},
+		models.Tags{"service": "api", "team": "core"})
+}
+
+func TestConfigurationInvalidTables(t *testing.T) {
+	tests := []TableConfiguration{
+		{Name: "teams", JoinTags: []string{"service"}},
+		{Name: "teams", JoinTags: []string{"service"}, URL: "http://a", KVKey: "b"},
+		{Name: "teams", JoinTags: []string{"service"}, KVKey: "teams"},
+	}
+
+	for _, table := range tests {
+		cfg := Configuration{Tables: []TableConfiguration{table}}
+		_, err := cfg.NewEnricher(nil, tally.NoopScope, zap.NewNop())
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package enrich
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+// keySeparator separates the values of the join tags of a row key, it is not
+// valid UTF-8 so it can not appear within a tag value read from CSV.
+const keySeparator = "\xff"
+
+var (
+	errNoJoinTags  = errors.New("lookup table has no join tags")
+	errEmptyHeader = errors.New("lookup table CSV has no header row")
+)
+
+// Table is an immutable lookup table of the tags to add to series, joined on
+// the values of one or more tags of the series.
+type Table struct {
+	joinTags []string
+	rows     map[string]models.Tags
+}
+
+// JoinTags returns the names of the tags the table joins on.
+func (t *Table) JoinTags() []string {
+	return t.joinTags
+}
+
+// Len returns the number of rows of the table.
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// Lookup returns the tags of the row joined with the tags, returns false if
+// the tags are missing a join tag or no row matches them.
+func (t *Table) Lookup(tags models.Tags) (models.Tags, bool) {
+	values := make([]string, 0, len(t.joinTags))
+	for _, name := range t.joinTags {
+		value, ok := tags[name]
+		if !ok {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+
+	row, ok := t.rows[strings.Join(values, keySeparator)]
+	return row, ok
+}
+
+// ParseCSV parses a lookup table from CSV, the first row is a header naming
+// the tag of each column. The columns named by the join tags are matched
+// against the tags of series and every other column is a tag added to the
+// series matched, empty cells add no tag.
+func ParseCSV(r io.Reader, joinTags []string) (*Table, error) {
+	if len(joinTags) == 0 {
+		return nil, errNoJoinTags
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errEmptyHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("lookup table CSV column %d has no name", i)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("lookup table CSV has duplicate column %s", name)
+		}
+		columns[name] = i
+		header[i] = name
+	}
+
+	var (
+		joinColumns = make([]int, 0, len(joinTags))
+		isJoin      = make(map[int]bool, len(joinTags))
+	)
+	for _, name := range joinTags {
+		idx, ok := columns[name]
+		if !ok {
+			return nil, fmt.Errorf("lookup table CSV has no column for join tag %s", name)
+		}
+		joinColumns = append(joinColumns, idx)
+		isJoin[idx] = true
+	}
+
+	table := &Table{
+		joinTags: joinTags,
+		rows:     make(map[string]models.Tags),
+	}
+	values := make([]string, len(joinColumns))
+	for rowNum := 1; ; rowNum++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i, idx := range joinColumns {
+			values[i] = record[idx]
+		}
+		key := strings.Join(values, keySeparator)
+		if _, ok := table.rows[key]; ok {
+			return nil, fmt.Errorf("lookup table CSV row %d duplicates the join tag values %v",
+				rowNum, values)
+		}
+
+		row := make(models.Tags, len(record)-len(joinColumns))
+		for idx, value := range record {
+			if isJoin[idx] || value == "" {
+				continue
+			}
+			row[header[idx]] = value
+		}
+		table.rows[key] = row
+	}
+
+	return table, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package enrich
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTeamsCSV = `service,env,team,owner
+api,prod,core,alice
+api,dev,core,
+billing,prod,payments,bob
+`
+
+func TestParseCSV(t *testing.T) {
+	table, err := ParseCSV(strings.NewReader(testTeamsCSV), []string{"service", "env"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"service", "env"}, table.JoinTags())
+	assert.Equal(t, 3, table.Len())
+
+	row, ok := table.Lookup(models.Tags{"service": "api", "env": "prod", "host": "a"})
+	require.True(t, ok)
+	assert.Equal(t, models.Tags{"team": "core", "owner": "alice"}, row)
+
+	// Empty cells add no tag.
+	row, ok = table.Lookup(models.Tags{"service": "api", "env": "dev"})
+	require.True(t, ok)
+	assert.Equal(t, models.Tags{"team": "core"}, row)
+
+	_, ok = table.Lookup(models.Tags{"service": "api", "env": "staging"})
+	assert.False(t, ok)
+
+	_, ok = table.Lookup(models.Tags{"service": "api"})
+	assert.False(t, ok)
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		joinTags []string
+	}{
+		{"no join tags", testTeamsCSV, nil},
+		{"empty", "", []string{"service"}},
+		{"missing join column", testTeamsCSV, []string{"cluster"}},
+		{"duplicate column", "service,team,team\napi,a,b\n", []string{"service"}},
+		{"unnamed column", "service,\napi,a\n", []string{"service"}},
+		{"duplicate row", "service,team\napi,a\napi,b\n", []string{"service"}},
+		{"uneven row", "service,team\napi\n", []string{"service"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(test.csv), test.joinTags)
+			assert.Error(t, err)
+		})
+	}
+}
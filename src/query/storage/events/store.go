@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+)
+
+var (
+	errNoTimestamp      = errors.New("event must have a timestamp")
+	errNoText           = errors.New("event must have text")
+	errReservedTag      = fmt.Errorf("event tags may not use reserved tag %s", models.MetricName)
+	errInvalidTimeRange = errors.New("query start must be before end")
+
+	// ErrNamespaceNotFound is returned when a namespace is not a configured
+	// cluster namespace.
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+type store struct {
+	clusters local.Clusters
+}
+
+// NewStore returns a new event store which stores events in the namespaces
+// of the given clusters.
+func NewStore(clusters local.Clusters) Store {
+	return &store{clusters: clusters}
+}
+
+func (s *store) namespace(name string) (local.ClusterNamespace, error) {
+	if name == "" {
+		return s.clusters.UnaggregatedClusterNamespace(), nil
+	}
+	for _, namespace := range s.clusters.ClusterNamespaces() {
+		if namespace.NamespaceID().String() == name {
+			return namespace, nil
+		}
+	}
+	return nil, ErrNamespaceNotFound
+}
+
+// Validate returns an error if the event can not be stored.
+func Validate(event Event) error {
+	if event.Timestamp.IsZero() {
+		return errNoTimestamp
+	}
+	if event.Text == "" {
+		return errNoText
+	}
+	if _, ok := event.Tags[models.MetricName]; ok {
+		return errReservedTag
+	}
+	return nil
+}
+
+func (s *store) Write(ctx context.Context, namespace string, event Event) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := Validate(event); err != nil {
+		return err
+	}
+
+	ns, err := s.namespace(namespace)
+	if err != nil {
+		return err
+	}
+
+	tags := make(models.Tags, len(event.Tags)+1)
+	for name, value := range event.Tags {
+		tags[name] = value
+	}
+	tags[models.MetricName] = MetricName
+
+	// Events are stored as a series with the text as the annotation of
+	// each datapoint.
+	return ns.Session().WriteTagged(ns.NamespaceID(), ident.StringID(tags.ID()),
+		storage.TagsToIdentTagIterator(tags), event.Timestamp, 1,
+		xtime.Millisecond, []byte(event.Text))
+}
+
+func (s *store) Query(ctx context.Context, query Query) ([]Event, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if !query.Start.Before(query.End) {
+		return nil, errInvalidTimeRange
+	}
+	for _, matcher := range query.Matchers {
+		if matcher.Name == models.MetricName {
+			return nil, errReservedTag
+		}
+	}
+
+	ns, err := s.namespace(query.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	nameMatcher, err := models.NewMatcher(models.MatchEqual, models.MetricName, MetricName)
+	if err != nil {
+		return nil, err
+	}
+	matchers := append(models.Matchers{nameMatcher}, query.Matchers...)
+	m3query, err := storage.FetchQueryToM3Query(&storage.FetchQuery{
+		TagMatchers: matchers,
+		Start:       query.Start,
+		End:         query.End,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	iters, _, err := ns.Session().FetchTagged(ns.NamespaceID(), m3query, index.QueryOptions{
+		StartInclusive: query.Start,
+		EndExclusive:   query.End,
+		Limit:          query.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iters.Close()
+
+	var events []Event
+	for _, iter := range iters.Iters() {
+		tags, err := storage.FromIdentTagIteratorToTags(iter.Tags())
+		if err != nil {
+			return nil, err
+		}
+		delete(tags, models.MetricName)
+
+		// Annotations are only encoded when they differ from the previous
+		// datapoint's annotation so the last seen text is carried forward.
+		var text []byte
+		for iter.Next() {
+			dp, _, annotation := iter.Current()
+			if annotation != nil {
+				text = append(text[:0], annotation...)
+			}
+			events = append(events, Event{
+				Timestamp: dp.Timestamp,
+				Tags:      tags,
+				Text:      string(text),
+			})
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+	return events, nil
+}
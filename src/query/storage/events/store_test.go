@@ -0,0 +1,169 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	m3ts "github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, ctrl *gomock.Controller) (Store, *client.MockSession) {
+	session := client.NewMockSession(ctrl)
+	clusters, err := local.NewClusters(local.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics"),
+		Session:     session,
+		Retention:   24 * time.Hour,
+	})
+	require.NoError(t, err)
+	return NewStore(clusters), session
+}
+
+func TestStoreWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store, session := newTestStore(t, ctrl)
+	now := time.Now()
+	session.EXPECT().WriteTagged(ident.NewIDMatcher("metrics"),
+		ident.NewIDMatcher("__name__=__event__,service=api,"), gomock.Any(),
+		now, 1.0, xtime.Millisecond, []byte("deployed v1.2")).Return(nil)
+
+	err := store.Write(context.Background(), "", Event{
+		Timestamp: now,
+		Tags:      models.Tags{"service": "api"},
+		Text:      "deployed v1.2",
+	})
+	require.NoError(t, err)
+}
+
+func TestStoreWriteInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store, _ := newTestStore(t, ctrl)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.Equal(t, errNoTimestamp, store.Write(ctx, "", Event{Text: "x"}))
+	assert.Equal(t, errNoText, store.Write(ctx, "", Event{Timestamp: now}))
+	assert.Equal(t, errReservedTag, store.Write(ctx, "", Event{
+		Timestamp: now,
+		Tags:      models.Tags{models.MetricName: "foo"},
+		Text:      "x",
+	}))
+	assert.Equal(t, ErrNamespaceNotFound, store.Write(ctx, "unknown", Event{
+		Timestamp: now,
+		Text:      "x",
+	}))
+}
+
+func TestStoreQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store, session := newTestStore(t, ctrl)
+	var (
+		end   = time.Now().Truncate(time.Second)
+		start = end.Add(-time.Hour)
+		first = start.Add(10 * time.Minute)
+		later = start.Add(20 * time.Minute)
+	)
+
+	iter := encoding.NewMockSeriesIterator(ctrl)
+	iter.EXPECT().Tags().Return(ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag(models.MetricName, MetricName),
+		ident.StringTag("service", "api"),
+	)))
+	gomock.InOrder(
+		iter.EXPECT().Next().Return(true),
+		iter.EXPECT().Current().Return(m3ts.Datapoint{Timestamp: later, Value: 1},
+			xtime.Millisecond, m3ts.Annotation("rollback")),
+		iter.EXPECT().Next().Return(true),
+		// An unchanged annotation is not encoded again.
+		iter.EXPECT().Current().Return(m3ts.Datapoint{Timestamp: later.Add(time.Minute), Value: 1},
+			xtime.Millisecond, nil),
+		iter.EXPECT().Next().Return(false),
+	)
+	iter.EXPECT().Err().Return(nil)
+
+	otherIter := encoding.NewMockSeriesIterator(ctrl)
+	otherIter.EXPECT().Tags().Return(ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag(models.MetricName, MetricName),
+		ident.StringTag("service", "web"),
+	)))
+	gomock.InOrder(
+		otherIter.EXPECT().Next().Return(true),
+		otherIter.EXPECT().Current().Return(m3ts.Datapoint{Timestamp: first, Value: 1},
+			xtime.Millisecond, m3ts.Annotation("deployed")),
+		otherIter.EXPECT().Next().Return(false),
+	)
+	otherIter.EXPECT().Err().Return(nil)
+
+	iters := encoding.NewMockSeriesIterators(ctrl)
+	iters.EXPECT().Iters().Return([]encoding.SeriesIterator{iter, otherIter})
+	iters.EXPECT().Close()
+
+	session.EXPECT().FetchTagged(ident.NewIDMatcher("metrics"), gomock.Any(), gomock.Any()).
+		Return(iters, true, nil)
+
+	events, err := store.Query(context.Background(), Query{
+		Start: start,
+		End:   end,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Event{
+		{Timestamp: first, Tags: models.Tags{"service": "web"}, Text: "deployed"},
+		{Timestamp: later, Tags: models.Tags{"service": "api"}, Text: "rollback"},
+		{Timestamp: later.Add(time.Minute), Tags: models.Tags{"service": "api"}, Text: "rollback"},
+	}, events)
+}
+
+func TestStoreQueryInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store, _ := newTestStore(t, ctrl)
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := store.Query(ctx, Query{Start: now, End: now})
+	assert.Equal(t, errInvalidTimeRange, err)
+
+	_, err = store.Query(ctx, Query{
+		Start:    now.Add(-time.Hour),
+		End:      now,
+		Matchers: models.Matchers{{Type: models.MatchEqual, Name: models.MetricName, Value: "up"}},
+	})
+	assert.Equal(t, errReservedTag, err)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package events provides a store for events, such as deployment markers or
+// incidents, which are stored in M3 alongside metrics so they can be
+// overlaid on graphs.
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+const (
+	// MetricName is the metric name of the series events are stored as,
+	// which keeps events out of metric queries.
+	MetricName = "__event__"
+)
+
+// Event is a timestamped text payload with tags.
+type Event struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Tags      models.Tags `json:"tags"`
+	Text      string      `json:"text"`
+}
+
+// Query is a range query for events.
+type Query struct {
+	// Namespace is the namespace to query, if empty the unaggregated
+	// namespace is queried.
+	Namespace string
+	// Matchers select the events to return by their tags.
+	Matchers models.Matchers
+	// Start is the inclusive start of the range.
+	Start time.Time
+	// End is the exclusive end of the range.
+	End time.Time
+	// Limit limits the number of events returned if positive.
+	Limit int
+}
+
+// Store writes and queries events.
+type Store interface {
+	// Write writes an event to a namespace, if the namespace is empty the
+	// event is written to the unaggregated namespace.
+	Write(ctx context.Context, namespace string, event Event) error
+
+	// Query returns the events matching a query sorted by time.
+	Query(ctx context.Context, query Query) ([]Event, error)
+}
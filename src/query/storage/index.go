@@ -22,9 +22,12 @@ package storage
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/m3ninx/idx"
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3x/ident"
 )
@@ -75,14 +78,24 @@ func TagsToIdentTagIterator(tags models.Tags) ident.TagIterator {
 
 // FetchOptionsToM3Options converts a set of coordinator options to M3 options
 func FetchOptionsToM3Options(fetchOptions *FetchOptions, fetchQuery *FetchQuery) index.QueryOptions {
-	return index.QueryOptions{
+	opts := index.QueryOptions{
 		Limit:          fetchOptions.Limit,
 		StartInclusive: fetchQuery.Start,
 		EndExclusive:   fetchQuery.End,
 	}
+	for _, name := range FetchTagNames(fetchOptions) {
+		opts.TagNames = append(opts.TagNames, []byte(name))
+	}
+
+	return opts
 }
 
-// FetchQueryToM3Query converts an m3coordinator fetch query to an M3 query
+// FetchQueryToM3Query converts an m3coordinator fetch query to an M3 query.
+// Negative matchers are computed as the complement of the postings of the
+// negated matcher within each index segment. A query consisting solely of
+// negative matchers matches every series of the index matching none of the
+// negated matchers, including series without the negated tags, as with
+// Prometheus selectors, and can therefore be expensive to evaluate.
 func FetchQueryToM3Query(fetchQuery *FetchQuery) (index.Query, error) {
 	matchers := fetchQuery.TagMatchers
 	idxQueries := make([]idx.Query, len(matchers))
@@ -106,7 +119,65 @@ func matcherToQuery(matcher *models.Matcher) (idx.Query, error) {
 	case models.MatchEqual:
 		return idx.NewTermQuery([]byte(matcher.Name), []byte(matcher.Value)), nil
 
+	case models.MatchNotEqual:
+		if matcher.Value == "" {
+			// Not equal to the empty value matches series with the tag set.
+			return idx.NewRegexpQuery([]byte(matcher.Name), []byte(".+"))
+		}
+		q := idx.NewTermQuery([]byte(matcher.Name), []byte(matcher.Value))
+		return idx.NewNegationQuery(q), nil
+
+	case models.MatchNotRegexp:
+		return notRegexpMatcherToQuery(matcher)
+
+	case models.MatchGreaterThan, models.MatchGreaterThanOrEqual,
+		models.MatchLessThan, models.MatchLessThanOrEqual:
+		return numericMatcherToQuery(matcher)
+
 	default:
 		return idx.Query{}, fmt.Errorf("unsupported query type %v", matcher)
 	}
 }
+
+func notRegexpMatcherToQuery(matcher *models.Matcher) (idx.Query, error) {
+	q, err := idx.NewRegexpQuery([]byte(matcher.Name), []byte(matcher.Value))
+	if err != nil {
+		return idx.Query{}, err
+	}
+
+	anchored, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+	if err != nil {
+		return idx.Query{}, err
+	}
+	if !anchored.MatchString("") {
+		return idx.NewNegationQuery(q), nil
+	}
+
+	// Series without the tag have an empty value for it, which the regexp
+	// matches, so only series with the tag set can match.
+	exists, err := idx.NewRegexpQuery([]byte(matcher.Name), []byte(".+"))
+	if err != nil {
+		return idx.Query{}, err
+	}
+	return idx.NewConjunctionQuery(exists, idx.NewNegationQuery(q)), nil
+}
+
+func numericMatcherToQuery(matcher *models.Matcher) (idx.Query, error) {
+	v, err := strconv.ParseFloat(matcher.Value, 64)
+	if err != nil {
+		return idx.Query{}, fmt.Errorf("invalid numeric value for matcher %v: %v", matcher, err)
+	}
+
+	var r m3ninxindex.NumericRange
+	switch matcher.Type {
+	case models.MatchGreaterThan:
+		r = m3ninxindex.NewNumericRangeGreaterThan(v, false)
+	case models.MatchGreaterThanOrEqual:
+		r = m3ninxindex.NewNumericRangeGreaterThan(v, true)
+	case models.MatchLessThan:
+		r = m3ninxindex.NewNumericRangeLessThan(v, false)
+	default:
+		r = m3ninxindex.NewNumericRangeLessThan(v, true)
+	}
+	return idx.NewNumericRangeQuery([]byte(matcher.Name), r), nil
+}
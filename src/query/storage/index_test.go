@@ -88,3 +88,88 @@ func TestFetchQueryToM3Query(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "conjunction(term(t1, v1))", m3Query.String())
 }
+
+func TestFetchQueryToM3QueryNumericRange(t *testing.T) {
+	matchers := models.Matchers{
+		{
+			Type:  models.MatchGreaterThanOrEqual,
+			Name:  "instance_port",
+			Value: "8000",
+		},
+		{
+			Type:  models.MatchLessThan,
+			Name:  "instance_port",
+			Value: "9000",
+		},
+	}
+
+	fetchQuery := &FetchQuery{
+		Raw:         "up",
+		TagMatchers: matchers,
+		Start:       now.Add(-5 * time.Minute),
+		End:         now,
+		Interval:    15 * time.Second,
+	}
+
+	m3Query, err := FetchQueryToM3Query(fetchQuery)
+	require.NoError(t, err)
+	assert.Equal(t, "conjunction(range(instance_port, [8000, +Inf]), "+
+		"range(instance_port, [-Inf, 9000)))", m3Query.String())
+
+	fetchQuery.TagMatchers[0].Value = "abc"
+	_, err = FetchQueryToM3Query(fetchQuery)
+	require.Error(t, err)
+}
+
+func TestFetchQueryToM3QueryNegative(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchers models.Matchers
+		expected string
+	}{
+		{
+			name: "not equal",
+			matchers: models.Matchers{
+				{Type: models.MatchEqual, Name: "t1", Value: "v1"},
+				{Type: models.MatchNotEqual, Name: "t2", Value: "v2"},
+			},
+			expected: "conjunction(term(t1, v1), negation(term(t2, v2)))",
+		},
+		{
+			name: "only negative matchers",
+			matchers: models.Matchers{
+				{Type: models.MatchNotEqual, Name: "t1", Value: "v1"},
+				{Type: models.MatchNotRegexp, Name: "t2", Value: "v.+"},
+			},
+			expected: "conjunction(negation(term(t1, v1)), negation(regexp(t2, v.+)))",
+		},
+		{
+			name: "not equal empty",
+			matchers: models.Matchers{
+				{Type: models.MatchNotEqual, Name: "t1", Value: ""},
+			},
+			expected: "conjunction(regexp(t1, .+))",
+		},
+		{
+			name: "not regexp matching empty",
+			matchers: models.Matchers{
+				{Type: models.MatchNotRegexp, Name: "t1", Value: "v1|"},
+			},
+			expected: "conjunction(regexp(t1, .+), negation(regexp(t1, v1|)))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m3Query, err := FetchQueryToM3Query(&FetchQuery{
+				Raw:         "up",
+				TagMatchers: test.matchers,
+				Start:       now.Add(-5 * time.Minute),
+				End:         now,
+				Interval:    15 * time.Second,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, m3Query.String())
+		})
+	}
+}
@@ -70,6 +70,9 @@ type FetchQuery struct {
 	Start       time.Time       `json:"start"`
 	End         time.Time       `json:"end"`
 	Interval    time.Duration   `json:"interval"`
+	// Decimation is how series with a resolution far finer than the
+	// interval are reduced to a single value per step.
+	Decimation models.Decimation `json:"decimation"`
 }
 
 func (q *FetchQuery) String() string {
@@ -80,6 +83,34 @@ func (q *FetchQuery) String() string {
 type FetchOptions struct {
 	Limit    int
 	KillChan chan struct{}
+	// Stats if set collects statistics and warnings for the fetch.
+	Stats *models.QueryStats
+	// TagProjection if set restricts the tags of each fetched series to
+	// those named, the remaining tags are dropped before being decoded.
+	TagProjection []string
+	// TagRewrites are applied in order to the tags of each fetched series
+	// before the tag projection.
+	TagRewrites []TagRewrite
+	// Enricher if set adds the tags of lookup tables to the tags of each
+	// fetched series before the tag rewrites.
+	Enricher TagEnricher
+	// Enrichment are the names of the lookup tables applied to the fetched
+	// series in addition to those of the namespace fetched from.
+	Enrichment []string
+}
+
+// TagEnricher adds the tags of lookup tables joined on the tags of series.
+type TagEnricher interface {
+	// Enrich returns the tags with the tags of the lookup tables of the
+	// namespace and the named lookup tables added.
+	Enrich(namespace string, tables []string, tags models.Tags) models.Tags
+
+	// JoinTagNames returns the names of the tags the lookup tables of every
+	// namespace and the named lookup tables join on.
+	JoinTagNames(tables []string) []string
+
+	// Validate returns an error if any of the named lookup tables are unknown.
+	Validate(tables []string) error
 }
 
 // Querier handles queries against a storage.
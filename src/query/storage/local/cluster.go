@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/query/storage"
 	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
@@ -68,6 +69,14 @@ type ClusterNamespace interface {
 	NamespaceID() ident.ID
 	Attributes() storage.Attributes
 	Session() client.Session
+
+	// ReadConsistencyLevel returns the read consistency level of the
+	// session if known.
+	ReadConsistencyLevel() (topology.ReadConsistencyLevel, bool)
+
+	// Materialized returns whether the namespace is populated by the
+	// continuous queries of another namespace rather than by downsampling.
+	Materialized() bool
 }
 
 // ClusterNamespaces is a slice of ClusterNamespace instances.
@@ -85,12 +94,27 @@ func (n ClusterNamespaces) NumAggregatedClusterNamespaces() int {
 	return count
 }
 
+// NumDownsampledClusterNamespaces returns the number of aggregated
+// cluster namespaces that are populated by downsampling, namespaces
+// materialized by continuous queries are excluded.
+func (n ClusterNamespaces) NumDownsampledClusterNamespaces() int {
+	count := 0
+	for _, namespace := range n {
+		if namespace.Attributes().MetricsType == storage.AggregatedMetricsType &&
+			!namespace.Materialized() {
+			count++
+		}
+	}
+	return count
+}
+
 // UnaggregatedClusterNamespaceDefinition is the definition for the
 // cluster namespace that holds unaggregated metrics data.
 type UnaggregatedClusterNamespaceDefinition struct {
-	NamespaceID ident.ID
-	Session     client.Session
-	Retention   time.Duration
+	NamespaceID          ident.ID
+	Session              client.Session
+	Retention            time.Duration
+	ReadConsistencyLevel *topology.ReadConsistencyLevel
 }
 
 // Validate will validate the cluster namespace definition.
@@ -111,10 +135,14 @@ func (def UnaggregatedClusterNamespaceDefinition) Validate() error {
 // cluster namespace that holds aggregated metrics data at a
 // specific retention and resolution.
 type AggregatedClusterNamespaceDefinition struct {
-	NamespaceID ident.ID
-	Session     client.Session
-	Retention   time.Duration
-	Resolution  time.Duration
+	NamespaceID          ident.ID
+	Session              client.Session
+	Retention            time.Duration
+	Resolution           time.Duration
+	ReadConsistencyLevel *topology.ReadConsistencyLevel
+	// Materialized is set when the namespace is written by the continuous
+	// queries of another namespace instead of the downsampler.
+	Materialized bool
 }
 
 // Validate validates the cluster namespace definition.
@@ -240,9 +268,11 @@ func (c *clusters) Close() error {
 }
 
 type clusterNamespace struct {
-	namespaceID ident.ID
-	attributes  storage.Attributes
-	session     client.Session
+	namespaceID          ident.ID
+	attributes           storage.Attributes
+	session              client.Session
+	readConsistencyLevel *topology.ReadConsistencyLevel
+	materialized         bool
 }
 
 func newUnaggregatedClusterNamespace(
@@ -257,7 +287,8 @@ func newUnaggregatedClusterNamespace(
 			MetricsType: storage.UnaggregatedMetricsType,
 			Retention:   def.Retention,
 		},
-		session: def.Session,
+		session:              def.Session,
+		readConsistencyLevel: def.ReadConsistencyLevel,
 	}, nil
 }
 
@@ -274,7 +305,9 @@ func newAggregatedClusterNamespace(
 			Retention:   def.Retention,
 			Resolution:  def.Resolution,
 		},
-		session: def.Session,
+		session:              def.Session,
+		readConsistencyLevel: def.ReadConsistencyLevel,
+		materialized:         def.Materialized,
 	}, nil
 }
 
@@ -290,6 +323,17 @@ func (n *clusterNamespace) Session() client.Session {
 	return n.session
 }
 
+func (n *clusterNamespace) ReadConsistencyLevel() (topology.ReadConsistencyLevel, bool) {
+	if n.readConsistencyLevel == nil {
+		return 0, false
+	}
+	return *n.readConsistencyLevel, true
+}
+
+func (n *clusterNamespace) Materialized() bool {
+	return n.materialized
+}
+
 type syncMultiErrs struct {
 	sync.Mutex
 	multiErr xerrors.MultiError
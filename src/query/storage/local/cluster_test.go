@@ -92,6 +92,7 @@ func TestNewClustersFromConfig(t *testing.T) {
 					StorageMetricsType: storage.AggregatedMetricsType,
 					Retention:          365 * 24 * time.Hour,
 					Resolution:         10 * time.Minute,
+					Materialized:       true,
 				},
 			},
 		},
@@ -134,6 +135,13 @@ func TestNewClustersFromConfig(t *testing.T) {
 		Resolution:  10 * time.Minute,
 	}, aggregated1Year10Minute.Attributes())
 	assert.True(t, mockSession2 == aggregated1Year10Minute.Session())
+	assert.True(t, aggregated1Year10Minute.Materialized())
+	assert.False(t, aggregated1Month1Minute.Materialized())
+
+	// Materialized namespaces are not populated by the downsampler
+	namespaces := clusters.ClusterNamespaces()
+	assert.Equal(t, 2, namespaces.NumAggregatedClusterNamespaces())
+	assert.Equal(t, 1, namespaces.NumDownsampledClusterNamespaces())
 
 	// Ensure cannot resolve unexpected clusters
 	_, ok = clusters.AggregatedClusterNamespace(RetentionResolution{
@@ -70,6 +70,10 @@ type ClusterStaticNamespaceConfiguration struct {
 	StorageMetricsType storage.MetricsType `yaml:"storageMetricsType"`
 	Retention          time.Duration       `yaml:"retention" validate:"nonzero"`
 	Resolution         time.Duration       `yaml:"resolution" validate:"min=0"`
+
+	// Materialized marks an aggregated namespace as written by the continuous
+	// queries of another namespace, no downsampler is required to populate it.
+	Materialized bool `yaml:"materialized"`
 }
 
 type unaggregatedClusterNamespaceConfiguration struct {
@@ -189,10 +193,13 @@ func (c ClustersStaticConfiguration) NewClusters(
 			unaggregatedClusterNamespaceCfg.result.err)
 	}
 
+	unaggregatedReadConsistencyLevel := unaggregatedClusterNamespaceCfg.client.
+		Options().ReadConsistencyLevel()
 	unaggregatedClusterNamespace = UnaggregatedClusterNamespaceDefinition{
-		NamespaceID: ident.StringID(unaggregatedClusterNamespaceCfg.namespace.Namespace),
-		Session:     unaggregatedClusterNamespaceCfg.result.session,
-		Retention:   unaggregatedClusterNamespaceCfg.namespace.Retention,
+		NamespaceID:          ident.StringID(unaggregatedClusterNamespaceCfg.namespace.Namespace),
+		Session:              unaggregatedClusterNamespaceCfg.result.session,
+		Retention:            unaggregatedClusterNamespaceCfg.namespace.Retention,
+		ReadConsistencyLevel: &unaggregatedReadConsistencyLevel,
 	}
 
 	for i, cfg := range aggregatedClusterNamespacesCfgs {
@@ -201,12 +208,15 @@ func (c ClustersStaticConfiguration) NewClusters(
 				i, cfg.result.err)
 		}
 
+		readConsistencyLevel := cfg.client.Options().ReadConsistencyLevel()
 		for _, n := range cfg.namespaces {
 			def := AggregatedClusterNamespaceDefinition{
-				NamespaceID: ident.StringID(n.Namespace),
-				Session:     cfg.result.session,
-				Retention:   n.Retention,
-				Resolution:  n.Resolution,
+				NamespaceID:          ident.StringID(n.Namespace),
+				Session:              cfg.result.session,
+				Retention:            n.Retention,
+				Resolution:           n.Resolution,
+				ReadConsistencyLevel: &readConsistencyLevel,
+				Materialized:         n.Materialized,
 			}
 			aggregatedClusterNamespaces = append(aggregatedClusterNamespaces, def)
 		}
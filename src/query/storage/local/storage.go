@@ -24,10 +24,12 @@ import (
 	"context"
 	goerrors "errors"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/errors"
 	"github.com/m3db/m3/src/query/models"
@@ -40,7 +42,9 @@ import (
 )
 
 var (
-	errNoLocalClustersFulfillsQuery = goerrors.New("no clusters can fulfill query")
+	// ErrNoLocalClustersFulfillsQuery is returned when no cluster namespace
+	// has a retention that covers the start of the query.
+	ErrNoLocalClustersFulfillsQuery = goerrors.New("no clusters can fulfill query")
 )
 
 type localStorage struct {
@@ -80,6 +84,8 @@ func (s *localStorage) Fetch(ctx context.Context, query *storage.FetchQuery, opt
 		result     multiFetchResult
 		wg         sync.WaitGroup
 	)
+	// Forward the trace context of the request to the nodes.
+	opts.Trace, _ = tracing.FromContext(ctx)
 	for _, namespace := range namespaces {
 		namespace := namespace // Capture var
 
@@ -94,14 +100,14 @@ func (s *localStorage) Fetch(ctx context.Context, query *storage.FetchQuery, opt
 
 		wg.Add(1)
 		go func() {
-			r, err := s.fetch(namespace, m3query, opts)
+			r, err := s.fetch(namespace, m3query, opts, options)
 			result.add(namespace.Attributes(), r, err)
 			wg.Done()
 		}()
 	}
 
 	if fetches == 0 {
-		return nil, errNoLocalClustersFulfillsQuery
+		return nil, ErrNoLocalClustersFulfillsQuery
 	}
 
 	wg.Wait()
@@ -115,17 +121,60 @@ func (s *localStorage) fetch(
 	namespace ClusterNamespace,
 	query index.Query,
 	opts index.QueryOptions,
+	fetchOptions *storage.FetchOptions,
 ) (*storage.FetchResult, error) {
 	namespaceID := namespace.NamespaceID()
 	session := namespace.Session()
+	stats := fetchOptions.Stats
+	start := time.Now()
 
-	// TODO (nikunj): Handle second return param
-	iters, _, err := session.FetchTagged(namespaceID, query, opts)
+	iters, exhaustive, err := session.FetchTagged(namespaceID, query, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return storage.SeriesIteratorsToFetchResult(iters, namespaceID, s.workerPool)
+	if !exhaustive {
+		stats.AddWarning(fmt.Sprintf(
+			"fetch limit reached, results truncated for namespace: %s",
+			namespaceID.String()))
+	}
+	if level, ok := namespace.ReadConsistencyLevel(); ok {
+		stats.AddReadConsistency(level.String())
+	}
+
+	result, err := storage.SeriesIteratorsToFetchResult(iters, namespaceID, s.workerPool)
+	if err != nil {
+		return nil, err
+	}
+	storage.ApplyTagOptions(result, namespaceID.String(), fetchOptions)
+
+	var (
+		datapoints         = 0
+		incomplete         = 0
+		lowestCompleteness = 1.0
+	)
+	for _, series := range result.SeriesList {
+		datapoints += series.Len()
+		if completeness := series.Completeness(); completeness < 1 {
+			incomplete++
+			lowestCompleteness = math.Min(lowestCompleteness, completeness)
+		}
+	}
+	stats.AddFetched(len(result.SeriesList), datapoints)
+	stats.AddStorageCall(models.StorageCall{
+		Namespace:  namespaceID.String(),
+		Duration:   time.Since(start),
+		Series:     len(result.SeriesList),
+		Datapoints: datapoints,
+	})
+
+	if incomplete > 0 {
+		stats.AddWarning(fmt.Sprintf(
+			"%d of %d series incomplete for namespace: %s, lowest completeness: %.2f",
+			incomplete, len(result.SeriesList), namespaceID.String(), lowestCompleteness))
+	}
+
+	return result, nil
 }
 
 func (s *localStorage) FetchTags(ctx context.Context, query *storage.FetchQuery, options *storage.FetchOptions) (*storage.SearchResults, error) {
@@ -151,6 +200,8 @@ func (s *localStorage) FetchTags(ctx context.Context, query *storage.FetchQuery,
 		result     multiFetchTagsResult
 		wg         sync.WaitGroup
 	)
+	// Forward the trace context of the request to the nodes.
+	opts.Trace, _ = tracing.FromContext(ctx)
 	for _, namespace := range namespaces {
 		namespace := namespace // Capture var
 
@@ -165,13 +216,13 @@ func (s *localStorage) FetchTags(ctx context.Context, query *storage.FetchQuery,
 
 		wg.Add(1)
 		go func() {
-			result.add(s.fetchTags(namespace, m3query, opts))
+			result.add(s.fetchTags(namespace, m3query, opts, options.Stats))
 			wg.Done()
 		}()
 	}
 
 	if fetches == 0 {
-		return nil, errNoLocalClustersFulfillsQuery
+		return nil, ErrNoLocalClustersFulfillsQuery
 	}
 
 	wg.Wait()
@@ -185,16 +236,22 @@ func (s *localStorage) fetchTags(
 	namespace ClusterNamespace,
 	query index.Query,
 	opts index.QueryOptions,
+	stats *models.QueryStats,
 ) (*storage.SearchResults, error) {
 	namespaceID := namespace.NamespaceID()
 	session := namespace.Session()
 
-	// TODO (juchan): Handle second return param
-	iter, _, err := session.FetchTaggedIDs(namespaceID, query, opts)
+	iter, exhaustive, err := session.FetchTaggedIDs(namespaceID, query, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	if !exhaustive {
+		stats.AddWarning(fmt.Sprintf(
+			"fetch limit reached, results truncated for namespace: %s",
+			namespaceID.String()))
+	}
+
 	var metrics models.Metrics
 	for iter.Next() {
 		m, err := storage.FromM3IdentToMetric(iter.Current())
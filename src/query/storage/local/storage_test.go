@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/test/seriesiter"
@@ -223,6 +224,60 @@ func TestLocalRead(t *testing.T) {
 	assert.Equal(t, tags, results.SeriesList[0].Tags)
 }
 
+func TestLocalReadRecordsReadConsistency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	level := topology.ReadConsistencyLevelFollower
+	clusters, err := NewClusters(UnaggregatedClusterNamespaceDefinition{
+		NamespaceID:          ident.StringID("metrics_unaggregated"),
+		Session:              session,
+		Retention:            testRetention,
+		ReadConsistencyLevel: &level,
+	})
+	require.NoError(t, err)
+	store := NewStorage(clusters, nil)
+
+	testTags := seriesiter.GenerateTag()
+	session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), true, nil)
+
+	stats := models.NewQueryStats()
+	_, err = store.Fetch(context.TODO(), newFetchReq(),
+		&storage.FetchOptions{Limit: 100, Stats: stats})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"follower"}, stats.Snapshot().ReadConsistency)
+}
+
+func TestLocalReadWarnsIncompleteSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	clusters, err := NewClusters(UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_unaggregated"),
+		Session:     session,
+		Retention:   testRetention,
+	})
+	require.NoError(t, err)
+	store := NewStorage(clusters, nil)
+
+	testTags := seriesiter.GenerateTag()
+	session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(seriesiter.NewMockSeriesItersWithCompleteness(ctrl, testTags, 2, 2, 0.5), true, nil)
+
+	stats := models.NewQueryStats()
+	results, err := store.Fetch(context.TODO(), newFetchReq(),
+		&storage.FetchOptions{Limit: 100, Stats: stats})
+	require.NoError(t, err)
+	require.Len(t, results.SeriesList, 2)
+	assert.Equal(t, 0.5, results.SeriesList[0].Completeness())
+	assert.Equal(t, []string{
+		"2 of 2 series incomplete for namespace: metrics_unaggregated, lowest completeness: 0.50",
+	}, stats.Snapshot().Warnings)
+}
+
 func TestLocalReadNoClustersForTimeRangeError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -232,7 +287,7 @@ func TestLocalReadNoClustersForTimeRangeError(t *testing.T) {
 	searchReq.End = time.Now()
 	_, err := store.Fetch(context.TODO(), searchReq, &storage.FetchOptions{Limit: 100})
 	require.Error(t, err)
-	assert.Equal(t, errNoLocalClustersFulfillsQuery, err)
+	assert.Equal(t, ErrNoLocalClustersFulfillsQuery, err)
 }
 
 func TestLocalSearchError(t *testing.T) {
@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package partition
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3x/pool"
+)
+
+// Configuration configures partitioning unaggregated metrics into time
+// partitioned namespaces. Each partition namespace must be created in the
+// cluster before the partition starts receiving writes.
+type Configuration struct {
+	// NamespacePrefix is the prefix of partition namespaces, partitions are
+	// named with the prefix followed by the partition start such as
+	// metrics_2018_06 for monthly partitions.
+	NamespacePrefix string `yaml:"namespacePrefix" validate:"nonzero"`
+
+	// Period is the period covered by each partition, monthly or daily.
+	Period Period `yaml:"period"`
+
+	// Retention is how far back partitions are read, partitions older than
+	// the retention may be dropped.
+	Retention time.Duration `yaml:"retention" validate:"nonzero"`
+}
+
+// NewStorage returns a partitioned storage that writes partitions using the
+// session of the unaggregated cluster namespace.
+func (c Configuration) NewStorage(
+	base storage.Storage,
+	clusters local.Clusters,
+	workerPool pool.ObjectPool,
+) storage.Storage {
+	session := clusters.UnaggregatedClusterNamespace().Session()
+	partitioner := NewPartitioner(c.NamespacePrefix, c.Period)
+	return NewStorage(base, session, partitioner, c.Retention, workerPool)
+}
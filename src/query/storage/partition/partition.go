@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package partition provides a storage that writes unaggregated metrics into
+// time partitioned namespaces, such as one namespace per month, and reads
+// transparently across the partitions a query spans. Partitions older than
+// the retention can then be dropped wholesale rather than expired series by
+// series.
+package partition
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is the length of time covered by a single partition.
+type Period int
+
+const (
+	// MonthlyPeriod partitions by calendar month.
+	MonthlyPeriod Period = iota
+	// DailyPeriod partitions by calendar day.
+	DailyPeriod
+)
+
+var validPeriods = []Period{
+	MonthlyPeriod,
+	DailyPeriod,
+}
+
+func (p Period) String() string {
+	switch p {
+	case MonthlyPeriod:
+		return "monthly"
+	case DailyPeriod:
+		return "daily"
+	}
+	return "unknown"
+}
+
+// UnmarshalYAML unmarshals a period from a string.
+func (p *Period) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	if str == "" {
+		*p = MonthlyPeriod
+		return nil
+	}
+	for _, valid := range validPeriods {
+		if str == valid.String() {
+			*p = valid
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid partition period '%s' valid periods are: %v",
+		str, validPeriods)
+}
+
+func (p Period) layout() string {
+	if p == DailyPeriod {
+		return "2006_01_02"
+	}
+	return "2006_01"
+}
+
+// start returns the start of the partition containing t, partitions are
+// aligned to UTC calendar boundaries.
+func (p Period) start(t time.Time) time.Time {
+	t = t.UTC()
+	if p == DailyPeriod {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (p Period) next(start time.Time) time.Time {
+	if p == DailyPeriod {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// maxDuration returns the longest length of time a partition may cover.
+func (p Period) maxDuration() time.Duration {
+	if p == DailyPeriod {
+		return 24 * time.Hour
+	}
+	return 31 * 24 * time.Hour
+}
+
+// Partition is a single time partition.
+type Partition struct {
+	Namespace string
+	Start     time.Time
+	End       time.Time
+}
+
+// Partitioner resolves the partitions for times and time ranges.
+type Partitioner struct {
+	prefix string
+	period Period
+}
+
+// NewPartitioner returns a new partitioner that names partitions with the
+// namespace prefix followed by the start of the partition, for instance
+// metrics_2018_06 for a monthly partition.
+func NewPartitioner(prefix string, period Period) Partitioner {
+	return Partitioner{prefix: prefix, period: period}
+}
+
+// Partition returns the partition containing t.
+func (p Partitioner) Partition(t time.Time) Partition {
+	start := p.period.start(t)
+	return Partition{
+		Namespace: p.prefix + "_" + start.Format(p.period.layout()),
+		Start:     start,
+		End:       p.period.next(start),
+	}
+}
+
+// Partitions returns the partitions overlapping the range [start, end].
+func (p Partitioner) Partitions(start, end time.Time) []Partition {
+	var result []Partition
+	for !start.After(end) {
+		partition := p.Partition(start)
+		result = append(result, partition)
+		start = partition.End
+	}
+	return result
+}
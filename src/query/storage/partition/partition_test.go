@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package partition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestPartitionMonthly(t *testing.T) {
+	p := NewPartitioner("metrics", MonthlyPeriod)
+	partition := p.Partition(time.Date(2018, 6, 15, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, Partition{
+		Namespace: "metrics_2018_06",
+		Start:     time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:       time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC),
+	}, partition)
+}
+
+func TestPartitionDaily(t *testing.T) {
+	p := NewPartitioner("metrics", DailyPeriod)
+	partition := p.Partition(time.Date(2018, 12, 31, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, "metrics_2018_12_31", partition.Namespace)
+	assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), partition.End)
+}
+
+func TestPartitionsSpanningRange(t *testing.T) {
+	p := NewPartitioner("metrics", MonthlyPeriod)
+	partitions := p.Partitions(
+		time.Date(2018, 11, 20, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	var namespaces []string
+	for _, partition := range partitions {
+		namespaces = append(namespaces, partition.Namespace)
+	}
+	assert.Equal(t, []string{
+		"metrics_2018_11",
+		"metrics_2018_12",
+		"metrics_2019_01",
+	}, namespaces)
+}
+
+func TestPeriodUnmarshalYAML(t *testing.T) {
+	var cfg Configuration
+	require.NoError(t, yaml.Unmarshal([]byte("period: daily\n"), &cfg))
+	assert.Equal(t, DailyPeriod, cfg.Period)
+
+	require.Error(t, yaml.Unmarshal([]byte("period: weekly\n"), &cfg))
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package partition
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/pool"
+)
+
+type partitionedStorage struct {
+	sync.RWMutex
+
+	base        storage.Storage
+	session     client.Session
+	partitioner Partitioner
+	retention   time.Duration
+	workerPool  pool.ObjectPool
+	nowFn       func() time.Time
+	stores      map[string]storage.Storage
+}
+
+// NewStorage returns a storage that writes unaggregated metrics to the time
+// partition of each datapoint on the session, all other writes are passed
+// through to the base storage. Reads are served from every partition
+// overlapping the query range that is within the retention and from the
+// base storage, so data written before partitioning was enabled remains
+// readable.
+func NewStorage(
+	base storage.Storage,
+	session client.Session,
+	partitioner Partitioner,
+	retention time.Duration,
+	workerPool pool.ObjectPool,
+) storage.Storage {
+	return &partitionedStorage{
+		base:        base,
+		session:     session,
+		partitioner: partitioner,
+		retention:   retention,
+		workerPool:  workerPool,
+		nowFn:       time.Now,
+		stores:      make(map[string]storage.Storage),
+	}
+}
+
+func (s *partitionedStorage) store(p Partition) (storage.Storage, error) {
+	s.RLock()
+	store, ok := s.stores[p.Namespace]
+	s.RUnlock()
+	if ok {
+		return store, nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if store, ok := s.stores[p.Namespace]; ok {
+		return store, nil
+	}
+
+	// NB: The partition retention includes the length of a partition so
+	// that the oldest partition within the retention is still read in full.
+	clusters, err := local.NewClusters(local.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID(p.Namespace),
+		Session:     s.session,
+		Retention:   s.retention + s.partitioner.period.maxDuration(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	store = local.NewStorage(clusters, s.workerPool)
+	s.stores[p.Namespace] = store
+	return store, nil
+}
+
+type partitionQuery struct {
+	partition Partition
+	query     *storage.FetchQuery
+}
+
+// readPartitions returns the partitions to read within the retention, each
+// with the query restricted to the range of the partition.
+func (s *partitionedStorage) readPartitions(query *storage.FetchQuery) []partitionQuery {
+	start := query.Start
+	if earliest := s.nowFn().Add(-s.retention); start.Before(earliest) {
+		start = earliest
+	}
+
+	var result []partitionQuery
+	for _, p := range s.partitioner.Partitions(start, query.End) {
+		q := *query
+		if q.Start.Before(p.Start) {
+			q.Start = p.Start
+		}
+		if q.End.After(p.End) {
+			q.End = p.End
+		}
+		result = append(result, partitionQuery{partition: p, query: &q})
+	}
+	return result
+}
+
+// readAll calls the read function with the base storage and the query
+// along with each partition store and its restricted query in parallel.
+func (s *partitionedStorage) readAll(
+	query *storage.FetchQuery,
+	readFn func(store storage.Storage, query *storage.FetchQuery) error,
+) error {
+	var (
+		wg       sync.WaitGroup
+		errsLock sync.Mutex
+		errs     xerrors.MultiError
+		addErr   = func(err error) {
+			errsLock.Lock()
+			errs = errs.Add(err)
+			errsLock.Unlock()
+		}
+	)
+	read := func(store storage.Storage, query *storage.FetchQuery) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := readFn(store, query); err != nil {
+				addErr(err)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// The base storage may not retain data as far back as the partitions.
+		err := readFn(s.base, query)
+		if err != nil && err != local.ErrNoLocalClustersFulfillsQuery {
+			addErr(err)
+		}
+	}()
+	for _, pq := range s.readPartitions(query) {
+		store, err := s.store(pq.partition)
+		if err != nil {
+			addErr(err)
+			continue
+		}
+		read(store, pq.query)
+	}
+
+	wg.Wait()
+	return errs.FinalError()
+}
+
+func (s *partitionedStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	var (
+		lock    sync.Mutex
+		results []*storage.FetchResult
+	)
+	err := s.readAll(query, func(store storage.Storage, q *storage.FetchQuery) error {
+		r, err := store.Fetch(ctx, q, options)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		results = append(results, r)
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeFetchResults(results), nil
+}
+
+// mergeFetchResults merges results from multiple partitions, datapoints of
+// the same series from different partitions are combined in time order.
+func mergeFetchResults(results []*storage.FetchResult) *storage.FetchResult {
+	var (
+		merged = &storage.FetchResult{LocalOnly: true}
+		byName = make(map[string]int)
+		points []ts.Datapoints
+	)
+	for _, r := range results {
+		if !r.LocalOnly {
+			merged.LocalOnly = false
+		}
+		merged.HasNext = merged.HasNext || r.HasNext
+		for _, series := range r.SeriesList {
+			idx, ok := byName[series.Name()]
+			if !ok {
+				idx = len(merged.SeriesList)
+				byName[series.Name()] = idx
+				merged.SeriesList = append(merged.SeriesList, series)
+				points = append(points, nil)
+			}
+			values := series.Values()
+			for i := 0; i < values.Len(); i++ {
+				points[idx] = append(points[idx], values.DatapointAt(i))
+			}
+		}
+	}
+
+	for idx, series := range merged.SeriesList {
+		dps := points[idx]
+		sort.Sort(datapointsByTime(dps))
+		deduped := dps[:0]
+		for i, dp := range dps {
+			if i > 0 && dp.Timestamp.Equal(dps[i-1].Timestamp) {
+				continue
+			}
+			deduped = append(deduped, dp)
+		}
+		merged.SeriesList[idx] = ts.NewSeries(series.Name(), deduped, series.Tags)
+	}
+	if merged.SeriesList == nil {
+		merged.SeriesList = make(ts.SeriesList, 0)
+	}
+	return merged
+}
+
+type datapointsByTime ts.Datapoints
+
+func (d datapointsByTime) Len() int      { return len(d) }
+func (d datapointsByTime) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d datapointsByTime) Less(i, j int) bool {
+	return d[i].Timestamp.Before(d[j].Timestamp)
+}
+
+func (s *partitionedStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	var (
+		lock   sync.Mutex
+		seen   = make(map[string]struct{})
+		result = &storage.SearchResults{Metrics: make(models.Metrics, 0)}
+	)
+	err := s.readAll(query, func(store storage.Storage, q *storage.FetchQuery) error {
+		r, err := store.FetchTags(ctx, q, options)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		for _, metric := range r.Metrics {
+			if _, ok := seen[metric.ID]; ok {
+				continue
+			}
+			seen[metric.ID] = struct{}{}
+			result.Metrics = append(result.Metrics, metric)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *partitionedStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	fetchResult, err := s.Fetch(ctx, query, options)
+	if err != nil {
+		return block.Result{}, err
+	}
+	return storage.FetchResultToBlockResult(fetchResult, query)
+}
+
+func (s *partitionedStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	if query == nil || query.Attributes.MetricsType != storage.UnaggregatedMetricsType {
+		return s.base.Write(ctx, query)
+	}
+
+	// Split the datapoints by the partition they fall within
+	var (
+		partitions = make(map[string]Partition)
+		datapoints = make(map[string]ts.Datapoints)
+	)
+	for _, dp := range query.Datapoints {
+		p := s.partitioner.Partition(dp.Timestamp)
+		partitions[p.Namespace] = p
+		datapoints[p.Namespace] = append(datapoints[p.Namespace], dp)
+	}
+
+	var errs xerrors.MultiError
+	for namespace, p := range partitions {
+		store, err := s.store(p)
+		if err != nil {
+			errs = errs.Add(err)
+			continue
+		}
+		q := *query
+		q.Datapoints = datapoints[namespace]
+		errs = errs.Add(store.Write(ctx, &q))
+	}
+	return errs.FinalError()
+}
+
+func (s *partitionedStorage) Type() storage.Type {
+	return storage.TypeLocalDC
+}
+
+func (s *partitionedStorage) Close() error {
+	// NB: The session is owned by the base clusters and closed with them.
+	return s.base.Close()
+}
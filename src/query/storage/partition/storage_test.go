@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package partition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeFetchResults(t *testing.T) {
+	var (
+		now  = time.Now().Truncate(time.Second)
+		tags = models.Tags{"__name__": "foo"}
+	)
+	merged := mergeFetchResults([]*storage.FetchResult{
+		{
+			SeriesList: ts.SeriesList{ts.NewSeries("foo", ts.Datapoints{
+				{Timestamp: now.Add(2 * time.Second), Value: 3},
+				{Timestamp: now.Add(3 * time.Second), Value: 4},
+			}, tags)},
+			LocalOnly: true,
+		},
+		{
+			SeriesList: ts.SeriesList{
+				ts.NewSeries("foo", ts.Datapoints{
+					{Timestamp: now, Value: 1},
+					{Timestamp: now.Add(time.Second), Value: 2},
+					{Timestamp: now.Add(2 * time.Second), Value: 3},
+				}, tags),
+				ts.NewSeries("bar", ts.Datapoints{
+					{Timestamp: now, Value: 5},
+				}, models.Tags{"__name__": "bar"}),
+			},
+			LocalOnly: true,
+		},
+	})
+
+	require.Len(t, merged.SeriesList, 2)
+	assert.True(t, merged.LocalOnly)
+
+	foo := merged.SeriesList[0]
+	assert.Equal(t, "foo", foo.Name())
+	require.Equal(t, 4, foo.Len())
+	for i := 0; i < foo.Len(); i++ {
+		dp := foo.Values().DatapointAt(i)
+		assert.Equal(t, now.Add(time.Duration(i)*time.Second), dp.Timestamp)
+		assert.Equal(t, float64(i+1), dp.Value)
+	}
+
+	assert.Equal(t, "bar", merged.SeriesList[1].Name())
+	assert.Equal(t, 1, merged.SeriesList[1].Len())
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+var errEmptyTagRewriteDst = errors.New("tag rewrite destination tag name is empty")
+
+// TagRewrite is a label_replace rewrite of the tags of a series, the
+// destination tag is set to the replacement expanded against the value of
+// the source tag when the value matches the regular expression.
+type TagRewrite struct {
+	Dst         string
+	Replacement string
+	Src         string
+	Regex       *regexp.Regexp
+}
+
+// NewTagRewrite creates a new tag rewrite, the regular expression is
+// anchored to match the whole value of the source tag.
+func NewTagRewrite(dst, replacement, src, regex string) (TagRewrite, error) {
+	if dst == "" {
+		return TagRewrite{}, errEmptyTagRewriteDst
+	}
+
+	re, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		return TagRewrite{}, fmt.Errorf("invalid tag rewrite regular expression %s: %v", regex, err)
+	}
+
+	return TagRewrite{
+		Dst:         dst,
+		Replacement: replacement,
+		Src:         src,
+		Regex:       re,
+	}, nil
+}
+
+// Rewrite returns the tags with the rewrite applied, the tags passed in are
+// never modified. The destination tag is removed if the expanded
+// replacement is empty.
+func (r TagRewrite) Rewrite(tags models.Tags) models.Tags {
+	value := tags[r.Src]
+	indexes := r.Regex.FindStringSubmatchIndex(value)
+	if indexes == nil {
+		return tags
+	}
+
+	expanded := r.Regex.ExpandString(nil, r.Replacement, value, indexes)
+	rewritten := make(models.Tags, len(tags)+1)
+	for name, value := range tags {
+		rewritten[name] = value
+	}
+
+	if len(expanded) == 0 {
+		delete(rewritten, r.Dst)
+	} else {
+		rewritten[r.Dst] = string(expanded)
+	}
+
+	return rewritten
+}
+
+// String representation
+func (r TagRewrite) String() string {
+	return fmt.Sprintf("dst: %s, replacement: %s, src: %s, regex: %s",
+		r.Dst, r.Replacement, r.Src, r.Regex.String())
+}
+
+// FetchTagNames returns the names of the tags that must be fetched to apply
+// the tag projection and rewrites of the fetch options, or nil if every tag
+// is required.
+func FetchTagNames(options *FetchOptions) []string {
+	if options == nil || len(options.TagProjection) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(options.TagProjection)+2*len(options.TagRewrites))
+	names = append(names, options.TagProjection...)
+	for _, rewrite := range options.TagRewrites {
+		names = append(names, rewrite.Src, rewrite.Dst)
+	}
+	if options.Enricher != nil {
+		// The tags lookup tables join on are required to enrich the series.
+		names = append(names, options.Enricher.JoinTagNames(options.Enrichment)...)
+	}
+
+	return names
+}
+
+// ApplyTagOptions applies the enrichment of the namespace fetched from, the
+// tag rewrites and then the tag projection of the fetch options to each
+// series of the result.
+func ApplyTagOptions(result *FetchResult, namespace string, options *FetchOptions) {
+	if options == nil || (options.Enricher == nil &&
+		len(options.TagRewrites) == 0 && len(options.TagProjection) == 0) {
+		return
+	}
+
+	for _, series := range result.SeriesList {
+		tags := series.Tags
+		if options.Enricher != nil {
+			tags = options.Enricher.Enrich(namespace, options.Enrichment, tags)
+		}
+		for _, rewrite := range options.TagRewrites {
+			tags = rewrite.Rewrite(tags)
+		}
+
+		if len(options.TagProjection) > 0 {
+			tags = projectTags(tags, options.TagProjection)
+		}
+
+		series.Tags = tags
+	}
+}
+
+func projectTags(tags models.Tags, names []string) models.Tags {
+	projected := make(models.Tags, len(names))
+	for _, name := range names {
+		if value, ok := tags[name]; ok {
+			projected[name] = value
+		}
+	}
+
+	return projected
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagRewrite(t *testing.T) {
+	rewrite, err := NewTagRewrite("service", "$1", "job", "(.*)-canary")
+	require.NoError(t, err)
+
+	tags := models.Tags{"job": "api-canary", "service": "old"}
+	assert.Equal(t, models.Tags{"job": "api-canary", "service": "api"}, rewrite.Rewrite(tags))
+	// The tags passed in are left untouched.
+	assert.Equal(t, "old", tags["service"])
+
+	// The regular expression is anchored so partial matches do not rewrite.
+	tags = models.Tags{"job": "api-canary-1"}
+	assert.Equal(t, tags, rewrite.Rewrite(tags))
+}
+
+func TestTagRewriteEmptyReplacementRemovesTag(t *testing.T) {
+	rewrite, err := NewTagRewrite("service", "", "job", ".*")
+	require.NoError(t, err)
+
+	tags := models.Tags{"job": "api", "service": "api"}
+	assert.Equal(t, models.Tags{"job": "api"}, rewrite.Rewrite(tags))
+}
+
+func TestNewTagRewriteInvalid(t *testing.T) {
+	_, err := NewTagRewrite("", "$1", "job", "(.*)")
+	assert.Error(t, err)
+
+	_, err = NewTagRewrite("service", "$1", "job", "(.*")
+	assert.Error(t, err)
+}
+
+func TestFetchTagNames(t *testing.T) {
+	rewrite, err := NewTagRewrite("service", "$1", "job", "(.*)")
+	require.NoError(t, err)
+
+	assert.Nil(t, FetchTagNames(nil))
+	assert.Nil(t, FetchTagNames(&FetchOptions{TagRewrites: []TagRewrite{rewrite}}))
+	assert.Equal(t, []string{"service", "job", "service"}, FetchTagNames(&FetchOptions{
+		TagProjection: []string{"service"},
+		TagRewrites:   []TagRewrite{rewrite},
+	}))
+}
+
+func TestApplyTagOptions(t *testing.T) {
+	rewrite, err := NewTagRewrite("service", "$1", "job", "(.*)")
+	require.NoError(t, err)
+
+	series := ts.NewSeries("foo", nil, models.Tags{
+		"job":  "api",
+		"host": "a",
+		"dc":   "east",
+	})
+	result := &FetchResult{SeriesList: ts.SeriesList{series}}
+	ApplyTagOptions(result, "ns", &FetchOptions{
+		TagProjection: []string{"service", "dc"},
+		TagRewrites:   []TagRewrite{rewrite},
+	})
+
+	assert.Equal(t, models.Tags{"service": "api", "dc": "east"}, result.SeriesList[0].Tags)
+	assert.Equal(t, "foo", result.SeriesList[0].Name())
+}
+
+// testEnricher adds the team of the service of a series in the namespace ns.
+type testEnricher struct{}
+
+func (testEnricher) Enrich(namespace string, _ []string, tags models.Tags) models.Tags {
+	if namespace != "ns" {
+		return tags
+	}
+	enriched := models.Tags{"team": "team-" + tags["service"]}
+	for name, value := range tags {
+		enriched[name] = value
+	}
+	return enriched
+}
+
+func (testEnricher) JoinTagNames(_ []string) []string {
+	return []string{"service"}
+}
+
+func (testEnricher) Validate(_ []string) error {
+	return nil
+}
+
+func TestApplyTagOptionsEnrichment(t *testing.T) {
+	rewrite, err := NewTagRewrite("owner", "$1", "team", "(.*)")
+	require.NoError(t, err)
+
+	options := &FetchOptions{
+		TagProjection: []string{"owner"},
+		TagRewrites:   []TagRewrite{rewrite},
+		Enricher:      testEnricher{},
+	}
+	assert.Equal(t, []string{"owner", "team", "owner", "service"}, FetchTagNames(options))
+
+	newResult := func() *FetchResult {
+		series := ts.NewSeries("foo", nil, models.Tags{"service": "api"})
+		return &FetchResult{SeriesList: ts.SeriesList{series}}
+	}
+
+	// The enriched tags are rewritten before the projection.
+	result := newResult()
+	ApplyTagOptions(result, "ns", options)
+	assert.Equal(t, models.Tags{"owner": "team-api"}, result.SeriesList[0].Tags)
+
+	result = newResult()
+	ApplyTagOptions(result, "other", options)
+	assert.Equal(t, models.Tags{}, result.SeriesList[0].Tags)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package readonly
+
+import (
+	"errors"
+
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+
+	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	errNoKVStore = errors.New("read only KV key set without a KV store")
+)
+
+// Configuration is the read only mode configuration.
+type Configuration struct {
+	// Enabled rejects all writes.
+	Enabled bool `yaml:"enabled"`
+
+	// Namespaces are the cluster namespaces that reject writes.
+	Namespaces []string `yaml:"namespaces"`
+
+	// KVKey is the KV key to watch to toggle read only mode at runtime, the
+	// value is expected to be a string proto containing the YAML encoded
+	// enabled and namespaces fields, which replace the static values.
+	KVKey string `yaml:"kvKey"`
+}
+
+// NewFlags creates new read only flags, watching the KV key for updates
+// if one is configured.
+func (c Configuration) NewFlags(
+	store kv.Store,
+	logger *zap.Logger,
+) (*Flags, error) {
+	flags := NewFlags(c.Enabled, c.Namespaces)
+	if c.KVKey == "" {
+		return flags, nil
+	}
+
+	if store == nil {
+		return nil, errNoKVStore
+	}
+
+	watch, err := store.Watch(c.KVKey)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		protoValue := &commonpb.StringProto{}
+		for range watch.C() {
+			update := c
+			if value := watch.Get(); value != nil {
+				if err := value.Unmarshal(protoValue); err != nil {
+					logger.Warn("unable to unmarshal read only flags",
+						zap.String("key", c.KVKey), zap.Any("error", err))
+					continue
+				}
+
+				update = Configuration{}
+				if err := yaml.Unmarshal([]byte(protoValue.Value), &update); err != nil {
+					logger.Warn("unable to parse read only flags",
+						zap.String("key", c.KVKey), zap.Any("error", err))
+					continue
+				}
+			}
+
+			flags.Set(update.Enabled, update.Namespaces)
+			logger.Info("set read only flags",
+				zap.String("key", c.KVKey), zap.Bool("enabled", update.Enabled),
+				zap.Strings("namespaces", update.Namespaces))
+		}
+	}()
+
+	return flags, nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package readonly provides a storage that rejects writes while the
+// coordinator or specific cluster namespaces are in read only mode.
+package readonly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/local"
+)
+
+var (
+	// ErrReadOnly is returned for writes while the coordinator is read only.
+	ErrReadOnly = errors.New("coordinator is read only, writes are rejected")
+)
+
+// Flags are the read only flags of the coordinator, they can be updated at
+// runtime to toggle read only mode without a restart.
+type Flags struct {
+	sync.RWMutex
+	readOnly   bool
+	namespaces map[string]struct{}
+}
+
+// NewFlags returns new read only flags.
+func NewFlags(readOnly bool, namespaces []string) *Flags {
+	f := &Flags{}
+	f.Set(readOnly, namespaces)
+	return f
+}
+
+// Set sets whether all writes are rejected and the cluster namespaces that
+// reject writes independent of whether all writes are rejected.
+func (f *Flags) Set(readOnly bool, namespaces []string) {
+	set := make(map[string]struct{}, len(namespaces))
+	for _, namespace := range namespaces {
+		set[namespace] = struct{}{}
+	}
+
+	f.Lock()
+	f.readOnly = readOnly
+	f.namespaces = set
+	f.Unlock()
+}
+
+// ReadOnly returns whether all writes are rejected.
+func (f *Flags) ReadOnly() bool {
+	f.RLock()
+	readOnly := f.readOnly
+	f.RUnlock()
+	return readOnly
+}
+
+// NamespaceReadOnly returns whether writes to a cluster namespace are
+// rejected.
+func (f *Flags) NamespaceReadOnly(namespace string) bool {
+	f.RLock()
+	_, ok := f.namespaces[namespace]
+	readOnly := f.readOnly || ok
+	f.RUnlock()
+	return readOnly
+}
+
+func (f *Flags) hasNamespaces() bool {
+	f.RLock()
+	n := len(f.namespaces)
+	f.RUnlock()
+	return n > 0
+}
+
+type readOnlyStorage struct {
+	storage.Storage
+	clusters local.Clusters
+	flags    *Flags
+}
+
+// NewStorage returns a storage that rejects writes while the flags are read
+// only, or the cluster namespace of the clusters a write resolves to is read
+// only, reads are passed through to the underlying storage.
+func NewStorage(
+	store storage.Storage,
+	clusters local.Clusters,
+	flags *Flags,
+) storage.Storage {
+	return &readOnlyStorage{
+		Storage:  store,
+		clusters: clusters,
+		flags:    flags,
+	}
+}
+
+func (s *readOnlyStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	if s.flags.ReadOnly() {
+		return ErrReadOnly
+	}
+
+	if query != nil && s.clusters != nil && s.flags.hasNamespaces() {
+		if namespace, ok := s.namespace(query.Attributes); ok &&
+			s.flags.NamespaceReadOnly(namespace) {
+			return fmt.Errorf("namespace %s is read only, writes are rejected",
+				namespace)
+		}
+	}
+
+	return s.Storage.Write(ctx, query)
+}
+
+// namespace returns the cluster namespace a write with the attributes is
+// written to.
+func (s *readOnlyStorage) namespace(attrs storage.Attributes) (string, bool) {
+	switch attrs.MetricsType {
+	case storage.UnaggregatedMetricsType:
+		namespace := s.clusters.UnaggregatedClusterNamespace()
+		return namespace.NamespaceID().String(), true
+	case storage.AggregatedMetricsType:
+		namespace, ok := s.clusters.AggregatedClusterNamespace(
+			local.RetentionResolution{
+				Retention:  attrs.Retention,
+				Resolution: attrs.Resolution,
+			})
+		if !ok {
+			return "", false
+		}
+		return namespace.NamespaceID().String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package readonly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClusters(t *testing.T, ctrl *gomock.Controller) local.Clusters {
+	clusters, err := local.NewClusters(local.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_unaggregated"),
+		Session:     client.NewMockSession(ctrl),
+		Retention:   24 * time.Hour,
+	}, local.AggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_aggregated"),
+		Session:     client.NewMockSession(ctrl),
+		Retention:   24 * time.Hour,
+		Resolution:  time.Minute,
+	})
+	require.NoError(t, err)
+	return clusters
+}
+
+func newWriteQuery(attrs storage.Attributes) *storage.WriteQuery {
+	return &storage.WriteQuery{
+		Tags:       models.Tags{"foo": "bar"},
+		Attributes: attrs,
+	}
+}
+
+func TestReadOnlyStorageRejectsAllWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStorage()
+	flags := NewFlags(true, nil)
+	s := NewStorage(store, newTestClusters(t, ctrl), flags)
+
+	unaggregated := newWriteQuery(storage.Attributes{
+		MetricsType: storage.UnaggregatedMetricsType,
+	})
+	assert.Equal(t, ErrReadOnly, s.Write(context.TODO(), unaggregated))
+	assert.Equal(t, 0, len(store.Writes()))
+
+	flags.Set(false, nil)
+	require.NoError(t, s.Write(context.TODO(), unaggregated))
+	assert.Equal(t, 1, len(store.Writes()))
+}
+
+func TestReadOnlyStorageRejectsNamespaceWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStorage()
+	flags := NewFlags(false, []string{"metrics_aggregated"})
+	s := NewStorage(store, newTestClusters(t, ctrl), flags)
+
+	unaggregated := newWriteQuery(storage.Attributes{
+		MetricsType: storage.UnaggregatedMetricsType,
+	})
+	aggregated := newWriteQuery(storage.Attributes{
+		MetricsType: storage.AggregatedMetricsType,
+		Retention:   24 * time.Hour,
+		Resolution:  time.Minute,
+	})
+
+	require.NoError(t, s.Write(context.TODO(), unaggregated))
+	err := s.Write(context.TODO(), aggregated)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "metrics_aggregated")
+	assert.Equal(t, 1, len(store.Writes()))
+
+	assert.True(t, flags.NamespaceReadOnly("metrics_aggregated"))
+	assert.False(t, flags.NamespaceReadOnly("metrics_unaggregated"))
+	assert.False(t, flags.ReadOnly())
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package retry provides a storage that retries fetches that fail
+// transiently, and helpers to retry fragments of queries that fail
+// transiently without recomputing the rest of the query.
+package retry
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/storage"
+	xerrors "github.com/m3db/m3x/errors"
+	xretry "github.com/m3db/m3x/retry"
+)
+
+// IsTransientError returns whether an error may not be returned again if
+// the operation that returned it is retried, errors of cancelled or timed
+// out operations, blocked queries and invalid or non-retryable requests
+// are not transient.
+func IsTransientError(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case err == context.Canceled, err == context.DeadlineExceeded:
+		return false
+	case xerrors.IsNonRetryableError(err), xerrors.IsInvalidParams(err):
+		return false
+	case rules.IsBlockedError(err):
+		return false
+	}
+	return true
+}
+
+// Attempt calls fn, retrying it with the backoff of the retrier while it
+// fails transiently and the context is not done. Errors that are not
+// transient are returned as is, transient errors that are still returned
+// once the retries are exhausted are returned as non-retryable errors so
+// that fragments of queries composed of attempts are not retried again. A
+// nil retrier calls fn once.
+func Attempt(ctx context.Context, retrier xretry.Retrier, fn func() error) error {
+	if retrier == nil {
+		return fn()
+	}
+
+	var lastErr error
+	err := retrier.AttemptWhile(func(int) bool {
+		return ctx.Err() == nil
+	}, func() error {
+		lastErr = fn()
+		if lastErr != nil && !IsTransientError(lastErr) {
+			return xerrors.NewNonRetryableError(lastErr)
+		}
+		return lastErr
+	})
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if !IsTransientError(lastErr) {
+		return lastErr
+	}
+	return xerrors.NewNonRetryableError(lastErr)
+}
+
+type retryStorage struct {
+	storage.Storage
+	retrier xretry.Retrier
+}
+
+// NewStorage returns a storage that retries fetches of the underlying
+// storage that fail transiently, writes are passed through to the
+// underlying storage.
+func NewStorage(store storage.Storage, retrier xretry.Retrier) storage.Storage {
+	return &retryStorage{
+		Storage: store,
+		retrier: retrier,
+	}
+}
+
+func (s *retryStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	var result *storage.FetchResult
+	err := Attempt(ctx, s.retrier, func() error {
+		var err error
+		result, err = s.Storage.Fetch(ctx, query, options)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *retryStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	var result *storage.SearchResults
+	err := Attempt(ctx, s.retrier, func() error {
+		var err error
+		result, err = s.Storage.FetchTags(ctx, query, options)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *retryStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	var result block.Result
+	err := Attempt(ctx, s.retrier, func() error {
+		var err error
+		result, err = s.Storage.FetchBlocks(ctx, query, options)
+		return err
+	})
+	if err != nil {
+		return block.Result{}, err
+	}
+	return result, nil
+}
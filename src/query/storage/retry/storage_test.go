@@ -0,0 +1,137 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3x/errors"
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRetrier(maxRetries int) xretry.Retrier {
+	return xretry.NewRetrier(xretry.NewOptions().
+		SetInitialBackoff(time.Millisecond).
+		SetMaxRetries(maxRetries).
+		SetJitter(false))
+}
+
+// flakyStorage fails the first fetches with an error.
+type flakyStorage struct {
+	mock.Storage
+	failures int
+	err      error
+	fetches  int
+}
+
+func (s *flakyStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	s.fetches++
+	if s.fetches <= s.failures {
+		return nil, s.err
+	}
+	return s.Storage.Fetch(ctx, query, options)
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.False(t, IsTransientError(nil))
+	assert.False(t, IsTransientError(context.Canceled))
+	assert.False(t, IsTransientError(context.DeadlineExceeded))
+	assert.False(t, IsTransientError(xerrors.NewNonRetryableError(errors.New("foo"))))
+	assert.False(t, IsTransientError(xerrors.NewInvalidParamsError(errors.New("foo"))))
+	assert.False(t, IsTransientError(rules.BlockedError{Rule: "foo"}))
+	assert.True(t, IsTransientError(errors.New("foo")))
+}
+
+func TestStorageRetriesTransientFetchErrors(t *testing.T) {
+	expected := &storage.FetchResult{SeriesList: []*ts.Series{
+		ts.NewSeries("foo", ts.NewFixedStepValues(time.Second, 1, 1, time.Now()), nil),
+	}}
+	store := &flakyStorage{Storage: mock.NewMockStorage(), failures: 2,
+		err: errors.New("connection reset")}
+	store.SetFetchResult(expected, nil)
+
+	s := NewStorage(store, newTestRetrier(2))
+	result, err := s.Fetch(context.TODO(), &storage.FetchQuery{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	assert.Equal(t, 3, store.fetches)
+}
+
+func TestStorageReturnsNonRetryableErrorOnceRetriesExhausted(t *testing.T) {
+	transientErr := errors.New("connection reset")
+	store := &flakyStorage{Storage: mock.NewMockStorage(), failures: 5,
+		err: transientErr}
+
+	s := NewStorage(store, newTestRetrier(2))
+	_, err := s.Fetch(context.TODO(), &storage.FetchQuery{}, nil)
+	require.Error(t, err)
+	assert.True(t, xerrors.IsNonRetryableError(err))
+	assert.Equal(t, transientErr, xerrors.GetInnerNonRetryableError(err))
+	assert.Equal(t, 3, store.fetches)
+}
+
+func TestStorageDoesNotRetryNonTransientErrors(t *testing.T) {
+	blockedErr := rules.BlockedError{Rule: "foo"}
+	store := &flakyStorage{Storage: mock.NewMockStorage(), failures: 5,
+		err: blockedErr}
+
+	s := NewStorage(store, newTestRetrier(2))
+	_, err := s.Fetch(context.TODO(), &storage.FetchQuery{}, nil)
+	assert.Equal(t, blockedErr, err)
+	assert.Equal(t, 1, store.fetches)
+}
+
+func TestAttemptStopsOnceContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := Attempt(ctx, newTestRetrier(5), func() error {
+		attempts++
+		cancel()
+		return errors.New("connection reset")
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestAttemptWithoutRetrier(t *testing.T) {
+	transientErr := errors.New("connection reset")
+	attempts := 0
+	err := Attempt(context.TODO(), nil, func() error {
+		attempts++
+		return transientErr
+	})
+	assert.Equal(t, transientErr, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package routing
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3x/pool"
+)
+
+// Configuration configures routing series to distinct clusters.
+type Configuration struct {
+	// Clusters are the named clusters that routes may route series to.
+	Clusters map[string]local.ClustersStaticConfiguration `yaml:"clusters"`
+
+	// Routes are evaluated in order, series are routed by the first route
+	// that matches them and otherwise to the default clusters.
+	Routes []RouteConfiguration `yaml:"routes"`
+}
+
+// RouteConfiguration configures a single route.
+type RouteConfiguration struct {
+	Name     string                       `yaml:"name" validate:"nonzero"`
+	Matchers []rules.MatcherConfiguration `yaml:"matchers" validate:"nonzero"`
+	// Clusters are the names of the clusters writes are replicated to,
+	// reads are served by the first cluster able to serve them.
+	Clusters []string `yaml:"clusters" validate:"nonzero"`
+}
+
+// NewClusters connects to all the named clusters.
+func (c Configuration) NewClusters(
+	opts local.ClustersStaticConfigurationOptions,
+) (map[string]local.Clusters, error) {
+	result := make(map[string]local.Clusters, len(c.Clusters))
+	for name, clustersCfg := range c.Clusters {
+		clusters, err := clustersCfg.NewClusters(opts)
+		if err != nil {
+			for _, connected := range result {
+				connected.Close()
+			}
+			return nil, fmt.Errorf("could not connect to routing cluster %s: %v",
+				name, err)
+		}
+		result[name] = clusters
+	}
+	return result, nil
+}
+
+// NewStores returns a storage for each of the connected clusters.
+func (c Configuration) NewStores(
+	clusters map[string]local.Clusters,
+	workerPool pool.ObjectPool,
+) map[string]storage.Storage {
+	stores := make(map[string]storage.Storage, len(clusters))
+	for name, cluster := range clusters {
+		stores[name] = local.NewStorage(cluster, workerPool)
+	}
+	return stores
+}
+
+// NewRoutes returns the configured routes, routing series to the named stores.
+func (c Configuration) NewRoutes(stores map[string]storage.Storage) ([]Route, error) {
+	routes := make([]Route, 0, len(c.Routes))
+	for _, routeCfg := range c.Routes {
+		route := Route{Name: routeCfg.Name}
+		for _, matcherCfg := range routeCfg.Matchers {
+			matcher, err := matcherCfg.NewMatcher()
+			if err != nil {
+				return nil, fmt.Errorf("route %s: %v", routeCfg.Name, err)
+			}
+			route.Matchers = append(route.Matchers, matcher)
+		}
+		for _, name := range routeCfg.Clusters {
+			store, ok := stores[name]
+			if !ok {
+				return nil, fmt.Errorf("route %s: unknown cluster %s",
+					routeCfg.Name, name)
+			}
+			route.Stores = append(route.Stores, store)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// NewStorage returns a storage routing series to the named stores,
+// all series not routed by a route are routed to the default store.
+func (c Configuration) NewStorage(
+	defaultStore storage.Storage,
+	stores map[string]storage.Storage,
+) (Storage, error) {
+	routes, err := c.NewRoutes(stores)
+	if err != nil {
+		return nil, err
+	}
+	return NewStorage(routes, defaultStore)
+}
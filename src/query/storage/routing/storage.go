@@ -0,0 +1,361 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package routing provides a storage that routes series to distinct
+// storage clusters by tag matchers, allowing one coordinator to front
+// isolated clusters per team or tenant.
+package routing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+var (
+	errNoRouteName     = errors.New("route name not set")
+	errNoRouteMatchers = errors.New("route matchers not set")
+	errNoRouteStores   = errors.New("route stores not set")
+)
+
+// Route routes series with tags matching all of its matchers to its stores,
+// writes are replicated to every store and reads are served by the first
+// store able to serve them.
+type Route struct {
+	Name     string
+	Matchers models.Matchers
+	Stores   []storage.Storage
+}
+
+// Validate validates the route.
+func (r Route) Validate() error {
+	if r.Name == "" {
+		return errNoRouteName
+	}
+	if len(r.Matchers) == 0 {
+		return errNoRouteMatchers
+	}
+	if len(r.Stores) == 0 {
+		return errNoRouteStores
+	}
+	return nil
+}
+
+// matchesTags returns whether the series tags match all route matchers.
+func (r Route) matchesTags(tags models.Tags) bool {
+	for _, m := range r.Matchers {
+		if !m.Matches(tags[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesQuery returns whether the query could select series routed by the
+// route and whether every series the query selects must be routed by it.
+func (r Route) matchesQuery(query *storage.FetchQuery) (may bool, must bool) {
+	must = true
+	for _, rm := range r.Matchers {
+		constrained := false
+		for _, qm := range query.TagMatchers {
+			if qm.Name != rm.Name || qm.Type != models.MatchEqual {
+				continue
+			}
+			if !rm.Matches(qm.Value) {
+				return false, false
+			}
+			constrained = true
+		}
+		if !constrained {
+			must = false
+		}
+	}
+	return true, must
+}
+
+// Storage is a storage that routes series by tag matchers, the routes
+// can be replaced at runtime.
+type Storage interface {
+	storage.Storage
+
+	// SetRoutes atomically replaces the routes, the routes are validated
+	// and the existing routes are kept if any are invalid.
+	SetRoutes(routes []Route) error
+}
+
+type routingStorage struct {
+	sync.RWMutex
+	routes       []Route
+	defaultStore storage.Storage
+	closeStores  []storage.Storage
+}
+
+// NewStorage returns a storage that routes series by the first route that
+// matches their tags, routing all other series to the default store. Reads
+// are aggregated across the default store and every route that may hold
+// series selected by the query.
+func NewStorage(routes []Route, defaultStore storage.Storage) (Storage, error) {
+	s := &routingStorage{
+		defaultStore: defaultStore,
+		closeStores:  []storage.Storage{defaultStore},
+	}
+	if err := s.SetRoutes(routes); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *routingStorage) SetRoutes(routes []Route) error {
+	for _, r := range routes {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.routes = routes
+	// Stores of replaced routes are still closed with the storage since
+	// they are owned by the storage once routed to.
+	for _, r := range routes {
+		for _, store := range r.Stores {
+			if !containsStore(s.closeStores, store) {
+				s.closeStores = append(s.closeStores, store)
+			}
+		}
+	}
+	return nil
+}
+
+func containsStore(stores []storage.Storage, store storage.Storage) bool {
+	for _, s := range stores {
+		if s == store {
+			return true
+		}
+	}
+	return false
+}
+
+// readStores returns the store groups to read from for a query, reads
+// are served by the first store of each group able to serve them.
+func (s *routingStorage) readStores(query *storage.FetchQuery) [][]storage.Storage {
+	s.RLock()
+	defer s.RUnlock()
+	var result [][]storage.Storage
+	for _, r := range s.routes {
+		may, must := r.matchesQuery(query)
+		if !may {
+			continue
+		}
+		result = append(result, r.Stores)
+		if must {
+			// Series selected by the query are only ever routed here
+			return result
+		}
+	}
+	return append(result, []storage.Storage{s.defaultStore})
+}
+
+func (s *routingStorage) writeStores(tags models.Tags) []storage.Storage {
+	s.RLock()
+	defer s.RUnlock()
+	for _, r := range s.routes {
+		if r.matchesTags(tags) {
+			return r.Stores
+		}
+	}
+	return []storage.Storage{s.defaultStore}
+}
+
+// readFromGroups reads from every store group in parallel, failing over to
+// the next store in a group if a store returns an error.
+func readFromGroups(
+	groups [][]storage.Storage,
+	readFn func(store storage.Storage) error,
+) error {
+	var (
+		wg       sync.WaitGroup
+		errsLock sync.Mutex
+		errs     xerrors.MultiError
+	)
+	for _, group := range groups {
+		group := group
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			for _, store := range group {
+				if err = readFn(store); err == nil {
+					return
+				}
+			}
+			errsLock.Lock()
+			errs = errs.Add(err)
+			errsLock.Unlock()
+		}()
+	}
+	wg.Wait()
+	return errs.FinalError()
+}
+
+func (s *routingStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	var (
+		lock   sync.Mutex
+		seen   = make(map[string]struct{})
+		result = &storage.FetchResult{
+			SeriesList: make(ts.SeriesList, 0),
+			LocalOnly:  true,
+		}
+	)
+	err := readFromGroups(s.readStores(query), func(store storage.Storage) error {
+		r, err := store.Fetch(ctx, query, options)
+		if err != nil {
+			return err
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		if !r.LocalOnly {
+			result.LocalOnly = false
+		}
+		result.HasNext = result.HasNext || r.HasNext
+		for _, series := range r.SeriesList {
+			if _, ok := seen[series.Name()]; ok {
+				continue
+			}
+			seen[series.Name()] = struct{}{}
+			result.SeriesList = append(result.SeriesList, series)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *routingStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	var (
+		lock   sync.Mutex
+		seen   = make(map[string]struct{})
+		result = &storage.SearchResults{}
+	)
+	err := readFromGroups(s.readStores(query), func(store storage.Storage) error {
+		r, err := store.FetchTags(ctx, query, options)
+		if err != nil {
+			return err
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		for _, metric := range r.Metrics {
+			if _, ok := seen[metric.ID]; ok {
+				continue
+			}
+			seen[metric.ID] = struct{}{}
+			result.Metrics = append(result.Metrics, metric)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *routingStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	var (
+		lock   sync.Mutex
+		result block.Result
+	)
+	err := readFromGroups(s.readStores(query), func(store storage.Storage) error {
+		r, err := store.FetchBlocks(ctx, query, options)
+		if err != nil {
+			return err
+		}
+
+		lock.Lock()
+		result.Blocks = append(result.Blocks, r.Blocks...)
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return block.Result{}, err
+	}
+	return result, nil
+}
+
+func (s *routingStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	var (
+		stores   = s.writeStores(query.Tags)
+		wg       sync.WaitGroup
+		errsLock sync.Mutex
+		errs     xerrors.MultiError
+	)
+	for _, store := range stores {
+		store := store
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.Write(ctx, query); err != nil {
+				errsLock.Lock()
+				errs = errs.Add(err)
+				errsLock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs.FinalError()
+}
+
+func (s *routingStorage) Type() storage.Type {
+	return storage.TypeLocalDC
+}
+
+func (s *routingStorage) Close() error {
+	s.RLock()
+	defer s.RUnlock()
+	var errs xerrors.MultiError
+	for _, store := range s.closeStores {
+		errs = errs.Add(store.Close())
+	}
+	return errs.FinalError()
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMatcher(t *testing.T, name, value string) *models.Matcher {
+	m, err := models.NewMatcher(models.MatchEqual, name, value)
+	require.NoError(t, err)
+	return m
+}
+
+func newTestFetchResult(names ...string) *storage.FetchResult {
+	result := &storage.FetchResult{LocalOnly: true}
+	for _, name := range names {
+		result.SeriesList = append(result.SeriesList,
+			ts.NewSeries(name, ts.NewFixedStepValues(time.Second, 1, 0, time.Time{}),
+				models.Tags{"__name__": name}))
+	}
+	return result
+}
+
+func newTestStorage(t *testing.T) (Storage, mock.Storage, mock.Storage, mock.Storage) {
+	var (
+		defaultStore = mock.NewMockStorage()
+		teamStoreA   = mock.NewMockStorage()
+		teamStoreB   = mock.NewMockStorage()
+	)
+	s, err := NewStorage([]Route{
+		{
+			Name:     "team",
+			Matchers: models.Matchers{newTestMatcher(t, "team", "a")},
+			Stores:   []storage.Storage{teamStoreA, teamStoreB},
+		},
+	}, defaultStore)
+	require.NoError(t, err)
+	return s, defaultStore, teamStoreA, teamStoreB
+}
+
+func TestNewStorageValidatesRoutes(t *testing.T) {
+	_, err := NewStorage([]Route{{Name: "empty"}}, mock.NewMockStorage())
+	assert.Equal(t, errNoRouteMatchers, err)
+}
+
+func TestSetRoutesReplacesRoutes(t *testing.T) {
+	s, defaultStore, teamStoreA, _ := newTestStorage(t)
+	teamStoreC := mock.NewMockStorage()
+
+	require.Equal(t, errNoRouteStores, s.SetRoutes([]Route{{
+		Name:     "invalid",
+		Matchers: models.Matchers{newTestMatcher(t, "team", "c")},
+	}}))
+
+	require.NoError(t, s.SetRoutes([]Route{{
+		Name:     "team",
+		Matchers: models.Matchers{newTestMatcher(t, "team", "c")},
+		Stores:   []storage.Storage{teamStoreC},
+	}}))
+
+	routed := &storage.WriteQuery{Tags: models.Tags{"team": "c"}}
+	require.NoError(t, s.Write(context.Background(), routed))
+	assert.Equal(t, []*storage.WriteQuery{routed}, teamStoreC.Writes())
+
+	unrouted := &storage.WriteQuery{Tags: models.Tags{"team": "a"}}
+	require.NoError(t, s.Write(context.Background(), unrouted))
+	assert.Equal(t, []*storage.WriteQuery{unrouted}, defaultStore.Writes())
+	assert.Empty(t, teamStoreA.Writes())
+}
+
+func TestWriteRoutesByTags(t *testing.T) {
+	s, defaultStore, teamStoreA, teamStoreB := newTestStorage(t)
+
+	routed := &storage.WriteQuery{Tags: models.Tags{"team": "a"}}
+	require.NoError(t, s.Write(context.Background(), routed))
+	assert.Equal(t, []*storage.WriteQuery{routed}, teamStoreA.Writes())
+	assert.Equal(t, []*storage.WriteQuery{routed}, teamStoreB.Writes())
+	assert.Empty(t, defaultStore.Writes())
+
+	unrouted := &storage.WriteQuery{Tags: models.Tags{"team": "b"}}
+	require.NoError(t, s.Write(context.Background(), unrouted))
+	assert.Equal(t, []*storage.WriteQuery{unrouted}, defaultStore.Writes())
+	assert.Len(t, teamStoreA.Writes(), 1)
+}
+
+func TestWriteReturnsReplicaErrors(t *testing.T) {
+	s, _, _, teamStoreB := newTestStorage(t)
+	teamStoreB.SetWriteResult(errors.New("write failed"))
+
+	err := s.Write(context.Background(),
+		&storage.WriteQuery{Tags: models.Tags{"team": "a"}})
+	assert.Error(t, err)
+}
+
+func TestFetchOnlyRoutedStoreForMatchingQuery(t *testing.T) {
+	s, defaultStore, teamStoreA, _ := newTestStorage(t)
+	defaultStore.SetFetchResult(newTestFetchResult("default"), nil)
+	teamStoreA.SetFetchResult(newTestFetchResult("a"), nil)
+
+	result, err := s.Fetch(context.Background(), &storage.FetchQuery{
+		TagMatchers: models.Matchers{newTestMatcher(t, "team", "a")},
+	}, &storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.SeriesList, 1)
+	assert.Equal(t, "a", result.SeriesList[0].Name())
+}
+
+func TestFetchAggregatesAcrossRoutes(t *testing.T) {
+	s, defaultStore, teamStoreA, _ := newTestStorage(t)
+	defaultStore.SetFetchResult(newTestFetchResult("default", "shared"), nil)
+	teamStoreA.SetFetchResult(newTestFetchResult("a", "shared"), nil)
+
+	result, err := s.Fetch(context.Background(), &storage.FetchQuery{
+		TagMatchers: models.Matchers{newTestMatcher(t, "__name__", "cpu")},
+	}, &storage.FetchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.SeriesList, 3)
+}
+
+func TestFetchSkipsUnmatchedRoutes(t *testing.T) {
+	s, defaultStore, teamStoreA, _ := newTestStorage(t)
+	defaultStore.SetFetchResult(newTestFetchResult("default"), nil)
+	teamStoreA.SetFetchResult(newTestFetchResult("a"), nil)
+
+	result, err := s.Fetch(context.Background(), &storage.FetchQuery{
+		TagMatchers: models.Matchers{newTestMatcher(t, "team", "b")},
+	}, &storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.SeriesList, 1)
+	assert.Equal(t, "default", result.SeriesList[0].Name())
+}
+
+func TestFetchFailsOverWithinRoute(t *testing.T) {
+	s, _, teamStoreA, teamStoreB := newTestStorage(t)
+	teamStoreA.SetFetchResult(nil, errors.New("unavailable"))
+	teamStoreB.SetFetchResult(newTestFetchResult("b"), nil)
+
+	result, err := s.Fetch(context.Background(), &storage.FetchQuery{
+		TagMatchers: models.Matchers{newTestMatcher(t, "team", "a")},
+	}, &storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.SeriesList, 1)
+	assert.Equal(t, "b", result.SeriesList[0].Name())
+}
+
+func TestFetchTagsDedupesMetrics(t *testing.T) {
+	s, defaultStore, teamStoreA, _ := newTestStorage(t)
+	defaultStore.SetFetchTagsResult(&storage.SearchResults{
+		Metrics: models.Metrics{{ID: "foo"}, {ID: "bar"}},
+	}, nil)
+	teamStoreA.SetFetchTagsResult(&storage.SearchResults{
+		Metrics: models.Metrics{{ID: "foo"}},
+	}, nil)
+
+	result, err := s.FetchTags(context.Background(), &storage.FetchQuery{},
+		&storage.FetchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Metrics, 2)
+}
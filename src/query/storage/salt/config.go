@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package salt
+
+import (
+	"github.com/m3db/m3/src/query/storage"
+)
+
+const (
+	// DefaultTagName is the default name of the tag holding the salt.
+	DefaultTagName = "__salt__"
+)
+
+// Configuration configures salting writes of hot series with a tag so that
+// the writes of a single series are spread across shards.
+type Configuration struct {
+	// TagName is the name of the tag holding the salt, defaults to __salt__.
+	TagName string `yaml:"tagName"`
+
+	// Buckets is the number of salt values writes are spread across.
+	Buckets int `yaml:"buckets" validate:"min=2"`
+
+	// Metrics are the metric names of the series to salt.
+	Metrics []string `yaml:"metrics" validate:"nonzero"`
+}
+
+// NewStorage returns a storage that salts the writes of the configured
+// metrics written to the base storage.
+func (c Configuration) NewStorage(base storage.Storage) storage.Storage {
+	tagName := c.TagName
+	if tagName == "" {
+		tagName = DefaultTagName
+	}
+	return NewStorage(base, tagName, c.Buckets, c.Metrics)
+}
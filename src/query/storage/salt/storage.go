@@ -0,0 +1,227 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package salt provides a storage that spreads the writes of extremely hot
+// series across shards by adding a tag with one of a fixed number of salt
+// values to each write, and recombines the salted series on read.
+package salt
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+)
+
+type saltedStorage struct {
+	storage.Storage
+	tagName string
+	buckets uint64
+	metrics map[string]struct{}
+	writes  uint64
+}
+
+// NewStorage returns a storage that adds a salt tag, rotating through the
+// number of buckets, to writes of series with one of the metric names so
+// the writes of each series are spread across as many series and shards.
+// The salt tag is removed from fetched series and series that only differ
+// by salt are merged, so reads return the series as written.
+func NewStorage(
+	base storage.Storage,
+	tagName string,
+	buckets int,
+	metrics []string,
+) storage.Storage {
+	set := make(map[string]struct{}, len(metrics))
+	for _, metric := range metrics {
+		set[metric] = struct{}{}
+	}
+	return &saltedStorage{
+		Storage: base,
+		tagName: tagName,
+		buckets: uint64(buckets),
+		metrics: set,
+	}
+}
+
+func (s *saltedStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	if query == nil || s.buckets < 2 {
+		return s.Storage.Write(ctx, query)
+	}
+	if _, ok := s.metrics[query.Tags[models.MetricName]]; !ok {
+		return s.Storage.Write(ctx, query)
+	}
+
+	salt := atomic.AddUint64(&s.writes, 1) % s.buckets
+	tags := make(models.Tags, len(query.Tags)+1)
+	for k, v := range query.Tags {
+		tags[k] = v
+	}
+	tags[s.tagName] = strconv.FormatUint(salt, 10)
+
+	q := *query
+	q.Tags = tags
+	return s.Storage.Write(ctx, &q)
+}
+
+func (s *saltedStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	result, err := s.Storage.Fetch(ctx, query, options)
+	if err != nil {
+		return nil, err
+	}
+	return s.unsalt(result), nil
+}
+
+// unsalt removes the salt tag from the series of the result and merges the
+// datapoints of series that only differ by salt in time order.
+func (s *saltedStorage) unsalt(result *storage.FetchResult) *storage.FetchResult {
+	var (
+		seriesList = make(ts.SeriesList, 0, len(result.SeriesList))
+		byID       = make(map[string]int)
+		points     []ts.Datapoints
+		salted     []bool
+	)
+	for _, series := range result.SeriesList {
+		if _, ok := series.Tags[s.tagName]; !ok {
+			seriesList = append(seriesList, series)
+			points = append(points, nil)
+			salted = append(salted, false)
+			continue
+		}
+
+		tags := s.unsaltTags(series.Tags)
+		id := tags.ID()
+		idx, ok := byID[id]
+		if !ok {
+			idx = len(seriesList)
+			byID[id] = idx
+			unsalted := ts.NewSeries(id, nil, tags)
+			unsalted.SetCompleteness(series.Completeness())
+			seriesList = append(seriesList, unsalted)
+			points = append(points, nil)
+			salted = append(salted, true)
+		} else if c := series.Completeness(); c < seriesList[idx].Completeness() {
+			seriesList[idx].SetCompleteness(c)
+		}
+
+		values := series.Values()
+		for i := 0; i < values.Len(); i++ {
+			points[idx] = append(points[idx], values.DatapointAt(i))
+		}
+	}
+
+	for idx, series := range seriesList {
+		if !salted[idx] {
+			continue
+		}
+		dps := points[idx]
+		sort.Sort(datapointsByTime(dps))
+		deduped := dps[:0]
+		for i, dp := range dps {
+			if i > 0 && dp.Timestamp.Equal(dps[i-1].Timestamp) {
+				continue
+			}
+			deduped = append(deduped, dp)
+		}
+		merged := ts.NewSeries(series.Name(), deduped, series.Tags)
+		merged.SetCompleteness(series.Completeness())
+		seriesList[idx] = merged
+	}
+
+	return &storage.FetchResult{
+		SeriesList: seriesList,
+		LocalOnly:  result.LocalOnly,
+		HasNext:    result.HasNext,
+	}
+}
+
+func (s *saltedStorage) unsaltTags(tags models.Tags) models.Tags {
+	unsalted := make(models.Tags, len(tags))
+	for k, v := range tags {
+		if k != s.tagName {
+			unsalted[k] = v
+		}
+	}
+	return unsalted
+}
+
+type datapointsByTime ts.Datapoints
+
+func (d datapointsByTime) Len() int      { return len(d) }
+func (d datapointsByTime) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d datapointsByTime) Less(i, j int) bool {
+	return d[i].Timestamp.Before(d[j].Timestamp)
+}
+
+func (s *saltedStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	result, err := s.Storage.FetchTags(ctx, query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		metrics = make(models.Metrics, 0, len(result.Metrics))
+		seen    = make(map[string]struct{})
+	)
+	for _, metric := range result.Metrics {
+		if _, ok := metric.Tags[s.tagName]; ok {
+			tags := s.unsaltTags(metric.Tags)
+			metric = &models.Metric{
+				Namespace: metric.Namespace,
+				ID:        tags.ID(),
+				Tags:      tags,
+			}
+		}
+		if _, ok := seen[metric.ID]; ok {
+			continue
+		}
+		seen[metric.ID] = struct{}{}
+		metrics = append(metrics, metric)
+	}
+	return &storage.SearchResults{Metrics: metrics}, nil
+}
+
+func (s *saltedStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	fetchResult, err := s.Fetch(ctx, query, options)
+	if err != nil {
+		return block.Result{}, err
+	}
+	return storage.FetchResultToBlockResult(fetchResult, query)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package salt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaltedStorageWrite(t *testing.T) {
+	base := mock.NewMockStorage()
+	store := NewStorage(base, DefaultTagName, 2, []string{"hot"})
+
+	hot := models.Tags{models.MetricName: "hot", "host": "a"}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Write(context.TODO(), &storage.WriteQuery{Tags: hot}))
+	}
+	cold := models.Tags{models.MetricName: "cold"}
+	require.NoError(t, store.Write(context.TODO(), &storage.WriteQuery{Tags: cold}))
+
+	writes := base.Writes()
+	require.Len(t, writes, 4)
+	assert.Equal(t, "1", writes[0].Tags[DefaultTagName])
+	assert.Equal(t, "0", writes[1].Tags[DefaultTagName])
+	assert.Equal(t, "1", writes[2].Tags[DefaultTagName])
+	assert.Equal(t, "a", writes[0].Tags["host"])
+	assert.Equal(t, cold, writes[3].Tags)
+
+	// The tags of the write are not modified.
+	_, ok := hot[DefaultTagName]
+	assert.False(t, ok)
+}
+
+func TestSaltedStorageFetchMergesSaltedSeries(t *testing.T) {
+	var (
+		now   = time.Now().Truncate(time.Second)
+		tags  = models.Tags{models.MetricName: "hot"}
+		salt0 = models.Tags{models.MetricName: "hot", DefaultTagName: "0"}
+		salt1 = models.Tags{models.MetricName: "hot", DefaultTagName: "1"}
+		base  = mock.NewMockStorage()
+	)
+	partial := ts.NewSeries(salt1.ID(), ts.Datapoints{
+		{Timestamp: now.Add(time.Second), Value: 2},
+	}, salt1)
+	partial.SetCompleteness(0.5)
+	base.SetFetchResult(&storage.FetchResult{
+		SeriesList: ts.SeriesList{
+			ts.NewSeries(salt0.ID(), ts.Datapoints{
+				{Timestamp: now, Value: 1},
+				{Timestamp: now.Add(2 * time.Second), Value: 3},
+			}, salt0),
+			ts.NewSeries("cold", ts.Datapoints{{Timestamp: now, Value: 4}},
+				models.Tags{models.MetricName: "cold"}),
+			partial,
+		},
+		LocalOnly: true,
+	}, nil)
+
+	store := NewStorage(base, DefaultTagName, 2, []string{"hot"})
+	result, err := store.Fetch(context.TODO(), &storage.FetchQuery{},
+		&storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.SeriesList, 2)
+	assert.True(t, result.LocalOnly)
+
+	hot := result.SeriesList[0]
+	assert.Equal(t, tags.ID(), hot.Name())
+	assert.Equal(t, tags, hot.Tags)
+	assert.Equal(t, 0.5, hot.Completeness())
+	require.Equal(t, 3, hot.Len())
+	for i := 0; i < hot.Len(); i++ {
+		dp := hot.Values().DatapointAt(i)
+		assert.Equal(t, now.Add(time.Duration(i)*time.Second), dp.Timestamp)
+		assert.Equal(t, float64(i+1), dp.Value)
+	}
+
+	assert.Equal(t, "cold", result.SeriesList[1].Name())
+}
+
+func TestSaltedStorageFetchTagsRemovesSalt(t *testing.T) {
+	var (
+		salt0 = models.Tags{models.MetricName: "hot", DefaultTagName: "0"}
+		salt1 = models.Tags{models.MetricName: "hot", DefaultTagName: "1"}
+		base  = mock.NewMockStorage()
+	)
+	base.SetFetchTagsResult(&storage.SearchResults{Metrics: models.Metrics{
+		{ID: salt0.ID(), Tags: salt0},
+		{ID: salt1.ID(), Tags: salt1},
+	}}, nil)
+
+	store := NewStorage(base, DefaultTagName, 2, []string{"hot"})
+	result, err := store.FetchTags(context.TODO(), &storage.FetchQuery{},
+		&storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Metrics, 1)
+	assert.Equal(t, models.Tags{models.MetricName: "hot"}, result.Metrics[0].Tags)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package temporality
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/policy/rules"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// DefaultTagName is the default name of the tag marking the temporality
+	// of a series.
+	DefaultTagName = "__temporality__"
+
+	// DeltaTagValue is the value of the temporality tag of delta series.
+	DeltaTagValue = "delta"
+
+	defaultStaleAfter       = 15 * time.Minute
+	defaultMaxTrackedSeries = 100000
+)
+
+// Configuration is the configuration for converting delta temporality
+// counters into cumulative series as they are written.
+type Configuration struct {
+	// TagName is the name of the tag marking the temporality of a series,
+	// series with the tag set to delta are converted and written without
+	// the tag, defaults to __temporality__.
+	TagName string `yaml:"tagName"`
+
+	// Matchers select series without a temporality tag that are converted,
+	// series must match all of the matchers (optional).
+	Matchers []rules.MatcherConfiguration `yaml:"matchers"`
+
+	// StaleAfter is how long after the last datapoint of a series its
+	// cumulative value is reset, a gap this long is treated as a restart of
+	// the source and the series restarts from zero, defaults to 15m.
+	StaleAfter time.Duration `yaml:"staleAfter" validate:"min=0"`
+
+	// MaxTrackedSeries is the max number of series whose cumulative value
+	// is tracked, datapoints of further series are dropped until tracked
+	// series become stale, defaults to 100,000.
+	MaxTrackedSeries int `yaml:"maxTrackedSeries" validate:"min=0"`
+}
+
+// NewStorage returns a storage that converts the writes of delta series to
+// cumulative series before writing them to the base storage.
+func (c Configuration) NewStorage(
+	base storage.Storage,
+	scope tally.Scope,
+) (storage.Storage, error) {
+	converter, err := c.NewConverter(scope)
+	if err != nil {
+		return nil, err
+	}
+	return NewStorage(base, converter), nil
+}
+
+// NewConverter returns a new converter from the configuration.
+func (c Configuration) NewConverter(scope tally.Scope) (*Converter, error) {
+	opts := ConverterOptions{
+		TagName:          c.TagName,
+		StaleAfter:       c.StaleAfter,
+		MaxTrackedSeries: c.MaxTrackedSeries,
+	}
+	if opts.TagName == "" {
+		opts.TagName = DefaultTagName
+	}
+	if opts.StaleAfter == 0 {
+		opts.StaleAfter = defaultStaleAfter
+	}
+	if opts.MaxTrackedSeries == 0 {
+		opts.MaxTrackedSeries = defaultMaxTrackedSeries
+	}
+	for _, matcherCfg := range c.Matchers {
+		matcher, err := matcherCfg.NewMatcher()
+		if err != nil {
+			return nil, err
+		}
+		opts.Matchers = append(opts.Matchers, matcher)
+	}
+	return NewConverter(opts, scope), nil
+}
+
+// ConverterOptions are the options of a converter.
+type ConverterOptions struct {
+	TagName          string
+	Matchers         models.Matchers
+	StaleAfter       time.Duration
+	MaxTrackedSeries int
+}
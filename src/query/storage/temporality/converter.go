@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package temporality
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/uber-go/tally"
+)
+
+// Converter converts the datapoints of delta series, each of which is the
+// increase since the previous datapoint, into cumulative datapoints by
+// tracking the running total of each series.
+type Converter struct {
+	sync.Mutex
+
+	opts    ConverterOptions
+	series  map[string]seriesState
+	metrics converterMetrics
+}
+
+type seriesState struct {
+	last  time.Time
+	total float64
+}
+
+type converterMetrics struct {
+	converted  tally.Counter
+	restarts   tally.Counter
+	outOfOrder tally.Counter
+	negative   tally.Counter
+	untracked  tally.Counter
+	evicted    tally.Counter
+}
+
+func newConverterMetrics(scope tally.Scope) converterMetrics {
+	return converterMetrics{
+		converted:  scope.Counter("converted"),
+		restarts:   scope.Counter("restarts"),
+		outOfOrder: scope.Counter("out-of-order"),
+		negative:   scope.Counter("negative"),
+		untracked:  scope.Counter("untracked-series"),
+		evicted:    scope.Counter("evicted-series"),
+	}
+}
+
+// NewConverter returns a new delta to cumulative converter.
+func NewConverter(opts ConverterOptions, scope tally.Scope) *Converter {
+	return &Converter{
+		opts:    opts,
+		series:  make(map[string]seriesState),
+		metrics: newConverterMetrics(scope),
+	}
+}
+
+// isDelta returns whether the series is a delta series and its tags
+// without the temporality tag.
+func (c *Converter) isDelta(tags models.Tags) (bool, models.Tags) {
+	if value, ok := tags[c.opts.TagName]; ok {
+		withoutTag := make(models.Tags, len(tags))
+		for name, value := range tags {
+			if name != c.opts.TagName {
+				withoutTag[name] = value
+			}
+		}
+		return value == DeltaTagValue, withoutTag
+	}
+
+	if len(c.opts.Matchers) == 0 {
+		return false, tags
+	}
+	for _, m := range c.opts.Matchers {
+		if !m.Matches(tags[m.Name]) {
+			return false, tags
+		}
+	}
+	return true, tags
+}
+
+// Convert returns the write with the datapoints of delta series converted
+// to cumulative datapoints, nil if all of its datapoints were dropped.
+// Datapoints not newer than the last datapoint of the series are dropped
+// since they would otherwise be counted twice, as are negative deltas.
+func (c *Converter) Convert(query *storage.WriteQuery) *storage.WriteQuery {
+	delta, tags := c.isDelta(query.Tags)
+	if !delta {
+		if len(tags) == len(query.Tags) {
+			return query
+		}
+		// Only the temporality tag is removed from cumulative series.
+		result := *query
+		result.Tags = tags
+		return &result
+	}
+
+	// Writes of a series to each namespace accumulate separately.
+	key := fmt.Sprintf("%s/%v/%v/%s", query.Attributes.MetricsType,
+		query.Attributes.Retention, query.Attributes.Resolution, tags.ID())
+	datapoints := make(ts.Datapoints, 0, len(query.Datapoints))
+
+	c.Lock()
+	defer c.Unlock()
+
+	state, tracked := c.series[key]
+	for _, dp := range query.Datapoints {
+		if math.IsNaN(dp.Value) {
+			continue
+		}
+		if dp.Value < 0 {
+			c.metrics.negative.Inc(1)
+			continue
+		}
+
+		switch {
+		case !tracked:
+			if !c.trackWithLock(dp.Timestamp) {
+				c.metrics.untracked.Inc(1)
+				continue
+			}
+			state, tracked = seriesState{}, true
+		case !dp.Timestamp.After(state.last):
+			c.metrics.outOfOrder.Inc(1)
+			continue
+		case dp.Timestamp.Sub(state.last) > c.opts.StaleAfter:
+			// The source restarted or stopped reporting for long enough
+			// that the increases in between are unknown, restart the series
+			// from zero so that queries see a counter reset.
+			c.metrics.restarts.Inc(1)
+			state.total = 0
+		}
+
+		state.last = dp.Timestamp
+		state.total += dp.Value
+		dp.Value = state.total
+		datapoints = append(datapoints, dp)
+		c.metrics.converted.Inc(1)
+	}
+	if tracked {
+		c.series[key] = state
+	}
+
+	if len(datapoints) == 0 {
+		return nil
+	}
+
+	result := *query
+	result.Tags = tags
+	result.Datapoints = datapoints
+	return &result
+}
+
+// trackWithLock returns whether another series can be tracked, evicting the
+// series which are stale at the time given if the max is reached.
+func (c *Converter) trackWithLock(now time.Time) bool {
+	if len(c.series) < c.opts.MaxTrackedSeries {
+		return true
+	}
+	for key, state := range c.series {
+		if now.Sub(state.last) > c.opts.StaleAfter {
+			delete(c.series, key)
+			c.metrics.evicted.Inc(1)
+		}
+	}
+	return len(c.series) < c.opts.MaxTrackedSeries
+}
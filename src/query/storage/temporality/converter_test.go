@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package temporality
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestConverter(t *testing.T, maxTrackedSeries int) *Converter {
+	matcher, err := models.NewMatcher(models.MatchEqual, "source", "otlp")
+	require.NoError(t, err)
+	return NewConverter(ConverterOptions{
+		TagName:          DefaultTagName,
+		Matchers:         models.Matchers{matcher},
+		StaleAfter:       time.Minute,
+		MaxTrackedSeries: maxTrackedSeries,
+	}, tally.NoopScope)
+}
+
+func newTestWriteQuery(tags models.Tags, start time.Time, values ...float64) *storage.WriteQuery {
+	datapoints := make(ts.Datapoints, 0, len(values))
+	for i, v := range values {
+		datapoints = append(datapoints, ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+			Value:     v,
+		})
+	}
+	return &storage.WriteQuery{Tags: tags, Datapoints: datapoints}
+}
+
+func values(query *storage.WriteQuery) []float64 {
+	result := make([]float64, 0, len(query.Datapoints))
+	for _, dp := range query.Datapoints {
+		result = append(result, dp.Value)
+	}
+	return result
+}
+
+func TestConvertAccumulatesDeltas(t *testing.T) {
+	c := newTestConverter(t, 10)
+	tags := models.Tags{"__name__": "requests", DefaultTagName: DeltaTagValue}
+	now := time.Now().Truncate(time.Minute)
+
+	result := c.Convert(newTestWriteQuery(tags, now, 1, 2, 3))
+	require.NotNil(t, result)
+	assert.Equal(t, models.Tags{"__name__": "requests"}, result.Tags)
+	assert.Equal(t, []float64{1, 3, 6}, values(result))
+
+	result = c.Convert(newTestWriteQuery(tags, now.Add(30*time.Second), 4))
+	require.NotNil(t, result)
+	assert.Equal(t, []float64{10}, values(result))
+}
+
+func TestConvertMatchesSeriesWithoutTag(t *testing.T) {
+	c := newTestConverter(t, 10)
+	now := time.Now().Truncate(time.Minute)
+
+	matched := models.Tags{"__name__": "requests", "source": "otlp"}
+	c.Convert(newTestWriteQuery(matched, now, 1))
+	result := c.Convert(newTestWriteQuery(matched, now.Add(10*time.Second), 1))
+	assert.Equal(t, []float64{2}, values(result))
+
+	unmatched := models.Tags{"__name__": "requests", "source": "prometheus"}
+	query := newTestWriteQuery(unmatched, now, 5)
+	assert.Equal(t, query, c.Convert(query))
+
+	// Cumulative series are passed through without the temporality tag.
+	cumulative := models.Tags{"__name__": "requests", "source": "otlp",
+		DefaultTagName: "cumulative"}
+	result = c.Convert(newTestWriteQuery(cumulative, now, 5))
+	assert.Equal(t, models.Tags{"__name__": "requests", "source": "otlp"}, result.Tags)
+	assert.Equal(t, []float64{5}, values(result))
+}
+
+func TestConvertDropsOutOfOrderAndNegativeDeltas(t *testing.T) {
+	c := newTestConverter(t, 10)
+	tags := models.Tags{"__name__": "requests", DefaultTagName: DeltaTagValue}
+	now := time.Now().Truncate(time.Minute)
+
+	c.Convert(newTestWriteQuery(tags, now, 1, 2))
+	assert.Nil(t, c.Convert(newTestWriteQuery(tags, now, 5)))
+
+	result := c.Convert(newTestWriteQuery(tags, now.Add(20*time.Second), -1, 4))
+	assert.Equal(t, []float64{7}, values(result))
+}
+
+func TestConvertRestartsStaleSeries(t *testing.T) {
+	c := newTestConverter(t, 10)
+	tags := models.Tags{"__name__": "requests", DefaultTagName: DeltaTagValue}
+	now := time.Now().Truncate(time.Minute)
+
+	c.Convert(newTestWriteQuery(tags, now, 5))
+	result := c.Convert(newTestWriteQuery(tags, now.Add(2*time.Minute), 1))
+	assert.Equal(t, []float64{1}, values(result))
+}
+
+func TestConvertEvictsStaleSeries(t *testing.T) {
+	c := newTestConverter(t, 1)
+	now := time.Now().Truncate(time.Minute)
+	first := models.Tags{"__name__": "first", DefaultTagName: DeltaTagValue}
+	second := models.Tags{"__name__": "second", DefaultTagName: DeltaTagValue}
+
+	c.Convert(newTestWriteQuery(first, now, 1))
+	assert.Nil(t, c.Convert(newTestWriteQuery(second, now, 1)))
+
+	result := c.Convert(newTestWriteQuery(second, now.Add(2*time.Minute), 1))
+	assert.Equal(t, []float64{1}, values(result))
+}
+
+func TestStorageWritesConvertedSeries(t *testing.T) {
+	store := mock.NewMockStorage()
+	s := NewStorage(store, newTestConverter(t, 10))
+	tags := models.Tags{"__name__": "requests", DefaultTagName: DeltaTagValue}
+	now := time.Now().Truncate(time.Minute)
+
+	require.NoError(t, s.Write(context.TODO(), newTestWriteQuery(tags, now, 1, 2)))
+	require.NoError(t, s.Write(context.TODO(), newTestWriteQuery(tags, now, 1)))
+	require.Len(t, store.Writes(), 1)
+	assert.Equal(t, []float64{1, 3}, values(store.Writes()[0]))
+}
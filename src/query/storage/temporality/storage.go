@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package temporality provides a storage that converts the writes of delta
+// temporality counters, such as those of OpenTelemetry sources, into
+// cumulative series so that they can be queried with PromQL functions like
+// rate and increase.
+package temporality
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/query/storage"
+)
+
+type temporalityStorage struct {
+	storage.Storage
+	converter *Converter
+}
+
+// NewStorage returns a storage that converts writes with the converter
+// before writing them to the underlying storage.
+func NewStorage(store storage.Storage, converter *Converter) storage.Storage {
+	return &temporalityStorage{Storage: store, converter: converter}
+}
+
+func (s *temporalityStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	if query == nil {
+		return s.Storage.Write(ctx, query)
+	}
+
+	converted := s.converter.Convert(query)
+	if converted == nil {
+		// All datapoints of the write were dropped.
+		return nil
+	}
+	return s.Storage.Write(ctx, converted)
+}
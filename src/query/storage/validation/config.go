@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validation
+
+import (
+	"errors"
+
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxTrackedSeries = 100000
+)
+
+var (
+	errNoKVStore = errors.New("write validation rules KV key set without a KV store")
+)
+
+// Configuration is the configuration of the write validation rules.
+type Configuration struct {
+	// Rules are the static rules to apply, they are replaced by the rules
+	// set in KV if a KV key is specified and has a value set.
+	Rules Rules `yaml:"rules"`
+
+	// KVKey is the KV key to watch for rules to apply at runtime, the value
+	// is expected to be a string proto containing the YAML encoded rules.
+	KVKey string `yaml:"kvKey"`
+
+	// MaxTrackedSeries is the max number of series whose last value is
+	// tracked to detect counter regressions, defaults to 100,000.
+	MaxTrackedSeries int `yaml:"maxTrackedSeries" validate:"min=0"`
+}
+
+// NewEngine returns a new validation engine from the configuration.
+func (c Configuration) NewEngine(
+	store kv.Store,
+	scope tally.Scope,
+	logger *zap.Logger,
+) (Engine, error) {
+	maxTrackedSeries := defaultMaxTrackedSeries
+	if c.MaxTrackedSeries > 0 {
+		maxTrackedSeries = c.MaxTrackedSeries
+	}
+
+	engine, err := NewEngine(c.Rules, maxTrackedSeries, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.KVKey == "" {
+		return engine, nil
+	}
+
+	if store == nil {
+		return nil, errNoKVStore
+	}
+
+	watch, err := store.Watch(c.KVKey)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		protoValue := &commonpb.StringProto{}
+		for range watch.C() {
+			rules := c.Rules
+			if value := watch.Get(); value != nil {
+				if err := value.Unmarshal(protoValue); err != nil {
+					logger.Warn("unable to unmarshal write validation rules",
+						zap.String("key", c.KVKey), zap.Any("error", err))
+					continue
+				}
+
+				parsed, err := ParseRules([]byte(protoValue.Value))
+				if err != nil {
+					logger.Warn("unable to parse write validation rules",
+						zap.String("key", c.KVKey), zap.Any("error", err))
+					continue
+				}
+				rules = parsed
+			}
+
+			if err := engine.SetRules(rules); err != nil {
+				logger.Warn("unable to set write validation rules",
+					zap.String("key", c.KVKey), zap.Any("error", err))
+				continue
+			}
+
+			logger.Info("set write validation rules",
+				zap.String("key", c.KVKey), zap.Int("numRules", len(rules)))
+		}
+	}()
+
+	return engine, nil
+}
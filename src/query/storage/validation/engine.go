@@ -0,0 +1,270 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validation
+
+import (
+	"math"
+	"sync"
+
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	reasonNonFinite   = "non-finite value"
+	reasonOutOfBounds = "value out of bounds"
+	reasonRegression  = "counter regression"
+)
+
+// Engine validates the datapoints of writes against a set of rules.
+type Engine interface {
+	// Validate validates a write, returning the write to store with values
+	// clamped or dropped by the rules, nil if all of its datapoints were
+	// dropped, or a RejectedError if the write was rejected.
+	Validate(query *storage.WriteQuery) (*storage.WriteQuery, error)
+
+	// SetRules validates and sets the rules to apply.
+	SetRules(rules Rules) error
+}
+
+type engine struct {
+	sync.RWMutex
+	rules   []compiledRule
+	scope   tally.Scope
+	metrics engineMetrics
+	last    *lastValues
+}
+
+type engineMetrics struct {
+	evaluated   tally.Counter
+	rejected    tally.Counter
+	clamped     tally.Counter
+	dropped     tally.Counter
+	flagged     tally.Counter
+	regressions tally.Counter
+	untracked   tally.Counter
+	ruleErrors  tally.Counter
+}
+
+func newEngineMetrics(scope tally.Scope) engineMetrics {
+	return engineMetrics{
+		evaluated:   scope.Counter("evaluated"),
+		rejected:    scope.Counter("rejected"),
+		clamped:     scope.Counter("clamped"),
+		dropped:     scope.Counter("dropped"),
+		flagged:     scope.Counter("flagged"),
+		regressions: scope.Counter("regressions"),
+		untracked:   scope.Counter("untracked-series"),
+		ruleErrors:  scope.Counter("rule-errors"),
+	}
+}
+
+// NewEngine returns a new validation engine that tracks the last value of
+// at most maxTrackedSeries series to detect counter regressions.
+func NewEngine(rules Rules, maxTrackedSeries int, scope tally.Scope) (Engine, error) {
+	e := &engine{
+		scope:   scope,
+		metrics: newEngineMetrics(scope),
+		last:    newLastValues(maxTrackedSeries),
+	}
+	if err := e.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *engine) SetRules(rules Rules) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := rule.compile()
+		if err != nil {
+			e.metrics.ruleErrors.Inc(1)
+			return err
+		}
+		compiled = append(compiled, c)
+	}
+
+	e.Lock()
+	e.rules = compiled
+	e.Unlock()
+	return nil
+}
+
+func (e *engine) Validate(query *storage.WriteQuery) (*storage.WriteQuery, error) {
+	e.metrics.evaluated.Inc(1)
+
+	e.RLock()
+	rules := e.rules
+	e.RUnlock()
+
+	result := query
+	for _, rule := range rules {
+		if !rule.matches(result.Tags) {
+			continue
+		}
+
+		validated, err := e.validate(rule, result)
+		if err != nil || validated == nil {
+			return nil, err
+		}
+		result = validated
+	}
+
+	return result, nil
+}
+
+func (e *engine) validate(
+	rule compiledRule,
+	query *storage.WriteQuery,
+) (*storage.WriteQuery, error) {
+	var (
+		ruleScope = e.scope.Tagged(map[string]string{"rule": rule.name})
+		seriesKey string
+		// Datapoints are only copied once modified as the datapoints of the
+		// write are owned by the caller.
+		datapoints ts.Datapoints
+		modified   bool
+	)
+	modify := func(i int) {
+		if modified {
+			return
+		}
+		datapoints = make(ts.Datapoints, 0, len(query.Datapoints))
+		datapoints = append(datapoints, query.Datapoints[:i]...)
+		modified = true
+	}
+
+	for i, dp := range query.Datapoints {
+		reason, action := rule.check(dp.Value)
+		switch action {
+		case RejectAction:
+			e.metrics.rejected.Inc(1)
+			ruleScope.Counter("rule-rejected").Inc(1)
+			return nil, RejectedError{Rule: rule.name, Reason: reason}
+		case ClampAction:
+			modify(i)
+			if math.IsNaN(dp.Value) {
+				e.metrics.dropped.Inc(1)
+				ruleScope.Counter("rule-dropped").Inc(1)
+				continue
+			}
+			e.metrics.clamped.Inc(1)
+			ruleScope.Counter("rule-clamped").Inc(1)
+			dp.Value = rule.clamp(dp.Value)
+		case FlagAction:
+			e.metrics.flagged.Inc(1)
+			ruleScope.Counter("rule-flagged").Inc(1)
+		}
+
+		if rule.monotonic && !math.IsNaN(dp.Value) {
+			if seriesKey == "" {
+				seriesKey = rule.name + "/" + query.Tags.ID()
+			}
+			prev, tracked, ok := e.last.update(seriesKey, dp)
+			if !tracked {
+				e.metrics.untracked.Inc(1)
+			} else if ok && dp.Timestamp.After(prev.Timestamp) && dp.Value < prev.Value {
+				e.metrics.regressions.Inc(1)
+				ruleScope.Counter("rule-regressions").Inc(1)
+				if rule.regression == RejectAction {
+					e.metrics.rejected.Inc(1)
+					ruleScope.Counter("rule-rejected").Inc(1)
+					return nil, RejectedError{Rule: rule.name, Reason: reasonRegression}
+				}
+			}
+		}
+
+		if modified {
+			datapoints = append(datapoints, dp)
+		}
+	}
+
+	if !modified {
+		return query, nil
+	}
+	if len(datapoints) == 0 {
+		return nil, nil
+	}
+
+	result := *query
+	result.Datapoints = datapoints
+	return &result, nil
+}
+
+// check returns the action to take for the value and the reason, an empty
+// action if the value is valid.
+func (r compiledRule) check(value float64) (string, Action) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return reasonNonFinite, r.nonFinite
+	}
+	if (r.min != nil && value < *r.min) || (r.max != nil && value > *r.max) {
+		return reasonOutOfBounds, r.outOfBounds
+	}
+	return "", ""
+}
+
+func (r compiledRule) clamp(value float64) float64 {
+	lower, upper := -math.MaxFloat64, math.MaxFloat64
+	if r.min != nil {
+		lower = *r.min
+	}
+	if r.max != nil {
+		upper = *r.max
+	}
+	return math.Max(lower, math.Min(upper, value))
+}
+
+// lastValues tracks the last datapoint of a bounded number of series.
+type lastValues struct {
+	sync.Mutex
+	max    int
+	values map[string]ts.Datapoint
+}
+
+func newLastValues(max int) *lastValues {
+	return &lastValues{
+		max:    max,
+		values: make(map[string]ts.Datapoint),
+	}
+}
+
+// update records the datapoint as the last datapoint of the series if it
+// is newer than the current one, returning the previous last datapoint if
+// the series was already tracked and whether the series is tracked at all.
+func (v *lastValues) update(key string, dp ts.Datapoint) (ts.Datapoint, bool, bool) {
+	v.Lock()
+	defer v.Unlock()
+
+	prev, ok := v.values[key]
+	if !ok {
+		if len(v.values) >= v.max {
+			return ts.Datapoint{}, false, false
+		}
+		v.values[key] = dp
+		return ts.Datapoint{}, true, false
+	}
+	if dp.Timestamp.After(prev.Timestamp) {
+		v.values[key] = dp
+	}
+	return prev, true, true
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func newTestWriteQuery(name string, start time.Time, values ...float64) *storage.WriteQuery {
+	datapoints := make(ts.Datapoints, 0, len(values))
+	for i, v := range values {
+		datapoints = append(datapoints, ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Value:     v,
+		})
+	}
+	return &storage.WriteQuery{
+		Tags:       models.Tags{models.MetricName: name, "host": "a"},
+		Datapoints: datapoints,
+	}
+}
+
+func values(query *storage.WriteQuery) []float64 {
+	result := make([]float64, 0, len(query.Datapoints))
+	for _, dp := range query.Datapoints {
+		result = append(result, dp.Value)
+	}
+	return result
+}
+
+func TestEngineRejectsNonFinite(t *testing.T) {
+	e, err := NewEngine(Rules{{Name: "finite", NonFinite: RejectAction}},
+		10, tally.NoopScope)
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = e.Validate(newTestWriteQuery("foo", now, 1, math.NaN()))
+	require.Error(t, err)
+	assert.True(t, IsRejectedError(err))
+	assert.Equal(t, RejectedError{Rule: "finite", Reason: reasonNonFinite}, err)
+
+	query := newTestWriteQuery("foo", now, 1, 2)
+	validated, err := e.Validate(query)
+	require.NoError(t, err)
+	assert.True(t, query == validated)
+}
+
+func TestEngineClampsAndDropsNonFinite(t *testing.T) {
+	e, err := NewEngine(Rules{{
+		Name:        "finite",
+		NonFinite:   ClampAction,
+		Max:         float64Ptr(100),
+		OutOfBounds: FlagAction,
+	}}, 10, tally.NoopScope)
+	require.NoError(t, err)
+
+	query := newTestWriteQuery("foo", time.Now(), 1, math.NaN(), math.Inf(1), math.Inf(-1))
+	validated, err := e.Validate(query)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 100, -math.MaxFloat64}, values(validated))
+
+	// The datapoints of the original write are not modified.
+	assert.Equal(t, 4, len(query.Datapoints))
+	assert.True(t, math.IsInf(query.Datapoints[2].Value, 1))
+
+	validated, err = e.Validate(newTestWriteQuery("foo", time.Now(), math.NaN()))
+	require.NoError(t, err)
+	assert.Nil(t, validated)
+}
+
+func TestEngineBounds(t *testing.T) {
+	e, err := NewEngine(Rules{
+		{
+			Name:        "percent",
+			MetricNames: []string{"cpu"},
+			Min:         float64Ptr(0),
+			Max:         float64Ptr(100),
+			OutOfBounds: ClampAction,
+		},
+		{
+			Name:             "latency",
+			MetricNameRegexp: "latency_.*",
+			Min:              float64Ptr(0),
+		},
+	}, 10, tally.NoopScope)
+	require.NoError(t, err)
+
+	now := time.Now()
+	validated, err := e.Validate(newTestWriteQuery("cpu", now, -1, 50, 101))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 50, 100}, values(validated))
+
+	_, err = e.Validate(newTestWriteQuery("latency_p99", now, 1, -1))
+	assert.Equal(t, RejectedError{Rule: "latency", Reason: reasonOutOfBounds}, err)
+
+	// Metrics not matching any rule are not validated.
+	validated, err = e.Validate(newTestWriteQuery("other", now, -1))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{-1}, values(validated))
+}
+
+func TestEngineMonotonic(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	e, err := NewEngine(Rules{
+		{Name: "counters", MetricNameRegexp: ".*_total", Monotonic: true},
+		{
+			Name:        "strict",
+			MetricNames: []string{"strict_count"},
+			Monotonic:   true,
+			Regression:  RejectAction,
+		},
+	}, 10, scope)
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = e.Validate(newTestWriteQuery("requests_total", now, 1, 2, 0))
+	require.NoError(t, err)
+
+	// Out of order writes are not regressions.
+	_, err = e.Validate(newTestWriteQuery("requests_total", now, 0))
+	require.NoError(t, err)
+
+	_, err = e.Validate(newTestWriteQuery("strict_count", now, 5))
+	require.NoError(t, err)
+	_, err = e.Validate(newTestWriteQuery("strict_count", now.Add(time.Minute), 4))
+	assert.Equal(t, RejectedError{Rule: "strict", Reason: reasonRegression}, err)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2), counters["regressions+"].Value())
+	assert.Equal(t, int64(1), counters["rejected+"].Value())
+	assert.Equal(t, int64(1), counters["rule-regressions+rule=counters"].Value())
+	assert.Equal(t, int64(1), counters["rule-rejected+rule=strict"].Value())
+}
+
+func TestEngineMonotonicMaxTrackedSeries(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	e, err := NewEngine(Rules{{Name: "counters", Monotonic: true}}, 1, scope)
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = e.Validate(newTestWriteQuery("a_total", now, 1))
+	require.NoError(t, err)
+	_, err = e.Validate(newTestWriteQuery("b_total", now, 1))
+	require.NoError(t, err)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["untracked-series+"].Value())
+}
+
+func TestEngineSetRulesInvalid(t *testing.T) {
+	e, err := NewEngine(nil, 10, tally.NoopScope)
+	require.NoError(t, err)
+
+	for _, rules := range []Rules{
+		{{NonFinite: RejectAction}},
+		{{Name: "empty"}},
+		{{Name: "bounds", Min: float64Ptr(1), Max: float64Ptr(0)}},
+		{{Name: "action", NonFinite: "drop"}},
+		{{Name: "regression", Monotonic: true, Regression: ClampAction}},
+		{{Name: "regexp", MetricNameRegexp: "(", Monotonic: true}},
+	} {
+		assert.Error(t, e.SetRules(rules))
+	}
+
+	require.NoError(t, e.SetRules(Rules{{Name: "ok", NonFinite: FlagAction}}))
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`
+- name: cpu
+  metricNames: [cpu]
+  min: 0
+  max: 100
+  outOfBounds: clamp
+- name: counters
+  metricNameRegexp: .*_total
+  monotonic: true
+`))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rules))
+	assert.Equal(t, float64(100), *rules[0].Max)
+	assert.Equal(t, ClampAction, rules[0].OutOfBounds)
+	assert.True(t, rules[1].Monotonic)
+}
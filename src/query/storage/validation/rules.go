@@ -0,0 +1,216 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/m3db/m3/src/query/models"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	errRuleNoName   = errors.New("write validation rule has no name")
+	errRuleNoChecks = errors.New("write validation rule has no checks")
+	errRuleBounds   = errors.New("write validation rule min must not be greater than max")
+)
+
+// Action is an action to take when a datapoint fails a check of a rule.
+type Action string
+
+const (
+	// RejectAction rejects the whole write.
+	RejectAction Action = "reject"
+	// ClampAction clamps the value into the bounds of the rule, NaN values
+	// can not be clamped and are dropped instead.
+	ClampAction Action = "clamp"
+	// FlagAction only counts the datapoint in the rule metrics.
+	FlagAction Action = "flag"
+)
+
+// RejectedError is returned when a write is rejected by a rule.
+type RejectedError struct {
+	Rule   string
+	Reason string
+}
+
+func (e RejectedError) Error() string {
+	return fmt.Sprintf("write rejected by validation rule %s: %s", e.Rule, e.Reason)
+}
+
+// IsRejectedError returns true if the error is a write rejected error.
+func IsRejectedError(err error) bool {
+	_, ok := err.(RejectedError)
+	return ok
+}
+
+// Rule is a write validation rule, the checks of the rule are applied to
+// the datapoints of writes of series whose metric name matches the rule.
+type Rule struct {
+	// Name is the name of the rule, used for the rule metrics.
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// MetricNames matches writes of any of the specified metric names.
+	MetricNames []string `yaml:"metricNames"`
+
+	// MetricNameRegexp matches writes of metric names matching the regexp,
+	// a rule without metric names or regexp matches all writes.
+	MetricNameRegexp string `yaml:"metricNameRegexp"`
+
+	// NonFinite is the action taken for NaN and infinite values, infinite
+	// values are clamped to the bounds of the rule or the largest finite
+	// values if there are no bounds. Non finite values are allowed if unset.
+	NonFinite Action `yaml:"nonFinite"`
+
+	// Min is the smallest value allowed.
+	Min *float64 `yaml:"min"`
+
+	// Max is the largest value allowed.
+	Max *float64 `yaml:"max"`
+
+	// OutOfBounds is the action taken for values outside of the bounds,
+	// defaults to reject.
+	OutOfBounds Action `yaml:"outOfBounds"`
+
+	// Monotonic checks that the values of each series never decrease, as
+	// expected of counters.
+	Monotonic bool `yaml:"monotonic"`
+
+	// Regression is the action taken for decreases of monotonic series,
+	// defaults to flag since counters legitimately reset on restarts.
+	Regression Action `yaml:"regression"`
+}
+
+// Rules is a set of write validation rules.
+type Rules []Rule
+
+// ParseRules parses a YAML (or JSON) encoded set of rules.
+func ParseRules(data []byte) (Rules, error) {
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+type compiledRule struct {
+	name             string
+	metricNames      map[string]struct{}
+	metricNameRegexp *regexp.Regexp
+	nonFinite        Action
+	min              *float64
+	max              *float64
+	outOfBounds      Action
+	monotonic        bool
+	regression       Action
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	if r.Name == "" {
+		return compiledRule{}, errRuleNoName
+	}
+
+	c := compiledRule{
+		name:        r.Name,
+		nonFinite:   r.NonFinite,
+		min:         r.Min,
+		max:         r.Max,
+		outOfBounds: r.OutOfBounds,
+		monotonic:   r.Monotonic,
+		regression:  r.Regression,
+	}
+	if c.outOfBounds == "" {
+		c.outOfBounds = RejectAction
+	}
+	if c.regression == "" {
+		c.regression = FlagAction
+	}
+
+	if c.nonFinite == "" && c.min == nil && c.max == nil && !c.monotonic {
+		return compiledRule{}, errRuleNoChecks
+	}
+	if c.min != nil && c.max != nil && *c.min > *c.max {
+		return compiledRule{}, errRuleBounds
+	}
+
+	for _, check := range []struct {
+		name    string
+		action  Action
+		allowed []Action
+	}{
+		{"nonFinite", c.nonFinite, []Action{"", RejectAction, ClampAction, FlagAction}},
+		{"outOfBounds", c.outOfBounds, []Action{RejectAction, ClampAction, FlagAction}},
+		{"regression", c.regression, []Action{RejectAction, FlagAction}},
+	} {
+		if !hasAction(check.allowed, check.action) {
+			return compiledRule{}, fmt.Errorf(
+				"write validation rule %s has invalid %s action: %s",
+				r.Name, check.name, check.action)
+		}
+	}
+
+	if len(r.MetricNames) > 0 {
+		c.metricNames = make(map[string]struct{}, len(r.MetricNames))
+		for _, name := range r.MetricNames {
+			c.metricNames[name] = struct{}{}
+		}
+	}
+
+	if r.MetricNameRegexp != "" {
+		re, err := regexp.Compile("^(?:" + r.MetricNameRegexp + ")$")
+		if err != nil {
+			return compiledRule{}, err
+		}
+		c.metricNameRegexp = re
+	}
+
+	return c, nil
+}
+
+func (r compiledRule) matches(tags models.Tags) bool {
+	if r.metricNames == nil && r.metricNameRegexp == nil {
+		return true
+	}
+
+	name, ok := tags[models.MetricName]
+	if !ok {
+		return false
+	}
+	if _, ok := r.metricNames[name]; !ok && r.metricNames != nil {
+		return false
+	}
+	if r.metricNameRegexp != nil && !r.metricNameRegexp.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+func hasAction(actions []Action, action Action) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -18,55 +18,42 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-package linear
+package validation
 
 import (
+	"context"
 	"math"
+	"testing"
+	"time"
 
-	"github.com/m3db/m3/src/query/executor/transform"
-)
-
-// AbsentType returns a timeseries with all NaNs if the timeseries passed in has any non NaNs,
-// and returns a timeseries with the value 1 if the timeseries passed in has no elements
-const AbsentType = "absent"
-
-// NewAbsentOp creates a new base linear transform with an absent node
-func NewAbsentOp() BaseOp {
-	return BaseOp{
-		operatorType: AbsentType,
-		processorFn:  newAbsentNode,
-	}
-}
-
-func newAbsentNode(op BaseOp, controller *transform.Controller) Processor {
-	return &absentNode{
-		op:         op,
-		controller: controller,
-	}
-}
+	"github.com/m3db/m3/src/query/storage/mock"
 
-type absentNode struct {
-	op         BaseOp
-	controller *transform.Controller
-}
-
-func (c *absentNode) Process(values []float64) []float64 {
-	num := 1.0
-	if !allNaNs(values) {
-		num = math.NaN()
-	}
-
-	for i := range values {
-		values[i] = num
-	}
-	return values
-}
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
 
-func allNaNs(vals []float64) bool {
-	for _, i := range vals {
-		if !math.IsNaN(i) {
-			return false
-		}
-	}
-	return true
+func TestValidationStorageWrite(t *testing.T) {
+	e, err := NewEngine(Rules{{
+		Name:        "cpu",
+		MetricNames: []string{"cpu"},
+		NonFinite:   ClampAction,
+		Max:         float64Ptr(100),
+	}}, 10, tally.NoopScope)
+	require.NoError(t, err)
+
+	store := mock.NewMockStorage()
+	s := NewStorage(store, e)
+
+	now := time.Now()
+	err = s.Write(context.TODO(), newTestWriteQuery("cpu", now, 101))
+	assert.True(t, IsRejectedError(err))
+	assert.Equal(t, 0, len(store.Writes()))
+
+	require.NoError(t, s.Write(context.TODO(), newTestWriteQuery("cpu", now, math.NaN())))
+	assert.Equal(t, 0, len(store.Writes()))
+
+	require.NoError(t, s.Write(context.TODO(), newTestWriteQuery("cpu", now, 1, math.Inf(1))))
+	require.Equal(t, 1, len(store.Writes()))
+	assert.Equal(t, []float64{1, 100}, values(store.Writes()[0]))
 }
@@ -114,6 +114,28 @@ func (s *AsyncSession) WriteTagged(namespace, id ident.ID, tags ident.TagIterato
 	return s.session.WriteTagged(namespace, id, tags, t, value, unit, annotation)
 }
 
+// WriteWithSequence writes a value to the database for an ID with a write sequence
+func (s *AsyncSession) WriteWithSequence(namespace, id ident.ID, t time.Time, value float64, unit xtime.Unit, annotation []byte, sequence int64) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.session.WriteWithSequence(namespace, id, t, value, unit, annotation, sequence)
+}
+
+// WriteTaggedWithSequence writes a value to the database for an ID and given tags with a write sequence
+func (s *AsyncSession) WriteTaggedWithSequence(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte, sequence int64) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.session.WriteTaggedWithSequence(namespace, id, tags, t, value, unit, annotation, sequence)
+}
+
 // Fetch fetches values from the database for an ID
 func (s *AsyncSession) Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error) {
 	s.RLock()
@@ -158,6 +180,22 @@ func (s *AsyncSession) FetchTaggedIDs(namespace ident.ID, q index.Query, opts in
 	return s.session.FetchTaggedIDs(namespace, q, opts)
 }
 
+// SubscribeNewSeries subscribes to series created in the namespace
+// whose tags match the query.
+func (s *AsyncSession) SubscribeNewSeries(
+	namespace ident.ID,
+	q index.Query,
+	opts client.NewSeriesSubscriptionOptions,
+) (client.NewSeriesSubscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s.session.SubscribeNewSeries(namespace, q, opts)
+}
+
 // ShardID returns the given shard for an ID for callers
 // to easily discern what shard is failing when operations
 // for given IDs begin failing
@@ -171,6 +209,18 @@ func (s *AsyncSession) ShardID(id ident.ID) (uint32, error) {
 	return s.session.ShardID(id)
 }
 
+// RouteSeries returns the shard of an ID in a namespace and the hosts
+// owning the shard
+func (s *AsyncSession) RouteSeries(namespace, id ident.ID) (client.SeriesRoute, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return client.SeriesRoute{}, s.err
+	}
+
+	return s.session.RouteSeries(namespace, id)
+}
+
 // IteratorPools exposes the internal iterator pools used by the session to clients
 func (s *AsyncSession) IteratorPools() (encoding.IteratorPools, error) {
 	s.RLock()
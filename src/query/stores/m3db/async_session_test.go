@@ -110,6 +110,9 @@ func TestAsyncSessionUninitialized(t *testing.T) {
 	assert.Equal(t, uint32(0), id)
 	assert.Equal(t, err, errSessionUninitialized)
 
+	_, err = asyncSession.RouteSeries(namespace, nil)
+	assert.Equal(t, err, errSessionUninitialized)
+
 	err = asyncSession.Close()
 	assert.Equal(t, err, errSessionUninitialized)
 
@@ -158,6 +161,10 @@ func TestAsyncSessionInitialized(t *testing.T) {
 	_, err = asyncSession.ShardID(nil)
 	assert.NoError(t, err)
 
+	mockSession.EXPECT().RouteSeries(gomock.Any(), gomock.Any()).Return(client.SeriesRoute{}, nil)
+	_, err = asyncSession.RouteSeries(namespace, nil)
+	assert.NoError(t, err)
+
 	mockSession.EXPECT().Close().Return(nil)
 	err = asyncSession.Close()
 	assert.NoError(t, err)
@@ -0,0 +1,426 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package conformance runs PromQL test scripts, in the format used by the
+// upstream Prometheus promql/testdata files, against the coordinator query
+// engine and reports the outcome of every eval case. It makes gaps in PromQL
+// compatibility visible and trackable as the engine evolves.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser/promql"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	xtime "github.com/m3db/m3x/time"
+)
+
+const (
+	// evalStep is the step used when evaluating instant queries.
+	evalStep = 10 * time.Second
+	// evalTimeout bounds the evaluation of a single case.
+	evalTimeout = 10 * time.Second
+	// epsilon is the relative tolerance used when comparing values.
+	epsilon = 1e-6
+)
+
+// testStartTime is the time loaded samples and evaluations are offset from,
+// matching the upstream test harness.
+var testStartTime = time.Unix(0, 0).UTC()
+
+// CaseResult is the outcome of a single eval case of a script.
+type CaseResult struct {
+	Script string
+	Line   int
+	Expr   string
+	// Err describes why the case failed, it is nil if the case passed.
+	Err error
+}
+
+// Passed returns whether the case passed.
+func (r CaseResult) Passed() bool {
+	return r.Err == nil
+}
+
+func (r CaseResult) String() string {
+	if r.Passed() {
+		return fmt.Sprintf("PASS %s:%d %s", r.Script, r.Line, r.Expr)
+	}
+
+	return fmt.Sprintf("FAIL %s:%d %s: %v", r.Script, r.Line, r.Expr, r.Err)
+}
+
+// Report holds the results of every evaluated case.
+type Report struct {
+	Cases []CaseResult
+}
+
+// Passed returns the number of cases which passed.
+func (r Report) Passed() int {
+	passed := 0
+	for _, c := range r.Cases {
+		if c.Passed() {
+			passed++
+		}
+	}
+
+	return passed
+}
+
+// Failed returns the cases which failed.
+func (r Report) Failed() []CaseResult {
+	var failed []CaseResult
+	for _, c := range r.Cases {
+		if !c.Passed() {
+			failed = append(failed, c)
+		}
+	}
+
+	return failed
+}
+
+func (r Report) String() string {
+	var buf bytes.Buffer
+	for _, c := range r.Cases {
+		buf.WriteString(c.String())
+		buf.WriteString("\n")
+	}
+
+	fmt.Fprintf(&buf, "%d/%d cases passed\n", r.Passed(), len(r.Cases))
+	return buf.String()
+}
+
+// RunDir parses and runs every `.test` script in the directory in
+// lexicographic order.
+func RunDir(dir string) (Report, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.test"))
+	if err != nil {
+		return Report{}, err
+	}
+
+	sort.Strings(paths)
+	var report Report
+	for _, path := range paths {
+		scriptReport, err := RunFile(path)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Cases = append(report.Cases, scriptReport.Cases...)
+	}
+
+	return report, nil
+}
+
+// RunFile parses and runs a single script.
+func RunFile(path string) (Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Report{}, err
+	}
+	defer f.Close()
+
+	script, err := ParseScript(filepath.Base(path), f)
+	if err != nil {
+		return Report{}, err
+	}
+
+	return RunScript(script)
+}
+
+// RunScript runs the commands of the script against a fresh in-memory
+// storage. Failing eval cases are recorded in the report, an error is only
+// returned if the script itself could not be run.
+func RunScript(script *Script) (Report, error) {
+	store := newMemStorage()
+	engine := executor.NewEngine(store)
+	defer engine.Close()
+
+	var report Report
+	for _, cmd := range script.Commands {
+		switch c := cmd.(type) {
+		case *ClearCommand:
+			store.clear()
+		case *LoadCommand:
+			if err := load(store, c); err != nil {
+				return Report{}, fmt.Errorf("%s:%d: %v", script.Name, c.Line(), err)
+			}
+		case *EvalCommand:
+			report.Cases = append(report.Cases, CaseResult{
+				Script: script.Name,
+				Line:   c.Line(),
+				Expr:   c.Expr,
+				Err:    eval(engine, c),
+			})
+		default:
+			return Report{}, fmt.Errorf("%s:%d: unknown command %T", script.Name, cmd.Line(), cmd)
+		}
+	}
+
+	return report, nil
+}
+
+func load(store storage.Storage, cmd *LoadCommand) error {
+	for _, series := range cmd.Series {
+		datapoints := make(ts.Datapoints, 0, len(series.Values))
+		for i, v := range series.Values {
+			if math.IsNaN(v) {
+				continue
+			}
+
+			datapoints = append(datapoints, ts.Datapoint{
+				Timestamp: testStartTime.Add(time.Duration(i) * cmd.Interval),
+				Value:     v,
+			})
+		}
+
+		if err := store.Write(context.Background(), &storage.WriteQuery{
+			Tags:       series.Tags,
+			Datapoints: datapoints,
+			Unit:       xtime.Millisecond,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sample is a single value of an evaluation result.
+type sample struct {
+	tags  models.Tags
+	value float64
+}
+
+func eval(engine *executor.Engine, cmd *EvalCommand) error {
+	at := testStartTime.Add(cmd.At)
+	samples, err := execute(engine, models.RequestParams{
+		Start:   at,
+		End:     at,
+		Now:     at,
+		Timeout: evalTimeout,
+		Step:    evalStep,
+		Target:  cmd.Expr,
+	})
+	if cmd.Fail {
+		if err == nil {
+			return fmt.Errorf("expected error evaluating query but got none")
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("error evaluating query: %v", err)
+	}
+
+	if cmd.IsScalar {
+		return compareScalar(cmd.ScalarWant, samples)
+	}
+
+	return compareVector(cmd.Expected, cmd.Ordered, samples)
+}
+
+// execute evaluates the query and returns the value of every series at the
+// last step of the result, absent values are dropped.
+func execute(engine *executor.Engine, params models.RequestParams) ([]sample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), params.Timeout)
+	defer cancel()
+
+	parser, err := promql.Parse(params.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results is closed by ExecuteExpr
+	results := make(chan executor.Query)
+	opts := &executor.EngineOptions{Stats: models.NewQueryStats()}
+	go engine.ExecuteExpr(ctx, parser, opts, params, results)
+
+	var (
+		samples    []sample
+		processErr error
+	)
+	for result := range results {
+		if result.Err != nil {
+			if processErr == nil {
+				processErr = result.Err
+			}
+			continue
+		}
+
+		for blkResult := range result.Result.ResultChan() {
+			// Keep draining once an error is seen so the engine is not blocked.
+			if processErr != nil {
+				if blkResult.Block != nil {
+					blkResult.Block.Close()
+				}
+				continue
+			}
+
+			if blkResult.Err != nil {
+				processErr = blkResult.Err
+				continue
+			}
+
+			blockSamples, err := lastSamples(blkResult.Block)
+			blkResult.Block.Close()
+			if err != nil {
+				processErr = err
+				continue
+			}
+
+			samples = append(samples, blockSamples...)
+		}
+	}
+
+	if processErr != nil {
+		return nil, processErr
+	}
+
+	return samples, nil
+}
+
+// lastSamples returns the non-NaN values at the last step of each series in
+// the block, tagged with the block's common tags and the series' own tags.
+func lastSamples(b block.Block) ([]sample, error) {
+	iter, err := b.SeriesIter()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	commonTags := iter.Meta().Tags
+	var samples []sample
+	for iter.Next() {
+		series, err := iter.Current()
+		if err != nil {
+			return nil, err
+		}
+
+		if series.Len() == 0 {
+			continue
+		}
+
+		v := series.ValueAtStep(series.Len() - 1)
+		if math.IsNaN(v) {
+			continue
+		}
+
+		tags := make(models.Tags, len(commonTags)+len(series.Meta.Tags))
+		for k, v := range commonTags {
+			tags[k] = v
+		}
+		for k, v := range series.Meta.Tags {
+			tags[k] = v
+		}
+
+		samples = append(samples, sample{tags: tags, value: v})
+	}
+
+	return samples, nil
+}
+
+func compareScalar(expected float64, samples []sample) error {
+	if len(samples) != 1 {
+		return fmt.Errorf("expected scalar %v but got %d series", expected, len(samples))
+	}
+
+	if !almostEqual(expected, samples[0].value) {
+		return fmt.Errorf("expected scalar %v but got %v", expected, samples[0].value)
+	}
+
+	return nil
+}
+
+func compareVector(expected []ExpectedSample, ordered bool, samples []sample) error {
+	if len(expected) != len(samples) {
+		return fmt.Errorf("expected %d samples but got %d: %s",
+			len(expected), len(samples), formatSamples(samples))
+	}
+
+	positions := make(map[string]int, len(samples))
+	for i, s := range samples {
+		positions[s.tags.ID()] = i
+	}
+
+	for i, e := range expected {
+		pos, ok := positions[e.Tags.ID()]
+		if !ok {
+			return fmt.Errorf("expected series %s not found in result: %s",
+				e.Tags.ID(), formatSamples(samples))
+		}
+
+		if ordered && pos != i {
+			return fmt.Errorf("expected series %s at position %d but got position %d",
+				e.Tags.ID(), i, pos)
+		}
+
+		if actual := samples[pos].value; !almostEqual(e.Value, actual) {
+			return fmt.Errorf("expected %v for series %s but got %v",
+				e.Value, e.Tags.ID(), actual)
+		}
+	}
+
+	return nil
+}
+
+func formatSamples(samples []sample) string {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, s := range samples {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%s => %v", s.tags.ID(), s.value)
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// almostEqual compares values with a relative tolerance, treating NaNs as
+// equal to each other.
+func almostEqual(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+
+	if a == b {
+		return true
+	}
+
+	diff := math.Abs(a - b)
+	if a == 0 || b == 0 || diff < math.SmallestNonzeroFloat64 {
+		return diff < epsilon*math.SmallestNonzeroFloat64
+	}
+
+	return diff/(math.Abs(a)+math.Abs(b)) < epsilon
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conformance
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance runs the scripts under testdata and reports the outcome
+// of every case, run with -v to see the per-case results. Failing cases are
+// PromQL compatibility gaps of the engine rather than test failures.
+func TestConformance(t *testing.T) {
+	report, err := RunDir("testdata")
+	require.NoError(t, err)
+	require.NotEmpty(t, report.Cases)
+
+	for _, c := range report.Cases {
+		t.Log(c.String())
+	}
+
+	t.Logf("%d/%d cases passed", report.Passed(), len(report.Cases))
+}
+
+func TestRunScriptReportsCases(t *testing.T) {
+	script, err := ParseScript("test", strings.NewReader(`
+eval_fail instant at 5m sum(
+eval instant at 5m sum(
+	{} 1
+`))
+	require.NoError(t, err)
+
+	report, err := RunScript(script)
+	require.NoError(t, err)
+	require.Len(t, report.Cases, 2)
+
+	assert.True(t, report.Cases[0].Passed())
+	assert.Equal(t, 2, report.Cases[0].Line)
+	assert.Equal(t, "sum(", report.Cases[0].Expr)
+
+	assert.False(t, report.Cases[1].Passed())
+	assert.Equal(t, 3, report.Cases[1].Line)
+	assert.Contains(t, report.Cases[1].Err.Error(), "error evaluating query")
+
+	assert.Equal(t, 1, report.Passed())
+	require.Len(t, report.Failed(), 1)
+	assert.Equal(t, report.Cases[1], report.Failed()[0])
+	assert.Contains(t, report.String(), "1/2 cases passed")
+}
+
+func TestMemStorageFetch(t *testing.T) {
+	store := newMemStorage()
+	ctx := context.Background()
+	start := time.Unix(0, 0)
+	for _, tags := range []models.Tags{
+		{models.MetricName: "foo", "a": "1"},
+		{models.MetricName: "foo", "a": "2"},
+		{models.MetricName: "bar"},
+	} {
+		require.NoError(t, store.Write(ctx, &storage.WriteQuery{
+			Tags: tags,
+			Datapoints: ts.Datapoints{
+				{Timestamp: start.Add(time.Minute), Value: 1},
+				{Timestamp: start, Value: 0},
+				{Timestamp: start.Add(2 * time.Minute), Value: 2},
+			},
+		}))
+	}
+
+	nameMatcher, err := models.NewMatcher(models.MatchEqual, models.MetricName, "foo")
+	require.NoError(t, err)
+	tagMatcher, err := models.NewMatcher(models.MatchNotEqual, "a", "1")
+	require.NoError(t, err)
+
+	result, err := store.Fetch(ctx, &storage.FetchQuery{
+		TagMatchers: models.Matchers{nameMatcher, tagMatcher},
+		Start:       start.Add(time.Minute),
+		End:         start.Add(2 * time.Minute),
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, result.SeriesList, 1)
+
+	series := result.SeriesList[0]
+	assert.Equal(t, "2", series.Tags["a"])
+	require.Equal(t, 2, series.Len())
+	assert.Equal(t, 1.0, series.Values().ValueAt(0))
+	assert.Equal(t, 2.0, series.Values().ValueAt(1))
+
+	// A tag absent from a series matches the empty string.
+	absentMatcher, err := models.NewMatcher(models.MatchEqual, "a", "")
+	require.NoError(t, err)
+	tags, err := store.FetchTags(ctx, &storage.FetchQuery{
+		TagMatchers: models.Matchers{absentMatcher},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, tags.Metrics, 1)
+	assert.Equal(t, "bar", tags.Metrics[0].Tags[models.MetricName])
+
+	store.clear()
+	tags, err = store.FetchTags(ctx, &storage.FetchQuery{
+		TagMatchers: models.Matchers{absentMatcher},
+	}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, tags.Metrics)
+}
@@ -0,0 +1,411 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conformance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+var (
+	patSpace       = regexp.MustCompile(`[\t ]+`)
+	patLoad        = regexp.MustCompile(`^load\s+(.+?)$`)
+	patEvalInstant = regexp.MustCompile(`^eval(?:_(fail|ordered))?\s+instant\s+(?:at\s+(.+?))?\s+(.+)$`)
+	patDuration    = regexp.MustCompile(`^([0-9]+)(y|w|d|h|m|s|ms)$`)
+	patExpand      = regexp.MustCompile(`^([-+]?[^-+x]+)(?:([-+])([^-+x]+))?x([0-9]+)$`)
+	patLabelName   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*`)
+)
+
+// Script is a parsed PromQL test script, written in the same format as the
+// upstream Prometheus promql/testdata files.
+type Script struct {
+	Name     string
+	Commands []Command
+}
+
+// Command is a single command of a test script.
+type Command interface {
+	// Line returns the line of the script the command starts on.
+	Line() int
+}
+
+// LoadCommand loads a set of series with samples spaced by an interval.
+type LoadCommand struct {
+	line     int
+	Interval time.Duration
+	Series   []LoadSeries
+}
+
+// Line returns the line of the script the command starts on.
+func (c *LoadCommand) Line() int { return c.line }
+
+// LoadSeries is a single series of a load command, values which are absent
+// from the series are represented by NaNs.
+type LoadSeries struct {
+	Tags   models.Tags
+	Values []float64
+}
+
+// ClearCommand removes all loaded series.
+type ClearCommand struct {
+	line int
+}
+
+// Line returns the line of the script the command starts on.
+func (c *ClearCommand) Line() int { return c.line }
+
+// EvalCommand evaluates an instant query and checks its result.
+type EvalCommand struct {
+	line       int
+	Expr       string
+	At         time.Duration
+	Fail       bool
+	Ordered    bool
+	Expected   []ExpectedSample
+	IsScalar   bool
+	ScalarWant float64
+}
+
+// Line returns the line of the script the command starts on.
+func (c *EvalCommand) Line() int { return c.line }
+
+// ExpectedSample is a sample expected in the result of an eval command.
+type ExpectedSample struct {
+	Tags  models.Tags
+	Value float64
+}
+
+// ParseScript parses a test script from the reader.
+func ParseScript(name string, r io.Reader) (*Script, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	script := &Script{Name: name}
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if len(l) == 0 || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		var (
+			cmd Command
+			err error
+		)
+		switch c := strings.ToLower(patSpace.Split(l, 2)[0]); {
+		case c == "clear":
+			cmd = &ClearCommand{line: i + 1}
+		case c == "load":
+			i, cmd, err = parseLoad(lines, i)
+		case strings.HasPrefix(c, "eval"):
+			i, cmd, err = parseEval(lines, i)
+		default:
+			err = fmt.Errorf("invalid command %q", l)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, i+1, err)
+		}
+
+		script.Commands = append(script.Commands, cmd)
+	}
+
+	return script, nil
+}
+
+func parseLoad(lines []string, i int) (int, Command, error) {
+	parts := patLoad.FindStringSubmatch(lines[i])
+	if parts == nil {
+		return i, nil, fmt.Errorf("invalid load command, expected 'load <step>'")
+	}
+
+	interval, err := parseDuration(parts[1])
+	if err != nil {
+		return i, nil, err
+	}
+
+	cmd := &LoadCommand{line: i + 1, Interval: interval}
+	for i+1 < len(lines) {
+		i++
+		defLine := lines[i]
+		if len(defLine) == 0 {
+			i--
+			break
+		}
+
+		tags, rest, err := parseSeries(defLine)
+		if err != nil {
+			return i, nil, err
+		}
+
+		values, err := parseValues(rest)
+		if err != nil {
+			return i, nil, err
+		}
+
+		cmd.Series = append(cmd.Series, LoadSeries{Tags: tags, Values: values})
+	}
+
+	return i, cmd, nil
+}
+
+func parseEval(lines []string, i int) (int, Command, error) {
+	parts := patEvalInstant.FindStringSubmatch(lines[i])
+	if parts == nil {
+		return i, nil, fmt.Errorf("invalid eval command, expected " +
+			"'eval[_fail|_ordered] instant [at <offset>] <query>'")
+	}
+
+	var at time.Duration
+	if parts[2] != "" {
+		var err error
+		at, err = parseDuration(parts[2])
+		if err != nil {
+			return i, nil, err
+		}
+	}
+
+	cmd := &EvalCommand{
+		line:    i + 1,
+		Expr:    parts[3],
+		At:      at,
+		Fail:    parts[1] == "fail",
+		Ordered: parts[1] == "ordered",
+	}
+
+	for i+1 < len(lines) {
+		i++
+		defLine := lines[i]
+		if len(defLine) == 0 {
+			i--
+			break
+		}
+
+		if cmd.Fail {
+			return i, nil, fmt.Errorf("eval_fail command cannot have expected results")
+		}
+
+		// A line consisting of a single number is an expected scalar result.
+		if v, err := parseNumber(defLine); err == nil {
+			cmd.IsScalar = true
+			cmd.ScalarWant = v
+			continue
+		}
+
+		tags, rest, err := parseSeries(defLine)
+		if err != nil {
+			return i, nil, err
+		}
+
+		v, err := parseNumber(rest)
+		if err != nil {
+			return i, nil, fmt.Errorf("invalid expected value %q: %v", rest, err)
+		}
+
+		cmd.Expected = append(cmd.Expected, ExpectedSample{Tags: tags, Value: v})
+	}
+
+	if cmd.IsScalar && len(cmd.Expected) > 0 {
+		return i, nil, fmt.Errorf("eval command cannot expect both scalar and vector results")
+	}
+
+	return i, cmd, nil
+}
+
+// parseSeries parses a series description of the form
+// `name{label="value", ...}`, returning the tags and the remainder of the line.
+func parseSeries(s string) (models.Tags, string, error) {
+	tags := make(models.Tags)
+	name := patLabelName.FindString(s)
+	if name != "" {
+		tags[models.MetricName] = name
+	}
+
+	s = s[len(name):]
+	if !strings.HasPrefix(s, "{") {
+		if name == "" {
+			return nil, "", fmt.Errorf("invalid series description %q", s)
+		}
+
+		return tags, strings.TrimSpace(s), nil
+	}
+
+	s = s[1:]
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return tags, strings.TrimSpace(s[1:]), nil
+		}
+
+		label := patLabelName.FindString(s)
+		if label == "" {
+			return nil, "", fmt.Errorf("invalid label name at %q", s)
+		}
+
+		s = strings.TrimLeft(s[len(label):], " \t")
+		if !strings.HasPrefix(s, "=") {
+			return nil, "", fmt.Errorf("expected '=' after label %q", label)
+		}
+
+		s = strings.TrimLeft(s[1:], " \t")
+		value, rest, err := parseQuoted(s)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid value for label %q: %v", label, err)
+		}
+
+		tags[label] = value
+		s = rest
+	}
+}
+
+func parseQuoted(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", "", fmt.Errorf("expected quoted string at %q", s)
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", err
+			}
+
+			return value, s[i+1:], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated quoted string %q", s)
+}
+
+// parseValues parses a sequence of values, supporting the `_` (absent),
+// `_xN`, `stale`, `axN` and `a+bxN` notations of the upstream test format.
+// Staleness markers are not modelled by the coordinator and are treated as
+// absent values.
+func parseValues(s string) ([]float64, error) {
+	var values []float64
+	for _, token := range strings.Fields(s) {
+		if token == "_" || token == "stale" {
+			values = append(values, math.NaN())
+			continue
+		}
+
+		if v, err := parseNumber(token); err == nil {
+			values = append(values, v)
+			continue
+		}
+
+		parts := patExpand.FindStringSubmatch(token)
+		if parts == nil {
+			return nil, fmt.Errorf("invalid value %q", token)
+		}
+
+		times, err := strconv.Atoi(parts[4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid repetition in %q: %v", token, err)
+		}
+
+		if parts[1] == "_" {
+			if parts[2] != "" {
+				return nil, fmt.Errorf("invalid value %q", token)
+			}
+
+			for j := 0; j < times; j++ {
+				values = append(values, math.NaN())
+			}
+			continue
+		}
+
+		start, err := parseNumber(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %v", token, err)
+		}
+
+		var delta float64
+		if parts[2] != "" {
+			delta, err = parseNumber(parts[3])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q: %v", token, err)
+			}
+
+			if parts[2] == "-" {
+				delta = -delta
+			}
+		}
+
+		for j := 0; j <= times; j++ {
+			values = append(values, start+float64(j)*delta)
+		}
+	}
+
+	return values, nil
+}
+
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseDuration parses durations in the Prometheus format, which in addition
+// to the units understood by time.ParseDuration supports days, weeks and
+// years but only allows a single unit.
+func parseDuration(s string) (time.Duration, error) {
+	parts := patDuration.FindStringSubmatch(s)
+	if parts == nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %v", s, err)
+	}
+
+	d := time.Duration(n)
+	switch parts[2] {
+	case "y":
+		return d * 365 * 24 * time.Hour, nil
+	case "w":
+		return d * 7 * 24 * time.Hour, nil
+	case "d":
+		return d * 24 * time.Hour, nil
+	case "h":
+		return d * time.Hour, nil
+	case "m":
+		return d * time.Minute, nil
+	case "s":
+		return d * time.Second, nil
+	default:
+		return d * time.Millisecond, nil
+	}
+}
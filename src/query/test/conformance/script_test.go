@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conformance
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScript = `
+# A comment.
+load 5m
+	http_requests{job="api-server", instance="0"}	0+10x3
+	http_requests{job="api-server", instance="1"}	1 _ -2-1x1 _x2 stale 3x1
+
+eval instant at 10m sum(http_requests)
+	{} 21
+
+eval_ordered instant at 1h topk(1, http_requests)
+	http_requests{job="api-server", instance="0"} 30
+
+clear
+
+eval instant at 5m 1 + 1
+	2
+
+eval_fail instant at 1d 1 +
+`
+
+func TestParseScript(t *testing.T) {
+	script, err := ParseScript("test", strings.NewReader(testScript))
+	require.NoError(t, err)
+	require.Len(t, script.Commands, 6)
+
+	load, ok := script.Commands[0].(*LoadCommand)
+	require.True(t, ok)
+	assert.Equal(t, 3, load.Line())
+	assert.Equal(t, 5*time.Minute, load.Interval)
+	require.Len(t, load.Series, 2)
+	assert.Equal(t, models.Tags{
+		models.MetricName: "http_requests",
+		"job":             "api-server",
+		"instance":        "0",
+	}, load.Series[0].Tags)
+	assert.Equal(t, []float64{0, 10, 20, 30}, load.Series[0].Values)
+
+	values := load.Series[1].Values
+	require.Len(t, values, 9)
+	for i, expected := range []float64{1, math.NaN(), -2, -3, math.NaN(), math.NaN(), math.NaN(), 3, 3} {
+		if math.IsNaN(expected) {
+			assert.True(t, math.IsNaN(values[i]), "value %d should be NaN", i)
+		} else {
+			assert.Equal(t, expected, values[i], "value %d", i)
+		}
+	}
+
+	eval, ok := script.Commands[1].(*EvalCommand)
+	require.True(t, ok)
+	assert.Equal(t, 7, eval.Line())
+	assert.Equal(t, "sum(http_requests)", eval.Expr)
+	assert.Equal(t, 10*time.Minute, eval.At)
+	assert.False(t, eval.Fail)
+	assert.False(t, eval.Ordered)
+	assert.Equal(t, []ExpectedSample{{Tags: models.Tags{}, Value: 21}}, eval.Expected)
+
+	eval, ok = script.Commands[2].(*EvalCommand)
+	require.True(t, ok)
+	assert.True(t, eval.Ordered)
+	assert.Equal(t, time.Hour, eval.At)
+	require.Len(t, eval.Expected, 1)
+	assert.Equal(t, "http_requests", eval.Expected[0].Tags[models.MetricName])
+
+	_, ok = script.Commands[3].(*ClearCommand)
+	assert.True(t, ok)
+
+	eval, ok = script.Commands[4].(*EvalCommand)
+	require.True(t, ok)
+	assert.True(t, eval.IsScalar)
+	assert.Equal(t, 2.0, eval.ScalarWant)
+
+	eval, ok = script.Commands[5].(*EvalCommand)
+	require.True(t, ok)
+	assert.True(t, eval.Fail)
+	assert.Equal(t, 24*time.Hour, eval.At)
+	assert.Equal(t, "1 +", eval.Expr)
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		errMsg string
+	}{
+		{
+			name:   "unknown command",
+			script: "foo bar",
+			errMsg: "test:1: invalid command",
+		},
+		{
+			name:   "bad load interval",
+			script: "load 5 minutes",
+			errMsg: "test:1: invalid duration",
+		},
+		{
+			name:   "bad series value",
+			script: "load 5m\n  metric{a=\"b\"} 1 two",
+			errMsg: "test:2: invalid value",
+		},
+		{
+			name:   "unterminated label value",
+			script: "load 5m\n  metric{a=\"b} 1",
+			errMsg: "test:2: invalid value for label",
+		},
+		{
+			name:   "eval_fail with results",
+			script: "eval_fail instant at 5m 1 +\n  1",
+			errMsg: "test:2: eval_fail command cannot have expected results",
+		},
+		{
+			name:   "range eval",
+			script: "eval range from 0 to 5m step 1m metric",
+			errMsg: "test:1: invalid eval command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScript("test", strings.NewReader(tt.script))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"100ms": 100 * time.Millisecond,
+		"30s":   30 * time.Second,
+		"5m":    5 * time.Minute,
+		"2h":    2 * time.Hour,
+		"1d":    24 * time.Hour,
+		"1w":    7 * 24 * time.Hour,
+		"1y":    365 * 24 * time.Hour,
+	} {
+		d, err := parseDuration(s)
+		require.NoError(t, err)
+		assert.Equal(t, expected, d, s)
+	}
+
+	_, err := parseDuration("1h30m")
+	assert.Error(t, err)
+}
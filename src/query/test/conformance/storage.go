@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conformance
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+)
+
+// memStorage is an in-memory storage.Storage the engine is evaluated
+// against, it holds raw datapoints keyed by the ID of their tags.
+type memStorage struct {
+	sync.RWMutex
+	series map[string]*memSeries
+}
+
+type memSeries struct {
+	tags       models.Tags
+	datapoints ts.Datapoints
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{series: make(map[string]*memSeries)}
+}
+
+func (s *memStorage) Fetch(
+	_ context.Context,
+	query *storage.FetchQuery,
+	_ *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	var seriesList ts.SeriesList
+	for _, id := range s.matchingIDs(query.TagMatchers) {
+		series := s.series[id]
+		var datapoints ts.Datapoints
+		for _, dp := range series.datapoints {
+			if dp.Timestamp.Before(query.Start) || dp.Timestamp.After(query.End) {
+				continue
+			}
+			datapoints = append(datapoints, dp)
+		}
+
+		seriesList = append(seriesList, ts.NewSeries(id, datapoints, copyTags(series.tags)))
+	}
+
+	return &storage.FetchResult{SeriesList: seriesList, LocalOnly: true}, nil
+}
+
+func (s *memStorage) FetchTags(
+	_ context.Context,
+	query *storage.FetchQuery,
+	_ *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	var metrics models.Metrics
+	for _, id := range s.matchingIDs(query.TagMatchers) {
+		metrics = append(metrics, &models.Metric{
+			ID:   id,
+			Tags: copyTags(s.series[id].tags),
+		})
+	}
+
+	return &storage.SearchResults{Metrics: metrics}, nil
+}
+
+func (s *memStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	result, err := s.Fetch(ctx, query, options)
+	if err != nil {
+		return block.Result{}, err
+	}
+
+	return storage.FetchResultToBlockResult(result, query)
+}
+
+func (s *memStorage) Write(_ context.Context, query *storage.WriteQuery) error {
+	s.Lock()
+	defer s.Unlock()
+
+	id := query.Tags.ID()
+	series, ok := s.series[id]
+	if !ok {
+		series = &memSeries{tags: copyTags(query.Tags)}
+		s.series[id] = series
+	}
+
+	series.datapoints = append(series.datapoints, query.Datapoints...)
+	sort.Slice(series.datapoints, func(i, j int) bool {
+		return series.datapoints[i].Timestamp.Before(series.datapoints[j].Timestamp)
+	})
+
+	return nil
+}
+
+func (s *memStorage) Type() storage.Type {
+	return storage.TypeLocalDC
+}
+
+func (s *memStorage) Close() error {
+	return nil
+}
+
+// clear removes all series from the storage.
+func (s *memStorage) clear() {
+	s.Lock()
+	s.series = make(map[string]*memSeries)
+	s.Unlock()
+}
+
+// matchingIDs returns the sorted IDs of the series matching all the
+// matchers, a tag absent from a series matches as the empty string.
+func (s *memStorage) matchingIDs(matchers models.Matchers) []string {
+	var ids []string
+	for id, series := range s.series {
+		matched := true
+		for _, m := range matchers {
+			if !m.Matches(series.tags[m.Name]) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func copyTags(tags models.Tags) models.Tags {
+	copied := make(models.Tags, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+
+	return copied
+}
@@ -40,6 +40,7 @@ func NewControllerWithSink(ID parser.NodeID) (*transform.Controller, *SinkNode)
 // SinkNode is a test node useful for comparisons
 type SinkNode struct {
 	Values [][]float64
+	Metas  []block.SeriesMeta
 }
 
 // Process processes and stores the last block output in the sink node
@@ -62,5 +63,7 @@ func (s *SinkNode) Process(ID parser.NodeID, block block.Block) error {
 		s.Values = append(s.Values, values)
 	}
 
+	s.Metas = append(s.Metas, iter.SeriesMeta()...)
+
 	return nil
 }
@@ -0,0 +1,245 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ts
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/models"
+)
+
+// DecimationRatio is the minimum ratio of the step to the resolution of a
+// series for it to be decimated, series with a coarser resolution are
+// sampled at each step as usual.
+const DecimationRatio = 4
+
+// Resolution returns the average interval between the datapoints, or zero if
+// there are fewer than two datapoints.
+func (d Datapoints) Resolution() time.Duration {
+	if len(d) < 2 {
+		return 0
+	}
+	return d[len(d)-1].Timestamp.Sub(d[0].Timestamp) / time.Duration(len(d)-1)
+}
+
+// DecimateToFixedStep converts raw datapoints to fixed step values. If the
+// interval is at least DecimationRatio times the resolution of the datapoints
+// the datapoints from the start of each step up to the start of the next are
+// reduced to a single value using the decimation, otherwise the datapoints
+// are sampled the same as RawPointsToFixedStep.
+func DecimateToFixedStep(
+	datapoints Datapoints,
+	start time.Time,
+	end time.Time,
+	interval time.Duration,
+	decimation models.Decimation,
+) (FixedResolutionMutableValues, error) {
+	resolution := datapoints.Resolution()
+	if !decimation.Enabled() || resolution == 0 || interval < DecimationRatio*resolution {
+		return RawPointsToFixedStep(datapoints, start, end, interval)
+	}
+
+	if end.Before(start) {
+		return nil, fmt.Errorf("start cannot be after end, start: %v, end: %v", start, end)
+	}
+
+	if interval == 0 {
+		return nil, errors.ErrZeroInterval
+	}
+
+	var numSteps int
+	if end.Equal(start) {
+		numSteps = 1
+	} else {
+		numSteps = int(end.Sub(start) / interval)
+	}
+
+	fixStepValues := newFixedStepValues(interval, numSteps, math.NaN(), start)
+	buckets := bucketDatapoints(datapoints, start, interval, numSteps)
+	switch decimation {
+	case models.MaxDecimation:
+		for i, b := range buckets {
+			fixStepValues.values[i] = maxValue(b)
+		}
+	case models.MinDecimation:
+		for i, b := range buckets {
+			fixStepValues.values[i] = minValue(b)
+		}
+	case models.AvgDecimation:
+		for i, b := range buckets {
+			fixStepValues.values[i] = avgPoint(b, start, interval).y
+		}
+	case models.LTTBDecimation:
+		largestTriangleThreeBuckets(fixStepValues.values, buckets, start, interval)
+	default:
+		return nil, fmt.Errorf("unknown decimation: %v", decimation)
+	}
+
+	return fixStepValues, nil
+}
+
+// bucketDatapoints splits the datapoints into the datapoints within each step.
+func bucketDatapoints(
+	datapoints Datapoints,
+	start time.Time,
+	interval time.Duration,
+	numSteps int,
+) []Datapoints {
+	buckets := make([]Datapoints, numSteps)
+	dpIdx := 0
+	numPoints := len(datapoints)
+	for dpIdx < numPoints && datapoints[dpIdx].Timestamp.Before(start) {
+		dpIdx++
+	}
+
+	for i := range buckets {
+		bucketStart := dpIdx
+		bucketEnd := start.Add(time.Duration(i+1) * interval)
+		for dpIdx < numPoints && datapoints[dpIdx].Timestamp.Before(bucketEnd) {
+			dpIdx++
+		}
+		buckets[i] = datapoints[bucketStart:dpIdx]
+	}
+
+	return buckets
+}
+
+func maxValue(datapoints Datapoints) float64 {
+	max := math.NaN()
+	for _, dp := range datapoints {
+		if math.IsNaN(max) || dp.Value > max {
+			max = dp.Value
+		}
+	}
+	return max
+}
+
+func minValue(datapoints Datapoints) float64 {
+	min := math.NaN()
+	for _, dp := range datapoints {
+		if math.IsNaN(min) || dp.Value < min {
+			min = dp.Value
+		}
+	}
+	return min
+}
+
+// point is a datapoint with its time in steps since the start.
+type point struct {
+	x, y float64
+}
+
+func toPoint(dp Datapoint, start time.Time, interval time.Duration) point {
+	return point{
+		x: float64(dp.Timestamp.Sub(start)) / float64(interval),
+		y: dp.Value,
+	}
+}
+
+// avgPoint returns the average of the datapoints ignoring NaNs, the value of
+// which is NaN if there are no such datapoints.
+func avgPoint(datapoints Datapoints, start time.Time, interval time.Duration) point {
+	var (
+		sum   point
+		count float64
+	)
+	for _, dp := range datapoints {
+		if math.IsNaN(dp.Value) {
+			continue
+		}
+		p := toPoint(dp, start, interval)
+		sum.x += p.x
+		sum.y += p.y
+		count++
+	}
+
+	if count == 0 {
+		return point{y: math.NaN()}
+	}
+	return point{x: sum.x / count, y: sum.y / count}
+}
+
+func triangleArea(a, b, c point) float64 {
+	return math.Abs((a.x-c.x)*(b.y-a.y)-(a.x-b.x)*(c.y-a.y)) / 2
+}
+
+// largestTriangleThreeBuckets selects the datapoint within each step which
+// forms the largest triangle with the datapoint selected for the previous
+// step and the average of the next step, which best preserves the peaks and
+// troughs of the series when drawn.
+func largestTriangleThreeBuckets(
+	values []float64,
+	buckets []Datapoints,
+	start time.Time,
+	interval time.Duration,
+) {
+	// The average of the next step with values for each step, falling back to
+	// the average of the step itself for the last such step.
+	next := make([]point, len(buckets))
+	var nextAvg point
+	hasNext := false
+	for i := len(buckets) - 1; i >= 0; i-- {
+		avg := avgPoint(buckets[i], start, interval)
+		if hasNext {
+			next[i] = nextAvg
+		} else {
+			next[i] = avg
+		}
+
+		if !math.IsNaN(avg.y) {
+			nextAvg, hasNext = avg, true
+		}
+	}
+
+	var prev point
+	hasPrev := false
+	for i, bucket := range buckets {
+		var (
+			selected point
+			maxArea  = -1.0
+		)
+		for _, dp := range bucket {
+			if math.IsNaN(dp.Value) {
+				continue
+			}
+
+			p := toPoint(dp, start, interval)
+			if !hasPrev {
+				// The first datapoint anchors the triangles of the first step.
+				prev, hasPrev = p, true
+			}
+
+			if area := triangleArea(prev, p, next[i]); area > maxArea {
+				selected, maxArea = p, area
+			}
+		}
+
+		if maxArea < 0 {
+			continue
+		}
+
+		values[i] = selected.y
+		prev = selected
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ts
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decimationDatapoints(start time.Time) Datapoints {
+	values := []float64{
+		1, 5, 2, 3, 0, 4,
+		2, 2, math.NaN(), 8, 2, 2,
+	}
+	dps := make(Datapoints, len(values))
+	for i, v := range values {
+		dps[i] = Datapoint{
+			Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+			Value:     v,
+		}
+	}
+	return dps
+}
+
+func requireValuesEqual(t *testing.T, expected []float64, actual Values) {
+	require.Equal(t, len(expected), actual.Len())
+	for i, v := range expected {
+		if math.IsNaN(v) {
+			assert.True(t, math.IsNaN(actual.ValueAt(i)), "expected NaN at %d", i)
+		} else {
+			assert.Equal(t, v, actual.ValueAt(i), "value at %d", i)
+		}
+	}
+}
+
+func TestDecimateToFixedStep(t *testing.T) {
+	start := time.Now().Truncate(time.Minute)
+	dps := decimationDatapoints(start)
+	end := start.Add(3 * time.Minute)
+
+	tests := []struct {
+		decimation models.Decimation
+		expected   []float64
+	}{
+		{models.UnsetDecimation, []float64{1, 2, math.NaN()}},
+		{models.NoDecimation, []float64{1, 2, math.NaN()}},
+		{models.MaxDecimation, []float64{5, 8, math.NaN()}},
+		{models.MinDecimation, []float64{0, 2, math.NaN()}},
+		{models.AvgDecimation, []float64{2.5, 3.2, math.NaN()}},
+		{models.LTTBDecimation, []float64{5, 8, math.NaN()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.decimation.String(), func(t *testing.T) {
+			vals, err := DecimateToFixedStep(dps, start, end, time.Minute, tt.decimation)
+			require.NoError(t, err)
+			requireValuesEqual(t, tt.expected, vals)
+		})
+	}
+}
+
+func TestDecimateToFixedStepBelowRatio(t *testing.T) {
+	start := time.Now().Truncate(time.Minute)
+	dps := decimationDatapoints(start)
+	end := start.Add(2 * time.Minute)
+
+	// Steps within DecimationRatio of the resolution are sampled as usual.
+	expected, err := RawPointsToFixedStep(dps, start, end, 20*time.Second)
+	require.NoError(t, err)
+
+	vals, err := DecimateToFixedStep(dps, start, end, 20*time.Second, models.MaxDecimation)
+	require.NoError(t, err)
+	requireValuesEqual(t, expected.(*fixedResolutionValues).values, vals)
+}
+
+func TestDecimateToFixedStepStartAfterEnd(t *testing.T) {
+	start := time.Now().Truncate(time.Minute)
+	dps := decimationDatapoints(start)
+
+	_, err := DecimateToFixedStep(dps, start, start.Add(-time.Minute), time.Minute, models.MaxDecimation)
+	require.Error(t, err)
+}
+
+func TestSeriesListDecimate(t *testing.T) {
+	start := time.Now().Truncate(time.Minute)
+	series := NewSeries("foo", decimationDatapoints(start), models.Tags{})
+	series.SetCompleteness(0.5)
+
+	decimated, err := SeriesList{series}.Decimate(start, start.Add(2*time.Minute),
+		time.Minute, models.MaxDecimation)
+	require.NoError(t, err)
+	require.Len(t, decimated, 1)
+	assert.Equal(t, "foo", decimated[0].Name())
+	assert.Equal(t, 0.5, decimated[0].Completeness())
+	requireValuesEqual(t, []float64{5, 8}, decimated[0].Values())
+}
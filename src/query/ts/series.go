@@ -34,6 +34,8 @@ type Series struct {
 	name string
 	vals Values
 	Tags models.Tags
+
+	completeness float64
 }
 
 // NewSeries creates a new Series at a given start time, backed by the provided values
@@ -54,6 +56,18 @@ func (s *Series) Len() int { return s.vals.Len() }
 // Values returns the underlying values interface
 func (s *Series) Values() Values { return s.vals }
 
+// Completeness returns the fraction of required replicas that returned the
+// series, series that were not read from replicas are always complete
+func (s *Series) Completeness() float64 {
+	if s.completeness == 0 {
+		return 1
+	}
+	return s.completeness
+}
+
+// SetCompleteness sets the fraction of required replicas that returned the series
+func (s *Series) SetCompleteness(value float64) { s.completeness = value }
+
 // Align adjusts the datapoints to start, end and a fixed interval
 func (s *Series) Align(start, end time.Time, interval time.Duration) (*Series, error) {
 	fixedVals, err := alignValues(s.Values(), start, end, interval)
@@ -61,7 +75,9 @@ func (s *Series) Align(start, end time.Time, interval time.Duration) (*Series, e
 		return nil, err
 	}
 
-	return NewSeries(s.name, fixedVals, s.Tags), nil
+	aligned := NewSeries(s.name, fixedVals, s.Tags)
+	aligned.completeness = s.completeness
+	return aligned, nil
 }
 
 func alignValues(values Values, start, end time.Time, interval time.Duration) (FixedResolutionMutableValues, error) {
@@ -76,6 +92,28 @@ func alignValues(values Values, start, end time.Time, interval time.Duration) (F
 	}
 }
 
+// Decimate adjusts the datapoints to start, end and a fixed interval, reducing
+// the datapoints within each step using the decimation if the interval is far
+// coarser than the resolution of the series
+func (s *Series) Decimate(start, end time.Time, interval time.Duration, decimation models.Decimation) (*Series, error) {
+	fixedVals, err := decimateValues(s.Values(), start, end, interval, decimation)
+	if err != nil {
+		return nil, err
+	}
+
+	decimated := NewSeries(s.name, fixedVals, s.Tags)
+	decimated.completeness = s.completeness
+	return decimated, nil
+}
+
+func decimateValues(values Values, start, end time.Time, interval time.Duration, decimation models.Decimation) (FixedResolutionMutableValues, error) {
+	vals, ok := values.(Datapoints)
+	if !ok {
+		return alignValues(values, start, end, interval)
+	}
+	return DecimateToFixedStep(vals, start, end, interval, decimation)
+}
+
 // SeriesList represents a slice of series pointers
 type SeriesList []*Series
 
@@ -114,3 +152,19 @@ func (seriesList SeriesList) Align(start, end time.Time, interval time.Duration)
 
 	return alignedList, nil
 }
+
+// Decimate aligns each series to the given start, end and step, decimating
+// series with a resolution far finer than the step.
+func (seriesList SeriesList) Decimate(start, end time.Time, interval time.Duration, decimation models.Decimation) (SeriesList, error) {
+	decimatedList := make(SeriesList, len(seriesList))
+	for i, s := range seriesList {
+		decimatedSeries, err := s.Decimate(start, end, interval, decimation)
+		if err != nil {
+			return nil, err
+		}
+
+		decimatedList[i] = decimatedSeries
+	}
+
+	return decimatedList, nil
+}
@@ -38,3 +38,19 @@ func TestCreateNewSeries(t *testing.T) {
 	assert.Equal(t, 10000, series.Len())
 	assert.Equal(t, 1.0, series.Values().ValueAt(0))
 }
+
+func TestSeriesCompleteness(t *testing.T) {
+	values := NewFixedStepValues(1000, 10, 1, time.Now())
+	series := NewSeries("metrics", values, models.Tags{})
+	assert.Equal(t, 1.0, series.Completeness())
+
+	series.SetCompleteness(0.5)
+	assert.Equal(t, 0.5, series.Completeness())
+
+	now := time.Now()
+	raw := NewSeries("metrics", Datapoints{{Timestamp: now, Value: 1}}, models.Tags{})
+	raw.SetCompleteness(0.5)
+	aligned, err := raw.Align(now, now.Add(time.Minute), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, aligned.Completeness())
+}
@@ -24,6 +24,7 @@ import (
 	"context"
 	"io"
 
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/errors"
 	rpc "github.com/m3db/m3/src/query/generated/proto/rpcpb"
@@ -53,7 +54,12 @@ func NewGrpcClient(addresses []string, additionalDialOpts ...grpc.DialOption) (C
 	}
 	resolver := newStaticResolver(addresses)
 	balancer := grpc.RoundRobin(resolver)
-	dialOptions := []grpc.DialOption{grpc.WithBalancer(balancer), grpc.WithInsecure()}
+	dialOptions := []grpc.DialOption{
+		grpc.WithBalancer(balancer),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(tracing.StreamClientInterceptor()),
+	}
 	dialOptions = append(dialOptions, additionalDialOpts...)
 
 	cc, err := grpc.Dial("", dialOptions...)
@@ -74,7 +80,7 @@ func (c *grpcClient) Fetch(ctx context.Context, query *storage.FetchQuery, optio
 	id := logging.ReadContextID(ctx)
 	fetchClient, err := c.client.Fetch(ctx, EncodeFetchMessage(query, id))
 	if err != nil {
-		return nil, err
+		return nil, fromGRPCError(err)
 	}
 
 	defer fetchClient.CloseSend()
@@ -92,7 +98,7 @@ func (c *grpcClient) Fetch(ctx context.Context, query *storage.FetchQuery, optio
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, fromGRPCError(err)
 		}
 		rpcSeries := result.GetSeries()
 		fResult, err := DecodeFetchResult(ctx, rpcSeries)
@@ -130,7 +136,7 @@ func (c *grpcClient) Write(ctx context.Context, query *storage.WriteQuery) error
 	if err == io.EOF {
 		return nil
 	}
-	return err
+	return fromGRPCError(err)
 }
 
 func (c *grpcClient) FetchBlocks(
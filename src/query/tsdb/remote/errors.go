@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toGRPCError converts an error to a gRPC status error carrying the
+// gRPC code of the error's code so remote callers can classify it.
+func toGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(grpcCodeFromCode(client.ErrorCode(err)), err.Error())
+}
+
+// fromGRPCError converts a gRPC status error to an error with the status
+// message classified with the code of its gRPC code, errors with other
+// gRPC codes are returned unchanged.
+func fromGRPCError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok || s == nil {
+		return err
+	}
+	code, ok := codeFromGRPCCode(s.Code())
+	if !ok {
+		return err
+	}
+	return m3dberrors.NewCodedError(code, errors.New(s.Message()))
+}
+
+func grpcCodeFromCode(code m3dberrors.Code) codes.Code {
+	switch code {
+	case m3dberrors.CodeBadRequest:
+		return codes.InvalidArgument
+	case m3dberrors.CodeResourceExhausted:
+		return codes.ResourceExhausted
+	case m3dberrors.CodeUnavailable:
+		return codes.Unavailable
+	case m3dberrors.CodeDataCorruption:
+		return codes.DataLoss
+	case m3dberrors.CodeConsistencyNotAchieved:
+		return codes.Aborted
+	}
+	return codes.Internal
+}
+
+func codeFromGRPCCode(code codes.Code) (m3dberrors.Code, bool) {
+	switch code {
+	case codes.Internal:
+		return m3dberrors.CodeInternal, true
+	case codes.InvalidArgument:
+		return m3dberrors.CodeBadRequest, true
+	case codes.ResourceExhausted:
+		return m3dberrors.CodeResourceExhausted, true
+	case codes.Unavailable:
+		return m3dberrors.CodeUnavailable, true
+	case codes.DataLoss:
+		return m3dberrors.CodeDataCorruption, true
+	case codes.Aborted:
+		return m3dberrors.CodeConsistencyNotAchieved, true
+	}
+	return 0, false
+}
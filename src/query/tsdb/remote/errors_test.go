@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	xerrors "github.com/m3db/m3x/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestGRPCErrorRoundTrip(t *testing.T) {
+	err := errors.New("an error")
+	for _, code := range m3dberrors.ValidCodes() {
+		grpcErr := toGRPCError(m3dberrors.NewCodedError(code, err))
+		assert.Equal(t, err.Error(), grpc.ErrorDesc(grpcErr))
+
+		remoteErr := fromGRPCError(grpcErr)
+		require.Error(t, remoteErr)
+		assert.Equal(t, err.Error(), remoteErr.Error())
+		assert.Equal(t, code, client.ErrorCode(remoteErr), code.String())
+	}
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	assert.Nil(t, toGRPCError(nil))
+	assert.Equal(t, codes.InvalidArgument,
+		grpc.Code(toGRPCError(xerrors.NewInvalidParamsError(errors.New("invalid")))))
+	assert.Equal(t, codes.Internal, grpc.Code(toGRPCError(errors.New("unclassified"))))
+
+	// Errors without a classified gRPC code are returned unchanged
+	err := grpc.Errorf(codes.DeadlineExceeded, "timed out")
+	assert.Equal(t, err, fromGRPCError(err))
+}
@@ -24,9 +24,11 @@ import (
 	"io"
 	"net"
 
+	"github.com/m3db/m3/src/dbnode/x/tracing"
 	rpc "github.com/m3db/m3/src/query/generated/proto/rpcpb"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3x/errors"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -44,7 +46,10 @@ func newServer(store storage.Storage) *grpcServer {
 
 // CreateNewGrpcServer creates server, given context local storage
 func CreateNewGrpcServer(store storage.Storage) *grpc.Server {
-	server := grpc.NewServer()
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(tracing.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(tracing.StreamServerInterceptor()),
+	)
 	grpcServer := newServer(store)
 	rpc.RegisterQueryServer(server, grpcServer)
 
@@ -69,7 +74,7 @@ func (s *grpcServer) Fetch(message *rpc.FetchMessage, stream rpc.Query_FetchServ
 
 	if err != nil {
 		logger.Error("unable to decode fetch query", zap.Any("error", err))
-		return err
+		return toGRPCError(xerrors.NewInvalidParamsError(err))
 	}
 
 	// Iterate while there are more results
@@ -78,7 +83,7 @@ func (s *grpcServer) Fetch(message *rpc.FetchMessage, stream rpc.Query_FetchServ
 
 		if err != nil {
 			logger.Error("unable to fetch local query", zap.Any("error", err))
-			return err
+			return toGRPCError(err)
 		}
 		err = stream.Send(EncodeFetchResult(result))
 
@@ -114,7 +119,7 @@ func (s *grpcServer) Write(stream rpc.Query_WriteServer) error {
 		err = s.storage.Write(ctx, query)
 		if err != nil {
 			logger.Error("unable to write local query", zap.Any("error", err))
-			return err
+			return toGRPCError(err)
 		}
 	}
 }
@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thanos
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/m3db/m3/src/query/generated/proto/storepb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/prometheus/tsdb/chunkenc"
+)
+
+const (
+	// maxSamplesPerChunk is the number of samples encoded into each chunk,
+	// matching the number of samples Prometheus cuts its chunks at.
+	maxSamplesPerChunk = 120
+)
+
+// decodeMatchers converts StoreAPI label matchers to m3 matchers. Matchers
+// on external labels are evaluated against the external labels and dropped,
+// if any of them does not match no series can match and ok is false.
+func decodeMatchers(
+	matchers []storepb.LabelMatcher,
+	externalLabels models.Tags,
+) (result models.Matchers, ok bool, err error) {
+	result = make(models.Matchers, 0, len(matchers))
+	for _, matcher := range matchers {
+		matchType, err := decodeMatchType(matcher.Type)
+		if err != nil {
+			return nil, false, err
+		}
+
+		m, err := models.NewMatcher(matchType, matcher.Name, matcher.Value)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if value, external := externalLabels[matcher.Name]; external {
+			if !m.Matches(value) {
+				return nil, false, nil
+			}
+			continue
+		}
+
+		result = append(result, m)
+	}
+
+	return result, true, nil
+}
+
+func decodeMatchType(matchType storepb.LabelMatcher_Type) (models.MatchType, error) {
+	switch matchType {
+	case storepb.LabelMatcher_EQ:
+		return models.MatchEqual, nil
+	case storepb.LabelMatcher_NEQ:
+		return models.MatchNotEqual, nil
+	case storepb.LabelMatcher_RE:
+		return models.MatchRegexp, nil
+	case storepb.LabelMatcher_NRE:
+		return models.MatchNotRegexp, nil
+	default:
+		return 0, fmt.Errorf("unknown match type %v", matchType)
+	}
+}
+
+// encodeLabels converts tags to StoreAPI labels sorted by name, with the
+// external labels overriding tags of the same name.
+func encodeLabels(tags models.Tags, externalLabels models.Tags) []storepb.Label {
+	labels := make([]storepb.Label, 0, len(tags)+len(externalLabels))
+	for name, value := range tags {
+		if _, external := externalLabels[name]; external {
+			continue
+		}
+		labels = append(labels, storepb.Label{Name: name, Value: value})
+	}
+	for name, value := range externalLabels {
+		labels = append(labels, storepb.Label{Name: name, Value: value})
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
+// encodeSeries converts a series to a StoreAPI series with its datapoints
+// encoded as raw XOR chunks, datapoints without a value are skipped.
+func encodeSeries(series *ts.Series, externalLabels models.Tags) (*storepb.Series, error) {
+	var (
+		values = series.Values()
+		chunks []storepb.AggrChunk
+		chunk  *chunkenc.XORChunk
+		app    chunkenc.Appender
+		err    error
+	)
+	for i := 0; i < values.Len(); i++ {
+		dp := values.DatapointAt(i)
+		if math.IsNaN(dp.Value) {
+			continue
+		}
+
+		timestamp := storage.TimeToTimestamp(dp.Timestamp)
+		if chunk == nil || chunk.NumSamples() >= maxSamplesPerChunk {
+			chunk = chunkenc.NewXORChunk()
+			app, err = chunk.Appender()
+			if err != nil {
+				return nil, err
+			}
+
+			chunks = append(chunks, storepb.AggrChunk{
+				MinTime: timestamp,
+				Raw:     &storepb.Chunk{Type: storepb.Chunk_XOR},
+			})
+		}
+
+		app.Append(timestamp, dp.Value)
+		last := &chunks[len(chunks)-1]
+		last.MaxTime = timestamp
+		last.Raw.Data = chunk.Bytes()
+	}
+
+	return &storepb.Series{
+		Labels: encodeLabels(series.Tags, externalLabels),
+		Chunks: chunks,
+	}, nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thanos
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/storepb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMatchersDropsExternalLabels(t *testing.T) {
+	external := models.Tags{"region": "us-east"}
+	matchers, ok, err := decodeMatchers([]storepb.LabelMatcher{
+		{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+		{Type: storepb.LabelMatcher_RE, Name: "region", Value: "us-.*"},
+		{Type: storepb.LabelMatcher_NEQ, Name: "job", Value: "api"},
+	}, external)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, matchers, 2)
+	assert.Equal(t, models.MatchEqual, matchers[0].Type)
+	assert.Equal(t, "__name__", matchers[0].Name)
+	assert.Equal(t, models.MatchNotEqual, matchers[1].Type)
+	assert.Equal(t, "job", matchers[1].Name)
+
+	_, ok, err = decodeMatchers([]storepb.LabelMatcher{
+		{Type: storepb.LabelMatcher_EQ, Name: "region", Value: "eu-west"},
+	}, external)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = decodeMatchers([]storepb.LabelMatcher{
+		{Type: storepb.LabelMatcher_Type(42), Name: "a", Value: "b"},
+	}, external)
+	assert.Error(t, err)
+}
+
+func TestEncodeLabelsSortedWithExternalLabels(t *testing.T) {
+	labels := encodeLabels(
+		models.Tags{"region": "local", "job": "api", "__name__": "up"},
+		models.Tags{"region": "us-east"},
+	)
+	assert.Equal(t, []storepb.Label{
+		{Name: "__name__", Value: "up"},
+		{Name: "job", Value: "api"},
+		{Name: "region", Value: "us-east"},
+	}, labels)
+}
+
+func TestEncodeSeriesChunks(t *testing.T) {
+	var (
+		start = time.Unix(1000, 0)
+		dps   ts.Datapoints
+	)
+	for i := 0; i < 2*maxSamplesPerChunk+10; i++ {
+		value := float64(i)
+		if i == 5 {
+			value = math.NaN()
+		}
+		dps = append(dps, ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+			Value:     value,
+		})
+	}
+
+	series, err := encodeSeries(ts.NewSeries("up", dps, models.Tags{"a": "b"}), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []storepb.Label{{Name: "a", Value: "b"}}, series.Labels)
+	require.Len(t, series.Chunks, 3)
+
+	var decoded ts.Datapoints
+	for _, c := range series.Chunks {
+		require.NotNil(t, c.Raw)
+		assert.Equal(t, storepb.Chunk_XOR, c.Raw.Type)
+
+		chunk, err := chunkenc.FromData(chunkenc.EncXOR, c.Raw.Data)
+		require.NoError(t, err)
+		assert.True(t, chunk.NumSamples() <= maxSamplesPerChunk)
+
+		it := chunk.Iterator()
+		first := true
+		for it.Next() {
+			timestamp, value := it.At()
+			if first {
+				assert.Equal(t, c.MinTime, timestamp)
+				first = false
+			}
+			assert.True(t, timestamp <= c.MaxTime)
+			decoded = append(decoded, ts.Datapoint{
+				Timestamp: storage.TimestampToTime(timestamp),
+				Value:     value,
+			})
+		}
+		require.NoError(t, it.Err())
+	}
+
+	expected := append(ts.Datapoints{}, dps[:5]...)
+	expected = append(expected, dps[6:]...)
+	require.Len(t, decoded, len(expected))
+	for i := range expected {
+		assert.True(t, expected[i].Timestamp.Equal(decoded[i].Timestamp))
+		assert.Equal(t, expected[i].Value, decoded[i].Value)
+	}
+}
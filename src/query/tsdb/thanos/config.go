@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thanos
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultLabelsLookback is the default range label names and values
+	// are searched over.
+	DefaultLabelsLookback = time.Hour
+)
+
+// Configuration configures serving the Thanos StoreAPI so that Thanos Query
+// can federate the coordinator as one of its stores.
+type Configuration struct {
+	// ListenAddress is the gRPC listen address of the StoreAPI.
+	ListenAddress string `yaml:"listenAddress" validate:"nonzero"`
+
+	// ExternalLabels are the labels advertised to Thanos Query to identify
+	// this store and added to every series served.
+	ExternalLabels map[string]string `yaml:"externalLabels"`
+
+	// Retention is how far back data is advertised as available, zero
+	// advertises all time.
+	Retention time.Duration `yaml:"retention"`
+
+	// LabelsLookback is the range label names and values are searched over,
+	// defaults to an hour.
+	LabelsLookback time.Duration `yaml:"labelsLookback"`
+}
+
+// NewServer returns a gRPC server serving the StoreAPI from the storage.
+func (c Configuration) NewServer(store storage.Storage) *grpc.Server {
+	labelsLookback := c.LabelsLookback
+	if labelsLookback <= 0 {
+		labelsLookback = DefaultLabelsLookback
+	}
+	return CreateNewGrpcServer(store, Options{
+		ExternalLabels: models.Tags(c.ExternalLabels),
+		Retention:      c.Retention,
+		LabelsLookback: labelsLookback,
+	})
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thanos
+
+import (
+	"math"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/x/tracing"
+	"github.com/m3db/m3/src/query/generated/proto/storepb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Options are the options of the StoreAPI server.
+type Options struct {
+	// ExternalLabels identify the store and are added to every series.
+	ExternalLabels models.Tags
+	// Retention is how far back data is advertised, zero advertises all time.
+	Retention time.Duration
+	// LabelsLookback is the range label names and values are searched over.
+	LabelsLookback time.Duration
+}
+
+type storeServer struct {
+	storage storage.Storage
+	opts    Options
+	nowFn   func() time.Time
+}
+
+func newServer(store storage.Storage, opts Options) *storeServer {
+	return &storeServer{
+		storage: store,
+		opts:    opts,
+		nowFn:   time.Now,
+	}
+}
+
+// CreateNewGrpcServer creates a server serving the Thanos StoreAPI from
+// the storage.
+func CreateNewGrpcServer(store storage.Storage, opts Options) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(tracing.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(tracing.StreamServerInterceptor()),
+	)
+	storepb.RegisterStoreServer(server, newServer(store, opts))
+	return server
+}
+
+// StartNewGrpcServer starts server on given address, then notifies channel
+func StartNewGrpcServer(server *grpc.Server, address string, waitForStart chan<- struct{}) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	waitForStart <- struct{}{}
+	return server.Serve(lis)
+}
+
+// Info returns the external labels and time range of the store.
+func (s *storeServer) Info(
+	ctx context.Context,
+	_ *storepb.InfoRequest,
+) (*storepb.InfoResponse, error) {
+	minTime := int64(math.MinInt64)
+	if s.opts.Retention > 0 {
+		minTime = storage.TimeToTimestamp(s.nowFn().Add(-s.opts.Retention))
+	}
+
+	return &storepb.InfoResponse{
+		Labels:    encodeLabels(nil, s.opts.ExternalLabels),
+		MinTime:   minTime,
+		MaxTime:   math.MaxInt64,
+		StoreType: storepb.StoreType_STORE,
+	}, nil
+}
+
+// Series streams the series matching the request as raw chunks, only raw
+// data is served so requested aggregates are answered with raw chunks.
+func (s *storeServer) Series(
+	req *storepb.SeriesRequest,
+	stream storepb.Store_SeriesServer,
+) error {
+	ctx := stream.Context()
+	logger := logging.WithContext(ctx)
+
+	matchers, ok, err := decodeMatchers(req.Matchers, s.opts.ExternalLabels)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !ok {
+		return nil
+	}
+	if len(matchers) == 0 {
+		return status.Error(codes.InvalidArgument,
+			"no matchers specified (excluding external labels)")
+	}
+
+	query := &storage.FetchQuery{
+		TagMatchers: matchers,
+		Start:       storage.TimestampToTime(req.MinTime),
+		End:         storage.TimestampToTime(req.MaxTime),
+	}
+	stats := models.NewQueryStats()
+	result, err := s.storage.Fetch(ctx, query, &storage.FetchOptions{
+		Stats: stats,
+	})
+	if err != nil {
+		logger.Error("unable to fetch series", zap.Any("error", err))
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	for _, series := range result.SeriesList {
+		encoded, err := encodeSeries(series, s.opts.ExternalLabels)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if len(encoded.Chunks) == 0 {
+			continue
+		}
+
+		err = stream.Send(&storepb.SeriesResponse{
+			Result: &storepb.SeriesResponse_Series{Series: encoded},
+		})
+		if err != nil {
+			logger.Error("unable to send series", zap.Any("error", err))
+			return err
+		}
+	}
+
+	return sendWarnings(stream, stats)
+}
+
+// LabelNames returns the label names of series within the labels lookback
+// along with the names of the external labels.
+func (s *storeServer) LabelNames(
+	ctx context.Context,
+	_ *storepb.LabelNamesRequest,
+) (*storepb.LabelNamesResponse, error) {
+	result, warnings, err := s.fetchTags(ctx, models.MetricName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(s.opts.ExternalLabels))
+	for _, metric := range result.Metrics {
+		for name := range metric.Tags {
+			names[name] = struct{}{}
+		}
+	}
+	for name := range s.opts.ExternalLabels {
+		names[name] = struct{}{}
+	}
+
+	return &storepb.LabelNamesResponse{
+		Names:    sortedStrings(names),
+		Warnings: warnings,
+	}, nil
+}
+
+// LabelValues returns the values of the requested label of series within
+// the labels lookback.
+func (s *storeServer) LabelValues(
+	ctx context.Context,
+	req *storepb.LabelValuesRequest,
+) (*storepb.LabelValuesResponse, error) {
+	if value, ok := s.opts.ExternalLabels[req.Label]; ok {
+		return &storepb.LabelValuesResponse{Values: []string{value}}, nil
+	}
+
+	result, warnings, err := s.fetchTags(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]struct{})
+	for _, metric := range result.Metrics {
+		if value, ok := metric.Tags[req.Label]; ok {
+			values[value] = struct{}{}
+		}
+	}
+
+	return &storepb.LabelValuesResponse{
+		Values:   sortedStrings(values),
+		Warnings: warnings,
+	}, nil
+}
+
+// fetchTags fetches the tags of series with a value for the label within
+// the labels lookback.
+func (s *storeServer) fetchTags(
+	ctx context.Context,
+	label string,
+) (*storage.SearchResults, []string, error) {
+	matcher, err := models.NewMatcher(models.MatchRegexp, label, ".+")
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var (
+		now   = s.nowFn()
+		stats = models.NewQueryStats()
+		query = &storage.FetchQuery{
+			TagMatchers: models.Matchers{matcher},
+			Start:       now.Add(-s.opts.LabelsLookback),
+			End:         now,
+		}
+	)
+	result, err := s.storage.FetchTags(ctx, query, &storage.FetchOptions{
+		Stats: stats,
+	})
+	if err != nil {
+		logging.WithContext(ctx).Error("unable to fetch tags", zap.Any("error", err))
+		return nil, nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return result, stats.Snapshot().Warnings, nil
+}
+
+func sortedStrings(set map[string]struct{}) []string {
+	result := make([]string, 0, len(set))
+	for value := range set {
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sendWarnings(stream storepb.Store_SeriesServer, stats *models.QueryStats) error {
+	for _, warning := range stats.Snapshot().Warnings {
+		err := stream.Send(&storepb.SeriesResponse{
+			Result: &storepb.SeriesResponse_Warning{Warning: warning},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thanos
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/storepb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type seriesStream struct {
+	grpc.ServerStream
+	responses []*storepb.SeriesResponse
+}
+
+func (s *seriesStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *seriesStream) Send(resp *storepb.SeriesResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func newTestServer(store storage.Storage) *storeServer {
+	server := newServer(store, Options{
+		ExternalLabels: models.Tags{"region": "us-east"},
+		Retention:      48 * time.Hour,
+		LabelsLookback: time.Hour,
+	})
+	server.nowFn = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+	return server
+}
+
+func TestInfo(t *testing.T) {
+	server := newTestServer(mock.NewMockStorage())
+	resp, err := server.Info(context.Background(), &storepb.InfoRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []storepb.Label{{Name: "region", Value: "us-east"}}, resp.Labels)
+	assert.Equal(t, storage.TimeToTimestamp(time.Unix(1000000, 0).Add(-48*time.Hour)), resp.MinTime)
+	assert.Equal(t, int64(math.MaxInt64), resp.MaxTime)
+	assert.Equal(t, storepb.StoreType_STORE, resp.StoreType)
+}
+
+func TestSeries(t *testing.T) {
+	store := mock.NewMockStorage()
+	start := time.Unix(1000, 0)
+	store.SetFetchResult(&storage.FetchResult{SeriesList: ts.SeriesList{
+		ts.NewSeries("up", ts.Datapoints{
+			{Timestamp: start, Value: 1},
+			{Timestamp: start.Add(time.Minute), Value: 2},
+		}, models.Tags{"__name__": "up"}),
+		ts.NewSeries("empty", ts.Datapoints{
+			{Timestamp: start, Value: math.NaN()},
+		}, models.Tags{"__name__": "empty"}),
+	}}, nil)
+
+	stream := &seriesStream{}
+	err := newTestServer(store).Series(&storepb.SeriesRequest{
+		MinTime: storage.TimeToTimestamp(start),
+		MaxTime: storage.TimeToTimestamp(start.Add(time.Hour)),
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "region", Value: "us-east"},
+			{Type: storepb.LabelMatcher_RE, Name: "__name__", Value: ".+"},
+		},
+	}, stream)
+	require.NoError(t, err)
+
+	require.Len(t, stream.responses, 1)
+	series := stream.responses[0].GetSeries()
+	require.NotNil(t, series)
+	assert.Equal(t, []storepb.Label{
+		{Name: "__name__", Value: "up"},
+		{Name: "region", Value: "us-east"},
+	}, series.Labels)
+	require.Len(t, series.Chunks, 1)
+	assert.Equal(t, storage.TimeToTimestamp(start), series.Chunks[0].MinTime)
+	assert.Equal(t, storage.TimeToTimestamp(start.Add(time.Minute)), series.Chunks[0].MaxTime)
+}
+
+func TestSeriesExternalLabelMismatch(t *testing.T) {
+	store := mock.NewMockStorage()
+	store.SetFetchResult(nil, errors.New("should not fetch"))
+
+	stream := &seriesStream{}
+	err := newTestServer(store).Series(&storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "region", Value: "eu-west"},
+			{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+		},
+	}, stream)
+	require.NoError(t, err)
+	assert.Len(t, stream.responses, 0)
+}
+
+func TestSeriesErrors(t *testing.T) {
+	store := mock.NewMockStorage()
+	store.SetFetchResult(nil, errors.New("fetch error"))
+	server := newTestServer(store)
+
+	err := server.Series(&storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "region", Value: "us-east"},
+		},
+	}, &seriesStream{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = server.Series(&storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+		},
+	}, &seriesStream{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestLabelNamesAndValues(t *testing.T) {
+	store := mock.NewMockStorage()
+	store.SetFetchTagsResult(&storage.SearchResults{Metrics: models.Metrics{
+		{Tags: models.Tags{"__name__": "up", "job": "api"}},
+		{Tags: models.Tags{"__name__": "up", "job": "db"}},
+		{Tags: models.Tags{"__name__": "down"}},
+	}}, nil)
+	server := newTestServer(store)
+
+	names, err := server.LabelNames(context.Background(), &storepb.LabelNamesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"__name__", "job", "region"}, names.Names)
+
+	values, err := server.LabelValues(context.Background(),
+		&storepb.LabelValuesRequest{Label: "job"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "db"}, values.Values)
+
+	values, err = server.LabelValues(context.Background(),
+		&storepb.LabelValuesRequest{Label: "region"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"us-east"}, values.Values)
+}
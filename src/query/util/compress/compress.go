@@ -0,0 +1,348 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package compress negotiates and applies content encodings to HTTP
+// responses so that large query results are cheaper to send over WAN links.
+package compress
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Identity is the identity encoding, i.e. no compression.
+	Identity = "identity"
+
+	// Gzip is the gzip content encoding.
+	Gzip = "gzip"
+
+	// Zstd is the zstd content encoding.
+	Zstd = "zstd"
+
+	// Snappy is the snappy block content encoding used by the Prometheus
+	// remote read and write protocols.
+	Snappy = "snappy"
+
+	acceptEncodingHeader  = "Accept-Encoding"
+	contentEncodingHeader = "Content-Encoding"
+	contentLengthHeader   = "Content-Length"
+	varyHeader            = "Vary"
+)
+
+var (
+	// StreamEncodings are the encodings that responses can be compressed
+	// with as they are written, in order of server preference.
+	StreamEncodings = []string{Zstd, Gzip}
+
+	errEncoderClosed = errors.New("encoder is closed")
+
+	gzipWriterPool = sync.Pool{
+		New: func() interface{} {
+			return gzip.NewWriter(nil)
+		},
+	}
+
+	zstdEncoderPool = sync.Pool{
+		New: func() interface{} {
+			// Options are static and valid so the error can be ignored.
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return enc
+		},
+	}
+)
+
+// Negotiate returns the encoding from supported that is most preferred by
+// the given Accept-Encoding header value, ties are broken by the order of
+// supported. An empty string is returned if none of the supported encodings
+// are acceptable.
+func Negotiate(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	var (
+		qualities    = make(map[string]float64)
+		wildcard     = -1.0
+		hasWildcard  = false
+		bestEncoding string
+		bestQuality  float64
+	)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		encoding, quality, ok := parseAcceptEncodingPart(part)
+		if !ok {
+			continue
+		}
+		if encoding == "*" {
+			hasWildcard = true
+			wildcard = quality
+			continue
+		}
+		qualities[encoding] = quality
+	}
+
+	for _, encoding := range supported {
+		quality, ok := qualities[encoding]
+		if !ok {
+			if !hasWildcard {
+				continue
+			}
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			bestEncoding = encoding
+			bestQuality = quality
+		}
+	}
+
+	return bestEncoding
+}
+
+func parseAcceptEncodingPart(part string) (string, float64, bool) {
+	params := strings.Split(part, ";")
+	encoding := strings.ToLower(strings.TrimSpace(params[0]))
+	if encoding == "" {
+		return "", 0, false
+	}
+
+	quality := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+		if err != nil || q < 0 || q > 1 {
+			return "", 0, false
+		}
+		quality = q
+	}
+
+	return encoding, quality, true
+}
+
+// Encode returns the data compressed with the given encoding, using dst as
+// the destination buffer if it is large enough.
+func Encode(encoding string, dst, data []byte) ([]byte, error) {
+	switch encoding {
+	case Identity, "":
+		return append(dst[:0], data...), nil
+	case Snappy:
+		return snappy.Encode(dst, data), nil
+	case Zstd:
+		enc := zstdEncoderPool.Get().(*zstd.Encoder)
+		result := enc.EncodeAll(data, dst[:0])
+		zstdEncoderPool.Put(enc)
+		return result, nil
+	case Gzip:
+		buf := bytesBuffer{b: dst[:0]}
+		w := gzipWriterPool.Get().(*gzip.Writer)
+		w.Reset(&buf)
+		_, err := w.Write(data)
+		if err == nil {
+			err = w.Close()
+		}
+		gzipWriterPool.Put(w)
+		if err != nil {
+			return nil, err
+		}
+		return buf.b, nil
+	}
+	return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+}
+
+type bytesBuffer struct {
+	b []byte
+}
+
+func (b *bytesBuffer) Write(p []byte) (int, error) {
+	b.b = append(b.b, p...)
+	return len(p), nil
+}
+
+// streamEncoder is a pooled encoder that compresses writes to an
+// underlying writer.
+type streamEncoder interface {
+	io.Writer
+
+	// Flush flushes any buffered data to the underlying writer.
+	Flush() error
+
+	// Close flushes all remaining data and returns the encoder to its pool.
+	Close() error
+}
+
+func newStreamEncoder(encoding string, w io.Writer) streamEncoder {
+	switch encoding {
+	case Gzip:
+		gw := gzipWriterPool.Get().(*gzip.Writer)
+		gw.Reset(w)
+		return &gzipStreamEncoder{w: gw}
+	case Zstd:
+		enc := zstdEncoderPool.Get().(*zstd.Encoder)
+		enc.Reset(w)
+		return &zstdStreamEncoder{enc: enc}
+	}
+	return nil
+}
+
+type gzipStreamEncoder struct {
+	w *gzip.Writer
+}
+
+func (e *gzipStreamEncoder) Write(p []byte) (int, error) {
+	if e.w == nil {
+		return 0, errEncoderClosed
+	}
+	return e.w.Write(p)
+}
+
+func (e *gzipStreamEncoder) Flush() error {
+	if e.w == nil {
+		return errEncoderClosed
+	}
+	return e.w.Flush()
+}
+
+func (e *gzipStreamEncoder) Close() error {
+	if e.w == nil {
+		return errEncoderClosed
+	}
+	err := e.w.Close()
+	e.w.Reset(nil)
+	gzipWriterPool.Put(e.w)
+	e.w = nil
+	return err
+}
+
+type zstdStreamEncoder struct {
+	enc *zstd.Encoder
+}
+
+func (e *zstdStreamEncoder) Write(p []byte) (int, error) {
+	if e.enc == nil {
+		return 0, errEncoderClosed
+	}
+	return e.enc.Write(p)
+}
+
+func (e *zstdStreamEncoder) Flush() error {
+	if e.enc == nil {
+		return errEncoderClosed
+	}
+	return e.enc.Flush()
+}
+
+func (e *zstdStreamEncoder) Close() error {
+	if e.enc == nil {
+		return errEncoderClosed
+	}
+	err := e.enc.Close()
+	e.enc.Reset(nil)
+	zstdEncoderPool.Put(e.enc)
+	e.enc = nil
+	return err
+}
+
+// WithCompression wraps around the given handler, compressing responses
+// with the encoding negotiated from the request's Accept-Encoding header.
+// Responses that the wrapped handler has already set a Content-Encoding on
+// are written as is.
+func WithCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := Negotiate(r.Header.Get(acceptEncodingHeader), StreamEncodings)
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add(varyHeader, acceptEncodingHeader)
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	encoding    string
+	encoder     streamEncoder
+	wroteHeader bool
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.Header()
+	if status != http.StatusNoContent && status != http.StatusNotModified &&
+		header.Get(contentEncodingHeader) == "" {
+		header.Set(contentEncodingHeader, w.encoding)
+		header.Del(contentLengthHeader)
+		w.encoder = newStreamEncoder(w.encoding, w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.encoder.Write(p)
+}
+
+func (w *compressResponseWriter) Flush() {
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressResponseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+func (w *compressResponseWriter) close() {
+	if w.encoder == nil {
+		return
+	}
+	w.encoder.Close()
+	w.encoder = nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	supported := []string{Zstd, Gzip}
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{acceptEncoding: "", expected: ""},
+		{acceptEncoding: "identity", expected: ""},
+		{acceptEncoding: "gzip", expected: Gzip},
+		{acceptEncoding: "gzip, deflate, br", expected: Gzip},
+		{acceptEncoding: "GZIP", expected: Gzip},
+		{acceptEncoding: "gzip, zstd", expected: Zstd},
+		{acceptEncoding: "gzip;q=1.0, zstd;q=0.5", expected: Gzip},
+		{acceptEncoding: "zstd;q=0, gzip", expected: Gzip},
+		{acceptEncoding: "*", expected: Zstd},
+		{acceptEncoding: "*;q=0.1, gzip;q=0.5", expected: Gzip},
+		{acceptEncoding: "*, zstd;q=0", expected: Gzip},
+		{acceptEncoding: "gzip;q=invalid", expected: ""},
+		{acceptEncoding: "gzip;q=0", expected: ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Negotiate(test.acceptEncoding, supported),
+			"accept encoding: %s", test.acceptEncoding)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	data := []byte(strings.Repeat("some highly compressible data ", 100))
+
+	for _, encoding := range []string{Identity, Snappy, Zstd, Gzip} {
+		encoded, err := Encode(encoding, nil, data)
+		require.NoError(t, err, encoding)
+		assert.Equal(t, data, decode(t, encoding, encoded), encoding)
+	}
+
+	_, err := Encode("unknown", nil, data)
+	require.Error(t, err)
+}
+
+func TestWithCompression(t *testing.T) {
+	data := []byte(strings.Repeat(`{"metric":"value"}`, 100))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data[:len(data)/2])
+		w.Write(data[len(data)/2:])
+	})
+	handler := WithCompression(next)
+
+	for _, encoding := range StreamEncodings {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, encoding, recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+		assert.True(t, recorder.Body.Len() < len(data))
+		assert.Equal(t, data, decode(t, encoding, recorder.Body.Bytes()), encoding)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, "", recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, data, recorder.Body.Bytes())
+}
+
+func TestWithCompressionAlreadyEncoded(t *testing.T) {
+	data := snappy.Encode(nil, []byte("already encoded"))
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", Snappy)
+		w.Write(data)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, Snappy, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, data, recorder.Body.Bytes())
+}
+
+func decode(t *testing.T, encoding string, data []byte) []byte {
+	switch encoding {
+	case Snappy:
+		decoded, err := snappy.Decode(nil, data)
+		require.NoError(t, err)
+		return decoded
+	case Zstd:
+		dec, err := zstd.NewReader(nil)
+		require.NoError(t, err)
+		defer dec.Close()
+		decoded, err := dec.DecodeAll(data, nil)
+		require.NoError(t, err)
+		return decoded
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		decoded, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return decoded
+	}
+	return data
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package export
+
+import (
+	"io"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+var arrowSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "name", Type: arrow.BinaryTypes.String},
+	{Name: "tags", Type: arrow.BinaryTypes.String},
+	{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ms},
+	{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+}, nil)
+
+// arrowEncoder writes each batch of rows as a record batch of an Arrow IPC
+// stream, the schema is written before the first record batch.
+type arrowEncoder struct {
+	w       *ipc.Writer
+	builder *array.RecordBuilder
+}
+
+func newArrowEncoder(w io.Writer) batchEncoder {
+	mem := memory.NewGoAllocator()
+	return &arrowEncoder{
+		w:       ipc.NewWriter(w, ipc.WithSchema(arrowSchema), ipc.WithAllocator(mem)),
+		builder: array.NewRecordBuilder(mem, arrowSchema),
+	}
+}
+
+func (e *arrowEncoder) encode(r *rows) error {
+	e.builder.Reserve(r.len())
+	e.builder.Field(0).(*array.StringBuilder).AppendValues(r.names, nil)
+	e.builder.Field(1).(*array.StringBuilder).AppendValues(r.tags, nil)
+	timestamps := e.builder.Field(2).(*array.TimestampBuilder)
+	for _, v := range r.timestamps {
+		timestamps.Append(arrow.Timestamp(v))
+	}
+	e.builder.Field(3).(*array.Float64Builder).AppendValues(r.values, nil)
+
+	record := e.builder.NewRecord()
+	defer record.Release()
+	return e.w.Write(record)
+}
+
+func (e *arrowEncoder) close() error {
+	e.builder.Release()
+	return e.w.Close()
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package export provides writers that stream query results in columnar
+// formats, such as Apache Arrow IPC streams, that can be loaded directly by
+// data analysis tools such as pandas and Spark.
+//
+// Results are exported in long form with one row per datapoint and the
+// columns name, tags (a JSON object of the series tags), timestamp (with
+// millisecond precision) and value.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/query/ts"
+)
+
+const (
+	defaultBatchSize = 65536
+)
+
+var (
+	errWriterClosed = errors.New("export writer closed")
+)
+
+// Format is an export format.
+type Format int
+
+const (
+	// ArrowFormat is the Apache Arrow IPC streaming format.
+	ArrowFormat Format = iota
+)
+
+var validFormats = []Format{
+	ArrowFormat,
+}
+
+func (f Format) String() string {
+	switch f {
+	case ArrowFormat:
+		return "arrow"
+	}
+	return "unknown"
+}
+
+// ContentType returns the HTTP content type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case ArrowFormat:
+		return "application/vnd.apache.arrow.stream"
+	}
+	return "application/octet-stream"
+}
+
+// ParseFormat parses an export format.
+func ParseFormat(str string) (Format, error) {
+	for _, valid := range validFormats {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid export format '%s' valid formats are: %v",
+		str, validFormats)
+}
+
+// Writer writes series to an export format.
+type Writer interface {
+	// Write writes the datapoints of a series, datapoints with NaN values
+	// are skipped.
+	Write(series *ts.Series) error
+
+	// Close writes any buffered rows and the end of the export, it does
+	// not close the underlying writer.
+	Close() error
+}
+
+// WriterOptions are options for an export writer.
+type WriterOptions struct {
+	// BatchSize is the number of rows buffered before they are written as
+	// an Arrow record batch.
+	BatchSize int
+}
+
+// NewWriter returns a new export writer for the format.
+func NewWriter(format Format, w io.Writer, opts WriterOptions) (Writer, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	var enc batchEncoder
+	switch format {
+	case ArrowFormat:
+		enc = newArrowEncoder(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %v", format)
+	}
+
+	return &writer{
+		enc:       enc,
+		batchSize: opts.BatchSize,
+	}, nil
+}
+
+// rows is a batch of rows stored by column.
+type rows struct {
+	names      []string
+	tags       []string
+	timestamps []int64
+	values     []float64
+}
+
+func (r *rows) len() int {
+	return len(r.values)
+}
+
+func (r *rows) reset() {
+	r.names = r.names[:0]
+	r.tags = r.tags[:0]
+	r.timestamps = r.timestamps[:0]
+	r.values = r.values[:0]
+}
+
+// batchEncoder encodes batches of rows to the underlying writer, close
+// writes the end of the export.
+type batchEncoder interface {
+	encode(r *rows) error
+	close() error
+}
+
+type writer struct {
+	enc       batchEncoder
+	batchSize int
+	rows      rows
+	closed    bool
+}
+
+func (w *writer) Write(series *ts.Series) error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	tags, err := json.Marshal(map[string]string(series.Tags))
+	if err != nil {
+		return err
+	}
+
+	values := series.Values()
+	for i := 0; i < values.Len(); i++ {
+		dp := values.DatapointAt(i)
+		if math.IsNaN(dp.Value) {
+			continue
+		}
+		w.rows.names = append(w.rows.names, series.Name())
+		w.rows.tags = append(w.rows.tags, string(tags))
+		w.rows.timestamps = append(w.rows.timestamps,
+			dp.Timestamp.UnixNano()/int64(time.Millisecond))
+		w.rows.values = append(w.rows.values, dp.Value)
+		if w.rows.len() >= w.batchSize {
+			if err := w.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *writer) flush() error {
+	if w.rows.len() == 0 {
+		return nil
+	}
+	err := w.enc.encode(&w.rows)
+	w.rows.reset()
+	return err
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return errWriterClosed
+	}
+	w.closed = true
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.enc.close()
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package export
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSeries(now time.Time) []*ts.Series {
+	return []*ts.Series{
+		ts.NewSeries("foo", ts.Datapoints{
+			{Timestamp: now, Value: 1},
+			{Timestamp: now.Add(time.Second), Value: math.NaN()},
+			{Timestamp: now.Add(2 * time.Second), Value: 3},
+		}, models.Tags{"a": "b"}),
+		ts.NewSeries("bar", ts.Datapoints{
+			{Timestamp: now, Value: 10},
+			{Timestamp: now.Add(time.Second), Value: 11},
+		}, models.Tags{"c": "d"}),
+	}
+}
+
+func writeSeries(t *testing.T, format Format, batchSize int) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, WriterOptions{BatchSize: batchSize})
+	require.NoError(t, err)
+	for _, series := range testSeries(time.Unix(1500000000, 0)) {
+		require.NoError(t, w.Write(series))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("arrow")
+	require.NoError(t, err)
+	assert.Equal(t, ArrowFormat, format)
+
+	_, err = ParseFormat("csv")
+	assert.Error(t, err)
+}
+
+func TestWriterBatches(t *testing.T) {
+	enc := &recordingEncoder{}
+	w := &writer{enc: enc, batchSize: 2}
+	for _, series := range testSeries(time.Unix(1500000000, 0)) {
+		require.NoError(t, w.Write(series))
+	}
+	require.NoError(t, w.Close())
+
+	// NaN values are skipped so four rows are written in two batches.
+	require.Equal(t, 2, len(enc.batches))
+	assert.Equal(t, []string{"foo", "foo"}, enc.batches[0].names)
+	assert.Equal(t, []string{`{"a":"b"}`, `{"a":"b"}`}, enc.batches[0].tags)
+	assert.Equal(t, []int64{1500000000000, 1500000002000},
+		enc.batches[0].timestamps)
+	assert.Equal(t, []float64{1, 3}, enc.batches[0].values)
+	assert.Equal(t, []string{"bar", "bar"}, enc.batches[1].names)
+	assert.Equal(t, []float64{10, 11}, enc.batches[1].values)
+	assert.True(t, enc.closed)
+
+	assert.Equal(t, errWriterClosed, w.Write(testSeries(time.Now())[0]))
+	assert.Equal(t, errWriterClosed, w.Close())
+}
+
+func TestArrowStreamRoundTrip(t *testing.T) {
+	data := writeSeries(t, ArrowFormat, 3)
+
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	r, err := ipc.NewReader(bytes.NewReader(data), ipc.WithAllocator(mem))
+	require.NoError(t, err)
+	defer r.Release()
+	require.True(t, r.Schema().Equal(arrowSchema))
+
+	var (
+		names      []string
+		tags       []string
+		timestamps []int64
+		values     []float64
+		batches    int
+	)
+	for r.Next() {
+		record := r.Record()
+		batches++
+		nameCol := record.Column(0).(*array.String)
+		tagsCol := record.Column(1).(*array.String)
+		timestampCol := record.Column(2).(*array.Timestamp)
+		valueCol := record.Column(3).(*array.Float64)
+		for i := 0; i < int(record.NumRows()); i++ {
+			names = append(names, nameCol.Value(i))
+			tags = append(tags, tagsCol.Value(i))
+			timestamps = append(timestamps, int64(timestampCol.Value(i)))
+			values = append(values, valueCol.Value(i))
+		}
+	}
+	require.NoError(t, r.Err())
+
+	// NaN values are skipped so four rows are written in two batches.
+	assert.Equal(t, 2, batches)
+	assert.Equal(t, []string{"foo", "foo", "bar", "bar"}, names)
+	assert.Equal(t, []string{`{"a":"b"}`, `{"a":"b"}`, `{"c":"d"}`, `{"c":"d"}`}, tags)
+	assert.Equal(t, []int64{1500000000000, 1500000002000, 1500000000000,
+		1500000001000}, timestamps)
+	assert.Equal(t, []float64{1, 3, 10, 11}, values)
+}
+
+func TestArrowStreamNoRows(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(ArrowFormat, &buf, WriterOptions{})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := ipc.NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer r.Release()
+	require.True(t, r.Schema().Equal(arrowSchema))
+	assert.Equal(t, arrow.Millisecond, r.Schema().Field(2).Type.(*arrow.TimestampType).Unit)
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+}
+
+type recordingEncoder struct {
+	batches []rows
+	closed  bool
+}
+
+func (e *recordingEncoder) encode(r *rows) error {
+	e.batches = append(e.batches, rows{
+		names:      append([]string(nil), r.names...),
+		tags:       append([]string(nil), r.tags...),
+		timestamps: append([]int64(nil), r.timestamps...),
+		values:     append([]float64(nil), r.values...),
+	})
+	return nil
+}
+
+func (e *recordingEncoder) close() error {
+	e.closed = true
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package journal
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultMaxBodySize = 1 << 20
+	journalFileMode    = 0644
+)
+
+var (
+	errNoJournalPath = errors.New("no query journal path set")
+)
+
+// Configuration is the configuration for recording incoming queries to
+// a journal file.
+type Configuration struct {
+	// Path is the file to append recorded queries to.
+	Path string `yaml:"path" validate:"nonzero"`
+
+	// BufferSize is the number of queries buffered before queries are
+	// dropped from the journal (optional).
+	BufferSize int `yaml:"bufferSize"`
+
+	// FlushInterval is how often buffered queries are flushed to the
+	// journal file (optional).
+	FlushInterval time.Duration `yaml:"flushInterval"`
+
+	// MaxBodySize is the largest request body recorded, queries with larger
+	// bodies are recorded without their body and cannot be replayed (optional).
+	MaxBodySize *int `yaml:"maxBodySize"`
+}
+
+// MaxBodySizeOrDefault returns the max recorded body size or the default.
+func (c Configuration) MaxBodySizeOrDefault() int {
+	if c.MaxBodySize == nil {
+		return defaultMaxBodySize
+	}
+	return *c.MaxBodySize
+}
+
+// NewWriter opens the journal file for appending and returns a writer
+// that records queries to it.
+func (c Configuration) NewWriter(scope tally.Scope) (Writer, error) {
+	if c.Path == "" {
+		return nil, errNoJournalPath
+	}
+
+	fd, err := os.OpenFile(c.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, journalFileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriter(fd, WriterOptions{
+		BufferSize:    c.BufferSize,
+		FlushInterval: c.FlushInterval,
+		Scope:         scope,
+	}), nil
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package journal records incoming queries to a journal file so that they can
+// later be replayed against a test cluster for capacity planning and
+// regression testing.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultBufferSize    = 4096
+	defaultFlushInterval = time.Second
+)
+
+var (
+	errWriterClosed = errors.New("journal writer is closed")
+
+	// recordedHeaders are the request headers required to re-execute a query.
+	recordedHeaders = []string{
+		"Accept",
+		"Content-Encoding",
+		"Content-Type",
+		"X-Prometheus-Remote-Read-Version",
+	}
+)
+
+// Entry is a single recorded query.
+type Entry struct {
+	// Start is the time the query was received.
+	Start time.Time `json:"start"`
+
+	// Duration is how long the query took to serve.
+	Duration time.Duration `json:"duration"`
+
+	// Method is the HTTP method of the query.
+	Method string `json:"method"`
+
+	// Path is the URL path of the query.
+	Path string `json:"path"`
+
+	// Query is the raw URL query string holding the query parameters.
+	Query string `json:"query,omitempty"`
+
+	// Header holds the request headers required to re-execute the query.
+	Header http.Header `json:"header,omitempty"`
+
+	// Body is the request body.
+	Body []byte `json:"body,omitempty"`
+
+	// BodyTruncated is true if the request body was larger than the
+	// maximum recorded body size and was not recorded.
+	BodyTruncated bool `json:"bodyTruncated,omitempty"`
+
+	// Status is the HTTP status code the query was served with.
+	Status int `json:"status"`
+}
+
+// Writer records entries to a journal.
+type Writer interface {
+	// Write records an entry, entries are dropped rather than blocking
+	// the caller if the journal cannot keep up.
+	Write(e Entry)
+
+	// Close flushes all pending entries and closes the journal.
+	Close() error
+}
+
+// WriterOptions is a set of options for a journal writer.
+type WriterOptions struct {
+	// BufferSize is the number of entries buffered before entries
+	// are dropped.
+	BufferSize int
+
+	// FlushInterval is how often buffered entries are flushed.
+	FlushInterval time.Duration
+
+	// Scope is the metrics scope to report to.
+	Scope tally.Scope
+}
+
+type writerMetrics struct {
+	recorded    tally.Counter
+	dropped     tally.Counter
+	writeErrors tally.Counter
+}
+
+func newWriterMetrics(scope tally.Scope) writerMetrics {
+	return writerMetrics{
+		recorded:    scope.Counter("recorded"),
+		dropped:     scope.Counter("dropped"),
+		writeErrors: scope.Counter("write-errors"),
+	}
+}
+
+type writer struct {
+	sync.RWMutex
+
+	out           io.WriteCloser
+	buffered      *bufio.Writer
+	encoder       *json.Encoder
+	flushInterval time.Duration
+	entries       chan Entry
+	closed        bool
+	doneCh        chan struct{}
+	metrics       writerMetrics
+}
+
+// NewWriter returns a new journal writer that records entries as new line
+// delimited JSON to the given output.
+func NewWriter(out io.WriteCloser, opts WriterOptions) Writer {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBufferSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.Scope == nil {
+		opts.Scope = tally.NoopScope
+	}
+
+	buffered := bufio.NewWriter(out)
+	w := &writer{
+		out:           out,
+		buffered:      buffered,
+		encoder:       json.NewEncoder(buffered),
+		flushInterval: opts.FlushInterval,
+		entries:       make(chan Entry, opts.BufferSize),
+		doneCh:        make(chan struct{}),
+		metrics:       newWriterMetrics(opts.Scope),
+	}
+	go w.writeLoop()
+	return w
+}
+
+func (w *writer) Write(e Entry) {
+	w.RLock()
+	defer w.RUnlock()
+
+	if w.closed {
+		w.metrics.dropped.Inc(1)
+		return
+	}
+
+	select {
+	case w.entries <- e:
+	default:
+		w.metrics.dropped.Inc(1)
+	}
+}
+
+func (w *writer) writeLoop() {
+	defer close(w.doneCh)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-w.entries:
+			if !ok {
+				w.flush()
+				return
+			}
+			if err := w.encoder.Encode(e); err != nil {
+				w.metrics.writeErrors.Inc(1)
+				continue
+			}
+			w.metrics.recorded.Inc(1)
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+func (w *writer) flush() {
+	if err := w.buffered.Flush(); err != nil {
+		w.metrics.writeErrors.Inc(1)
+	}
+}
+
+func (w *writer) Close() error {
+	w.Lock()
+	if w.closed {
+		w.Unlock()
+		return errWriterClosed
+	}
+	w.closed = true
+	close(w.entries)
+	w.Unlock()
+
+	<-w.doneCh
+	return w.out.Close()
+}
+
+// Reader reads entries from a journal.
+type Reader struct {
+	decoder *json.Decoder
+}
+
+// NewReader returns a new journal reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{decoder: json.NewDecoder(r)}
+}
+
+// Next returns the next entry in the journal, or io.EOF if there are no
+// more entries.
+func (r *Reader) Next() (Entry, error) {
+	var e Entry
+	if err := r.decoder.Decode(&e); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// WithJournal wraps around the given handler, recording each request it
+// serves to the journal along with its timing and response status. Request
+// bodies larger than maxBodySize are not recorded.
+func WithJournal(w Writer, maxBodySize int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		entry := Entry{
+			Start:  time.Now(),
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.RawQuery,
+		}
+		for _, name := range recordedHeaders {
+			if value := r.Header.Get(name); value != "" {
+				if entry.Header == nil {
+					entry.Header = make(http.Header)
+				}
+				entry.Header.Set(name, value)
+			}
+		}
+
+		if r.Body != nil {
+			// Read up to one byte past the limit to detect truncation and
+			// hand the full body on to the wrapped handler.
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(maxBodySize)+1))
+			if err == nil {
+				if len(body) > maxBodySize {
+					entry.BodyTruncated = true
+				} else {
+					entry.Body = body
+				}
+			}
+			r.Body = readCloser{
+				Reader: io.MultiReader(bytes.NewReader(body), r.Body),
+				Closer: r.Body,
+			}
+		}
+
+		statusWriter := &statusResponseWriter{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(statusWriter, r)
+
+		entry.Duration = time.Since(entry.Start)
+		entry.Status = statusWriter.status
+		w.Write(entry)
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package journal
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestWithJournalRecordsQueries(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(nopCloser{&buf}, WriterOptions{})
+
+	var received []byte
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var err error
+		received, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		rw.WriteHeader(http.StatusBadRequest)
+	})
+	h := WithJournal(w, 8, next)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/prom/remote/read?foo=bar",
+		strings.NewReader("body"))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Unrecorded", "value")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "body", string(received))
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/prom/remote/read",
+		strings.NewReader("larger than max body"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "larger than max body", string(received))
+
+	require.NoError(t, w.Close())
+
+	r := NewReader(&buf)
+	entry, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, entry.Method)
+	assert.Equal(t, "/api/v1/prom/remote/read", entry.Path)
+	assert.Equal(t, "foo=bar", entry.Query)
+	assert.Equal(t, "body", string(entry.Body))
+	assert.False(t, entry.BodyTruncated)
+	assert.Equal(t, http.StatusBadRequest, entry.Status)
+	assert.Equal(t, http.Header{
+		"Content-Type": []string{"application/x-protobuf"},
+	}, entry.Header)
+
+	entry, err = r.Next()
+	require.NoError(t, err)
+	assert.Nil(t, entry.Body)
+	assert.True(t, entry.BodyTruncated)
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReplay(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		received []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		lock.Lock()
+		received = append(received, r.URL.RequestURI()+" "+string(body))
+		lock.Unlock()
+
+		if r.URL.Query().Get("fail") != "" {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	w := NewWriter(nopCloser{&buf}, WriterOptions{})
+	start := time.Now()
+	w.Write(Entry{
+		Start:    start,
+		Duration: time.Second,
+		Method:   http.MethodGet,
+		Path:     "/api/v1/prom/native/read",
+		Query:    "target=foo",
+		Status:   http.StatusOK,
+	})
+	w.Write(Entry{
+		Start:    start.Add(time.Millisecond),
+		Duration: 2 * time.Second,
+		Method:   http.MethodPost,
+		Path:     "/api/v1/prom/remote/read",
+		Body:     []byte("body"),
+		Status:   http.StatusOK,
+	})
+	w.Write(Entry{
+		Start:         start.Add(2 * time.Millisecond),
+		Method:        http.MethodPost,
+		Path:          "/api/v1/prom/remote/read",
+		BodyTruncated: true,
+		Status:        http.StatusOK,
+	})
+	w.Write(Entry{
+		Start:    start.Add(3 * time.Millisecond),
+		Duration: time.Second,
+		Method:   http.MethodGet,
+		Path:     "/api/v1/prom/native/read",
+		Query:    "fail=true",
+		Status:   http.StatusOK,
+	})
+	require.NoError(t, w.Close())
+
+	result, err := Replay(NewReader(&buf), ReplayOptions{
+		TargetURL: srv.URL + "/",
+		Speed:     1,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Queries)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Equal(t, 0, result.Errors)
+	assert.Equal(t, 1, result.StatusMismatches)
+	assert.Equal(t, 4*time.Second, result.OriginalDuration)
+	assert.Equal(t, 2*time.Second, result.MaxOriginalDuration)
+	assert.True(t, result.Elapsed >= 3*time.Millisecond)
+
+	sort.Strings(received)
+	assert.Equal(t, []string{
+		"/api/v1/prom/native/read?fail=true ",
+		"/api/v1/prom/native/read?target=foo ",
+		"/api/v1/prom/remote/read body",
+	}, received)
+}
+
+func TestReplayNoTarget(t *testing.T) {
+	_, err := Replay(NewReader(&bytes.Buffer{}), ReplayOptions{})
+	assert.Equal(t, errNoReplayTarget, err)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReplayConcurrency = 16
+	defaultReplayTimeout     = time.Minute
+)
+
+var (
+	errNoReplayTarget = errors.New("no replay target URL set")
+)
+
+// ReplayOptions is a set of options for replaying a journal.
+type ReplayOptions struct {
+	// TargetURL is the base URL of the coordinator to replay queries
+	// against, i.e. http://localhost:7201.
+	TargetURL string
+
+	// Speed is the factor to accelerate the original pace of queries by,
+	// 1 replays at the original pace and 0 replays as fast as possible.
+	Speed float64
+
+	// Concurrency is the maximum number of queries in flight.
+	Concurrency int
+
+	// Client is the HTTP client to execute queries with.
+	Client *http.Client
+}
+
+// ReplayResult is the result of replaying a journal.
+type ReplayResult struct {
+	// Queries is the number of queries replayed.
+	Queries int
+
+	// Skipped is the number of queries skipped since their body
+	// was not recorded.
+	Skipped int
+
+	// Errors is the number of queries that failed to execute.
+	Errors int
+
+	// StatusMismatches is the number of queries that returned a different
+	// status to the status originally recorded.
+	StatusMismatches int
+
+	// Elapsed is how long the replay took.
+	Elapsed time.Duration
+
+	// OriginalDuration is the sum of the original query durations.
+	OriginalDuration time.Duration
+
+	// ReplayDuration is the sum of the replayed query durations.
+	ReplayDuration time.Duration
+
+	// MaxOriginalDuration is the longest original query duration.
+	MaxOriginalDuration time.Duration
+
+	// MaxReplayDuration is the longest replayed query duration.
+	MaxReplayDuration time.Duration
+}
+
+// Replay re-executes all queries read from the journal against the target,
+// preserving the relative start times of the queries scaled by the speed.
+func Replay(r *Reader, opts ReplayOptions) (ReplayResult, error) {
+	if opts.TargetURL == "" {
+		return ReplayResult{}, errNoReplayTarget
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultReplayConcurrency
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: defaultReplayTimeout}
+	}
+
+	var (
+		target      = strings.TrimSuffix(opts.TargetURL, "/")
+		result      ReplayResult
+		resultLock  sync.Mutex
+		wg          sync.WaitGroup
+		sem         = make(chan struct{}, opts.Concurrency)
+		replayStart = time.Now()
+		first       time.Time
+	)
+	for {
+		entry, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			wg.Wait()
+			return result, err
+		}
+
+		if entry.BodyTruncated {
+			result.Skipped++
+			continue
+		}
+
+		if first.IsZero() {
+			first = entry.Start
+		}
+		if opts.Speed > 0 {
+			offset := time.Duration(float64(entry.Start.Sub(first)) / opts.Speed)
+			if wait := offset - time.Since(replayStart); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(entry Entry) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			status, took, err := replayEntry(opts.Client, target, entry)
+
+			resultLock.Lock()
+			defer resultLock.Unlock()
+
+			result.Queries++
+			result.OriginalDuration += entry.Duration
+			if entry.Duration > result.MaxOriginalDuration {
+				result.MaxOriginalDuration = entry.Duration
+			}
+			if err != nil {
+				result.Errors++
+				return
+			}
+			result.ReplayDuration += took
+			if took > result.MaxReplayDuration {
+				result.MaxReplayDuration = took
+			}
+			if status != entry.Status {
+				result.StatusMismatches++
+			}
+		}(entry)
+	}
+
+	wg.Wait()
+	result.Elapsed = time.Since(replayStart)
+	return result, nil
+}
+
+func replayEntry(
+	client *http.Client,
+	target string,
+	entry Entry,
+) (int, time.Duration, error) {
+	url := target + entry.Path
+	if entry.Query != "" {
+		url += "?" + entry.Query
+	}
+
+	req, err := http.NewRequest(entry.Method, url, bytes.NewReader(entry.Body))
+	if err != nil {
+		return 0, 0, err
+	}
+	for name, values := range entry.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return 0, 0, fmt.Errorf("unable to read response: %v", err)
+	}
+	return resp.StatusCode, time.Since(start), nil
+}
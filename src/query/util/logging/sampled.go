@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logging
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/dbnode/x/logsample"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SampledLogger logs records of error classes through a sampler, annotating
+// each logged record with its class and the number of records suppressed
+// since the last one logged.
+type SampledLogger struct {
+	sampler *logsample.Sampler
+}
+
+// NewSampledLogger returns a logger sampling records with the sampler, a nil
+// sampler logs every record.
+func NewSampledLogger(sampler *logsample.Sampler) *SampledLogger {
+	return &SampledLogger{sampler: sampler}
+}
+
+// Warn logs a warning of the class with the logger of the context if sampled.
+func (l *SampledLogger) Warn(
+	ctx context.Context,
+	class string,
+	msg string,
+	fields ...zapcore.Field,
+) {
+	if ok, suppressed := l.sampler.Sample(class); ok {
+		WithContext(ctx).Warn(msg, sampledFields(class, suppressed, fields)...)
+	}
+}
+
+// Error logs an error of the class with the logger of the context if sampled.
+func (l *SampledLogger) Error(
+	ctx context.Context,
+	class string,
+	msg string,
+	fields ...zapcore.Field,
+) {
+	if ok, suppressed := l.sampler.Sample(class); ok {
+		WithContext(ctx).Error(msg, sampledFields(class, suppressed, fields)...)
+	}
+}
+
+func sampledFields(
+	class string,
+	suppressed int64,
+	fields []zapcore.Field,
+) []zapcore.Field {
+	fields = append(fields, zap.String("class", class))
+	if suppressed > 0 {
+		fields = append(fields, zap.Int64("suppressed", suppressed))
+	}
+	return fields
+}